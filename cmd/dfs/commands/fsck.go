@@ -0,0 +1,211 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/marmos91/dittofs/internal/cli/output"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/block/engine"
+	"github.com/marmos91/dittofs/pkg/config"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fsckShare     string
+	fsckRepair    bool
+	fsckOutput    string
+	fsckPidFile   string
+	fsckForce     bool
+	fsckSampleCap int
+)
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Check a share for metadata/block consistency (server must be stopped)",
+	Long: `Check a share's metadata store against its block records, local journal
+and remote block store, and report every inconsistency by category.
+
+The check walks the share namespace (parents, link counts, the PayloadID index,
+the #recycle bin) and the FileChunk manifest of every file, then cross-checks
+block records and synced locators against the remote store's block listing and
+the journal. It reports dangling references, orphaned chunks and link-count
+mismatches, among others.
+
+fsck opens the stores directly, so the server must be stopped; it refuses to
+run while the PID file names a live process.
+
+With --repair the safe categories are fixed after the scan: dangling directory
+entries, parent pointers, link counts, missing PayloadID index entries,
+File.Blocks lagging its FileChunk rows, orphaned chunk rows and journal files,
+and #recycle stamps. Categories whose fix could lose data (dangling manifest
+references, missing remote blocks) are only reported. Leaked block records and
+orphan remote objects are reclaimed by the block GC once the server is running
+again ("dfsctl store block gc").
+
+The command exits non-zero when problems remain after the run.
+
+Examples:
+  # Report only
+  dfs fsck --share /export
+
+  # Fix the safe categories
+  dfs fsck --share /export --repair
+
+  # Machine-readable report
+  dfs fsck --share /export --output json`,
+	RunE: runFsck,
+}
+
+func init() {
+	fsckCmd.Flags().StringVar(&fsckShare, "share", "", "Share to check (required)")
+	fsckCmd.Flags().BoolVar(&fsckRepair, "repair", false, "Fix the safe categories after the scan")
+	fsckCmd.Flags().StringVarP(&fsckOutput, "output", "o", "table", "Output format (table|json|yaml)")
+	fsckCmd.Flags().StringVar(&fsckPidFile, "pid-file", "", "Path to PID file (default: $XDG_STATE_HOME/dittofs/dittofs.pid)")
+	fsckCmd.Flags().BoolVar(&fsckForce, "force", false, "Run even if the PID file names a live process")
+	fsckCmd.Flags().IntVar(&fsckSampleCap, "sample", 20, "Maximum affected paths/IDs listed per category")
+	_ = fsckCmd.MarkFlagRequired("share")
+}
+
+func runFsck(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(fsckOutput)
+	if err != nil {
+		return err
+	}
+
+	pidPath := fsckPidFile
+	if pidPath == "" {
+		pidPath = GetDefaultPidFile()
+	}
+	if pid, running := isProcessRunning(pidPath); running && !fsckForce {
+		return fmt.Errorf("server appears to be running (PID %d from %s); stop it first (dfs stop) or pass --force", pid, pidPath)
+	}
+
+	cfg, err := config.MustLoad(GetConfigFile())
+	if err != nil {
+		return err
+	}
+	if err := InitLogger(cfg); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	cpStore, err := store.New(&cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to open control plane store: %w", err)
+	}
+	defer func() { _ = cpStore.Close() }()
+
+	logger.Info("Running fsck", "share", fsckShare, "repair", fsckRepair)
+	rep, err := runtime.FsckShare(ctx, cpStore, fsckShare, engine.FsckOptions{
+		Repair:    fsckRepair,
+		SampleCap: fsckSampleCap,
+	})
+	if err != nil {
+		return fmt.Errorf("fsck failed: %w", err)
+	}
+
+	switch format {
+	case output.FormatJSON:
+		err = output.PrintJSON(os.Stdout, rep)
+	case output.FormatYAML:
+		err = output.PrintYAML(os.Stdout, rep)
+	default:
+		err = printFsckReport(rep)
+	}
+	if err != nil {
+		return err
+	}
+
+	if n := rep.Unresolved(); n > 0 || rep.RepairErrors > 0 {
+		return fmt.Errorf("share %q: %d unresolved problem(s), %d repair error(s)", rep.Share, n, rep.RepairErrors)
+	}
+	return nil
+}
+
+// fsckCategory is one row of the table output.
+type fsckCategory struct {
+	name       string
+	class      *engine.FsckClass
+	repairable bool
+}
+
+func fsckCategories(r *engine.FsckReport) []fsckCategory {
+	return []fsckCategory{
+		{"Dangling directory entries", &r.DanglingEntries, true},
+		{"Parent mismatches", &r.ParentMismatches, true},
+		{"Link count mismatches", &r.NlinkMismatches, true},
+		{"PayloadID index mismatches", &r.PayloadIndexMismatches, true},
+		{"Dangling manifest refs", &r.DanglingChunkRefs, false},
+		{"Unprojected manifests", &r.UnprojectedManifests, true},
+		{"Unbacked chunks", &r.UnbackedChunks, false},
+		{"Dangling locators", &r.DanglingLocators, false},
+		{"Missing remote blocks", &r.MissingRemoteBlocks, false},
+		{"Orphaned chunk rows", &r.OrphanedChunks, true},
+		{"Orphaned journal files", &r.OrphanedJournalFiles, true},
+		{"Orphaned #recycle entries", &r.OrphanedRecycleEntries, true},
+		{"Stale #recycle stamps", &r.StaleRecycleStamps, true},
+		{"Zero-ref block records", &r.ZeroRefRecords, false},
+		{"Leaked blocks", &r.LeakedBlocks, false},
+		{"Orphan remote objects", &r.OrphanRemoteObjects, false},
+	}
+}
+
+func printFsckReport(r *engine.FsckReport) error {
+	pairs := [][2]string{
+		{"Share", r.Share},
+		{"Mode", map[bool]string{false: "report", true: "repair"}[r.Repair]},
+		{"Duration", fmt.Sprintf("%dms", r.DurationMS)},
+		{"Directories", strconv.FormatInt(r.DirectoriesScanned, 10)},
+		{"Files", strconv.FormatInt(r.FilesScanned, 10)},
+		{"Chunk refs", strconv.FormatInt(r.ChunkRefsScanned, 10)},
+		{"Block records", strconv.FormatInt(r.BlockRecordsScanned, 10)},
+		{"Remote objects", strconv.FormatInt(r.RemoteObjectsScanned, 10)},
+	}
+	if err := output.SimpleTable(os.Stdout, pairs); err != nil {
+		return err
+	}
+	fmt.Println()
+
+	table := output.NewTableData("CATEGORY", "FOUND", "REPAIRED", "FIX")
+	for _, c := range fsckCategories(r) {
+		fix := "report only"
+		if c.repairable {
+			fix = "--repair"
+		}
+		table.AddRow(c.name, strconv.FormatInt(c.class.Count, 10), strconv.FormatInt(c.class.Repaired, 10), fix)
+	}
+	if err := output.PrintTable(os.Stdout, table); err != nil {
+		return err
+	}
+
+	for _, c := range fsckCategories(r) {
+		if len(c.class.Sample) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s:\n", c.name)
+		for _, id := range c.class.Sample {
+			fmt.Printf("  %s\n", id)
+		}
+		if c.class.Truncated {
+			fmt.Printf("  ... and %d more\n", c.class.Count-int64(len(c.class.Sample)))
+		}
+	}
+
+	fmt.Println()
+	switch {
+	case r.Problems() == 0:
+		fmt.Println("No problems found.")
+	case r.Unresolved() == 0:
+		fmt.Printf("%d problem(s) found, all repaired.\n", r.Problems())
+	case !r.Repair:
+		fmt.Printf("%d problem(s) found. Re-run with --repair to fix the safe categories.\n", r.Problems())
+	default:
+		fmt.Printf("%d problem(s) found, %d unresolved.\n", r.Problems(), r.Unresolved())
+	}
+	return nil
+}
@@ -8,12 +8,15 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/marmos91/dittofs/internal/auth/netlogon"
+	"github.com/marmos91/dittofs/internal/changefeed"
 	"github.com/marmos91/dittofs/internal/controlplane/api/handlers"
+	"github.com/marmos91/dittofs/internal/fileaudit"
 	"github.com/marmos91/dittofs/internal/logger"
 	"github.com/marmos91/dittofs/internal/sysinfo"
 	"github.com/marmos91/dittofs/pkg/adapter/nfs"
@@ -27,6 +30,7 @@ import (
 	"github.com/marmos91/dittofs/pkg/controlplane/runtime/shares"
 	"github.com/marmos91/dittofs/pkg/controlplane/store"
 	"github.com/marmos91/dittofs/pkg/identity/ldap"
+	"github.com/marmos91/dittofs/pkg/metadata"
 	badgerstore "github.com/marmos91/dittofs/pkg/metadata/store/badger"
 	"github.com/marmos91/dittofs/pkg/metrics"
 	"github.com/spf13/cobra"
@@ -121,6 +125,9 @@ func runStart(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize control plane store: %w", err)
 	}
+	// Password, lockout and TOTP policy for local accounts, enforced by the
+	// store for API logins and SMB NTLM session setup alike.
+	cpStore.SetAccountPolicy(cfg.Accounts.Policy())
 
 	// Ensure admin user exists. On first run the password is taken from
 	// admin.password_hash (config), DITTOFS_ADMIN_INITIAL_PASSWORD (env,
@@ -350,6 +357,32 @@ func runStart(cmd *cobra.Command, args []string) error {
 			"addr", cfg.Metrics.Addr(), "path", cfg.Metrics.Path, "auth", cfg.Metrics.Auth)
 	}
 
+	// File-access audit trail: shares opt in individually; the sinks are
+	// process-wide. Closed after Serve returns so queued events are delivered.
+	fileAudit, err := buildFileAuditDispatcher(&cfg.FileAudit)
+	if err != nil {
+		return err
+	}
+	rt.SetFileAuditRecorder(fileAudit)
+	defer func() {
+		if err := fileAudit.Close(); err != nil {
+			logger.Warn("Failed to close file audit sinks", "error", err)
+		}
+	}()
+
+	// Share change events (events API, webhooks, NATS). Closed after Serve
+	// returns so held and queued events are delivered.
+	if feed, err := buildChangeFeed(&cfg.Events); err != nil {
+		return err
+	} else if feed != nil {
+		rt.SetChangeFeed(feed)
+		defer func() {
+			if err := feed.Close(); err != nil {
+				logger.Warn("Failed to close change event sinks", "error", err)
+			}
+		}()
+	}
+
 	// Write PID file if specified
 	if pidFile != "" {
 		if err := os.WriteFile(pidFile, fmt.Appendf(nil, "%d", os.Getpid()), 0644); err != nil {
@@ -489,6 +522,88 @@ func buildMetricsListener(cfg *config.MetricsConfig, m *metrics.Metrics) (*metri
 	return srv, nil
 }
 
+// buildFileAuditDispatcher opens the file-access audit sinks configured in
+// cfg. With none configured, events from auditing shares go to the server log.
+func buildFileAuditDispatcher(cfg *config.FileAuditConfig) (*fileaudit.Dispatcher, error) {
+	var sinks []fileaudit.Sink
+	if cfg.File != "" {
+		fs, err := fileaudit.NewFileSink(cfg.File, fileaudit.Rotation{
+			MaxSize:    cfg.Rotation.MaxSize,
+			MaxBackups: cfg.Rotation.MaxBackups,
+			MaxAge:     cfg.Rotation.MaxAge,
+			Compress:   cfg.Rotation.Compress,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("file_audit.file: %w", err)
+		}
+		sinks = append(sinks, fs)
+		logger.Info("File audit file sink enabled", "path", cfg.File)
+	}
+	if cfg.Syslog.Enabled {
+		ss, err := fileaudit.NewSyslogSink(cfg.Syslog.Network, cfg.Syslog.Address, cfg.Syslog.AppName)
+		if err != nil {
+			for _, s := range sinks {
+				_ = s.Close()
+			}
+			return nil, fmt.Errorf("file_audit.syslog: %w", err)
+		}
+		sinks = append(sinks, ss)
+		logger.Info("File audit syslog sink enabled", "network", cfg.Syslog.Network, "address", cfg.Syslog.Address)
+	}
+	if len(sinks) == 0 {
+		sinks = append(sinks, fileaudit.LogSink{})
+	}
+	return fileaudit.NewDispatcher(cfg.QueueSize, cfg.CoalesceWindow, sinks...), nil
+}
+
+// buildChangeFeed creates the change-event broker and attaches the webhook and
+// NATS sinks configured in cfg. It returns nil when events are disabled.
+func buildChangeFeed(cfg *config.EventsConfig) (*changefeed.Broker, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	var nats *changefeed.NATSSink
+	if cfg.NATS.URL != "" {
+		var err error
+		nats, err = changefeed.NewNATSSink(changefeed.NATSOptions{
+			URL:           cfg.NATS.URL,
+			SubjectPrefix: cfg.NATS.SubjectPrefix,
+			Token:         cfg.NATS.Token,
+			User:          cfg.NATS.User,
+			Password:      cfg.NATS.Password,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("events.nats: %w", err)
+		}
+	}
+
+	b := changefeed.NewBroker(cfg.BufferSize, cfg.SettleWindow)
+	for i, wh := range cfg.Webhooks {
+		name := wh.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		types := make([]metadata.ChangeEventType, len(wh.Types))
+		for j, t := range wh.Types {
+			types[j] = metadata.ChangeEventType(t)
+		}
+		sink := changefeed.NewWebhookSink(name, wh.URL, changefeed.WebhookOptions{
+			Secret:     wh.Secret,
+			Timeout:    wh.Timeout,
+			MaxRetries: wh.MaxRetries,
+			Backoff:    time.Second,
+		})
+		b.AddSink(sink, changefeed.Filter{Shares: wh.Shares, Types: types, PathPrefix: wh.PathPrefix}, wh.QueueSize)
+		logger.Info("Change event webhook enabled", "name", name, "url", wh.URL, "signed", wh.Secret != "")
+	}
+	if nats != nil {
+		b.AddSink(nats, changefeed.Filter{}, cfg.NATS.QueueSize)
+		logger.Info("Change event NATS publisher enabled", "server", nats.Name(), "subject_prefix", cfg.NATS.SubjectPrefix)
+	}
+	logger.Info("Share change events enabled", "buffer_size", cfg.BufferSize, "settle_window", cfg.SettleWindow)
+	return b, nil
+}
+
 // resolveIdentityProviders reconciles identity-provider configuration between
 // the file/env config and the control-plane database. A persisted row (managed
 // over the API) takes precedence; when no row exists the file/env config seeds
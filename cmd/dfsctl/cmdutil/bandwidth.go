@@ -0,0 +1,66 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/spf13/pflag"
+)
+
+// BandwidthFlags holds the --upload-limit, --download-limit and
+// --bandwidth-schedule flags shared by `share edit` and
+// `store block remote edit`.
+type BandwidthFlags struct {
+	Upload   string
+	Download string
+	Schedule string
+}
+
+// Register adds the bandwidth flags to fs.
+func (f *BandwidthFlags) Register(fs *pflag.FlagSet) {
+	fs.StringVar(&f.Upload, "upload-limit", "", "Upload bandwidth cap per second (e.g., 20MB); 0 = unlimited")
+	fs.StringVar(&f.Download, "download-limit", "", "Download bandwidth cap per second (e.g., 50MB); 0 = unlimited")
+	fs.StringVar(&f.Schedule, "bandwidth-schedule", "", `Time-of-day bandwidth windows as JSON, e.g. '[{"days":["mon","tue","wed","thu","fri"],"start":"08:00","end":"18:00","upload":"20MB"}]'; "none" clears`)
+}
+
+// Changed reports whether any bandwidth flag was set on the command line.
+func (f *BandwidthFlags) Changed(fs *pflag.FlagSet) bool {
+	return fs.Changed("upload-limit") || fs.Changed("download-limit") || fs.Changed("bandwidth-schedule")
+}
+
+// Apply merges the flags that were set into current and returns the
+// resulting policy. Unset flags keep the current value.
+func (f *BandwidthFlags) Apply(fs *pflag.FlagSet, current block.BandwidthPolicy) (block.BandwidthPolicy, error) {
+	p := current
+	if fs.Changed("upload-limit") {
+		r, err := block.ParseBandwidthRate(f.Upload)
+		if err != nil {
+			return p, fmt.Errorf("--upload-limit: %w", err)
+		}
+		p.Upload = r
+	}
+	if fs.Changed("download-limit") {
+		r, err := block.ParseBandwidthRate(f.Download)
+		if err != nil {
+			return p, fmt.Errorf("--download-limit: %w", err)
+		}
+		p.Download = r
+	}
+	if fs.Changed("bandwidth-schedule") {
+		if s := strings.TrimSpace(f.Schedule); s == "" || strings.EqualFold(s, "none") {
+			p.Schedule = nil
+		} else {
+			var windows []block.BandwidthWindow
+			if err := json.Unmarshal([]byte(s), &windows); err != nil {
+				return p, fmt.Errorf("--bandwidth-schedule: invalid JSON: %w", err)
+			}
+			p.Schedule = windows
+		}
+	}
+	if err := p.Validate(); err != nil {
+		return p, err
+	}
+	return p, nil
+}
@@ -217,6 +217,11 @@ var (
 	settingsPortmapperRegisterWithSys  bool
 	settingsUDPEnabled                 bool
 	settingsMDNSEnabled                bool
+	settingsIDMapDomain                string
+	settingsIDMapNumericAuthSys        bool
+	settingsPNFSDataServers            string
+	settingsPNFSDataServerVersion      string
+	settingsRQuotaSetQuotaEnabled      bool
 	settingsV4MinMinorVersion          int
 	settingsV4MaxMinorVersion          int
 	settingsV4MaxConnectionsPerSession int
@@ -297,6 +302,11 @@ func registerNFSUpdateFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&settingsPortmapperRegisterWithSys, "portmapper-register-with-system", false, "Register NFS/MOUNT/NLM services with the host's system rpcbind on port 111, so kernel NFSv3 clients can lock without 'nolock' (restart to apply)")
 	cmd.Flags().BoolVar(&settingsUDPEnabled, "udp-enabled", false, "Serve NLM/NSM/MOUNT over UDP (needed for NFSv3 locking from macOS/BSD; restart to apply)")
 	cmd.Flags().BoolVar(&settingsMDNSEnabled, "mdns-enabled", false, "Advertise the NFS export over mDNS/DNS-SD (_nfs._tcp) for macOS Finder / Linux Avahi (applied immediately)")
+	cmd.Flags().StringVar(&settingsIDMapDomain, "idmap-domain", "", "NFSv4 ID-mapping domain for user@domain owner strings; must match the clients' idmapd Domain (empty = numeric owners)")
+	cmd.Flags().BoolVar(&settingsIDMapNumericAuthSys, "idmap-numeric-auth-sys", false, "Send numeric owners to AUTH_SYS clients even when --idmap-domain is set (like nfs4_disable_idmapping)")
+	cmd.Flags().StringVar(&settingsPNFSDataServers, "pnfs-data-servers", "", "Comma-separated pNFS data servers (host:port); each must be a DittoFS instance sharing this server's stores (empty = pNFS off)")
+	cmd.Flags().StringVar(&settingsPNFSDataServerVersion, "pnfs-data-server-version", "", "NFS version clients use to reach pNFS data servers: 3|4.1")
+	cmd.Flags().BoolVar(&settingsRQuotaSetQuotaEnabled, "rquota-set-quota-enabled", false, "Let client root change quotas over rquota (setquota/edquota); trusts AUTH_SYS uid 0, never honored over UDP")
 	cmd.Flags().IntVar(&settingsV4MinMinorVersion, "v4-min-minor-version", 0, "Minimum NFSv4 minor version (0=v4.0, 1=v4.1)")
 	cmd.Flags().IntVar(&settingsV4MaxMinorVersion, "v4-max-minor-version", 0, "Maximum NFSv4 minor version (0=v4.0, 1=v4.1)")
 	cmd.Flags().IntVar(&settingsV4MaxConnectionsPerSession, "v4-max-connections-per-session", 0, "Maximum connections per NFSv4.1 session (0=unlimited)")
@@ -397,6 +407,17 @@ func showNFSSettings(client *apiclient.Client, format output.Format) error {
 	printSettingsGroup("Discovery", []settingRow{
 		newSettingRowBool("mdns_enabled", settings.MDNSEnabled, d.MDNSEnabled),
 	})
+	printSettingsGroup("ID Mapping", []settingRow{
+		newSettingRow("idmap_domain", settings.IDMapDomain, d.IDMapDomain),
+		newSettingRowBool("idmap_numeric_auth_sys", settings.IDMapNumericAuthSys, d.IDMapNumericAuthSys),
+	})
+	printSettingsGroup("pNFS", []settingRow{
+		newSettingRowStrSlice("pnfs_data_servers", settings.PNFSDataServers, d.PNFSDataServers),
+		newSettingRow("pnfs_data_server_version", settings.PNFSDataServerVersion, d.PNFSDataServerVersion),
+	})
+	printSettingsGroup("Quotas", []settingRow{
+		newSettingRowBool("rquota_set_quota_enabled", settings.RQuotaSetQuotaEnabled, d.RQuotaSetQuotaEnabled),
+	})
 	printSettingsGroup("Operations", []settingRow{
 		newSettingRowStrSlice("blocked_operations", settings.BlockedOperations, d.BlockedOperations),
 	})
@@ -632,6 +653,31 @@ func updateNFSSettings(cmd *cobra.Command, client *apiclient.Client) error {
 		req.MDNSEnabled = &settingsMDNSEnabled
 		hasChanges = true
 	}
+	if cmd.Flags().Changed("idmap-domain") {
+		req.IDMapDomain = &settingsIDMapDomain
+		hasChanges = true
+	}
+	if cmd.Flags().Changed("idmap-numeric-auth-sys") {
+		req.IDMapNumericAuthSys = &settingsIDMapNumericAuthSys
+		hasChanges = true
+	}
+	if cmd.Flags().Changed("pnfs-data-servers") {
+		// An empty list must reach the server as [] (not null) to clear it.
+		servers := cmdutil.ParseCommaSeparatedList(settingsPNFSDataServers)
+		if servers == nil {
+			servers = []string{}
+		}
+		req.PNFSDataServers = &servers
+		hasChanges = true
+	}
+	if cmd.Flags().Changed("pnfs-data-server-version") {
+		req.PNFSDataServerVersion = &settingsPNFSDataServerVersion
+		hasChanges = true
+	}
+	if cmd.Flags().Changed("rquota-set-quota-enabled") {
+		req.RQuotaSetQuotaEnabled = &settingsRQuotaSetQuotaEnabled
+		hasChanges = true
+	}
 	if cmd.Flags().Changed("v4-min-minor-version") {
 		req.V4MinMinorVersion = &settingsV4MinMinorVersion
 		hasChanges = true
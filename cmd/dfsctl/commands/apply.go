@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/marmos91/dittofs/internal/cli/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyFiles  []string
+	applyPrune  bool
+	applyDryRun bool
+	applyYes    bool
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply -f <manifest>...",
+	Short: "Converge the server to a declarative manifest",
+	Long: `Apply a YAML or JSON manifest describing metadata stores, block stores,
+netgroups, shares (with their NFS options, permissions, quotas and snapshot
+policy) and adapter settings.
+
+The manifest is compared with the live server and the resulting plan is
+printed before anything changes. Only what the manifest states is managed:
+fields left out of a resource keep their current value. Permission, quota and
+netgroup member lists are authoritative when present. Stores, netgroups and
+shares the manifest does not mention are listed and kept, unless --prune is
+given. Applying the same manifest twice makes no changes.
+
+-f accepts files, directories (their *.yaml, *.yml and *.json files) and "-"
+for standard input, and may be repeated. ${NAME} in values is replaced by the
+environment variable NAME, so secrets need not be committed.
+
+Examples:
+  # Show the plan without changing anything
+  dfsctl apply -f dittofs.yaml --dry-run
+
+  # Apply every manifest in a directory
+  dfsctl apply -f ./config/
+
+  # Also delete resources missing from the manifest, without prompting
+  dfsctl apply -f dittofs.yaml --prune --yes`,
+	RunE: runApply,
+}
+
+func init() {
+	applyCmd.Flags().StringArrayVarP(&applyFiles, "filename", "f", nil, "Manifest file or directory (repeatable, - for stdin)")
+	applyCmd.Flags().BoolVar(&applyPrune, "prune", false, "Delete stores, netgroups and shares not in the manifest")
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "Print the plan without applying it")
+	applyCmd.Flags().BoolVar(&applyYes, "yes", false, "Skip confirmation prompt for plans that delete resources")
+	_ = applyCmd.MarkFlagRequired("filename")
+	rootCmd.AddCommand(applyCmd)
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	m, err := manifest.Load(applyFiles, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	plan, err := manifest.BuildPlan(m, client, manifest.Options{Prune: applyPrune})
+	if err != nil {
+		return fmt.Errorf("failed to plan: %w", err)
+	}
+	plan.Print(os.Stdout)
+	if plan.Empty() || applyDryRun {
+		return nil
+	}
+
+	if plan.HasDeletes() {
+		ok, err := cmdutil.ConfirmDestructive("\nThe plan deletes resources. This cannot be undone.", applyYes)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	fmt.Println()
+	if err := plan.Apply(os.Stdout); err != nil {
+		return fmt.Errorf("apply failed (re-run to resume): %w", err)
+	}
+	cmdutil.PrintSuccess(fmt.Sprintf("Applied %d changes", len(plan.Changes)))
+	return nil
+}
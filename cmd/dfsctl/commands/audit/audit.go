@@ -0,0 +1,28 @@
+// Package audit implements control-plane audit log commands for dfsctl.
+package audit
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Cmd is the parent command for the audit log.
+var Cmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the control-plane audit log",
+	Long: `Inspect the control-plane audit log. The server records every mutating API
+call (users, permissions, shares, stores, snapshot restores, GC and reclaim,
+trash empty, adapter settings, tokens and roles) with the caller, the time,
+the result, and the target's state before and after. Secrets are redacted
+before they are recorded. Reading the log requires admin privileges.
+
+Examples:
+  # Show what changed in the last 24 hours
+  dfsctl audit list --since 24h
+
+  # Show one administrator's share changes, with full before/after state
+  dfsctl audit list --actor alice --resource shares -o json`,
+}
+
+func init() {
+	Cmd.AddCommand(listCmd)
+}
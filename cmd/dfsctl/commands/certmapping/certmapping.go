@@ -0,0 +1,47 @@
+// Package certmapping implements NFS-over-TLS client certificate mapping
+// commands for dfsctl.
+package certmapping
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Cmd is the parent command for cert mapping management.
+var Cmd = &cobra.Command{
+	Use:   "cert-mapping",
+	Short: "Manage NFS-over-TLS client certificate mappings",
+	Long: `Manage rules that map the verified client certificate of an NFS-over-TLS
+connection to an access policy. A rule matches the certificate's subject CN or
+a DNS, email or URI subject alternative name, and can restrict which shares the
+host may mount and how its AUTH_SYS credentials are squashed. This gives strong
+host authentication without deploying Kerberos. Rules require the NFS adapter
+to run with TLS and a client CA (mTLS). All subcommands require admin
+privileges.
+
+Rules are evaluated in ascending priority. On a share, the first matching rule
+that names the share wins, then the first matching rule without a share list.
+A share named by any rule may only be used by certificates matching a rule
+that names it, and a certificate whose rules all name other shares is confined
+to those shares.
+
+Examples:
+  # Squash every build host to uid/gid 2000
+  dfsctl cert-mapping create --name build-hosts --field dns --pattern '*.build.internal' \
+    --squash all_to_guest --anon-uid 2000 --anon-gid 2000
+
+  # Only the certificate with CN "deploy" may mount /releases
+  dfsctl cert-mapping create --name deploy --field cn --pattern deploy --shares /releases
+
+  # List rules in evaluation order
+  dfsctl cert-mapping list
+
+  # Remove a rule
+  dfsctl cert-mapping remove build-hosts`,
+}
+
+func init() {
+	Cmd.AddCommand(createCmd)
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(showCmd)
+	Cmd.AddCommand(removeCmd)
+}
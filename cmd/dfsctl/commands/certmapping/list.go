@@ -0,0 +1,84 @@
+package certmapping
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List client certificate mappings",
+	Long: `List the client certificate mapping rules registered on the DittoFS server
+in evaluation order. The table view shows each rule's priority, the matched
+certificate field and pattern, the shares it applies to and its identity
+mapping.
+
+Examples:
+  # List all rules as a table
+  dfsctl cert-mapping list
+
+  # Output as JSON
+  dfsctl cert-mapping list -o json`,
+	RunE: runList,
+}
+
+// CertMappingList is a list of cert mappings for table rendering.
+type CertMappingList []*apiclient.CertMapping
+
+// Headers implements TableRenderer.
+func (cl CertMappingList) Headers() []string {
+	return []string{"PRIORITY", "NAME", "FIELD", "PATTERN", "SHARES", "IDENTITY"}
+}
+
+// Rows implements TableRenderer.
+func (cl CertMappingList) Rows() [][]string {
+	rows := make([][]string, 0, len(cl))
+	for _, m := range cl {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", m.Priority), m.Name, m.Field, m.Pattern, sharesColumn(m), identityColumn(m),
+		})
+	}
+	return rows
+}
+
+// sharesColumn renders a rule's share list; empty means every share.
+func sharesColumn(m *apiclient.CertMapping) string {
+	if len(m.Shares) == 0 {
+		return "(all)"
+	}
+	return strings.Join(m.Shares, ",")
+}
+
+// identityColumn renders a rule's squash overrides, e.g. "all_to_guest 2000:2000".
+func identityColumn(m *apiclient.CertMapping) string {
+	squash := cmdutil.EmptyOr(m.Squash, "(share)")
+	if m.AnonymousUID == nil && m.AnonymousGID == nil {
+		return squash
+	}
+	id := func(v *uint32) string {
+		if v == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%d", *v)
+	}
+	return fmt.Sprintf("%s %s:%s", squash, id(m.AnonymousUID), id(m.AnonymousGID))
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	mappings, err := client.ListCertMappings()
+	if err != nil {
+		return fmt.Errorf("failed to list cert mappings: %w", err)
+	}
+
+	return cmdutil.PrintOutput(os.Stdout, mappings, len(mappings) == 0, "No cert mappings found.", CertMappingList(mappings))
+}
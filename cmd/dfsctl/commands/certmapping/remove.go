@@ -0,0 +1,48 @@
+package certmapping
+
+import (
+	"fmt"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+var removeForce bool
+
+var removeCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a client certificate mapping",
+	Long: `Remove a client certificate mapping rule. Clients it matched fall back to
+the remaining rules on their next request; a share it named stops being
+restricted once no rule names it. You will be prompted for confirmation unless
+--force is specified.
+
+Examples:
+  # Remove a rule (prompts for confirmation)
+  dfsctl cert-mapping remove build-hosts
+
+  # Remove a rule non-interactively
+  dfsctl cert-mapping remove build-hosts --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRemove,
+}
+
+func init() {
+	removeCmd.Flags().BoolVarP(&removeForce, "force", "f", false, "Skip confirmation prompt")
+}
+
+func runRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	return cmdutil.RunDeleteWithConfirmation("Cert mapping", name, removeForce, func() error {
+		if err := client.RemoveCertMapping(name); err != nil {
+			return fmt.Errorf("failed to remove cert mapping: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,66 @@
+package certmapping
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/spf13/cobra"
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show client certificate mapping details",
+	Long: `Show a client certificate mapping rule. Use -o json or -o yaml for
+machine-readable output.
+
+Examples:
+  # Show a rule
+  dfsctl cert-mapping show build-hosts
+
+  # Output as YAML
+  dfsctl cert-mapping show build-hosts -o yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runShow,
+}
+
+// CertMappingDetail wraps a cert mapping for detailed table rendering.
+type CertMappingDetail struct {
+	mapping *apiclient.CertMapping
+}
+
+// Headers implements TableRenderer.
+func (cd CertMappingDetail) Headers() []string {
+	return []string{"FIELD", "VALUE"}
+}
+
+// Rows implements TableRenderer.
+func (cd CertMappingDetail) Rows() [][]string {
+	m := cd.mapping
+	return [][]string{
+		{"ID", m.ID},
+		{"Name", m.Name},
+		{"Priority", fmt.Sprintf("%d", m.Priority)},
+		{"Field", m.Field},
+		{"Pattern", m.Pattern},
+		{"Shares", sharesColumn(m)},
+		{"Identity", identityColumn(m)},
+		{"Created", m.CreatedAt.Format("2006-01-02 15:04:05")},
+		{"Updated", m.UpdatedAt.Format("2006-01-02 15:04:05")},
+	}
+}
+
+func runShow(cmd *cobra.Command, args []string) error {
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	mapping, err := client.GetCertMapping(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to get cert mapping: %w", err)
+	}
+
+	return cmdutil.PrintResource(os.Stdout, mapping, CertMappingDetail{mapping: mapping})
+}
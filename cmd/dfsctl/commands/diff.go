@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"errors"
+	"os"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/marmos91/dittofs/internal/cli/manifest"
+	"github.com/marmos91/dittofs/internal/cli/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFiles []string
+	diffPrune bool
+)
+
+// errDrift makes dfsctl diff exit non-zero when the server has drifted.
+var errDrift = errors.New("the server differs from the manifest")
+
+var diffCmd = &cobra.Command{
+	Use:   "diff -f <manifest>...",
+	Short: "Report drift between the server and a manifest",
+	Long: `Compare the live server with a manifest and print what dfsctl apply would
+change. Nothing is modified.
+
+The command exits non-zero when the server has drifted, so it can gate a CI
+pipeline or alert from a cron job. Resources the manifest does not mention
+count as drift only with --prune.
+
+Examples:
+  # Show drift
+  dfsctl diff -f dittofs.yaml
+
+  # Machine-readable plan
+  dfsctl diff -f ./config/ -o json`,
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringArrayVarP(&diffFiles, "filename", "f", nil, "Manifest file or directory (repeatable, - for stdin)")
+	diffCmd.Flags().BoolVar(&diffPrune, "prune", false, "Report resources not in the manifest as drift")
+	_ = diffCmd.MarkFlagRequired("filename")
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	m, err := manifest.Load(diffFiles, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	plan, err := manifest.BuildPlan(m, client, manifest.Options{Prune: diffPrune})
+	if err != nil {
+		return err
+	}
+
+	format, err := cmdutil.GetOutputFormatParsed()
+	if err != nil {
+		return err
+	}
+	switch format {
+	case output.FormatJSON:
+		err = output.PrintJSON(os.Stdout, plan)
+	case output.FormatYAML:
+		err = output.PrintYAML(os.Stdout, plan)
+	default:
+		plan.Print(os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+	if !plan.Empty() {
+		return errDrift
+	}
+	return nil
+}
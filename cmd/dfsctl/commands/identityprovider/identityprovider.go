@@ -140,6 +140,7 @@ apply live; Kerberos changes apply on the next server restart.
 
 Examples:
   dfsctl identity-provider set ldap --config '{"enabled":true,"url":"ldaps://dc:636","base_dn":"DC=x,DC=y","bind_dn":"CN=svc,DC=x,DC=y","bind_password":"s3cret","idmap":"rfc2307"}'
+  dfsctl identity-provider set ldap --config '{"enabled":true,"url":"ldaps://ldap:636","base_dn":"dc=example,dc=com","bind_dn":"cn=svc,dc=example,dc=com","bind_password":"s3cret","user_attr":"uid","user_object_class":"posixAccount"}'
   dfsctl identity-provider set kerberos --config @/path/to/krb.json`,
 	Args: cobra.ExactArgs(1),
 	RunE: runSet,
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"path/filepath"
@@ -16,7 +17,10 @@ var (
 	loginServer      string
 	loginUsername    string
 	loginPassword    string
+	loginOTP         string
 	loginContextName string
+	loginOIDC        bool
+	loginDevice      bool
 
 	loginCACert        string
 	loginClientCert    string
@@ -36,6 +40,13 @@ commands reuse the stored token and refresh it automatically when it expires.
 TLS client certificates and a custom CA bundle can be pinned at login time and
 are persisted into the context for later commands.
 
+With --oidc, dfsctl signs in through the server's OpenID Connect identity
+provider instead of a DittoFS password: it opens the IdP's login page in a
+browser (authorization code with PKCE, redirected to a temporary
+127.0.0.1 listener) and the server maps the IdP identity to a DittoFS user.
+--device uses the device-code flow instead, for hosts without a browser:
+approve the printed code from any other device.
+
 Examples:
   # First login to a local server (prompts for password)
   dfsctl login --server http://localhost:8080 --username admin
@@ -46,8 +57,17 @@ Examples:
   # Login passing password on the command line (less secure; avoid in shared environments)
   dfsctl login --server http://localhost:8080 -u admin -p secret
 
+  # Login as a user enrolled in TOTP, passing the one-time code (prompted for if omitted)
+  dfsctl login --server http://localhost:8080 -u alice --otp 123456
+
   # Re-login to the already-stored server (password prompt only)
-  dfsctl login`,
+  dfsctl login
+
+  # Single sign-on through the server's identity provider (opens a browser)
+  dfsctl login --server https://dfs.example.com --oidc
+
+  # Single sign-on from a headless host (device code)
+  dfsctl login --server https://dfs.example.com --oidc --device`,
 	RunE: runLogin,
 }
 
@@ -55,7 +75,10 @@ func init() {
 	loginCmd.Flags().StringVar(&loginServer, "server", "http://localhost:8080", "Server URL")
 	loginCmd.Flags().StringVarP(&loginUsername, "username", "u", "", "Username")
 	loginCmd.Flags().StringVarP(&loginPassword, "password", "p", "", "Password")
+	loginCmd.Flags().StringVar(&loginOTP, "otp", "", "One-time code for users enrolled in TOTP (prompted for when required)")
 	loginCmd.Flags().StringVarP(&loginContextName, "context", "c", "", "Context name (defaults to current or auto-generated)")
+	loginCmd.Flags().BoolVar(&loginOIDC, "oidc", false, "Sign in through the server's OpenID Connect identity provider")
+	loginCmd.Flags().BoolVar(&loginDevice, "device", false, "Use the OIDC device-code flow (for hosts without a browser; implies --oidc)")
 	loginCmd.Flags().StringVar(&loginCACert, "cacert", "", "Path to a PEM CA bundle trusted for the server certificate")
 	loginCmd.Flags().StringVar(&loginClientCert, "client-cert", "", "Path to a PEM client certificate for mutual TLS")
 	loginCmd.Flags().StringVar(&loginClientKey, "client-key", "", "Path to the PEM client private key for mutual TLS")
@@ -71,6 +94,21 @@ func absPath(p string) (string, error) {
 	return filepath.Abs(p)
 }
 
+// loginWithPassword logs in with a password, prompting for a one-time code
+// when the user is enrolled in TOTP and otp is empty.
+func loginWithPassword(client *apiclient.Client, username, password, otp string) (*apiclient.TokenResponse, error) {
+	tokens, err := client.LoginWithOTP(username, password, otp)
+	var apiErr *apiclient.APIError
+	if otp != "" || !errors.As(err, &apiErr) || !apiErr.IsMFARequired() {
+		return tokens, err
+	}
+	otp, err = prompt.InputRequired("One-time code")
+	if err != nil {
+		return nil, cmdutil.HandleAbort(err)
+	}
+	return client.LoginWithOTP(username, password, otp)
+}
+
 func runLogin(cmd *cobra.Command, args []string) error {
 	// Load credential store
 	store, err := credentials.NewStore()
@@ -91,24 +129,6 @@ func runLogin(cmd *cobra.Command, args []string) error {
 		serverURLStr = parsedURL.String()
 	}
 
-	// Get username (prompt if not provided)
-	username := loginUsername
-	if username == "" {
-		username, err = prompt.InputRequired("Username")
-		if err != nil {
-			return cmdutil.HandleAbort(err)
-		}
-	}
-
-	// Get password (prompt if not provided)
-	password := loginPassword
-	if password == "" {
-		password, err = prompt.PasswordWithValidation("Password", 8)
-		if err != nil {
-			return cmdutil.HandleAbort(err)
-		}
-	}
-
 	// Normalize TLS cert paths to absolute before they are captured and
 	// persisted. The stored paths are re-read by later commands from a
 	// potentially different working directory, so a relative path supplied here
@@ -128,11 +148,44 @@ func runLogin(cmd *cobra.Command, args []string) error {
 	tlsOpts := cmdutil.TLSClientOptions(loginCACert, loginClientCert, loginClientKey, loginTLSSkipVerify)
 	client := apiclient.New(serverURLStr, tlsOpts...)
 
-	// Attempt login
-	fmt.Printf("Logging in to %s as %s...\n", serverURLStr, username)
-	tokens, err := client.Login(username, password)
-	if err != nil {
-		return fmt.Errorf("login failed: %w", err)
+	var tokens *apiclient.TokenResponse
+	username := loginUsername
+	if loginOIDC || loginDevice {
+		var resp *apiclient.OIDCLoginResponse
+		if loginDevice {
+			resp, err = loginWithOIDCDevice(client)
+		} else {
+			resp, err = loginWithOIDC(client)
+		}
+		if err != nil {
+			return fmt.Errorf("login failed: %w", err)
+		}
+		tokens = &resp.TokenResponse
+		username = resp.User.Username
+	} else {
+		// Get username (prompt if not provided)
+		if username == "" {
+			username, err = prompt.InputRequired("Username")
+			if err != nil {
+				return cmdutil.HandleAbort(err)
+			}
+		}
+
+		// Get password (prompt if not provided)
+		password := loginPassword
+		if password == "" {
+			password, err = prompt.PasswordWithValidation("Password", 8)
+			if err != nil {
+				return cmdutil.HandleAbort(err)
+			}
+		}
+
+		// Attempt login
+		fmt.Printf("Logging in to %s as %s...\n", serverURLStr, username)
+		tokens, err = loginWithPassword(client, username, password, loginOTP)
+		if err != nil {
+			return fmt.Errorf("login failed: %w", err)
+		}
 	}
 
 	// Guard against empty tokens — a server that omits them from the response
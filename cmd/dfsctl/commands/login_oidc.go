@@ -0,0 +1,195 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/apiclient"
+)
+
+// oidcLoginTimeout bounds how long dfsctl waits for the browser redirect.
+const oidcLoginTimeout = 5 * time.Minute
+
+// loginWithOIDC runs the authorization-code flow with PKCE: it serves a
+// one-shot loopback redirect endpoint, sends the user's browser to the IdP,
+// and hands the returned code to the DittoFS server, which redeems it.
+func loginWithOIDC(client *apiclient.Client) (*apiclient.OIDCLoginResponse, error) {
+	info, err := client.GetOIDCInfo()
+	if err != nil {
+		return nil, oidcUnavailable(err)
+	}
+	if info.AuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("identity provider %s has no authorization endpoint; use --device", info.Issuer)
+	}
+
+	verifier, state, nonce := randomToken(), randomToken(), randomToken()
+	challenge := sha256.Sum256([]byte(verifier))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open loopback listener: %w", err)
+	}
+	redirectURI := fmt.Sprintf("http://%s/callback", listener.Addr())
+
+	authURL, err := url.Parse(info.AuthorizationEndpoint)
+	if err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	q := authURL.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", info.ClientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("scope", strings.Join(info.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	authURL.RawQuery = q.Encode()
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	srv := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/callback" {
+				http.NotFound(w, r)
+				return
+			}
+			params := r.URL.Query()
+			var res result
+			switch {
+			case params.Get("state") != state:
+				res.err = errors.New("login callback has a mismatched state (possible CSRF); try again")
+			case params.Get("error") != "":
+				res.err = fmt.Errorf("identity provider returned %s: %s", params.Get("error"), params.Get("error_description"))
+			case params.Get("code") == "":
+				res.err = errors.New("login callback has no authorization code")
+			default:
+				res.code = params.Get("code")
+			}
+			msg := "Login complete. You can close this window and return to dfsctl."
+			if res.err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				msg = "Login failed: " + res.err.Error()
+			}
+			_, _ = fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", html.EscapeString(msg))
+			select {
+			case results <- res:
+			default:
+			}
+		}),
+	}
+	go func() { _ = srv.Serve(listener) }()
+	defer func() { _ = srv.Close() }()
+
+	fmt.Printf("Opening your browser to sign in with %s.\n", info.Issuer)
+	fmt.Printf("If it does not open, visit:\n\n  %s\n\n", authURL)
+	openBrowser(authURL.String())
+
+	var res result
+	select {
+	case res = <-results:
+	case <-time.After(oidcLoginTimeout):
+		return nil, fmt.Errorf("timed out after %s waiting for the browser login", oidcLoginTimeout)
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+	return client.OIDCLogin(res.code, verifier, redirectURI, nonce)
+}
+
+// loginWithOIDCDevice runs the device-code flow for hosts without a browser:
+// the user approves the login on another device while dfsctl polls.
+func loginWithOIDCDevice(client *apiclient.Client) (*apiclient.OIDCLoginResponse, error) {
+	da, err := client.StartOIDCDeviceLogin()
+	if err != nil {
+		return nil, oidcUnavailable(err)
+	}
+
+	if da.VerificationURIComplete != "" {
+		fmt.Printf("To sign in, visit:\n\n  %s\n\nand confirm the code %s\n\n", da.VerificationURIComplete, da.UserCode)
+	} else {
+		fmt.Printf("To sign in, visit:\n\n  %s\n\nand enter the code %s\n\n", da.VerificationURI, da.UserCode)
+	}
+	fmt.Println("Waiting for approval...")
+
+	interval := time.Duration(da.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ctx := context.Background()
+	if da.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(da.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, errors.New("device code expired before the login was approved")
+		case <-time.After(interval):
+		}
+		resp, status, err := client.PollOIDCDeviceLogin(da.DeviceCode)
+		if err != nil {
+			return nil, err
+		}
+		switch status {
+		case "":
+			return resp, nil
+		case "slow_down":
+			// RFC 8628 §3.5: back off by 5 seconds on every slow_down.
+			interval += 5 * time.Second
+		}
+	}
+}
+
+// oidcUnavailable explains a 404 from the OIDC endpoints, which the server
+// returns when OIDC login is not configured.
+func oidcUnavailable(err error) error {
+	var apiErr *apiclient.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return errors.New("OIDC login is not enabled on this server (controlplane.oidc.enabled)")
+	}
+	return err
+}
+
+// randomToken returns 32 random bytes, base64url-encoded: a valid PKCE code
+// verifier and an unguessable state or nonce.
+func randomToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// openBrowser opens url in the user's browser, best-effort: the URL is
+// always printed too, for hosts where this fails.
+func openBrowser(url string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err == nil {
+		go func() { _ = cmd.Wait() }()
+	}
+}
@@ -0,0 +1,58 @@
+package role
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/spf13/cobra"
+)
+
+var bindingsCmd = &cobra.Command{
+	Use:   "bindings <role>",
+	Short: "List the bindings of a role",
+	Long: `List who holds a custom role and on which shares.
+
+Examples:
+  # Show who holds project-lead
+  dfsctl role bindings project-lead
+
+  # Output as JSON
+  dfsctl role bindings project-lead -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBindings,
+}
+
+// BindingList is a list of role bindings for table rendering.
+type BindingList []apiclient.RoleBinding
+
+// Headers implements TableRenderer.
+func (bl BindingList) Headers() []string {
+	return []string{"ID", "SUBJECT", "SHARES"}
+}
+
+// Rows implements TableRenderer.
+func (bl BindingList) Rows() [][]string {
+	rows := make([][]string, 0, len(bl))
+	for _, b := range bl {
+		shares := strings.Join(b.Shares, ",")
+		rows = append(rows, []string{b.ID, subject(b), cmdutil.EmptyOr(shares, "all")})
+	}
+	return rows
+}
+
+func runBindings(cmd *cobra.Command, args []string) error {
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	bindings, err := client.ListRoleBindings(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to list role bindings: %w", err)
+	}
+
+	return cmdutil.PrintOutput(os.Stdout, bindings, len(bindings) == 0, "No bindings found.", BindingList(bindings))
+}
@@ -0,0 +1,60 @@
+package role
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/spf13/cobra"
+)
+
+var (
+	createPermissions string
+	createDescription string
+)
+
+var createCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a custom role",
+	Long: `Create a custom role from a comma-separated list of permissions. The role
+grants nothing until it is bound with "dfsctl role bind".
+
+Permissions: shares, snapshots, quotas, trash, stores, adapters and users,
+each with :read or :write (write implies read).
+
+Examples:
+  # Share-level maintenance role
+  dfsctl role create project-lead --permissions shares:read,quotas:write,trash:write,snapshots:write
+
+  # Helpdesk role that manages users and groups
+  dfsctl role create helpdesk --permissions users:write --description "Account management"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCreate,
+}
+
+func init() {
+	createCmd.Flags().StringVar(&createPermissions, "permissions", "", "Comma-separated permissions (e.g., quotas:write,trash:read)")
+	createCmd.Flags().StringVar(&createDescription, "description", "", "Role description")
+	_ = createCmd.MarkFlagRequired("permissions")
+}
+
+func runCreate(cmd *cobra.Command, args []string) error {
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	perms := cmdutil.ParseCommaSeparatedList(createPermissions)
+	req := &apiclient.RoleRequest{Name: args[0], Permissions: &perms}
+	if createDescription != "" {
+		req.Description = &createDescription
+	}
+
+	role, err := client.CreateRole(req)
+	if err != nil {
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+
+	return cmdutil.PrintResourceWithSuccess(os.Stdout, role, fmt.Sprintf("Role '%s' created", role.Name))
+}
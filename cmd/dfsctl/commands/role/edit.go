@@ -0,0 +1,63 @@
+package role
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/spf13/cobra"
+)
+
+var (
+	editPermissions string
+	editDescription string
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Edit a custom role",
+	Long: `Edit a custom role's permissions or description. --permissions replaces the
+whole permission list. Changes apply to every binding of the role on the next
+request.
+
+Examples:
+  # Allow project leads to manage trash as well
+  dfsctl role edit project-lead --permissions shares:read,quotas:write,snapshots:write,trash:write
+
+  # Update the description only
+  dfsctl role edit project-lead --description "Per-project maintenance"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEdit,
+}
+
+func init() {
+	editCmd.Flags().StringVar(&editPermissions, "permissions", "", "Comma-separated permissions (replaces the current list)")
+	editCmd.Flags().StringVar(&editDescription, "description", "", "Role description")
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	req := &apiclient.RoleRequest{}
+	if cmd.Flags().Changed("permissions") {
+		perms := cmdutil.ParseCommaSeparatedList(editPermissions)
+		req.Permissions = &perms
+	}
+	if cmd.Flags().Changed("description") {
+		req.Description = &editDescription
+	}
+	if req.Permissions == nil && req.Description == nil {
+		return fmt.Errorf("no fields specified. Use --permissions or --description")
+	}
+
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	role, err := client.UpdateRole(args[0], req)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	return cmdutil.PrintResourceWithSuccess(os.Stdout, role, fmt.Sprintf("Role '%s' updated", role.Name))
+}
@@ -0,0 +1,56 @@
+package role
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List custom roles",
+	Long: `List the custom roles defined on the DittoFS server with their permissions.
+
+Examples:
+  # List all roles
+  dfsctl role list
+
+  # Output as JSON
+  dfsctl role list -o json`,
+	RunE: runList,
+}
+
+// RoleList is a list of roles for table rendering.
+type RoleList []apiclient.Role
+
+// Headers implements TableRenderer.
+func (rl RoleList) Headers() []string {
+	return []string{"NAME", "PERMISSIONS", "DESCRIPTION"}
+}
+
+// Rows implements TableRenderer.
+func (rl RoleList) Rows() [][]string {
+	rows := make([][]string, 0, len(rl))
+	for _, r := range rl {
+		rows = append(rows, []string{r.Name, strings.Join(r.Permissions, ","), cmdutil.EmptyOr(r.Description, "-")})
+	}
+	return rows
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	roles, err := client.ListRoles()
+	if err != nil {
+		return fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	return cmdutil.PrintOutput(os.Stdout, roles, len(roles) == 0, "No custom roles found.", RoleList(roles))
+}
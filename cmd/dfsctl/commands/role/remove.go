@@ -0,0 +1,46 @@
+package role
+
+import (
+	"fmt"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+var removeForce bool
+
+var removeCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a custom role",
+	Long: `Remove a custom role together with all of its bindings. Users and groups
+holding the role lose its permissions immediately. You will be prompted for
+confirmation unless --force is specified.
+
+Examples:
+  # Remove a role (prompts for confirmation)
+  dfsctl role remove project-lead
+
+  # Remove without confirmation
+  dfsctl role remove project-lead --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRemove,
+}
+
+func init() {
+	removeCmd.Flags().BoolVarP(&removeForce, "force", "f", false, "Skip confirmation prompt")
+}
+
+func runRemove(cmd *cobra.Command, args []string) error {
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	name := args[0]
+	return cmdutil.RunDeleteWithConfirmation("Role", name, removeForce, func() error {
+		if err := client.DeleteRole(name); err != nil {
+			return fmt.Errorf("failed to remove role: %w", err)
+		}
+		return nil
+	})
+}
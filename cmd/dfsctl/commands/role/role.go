@@ -0,0 +1,49 @@
+// Package role implements custom role commands for dfsctl.
+package role
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Cmd is the parent command for custom role management.
+var Cmd = &cobra.Command{
+	Use:   "role",
+	Short: "Manage custom roles and delegation",
+	Long: `Manage custom roles, which delegate parts of server administration without
+granting the full admin role. A role is a set of permissions of the form
+<resource>:<verb>; it takes effect when bound to a user or group, optionally
+limited to specific shares.
+
+Resources: shares, snapshots, quotas, trash, stores, adapters, users
+Verbs:     read, write (write implies read)
+
+Share-scoped bindings apply to the routes of those shares only
+(/api/v1/shares/<name>/...). Server-wide routes, such as listing or creating
+shares, need an unscoped binding. Settings, system operations, API tokens and
+role management are never delegated. users:write never allows creating,
+editing or resetting admin users, changing the membership or GID of system
+or role-bound groups, or assigning UID/GID 0.
+
+Examples:
+  # A project lead who manages quotas, trash and snapshots of one share
+  dfsctl role create project-lead --permissions shares:read,quotas:write,trash:write,snapshots:write
+  dfsctl role bind project-lead --user alice --shares /projects
+
+  # Read-only auditors across the whole server
+  dfsctl role create auditor --permissions shares:read,stores:read,users:read
+  dfsctl role bind auditor --group auditors
+
+  # Inspect and revoke
+  dfsctl role bindings project-lead
+  dfsctl role unbind project-lead <binding-id>`,
+}
+
+func init() {
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(createCmd)
+	Cmd.AddCommand(editCmd)
+	Cmd.AddCommand(removeCmd)
+	Cmd.AddCommand(bindCmd)
+	Cmd.AddCommand(bindingsCmd)
+	Cmd.AddCommand(unbindCmd)
+}
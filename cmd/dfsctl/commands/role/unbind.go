@@ -0,0 +1,46 @@
+package role
+
+import (
+	"fmt"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+var unbindForce bool
+
+var unbindCmd = &cobra.Command{
+	Use:   "unbind <role> <binding-id>",
+	Short: "Remove a role binding",
+	Long: `Remove a role binding by ID (see "dfsctl role bindings"). The user or group
+loses the role's permissions immediately. You will be prompted for
+confirmation unless --force is specified.
+
+Examples:
+  # Revoke a delegation
+  dfsctl role unbind project-lead 5d1c...
+
+  # Without confirmation
+  dfsctl role unbind project-lead 5d1c... --force`,
+	Args: cobra.ExactArgs(2),
+	RunE: runUnbind,
+}
+
+func init() {
+	unbindCmd.Flags().BoolVarP(&unbindForce, "force", "f", false, "Skip confirmation prompt")
+}
+
+func runUnbind(cmd *cobra.Command, args []string) error {
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	role, id := args[0], args[1]
+	return cmdutil.RunDeleteWithConfirmation("Role binding", id, unbindForce, func() error {
+		if err := client.UnbindRole(role, id); err != nil {
+			return fmt.Errorf("failed to remove role binding: %w", err)
+		}
+		return nil
+	})
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
 	adaptercmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/adapter"
+	auditcmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/audit"
+	certmappingcmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/certmapping"
 	clientcmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/client"
 	ctxcmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/context"
 	gracecmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/grace"
@@ -15,10 +17,12 @@ import (
 	netgroupcmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/netgroup"
 	netlogoncmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/netlogon"
 	quotacmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/quota"
+	rolecmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/role"
 	settingscmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/settings"
 	sharecmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/share"
 	storecmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/store"
 	systemcmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/system"
+	tokencmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/token"
 	trashcmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/trash"
 	usercmd "github.com/marmos91/dittofs/cmd/dfsctl/commands/user"
 	"github.com/spf13/cobra"
@@ -58,6 +62,15 @@ Use "dfsctl [command] --help" for more information about a command.`,
 		cmdutil.Flags.ClientKey, _ = cmd.Flags().GetString("client-key")
 		cmdutil.Flags.TLSSkipVerify, _ = cmd.Flags().GetBool("tls-skip-verify")
 		cmdutil.Flags.TLSSkipVerifySet = cmd.Flags().Changed("tls-skip-verify")
+
+		// Automation (CI, Terraform) passes a service-account API token via
+		// the environment so it never shows up in process listings.
+		if cmdutil.Flags.ServerURL == "" {
+			cmdutil.Flags.ServerURL = os.Getenv("DITTOFS_SERVER")
+		}
+		if cmdutil.Flags.Token == "" {
+			cmdutil.Flags.Token = os.Getenv("DITTOFS_TOKEN")
+		}
 	},
 }
 
@@ -73,8 +86,8 @@ func GetRootCmd() *cobra.Command {
 
 func init() {
 	// Global persistent flags
-	rootCmd.PersistentFlags().String("server", "", "Server URL (overrides stored credential)")
-	rootCmd.PersistentFlags().String("token", "", "Bearer token (overrides stored credential)")
+	rootCmd.PersistentFlags().String("server", "", "Server URL (overrides stored credential; env DITTOFS_SERVER)")
+	rootCmd.PersistentFlags().String("token", "", "Bearer or API token (overrides stored credential; env DITTOFS_TOKEN)")
 	rootCmd.PersistentFlags().StringP("output", "o", "table", "Output format (table|json|yaml)")
 	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colored output")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Enable verbose output")
@@ -93,6 +106,7 @@ func init() {
 	rootCmd.AddCommand(ctxcmd.Cmd)
 	rootCmd.AddCommand(usercmd.Cmd)
 	rootCmd.AddCommand(groupcmd.Cmd)
+	rootCmd.AddCommand(rolecmd.Cmd)
 	rootCmd.AddCommand(sharecmd.Cmd)
 	rootCmd.AddCommand(quotacmd.Cmd)
 	rootCmd.AddCommand(trashcmd.Cmd)
@@ -101,9 +115,12 @@ func init() {
 	rootCmd.AddCommand(clientcmd.Cmd)
 	rootCmd.AddCommand(gracecmd.Cmd)
 	rootCmd.AddCommand(netgroupcmd.Cmd)
+	rootCmd.AddCommand(certmappingcmd.Cmd)
 	rootCmd.AddCommand(netlogoncmd.Cmd)
 	rootCmd.AddCommand(idmapcmd.Cmd)
 	rootCmd.AddCommand(idpcmd.Cmd)
+	rootCmd.AddCommand(tokencmd.Cmd)
+	rootCmd.AddCommand(auditcmd.Cmd)
 	rootCmd.AddCommand(settingscmd.Cmd)
 	rootCmd.AddCommand(switchUserCmd)
 	rootCmd.AddCommand(systemcmd.Cmd)
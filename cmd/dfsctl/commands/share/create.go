@@ -38,6 +38,8 @@ var (
 	createTrashRestrictAdm  bool
 	createTrashMaxSize      int64
 	createTrashExclude      []string
+	createFileAudit         bool
+	createFileAuditOps      []string
 )
 
 var createCmd = &cobra.Command{
@@ -108,6 +110,8 @@ func init() {
 	createCmd.Flags().BoolVar(&createTrashRestrictAdm, "trash-restrict-empty-to-admin", false, "Restrict emptying the recycle bin to admins.")
 	createCmd.Flags().Int64Var(&createTrashMaxSize, "trash-max-size", 0, "Max bytes the recycle bin may hold before the reaper evicts oldest items (0 = unbounded).")
 	createCmd.Flags().StringSliceVar(&createTrashExclude, "trash-exclude", nil, "Glob patterns whose deletions bypass the recycle bin (repeatable).")
+	createCmd.Flags().BoolVar(&createFileAudit, "file-audit", false, "Record file accesses on this share in the file-access audit trail.")
+	createCmd.Flags().StringSliceVar(&createFileAuditOps, "file-audit-ops", nil, "Audited operations: open, close, read, write, create, delete, rename, set_acl, set_owner (default: open, create, delete, rename, set_acl, set_owner).")
 	_ = createCmd.MarkFlagRequired("local")
 }
 
@@ -238,6 +242,13 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	if cmd.Flags().Changed("trash-exclude") {
 		req.TrashExcludePatterns = createTrashExclude
 	}
+	if cmd.Flags().Changed("file-audit") {
+		v := createFileAudit
+		req.FileAuditEnabled = &v
+	}
+	if cmd.Flags().Changed("file-audit-ops") {
+		req.FileAuditOperations = createFileAuditOps
+	}
 
 	// Squash lives on the NFS adapter config endpoint, not the share record.
 	// Validate up front so we don't create a share and then fail to apply a
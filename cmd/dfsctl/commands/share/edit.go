@@ -8,6 +8,7 @@ import (
 	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
 	"github.com/marmos91/dittofs/internal/cli/prompt"
 	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/marmos91/dittofs/pkg/block"
 	"github.com/spf13/cobra"
 )
 
@@ -30,6 +31,15 @@ var (
 	editTrashRestrictAdm  string
 	editTrashMaxSize      int64
 	editTrashExclude      []string
+	editBandwidth         cmdutil.BandwidthFlags
+	editWarmOnStart       string
+	editWarmAfterEvict    string
+	editWarmInterval      string
+	editWarmPaths         []string
+	editWarmAccessed      string
+	editWarmHottest       int
+	editFileAudit         string
+	editFileAuditOps      []string
 )
 
 var editCmd = &cobra.Command{
@@ -78,7 +88,19 @@ Examples:
   dfsctl share edit /archive --quota-bytes 10GiB
 
   # Remove quota (set to unlimited)
-  dfsctl share edit /archive --quota-bytes 0`,
+  dfsctl share edit /archive --quota-bytes 0
+
+  # Cap remote uploads at 20 MB/s during business hours, unlimited otherwise
+  dfsctl share edit /archive --bandwidth-schedule \
+    '[{"days":["mon","tue","wed","thu","fri"],"start":"08:00","end":"18:00","upload":"20MB"}]'
+
+  # Re-warm the 1000 hottest files of the last 3 days after every restart
+  # and eviction, and hourly to undo pressure evictions
+  dfsctl share edit /ci --warm-on-start true --warm-after-evict true \
+    --warm-interval 1h --warm-accessed-within 3d --warm-hottest 1000
+
+  # Disable the warm policy
+  dfsctl share edit /ci --warm-on-start false --warm-after-evict false --warm-interval none`,
 	Args: cobra.ExactArgs(1),
 	RunE: runEdit,
 }
@@ -102,6 +124,76 @@ func init() {
 	editCmd.Flags().StringVar(&editTrashRestrictAdm, "trash-restrict-empty-to-admin", "", "Restrict emptying the recycle bin to admins (true|false).")
 	editCmd.Flags().Int64Var(&editTrashMaxSize, "trash-max-size", -1, "Max bytes the recycle bin may hold before the reaper evicts oldest items (0 = unbounded). -1 leaves unchanged.")
 	editCmd.Flags().StringSliceVar(&editTrashExclude, "trash-exclude", nil, "Glob patterns whose deletions bypass the recycle bin (repeatable).")
+	editBandwidth.Register(editCmd.Flags())
+	editCmd.Flags().StringVar(&editWarmOnStart, "warm-on-start", "", "Warm policy: warm the selection whenever the share is loaded, e.g. after a restart (true|false)")
+	editCmd.Flags().StringVar(&editWarmAfterEvict, "warm-after-evict", "", "Warm policy: re-warm the selection after an eviction (true|false)")
+	editCmd.Flags().StringVar(&editWarmInterval, "warm-interval", "", `Warm policy: re-warm the selection periodically (e.g., 1h, 1d); "none" disables`)
+	editCmd.Flags().StringArrayVar(&editWarmPaths, "warm-path", nil, `Warm policy: select files matching this glob relative to the share root (repeatable); "none" clears`)
+	editCmd.Flags().StringVar(&editWarmAccessed, "warm-accessed-within", "", `Warm policy: select files read within this window (e.g., 7d); "none" clears`)
+	editCmd.Flags().IntVar(&editWarmHottest, "warm-hottest", -1, "Warm policy: select at most the N most frequently read files (0 = no cap). -1 leaves unchanged.")
+	editCmd.Flags().StringVar(&editFileAudit, "file-audit", "", "Enable/disable the file-access audit trail for this share (true|false). Applied live.")
+	editCmd.Flags().StringSliceVar(&editFileAuditOps, "file-audit-ops", nil, `Audited operations: open, close, read, write, create, delete, rename, set_acl, set_owner; "default" restores the default set. Applied live.`)
+}
+
+// warmPolicyFlagsChanged reports whether any --warm-* flag was set.
+func warmPolicyFlagsChanged(cmd *cobra.Command) bool {
+	for _, name := range []string{"warm-on-start", "warm-after-evict", "warm-interval", "warm-path", "warm-accessed-within", "warm-hottest"} {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyWarmPolicyFlags merges the --warm-* flags that were set into current.
+func applyWarmPolicyFlags(cmd *cobra.Command, current block.WarmPolicy) (block.WarmPolicy, error) {
+	p := current
+	if cmd.Flags().Changed("warm-on-start") {
+		val := strings.ToLower(strings.TrimSpace(editWarmOnStart))
+		if val != "true" && val != "false" {
+			return p, fmt.Errorf("--warm-on-start: invalid value %q, must be true or false", editWarmOnStart)
+		}
+		p.OnStart = val == "true"
+	}
+	if cmd.Flags().Changed("warm-after-evict") {
+		val := strings.ToLower(strings.TrimSpace(editWarmAfterEvict))
+		if val != "true" && val != "false" {
+			return p, fmt.Errorf("--warm-after-evict: invalid value %q, must be true or false", editWarmAfterEvict)
+		}
+		p.AfterEvict = val == "true"
+	}
+	if cmd.Flags().Changed("warm-interval") {
+		p.Interval = clearableFlag(editWarmInterval)
+	}
+	if cmd.Flags().Changed("warm-path") {
+		p.Paths = nil
+		for _, glob := range editWarmPaths {
+			if clearableFlag(glob) != "" {
+				p.Paths = append(p.Paths, glob)
+			}
+		}
+	}
+	if cmd.Flags().Changed("warm-accessed-within") {
+		p.AccessedWithin = clearableFlag(editWarmAccessed)
+	}
+	if cmd.Flags().Changed("warm-hottest") {
+		if editWarmHottest < 0 {
+			return p, fmt.Errorf("--warm-hottest: must be >= 0 (0 = no cap)")
+		}
+		p.Hottest = editWarmHottest
+	}
+	if err := p.Validate(); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// clearableFlag maps the "none" sentinel (any case) to the empty string.
+func clearableFlag(v string) string {
+	if v = strings.TrimSpace(v); strings.EqualFold(v, "none") {
+		return ""
+	}
+	return v
 }
 
 func runEdit(cmd *cobra.Command, args []string) error {
@@ -125,7 +217,11 @@ func runEdit(cmd *cobra.Command, args []string) error {
 		cmd.Flags().Changed("trash-retention-days") ||
 		cmd.Flags().Changed("trash-restrict-empty-to-admin") ||
 		cmd.Flags().Changed("trash-max-size") ||
-		cmd.Flags().Changed("trash-exclude")
+		cmd.Flags().Changed("trash-exclude") ||
+		editBandwidth.Changed(cmd.Flags()) ||
+		warmPolicyFlagsChanged(cmd) ||
+		cmd.Flags().Changed("file-audit") ||
+		cmd.Flags().Changed("file-audit-ops")
 
 	// If no flags provided, run interactive mode
 	if !hasFlags {
@@ -256,8 +352,64 @@ func runEdit(cmd *cobra.Command, args []string) error {
 		hasUpdate = true
 	}
 
+	if editBandwidth.Changed(cmd.Flags()) {
+		// The server replaces the whole policy, so merge into the current one
+		// to let --upload-limit alone keep an existing schedule.
+		current, err := client.GetShare(name)
+		if err != nil {
+			return fmt.Errorf("failed to get share: %w", err)
+		}
+		var base block.BandwidthPolicy
+		if current.Bandwidth != nil {
+			base = *current.Bandwidth
+		}
+		policy, err := editBandwidth.Apply(cmd.Flags(), base)
+		if err != nil {
+			return err
+		}
+		req.Bandwidth = &policy
+		hasUpdate = true
+	}
+
+	if warmPolicyFlagsChanged(cmd) {
+		// Like bandwidth, the server replaces the whole policy.
+		current, err := client.GetShare(name)
+		if err != nil {
+			return fmt.Errorf("failed to get share: %w", err)
+		}
+		var base block.WarmPolicy
+		if current.WarmPolicy != nil {
+			base = *current.WarmPolicy
+		}
+		policy, err := applyWarmPolicyFlags(cmd, base)
+		if err != nil {
+			return err
+		}
+		req.WarmPolicy = &policy
+		hasUpdate = true
+	}
+
+	if editFileAudit != "" {
+		val := strings.ToLower(strings.TrimSpace(editFileAudit))
+		if val != "true" && val != "false" {
+			return fmt.Errorf("--file-audit: invalid value %q, must be true or false", editFileAudit)
+		}
+		enabled := val == "true"
+		req.FileAuditEnabled = &enabled
+		hasUpdate = true
+	}
+
+	if cmd.Flags().Changed("file-audit-ops") {
+		ops := editFileAuditOps
+		if len(ops) == 1 && ops[0] == "default" {
+			ops = []string{}
+		}
+		req.FileAuditOperations = &ops
+		hasUpdate = true
+	}
+
 	if !hasUpdate {
-		return fmt.Errorf("no fields specified. Use --local, --remote, --read-only, --default-permission, --description, --retention, --retention-ttl, --local-store-size, --read-buffer-size, --quota-bytes, --acl-canonicalize-inherited, --access-based-enumeration, --enable-trash, --trash-retention-days, --trash-restrict-empty-to-admin, --trash-max-size, or --trash-exclude")
+		return fmt.Errorf("no fields specified. Use --local, --remote, --read-only, --default-permission, --description, --retention, --retention-ttl, --local-store-size, --read-buffer-size, --quota-bytes, --acl-canonicalize-inherited, --access-based-enumeration, --enable-trash, --trash-retention-days, --trash-restrict-empty-to-admin, --trash-max-size, --trash-exclude, --upload-limit, --download-limit, --bandwidth-schedule, --file-audit, --file-audit-ops, or a --warm-* flag")
 	}
 
 	share, err := client.UpdateShare(name, req)
@@ -0,0 +1,102 @@
+package share
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/marmos91/dittofs/internal/cli/output"
+	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/spf13/cobra"
+)
+
+var (
+	eventsTypes      string
+	eventsPathPrefix string
+	eventsCursor     string
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events <name>",
+	Short: "Follow a share's change events",
+	Long: `Stream a share's change events (created, removed, renamed, modified)
+until interrupted. Requires change events to be enabled on the server
+(events.enabled).
+
+A dropped connection is resumed from the last event received. A "reset"
+event means the stream could not resume (the server restarted or the events
+left its buffer) and the share should be rescanned. Modified events are
+published once a file has been quiet for the server's settle window.
+
+Examples:
+  # Follow every change on a share
+  dfsctl share events /media
+
+  # Only new and renamed files under /incoming, one JSON object per line
+  dfsctl share events /media --types created,renamed --path-prefix /incoming -o json
+
+  # Resume from a cursor printed earlier
+  dfsctl share events /media --cursor 1a2b3c-42`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEvents,
+}
+
+func init() {
+	eventsCmd.Flags().StringVar(&eventsTypes, "types", "", "Comma-separated event types to follow (created,removed,renamed,modified)")
+	eventsCmd.Flags().StringVar(&eventsPathPrefix, "path-prefix", "", "Only follow events at or below this share-relative path")
+	eventsCmd.Flags().StringVar(&eventsCursor, "cursor", "", "Resume after this event ID")
+}
+
+func runEvents(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+	format, err := cmdutil.GetOutputFormatParsed()
+	if err != nil {
+		return err
+	}
+
+	opts := apiclient.ShareEventsOptions{PathPrefix: eventsPathPrefix, Cursor: eventsCursor}
+	if eventsTypes != "" {
+		opts.Types = strings.Split(eventsTypes, ",")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	enc := json.NewEncoder(os.Stdout)
+	err = client.StreamShareEvents(ctx, name, opts, func(ev apiclient.ShareEvent) error {
+		if format != output.FormatTable {
+			return enc.Encode(ev)
+		}
+		printShareEvent(ev)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to stream events: %w", err)
+	}
+	return nil
+}
+
+func printShareEvent(ev apiclient.ShareEvent) {
+	switch ev.Type {
+	case "reset":
+		fmt.Printf("-- stream reset at %s: rescan the share --\n", ev.ID)
+	case "renamed":
+		fmt.Printf("%s  %-8s  %s -> %s\n", ev.Time.Local().Format("15:04:05"), ev.Type, ev.OldPath, ev.Path)
+	default:
+		line := fmt.Sprintf("%s  %-8s  %s", ev.Time.Local().Format("15:04:05"), ev.Type, ev.Path)
+		if ev.FileType == "file" && ev.Type != "removed" {
+			line += fmt.Sprintf("  (%d bytes)", ev.Size)
+		}
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,128 @@
+package share
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/marmos91/dittofs/internal/cli/output"
+	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateMetadataTo  string
+	migrateMetadataYes bool
+)
+
+var migrateMetadataCmd = &cobra.Command{
+	Use:   "migrate-metadata <name>",
+	Short: "Move a share's metadata to another metadata store (offline)",
+	Long: `Move a share's metadata to another metadata store, for example from
+badger or sqlite to postgres.
+
+This is an offline migration: the share must be disabled first and stays
+unavailable to clients until it is enabled again. The downtime grows with the
+number of files in the share.
+
+Every file, directory, xattr, ACL, persisted lock, durable handle and block
+record of the share is streamed into the target store, verified, and only then
+is the share switched over. Block data is not touched. File handles are
+preserved except the share root, so NFS clients should remount afterwards. On
+success the share is removed from the previous store.
+
+The target store must not already hold a share with the same name. Snapshots taken on a different engine cannot be
+restored after the move; take a new snapshot once the share is back.
+
+Examples:
+  # Move /archive onto the postgres store "pg-ha"
+  dfsctl share disable /archive
+  dfsctl share migrate-metadata /archive --to pg-ha
+  dfsctl share enable /archive
+
+  # Without prompt
+  dfsctl share migrate-metadata /archive --to pg-ha --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMigrateMetadata,
+}
+
+func init() {
+	migrateMetadataCmd.Flags().StringVar(&migrateMetadataTo, "to", "", "Target metadata store name or ID (required)")
+	migrateMetadataCmd.Flags().BoolVar(&migrateMetadataYes, "yes", false, "Skip confirmation prompt")
+	_ = migrateMetadataCmd.MarkFlagRequired("to")
+}
+
+func runMigrateMetadata(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	// Pre-flight: refuse on enabled share with the same hint as restore.
+	s, err := client.GetShare(name)
+	if err != nil {
+		return fmt.Errorf("failed to look up share: %w", err)
+	}
+	if s.Enabled {
+		fmt.Fprintf(os.Stderr, "share %s is enabled; run 'dfsctl share disable %s' first\n", name, name)
+		return errors.New("share is enabled")
+	}
+
+	prompt := fmt.Sprintf("Move the metadata of share %s to store %s?", name, migrateMetadataTo)
+	ok, err := cmdutil.ConfirmDestructive(prompt, migrateMetadataYes)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	res, err := client.MigrateShareMetadata(name, migrateMetadataTo)
+	if err != nil {
+		return fmt.Errorf("failed to migrate share metadata: %w", err)
+	}
+
+	format, err := cmdutil.GetOutputFormatParsed()
+	if err != nil {
+		return err
+	}
+	switch format {
+	case output.FormatJSON:
+		return output.PrintJSON(os.Stdout, res)
+	case output.FormatYAML:
+		return output.PrintYAML(os.Stdout, res)
+	default:
+		return printMigrationResult(res)
+	}
+}
+
+func printMigrationResult(res *apiclient.MetadataMigrationResult) error {
+	fmt.Printf("Moved share %s from %s to %s.\n\n", res.Share, res.From, res.To)
+	st := res.Stats
+	pairs := [][2]string{
+		{"Directories", strconv.FormatInt(st.Directories, 10)},
+		{"Files", strconv.FormatInt(st.Files, 10)},
+		{"Entries", strconv.FormatInt(st.Entries, 10)},
+		{"Xattrs", strconv.FormatInt(st.Xattrs, 10)},
+		{"Chunks", strconv.FormatInt(st.Chunks, 10)},
+		{"Block records", strconv.FormatInt(st.BlockRecords, 10)},
+		{"Locks", strconv.FormatInt(st.Locks, 10)},
+		{"Durable handles", strconv.FormatInt(st.DurableHandles, 10)},
+		{"Duration", fmt.Sprintf("%dms", res.DurationMS)},
+	}
+	if err := output.SimpleTable(os.Stdout, pairs); err != nil {
+		return err
+	}
+	if res.RootHandleChanged {
+		fmt.Println("\nThe share root handle changed; NFS clients must remount.")
+	}
+	for _, w := range res.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	return nil
+}
@@ -26,7 +26,8 @@ var nfsConfigCmd = &cobra.Command{
 This controls protocol-specific NFS export settings such as the squash mode,
 auth flavor, and the netgroup that restricts which clients may access the
 export. Netgroup changes take effect immediately; other fields apply on the
-next adapter restart.
+next adapter restart. Directories inside the share can be exported with their
+own rules via 'nfs-config export'.
 
 Examples:
   # Show a share's NFS config
@@ -0,0 +1,183 @@
+package share
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/marmos91/dittofs/internal/bytesize"
+	"github.com/marmos91/dittofs/internal/cli/output"
+	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/spf13/cobra"
+)
+
+// Terminal re-home job states (mirror shares.RehomeState*).
+const (
+	rehomeStateDone     = "done"
+	rehomeStateFailed   = "failed"
+	rehomeStateCanceled = "canceled"
+)
+
+var (
+	rehomeTo     string
+	rehomeMode   string
+	rehomeWatch  bool
+	rehomeStatus bool
+	rehomeYes    bool
+)
+
+var rehomeCmd = &cobra.Command{
+	Use:   "rehome <name>",
+	Short: "Move a share's data to another remote block store",
+	Long: `Move a share's block data to another remote block store while the share
+keeps serving, for example from MinIO to S3 or between buckets.
+
+The move runs as a server-side job in three phases:
+
+  copying      blocks are moved while the share still writes to the old
+               remote; reads of blocks already moved are served from the
+               new one
+  switching    the share is rebound onto the new remote
+  catching_up  blocks written during the first phase are moved; reads of
+               blocks not yet moved are served from the old remote
+
+Two modes are available. "copy" moves the packed blocks byte-for-byte and
+requires both remotes to have the same compression and encryption settings.
+"repack" reads every live chunk and packs it again through the new remote's
+settings; it is refused when another share on the same metadata store uses the
+old remote. Without --mode the server picks copy when it can.
+
+A failed or interrupted job keeps serving from both remotes; run the command
+again with the same --to (or restart the server) to resume it. Objects on the
+old remote are left in place.
+
+Examples:
+  # Move /archive from its MinIO remote onto "s3-prod" and follow progress
+  dfsctl share rehome /archive --to s3-prod --watch
+
+  # Force a repack, without prompt
+  dfsctl share rehome /archive --to s3-prod --mode repack --yes
+
+  # Show the progress of the current job
+  dfsctl share rehome /archive --status`,
+	Args: cobra.ExactArgs(1),
+	RunE: runShareRehome,
+}
+
+func init() {
+	rehomeCmd.Flags().StringVar(&rehomeTo, "to", "", "Target remote block store name or ID")
+	rehomeCmd.Flags().StringVar(&rehomeMode, "mode", "", "Move mode: copy or repack (default: copy when possible)")
+	rehomeCmd.Flags().BoolVar(&rehomeWatch, "watch", false, "Poll the job until it reaches a terminal state")
+	rehomeCmd.Flags().BoolVar(&rehomeStatus, "status", false, "Show the share's current re-home job instead of starting one")
+	rehomeCmd.Flags().BoolVar(&rehomeYes, "yes", false, "Skip confirmation prompt")
+}
+
+func runShareRehome(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	var job *apiclient.RehomeJob
+	if rehomeStatus {
+		if job, err = client.GetShareRehome(name); err != nil {
+			return fmt.Errorf("failed to get re-home job: %w", err)
+		}
+	} else {
+		if rehomeTo == "" {
+			return errors.New("--to is required")
+		}
+		prompt := fmt.Sprintf("Move the block data of share %s to remote %s?", name, rehomeTo)
+		ok, err := cmdutil.ConfirmDestructive(prompt, rehomeYes)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Aborted.")
+			return nil
+		}
+		if job, err = client.StartShareRehome(name, rehomeTo, rehomeMode); err != nil {
+			return fmt.Errorf("failed to start re-home: %w", err)
+		}
+	}
+
+	if rehomeWatch {
+		return watchRehome(client, name)
+	}
+
+	format, err := cmdutil.GetOutputFormatParsed()
+	if err != nil {
+		return err
+	}
+	switch format {
+	case output.FormatJSON:
+		return output.PrintJSON(os.Stdout, job)
+	case output.FormatYAML:
+		return output.PrintYAML(os.Stdout, job)
+	default:
+		return printRehomeJob(job)
+	}
+}
+
+func printRehomeJob(job *apiclient.RehomeJob) error {
+	p := job.Progress
+	pairs := [][2]string{
+		{"Job", job.ID},
+		{"Share", job.Share},
+		{"From", job.From},
+		{"To", job.To},
+		{"Mode", job.Mode},
+		{"State", job.State},
+		{"Phase", job.Phase},
+		{"Blocks", fmt.Sprintf("%d/%d", p.BlocksDone, p.BlocksTotal)},
+		{"Moved", fmt.Sprintf("%d (%s)", p.BlocksMoved, bytesize.ByteSize(p.BytesMoved).String())},
+		{"Already present", strconv.FormatInt(p.BlocksPresent, 10)},
+		{"Missing", strconv.FormatInt(p.BlocksMissing, 10)},
+		{"Chunks repacked", strconv.FormatInt(p.ChunksRepacked, 10)},
+	}
+	if job.Resumed {
+		pairs = append(pairs, [2]string{"Resumed", "yes"})
+	}
+	if job.Error != "" {
+		pairs = append(pairs, [2]string{"Error", job.Error})
+	}
+	return output.SimpleTable(os.Stdout, pairs)
+}
+
+// watchRehome polls the share's re-home job every second, rendering progress
+// until a terminal state. It returns a non-nil error on failed/canceled jobs
+// so the process exits non-zero.
+func watchRehome(client *apiclient.Client, shareName string) error {
+	for {
+		job, err := client.GetShareRehome(shareName)
+		if err != nil {
+			return fmt.Errorf("failed to poll re-home job: %w", err)
+		}
+		p := job.Progress
+
+		fmt.Printf("\r%s: %d/%d blocks (%s moved)        ",
+			job.Phase, p.BlocksDone, p.BlocksTotal, bytesize.ByteSize(p.BytesMoved).String())
+
+		switch job.State {
+		case rehomeStateDone:
+			fmt.Printf("\rshare %s now uses remote %s — done                \n", job.Share, job.To)
+			if p.BlocksMissing > 0 {
+				fmt.Fprintf(os.Stderr, "warning: %d blocks were missing on the old remote\n", p.BlocksMissing)
+			}
+			return nil
+		case rehomeStateFailed:
+			fmt.Println()
+			return fmt.Errorf("re-home failed: %s", job.Error)
+		case rehomeStateCanceled:
+			fmt.Println()
+			return fmt.Errorf("re-home canceled: %s", job.Error)
+		}
+
+		time.Sleep(1 * time.Second)
+	}
+}
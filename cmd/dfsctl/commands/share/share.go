@@ -40,6 +40,15 @@ Examples:
   # Re-enable a share
   dfsctl share enable /archive
 
+  # Move a disabled share onto another metadata store
+  dfsctl share migrate-metadata /archive --to pg-ha
+
+  # Move a share's block data onto another remote while it keeps serving
+  dfsctl share rehome /archive --to s3-prod --watch
+
+  # Follow a share's change events
+  dfsctl share events /media --types created
+
   # Remove a share
   dfsctl share remove /archive
 
@@ -66,6 +75,9 @@ func init() {
 	Cmd.AddCommand(disableCmd)
 	Cmd.AddCommand(enableCmd)
 	Cmd.AddCommand(warmCmd)
+	Cmd.AddCommand(migrateMetadataCmd)
+	Cmd.AddCommand(rehomeCmd)
+	Cmd.AddCommand(eventsCmd)
 
 	Cmd.AddCommand(nfsConfigCmd) // per-share NFS adapter config (squash, netgroup, auth)
 }
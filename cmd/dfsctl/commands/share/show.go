@@ -130,6 +130,15 @@ func (sd ShareDetail) Rows() [][]string {
 		)
 	}
 
+	rows = append(rows, []string{"File Audit", fmt.Sprintf("%v", s.FileAuditEnabled)})
+	if s.FileAuditEnabled {
+		ops := "default (open, create, delete, rename, set_acl, set_owner)"
+		if len(s.FileAuditOperations) > 0 {
+			ops = strings.Join(s.FileAuditOperations, ", ")
+		}
+		rows = append(rows, []string{"File Audit Operations", ops})
+	}
+
 	rows = append(rows,
 		[]string{"Created", s.CreatedAt.Format("2006-01-02 15:04:05")},
 		[]string{"Updated", s.UpdatedAt.Format("2006-01-02 15:04:05")},
@@ -9,6 +9,7 @@ import (
 	"github.com/marmos91/dittofs/internal/bytesize"
 	"github.com/marmos91/dittofs/internal/cli/output"
 	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/marmos91/dittofs/pkg/block"
 	"github.com/spf13/cobra"
 )
 
@@ -25,8 +26,25 @@ var warmCmd = &cobra.Command{
 	Long: `Proactively materialize a share's blocks onto the local disk tier.
 
 Starts an asynchronous job that downloads every remote block of the share into
-the local cache so subsequent reads are served locally. The command prints the
-job id and exits; use --watch to poll until the job completes.
+the local cache so subsequent reads are served locally. Blocks already held
+locally are skipped. The command prints the job id and exits; use --watch to
+poll until the job completes.
+
+The selection flags narrow the warm to part of the share; when several are
+given a file must satisfy all of them:
+
+  --path             glob relative to the share root; "**" matches any number
+                     of directories (repeatable, a file matching any glob is
+                     selected)
+  --accessed-within  files read within this window ("72h", "7d")
+  --hottest          the N most frequently read files
+
+Recency and frequency come from the share's read history, which the server
+keeps across restarts. A file never read through this server is neither recent
+nor hot.
+
+To re-warm automatically after a restart or eviction, set a warm policy with
+"dfsctl share edit --warm-on-start ...".
 
 The share must have a remote tier configured. A pinned share with a bounded
 local tier may fail with a disk-full error if its working set exceeds the tier.
@@ -36,13 +54,22 @@ Examples:
   dfsctl share warm /archive
 
   # Start and follow progress until done
-  dfsctl share warm --watch /archive`,
+  dfsctl share warm --watch /archive
+
+  # Warm the textures of every project
+  dfsctl share warm /renders --path 'projects/*/textures/**'
+
+  # Warm the 500 hottest files read in the last week
+  dfsctl share warm /ci --accessed-within 7d --hottest 500`,
 	Args: cobra.ExactArgs(1),
 	RunE: runShareWarm,
 }
 
 func init() {
 	warmCmd.Flags().Bool("watch", false, "Poll the job until it reaches a terminal state")
+	warmCmd.Flags().StringArray("path", nil, "Warm files matching this glob relative to the share root (repeatable)")
+	warmCmd.Flags().String("accessed-within", "", "Warm files read within this window (e.g. 72h, 7d)")
+	warmCmd.Flags().Int("hottest", 0, "Warm at most the N most frequently read files")
 }
 
 func runShareWarm(cmd *cobra.Command, args []string) error {
@@ -53,8 +80,15 @@ func runShareWarm(cmd *cobra.Command, args []string) error {
 
 	shareName := args[0]
 	watch, _ := cmd.Flags().GetBool("watch")
+	var sel block.WarmSelector
+	sel.Paths, _ = cmd.Flags().GetStringArray("path")
+	sel.AccessedWithin, _ = cmd.Flags().GetString("accessed-within")
+	sel.Hottest, _ = cmd.Flags().GetInt("hottest")
+	if err := sel.Validate(); err != nil {
+		return err
+	}
 
-	jobID, err := client.StartShareWarm(shareName)
+	jobID, err := client.StartShareWarm(shareName, sel)
 	if err != nil {
 		return fmt.Errorf("failed to start warm job: %w", err)
 	}
@@ -97,6 +131,9 @@ func watchWarm(client *apiclient.Client, shareName, jobID string) error {
 			fmt.Printf("\rfetched %d/%d blocks (%s) — done\n",
 				status.BlocksDone, status.BlocksTotal,
 				bytesize.ByteSize(status.BytesDone).String())
+			if status.Selector != nil {
+				fmt.Printf("%d files selected\n", status.FilesSelected)
+			}
 			if status.Warning != "" {
 				fmt.Fprintf(os.Stderr, "warning: %s\n", status.Warning)
 			}
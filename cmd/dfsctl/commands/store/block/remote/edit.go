@@ -8,6 +8,7 @@ import (
 	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
 	"github.com/marmos91/dittofs/internal/cli/prompt"
 	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/marmos91/dittofs/pkg/block"
 	"github.com/spf13/cobra"
 )
 
@@ -21,6 +22,7 @@ var (
 	editAccessKey       string
 	editSecretKey       string
 	editParallelUploads int
+	editBandwidth       cmdutil.BandwidthFlags
 )
 
 var editCmd = &cobra.Command{
@@ -39,7 +41,10 @@ Examples:
   dfsctl store block remote edit s3-store --config '{"bucket":"new-bucket"}'
 
   # Update S3 settings
-  dfsctl store block remote edit s3-store --bucket new-bucket --region us-west-2`,
+  dfsctl store block remote edit s3-store --bucket new-bucket --region us-west-2
+
+  # Cap uploads to this remote (shared by every share using it) at 20 MB/s
+  dfsctl store block remote edit s3-store --upload-limit 20MB`,
 	Args: cobra.ExactArgs(1),
 	RunE: runEdit,
 }
@@ -53,6 +58,7 @@ func init() {
 	editCmd.Flags().StringVar(&editAccessKey, "access-key", "", "AWS access key ID (for s3)")
 	editCmd.Flags().StringVar(&editSecretKey, "secret-key", "", "AWS secret access key (for s3)")
 	editCmd.Flags().IntVar(&editParallelUploads, "parallel-uploads", 0, "Max parallel chunk uploads to this remote (0 = adaptive: auto-tune to saturate the uplink)")
+	editBandwidth.Register(editCmd.Flags())
 }
 
 func runEdit(cmd *cobra.Command, args []string) error {
@@ -71,7 +77,7 @@ func runEdit(cmd *cobra.Command, args []string) error {
 	hasFlags := cmd.Flags().Changed("type") || cmd.Flags().Changed("config") ||
 		cmd.Flags().Changed("bucket") || cmd.Flags().Changed("region") || cmd.Flags().Changed("endpoint") ||
 		cmd.Flags().Changed("access-key") || cmd.Flags().Changed("secret-key") ||
-		cmd.Flags().Changed("parallel-uploads")
+		cmd.Flags().Changed("parallel-uploads") || editBandwidth.Changed(cmd.Flags())
 
 	if !hasFlags {
 		return runEditInteractive(client, name, current)
@@ -87,7 +93,7 @@ func runEdit(cmd *cobra.Command, args []string) error {
 		}
 		req.Config = config
 		hasUpdate = true
-	} else if editBucket != "" || editRegion != "" || editEndpoint != "" || editAccessKey != "" || editSecretKey != "" || cmd.Flags().Changed("parallel-uploads") {
+	} else if editBucket != "" || editRegion != "" || editEndpoint != "" || editAccessKey != "" || editSecretKey != "" || cmd.Flags().Changed("parallel-uploads") || editBandwidth.Changed(cmd.Flags()) {
 		var currentConfig map[string]any
 		if len(current.Config) > 0 {
 			_ = json.Unmarshal(current.Config, &currentConfig)
@@ -121,6 +127,21 @@ func runEdit(cmd *cobra.Command, args []string) error {
 				delete(currentConfig, "parallel_uploads")
 			}
 		}
+		if editBandwidth.Changed(cmd.Flags()) {
+			current, err := block.ParseBandwidthPolicy(currentConfig["bandwidth"])
+			if err != nil {
+				return fmt.Errorf("current bandwidth config: %w", err)
+			}
+			policy, err := editBandwidth.Apply(cmd.Flags(), current)
+			if err != nil {
+				return err
+			}
+			if policy.IsZero() {
+				delete(currentConfig, "bandwidth")
+			} else {
+				currentConfig["bandwidth"] = policy
+			}
+		}
 		req.Config = currentConfig
 		hasUpdate = true
 	}
@@ -131,7 +152,7 @@ func runEdit(cmd *cobra.Command, args []string) error {
 	}
 
 	if !hasUpdate {
-		return fmt.Errorf("no update fields specified. Use --type, --config, --bucket, --region, --endpoint, --access-key, --secret-key, or --parallel-uploads")
+		return fmt.Errorf("no update fields specified. Use --type, --config, --bucket, --region, --endpoint, --access-key, --secret-key, --parallel-uploads, --upload-limit, --download-limit, or --bandwidth-schedule")
 	}
 
 	store, err := client.UpdateBlockStore("remote", name, req)
@@ -213,6 +234,9 @@ func runEditInteractive(client *apiclient.Client, name string, current *apiclien
 		if v, ok := currentConfig["parallel_uploads"]; ok {
 			newConfig["parallel_uploads"] = v
 		}
+		if v, ok := currentConfig["bandwidth"]; ok {
+			newConfig["bandwidth"] = v
+		}
 
 		req.Config = newConfig
 		hasUpdate = true
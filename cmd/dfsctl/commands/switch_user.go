@@ -87,7 +87,7 @@ func runSwitchUser(cmd *cobra.Command, args []string) error {
 	client := apiclient.New(serverURL)
 	fmt.Printf("Authenticating as %s on %s...\n", username, serverURL)
 
-	tokens, err := client.Login(username, password)
+	tokens, err := loginWithPassword(client, username, password, "")
 	if err != nil {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
@@ -0,0 +1,83 @@
+package token
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/spf13/cobra"
+)
+
+var listServiceAccount string
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API tokens",
+	Long: `List API tokens. Secrets are never shown; each row shows the token ID (for
+revoke), its name, service account, display prefix, scopes, expiry and when
+it was last used.
+
+Examples:
+  # List all API tokens
+  dfsctl token list
+
+  # List tokens of one service account
+  dfsctl token list --service-account terraform
+
+  # Output as JSON
+  dfsctl token list -o json`,
+	RunE: runList,
+}
+
+func init() {
+	listCmd.Flags().StringVar(&listServiceAccount, "service-account", "", "Only list tokens of this service account")
+}
+
+// TokenList is a list of API tokens for table rendering.
+type TokenList []apiclient.APIToken
+
+// Headers implements TableRenderer.
+func (tl TokenList) Headers() []string {
+	return []string{"ID", "NAME", "SERVICE ACCOUNT", "PREFIX", "SCOPES", "EXPIRES", "LAST USED"}
+}
+
+// Rows implements TableRenderer.
+func (tl TokenList) Rows() [][]string {
+	rows := make([][]string, 0, len(tl))
+	for _, t := range tl {
+		rows = append(rows, []string{
+			t.ID,
+			t.Name,
+			t.ServiceAccount,
+			t.Prefix + "...",
+			strings.Join(t.Scopes, ","),
+			formatTime(t.ExpiresAt, "never"),
+			formatTime(t.LastUsedAt, "never"),
+		})
+	}
+	return rows
+}
+
+func formatTime(t *time.Time, fallback string) string {
+	if t == nil {
+		return fallback
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	tokens, err := client.ListAPITokens(listServiceAccount)
+	if err != nil {
+		return fmt.Errorf("failed to list API tokens: %w", err)
+	}
+
+	return cmdutil.PrintOutput(os.Stdout, tokens, len(tokens) == 0, "No API tokens found.", TokenList(tokens))
+}
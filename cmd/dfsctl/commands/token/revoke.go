@@ -0,0 +1,46 @@
+package token
+
+import (
+	"fmt"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+var revokeForce bool
+
+var revokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revoke an API token",
+	Long: `Revoke an API token by ID (see "dfsctl token list"). The token stops
+working immediately; other tokens of the same service account are not
+affected. You will be prompted for confirmation unless --force is specified.
+
+Examples:
+  # Revoke a token (prompts for confirmation)
+  dfsctl token revoke 3f2b6c1e-...
+
+  # Revoke without confirmation (for rotation scripts)
+  dfsctl token revoke 3f2b6c1e-... --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRevoke,
+}
+
+func init() {
+	revokeCmd.Flags().BoolVarP(&revokeForce, "force", "f", false, "Skip confirmation prompt")
+}
+
+func runRevoke(cmd *cobra.Command, args []string) error {
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	id := args[0]
+	return cmdutil.RunDeleteWithConfirmation("API token", id, revokeForce, func() error {
+		if err := client.RevokeAPIToken(id); err != nil {
+			return fmt.Errorf("failed to revoke API token: %w", err)
+		}
+		return nil
+	})
+}
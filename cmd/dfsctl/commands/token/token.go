@@ -0,0 +1,42 @@
+// Package token implements service-account API token commands for dfsctl.
+package token
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Cmd is the parent command for API token management.
+var Cmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage service-account API tokens",
+	Long: `Manage long-lived API tokens for automation such as CI pipelines and
+Terraform. Each token is bound to a service account, carries a set of scopes
+that limit which API areas it can reach, and may have an expiry. Tokens are
+stored hashed: the secret is shown once, when the token is created.
+
+Service accounts are created on first use and cannot log in with a password.
+Revoking a token affects no other token or account, so credentials can be
+rotated independently of human users.
+
+Use a token by exporting it, together with the server URL:
+
+  export DITTOFS_SERVER=https://dittofs.example.com:8080
+  export DITTOFS_TOKEN=dfs_...
+
+Examples:
+  # Create a token that can manage shares and take snapshots, valid 90 days
+  dfsctl token create --service-account terraform --name ci \
+    --scopes shares:write,snapshots:write --expires-in 90d
+
+  # List tokens of one service account
+  dfsctl token list --service-account terraform
+
+  # Revoke a token
+  dfsctl token revoke 3f2b6c1e-...`,
+}
+
+func init() {
+	Cmd.AddCommand(createCmd)
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(revokeCmd)
+}
@@ -15,7 +15,7 @@ var getCmd = &cobra.Command{
 	Long: `Get detailed information about a specific user on the DittoFS server.
 Accepts either the username or the user's full ID (see 'dfsctl user list').
 The output includes the user's role, UID, group memberships, account status,
-and last-login timestamp. Use -o json or -o yaml for machine-readable output.
+TOTP enrollment, lockout, and last-login timestamp. Use -o json or -o yaml for machine-readable output.
 
 Examples:
   # Show user details as a table
@@ -56,6 +56,11 @@ func (ul SingleUserList) Rows() [][]string {
 		uidStr = fmt.Sprintf("%d", *u.UID)
 	}
 
+	locked := "No"
+	if u.LockedUntil != nil {
+		locked = "Until " + u.LockedUntil.Format("2006-01-02 15:04:05")
+	}
+
 	lastLogin := "Never"
 	if u.LastLogin != nil {
 		lastLogin = u.LastLogin.Format("2006-01-02 15:04:05")
@@ -71,6 +76,8 @@ func (ul SingleUserList) Rows() [][]string {
 		{"Groups", groups},
 		{"Enabled", cmdutil.BoolToYesNo(u.Enabled)},
 		{"Must Change Password", cmdutil.BoolToYesNo(u.MustChangePassword)},
+		{"TOTP", cmdutil.BoolToYesNo(u.TOTPEnabled)},
+		{"Locked", locked},
 		{"Created", u.CreatedAt.Format("2006-01-02 15:04:05")},
 		{"Last Login", lastLogin},
 	}
@@ -0,0 +1,166 @@
+package user
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/marmos91/dittofs/internal/cli/output"
+	"github.com/marmos91/dittofs/internal/cli/prompt"
+	"github.com/marmos91/dittofs/pkg/apiclient"
+	"github.com/spf13/cobra"
+)
+
+var totpCode string
+
+var totpCmd = &cobra.Command{
+	Use:   "totp",
+	Short: "Manage TOTP two-factor authentication",
+	Long: `Manage TOTP (RFC 6238) one-time codes as a second factor for API login.
+Once enrolled, "dfsctl login" asks for a code from the authenticator app after
+the password. SMB logins are not affected: NTLM has no second factor.
+
+Examples:
+  # Enroll the current user (prints the secret and otpauth:// URI, then asks for a code)
+  dfsctl user totp enroll
+
+  # Remove the current user's enrollment (requires a current code)
+  dfsctl user totp disable
+
+  # Remove a user's enrollment after a lost device (admin operation)
+  dfsctl user totp reset alice`,
+}
+
+var totpEnrollCmd = &cobra.Command{
+	Use:   "enroll",
+	Short: "Enroll the current user in TOTP",
+	Long: `Generate a TOTP secret for the current user and confirm it with a code.
+Add the printed secret or otpauth:// URI to an authenticator app, then enter
+the code it shows. The code is not required at login until it is confirmed.
+
+Examples:
+  # Enroll interactively
+  dfsctl user totp enroll
+
+  # Print the enrollment as JSON and confirm later with --code
+  dfsctl user totp enroll -o json
+  dfsctl user totp enroll --code 123456`,
+	Args: cobra.NoArgs,
+	RunE: runTOTPEnroll,
+}
+
+var totpDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Remove the current user's TOTP enrollment",
+	Long: `Remove the current user's TOTP enrollment. Requires a current code.
+
+Examples:
+  # Disable TOTP (prompts for a code)
+  dfsctl user totp disable
+
+  # Disable TOTP non-interactively
+  dfsctl user totp disable --code 123456`,
+	Args: cobra.NoArgs,
+	RunE: runTOTPDisable,
+}
+
+var totpResetCmd = &cobra.Command{
+	Use:   "reset <username>",
+	Short: "Remove a user's TOTP enrollment (admin)",
+	Long: `Remove a user's TOTP enrollment without a code, e.g. after a lost
+device (admin operation). The user can enroll again after the next login.
+
+Examples:
+  # Reset a user's TOTP enrollment
+  dfsctl user totp reset alice`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTOTPReset,
+}
+
+func init() {
+	totpEnrollCmd.Flags().StringVar(&totpCode, "code", "", "Confirm a pending enrollment with this code")
+	totpDisableCmd.Flags().StringVar(&totpCode, "code", "", "Current one-time code (prompts if not provided)")
+	totpCmd.AddCommand(totpEnrollCmd)
+	totpCmd.AddCommand(totpDisableCmd)
+	totpCmd.AddCommand(totpResetCmd)
+}
+
+func runTOTPEnroll(cmd *cobra.Command, args []string) error {
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	// --code confirms an enrollment started earlier (e.g. with -o json).
+	if totpCode != "" {
+		return confirmTOTP(client, totpCode)
+	}
+
+	enrollment, err := client.EnrollTOTP()
+	if err != nil {
+		return fmt.Errorf("failed to enroll TOTP: %w", err)
+	}
+
+	format, err := cmdutil.GetOutputFormatParsed()
+	if err != nil {
+		return err
+	}
+	if format != output.FormatTable {
+		return cmdutil.PrintResource(os.Stdout, enrollment, nil)
+	}
+
+	fmt.Println("Add this account to your authenticator app:")
+	fmt.Printf("  Secret: %s\n", enrollment.Secret)
+	fmt.Printf("  URI:    %s\n\n", enrollment.URI)
+
+	code, err := prompt.InputRequired("Code from the app")
+	if err != nil {
+		return cmdutil.HandleAbort(err)
+	}
+	return confirmTOTP(client, code)
+}
+
+func confirmTOTP(client *apiclient.Client, code string) error {
+	if err := client.ConfirmTOTP(code); err != nil {
+		return fmt.Errorf("failed to confirm TOTP: %w", err)
+	}
+	cmdutil.PrintSuccessWithInfo("TOTP enabled",
+		"API logins now require a one-time code after the password.")
+	return nil
+}
+
+func runTOTPDisable(cmd *cobra.Command, args []string) error {
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	code := totpCode
+	if code == "" {
+		code, err = prompt.InputRequired("One-time code")
+		if err != nil {
+			return cmdutil.HandleAbort(err)
+		}
+	}
+
+	if err := client.DisableTOTP(code); err != nil {
+		return fmt.Errorf("failed to disable TOTP: %w", err)
+	}
+	cmdutil.PrintSuccess("TOTP disabled")
+	return nil
+}
+
+func runTOTPReset(cmd *cobra.Command, args []string) error {
+	username := args[0]
+
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	if err := client.ResetUserTOTP(username); err != nil {
+		return fmt.Errorf("failed to reset TOTP: %w", err)
+	}
+	cmdutil.PrintSuccess(fmt.Sprintf("TOTP enrollment removed for user '%s'", username))
+	return nil
+}
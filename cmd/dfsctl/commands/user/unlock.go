@@ -0,0 +1,39 @@
+package user
+
+import (
+	"fmt"
+
+	"github.com/marmos91/dittofs/cmd/dfsctl/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock <username>",
+	Short: "Unlock a user locked out after failed logins",
+	Long: `Unlock a user account locked out after too many failed logins (admin
+operation). Lockouts also expire on their own after accounts.lockout.duration;
+this clears one early and resets the failed-login count. The lockout covers
+both API logins and SMB session setup.
+
+Examples:
+  # Unlock a user
+  dfsctl user unlock alice`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUnlock,
+}
+
+func runUnlock(cmd *cobra.Command, args []string) error {
+	username := args[0]
+
+	client, err := cmdutil.GetAuthenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	if err := client.UnlockUser(username); err != nil {
+		return fmt.Errorf("failed to unlock user: %w", err)
+	}
+
+	cmdutil.PrintSuccess(fmt.Sprintf("User '%s' unlocked", username))
+	return nil
+}
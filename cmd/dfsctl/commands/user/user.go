@@ -12,8 +12,9 @@ var Cmd = &cobra.Command{
 	Long: `Manage local user accounts on the DittoFS server. Local users are
 distinct from identities resolved via Kerberos or LDAP — they are accounts
 stored in the DittoFS control plane and used for direct authentication.
-Most subcommands require admin privileges; "change-password" operates on the
-currently authenticated account and is available to all users.
+Most subcommands require admin privileges; "change-password" and "totp
+enroll/disable" operate on the currently authenticated account and are
+available to all users.
 
 Examples:
   # List all registered users
@@ -31,6 +32,12 @@ Examples:
   # Reset a user's password as an admin
   dfsctl user password alice
 
+  # Unlock a user locked out after failed logins
+  dfsctl user unlock alice
+
+  # Enroll the current user in TOTP two-factor login
+  dfsctl user totp enroll
+
   # Remove a user (prompts for confirmation)
   dfsctl user remove alice`,
 }
@@ -43,4 +50,6 @@ func init() {
 	Cmd.AddCommand(editCmd)
 	Cmd.AddCommand(passwordCmd)
 	Cmd.AddCommand(changePasswordCmd)
+	Cmd.AddCommand(unlockCmd)
+	Cmd.AddCommand(totpCmd)
 }
@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// layoutKeySetting names the metadata-store CustomSettings entry holding the
+// base64 HMAC key that signs pNFS layout credentials. It lives in the shared
+// metadata store because that is the only state the metadata server and its
+// data servers have in common.
+const layoutKeySetting = "nfs.pnfs.layout_key"
+
+// layoutKeyTTL bounds how long a data server serves a cached key, and so how
+// long a fenced credential keeps working on a data server other than the one
+// that rotated the key.
+const layoutKeyTTL = time.Second
+
+// layoutUIDBit marks a synthetic layout uid. The remaining 31 bits carry a
+// 15-bit per-layout nonce and 16 MAC bits; the gid carries 31 more MAC bits.
+const layoutUIDBit = 0x80000000
+
+// maxLayoutNonce keeps the uid below 0xFFFF0000 so it never collides with
+// the (uint32)-1 / -2 "nobody" conventions.
+const maxLayoutNonce = 0x7FFF
+
+// ErrInvalidLayoutCredential is returned by Verify for a layout uid whose
+// gid does not match: forged, issued under a fenced key, or issued before the
+// file's mode or ownership changed.
+var ErrInvalidLayoutCredential = errors.New("invalid pNFS layout credential")
+
+// LayoutCredentials issues and verifies the synthetic AUTH_SYS credentials a
+// pNFS Flex Files layout hands to clients for data-server I/O (RFC 8435
+// Section 2.2).
+//
+// Each credential is an HMAC over the file handle, the layout iomode, a
+// per-layout nonce and the file's permission bits and ownership, keyed by a
+// secret kept in the metadata store. It therefore authorizes only the one
+// file and iomode it was issued for, stops working as soon as the file is
+// chmod'ed or chown'ed, and is fenced wholesale by rotating the key.
+//
+// Safe for concurrent use. The metadata server and the NFSv3/NFSv4 data
+// server paths of one process share a single instance so that a fence is
+// visible locally at once; other processes pick it up within layoutKeyTTL.
+type LayoutCredentials struct {
+	mu   sync.Mutex
+	keys map[metadata.Store]layoutKeyEntry
+}
+
+// layoutKeyEntry is a cached key; key is nil when the store has none.
+type layoutKeyEntry struct {
+	key      []byte
+	loadedAt time.Time
+}
+
+// NewLayoutCredentials creates an empty credential issuer/verifier.
+func NewLayoutCredentials() *LayoutCredentials {
+	return &LayoutCredentials{keys: make(map[metadata.Store]layoutKeyEntry)}
+}
+
+// IsLayoutUID reports whether an AUTH_SYS uid has the shape of a synthetic
+// layout credential. Such uids are reserved on stores that have issued
+// layouts; Verify decides whether the store has.
+func IsLayoutUID(uid uint32) bool {
+	return uid&layoutUIDBit != 0 && uid < 0xFFFF0000
+}
+
+// LayoutNonce derives the per-layout nonce from a layout stateid's "other"
+// field.
+func LayoutNonce(other []byte) uint16 {
+	sum := sha256.Sum256(other)
+	return binary.BigEndian.Uint16(sum[:2]) % maxLayoutNonce
+}
+
+// Issue returns the uid/gid pair a client presents to the data servers for
+// I/O on file under a layout of the given iomode. The store's key is created
+// on first use.
+func (c *LayoutCredentials) Issue(ctx context.Context, store metadata.Store, handle []byte, file *metadata.File, write bool, nonce uint16) (uid, gid uint32, err error) {
+	key, err := c.key(ctx, store, true)
+	if err != nil {
+		return 0, 0, err
+	}
+	uid, gid = layoutCredential(key, handle, file, write, nonce%maxLayoutNonce)
+	return uid, gid, nil
+}
+
+// Verify checks a layout credential presented to a data server and returns
+// the access it carries. It returns (nil, nil) when the store has never
+// issued layouts, in which case uid is an ordinary user id, and
+// ErrInvalidLayoutCredential when the credential does not match.
+func (c *LayoutCredentials) Verify(ctx context.Context, store metadata.Store, handle []byte, file *metadata.File, uid, gid uint32) (*metadata.LayoutGrant, error) {
+	key, err := c.key(ctx, store, false)
+	if err != nil || key == nil {
+		return nil, err
+	}
+	nonce := uint16((uid &^ layoutUIDBit) >> 16)
+	for _, write := range []bool{true, false} {
+		wantUID, wantGID := layoutCredential(key, handle, file, write, nonce)
+		if hmac.Equal(packCredential(wantUID, wantGID), packCredential(uid, gid)) {
+			return &metadata.LayoutGrant{Handle: metadata.FileHandle(handle), Write: write}, nil
+		}
+	}
+	return nil, ErrInvalidLayoutCredential
+}
+
+// VerifyHandle is Verify for a data-server request: it resolves the owning
+// store and the current file from the handle. Same results as Verify.
+func (c *LayoutCredentials) VerifyHandle(ctx context.Context, metaSvc *metadata.Service, handle metadata.FileHandle, uid, gid uint32) (*metadata.LayoutGrant, error) {
+	shareName, _, err := metadata.DecodeFileHandle(handle)
+	if err != nil {
+		return nil, err
+	}
+	store, err := metaSvc.GetStoreForShare(shareName)
+	if err != nil {
+		return nil, err
+	}
+	// Skip the file fetch on stores that never issued a layout.
+	if key, err := c.key(ctx, store, false); err != nil || key == nil {
+		return nil, err
+	}
+	file, err := store.GetFile(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	return c.Verify(ctx, store, handle, file, uid, gid)
+}
+
+// LayoutIdentity is the identity a request authorized by a layout
+// credential runs as. The synthetic uid doubles as the gid so the request
+// can never match a real file owner or group.
+func LayoutIdentity(uid uint32) *metadata.Identity {
+	return &metadata.Identity{
+		UID:      &uid,
+		GID:      &uid,
+		Username: fmt.Sprintf("pnfs-layout:%d", uid),
+	}
+}
+
+// Fence rotates the store's key, invalidating every layout credential issued
+// for it. Clients still holding layouts get access errors from the data
+// servers and fetch fresh layouts from the metadata server (RFC 8435
+// Section 2.2).
+func (c *LayoutCredentials) Fence(ctx context.Context, store metadata.Store) error {
+	var key []byte
+	err := store.WithTransaction(ctx, func(tx metadata.Transaction) error {
+		var err error
+		key, err = storeLayoutKey(ctx, tx, nil)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("rotate layout key: %w", err)
+	}
+	c.mu.Lock()
+	c.keys[store] = layoutKeyEntry{key: key, loadedAt: time.Now()}
+	c.mu.Unlock()
+	return nil
+}
+
+// key returns the store's layout key, from cache while it is fresh. With
+// create set, a missing key is generated and persisted.
+func (c *LayoutCredentials) key(ctx context.Context, store metadata.Store, create bool) ([]byte, error) {
+	c.mu.Lock()
+	entry, ok := c.keys[store]
+	c.mu.Unlock()
+	if ok && time.Since(entry.loadedAt) < layoutKeyTTL && (entry.key != nil || !create) {
+		return entry.key, nil
+	}
+
+	cfg, err := store.GetServerConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load layout key: %w", err)
+	}
+	key := decodeLayoutKey(cfg.CustomSettings)
+	if key == nil && create {
+		err := store.WithTransaction(ctx, func(tx metadata.Transaction) error {
+			cfg, err := tx.GetServerConfig(ctx)
+			if err != nil {
+				return err
+			}
+			// Another issuer may have created it since the read above.
+			if key = decodeLayoutKey(cfg.CustomSettings); key != nil {
+				return nil
+			}
+			key, err = storeLayoutKey(ctx, tx, &cfg)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create layout key: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	c.keys[store] = layoutKeyEntry{key: key, loadedAt: time.Now()}
+	c.mu.Unlock()
+	return key, nil
+}
+
+// storeLayoutKey generates a new key and writes it into the server config.
+// cfg is the config already read in this transaction, or nil to read it.
+func storeLayoutKey(ctx context.Context, tx metadata.Transaction, cfg *metadata.MetadataServerConfig) ([]byte, error) {
+	if cfg == nil {
+		current, err := tx.GetServerConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cfg = &current
+	}
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	settings := make(map[string]any, len(cfg.CustomSettings)+1)
+	for k, v := range cfg.CustomSettings {
+		settings[k] = v
+	}
+	settings[layoutKeySetting] = base64.StdEncoding.EncodeToString(key)
+	cfg.CustomSettings = settings
+	if err := tx.SetServerConfig(ctx, *cfg); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// decodeLayoutKey extracts the key from CustomSettings, or nil when it is
+// missing or malformed.
+func decodeLayoutKey(settings map[string]any) []byte {
+	encoded, ok := settings[layoutKeySetting].(string)
+	if !ok {
+		return nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != sha256.Size {
+		return nil
+	}
+	return key
+}
+
+// layoutCredential computes the uid/gid pair for one file, iomode and nonce.
+// Only the permission bits of the mode are bound: a write by a non-owner
+// clears setuid/setgid, which must not invalidate the writer's own layout.
+func layoutCredential(key, handle []byte, file *metadata.File, write bool, nonce uint16) (uid, gid uint32) {
+	mac := hmac.New(sha256.New, key)
+	var buf [19]byte
+	if write {
+		buf[0] = 1
+	}
+	binary.BigEndian.PutUint16(buf[1:3], nonce)
+	binary.BigEndian.PutUint32(buf[3:7], file.Mode&0o777)
+	binary.BigEndian.PutUint32(buf[7:11], file.UID)
+	binary.BigEndian.PutUint32(buf[11:15], file.GID)
+	binary.BigEndian.PutUint32(buf[15:19], uint32(len(handle)))
+	mac.Write(buf[:])
+	mac.Write(handle)
+	sum := mac.Sum(nil)
+
+	uid = layoutUIDBit | uint32(nonce)<<16 | uint32(binary.BigEndian.Uint16(sum[0:2]))
+	gid = binary.BigEndian.Uint32(sum[2:6]) &^ layoutUIDBit
+	return uid, gid
+}
+
+// packCredential packs a uid/gid pair for constant-time comparison.
+func packCredential(uid, gid uint32) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint32(b[0:4], uid)
+	binary.BigEndian.PutUint32(b[4:8], gid)
+	return b[:]
+}
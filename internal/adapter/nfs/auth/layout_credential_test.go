@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/marmos91/dittofs/pkg/metadata/store/memory"
+)
+
+func TestLayoutCredentials_IssueVerify(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryMetadataStoreWithDefaults()
+	creds := NewLayoutCredentials()
+	handle := []byte("share-a:file-1")
+	file := &metadata.File{FileAttr: metadata.FileAttr{Mode: 0o640, UID: 1000, GID: 1000}}
+
+	// A store that never issued a layout treats high uids as ordinary.
+	if grant, err := creds.Verify(ctx, store, handle, file, 0x80001234, 42); grant != nil || err != nil {
+		t.Fatalf("Verify before any issue = (%v, %v), want (nil, nil)", grant, err)
+	}
+
+	ruid, rgid, err := creds.Issue(ctx, store, handle, file, false, 7)
+	if err != nil {
+		t.Fatalf("Issue(read): %v", err)
+	}
+	wuid, wgid, err := creds.Issue(ctx, store, handle, file, true, 7)
+	if err != nil {
+		t.Fatalf("Issue(rw): %v", err)
+	}
+	if !IsLayoutUID(ruid) || !IsLayoutUID(wuid) {
+		t.Fatalf("issued uids %#x/%#x are not layout uids", ruid, wuid)
+	}
+	if ruid == wuid && rgid == wgid {
+		t.Fatal("read and rw credentials are identical")
+	}
+
+	grant, err := creds.Verify(ctx, store, handle, file, ruid, rgid)
+	if err != nil || grant == nil || grant.Write {
+		t.Fatalf("Verify(read) = (%+v, %v), want read-only grant", grant, err)
+	}
+	grant, err = creds.Verify(ctx, store, handle, file, wuid, wgid)
+	if err != nil || grant == nil || !grant.Write {
+		t.Fatalf("Verify(rw) = (%+v, %v), want write grant", grant, err)
+	}
+
+	// The credential is bound to the file it was issued for.
+	if _, err := creds.Verify(ctx, store, []byte("share-a:file-2"), file, wuid, wgid); !errors.Is(err, ErrInvalidLayoutCredential) {
+		t.Fatalf("Verify(other file) err = %v, want ErrInvalidLayoutCredential", err)
+	}
+	// A forged gid is rejected.
+	if _, err := creds.Verify(ctx, store, handle, file, wuid, wgid^1); !errors.Is(err, ErrInvalidLayoutCredential) {
+		t.Fatalf("Verify(forged) err = %v, want ErrInvalidLayoutCredential", err)
+	}
+
+	// A data server with its own cache reads the same key from the store.
+	if grant, err := NewLayoutCredentials().Verify(ctx, store, handle, file, wuid, wgid); err != nil || grant == nil {
+		t.Fatalf("Verify on second instance = (%v, %v)", grant, err)
+	}
+}
+
+func TestLayoutCredentials_ModeAndOwnerChangeInvalidate(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryMetadataStoreWithDefaults()
+	creds := NewLayoutCredentials()
+	handle := []byte("share-a:file-1")
+	file := &metadata.File{FileAttr: metadata.FileAttr{Mode: 0o4664, UID: 1000, GID: 1000}}
+
+	uid, gid, err := creds.Issue(ctx, store, handle, file, true, 1)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	// Clearing setuid on write must not invalidate the writer's layout.
+	cleared := *file
+	cleared.Mode = 0o664
+	if _, err := creds.Verify(ctx, store, handle, &cleared, uid, gid); err != nil {
+		t.Fatalf("Verify after setuid clear: %v", err)
+	}
+
+	chmodded := *file
+	chmodded.Mode = 0o4644
+	if _, err := creds.Verify(ctx, store, handle, &chmodded, uid, gid); !errors.Is(err, ErrInvalidLayoutCredential) {
+		t.Fatalf("Verify after chmod err = %v, want ErrInvalidLayoutCredential", err)
+	}
+	chowned := *file
+	chowned.UID = 1001
+	if _, err := creds.Verify(ctx, store, handle, &chowned, uid, gid); !errors.Is(err, ErrInvalidLayoutCredential) {
+		t.Fatalf("Verify after chown err = %v, want ErrInvalidLayoutCredential", err)
+	}
+}
+
+func TestLayoutCredentials_Fence(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryMetadataStoreWithDefaults()
+	creds := NewLayoutCredentials()
+	handle := []byte("share-a:file-1")
+	file := &metadata.File{FileAttr: metadata.FileAttr{Mode: 0o644, UID: 1000, GID: 1000}}
+
+	uid, gid, err := creds.Issue(ctx, store, handle, file, true, 1)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if err := creds.Fence(ctx, store); err != nil {
+		t.Fatalf("Fence: %v", err)
+	}
+	if _, err := creds.Verify(ctx, store, handle, file, uid, gid); !errors.Is(err, ErrInvalidLayoutCredential) {
+		t.Fatalf("Verify after fence err = %v, want ErrInvalidLayoutCredential", err)
+	}
+
+	uid2, gid2, err := creds.Issue(ctx, store, handle, file, true, 1)
+	if err != nil {
+		t.Fatalf("Issue after fence: %v", err)
+	}
+	if grant, err := creds.Verify(ctx, store, handle, file, uid2, gid2); err != nil || grant == nil {
+		t.Fatalf("Verify of reissued credential = (%v, %v)", grant, err)
+	}
+}
+
+func TestIsLayoutUID(t *testing.T) {
+	for _, tc := range []struct {
+		uid  uint32
+		want bool
+	}{
+		{0, false},
+		{1000, false},
+		{65534, false},
+		{0x7FFFFFFF, false},
+		{0x80000000, true},
+		{0xFFFEFFFF, true},
+		{0xFFFFFFFE, false},
+		{0xFFFFFFFF, false},
+	} {
+		if got := IsLayoutUID(tc.uid); got != tc.want {
+			t.Errorf("IsLayoutUID(%#x) = %v, want %v", tc.uid, got, tc.want)
+		}
+	}
+}
@@ -102,6 +102,9 @@ type DispatchDeps struct {
 	// NSMHandler dispatches NSM (Network Status Monitor) procedure calls.
 	NSMHandler NSMDispatcher
 
+	// RQuotaHandler dispatches rquota (remote quota) procedure calls.
+	RQuotaHandler RQuotaDispatcher
+
 	// PortmapHandler dispatches portmapper procedure calls.
 	PortmapHandler PortmapDispatcher
 
@@ -129,6 +132,12 @@ type NSMDispatcher interface {
 	DispatchNSM(ctx context.Context, call *rpc.RPCCallMessage, data []byte, clientAddr string) ([]byte, error)
 }
 
+// RQuotaDispatcher is the interface for rquota procedure dispatch.
+type RQuotaDispatcher interface {
+	// DispatchRQuota dispatches an rquota procedure call.
+	DispatchRQuota(ctx context.Context, call *rpc.RPCCallMessage, data []byte, clientAddr string) ([]byte, error)
+}
+
 // PortmapDispatcher is the interface for portmapper procedure dispatch.
 type PortmapDispatcher interface {
 	// DispatchPortmap dispatches a portmapper procedure call.
@@ -143,6 +152,8 @@ type PortmapDispatcher interface {
 //   - 100005 (Mount) -> dispatchMount() -> mount procedure dispatch
 //   - 100021 (NLM) -> deps.NLMHandler.DispatchNLM()
 //   - 100024 (NSM) -> deps.NSMHandler.DispatchNSM()
+//   - 100227 (NFSACL) -> dispatchNFSACL() -> NFSACL v3 procedure dispatch
+//   - 100011 (RQUOTA) -> deps.RQuotaHandler.DispatchRQuota()
 //   - 100000 (Portmap) -> deps.PortmapHandler.DispatchPortmap()
 //   - default -> PROG_UNAVAIL error
 //
@@ -176,6 +187,12 @@ func Dispatch(ctx context.Context, call *rpc.RPCCallMessage, data []byte, client
 	case rpc.ProgramNSM:
 		return dispatchNSM(ctx, call, data, clientAddr, deps)
 
+	case rpc.ProgramNFSACL:
+		return dispatchNFSACL(ctx, call, data, clientAddr, deps)
+
+	case rpc.ProgramRQuota:
+		return dispatchRQuota(ctx, call, data, clientAddr, deps)
+
 	case rpc.ProgramPortmap:
 		return dispatchPortmap(ctx, call, data, clientAddr, deps)
 
@@ -329,6 +346,65 @@ func dispatchNSM(ctx context.Context, call *rpc.RPCCallMessage, data []byte, cli
 	return result, nil, err
 }
 
+// dispatchNFSACL routes NFSACL calls. NFSACL v3 only; the procedures run on the
+// NFSv3 handler.
+func dispatchNFSACL(ctx context.Context, call *rpc.RPCCallMessage, data []byte, clientAddr string, deps *DispatchDeps) ([]byte, []byte, error) {
+	if call.Version != rpc.NFSACLVersion3 {
+		logger.Warn("Unsupported NFSACL version",
+			"requested", call.Version,
+			"supported", rpc.NFSACLVersion3,
+			"xid", fmt.Sprintf("0x%x", call.XID),
+			"client", clientAddr)
+
+		mismatchReply, makeErr := rpc.MakeProgMismatchReply(call.XID, rpc.NFSACLVersion3, rpc.NFSACLVersion3)
+		if makeErr != nil {
+			return nil, nil, fmt.Errorf("make version mismatch reply: %w", makeErr)
+		}
+		return nil, mismatchReply, nil
+	}
+
+	procedure, ok := NfsACLDispatchTable[call.Procedure]
+	if !ok {
+		logger.Debug("Unknown NFSACL procedure", "procedure", call.Procedure)
+		return []byte{}, nil, nil
+	}
+
+	handlerCtx := middleware.ExtractHandlerContext(ctx, call, clientAddr, "", procedure.Name)
+
+	result, err := procedure.Handler(handlerCtx, deps.V3Handler, deps.Registry, data)
+	if result == nil {
+		return nil, nil, err
+	}
+	return result.Data, nil, err
+}
+
+// dispatchRQuota routes rquota calls. v1 (user quotas) and v2 (user and group
+// quotas) are accepted.
+func dispatchRQuota(ctx context.Context, call *rpc.RPCCallMessage, data []byte, clientAddr string, deps *DispatchDeps) ([]byte, []byte, error) {
+	if call.Version != rpc.RQuotaVersion1 && call.Version != rpc.RQuotaVersion2 {
+		logger.Warn("Unsupported RQUOTA version",
+			"requested", call.Version,
+			"supported_low", rpc.RQuotaVersion1,
+			"supported_high", rpc.RQuotaVersion2,
+			"xid", fmt.Sprintf("0x%x", call.XID),
+			"client", clientAddr)
+
+		mismatchReply, makeErr := rpc.MakeProgMismatchReply(call.XID, rpc.RQuotaVersion1, rpc.RQuotaVersion2)
+		if makeErr != nil {
+			return nil, nil, fmt.Errorf("make version mismatch reply: %w", makeErr)
+		}
+		return nil, mismatchReply, nil
+	}
+
+	if deps.RQuotaHandler == nil {
+		logger.Debug("RQUOTA handler not available", "client", clientAddr)
+		return []byte{}, nil, nil
+	}
+
+	result, err := deps.RQuotaHandler.DispatchRQuota(ctx, call, data, clientAddr)
+	return result, nil, err
+}
+
 // dispatchPortmap routes portmapper calls.
 func dispatchPortmap(ctx context.Context, call *rpc.RPCCallMessage, data []byte, clientAddr string, deps *DispatchDeps) ([]byte, []byte, error) {
 	if deps.PortmapHandler == nil {
@@ -389,6 +465,10 @@ type nfsProcedure struct {
 // version routing in Dispatch().
 var NfsDispatchTable map[uint32]*nfsProcedure
 
+// NfsACLDispatchTable maps NFSACL v3 procedure numbers to their handlers.
+// NFSACL procedures share the NFSv3 handler signature (see nfsProcedure).
+var NfsACLDispatchTable map[uint32]*nfsProcedure
+
 // mountProcedureHandler defines the signature for Mount procedure handlers.
 //
 // **Return Values:**
@@ -421,5 +501,6 @@ var MountDispatchTable map[uint32]*mountProcedure
 // This is called once at package initialization time.
 func init() {
 	initNFSDispatchTable()
+	initNFSACLDispatchTable()
 	initMountDispatchTable()
 }
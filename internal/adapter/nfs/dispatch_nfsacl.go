@@ -0,0 +1,74 @@
+package nfs
+
+import (
+	"github.com/marmos91/dittofs/internal/adapter/nfs/types"
+	nfs "github.com/marmos91/dittofs/internal/adapter/nfs/v3/handlers"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
+)
+
+// ============================================================================
+// NFSACL Dispatch Table Initialization
+// ============================================================================
+
+func initNFSACLDispatchTable() {
+	NfsACLDispatchTable = map[uint32]*nfsProcedure{
+		types.NFSACLProcNull: {
+			Name:    "NULL",
+			Handler: handleNFSNull,
+		},
+		types.NFSACLProcGetACL: {
+			Name:    "GETACL",
+			Handler: handleNFSACLGetACL,
+		},
+		types.NFSACLProcSetACL: {
+			Name:    "SETACL",
+			Handler: handleNFSACLSetACL,
+		},
+	}
+}
+
+// ============================================================================
+// NFSACL Procedure Handlers
+// ============================================================================
+//
+// NFSACL procedures are served by the NFSv3 handler: they address objects by
+// NFSv3 file handle and share its auth context, so they follow the same
+// pattern as the NFS procedure handlers.
+
+func handleNFSACLGetACL(
+	ctx *nfs.NFSHandlerContext,
+	handler *nfs.Handler,
+	reg *runtime.Runtime,
+	data []byte,
+) (*HandlerResult, error) {
+	return handleRequest(
+		data,
+		nfs.DecodeGetACLRequest,
+		func(req *nfs.GetACLRequest) (*nfs.GetACLResponse, error) {
+			return handler.GetACL(ctx, req)
+		},
+		types.NFS3ErrAccess,
+		func(status uint32) *nfs.GetACLResponse {
+			return &nfs.GetACLResponse{NFSResponseBase: nfs.NFSResponseBase{Status: status}}
+		},
+	)
+}
+
+func handleNFSACLSetACL(
+	ctx *nfs.NFSHandlerContext,
+	handler *nfs.Handler,
+	reg *runtime.Runtime,
+	data []byte,
+) (*HandlerResult, error) {
+	return handleRequest(
+		data,
+		nfs.DecodeSetACLRequest,
+		func(req *nfs.SetACLRequest) (*nfs.SetACLResponse, error) {
+			return handler.SetACL(ctx, req)
+		},
+		types.NFS3ErrAccess,
+		func(status uint32) *nfs.SetACLResponse {
+			return &nfs.SetACLResponse{NFSResponseBase: nfs.NFSResponseBase{Status: status}}
+		},
+	)
+}
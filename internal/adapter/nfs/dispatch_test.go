@@ -315,6 +315,26 @@ func TestDispatch_VersionNegotiation(t *testing.T) {
 			wantHigh:    rpc.NSMVersion1,
 			expectReply: true,
 		},
+		{
+			name:        "NFSACL_v2_rejected_with_PROG_MISMATCH",
+			program:     rpc.ProgramNFSACL,
+			version:     2,
+			procedure:   0,
+			wantStat:    rpc.RPCProgMismatch,
+			wantLow:     rpc.NFSACLVersion3,
+			wantHigh:    rpc.NFSACLVersion3,
+			expectReply: true,
+		},
+		{
+			name:        "RQUOTA_v3_rejected_with_PROG_MISMATCH",
+			program:     rpc.ProgramRQuota,
+			version:     3,
+			procedure:   0,
+			wantStat:    rpc.RPCProgMismatch,
+			wantLow:     rpc.RQuotaVersion1,
+			wantHigh:    rpc.RQuotaVersion2,
+			expectReply: true,
+		},
 		{
 			name:        "Mount_MNT_v1_rejected_with_PROG_MISMATCH",
 			program:     rpc.ProgramMount,
@@ -29,6 +29,8 @@ type rpcRequest interface {
 		*nfs.ReadLinkRequest |
 		*nfs.MknodRequest |
 		*nfs.CommitRequest |
+		*nfs.GetACLRequest |
+		*nfs.SetACLRequest |
 		*mount.MountRequest |
 		*mount.NullRequest |
 		*mount.UmountRequest |
@@ -60,6 +62,8 @@ type rpcResponse interface {
 		*nfs.ReadLinkResponse |
 		*nfs.MknodResponse |
 		*nfs.CommitResponse |
+		*nfs.GetACLResponse |
+		*nfs.SetACLResponse |
 		*mount.MountResponse |
 		*mount.NullResponse |
 		*mount.UmountResponse |
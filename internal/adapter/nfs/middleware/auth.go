@@ -8,6 +8,7 @@ import (
 	mount "github.com/marmos91/dittofs/internal/adapter/nfs/mount/handlers"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc/gss"
+	rquota "github.com/marmos91/dittofs/internal/adapter/nfs/rquota/handlers"
 	nfs "github.com/marmos91/dittofs/internal/adapter/nfs/v3/handlers"
 	"github.com/marmos91/dittofs/internal/logger"
 )
@@ -150,3 +151,33 @@ func ExtractMountHandlerContext(
 
 	return handlerCtx
 }
+
+// ExtractRQuotaHandlerContext creates an RQuotaHandlerContext from an RPC call
+// message. Credentials are extracted exactly as for NFS (AUTH_UNIX and
+// RPCSEC_GSS), since rquota authorizes queries against the caller's identity.
+//
+// Parameters:
+//   - ctx: The Go context for cancellation and timeout control
+//   - call: The RPC call message containing authentication data
+//   - clientAddr: The remote address of the client connection
+//   - procedure: Name of the procedure (for logging purposes)
+//
+// Returns:
+//   - *rquota.RQuotaHandlerContext with extracted authentication information
+func ExtractRQuotaHandlerContext(
+	ctx context.Context,
+	call *rpc.RPCCallMessage,
+	clientAddr string,
+	procedure string,
+) *rquota.RQuotaHandlerContext {
+	nfsCtx := ExtractHandlerContext(ctx, call, clientAddr, "", procedure)
+	return &rquota.RQuotaHandlerContext{
+		Context:    ctx,
+		ClientAddr: clientAddr,
+		AuthFlavor: nfsCtx.AuthFlavor,
+		Version:    call.Version,
+		UID:        nfsCtx.UID,
+		GID:        nfsCtx.GID,
+		GIDs:       nfsCtx.GIDs,
+	}
+}
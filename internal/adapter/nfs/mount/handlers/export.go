@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"path"
 
 	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr"
 	"github.com/marmos91/dittofs/internal/logger"
@@ -74,13 +75,19 @@ func (h *Handler) Export(
 	// Get all shares from registry
 	shareNames := h.Registry.ListShares()
 
-	// Convert to export entries
+	// Convert to export entries: each share, then its subtree exports
 	entries := make([]ExportEntry, 0, len(shareNames))
 	for _, name := range shareNames {
 		entries = append(entries, ExportEntry{
 			Directory: name,
 			Groups:    nil, // No group restrictions
 		})
+		subtrees, _ := h.Registry.ShareExports(name)
+		for i := len(subtrees) - 1; i >= 0; i-- {
+			entries = append(entries, ExportEntry{
+				Directory: path.Join(name, subtrees[i].Path),
+			})
+		}
 	}
 
 	logger.Info("Export successful", "returned", len(entries))
@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"net"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc/gss"
 	internalxdr "github.com/marmos91/dittofs/internal/adapter/nfs/xdr"
 	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
 	xdr "github.com/rasky/go-xdr/xdr2"
 )
 
@@ -94,14 +96,20 @@ func (h *Handler) Mount(
 	default:
 	}
 
-	// Check if share exists in registry
-	if !h.Registry.ShareExists(req.DirPath) {
-		logger.Warn("Mount denied", "path", req.DirPath, "client_ip", clientIP, "reason", "share not found")
-		return &MountResponse{MountResponseBase: MountResponseBase{Status: MountErrNoEnt}}, nil
+	// Resolve the path to a share and a directory in it: the share root, or a
+	// directory inside the share for a subtree mount (/share/projects/alpha).
+	target, err := h.Registry.ResolveExportPath(ctx.Context, req.DirPath)
+	if err != nil {
+		if errors.Is(err, runtime.ErrExportPathNotFound) {
+			logger.Warn("Mount denied", "path", req.DirPath, "client_ip", clientIP, "reason", "share not found")
+			return &MountResponse{MountResponseBase: MountResponseBase{Status: MountErrNoEnt}}, nil
+		}
+		logger.Error("Mount path resolution failed", "path", req.DirPath, "client_ip", clientIP, "error", err)
+		return &MountResponse{MountResponseBase: MountResponseBase{Status: MountErrServerFault}}, nil
 	}
 
 	// Get share to check read-only status and security policy
-	share, err := h.Registry.GetShare(req.DirPath)
+	share, err := h.Registry.GetShare(target.ShareName)
 	if err != nil {
 		logger.Error("Mount access check failed", "path", req.DirPath, "client_ip", clientIP, "error", err)
 		return &MountResponse{MountResponseBase: MountResponseBase{Status: MountErrServerFault}}, nil
@@ -159,8 +167,21 @@ func (h *Handler) Mount(
 			"path", req.DirPath, "client_ip", clientIP)
 		return &MountResponse{MountResponseBase: MountResponseBase{Status: MountErrAccess}}, nil
 	}
-	{
-		allowed, netErr := h.Registry.CheckNetgroupAccess(ctx.Context, req.DirPath, clientNetIP)
+	// On a share with subtree exports the allowlist comes from the export
+	// governing the mounted directory. A directory the client may only
+	// traverse (it sits above the client's own export) cannot be mounted.
+	policy, polErr := h.Registry.ResolveExportPolicy(ctx.Context, target.ShareName, target.Handle, clientNetIP)
+	switch {
+	case errors.Is(polErr, runtime.ErrExportAccessDenied) || (polErr == nil && policy != nil && policy.Traverse):
+		logger.Warn("Mount denied: client IP not allowed by export",
+			"path", req.DirPath, "client_ip", clientIP)
+		return &MountResponse{MountResponseBase: MountResponseBase{Status: MountErrAccess}}, nil
+	case polErr != nil:
+		logger.Warn("Mount export access check error",
+			"path", req.DirPath, "client_ip", clientIP, "error", polErr)
+		return &MountResponse{MountResponseBase: MountResponseBase{Status: MountErrAccess}}, nil
+	case policy == nil:
+		allowed, netErr := h.Registry.CheckNetgroupAccess(ctx.Context, target.ShareName, clientNetIP)
 		if netErr != nil {
 			logger.Warn("Mount netgroup access check error",
 				"path", req.DirPath, "client_ip", clientIP, "error", netErr)
@@ -177,12 +198,9 @@ func (h *Handler) Mount(
 	// Record the mount in the registry
 	h.Registry.RecordMount(clientIP, req.DirPath, time.Now().Unix())
 
-	// Get root handle from registry (which encodes the share and root path)
-	rootHandle, err := h.Registry.GetRootHandle(req.DirPath)
-	if err != nil {
-		logger.Error("Mount failed: cannot get root handle", "path", req.DirPath, "client_ip", clientIP, "error", err)
-		return &MountResponse{MountResponseBase: MountResponseBase{Status: MountErrServerFault}}, nil
-	}
+	// The mounted directory's handle: the share root handle, or the handle of
+	// the directory a subtree mount resolved to.
+	rootHandle := target.Handle
 
 	// Return supported authentication flavors
 	// AUTH_UNIX (1) is always supported
@@ -202,7 +220,8 @@ func (h *Handler) Mount(
 		)
 	}
 
-	logger.Info("Mount successful", "path", req.DirPath, "client_ip", clientIP, "handle_len", len(rootHandle), "auth_flavors", authFlavors, "readonly", share.ReadOnly)
+	readOnly := share.ReadOnly || (policy != nil && policy.ReadOnly)
+	logger.Info("Mount successful", "path", req.DirPath, "client_ip", clientIP, "handle_len", len(rootHandle), "auth_flavors", authFlavors, "readonly", readOnly)
 
 	return &MountResponse{
 		MountResponseBase: MountResponseBase{Status: MountOK},
@@ -162,3 +162,61 @@ func TestMount_EnabledShare_AllowsMount(t *testing.T) {
 		t.Error("FileHandle is empty, want a non-empty root handle on success")
 	}
 }
+
+// TestMount_SubtreeExport verifies a client can mount a directory inside a
+// share when it is configured as a subtree export, and gets that directory's
+// handle back rather than the share root.
+func TestMount_SubtreeExport(t *testing.T) {
+	h, ctx := newTestMountHandler(t, "/export", true)
+	rt := h.Registry.(*runtime.Runtime)
+	svc := rt.GetMetadataService()
+
+	rootHandle, err := rt.GetRootHandle("/export")
+	if err != nil {
+		t.Fatalf("GetRootHandle: %v", err)
+	}
+	authCtx := &metadata.AuthContext{
+		Context:    ctx,
+		AuthMethod: "unix",
+		Identity:   &metadata.Identity{UID: metadata.Uint32Ptr(0), GID: metadata.Uint32Ptr(0)},
+	}
+	projects, _, err := svc.CreateDirectory(authCtx, rootHandle, "projects", &metadata.FileAttr{Mode: 0o755})
+	if err != nil {
+		t.Fatalf("CreateDirectory(projects): %v", err)
+	}
+	projectsHandle, err := metadata.EncodeFileHandle(projects)
+	if err != nil {
+		t.Fatalf("EncodeFileHandle: %v", err)
+	}
+	alpha, _, err := svc.CreateDirectory(authCtx, projectsHandle, "alpha", &metadata.FileAttr{Mode: 0o755})
+	if err != nil {
+		t.Fatalf("CreateDirectory(alpha): %v", err)
+	}
+	alphaHandle, err := metadata.EncodeFileHandle(alpha)
+	if err != nil {
+		t.Fatalf("EncodeFileHandle: %v", err)
+	}
+
+	if err := rt.SetShareExports("/export", []runtime.SubtreeExport{{Path: "/projects/alpha"}}); err != nil {
+		t.Fatalf("SetShareExports: %v", err)
+	}
+
+	resp, err := h.Mount(newMountCtx(ctx), &MountRequest{DirPath: "/export/projects/alpha"})
+	if err != nil {
+		t.Fatalf("Mount returned unexpected error: %v", err)
+	}
+	if resp.Status != MountOK {
+		t.Fatalf("Status = %d, want MountOK", resp.Status)
+	}
+	if string(resp.FileHandle) != string(alphaHandle) {
+		t.Errorf("FileHandle = %x, want the subtree directory handle %x", resp.FileHandle, alphaHandle)
+	}
+
+	resp, err = h.Mount(newMountCtx(ctx), &MountRequest{DirPath: "/export/projects/missing"})
+	if err != nil {
+		t.Fatalf("Mount returned unexpected error: %v", err)
+	}
+	if resp.Status != MountErrNoEnt {
+		t.Errorf("Status = %d, want MountErrNoEnt (%d)", resp.Status, MountErrNoEnt)
+	}
+}
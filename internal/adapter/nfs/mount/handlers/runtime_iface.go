@@ -19,9 +19,12 @@ type mountRuntime interface {
 	GetRootHandle(shareName string) (metadata.FileHandle, error)
 	ListShares() []string
 	ShareExists(name string) bool
+	ShareExports(name string) ([]runtime.SubtreeExport, bool)
+	ResolveExportPath(ctx context.Context, dirPath string) (*runtime.ExportTarget, error)
 
 	// Per-client export access control.
 	CheckNetgroupAccess(ctx context.Context, shareName string, clientIP net.IP) (bool, error)
+	ResolveExportPolicy(ctx context.Context, shareName string, handle metadata.FileHandle, clientIP net.IP) (*runtime.ExportPolicy, error)
 
 	// Mount tracking (DUMP / UMNT / UMNTALL).
 	RecordMount(clientAddr, shareName string, mountTime int64)
@@ -250,9 +250,12 @@ func TestPortmapperFullServiceRegistry(t *testing.T) {
 	services := []serviceQuery{
 		{"NFS_v3_TCP", 100003, 3, types.ProtoTCP},
 		{"NFS_v4_TCP", 100003, 4, types.ProtoTCP},
+		{"NFSACL_v3_TCP", 100227, 3, types.ProtoTCP},
 		{"MOUNT_v3_TCP", 100005, 3, types.ProtoTCP},
 		{"NLM_v4_TCP", 100021, 4, types.ProtoTCP},
 		{"NSM_v1_TCP", 100024, 1, types.ProtoTCP},
+		{"RQUOTA_v1_TCP", 100011, 1, types.ProtoTCP},
+		{"RQUOTA_v2_TCP", 100011, 2, types.ProtoTCP},
 	}
 
 	for _, svc := range services {
@@ -278,8 +281,8 @@ func TestPortmapperFullServiceRegistry(t *testing.T) {
 		})
 	}
 
-	// Verify DUMP returns exactly the 9 TCP-only entries
-	t.Run("DUMP_9_entries", func(t *testing.T) {
+	// Verify DUMP returns exactly the 12 TCP-only entries
+	t.Run("DUMP_12_entries", func(t *testing.T) {
 		msg := buildRPCCall(0x30000001, types.ProgramPortmap, types.PortmapVersion2, types.ProcDump, nil)
 		reply := sendTCPRequest(t, tcpAddr, msg)
 
@@ -309,9 +312,10 @@ func TestPortmapperFullServiceRegistry(t *testing.T) {
 			count++
 		}
 
-		// 9 TCP mappings: NFS v3/v4, MOUNT v1/v2/v3, NLM v1/v3/v4, NSM v1.
-		if count != 9 {
-			t.Errorf("DUMP count: got %d, want 9", count)
+		// 12 TCP mappings: NFS v3/v4, NFSACL v3, MOUNT v1/v2/v3, NLM v1/v3/v4,
+		// NSM v1, RQUOTA v1/v2.
+		if count != 12 {
+			t.Errorf("DUMP count: got %d, want 12", count)
 		}
 	})
 }
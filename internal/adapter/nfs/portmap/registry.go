@@ -128,25 +128,29 @@ func (r *Registry) Dump() []*xdr.Mapping {
 }
 
 // RegisterDittoFSServices registers all DittoFS RPC services on the given port.
-// This populates the portmap registry with NFS, MOUNT, NLM, and NSM services.
+// This populates the portmap registry with NFS, NFSACL, MOUNT, NLM, NSM, and
+// RQUOTA services.
 //
 // NFS data operations are always TCP-only (large READ/WRITE payloads do not fit
-// a UDP datagram). The lock-manager auxiliary protocols (NLM, NSM) and MOUNT are
-// additionally advertised over UDP when udpEnabled is true, because BSD/macOS
+// a UDP datagram). The lock-manager auxiliary protocols (NLM, NSM), MOUNT, and
+// RQUOTA are additionally advertised over UDP when udpEnabled is true, because BSD/macOS
 // NFSv3 lock clients (rpc.lockd / rpc.statd) discover and reach them over UDP
 // (issue #1353). NLM is advertised as v1, v3, and v4 so both BSD/macOS (v1/v3,
 // 32-bit offsets) and Linux (v4, 64-bit) clients find a usable version.
 //
 // Always registered (TCP):
 //   - NFS (100003) v3 and v4
+//   - NFSACL (100227) v3 (POSIX ACL side protocol for NFSv3)
 //   - MOUNT (100005) v1, v2, v3 (v1/v2 for client compatibility)
 //   - NLM (100021) v1, v3, v4
 //   - NSM (100024) v1
+//   - RQUOTA (100011) v1, v2
 //
 // Additionally registered when udpEnabled (UDP):
 //   - MOUNT (100005) v1, v2, v3
 //   - NLM (100021) v1, v3, v4
 //   - NSM (100024) v1
+//   - RQUOTA (100011) v1, v2
 func (r *Registry) RegisterDittoFSServices(nfsPort int, udpEnabled bool) {
 	for _, m := range DittoFSServiceMappings(nfsPort, udpEnabled) {
 		r.Set(m)
@@ -168,10 +172,11 @@ func DittoFSServiceMappings(nfsPort int, udpEnabled bool) []*xdr.Mapping {
 		vers uint32
 	}
 
-	// TCP services. NFS is TCP-only; NLM/NSM/MOUNT are served over TCP too.
+	// TCP services. NFS is TCP-only; NLM/NSM/MOUNT/RQUOTA are served over TCP too.
 	tcpServices := []svc{
 		{100003, 3}, // NFS v3
 		{100003, 4}, // NFS v4
+		{100227, 3}, // NFSACL v3
 		{100005, 1}, // MOUNT v1 (client compatibility)
 		{100005, 2}, // MOUNT v2 (client compatibility)
 		{100005, 3}, // MOUNT v3
@@ -179,6 +184,8 @@ func DittoFSServiceMappings(nfsPort int, udpEnabled bool) []*xdr.Mapping {
 		{100021, 3}, // NLM v3 (32-bit offsets; BSD/macOS)
 		{100021, 4}, // NLM v4 (64-bit offsets; Linux)
 		{100024, 1}, // NSM v1
+		{100011, 1}, // RQUOTA v1 (user quotas)
+		{100011, 2}, // RQUOTA v2 (user and group quotas)
 	}
 
 	mappings := make([]*xdr.Mapping, 0, len(tcpServices)*2)
@@ -190,8 +197,8 @@ func DittoFSServiceMappings(nfsPort int, udpEnabled bool) []*xdr.Mapping {
 		return mappings
 	}
 
-	// UDP services: the lock-manager protocols and MOUNT only. NFS is never
-	// advertised over UDP since it is not served there.
+	// UDP services: the lock-manager protocols, MOUNT, and RQUOTA only. NFS and NFSACL
+	// are never advertised over UDP since they are not served there.
 	udpServices := []svc{
 		{100005, 1}, // MOUNT v1
 		{100005, 2}, // MOUNT v2
@@ -200,6 +207,8 @@ func DittoFSServiceMappings(nfsPort int, udpEnabled bool) []*xdr.Mapping {
 		{100021, 3}, // NLM v3
 		{100021, 4}, // NLM v4
 		{100024, 1}, // NSM v1
+		{100011, 1}, // RQUOTA v1
+		{100011, 2}, // RQUOTA v2
 	}
 	for _, s := range udpServices {
 		mappings = append(mappings, &xdr.Mapping{Prog: s.prog, Vers: s.vers, Prot: types.ProtoUDP, Port: port})
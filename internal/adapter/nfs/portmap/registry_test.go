@@ -186,10 +186,10 @@ func TestRegisterDittoFSServices(t *testing.T) {
 	r := NewRegistry()
 	r.RegisterDittoFSServices(12049, false)
 
-	// With UDP disabled: 9 TCP mappings (NFS v3/v4, MOUNT v1/v2/v3,
-	// NLM v1/v3/v4, NSM v1) and nothing on UDP.
-	if r.Count() != 9 {
-		t.Fatalf("RegisterDittoFSServices(udp=false) created %d mappings, want 9", r.Count())
+	// With UDP disabled: 12 TCP mappings (NFS v3/v4, NFSACL v3,
+	// MOUNT v1/v2/v3, NLM v1/v3/v4, NSM v1, RQUOTA v1/v2) and nothing on UDP.
+	if r.Count() != 12 {
+		t.Fatalf("RegisterDittoFSServices(udp=false) created %d mappings, want 12", r.Count())
 	}
 
 	// Verify each expected TCP registration
@@ -200,6 +200,7 @@ func TestRegisterDittoFSServices(t *testing.T) {
 	}{
 		{"NFS v3", 100003, 3},
 		{"NFS v4", 100003, 4},
+		{"NFSACL v3", 100227, 3},
 		{"MOUNT v1", 100005, 1},
 		{"MOUNT v2", 100005, 2},
 		{"MOUNT v3", 100005, 3},
@@ -207,6 +208,8 @@ func TestRegisterDittoFSServices(t *testing.T) {
 		{"NLM v3", 100021, 3},
 		{"NLM v4", 100021, 4},
 		{"NSM v1", 100024, 1},
+		{"RQUOTA v1", 100011, 1},
+		{"RQUOTA v2", 100011, 2},
 	}
 
 	for _, exp := range expectations {
@@ -227,12 +230,14 @@ func TestRegisterDittoFSServicesUDP(t *testing.T) {
 	r := NewRegistry()
 	r.RegisterDittoFSServices(12049, true)
 
-	// 9 TCP + 7 UDP (MOUNT v1/v2/v3, NLM v1/v3/v4, NSM v1). NFS stays TCP-only.
-	if r.Count() != 16 {
-		t.Fatalf("RegisterDittoFSServices(udp=true) created %d mappings, want 16", r.Count())
+	// 12 TCP + 9 UDP (MOUNT v1/v2/v3, NLM v1/v3/v4, NSM v1, RQUOTA v1/v2).
+	// NFS and NFSACL stay TCP-only.
+	if r.Count() != 21 {
+		t.Fatalf("RegisterDittoFSServices(udp=true) created %d mappings, want 21", r.Count())
 	}
 
-	// The lock-manager protocols and MOUNT must be reachable over UDP.
+	// The lock-manager protocols, MOUNT, and RQUOTA must be reachable over
+	// UDP (quota(1) queries rquota over UDP first).
 	udpExpect := []struct {
 		name       string
 		prog, vers uint32
@@ -243,6 +248,8 @@ func TestRegisterDittoFSServicesUDP(t *testing.T) {
 		{"NLM v3", 100021, 3},
 		{"NLM v4", 100021, 4},
 		{"NSM v1", 100024, 1},
+		{"RQUOTA v1", 100011, 1},
+		{"RQUOTA v2", 100011, 2},
 	}
 	for _, exp := range udpExpect {
 		if port := r.Getport(exp.prog, exp.vers, 17); port != 12049 {
@@ -254,6 +261,9 @@ func TestRegisterDittoFSServicesUDP(t *testing.T) {
 	if port := r.Getport(100003, 3, 17); port != 0 {
 		t.Errorf("NFS v3 UDP: port = %d, want 0 (NFS is TCP-only)", port)
 	}
+	if port := r.Getport(100227, 3, 17); port != 0 {
+		t.Errorf("NFSACL v3 UDP: port = %d, want 0 (NFSACL is TCP-only)", port)
+	}
 }
 
 func TestRegisterDittoFSServicesCustomPort(t *testing.T) {
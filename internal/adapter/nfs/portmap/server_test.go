@@ -681,11 +681,12 @@ func TestServerUDPDump(t *testing.T) {
 		count++
 	}
 
-	// RegisterDittoFSServices(udp=true) registers 16 entries: 9 TCP
-	// (NFS v3/v4, MOUNT v1/v2/v3, NLM v1/v3/v4, NSM v1) + 7 UDP
-	// (MOUNT v1/v2/v3, NLM v1/v3/v4, NSM v1).
-	if count != 16 {
-		t.Errorf("DUMP entry count: got %d, want 16", count)
+	// RegisterDittoFSServices(udp=true) registers 21 entries: 12 TCP
+	// (NFS v3/v4, NFSACL v3, MOUNT v1/v2/v3, NLM v1/v3/v4, NSM v1,
+	// RQUOTA v1/v2) + 9 UDP (MOUNT v1/v2/v3, NLM v1/v3/v4, NSM v1,
+	// RQUOTA v1/v2).
+	if count != 21 {
+		t.Errorf("DUMP entry count: got %d, want 21", count)
 	}
 }
 
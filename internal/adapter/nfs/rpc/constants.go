@@ -37,6 +37,17 @@ const (
 	// and restarts, NSM notifies clients so they can reclaim their locks.
 	// NSM typically listens on the same port as NFS for TCP connections.
 	ProgramNSM = 100024
+
+	// ProgramNFSACL is the NFS_ACL side protocol program number (Solaris).
+	// NFSv3 has no ACL attribute, so getfacl/setfacl on NFSv3 mounts use this
+	// separate program to read and write POSIX access/default ACLs. Like NLM,
+	// it is served on the NFS port.
+	ProgramNFSACL = 100227
+
+	// ProgramRQuota is the remote quota program number (rquotad).
+	// Userspace quota tools on NFS clients (quota(1), repquota) query it
+	// to report the caller's limits and usage on a mounted export.
+	ProgramRQuota = 100011
 )
 
 // RPC Program Versions
@@ -79,6 +90,17 @@ const (
 	// NSM v1 is the standard version used by all NFS implementations.
 	// This is the only NSM version supported by DittoFS.
 	NSMVersion1 = 1
+
+	// NFSACLVersion3 is the NFS_ACL protocol version paired with NFSv3.
+	// Version 2 (paired with NFSv2) is not supported.
+	NFSACLVersion3 = 3
+
+	// RQuotaVersion1 is the original rquota version: user quotas only.
+	RQuotaVersion1 = 1
+
+	// RQuotaVersion2 is the extended rquota version, which adds the quota
+	// type (user or group) to the request.
+	RQuotaVersion2 = 2
 )
 
 // RPC Message Types
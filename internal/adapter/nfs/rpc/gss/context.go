@@ -67,6 +67,10 @@ type GSSSessionInfo struct {
 	// Needed by the reply path to determine which wrapping to apply.
 	Service uint32
 
+	// Principal is the authenticated principal with its realm
+	// ("alice@EXAMPLE.COM").
+	Principal string
+
 	// HasAcceptorSubkey indicates the acceptor produced a subkey in the AP-REP
 	// during context establishment (mutual auth). When true, RFC 4121 §4.2.2
 	// requires FLAG_ACCEPTOR_SUBKEY on every acceptor MIC/Wrap token, including
@@ -135,6 +139,16 @@ type GSSContext struct {
 	mu sync.Mutex
 }
 
+// FullPrincipal returns the principal qualified with its realm
+// ("nfs/host.example.com@EXAMPLE.COM"), or the bare principal when the realm
+// is unknown.
+func (c *GSSContext) FullPrincipal() string {
+	if c.Realm == "" {
+		return c.Principal
+	}
+	return c.Principal + "@" + c.Realm
+}
+
 // Touch updates the LastUsed timestamp to now.
 // Thread-safe.
 func (c *GSSContext) Touch() {
@@ -331,6 +331,10 @@ type GSSProcessResult struct {
 	// Needed for reply body wrapping (integrity/privacy).
 	Service uint32
 
+	// Principal is the authenticated principal with its realm
+	// ("alice@EXAMPLE.COM"). Only set for DATA requests.
+	Principal string
+
 	// SessionKey is the session key from the GSS context.
 	// Needed for computing the reply verifier (MIC of seq_num).
 	// Only set for DATA requests.
@@ -729,6 +733,7 @@ func (p *GSSProcessor) handleData(ctx context.Context, cred *RPCGSSCredV1, verif
 		IsControl:         false,
 		SeqNum:            cred.SeqNum,
 		Service:           cred.Service,
+		Principal:         gssCtx.FullPrincipal(),
 		SessionKey:        gssCtx.SessionKey,
 		HasAcceptorSubkey: gssCtx.HasAcceptorSubkey,
 	}
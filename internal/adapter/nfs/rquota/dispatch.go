@@ -0,0 +1,107 @@
+// Package rquota provides remote quota (rquota) protocol dispatch.
+//
+// rquota is the side protocol NFS quota tools use to read and set user and
+// group quotas on a remote filesystem. This package provides the dispatch
+// table that routes rquota procedure calls to their handlers.
+package rquota
+
+import (
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rquota/handlers"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rquota/types"
+)
+
+// ============================================================================
+// Procedure Dispatch Types
+// ============================================================================
+
+// RQuotaProcedureHandler defines the signature for rquota procedure handlers.
+//
+// Parameters:
+//   - ctx: Handler context with client info, credentials, and Go context
+//   - handler: The rquota handler instance
+//   - data: Raw XDR-encoded request data
+//
+// Returns:
+//   - *handlers.HandlerResult: XDR-encoded response and status
+//   - error: Processing error (separate from rquota status)
+type RQuotaProcedureHandler func(
+	ctx *handlers.RQuotaHandlerContext,
+	handler *handlers.Handler,
+	data []byte,
+) (*handlers.HandlerResult, error)
+
+// RQuotaProcedure contains metadata about an rquota procedure for dispatch.
+type RQuotaProcedure struct {
+	// Name is the procedure name for logging (e.g., "NULL", "GETQUOTA")
+	Name string
+
+	// Handler is the function that processes this procedure
+	Handler RQuotaProcedureHandler
+}
+
+// RQuotaDispatchTable maps rquota procedure numbers to their handlers.
+// The table is shared by v1 and v2; handlers select the argument layout from
+// the call version.
+var RQuotaDispatchTable map[uint32]*RQuotaProcedure
+
+// init initializes the rquota procedure dispatch table.
+func init() {
+	initRQuotaDispatchTable()
+}
+
+// ============================================================================
+// rquota Dispatch Table Initialization
+// ============================================================================
+
+func initRQuotaDispatchTable() {
+	RQuotaDispatchTable = map[uint32]*RQuotaProcedure{
+		types.RQuotaProcNull: {
+			Name:    "NULL",
+			Handler: handleRQuotaNull,
+		},
+		types.RQuotaProcGetQuota: {
+			Name:    "GETQUOTA",
+			Handler: handleRQuotaGetQuota,
+		},
+		types.RQuotaProcGetActiveQuota: {
+			Name:    "GETACTIVEQUOTA",
+			Handler: handleRQuotaGetQuota,
+		},
+		types.RQuotaProcSetQuota: {
+			Name:    "SETQUOTA",
+			Handler: handleRQuotaSetQuota,
+		},
+		types.RQuotaProcSetActiveQuota: {
+			Name:    "SETACTIVEQUOTA",
+			Handler: handleRQuotaSetQuota,
+		},
+	}
+}
+
+// ============================================================================
+// rquota Procedure Handler Wrappers
+// ============================================================================
+
+func handleRQuotaNull(
+	ctx *handlers.RQuotaHandlerContext,
+	handler *handlers.Handler,
+	data []byte,
+) (*handlers.HandlerResult, error) {
+	return handler.Null(ctx)
+}
+
+func handleRQuotaGetQuota(
+	ctx *handlers.RQuotaHandlerContext,
+	handler *handlers.Handler,
+	data []byte,
+) (*handlers.HandlerResult, error) {
+	return handler.GetQuota(ctx, data)
+}
+
+func handleRQuotaSetQuota(
+	ctx *handlers.RQuotaHandlerContext,
+	handler *handlers.Handler,
+	data []byte,
+) (*handlers.HandlerResult, error) {
+	return handler.SetQuota(ctx, data)
+}
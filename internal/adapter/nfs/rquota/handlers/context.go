@@ -0,0 +1,41 @@
+// Package handlers provides rquota (remote quota) protocol handlers.
+//
+// rquota lets NFS clients read, and root change, the user and group quotas of
+// a DittoFS share through the standard quota tools. Quotas themselves are
+// configured and enforced by the control plane and metadata service; these
+// handlers only translate between them and the rquota wire format.
+package handlers
+
+import "context"
+
+// RQuotaHandlerContext contains context for rquota procedure handlers.
+//
+// Unlike NFS, rquota requests carry a path instead of a file handle, so the
+// share is resolved by the handler rather than extracted before dispatch.
+type RQuotaHandlerContext struct {
+	// Context is the Go context for cancellation and timeouts.
+	Context context.Context
+
+	// ClientAddr is the remote client address, used for logging.
+	ClientAddr string
+
+	// AuthFlavor is the RPC authentication flavor (AUTH_NULL, AUTH_UNIX, etc.).
+	AuthFlavor uint32
+
+	// Version is the rquota program version of the call (1 or 2). It selects
+	// the argument layout.
+	Version uint32
+
+	// UID is the caller's user ID from AUTH_UNIX or RPCSEC_GSS, if present.
+	UID *uint32
+
+	// GID is the caller's primary group ID, if present.
+	GID *uint32
+
+	// GIDs contains the caller's supplementary group IDs, if present.
+	GIDs []uint32
+
+	// UDP reports whether the call arrived over the UDP transport, where the
+	// source address (and so the export's host check) is trivially spoofed.
+	UDP bool
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"math"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rquota/types"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rquota/xdr"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// GetQuota handles rquota GETQUOTA and GETACTIVEQUOTA (procedures 1 and 2).
+// Resolves the path to a share and reports the quota of the requested user
+// (v1, v2) or group (v2): limits, live usage, and remaining soft-limit grace.
+// A user without an explicit quota reports the share's default-user quota.
+// Authorization: after identity mapping, root may query any identity; other
+// callers only their own uid or a group they belong to.
+// No side effects; read-only.
+// Errors: Q_NOQUOTA (unknown path or no quota), Q_EPERM (not permitted).
+func (h *Handler) GetQuota(ctx *RQuotaHandlerContext, data []byte) (*HandlerResult, error) {
+	args, err := xdr.DecodeGetQuotaArgs(newBytesReader(data), ctx.Version)
+	if err != nil {
+		logger.Warn("RQUOTA GETQUOTA decode error",
+			"client", ctx.ClientAddr,
+			"error", err)
+		return nil, err
+	}
+
+	logger.Debug("RQUOTA GETQUOTA request",
+		"client", ctx.ClientAddr,
+		"path", args.Path,
+		"type", args.Type,
+		"id", args.ID)
+
+	scope, ok := quotaScope(args.Type)
+	if !ok {
+		return statusOnly(ctx, types.QNoQuota)
+	}
+
+	target, err := h.rt.ResolveExportPath(ctx.Context, args.Path)
+	if err != nil {
+		logger.Debug("RQUOTA GETQUOTA unknown path",
+			"client", ctx.ClientAddr,
+			"path", args.Path,
+			"error", err)
+		return statusOnly(ctx, types.QNoQuota)
+	}
+
+	ident := h.callerIdentity(ctx, target)
+	if !mayQuery(ident, scope, args.ID) {
+		logger.Debug("RQUOTA GETQUOTA denied",
+			"client", ctx.ClientAddr,
+			"share", target.ShareName,
+			"type", args.Type,
+			"id", args.ID)
+		return statusOnly(ctx, types.QEPerm)
+	}
+
+	report, ok, err := h.rt.GetMetadataService().GetIdentityQuotaReport(target.ShareName, scope, args.ID)
+	if err != nil {
+		logger.Warn("RQUOTA GETQUOTA report failed",
+			"client", ctx.ClientAddr,
+			"share", target.ShareName,
+			"error", err)
+		return statusOnly(ctx, types.QNoQuota)
+	}
+	if !ok {
+		return statusOnly(ctx, types.QNoQuota)
+	}
+
+	return encodeResult(ctx, &types.QuotaRes{
+		Status: types.QOK,
+		Quota:  toRQuota(report),
+	})
+}
+
+// mayQuery reports whether ident may read the quota of (scope, id): root reads
+// any quota, a user reads their own, and a group member reads the group's.
+func mayQuery(ident *metadata.Identity, scope metadata.QuotaScope, id uint32) bool {
+	if ident == nil || ident.UID == nil {
+		return false
+	}
+	if isRoot(ident) {
+		return true
+	}
+	if scope == metadata.QuotaScopeUser {
+		return *ident.UID == id
+	}
+	return (ident.GID != nil && *ident.GID == id) || ident.HasGID(id)
+}
+
+// toRQuota converts a quota report to the rquota wire form.
+//
+// Byte values are reported in the smallest power-of-two block size (at least
+// 1 KiB) that keeps every block field within 32 bits, so multi-terabyte
+// quotas are not truncated. Usage rounds up; limits round down but never to
+// zero, which would read as "unlimited".
+func toRQuota(report metadata.QuotaReport) types.RQuota {
+	q := report.Quota
+
+	// rq_bsize is a signed int: 1 GiB blocks are the largest usable unit and
+	// cover any int64 byte count.
+	bsize := int64(1024)
+	for bsize < 1<<30 &&
+		(ceilDiv(report.Usage.Bytes, bsize) > math.MaxUint32 ||
+			q.LimitBytes/bsize > math.MaxUint32 ||
+			q.SoftBytes/bsize > math.MaxUint32) {
+		bsize *= 2
+	}
+
+	return types.RQuota{
+		BSize:  uint32(bsize),
+		Active: true,
+		DQBlk: types.DQBlk{
+			BHardLimit: limitBlocks(q.LimitBytes, bsize),
+			BSoftLimit: limitBlocks(q.SoftBytes, bsize),
+			CurBlocks:  clampUint32(ceilDiv(report.Usage.Bytes, bsize)),
+			FHardLimit: clampUint32(q.LimitFiles),
+			FSoftLimit: clampUint32(q.SoftFiles),
+			CurFiles:   clampUint32(report.Usage.Files),
+			BTimeLeft:  seconds(report.BytesGraceLeft),
+			FTimeLeft:  seconds(report.FilesGraceLeft),
+		},
+	}
+}
+
+// limitBlocks converts a byte limit to blocks, rounding down but keeping a
+// nonzero limit nonzero.
+func limitBlocks(limit, bsize int64) uint32 {
+	if limit <= 0 {
+		return 0
+	}
+	return clampUint32(max(limit/bsize, 1))
+}
+
+// ceilDiv divides a non-negative n by d, rounding up.
+func ceilDiv(n, d int64) int64 {
+	if n <= 0 {
+		return 0
+	}
+	q := n / d
+	if n%d != 0 {
+		q++
+	}
+	return q
+}
+
+// clampUint32 clamps a non-negative value to the uint32 range.
+func clampUint32(v int64) uint32 {
+	switch {
+	case v <= 0:
+		return 0
+	case v > math.MaxUint32:
+		return math.MaxUint32
+	default:
+		return uint32(v)
+	}
+}
+
+// seconds converts a remaining grace period to whole seconds, rounding up so
+// a running timer never reads as expired.
+func seconds(d time.Duration) uint32 {
+	return clampUint32(ceilDiv(int64(d), int64(time.Second)))
+}
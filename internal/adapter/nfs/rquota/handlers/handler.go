@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rquota/types"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rquota/xdr"
+	nfsxdr "github.com/marmos91/dittofs/internal/adapter/nfs/xdr"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// rquotaRuntime is the consumer-defined role interface for the rquota
+// handlers. It lists exactly the Runtime methods this package depends on.
+// *runtime.Runtime satisfies it implicitly (compile-time assertion below).
+type rquotaRuntime interface {
+	// ResolveExportPath maps the client's mount path to a share.
+	ResolveExportPath(ctx context.Context, dirPath string) (*runtime.ExportTarget, error)
+
+	// ResolveExportPolicy and ApplyExportIdentityMapping apply the squash
+	// rules governing the caller: the share's, a subtree export's or a
+	// client certificate rule's.
+	ResolveExportPolicy(ctx context.Context, shareName string, handle metadata.FileHandle, clientIP net.IP) (*runtime.ExportPolicy, error)
+	ApplyExportIdentityMapping(shareName string, policy *runtime.ExportPolicy, ident *metadata.Identity) (*metadata.Identity, error)
+
+	// GetMetadataService reports quota limits and usage.
+	GetMetadataService() *metadata.Service
+
+	// SetIdentityQuotaLimits persists limits changed by SETQUOTA.
+	SetIdentityQuotaLimits(ctx context.Context, shareName string, iq metadata.IdentityQuota) error
+}
+
+var _ rquotaRuntime = (*runtime.Runtime)(nil)
+
+// Handler handles rquota protocol requests.
+//
+// Handler coordinates rquota operations:
+//   - NULL: Ping/health check
+//   - GETQUOTA / GETACTIVEQUOTA: Report a user or group quota
+//   - SETQUOTA / SETACTIVEQUOTA: Replace a user or group quota (root only,
+//     when enabled with SetAllowSetQuota)
+//
+// Thread Safety:
+// Handler is safe for concurrent use by multiple goroutines.
+type Handler struct {
+	rt rquotaRuntime
+
+	// allowSetQuota gates SETQUOTA/SETACTIVEQUOTA. Off by default: root is
+	// only an AUTH_SYS claim, so changing quotas from clients is opt-in.
+	allowSetQuota atomic.Bool
+}
+
+// NewHandler creates a new rquota handler backed by the given runtime.
+func NewHandler(rt rquotaRuntime) *Handler {
+	return &Handler{rt: rt}
+}
+
+// SetAllowSetQuota enables or disables SETQUOTA and SETACTIVEQUOTA. Applied
+// live from the adapter settings (rquota_set_quota_enabled).
+func (h *Handler) SetAllowSetQuota(allow bool) {
+	h.allowSetQuota.Store(allow)
+}
+
+// HandlerResult contains the XDR-encoded response for rquota procedures.
+// This type is used by all rquota handlers and the dispatch table.
+type HandlerResult struct {
+	// Data contains the XDR-encoded response
+	Data []byte
+
+	// Status is the rquota qr_status (QOK, QNoQuota, QEPerm)
+	Status uint32
+}
+
+// Null handles rquota NULL (procedure 0).
+// No-op ping/health check verifying the rquota service is reachable.
+// No side effects; stateless operation.
+// Errors: none (NULL always succeeds).
+func (h *Handler) Null(ctx *RQuotaHandlerContext) (*HandlerResult, error) {
+	logger.Debug("RQUOTA NULL request",
+		"client", ctx.ClientAddr)
+
+	return &HandlerResult{
+		Data:   []byte{},
+		Status: types.QOK,
+	}, nil
+}
+
+// encodeResult encodes a quota result, falling back to an empty body if the
+// encoding fails (it cannot for a well-formed QuotaRes).
+func encodeResult(ctx *RQuotaHandlerContext, res *types.QuotaRes) (*HandlerResult, error) {
+	encoded, err := xdr.EncodeQuotaRes(res)
+	if err != nil {
+		logger.Error("RQUOTA encode error",
+			"client", ctx.ClientAddr,
+			"error", err)
+		return &HandlerResult{Data: []byte{}, Status: res.Status}, nil
+	}
+	return &HandlerResult{Data: encoded, Status: res.Status}, nil
+}
+
+// statusOnly returns a result carrying only a qr_status (Q_NOQUOTA, Q_EPERM).
+func statusOnly(ctx *RQuotaHandlerContext, status uint32) (*HandlerResult, error) {
+	return encodeResult(ctx, &types.QuotaRes{Status: status})
+}
+
+// callerIdentity builds the caller's identity from the RPC credentials and
+// applies the identity mapping (root squash, all squash) of the export
+// governing the resolved directory. A nil result means the caller is
+// anonymous or denied by the export and may not see any quota.
+func (h *Handler) callerIdentity(ctx *RQuotaHandlerContext, target *runtime.ExportTarget) *metadata.Identity {
+	if ctx.UID == nil {
+		return nil
+	}
+	clientIP := net.ParseIP(nfsxdr.ExtractClientIP(ctx.ClientAddr))
+	policy, err := h.rt.ResolveExportPolicy(ctx.Context, target.ShareName, target.Handle, clientIP)
+	if err != nil {
+		logger.Debug("RQUOTA export policy denied caller",
+			"client", ctx.ClientAddr,
+			"share", target.ShareName,
+			"error", err)
+		return nil
+	}
+	ident := &metadata.Identity{
+		UID:  ctx.UID,
+		GID:  ctx.GID,
+		GIDs: ctx.GIDs,
+	}
+	mapped, err := h.rt.ApplyExportIdentityMapping(target.ShareName, policy, ident)
+	if err != nil {
+		logger.Warn("RQUOTA identity mapping failed",
+			"client", ctx.ClientAddr,
+			"share", target.ShareName,
+			"error", err)
+		return nil
+	}
+	return mapped
+}
+
+// isRoot reports whether the (mapped) identity is uid 0.
+func isRoot(ident *metadata.Identity) bool {
+	return ident != nil && ident.UID != nil && *ident.UID == 0
+}
+
+// quotaScope maps an rquota quota type to a metadata quota scope.
+func quotaScope(quotaType uint32) (metadata.QuotaScope, bool) {
+	switch quotaType {
+	case types.USRQuota:
+		return metadata.QuotaScopeUser, true
+	case types.GRPQuota:
+		return metadata.QuotaScopeGroup, true
+	default:
+		return 0, false
+	}
+}
+
+// newBytesReader creates an io.Reader from a byte slice.
+func newBytesReader(data []byte) io.Reader {
+	return bytes.NewReader(data)
+}
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rquota/types"
+	xdrcore "github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
+	"github.com/marmos91/dittofs/pkg/metadata"
+	metadatamemory "github.com/marmos91/dittofs/pkg/metadata/store/memory"
+)
+
+// fakeRuntime is a real runtime whose SetIdentityQuotaLimits applies limits
+// straight to the metadata service, since the test runtime has no control-plane
+// store to persist them in.
+type fakeRuntime struct {
+	*runtime.Runtime
+	set []metadata.IdentityQuota
+}
+
+func (f *fakeRuntime) SetIdentityQuotaLimits(_ context.Context, shareName string, iq metadata.IdentityQuota) error {
+	f.set = append(f.set, iq)
+	f.GetMetadataService().SetIdentityQuota(shareName, iq)
+	return nil
+}
+
+// newTestHandler builds a Handler over a runtime with a "/export" share using
+// the given squash mode.
+func newTestHandler(t *testing.T, squash models.SquashMode) (*Handler, *fakeRuntime) {
+	t.Helper()
+
+	rt := runtime.New(nil)
+	if err := rt.RegisterMetadataStore("meta", metadatamemory.NewMemoryMetadataStoreWithDefaults()); err != nil {
+		t.Fatalf("RegisterMetadataStore: %v", err)
+	}
+	if err := rt.AddShare(context.Background(), &runtime.ShareConfig{
+		Name:          "/export",
+		MetadataStore: "meta",
+		Enabled:       true,
+		Squash:        squash,
+		AnonymousUID:  65534,
+		AnonymousGID:  65534,
+		RootAttr:      &metadata.FileAttr{Type: metadata.FileTypeDirectory, Mode: 0o755},
+	}); err != nil {
+		t.Fatalf("AddShare: %v", err)
+	}
+
+	fake := &fakeRuntime{Runtime: rt}
+	return NewHandler(fake), fake
+}
+
+// newCtx builds an AUTH_UNIX handler context for the given rquota version.
+func newCtx(version, uid, gid uint32, gids ...uint32) *RQuotaHandlerContext {
+	return &RQuotaHandlerContext{
+		Context:    context.Background(),
+		ClientAddr: "127.0.0.1:700",
+		AuthFlavor: rpc.AuthUnix,
+		Version:    version,
+		UID:        &uid,
+		GID:        &gid,
+		GIDs:       gids,
+	}
+}
+
+func encodeGetQuota(version uint32, path string, quotaType, id uint32) []byte {
+	var buf bytes.Buffer
+	_ = xdrcore.WriteXDRString(&buf, path)
+	if version == rpc.RQuotaVersion2 {
+		_ = xdrcore.WriteUint32(&buf, quotaType)
+	}
+	_ = xdrcore.WriteUint32(&buf, id)
+	return buf.Bytes()
+}
+
+func encodeSetQuota(version uint32, path string, quotaType, id uint32, dq types.DQBlk) []byte {
+	var buf bytes.Buffer
+	_ = xdrcore.WriteUint32(&buf, 0) // qcmd
+	_ = xdrcore.WriteXDRString(&buf, path)
+	_ = xdrcore.WriteUint32(&buf, id)
+	if version == rpc.RQuotaVersion2 {
+		_ = xdrcore.WriteUint32(&buf, quotaType)
+	}
+	for _, v := range []uint32{dq.BHardLimit, dq.BSoftLimit, dq.CurBlocks, dq.FHardLimit, dq.FSoftLimit, dq.CurFiles, dq.BTimeLeft, dq.FTimeLeft} {
+		_ = xdrcore.WriteUint32(&buf, v)
+	}
+	return buf.Bytes()
+}
+
+// decodeRQuota parses a Q_OK getquota_rslt body.
+func decodeRQuota(t *testing.T, data []byte) types.RQuota {
+	t.Helper()
+	if len(data) != 4*11 {
+		t.Fatalf("Q_OK result is %d bytes, want 44", len(data))
+	}
+	u := func(i int) uint32 { return binary.BigEndian.Uint32(data[4*i:]) }
+	return types.RQuota{
+		BSize:  u(1),
+		Active: u(2) == 1,
+		DQBlk: types.DQBlk{
+			BHardLimit: u(3), BSoftLimit: u(4), CurBlocks: u(5),
+			FHardLimit: u(6), FSoftLimit: u(7), CurFiles: u(8),
+			BTimeLeft: u(9), FTimeLeft: u(10),
+		},
+	}
+}
+
+func TestGetQuota(t *testing.T) {
+	h, rt := newTestHandler(t, models.SquashRootToGuest)
+	svc := rt.GetMetadataService()
+	svc.SetIdentityQuota("/export", metadata.IdentityQuota{
+		Scope: metadata.QuotaScopeUser, ID: 1000, LimitBytes: 10 << 20, SoftBytes: 8 << 20, LimitFiles: 100,
+	})
+	svc.SetIdentityQuota("/export", metadata.IdentityQuota{
+		Scope: metadata.QuotaScopeGroup, ID: 50, LimitFiles: 7,
+	})
+
+	tests := []struct {
+		name       string
+		ctx        *RQuotaHandlerContext
+		path       string
+		quotaType  uint32
+		id         uint32
+		wantStatus uint32
+	}{
+		{"v1 own uid", newCtx(rpc.RQuotaVersion1, 1000, 1000), "/export", types.USRQuota, 1000, types.QOK},
+		{"v2 own uid", newCtx(rpc.RQuotaVersion2, 1000, 1000), "/export", types.USRQuota, 1000, types.QOK},
+		{"other uid denied", newCtx(rpc.RQuotaVersion2, 1001, 1001), "/export", types.USRQuota, 1000, types.QEPerm},
+		{"supplementary group", newCtx(rpc.RQuotaVersion2, 1000, 1000, 50), "/export", types.GRPQuota, 50, types.QOK},
+		{"primary group", newCtx(rpc.RQuotaVersion2, 1000, 50), "/export", types.GRPQuota, 50, types.QOK},
+		{"non-member group denied", newCtx(rpc.RQuotaVersion2, 1000, 1000), "/export", types.GRPQuota, 50, types.QEPerm},
+		{"squashed root denied", newCtx(rpc.RQuotaVersion2, 0, 0), "/export", types.USRQuota, 1000, types.QEPerm},
+		{"no quota", newCtx(rpc.RQuotaVersion2, 1001, 1001), "/export", types.USRQuota, 1001, types.QNoQuota},
+		{"unknown path", newCtx(rpc.RQuotaVersion2, 1000, 1000), "/nope", types.USRQuota, 1000, types.QNoQuota},
+		{"unknown type", newCtx(rpc.RQuotaVersion2, 1000, 1000), "/export", 9, 1000, types.QNoQuota},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := h.GetQuota(tt.ctx, encodeGetQuota(tt.ctx.Version, tt.path, tt.quotaType, tt.id))
+			if err != nil {
+				t.Fatalf("GetQuota: %v", err)
+			}
+			if res.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", types.StatusString(res.Status), types.StatusString(tt.wantStatus))
+			}
+			if res.Status != types.QOK && len(res.Data) != 4 {
+				t.Errorf("non-Q_OK result is %d bytes, want 4", len(res.Data))
+			}
+		})
+	}
+
+	res, err := h.GetQuota(newCtx(rpc.RQuotaVersion1, 1000, 1000), encodeGetQuota(rpc.RQuotaVersion1, "/export", 0, 1000))
+	if err != nil {
+		t.Fatalf("GetQuota: %v", err)
+	}
+	q := decodeRQuota(t, res.Data)
+	if q.BSize != 1024 || !q.Active {
+		t.Errorf("bsize/active = %d/%v, want 1024/true", q.BSize, q.Active)
+	}
+	if q.BHardLimit != 10240 || q.BSoftLimit != 8192 || q.FHardLimit != 100 {
+		t.Errorf("limits = %+v, want bhard 10240, bsoft 8192, fhard 100", q.DQBlk)
+	}
+}
+
+func TestGetQuota_RootUnsquashed(t *testing.T) {
+	h, rt := newTestHandler(t, models.SquashNone)
+	rt.GetMetadataService().SetIdentityQuota("/export", metadata.IdentityQuota{
+		Scope: metadata.QuotaScopeUser, ID: 1000, LimitFiles: 5,
+	})
+
+	res, err := h.GetQuota(newCtx(rpc.RQuotaVersion2, 0, 0), encodeGetQuota(rpc.RQuotaVersion2, "/export", types.USRQuota, 1000))
+	if err != nil {
+		t.Fatalf("GetQuota: %v", err)
+	}
+	if res.Status != types.QOK {
+		t.Fatalf("status = %s, want Q_OK", types.StatusString(res.Status))
+	}
+}
+
+func TestSetQuota(t *testing.T) {
+	h, rt := newTestHandler(t, models.SquashNone)
+	h.SetAllowSetQuota(true)
+	dq := types.DQBlk{BHardLimit: 2048, BSoftLimit: 1024, FHardLimit: 10, CurBlocks: 99, BTimeLeft: 5}
+
+	// Non-root callers may not set quotas.
+	res, err := h.SetQuota(newCtx(rpc.RQuotaVersion2, 1000, 1000), encodeSetQuota(rpc.RQuotaVersion2, "/export", types.GRPQuota, 50, dq))
+	if err != nil {
+		t.Fatalf("SetQuota: %v", err)
+	}
+	if res.Status != types.QEPerm || len(rt.set) != 0 {
+		t.Fatalf("non-root SETQUOTA: status %s, %d sets; want Q_EPERM, 0", types.StatusString(res.Status), len(rt.set))
+	}
+
+	res, err = h.SetQuota(newCtx(rpc.RQuotaVersion2, 0, 0), encodeSetQuota(rpc.RQuotaVersion2, "/export", types.GRPQuota, 50, dq))
+	if err != nil {
+		t.Fatalf("SetQuota: %v", err)
+	}
+	if res.Status != types.QOK {
+		t.Fatalf("status = %s, want Q_OK", types.StatusString(res.Status))
+	}
+	want := metadata.IdentityQuota{Scope: metadata.QuotaScopeGroup, ID: 50, LimitBytes: 2 << 20, SoftBytes: 1 << 20, LimitFiles: 10}
+	if len(rt.set) != 1 || rt.set[0] != want {
+		t.Fatalf("applied %+v, want %+v", rt.set, want)
+	}
+
+	// The reply reports live usage, not the client-supplied usage fields.
+	q := decodeRQuota(t, res.Data)
+	if q.BHardLimit != 2048 || q.CurBlocks != 0 || q.BTimeLeft != 0 {
+		t.Errorf("reply = %+v, want bhard 2048 with server-side usage", q.DQBlk)
+	}
+
+	// v1 carries no type: the id is a uid.
+	res, err = h.SetQuota(newCtx(rpc.RQuotaVersion1, 0, 0), encodeSetQuota(rpc.RQuotaVersion1, "/export", 0, 1000, dq))
+	if err != nil {
+		t.Fatalf("SetQuota v1: %v", err)
+	}
+	if res.Status != types.QOK || rt.set[1].Scope != metadata.QuotaScopeUser || rt.set[1].ID != 1000 {
+		t.Fatalf("v1 SETQUOTA: status %s, applied %+v", types.StatusString(res.Status), rt.set[1])
+	}
+}
+
+func TestSetQuota_SquashedRootDenied(t *testing.T) {
+	h, rt := newTestHandler(t, models.SquashRootToGuest)
+	h.SetAllowSetQuota(true)
+
+	res, err := h.SetQuota(newCtx(rpc.RQuotaVersion2, 0, 0), encodeSetQuota(rpc.RQuotaVersion2, "/export", types.USRQuota, 1000, types.DQBlk{FHardLimit: 1}))
+	if err != nil {
+		t.Fatalf("SetQuota: %v", err)
+	}
+	if res.Status != types.QEPerm || len(rt.set) != 0 {
+		t.Fatalf("squashed root: status %s, %d sets; want Q_EPERM, 0", types.StatusString(res.Status), len(rt.set))
+	}
+}
+
+func TestSetQuota_DisabledOrUDPDenied(t *testing.T) {
+	h, rt := newTestHandler(t, models.SquashNone)
+	args := encodeSetQuota(rpc.RQuotaVersion2, "/export", types.USRQuota, 1000, types.DQBlk{FHardLimit: 1})
+
+	// Off by default, even for an unsquashed root.
+	res, err := h.SetQuota(newCtx(rpc.RQuotaVersion2, 0, 0), args)
+	if err != nil {
+		t.Fatalf("SetQuota: %v", err)
+	}
+	if res.Status != types.QEPerm || len(rt.set) != 0 {
+		t.Fatalf("disabled: status %s, %d sets; want Q_EPERM, 0", types.StatusString(res.Status), len(rt.set))
+	}
+
+	// Enabled, but the call came over UDP.
+	h.SetAllowSetQuota(true)
+	ctx := newCtx(rpc.RQuotaVersion2, 0, 0)
+	ctx.UDP = true
+	res, err = h.SetQuota(ctx, args)
+	if err != nil {
+		t.Fatalf("SetQuota: %v", err)
+	}
+	if res.Status != types.QEPerm || len(rt.set) != 0 {
+		t.Fatalf("UDP: status %s, %d sets; want Q_EPERM, 0", types.StatusString(res.Status), len(rt.set))
+	}
+
+	// Reading quotas over UDP is unaffected.
+	res, err = h.GetQuota(ctx, encodeGetQuota(rpc.RQuotaVersion2, "/export", types.USRQuota, 1000))
+	if err != nil {
+		t.Fatalf("GetQuota: %v", err)
+	}
+	if res.Status == types.QEPerm {
+		t.Fatalf("GETQUOTA over UDP: status %s", types.StatusString(res.Status))
+	}
+}
+
+func TestToRQuota(t *testing.T) {
+	t.Run("rounding", func(t *testing.T) {
+		q := toRQuota(metadata.QuotaReport{
+			Quota:          metadata.IdentityQuota{LimitBytes: 1500, SoftBytes: 100},
+			Usage:          metadata.UsageStat{Bytes: 1025, Files: 3},
+			BytesGraceLeft: 1500 * time.Millisecond,
+		})
+		if q.BSize != 1024 {
+			t.Fatalf("bsize = %d, want 1024", q.BSize)
+		}
+		// Usage rounds up; limits round down but never to "unlimited".
+		if q.CurBlocks != 2 || q.BHardLimit != 1 || q.BSoftLimit != 1 || q.CurFiles != 3 {
+			t.Errorf("dqblk = %+v, want cur 2, hard 1, soft 1, files 3", q.DQBlk)
+		}
+		if q.BTimeLeft != 2 {
+			t.Errorf("btimeleft = %d, want 2 (rounded up)", q.BTimeLeft)
+		}
+	})
+
+	t.Run("large quota scales block size", func(t *testing.T) {
+		const limit = int64(8) << 40 // 8 TiB: 2^33 KiB overflows uint32
+		q := toRQuota(metadata.QuotaReport{
+			Quota: metadata.IdentityQuota{LimitBytes: limit},
+			Usage: metadata.UsageStat{Bytes: limit / 2},
+		})
+		if q.BSize != 4096 {
+			t.Fatalf("bsize = %d, want 4096", q.BSize)
+		}
+		if int64(q.BHardLimit)*int64(q.BSize) != limit || int64(q.CurBlocks)*int64(q.BSize) != limit/2 {
+			t.Errorf("dqblk = %+v does not round-trip at bsize %d", q.DQBlk, q.BSize)
+		}
+	})
+
+	t.Run("block size is capped", func(t *testing.T) {
+		q := toRQuota(metadata.QuotaReport{
+			Quota: metadata.IdentityQuota{LimitBytes: math.MaxInt64},
+		})
+		if q.BSize != 1<<30 {
+			t.Fatalf("bsize = %d, want 1 GiB", q.BSize)
+		}
+		if q.BHardLimit != math.MaxUint32 {
+			t.Errorf("bhard = %d, want clamped to MaxUint32", q.BHardLimit)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rquota/types"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rquota/xdr"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// SetQuota handles rquota SETQUOTA and SETACTIVEQUOTA (procedures 3 and 4).
+// Replaces the block and inode limits of a user (v1, v2) or group (v2) quota
+// on the share the path resolves to, persisting them in the control plane.
+// Limits arrive in 1 KiB blocks; usage and time-left fields are ignored since
+// both are derived by the server. All-zero limits remove the quota.
+// Authorization: disabled unless SetAllowSetQuota(true), and never over UDP,
+// where the caller's address is unauthenticated; then root only, after
+// identity mapping (a squashed root is denied).
+// Side effects: persists the quota and hot-updates enforcement.
+// Errors: Q_NOQUOTA (unknown path), Q_EPERM (disabled, UDP, not root, or
+// invalid limits).
+func (h *Handler) SetQuota(ctx *RQuotaHandlerContext, data []byte) (*HandlerResult, error) {
+	args, err := xdr.DecodeSetQuotaArgs(newBytesReader(data), ctx.Version)
+	if err != nil {
+		logger.Warn("RQUOTA SETQUOTA decode error",
+			"client", ctx.ClientAddr,
+			"error", err)
+		return nil, err
+	}
+
+	logger.Debug("RQUOTA SETQUOTA request",
+		"client", ctx.ClientAddr,
+		"path", args.Path,
+		"type", args.Type,
+		"id", args.ID)
+
+	if !h.allowSetQuota.Load() {
+		logger.Debug("RQUOTA SETQUOTA denied: disabled by rquota_set_quota_enabled",
+			"client", ctx.ClientAddr)
+		return statusOnly(ctx, types.QEPerm)
+	}
+	if ctx.UDP {
+		logger.Debug("RQUOTA SETQUOTA denied: not accepted over UDP",
+			"client", ctx.ClientAddr)
+		return statusOnly(ctx, types.QEPerm)
+	}
+
+	scope, ok := quotaScope(args.Type)
+	if !ok {
+		return statusOnly(ctx, types.QNoQuota)
+	}
+
+	target, err := h.rt.ResolveExportPath(ctx.Context, args.Path)
+	if err != nil {
+		logger.Debug("RQUOTA SETQUOTA unknown path",
+			"client", ctx.ClientAddr,
+			"path", args.Path,
+			"error", err)
+		return statusOnly(ctx, types.QNoQuota)
+	}
+
+	if !isRoot(h.callerIdentity(ctx, target)) {
+		logger.Debug("RQUOTA SETQUOTA denied: caller is not root",
+			"client", ctx.ClientAddr,
+			"share", target.ShareName)
+		return statusOnly(ctx, types.QEPerm)
+	}
+
+	iq := metadata.IdentityQuota{
+		Scope:      scope,
+		ID:         args.ID,
+		LimitBytes: int64(args.DQBlk.BHardLimit) * types.SetQuotaBlockSize,
+		SoftBytes:  int64(args.DQBlk.BSoftLimit) * types.SetQuotaBlockSize,
+		LimitFiles: int64(args.DQBlk.FHardLimit),
+		SoftFiles:  int64(args.DQBlk.FSoftLimit),
+	}
+	if err := h.rt.SetIdentityQuotaLimits(ctx.Context, target.ShareName, iq); err != nil {
+		logger.Warn("RQUOTA SETQUOTA failed",
+			"client", ctx.ClientAddr,
+			"share", target.ShareName,
+			"type", args.Type,
+			"id", args.ID,
+			"error", err)
+		return statusOnly(ctx, types.QEPerm)
+	}
+
+	logger.Info("RQUOTA SETQUOTA applied",
+		"client", ctx.ClientAddr,
+		"share", target.ShareName,
+		"scope", scope.String(),
+		"id", args.ID,
+		"limit_bytes", iq.LimitBytes,
+		"soft_bytes", iq.SoftBytes,
+		"limit_files", iq.LimitFiles,
+		"soft_files", iq.SoftFiles)
+
+	report, ok, err := h.rt.GetMetadataService().GetIdentityQuotaReport(target.ShareName, scope, args.ID)
+	if err != nil || !ok {
+		// Limits were cleared (or usage is unreadable): nothing to report.
+		return statusOnly(ctx, types.QNoQuota)
+	}
+
+	return encodeResult(ctx, &types.QuotaRes{
+		Status: types.QOK,
+		Quota:  toRQuota(report),
+	})
+}
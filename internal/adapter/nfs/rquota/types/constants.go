@@ -0,0 +1,115 @@
+// Package types provides rquota (remote quota) protocol types and constants.
+//
+// rquota is the side protocol NFS clients use to read the quota of a user or
+// group on a remote filesystem. Userspace quota tools (quota(1), repquota,
+// setquota -r) call it against the host of each NFS mount; the kernel NFS
+// client does not use it.
+//
+// Two versions exist:
+//   - v1 (RQUOTAVERS): user quotas only, keyed by uid
+//   - v2 (EXT_RQUOTAVERS): adds a quota type so group quotas can be queried
+//
+// References:
+//   - rquota.x from the Linux quota-tools / Sun ONC RPC distribution
+package types
+
+// ============================================================================
+// rquota Procedure Numbers
+// ============================================================================
+//
+// Procedure numbers are the same for v1 and v2; only the argument layout
+// differs (v2 carries a quota type).
+
+const (
+	// RQuotaProcNull is the NULL procedure for connection testing (ping).
+	RQuotaProcNull uint32 = 0
+
+	// RQuotaProcGetQuota returns the quota of a user (v1) or user/group (v2).
+	RQuotaProcGetQuota uint32 = 1
+
+	// RQuotaProcGetActiveQuota is GETQUOTA restricted to filesystems with
+	// quotas enabled. DittoFS answers it identically to GETQUOTA: an
+	// identity without a quota reports Q_NOQUOTA either way.
+	RQuotaProcGetActiveQuota uint32 = 2
+
+	// RQuotaProcSetQuota replaces the limits of a user or group quota.
+	RQuotaProcSetQuota uint32 = 3
+
+	// RQuotaProcSetActiveQuota is SETQUOTA restricted to filesystems with
+	// quotas enabled. Answered identically to SETQUOTA.
+	RQuotaProcSetActiveQuota uint32 = 4
+)
+
+// ============================================================================
+// rquota Result Codes (qr_status)
+// ============================================================================
+
+const (
+	// QOK indicates the quota was found and is returned.
+	QOK uint32 = 1
+
+	// QNoQuota indicates no quota applies (unknown path, or no limits set
+	// for the identity).
+	QNoQuota uint32 = 2
+
+	// QEPerm indicates the caller may not read or change the quota.
+	QEPerm uint32 = 3
+)
+
+// ============================================================================
+// Quota Types (v2)
+// ============================================================================
+
+const (
+	// USRQuota selects a user quota (gqa_type in ext_getquota_args).
+	USRQuota uint32 = 0
+
+	// GRPQuota selects a group quota.
+	GRPQuota uint32 = 1
+)
+
+// ============================================================================
+// Limits
+// ============================================================================
+
+const (
+	// RQPathLen is the maximum length of the path argument (RQ_PATHLEN).
+	RQPathLen = 1024
+
+	// SetQuotaBlockSize is the block size, in bytes, of the limits carried by
+	// SETQUOTA (sq_dqblk has no block size field; quota-tools send 1 KiB
+	// QUOTABLOCK_SIZE units).
+	SetQuotaBlockSize = 1024
+)
+
+// ProcedureName returns a human-readable name for an rquota procedure number.
+func ProcedureName(proc uint32) string {
+	switch proc {
+	case RQuotaProcNull:
+		return "NULL"
+	case RQuotaProcGetQuota:
+		return "GETQUOTA"
+	case RQuotaProcGetActiveQuota:
+		return "GETACTIVEQUOTA"
+	case RQuotaProcSetQuota:
+		return "SETQUOTA"
+	case RQuotaProcSetActiveQuota:
+		return "SETACTIVEQUOTA"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// StatusString returns a human-readable string for an rquota result code.
+func StatusString(status uint32) string {
+	switch status {
+	case QOK:
+		return "Q_OK"
+	case QNoQuota:
+		return "Q_NOQUOTA"
+	case QEPerm:
+		return "Q_EPERM"
+	default:
+		return "UNKNOWN"
+	}
+}
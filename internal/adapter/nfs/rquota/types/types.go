@@ -0,0 +1,127 @@
+package types
+
+// ============================================================================
+// rquota XDR Types
+// ============================================================================
+//
+// These types match rquota.x. All structures follow the XDR encoding rules
+// from RFC 4506.
+
+// GetQuotaArgs is the GETQUOTA/GETACTIVEQUOTA argument.
+//
+// v1 (getquota_args) carries only a uid; v2 (ext_getquota_args) adds the
+// quota type:
+//
+//	struct getquota_args {
+//	    string gqa_pathp<RQ_PATHLEN>;
+//	    int    gqa_uid;
+//	};
+//
+//	struct ext_getquota_args {
+//	    string gqa_pathp<RQ_PATHLEN>;
+//	    int    gqa_type;
+//	    int    gqa_id;
+//	};
+type GetQuotaArgs struct {
+	// Path is the exported path the client mounted (from its mount table).
+	Path string
+
+	// Type is USRQuota or GRPQuota. Always USRQuota for v1.
+	Type uint32
+
+	// ID is the uid or gid whose quota is requested.
+	ID uint32
+}
+
+// DQBlk carries quota limits and usage in blocks and inodes.
+//
+//	struct sq_dqblk {
+//	    unsigned int rq_bhardlimit;
+//	    unsigned int rq_bsoftlimit;
+//	    unsigned int rq_curblocks;
+//	    unsigned int rq_fhardlimit;
+//	    unsigned int rq_fsoftlimit;
+//	    unsigned int rq_curfiles;
+//	    unsigned int rq_btimeleft;
+//	    unsigned int rq_ftimeleft;
+//	};
+type DQBlk struct {
+	BHardLimit uint32
+	BSoftLimit uint32
+	CurBlocks  uint32
+	FHardLimit uint32
+	FSoftLimit uint32
+	CurFiles   uint32
+	BTimeLeft  uint32
+	FTimeLeft  uint32
+}
+
+// SetQuotaArgs is the SETQUOTA/SETACTIVEQUOTA argument.
+//
+//	struct setquota_args {
+//	    int     sqa_qcmd;
+//	    string  sqa_pathp<RQ_PATHLEN>;
+//	    int     sqa_id;
+//	    sq_dqblk sqa_dqblk;
+//	};
+//
+//	struct ext_setquota_args {
+//	    int     sqa_qcmd;
+//	    string  sqa_pathp<RQ_PATHLEN>;
+//	    int     sqa_id;
+//	    int     sqa_type;
+//	    sq_dqblk sqa_dqblk;
+//	};
+type SetQuotaArgs struct {
+	// Cmd is the client's quotactl command. DittoFS only ever applies the
+	// limits: usage is derived from the filesystem and cannot be set.
+	Cmd uint32
+
+	// Path is the exported path the client mounted.
+	Path string
+
+	// ID is the uid or gid whose quota is replaced.
+	ID uint32
+
+	// Type is USRQuota or GRPQuota. Always USRQuota for v1.
+	Type uint32
+
+	// DQBlk holds the new limits, in SetQuotaBlockSize blocks.
+	DQBlk DQBlk
+}
+
+// RQuota is the quota returned by GETQUOTA and SETQUOTA.
+//
+//	struct rquota {
+//	    int          rq_bsize;
+//	    bool         rq_active;
+//	    unsigned int rq_bhardlimit;
+//	    ...                         (as sq_dqblk)
+//	};
+type RQuota struct {
+	// BSize is the size in bytes of the block unit used by the block fields.
+	BSize uint32
+
+	// Active reports whether quotas are enforced on the filesystem.
+	Active bool
+
+	DQBlk
+}
+
+// QuotaRes is the GETQUOTA/SETQUOTA result (getquota_rslt / setquota_rslt).
+//
+//	union getquota_rslt switch (qr_status status) {
+//	case Q_OK:
+//	    rquota gqr_rquota;
+//	case Q_NOQUOTA:
+//	    void;
+//	case Q_EPERM:
+//	    void;
+//	};
+type QuotaRes struct {
+	// Status is QOK, QNoQuota, or QEPerm.
+	Status uint32
+
+	// Quota is present only when Status == QOK.
+	Quota RQuota
+}
@@ -0,0 +1,124 @@
+// Package xdr provides XDR encoding/decoding for rquota protocol messages.
+//
+// This package uses the shared XDR utilities from internal/adapter/nfs/xdr/core
+// for primitive type encoding/decoding, following the same pattern as the NSM
+// and NLM XDR packages.
+package xdr
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rquota/types"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
+)
+
+// ============================================================================
+// rquota Request Decoding
+// ============================================================================
+
+// DecodeGetQuotaArgs decodes a GETQUOTA/GETACTIVEQUOTA argument for the given
+// rquota version: getquota_args for v1, ext_getquota_args for v2.
+//
+// XDR format (v1):
+//
+//	struct getquota_args {
+//	    string gqa_pathp<RQ_PATHLEN>;
+//	    int    gqa_uid;
+//	};
+//
+// XDR format (v2):
+//
+//	struct ext_getquota_args {
+//	    string gqa_pathp<RQ_PATHLEN>;
+//	    int    gqa_type;
+//	    int    gqa_id;
+//	};
+func DecodeGetQuotaArgs(r io.Reader, version uint32) (*types.GetQuotaArgs, error) {
+	path, err := decodePath(r)
+	if err != nil {
+		return nil, err
+	}
+
+	args := &types.GetQuotaArgs{Path: path, Type: types.USRQuota}
+
+	if version == rpc.RQuotaVersion2 {
+		if args.Type, err = xdr.DecodeUint32(r); err != nil {
+			return nil, fmt.Errorf("decode gqa_type: %w", err)
+		}
+	}
+
+	if args.ID, err = xdr.DecodeUint32(r); err != nil {
+		return nil, fmt.Errorf("decode gqa_id: %w", err)
+	}
+
+	return args, nil
+}
+
+// DecodeSetQuotaArgs decodes a SETQUOTA/SETACTIVEQUOTA argument for the given
+// rquota version: setquota_args for v1, ext_setquota_args for v2.
+//
+// XDR format (v1):
+//
+//	struct setquota_args {
+//	    int      sqa_qcmd;
+//	    string   sqa_pathp<RQ_PATHLEN>;
+//	    int      sqa_id;
+//	    sq_dqblk sqa_dqblk;
+//	};
+//
+// XDR format (v2) adds "int sqa_type;" after sqa_id.
+func DecodeSetQuotaArgs(r io.Reader, version uint32) (*types.SetQuotaArgs, error) {
+	cmd, err := xdr.DecodeUint32(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode sqa_qcmd: %w", err)
+	}
+
+	path, err := decodePath(r)
+	if err != nil {
+		return nil, err
+	}
+
+	args := &types.SetQuotaArgs{Cmd: cmd, Path: path, Type: types.USRQuota}
+
+	if args.ID, err = xdr.DecodeUint32(r); err != nil {
+		return nil, fmt.Errorf("decode sqa_id: %w", err)
+	}
+
+	if version == rpc.RQuotaVersion2 {
+		if args.Type, err = xdr.DecodeUint32(r); err != nil {
+			return nil, fmt.Errorf("decode sqa_type: %w", err)
+		}
+	}
+
+	fields := []*uint32{
+		&args.DQBlk.BHardLimit,
+		&args.DQBlk.BSoftLimit,
+		&args.DQBlk.CurBlocks,
+		&args.DQBlk.FHardLimit,
+		&args.DQBlk.FSoftLimit,
+		&args.DQBlk.CurFiles,
+		&args.DQBlk.BTimeLeft,
+		&args.DQBlk.FTimeLeft,
+	}
+	for i, f := range fields {
+		if *f, err = xdr.DecodeUint32(r); err != nil {
+			return nil, fmt.Errorf("decode sqa_dqblk field %d: %w", i, err)
+		}
+	}
+
+	return args, nil
+}
+
+// decodePath decodes the RQ_PATHLEN-bounded path argument.
+func decodePath(r io.Reader) (string, error) {
+	path, err := xdr.DecodeString(r)
+	if err != nil {
+		return "", fmt.Errorf("decode path: %w", err)
+	}
+	if len(path) > types.RQPathLen {
+		return "", fmt.Errorf("path too long: %d > %d", len(path), types.RQPathLen)
+	}
+	return path, nil
+}
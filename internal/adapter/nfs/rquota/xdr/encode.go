@@ -0,0 +1,59 @@
+package xdr
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rquota/types"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
+)
+
+// ============================================================================
+// rquota Response Encoding
+// ============================================================================
+
+// EncodeQuotaRes encodes a GETQUOTA/SETQUOTA result (getquota_rslt /
+// setquota_rslt). The rquota body follows only for Q_OK.
+//
+// XDR format:
+//
+//	union getquota_rslt switch (qr_status status) {
+//	case Q_OK:
+//	    rquota gqr_rquota;
+//	default:
+//	    void;
+//	};
+func EncodeQuotaRes(res *types.QuotaRes) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	if err := xdr.WriteUint32(buf, res.Status); err != nil {
+		return nil, fmt.Errorf("encode status: %w", err)
+	}
+	if res.Status != types.QOK {
+		return buf.Bytes(), nil
+	}
+
+	q := &res.Quota
+	if err := xdr.WriteUint32(buf, q.BSize); err != nil {
+		return nil, fmt.Errorf("encode rq_bsize: %w", err)
+	}
+	if err := xdr.WriteBool(buf, q.Active); err != nil {
+		return nil, fmt.Errorf("encode rq_active: %w", err)
+	}
+	for i, v := range []uint32{
+		q.BHardLimit,
+		q.BSoftLimit,
+		q.CurBlocks,
+		q.FHardLimit,
+		q.FSoftLimit,
+		q.CurFiles,
+		q.BTimeLeft,
+		q.FTimeLeft,
+	} {
+		if err := xdr.WriteUint32(buf, v); err != nil {
+			return nil, fmt.Errorf("encode rquota field %d: %w", i, err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
@@ -70,6 +70,50 @@ const (
 	NFSProcCommit = 21
 )
 
+// NFSACLv3 Procedure Numbers
+// The NFS_ACL side protocol (program 100227) that NFSv3 clients use for
+// getfacl/setfacl. It has no RFC; the de facto specification is the Solaris
+// and Linux implementations (fs/nfsd/nfs3acl.c).
+const (
+	// NFSACLProcNull - Do nothing (connectivity test)
+	NFSACLProcNull = 0
+
+	// NFSACLProcGetACL - Get the POSIX access and default ACLs
+	NFSACLProcGetACL = 1
+
+	// NFSACLProcSetACL - Set the POSIX access and default ACLs
+	NFSACLProcSetACL = 2
+)
+
+// NFSACL GETACL/SETACL mask bits select which ACLs (and entry counts) a
+// request carries or asks for.
+const (
+	// NFSACLMaskACL - The access ACL entries
+	NFSACLMaskACL = 0x01
+
+	// NFSACLMaskACLCnt - The access ACL entry count
+	NFSACLMaskACLCnt = 0x02
+
+	// NFSACLMaskDfACL - The default ACL entries
+	NFSACLMaskDfACL = 0x04
+
+	// NFSACLMaskDfACLCnt - The default ACL entry count
+	NFSACLMaskDfACLCnt = 0x08
+
+	// NFSACLMaskAll - Every mask bit a server may honor
+	NFSACLMaskAll = NFSACLMaskACL | NFSACLMaskACLCnt | NFSACLMaskDfACL | NFSACLMaskDfACLCnt
+)
+
+// NFSACL wire limits and flags.
+const (
+	// NFSACLMaxEntries is the maximum number of entries per ACL on the wire
+	// (NFS_ACL_MAX_ENTRIES in Linux).
+	NFSACLMaxEntries = 1024
+
+	// NFSACLDefaultFlag is OR'd into the tag of default ACL entries on the wire.
+	NFSACLDefaultFlag = 0x1000
+)
+
 // NFS Status Codes
 // These are the error codes that can be returned by NFSv3 procedures.
 // Defined in RFC 1813 Section 3.3.
@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 
 	"github.com/marmos91/dittofs/internal/adapter/nfs/auth"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/types"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr"
 	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
 	"github.com/marmos91/dittofs/pkg/metadata"
 )
 
@@ -73,6 +76,37 @@ func BuildAuthContextWithMapping(
 	nfsCtx *NFSHandlerContext,
 	reg nfsRuntime,
 	shareName string,
+) (*metadata.AuthContext, error) {
+	policy, err := resolveExportPolicy(nfsCtx, reg, shareName)
+	if err != nil {
+		return nil, err
+	}
+	return buildAuthContext(nfsCtx, reg, shareName, policy)
+}
+
+// resolveExportPolicy returns the subtree export policy governing the
+// request's file handle, or nil when the share has no subtree exports (or the
+// procedure carries no handle). A client outside the governing export's
+// allowlist gets ErrShareAccessDenied.
+func resolveExportPolicy(nfsCtx *NFSHandlerContext, reg nfsRuntime, shareName string) (*runtime.ExportPolicy, error) {
+	if len(nfsCtx.Handle) == 0 || shareName == "" {
+		return nil, nil
+	}
+	clientIP := net.ParseIP(xdr.ExtractClientIP(nfsCtx.ClientAddr))
+	policy, err := reg.ResolveExportPolicy(nfsCtx.Context, shareName, nfsCtx.Handle, clientIP)
+	if errors.Is(err, runtime.ErrExportAccessDenied) {
+		return nil, fmt.Errorf("%w: %w", ErrShareAccessDenied, err)
+	}
+	return policy, err
+}
+
+// buildAuthContext is BuildAuthContextWithMapping under an already resolved
+// export policy (nil = the share's own settings).
+func buildAuthContext(
+	nfsCtx *NFSHandlerContext,
+	reg nfsRuntime,
+	shareName string,
+	policy *runtime.ExportPolicy,
 ) (*metadata.AuthContext, error) {
 	ctx := nfsCtx.Context
 	clientAddr := nfsCtx.ClientAddr
@@ -109,7 +143,7 @@ func BuildAuthContextWithMapping(
 	if nfsCtx.GID != nil {
 		permGIDs = append(permGIDs, *nfsCtx.GID)
 	}
-	permResult, err := auth.ResolveSharePermission(ctx, identityStore, share, shareName, clientAddr, nfsCtx.UID, permGIDs)
+	permResult, err := auth.ResolveSharePermission(ctx, identityStore, runtime.ApplyExportPolicy(share, policy), shareName, clientAddr, nfsCtx.UID, permGIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -117,8 +151,9 @@ func BuildAuthContextWithMapping(
 		originalIdentity.Username = permResult.Username
 	}
 
-	// Apply share-level identity mapping (all_squash, root_squash)
-	effectiveIdentity, err := reg.ApplyIdentityMapping(shareName, originalIdentity)
+	// Apply share-level identity mapping (all_squash, root_squash), under the
+	// governing subtree export's squash settings when there is one
+	effectiveIdentity, err := reg.ApplyExportIdentityMapping(shareName, policy, originalIdentity)
 	if err != nil {
 		return nil, fmt.Errorf("failed to apply identity mapping: %w", err)
 	}
@@ -127,6 +162,7 @@ func BuildAuthContextWithMapping(
 	effectiveAuthCtx := &metadata.AuthContext{
 		Context:       ctx,
 		ClientAddr:    clientAddr,
+		Protocol:      "nfs3",
 		AuthMethod:    authMethod,
 		Identity:      effectiveIdentity,
 		ShareReadOnly: permResult.ReadOnly,
@@ -144,3 +180,38 @@ func BuildAuthContextWithMapping(
 
 	return effectiveAuthCtx, nil
 }
+
+// layoutAuthContext authorizes a data-server request that presents a pNFS
+// layout credential (see auth.LayoutCredentials) in place of a user
+// identity. ok is false when the request is not one: no credentials are
+// configured, the flavor is not AUTH_SYS, the uid is not a layout uid, or the
+// store never issued a layout. An invalid credential is a share access
+// denial (NFS3ERR_ACCES).
+func (h *Handler) layoutAuthContext(nfsCtx *NFSHandlerContext, policy *runtime.ExportPolicy) (authCtx *metadata.AuthContext, ok bool, err error) {
+	if h.LayoutCreds == nil || nfsCtx.AuthFlavor != rpc.AuthUnix ||
+		nfsCtx.UID == nil || nfsCtx.GID == nil || !auth.IsLayoutUID(*nfsCtx.UID) || len(nfsCtx.Handle) == 0 {
+		return nil, false, nil
+	}
+	grant, err := h.LayoutCreds.VerifyHandle(nfsCtx.Context, h.Registry.GetMetadataService(), nfsCtx.Handle, *nfsCtx.UID, *nfsCtx.GID)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidLayoutCredential) {
+			return nil, true, fmt.Errorf("%w: %w", ErrShareAccessDenied, err)
+		}
+		return nil, true, err
+	}
+	if grant == nil {
+		return nil, false, nil
+	}
+
+	share, _ := h.Registry.GetShare(nfsCtx.Share)
+	share = runtime.ApplyExportPolicy(share, policy)
+	return &metadata.AuthContext{
+		Context:       nfsCtx.Context,
+		ClientAddr:    nfsCtx.ClientAddr,
+		Protocol:      "nfs3",
+		AuthMethod:    "unix",
+		Identity:      auth.LayoutIdentity(*nfsCtx.UID),
+		ShareReadOnly: share != nil && share.ReadOnly,
+		LayoutGrant:   grant,
+	}, true, nil
+}
@@ -7,10 +7,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/marmos91/dittofs/internal/adapter/nfs/auth"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/types"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr"
 	"github.com/marmos91/dittofs/internal/logger"
 	"github.com/marmos91/dittofs/pkg/adapter"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
 	"github.com/marmos91/dittofs/pkg/identity"
 	"github.com/marmos91/dittofs/pkg/metadata"
 )
@@ -33,6 +35,11 @@ type Handler struct {
 	// can stay effective when no invalidation event fires. A zero value means
 	// identity.DefaultCacheTTL (aligned with the pkg/identity positive cache).
 	authCacheTTL time.Duration
+
+	// LayoutCreds verifies the pNFS layout credentials clients present when
+	// this server acts as a Flex Files data server. Shared with the NFSv4
+	// handler, which issues them; nil disables the data-server path.
+	LayoutCreds *auth.LayoutCredentials
 }
 
 // authCacheEntry is a cached auth context plus the time it was built, so
@@ -45,11 +52,12 @@ type authCacheEntry struct {
 // authCacheKey generates a cache key for auth context caching.
 //
 // The key covers every input that BuildAuthContextWithMapping resolves on:
-// the share, the auth flavor (which selects the AuthMethod string), and the
-// full credential set (UID, primary GID, and supplementary GIDs). Including
-// the supplementary GIDs and flavor avoids returning a cached context that
-// was built for a different credential set that happens to share UID/GID.
-func authCacheKey(ctx *NFSHandlerContext) string {
+// the share, the governing subtree export policy (if any), the auth flavor
+// (which selects the AuthMethod string), and the full credential set (UID,
+// primary GID, and supplementary GIDs). Including the supplementary GIDs and
+// flavor avoids returning a cached context that was built for a different
+// credential set that happens to share UID/GID.
+func authCacheKey(ctx *NFSHandlerContext, policy *runtime.ExportPolicy) string {
 	uidVal := uint32(0xFFFFFFFF) // sentinel for nil
 	gidVal := uint32(0xFFFFFFFF)
 	if ctx.UID != nil {
@@ -61,6 +69,10 @@ func authCacheKey(ctx *NFSHandlerContext) string {
 
 	var b strings.Builder
 	b.WriteString(ctx.Share)
+	if policy != nil {
+		b.WriteByte('#')
+		b.WriteString(policy.CacheKey())
+	}
 	appendUint(&b, uint64(ctx.AuthFlavor))
 	appendUint(&b, uint64(uidVal))
 	appendUint(&b, uint64(gidVal))
@@ -110,7 +122,21 @@ func (h *Handler) loadLiveAuthCtx(key string) *metadata.AuthContext {
 func (h *Handler) GetCachedAuthContext(
 	ctx *NFSHandlerContext,
 ) (*metadata.AuthContext, error) {
-	key := authCacheKey(ctx)
+	// The subtree export policy is resolved per request (it depends on the
+	// handle, not just the credentials) and keys the cached context.
+	policy, err := resolveExportPolicy(ctx, h.Registry, ctx.Share)
+	if err != nil {
+		return nil, err
+	}
+
+	// A pNFS layout credential is re-verified against the file on every
+	// request and never cached, so a chmod, chown or fence takes effect at
+	// once.
+	if authCtx, ok, err := h.layoutAuthContext(ctx, policy); ok {
+		return authCtx, err
+	}
+
+	key := authCacheKey(ctx, policy)
 
 	// Fast path: serve a live (non-expired) cache entry, returning a copy with
 	// the current request's context.
@@ -118,6 +144,7 @@ func (h *Handler) GetCachedAuthContext(
 		return &metadata.AuthContext{
 			Context:       ctx.Context,
 			ClientAddr:    ctx.ClientAddr,
+			Protocol:      authCtx.Protocol,
 			AuthMethod:    authCtx.AuthMethod,
 			Identity:      authCtx.Identity,
 			ShareReadOnly: authCtx.ShareReadOnly,
@@ -125,7 +152,7 @@ func (h *Handler) GetCachedAuthContext(
 	}
 
 	// Slow path: build auth context
-	authCtx, err := BuildAuthContextWithMapping(ctx, h.Registry, ctx.Share)
+	authCtx, err := buildAuthContext(ctx, h.Registry, ctx.Share, policy)
 	if err != nil {
 		return nil, err
 	}
@@ -136,6 +163,7 @@ func (h *Handler) GetCachedAuthContext(
 	// re-derives those from the current request anyway.
 	h.authCache.Store(key, &authCacheEntry{
 		authCtx: &metadata.AuthContext{
+			Protocol:      authCtx.Protocol,
 			AuthMethod:    authCtx.AuthMethod,
 			Identity:      authCtx.Identity,
 			ShareReadOnly: authCtx.ShareReadOnly,
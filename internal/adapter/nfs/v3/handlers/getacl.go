@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/types"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/marmos91/dittofs/pkg/metadata/acl"
+)
+
+// Request and Response Structures
+
+// GetACLRequest represents an NFSACL GETACL request from an NFS client.
+// The client provides a file handle and a mask selecting which ACLs to return.
+//
+// The NFSACL v3 side protocol specifies the GETACL procedure as:
+//
+//	GETACL3res ACLPROC3_GETACL(GETACL3args) = 1;
+//
+// Linux clients call it for getfacl(1) and to seed their ACL cache.
+type GetACLRequest struct {
+	// Handle is the NFSv3 file handle of the object whose ACLs are requested.
+	Handle []byte
+
+	// Mask selects the ACLs to return (types.NFSACLMask* bits).
+	Mask uint32
+}
+
+// GetACLResponse represents the response to a GETACL request.
+//
+// The response is encoded in XDR format before being sent back to the client.
+type GetACLResponse struct {
+	NFSResponseBase // Embeds Status field and GetStatus() method
+
+	// Attr contains post-operation attributes of the object (optional).
+	Attr *types.NFSFileAttr
+
+	// Mask echoes the request mask. Only present when Status == types.NFS3OK.
+	Mask uint32
+
+	// Access is the POSIX access ACL, in canonical order.
+	Access []acl.POSIXEntry
+
+	// Default is the POSIX default ACL (directories only; nil when none).
+	Default []acl.POSIXEntry
+}
+
+// Protocol Handler
+
+// GetACL handles NFSACL GETACL (NFSACL v3 procedure 1).
+// Returns the POSIX access ACL and, for directories, the default ACL of a file handle.
+// Delegates to acl.ACLToPOSIX to project the stored ACL; objects without one report
+// the minimal ACL equivalent to their mode bits.
+// No side effects; read-only.
+// Errors: NFS3ErrBadHandle, NFS3ErrInval (unknown mask bits), NFS3ErrStale, NFS3ErrAccess, NFS3ErrIO.
+func (h *Handler) GetACL(
+	ctx *NFSHandlerContext,
+	req *GetACLRequest,
+) (*GetACLResponse, error) {
+	if ctx.isContextCancelled() {
+		logger.DebugCtx(ctx.Context, "GETACL cancelled before processing",
+			"handle", fmt.Sprintf("%x", req.Handle),
+			"client", ctx.ClientAddr,
+			"error", ctx.Context.Err())
+		return &GetACLResponse{NFSResponseBase: NFSResponseBase{Status: types.NFS3ErrIO}}, ctx.Context.Err()
+	}
+
+	clientIP := xdr.LazyClientIP(ctx.ClientAddr)
+
+	logger.DebugCtx(ctx.Context, "GETACL",
+		"handle", fmt.Sprintf("%x", req.Handle),
+		"mask", fmt.Sprintf("0x%x", req.Mask),
+		"client", clientIP,
+		"auth", ctx.AuthFlavor)
+
+	if err := validateNFSACLRequest(req.Handle, req.Mask); err != nil {
+		logger.WarnCtx(ctx.Context, "GETACL validation failed",
+			"handle", fmt.Sprintf("%x", req.Handle),
+			"client", clientIP,
+			"error", err)
+		return &GetACLResponse{NFSResponseBase: NFSResponseBase{Status: err.nfsStatus}}, nil
+	}
+
+	if _, err := getMetadataService(h.Registry); err != nil {
+		logger.ErrorCtx(ctx.Context, "GETACL failed: metadata service not initialized", "client", clientIP, "error", err)
+		return &GetACLResponse{NFSResponseBase: NFSResponseBase{Status: types.NFS3ErrIO}}, nil
+	}
+
+	fileHandle := metadata.FileHandle(req.Handle)
+
+	file, status, err := h.getFileOrError(ctx, fileHandle, "GETACL", req.Handle)
+	if file == nil {
+		return &GetACLResponse{NFSResponseBase: NFSResponseBase{Status: status}}, err
+	}
+
+	nfsAttr := h.convertFileAttrToNFS(fileHandle, &file.FileAttr)
+
+	// Reading an ACL needs no permission beyond share access, as with getxattr
+	// of system.posix_acl_* on a local filesystem.
+	if _, err := h.GetCachedAuthContext(ctx); err != nil {
+		if ctx.Context.Err() != nil {
+			return &GetACLResponse{NFSResponseBase: NFSResponseBase{Status: types.NFS3ErrIO}}, ctx.Context.Err()
+		}
+		logAuthCtxError(ctx.Context, err, "GETACL",
+			"handle", fmt.Sprintf("%x", req.Handle),
+			"client", clientIP)
+		return &GetACLResponse{NFSResponseBase: NFSResponseBase{Status: authDenialStatus(err)}, Attr: nfsAttr}, nil
+	}
+
+	access, dflt := acl.ACLToPOSIX(file.ACL, file.Mode, file.Type == metadata.FileTypeDirectory)
+
+	resp := &GetACLResponse{
+		NFSResponseBase: NFSResponseBase{Status: types.NFS3OK},
+		Attr:            nfsAttr,
+		Mask:            req.Mask,
+	}
+	if req.Mask&(types.NFSACLMaskACL|types.NFSACLMaskACLCnt) != 0 {
+		resp.Access = access
+	}
+	if req.Mask&(types.NFSACLMaskDfACL|types.NFSACLMaskDfACLCnt) != 0 {
+		resp.Default = dflt
+	}
+
+	logger.DebugCtx(ctx.Context, "GETACL successful",
+		"handle", fmt.Sprintf("%x", req.Handle),
+		"access_entries", len(resp.Access),
+		"default_entries", len(resp.Default),
+		"client", clientIP)
+
+	return resp, nil
+}
+
+// Request Validation
+
+// validateNFSACLRequest validates the file handle and mask shared by GETACL
+// and SETACL requests.
+//
+// Checks performed:
+//   - File handle is not empty and within RFC 1813 limits (8-64 bytes)
+//   - Mask carries no bits outside types.NFSACLMaskAll
+//
+// Returns:
+//   - nil if valid
+//   - *validationError with NFS status if invalid
+func validateNFSACLRequest(handle []byte, mask uint32) *validationError {
+	if len(handle) == 0 {
+		return &validationError{
+			message:   "file handle is empty",
+			nfsStatus: types.NFS3ErrBadHandle,
+		}
+	}
+
+	if len(handle) > 64 {
+		return &validationError{
+			message:   fmt.Sprintf("file handle too long: %d bytes (max 64)", len(handle)),
+			nfsStatus: types.NFS3ErrBadHandle,
+		}
+	}
+
+	if len(handle) < 8 {
+		return &validationError{
+			message:   fmt.Sprintf("file handle too short: %d bytes (min 8)", len(handle)),
+			nfsStatus: types.NFS3ErrBadHandle,
+		}
+	}
+
+	if mask&^types.NFSACLMaskAll != 0 {
+		return &validationError{
+			message:   fmt.Sprintf("unknown ACL mask bits: 0x%x", mask&^types.NFSACLMaskAll),
+			nfsStatus: types.NFS3ErrInval,
+		}
+	}
+
+	return nil
+}
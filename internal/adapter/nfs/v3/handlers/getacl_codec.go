@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/types"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr"
+)
+
+// XDR Decoding
+
+// DecodeGetACLRequest decodes a GETACL request from XDR-encoded bytes.
+//
+// The decoding follows the NFSACL v3 GETACL3args layout:
+//  1. File handle length (4 bytes, big-endian uint32)
+//  2. File handle data (variable length, up to 64 bytes)
+//  3. Padding to 4-byte boundary (0-3 bytes)
+//  4. Mask (4 bytes, big-endian uint32)
+//
+// Parameters:
+//   - data: XDR-encoded bytes containing the GETACL request
+//
+// Returns:
+//   - *GetACLRequest: The decoded request containing handle and mask
+//   - error: Any error encountered during decoding (malformed data, invalid length)
+func DecodeGetACLRequest(data []byte) (*GetACLRequest, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("data too short: need at least 4 bytes for handle length, got %d", len(data))
+	}
+
+	reader := bytes.NewReader(data)
+
+	handle, err := xdr.DecodeFileHandleFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode file handle: %w", err)
+	}
+	if handle == nil {
+		return nil, fmt.Errorf("invalid handle length: 0 (must be > 0)")
+	}
+
+	var mask uint32
+	if err := binary.Read(reader, binary.BigEndian, &mask); err != nil {
+		return nil, fmt.Errorf("failed to read mask: %w", err)
+	}
+
+	return &GetACLRequest{Handle: handle, Mask: mask}, nil
+}
+
+// XDR Encoding
+
+// Encode serializes the GetACLResponse into XDR-encoded bytes suitable for
+// transmission over the network.
+//
+// The encoding follows the NFSACL v3 GETACL3res layout:
+//  1. Status code (4 bytes, big-endian uint32)
+//  2. Post-op attributes (present flag + attributes if present)
+//  3. If status == types.NFS3OK:
+//     a. Mask (4 bytes)
+//     b. Access ACL in secattr form (see encodeNFSACL)
+//     c. Default ACL in secattr form, tags flagged with NFSACLDefaultFlag
+//
+// Returns:
+//   - []byte: The XDR-encoded response ready to send to the client
+//   - error: Any error encountered during encoding
+func (resp *GetACLResponse) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := binary.Write(&buf, binary.BigEndian, resp.Status); err != nil {
+		return nil, fmt.Errorf("failed to write status: %w", err)
+	}
+
+	if err := xdr.EncodeOptionalFileAttr(&buf, resp.Attr); err != nil {
+		return nil, fmt.Errorf("failed to encode post-op attributes: %w", err)
+	}
+
+	if resp.Status != types.NFS3OK {
+		return buf.Bytes(), nil
+	}
+
+	if err := binary.Write(&buf, binary.BigEndian, resp.Mask); err != nil {
+		return nil, fmt.Errorf("failed to write mask: %w", err)
+	}
+
+	if err := encodeNFSACL(&buf, resp.Access, resp.Mask&types.NFSACLMaskACL != 0, 0); err != nil {
+		return nil, fmt.Errorf("failed to encode access ACL: %w", err)
+	}
+	if err := encodeNFSACL(&buf, resp.Default, resp.Mask&types.NFSACLMaskDfACL != 0, types.NFSACLDefaultFlag); err != nil {
+		return nil, fmt.Errorf("failed to encode default ACL: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
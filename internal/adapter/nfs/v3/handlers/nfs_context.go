@@ -1,6 +1,10 @@
 package handlers
 
-import "context"
+import (
+	"context"
+
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
 
 // NFSHandlerContext is the unified context used by all NFS v3 procedure handlers.
 //
@@ -41,6 +45,11 @@ type NFSHandlerContext struct {
 	// Used for routing operations to the correct metadata store.
 	Share string
 
+	// Handle is the request's first file handle, decoded at the connection
+	// layer (nil when the procedure carries none). On a share with subtree
+	// exports it selects the export whose rules govern the request.
+	Handle metadata.FileHandle
+
 	// AuthFlavor indicates the RPC authentication method.
 	// Common values:
 	//   - 0: AUTH_NULL (no authentication)
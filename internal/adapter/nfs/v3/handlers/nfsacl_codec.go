@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/types"
+	"github.com/marmos91/dittofs/pkg/metadata/acl"
+)
+
+// NFSACL secattr Encoding
+//
+// GETACL and SETACL carry each POSIX ACL (access, then default) as:
+//  1. Entry count (4 bytes)
+//  2. Entry array: length (4 bytes) followed by that many entries
+//
+// Each entry is tag (4 bytes, OR'd with NFSACLDefaultFlag for default ACL
+// entries), id (4 bytes, only meaningful for named users/groups) and perm
+// (4 bytes, rwx). The count is sent even when the array is omitted, which is
+// how GETACL answers an NFSACLMaskACLCnt-only request.
+
+// encodeNFSACL writes one POSIX ACL in secattr form. A minimal three-entry
+// ACL is padded with a MASK entry equal to GROUP_OBJ, as Linux nfsd does:
+// the Linux client strips exactly that padding again, and other clients
+// (Solaris VxFS) expect at least four entries.
+func encodeNFSACL(buf *bytes.Buffer, entries []acl.POSIXEntry, withEntries bool, tagFlag uint32) error {
+	if len(entries) == 3 {
+		var groupPerm uint32
+		for _, e := range entries {
+			if e.Tag == acl.POSIXTagGroupObj {
+				groupPerm = e.Perm
+			}
+		}
+		entries = append(slices.Clone(entries), acl.POSIXEntry{Tag: acl.POSIXTagMask, Perm: groupPerm})
+		acl.SortPOSIXEntries(entries)
+	}
+
+	if err := binary.Write(buf, binary.BigEndian, uint32(len(entries))); err != nil {
+		return fmt.Errorf("failed to write ACL count: %w", err)
+	}
+
+	if !withEntries {
+		entries = nil
+	}
+	if err := binary.Write(buf, binary.BigEndian, uint32(len(entries))); err != nil {
+		return fmt.Errorf("failed to write ACL array length: %w", err)
+	}
+	for _, e := range entries {
+		var id uint32
+		if e.Tag == acl.POSIXTagUser || e.Tag == acl.POSIXTagGroup {
+			id = e.ID
+		}
+		for _, v := range []uint32{e.Tag | tagFlag, id, e.Perm} {
+			if err := binary.Write(buf, binary.BigEndian, v); err != nil {
+				return fmt.Errorf("failed to write ACL entry: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// decodeNFSACL reads one POSIX ACL in secattr form, strips the default-ACL
+// tag flag, sorts the entries canonically and drops the MASK padding a
+// client adds to a minimal ACL (four entries with MASK equal to GROUP_OBJ).
+// An empty ACL decodes to nil.
+func decodeNFSACL(reader io.Reader) ([]acl.POSIXEntry, error) {
+	var count, length uint32
+	if err := binary.Read(reader, binary.BigEndian, &count); err != nil {
+		return nil, fmt.Errorf("failed to read ACL count: %w", err)
+	}
+	if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+		return nil, fmt.Errorf("failed to read ACL array length: %w", err)
+	}
+	if length > types.NFSACLMaxEntries {
+		return nil, fmt.Errorf("ACL has %d entries (max %d)", length, types.NFSACLMaxEntries)
+	}
+	if length == 0 {
+		return nil, nil
+	}
+
+	entries := make([]acl.POSIXEntry, length)
+	for i := range entries {
+		var raw [3]uint32
+		if err := binary.Read(reader, binary.BigEndian, &raw); err != nil {
+			return nil, fmt.Errorf("failed to read ACL entry %d: %w", i, err)
+		}
+		entries[i] = acl.POSIXEntry{Tag: raw[0] &^ types.NFSACLDefaultFlag, ID: raw[1], Perm: raw[2]}
+	}
+
+	acl.SortPOSIXEntries(entries)
+	if len(entries) == 4 && acl.IsMinimalPOSIXACL(entries) &&
+		entries[1].Tag == acl.POSIXTagGroupObj && entries[2].Tag == acl.POSIXTagMask &&
+		entries[1].Perm == entries[2].Perm {
+		entries = append(entries[:2], entries[3])
+	}
+	return entries, nil
+}
@@ -0,0 +1,227 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/types"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v3/handlers"
+	handlertesting "github.com/marmos91/dittofs/internal/adapter/nfs/v3/handlers/testing"
+	"github.com/marmos91/dittofs/pkg/metadata/acl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNFSACL tests the NFSACL v3 GETACL/SETACL handlers (program 100227),
+// which Linux clients use for getfacl(1)/setfacl(1) over NFSv3.
+
+// namedUserACL is an access ACL granting uid 2000 read/write on top of mode 0640.
+var namedUserACL = []acl.POSIXEntry{
+	{Tag: acl.POSIXTagUserObj, Perm: 6},
+	{Tag: acl.POSIXTagUser, ID: 2000, Perm: 6},
+	{Tag: acl.POSIXTagGroupObj, Perm: 4},
+	{Tag: acl.POSIXTagMask, Perm: 6},
+	{Tag: acl.POSIXTagOther, Perm: 0},
+}
+
+// TestGetACL_ModeOnly tests that an object without a stored ACL reports the
+// minimal ACL equivalent to its mode bits.
+func TestGetACL_ModeOnly(t *testing.T) {
+	fx := handlertesting.NewHandlerFixture(t)
+	fileHandle := fx.CreateFile("plain.txt", []byte("content"))
+
+	resp, err := fx.Handler.GetACL(fx.Context(), &handlers.GetACLRequest{
+		Handle: fileHandle,
+		Mask:   types.NFSACLMaskAll,
+	})
+
+	require.NoError(t, err)
+	assert.EqualValues(t, types.NFS3OK, resp.Status)
+	require.NotNil(t, resp.Attr)
+	assert.Equal(t, acl.POSIXFromMode(0o644), resp.Access)
+	assert.Nil(t, resp.Default, "regular files have no default ACL")
+}
+
+// TestGetACL_InvalidMask tests that unknown mask bits are rejected.
+func TestGetACL_InvalidMask(t *testing.T) {
+	fx := handlertesting.NewHandlerFixture(t)
+	fileHandle := fx.CreateFile("mask.txt", nil)
+
+	resp, err := fx.Handler.GetACL(fx.Context(), &handlers.GetACLRequest{
+		Handle: fileHandle,
+		Mask:   0x100,
+	})
+
+	require.NoError(t, err)
+	assert.EqualValues(t, types.NFS3ErrInval, resp.Status)
+}
+
+// TestSetACL_NamedUserRoundTrip tests that a named-user ACL set via SETACL is
+// returned unchanged by GETACL and that the mode follows the access ACL.
+func TestSetACL_NamedUserRoundTrip(t *testing.T) {
+	fx := handlertesting.NewHandlerFixture(t)
+	fileHandle := fx.CreateFile("shared.txt", []byte("content"))
+
+	setResp, err := fx.Handler.SetACL(fx.Context(), &handlers.SetACLRequest{
+		Handle: fileHandle,
+		Mask:   types.NFSACLMaskACL,
+		Access: namedUserACL,
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, types.NFS3OK, setResp.Status)
+	require.NotNil(t, setResp.Attr)
+	assert.EqualValues(t, 0o660, setResp.Attr.Mode&0o777, "group bits should carry the mask")
+
+	getResp, err := fx.Handler.GetACL(fx.Context(), &handlers.GetACLRequest{
+		Handle: fileHandle,
+		Mask:   types.NFSACLMaskACL,
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, types.NFS3OK, getResp.Status)
+	assert.Equal(t, namedUserACL, getResp.Access)
+
+	file := fx.GetFile("shared.txt")
+	require.NotNil(t, file.ACL, "a non-minimal ACL should be stored")
+	assert.Equal(t, acl.ACLSourcePOSIXExplicit, file.ACL.Source)
+}
+
+// TestSetACL_DirectoryDefault tests setting and clearing a default ACL on a directory.
+func TestSetACL_DirectoryDefault(t *testing.T) {
+	fx := handlertesting.NewHandlerFixture(t)
+	dirHandle := fx.CreateDirectory("project")
+
+	dflt := []acl.POSIXEntry{
+		{Tag: acl.POSIXTagUserObj, Perm: 7},
+		{Tag: acl.POSIXTagGroupObj, Perm: 5},
+		{Tag: acl.POSIXTagGroup, ID: 3000, Perm: 5},
+		{Tag: acl.POSIXTagMask, Perm: 5},
+		{Tag: acl.POSIXTagOther, Perm: 0},
+	}
+
+	setResp, err := fx.Handler.SetACL(fx.Context(), &handlers.SetACLRequest{
+		Handle:  dirHandle,
+		Mask:    types.NFSACLMaskDfACL,
+		Default: dflt,
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, types.NFS3OK, setResp.Status)
+
+	getResp, err := fx.Handler.GetACL(fx.Context(), &handlers.GetACLRequest{
+		Handle: dirHandle,
+		Mask:   types.NFSACLMaskAll,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, acl.POSIXFromMode(0o755), getResp.Access, "access ACL should be untouched")
+	assert.Equal(t, dflt, getResp.Default)
+
+	// An empty default ACL removes it.
+	setResp, err = fx.Handler.SetACL(fx.Context(), &handlers.SetACLRequest{
+		Handle: dirHandle,
+		Mask:   types.NFSACLMaskDfACL,
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, types.NFS3OK, setResp.Status)
+
+	getResp, err = fx.Handler.GetACL(fx.Context(), &handlers.GetACLRequest{
+		Handle: dirHandle,
+		Mask:   types.NFSACLMaskDfACL,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, getResp.Default)
+}
+
+// TestSetACL_DefaultOnFile tests that a default ACL on a regular file is rejected.
+func TestSetACL_DefaultOnFile(t *testing.T) {
+	fx := handlertesting.NewHandlerFixture(t)
+	fileHandle := fx.CreateFile("file.txt", nil)
+
+	resp, err := fx.Handler.SetACL(fx.Context(), &handlers.SetACLRequest{
+		Handle:  fileHandle,
+		Mask:    types.NFSACLMaskDfACL,
+		Default: acl.POSIXFromMode(0o755),
+	})
+
+	require.NoError(t, err)
+	assert.EqualValues(t, types.NFS3ErrInval, resp.Status)
+}
+
+// TestSetACL_Malformed tests that an ACL with named entries but no MASK is rejected.
+func TestSetACL_Malformed(t *testing.T) {
+	fx := handlertesting.NewHandlerFixture(t)
+	fileHandle := fx.CreateFile("bad.txt", nil)
+
+	resp, err := fx.Handler.SetACL(fx.Context(), &handlers.SetACLRequest{
+		Handle: fileHandle,
+		Mask:   types.NFSACLMaskACL,
+		Access: []acl.POSIXEntry{
+			{Tag: acl.POSIXTagUserObj, Perm: 6},
+			{Tag: acl.POSIXTagUser, ID: 2000, Perm: 6},
+			{Tag: acl.POSIXTagGroupObj, Perm: 4},
+			{Tag: acl.POSIXTagOther, Perm: 0},
+		},
+	})
+
+	require.NoError(t, err)
+	assert.EqualValues(t, types.NFS3ErrInval, resp.Status)
+}
+
+// TestSetACL_NotOwner tests that only the owner may change an ACL.
+func TestSetACL_NotOwner(t *testing.T) {
+	fx := handlertesting.NewHandlerFixture(t)
+	fileHandle := fx.CreateFile("owned.txt", nil)
+
+	resp, err := fx.Handler.SetACL(fx.ContextWithUID(4242, 4242), &handlers.SetACLRequest{
+		Handle: fileHandle,
+		Mask:   types.NFSACLMaskACL,
+		Access: namedUserACL,
+	})
+
+	require.NoError(t, err)
+	assert.NotEqualValues(t, types.NFS3OK, resp.Status)
+	assert.Nil(t, fx.GetFile("owned.txt").ACL)
+}
+
+// TestSetACL_MinimalStaysModeOnly tests that a minimal ACL on a mode-only
+// object acts as a chmod without materializing a stored ACL.
+func TestSetACL_MinimalStaysModeOnly(t *testing.T) {
+	fx := handlertesting.NewHandlerFixture(t)
+	fileHandle := fx.CreateFile("chmod.txt", nil)
+
+	resp, err := fx.Handler.SetACL(fx.Context(), &handlers.SetACLRequest{
+		Handle: fileHandle,
+		Mask:   types.NFSACLMaskACL,
+		Access: acl.POSIXFromMode(0o600),
+	})
+
+	require.NoError(t, err)
+	require.EqualValues(t, types.NFS3OK, resp.Status)
+	file := fx.GetFile("chmod.txt")
+	assert.EqualValues(t, 0o600, file.Mode&0o777)
+	assert.Nil(t, file.ACL)
+}
+
+// TestNFSACLCodec_RoundTrip tests SETACL decoding of the wire form GETACL
+// encodes, including the MASK padding of a minimal ACL.
+func TestNFSACLCodec_RoundTrip(t *testing.T) {
+	handle := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	getResp := &handlers.GetACLResponse{
+		NFSResponseBase: handlers.NFSResponseBase{Status: types.NFS3OK},
+		Mask:            types.NFSACLMaskACL | types.NFSACLMaskDfACL,
+		Access:          acl.POSIXFromMode(0o640),
+		Default:         namedUserACL,
+	}
+	encoded, err := getResp.Encode()
+	require.NoError(t, err)
+
+	// GETACL3resok after status + post_op_attr(absent) is mask + two ACLs,
+	// the same tail as SETACL3args after the handle.
+	tail := encoded[8:]
+	args := append([]byte{0, 0, 0, 8}, handle...)
+	args = append(args, tail...)
+
+	req, err := handlers.DecodeSetACLRequest(args)
+	require.NoError(t, err)
+	assert.Equal(t, handle, req.Handle)
+	assert.Equal(t, getResp.Mask, req.Mask)
+	assert.Equal(t, acl.POSIXFromMode(0o640), req.Access, "MASK padding should be stripped")
+	assert.Equal(t, namedUserACL, req.Default)
+}
@@ -1,8 +1,10 @@
 package handlers_test
 
 import (
+	"context"
 	"testing"
 
+	"github.com/marmos91/dittofs/internal/adapter/nfs/auth"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/types"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/v3/handlers"
 	handlertesting "github.com/marmos91/dittofs/internal/adapter/nfs/v3/handlers/testing"
@@ -452,3 +454,43 @@ func TestRead_PermissionGranted(t *testing.T) {
 	assert.EqualValues(t, types.NFS3OK, resp.Status)
 	assert.Equal(t, content, resp.Data)
 }
+
+// TestRead_LayoutCredential tests data-server I/O under pNFS layout
+// credentials: a read layout reads but cannot write, an RW layout writes,
+// and a forged credential is refused.
+func TestRead_LayoutCredential(t *testing.T) {
+	fx := handlertesting.NewHandlerFixture(t)
+	fx.Handler.LayoutCreds = auth.NewLayoutCredentials()
+
+	content := []byte("hello world")
+	fileHandle := fx.CreateFile("layout.txt", content)
+	file := fx.GetFile("layout.txt")
+	uid, gid, err := fx.Handler.LayoutCreds.Issue(context.Background(), fx.MetaStore, fileHandle, file, false, 1)
+	require.NoError(t, err)
+
+	dsContext := func(uid, gid uint32) *handlers.NFSHandlerContext {
+		ctx := fx.ContextWithUID(uid, gid)
+		ctx.Handle = fileHandle
+		return ctx
+	}
+
+	readReq := &handlers.ReadRequest{Handle: fileHandle, Count: 100}
+	resp, err := fx.Handler.Read(dsContext(uid, gid), readReq)
+	require.NoError(t, err)
+	assert.EqualValues(t, types.NFS3OK, resp.Status, "READ with a read layout credential should succeed")
+
+	writeReq := &handlers.WriteRequest{Handle: fileHandle, Count: 1, Stable: 2, Data: []byte("x")}
+	wresp, err := fx.Handler.Write(dsContext(uid, gid), writeReq)
+	require.NoError(t, err)
+	assert.NotEqualValues(t, types.NFS3OK, wresp.Status, "WRITE with a read layout credential should fail")
+
+	wuid, wgid, err := fx.Handler.LayoutCreds.Issue(context.Background(), fx.MetaStore, fileHandle, file, true, 1)
+	require.NoError(t, err)
+	wresp, err = fx.Handler.Write(dsContext(wuid, wgid), writeReq)
+	require.NoError(t, err)
+	assert.EqualValues(t, types.NFS3OK, wresp.Status, "WRITE with an RW layout credential should succeed")
+
+	resp, err = fx.Handler.Read(dsContext(uid, gid^1), readReq)
+	require.NoError(t, err)
+	assert.EqualValues(t, types.NFS3ErrAccess, resp.Status, "READ with a forged layout credential should be refused")
+}
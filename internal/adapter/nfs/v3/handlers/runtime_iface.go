@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"context"
+	"net"
+
 	"github.com/marmos91/dittofs/internal/adapter/common"
 	"github.com/marmos91/dittofs/pkg/controlplane/models"
 	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
@@ -26,6 +29,11 @@ type nfsRuntime interface {
 	GetIdentityStore() models.IdentityStore
 	ApplyIdentityMapping(shareName string, ident *metadata.Identity) (*metadata.Identity, error)
 
+	// Subtree exports: the export policy governing a handle, and identity
+	// mapping under that policy's squash settings.
+	ResolveExportPolicy(ctx context.Context, shareName string, handle metadata.FileHandle, clientIP net.IP) (*runtime.ExportPolicy, error)
+	ApplyExportIdentityMapping(shareName string, policy *runtime.ExportPolicy, ident *metadata.Identity) (*metadata.Identity, error)
+
 	// Adapter-provided extensions (e.g. GSS processor) keyed by name.
 	GetAdapterProvider(key string) any
 
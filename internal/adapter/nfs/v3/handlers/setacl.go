@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/types"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/marmos91/dittofs/pkg/metadata/acl"
+)
+
+// Request and Response Structures
+
+// SetACLRequest represents an NFSACL SETACL request from an NFS client.
+//
+// The NFSACL v3 side protocol specifies the SETACL procedure as:
+//
+//	SETACL3res ACLPROC3_SETACL(SETACL3args) = 2;
+//
+// Linux clients call it for setfacl(1) and for ACL-aware copies (cp -a).
+type SetACLRequest struct {
+	// Handle is the NFSv3 file handle of the object whose ACLs are replaced.
+	Handle []byte
+
+	// Mask selects which ACLs the request replaces: NFSACLMaskACL for the
+	// access ACL, NFSACLMaskDfACL for the default ACL.
+	Mask uint32
+
+	// Access is the new access ACL. Empty resets the access ACL to the
+	// minimal ACL of the current mode bits.
+	Access []acl.POSIXEntry
+
+	// Default is the new default ACL. Empty removes the default ACL.
+	Default []acl.POSIXEntry
+}
+
+// SetACLResponse represents the response to a SETACL request.
+type SetACLResponse struct {
+	NFSResponseBase // Embeds Status field and GetStatus() method
+
+	// Attr contains post-operation attributes of the object (optional).
+	Attr *types.NFSFileAttr
+}
+
+// Protocol Handler
+
+// SetACL handles NFSACL SETACL (NFSACL v3 procedure 2).
+// Replaces the POSIX access and/or default ACL selected by the mask, translating them
+// into the internal ACL model via acl.POSIXToACL; ACEs for principals POSIX cannot name
+// (SMB SIDs, SYSTEM@) are preserved. The mode's permission bits follow the access ACL.
+// Delegates to MetadataService.SetFileAttributes, so only the owner (or root) may change
+// ACLs and read-only shares are refused.
+// Errors: NFS3ErrBadHandle, NFS3ErrInval (malformed ACL, default ACL on a non-directory),
+// NFS3ErrStale, NFS3ErrPerm, NFS3ErrAccess, NFS3ErrRofs, NFS3ErrIO.
+func (h *Handler) SetACL(
+	ctx *NFSHandlerContext,
+	req *SetACLRequest,
+) (*SetACLResponse, error) {
+	if ctx.isContextCancelled() {
+		logger.DebugCtx(ctx.Context, "SETACL cancelled before processing",
+			"handle", fmt.Sprintf("%x", req.Handle),
+			"client", ctx.ClientAddr,
+			"error", ctx.Context.Err())
+		return nil, ctx.Context.Err()
+	}
+
+	clientIP := xdr.LazyClientIP(ctx.ClientAddr)
+
+	logger.DebugCtx(ctx.Context, "SETACL",
+		"handle", fmt.Sprintf("%x", req.Handle),
+		"mask", fmt.Sprintf("0x%x", req.Mask),
+		"access_entries", len(req.Access),
+		"default_entries", len(req.Default),
+		"client", clientIP,
+		"auth", ctx.AuthFlavor)
+
+	if err := validateNFSACLRequest(req.Handle, req.Mask); err != nil {
+		logger.WarnCtx(ctx.Context, "SETACL validation failed",
+			"handle", fmt.Sprintf("%x", req.Handle),
+			"client", clientIP,
+			"error", err)
+		return &SetACLResponse{NFSResponseBase: NFSResponseBase{Status: err.nfsStatus}}, nil
+	}
+
+	metaSvc, err := getMetadataService(h.Registry)
+	if err != nil {
+		logger.ErrorCtx(ctx.Context, "SETACL failed: metadata service not initialized", "client", clientIP, "error", err)
+		return &SetACLResponse{NFSResponseBase: NFSResponseBase{Status: types.NFS3ErrIO}}, nil
+	}
+
+	fileHandle := metadata.FileHandle(req.Handle)
+
+	// SETACL needs the stored ACL, so read the full file rather than the
+	// attribute-only fast path getFileOrError uses.
+	file, err := metaSvc.GetFile(ctx.Context, fileHandle)
+	if err != nil {
+		if ctx.Context.Err() != nil {
+			return nil, ctx.Context.Err()
+		}
+		logger.DebugCtx(ctx.Context, "SETACL failed: handle not found",
+			"handle", fmt.Sprintf("%x", req.Handle),
+			"client", clientIP,
+			"error", err)
+		return &SetACLResponse{NFSResponseBase: NFSResponseBase{Status: types.NFS3ErrStale}}, nil
+	}
+
+	currentAttr := h.convertFileAttrToNFS(fileHandle, &file.FileAttr)
+	isDirectory := file.Type == metadata.FileTypeDirectory
+
+	access, dflt, status := resolveSetACL(req, file, isDirectory)
+	if status != types.NFS3OK {
+		logger.DebugCtx(ctx.Context, "SETACL rejected: invalid ACL",
+			"handle", fmt.Sprintf("%x", req.Handle),
+			"status", status,
+			"client", clientIP)
+		return &SetACLResponse{NFSResponseBase: NFSResponseBase{Status: status}, Attr: currentAttr}, nil
+	}
+
+	authCtx, wccAfter, authStatus, err := h.buildAuthContextWithWCCError(ctx, fileHandle, &file.FileAttr, "SETACL", "", req.Handle)
+	if authCtx == nil {
+		return &SetACLResponse{NFSResponseBase: NFSResponseBase{Status: authStatus}, Attr: wccAfter}, err
+	}
+
+	// The permission bits follow the access ACL, as chmod would; the
+	// setuid/setgid/sticky bits are kept.
+	mode := (file.Mode &^ 0o777) | acl.POSIXModeBits(access)
+	attrs := &metadata.SetAttrs{Mode: &mode}
+
+	// A minimal ACL on an object without a stored ACL is just a chmod: keep
+	// the object mode-only rather than materializing an equivalent ACL.
+	if file.ACL != nil || !acl.IsMinimalPOSIXACL(access) || len(dflt) > 0 {
+		attrs.ACL = acl.POSIXToACL(access, dflt, isDirectory, file.ACL)
+	}
+
+	setWcc, err := metaSvc.SetFileAttributes(authCtx, fileHandle, attrs)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+
+		logError(ctx.Context, err, "SETACL failed: store error",
+			"handle", fmt.Sprintf("%x", req.Handle),
+			"client", clientIP)
+
+		return &SetACLResponse{
+			NFSResponseBase: NFSResponseBase{Status: xdr.MapStoreErrorToNFSStatus(err, clientIP.String(), "SETACL")},
+			Attr:            currentAttr,
+		}, nil
+	}
+
+	var nfsAttr *types.NFSFileAttr
+	if setWcc != nil && setWcc.After != nil {
+		nfsAttr = h.convertFileAttrToNFS(fileHandle, setWcc.After)
+	} else {
+		nfsAttr = h.wccAfterOrFallback(ctx, metaSvc, fileHandle, &file.FileAttr)
+	}
+
+	logger.DebugCtx(ctx.Context, "SETACL successful",
+		"handle", fmt.Sprintf("%x", req.Handle),
+		"mode", fmt.Sprintf("%o", mode),
+		"stored_acl", attrs.ACL != nil,
+		"client", clientIP)
+
+	return &SetACLResponse{
+		NFSResponseBase: NFSResponseBase{Status: types.NFS3OK},
+		Attr:            nfsAttr,
+	}, nil
+}
+
+// resolveSetACL computes the access and default ACLs a SETACL request leaves
+// on file. An ACL the mask does not select keeps its current value; an empty
+// access ACL resets to the mode's minimal ACL and an empty default ACL
+// removes it. Returns NFS3ErrInval for a malformed ACL or a default ACL on a
+// non-directory.
+func resolveSetACL(req *SetACLRequest, file *metadata.File, isDirectory bool) (access, dflt []acl.POSIXEntry, status uint32) {
+	access, dflt = acl.ACLToPOSIX(file.ACL, file.Mode, isDirectory)
+
+	if req.Mask&types.NFSACLMaskACL != 0 {
+		access = req.Access
+		if len(access) == 0 {
+			access = acl.POSIXFromMode(file.Mode)
+		}
+	}
+	if req.Mask&types.NFSACLMaskDfACL != 0 {
+		dflt = req.Default
+		if len(dflt) > 0 && !isDirectory {
+			return nil, nil, types.NFS3ErrInval
+		}
+	}
+
+	if err := acl.ValidatePOSIXACL(access); err != nil {
+		return nil, nil, types.NFS3ErrInval
+	}
+	if len(dflt) > 0 {
+		if err := acl.ValidatePOSIXACL(dflt); err != nil {
+			return nil, nil, types.NFS3ErrInval
+		}
+	}
+	return access, dflt, types.NFS3OK
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr"
+)
+
+// XDR Decoding
+
+// DecodeSetACLRequest decodes a SETACL request from XDR-encoded bytes.
+//
+// The decoding follows the NFSACL v3 SETACL3args layout:
+//  1. File handle length (4 bytes, big-endian uint32)
+//  2. File handle data (variable length, up to 64 bytes)
+//  3. Padding to 4-byte boundary (0-3 bytes)
+//  4. Mask (4 bytes, big-endian uint32)
+//  5. Access ACL in secattr form (see decodeNFSACL)
+//  6. Default ACL in secattr form
+//
+// Parameters:
+//   - data: XDR-encoded bytes containing the SETACL request
+//
+// Returns:
+//   - *SetACLRequest: The decoded request containing handle, mask and ACLs
+//   - error: Any error encountered during decoding (malformed data, invalid length)
+func DecodeSetACLRequest(data []byte) (*SetACLRequest, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("data too short: need at least 4 bytes for handle length, got %d", len(data))
+	}
+
+	reader := bytes.NewReader(data)
+
+	handle, err := xdr.DecodeFileHandleFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode file handle: %w", err)
+	}
+	if handle == nil {
+		return nil, fmt.Errorf("invalid handle length: 0 (must be > 0)")
+	}
+
+	var mask uint32
+	if err := binary.Read(reader, binary.BigEndian, &mask); err != nil {
+		return nil, fmt.Errorf("failed to read mask: %w", err)
+	}
+
+	access, err := decodeNFSACL(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode access ACL: %w", err)
+	}
+	dflt, err := decodeNFSACL(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode default ACL: %w", err)
+	}
+
+	return &SetACLRequest{Handle: handle, Mask: mask, Access: access, Default: dflt}, nil
+}
+
+// XDR Encoding
+
+// Encode serializes the SetACLResponse into XDR-encoded bytes suitable for
+// transmission over the network.
+//
+// The encoding follows the NFSACL v3 SETACL3res layout:
+//  1. Status code (4 bytes, big-endian uint32)
+//  2. Post-op attributes (present flag + attributes if present)
+//
+// Returns:
+//   - []byte: The XDR-encoded response ready to send to the client
+//   - error: Any error encountered during encoding
+func (resp *SetACLResponse) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := binary.Write(&buf, binary.BigEndian, resp.Status); err != nil {
+		return nil, fmt.Errorf("failed to write status: %w", err)
+	}
+
+	if err := xdr.EncodeOptionalFileAttr(&buf, resp.Attr); err != nil {
+		return nil, fmt.Errorf("failed to encode post-op attributes: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -49,6 +49,7 @@ func isWritableAttr(bit uint32) bool {
 //   - "N@domain" where N is numeric (e.g., "1000@localdomain" -> 1000)
 //   - "N" bare numeric (e.g., "0" -> 0)
 //   - Well-known names: "root@localdomain" -> 0, "nobody@localdomain" -> 65534
+//   - "name@domain" or "name" through the configured ID mapping (SetIDMapping)
 //
 // Returns NFS4ERR_BADOWNER-style error for unrecognized names.
 func ParseOwnerString(owner string) (uint32, error) {
@@ -69,6 +70,9 @@ func ParseOwnerString(owner string) (uint32, error) {
 			return 65534, nil
 		}
 
+		if uid, ok := mapOwnerName(owner, false); ok {
+			return uid, nil
+		}
 		return 0, fmt.Errorf("invalid owner string: %s", owner)
 	}
 
@@ -85,6 +89,9 @@ func ParseOwnerString(owner string) (uint32, error) {
 		return 65534, nil
 	}
 
+	if uid, ok := mapOwnerName(owner, false); ok {
+		return uid, nil
+	}
 	return 0, fmt.Errorf("invalid owner string: %s", owner)
 }
 
@@ -95,6 +102,7 @@ func ParseOwnerString(owner string) (uint32, error) {
 //   - "N" bare numeric (e.g., "0" -> 0)
 //   - Well-known names: "root@localdomain" -> 0, "nogroup@localdomain" -> 65534,
 //     "nobody@localdomain" -> 65534
+//   - "group@domain" or "group" through the configured ID mapping (SetIDMapping)
 //
 // Returns NFS4ERR_BADOWNER-style error for unrecognized names.
 func ParseGroupString(group string) (uint32, error) {
@@ -115,6 +123,9 @@ func ParseGroupString(group string) (uint32, error) {
 			return 65534, nil
 		}
 
+		if gid, ok := mapOwnerName(group, true); ok {
+			return gid, nil
+		}
 		return 0, fmt.Errorf("invalid group string: %s", group)
 	}
 
@@ -131,6 +142,9 @@ func ParseGroupString(group string) (uint32, error) {
 		return 65534, nil
 	}
 
+	if gid, ok := mapOwnerName(group, true); ok {
+		return gid, nil
+	}
 	return 0, fmt.Errorf("invalid group string: %s", group)
 }
 
@@ -2,13 +2,11 @@ package attrs
 
 import (
 	"bytes"
-	"context"
 	"fmt"
 	"sync/atomic"
 
 	v4types "github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
-	"github.com/marmos91/dittofs/pkg/adapter/nfs/identity"
 	"github.com/marmos91/dittofs/pkg/metadata"
 )
 
@@ -64,6 +62,8 @@ const (
 	FATTR4_SPACE_TOTAL        = 59 // uint64: total filesystem space in bytes (RFC 7530 Section 5.8.2.28)
 	FATTR4_SPACE_FREE         = 60 // uint64: free filesystem space in bytes (RFC 7530 Section 5.8.2.29)
 	FATTR4_SPACE_AVAIL        = 61 // uint64: available space for caller in bytes (RFC 7530 Section 5.8.2.30)
+	FATTR4_FS_LAYOUT_TYPES    = 62 // layouttype4<>: pNFS layout types of the filesystem (RFC 8881 Section 5.12.1)
+	FATTR4_LAYOUT_BLKSIZE     = 65 // uint32: preferred pNFS I/O block size (RFC 8881 Section 5.12.4)
 	FATTR4_SUPPATTR_EXCLCREAT = 75 // bitmap4: attrs settable during EXCLUSIVE4_1 create (RFC 8881 Section 5.8.1.10)
 	FATTR4_CLONE_BLKSIZE      = 77 // uint32: preferred block size for CLONE (RFC 7862 Section 12.2.1; word 2, bit 13)
 	FATTR4_XATTR_SUPPORT      = 82 // bool: extended attributes supported (RFC 8276 Section 8.4; word 2, bit 18)
@@ -116,6 +116,40 @@ func init() {
 	fsMaxWriteSize.Store(1048576)  // 1MB
 }
 
+// fsLayoutTypes holds the pNFS layout types reported by
+// FATTR4_FS_LAYOUT_TYPES. Empty (nil) unless pNFS data servers are configured;
+// the Linux client only asks for layouts when this list is non-empty.
+var fsLayoutTypes atomic.Pointer[[]uint32]
+
+// SetLayoutTypes configures the layout types returned by
+// FATTR4_FS_LAYOUT_TYPES. Pass nil to advertise no pNFS support.
+// Thread-safe: uses atomic store.
+func SetLayoutTypes(layoutTypes []uint32) {
+	if len(layoutTypes) == 0 {
+		fsLayoutTypes.Store(nil)
+		return
+	}
+	cp := append([]uint32(nil), layoutTypes...)
+	fsLayoutTypes.Store(&cp)
+}
+
+// encodeLayoutTypes writes the FATTR4_FS_LAYOUT_TYPES value (layouttype4<>).
+func encodeLayoutTypes(buf *bytes.Buffer) error {
+	var layoutTypes []uint32
+	if p := fsLayoutTypes.Load(); p != nil {
+		layoutTypes = *p
+	}
+	if err := xdr.WriteUint32(buf, uint32(len(layoutTypes))); err != nil {
+		return err
+	}
+	for _, t := range layoutTypes {
+		if err := xdr.WriteUint32(buf, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CloneBlockSize is the value reported by FATTR4_CLONE_BLKSIZE and the
 // alignment a CLONE source offset, destination offset, and count must satisfy
 // (RFC 7862 Section 15.13). DittoFS clones by splicing the content-addressed
@@ -137,32 +171,6 @@ func SetFilesystemCapabilities(maxFileSize uint64, maxReadSize, maxWriteSize uin
 	fsMaxWriteSize.Store(uint64(maxWriteSize))
 }
 
-// identityMapper holds the configured identity mapper for FATTR4_OWNER/OWNER_GROUP encoding.
-// When nil (zero Pointer), the numeric UID/GID format is used as fallback.
-// Uses atomic.Pointer to avoid data races with concurrent GETATTR encoding.
-var identityMapper atomic.Pointer[identity.IdentityMapper]
-
-// SetIdentityMapper configures the identity mapper used for FATTR4_OWNER
-// and FATTR4_OWNER_GROUP encoding. When set, UIDs/GIDs are reverse-resolved
-// to user@domain/group@domain format. When nil, numeric format is used.
-// Thread-safe: uses atomic store.
-func SetIdentityMapper(mapper identity.IdentityMapper) {
-	if mapper == nil {
-		identityMapper.Store(nil)
-		return
-	}
-	identityMapper.Store(&mapper)
-}
-
-// GetIdentityMapper returns the currently configured identity mapper, or nil.
-// Thread-safe: uses atomic load.
-func GetIdentityMapper() identity.IdentityMapper {
-	if p := identityMapper.Load(); p != nil {
-		return *p
-	}
-	return nil
-}
-
 // ============================================================================
 // PseudoFSAttrSource Interface
 // ============================================================================
@@ -230,6 +238,8 @@ func SupportedAttrs() []uint32 {
 	SetBit(&bitmap, FATTR4_SPACE_TOTAL)
 	SetBit(&bitmap, FATTR4_SPACE_FREE)
 	SetBit(&bitmap, FATTR4_SPACE_AVAIL)
+	SetBit(&bitmap, FATTR4_FS_LAYOUT_TYPES)
+	SetBit(&bitmap, FATTR4_LAYOUT_BLKSIZE)
 	SetBit(&bitmap, FATTR4_TIME_ACCESS)
 	SetBit(&bitmap, FATTR4_TIME_ACCESS_SET)
 	SetBit(&bitmap, FATTR4_TIME_METADATA)
@@ -446,12 +456,12 @@ func encodeSingleAttr(buf *bytes.Buffer, bit uint32, node PseudoFSAttrSource) er
 		return xdr.WriteUint32(buf, 2)
 
 	case FATTR4_OWNER:
-		// utf8str_mixed: numeric UID for nfs4_disable_idmapping=Y compatibility
-		return xdr.WriteXDRString(buf, resolveOwnerString(0))
+		// utf8str_mixed: root, numeric unless an ID-mapping domain is set
+		return xdr.WriteXDRString(buf, ownerString(0, OwnerNames))
 
 	case FATTR4_OWNER_GROUP:
-		// utf8str_mixed: numeric GID for nfs4_disable_idmapping=Y compatibility
-		return xdr.WriteXDRString(buf, resolveGroupString(0))
+		// utf8str_mixed: root, numeric unless an ID-mapping domain is set
+		return xdr.WriteXDRString(buf, groupString(0, OwnerNames))
 
 	case FATTR4_SPACE_USED:
 		// uint64: 0 for pseudo-fs directories
@@ -469,6 +479,12 @@ func encodeSingleAttr(buf *bytes.Buffer, bit uint32, node PseudoFSAttrSource) er
 		// Pseudo-fs: report 1 PiB
 		return xdr.WriteUint64(buf, 1<<50)
 
+	case FATTR4_FS_LAYOUT_TYPES:
+		return encodeLayoutTypes(buf)
+
+	case FATTR4_LAYOUT_BLKSIZE:
+		return xdr.WriteUint32(buf, uint32(fsMaxWriteSize.Load()))
+
 	case FATTR4_TIME_ACCESS:
 		// nfstime4: {seconds: 0, nseconds: 0} for pseudo-fs
 		if err := xdr.WriteUint64(buf, 0); err != nil { // seconds (int64 on wire but uint64 encoding)
@@ -559,8 +575,9 @@ func SupportedRealAttrs() []uint32 {
 //   - requested: Client-requested attribute bitmap
 //   - file: The real file metadata
 //   - handle: The file handle (used for FILEHANDLE and FILEID attributes)
+//   - owners: How OWNER/OWNER_GROUP are encoded (see OwnerFormatFor)
 //   - fsStats: Optional filesystem statistics for SPACE_TOTAL/FREE/AVAIL (can be nil)
-func EncodeRealFileAttrs(buf *bytes.Buffer, requested []uint32, file *metadata.File, handle metadata.FileHandle, fsStats ...*metadata.FilesystemStatistics) error {
+func EncodeRealFileAttrs(buf *bytes.Buffer, requested []uint32, file *metadata.File, handle metadata.FileHandle, owners OwnerFormat, fsStats ...*metadata.FilesystemStatistics) error {
 	supported := SupportedRealAttrs()
 	responseBitmap := responseAttrBitmap(requested, supported)
 
@@ -585,7 +602,7 @@ func EncodeRealFileAttrs(buf *bytes.Buffer, requested []uint32, file *metadata.F
 			continue
 		}
 
-		if err := encodeRealFileAttr(&attrData, bit, file, handle, stats); err != nil {
+		if err := encodeRealFileAttr(&attrData, bit, file, handle, owners, stats); err != nil {
 			return fmt.Errorf("encode attr bit %d: %w", bit, err)
 		}
 	}
@@ -599,7 +616,7 @@ func EncodeRealFileAttrs(buf *bytes.Buffer, requested []uint32, file *metadata.F
 }
 
 // encodeRealFileAttr encodes a single attribute value for a real file.
-func encodeRealFileAttr(buf *bytes.Buffer, bit uint32, file *metadata.File, handle metadata.FileHandle, fsStats *metadata.FilesystemStatistics) error {
+func encodeRealFileAttr(buf *bytes.Buffer, bit uint32, file *metadata.File, handle metadata.FileHandle, owners OwnerFormat, fsStats *metadata.FilesystemStatistics) error {
 	switch bit {
 	case FATTR4_SUPPORTED_ATTRS:
 		return EncodeBitmap4(buf, SupportedRealAttrs())
@@ -683,16 +700,14 @@ func encodeRealFileAttr(buf *bytes.Buffer, bit uint32, file *metadata.File, hand
 		return xdr.WriteUint32(buf, file.Nlink)
 
 	case FATTR4_OWNER:
-		// NFSv4 identity format: "user@domain" per RFC 7530 Section 5.9
-		// Use identity mapper for reverse resolution if available.
-		owner := resolveOwnerString(file.UID)
-		return xdr.WriteXDRString(buf, owner)
+		// NFSv4 identity format: "user@domain" per RFC 7530 Section 5.9,
+		// or the numeric UID (see OwnerFormatFor).
+		return xdr.WriteXDRString(buf, ownerString(file.UID, owners))
 
 	case FATTR4_OWNER_GROUP:
-		// NFSv4 identity format: "group@domain" per RFC 7530 Section 5.9
-		// Use identity mapper for reverse resolution if available.
-		group := resolveGroupString(file.GID)
-		return xdr.WriteXDRString(buf, group)
+		// NFSv4 identity format: "group@domain" per RFC 7530 Section 5.9,
+		// or the numeric GID.
+		return xdr.WriteXDRString(buf, groupString(file.GID, owners))
 
 	case FATTR4_SPACE_USED:
 		return xdr.WriteUint64(buf, file.Size)
@@ -718,6 +733,14 @@ func encodeRealFileAttr(buf *bytes.Buffer, bit uint32, file *metadata.File, hand
 		}
 		return xdr.WriteUint64(buf, 1<<50) // 1 PiB fallback
 
+	case FATTR4_FS_LAYOUT_TYPES:
+		// layouttype4<>: empty unless pNFS data servers are configured.
+		return encodeLayoutTypes(buf)
+
+	case FATTR4_LAYOUT_BLKSIZE:
+		// uint32: data servers take the same I/O sizes as the MDS.
+		return xdr.WriteUint32(buf, uint32(fsMaxWriteSize.Load()))
+
 	case FATTR4_TIME_ACCESS:
 		// nfstime4: seconds (int64) + nseconds (uint32)
 		if err := xdr.WriteUint64(buf, uint64(file.Atime.Unix())); err != nil {
@@ -791,50 +814,5 @@ func NeedsFilesystemStats(requested []uint32) bool {
 		IsBitSet(requested, FATTR4_SPACE_AVAIL)
 }
 
-// ============================================================================
-// Identity Mapper Helpers for OWNER/OWNER_GROUP
-// ============================================================================
-
-// resolveOwnerString converts a UID to an NFSv4 owner string.
-//
-// When an identity mapper is configured, it attempts to reverse-resolve the UID
-// to a "user@domain" string for Kerberos/RPCSEC_GSS environments.
-//
-// Without an identity mapper (or when resolution fails), returns a purely numeric
-// string (e.g., "0", "1000"). This is compatible with the Linux kernel NFS client's
-// default nfs4_disable_idmapping=Y mode, which passes numeric strings through
-// directly as UIDs without idmapd resolution. Using "user@domain" format without
-// matching idmapd configuration would cause the client to map all owners to
-// nobody (65534).
-func resolveOwnerString(uid uint32) string {
-	if p := identityMapper.Load(); p != nil {
-		mapper := *p
-		// Try numeric UID format as the principal for reverse lookup
-		principal := fmt.Sprintf("%d@localdomain", uid)
-		resolved, err := mapper.Resolve(context.Background(), principal)
-		if err == nil && resolved != nil && resolved.Found && resolved.Username != "" {
-			domain := resolved.Domain
-			if domain == "" {
-				domain = "localdomain"
-			}
-			return resolved.Username + "@" + domain
-		}
-	}
-
-	// Numeric fallback: compatible with nfs4_disable_idmapping=Y (Linux default)
-	return fmt.Sprintf("%d", uid)
-}
-
-// resolveGroupString converts a GID to an NFSv4 group owner string.
-//
-// Without an identity mapper, returns a purely numeric string (e.g., "0", "1000").
-// This is compatible with the Linux kernel NFS client's default
-// nfs4_disable_idmapping=Y mode. See resolveOwnerString for details.
-func resolveGroupString(gid uint32) string {
-	// Note: the identity mapper does not currently support group reverse
-	// resolution. This can be extended when reverse group lookup is added.
-	return fmt.Sprintf("%d", gid)
-}
-
 // shareNameToFSIDMinor generates a consistent minor FSID number from a share name.
 // Uses SHA-256 hash of the share name, taking the first 8 bytes as a uint64.
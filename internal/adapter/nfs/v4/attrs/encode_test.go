@@ -258,9 +258,9 @@ func TestEncodePseudoFSAttrsUnsupportedBitNotInResponse(t *testing.T) {
 	var buf bytes.Buffer
 	node := newMockNode()
 
-	// Request a bit we do NOT support (bit 62 = some hypothetical future attr)
+	// Request a bit we do NOT support (bit 63 = layout_hint)
 	var requested []uint32
-	SetBit(&requested, 62) // Not in SupportedAttrs
+	SetBit(&requested, 63) // Not in SupportedAttrs
 
 	err := EncodePseudoFSAttrs(&buf, requested, node)
 	if err != nil {
@@ -276,9 +276,9 @@ func TestEncodePseudoFSAttrsUnsupportedBitNotInResponse(t *testing.T) {
 		t.Fatalf("decode response bitmap: %v", err)
 	}
 
-	// Bit 62 should NOT be in response (not supported)
-	if IsBitSet(responseBitmap, 62) {
-		t.Error("unsupported bit 62 should not be in response bitmap")
+	// Bit 63 should NOT be in response (not supported)
+	if IsBitSet(responseBitmap, 63) {
+		t.Error("unsupported bit 63 should not be in response bitmap")
 	}
 
 	// No bits should be set (our request had no supported bits)
@@ -481,28 +481,32 @@ func TestSetLeaseTime_NoDataRace(t *testing.T) {
 	wg.Wait()
 }
 
-// TestSetIdentityMapper_NoDataRace exercises concurrent SetIdentityMapper writes
-// against FATTR4_OWNER encode reads (resolveOwnerString). Run with -race to detect
-// the data race that existed before identityMapper became an atomic.Pointer.
-func TestSetIdentityMapper_NoDataRace(t *testing.T) {
-	// Restore nil after test.
-	t.Cleanup(func() { SetIdentityMapper(nil) })
+// TestSetIDMapping_NoDataRace exercises concurrent SetIDMapping writes against
+// FATTR4_OWNER encode reads (ownerString). Run with -race to detect a data race
+// on the ID-mapping configuration.
+func TestSetIDMapping_NoDataRace(t *testing.T) {
+	// Restore numeric owners after test.
+	t.Cleanup(func() { SetIDMapping(nil) })
 
 	const goroutines = 8
 	ready := make(chan struct{})
 	var wg sync.WaitGroup
 	wg.Add(goroutines * 2)
 
-	// Half goroutines swap the mapper.
+	// Half goroutines swap the mapping.
 	for i := 0; i < goroutines; i++ {
-		go func() {
+		go func(i int) {
 			defer wg.Done()
 			<-ready
-			SetIdentityMapper(nil)
-		}()
+			if i%2 == 0 {
+				SetIDMapping(&IDMapping{Domain: "example.com"})
+			} else {
+				SetIDMapping(nil)
+			}
+		}(i)
 	}
 
-	// Half goroutines read via OWNER encoding (calls resolveOwnerString).
+	// Half goroutines read via OWNER encoding (calls ownerString).
 	for i := 0; i < goroutines; i++ {
 		go func() {
 			defer wg.Done()
@@ -612,7 +616,7 @@ func TestXattrSupportAttr(t *testing.T) {
 
 	// Real-file encode: bool true (uint32 1).
 	var buf bytes.Buffer
-	if err := encodeRealFileAttr(&buf, FATTR4_XATTR_SUPPORT, &metadata.File{}, metadata.FileHandle("/s:id"), nil); err != nil {
+	if err := encodeRealFileAttr(&buf, FATTR4_XATTR_SUPPORT, &metadata.File{}, metadata.FileHandle("/s:id"), OwnerNumeric, nil); err != nil {
 		t.Fatalf("encodeRealFileAttr(XATTR_SUPPORT): %v", err)
 	}
 	if got := binary.BigEndian.Uint32(buf.Bytes()); got != 1 {
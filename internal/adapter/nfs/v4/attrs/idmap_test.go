@@ -0,0 +1,167 @@
+package attrs
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeNames is a fixed IDNameResolver for tests.
+type fakeNames struct {
+	users  map[uint32]string
+	groups map[uint32]string
+}
+
+func (f *fakeNames) UserName(_ context.Context, uid uint32) (string, bool) {
+	name, ok := f.users[uid]
+	return name, ok
+}
+
+func (f *fakeNames) GroupName(_ context.Context, gid uint32) (string, bool) {
+	name, ok := f.groups[gid]
+	return name, ok
+}
+
+func (f *fakeNames) UserID(_ context.Context, name string) (uint32, bool) {
+	for id, n := range f.users {
+		if n == name {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func (f *fakeNames) GroupID(_ context.Context, name string) (uint32, bool) {
+	for id, n := range f.groups {
+		if n == name {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func setTestIDMapping(t *testing.T, numericAuthSys bool) {
+	t.Helper()
+	SetIDMapping(&IDMapping{
+		Domain:         "example.com",
+		NumericAuthSys: numericAuthSys,
+		Names: &fakeNames{
+			users:  map[uint32]string{1000: "alice"},
+			groups: map[uint32]string{2000: "staff"},
+		},
+	})
+	t.Cleanup(func() { SetIDMapping(nil) })
+}
+
+// ============================================================================
+// OwnerFormatFor Tests
+// ============================================================================
+
+func TestOwnerFormatFor(t *testing.T) {
+	if got := OwnerFormatFor(false); got != OwnerNumeric {
+		t.Errorf("OwnerFormatFor(false) without mapping = %d, want OwnerNumeric", got)
+	}
+
+	setTestIDMapping(t, true)
+	if got := OwnerFormatFor(true); got != OwnerNumeric {
+		t.Errorf("OwnerFormatFor(true) with NumericAuthSys = %d, want OwnerNumeric", got)
+	}
+	if got := OwnerFormatFor(false); got != OwnerNames {
+		t.Errorf("OwnerFormatFor(false) = %d, want OwnerNames", got)
+	}
+
+	setTestIDMapping(t, false)
+	if got := OwnerFormatFor(true); got != OwnerNames {
+		t.Errorf("OwnerFormatFor(true) without NumericAuthSys = %d, want OwnerNames", got)
+	}
+}
+
+func TestSetIDMapping_EmptyDomainClears(t *testing.T) {
+	setTestIDMapping(t, false)
+	SetIDMapping(&IDMapping{Domain: ""})
+	if GetIDMapping() != nil {
+		t.Error("SetIDMapping with empty domain should clear the mapping")
+	}
+}
+
+// ============================================================================
+// Owner String Encoding Tests
+// ============================================================================
+
+func TestOwnerString_Names(t *testing.T) {
+	setTestIDMapping(t, false)
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"mapped user", ownerString(1000, OwnerNames), "alice@example.com"},
+		{"root user", ownerString(0, OwnerNames), "root@example.com"},
+		{"unmapped user", ownerString(1001, OwnerNames), "1001"},
+		{"numeric format", ownerString(1000, OwnerNumeric), "1000"},
+		{"mapped group", groupString(2000, OwnerNames), "staff@example.com"},
+		{"root group", groupString(0, OwnerNames), "root@example.com"},
+		{"unmapped group", groupString(2001, OwnerNames), "2001"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestOwnerString_NoMapping(t *testing.T) {
+	if got := ownerString(1000, OwnerNames); got != "1000" {
+		t.Errorf("ownerString without mapping = %q, want %q", got, "1000")
+	}
+	if got := groupString(0, OwnerNames); got != "0" {
+		t.Errorf("groupString without mapping = %q, want %q", got, "0")
+	}
+}
+
+// ============================================================================
+// Owner String Decoding Tests
+// ============================================================================
+
+func TestParseOwnerString_IDMapping(t *testing.T) {
+	setTestIDMapping(t, true)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    uint32
+		wantErr bool
+	}{
+		{"name@domain", "alice@example.com", 1000, false},
+		{"domain case-insensitive", "alice@EXAMPLE.COM", 1000, false},
+		{"bare name", "alice", 1000, false},
+		{"numeric still accepted", "1000@example.com", 1000, false},
+		{"wrong domain", "alice@other.org", 0, true},
+		{"unknown name", "bob@example.com", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOwnerString(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseOwnerString(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseOwnerString(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGroupString_IDMapping(t *testing.T) {
+	setTestIDMapping(t, true)
+
+	if got, err := ParseGroupString("staff@example.com"); err != nil || got != 2000 {
+		t.Errorf("ParseGroupString(staff@example.com) = %d, %v; want 2000", got, err)
+	}
+	if _, err := ParseGroupString("alice@example.com"); err == nil {
+		t.Error("ParseGroupString should not resolve user names")
+	}
+}
@@ -114,6 +114,7 @@ func (h *Handler) handleClose(ctx *types.CompoundContext, reader io.Reader) *typ
 			logger.Debug("NFSv4 CLOSE flushed pending metadata",
 				"client", ctx.ClientAddr)
 		}
+		metaSvc.AuditFileAccess(authCtx, fileHandle, metadata.FileAuditClose, 0, nil)
 	}
 
 	// NOTE: CLOSE does NOT clear ctx.CurrentFH per RFC 7530
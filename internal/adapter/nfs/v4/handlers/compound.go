@@ -135,6 +135,21 @@ func (h *Handler) dispatchOne(compCtx *types.CompoundContext, v41ctx *types.V41R
 		return result
 	}
 
+	// SP4_MACH_CRED: ops the session's client reserved for its machine
+	// credential are refused for any other principal. Like NOTSUPP below,
+	// the op's args are left unread; the error stops the COMPOUND.
+	if v41ctx != nil && h.StateManager != nil {
+		if err := h.StateManager.CheckMachCred(v41ctx.ClientID, opCode, compCtx.GSSPrincipal); err != nil {
+			logger.Debug("NFSv4.1 op refused: requires the client's machine credential",
+				"opcode", opCode, "op_name", types.OpName(opCode), "client", compCtx.ClientAddr)
+			return &types.CompoundResult{
+				Status: types.NFS4ERR_WRONG_CRED,
+				OpCode: opCode,
+				Data:   encodeStatusOnly(types.NFS4ERR_WRONG_CRED),
+			}
+		}
+	}
+
 	// Dispatch precedence is by minor version, highest first: a v4.2 op (the
 	// RFC 8276 xattr ops) is recognised only under minorversion 2; otherwise
 	// fall through to the v4.1 then v4.0 tables (the later minor versions are
@@ -52,6 +52,9 @@ func ExtractV4HandlerContext(
 			compCtx.UID = gssIdentity.UID
 			compCtx.GID = gssIdentity.GID
 			compCtx.GIDs = gssIdentity.GIDs
+			if si := gss.SessionInfoFromContext(ctx); si != nil {
+				compCtx.GSSPrincipal = si.Principal
+			}
 
 			logger.Debug("NFSv4 using GSS identity",
 				"client", clientAddr,
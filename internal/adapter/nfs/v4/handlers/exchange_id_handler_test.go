@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"testing"
 
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc/gss"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
 )
@@ -113,11 +116,13 @@ func TestHandleExchangeID_SP4Rejected(t *testing.T) {
 	ctx := newTestCompoundContext()
 
 	tests := []struct {
-		name string
-		how  uint32
+		name       string
+		how        uint32
+		wantStatus uint32
 	}{
-		{"SP4_MACH_CRED", types.SP4_MACH_CRED},
-		{"SP4_SSV", types.SP4_SSV},
+		// MACH_CRED needs an RPCSEC_GSS integrity or privacy context.
+		{"SP4_MACH_CRED over AUTH_SYS", types.SP4_MACH_CRED, types.NFS4ERR_INVAL},
+		{"SP4_SSV", types.SP4_SSV, types.NFS4ERR_ENCR_ALG_UNSUPP},
 	}
 
 	for _, tt := range tests {
@@ -141,9 +146,8 @@ func TestHandleExchangeID_SP4Rejected(t *testing.T) {
 				t.Fatalf("decode response error: %v", err)
 			}
 
-			if decoded.Status != types.NFS4ERR_ENCR_ALG_UNSUPP {
-				t.Errorf("status = %d, want NFS4ERR_ENCR_ALG_UNSUPP (%d)",
-					decoded.Status, types.NFS4ERR_ENCR_ALG_UNSUPP)
+			if decoded.Status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", decoded.Status, tt.wantStatus)
 			}
 			if decoded.NumResults != 1 {
 				t.Fatalf("numResults = %d, want 1", decoded.NumResults)
@@ -155,6 +159,98 @@ func TestHandleExchangeID_SP4Rejected(t *testing.T) {
 	}
 }
 
+// newGSSCompoundContext returns a compound context authenticated as principal
+// over RPCSEC_GSS with integrity protection.
+func newGSSCompoundContext(principal string) *types.CompoundContext {
+	ctx := gss.ContextWithSessionInfo(context.Background(), &gss.GSSSessionInfo{
+		Service:   gss.RPCGSSSvcIntegrity,
+		Principal: principal,
+	})
+	return &types.CompoundContext{
+		Context:      ctx,
+		ClientAddr:   "127.0.0.1:12345",
+		AuthFlavor:   rpc.AuthRPCSECGSS,
+		GSSPrincipal: principal,
+	}
+}
+
+// exchangeIDMachCred sends an SP4_MACH_CRED EXCHANGE_ID requesting CLOSE be
+// enforced and returns the decoded result.
+func exchangeIDMachCred(t *testing.T, h *Handler, ctx *types.CompoundContext, ownerID []byte) (uint32, *types.ExchangeIdRes) {
+	t.Helper()
+
+	var args bytes.Buffer
+	var verifier [8]byte
+	args.Write(verifier[:])
+	_ = xdr.WriteXDROpaque(&args, ownerID)
+	_ = xdr.WriteUint32(&args, 0)
+	spa := types.StateProtect4A{How: types.SP4_MACH_CRED}
+	spa.MustEnforce.Set(types.OP_CLOSE)
+	_ = spa.Encode(&args)
+	_ = xdr.WriteUint32(&args, 0)
+
+	ops := []compoundOp{{opCode: types.OP_EXCHANGE_ID, data: args.Bytes()}}
+	resp, err := h.ProcessCompound(ctx, buildCompoundArgsWithOps([]byte("machcred"), 1, ops))
+	if err != nil {
+		t.Fatalf("ProcessCompound error: %v", err)
+	}
+
+	reader := bytes.NewReader(resp)
+	status, _ := xdr.DecodeUint32(reader)
+	if status != types.NFS4_OK {
+		return status, nil
+	}
+	_, _ = xdr.DecodeOpaque(reader) // tag
+	_, _ = xdr.DecodeUint32(reader) // numResults
+	_, _ = xdr.DecodeUint32(reader) // opcode
+	var res types.ExchangeIdRes
+	if err := res.Decode(reader); err != nil {
+		t.Fatalf("decode ExchangeIdRes: %v", err)
+	}
+	return status, &res
+}
+
+func TestHandleExchangeID_MachCred(t *testing.T) {
+	h := newTestHandler()
+	ownerID := []byte("mach-cred-client")
+	machine := "host/client.example.com@EXAMPLE.COM"
+
+	status, res := exchangeIDMachCred(t, h, newGSSCompoundContext(machine), ownerID)
+	if status != types.NFS4_OK {
+		t.Fatalf("status = %d, want NFS4_OK", status)
+	}
+	if res.StateProtect.How != types.SP4_MACH_CRED {
+		t.Fatalf("StateProtect.How = %d, want SP4_MACH_CRED", res.StateProtect.How)
+	}
+	for _, op := range []uint32{types.OP_CLOSE, types.OP_DESTROY_CLIENTID, types.OP_CREATE_SESSION} {
+		if !res.StateProtect.MustEnforce.IsSet(op) {
+			t.Errorf("spr_must_enforce missing %s", types.OpName(op))
+		}
+	}
+
+	// Another principal can neither take over the client at EXCHANGE_ID nor
+	// destroy it.
+	other := newGSSCompoundContext("alice@EXAMPLE.COM")
+	if status, _ := exchangeIDMachCred(t, h, other, ownerID); status != types.NFS4ERR_WRONG_CRED {
+		t.Errorf("EXCHANGE_ID from other principal: status = %d, want NFS4ERR_WRONG_CRED", status)
+	}
+
+	var args bytes.Buffer
+	_ = (&types.DestroyClientidArgs{ClientID: res.ClientID}).Encode(&args)
+	ops := []compoundOp{{opCode: types.OP_DESTROY_CLIENTID, data: args.Bytes()}}
+	resp, err := h.ProcessCompound(other, buildCompoundArgsWithOps([]byte("destroy"), 1, ops))
+	if err != nil {
+		t.Fatalf("ProcessCompound error: %v", err)
+	}
+	decoded, err := decodeCompoundResponse(resp)
+	if err != nil {
+		t.Fatalf("decode response error: %v", err)
+	}
+	if decoded.Status != types.NFS4ERR_WRONG_CRED {
+		t.Errorf("DESTROY_CLIENTID from other principal: status = %d, want NFS4ERR_WRONG_CRED", decoded.Status)
+	}
+}
+
 func TestHandleExchangeID_BadXDR(t *testing.T) {
 	h := newTestHandler()
 	ctx := newTestCompoundContext()
@@ -126,7 +126,7 @@ func (h *Handler) getAttrRealFS(ctx *types.CompoundContext, requested []uint32)
 	var buf bytes.Buffer
 	_ = xdr.WriteUint32(&buf, types.NFS4_OK)
 
-	if err := attrs.EncodeRealFileAttrs(&buf, requested, file, metadata.FileHandle(ctx.CurrentFH), fsStats); err != nil {
+	if err := attrs.EncodeRealFileAttrs(&buf, requested, file, metadata.FileHandle(ctx.CurrentFH), ownerFormat(ctx), fsStats); err != nil {
 		return &types.CompoundResult{
 			Status: types.NFS4ERR_SERVERFAULT,
 			OpCode: types.OP_GETATTR,
@@ -6,7 +6,9 @@ package handlers
 import (
 	"io"
 	"sync"
+	"sync/atomic"
 
+	"github.com/marmos91/dittofs/internal/adapter/nfs/auth"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/pseudofs"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/state"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
@@ -65,6 +67,15 @@ type Handler struct {
 	// If an operation is in this set, COMPOUND returns NFS4ERR_NOTSUPP for it.
 	blockedOps map[uint32]bool
 
+	// pnfs is the pNFS Flexible Files data server set, replaced by
+	// SetPNFSConfig. Nil or empty means pNFS is disabled.
+	pnfs atomic.Pointer[pnfsState]
+
+	// LayoutCreds issues the per-layout data-server credentials LAYOUTGET
+	// hands out, and verifies them when this server acts as a data server.
+	// The adapter shares it with the NFSv3 handler.
+	LayoutCreds *auth.LayoutCredentials
+
 	// v40DispatchTable maps v4.0 operation numbers to handler functions.
 	v40DispatchTable map[uint32]V40OpHandler
 
@@ -121,7 +132,9 @@ func NewHandler(registry nfsRuntime, pfs *pseudofs.PseudoFS, stateManager ...*st
 		v41DispatchTable: make(map[uint32]V41OpHandler),
 		v42DispatchTable: make(map[uint32]V42OpHandler),
 		maxMinorVersion:  2, // default: accept v4.0, v4.1, and v4.2 (RFC 8276 xattr ops)
+		LayoutCreds:      auth.NewLayoutCredentials(),
 	}
+	sm.SetLayoutFence(h.fenceLayouts)
 
 	// Initialize v4.1 handler dependencies
 	v41d := &v41handlers.Deps{
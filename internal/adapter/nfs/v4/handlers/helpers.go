@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"net"
 
 	"github.com/marmos91/dittofs/internal/adapter/nfs/auth"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc/gss"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/attrs"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
+	nfsxdr "github.com/marmos91/dittofs/internal/adapter/nfs/xdr"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
 	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
 	"github.com/marmos91/dittofs/pkg/metadata"
 )
 
@@ -38,6 +42,50 @@ func nfs4StatusForAuthError(err error) uint32 {
 	return types.NFS4ERR_SERVERFAULT
 }
 
+// checkExportAuthFlavor enforces a share's RequireKerberos / AllowAuthSys /
+// MinKerberosLevel policy against the request's auth flavor. A refusal is an
+// authStatusError carrying NFS4ERR_WRONGSEC. A nil share has no policy.
+func checkExportAuthFlavor(ctx *types.CompoundContext, share *runtime.Share, shareName string) error {
+	if share == nil {
+		return nil
+	}
+	if !share.AllowAuthSys && ctx.AuthFlavor == rpc.AuthUnix {
+		return &authStatusError{
+			status: types.NFS4ERR_WRONGSEC,
+			err:    fmt.Errorf("share %q does not allow AUTH_SYS", shareName),
+		}
+	}
+	if share.RequireKerberos && ctx.AuthFlavor != rpc.AuthRPCSECGSS {
+		return &authStatusError{
+			status: types.NFS4ERR_WRONGSEC,
+			err:    fmt.Errorf("share %q requires Kerberos", shareName),
+		}
+	}
+	// Enforce the per-share GSS protection floor (min_kerberos_level). A
+	// share configured krb5i/krb5p must reject a GSS session negotiated at a
+	// weaker service level (e.g. plain krb5 authentication-only on a krb5p
+	// privacy share). The negotiated RPCSEC_GSS service level is carried in
+	// the request context by the GSS DATA dispatch, which sets the session
+	// info for every processed RPCSEC_GSS request. We enforce the floor only
+	// when that session info is present: a real GSS session always carries
+	// it, so its absence means the request was not processed as GSS (e.g.
+	// Kerberos is not configured on the server) — applying the default
+	// "krb5" floor there would spuriously deny. Non-GSS flavors are gated
+	// above by AllowAuthSys / RequireKerberos.
+	if ctx.AuthFlavor == rpc.AuthRPCSECGSS {
+		if si := gss.SessionInfoFromContext(ctx.Context); si != nil {
+			if !auth.MeetsMinKerberosLevel(share.MinKerberosLevel, si.Service) {
+				return &authStatusError{
+					status: types.NFS4ERR_WRONGSEC,
+					err: fmt.Errorf("share %q requires min kerberos level %q (negotiated service %d)",
+						shareName, share.MinKerberosLevel, si.Service),
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // buildV4AuthContext creates an AuthContext for NFSv4 real-FS operations.
 //
 // It extracts the share name from the file handle, builds an identity from
@@ -84,6 +132,7 @@ func (h *Handler) buildV4AuthContext(ctx *types.CompoundContext, handle []byte)
 		return &metadata.AuthContext{
 			Context:    ctx.Context,
 			ClientAddr: ctx.ClientAddr,
+			Protocol:   "nfs4",
 			AuthMethod: authMethod,
 			Identity:   originalIdentity,
 		}, shareName, nil
@@ -102,41 +151,31 @@ func (h *Handler) buildV4AuthContext(ctx *types.CompoundContext, handle []byte)
 	// requirement. Mirror the v3 logic here, at the first real-FS op that
 	// resolves the share handle, and surface the refusal as NFS4ERR_WRONGSEC so
 	// the client retries with the correct flavor (SECINFO).
-	if share != nil {
-		if !share.AllowAuthSys && ctx.AuthFlavor == rpc.AuthUnix {
-			return nil, "", &authStatusError{
-				status: types.NFS4ERR_WRONGSEC,
-				err:    fmt.Errorf("share %q does not allow AUTH_SYS", shareName),
-			}
-		}
-		if share.RequireKerberos && ctx.AuthFlavor != rpc.AuthRPCSECGSS {
-			return nil, "", &authStatusError{
-				status: types.NFS4ERR_WRONGSEC,
-				err:    fmt.Errorf("share %q requires Kerberos", shareName),
-			}
-		}
-		// Enforce the per-share GSS protection floor (min_kerberos_level). A
-		// share configured krb5i/krb5p must reject a GSS session negotiated at a
-		// weaker service level (e.g. plain krb5 authentication-only on a krb5p
-		// privacy share). The negotiated RPCSEC_GSS service level is carried in
-		// the request context by the GSS DATA dispatch, which sets the session
-		// info for every processed RPCSEC_GSS request. We enforce the floor only
-		// when that session info is present: a real GSS session always carries
-		// it, so its absence means the request was not processed as GSS (e.g.
-		// Kerberos is not configured on the server) — applying the default
-		// "krb5" floor there would spuriously deny. Non-GSS flavors are gated
-		// above by AllowAuthSys / RequireKerberos.
-		if ctx.AuthFlavor == rpc.AuthRPCSECGSS {
-			if si := gss.SessionInfoFromContext(ctx.Context); si != nil {
-				if !auth.MeetsMinKerberosLevel(share.MinKerberosLevel, si.Service) {
-					return nil, "", &authStatusError{
-						status: types.NFS4ERR_WRONGSEC,
-						err: fmt.Errorf("share %q requires min kerberos level %q (negotiated service %d)",
-							shareName, share.MinKerberosLevel, si.Service),
-					}
-				}
-			}
+	if err := checkExportAuthFlavor(ctx, share, shareName); err != nil {
+		return nil, "", err
+	}
+
+	// Apply the subtree export governing this handle, if the share has any.
+	// NFSv4 has no MOUNT, so this per-operation check is where a pseudo-fs
+	// walk into a subtree export (LOOKUP share, projects, alpha) meets that
+	// export's allowlist, read-only flag and squash policy. Directories above
+	// an export the client may use resolve to a read-only traverse policy so
+	// the walk can reach it.
+	clientIP := net.ParseIP(nfsxdr.ExtractClientIP(ctx.ClientAddr))
+	policy, err := h.Registry.ResolveExportPolicy(ctx.Context, shareName, metadata.FileHandle(handle), clientIP)
+	if err != nil {
+		if errors.Is(err, runtime.ErrExportAccessDenied) {
+			return nil, "", &authStatusError{status: types.NFS4ERR_ACCESS, err: err}
 		}
+		return nil, "", err
+	}
+	share = runtime.ApplyExportPolicy(share, policy)
+
+	// A data-server request carrying a pNFS layout credential is authorized
+	// by the layout, not by a user identity, so share-permission resolution
+	// and squashing do not apply to it.
+	if authCtx, ok, err := h.layoutAuthContext(ctx, handle, share, authMethod); ok {
+		return authCtx, shareName, err
 	}
 
 	// Apply the export-squash permission policy (default_permission=none denial,
@@ -172,7 +211,7 @@ func (h *Handler) buildV4AuthContext(ctx *types.CompoundContext, handle []byte)
 	// not be resolved (e.g. a stale handle for a deleted/renamed share); the
 	// previous behaviour of falling back to the original, UNMAPPED identity
 	// silently bypassed squash rules (a root client would have stayed root).
-	effectiveIdentity, err := h.Registry.ApplyIdentityMapping(shareName, originalIdentity)
+	effectiveIdentity, err := h.Registry.ApplyExportIdentityMapping(shareName, policy, originalIdentity)
 	if err != nil {
 		logger.Debug("NFSv4 identity mapping failed",
 			"share", shareName,
@@ -185,6 +224,7 @@ func (h *Handler) buildV4AuthContext(ctx *types.CompoundContext, handle []byte)
 	authCtx := &metadata.AuthContext{
 		Context:       ctx.Context,
 		ClientAddr:    ctx.ClientAddr,
+		Protocol:      "nfs4",
 		AuthMethod:    authMethod,
 		Identity:      effectiveIdentity,
 		ShareReadOnly: permResult.ReadOnly,
@@ -202,6 +242,12 @@ func getMetadataServiceForCtx(h *Handler) (*metadata.Service, error) {
 	return h.Registry.GetMetadataService(), nil
 }
 
+// ownerFormat returns how OWNER/OWNER_GROUP are encoded for the request:
+// AUTH_SYS callers may get numeric IDs, Kerberos callers get names.
+func ownerFormat(ctx *types.CompoundContext) attrs.OwnerFormat {
+	return attrs.OwnerFormatFor(ctx.AuthFlavor == rpc.AuthUnix)
+}
+
 // encodeChangeInfo4 encodes a change_info4 structure into the buffer.
 //
 // change_info4 is used by CREATE, REMOVE, RENAME, and other operations
@@ -0,0 +1,521 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/adapter/common"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/auth"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/pseudofs"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/state"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// layoutLengthEOF is the NFS4_UINT64_MAX length meaning "to end of file".
+const layoutLengthEOF = ^uint64(0)
+
+// pnfsStatusResult builds a status-only result for a pNFS operation.
+func pnfsStatusResult(op, status uint32) *types.CompoundResult {
+	return &types.CompoundResult{
+		Status: status,
+		OpCode: op,
+		Data:   encodeStatusOnly(status),
+	}
+}
+
+// pnfsEncodedResult wraps an encoded pNFS response, mapping encode failures
+// to NFS4ERR_SERVERFAULT.
+func pnfsEncodedResult(op, status uint32, encode func(*bytes.Buffer) error) *types.CompoundResult {
+	var buf bytes.Buffer
+	if err := encode(&buf); err != nil {
+		logger.Error("pNFS: encode response failed", "op", types.OpName(op), "error", err)
+		return pnfsStatusResult(op, types.NFS4ERR_SERVERFAULT)
+	}
+	return &types.CompoundResult{
+		Status: status,
+		OpCode: op,
+		Data:   buf.Bytes(),
+	}
+}
+
+// layoutAuthContext authorizes a data-server request that presents a pNFS
+// layout credential (see auth.LayoutCredentials) in place of a user
+// identity. ok is false when the request is not one: the flavor is not
+// AUTH_SYS, the uid is not a layout uid, or the store never issued a layout.
+// An invalid credential is refused with NFS4ERR_ACCESS.
+func (h *Handler) layoutAuthContext(ctx *types.CompoundContext, handle []byte, share *runtime.Share, authMethod string) (authCtx *metadata.AuthContext, ok bool, err error) {
+	if h.LayoutCreds == nil || ctx.AuthFlavor != rpc.AuthUnix ||
+		ctx.UID == nil || ctx.GID == nil || !auth.IsLayoutUID(*ctx.UID) {
+		return nil, false, nil
+	}
+	grant, err := h.LayoutCreds.VerifyHandle(ctx.Context, h.Registry.GetMetadataService(), metadata.FileHandle(handle), *ctx.UID, *ctx.GID)
+	if err != nil {
+		status := common.MapToNFS4(err)
+		if errors.Is(err, auth.ErrInvalidLayoutCredential) {
+			status = types.NFS4ERR_ACCESS
+		}
+		return nil, true, &authStatusError{status: status, err: err}
+	}
+	if grant == nil {
+		return nil, false, nil
+	}
+	return &metadata.AuthContext{
+		Context:       ctx.Context,
+		ClientAddr:    ctx.ClientAddr,
+		Protocol:      "nfs4",
+		AuthMethod:    authMethod,
+		Identity:      auth.LayoutIdentity(*ctx.UID),
+		ShareReadOnly: share != nil && share.ReadOnly,
+		LayoutGrant:   grant,
+	}, true, nil
+}
+
+// fenceLayouts is the StateManager's layout fence hook. It rotates the
+// layout key of the metadata store behind fileHandle's share, invalidating
+// every credential issued for that store; holders of layouts that are still
+// valid get access errors from the data servers and fetch fresh layouts.
+func (h *Handler) fenceLayouts(fileHandle []byte) {
+	metaSvc, err := getMetadataServiceForCtx(h)
+	if err != nil || metaSvc == nil {
+		return
+	}
+	shareName, _, err := metadata.DecodeFileHandle(metadata.FileHandle(fileHandle))
+	if err != nil {
+		return
+	}
+	store, err := metaSvc.GetStoreForShare(shareName)
+	if err != nil {
+		logger.Warn("pNFS: cannot fence layouts", "share", shareName, "error", err)
+		return
+	}
+	if err := h.LayoutCreds.Fence(context.Background(), store); err != nil {
+		logger.Warn("pNFS: cannot fence layouts", "share", shareName, "error", err)
+		return
+	}
+	logger.Info("pNFS: layouts fenced", "share", shareName)
+}
+
+// handleLayoutGet implements the LAYOUTGET operation (RFC 8881 Section 18.43).
+// Grants a whole-file Flexible Files layout (RFC 8435) pointing at one DittoFS data server.
+// Delegates to StateManager.GrantLayout; the data server is chosen by hashing the file handle.
+// Creates or updates the client's layout stateid for the file; data servers reuse this server's file handle.
+// Errors: NFS4ERR_NOFILEHANDLE, NFS4ERR_LAYOUTUNAVAILABLE, NFS4ERR_UNKNOWN_LAYOUTTYPE, NFS4ERR_BADIOMODE,
+// NFS4ERR_BAD_STATEID, NFS4ERR_OPENMODE, NFS4ERR_RECALLCONFLICT, NFS4ERR_WRONG_TYPE, NFS4ERR_TOOSMALL, NFS4ERR_BADXDR.
+func (h *Handler) handleLayoutGet(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
+	const op = types.OP_LAYOUTGET
+
+	var args types.LayoutGetArgs
+	if err := args.Decode(reader); err != nil {
+		logger.Debug("LAYOUTGET: decode error", "error", err, "client", ctx.ClientAddr)
+		return pnfsStatusResult(op, types.NFS4ERR_BADXDR)
+	}
+
+	if status := types.RequireCurrentFH(ctx); status != types.NFS4_OK {
+		return pnfsStatusResult(op, status)
+	}
+	if v41ctx == nil {
+		return pnfsStatusResult(op, types.NFS4ERR_OP_NOT_IN_SESSION)
+	}
+
+	pnfs := h.pnfsDevices()
+	if pnfs == nil || pseudofs.IsPseudoFSHandle(ctx.CurrentFH) {
+		return pnfsStatusResult(op, types.NFS4ERR_LAYOUTUNAVAILABLE)
+	}
+	if args.LayoutType != types.LAYOUT4_FLEX_FILES {
+		return pnfsStatusResult(op, types.NFS4ERR_UNKNOWN_LAYOUTTYPE)
+	}
+	if args.IOMode != types.LAYOUTIOMODE4_READ && args.IOMode != types.LAYOUTIOMODE4_RW {
+		return pnfsStatusResult(op, types.NFS4ERR_BADIOMODE)
+	}
+	if args.Length == 0 || args.MinLength > args.Length {
+		return pnfsStatusResult(op, types.NFS4ERR_INVAL)
+	}
+
+	// RFC 8881 Section 18.43.3: the stateid must be a layout, open,
+	// delegation or lock stateid -- never a special one.
+	if args.Stateid.IsSpecialStateid() {
+		return pnfsStatusResult(op, types.NFS4ERR_BAD_STATEID)
+	}
+	if args.Stateid.Other[0] != state.StateTypeLayout {
+		stateOp := state.StateidOpRead
+		if args.IOMode == types.LAYOUTIOMODE4_RW {
+			stateOp = state.StateidOpWrite
+		}
+		openState, err := h.StateManager.ValidateStateid(&args.Stateid, ctx.CurrentFH, stateOp)
+		if err != nil {
+			status := mapStateError(err)
+			logger.Debug("LAYOUTGET: stateid validation failed",
+				"error", err, "nfs_status", status, "client", ctx.ClientAddr)
+			return pnfsStatusResult(op, status)
+		}
+		if args.IOMode == types.LAYOUTIOMODE4_RW && openState != nil &&
+			openState.ShareAccess&types.OPEN4_SHARE_ACCESS_WRITE == 0 {
+			return pnfsStatusResult(op, types.NFS4ERR_OPENMODE)
+		}
+	}
+
+	// The layout credential carries the iomode's access to the data servers,
+	// so the client must hold that access on the file right now -- also when
+	// it presents a layout stateid, which outlives the open it came from.
+	access := uint32(types.OPEN4_SHARE_ACCESS_READ | types.OPEN4_SHARE_ACCESS_WRITE)
+	if args.IOMode == types.LAYOUTIOMODE4_RW {
+		access = types.OPEN4_SHARE_ACCESS_WRITE
+	}
+	if !h.StateManager.ClientHoldsAccess(v41ctx.ClientID, ctx.CurrentFH, access) {
+		return pnfsStatusResult(op, types.NFS4ERR_OPENMODE)
+	}
+
+	_, shareName, err := h.buildV4AuthContext(ctx, ctx.CurrentFH)
+	if err != nil {
+		return pnfsStatusResult(op, nfs4StatusForAuthError(err))
+	}
+
+	// Data servers authenticate layout I/O with AUTH_SYS, so a share that
+	// requires Kerberos or refuses AUTH_SYS never hands out layouts: the
+	// client does its I/O through this server under the share's own policy.
+	var share *runtime.Share
+	if h.Registry != nil {
+		share, _ = h.Registry.GetShare(shareName)
+	}
+	if share == nil || share.RequireKerberos || !share.AllowAuthSys {
+		return pnfsStatusResult(op, types.NFS4ERR_LAYOUTUNAVAILABLE)
+	}
+
+	metaSvc, err := getMetadataServiceForCtx(h)
+	if err != nil {
+		return pnfsStatusResult(op, types.NFS4ERR_SERVERFAULT)
+	}
+	store, err := metaSvc.GetStoreForShare(shareName)
+	if err != nil {
+		return pnfsStatusResult(op, common.MapToNFS4(err))
+	}
+	file, err := metaSvc.GetFile(ctx.Context, metadata.FileHandle(ctx.CurrentFH))
+	if err != nil {
+		return pnfsStatusResult(op, common.MapToNFS4(err))
+	}
+	if file.Type != metadata.FileTypeRegular {
+		return pnfsStatusResult(op, types.NFS4ERR_WRONG_TYPE)
+	}
+
+	layoutStateid, err := h.StateManager.GrantLayout(v41ctx.ClientID, ctx.CurrentFH, args.IOMode, &args.Stateid)
+	if err != nil {
+		status := mapStateError(err)
+		logger.Debug("LAYOUTGET: grant refused", "error", err, "nfs_status", status, "client", ctx.ClientAddr)
+		return pnfsStatusResult(op, status)
+	}
+
+	// Data servers are loosely coupled: the client presents synthetic AUTH_SYS
+	// credentials and the anonymous stateid. The credentials are minted for
+	// this layout and iomode and are good for this one file only (see
+	// auth.LayoutCredentials). Writes through a data server land in the
+	// shared metadata store directly, so the size and mtime are already
+	// current and LAYOUTCOMMIT is unnecessary.
+	uid, gid, err := h.LayoutCreds.Issue(ctx.Context, store, ctx.CurrentFH, file,
+		args.IOMode == types.LAYOUTIOMODE4_RW, auth.LayoutNonce(layoutStateid.Other[:]))
+	if err != nil {
+		logger.Error("LAYOUTGET: issue data server credentials failed", "error", err)
+		return pnfsStatusResult(op, types.NFS4ERR_SERVERFAULT)
+	}
+	dev := pnfs.deviceForHandle(ctx.CurrentFH)
+	body := types.FFLayout4{
+		Mirrors: []types.FFMirror4{{
+			DataServers: []types.FFDataServer4{{
+				DeviceID:   dev.id,
+				Efficiency: 1,
+				FHVersions: [][]byte{ctx.CurrentFH},
+				User:       strconv.FormatUint(uint64(uid), 10),
+				Group:      strconv.FormatUint(uint64(gid), 10),
+			}},
+		}},
+		Flags: types.FF_FLAGS_NO_LAYOUTCOMMIT,
+	}
+	var bodyBuf bytes.Buffer
+	if err := body.Encode(&bodyBuf); err != nil {
+		logger.Error("LAYOUTGET: encode layout body failed", "error", err)
+		return pnfsStatusResult(op, types.NFS4ERR_SERVERFAULT)
+	}
+
+	res := types.LayoutGetRes{
+		Status:  types.NFS4_OK,
+		Stateid: layoutStateid,
+		Layouts: []types.Layout4{{
+			Offset: 0,
+			Length: layoutLengthEOF,
+			IOMode: args.IOMode,
+			Type:   types.LAYOUT4_FLEX_FILES,
+			Body:   bodyBuf.Bytes(),
+		}},
+	}
+	var resBuf bytes.Buffer
+	if err := res.Encode(&resBuf); err != nil {
+		logger.Error("LAYOUTGET: encode response failed", "error", err)
+		return pnfsStatusResult(op, types.NFS4ERR_SERVERFAULT)
+	}
+	// loga_maxcount bounds the result excluding the status word.
+	if args.MaxCount > 0 && uint32(resBuf.Len()-4) > args.MaxCount {
+		return pnfsStatusResult(op, types.NFS4ERR_TOOSMALL)
+	}
+
+	logger.Debug("LAYOUTGET: granted",
+		"iomode", args.IOMode,
+		"client_id", v41ctx.ClientID,
+		"stateid_seqid", layoutStateid.Seqid,
+		"client", ctx.ClientAddr)
+
+	return &types.CompoundResult{
+		Status: types.NFS4_OK,
+		OpCode: op,
+		Data:   resBuf.Bytes(),
+	}
+}
+
+// handleLayoutCommit implements the LAYOUTCOMMIT operation (RFC 8881 Section 18.42).
+// Publishes the size and mtime a client produced through data servers.
+// Delegates to MetadataService.SetFileAttributes; the size only ever grows.
+// Updates file size/mtime in the metadata store; returns the new size when it changed.
+// Errors: NFS4ERR_NOFILEHANDLE, NFS4ERR_BADLAYOUT, NFS4ERR_BAD_STATEID, NFS4ERR_NO_GRACE, NFS4ERR_BADXDR.
+func (h *Handler) handleLayoutCommit(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
+	const op = types.OP_LAYOUTCOMMIT
+
+	var args types.LayoutCommitArgs
+	if err := args.Decode(reader); err != nil {
+		logger.Debug("LAYOUTCOMMIT: decode error", "error", err, "client", ctx.ClientAddr)
+		return pnfsStatusResult(op, types.NFS4ERR_BADXDR)
+	}
+
+	if status := types.RequireCurrentFH(ctx); status != types.NFS4_OK {
+		return pnfsStatusResult(op, status)
+	}
+	if v41ctx == nil {
+		return pnfsStatusResult(op, types.NFS4ERR_OP_NOT_IN_SESSION)
+	}
+	if args.Reclaim {
+		// Layouts are not persisted, so there is nothing to reclaim.
+		return pnfsStatusResult(op, types.NFS4ERR_NO_GRACE)
+	}
+	if err := h.StateManager.ValidateLayoutStateid(v41ctx.ClientID, &args.Stateid, ctx.CurrentFH); err != nil {
+		return pnfsStatusResult(op, mapStateError(err))
+	}
+
+	authCtx, _, err := h.buildV4AuthContext(ctx, ctx.CurrentFH)
+	if err != nil {
+		return pnfsStatusResult(op, nfs4StatusForAuthError(err))
+	}
+	metaSvc, err := getMetadataServiceForCtx(h)
+	if err != nil {
+		return pnfsStatusResult(op, types.NFS4ERR_SERVERFAULT)
+	}
+	handle := metadata.FileHandle(ctx.CurrentFH)
+	file, err := metaSvc.GetFile(ctx.Context, handle)
+	if err != nil {
+		return pnfsStatusResult(op, common.MapToNFS4(err))
+	}
+
+	var setAttrs metadata.SetAttrs
+	res := types.LayoutCommitRes{Status: types.NFS4_OK}
+	if args.NewOffsetPresent && args.NewOffset != layoutLengthEOF && args.NewOffset+1 > file.Size {
+		newSize := args.NewOffset + 1
+		setAttrs.Size = &newSize
+		res.NewSizePresent = true
+		res.NewSize = newSize
+	}
+	if args.TimeModifyPresent {
+		mtime := time.Unix(args.TimeModify.Seconds, int64(args.TimeModify.Nseconds))
+		setAttrs.Mtime = &mtime
+	}
+	if setAttrs.Size != nil || setAttrs.Mtime != nil {
+		if _, err := metaSvc.SetFileAttributes(authCtx, handle, &setAttrs); err != nil {
+			status := common.MapToNFS4(err)
+			logger.Debug("LAYOUTCOMMIT: set attributes failed", "error", err, "nfs_status", status)
+			return pnfsStatusResult(op, status)
+		}
+	}
+
+	return pnfsEncodedResult(op, types.NFS4_OK, res.Encode)
+}
+
+// handleLayoutReturn implements the LAYOUTRETURN operation (RFC 8881 Section 18.44).
+// Releases layouts for the current file, its filesystem (share), or all of the client's files.
+// Delegates to StateManager.ReturnLayout / ReturnLayouts; completes any outstanding recall.
+// Removes layout state once every iomode is returned; lrs_present reports whether a stateid remains.
+// Errors: NFS4ERR_NOFILEHANDLE, NFS4ERR_UNKNOWN_LAYOUTTYPE, NFS4ERR_BADIOMODE, NFS4ERR_BAD_STATEID, NFS4ERR_BADXDR.
+func (h *Handler) handleLayoutReturn(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
+	const op = types.OP_LAYOUTRETURN
+
+	var args types.LayoutReturnArgs
+	if err := args.Decode(reader); err != nil {
+		logger.Debug("LAYOUTRETURN: decode error", "error", err, "client", ctx.ClientAddr)
+		return pnfsStatusResult(op, types.NFS4ERR_BADXDR)
+	}
+
+	if v41ctx == nil {
+		return pnfsStatusResult(op, types.NFS4ERR_OP_NOT_IN_SESSION)
+	}
+	if args.LayoutType != types.LAYOUT4_FLEX_FILES {
+		return pnfsStatusResult(op, types.NFS4ERR_UNKNOWN_LAYOUTTYPE)
+	}
+	switch args.IOMode {
+	case types.LAYOUTIOMODE4_READ, types.LAYOUTIOMODE4_RW, types.LAYOUTIOMODE4_ANY:
+	default:
+		return pnfsStatusResult(op, types.NFS4ERR_BADIOMODE)
+	}
+	if args.Reclaim {
+		return pnfsStatusResult(op, types.NFS4ERR_NO_GRACE)
+	}
+
+	res := types.LayoutReturnRes{Status: types.NFS4_OK}
+	switch args.ReturnType {
+	case types.LAYOUTRETURN4_FILE:
+		if status := types.RequireCurrentFH(ctx); status != types.NFS4_OK {
+			return pnfsStatusResult(op, status)
+		}
+		remaining, err := h.StateManager.ReturnLayout(v41ctx.ClientID, ctx.CurrentFH, args.IOMode, &args.Stateid)
+		if err != nil {
+			return pnfsStatusResult(op, mapStateError(err))
+		}
+		if remaining != nil {
+			res.StateidPresent = true
+			res.Stateid = *remaining
+		}
+
+	case types.LAYOUTRETURN4_FSID:
+		if status := types.RequireCurrentFH(ctx); status != types.NFS4_OK {
+			return pnfsStatusResult(op, status)
+		}
+		shareName, _, err := metadata.DecodeFileHandle(metadata.FileHandle(ctx.CurrentFH))
+		if err != nil {
+			return pnfsStatusResult(op, types.NFS4ERR_BADHANDLE)
+		}
+		h.StateManager.ReturnLayouts(v41ctx.ClientID, func(fh []byte) bool {
+			name, _, err := metadata.DecodeFileHandle(metadata.FileHandle(fh))
+			return err == nil && name == shareName
+		})
+
+	case types.LAYOUTRETURN4_ALL:
+		h.StateManager.ReturnLayouts(v41ctx.ClientID, nil)
+
+	default:
+		return pnfsStatusResult(op, types.NFS4ERR_INVAL)
+	}
+
+	return pnfsEncodedResult(op, types.NFS4_OK, res.Encode)
+}
+
+// handleGetDeviceInfo implements the GETDEVICEINFO operation (RFC 8881 Section 18.40).
+// Returns the ff_device_addr4 (universal addresses and NFS version) of a data server.
+// Reads the device set installed by SetPNFSConfig; no device notifications are offered.
+// No side effects.
+// Errors: NFS4ERR_UNKNOWN_LAYOUTTYPE, NFS4ERR_NOENT, NFS4ERR_TOOSMALL, NFS4ERR_BADXDR.
+func (h *Handler) handleGetDeviceInfo(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
+	const op = types.OP_GETDEVICEINFO
+
+	var args types.GetDeviceInfoArgs
+	if err := args.Decode(reader); err != nil {
+		logger.Debug("GETDEVICEINFO: decode error", "error", err, "client", ctx.ClientAddr)
+		return pnfsStatusResult(op, types.NFS4ERR_BADXDR)
+	}
+
+	if v41ctx == nil {
+		return pnfsStatusResult(op, types.NFS4ERR_OP_NOT_IN_SESSION)
+	}
+	if args.LayoutType != types.LAYOUT4_FLEX_FILES {
+		return pnfsStatusResult(op, types.NFS4ERR_UNKNOWN_LAYOUTTYPE)
+	}
+
+	pnfs := h.pnfsDevices()
+	if pnfs == nil {
+		return pnfsStatusResult(op, types.NFS4ERR_NOENT)
+	}
+	dev, ok := pnfs.findDevice(args.DeviceID)
+	if !ok {
+		return pnfsStatusResult(op, types.NFS4ERR_NOENT)
+	}
+
+	var addrBuf bytes.Buffer
+	if err := dev.addr.Encode(&addrBuf); err != nil {
+		logger.Error("GETDEVICEINFO: encode device address failed", "error", err)
+		return pnfsStatusResult(op, types.NFS4ERR_SERVERFAULT)
+	}
+
+	// gdia_maxcount bounds the device_addr4: layout type + opaque body.
+	needed := uint32(4 + 4 + (addrBuf.Len()+3)&^3)
+	if args.MaxCount > 0 && needed > args.MaxCount {
+		res := types.GetDeviceInfoRes{Status: types.NFS4ERR_TOOSMALL, MinCount: needed}
+		return pnfsEncodedResult(op, types.NFS4ERR_TOOSMALL, res.Encode)
+	}
+
+	res := types.GetDeviceInfoRes{
+		Status:     types.NFS4_OK,
+		LayoutType: types.LAYOUT4_FLEX_FILES,
+		DeviceAddr: addrBuf.Bytes(),
+	}
+	return pnfsEncodedResult(op, types.NFS4_OK, res.Encode)
+}
+
+// handleGetDeviceList implements the GETDEVICELIST operation (RFC 8881 Section 18.41).
+// Pages through the IDs of all configured data servers.
+// The cookie is an index into the device set; the verifier changes with the set.
+// No side effects.
+// Errors: NFS4ERR_NOFILEHANDLE, NFS4ERR_UNKNOWN_LAYOUTTYPE, NFS4ERR_BAD_COOKIE, NFS4ERR_INVAL, NFS4ERR_BADXDR.
+func (h *Handler) handleGetDeviceList(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
+	const op = types.OP_GETDEVICELIST
+
+	var args types.GetDeviceListArgs
+	if err := args.Decode(reader); err != nil {
+		logger.Debug("GETDEVICELIST: decode error", "error", err, "client", ctx.ClientAddr)
+		return pnfsStatusResult(op, types.NFS4ERR_BADXDR)
+	}
+
+	if status := types.RequireCurrentFH(ctx); status != types.NFS4_OK {
+		return pnfsStatusResult(op, status)
+	}
+	if v41ctx == nil {
+		return pnfsStatusResult(op, types.NFS4ERR_OP_NOT_IN_SESSION)
+	}
+	if args.LayoutType != types.LAYOUT4_FLEX_FILES {
+		return pnfsStatusResult(op, types.NFS4ERR_UNKNOWN_LAYOUTTYPE)
+	}
+	if args.MaxDevices == 0 {
+		return pnfsStatusResult(op, types.NFS4ERR_INVAL)
+	}
+
+	var devices []pnfsDevice
+	if pnfs := h.pnfsDevices(); pnfs != nil {
+		devices = pnfs.devices
+	}
+
+	// The verifier is derived from the device IDs, so any change to the set
+	// invalidates outstanding cookies.
+	digest := sha256.New()
+	for i := range devices {
+		digest.Write(devices[i].id[:])
+	}
+	var verf [8]byte
+	copy(verf[:], digest.Sum(nil))
+
+	if args.Cookie != 0 && (args.CookieVerf != verf || args.Cookie > uint64(len(devices))) {
+		return pnfsStatusResult(op, types.NFS4ERR_BAD_COOKIE)
+	}
+
+	start := int(args.Cookie)
+	end := min(start+int(args.MaxDevices), len(devices))
+	res := types.GetDeviceListRes{
+		Status:     types.NFS4_OK,
+		Cookie:     uint64(end),
+		CookieVerf: verf,
+		EOF:        end == len(devices),
+	}
+	for _, dev := range devices[start:end] {
+		res.DeviceIDs = append(res.DeviceIDs, dev.id)
+	}
+	return pnfsEncodedResult(op, types.NFS4_OK, res.Encode)
+}
@@ -0,0 +1,406 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/auth"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
+	xdr "github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// newPNFSFixture returns a real-FS fixture whose handler serves layouts from
+// two data servers, plus a regular file owned by uid/gid 1000 and a
+// delegation stateid to present on the first LAYOUTGET.
+func newPNFSFixture(t *testing.T) (*realFSTestFixture, metadata.FileHandle, types.Stateid4) {
+	t.Helper()
+	fx := newRealFSTestFixture(t, "/export")
+	fx.handler.SetPNFSConfig(PNFSConfig{
+		DataServers: []string{"127.0.0.1:12049", "127.0.0.2:12049"},
+		RSize:       1 << 20,
+		WSize:       1 << 20,
+	})
+	t.Cleanup(func() { fx.handler.SetPNFSConfig(PNFSConfig{}) })
+
+	fh := fx.createTestFile(t, fx.rootHandle, "data.bin", metadata.FileTypeRegular, 0o644, 1000, 1000)
+	deleg := fx.handler.StateManager.GrantDelegation(42, fh, types.OPEN_DELEGATE_WRITE)
+	return fx, fh, deleg.Stateid
+}
+
+func encodeLayoutGetArgs(t *testing.T, iomode uint32, stateid types.Stateid4) []byte {
+	t.Helper()
+	args := types.LayoutGetArgs{
+		LayoutType: types.LAYOUT4_FLEX_FILES,
+		IOMode:     iomode,
+		Length:     ^uint64(0),
+		Stateid:    stateid,
+		MaxCount:   4096,
+	}
+	var buf bytes.Buffer
+	if err := args.Encode(&buf); err != nil {
+		t.Fatalf("encode LAYOUTGET args: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// layoutCredentials parses the synthetic AUTH_SYS uid/gid of a data server.
+func layoutCredentials(t *testing.T, ds types.FFDataServer4) (uint32, uint32) {
+	t.Helper()
+	uid, err := strconv.ParseUint(ds.User, 10, 32)
+	if err != nil {
+		t.Fatalf("ffds_user %q: %v", ds.User, err)
+	}
+	gid, err := strconv.ParseUint(ds.Group, 10, 32)
+	if err != nil {
+		t.Fatalf("ffds_group %q: %v", ds.Group, err)
+	}
+	return uint32(uid), uint32(gid)
+}
+
+// layoutGet runs LAYOUTGET and returns the decoded result and the data
+// server credentials, failing the test on a non-OK status.
+func layoutGet(t *testing.T, fx *realFSTestFixture, fh metadata.FileHandle, clientID uint64, iomode uint32, stateid types.Stateid4) (types.LayoutGetRes, uint32, uint32) {
+	t.Helper()
+	ctx := newRealFSContext(1000, 1000)
+	ctx.CurrentFH = fh
+	result := fx.handler.handleLayoutGet(ctx, &types.V41RequestContext{ClientID: clientID},
+		bytes.NewReader(encodeLayoutGetArgs(t, iomode, stateid)))
+	if result.Status != types.NFS4_OK {
+		t.Fatalf("LAYOUTGET status = %d, want NFS4_OK", result.Status)
+	}
+	var res types.LayoutGetRes
+	if err := res.Decode(bytes.NewReader(result.Data)); err != nil {
+		t.Fatalf("decode LAYOUTGET result: %v", err)
+	}
+	var ff types.FFLayout4
+	if err := ff.Decode(bytes.NewReader(res.Layouts[0].Body)); err != nil {
+		t.Fatalf("decode ff_layout4: %v", err)
+	}
+	uid, gid := layoutCredentials(t, ff.Mirrors[0].DataServers[0])
+	return res, uid, gid
+}
+
+func TestLayoutGet_Disabled(t *testing.T) {
+	fx := newRealFSTestFixture(t, "/export")
+	fh := fx.createTestFile(t, fx.rootHandle, "data.bin", metadata.FileTypeRegular, 0o644, 0, 0)
+	deleg := fx.handler.StateManager.GrantDelegation(42, fh, types.OPEN_DELEGATE_WRITE)
+
+	ctx := newRealFSContext(0, 0)
+	ctx.CurrentFH = fh
+	result := fx.handler.handleLayoutGet(ctx, &types.V41RequestContext{ClientID: 42},
+		bytes.NewReader(encodeLayoutGetArgs(t, types.LAYOUTIOMODE4_RW, deleg.Stateid)))
+
+	if result.Status != types.NFS4ERR_LAYOUTUNAVAILABLE {
+		t.Fatalf("status = %d, want NFS4ERR_LAYOUTUNAVAILABLE", result.Status)
+	}
+}
+
+func TestLayoutGet_GetDeviceInfo_LayoutReturn(t *testing.T) {
+	fx, fh, stateid := newPNFSFixture(t)
+	v41ctx := &types.V41RequestContext{ClientID: 42}
+
+	ctx := newRealFSContext(1000, 1000)
+	ctx.CurrentFH = fh
+	result := fx.handler.handleLayoutGet(ctx, v41ctx,
+		bytes.NewReader(encodeLayoutGetArgs(t, types.LAYOUTIOMODE4_RW, stateid)))
+	if result.Status != types.NFS4_OK {
+		t.Fatalf("LAYOUTGET status = %d, want NFS4_OK", result.Status)
+	}
+
+	var res types.LayoutGetRes
+	if err := res.Decode(bytes.NewReader(result.Data)); err != nil {
+		t.Fatalf("decode LAYOUTGET result: %v", err)
+	}
+	if len(res.Layouts) != 1 {
+		t.Fatalf("layouts = %d, want 1", len(res.Layouts))
+	}
+	layout := res.Layouts[0]
+	if layout.Type != types.LAYOUT4_FLEX_FILES || layout.IOMode != types.LAYOUTIOMODE4_RW {
+		t.Errorf("type/iomode = %d/%d", layout.Type, layout.IOMode)
+	}
+	if layout.Offset != 0 || layout.Length != ^uint64(0) {
+		t.Errorf("layout range = %d+%d, want whole file", layout.Offset, layout.Length)
+	}
+
+	var ff types.FFLayout4
+	if err := ff.Decode(bytes.NewReader(layout.Body)); err != nil {
+		t.Fatalf("decode ff_layout4: %v", err)
+	}
+	if len(ff.Mirrors) != 1 || len(ff.Mirrors[0].DataServers) != 1 {
+		t.Fatalf("mirrors = %+v, want one mirror with one data server", ff.Mirrors)
+	}
+	ds := ff.Mirrors[0].DataServers[0]
+	if len(ds.FHVersions) != 1 || !bytes.Equal(ds.FHVersions[0], fh) {
+		t.Error("data server file handle should be the MDS file handle")
+	}
+	uid, gid := layoutCredentials(t, ds)
+	grant, err := fx.handler.LayoutCreds.VerifyHandle(ctx.Context, fx.metaSvc, fh, uid, gid)
+	if err != nil || grant == nil || !grant.Write {
+		t.Errorf("data server credentials %q/%q verify as (%+v, %v), want an RW grant", ds.User, ds.Group, grant, err)
+	}
+
+	// GETDEVICEINFO resolves the device named in the layout.
+	var devArgs bytes.Buffer
+	gdi := types.GetDeviceInfoArgs{DeviceID: ds.DeviceID, LayoutType: types.LAYOUT4_FLEX_FILES, MaxCount: 4096}
+	if err := gdi.Encode(&devArgs); err != nil {
+		t.Fatalf("encode GETDEVICEINFO args: %v", err)
+	}
+	devResult := fx.handler.handleGetDeviceInfo(ctx, v41ctx, bytes.NewReader(devArgs.Bytes()))
+	if devResult.Status != types.NFS4_OK {
+		t.Fatalf("GETDEVICEINFO status = %d, want NFS4_OK", devResult.Status)
+	}
+	var devRes types.GetDeviceInfoRes
+	if err := devRes.Decode(bytes.NewReader(devResult.Data)); err != nil {
+		t.Fatalf("decode GETDEVICEINFO result: %v", err)
+	}
+	var addr types.FFDeviceAddr4
+	if err := addr.Decode(bytes.NewReader(devRes.DeviceAddr)); err != nil {
+		t.Fatalf("decode ff_device_addr4: %v", err)
+	}
+	if len(addr.NetAddrs) != 1 || addr.NetAddrs[0].Netid != "tcp" ||
+		!strings.HasSuffix(addr.NetAddrs[0].Addr, ".47.17") { // port 12049 = 47*256 + 17
+		t.Errorf("netaddrs = %+v", addr.NetAddrs)
+	}
+	if len(addr.Versions) != 1 || addr.Versions[0].Version != 3 {
+		t.Errorf("versions = %+v, want NFSv3", addr.Versions)
+	}
+
+	// LAYOUTRETURN of the whole layout leaves no layout stateid behind.
+	var retArgs bytes.Buffer
+	lr := types.LayoutReturnArgs{
+		LayoutType: types.LAYOUT4_FLEX_FILES,
+		IOMode:     types.LAYOUTIOMODE4_ANY,
+		ReturnType: types.LAYOUTRETURN4_FILE,
+		Length:     ^uint64(0),
+		Stateid:    res.Stateid,
+	}
+	if err := lr.Encode(&retArgs); err != nil {
+		t.Fatalf("encode LAYOUTRETURN args: %v", err)
+	}
+	retResult := fx.handler.handleLayoutReturn(ctx, v41ctx, bytes.NewReader(retArgs.Bytes()))
+	if retResult.Status != types.NFS4_OK {
+		t.Fatalf("LAYOUTRETURN status = %d, want NFS4_OK", retResult.Status)
+	}
+	var retRes types.LayoutReturnRes
+	if err := retRes.Decode(bytes.NewReader(retResult.Data)); err != nil {
+		t.Fatalf("decode LAYOUTRETURN result: %v", err)
+	}
+	if retRes.StateidPresent {
+		t.Error("lrs_present should be false after returning every iomode")
+	}
+}
+
+func TestLayoutGet_UnknownDevice(t *testing.T) {
+	fx, _, _ := newPNFSFixture(t)
+
+	var args bytes.Buffer
+	gdi := types.GetDeviceInfoArgs{LayoutType: types.LAYOUT4_FLEX_FILES, MaxCount: 4096}
+	if err := gdi.Encode(&args); err != nil {
+		t.Fatalf("encode GETDEVICEINFO args: %v", err)
+	}
+	result := fx.handler.handleGetDeviceInfo(newRealFSContext(0, 0), &types.V41RequestContext{ClientID: 42}, bytes.NewReader(args.Bytes()))
+	if result.Status != types.NFS4ERR_NOENT {
+		t.Errorf("status = %d, want NFS4ERR_NOENT", result.Status)
+	}
+}
+
+func TestLayoutGet_RejectsBadArgs(t *testing.T) {
+	fx, fh, stateid := newPNFSFixture(t)
+	v41ctx := &types.V41RequestContext{ClientID: 42}
+	ctx := newRealFSContext(1000, 1000)
+
+	// Directories have no layouts.
+	ctx.CurrentFH = fx.rootHandle
+	dirDeleg := fx.handler.StateManager.GrantDelegation(42, fx.rootHandle, types.OPEN_DELEGATE_READ)
+	result := fx.handler.handleLayoutGet(ctx, v41ctx,
+		bytes.NewReader(encodeLayoutGetArgs(t, types.LAYOUTIOMODE4_READ, dirDeleg.Stateid)))
+	if result.Status != types.NFS4ERR_WRONG_TYPE {
+		t.Errorf("directory status = %d, want NFS4ERR_WRONG_TYPE", result.Status)
+	}
+
+	ctx.CurrentFH = fh
+	special := types.Stateid4{}
+	result = fx.handler.handleLayoutGet(ctx, v41ctx,
+		bytes.NewReader(encodeLayoutGetArgs(t, types.LAYOUTIOMODE4_READ, special)))
+	if result.Status != types.NFS4ERR_BAD_STATEID {
+		t.Errorf("special stateid status = %d, want NFS4ERR_BAD_STATEID", result.Status)
+	}
+
+	result = fx.handler.handleLayoutGet(ctx, v41ctx,
+		bytes.NewReader(encodeLayoutGetArgs(t, types.LAYOUTIOMODE4_ANY, stateid)))
+	if result.Status != types.NFS4ERR_BADIOMODE {
+		t.Errorf("iomode ANY status = %d, want NFS4ERR_BADIOMODE", result.Status)
+	}
+}
+
+func TestGetDeviceList_Pages(t *testing.T) {
+	fx, fh, _ := newPNFSFixture(t)
+	v41ctx := &types.V41RequestContext{ClientID: 42}
+	ctx := newRealFSContext(0, 0)
+	ctx.CurrentFH = fh
+
+	list := func(cookie uint64, verf [8]byte) *types.CompoundResult {
+		var buf bytes.Buffer
+		args := types.GetDeviceListArgs{LayoutType: types.LAYOUT4_FLEX_FILES, MaxDevices: 1, Cookie: cookie, CookieVerf: verf}
+		if err := args.Encode(&buf); err != nil {
+			t.Fatalf("encode GETDEVICELIST args: %v", err)
+		}
+		return fx.handler.handleGetDeviceList(ctx, v41ctx, bytes.NewReader(buf.Bytes()))
+	}
+
+	var first types.GetDeviceListRes
+	if err := first.Decode(bytes.NewReader(list(0, [8]byte{}).Data)); err != nil {
+		t.Fatalf("decode first page: %v", err)
+	}
+	if len(first.DeviceIDs) != 1 || first.EOF {
+		t.Fatalf("first page = %d ids, eof=%t; want 1 id, more to come", len(first.DeviceIDs), first.EOF)
+	}
+
+	var second types.GetDeviceListRes
+	if err := second.Decode(bytes.NewReader(list(first.Cookie, first.CookieVerf).Data)); err != nil {
+		t.Fatalf("decode second page: %v", err)
+	}
+	if len(second.DeviceIDs) != 1 || !second.EOF || second.DeviceIDs[0] == first.DeviceIDs[0] {
+		t.Errorf("second page = %+v, want the other device and EOF", second)
+	}
+
+	if result := list(first.Cookie, [8]byte{1}); result.Status != types.NFS4ERR_BAD_COOKIE {
+		t.Errorf("stale verifier status = %d, want NFS4ERR_BAD_COOKIE", result.Status)
+	}
+}
+
+func TestSetAttr_SizeChangeRecallsLayouts(t *testing.T) {
+	fx, fh, stateid := newPNFSFixture(t)
+	v41ctx := &types.V41RequestContext{ClientID: 42}
+	ctx := newRealFSContext(0, 0)
+	ctx.CurrentFH = fh
+
+	result := fx.handler.handleLayoutGet(ctx, v41ctx,
+		bytes.NewReader(encodeLayoutGetArgs(t, types.LAYOUTIOMODE4_RW, stateid)))
+	if result.Status != types.NFS4_OK {
+		t.Fatalf("LAYOUTGET status = %d, want NFS4_OK", result.Status)
+	}
+	var res types.LayoutGetRes
+	if err := res.Decode(bytes.NewReader(result.Data)); err != nil {
+		t.Fatalf("decode LAYOUTGET result: %v", err)
+	}
+
+	var attrVals bytes.Buffer
+	_ = xdr.WriteUint64(&attrVals, 0)
+	args := encodeSetAttrArgs(t, &stateid, []uint32{1 << 4}, attrVals.Bytes()) // FATTR4_SIZE
+	if r := fx.handler.handleSetAttr(ctx, bytes.NewReader(args)); r.Status != types.NFS4_OK {
+		t.Fatalf("SETATTR status = %d, want NFS4_OK", r.Status)
+	}
+
+	// The recall is pending (or, with no backchannel, already revoked):
+	// the old layout stateid cannot fetch a new layout.
+	result = fx.handler.handleLayoutGet(ctx, v41ctx,
+		bytes.NewReader(encodeLayoutGetArgs(t, types.LAYOUTIOMODE4_RW, res.Stateid)))
+	if result.Status == types.NFS4_OK {
+		t.Error("LAYOUTGET with a recalled layout stateid should fail")
+	}
+}
+
+func TestLayoutCredentials_DataServerAccess(t *testing.T) {
+	fx, fh, stateid := newPNFSFixture(t)
+	_, ruid, rgid := layoutGet(t, fx, fh, 42, types.LAYOUTIOMODE4_READ, stateid)
+	_, wuid, wgid := layoutGet(t, fx, fh, 42, types.LAYOUTIOMODE4_RW, stateid)
+
+	dsCtx := func(uid, gid uint32) *metadata.AuthContext {
+		t.Helper()
+		ctx := newRealFSContext(uid, gid)
+		authCtx, _, err := fx.handler.buildV4AuthContext(ctx, fh)
+		if err != nil {
+			t.Fatalf("buildV4AuthContext(%d/%d): %v", uid, gid, err)
+		}
+		if authCtx.LayoutGrant == nil {
+			t.Fatalf("credentials %d/%d were not recognised as a layout credential", uid, gid)
+		}
+		return authCtx
+	}
+
+	file, err := fx.metaSvc.GetFile(context.Background(), fh)
+	if err != nil {
+		t.Fatalf("GetFile: %v", err)
+	}
+
+	// A read layout reads but never writes, even though the file is 0644
+	// and its owner could.
+	readCtx := dsCtx(ruid, rgid)
+	if err := fx.metaSvc.CheckReadPermissionFile(readCtx, fh, file); err != nil {
+		t.Errorf("read through a read layout: %v", err)
+	}
+	if _, err := fx.metaSvc.PrepareWrite(readCtx, fh, 1); err == nil {
+		t.Error("write through a read layout should be denied")
+	}
+	if _, err := fx.metaSvc.PrepareWrite(dsCtx(wuid, wgid), fh, 1); err != nil {
+		t.Errorf("write through an RW layout: %v", err)
+	}
+
+	// The credential is good for its own file only.
+	other := fx.createTestFile(t, fx.rootHandle, "other.bin", metadata.FileTypeRegular, 0o666, 1000, 1000)
+	ctx := newRealFSContext(wuid, wgid)
+	if _, _, err := fx.handler.buildV4AuthContext(ctx, other); nfs4StatusForAuthError(err) != types.NFS4ERR_ACCESS {
+		t.Errorf("layout credential on another file: err = %v, want NFS4ERR_ACCESS", err)
+	}
+	if _, _, err := fx.handler.buildV4AuthContext(newRealFSContext(wuid, wgid^1), fh); nfs4StatusForAuthError(err) != types.NFS4ERR_ACCESS {
+		t.Errorf("forged layout credential: err = %v, want NFS4ERR_ACCESS", err)
+	}
+
+	// chmod invalidates the credentials at once.
+	mode := uint32(0o600)
+	if _, err := fx.metaSvc.SetFileAttributes(metadata.NewSystemAuthContext(context.Background()), fh, &metadata.SetAttrs{Mode: &mode}); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	_, _, err = fx.handler.buildV4AuthContext(newRealFSContext(wuid, wgid), fh)
+	if nfs4StatusForAuthError(err) != types.NFS4ERR_ACCESS || !errors.Is(err, auth.ErrInvalidLayoutCredential) {
+		t.Errorf("layout credential after chmod: err = %v, want NFS4ERR_ACCESS", err)
+	}
+}
+
+func TestLayoutGet_ScopedToOpenAccess(t *testing.T) {
+	fx, fh, _ := newPNFSFixture(t)
+	deleg := fx.handler.StateManager.GrantDelegation(43, fh, types.OPEN_DELEGATE_READ)
+
+	ctx := newRealFSContext(1000, 1000)
+	ctx.CurrentFH = fh
+	result := fx.handler.handleLayoutGet(ctx, &types.V41RequestContext{ClientID: 43},
+		bytes.NewReader(encodeLayoutGetArgs(t, types.LAYOUTIOMODE4_RW, deleg.Stateid)))
+	if result.Status != types.NFS4ERR_OPENMODE {
+		t.Fatalf("RW LAYOUTGET under a read delegation: status = %d, want NFS4ERR_OPENMODE", result.Status)
+	}
+
+	// A read layout is fine, but its layout stateid cannot be upgraded to RW
+	// without write access either.
+	res, _, _ := layoutGet(t, fx, fh, 43, types.LAYOUTIOMODE4_READ, deleg.Stateid)
+	result = fx.handler.handleLayoutGet(ctx, &types.V41RequestContext{ClientID: 43},
+		bytes.NewReader(encodeLayoutGetArgs(t, types.LAYOUTIOMODE4_RW, res.Stateid)))
+	if result.Status != types.NFS4ERR_OPENMODE {
+		t.Fatalf("RW LAYOUTGET with a layout stateid: status = %d, want NFS4ERR_OPENMODE", result.Status)
+	}
+}
+
+func TestSetAttr_ModeChangeRecallsLayouts(t *testing.T) {
+	fx, fh, stateid := newPNFSFixture(t)
+	res, _, _ := layoutGet(t, fx, fh, 42, types.LAYOUTIOMODE4_RW, stateid)
+
+	ctx := newRealFSContext(0, 0)
+	ctx.CurrentFH = fh
+	var attrVals bytes.Buffer
+	_ = xdr.WriteUint32(&attrVals, 0o600)
+	args := encodeSetAttrArgs(t, &stateid, []uint32{0, 1 << 1}, attrVals.Bytes()) // FATTR4_MODE
+	if r := fx.handler.handleSetAttr(ctx, bytes.NewReader(args)); r.Status != types.NFS4_OK {
+		t.Fatalf("SETATTR status = %d, want NFS4_OK", r.Status)
+	}
+
+	result := fx.handler.handleLayoutGet(ctx, &types.V41RequestContext{ClientID: 42},
+		bytes.NewReader(encodeLayoutGetArgs(t, types.LAYOUTIOMODE4_RW, res.Stateid)))
+	if result.Status == types.NFS4_OK {
+		t.Error("LAYOUTGET with a layout recalled by chmod should fail")
+	}
+}
@@ -15,7 +15,8 @@ import (
 // Traverses a directory by name, setting the current filehandle to the resolved child.
 // Delegates to MetadataService.GetChild for real files; navigates pseudo-fs tree for virtual handles.
 // Sets CurrentFH to the child entry; crosses export junctions into real shares transparently.
-// Errors: NFS4ERR_NOFILEHANDLE, NFS4ERR_NOENT, NFS4ERR_NOTDIR, NFS4ERR_BADXDR, NFS4ERR_STALE.
+// Errors: NFS4ERR_NOFILEHANDLE, NFS4ERR_NOENT, NFS4ERR_NOTDIR, NFS4ERR_BADXDR, NFS4ERR_STALE,
+// NFS4ERR_WRONGSEC (junction into a share that refuses the request's flavor).
 func (h *Handler) handleLookup(ctx *types.CompoundContext, reader io.Reader) *types.CompoundResult {
 	// Require current filehandle
 	if status := types.RequireCurrentFH(ctx); status != types.NFS4_OK {
@@ -151,6 +152,24 @@ func (h *Handler) lookupInPseudoFS(ctx *types.CompoundContext, name string) *typ
 			}
 		}
 
+		// A junction is a security policy boundary (RFC 8881 Section
+		// 2.6.3.1.1): refuse the crossing with NFS4ERR_WRONGSEC when the
+		// share does not accept this flavor, so the client renegotiates via
+		// SECINFO on the parent instead of failing on the next op.
+		share, _ := h.Registry.GetShare(child.ShareName)
+		if err := checkExportAuthFlavor(ctx, share, child.ShareName); err != nil {
+			logger.Debug("NFSv4 LOOKUP junction crossing refused",
+				"share", child.ShareName,
+				"error", err,
+				"client", ctx.ClientAddr)
+			st := nfs4StatusForAuthError(err)
+			return &types.CompoundResult{
+				Status: st,
+				OpCode: types.OP_LOOKUP,
+				Data:   encodeStatusOnly(st),
+			}
+		}
+
 		// Set current FH to the real share root handle
 		ctx.CurrentFH = make([]byte, len(realHandle))
 		copy(ctx.CurrentFH, realHandle)
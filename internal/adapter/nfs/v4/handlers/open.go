@@ -13,6 +13,7 @@ import (
 	xdr "github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
 	"github.com/marmos91/dittofs/internal/logger"
 	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/marmos91/dittofs/pkg/metadata/acl"
 )
 
 // handleOpen implements the OPEN operation (RFC 7530 Section 16.16).
@@ -80,10 +81,12 @@ func (h *Handler) handleOpen(ctx *types.CompoundContext, reader io.Reader) *type
 	// Validate the share_access / share_deny modes (RFC 7530 Section 16.16).
 	// The access mode (low 2 bits) must be exactly READ, WRITE, or BOTH; the
 	// deny mode must be a subset of {READ, WRITE}. Reject anything else with
-	// NFS4ERR_INVAL before touching state. The server ignores any higher
-	// share_access bits (e.g. the v4.1 want-delegation hints), so the access
-	// mode is masked to OPEN4_SHARE_ACCESS_BOTH and the masked value carried
-	// forward consistently with the rest of the handler.
+	// NFS4ERR_INVAL before touching state. The higher share_access bits are
+	// the v4.1 OPEN4_SHARE_ACCESS_WANT_* delegation preferences (RFC 8881
+	// Section 18.16.3); they are split off into want and honored only for
+	// v4.1 clients, so the access mode is masked to OPEN4_SHARE_ACCESS_BOTH
+	// and the masked value carried forward consistently with the rest of the
+	// handler.
 	accessMode := shareAccess & uint32(types.OPEN4_SHARE_ACCESS_BOTH)
 	if accessMode == 0 {
 		logger.Debug("NFSv4 OPEN invalid share_access", "access", shareAccess, "client", ctx.ClientAddr)
@@ -93,6 +96,10 @@ func (h *Handler) handleOpen(ctx *types.CompoundContext, reader io.Reader) *type
 		logger.Debug("NFSv4 OPEN invalid share_deny", "deny", shareDeny, "client", ctx.ClientAddr)
 		return openError(types.NFS4ERR_INVAL)
 	}
+	var want uint32
+	if ctx.SkipOwnerSeqid { // v4.1 dispatch
+		want = shareAccess &^ uint32(types.OPEN4_SHARE_ACCESS_BOTH)
+	}
 	shareAccess = accessMode
 
 	// 4. open_owner4: clientid (uint64) + owner (XDR opaque)
@@ -187,7 +194,7 @@ func (h *Handler) handleOpen(ctx *types.CompoundContext, reader io.Reader) *type
 			return openError(nfsStatus)
 		}
 
-		return h.handleOpenClaimNull(ctx, reader, seqid, shareAccess, shareDeny,
+		return h.handleOpenClaimNull(ctx, reader, seqid, shareAccess, shareDeny, want,
 			clientID, ownerData, openType, createMode, claimType, createAttrs, createVerifier)
 
 	case types.CLAIM_FH:
@@ -210,7 +217,7 @@ func (h *Handler) handleOpen(ctx *types.CompoundContext, reader io.Reader) *type
 		if openType == types.OPEN4_CREATE {
 			return openError(types.NFS4ERR_INVAL)
 		}
-		return h.handleOpenClaimFH(ctx, seqid, shareAccess, shareDeny,
+		return h.handleOpenClaimFH(ctx, seqid, shareAccess, shareDeny, want,
 			clientID, ownerData, claimType)
 
 	case types.CLAIM_PREVIOUS:
@@ -238,7 +245,7 @@ func (h *Handler) handleOpen(ctx *types.CompoundContext, reader io.Reader) *type
 func (h *Handler) handleOpenClaimNull(
 	ctx *types.CompoundContext,
 	reader io.Reader,
-	seqid, shareAccess, shareDeny uint32,
+	seqid, shareAccess, shareDeny, want uint32,
 	clientID uint64, ownerData []byte,
 	openType, createMode, claimType uint32,
 	createAttrs *metadata.SetAttrs,
@@ -486,16 +493,13 @@ func (h *Handler) handleOpenClaimNull(
 	}
 
 	// Try to grant a delegation
-
-	delegType, shouldGrant := h.StateManager.ShouldGrantDelegation(clientID, []byte(fileHandle), shareAccess)
-	var deleg *state.DelegationState
-	if shouldGrant {
-		deleg = h.StateManager.GrantDelegation(clientID, []byte(fileHandle), delegType)
-	}
+	deleg := h.grantOpenDelegation(metaSvc, authCtx, clientID, fileHandle, shareAccess, want)
 
 	// Directory change notifications for OPEN+CREATE are now handled by
 	// MetadataService.CreateFile via DirChangeNotifier -> LockManager -> BreakCallbacks.
 
+	metaSvc.AuditFileAccess(authCtx, fileHandle, metadata.FileAuditOpen, openAuditMask(shareAccess), nil)
+
 	logger.Debug("NFSv4 OPEN successful",
 		"file", filename,
 		"created", created,
@@ -503,7 +507,7 @@ func (h *Handler) handleOpenClaimNull(
 		"createMode", createMode,
 		"stateid_seqid", openResult.Stateid.Seqid,
 		"rflags", openResult.RFlags,
-		"delegation", delegType,
+		"delegation", openDelegationType(deleg),
 		"client", ctx.ClientAddr)
 
 	// Encode OPEN4resok
@@ -521,7 +525,7 @@ func (h *Handler) handleOpenClaimNull(
 // opentype the client sent.
 func (h *Handler) handleOpenClaimFH(
 	ctx *types.CompoundContext,
-	seqid, shareAccess, shareDeny uint32,
+	seqid, shareAccess, shareDeny, want uint32,
 	clientID uint64, ownerData []byte,
 	claimType uint32,
 ) *types.CompoundResult {
@@ -582,16 +586,14 @@ func (h *Handler) handleOpenClaimFH(
 		_ = h.StateManager.ConfirmOpenV41(&openResult.Stateid)
 	}
 
-	delegType, shouldGrant := h.StateManager.ShouldGrantDelegation(clientID, []byte(fileHandle), shareAccess)
-	var deleg *state.DelegationState
-	if shouldGrant {
-		deleg = h.StateManager.GrantDelegation(clientID, []byte(fileHandle), delegType)
-	}
+	deleg := h.grantOpenDelegation(metaSvc, authCtx, clientID, fileHandle, shareAccess, want)
+
+	metaSvc.AuditFileAccess(authCtx, fileHandle, metadata.FileAuditOpen, openAuditMask(shareAccess), nil)
 
 	logger.Debug("NFSv4 OPEN CLAIM_FH successful",
 		"stateid_seqid", openResult.Stateid.Seqid,
 		"rflags", openResult.RFlags,
-		"delegation", delegType,
+		"delegation", openDelegationType(deleg),
 		"client", ctx.ClientAddr)
 
 	// CLAIM_FH does not create or change a directory, so change_info is empty.
@@ -674,16 +676,63 @@ func (h *Handler) handleOpenClaimPrevious(
 		0, 0, nil)
 }
 
+// grantOpenDelegation applies the delegation policy after a successful OPEN.
+//
+// A v4.1 client that sent OPEN4_SHARE_ACCESS_WANT_* bits gets the
+// WANT_DELEGATION treatment (StateManager.WantDelegation), including an
+// OPEN_DELEGATE_NONE_EXT reason and deferred CB_PUSH_DELEG when declined.
+// A WRITE delegation wanted on a read-only open falls back to READ unless
+// the caller may write the file. Everyone else gets the plain grant policy;
+// nil means OPEN_DELEGATE_NONE.
+func (h *Handler) grantOpenDelegation(
+	metaSvc *metadata.Service, authCtx *metadata.AuthContext,
+	clientID uint64, fileHandle metadata.FileHandle,
+	shareAccess, want uint32,
+) *state.DelegationWantResult {
+	if want == 0 {
+		delegType, shouldGrant := h.StateManager.ShouldGrantDelegation(clientID, []byte(fileHandle), shareAccess)
+		if !shouldGrant {
+			return nil
+		}
+		if deleg := h.StateManager.GrantDelegation(clientID, []byte(fileHandle), delegType); deleg != nil {
+			return &state.DelegationWantResult{Deleg: deleg}
+		}
+		return nil
+	}
+
+	if want&types.OPEN4_SHARE_ACCESS_WANT_DELEG_MASK == types.OPEN4_SHARE_ACCESS_WANT_WRITE_DELEG &&
+		shareAccess&types.OPEN4_SHARE_ACCESS_WRITE == 0 &&
+		checkOpenPermissions(metaSvc, authCtx, fileHandle, types.OPEN4_SHARE_ACCESS_WRITE) != nil {
+		want = want&^types.OPEN4_SHARE_ACCESS_WANT_DELEG_MASK | types.OPEN4_SHARE_ACCESS_WANT_READ_DELEG
+	}
+
+	res, err := h.StateManager.WantDelegation(clientID, []byte(fileHandle), want)
+	if err != nil {
+		// An unknown want value only loses the delegation, not the open.
+		return nil
+	}
+	return res
+}
+
+// openDelegationType returns the delegation type granted by an OPEN, for logging.
+func openDelegationType(deleg *state.DelegationWantResult) uint32 {
+	if deleg == nil || deleg.Deleg == nil {
+		return types.OPEN_DELEGATE_NONE
+	}
+	return deleg.Deleg.DelegType
+}
+
 // encodeOpenResult encodes the OPEN4resok response shared by all claim paths.
 //
 // The deleg parameter controls the open_delegation4 response:
 //   - nil: OPEN_DELEGATE_NONE
-//   - non-nil: full delegation encoding (stateid, recall, ACE, space limit)
+//   - granted delegation: full delegation encoding (stateid, recall, ACE, space limit)
+//   - declined v4.1 want: OPEN_DELEGATE_NONE_EXT with its why_no_delegation4 reason
 func (h *Handler) encodeOpenResult(
 	clientID uint64, ownerData []byte,
 	stateid *types.Stateid4, rflags uint32,
 	beforeCtime, afterCtime uint64,
-	deleg *state.DelegationState,
+	deleg *state.DelegationWantResult,
 ) *types.CompoundResult {
 	var buf bytes.Buffer
 	_ = xdr.WriteUint32(&buf, types.NFS4_OK)
@@ -692,7 +741,11 @@ func (h *Handler) encodeOpenResult(
 	encodeChangeInfo4(&buf, true, beforeCtime, afterCtime)
 	_ = xdr.WriteUint32(&buf, rflags)
 	_ = xdr.WriteUint32(&buf, 0) // attrset: empty bitmap
-	state.EncodeDelegation(&buf, deleg)
+	if deleg == nil {
+		state.EncodeDelegation(&buf, nil)
+	} else {
+		state.EncodeDelegationWant(&buf, deleg)
+	}
 
 	// Cache the result for replay detection
 	h.StateManager.CacheOpenOwnerResult(clientID, ownerData, types.NFS4_OK, buf.Bytes())
@@ -919,6 +972,8 @@ func (h *Handler) handleOpenClaimDelegateCur(
 		_ = h.StateManager.ConfirmOpenV41(&openResult.Stateid)
 	}
 
+	metaSvc.AuditFileAccess(authCtx, fileHandle, metadata.FileAuditOpen, openAuditMask(shareAccess), nil)
+
 	logger.Debug("NFSv4 OPEN CLAIM_DELEGATE_CUR successful",
 		"file", filename,
 		"stateid_seqid", openResult.Stateid.Seqid,
@@ -954,7 +1009,28 @@ func openError(status uint32) *types.CompoundResult {
 // checkOpenAccess verifies that the caller has appropriate file-level permissions
 // for the requested share_access mode. This is required by NFSv4 OPEN to enforce
 // POSIX access control -- without it, any user can open any file regardless of mode bits.
+// A denial is recorded in the file-access audit trail.
 func checkOpenAccess(metaSvc *metadata.Service, authCtx *metadata.AuthContext, handle metadata.FileHandle, shareAccess uint32) error {
+	err := checkOpenPermissions(metaSvc, authCtx, handle, shareAccess)
+	if err != nil {
+		metaSvc.AuditFileAccess(authCtx, handle, metadata.FileAuditOpen, openAuditMask(shareAccess), err)
+	}
+	return err
+}
+
+// openAuditMask maps an OPEN share_access to the ACE4 access bits it requests.
+func openAuditMask(shareAccess uint32) uint32 {
+	var mask uint32
+	if shareAccess&types.OPEN4_SHARE_ACCESS_READ != 0 {
+		mask |= acl.ACE4_READ_DATA
+	}
+	if shareAccess&types.OPEN4_SHARE_ACCESS_WRITE != 0 {
+		mask |= acl.ACE4_WRITE_DATA | acl.ACE4_APPEND_DATA
+	}
+	return mask
+}
+
+func checkOpenPermissions(metaSvc *metadata.Service, authCtx *metadata.AuthContext, handle metadata.FileHandle, shareAccess uint32) error {
 	var requiredPerm metadata.Permission
 	if shareAccess&types.OPEN4_SHARE_ACCESS_READ != 0 {
 		requiredPerm |= metadata.PermissionRead
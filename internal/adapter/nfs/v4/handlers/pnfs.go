@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/attrs"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
+	"github.com/marmos91/dittofs/internal/logger"
+)
+
+// pNFS data server protocol versions accepted in PNFSConfig.DataServerVersion.
+const (
+	PNFSDataServerVersion3  = "3"
+	PNFSDataServerVersion41 = "4.1"
+)
+
+// pnfsResolveTimeout bounds the DNS lookups done when the data server list
+// changes, so a dead resolver cannot stall the settings watcher.
+const pnfsResolveTimeout = 5 * time.Second
+
+// PNFSConfig configures the pNFS Flexible Files metadata server role.
+//
+// Each data server must be a DittoFS instance exporting the same shares
+// backed by the same metadata and block stores as this server: the layout
+// hands out this server's file handles unchanged, and the data server
+// resolves them against the shared stores.
+type PNFSConfig struct {
+	// DataServers lists data server addresses as "host:port". Empty disables pNFS.
+	DataServers []string
+
+	// DataServerVersion is the NFS version clients use to reach the data
+	// servers: "3" (default) or "4.1".
+	DataServerVersion string
+
+	// RSize and WSize are the I/O sizes advertised per data server.
+	RSize uint32
+	WSize uint32
+}
+
+// pnfsDevice is one data server as handed out in layouts and GETDEVICEINFO.
+type pnfsDevice struct {
+	id   types.DeviceId4
+	addr types.FFDeviceAddr4
+}
+
+// pnfsState is the resolved device set. Replaced wholesale on config change.
+type pnfsState struct {
+	config  PNFSConfig
+	devices []pnfsDevice
+}
+
+// SetPNFSConfig replaces the data server set. Called when live settings
+// change (via SettingsWatcher).
+//
+// Data servers that cannot be resolved are skipped with a warning. When the
+// device set changes, every outstanding layout is recalled so clients stop
+// using stale devices. An empty resolved set disables pNFS: LAYOUTGET answers
+// NFS4ERR_LAYOUTUNAVAILABLE and clients do all I/O through this server.
+func (h *Handler) SetPNFSConfig(cfg PNFSConfig) {
+	if cfg.DataServerVersion == "" {
+		cfg.DataServerVersion = PNFSDataServerVersion3
+	}
+
+	devices := make([]pnfsDevice, 0, len(cfg.DataServers))
+	for _, ds := range cfg.DataServers {
+		dev, err := resolvePNFSDevice(ds, cfg)
+		if err != nil {
+			logger.Warn("pNFS: skipping unresolvable data server", "data_server", ds, "error", err)
+			continue
+		}
+		devices = append(devices, dev)
+	}
+
+	next := &pnfsState{config: cfg, devices: devices}
+	prev := h.pnfs.Swap(next)
+
+	enabled := len(devices) > 0
+	if enabled {
+		attrs.SetLayoutTypes([]uint32{types.LAYOUT4_FLEX_FILES})
+	} else {
+		attrs.SetLayoutTypes(nil)
+	}
+	if h.StateManager != nil {
+		if prev != nil && (!samePNFSDevices(prev.devices, devices) ||
+			prev.config.DataServerVersion != cfg.DataServerVersion) {
+			if n := h.StateManager.RecallAllLayouts(); n > 0 {
+				logger.Info("pNFS: data servers changed, recalling layouts", "layouts", n)
+			}
+		}
+		h.StateManager.SetLayoutsEnabled(enabled)
+	}
+
+	if enabled || (prev != nil && len(prev.devices) > 0) {
+		logger.Info("pNFS: data server set applied",
+			"data_servers", len(devices),
+			"version", cfg.DataServerVersion)
+	}
+}
+
+// pnfsDevices returns the current device set (nil when pNFS is disabled).
+func (h *Handler) pnfsDevices() *pnfsState {
+	st := h.pnfs.Load()
+	if st == nil || len(st.devices) == 0 {
+		return nil
+	}
+	return st
+}
+
+// findDevice returns the device with the given ID.
+func (st *pnfsState) findDevice(id types.DeviceId4) (*pnfsDevice, bool) {
+	for i := range st.devices {
+		if st.devices[i].id == id {
+			return &st.devices[i], true
+		}
+	}
+	return nil, false
+}
+
+// deviceForHandle picks the data server for a file by hashing its handle,
+// so a file always maps to the same data server while the set is unchanged.
+func (st *pnfsState) deviceForHandle(fh []byte) *pnfsDevice {
+	sum := sha256.Sum256(fh)
+	idx := (uint64(sum[0])<<24 | uint64(sum[1])<<16 | uint64(sum[2])<<8 | uint64(sum[3])) % uint64(len(st.devices))
+	return &st.devices[idx]
+}
+
+// resolvePNFSDevice resolves a "host:port" data server into a device whose
+// ID is derived from the configured address (stable across restarts).
+func resolvePNFSDevice(hostPort string, cfg PNFSConfig) (pnfsDevice, error) {
+	host, portStr, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return pnfsDevice{}, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || port == 0 {
+		return pnfsDevice{}, fmt.Errorf("invalid port %q", portStr)
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), pnfsResolveTimeout)
+		defer cancel()
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return pnfsDevice{}, err
+		}
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return pnfsDevice{}, fmt.Errorf("no addresses for %q", host)
+	}
+
+	var dev pnfsDevice
+	sum := sha256.Sum256([]byte(hostPort))
+	copy(dev.id[:], sum[:])
+	for _, ip := range ips {
+		dev.addr.NetAddrs = append(dev.addr.NetAddrs, universalAddr(ip, uint16(port)))
+	}
+
+	version := types.FFDeviceVersion4{Version: 3, RSize: cfg.RSize, WSize: cfg.WSize}
+	if cfg.DataServerVersion == PNFSDataServerVersion41 {
+		version.Version = 4
+		version.MinorVersion = 1
+	}
+	dev.addr.Versions = []types.FFDeviceVersion4{version}
+	return dev, nil
+}
+
+// universalAddr formats ip:port as an RPC universal address
+// (RFC 5665 Section 5.2.3): the IP followed by the port's high and low octets.
+func universalAddr(ip net.IP, port uint16) types.NetAddr4 {
+	netid := "tcp"
+	if ip.To4() == nil {
+		netid = "tcp6"
+	}
+	return types.NetAddr4{
+		Netid: netid,
+		Addr:  fmt.Sprintf("%s.%d.%d", ip.String(), port>>8, port&0xff),
+	}
+}
+
+// samePNFSDevices reports whether two device sets hand out the same devices
+// in the same order (the order determines which file maps to which server).
+func samePNFSDevices(a, b []pnfsDevice) bool {
+	return slices.EqualFunc(a, b, func(x, y pnfsDevice) bool {
+		return x.id == y.id
+	})
+}
@@ -201,7 +201,7 @@ func (h *Handler) readDirRealFS(ctx *types.CompoundContext, cookie uint64, cooki
 				ShareName: entryShareName,
 				FileAttr:  *entry.Attr,
 			}
-			_ = attrs.EncodeRealFileAttrs(&entryBuf, attrRequest, file, entry.Handle)
+			_ = attrs.EncodeRealFileAttrs(&entryBuf, attrRequest, file, entry.Handle, ownerFormat(ctx))
 		} else {
 			// No attrs available -- encode empty fattr4
 			_ = attrs.EncodeBitmap4(&entryBuf, nil)
@@ -10,7 +10,7 @@ import (
 )
 
 // registerV41Ops registers the 19 NFSv4.1 operations (op numbers 40-58) into
-// v41DispatchTable. Most are stubs that decode their args and return NOTSUPP.
+// v41DispatchTable. The remaining stubs decode their args and return NOTSUPP.
 func (h *Handler) registerV41Ops() {
 	// BACKCHANNEL_CTL: update callback program and security params (RFC 8881 Section 18.33)
 	h.v41DispatchTable[types.OP_BACKCHANNEL_CTL] = func(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
@@ -38,30 +38,26 @@ func (h *Handler) registerV41Ops() {
 	h.v41DispatchTable[types.OP_GET_DIR_DELEGATION] = func(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
 		return v41handlers.HandleGetDirDelegation(h.v41Deps, ctx, v41ctx, reader)
 	}
-	h.v41DispatchTable[types.OP_GETDEVICEINFO] = v41StubHandler(types.OP_GETDEVICEINFO, func(r io.Reader) error {
-		var args types.GetDeviceInfoArgs
-		return args.Decode(r)
-	})
-	h.v41DispatchTable[types.OP_GETDEVICELIST] = v41StubHandler(types.OP_GETDEVICELIST, func(r io.Reader) error {
-		var args types.GetDeviceListArgs
-		return args.Decode(r)
-	})
-	h.v41DispatchTable[types.OP_LAYOUTCOMMIT] = v41StubHandler(types.OP_LAYOUTCOMMIT, func(r io.Reader) error {
-		var args types.LayoutCommitArgs
-		return args.Decode(r)
-	})
-	h.v41DispatchTable[types.OP_LAYOUTGET] = v41StubHandler(types.OP_LAYOUTGET, func(r io.Reader) error {
-		var args types.LayoutGetArgs
-		return args.Decode(r)
-	})
-	h.v41DispatchTable[types.OP_LAYOUTRETURN] = v41StubHandler(types.OP_LAYOUTRETURN, func(r io.Reader) error {
-		var args types.LayoutReturnArgs
-		return args.Decode(r)
-	})
-	h.v41DispatchTable[types.OP_SECINFO_NO_NAME] = v41StubHandler(types.OP_SECINFO_NO_NAME, func(r io.Reader) error {
-		var args types.SecinfoNoNameArgs
-		return args.Decode(r)
-	})
+	// GETDEVICEINFO / GETDEVICELIST / LAYOUT*: pNFS Flexible Files layouts (RFC 8881 Section 18.40-18.44, RFC 8435)
+	h.v41DispatchTable[types.OP_GETDEVICEINFO] = func(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
+		return h.handleGetDeviceInfo(ctx, v41ctx, reader)
+	}
+	h.v41DispatchTable[types.OP_GETDEVICELIST] = func(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
+		return h.handleGetDeviceList(ctx, v41ctx, reader)
+	}
+	h.v41DispatchTable[types.OP_LAYOUTCOMMIT] = func(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
+		return h.handleLayoutCommit(ctx, v41ctx, reader)
+	}
+	h.v41DispatchTable[types.OP_LAYOUTGET] = func(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
+		return h.handleLayoutGet(ctx, v41ctx, reader)
+	}
+	h.v41DispatchTable[types.OP_LAYOUTRETURN] = func(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
+		return h.handleLayoutReturn(ctx, v41ctx, reader)
+	}
+	// SECINFO_NO_NAME: flavor negotiation for the current FH or its parent (RFC 8881 Section 18.45)
+	h.v41DispatchTable[types.OP_SECINFO_NO_NAME] = func(ctx *types.CompoundContext, _ *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
+		return h.handleSecInfoNoName(ctx, reader)
+	}
 	// SEQUENCE at position > 0 returns NFS4ERR_SEQUENCE_POS per RFC 8881.
 	// SEQUENCE at position 0 is handled specially in dispatchV41 before the op loop.
 	h.v41DispatchTable[types.OP_SEQUENCE] = func(ctx *types.CompoundContext, _ *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
@@ -86,10 +82,10 @@ func (h *Handler) registerV41Ops() {
 	h.v41DispatchTable[types.OP_TEST_STATEID] = func(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
 		return v41handlers.HandleTestStateid(h.v41Deps, ctx, v41ctx, reader)
 	}
-	h.v41DispatchTable[types.OP_WANT_DELEGATION] = v41StubHandler(types.OP_WANT_DELEGATION, func(r io.Reader) error {
-		var args types.WantDelegationArgs
-		return args.Decode(r)
-	})
+	// WANT_DELEGATION: delegation on an already-open file (RFC 8881 Section 18.49)
+	h.v41DispatchTable[types.OP_WANT_DELEGATION] = func(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
+		return h.handleWantDelegation(ctx, v41ctx, reader)
+	}
 	h.v41DispatchTable[types.OP_DESTROY_CLIENTID] = func(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
 		return v41handlers.HandleDestroyClientID(h.v41Deps, ctx, v41ctx, reader)
 	}
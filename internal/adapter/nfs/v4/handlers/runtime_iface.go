@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"net"
 
 	"github.com/marmos91/dittofs/internal/adapter/common"
 	"github.com/marmos91/dittofs/pkg/controlplane/models"
@@ -33,6 +34,11 @@ type nfsRuntime interface {
 	// Identity / auth.
 	GetIdentityStore() models.IdentityStore
 	ApplyIdentityMapping(shareName string, ident *metadata.Identity) (*metadata.Identity, error)
+
+	// Subtree exports: the export policy governing a handle, and identity
+	// mapping under that policy's squash settings.
+	ResolveExportPolicy(ctx context.Context, shareName string, handle metadata.FileHandle, clientIP net.IP) (*runtime.ExportPolicy, error)
+	ApplyExportIdentityMapping(shareName string, policy *runtime.ExportPolicy, ident *metadata.Identity) (*metadata.Identity, error)
 }
 
 var _ nfsRuntime = (*runtime.Runtime)(nil)
@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"io"
 
+	"github.com/marmos91/dittofs/internal/adapter/nfs/auth"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/pseudofs"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
 	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
+	"github.com/marmos91/dittofs/pkg/metadata"
 )
 
 // krb5OIDDER is the DER-encoded OID for the Kerberos 5 GSS-API mechanism.
@@ -29,8 +33,9 @@ const (
 const authRPCSECGSS uint32 = 6
 
 // handleSecInfo implements the SECINFO operation (RFC 7530 Section 16.33).
-// Returns available security mechanisms (AUTH_SYS, AUTH_NONE, optional Kerberos) for a name.
-// No store delegation; checks Handler.KerberosEnabled flag to build the security flavor list.
+// Returns the security mechanisms acceptable for a name in the current directory.
+// No store delegation; the list honors the target share's AllowAuthSys /
+// RequireKerberos / MinKerberosLevel policy and Handler.KerberosEnabled.
 // Consumes the current filehandle (CurrentFH cleared after SECINFO per RFC 7530).
 // Errors: NFS4ERR_NOFILEHANDLE, NFS4ERR_BADXDR.
 func (h *Handler) handleSecInfo(ctx *types.CompoundContext, reader io.Reader) *types.CompoundResult {
@@ -53,53 +58,193 @@ func (h *Handler) handleSecInfo(ctx *types.CompoundContext, reader io.Reader) *t
 		}
 	}
 
+	// The name only matters in the pseudo-fs, where it may be an export
+	// junction; below a share root every name belongs to that share.
+	share := h.secInfoShare(ctx.CurrentFH, name)
+
 	// Per RFC 7530 Section 16.31.4: SECINFO consumes the current filehandle.
 	ctx.CurrentFH = nil
 
-	// Build response based on whether Kerberos is enabled
+	logger.Debug("SECINFO: returning security flavors",
+		"name", name,
+		"share", secInfoShareName(share),
+		"client", ctx.ClientAddr)
+
+	return &types.CompoundResult{
+		Status: types.NFS4_OK,
+		OpCode: types.OP_SECINFO,
+		Data:   h.encodeSecInfoResult(share),
+	}
+}
+
+// handleSecInfoNoName implements the SECINFO_NO_NAME operation (RFC 8881 Section 18.45).
+// Returns the security mechanisms acceptable for the current filehandle
+// (SECINFO_STYLE4_CURRENT_FH) or its parent (SECINFO_STYLE4_PARENT), so a
+// client can negotiate a flavor after PUTROOTFH / PUTFH without a name.
+// Consumes the current filehandle on success (RFC 8881 Section 18.45.3).
+// Errors: NFS4ERR_NOFILEHANDLE, NFS4ERR_BADXDR, NFS4ERR_INVAL (unknown style),
+// NFS4ERR_NOENT (parent of the pseudo-fs root).
+func (h *Handler) handleSecInfoNoName(ctx *types.CompoundContext, reader io.Reader) *types.CompoundResult {
+	var args types.SecinfoNoNameArgs
+	if err := args.Decode(reader); err != nil {
+		return &types.CompoundResult{
+			Status: types.NFS4ERR_BADXDR,
+			OpCode: types.OP_SECINFO_NO_NAME,
+			Data:   encodeStatusOnly(types.NFS4ERR_BADXDR),
+		}
+	}
+
+	if status := types.RequireCurrentFH(ctx); status != types.NFS4_OK {
+		return &types.CompoundResult{
+			Status: status,
+			OpCode: types.OP_SECINFO_NO_NAME,
+			Data:   encodeStatusOnly(status),
+		}
+	}
+
+	var share *runtime.Share
+	switch args.Style {
+	case types.SECINFO_STYLE4_CURRENT_FH:
+		share = h.secInfoShare(ctx.CurrentFH, "")
+	case types.SECINFO_STYLE4_PARENT:
+		var status uint32
+		share, status = h.secInfoParentShare(ctx.CurrentFH)
+		if status != types.NFS4_OK {
+			return &types.CompoundResult{
+				Status: status,
+				OpCode: types.OP_SECINFO_NO_NAME,
+				Data:   encodeStatusOnly(status),
+			}
+		}
+	default:
+		return &types.CompoundResult{
+			Status: types.NFS4ERR_INVAL,
+			OpCode: types.OP_SECINFO_NO_NAME,
+			Data:   encodeStatusOnly(types.NFS4ERR_INVAL),
+		}
+	}
+
+	// Per RFC 8881 Section 18.45.3: SECINFO_NO_NAME consumes the current
+	// filehandle, just like SECINFO.
+	ctx.CurrentFH = nil
+
+	logger.Debug("SECINFO_NO_NAME: returning security flavors",
+		"style", args.Style,
+		"share", secInfoShareName(share),
+		"client", ctx.ClientAddr)
+
+	return &types.CompoundResult{
+		Status: types.NFS4_OK,
+		OpCode: types.OP_SECINFO_NO_NAME,
+		Data:   h.encodeSecInfoResult(share),
+	}
+}
+
+// secInfoShare returns the share whose security policy governs name in the
+// directory fh (or fh itself when name is empty). A nil result means the
+// target is in the pseudo-fs (or unknown) and gets the server-wide flavor list.
+func (h *Handler) secInfoShare(fh []byte, name string) *runtime.Share {
+	if h.Registry == nil {
+		return nil
+	}
+
+	shareName := ""
+	if pseudofs.IsPseudoFSHandle(fh) {
+		if name == "" || h.PseudoFS == nil {
+			return nil
+		}
+		node, ok := h.PseudoFS.LookupByHandle(fh)
+		if !ok {
+			return nil
+		}
+		child, ok := h.PseudoFS.LookupChild(node, name)
+		if !ok || !child.IsExport {
+			return nil
+		}
+		shareName = child.ShareName
+	} else {
+		decoded, _, err := metadata.DecodeFileHandle(metadata.FileHandle(fh))
+		if err != nil {
+			return nil
+		}
+		shareName = decoded
+	}
+
+	share, err := h.Registry.GetShare(shareName)
+	if err != nil {
+		return nil
+	}
+	return share
+}
+
+// secInfoParentShare returns the share governing the parent of fh. The parent
+// of a share root is its pseudo-fs junction, so it gets the server-wide list.
+func (h *Handler) secInfoParentShare(fh []byte) (*runtime.Share, uint32) {
+	if pseudofs.IsPseudoFSHandle(fh) {
+		if h.PseudoFS != nil && bytes.Equal(fh, h.PseudoFS.GetRootHandle()) {
+			return nil, types.NFS4ERR_NOENT
+		}
+		return nil, types.NFS4_OK
+	}
+
+	share := h.secInfoShare(fh, "")
+	if share != nil && bytes.Equal(fh, share.RootHandle) {
+		return nil, types.NFS4_OK
+	}
+	return share, types.NFS4_OK
+}
+
+// secInfoShareName names the share in SECINFO debug logs.
+func secInfoShareName(share *runtime.Share) string {
+	if share == nil {
+		return "(pseudo-fs)"
+	}
+	return share.Name
+}
+
+// encodeSecInfoResult encodes a successful SECINFO4res for share: the flavors
+// it accepts, most secure first. A nil share advertises every flavor the
+// server supports. The list mirrors checkExportAuthFlavor, so a client that
+// picks any entry is not refused with NFS4ERR_WRONGSEC.
+func (h *Handler) encodeSecInfoResult(share *runtime.Share) []byte {
 	const (
 		authNoneFlavor = 0
 		authSysFlavor  = 1
 	)
 
-	var buf bytes.Buffer
-	_ = xdr.WriteUint32(&buf, types.NFS4_OK)
-
+	var gssServices []uint32
 	if h.KerberosEnabled {
-		// Return 5 entries: krb5p, krb5i, krb5, AUTH_SYS, AUTH_NONE
-		// Ordered most secure first per RFC 7530 convention
-		logger.Debug("SECINFO: returning RPCSEC_GSS (krb5p, krb5i, krb5) + AUTH_SYS + AUTH_NONE",
-			"name", name,
-			"client", ctx.ClientAddr)
-
-		_ = xdr.WriteUint32(&buf, 5) // array length: 5 entries
-
-		// 1. krb5p (privacy)
-		encodeSecInfoGSSEntry(&buf, rpcGSSSvcPrivacy)
-		// 2. krb5i (integrity)
-		encodeSecInfoGSSEntry(&buf, rpcGSSSvcIntegrity)
-		// 3. krb5 (auth only, no integrity/privacy)
-		encodeSecInfoGSSEntry(&buf, rpcGSSSvcNone)
-		// 4. AUTH_SYS fallback
-		_ = xdr.WriteUint32(&buf, authSysFlavor)
-		// 5. AUTH_NONE
-		_ = xdr.WriteUint32(&buf, authNoneFlavor)
-	} else {
-		// No Kerberos: return AUTH_SYS + AUTH_NONE only
-		logger.Debug("SECINFO: returning AUTH_SYS + AUTH_NONE",
-			"name", name,
-			"client", ctx.ClientAddr)
+		// krb5p, krb5i, krb5, dropping levels below the share's floor.
+		for _, svc := range []uint32{rpcGSSSvcPrivacy, rpcGSSSvcIntegrity, rpcGSSSvcNone} {
+			if share == nil || auth.MeetsMinKerberosLevel(share.MinKerberosLevel, svc) {
+				gssServices = append(gssServices, svc)
+			}
+		}
+	}
+	allowSys := share == nil || (share.AllowAuthSys && !share.RequireKerberos)
+	allowNone := share == nil || !share.RequireKerberos
 
-		_ = xdr.WriteUint32(&buf, 2) // array length: 2 entries
-		_ = xdr.WriteUint32(&buf, authSysFlavor)
-		_ = xdr.WriteUint32(&buf, authNoneFlavor)
+	count := uint32(len(gssServices))
+	if allowSys {
+		count++
+	}
+	if allowNone {
+		count++
 	}
 
-	return &types.CompoundResult{
-		Status: types.NFS4_OK,
-		OpCode: types.OP_SECINFO,
-		Data:   buf.Bytes(),
+	var buf bytes.Buffer
+	_ = xdr.WriteUint32(&buf, types.NFS4_OK)
+	_ = xdr.WriteUint32(&buf, count)
+	for _, svc := range gssServices {
+		encodeSecInfoGSSEntry(&buf, svc)
+	}
+	if allowSys {
+		_ = xdr.WriteUint32(&buf, authSysFlavor)
+	}
+	if allowNone {
+		_ = xdr.WriteUint32(&buf, authNoneFlavor)
 	}
+	return buf.Bytes()
 }
 
 // encodeSecInfoGSSEntry encodes a single RPCSEC_GSS secinfo4 entry.
@@ -3,11 +3,14 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/pseudofs"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/metadata"
 )
 
 // ============================================================================
@@ -410,3 +413,178 @@ func TestEncodeSecInfoGSSEntry_None(t *testing.T) {
 		t.Fatalf("service = %d, want 1 (none)", svc)
 	}
 }
+
+// ============================================================================
+// Per-share flavor lists and SECINFO_NO_NAME (RFC 8881 Section 18.45)
+// ============================================================================
+
+// decodeSecInfoFlavors parses a SECINFO4res body into flavor names
+// ("krb5p", "krb5i", "krb5", "sys", "none") in wire order.
+func decodeSecInfoFlavors(t *testing.T, data []byte) (uint32, []string) {
+	t.Helper()
+	reader := bytes.NewReader(data)
+	status, _ := xdr.DecodeUint32(reader)
+	if status != types.NFS4_OK {
+		return status, nil
+	}
+	count, _ := xdr.DecodeUint32(reader)
+	flavors := make([]string, 0, count)
+	for i := uint32(0); i < count; i++ {
+		flavor, _ := xdr.DecodeUint32(reader)
+		switch flavor {
+		case 0:
+			flavors = append(flavors, "none")
+		case 1:
+			flavors = append(flavors, "sys")
+		case authRPCSECGSS:
+			_, _ = xdr.DecodeOpaque(reader) // oid
+			_, _ = xdr.DecodeUint32(reader) // qop
+			svc, _ := xdr.DecodeUint32(reader)
+			flavors = append(flavors, map[uint32]string{
+				rpcGSSSvcNone: "krb5", rpcGSSSvcIntegrity: "krb5i", rpcGSSSvcPrivacy: "krb5p",
+			}[svc])
+		default:
+			t.Fatalf("unexpected flavor %d", flavor)
+		}
+	}
+	return status, flavors
+}
+
+func secInfoNoNameArgs(style uint32) []byte {
+	var args bytes.Buffer
+	_ = xdr.WriteUint32(&args, style)
+	return args.Bytes()
+}
+
+func assertFlavors(t *testing.T, got, want []string) {
+	t.Helper()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("flavors = %v, want %v", got, want)
+	}
+}
+
+func TestHandleSecInfo_RequireKerberosShare(t *testing.T) {
+	fx := newRealFSTestFixture(t, "/export")
+	fx.handler.KerberosEnabled = true
+	if err := fx.rt.SetExportAuthPolicyForTesting("/export", true, true); err != nil {
+		t.Fatalf("SetExportAuthPolicyForTesting: %v", err)
+	}
+
+	// SECINFO(pseudo root, "export") names the junction, so the share's
+	// policy applies.
+	pfs := fx.handler.PseudoFS
+	ctx := newRealFSContext(1000, 1000)
+	ctx.CurrentFH = append([]byte(nil), pfs.GetRootHandle()...)
+	var args bytes.Buffer
+	_ = xdr.WriteXDRString(&args, "export")
+
+	result := fx.handler.handleSecInfo(ctx, bytes.NewReader(args.Bytes()))
+	_, flavors := decodeSecInfoFlavors(t, result.Data)
+	assertFlavors(t, flavors, []string{"krb5p", "krb5i", "krb5"})
+
+	// Inside the share the same policy applies to every name.
+	ctx.CurrentFH = append([]byte(nil), fx.rootHandle...)
+	args.Reset()
+	_ = xdr.WriteXDRString(&args, "anything")
+	result = fx.handler.handleSecInfo(ctx, bytes.NewReader(args.Bytes()))
+	_, flavors = decodeSecInfoFlavors(t, result.Data)
+	assertFlavors(t, flavors, []string{"krb5p", "krb5i", "krb5"})
+}
+
+func TestHandleSecInfoNoName_CurrentFH(t *testing.T) {
+	fx := newRealFSTestFixture(t, "/export")
+	fx.handler.KerberosEnabled = true
+	if err := fx.rt.SetExportAuthPolicyForTesting("/export", false, false); err != nil {
+		t.Fatalf("SetExportAuthPolicyForTesting: %v", err)
+	}
+	if err := fx.rt.SetMinKerberosLevelForTesting("/export", models.KerberosLevelKrb5i); err != nil {
+		t.Fatalf("SetMinKerberosLevelForTesting: %v", err)
+	}
+
+	// Pseudo-fs root: the server-wide list.
+	ctx := newRealFSContext(1000, 1000)
+	ctx.CurrentFH = append([]byte(nil), fx.handler.PseudoFS.GetRootHandle()...)
+	result := fx.handler.handleSecInfoNoName(ctx, bytes.NewReader(secInfoNoNameArgs(types.SECINFO_STYLE4_CURRENT_FH)))
+	if result.Status != types.NFS4_OK {
+		t.Fatalf("SECINFO_NO_NAME status = %d, want NFS4_OK", result.Status)
+	}
+	_, flavors := decodeSecInfoFlavors(t, result.Data)
+	assertFlavors(t, flavors, []string{"krb5p", "krb5i", "krb5", "sys", "none"})
+	if ctx.CurrentFH != nil {
+		t.Error("CurrentFH should be consumed by SECINFO_NO_NAME")
+	}
+
+	// Share root: AUTH_SYS dropped, krb5 below the krb5i floor dropped.
+	ctx.CurrentFH = append([]byte(nil), fx.rootHandle...)
+	result = fx.handler.handleSecInfoNoName(ctx, bytes.NewReader(secInfoNoNameArgs(types.SECINFO_STYLE4_CURRENT_FH)))
+	_, flavors = decodeSecInfoFlavors(t, result.Data)
+	assertFlavors(t, flavors, []string{"krb5p", "krb5i", "none"})
+}
+
+func TestHandleSecInfoNoName_Parent(t *testing.T) {
+	fx := newRealFSTestFixture(t, "/export")
+	if err := fx.rt.SetExportAuthPolicyForTesting("/export", false, false); err != nil {
+		t.Fatalf("SetExportAuthPolicyForTesting: %v", err)
+	}
+	dirHandle := fx.createTestFile(t, fx.rootHandle, "dir", metadata.FileTypeDirectory, 0o755, 1000, 1000)
+
+	// Parent of a directory inside the share is governed by the share.
+	ctx := newRealFSContext(1000, 1000)
+	ctx.CurrentFH = append([]byte(nil), dirHandle...)
+	result := fx.handler.handleSecInfoNoName(ctx, bytes.NewReader(secInfoNoNameArgs(types.SECINFO_STYLE4_PARENT)))
+	_, flavors := decodeSecInfoFlavors(t, result.Data)
+	assertFlavors(t, flavors, []string{"none"})
+
+	// The pseudo-fs root has no parent.
+	ctx.CurrentFH = append([]byte(nil), fx.handler.PseudoFS.GetRootHandle()...)
+	result = fx.handler.handleSecInfoNoName(ctx, bytes.NewReader(secInfoNoNameArgs(types.SECINFO_STYLE4_PARENT)))
+	if result.Status != types.NFS4ERR_NOENT {
+		t.Fatalf("SECINFO_NO_NAME(parent of root) status = %d, want NFS4ERR_NOENT", result.Status)
+	}
+	if ctx.CurrentFH == nil {
+		t.Error("CurrentFH should be kept when SECINFO_NO_NAME fails")
+	}
+}
+
+func TestHandleSecInfoNoName_Errors(t *testing.T) {
+	h := NewHandler(nil, pseudofs.New())
+	ctx := &types.CompoundContext{Context: context.Background(), ClientAddr: "127.0.0.1:9999"}
+
+	result := h.handleSecInfoNoName(ctx, bytes.NewReader(secInfoNoNameArgs(types.SECINFO_STYLE4_CURRENT_FH)))
+	if result.Status != types.NFS4ERR_NOFILEHANDLE {
+		t.Errorf("no FH status = %d, want NFS4ERR_NOFILEHANDLE", result.Status)
+	}
+
+	ctx.CurrentFH = h.PseudoFS.GetRootHandle()
+	result = h.handleSecInfoNoName(ctx, bytes.NewReader(secInfoNoNameArgs(7)))
+	if result.Status != types.NFS4ERR_INVAL {
+		t.Errorf("bad style status = %d, want NFS4ERR_INVAL", result.Status)
+	}
+
+	result = h.handleSecInfoNoName(ctx, bytes.NewReader(nil))
+	if result.Status != types.NFS4ERR_BADXDR {
+		t.Errorf("truncated args status = %d, want NFS4ERR_BADXDR", result.Status)
+	}
+}
+
+// TestLookup_JunctionWrongSec verifies that crossing from the pseudo-fs into a
+// share that refuses the request's flavor fails with NFS4ERR_WRONGSEC at the
+// LOOKUP, leaving the current FH on the parent for a following SECINFO.
+func TestLookup_JunctionWrongSec(t *testing.T) {
+	fx := newRealFSTestFixture(t, "/export")
+	if err := fx.rt.SetExportAuthPolicyForTesting("/export", true, true); err != nil {
+		t.Fatalf("SetExportAuthPolicyForTesting: %v", err)
+	}
+
+	rootHandle := fx.handler.PseudoFS.GetRootHandle()
+	ctx := newRealFSContext(1000, 1000) // AUTH_UNIX
+	ctx.CurrentFH = append([]byte(nil), rootHandle...)
+
+	result := fx.handler.lookupInPseudoFS(ctx, "export")
+	if result.Status != types.NFS4ERR_WRONGSEC {
+		t.Fatalf("LOOKUP across junction status = %d, want NFS4ERR_WRONGSEC", result.Status)
+	}
+	if !bytes.Equal(ctx.CurrentFH, rootHandle) {
+		t.Error("CurrentFH should stay on the pseudo-fs parent after WRONGSEC")
+	}
+}
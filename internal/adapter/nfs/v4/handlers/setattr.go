@@ -193,7 +193,16 @@ func (h *Handler) handleSetAttr(ctx *types.CompoundContext, reader io.Reader) *t
 		}
 	}
 
-	// 8. Notify directory delegation holders about significant attribute changes
+	// 8. A size change invalidates the file extent clients are doing pNFS
+	// I/O against, and a mode, owner or ACL change invalidates the access
+	// their layouts were issued under, so recall every layout on the file
+	// (RFC 8881 Section 12.5.5). The data-server credentials of those
+	// layouts already stopped working with the mode or owner change.
+	if h.StateManager != nil && (sizeChange || isAccessAttrChange(requestedBitmap)) {
+		h.StateManager.RecallLayouts(ctx.CurrentFH)
+	}
+
+	// 9. Notify directory delegation holders about significant attribute changes
 	if h.StateManager != nil && isSignificantAttrChange(requestedBitmap) {
 		// Check if the target is a directory by inspecting the file
 		file, getErr := metaSvc.GetFile(ctx.Context, metadata.FileHandle(ctx.CurrentFH))
@@ -209,7 +218,7 @@ func (h *Handler) handleSetAttr(ctx *types.CompoundContext, reader io.Reader) *t
 		}
 	}
 
-	// 9. Success: encode response with attrsset bitmap
+	// 10. Success: encode response with attrsset bitmap
 	logger.Debug("NFSv4 SETATTR success",
 		"handle", string(ctx.CurrentFH))
 
@@ -271,6 +280,15 @@ func isSignificantAttrChange(bitmap []uint32) bool {
 		attrs.IsBitSet(bitmap, attrs.FATTR4_SIZE)
 }
 
+// isAccessAttrChange returns true if the SETATTR changes who may access the
+// file: its mode, owner, owner group or ACL.
+func isAccessAttrChange(bitmap []uint32) bool {
+	return attrs.IsBitSet(bitmap, attrs.FATTR4_MODE) ||
+		attrs.IsBitSet(bitmap, attrs.FATTR4_OWNER) ||
+		attrs.IsBitSet(bitmap, attrs.FATTR4_OWNER_GROUP) ||
+		attrs.IsBitSet(bitmap, attrs.FATTR4_ACL)
+}
+
 // encodeSetAttrSuccess encodes a successful SETATTR response.
 // Response format: status (NFS4_OK) + attrsset bitmap
 func encodeSetAttrSuccess(attrsset []uint32) []byte {
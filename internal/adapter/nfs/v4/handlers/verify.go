@@ -74,7 +74,7 @@ func verifyAttributes(h *Handler, ctx *types.CompoundContext, reader io.Reader)
 		}
 
 		serverAttrData, err = encodeAttrValsOnly(func(buf *bytes.Buffer, responseBitmap []uint32) error {
-			return attrs.EncodeRealFileAttrs(buf, clientBitmap, file, metadata.FileHandle(ctx.CurrentFH))
+			return attrs.EncodeRealFileAttrs(buf, clientBitmap, file, metadata.FileHandle(ctx.CurrentFH), ownerFormat(ctx))
 		}, clientBitmap)
 		if err != nil {
 			return false, types.NFS4ERR_SERVERFAULT
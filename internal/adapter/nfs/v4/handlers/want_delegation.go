@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/marmos91/dittofs/internal/adapter/common"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/pseudofs"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/state"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// handleWantDelegation implements the WANT_DELEGATION operation (RFC 8881 Section 18.49).
+// Requests a delegation on the current filehandle without (re)opening it, honoring the
+// OPEN4_SHARE_ACCESS_WANT_* preference and deferred-grant flags in wda_want.
+// Delegates to StateManager.WantDelegation; declined wants may be followed up with
+// CB_PUSH_DELEG (contention cleared) or CB_RECALLABLE_OBJ_AVAIL (delegation limit cleared).
+// May create delegation state for the client; the current filehandle is unchanged.
+// Errors: NFS4ERR_NOFILEHANDLE, NFS4ERR_OP_NOT_IN_SESSION, NFS4ERR_GRACE, NFS4ERR_NOTSUPP
+// (delegation reclaim), NFS4ERR_INVAL, NFS4ERR_ACCESS, NFS4ERR_BADXDR.
+func (h *Handler) handleWantDelegation(ctx *types.CompoundContext, v41ctx *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
+	var args types.WantDelegationArgs
+	if err := args.Decode(reader); err != nil {
+		logger.Debug("WANT_DELEGATION: decode error", "error", err, "client", ctx.ClientAddr)
+		return wantDelegationError(types.NFS4ERR_BADXDR)
+	}
+
+	if status := types.RequireCurrentFH(ctx); status != types.NFS4_OK {
+		return wantDelegationError(status)
+	}
+	if v41ctx == nil {
+		return wantDelegationError(types.NFS4ERR_OP_NOT_IN_SESSION)
+	}
+
+	switch args.Claim.ClaimType {
+	case types.CLAIM_FH:
+	case types.CLAIM_PREVIOUS, types.CLAIM_DELEG_PREV_FH:
+		// Delegation reclaim needs persistent delegation state, which is
+		// out of scope (as for OPEN CLAIM_DELEGATE_PREV).
+		return wantDelegationError(types.NFS4ERR_NOTSUPP)
+	default:
+		return wantDelegationError(types.NFS4ERR_INVAL)
+	}
+
+	if graceErr := h.StateManager.CheckGraceForNewState(); graceErr != nil {
+		return wantDelegationError(mapStateError(graceErr))
+	}
+
+	if pseudofs.IsPseudoFSHandle(ctx.CurrentFH) {
+		return wantDelegationResult(&state.DelegationWantResult{WhyNone: types.WND4_IS_DIR})
+	}
+
+	authCtx, _, err := h.buildV4AuthContext(ctx, ctx.CurrentFH)
+	if err != nil {
+		return wantDelegationError(nfs4StatusForAuthError(err))
+	}
+	metaSvc, err := getMetadataServiceForCtx(h)
+	if err != nil {
+		return wantDelegationError(types.NFS4ERR_SERVERFAULT)
+	}
+	file, err := metaSvc.GetFile(ctx.Context, metadata.FileHandle(ctx.CurrentFH))
+	if err != nil {
+		return wantDelegationError(common.MapToNFS4(err))
+	}
+	switch file.Type {
+	case metadata.FileTypeRegular:
+	case metadata.FileTypeDirectory:
+		return wantDelegationResult(&state.DelegationWantResult{WhyNone: types.WND4_IS_DIR})
+	default:
+		return wantDelegationResult(&state.DelegationWantResult{WhyNone: types.WND4_NOT_SUPP_FTYPE})
+	}
+
+	// A delegation lets the client open the file locally, so the caller must
+	// be allowed the access the delegation type implies.
+	if args.Want&types.OPEN4_SHARE_ACCESS_WANT_DELEG_MASK == types.OPEN4_SHARE_ACCESS_WANT_WRITE_DELEG {
+		if err := checkOpenPermissions(metaSvc, authCtx, metadata.FileHandle(ctx.CurrentFH), types.OPEN4_SHARE_ACCESS_BOTH); err != nil {
+			return wantDelegationError(common.MapToNFS4(err))
+		}
+	} else if err := checkOpenPermissions(metaSvc, authCtx, metadata.FileHandle(ctx.CurrentFH), types.OPEN4_SHARE_ACCESS_READ); err != nil {
+		return wantDelegationError(common.MapToNFS4(err))
+	}
+
+	res, err := h.StateManager.WantDelegation(v41ctx.ClientID, ctx.CurrentFH, args.Want)
+	if err != nil {
+		return wantDelegationError(mapStateError(err))
+	}
+
+	logger.Debug("WANT_DELEGATION",
+		"want", args.Want,
+		"granted", res.Deleg != nil,
+		"why_none", res.WhyNone,
+		"will_notify", res.WillNotify,
+		"client", ctx.ClientAddr)
+
+	return wantDelegationResult(res)
+}
+
+// wantDelegationResult encodes a successful WANT_DELEGATION4res.
+func wantDelegationResult(res *state.DelegationWantResult) *types.CompoundResult {
+	var deleg bytes.Buffer
+	state.EncodeDelegationWant(&deleg, res)
+	raw := deleg.Bytes()
+
+	wantRes := types.WantDelegationRes{
+		Status:         types.NFS4_OK,
+		DelegationType: binary.BigEndian.Uint32(raw[:4]),
+		DelegationData: raw[4:],
+	}
+	var buf bytes.Buffer
+	_ = wantRes.Encode(&buf)
+	return &types.CompoundResult{
+		Status: types.NFS4_OK,
+		OpCode: types.OP_WANT_DELEGATION,
+		Data:   buf.Bytes(),
+	}
+}
+
+// wantDelegationError builds a status-only WANT_DELEGATION result.
+func wantDelegationError(status uint32) *types.CompoundResult {
+	return &types.CompoundResult{
+		Status: status,
+		OpCode: types.OP_WANT_DELEGATION,
+		Data:   encodeStatusOnly(status),
+	}
+}
@@ -60,6 +60,56 @@ func EncodeCBRecallOp(stateid *types.Stateid4, truncate bool, fh []byte) []byte
 	return buf.Bytes()
 }
 
+// EncodeCBLayoutRecallOp encodes one nfs_cb_argop4 for a whole-file
+// CB_LAYOUTRECALL of a Flexible Files layout (RFC 8881 Section 20.3).
+func EncodeCBLayoutRecallOp(stateid *types.Stateid4, fh []byte) []byte {
+	var buf bytes.Buffer
+
+	// argop: CB_LAYOUTRECALL = 5
+	_ = xdr.WriteUint32(&buf, types.CB_LAYOUTRECALL)
+
+	args := types.CbLayoutRecallArgs{
+		LayoutType:  types.LAYOUT4_FLEX_FILES,
+		IOMode:      types.LAYOUTIOMODE4_ANY,
+		Changed:     true,
+		RecallType:  types.LAYOUTRECALL4_FILE,
+		FileOffset:  0,
+		FileLength:  ^uint64(0),
+		FileStateid: *stateid,
+		FileFH:      fh,
+	}
+	_ = args.Encode(&buf)
+	return buf.Bytes()
+}
+
+// EncodeCBPushDelegOp encodes one nfs_cb_argop4 for CB_PUSH_DELEG offering
+// a granted delegation to its client (RFC 8881 Section 20.5).
+func EncodeCBPushDelegOp(deleg *DelegationState) []byte {
+	var buf bytes.Buffer
+
+	// argop: CB_PUSH_DELEG = 7
+	_ = xdr.WriteUint32(&buf, types.CB_PUSH_DELEG)
+
+	var delegBuf bytes.Buffer
+	EncodeDelegation(&delegBuf, deleg)
+	args := types.CbPushDelegArgs{
+		FH:         deleg.FileHandle,
+		Delegation: delegBuf.Bytes(),
+	}
+	_ = args.Encode(&buf)
+	return buf.Bytes()
+}
+
+// EncodeCBRecallableObjAvailOp encodes one nfs_cb_argop4 for
+// CB_RECALLABLE_OBJ_AVAIL (RFC 8881 Section 20.7). The args are void.
+func EncodeCBRecallableObjAvailOp() []byte {
+	var buf bytes.Buffer
+
+	// argop: CB_RECALLABLE_OBJ_AVAIL = 9
+	_ = xdr.WriteUint32(&buf, types.CB_RECALLABLE_OBJ_AVAIL)
+	return buf.Bytes()
+}
+
 // BuildCBRPCCallMessage builds an RPC CALL message with AUTH_NULL credentials.
 //
 // Wire format per RFC 5531:
@@ -361,6 +361,7 @@ func (sm *StateManager) ReturnDelegation(stateid *types.Stateid4) error {
 	}
 
 	lockMgr := sm.lockManagerFor(deleg.FileHandle)
+	followUp := !deleg.IsDirectory && sm.hasDelegationFollowUpsLocked(deleg.FileHandle)
 
 	if deleg.Revoked {
 		logger.Info("Revoked delegation returned by client",
@@ -380,6 +381,10 @@ func (sm *StateManager) ReturnDelegation(stateid *types.Stateid4) error {
 		_ = lockMgr.ReturnDelegation(fhKey, lmDelegID)
 	}
 
+	if followUp {
+		go sm.onDelegationStateReleased(deleg.FileHandle)
+	}
+
 	return nil
 }
 
@@ -430,7 +435,8 @@ func (sm *StateManager) countOpensOnFile(fileHandle []byte, excludeClientID uint
 // for a client opening a file.
 //
 // Policy (simple, per research recommendation -- no heuristics):
-//  1. Client must have a non-empty callback address
+//  1. Client must have a usable callback path (v4.0 callback address
+//     verified by CB_NULL, or a v4.1 session with a backchannel)
 //  2. No other clients may have opens on the file
 //  3. No existing delegations from other clients on the file
 //  4. Same client must not already have a delegation (avoid double-grant)
@@ -443,36 +449,66 @@ func (sm *StateManager) ShouldGrantDelegation(clientID uint64, fileHandle []byte
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 
-	if !sm.delegationsEnabled {
+	if ok, _ := sm.delegationDecisionLocked(clientID, fileHandle); !ok {
 		return types.OPEN_DELEGATE_NONE, false
 	}
 
-	client, exists := sm.clientsByID[clientID]
-	if !exists {
-		return types.OPEN_DELEGATE_NONE, false
+	if shareAccess&types.OPEN4_SHARE_ACCESS_WRITE != 0 {
+		return types.OPEN_DELEGATE_WRITE, true
 	}
-	if !client.CBPathUp {
-		return types.OPEN_DELEGATE_NONE, false
+	return types.OPEN_DELEGATE_READ, true
+}
+
+// delegationDecisionLocked applies the ShouldGrantDelegation policy to a
+// file. When it declines, the second result is the why_no_delegation4
+// reason reported to v4.1 clients: WND4_CONTENTION when other state on the
+// file is in the way, WND4_RESOURCE when the server cannot call the client
+// back or delegations are disabled.
+//
+// Caller must hold sm.mu (RLock or Lock).
+func (sm *StateManager) delegationDecisionLocked(clientID uint64, fileHandle []byte) (bool, uint32) {
+	if !sm.delegationsEnabled {
+		return false, types.WND4_RESOURCE
+	}
+
+	if !sm.callbackPathUpLocked(clientID) {
+		return false, types.WND4_RESOURCE
 	}
 
 	if sm.isRecentlyRecalled(fileHandle) {
-		return types.OPEN_DELEGATE_NONE, false
+		return false, types.WND4_CONTENTION
 	}
 
 	if sm.countOpensOnFile(fileHandle, clientID) > 0 {
-		return types.OPEN_DELEGATE_NONE, false
+		return false, types.WND4_CONTENTION
 	}
 
 	for _, deleg := range sm.delegByFile[string(fileHandle)] {
 		if !deleg.Revoked {
-			return types.OPEN_DELEGATE_NONE, false
+			return false, types.WND4_CONTENTION
 		}
 	}
 
-	if shareAccess&types.OPEN4_SHARE_ACCESS_WRITE != 0 {
-		return types.OPEN_DELEGATE_WRITE, true
+	return true, types.WND4_NOT_WANTED
+}
+
+// callbackPathUpLocked reports whether the server can recall a delegation
+// from the client: a v4.0 client needs a callback address that answered
+// CB_NULL, a v4.1 client needs a session with a backchannel.
+//
+// Caller must hold sm.mu (RLock or Lock).
+func (sm *StateManager) callbackPathUpLocked(clientID uint64) bool {
+	if client, exists := sm.clientsByID[clientID]; exists {
+		return client.CBPathUp
+	}
+	if _, exists := sm.v41ClientsByID[clientID]; exists {
+		for _, session := range sm.sessionsByClientID[clientID] {
+			if session.backchannelSender != nil {
+				return true
+			}
+		}
 	}
-	return types.OPEN_DELEGATE_READ, true
+	return false
 }
 
 // ============================================================================
@@ -488,7 +524,8 @@ func (sm *StateManager) ShouldGrantDelegation(clientID uint64, fileHandle []byte
 //   - READ delegation + READ-only access: no conflict (multiple readers allowed)
 //
 // On conflict, marks the delegation as recalled and launches an async
-// goroutine to send CB_RECALL (does NOT hold the lock during TCP).
+// goroutine to send CB_RECALL (does NOT hold the lock during TCP). The
+// holder's pNFS layout on the file, if any, is recalled alongside it.
 //
 // Returns true if a conflict was detected (caller should return NFS4ERR_DELAY).
 //
@@ -528,6 +565,7 @@ func (sm *StateManager) CheckDelegationConflict(fileHandle []byte, clientID uint
 
 	for _, deleg := range toRecall {
 		go sm.sendRecall(deleg)
+		sm.dispatchLayoutRecalls(sm.recallClientLayoutLocked(deleg.ClientID, deleg.FileHandle))
 	}
 
 	return conflicting, nil
@@ -0,0 +1,624 @@
+package state
+
+import (
+	"bytes"
+	"encoding/hex"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
+	"github.com/marmos91/dittofs/internal/logger"
+)
+
+// LayoutState represents the pNFS layouts one client holds on one file.
+//
+// Per RFC 8881 Section 12.5.2, a client holds at most one layout stateid per
+// file; every LAYOUTGET, LAYOUTRETURN and CB_LAYOUTRECALL on the file bumps
+// its seqid. DittoFS always grants whole-file layouts, so the state only needs
+// to remember which iomodes are outstanding.
+type LayoutState struct {
+	// Stateid is the layout stateid (type tag = 0x04).
+	Stateid types.Stateid4
+
+	// ClientID is the v4.1 client that holds the layout.
+	ClientID uint64
+
+	// FileHandle is the file handle the layout covers.
+	FileHandle []byte
+
+	// ReadGranted and RWGranted record the outstanding iomodes.
+	ReadGranted bool
+	RWGranted   bool
+
+	// RecallSent indicates CB_LAYOUTRECALL has been sent. New LAYOUTGETs on
+	// the file fail with NFS4ERR_RECALLCONFLICT until the layout is returned
+	// or revoked.
+	RecallSent bool
+
+	// RecallTimer revokes the layout if the client has not returned it
+	// within the lease period after the recall.
+	RecallTimer *time.Timer
+}
+
+// stopRecallTimer stops the recall timer if it exists.
+func (l *LayoutState) stopRecallTimer() {
+	if l.RecallTimer != nil {
+		l.RecallTimer.Stop()
+		l.RecallTimer = nil
+	}
+}
+
+// bumpSeqid advances the stateid seqid, skipping 0 on wraparound
+// (seqid 0 means "current" on the wire per RFC 8881 Section 8.2.2).
+func (l *LayoutState) bumpSeqid() {
+	l.Stateid.Seqid++
+	if l.Stateid.Seqid == 0 {
+		l.Stateid.Seqid = 1
+	}
+}
+
+// ============================================================================
+// Configuration
+// ============================================================================
+
+// SetLayoutsEnabled controls whether pNFS layouts can be granted. When
+// enabled, EXCHANGE_ID advertises EXCHGID4_FLAG_USE_PNFS_MDS. Disabling does
+// not revoke outstanding layouts; callers recall them first.
+//
+// Thread-safe: acquires sm.mu.Lock.
+func (sm *StateManager) SetLayoutsEnabled(enabled bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.layoutsEnabled = enabled
+}
+
+// SetLayoutFence injects the function that fences a file's layouts on the
+// data servers, making the credentials already handed out for it unusable.
+// It is called whenever a layout is revoked, dropped with its client's lease,
+// or must stop working at once because a conflicting OPEN was granted.
+//
+// Init-only: must be called before any goroutine serves requests, like
+// SetLockManagerResolver. The hook always runs on its own goroutine, so it may
+// block on the metadata store.
+func (sm *StateManager) SetLayoutFence(fence func(fileHandle []byte)) {
+	sm.layoutFence = fence
+}
+
+// LayoutsEnabled reports whether pNFS layouts can be granted.
+//
+// Thread-safe: acquires sm.mu.RLock.
+func (sm *StateManager) LayoutsEnabled() bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.layoutsEnabled
+}
+
+// ============================================================================
+// LAYOUTGET / LAYOUTRETURN
+// ============================================================================
+
+// GrantLayout records a whole-file layout of the given iomode for a client,
+// per RFC 8881 Section 18.43.
+//
+// The stateid is either the client's layout stateid for the file or, on the
+// first LAYOUTGET, an open, delegation or lock stateid the caller has already
+// validated with ValidateStateid. Returns the layout stateid to send back
+// (seqid bumped).
+//
+// Errors:
+//   - NFS4ERR_LAYOUTUNAVAILABLE when layouts are disabled
+//   - NFS4ERR_BAD_STATEID / NFS4ERR_STALE_STATEID for unknown layout stateids
+//   - NFS4ERR_RECALLCONFLICT while a recall is outstanding on the layout
+//
+// Caller must NOT hold sm.mu.
+func (sm *StateManager) GrantLayout(clientID uint64, fileHandle []byte, iomode uint32, stateid *types.Stateid4) (types.Stateid4, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if !sm.layoutsEnabled {
+		return types.Stateid4{}, &NFS4StateError{
+			Status:  types.NFS4ERR_LAYOUTUNAVAILABLE,
+			Message: "pNFS layouts are disabled",
+		}
+	}
+
+	var layout *LayoutState
+	if stateid.Other[0] == StateTypeLayout {
+		var err error
+		if layout, err = sm.lookupLayoutLocked(clientID, stateid, fileHandle); err != nil {
+			return types.Stateid4{}, err
+		}
+	} else {
+		layout = sm.findLayoutLocked(clientID, fileHandle)
+	}
+
+	if layout != nil && layout.RecallSent {
+		return types.Stateid4{}, &NFS4StateError{
+			Status:  types.NFS4ERR_RECALLCONFLICT,
+			Message: "layout recall in progress",
+		}
+	}
+
+	if layout == nil {
+		fhCopy := make([]byte, len(fileHandle))
+		copy(fhCopy, fileHandle)
+		layout = &LayoutState{
+			Stateid:    types.Stateid4{Other: sm.generateStateidOther(StateTypeLayout)},
+			ClientID:   clientID,
+			FileHandle: fhCopy,
+		}
+		sm.layoutByOther[layout.Stateid.Other] = layout
+		fhKey := string(fileHandle)
+		sm.layoutByFile[fhKey] = append(sm.layoutByFile[fhKey], layout)
+	}
+
+	if iomode == types.LAYOUTIOMODE4_RW {
+		layout.RWGranted = true
+	} else {
+		layout.ReadGranted = true
+	}
+	layout.bumpSeqid()
+
+	logger.Debug("LAYOUTGET: layout granted",
+		"client_id", clientID,
+		"iomode", iomode,
+		"stateid_seqid", layout.Stateid.Seqid)
+
+	return layout.Stateid, nil
+}
+
+// ClientHoldsAccess reports whether clientID holds an open on fileHandle
+// with any of the shareAccess bits, or a delegation covering them (a write
+// delegation covers both, a read delegation only OPEN4_SHARE_ACCESS_READ).
+// LAYOUTGET uses it to scope each layout to what the client opened, even
+// when the request presents a layout stateid rather than an open stateid.
+//
+// Caller must NOT hold sm.mu.
+func (sm *StateManager) ClientHoldsAccess(clientID uint64, fileHandle []byte, shareAccess uint32) bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	for _, os := range sm.openStateByFile[string(fileHandle)] {
+		if os.Owner != nil && os.Owner.ClientID == clientID && os.ShareAccess&shareAccess != 0 {
+			return true
+		}
+	}
+	for _, deleg := range sm.delegByFile[string(fileHandle)] {
+		if deleg.ClientID != clientID || deleg.Revoked {
+			continue
+		}
+		if deleg.DelegType == types.OPEN_DELEGATE_WRITE ||
+			(deleg.DelegType == types.OPEN_DELEGATE_READ && shareAccess&types.OPEN4_SHARE_ACCESS_READ != 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateLayoutStateid checks that stateid is a layout stateid held by
+// clientID on fileHandle (LAYOUTCOMMIT). Returns NFS4ERR_BADLAYOUT when the
+// stateid is not a layout stateid at all.
+//
+// Caller must NOT hold sm.mu.
+func (sm *StateManager) ValidateLayoutStateid(clientID uint64, stateid *types.Stateid4, fileHandle []byte) error {
+	if stateid.Other[0] != StateTypeLayout {
+		return &NFS4StateError{
+			Status:  types.NFS4ERR_BADLAYOUT,
+			Message: "not a layout stateid",
+		}
+	}
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	_, err := sm.lookupLayoutLocked(clientID, stateid, fileHandle)
+	return err
+}
+
+// ReturnLayout implements a LAYOUTRETURN4_FILE return per RFC 8881
+// Section 18.44. LAYOUTIOMODE4_ANY returns every iomode.
+//
+// Returns the updated layout stateid while the client still holds a layout on
+// the file, or nil once the layout state is gone (lrs_present = false).
+// Returning a layout the server no longer knows (already returned or revoked)
+// succeeds with nil, since the client's goal is already met.
+//
+// Caller must NOT hold sm.mu.
+func (sm *StateManager) ReturnLayout(clientID uint64, fileHandle []byte, iomode uint32, stateid *types.Stateid4) (*types.Stateid4, error) {
+	if stateid.Other[0] != StateTypeLayout {
+		return nil, &NFS4StateError{
+			Status:  types.NFS4ERR_BAD_STATEID,
+			Message: "not a layout stateid",
+		}
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	layout, exists := sm.layoutByOther[stateid.Other]
+	if !exists {
+		if !sm.isCurrentEpoch(stateid.Other) {
+			return nil, &NFS4StateError{
+				Status:  types.NFS4ERR_STALE_STATEID,
+				Message: "layout stateid from previous server incarnation",
+			}
+		}
+		return nil, nil
+	}
+	if _, err := sm.lookupLayoutLocked(clientID, stateid, fileHandle); err != nil {
+		return nil, err
+	}
+
+	switch iomode {
+	case types.LAYOUTIOMODE4_READ:
+		layout.ReadGranted = false
+	case types.LAYOUTIOMODE4_RW:
+		layout.RWGranted = false
+	default:
+		layout.ReadGranted = false
+		layout.RWGranted = false
+	}
+
+	if !layout.ReadGranted && !layout.RWGranted {
+		sm.removeLayoutLocked(layout)
+		logger.Debug("LAYOUTRETURN: layout returned",
+			"client_id", clientID,
+			"stateid_other", hex.EncodeToString(stateid.Other[:]))
+		return nil, nil
+	}
+
+	layout.bumpSeqid()
+	sid := layout.Stateid
+	return &sid, nil
+}
+
+// ReturnLayouts implements LAYOUTRETURN4_FSID and LAYOUTRETURN4_ALL: every
+// layout of clientID whose file handle satisfies match is returned. A nil
+// match returns all of the client's layouts. Returns the number released.
+//
+// Caller must NOT hold sm.mu.
+func (sm *StateManager) ReturnLayouts(clientID uint64, match func(fileHandle []byte) bool) int {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	count := 0
+	for _, layout := range sm.layoutByOther {
+		if layout.ClientID != clientID {
+			continue
+		}
+		if match != nil && !match(layout.FileHandle) {
+			continue
+		}
+		sm.removeLayoutLocked(layout)
+		count++
+	}
+	return count
+}
+
+// ============================================================================
+// CB_LAYOUTRECALL
+// ============================================================================
+
+// RecallLayouts recalls every layout on a file, e.g. after a truncate changes
+// the file size under clients doing direct data-server I/O.
+//
+// Each holder is sent CB_LAYOUTRECALL (LAYOUTRECALL4_FILE, whole file, iomode
+// ANY) over its backchannel; layouts not returned within the lease period are
+// revoked. Returns the number of recalls dispatched.
+//
+// Caller must NOT hold sm.mu.
+func (sm *StateManager) RecallLayouts(fileHandle []byte) int {
+	sm.mu.Lock()
+	recalls := sm.beginLayoutRecallsLocked(sm.layoutByFile[string(fileHandle)])
+	sm.mu.Unlock()
+
+	sm.dispatchLayoutRecalls(recalls)
+	return len(recalls)
+}
+
+// RecallAllLayouts recalls every outstanding layout, used when the data
+// server set changes and existing layouts point at stale devices.
+//
+// Caller must NOT hold sm.mu.
+func (sm *StateManager) RecallAllLayouts() int {
+	sm.mu.Lock()
+	all := make([]*LayoutState, 0, len(sm.layoutByOther))
+	for _, layout := range sm.layoutByOther {
+		all = append(all, layout)
+	}
+	recalls := sm.beginLayoutRecallsLocked(all)
+	sm.mu.Unlock()
+
+	sm.dispatchLayoutRecalls(recalls)
+	return len(recalls)
+}
+
+// recallClientLayoutLocked starts recalling the layout clientID holds on
+// fileHandle, if any. Used when the client's delegation on the file is
+// recalled: a layout obtained under the delegation must not outlive it.
+// Caller must hold sm.mu and pass the result to dispatchLayoutRecalls.
+func (sm *StateManager) recallClientLayoutLocked(clientID uint64, fileHandle []byte) []layoutRecall {
+	layout := sm.findLayoutLocked(clientID, fileHandle)
+	if layout == nil {
+		return nil
+	}
+	return sm.beginLayoutRecallsLocked([]*LayoutState{layout})
+}
+
+// revokeDeniedLayoutsLocked handles an OPEN by clientID whose share_deny
+// excludes layouts other clients hold on fileHandle: DENY_WRITE excludes RW
+// layouts, DENY_READ excludes every layout. The share reservation has
+// already been granted, so those layouts are recalled and fenced at once
+// rather than left usable until the holders return them.
+// Caller must hold sm.mu.
+func (sm *StateManager) revokeDeniedLayoutsLocked(clientID uint64, fileHandle []byte, shareDeny uint32) {
+	if shareDeny == 0 {
+		return
+	}
+	var denied []*LayoutState
+	for _, layout := range sm.layoutByFile[string(fileHandle)] {
+		if layout.ClientID == clientID {
+			continue
+		}
+		if shareDeny&types.OPEN4_SHARE_DENY_READ != 0 ||
+			(shareDeny&types.OPEN4_SHARE_DENY_WRITE != 0 && layout.RWGranted) {
+			denied = append(denied, layout)
+		}
+	}
+	if len(denied) == 0 {
+		return
+	}
+	sm.dispatchLayoutRecalls(sm.beginLayoutRecallsLocked(denied))
+	sm.fenceLayouts(fileHandle)
+}
+
+// dispatchLayoutRecalls sends each recall on its own goroutine. Safe to call
+// with or without sm.mu held.
+func (sm *StateManager) dispatchLayoutRecalls(recalls []layoutRecall) {
+	for _, r := range recalls {
+		go sm.sendLayoutRecall(r)
+	}
+}
+
+// fenceLayouts runs the fence hook for each file handle on its own
+// goroutine. Safe to call with or without sm.mu held.
+func (sm *StateManager) fenceLayouts(fileHandles ...[]byte) {
+	if sm.layoutFence == nil {
+		return
+	}
+	for _, fh := range fileHandles {
+		go sm.layoutFence(fh)
+	}
+}
+
+// layoutRecall is a snapshot of a layout taken under sm.mu so the recall can
+// be sent without holding the lock.
+type layoutRecall struct {
+	layout  *LayoutState
+	stateid types.Stateid4
+	fh      []byte
+}
+
+// beginLayoutRecallsLocked marks the layouts recalled and bumps their seqid,
+// which CB_LAYOUTRECALL carries (RFC 8881 Section 12.5.5.2). Layouts already
+// being recalled are skipped.
+// Caller must hold sm.mu.
+func (sm *StateManager) beginLayoutRecallsLocked(layouts []*LayoutState) []layoutRecall {
+	recalls := make([]layoutRecall, 0, len(layouts))
+	for _, layout := range layouts {
+		if layout.RecallSent {
+			continue
+		}
+		layout.RecallSent = true
+		layout.bumpSeqid()
+		recalls = append(recalls, layoutRecall{
+			layout:  layout,
+			stateid: layout.Stateid,
+			fh:      layout.FileHandle,
+		})
+	}
+	return recalls
+}
+
+// sendLayoutRecall sends CB_LAYOUTRECALL via the client's v4.1
+// BackchannelSender, following the same timing rules as sendRecallV41:
+// the full lease period to return after a delivered recall, a short grace
+// when delivery fails.
+//
+// IMPORTANT: This must NOT hold sm.mu during the send.
+func (sm *StateManager) sendLayoutRecall(r layoutRecall) {
+	clientID := r.layout.ClientID
+	sender := sm.getBackchannelSender(clientID)
+	if sender == nil {
+		logger.Warn("CB_LAYOUTRECALL: no backchannel, revoking layout",
+			"client_id", clientID)
+		sm.revokeLayout(r.layout.Stateid.Other)
+		return
+	}
+
+	resultCh := make(chan error, 1)
+	req := CallbackRequest{
+		OpCode:   types.CB_LAYOUTRECALL,
+		Payload:  EncodeCBLayoutRecallOp(&r.stateid, r.fh),
+		ResultCh: resultCh,
+	}
+
+	if !sender.Enqueue(req) {
+		logger.Warn("CB_LAYOUTRECALL: backchannel queue full, starting short revocation timer",
+			"client_id", clientID)
+		sm.startLayoutRevocationTimer(r.layout, 5*time.Second)
+		return
+	}
+
+	select {
+	case err := <-resultCh:
+		if err != nil {
+			// Includes NFS4ERR_NOMATCHING_LAYOUT: the client holds nothing,
+			// so revoking shortly after loses nothing.
+			logger.Debug("CB_LAYOUTRECALL failed",
+				"client_id", clientID,
+				"error", err)
+			sm.startLayoutRevocationTimer(r.layout, 5*time.Second)
+			return
+		}
+		sm.mu.RLock()
+		lease := sm.leaseDuration
+		sm.mu.RUnlock()
+		sm.startLayoutRevocationTimer(r.layout, lease)
+		logger.Debug("CB_LAYOUTRECALL sent successfully",
+			"client_id", clientID)
+
+	case <-sender.stopCh:
+		logger.Debug("CB_LAYOUTRECALL aborted: backchannel sender stopped",
+			"client_id", clientID)
+		sm.startLayoutRevocationTimer(r.layout, 5*time.Second)
+
+	case <-time.After(30 * time.Second):
+		logger.Warn("CB_LAYOUTRECALL result timeout",
+			"client_id", clientID)
+		sm.startLayoutRevocationTimer(r.layout, 5*time.Second)
+	}
+}
+
+// startLayoutRevocationTimer arms the recall timer on a layout that is still
+// outstanding.
+func (sm *StateManager) startLayoutRevocationTimer(layout *LayoutState, timeout time.Duration) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if sm.layoutByOther[layout.Stateid.Other] != layout {
+		return // already returned
+	}
+	layout.stopRecallTimer()
+	other := layout.Stateid.Other
+	layout.RecallTimer = time.AfterFunc(timeout, func() {
+		sm.revokeLayout(other)
+	})
+}
+
+// revokeLayout forgets a recalled layout the client failed to return and
+// fences it, so the credentials the layout carried stop working on the data
+// servers (RFC 8435 Section 2.2).
+func (sm *StateManager) revokeLayout(other [types.NFS4_OTHER_SIZE]byte) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	layout, exists := sm.layoutByOther[other]
+	if !exists {
+		return
+	}
+	sm.removeLayoutLocked(layout)
+	sm.fenceLayouts(layout.FileHandle)
+	logger.Info("Layout revoked",
+		"client_id", layout.ClientID,
+		"stateid_other", hex.EncodeToString(other[:]))
+}
+
+// ============================================================================
+// Internal helpers
+// ============================================================================
+
+// lookupLayoutLocked resolves a layout stateid owned by clientID on
+// fileHandle. Older seqids are accepted: parallel LAYOUTGETs legitimately
+// race, and every layout DittoFS grants covers the whole file.
+// Caller must hold sm.mu (read or write).
+func (sm *StateManager) lookupLayoutLocked(clientID uint64, stateid *types.Stateid4, fileHandle []byte) (*LayoutState, error) {
+	layout, exists := sm.layoutByOther[stateid.Other]
+	if !exists {
+		if !sm.isCurrentEpoch(stateid.Other) {
+			return nil, &NFS4StateError{
+				Status:  types.NFS4ERR_STALE_STATEID,
+				Message: "layout stateid from previous server incarnation",
+			}
+		}
+		return nil, &NFS4StateError{
+			Status:  types.NFS4ERR_BAD_STATEID,
+			Message: "layout stateid not found",
+		}
+	}
+	if layout.ClientID != clientID || !bytes.Equal(layout.FileHandle, fileHandle) {
+		return nil, &NFS4StateError{
+			Status:  types.NFS4ERR_BAD_STATEID,
+			Message: "layout stateid does not match client or file",
+		}
+	}
+	if stateid.Seqid > layout.Stateid.Seqid {
+		return nil, &NFS4StateError{
+			Status:  types.NFS4ERR_BAD_STATEID,
+			Message: "layout stateid seqid from the future",
+		}
+	}
+	return layout, nil
+}
+
+// findLayoutLocked returns the layout clientID holds on fileHandle, if any.
+// Caller must hold sm.mu (read or write).
+func (sm *StateManager) findLayoutLocked(clientID uint64, fileHandle []byte) *LayoutState {
+	for _, layout := range sm.layoutByFile[string(fileHandle)] {
+		if layout.ClientID == clientID {
+			return layout
+		}
+	}
+	return nil
+}
+
+// removeLayoutLocked drops a layout from both indexes.
+// Caller must hold sm.mu.
+func (sm *StateManager) removeLayoutLocked(layout *LayoutState) {
+	layout.stopRecallTimer()
+	delete(sm.layoutByOther, layout.Stateid.Other)
+
+	fhKey := string(layout.FileHandle)
+	layouts := sm.layoutByFile[fhKey]
+	for i, l := range layouts {
+		if l == layout {
+			layouts = append(layouts[:i], layouts[i+1:]...)
+			break
+		}
+	}
+	if len(layouts) == 0 {
+		delete(sm.layoutByFile, fhKey)
+	} else {
+		sm.layoutByFile[fhKey] = layouts
+	}
+}
+
+// freeLayoutStateidLocked handles FREE_STATEID on a layout stateid. A layout
+// stateid exists only while layouts are held, so a known one always answers
+// NFS4ERR_LOCKS_HELD (RFC 8881 Section 18.38.3); the client must LAYOUTRETURN.
+// Caller must hold sm.mu.
+func (sm *StateManager) freeLayoutStateidLocked(clientID uint64, stateid *types.Stateid4) error {
+	layout, exists := sm.layoutByOther[stateid.Other]
+	if !exists || layout.ClientID != clientID {
+		return &NFS4StateError{
+			Status:  types.NFS4ERR_BAD_STATEID,
+			Message: "layout stateid not found",
+		}
+	}
+	return &NFS4StateError{
+		Status:  types.NFS4ERR_LOCKS_HELD,
+		Message: "layout stateid still holds layouts",
+	}
+}
+
+// testLayoutStateid validates a layout stateid without lease renewal.
+// Caller must hold sm.mu.RLock.
+func (sm *StateManager) testLayoutStateid(stateid *types.Stateid4) uint32 {
+	layout, exists := sm.layoutByOther[stateid.Other]
+	if !exists {
+		return types.NFS4ERR_BAD_STATEID
+	}
+	if stateid.Seqid != 0 && stateid.Seqid > layout.Stateid.Seqid {
+		return types.NFS4ERR_BAD_STATEID
+	}
+	return types.NFS4_OK
+}
+
+// purgeClientLayoutsLocked drops and fences every layout held by a client.
+// Caller must hold sm.mu.
+func (sm *StateManager) purgeClientLayoutsLocked(clientID uint64) {
+	for _, layout := range sm.layoutByOther {
+		if layout.ClientID == clientID {
+			sm.removeLayoutLocked(layout)
+			sm.fenceLayouts(layout.FileHandle)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
+)
+
+func newLayoutTestManager(t *testing.T) *StateManager {
+	t.Helper()
+	sm := NewStateManager(90 * time.Second)
+	sm.SetLayoutsEnabled(true)
+	return sm
+}
+
+// openStateidForLayout returns a fake, already-validated open stateid to
+// present on a first LAYOUTGET.
+func openStateidForLayout(sm *StateManager) types.Stateid4 {
+	return types.Stateid4{Seqid: 1, Other: sm.generateStateidOther(StateTypeOpen)}
+}
+
+func layoutStatus(err error) uint32 {
+	var stateErr *NFS4StateError
+	if errors.As(err, &stateErr) {
+		return stateErr.Status
+	}
+	return 0
+}
+
+func TestGrantLayout_Disabled(t *testing.T) {
+	sm := NewStateManager(90 * time.Second)
+	open := openStateidForLayout(sm)
+
+	_, err := sm.GrantLayout(1, []byte("fh"), types.LAYOUTIOMODE4_READ, &open)
+	if got := layoutStatus(err); got != types.NFS4ERR_LAYOUTUNAVAILABLE {
+		t.Fatalf("status = %d, want NFS4ERR_LAYOUTUNAVAILABLE", got)
+	}
+}
+
+func TestGrantLayout_ReusesStateidPerFile(t *testing.T) {
+	sm := newLayoutTestManager(t)
+	fh := []byte("fh-layout")
+	open := openStateidForLayout(sm)
+
+	first, err := sm.GrantLayout(1, fh, types.LAYOUTIOMODE4_READ, &open)
+	if err != nil {
+		t.Fatalf("GrantLayout: %v", err)
+	}
+	if first.Other[0] != StateTypeLayout {
+		t.Errorf("type tag = 0x%02x, want 0x%02x", first.Other[0], StateTypeLayout)
+	}
+	if first.Seqid != 1 {
+		t.Errorf("seqid = %d, want 1", first.Seqid)
+	}
+
+	second, err := sm.GrantLayout(1, fh, types.LAYOUTIOMODE4_RW, &first)
+	if err != nil {
+		t.Fatalf("GrantLayout with layout stateid: %v", err)
+	}
+	if second.Other != first.Other {
+		t.Error("second LAYOUTGET should reuse the layout stateid")
+	}
+	if second.Seqid != 2 {
+		t.Errorf("seqid = %d, want 2", second.Seqid)
+	}
+
+	// A layout stateid cannot be presented for another client or file.
+	if _, err := sm.GrantLayout(2, fh, types.LAYOUTIOMODE4_READ, &second); layoutStatus(err) != types.NFS4ERR_BAD_STATEID {
+		t.Errorf("cross-client status = %d, want NFS4ERR_BAD_STATEID", layoutStatus(err))
+	}
+	if _, err := sm.GrantLayout(1, []byte("other"), types.LAYOUTIOMODE4_READ, &second); layoutStatus(err) != types.NFS4ERR_BAD_STATEID {
+		t.Errorf("cross-file status = %d, want NFS4ERR_BAD_STATEID", layoutStatus(err))
+	}
+}
+
+func TestReturnLayout_PartialThenFull(t *testing.T) {
+	sm := newLayoutTestManager(t)
+	fh := []byte("fh-return")
+	open := openStateidForLayout(sm)
+
+	sid, _ := sm.GrantLayout(1, fh, types.LAYOUTIOMODE4_READ, &open)
+	sid, _ = sm.GrantLayout(1, fh, types.LAYOUTIOMODE4_RW, &sid)
+
+	remaining, err := sm.ReturnLayout(1, fh, types.LAYOUTIOMODE4_RW, &sid)
+	if err != nil {
+		t.Fatalf("ReturnLayout(RW): %v", err)
+	}
+	if remaining == nil {
+		t.Fatal("READ layout still held; stateid should be returned")
+	}
+	if remaining.Seqid != sid.Seqid+1 {
+		t.Errorf("seqid = %d, want %d", remaining.Seqid, sid.Seqid+1)
+	}
+
+	remaining, err = sm.ReturnLayout(1, fh, types.LAYOUTIOMODE4_ANY, remaining)
+	if err != nil {
+		t.Fatalf("ReturnLayout(ANY): %v", err)
+	}
+	if remaining != nil {
+		t.Error("all layouts returned; stateid should be absent")
+	}
+
+	// Returning again is harmless.
+	if _, err := sm.ReturnLayout(1, fh, types.LAYOUTIOMODE4_ANY, &sid); err != nil {
+		t.Errorf("second return: %v", err)
+	}
+	if got := sm.TestStateids([]types.Stateid4{sid}); got[0] != types.NFS4ERR_BAD_STATEID {
+		t.Errorf("TEST_STATEID after return = %d, want NFS4ERR_BAD_STATEID", got[0])
+	}
+}
+
+func TestReturnLayouts_Bulk(t *testing.T) {
+	sm := newLayoutTestManager(t)
+	open := openStateidForLayout(sm)
+
+	for _, fh := range []string{"share-a/1", "share-a/2", "share-b/1"} {
+		if _, err := sm.GrantLayout(1, []byte(fh), types.LAYOUTIOMODE4_READ, &open); err != nil {
+			t.Fatalf("GrantLayout(%s): %v", fh, err)
+		}
+	}
+	if _, err := sm.GrantLayout(2, []byte("share-a/1"), types.LAYOUTIOMODE4_READ, &open); err != nil {
+		t.Fatalf("GrantLayout client 2: %v", err)
+	}
+
+	n := sm.ReturnLayouts(1, func(fh []byte) bool { return bytes.HasPrefix(fh, []byte("share-a/")) })
+	if n != 2 {
+		t.Errorf("FSID return released %d, want 2", n)
+	}
+	if n := sm.ReturnLayouts(1, nil); n != 1 {
+		t.Errorf("ALL return released %d, want 1", n)
+	}
+	if len(sm.layoutByOther) != 1 {
+		t.Errorf("remaining layouts = %d, want 1 (client 2)", len(sm.layoutByOther))
+	}
+}
+
+func TestRecallLayouts_NoBackchannelRevokes(t *testing.T) {
+	sm := newLayoutTestManager(t)
+	fh := []byte("fh-recall")
+	open := openStateidForLayout(sm)
+
+	sid, err := sm.GrantLayout(1, fh, types.LAYOUTIOMODE4_RW, &open)
+	if err != nil {
+		t.Fatalf("GrantLayout: %v", err)
+	}
+
+	if n := sm.RecallLayouts(fh); n != 1 {
+		t.Fatalf("RecallLayouts = %d, want 1", n)
+	}
+
+	// While the recall is pending (or once revoked), a new LAYOUTGET with the
+	// old layout stateid must not succeed.
+	if _, err := sm.GrantLayout(1, fh, types.LAYOUTIOMODE4_RW, &sid); err == nil {
+		t.Error("LAYOUTGET during recall should fail")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		sm.mu.RLock()
+		remaining := len(sm.layoutByOther)
+		sm.mu.RUnlock()
+		if remaining == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("layout was not revoked without a backchannel")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFreeStateid_Layout(t *testing.T) {
+	sm := newLayoutTestManager(t)
+	open := openStateidForLayout(sm)
+
+	sid, _ := sm.GrantLayout(7, []byte("fh-free"), types.LAYOUTIOMODE4_READ, &open)
+
+	if got := layoutStatus(sm.FreeStateid(7, &sid)); got != types.NFS4ERR_LOCKS_HELD {
+		t.Errorf("FREE_STATEID on held layout = %d, want NFS4ERR_LOCKS_HELD", got)
+	}
+	if got := layoutStatus(sm.FreeStateid(8, &sid)); got != types.NFS4ERR_BAD_STATEID {
+		t.Errorf("FREE_STATEID by other client = %d, want NFS4ERR_BAD_STATEID", got)
+	}
+}
+
+func TestExchangeID_PNFSFlags(t *testing.T) {
+	sm := NewStateManager(DefaultLeaseDuration)
+	var verifier [8]byte
+
+	res, err := sm.ExchangeID([]byte("pnfs-off"), verifier, types.EXCHGID4_FLAG_USE_PNFS_DS, nil, "10.0.0.1:1")
+	if err != nil {
+		t.Fatalf("ExchangeID: %v", err)
+	}
+	if res.Flags&(types.EXCHGID4_FLAG_USE_PNFS_MDS|types.EXCHGID4_FLAG_USE_PNFS_DS) != 0 {
+		t.Errorf("pNFS flags advertised while disabled: 0x%x", res.Flags)
+	}
+
+	sm.SetLayoutsEnabled(true)
+	res, err = sm.ExchangeID([]byte("pnfs-on"), verifier, types.EXCHGID4_FLAG_USE_PNFS_DS, nil, "10.0.0.1:2")
+	if err != nil {
+		t.Fatalf("ExchangeID: %v", err)
+	}
+	if res.Flags&types.EXCHGID4_FLAG_USE_PNFS_MDS == 0 {
+		t.Error("USE_PNFS_MDS should be advertised when layouts are enabled")
+	}
+	if res.Flags&types.EXCHGID4_FLAG_USE_PNFS_DS == 0 {
+		t.Error("USE_PNFS_DS should be echoed when requested")
+	}
+}
+
+func TestEncodeCBLayoutRecallOp(t *testing.T) {
+	sid := types.Stateid4{Seqid: 3, Other: [types.NFS4_OTHER_SIZE]byte{StateTypeLayout, 1}}
+	fh := []byte("fh-cb")
+
+	r := bytes.NewReader(EncodeCBLayoutRecallOp(&sid, fh))
+	op, err := xdr.DecodeUint32(r)
+	if err != nil || op != types.CB_LAYOUTRECALL {
+		t.Fatalf("argop = %d (%v), want CB_LAYOUTRECALL", op, err)
+	}
+	var args types.CbLayoutRecallArgs
+	if err := args.Decode(r); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if args.LayoutType != types.LAYOUT4_FLEX_FILES || args.RecallType != types.LAYOUTRECALL4_FILE {
+		t.Errorf("type/recall = %d/%d", args.LayoutType, args.RecallType)
+	}
+	if args.FileStateid != sid || !bytes.Equal(args.FileFH, fh) {
+		t.Error("stateid or file handle mismatch")
+	}
+}
+
+// fenceRecorder installs a layout fence hook that reports fenced handles.
+func fenceRecorder(sm *StateManager) <-chan string {
+	fenced := make(chan string, 16)
+	sm.SetLayoutFence(func(fh []byte) { fenced <- string(fh) })
+	return fenced
+}
+
+func expectFence(t *testing.T, fenced <-chan string, fh string) {
+	t.Helper()
+	select {
+	case got := <-fenced:
+		if got != fh {
+			t.Fatalf("fenced %q, want %q", got, fh)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("layout on %q was not fenced", fh)
+	}
+}
+
+func layoutRecallSent(sm *StateManager, clientID uint64, fh []byte) bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	layout := sm.findLayoutLocked(clientID, fh)
+	return layout != nil && layout.RecallSent
+}
+
+func TestRevokeLayout_Fences(t *testing.T) {
+	sm := newLayoutTestManager(t)
+	fenced := fenceRecorder(sm)
+	open := openStateidForLayout(sm)
+
+	if _, err := sm.GrantLayout(1, []byte("fh-fence"), types.LAYOUTIOMODE4_RW, &open); err != nil {
+		t.Fatalf("GrantLayout: %v", err)
+	}
+	// No backchannel: the recall revokes the layout, which must fence it.
+	sm.RecallLayouts([]byte("fh-fence"))
+	expectFence(t, fenced, "fh-fence")
+}
+
+func TestPurgeClientLayouts_Fences(t *testing.T) {
+	sm := newLayoutTestManager(t)
+	fenced := fenceRecorder(sm)
+	open := openStateidForLayout(sm)
+
+	if _, err := sm.GrantLayout(1, []byte("fh-purge"), types.LAYOUTIOMODE4_READ, &open); err != nil {
+		t.Fatalf("GrantLayout: %v", err)
+	}
+	sm.mu.Lock()
+	sm.purgeClientLayoutsLocked(1)
+	sm.mu.Unlock()
+	expectFence(t, fenced, "fh-purge")
+}
+
+func TestDelegationConflict_RecallsHolderLayout(t *testing.T) {
+	sm := newLayoutTestManager(t)
+	fh := []byte("fh-deleg-layout")
+	deleg := sm.GrantDelegation(1, fh, types.OPEN_DELEGATE_READ)
+	if _, err := sm.GrantLayout(1, fh, types.LAYOUTIOMODE4_READ, &deleg.Stateid); err != nil {
+		t.Fatalf("GrantLayout: %v", err)
+	}
+
+	conflict, err := sm.CheckDelegationConflict(fh, 2, types.OPEN4_SHARE_ACCESS_WRITE)
+	if err != nil || !conflict {
+		t.Fatalf("CheckDelegationConflict = (%v, %v), want conflict", conflict, err)
+	}
+	if !layoutRecallSent(sm, 1, fh) {
+		t.Error("holder's layout was not recalled with its delegation")
+	}
+}
+
+func TestRevokeDelegation_RevokesHolderLayout(t *testing.T) {
+	sm := newLayoutTestManager(t)
+	fenced := fenceRecorder(sm)
+	fh := []byte("fh-deleg-revoke")
+	deleg := sm.GrantDelegation(1, fh, types.OPEN_DELEGATE_WRITE)
+	if _, err := sm.GrantLayout(1, fh, types.LAYOUTIOMODE4_RW, &deleg.Stateid); err != nil {
+		t.Fatalf("GrantLayout: %v", err)
+	}
+
+	sm.RevokeDelegation(deleg.Stateid.Other)
+	expectFence(t, fenced, string(fh))
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if sm.findLayoutLocked(1, fh) != nil {
+		t.Error("layout survived the revocation of its delegation")
+	}
+}
+
+func TestOpenShareDeny_FencesOtherLayouts(t *testing.T) {
+	sm := newLayoutTestManager(t)
+	fenced := fenceRecorder(sm)
+	fh := []byte("fh-deny")
+	open := openStateidForLayout(sm)
+
+	if _, err := sm.GrantLayout(1, fh, types.LAYOUTIOMODE4_RW, &open); err != nil {
+		t.Fatalf("GrantLayout: %v", err)
+	}
+	// A read layout does not conflict with DENY_WRITE.
+	if _, err := sm.GrantLayout(3, fh, types.LAYOUTIOMODE4_READ, &open); err != nil {
+		t.Fatalf("GrantLayout client 3: %v", err)
+	}
+
+	if _, err := sm.OpenFile(2, []byte("owner"), 1, fh,
+		types.OPEN4_SHARE_ACCESS_READ, types.OPEN4_SHARE_DENY_WRITE, types.CLAIM_NULL); err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	expectFence(t, fenced, string(fh))
+	if !layoutRecallSent(sm, 1, fh) {
+		t.Error("RW layout excluded by DENY_WRITE was not recalled")
+	}
+	if layoutRecallSent(sm, 3, fh) {
+		t.Error("read layout was recalled by DENY_WRITE")
+	}
+}
+
+func TestClientHoldsAccess(t *testing.T) {
+	sm := newLayoutTestManager(t)
+	fh := []byte("fh-access")
+
+	if _, err := sm.OpenFile(1, []byte("owner"), 1, fh,
+		types.OPEN4_SHARE_ACCESS_READ, types.OPEN4_SHARE_DENY_NONE, types.CLAIM_NULL); err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	sm.GrantDelegation(2, fh, types.OPEN_DELEGATE_WRITE)
+
+	if !sm.ClientHoldsAccess(1, fh, types.OPEN4_SHARE_ACCESS_READ) {
+		t.Error("read open should give read access")
+	}
+	if sm.ClientHoldsAccess(1, fh, types.OPEN4_SHARE_ACCESS_WRITE) {
+		t.Error("read open should not give write access")
+	}
+	if !sm.ClientHoldsAccess(2, fh, types.OPEN4_SHARE_ACCESS_WRITE) {
+		t.Error("write delegation should give write access")
+	}
+	if sm.ClientHoldsAccess(3, fh, types.OPEN4_SHARE_ACCESS_READ) {
+		t.Error("client without state should have no access")
+	}
+}
@@ -0,0 +1,107 @@
+package state
+
+import (
+	"errors"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
+)
+
+// ============================================================================
+// SP4_MACH_CRED State Protection (RFC 8881 Section 2.10.8.3)
+// ============================================================================
+
+// ErrWrongCred indicates an operation on SP4_MACH_CRED-protected client state
+// was sent with a credential other than the client's machine credential.
+// Maps to NFS4ERR_WRONG_CRED (10082).
+var ErrWrongCred = errors.New("operation requires the client's machine credential")
+
+// MachCredProtection is the SP4_MACH_CRED state protection negotiated at
+// EXCHANGE_ID. Operations in MustEnforce are refused unless sent with the
+// RPCSEC_GSS principal that established the client.
+type MachCredProtection struct {
+	// Principal is the machine credential: the RPCSEC_GSS principal of the
+	// EXCHANGE_ID that created the client.
+	Principal string
+
+	// MustEnforce is spr_must_enforce: ops that must use Principal.
+	MustEnforce types.Bitmap4
+
+	// MustAllow is spr_must_allow: ops Principal may send on behalf of any
+	// user. Stateids are owned per client, so this is advisory here.
+	MustAllow types.Bitmap4
+}
+
+// machCredAlwaysEnforced are the client and session lifecycle ops every
+// SP4_MACH_CRED client gets, whatever it asked for (as the Linux server does):
+// without them any principal could tear down or hijack the client.
+var machCredAlwaysEnforced = []uint32{
+	types.OP_EXCHANGE_ID,
+	types.OP_CREATE_SESSION,
+	types.OP_BIND_CONN_TO_SESSION,
+	types.OP_DESTROY_SESSION,
+	types.OP_DESTROY_CLIENTID,
+}
+
+// machCredEnforceable are the state-changing ops a client may additionally
+// ask to reserve for its machine credential.
+var machCredEnforceable = []uint32{
+	types.OP_CLOSE,
+	types.OP_OPEN_DOWNGRADE,
+	types.OP_LOCKU,
+	types.OP_DELEGRETURN,
+	types.OP_FREE_STATEID,
+	types.OP_RECLAIM_COMPLETE,
+}
+
+// machCredAllowable are the ops a client may send with its machine credential
+// on behalf of a user, typically to clean up state after the user's
+// credential expired.
+var machCredAllowable = []uint32{
+	types.OP_CLOSE,
+	types.OP_OPEN_DOWNGRADE,
+	types.OP_LOCKU,
+	types.OP_DELEGRETURN,
+	types.OP_TEST_STATEID,
+	types.OP_FREE_STATEID,
+}
+
+// NegotiateMachCred builds the server's SP4_MACH_CRED reply from the client's
+// spo_must_enforce / spo_must_allow request.
+func NegotiateMachCred(principal string, req *types.StateProtect4A) *MachCredProtection {
+	p := &MachCredProtection{
+		Principal:   principal,
+		MustEnforce: types.Bitmap4{},
+		MustAllow:   types.Bitmap4{},
+	}
+	for _, op := range machCredAlwaysEnforced {
+		p.MustEnforce.Set(op)
+	}
+	for _, op := range machCredEnforceable {
+		if req.MustEnforce.IsSet(op) {
+			p.MustEnforce.Set(op)
+		}
+	}
+	for _, op := range machCredAllowable {
+		if req.MustAllow.IsSet(op) && !p.MustEnforce.IsSet(op) {
+			p.MustAllow.Set(op)
+		}
+	}
+	return p
+}
+
+// CheckMachCred returns ErrWrongCred when clientID is SP4_MACH_CRED-protected,
+// op is one of its enforced ops, and gssPrincipal is not the client's machine
+// credential. Unknown and unprotected clients pass.
+func (sm *StateManager) CheckMachCred(clientID uint64, op uint32, gssPrincipal string) error {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	record := sm.v41ClientsByID[clientID]
+	if record == nil || record.MachCred == nil {
+		return nil
+	}
+	if !record.MachCred.MustEnforce.IsSet(op) || gssPrincipal == record.MachCred.Principal {
+		return nil
+	}
+	return ErrWrongCred
+}
@@ -0,0 +1,112 @@
+package state
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
+)
+
+func TestNegotiateMachCred(t *testing.T) {
+	req := &types.StateProtect4A{How: types.SP4_MACH_CRED}
+	req.MustEnforce.Set(types.OP_CLOSE)
+	req.MustEnforce.Set(types.OP_READ) // not enforceable: dropped
+	req.MustAllow.Set(types.OP_CLOSE)  // already enforced: not also allowed
+	req.MustAllow.Set(types.OP_TEST_STATEID)
+
+	p := NegotiateMachCred("host/client@EXAMPLE.COM", req)
+
+	for _, op := range machCredAlwaysEnforced {
+		if !p.MustEnforce.IsSet(op) {
+			t.Errorf("MustEnforce missing always-enforced op %d", op)
+		}
+	}
+	if !p.MustEnforce.IsSet(types.OP_CLOSE) {
+		t.Error("MustEnforce should include requested OP_CLOSE")
+	}
+	if p.MustEnforce.IsSet(types.OP_READ) {
+		t.Error("MustEnforce should not include OP_READ")
+	}
+	if p.MustAllow.IsSet(types.OP_CLOSE) {
+		t.Error("MustAllow should not repeat an enforced op")
+	}
+	if !p.MustAllow.IsSet(types.OP_TEST_STATEID) {
+		t.Error("MustAllow should include requested OP_TEST_STATEID")
+	}
+}
+
+func TestCheckMachCred(t *testing.T) {
+	sm := NewStateManager(DefaultLeaseDuration)
+	var verifier [8]byte
+	copy(verifier[:], "verify01")
+
+	const machine = "host/client@EXAMPLE.COM"
+	protection := NegotiateMachCred(machine, &types.StateProtect4A{How: types.SP4_MACH_CRED})
+	protected, err := sm.ExchangeIDMachCred([]byte("mach-cred-client"), verifier, 0, nil, "10.0.0.1:1", "", machine, protection)
+	if err != nil {
+		t.Fatalf("ExchangeIDMachCred error: %v", err)
+	}
+	if protected.MachCred == nil {
+		t.Fatal("ExchangeIDResult.MachCred should be set")
+	}
+
+	plain, err := sm.ExchangeID([]byte("plain-client"), verifier, 0, nil, "10.0.0.2:1")
+	if err != nil {
+		t.Fatalf("ExchangeID error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		clientID  uint64
+		op        uint32
+		principal string
+		wantErr   bool
+	}{
+		{"machine credential", protected.ClientID, types.OP_DESTROY_CLIENTID, machine, false},
+		{"other principal", protected.ClientID, types.OP_DESTROY_CLIENTID, "alice@EXAMPLE.COM", true},
+		{"AUTH_SYS", protected.ClientID, types.OP_CREATE_SESSION, "", true},
+		{"op not enforced", protected.ClientID, types.OP_READ, "alice@EXAMPLE.COM", false},
+		{"unprotected client", plain.ClientID, types.OP_DESTROY_CLIENTID, "", false},
+		{"unknown client", 0xdead, types.OP_DESTROY_CLIENTID, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sm.CheckMachCred(tt.clientID, tt.op, tt.principal)
+			if tt.wantErr && !errors.Is(err, ErrWrongCred) {
+				t.Errorf("CheckMachCred = %v, want ErrWrongCred", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("CheckMachCred = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestExchangeIDMachCred_WrongPrincipal(t *testing.T) {
+	sm := NewStateManager(DefaultLeaseDuration)
+	ownerID := []byte("mach-cred-owner")
+	var verifier [8]byte
+	copy(verifier[:], "verify01")
+
+	const machine = "host/client@EXAMPLE.COM"
+	protection := NegotiateMachCred(machine, &types.StateProtect4A{How: types.SP4_MACH_CRED})
+	first, err := sm.ExchangeIDMachCred(ownerID, verifier, 0, nil, "10.0.0.1:1", "", machine, protection)
+	if err != nil {
+		t.Fatalf("ExchangeIDMachCred error: %v", err)
+	}
+
+	// Another principal presenting a new verifier must not replace the client.
+	var newVerifier [8]byte
+	copy(newVerifier[:], "verify02")
+	if _, err := sm.ExchangeIDMachCred(ownerID, newVerifier, 0, nil, "10.0.0.9:1", "", "mallory@EXAMPLE.COM", nil); !errors.Is(err, ErrWrongCred) {
+		t.Fatalf("ExchangeIDMachCred from other principal = %v, want ErrWrongCred", err)
+	}
+
+	again, err := sm.ExchangeIDMachCred(ownerID, verifier, 0, nil, "10.0.0.1:1", "", machine, protection)
+	if err != nil {
+		t.Fatalf("ExchangeIDMachCred from machine credential error: %v", err)
+	}
+	if again.ClientID != first.ClientID {
+		t.Errorf("ClientID = %d, want %d", again.ClientID, first.ClientID)
+	}
+}
@@ -109,6 +109,30 @@ type StateManager struct {
 	// Defaults to true; updated from live adapter settings.
 	delegationsEnabled bool
 
+	// delegWants holds deferred v4.1 delegation wants
+	// (OPEN4_SHARE_ACCESS_WANT_PUSH_DELEG_WHEN_UNCONTENDED) by file handle;
+	// delegWantTimers re-services a file's wants once its recently-recalled
+	// window closes. delegAvailWaiters are clients to signal with
+	// CB_RECALLABLE_OBJ_AVAIL when the delegation limit clears.
+	delegWants        map[string][]*delegationWant
+	delegWantTimers   map[string]*time.Timer
+	delegAvailWaiters map[uint64]struct{}
+
+	// layoutByOther maps pNFS layout stateid "other" fields to LayoutState
+	// records; layoutByFile indexes the same records by file handle so a
+	// size change can recall every layout on the file.
+	layoutByOther map[[types.NFS4_OTHER_SIZE]byte]*LayoutState
+	layoutByFile  map[string][]*LayoutState
+
+	// layoutFence fences a file's layouts on the data servers. Init-only;
+	// see SetLayoutFence.
+	layoutFence func(fileHandle []byte)
+
+	// layoutsEnabled controls whether LAYOUTGET may grant layouts and whether
+	// EXCHANGE_ID advertises the pNFS metadata server role. Defaults to false;
+	// enabled when data servers are configured.
+	layoutsEnabled bool
+
 	// maxDelegations is the maximum total outstanding delegations (file + directory).
 	// 0 means unlimited. Updated via SetMaxDelegations.
 	maxDelegations int
@@ -271,6 +295,11 @@ func NewStateManager(leaseDuration time.Duration, graceDuration ...time.Duration
 		recentlyRecalled:    make(map[string]time.Time),
 		recentlyRecalledTTL: RecentlyRecalledTTL,
 		delegationsEnabled:  true,
+		delegWants:          make(map[string][]*delegationWant),
+		delegWantTimers:     make(map[string]*time.Timer),
+		delegAvailWaiters:   make(map[uint64]struct{}),
+		layoutByOther:       make(map[[types.NFS4_OTHER_SIZE]byte]*LayoutState),
+		layoutByFile:        make(map[string][]*LayoutState),
 		bootEpoch:           epoch,
 		leaseDuration:       leaseDuration,
 		graceDuration:       gd,
@@ -843,6 +872,12 @@ func (sm *StateManager) RevokeDelegation(delegOther [types.NFS4_OTHER_SIZE]byte)
 	sm.removeDelegFromFile(deleg)
 	sm.addRecentlyRecalled(deleg.FileHandle)
 
+	// A layout the holder obtained on the file goes with the delegation.
+	if layout := sm.findLayoutLocked(deleg.ClientID, deleg.FileHandle); layout != nil {
+		sm.removeLayoutLocked(layout)
+		sm.fenceLayouts(layout.FileHandle)
+	}
+
 	// Clean up LockManager delegation and stateid mapping
 	lmDelegID := deleg.LockManagerDelegID
 	fhKey := string(deleg.FileHandle)
@@ -852,6 +887,7 @@ func (sm *StateManager) RevokeDelegation(delegOther [types.NFS4_OTHER_SIZE]byte)
 
 	// Capture lockManager reference before releasing mu
 	lockMgr := sm.lockManagerFor(deleg.FileHandle)
+	followUp := sm.hasDelegationFollowUpsLocked(deleg.FileHandle)
 
 	// Keep in delegByOther for stale stateid detection.
 
@@ -865,6 +901,10 @@ func (sm *StateManager) RevokeDelegation(delegOther [types.NFS4_OTHER_SIZE]byte)
 	if lockMgr != nil && lmDelegID != "" {
 		_ = lockMgr.RevokeDelegation(fhKey, lmDelegID)
 	}
+
+	if followUp {
+		go sm.onDelegationStateReleased(deleg.FileHandle)
+	}
 }
 
 // Shutdown stops all active lease timers, recall timers, and the grace period
@@ -886,6 +926,12 @@ func (sm *StateManager) Shutdown() {
 		sm.cleanupDirDelegation(deleg)
 	}
 
+	// Stop deferred delegation-want retries
+	for fhKey, timer := range sm.delegWantTimers {
+		timer.Stop()
+		delete(sm.delegWantTimers, fhKey)
+	}
+
 	// Stop all backchannel senders to prevent orphan goroutines
 	for _, session := range sm.sessionsByID {
 		if session.backchannelSender != nil {
@@ -1193,6 +1239,7 @@ func (sm *StateManager) OpenFile(
 				"req_deny", shareDeny)
 			return nil, ErrShareDenied
 		}
+		sm.revokeDeniedLayoutsLocked(clientID, fileHandle, shareDeny)
 	}
 
 	// Check if this owner already has an open on this file
@@ -1533,6 +1580,12 @@ func (sm *StateManager) CloseFile(stateid *types.Stateid4, seqid uint32) (*OpenS
 	delete(sm.openStateByOther, stateid.Other)
 	sm.removeOpenStateFromFileLocked(openState)
 
+	// The close may clear the contention a deferred delegation want is
+	// waiting on; service it once sm.mu is released.
+	if len(sm.delegWants[string(openState.FileHandle)]) > 0 {
+		go sm.serviceDelegationWants(openState.FileHandle)
+	}
+
 	// Remember which retained owner this now-closed stateid belonged to so a
 	// retransmitted CLOSE can still resolve the owner's cached reply.
 	sm.closedOwnerByOther[stateid.Other] = owner
@@ -27,7 +27,8 @@ func NewNFSBreakHandler(sm *StateManager) *NFSBreakHandler {
 }
 
 // OnDelegationRecall looks up the NFS stateid for the delegation and sends
-// CB_RECALL via the backchannel. No-op if no NFS stateid mapping exists.
+// CB_RECALL via the backchannel, recalling the holder's pNFS layout on the
+// file alongside it. No-op if no NFS stateid mapping exists.
 func (h *NFSBreakHandler) OnDelegationRecall(handleKey string, ul *lock.UnifiedLock) {
 	if ul == nil || ul.Delegation == nil {
 		return
@@ -63,6 +64,7 @@ func (h *NFSBreakHandler) OnDelegationRecall(handleKey string, ul *lock.UnifiedL
 	deleg.RecallSent = true
 	deleg.RecallTime = time.Now()
 	clientID := deleg.ClientID
+	layoutRecalls := h.stateManager.recallClientLayoutLocked(clientID, deleg.FileHandle)
 	h.stateManager.mu.Unlock()
 
 	logger.Debug("NFSBreakHandler: dispatching CB_RECALL",
@@ -71,6 +73,7 @@ func (h *NFSBreakHandler) OnDelegationRecall(handleKey string, ul *lock.UnifiedL
 		"handleKey", handleKey)
 
 	go h.stateManager.sendRecall(deleg)
+	h.stateManager.dispatchLayoutRecalls(layoutRecalls)
 }
 
 // OnOpLockBreak is a no-op for NFS (no SMB-style leases).
@@ -26,6 +26,10 @@ const (
 
 	// StateTypeDeleg identifies a delegation stateid (created by OPEN delegation grant).
 	StateTypeDeleg byte = 0x03
+
+	// StateTypeLayout identifies a pNFS layout stateid (created by LAYOUTGET,
+	// removed by LAYOUTRETURN).
+	StateTypeLayout byte = 0x04
 )
 
 // ============================================================================
@@ -76,7 +80,7 @@ const (
 // generateStateidOther creates a unique 12-byte "other" field for a stateid.
 //
 // Layout:
-//   - Byte 0:    state type tag (open=0x01, lock=0x02, deleg=0x03)
+//   - Byte 0:    state type tag (open=0x01, lock=0x02, deleg=0x03, layout=0x04)
 //   - Bytes 1-3: boot epoch fragment (low 24 bits of sm.bootEpoch)
 //   - Bytes 4-11: atomic sequence counter (8 bytes, big-endian)
 //
@@ -406,6 +410,7 @@ func isSpecialOther(other [types.NFS4_OTHER_SIZE]byte) bool {
 //   - Lock stateids are removed directly
 //   - Open stateids are rejected with NFS4ERR_LOCKS_HELD if locks exist
 //   - Delegation stateids are removed directly
+//   - Layout stateids are rejected with NFS4ERR_LOCKS_HELD while layouts remain
 //   - Special stateids (all-zeros, all-ones) return NFS4ERR_BAD_STATEID
 //
 // No cache flush is triggered (trusts existing COMMIT/cache/WAL flow).
@@ -432,6 +437,8 @@ func (sm *StateManager) FreeStateid(clientID uint64, stateid *types.Stateid4) er
 		return sm.freeOpenStateidLocked(clientID, stateid)
 	case StateTypeDeleg:
 		return sm.freeDelegStateidLocked(clientID, stateid)
+	case StateTypeLayout:
+		return sm.freeLayoutStateidLocked(clientID, stateid)
 	default:
 		return &NFS4StateError{
 			Status:  types.NFS4ERR_BAD_STATEID,
@@ -657,6 +664,8 @@ func (sm *StateManager) testSingleStateid(stateid *types.Stateid4) uint32 {
 		return sm.testLockStateid(stateid)
 	case StateTypeDeleg:
 		return sm.testDelegStateid(stateid)
+	case StateTypeLayout:
+		return sm.testLayoutStateid(stateid)
 	default:
 		return types.NFS4ERR_BAD_STATEID
 	}
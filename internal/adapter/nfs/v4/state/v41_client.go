@@ -69,6 +69,10 @@ type V41ClientRecord struct {
 	// Lease is the lease timer for this client (shared behavior via pointer, same as v4.0 pattern).
 	Lease *LeaseState
 
+	// MachCred is the SP4_MACH_CRED protection negotiated at EXCHANGE_ID;
+	// nil for SP4_NONE clients.
+	MachCred *MachCredProtection
+
 	// CachedCreateSessionRes holds the full XDR-encoded CREATE_SESSION
 	// response bytes for replay detection (RFC 8881 Section 18.36).
 	// nil until the first successful CREATE_SESSION.
@@ -147,6 +151,9 @@ type ExchangeIDResult struct {
 	ServerOwner  types.ServerOwner4
 	ServerScope  []byte
 	ServerImplId []types.NfsImplId4
+
+	// MachCred is the client's SP4_MACH_CRED protection (nil for SP4_NONE).
+	MachCred *MachCredProtection
 }
 
 // ============================================================================
@@ -164,9 +171,11 @@ type ExchangeIDResult struct {
 //   - Case 3 (same owner, different verifier): Client reboot -> replace with fresh clientID
 //   - Case 4 (unconfirmed record exists): Supersede unconfirmed record
 //
-// The flags parameter from the client is currently ignored; the server always
-// sets EXCHGID4_FLAG_USE_NON_PNFS. EXCHGID4_FLAG_CONFIRMED_R is set only
-// if the record has been confirmed via CREATE_SESSION.
+// The server always sets EXCHGID4_FLAG_USE_NON_PNFS; when pNFS layouts are
+// enabled it also sets EXCHGID4_FLAG_USE_PNFS_MDS, and echoes
+// EXCHGID4_FLAG_USE_PNFS_DS if the client asked for it (DittoFS data servers
+// serve plain NFS I/O). EXCHGID4_FLAG_CONFIRMED_R is set only if the record
+// has been confirmed via CREATE_SESSION.
 //
 // Caller must NOT hold sm.mu.
 // The trailing principal is variadic so existing callers/tests that do not
@@ -174,16 +183,38 @@ type ExchangeIDResult struct {
 func (sm *StateManager) ExchangeID(
 	ownerID []byte,
 	verifier [8]byte,
-	_ uint32,
+	flags uint32,
 	clientImplId []types.NfsImplId4,
 	clientAddr string,
 	principal ...string,
+) (*ExchangeIDResult, error) {
+	return sm.ExchangeIDMachCred(ownerID, verifier, flags, clientImplId, clientAddr, firstOrEmpty(principal), "", nil)
+}
+
+// ExchangeIDMachCred is ExchangeID with SP4_MACH_CRED state protection.
+// gssPrincipal is the request's RPCSEC_GSS principal ("" for other flavors);
+// machCred, when non-nil, is the protection requested for a new client.
+//
+// An existing client that is already protected can only be updated or
+// replaced by its machine credential (ErrWrongCred otherwise), so another
+// principal cannot reset a protected client by presenting a new verifier.
+func (sm *StateManager) ExchangeIDMachCred(
+	ownerID []byte,
+	verifier [8]byte,
+	flags uint32,
+	clientImplId []types.NfsImplId4,
+	clientAddr string,
+	princ string,
+	gssPrincipal string,
+	machCred *MachCredProtection,
 ) (*ExchangeIDResult, error) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	princ := firstOrEmpty(principal)
 	existing := sm.v41ClientsByOwner[string(ownerID)]
+	if existing != nil && existing.MachCred != nil && existing.MachCred.Principal != gssPrincipal {
+		return nil, ErrWrongCred
+	}
 
 	var record *V41ClientRecord
 
@@ -191,6 +222,7 @@ func (sm *StateManager) ExchangeID(
 	case existing == nil:
 		// Case 1: New client -- no existing record for this owner
 		record = sm.createV41Client(ownerID, verifier, clientImplId, clientAddr, princ)
+		record.MachCred = machCred
 		logger.Info("EXCHANGE_ID: new v4.1 client registered",
 			"client_id", record.ClientID,
 			"client_addr", clientAddr)
@@ -214,6 +246,11 @@ func (sm *StateManager) ExchangeID(
 		}
 		existing.LastRenewal = time.Now()
 		applyImplInfo(existing, clientImplId)
+		// Protection is fixed once the client is confirmed; an unconfirmed
+		// record may still take the protection of its retried EXCHANGE_ID.
+		if !existing.Confirmed && existing.MachCred == nil {
+			existing.MachCred = machCred
+		}
 		record = existing
 		logger.Debug("EXCHANGE_ID: idempotent return for existing v4.1 client",
 			"client_id", record.ClientID,
@@ -229,6 +266,7 @@ func (sm *StateManager) ExchangeID(
 		}
 		sm.purgeV41Client(existing)
 		record = sm.createV41Client(ownerID, verifier, clientImplId, clientAddr, princ)
+		record.MachCred = machCred
 		logger.Info("EXCHANGE_ID: new v4.1 client",
 			"reason", reason,
 			"old_client_id", existing.ClientID,
@@ -238,6 +276,12 @@ func (sm *StateManager) ExchangeID(
 
 	// Build result flags
 	resultFlags := uint32(types.EXCHGID4_FLAG_USE_NON_PNFS)
+	if sm.layoutsEnabled {
+		resultFlags |= types.EXCHGID4_FLAG_USE_PNFS_MDS
+		if flags&types.EXCHGID4_FLAG_USE_PNFS_DS != 0 {
+			resultFlags |= types.EXCHGID4_FLAG_USE_PNFS_DS
+		}
+	}
 	if record.Confirmed {
 		resultFlags |= types.EXCHGID4_FLAG_CONFIRMED_R
 	}
@@ -253,6 +297,7 @@ func (sm *StateManager) ExchangeID(
 		ServerOwner:  sm.serverIdentity.ServerOwner,
 		ServerScope:  sm.serverIdentity.ServerScope,
 		ServerImplId: []types.NfsImplId4{sm.serverIdentity.ImplID},
+		MachCred:     record.MachCred,
 	}, nil
 }
 
@@ -330,6 +375,10 @@ func (sm *StateManager) purgeV41Client(record *V41ClientRecord) {
 		sm.removeDelegFromFile(deleg)
 	}
 
+	// Drop the client's pNFS layouts and deferred delegation wants
+	sm.purgeClientLayoutsLocked(record.ClientID)
+	sm.purgeClientDelegationWantsLocked(record.ClientID)
+
 	// Destroy all sessions for this client. Stop each session's backchannel
 	// sender BEFORE removing it from sessionsByID -- stopBackchannelSender
 	// looks the session up there, so deleting first would leak the goroutine.
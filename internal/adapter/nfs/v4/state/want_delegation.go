@@ -0,0 +1,437 @@
+package state
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
+	"github.com/marmos91/dittofs/internal/logger"
+)
+
+// delegationWant is a deferred delegation request registered with
+// OPEN4_SHARE_ACCESS_WANT_PUSH_DELEG_WHEN_UNCONTENDED. Once the contention on
+// the file clears, the server grants the delegation and pushes it to the
+// client with CB_PUSH_DELEG (RFC 8881 Section 20.5).
+type delegationWant struct {
+	clientID   uint64
+	fileHandle []byte
+	want       uint32 // OPEN4_SHARE_ACCESS_WANT_DELEG_MASK bits
+}
+
+// DelegationWantResult is the outcome of a v4.1 delegation want expressed
+// through WANT_DELEGATION or the OPEN4_SHARE_ACCESS_WANT_* bits of OPEN.
+type DelegationWantResult struct {
+	// Deleg is the granted (or already held) delegation; nil when none.
+	Deleg *DelegationState
+
+	// WhyNone is the why_no_delegation4 reason when Deleg is nil.
+	WhyNone uint32
+
+	// WillNotify is ond_server_will_push_deleg for WND4_CONTENTION and
+	// ond_server_will_signal_avail for WND4_RESOURCE: the server registered
+	// the want and will follow up with CB_PUSH_DELEG or
+	// CB_RECALLABLE_OBJ_AVAIL.
+	WillNotify bool
+}
+
+// EncodeDelegationWant encodes the open_delegation4 answering a v4.1
+// delegation want: the delegation itself when one was granted, otherwise
+// OPEN_DELEGATE_NONE_EXT with its why_no_delegation4 reason.
+func EncodeDelegationWant(buf *bytes.Buffer, res *DelegationWantResult) {
+	if res.Deleg != nil {
+		EncodeDelegation(buf, res.Deleg)
+		return
+	}
+	_ = xdr.WriteUint32(buf, types.OPEN_DELEGATE_NONE_EXT)
+	_ = xdr.WriteUint32(buf, res.WhyNone)
+	if res.WhyNone == types.WND4_CONTENTION || res.WhyNone == types.WND4_RESOURCE {
+		_ = xdr.WriteBool(buf, res.WillNotify)
+	}
+}
+
+// WantDelegation evaluates a v4.1 delegation want on a regular file.
+//
+// The want is the OPEN4_SHARE_ACCESS_WANT_* word from WANT_DELEGATION's
+// wda_want or OPEN's share_access (RFC 8881 Section 18.49.3):
+//   - NO_DELEG declines a delegation (WND4_NOT_WANTED)
+//   - CANCEL drops any deferred want on the file (WND4_CANCELLED)
+//   - READ_DELEG / WRITE_DELEG ask for that type; NO_PREFERENCE and
+//     ANY_DELEG get a WRITE delegation when the client has the file open
+//     for writing and a READ delegation otherwise
+//
+// An existing delegation held by the client is returned as-is when it
+// satisfies the want; upgrades and downgrades are not supported. When the
+// grant policy declines, the want is registered for a later CB_PUSH_DELEG
+// (contention) or CB_RECALLABLE_OBJ_AVAIL (delegation limit reached) if the
+// client asked for it and has a backchannel.
+//
+// Returns NFS4ERR_INVAL for an unknown want value.
+//
+// Caller must NOT hold sm.mu (method acquires it).
+func (sm *StateManager) WantDelegation(clientID uint64, fileHandle []byte, want uint32) (*DelegationWantResult, error) {
+	wantType := want & types.OPEN4_SHARE_ACCESS_WANT_DELEG_MASK
+	switch wantType {
+	case types.OPEN4_SHARE_ACCESS_WANT_NO_DELEG:
+		return &DelegationWantResult{WhyNone: types.WND4_NOT_WANTED}, nil
+	case types.OPEN4_SHARE_ACCESS_WANT_CANCEL:
+		sm.CancelDelegationWant(clientID, fileHandle)
+		return &DelegationWantResult{WhyNone: types.WND4_CANCELLED}, nil
+	case types.OPEN4_SHARE_ACCESS_WANT_NO_PREFERENCE,
+		types.OPEN4_SHARE_ACCESS_WANT_READ_DELEG,
+		types.OPEN4_SHARE_ACCESS_WANT_WRITE_DELEG,
+		types.OPEN4_SHARE_ACCESS_WANT_ANY_DELEG:
+	default:
+		return nil, &NFS4StateError{
+			Status:  types.NFS4ERR_INVAL,
+			Message: "invalid delegation want",
+		}
+	}
+
+	sm.mu.RLock()
+	held := sm.clientFileDelegationLocked(clientID, fileHandle)
+	delegType := sm.wantedDelegTypeLocked(clientID, fileHandle, wantType)
+	ok, why := sm.delegationDecisionLocked(clientID, fileHandle)
+	sm.mu.RUnlock()
+
+	if held != nil {
+		switch {
+		case held.DelegType == types.OPEN_DELEGATE_READ && wantType == types.OPEN4_SHARE_ACCESS_WANT_WRITE_DELEG:
+			return &DelegationWantResult{WhyNone: types.WND4_NOT_SUPP_UPGRADE}, nil
+		case held.DelegType == types.OPEN_DELEGATE_WRITE && wantType == types.OPEN4_SHARE_ACCESS_WANT_READ_DELEG:
+			return &DelegationWantResult{WhyNone: types.WND4_NOT_SUPP_DOWNGRADE}, nil
+		}
+		return &DelegationWantResult{Deleg: held}, nil
+	}
+
+	if ok {
+		if deleg := sm.GrantDelegation(clientID, fileHandle, delegType); deleg != nil {
+			return &DelegationWantResult{Deleg: deleg}, nil
+		}
+		why = types.WND4_RESOURCE
+	}
+
+	return &DelegationWantResult{
+		WhyNone:    why,
+		WillNotify: sm.registerDelegationWant(clientID, fileHandle, want, why),
+	}, nil
+}
+
+// CancelDelegationWant drops a deferred delegation want the client registered
+// on the file (OPEN4_SHARE_ACCESS_WANT_CANCEL).
+//
+// Caller must NOT hold sm.mu (method acquires it).
+func (sm *StateManager) CancelDelegationWant(clientID uint64, fileHandle []byte) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	fhKey := string(fileHandle)
+	for _, w := range sm.delegWants[fhKey] {
+		if w.clientID == clientID {
+			sm.removeDelegationWantLocked(w)
+			return
+		}
+	}
+}
+
+// clientFileDelegationLocked returns the client's active file delegation on
+// the file, or nil.
+//
+// Caller must hold sm.mu (RLock or Lock).
+func (sm *StateManager) clientFileDelegationLocked(clientID uint64, fileHandle []byte) *DelegationState {
+	for _, deleg := range sm.delegByFile[string(fileHandle)] {
+		if deleg.ClientID == clientID && !deleg.Revoked && !deleg.IsDirectory {
+			return deleg
+		}
+	}
+	return nil
+}
+
+// wantedDelegTypeLocked maps a want to the delegation type to grant.
+// NO_PREFERENCE and ANY_DELEG follow the client's open share access on the
+// file, matching the OPEN grant policy.
+//
+// Caller must hold sm.mu (RLock or Lock).
+func (sm *StateManager) wantedDelegTypeLocked(clientID uint64, fileHandle []byte, wantType uint32) uint32 {
+	switch wantType {
+	case types.OPEN4_SHARE_ACCESS_WANT_READ_DELEG:
+		return types.OPEN_DELEGATE_READ
+	case types.OPEN4_SHARE_ACCESS_WANT_WRITE_DELEG:
+		return types.OPEN_DELEGATE_WRITE
+	}
+
+	var access uint32
+	for _, openState := range sm.openStateByFile[string(fileHandle)] {
+		if openState.Owner != nil && openState.Owner.ClientID == clientID {
+			access |= openState.ShareAccess
+		}
+	}
+	if access&types.OPEN4_SHARE_ACCESS_WRITE != 0 {
+		return types.OPEN_DELEGATE_WRITE
+	}
+	return types.OPEN_DELEGATE_READ
+}
+
+// registerDelegationWant records a declined want for a later callback when
+// the client asked for one: PUSH_DELEG_WHEN_UNCONTENDED on contention,
+// SIGNAL_DELEG_WHEN_RESRC_AVAIL when the delegation limit is reached.
+// Only clients with a backchannel can be called back.
+//
+// Returns whether the server will follow up (ond_server_will_*).
+//
+// Caller must NOT hold sm.mu (method acquires it).
+func (sm *StateManager) registerDelegationWant(clientID uint64, fileHandle []byte, want, why uint32) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if !sm.delegationsEnabled || !sm.hasBackchannelLocked(clientID) {
+		return false
+	}
+
+	switch why {
+	case types.WND4_CONTENTION:
+		if want&types.OPEN4_SHARE_ACCESS_WANT_PUSH_DELEG_WHEN_UNCONTENDED == 0 {
+			return false
+		}
+		fhKey := string(fileHandle)
+		for _, w := range sm.delegWants[fhKey] {
+			if w.clientID == clientID {
+				w.want = want & types.OPEN4_SHARE_ACCESS_WANT_DELEG_MASK
+				return true
+			}
+		}
+		fhCopy := make([]byte, len(fileHandle))
+		copy(fhCopy, fileHandle)
+		sm.delegWants[fhKey] = append(sm.delegWants[fhKey], &delegationWant{
+			clientID:   clientID,
+			fileHandle: fhCopy,
+			want:       want & types.OPEN4_SHARE_ACCESS_WANT_DELEG_MASK,
+		})
+		sm.scheduleWantRetryLocked(fhCopy)
+		return true
+
+	case types.WND4_RESOURCE:
+		if want&types.OPEN4_SHARE_ACCESS_WANT_SIGNAL_DELEG_WHEN_RESRC_AVAIL == 0 {
+			return false
+		}
+		// Only the delegation limit clears on its own; a missing callback
+		// path or disabled delegations are not worth waiting for.
+		if sm.maxDelegations == 0 || sm.countActiveDelegations() < sm.maxDelegations {
+			return false
+		}
+		sm.delegAvailWaiters[clientID] = struct{}{}
+		return true
+	}
+	return false
+}
+
+// hasBackchannelLocked reports whether any of the client's sessions has a
+// running backchannel sender.
+//
+// Caller must hold sm.mu (RLock or Lock).
+func (sm *StateManager) hasBackchannelLocked(clientID uint64) bool {
+	for _, session := range sm.sessionsByClientID[clientID] {
+		if session.backchannelSender != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// removeDelegationWantLocked removes a want from the delegWants index.
+//
+// Caller must hold sm.mu.
+func (sm *StateManager) removeDelegationWantLocked(want *delegationWant) {
+	fhKey := string(want.fileHandle)
+	wants := sm.delegWants[fhKey]
+	for i, w := range wants {
+		if w == want {
+			sm.delegWants[fhKey] = append(wants[:i], wants[i+1:]...)
+			break
+		}
+	}
+	if len(sm.delegWants[fhKey]) == 0 {
+		delete(sm.delegWants, fhKey)
+		if timer, ok := sm.delegWantTimers[fhKey]; ok {
+			timer.Stop()
+			delete(sm.delegWantTimers, fhKey)
+		}
+	}
+}
+
+// purgeClientDelegationWantsLocked drops every deferred want of a client.
+//
+// Caller must hold sm.mu.
+func (sm *StateManager) purgeClientDelegationWantsLocked(clientID uint64) {
+	for _, wants := range sm.delegWants {
+		for _, w := range wants {
+			if w.clientID == clientID {
+				sm.removeDelegationWantLocked(w)
+				break
+			}
+		}
+	}
+	delete(sm.delegAvailWaiters, clientID)
+}
+
+// hasDelegationFollowUpsLocked reports whether releasing state on the file
+// may let the server honor a deferred want.
+//
+// Caller must hold sm.mu (RLock or Lock).
+func (sm *StateManager) hasDelegationFollowUpsLocked(fileHandle []byte) bool {
+	return len(sm.delegWants[string(fileHandle)]) > 0 || len(sm.delegAvailWaiters) > 0
+}
+
+// scheduleWantRetryLocked re-services the file's wants once its
+// recently-recalled window closes, since no other state change may happen
+// on the file to trigger them.
+//
+// Caller must hold sm.mu.
+func (sm *StateManager) scheduleWantRetryLocked(fileHandle []byte) {
+	recallTime, exists := sm.recentlyRecalled[string(fileHandle)]
+	if !exists {
+		return
+	}
+	fhKey := string(fileHandle)
+	if _, pending := sm.delegWantTimers[fhKey]; pending {
+		return
+	}
+	wait := sm.recentlyRecalledTTL - time.Since(recallTime)
+	if wait <= 0 {
+		return
+	}
+	sm.delegWantTimers[fhKey] = time.AfterFunc(wait+time.Millisecond, func() {
+		sm.mu.Lock()
+		delete(sm.delegWantTimers, fhKey)
+		sm.mu.Unlock()
+		sm.serviceDelegationWants(fileHandle)
+	})
+}
+
+// onDelegationStateReleased follows up on deferred wants after a delegation
+// or open on the file went away.
+func (sm *StateManager) onDelegationStateReleased(fileHandle []byte) {
+	sm.serviceDelegationWants(fileHandle)
+	sm.signalDelegationAvail()
+}
+
+// serviceDelegationWants grants the first deferred want on the file that the
+// grant policy now accepts and pushes it to the client with CB_PUSH_DELEG.
+// The policy allows a single delegation per file, so one pass grants at most
+// one want; the rest wait for the next release.
+//
+// Caller must NOT hold sm.mu (method acquires it).
+func (sm *StateManager) serviceDelegationWants(fileHandle []byte) {
+	sm.mu.Lock()
+	var ready *delegationWant
+	var delegType uint32
+	for _, w := range sm.delegWants[string(fileHandle)] {
+		if ok, _ := sm.delegationDecisionLocked(w.clientID, fileHandle); ok {
+			ready = w
+			delegType = sm.wantedDelegTypeLocked(w.clientID, fileHandle, w.want)
+			break
+		}
+	}
+	if ready == nil {
+		if len(sm.delegWants[string(fileHandle)]) > 0 {
+			sm.scheduleWantRetryLocked(fileHandle)
+		}
+		sm.mu.Unlock()
+		return
+	}
+	sm.removeDelegationWantLocked(ready)
+	sm.mu.Unlock()
+
+	deleg := sm.GrantDelegation(ready.clientID, ready.fileHandle, delegType)
+	if deleg == nil {
+		logger.Debug("Deferred delegation want dropped: delegation limit reached",
+			"client_id", ready.clientID)
+		return
+	}
+	sm.pushDelegation(deleg)
+}
+
+// pushDelegation offers a freshly granted delegation to its client with
+// CB_PUSH_DELEG. If the client rejects it (NFS4ERR_REJECT_DELEG) or cannot be
+// reached, the delegation is returned on the client's behalf.
+//
+// IMPORTANT: This must NOT hold sm.mu during the send.
+func (sm *StateManager) pushDelegation(deleg *DelegationState) {
+	sender := sm.getBackchannelSender(deleg.ClientID)
+	if sender == nil {
+		_ = sm.ReturnDelegation(&deleg.Stateid)
+		return
+	}
+
+	resultCh := make(chan error, 1)
+	req := CallbackRequest{
+		OpCode:   types.CB_PUSH_DELEG,
+		Payload:  EncodeCBPushDelegOp(deleg),
+		ResultCh: resultCh,
+	}
+
+	if !sender.Enqueue(req) {
+		logger.Warn("CB_PUSH_DELEG: backchannel queue full, dropping delegation",
+			"client_id", deleg.ClientID)
+		_ = sm.ReturnDelegation(&deleg.Stateid)
+		return
+	}
+
+	select {
+	case err := <-resultCh:
+		if err != nil {
+			logger.Debug("CB_PUSH_DELEG failed, dropping delegation",
+				"client_id", deleg.ClientID,
+				"error", err)
+			_ = sm.ReturnDelegation(&deleg.Stateid)
+			return
+		}
+		logger.Debug("CB_PUSH_DELEG sent successfully",
+			"client_id", deleg.ClientID,
+			"deleg_type", deleg.DelegType)
+
+	case <-sender.stopCh:
+		_ = sm.ReturnDelegation(&deleg.Stateid)
+
+	case <-time.After(30 * time.Second):
+		logger.Warn("CB_PUSH_DELEG result timeout, dropping delegation",
+			"client_id", deleg.ClientID)
+		_ = sm.ReturnDelegation(&deleg.Stateid)
+	}
+}
+
+// signalDelegationAvail sends CB_RECALLABLE_OBJ_AVAIL to every client waiting
+// for the delegation limit to clear, once it has. Waiters are signalled once;
+// a client that still wants a delegation asks again.
+//
+// Caller must NOT hold sm.mu (method acquires it).
+func (sm *StateManager) signalDelegationAvail() {
+	sm.mu.Lock()
+	if len(sm.delegAvailWaiters) == 0 ||
+		(sm.maxDelegations > 0 && sm.countActiveDelegations() >= sm.maxDelegations) {
+		sm.mu.Unlock()
+		return
+	}
+	waiters := make([]uint64, 0, len(sm.delegAvailWaiters))
+	for clientID := range sm.delegAvailWaiters {
+		waiters = append(waiters, clientID)
+	}
+	clear(sm.delegAvailWaiters)
+	sm.mu.Unlock()
+
+	for _, clientID := range waiters {
+		sender := sm.getBackchannelSender(clientID)
+		if sender == nil {
+			continue
+		}
+		// Fire-and-forget: the signal is a hint and ResultCh is buffered.
+		if !sender.Enqueue(CallbackRequest{
+			OpCode:   types.CB_RECALLABLE_OBJ_AVAIL,
+			Payload:  EncodeCBRecallableObjAvailOp(),
+			ResultCh: make(chan error, 1),
+		}) {
+			logger.Debug("CB_RECALLABLE_OBJ_AVAIL: backchannel queue full",
+				"client_id", clientID)
+		}
+	}
+}
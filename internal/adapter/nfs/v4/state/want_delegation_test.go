@@ -0,0 +1,226 @@
+package state
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
+)
+
+// newWantTestClient registers a v4.1 client whose session has a backchannel
+// sender that is never run, so queued callbacks can be inspected.
+func newWantTestClient(t *testing.T, sm *StateManager) (uint64, *BackchannelSender) {
+	t.Helper()
+	clientID, seqID := registerV41Client(t, sm)
+
+	csResult, _, err := sm.CreateSession(
+		clientID, seqID, types.CREATE_SESSION4_FLAG_CONN_BACK_CHAN,
+		defaultForeAttrs(), defaultBackAttrs(), 0x40000000, nil,
+	)
+	if err != nil {
+		t.Fatalf("CreateSession error: %v", err)
+	}
+	session := sm.GetSession(csResult.SessionID)
+	sender := NewBackchannelSender(csResult.SessionID, clientID, 0x40000000, session.BackChannelSlots, sm)
+	sm.mu.Lock()
+	session.backchannelSender = sender
+	sm.mu.Unlock()
+	t.Cleanup(sm.Shutdown)
+	return clientID, sender
+}
+
+// nextCallback waits for the next callback queued on an idle sender.
+func nextCallback(t *testing.T, sender *BackchannelSender) CallbackRequest {
+	t.Helper()
+	select {
+	case req := <-sender.queue:
+		return req
+	case <-time.After(2 * time.Second):
+		t.Fatal("no callback queued")
+		return CallbackRequest{}
+	}
+}
+
+func TestWantDelegation_GrantsWhenUncontended(t *testing.T) {
+	sm := NewStateManager(90 * time.Second)
+	clientID, _ := newWantTestClient(t, sm)
+	fh := []byte("want-fh")
+
+	res, err := sm.WantDelegation(clientID, fh, types.OPEN4_SHARE_ACCESS_WANT_ANY_DELEG)
+	if err != nil {
+		t.Fatalf("WantDelegation: %v", err)
+	}
+	if res.Deleg == nil {
+		t.Fatalf("expected a delegation, got why=%d", res.WhyNone)
+	}
+	if res.Deleg.DelegType != types.OPEN_DELEGATE_READ {
+		t.Errorf("DelegType = %d, want READ (no write open)", res.Deleg.DelegType)
+	}
+
+	// Asking again returns the delegation already held.
+	again, _ := sm.WantDelegation(clientID, fh, types.OPEN4_SHARE_ACCESS_WANT_READ_DELEG)
+	if again.Deleg != res.Deleg {
+		t.Error("second want should return the held delegation")
+	}
+
+	// A READ delegation cannot be upgraded to WRITE.
+	upgrade, _ := sm.WantDelegation(clientID, fh, types.OPEN4_SHARE_ACCESS_WANT_WRITE_DELEG)
+	if upgrade.Deleg != nil || upgrade.WhyNone != types.WND4_NOT_SUPP_UPGRADE {
+		t.Errorf("upgrade = %+v, want WND4_NOT_SUPP_UPGRADE", upgrade)
+	}
+}
+
+func TestWantDelegation_NotWantedCancelAndInvalid(t *testing.T) {
+	sm := NewStateManager(90 * time.Second)
+	clientID, _ := newWantTestClient(t, sm)
+	fh := []byte("want-fh")
+
+	res, _ := sm.WantDelegation(clientID, fh, types.OPEN4_SHARE_ACCESS_WANT_NO_DELEG)
+	if res.Deleg != nil || res.WhyNone != types.WND4_NOT_WANTED {
+		t.Errorf("NO_DELEG = %+v, want WND4_NOT_WANTED", res)
+	}
+
+	res, _ = sm.WantDelegation(clientID, fh, types.OPEN4_SHARE_ACCESS_WANT_CANCEL)
+	if res.Deleg != nil || res.WhyNone != types.WND4_CANCELLED {
+		t.Errorf("CANCEL = %+v, want WND4_CANCELLED", res)
+	}
+
+	if _, err := sm.WantDelegation(clientID, fh, 0x0600); layoutStatus(err) != types.NFS4ERR_INVAL {
+		t.Errorf("unknown want status = %d, want NFS4ERR_INVAL", layoutStatus(err))
+	}
+}
+
+func TestWantDelegation_NoBackchannel(t *testing.T) {
+	sm := NewStateManager(90 * time.Second)
+	clientID, _ := registerV41Client(t, sm)
+
+	res, err := sm.WantDelegation(clientID, []byte("want-fh"),
+		types.OPEN4_SHARE_ACCESS_WANT_ANY_DELEG|types.OPEN4_SHARE_ACCESS_WANT_SIGNAL_DELEG_WHEN_RESRC_AVAIL)
+	if err != nil {
+		t.Fatalf("WantDelegation: %v", err)
+	}
+	if res.Deleg != nil || res.WhyNone != types.WND4_RESOURCE || res.WillNotify {
+		t.Errorf("result = %+v, want WND4_RESOURCE without signal", res)
+	}
+}
+
+func TestWantDelegation_PushWhenUncontended(t *testing.T) {
+	sm := NewStateManager(90 * time.Second)
+	clientID, sender := newWantTestClient(t, sm)
+	fh := []byte("want-fh")
+
+	// Another client holds a delegation on the file.
+	other := sm.GrantDelegation(999, fh, types.OPEN_DELEGATE_READ)
+
+	res, err := sm.WantDelegation(clientID, fh,
+		types.OPEN4_SHARE_ACCESS_WANT_READ_DELEG|types.OPEN4_SHARE_ACCESS_WANT_PUSH_DELEG_WHEN_UNCONTENDED)
+	if err != nil {
+		t.Fatalf("WantDelegation: %v", err)
+	}
+	if res.Deleg != nil || res.WhyNone != types.WND4_CONTENTION || !res.WillNotify {
+		t.Fatalf("result = %+v, want WND4_CONTENTION with push", res)
+	}
+
+	if err := sm.ReturnDelegation(&other.Stateid); err != nil {
+		t.Fatalf("ReturnDelegation: %v", err)
+	}
+
+	req := nextCallback(t, sender)
+	if req.OpCode != types.CB_PUSH_DELEG {
+		t.Fatalf("callback op = %d, want CB_PUSH_DELEG", req.OpCode)
+	}
+	var args types.CbPushDelegArgs
+	if err := args.Decode(bytes.NewReader(req.Payload[4:])); err != nil {
+		t.Fatalf("decode CB_PUSH_DELEG: %v", err)
+	}
+	if !bytes.Equal(args.FH, fh) {
+		t.Errorf("pushed FH = %q, want %q", args.FH, fh)
+	}
+	req.ResultCh <- nil
+
+	delegs := sm.GetDelegationsForFile(fh)
+	if len(delegs) != 1 || delegs[0].ClientID != clientID {
+		t.Fatalf("delegations on file = %+v, want one held by the pushing client", delegs)
+	}
+}
+
+func TestWantDelegation_RejectedPushIsReturned(t *testing.T) {
+	sm := NewStateManager(90 * time.Second)
+	clientID, sender := newWantTestClient(t, sm)
+	fh := []byte("want-fh")
+
+	other := sm.GrantDelegation(999, fh, types.OPEN_DELEGATE_READ)
+	_, _ = sm.WantDelegation(clientID, fh,
+		types.OPEN4_SHARE_ACCESS_WANT_ANY_DELEG|types.OPEN4_SHARE_ACCESS_WANT_PUSH_DELEG_WHEN_UNCONTENDED)
+	_ = sm.ReturnDelegation(&other.Stateid)
+
+	req := nextCallback(t, sender)
+	req.ResultCh <- &NFS4StateError{Status: types.NFS4ERR_REJECT_DELEG}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sm.GetDelegationsForFile(fh)) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("rejected pushed delegation was not returned")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWantDelegation_CancelDropsDeferredWant(t *testing.T) {
+	sm := NewStateManager(90 * time.Second)
+	clientID, sender := newWantTestClient(t, sm)
+	fh := []byte("want-fh")
+
+	other := sm.GrantDelegation(999, fh, types.OPEN_DELEGATE_READ)
+	_, _ = sm.WantDelegation(clientID, fh,
+		types.OPEN4_SHARE_ACCESS_WANT_ANY_DELEG|types.OPEN4_SHARE_ACCESS_WANT_PUSH_DELEG_WHEN_UNCONTENDED)
+	_, _ = sm.WantDelegation(clientID, fh, types.OPEN4_SHARE_ACCESS_WANT_CANCEL)
+	_ = sm.ReturnDelegation(&other.Stateid)
+
+	select {
+	case req := <-sender.queue:
+		t.Fatalf("unexpected callback %d after cancel", req.OpCode)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWantDelegation_SignalWhenResourceAvailable(t *testing.T) {
+	sm := NewStateManager(90 * time.Second)
+	sm.SetMaxDelegations(1)
+	clientID, sender := newWantTestClient(t, sm)
+
+	other := sm.GrantDelegation(999, []byte("busy-fh"), types.OPEN_DELEGATE_READ)
+
+	res, err := sm.WantDelegation(clientID, []byte("want-fh"),
+		types.OPEN4_SHARE_ACCESS_WANT_ANY_DELEG|types.OPEN4_SHARE_ACCESS_WANT_SIGNAL_DELEG_WHEN_RESRC_AVAIL)
+	if err != nil {
+		t.Fatalf("WantDelegation: %v", err)
+	}
+	if res.Deleg != nil || res.WhyNone != types.WND4_RESOURCE || !res.WillNotify {
+		t.Fatalf("result = %+v, want WND4_RESOURCE with signal", res)
+	}
+
+	_ = sm.ReturnDelegation(&other.Stateid)
+
+	if req := nextCallback(t, sender); req.OpCode != types.CB_RECALLABLE_OBJ_AVAIL {
+		t.Errorf("callback op = %d, want CB_RECALLABLE_OBJ_AVAIL", req.OpCode)
+	}
+}
+
+func TestEncodeDelegationWant_NoneExt(t *testing.T) {
+	var buf bytes.Buffer
+	EncodeDelegationWant(&buf, &DelegationWantResult{WhyNone: types.WND4_CONTENTION, WillNotify: true})
+
+	delegType, body, err := types.DecodeOpenDelegation4(&buf)
+	if err != nil {
+		t.Fatalf("DecodeOpenDelegation4: %v", err)
+	}
+	if delegType != types.OPEN_DELEGATE_NONE_EXT {
+		t.Errorf("type = %d, want OPEN_DELEGATE_NONE_EXT", delegType)
+	}
+	want := []byte{0, 0, 0, types.WND4_CONTENTION, 0, 0, 0, 1}
+	if !bytes.Equal(body, want) {
+		t.Errorf("body = %x, want %x", body, want)
+	}
+}
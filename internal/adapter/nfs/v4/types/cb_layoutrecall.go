@@ -2,7 +2,7 @@
 //
 // CB_LAYOUTRECALL recalls layout segments from a client. The recall can target
 // a specific file (with offset/length), an entire FSID, or all layouts.
-// Included for wire compatibility; pNFS is out of scope for v3.0.
+// Used by the pNFS Flexible Files layout (see flexfiles.go).
 package types
 
 import (
@@ -1,8 +1,8 @@
 // Package types - CB_PUSH_DELEG callback operation types (RFC 8881 Section 20.5).
 //
 // CB_PUSH_DELEG offers a delegation to the client for a specified filehandle.
-// The delegation is encoded as an open_delegation4 union -- stored as raw opaque
-// since full delegation encoding already exists in v4.0.
+// The delegation is an open_delegation4 union, carried as its pre-encoded XDR
+// bytes since full delegation encoding already exists in v4.0.
 package types
 
 import (
@@ -25,7 +25,7 @@ import (
 //	};
 type CbPushDelegArgs struct {
 	FH         []byte // filehandle
-	Delegation []byte // open_delegation4 as raw opaque
+	Delegation []byte // open_delegation4 as raw XDR (discriminant + arm)
 }
 
 // Encode writes the CB_PUSH_DELEG args in XDR format.
@@ -33,7 +33,7 @@ func (a *CbPushDelegArgs) Encode(buf *bytes.Buffer) error {
 	if err := xdr.WriteXDROpaque(buf, a.FH); err != nil {
 		return fmt.Errorf("encode cb_push_deleg fh: %w", err)
 	}
-	if err := xdr.WriteXDROpaque(buf, a.Delegation); err != nil {
+	if _, err := buf.Write(a.Delegation); err != nil {
 		return fmt.Errorf("encode cb_push_deleg delegation: %w", err)
 	}
 	return nil
@@ -45,9 +45,14 @@ func (a *CbPushDelegArgs) Decode(r io.Reader) error {
 	if a.FH, err = xdr.DecodeOpaque(r); err != nil {
 		return fmt.Errorf("decode cb_push_deleg fh: %w", err)
 	}
-	if a.Delegation, err = xdr.DecodeOpaque(r); err != nil {
+	delegType, body, err := DecodeOpenDelegation4(r)
+	if err != nil {
 		return fmt.Errorf("decode cb_push_deleg delegation: %w", err)
 	}
+	var deleg bytes.Buffer
+	_ = xdr.WriteUint32(&deleg, delegType)
+	deleg.Write(body)
+	a.Delegation = deleg.Bytes()
 	return nil
 }
 
@@ -3,12 +3,27 @@ package types
 import (
 	"bytes"
 	"testing"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
 )
 
+// encodeTestReadDelegation builds an open_delegation4 READ arm.
+func encodeTestReadDelegation() []byte {
+	var buf bytes.Buffer
+	_ = xdr.WriteUint32(&buf, OPEN_DELEGATE_READ)
+	EncodeStateid4(&buf, &Stateid4{Seqid: 1, Other: [NFS4_OTHER_SIZE]byte{0x03, 0x01}})
+	_ = xdr.WriteBool(&buf, false)
+	_ = xdr.WriteUint32(&buf, ACE4_ACCESS_ALLOWED_ACE_TYPE)
+	_ = xdr.WriteUint32(&buf, 0)
+	_ = xdr.WriteUint32(&buf, ACE4_GENERIC_READ)
+	_ = xdr.WriteXDRString(&buf, "EVERYONE@")
+	return buf.Bytes()
+}
+
 func TestCbPushDelegArgs_RoundTrip(t *testing.T) {
 	original := CbPushDelegArgs{
 		FH:         []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
-		Delegation: []byte{0xde, 0xad, 0xbe, 0xef, 0xca, 0xfe},
+		Delegation: encodeTestReadDelegation(),
 	}
 
 	var buf bytes.Buffer
@@ -27,6 +42,9 @@ func TestCbPushDelegArgs_RoundTrip(t *testing.T) {
 	if !bytes.Equal(decoded.Delegation, original.Delegation) {
 		t.Errorf("Delegation: got %x, want %x", decoded.Delegation, original.Delegation)
 	}
+	if buf.Len() != 0 {
+		t.Errorf("%d trailing bytes after decode", buf.Len())
+	}
 }
 
 func TestCbPushDelegRes_RoundTrip(t *testing.T) {
@@ -369,6 +369,24 @@ const (
 	OPEN4_SHARE_DENY_BOTH  = 0x03
 )
 
+// OPEN4_SHARE_ACCESS_WANT_* delegation preferences carried in the upper bits
+// of share_access (OPEN) and wda_want (WANT_DELEGATION) per RFC 8881
+// Section 18.16.3. The WANT_DELEG_MASK bits form an enumeration; the two
+// high flags request a deferred grant (CB_PUSH_DELEG) or an availability
+// signal (CB_RECALLABLE_OBJ_AVAIL) when no delegation can be granted now.
+const (
+	OPEN4_SHARE_ACCESS_WANT_DELEG_MASK    = 0xFF00
+	OPEN4_SHARE_ACCESS_WANT_NO_PREFERENCE = 0x0000
+	OPEN4_SHARE_ACCESS_WANT_READ_DELEG    = 0x0100
+	OPEN4_SHARE_ACCESS_WANT_WRITE_DELEG   = 0x0200
+	OPEN4_SHARE_ACCESS_WANT_ANY_DELEG     = 0x0300
+	OPEN4_SHARE_ACCESS_WANT_NO_DELEG      = 0x0400
+	OPEN4_SHARE_ACCESS_WANT_CANCEL        = 0x0500
+
+	OPEN4_SHARE_ACCESS_WANT_SIGNAL_DELEG_WHEN_RESRC_AVAIL = 0x10000
+	OPEN4_SHARE_ACCESS_WANT_PUSH_DELEG_WHEN_UNCONTENDED   = 0x20000
+)
+
 // OPEN create type
 const (
 	OPEN4_NOCREATE = 0
@@ -399,6 +417,24 @@ const (
 	OPEN_DELEGATE_NONE  = 0
 	OPEN_DELEGATE_READ  = 1
 	OPEN_DELEGATE_WRITE = 2
+
+	// OPEN_DELEGATE_NONE_EXT is the v4.1 "no delegation" arm that carries a
+	// why_no_delegation4 reason (RFC 8881 Section 18.16.3).
+	OPEN_DELEGATE_NONE_EXT = 3
+)
+
+// why_no_delegation4 values returned with OPEN_DELEGATE_NONE_EXT
+// (RFC 8881 Section 18.16.3).
+const (
+	WND4_NOT_WANTED                 = 0
+	WND4_CONTENTION                 = 1
+	WND4_RESOURCE                   = 2
+	WND4_NOT_SUPP_FTYPE             = 3
+	WND4_WRITE_DELEG_NOT_SUPP_FTYPE = 4
+	WND4_NOT_SUPP_UPGRADE           = 5
+	WND4_NOT_SUPP_DOWNGRADE         = 6
+	WND4_CANCELLED                  = 7
+	WND4_IS_DIR                     = 8
 )
 
 // ============================================================================
@@ -637,6 +673,7 @@ const (
 	LAYOUT4_NFSV4_1_FILES = 1
 	LAYOUT4_OSD2_OBJECTS  = 2
 	LAYOUT4_BLOCK_VOLUME  = 3
+	LAYOUT4_FLEX_FILES    = 4 // RFC 8435
 )
 
 // ============================================================================
@@ -87,9 +87,9 @@ func TestExchangeIdArgs_RoundTrip_MachCred(t *testing.T) {
 		ClientOwner: ValidClientOwner(),
 		Flags:       EXCHGID4_FLAG_USE_NON_PNFS,
 		StateProtect: StateProtect4A{
-			How:        SP4_MACH_CRED,
-			MachOps:    Bitmap4{0x00000003, 0x00000001},
-			EnforceOps: Bitmap4{0x00000002},
+			How:         SP4_MACH_CRED,
+			MustEnforce: Bitmap4{0x00000003, 0x00000001},
+			MustAllow:   Bitmap4{0x00000002},
 		},
 		ClientImplId: []NfsImplId4{ValidNfsImplId()},
 	}
@@ -107,20 +107,20 @@ func TestExchangeIdArgs_RoundTrip_MachCred(t *testing.T) {
 	if decoded.StateProtect.How != SP4_MACH_CRED {
 		t.Errorf("StateProtect.How = %d, want %d", decoded.StateProtect.How, SP4_MACH_CRED)
 	}
-	if len(decoded.StateProtect.MachOps) != 2 {
-		t.Fatalf("MachOps length = %d, want 2", len(decoded.StateProtect.MachOps))
+	if len(decoded.StateProtect.MustEnforce) != 2 {
+		t.Fatalf("MustEnforce length = %d, want 2", len(decoded.StateProtect.MustEnforce))
 	}
-	if decoded.StateProtect.MachOps[0] != 0x00000003 {
-		t.Errorf("MachOps[0] = 0x%x, want 0x00000003", decoded.StateProtect.MachOps[0])
+	if decoded.StateProtect.MustEnforce[0] != 0x00000003 {
+		t.Errorf("MustEnforce[0] = 0x%x, want 0x00000003", decoded.StateProtect.MustEnforce[0])
 	}
-	if decoded.StateProtect.MachOps[1] != 0x00000001 {
-		t.Errorf("MachOps[1] = 0x%x, want 0x00000001", decoded.StateProtect.MachOps[1])
+	if decoded.StateProtect.MustEnforce[1] != 0x00000001 {
+		t.Errorf("MustEnforce[1] = 0x%x, want 0x00000001", decoded.StateProtect.MustEnforce[1])
 	}
-	if len(decoded.StateProtect.EnforceOps) != 1 {
-		t.Fatalf("EnforceOps length = %d, want 1", len(decoded.StateProtect.EnforceOps))
+	if len(decoded.StateProtect.MustAllow) != 1 {
+		t.Fatalf("MustAllow length = %d, want 1", len(decoded.StateProtect.MustAllow))
 	}
-	if decoded.StateProtect.EnforceOps[0] != 0x00000002 {
-		t.Errorf("EnforceOps[0] = 0x%x, want 0x00000002", decoded.StateProtect.EnforceOps[0])
+	if decoded.StateProtect.MustAllow[0] != 0x00000002 {
+		t.Errorf("MustAllow[0] = 0x%x, want 0x00000002", decoded.StateProtect.MustAllow[0])
 	}
 }
 
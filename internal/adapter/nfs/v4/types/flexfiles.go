@@ -0,0 +1,338 @@
+// Package types - pNFS Flexible Files layout types (RFC 8435).
+//
+// The Flexible Files layout lets a client read and write a file directly on
+// one or more data servers, addressed over plain NFSv3 or NFSv4.x. The
+// metadata server hands out ff_layout4 bodies in LAYOUTGET and
+// ff_device_addr4 bodies in GETDEVICEINFO; both travel as opaque
+// layout-type-specific data inside the generic NFSv4.1 pNFS structures.
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr/core"
+)
+
+// Flexible Files layout flags (RFC 8435 Section 5.1, ffl_flags4).
+const (
+	FF_FLAGS_NO_LAYOUTCOMMIT  = 0x00000001
+	FF_FLAGS_NO_IO_THRU_MDS   = 0x00000002
+	FF_FLAGS_NO_READ_IO       = 0x00000004
+	FF_FLAGS_WRITE_ONE_MIRROR = 0x00000008
+)
+
+// Decode limits for counted arrays, guarding against hostile lengths.
+const (
+	ffMaxDecodeEntries         = 256
+	ffMaxDecodeFileHandleCount = 16
+)
+
+// ============================================================================
+// netaddr4 (RFC 8881 Section 3.3.9)
+// ============================================================================
+
+// NetAddr4 is a network address in universal address form.
+//
+//	struct netaddr4 {
+//	    string na_r_netid<>;  /* "tcp" or "tcp6" */
+//	    string na_r_addr<>;   /* "h1.h2.h3.h4.p1.p2" */
+//	};
+type NetAddr4 struct {
+	Netid string
+	Addr  string
+}
+
+// Encode writes a NetAddr4 in XDR format.
+func (n *NetAddr4) Encode(buf *bytes.Buffer) error {
+	if err := xdr.WriteXDRString(buf, n.Netid); err != nil {
+		return fmt.Errorf("encode netaddr netid: %w", err)
+	}
+	if err := xdr.WriteXDRString(buf, n.Addr); err != nil {
+		return fmt.Errorf("encode netaddr addr: %w", err)
+	}
+	return nil
+}
+
+// Decode reads a NetAddr4 from XDR format.
+func (n *NetAddr4) Decode(r io.Reader) error {
+	var err error
+	if n.Netid, err = xdr.DecodeString(r); err != nil {
+		return fmt.Errorf("decode netaddr netid: %w", err)
+	}
+	if n.Addr, err = xdr.DecodeString(r); err != nil {
+		return fmt.Errorf("decode netaddr addr: %w", err)
+	}
+	return nil
+}
+
+// ============================================================================
+// ff_device_addr4 (RFC 8435 Section 4.1)
+// ============================================================================
+
+// FFDeviceVersion4 is one protocol version a data server speaks.
+//
+//	struct ff_device_versions4 {
+//	    uint32_t ffdv_version;
+//	    uint32_t ffdv_minorversion;
+//	    uint32_t ffdv_rsize;
+//	    uint32_t ffdv_wsize;
+//	    bool     ffdv_tightly_coupled;
+//	};
+type FFDeviceVersion4 struct {
+	Version        uint32
+	MinorVersion   uint32
+	RSize          uint32
+	WSize          uint32
+	TightlyCoupled bool
+}
+
+// FFDeviceAddr4 is the GETDEVICEINFO address body of a Flexible Files device.
+//
+//	struct ff_device_addr4 {
+//	    multipath_list4     ffda_netaddrs;   /* netaddr4<> */
+//	    ff_device_versions4 ffda_versions<>;
+//	};
+type FFDeviceAddr4 struct {
+	NetAddrs []NetAddr4
+	Versions []FFDeviceVersion4
+}
+
+// Encode writes an FFDeviceAddr4 in XDR format.
+func (d *FFDeviceAddr4) Encode(buf *bytes.Buffer) error {
+	if err := xdr.WriteUint32(buf, uint32(len(d.NetAddrs))); err != nil {
+		return fmt.Errorf("encode ff_device_addr netaddrs count: %w", err)
+	}
+	for i := range d.NetAddrs {
+		if err := d.NetAddrs[i].Encode(buf); err != nil {
+			return fmt.Errorf("encode ff_device_addr netaddr[%d]: %w", i, err)
+		}
+	}
+	if err := xdr.WriteUint32(buf, uint32(len(d.Versions))); err != nil {
+		return fmt.Errorf("encode ff_device_addr versions count: %w", err)
+	}
+	for i := range d.Versions {
+		v := &d.Versions[i]
+		for _, field := range []uint32{v.Version, v.MinorVersion, v.RSize, v.WSize} {
+			if err := xdr.WriteUint32(buf, field); err != nil {
+				return fmt.Errorf("encode ff_device_addr version[%d]: %w", i, err)
+			}
+		}
+		if err := xdr.WriteBool(buf, v.TightlyCoupled); err != nil {
+			return fmt.Errorf("encode ff_device_addr version[%d] tightly_coupled: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Decode reads an FFDeviceAddr4 from XDR format.
+func (d *FFDeviceAddr4) Decode(r io.Reader) error {
+	count, err := xdr.DecodeUint32(r)
+	if err != nil {
+		return fmt.Errorf("decode ff_device_addr netaddrs count: %w", err)
+	}
+	if count > ffMaxDecodeEntries {
+		return fmt.Errorf("ff_device_addr netaddrs count %d exceeds limit", count)
+	}
+	d.NetAddrs = make([]NetAddr4, count)
+	for i := range d.NetAddrs {
+		if err := d.NetAddrs[i].Decode(r); err != nil {
+			return fmt.Errorf("decode ff_device_addr netaddr[%d]: %w", i, err)
+		}
+	}
+	if count, err = xdr.DecodeUint32(r); err != nil {
+		return fmt.Errorf("decode ff_device_addr versions count: %w", err)
+	}
+	if count > ffMaxDecodeEntries {
+		return fmt.Errorf("ff_device_addr versions count %d exceeds limit", count)
+	}
+	d.Versions = make([]FFDeviceVersion4, count)
+	for i := range d.Versions {
+		v := &d.Versions[i]
+		for _, field := range []*uint32{&v.Version, &v.MinorVersion, &v.RSize, &v.WSize} {
+			if *field, err = xdr.DecodeUint32(r); err != nil {
+				return fmt.Errorf("decode ff_device_addr version[%d]: %w", i, err)
+			}
+		}
+		if v.TightlyCoupled, err = xdr.DecodeBool(r); err != nil {
+			return fmt.Errorf("decode ff_device_addr version[%d] tightly_coupled: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// ff_layout4 (RFC 8435 Section 5.1)
+// ============================================================================
+
+// FFDataServer4 tells the client how to reach one data server for a mirror.
+//
+//	struct ff_data_server4 {
+//	    deviceid4          ffds_deviceid;
+//	    uint32_t           ffds_efficiency;
+//	    stateid4           ffds_stateid;
+//	    nfs_fh4            ffds_fh_vers<>;
+//	    fattr4_owner       ffds_user;
+//	    fattr4_owner_group ffds_group;
+//	};
+//
+// For loosely coupled data servers, User and Group are the synthetic AUTH_SYS
+// credentials the client presents to the data server.
+type FFDataServer4 struct {
+	DeviceID   DeviceId4
+	Efficiency uint32
+	Stateid    Stateid4
+	FHVersions [][]byte
+	User       string
+	Group      string
+}
+
+// FFMirror4 is one copy of the file; its data servers stripe the file.
+//
+//	struct ff_mirror4 {
+//	    ff_data_server4 ffm_data_servers<>;
+//	};
+type FFMirror4 struct {
+	DataServers []FFDataServer4
+}
+
+// FFLayout4 is the LAYOUTGET body of a Flexible Files layout.
+//
+//	struct ff_layout4 {
+//	    length4     ffl_stripe_unit;
+//	    ff_mirror4  ffl_mirrors<>;
+//	    ffl_flags4  ffl_flags;
+//	    uint32_t    ffl_stats_collect_hint;
+//	};
+type FFLayout4 struct {
+	StripeUnit       uint64
+	Mirrors          []FFMirror4
+	Flags            uint32
+	StatsCollectHint uint32
+}
+
+// Encode writes an FFLayout4 in XDR format.
+func (l *FFLayout4) Encode(buf *bytes.Buffer) error {
+	if err := xdr.WriteUint64(buf, l.StripeUnit); err != nil {
+		return fmt.Errorf("encode ff_layout stripe_unit: %w", err)
+	}
+	if err := xdr.WriteUint32(buf, uint32(len(l.Mirrors))); err != nil {
+		return fmt.Errorf("encode ff_layout mirrors count: %w", err)
+	}
+	for i := range l.Mirrors {
+		servers := l.Mirrors[i].DataServers
+		if err := xdr.WriteUint32(buf, uint32(len(servers))); err != nil {
+			return fmt.Errorf("encode ff_layout mirror[%d] count: %w", i, err)
+		}
+		for j := range servers {
+			if err := servers[j].encode(buf); err != nil {
+				return fmt.Errorf("encode ff_layout mirror[%d] ds[%d]: %w", i, j, err)
+			}
+		}
+	}
+	if err := xdr.WriteUint32(buf, l.Flags); err != nil {
+		return fmt.Errorf("encode ff_layout flags: %w", err)
+	}
+	if err := xdr.WriteUint32(buf, l.StatsCollectHint); err != nil {
+		return fmt.Errorf("encode ff_layout stats_collect_hint: %w", err)
+	}
+	return nil
+}
+
+// Decode reads an FFLayout4 from XDR format.
+func (l *FFLayout4) Decode(r io.Reader) error {
+	var err error
+	if l.StripeUnit, err = xdr.DecodeUint64(r); err != nil {
+		return fmt.Errorf("decode ff_layout stripe_unit: %w", err)
+	}
+	count, err := xdr.DecodeUint32(r)
+	if err != nil {
+		return fmt.Errorf("decode ff_layout mirrors count: %w", err)
+	}
+	if count > ffMaxDecodeEntries {
+		return fmt.Errorf("ff_layout mirrors count %d exceeds limit", count)
+	}
+	l.Mirrors = make([]FFMirror4, count)
+	for i := range l.Mirrors {
+		n, err := xdr.DecodeUint32(r)
+		if err != nil {
+			return fmt.Errorf("decode ff_layout mirror[%d] count: %w", i, err)
+		}
+		if n > ffMaxDecodeEntries {
+			return fmt.Errorf("ff_layout mirror[%d] count %d exceeds limit", i, n)
+		}
+		l.Mirrors[i].DataServers = make([]FFDataServer4, n)
+		for j := range l.Mirrors[i].DataServers {
+			if err := l.Mirrors[i].DataServers[j].decode(r); err != nil {
+				return fmt.Errorf("decode ff_layout mirror[%d] ds[%d]: %w", i, j, err)
+			}
+		}
+	}
+	if l.Flags, err = xdr.DecodeUint32(r); err != nil {
+		return fmt.Errorf("decode ff_layout flags: %w", err)
+	}
+	if l.StatsCollectHint, err = xdr.DecodeUint32(r); err != nil {
+		return fmt.Errorf("decode ff_layout stats_collect_hint: %w", err)
+	}
+	return nil
+}
+
+func (ds *FFDataServer4) encode(buf *bytes.Buffer) error {
+	// Fixed 16-byte opaque, no length prefix
+	buf.Write(ds.DeviceID[:])
+	if err := xdr.WriteUint32(buf, ds.Efficiency); err != nil {
+		return fmt.Errorf("efficiency: %w", err)
+	}
+	EncodeStateid4(buf, &ds.Stateid)
+	if err := xdr.WriteUint32(buf, uint32(len(ds.FHVersions))); err != nil {
+		return fmt.Errorf("fh_vers count: %w", err)
+	}
+	for i := range ds.FHVersions {
+		if err := xdr.WriteXDROpaque(buf, ds.FHVersions[i]); err != nil {
+			return fmt.Errorf("fh_vers[%d]: %w", i, err)
+		}
+	}
+	if err := xdr.WriteXDRString(buf, ds.User); err != nil {
+		return fmt.Errorf("user: %w", err)
+	}
+	if err := xdr.WriteXDRString(buf, ds.Group); err != nil {
+		return fmt.Errorf("group: %w", err)
+	}
+	return nil
+}
+
+func (ds *FFDataServer4) decode(r io.Reader) error {
+	if _, err := io.ReadFull(r, ds.DeviceID[:]); err != nil {
+		return fmt.Errorf("deviceid: %w", err)
+	}
+	var err error
+	if ds.Efficiency, err = xdr.DecodeUint32(r); err != nil {
+		return fmt.Errorf("efficiency: %w", err)
+	}
+	sid, err := DecodeStateid4(r)
+	if err != nil {
+		return fmt.Errorf("stateid: %w", err)
+	}
+	ds.Stateid = *sid
+	count, err := xdr.DecodeUint32(r)
+	if err != nil {
+		return fmt.Errorf("fh_vers count: %w", err)
+	}
+	if count > ffMaxDecodeFileHandleCount {
+		return fmt.Errorf("fh_vers count %d exceeds limit", count)
+	}
+	ds.FHVersions = make([][]byte, count)
+	for i := range ds.FHVersions {
+		if ds.FHVersions[i], err = xdr.DecodeOpaque(r); err != nil {
+			return fmt.Errorf("fh_vers[%d]: %w", i, err)
+		}
+	}
+	if ds.User, err = xdr.DecodeString(r); err != nil {
+		return fmt.Errorf("user: %w", err)
+	}
+	if ds.Group, err = xdr.DecodeString(r); err != nil {
+		return fmt.Errorf("group: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestFFDeviceAddr4_RoundTrip(t *testing.T) {
+	original := FFDeviceAddr4{
+		NetAddrs: []NetAddr4{
+			{Netid: "tcp", Addr: "10.0.0.5.8.1"},
+			{Netid: "tcp6", Addr: "fd00::5.8.1"},
+		},
+		Versions: []FFDeviceVersion4{
+			{Version: 3, MinorVersion: 0, RSize: 1048576, WSize: 1048576, TightlyCoupled: false},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := original.Encode(&buf); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	var decoded FFDeviceAddr4
+	if err := decoded.Decode(&buf); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, original) {
+		t.Errorf("round trip mismatch:\n got  %+v\n want %+v", decoded, original)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("trailing bytes after decode: %d", buf.Len())
+	}
+}
+
+func TestFFLayout4_RoundTrip(t *testing.T) {
+	original := FFLayout4{
+		StripeUnit: 0,
+		Mirrors: []FFMirror4{{
+			DataServers: []FFDataServer4{{
+				DeviceID:   DeviceId4{0xde, 0xad, 0xbe, 0xef},
+				Efficiency: 1,
+				Stateid:    ValidStateid(),
+				FHVersions: [][]byte{{0x01, 0x02, 0x03}},
+				User:       "1000",
+				Group:      "1000",
+			}},
+		}},
+		Flags:            FF_FLAGS_NO_IO_THRU_MDS,
+		StatsCollectHint: 0,
+	}
+
+	var buf bytes.Buffer
+	if err := original.Encode(&buf); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	var decoded FFLayout4
+	if err := decoded.Decode(&buf); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, original) {
+		t.Errorf("round trip mismatch:\n got  %+v\n want %+v", decoded, original)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("trailing bytes after decode: %d", buf.Len())
+	}
+}
+
+func TestFFLayout4_Decode_RejectsHugeMirrorCount(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0}) // stripe unit
+	buf.Write([]byte{0xff, 0xff, 0xff, 0xff}) // mirror count
+
+	var decoded FFLayout4
+	if err := decoded.Decode(&buf); err == nil {
+		t.Fatal("expected error for oversized mirror count")
+	}
+}
@@ -2,7 +2,6 @@
 //
 // GETDEVICEINFO returns the mapping of a device ID to its storage device
 // address. Used in pNFS to resolve data server locations.
-// Included for wire compatibility; pNFS is out of scope for v3.0.
 package types
 
 import (
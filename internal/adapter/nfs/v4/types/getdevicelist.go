@@ -1,7 +1,7 @@
 // Package types - GETDEVICELIST operation types (RFC 8881 Section 18.41).
 //
 // GETDEVICELIST returns a list of device IDs for a given layout type.
-// Included for wire compatibility; pNFS is out of scope for v3.0.
+// Used by the pNFS Flexible Files layout (see flexfiles.go).
 package types
 
 import (
@@ -2,7 +2,7 @@
 //
 // LAYOUTCOMMIT commits data written through a layout. It informs the metadata
 // server about the regions that were written so it can update file metadata.
-// Included for wire compatibility; pNFS is out of scope for v3.0.
+// Used by the pNFS Flexible Files layout (see flexfiles.go).
 package types
 
 import (
@@ -2,7 +2,7 @@
 //
 // LAYOUTGET requests layout information for a file from the metadata server.
 // The layout describes how to access the file's data directly from data servers
-// (pNFS). Included for wire compatibility; pNFS is out of scope for v3.0.
+// (pNFS). DittoFS serves the Flexible Files layout type (see flexfiles.go).
 package types
 
 import (
@@ -17,6 +17,7 @@ import (
 const (
 	LAYOUTIOMODE4_READ = 1
 	LAYOUTIOMODE4_RW   = 2
+	LAYOUTIOMODE4_ANY  = 3 // LAYOUTRETURN and CB_LAYOUTRECALL only
 )
 
 // ============================================================================
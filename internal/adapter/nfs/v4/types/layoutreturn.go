@@ -2,7 +2,7 @@
 //
 // LAYOUTRETURN returns layout segments to the metadata server.
 // The return can be for a specific file, an entire filesystem, or all layouts.
-// Included for wire compatibility; pNFS is out of scope for v3.0.
+// Used by the pNFS Flexible Files layout (see flexfiles.go).
 package types
 
 import (
@@ -91,6 +91,21 @@ func (b *Bitmap4) Decode(r io.Reader) error {
 	return nil
 }
 
+// IsSet reports whether bit is set. Bits beyond the bitmap's length are unset.
+func (b Bitmap4) IsSet(bit uint32) bool {
+	word := bit / 32
+	return int(word) < len(b) && b[word]&(1<<(bit%32)) != 0
+}
+
+// Set sets bit, growing the bitmap as needed.
+func (b *Bitmap4) Set(bit uint32) {
+	word := int(bit / 32)
+	for len(*b) <= word {
+		*b = append(*b, 0)
+	}
+	(*b)[word] |= 1 << (bit % 32)
+}
+
 // String returns the bitmap as a hex representation.
 func (b *Bitmap4) String() string {
 	if b == nil || len(*b) == 0 {
@@ -350,9 +365,9 @@ func (c *ChannelAttrs) String() string {
 type StateProtect4A struct {
 	How uint32 // SP4_NONE, SP4_MACH_CRED, or SP4_SSV
 
-	// SP4_MACH_CRED fields
-	MachOps    Bitmap4 // operations protected by RPCSEC_GSS machine cred
-	EnforceOps Bitmap4 // operations that MUST use machine cred
+	// SP4_MACH_CRED fields (spo_must_enforce, spo_must_allow)
+	MustEnforce Bitmap4 // operations that MUST use the machine credential
+	MustAllow   Bitmap4 // operations the machine credential MAY use on any state
 
 	// SP4_SSV fields (stored as raw bytes - full SSV is out of scope)
 	SsvOps []byte
@@ -367,10 +382,10 @@ func (s *StateProtect4A) Encode(buf *bytes.Buffer) error {
 	case SP4_NONE:
 		// void
 	case SP4_MACH_CRED:
-		if err := s.MachOps.Encode(buf); err != nil {
+		if err := s.MustEnforce.Encode(buf); err != nil {
 			return fmt.Errorf("encode mach_ops: %w", err)
 		}
-		if err := s.EnforceOps.Encode(buf); err != nil {
+		if err := s.MustAllow.Encode(buf); err != nil {
 			return fmt.Errorf("encode enforce_ops: %w", err)
 		}
 	case SP4_SSV:
@@ -394,10 +409,10 @@ func (s *StateProtect4A) Decode(r io.Reader) error {
 	case SP4_NONE:
 		// void
 	case SP4_MACH_CRED:
-		if err := s.MachOps.Decode(r); err != nil {
+		if err := s.MustEnforce.Decode(r); err != nil {
 			return fmt.Errorf("decode mach_ops: %w", err)
 		}
-		if err := s.EnforceOps.Decode(r); err != nil {
+		if err := s.MustAllow.Decode(r); err != nil {
 			return fmt.Errorf("decode enforce_ops: %w", err)
 		}
 	case SP4_SSV:
@@ -418,8 +433,8 @@ func (s *StateProtect4A) String() string {
 	case SP4_NONE:
 		return "StateProtect4A{SP4_NONE}"
 	case SP4_MACH_CRED:
-		return fmt.Sprintf("StateProtect4A{SP4_MACH_CRED, mach=%s, enforce=%s}",
-			s.MachOps.String(), s.EnforceOps.String())
+		return fmt.Sprintf("StateProtect4A{SP4_MACH_CRED, enforce=%s, allow=%s}",
+			s.MustEnforce.String(), s.MustAllow.String())
 	case SP4_SSV:
 		return fmt.Sprintf("StateProtect4A{SP4_SSV, ops=%d bytes}", len(s.SsvOps))
 	default:
@@ -436,9 +451,9 @@ func (s *StateProtect4A) String() string {
 type StateProtect4R struct {
 	How uint32 // SP4_NONE, SP4_MACH_CRED, or SP4_SSV
 
-	// SP4_MACH_CRED fields
-	MachOps    Bitmap4
-	EnforceOps Bitmap4
+	// SP4_MACH_CRED fields (spr_must_enforce, spr_must_allow)
+	MustEnforce Bitmap4
+	MustAllow   Bitmap4
 
 	// SP4_SSV fields (stored as raw bytes - full SSV is out of scope)
 	SsvInfo []byte
@@ -453,10 +468,10 @@ func (s *StateProtect4R) Encode(buf *bytes.Buffer) error {
 	case SP4_NONE:
 		// void
 	case SP4_MACH_CRED:
-		if err := s.MachOps.Encode(buf); err != nil {
+		if err := s.MustEnforce.Encode(buf); err != nil {
 			return fmt.Errorf("encode mach_ops: %w", err)
 		}
-		if err := s.EnforceOps.Encode(buf); err != nil {
+		if err := s.MustAllow.Encode(buf); err != nil {
 			return fmt.Errorf("encode enforce_ops: %w", err)
 		}
 	case SP4_SSV:
@@ -480,10 +495,10 @@ func (s *StateProtect4R) Decode(r io.Reader) error {
 	case SP4_NONE:
 		// void
 	case SP4_MACH_CRED:
-		if err := s.MachOps.Decode(r); err != nil {
+		if err := s.MustEnforce.Decode(r); err != nil {
 			return fmt.Errorf("decode mach_ops: %w", err)
 		}
-		if err := s.EnforceOps.Decode(r); err != nil {
+		if err := s.MustAllow.Decode(r); err != nil {
 			return fmt.Errorf("decode enforce_ops: %w", err)
 		}
 	case SP4_SSV:
@@ -504,8 +519,8 @@ func (s *StateProtect4R) String() string {
 	case SP4_NONE:
 		return "StateProtect4R{SP4_NONE}"
 	case SP4_MACH_CRED:
-		return fmt.Sprintf("StateProtect4R{SP4_MACH_CRED, mach=%s, enforce=%s}",
-			s.MachOps.String(), s.EnforceOps.String())
+		return fmt.Sprintf("StateProtect4R{SP4_MACH_CRED, enforce=%s, allow=%s}",
+			s.MustEnforce.String(), s.MustAllow.String())
 	case SP4_SSV:
 		return fmt.Sprintf("StateProtect4R{SP4_SSV, info=%d bytes}", len(s.SsvInfo))
 	default:
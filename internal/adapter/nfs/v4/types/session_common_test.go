@@ -306,9 +306,9 @@ func TestStateProtect4A_RoundTrip_None(t *testing.T) {
 
 func TestStateProtect4A_RoundTrip_MachCred(t *testing.T) {
 	original := StateProtect4A{
-		How:        SP4_MACH_CRED,
-		MachOps:    Bitmap4{0x00000003, 0x00000001},
-		EnforceOps: Bitmap4{0x00000001},
+		How:         SP4_MACH_CRED,
+		MustEnforce: Bitmap4{0x00000003, 0x00000001},
+		MustAllow:   Bitmap4{0x00000001},
 	}
 
 	var buf bytes.Buffer
@@ -324,14 +324,14 @@ func TestStateProtect4A_RoundTrip_MachCred(t *testing.T) {
 	if decoded.How != SP4_MACH_CRED {
 		t.Errorf("How = %d, want %d (SP4_MACH_CRED)", decoded.How, SP4_MACH_CRED)
 	}
-	if len(decoded.MachOps) != 2 {
-		t.Fatalf("MachOps length = %d, want 2", len(decoded.MachOps))
+	if len(decoded.MustEnforce) != 2 {
+		t.Fatalf("MustEnforce length = %d, want 2", len(decoded.MustEnforce))
 	}
-	if decoded.MachOps[0] != 0x00000003 || decoded.MachOps[1] != 0x00000001 {
-		t.Errorf("MachOps = %v, want [3 1]", decoded.MachOps)
+	if decoded.MustEnforce[0] != 0x00000003 || decoded.MustEnforce[1] != 0x00000001 {
+		t.Errorf("MustEnforce = %v, want [3 1]", decoded.MustEnforce)
 	}
-	if len(decoded.EnforceOps) != 1 || decoded.EnforceOps[0] != 0x00000001 {
-		t.Errorf("EnforceOps = %v, want [1]", decoded.EnforceOps)
+	if len(decoded.MustAllow) != 1 || decoded.MustAllow[0] != 0x00000001 {
+		t.Errorf("MustAllow = %v, want [1]", decoded.MustAllow)
 	}
 }
 
@@ -359,9 +359,9 @@ func TestStateProtect4R_RoundTrip_None(t *testing.T) {
 
 func TestStateProtect4R_RoundTrip_MachCred(t *testing.T) {
 	original := StateProtect4R{
-		How:        SP4_MACH_CRED,
-		MachOps:    Bitmap4{0xdeadbeef},
-		EnforceOps: Bitmap4{0xcafebabe},
+		How:         SP4_MACH_CRED,
+		MustEnforce: Bitmap4{0xdeadbeef},
+		MustAllow:   Bitmap4{0xcafebabe},
 	}
 
 	var buf bytes.Buffer
@@ -377,11 +377,11 @@ func TestStateProtect4R_RoundTrip_MachCred(t *testing.T) {
 	if decoded.How != SP4_MACH_CRED {
 		t.Errorf("How = %d, want %d", decoded.How, SP4_MACH_CRED)
 	}
-	if len(decoded.MachOps) != 1 || decoded.MachOps[0] != 0xdeadbeef {
-		t.Errorf("MachOps = %v, want [0xdeadbeef]", decoded.MachOps)
+	if len(decoded.MustEnforce) != 1 || decoded.MustEnforce[0] != 0xdeadbeef {
+		t.Errorf("MustEnforce = %v, want [0xdeadbeef]", decoded.MustEnforce)
 	}
-	if len(decoded.EnforceOps) != 1 || decoded.EnforceOps[0] != 0xcafebabe {
-		t.Errorf("EnforceOps = %v, want [0xcafebabe]", decoded.EnforceOps)
+	if len(decoded.MustAllow) != 1 || decoded.MustAllow[0] != 0xcafebabe {
+		t.Errorf("MustAllow = %v, want [0xcafebabe]", decoded.MustAllow)
 	}
 }
 
@@ -112,6 +112,11 @@ type CompoundContext struct {
 	// GIDs contains supplementary group IDs from AUTH_UNIX credentials.
 	GIDs []uint32
 
+	// GSSPrincipal is the authenticated RPCSEC_GSS principal
+	// ("nfs/host.example.com@EXAMPLE.COM"). Empty for other flavors. It is
+	// the credential SP4_MACH_CRED state protection compares against.
+	GSSPrincipal string
+
 	// Context is the Go context for cancellation and timeout control.
 	Context context.Context
 
@@ -312,6 +317,9 @@ type V41RequestContext struct {
 	// SessionID is the NFSv4.1 session identifier.
 	SessionID SessionId4
 
+	// ClientID is the client that owns the session.
+	ClientID uint64
+
 	// SlotID identifies the slot within the session.
 	SlotID uint32
 
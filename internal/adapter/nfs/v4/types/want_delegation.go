@@ -91,24 +91,24 @@ func (a *WantDelegationArgs) String() string {
 
 // WantDelegationRes represents WANT_DELEGATION4res per RFC 8881 Section 18.49.
 //
-// The result is a union switched on delegation_type:
+// On NFS4_OK the result is an open_delegation4 union; DelegationData holds
+// the XDR body of the arm selected by DelegationType (empty for
+// OPEN_DELEGATE_NONE):
 //
-//   - OPEN_DELEGATE_NONE: void (just status)
-//
-//   - OPEN_DELEGATE_READ/WRITE: delegation struct (stored as raw opaque)
-//
-//     union open_delegation4 switch (open_delegation_type4 delegation_type) {
-//     case OPEN_DELEGATE_NONE:
-//     void;
-//     case OPEN_DELEGATE_READ:
-//     open_read_delegation4 read;
-//     case OPEN_DELEGATE_WRITE:
-//     open_write_delegation4 write;
-//     };
+//	union open_delegation4 switch (open_delegation_type4 delegation_type) {
+//	case OPEN_DELEGATE_NONE:
+//	        void;
+//	case OPEN_DELEGATE_READ:
+//	        open_read_delegation4 read;
+//	case OPEN_DELEGATE_WRITE:
+//	        open_write_delegation4 write;
+//	case OPEN_DELEGATE_NONE_EXT:
+//	        open_none_delegation4 od_whynone;
+//	};
 type WantDelegationRes struct {
 	Status         uint32
-	DelegationType uint32 // OPEN_DELEGATE_NONE/READ/WRITE (only if NFS4_OK)
-	DelegationData []byte // raw opaque delegation body (only if READ/WRITE)
+	DelegationType uint32 // OPEN_DELEGATE_NONE/READ/WRITE/NONE_EXT (only if NFS4_OK)
+	DelegationData []byte // raw XDR delegation body (only if not NONE)
 }
 
 // Encode writes the WANT_DELEGATION result in XDR format.
@@ -120,7 +120,7 @@ func (res *WantDelegationRes) Encode(buf *bytes.Buffer) error {
 		if err := xdr.WriteUint32(buf, res.DelegationType); err != nil {
 			return fmt.Errorf("encode want_delegation delegation_type: %w", err)
 		}
-		if res.DelegationType == OPEN_DELEGATE_READ || res.DelegationType == OPEN_DELEGATE_WRITE {
+		if res.DelegationType != OPEN_DELEGATE_NONE {
 			if _, err := buf.Write(res.DelegationData); err != nil {
 				return fmt.Errorf("encode want_delegation delegation_data: %w", err)
 			}
@@ -137,13 +137,12 @@ func (res *WantDelegationRes) Decode(r io.Reader) error {
 	}
 	res.Status = status
 	if res.Status == NFS4_OK {
-		delegType, err := xdr.DecodeUint32(r)
+		delegType, body, err := DecodeOpenDelegation4(r)
 		if err != nil {
-			return fmt.Errorf("decode want_delegation delegation_type: %w", err)
+			return fmt.Errorf("decode want_delegation delegation: %w", err)
 		}
 		res.DelegationType = delegType
-		// Delegation body is consumed by the handler for READ/WRITE types;
-		// at the type level, we stop after the discriminant for NONE.
+		res.DelegationData = body
 	}
 	return nil
 }
@@ -157,9 +156,90 @@ func (res *WantDelegationRes) String() string {
 			typeName = "READ"
 		case OPEN_DELEGATE_WRITE:
 			typeName = "WRITE"
+		case OPEN_DELEGATE_NONE_EXT:
+			typeName = "NONE_EXT"
 		}
 		return fmt.Sprintf("WantDelegationRes{status=OK, deleg=%s, data=%d bytes}",
 			typeName, len(res.DelegationData))
 	}
 	return fmt.Sprintf("WantDelegationRes{status=%d}", res.Status)
 }
+
+// ============================================================================
+// open_delegation4 (RFC 8881 Section 18.16.2)
+// ============================================================================
+
+// DecodeOpenDelegation4 reads one open_delegation4 union and returns its
+// discriminant together with the raw XDR bytes of the selected arm, so the
+// delegation can be carried verbatim by WANT_DELEGATION and CB_PUSH_DELEG.
+func DecodeOpenDelegation4(r io.Reader) (uint32, []byte, error) {
+	delegType, err := xdr.DecodeUint32(r)
+	if err != nil {
+		return 0, nil, fmt.Errorf("delegation_type: %w", err)
+	}
+
+	var body bytes.Buffer
+	tr := io.TeeReader(r, &body)
+
+	switch delegType {
+	case OPEN_DELEGATE_NONE:
+	case OPEN_DELEGATE_READ, OPEN_DELEGATE_WRITE:
+		if _, err := DecodeStateid4(tr); err != nil {
+			return 0, nil, fmt.Errorf("stateid: %w", err)
+		}
+		if _, err := xdr.DecodeBool(tr); err != nil {
+			return 0, nil, fmt.Errorf("recall: %w", err)
+		}
+		if delegType == OPEN_DELEGATE_WRITE {
+			if err := skipSpaceLimit4(tr); err != nil {
+				return 0, nil, err
+			}
+		}
+		// nfsace4 permissions: type, flag, access_mask, who
+		for i := 0; i < 3; i++ {
+			if _, err := xdr.DecodeUint32(tr); err != nil {
+				return 0, nil, fmt.Errorf("permissions: %w", err)
+			}
+		}
+		if _, err := xdr.DecodeString(tr); err != nil {
+			return 0, nil, fmt.Errorf("permissions who: %w", err)
+		}
+	case OPEN_DELEGATE_NONE_EXT:
+		why, err := xdr.DecodeUint32(tr)
+		if err != nil {
+			return 0, nil, fmt.Errorf("ond_why: %w", err)
+		}
+		if why == WND4_CONTENTION || why == WND4_RESOURCE {
+			if _, err := xdr.DecodeBool(tr); err != nil {
+				return 0, nil, fmt.Errorf("ond_server_will: %w", err)
+			}
+		}
+	default:
+		return 0, nil, fmt.Errorf("invalid delegation_type %d", delegType)
+	}
+	return delegType, body.Bytes(), nil
+}
+
+// skipSpaceLimit4 consumes an nfs_space_limit4 union.
+func skipSpaceLimit4(r io.Reader) error {
+	limitBy, err := xdr.DecodeUint32(r)
+	if err != nil {
+		return fmt.Errorf("space_limit limitby: %w", err)
+	}
+	switch limitBy {
+	case NFS_LIMIT_SIZE:
+		if _, err := xdr.DecodeUint64(r); err != nil {
+			return fmt.Errorf("space_limit filesize: %w", err)
+		}
+	case NFS_LIMIT_BLOCKS:
+		// nfs_modified_limit4: num_blocks, bytes_per_block
+		for i := 0; i < 2; i++ {
+			if _, err := xdr.DecodeUint32(r); err != nil {
+				return fmt.Errorf("space_limit mod_blocks: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid space_limit limitby %d", limitBy)
+	}
+	return nil
+}
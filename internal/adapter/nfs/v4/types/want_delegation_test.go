@@ -85,6 +85,61 @@ func TestWantDelegationRes_RoundTrip_OK_None(t *testing.T) {
 	}
 }
 
+func TestWantDelegationRes_RoundTrip_Read(t *testing.T) {
+	deleg := encodeTestReadDelegation()
+	original := WantDelegationRes{
+		Status:         NFS4_OK,
+		DelegationType: OPEN_DELEGATE_READ,
+		DelegationData: deleg[4:],
+	}
+
+	var buf bytes.Buffer
+	if err := original.Encode(&buf); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	var decoded WantDelegationRes
+	if err := decoded.Decode(&buf); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	if decoded.DelegationType != OPEN_DELEGATE_READ {
+		t.Errorf("DelegationType: got %d, want %d", decoded.DelegationType, OPEN_DELEGATE_READ)
+	}
+	if !bytes.Equal(decoded.DelegationData, original.DelegationData) {
+		t.Errorf("DelegationData: got %x, want %x", decoded.DelegationData, original.DelegationData)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("%d trailing bytes after decode", buf.Len())
+	}
+}
+
+func TestWantDelegationRes_RoundTrip_NoneExt(t *testing.T) {
+	// WND4_CONTENTION carries ond_server_will_push_deleg.
+	original := WantDelegationRes{
+		Status:         NFS4_OK,
+		DelegationType: OPEN_DELEGATE_NONE_EXT,
+		DelegationData: []byte{0, 0, 0, WND4_CONTENTION, 0, 0, 0, 1},
+	}
+
+	var buf bytes.Buffer
+	if err := original.Encode(&buf); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	var decoded WantDelegationRes
+	if err := decoded.Decode(&buf); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	if decoded.DelegationType != OPEN_DELEGATE_NONE_EXT {
+		t.Errorf("DelegationType: got %d, want %d", decoded.DelegationType, OPEN_DELEGATE_NONE_EXT)
+	}
+	if !bytes.Equal(decoded.DelegationData, original.DelegationData) {
+		t.Errorf("DelegationData: got %x, want %x", decoded.DelegationData, original.DelegationData)
+	}
+}
+
 func TestWantDelegationRes_RoundTrip_Error(t *testing.T) {
 	original := WantDelegationRes{Status: NFS4ERR_NOTSUPP}
 
@@ -48,6 +48,12 @@ func HandleBindConnToSession(
 		}
 	}
 
+	if sess := d.StateManager.GetSession(args.SessionID); sess != nil {
+		if res := checkMachCred(d, ctx, sess.ClientID, types.OP_BIND_CONN_TO_SESSION); res != nil {
+			return res
+		}
+	}
+
 	// Delegate to StateManager
 	result, err := d.StateManager.BindConnToSession(ctx.ConnectionID, args.SessionID, args.Dir)
 	if err != nil {
@@ -37,6 +37,10 @@ func HandleCreateSession(d *Deps, ctx *types.CompoundContext, _ *types.V41Reques
 		}
 	}
 
+	if res := checkMachCred(d, ctx, args.ClientID, types.OP_CREATE_SESSION); res != nil {
+		return res
+	}
+
 	// Delegate to StateManager for the multi-case algorithm
 	result, cachedReply, err := d.StateManager.CreateSession(
 		args.ClientID,
@@ -6,9 +6,11 @@ package v41handlers
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 
 	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/state"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
+	"github.com/marmos91/dittofs/internal/logger"
 )
 
 // Deps holds shared dependencies for v4.1 handlers.
@@ -35,7 +37,28 @@ func MapStateError(err error) uint32 {
 		return types.NFS4ERR_STALE_CLIENTID
 	case errors.Is(err, state.ErrClientIDInUse):
 		return types.NFS4ERR_CLID_INUSE
+	case errors.Is(err, state.ErrWrongCred):
+		return types.NFS4ERR_WRONG_CRED
 	default:
 		return types.NFS4ERR_SERVERFAULT
 	}
 }
+
+// checkMachCred enforces SP4_MACH_CRED protection of clientID for op. It
+// returns nil when the op may proceed, or a NFS4ERR_WRONG_CRED result when the
+// client is protected and the request is not from its machine credential.
+func checkMachCred(d *Deps, ctx *types.CompoundContext, clientID uint64, op uint32) *types.CompoundResult {
+	if err := d.StateManager.CheckMachCred(clientID, op, ctx.GSSPrincipal); err != nil {
+		logger.Debug("SP4_MACH_CRED: refusing op without machine credential",
+			"op", types.OpName(op),
+			"client_id", fmt.Sprintf("0x%016x", clientID),
+			"principal", ctx.GSSPrincipal,
+			"client", ctx.ClientAddr)
+		return &types.CompoundResult{
+			Status: types.NFS4ERR_WRONG_CRED,
+			OpCode: op,
+			Data:   EncodeStatusOnly(types.NFS4ERR_WRONG_CRED),
+		}
+	}
+	return nil
+}
@@ -65,6 +65,10 @@ func HandleDestroyClientID(
 		}
 	}
 
+	if res := checkMachCred(d, ctx, args.ClientID, types.OP_DESTROY_CLIENTID); res != nil {
+		return res
+	}
+
 	// Delegate to StateManager
 	err := d.StateManager.DestroyV41ClientID(args.ClientID)
 	if err != nil {
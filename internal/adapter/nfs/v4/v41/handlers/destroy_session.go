@@ -62,6 +62,9 @@ func HandleDestroySession(d *Deps, ctx *types.CompoundContext, v41ctx *types.V41
 				Data:   EncodeStatusOnly(types.NFS4ERR_NOT_SAME),
 			}
 		}
+		if res := checkMachCred(d, ctx, targetSess.ClientID, types.OP_DESTROY_SESSION); res != nil {
+			return res
+		}
 	}
 
 	// Delegate to StateManager
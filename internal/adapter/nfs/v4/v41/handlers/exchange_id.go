@@ -4,15 +4,21 @@ import (
 	"bytes"
 	"io"
 
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc/gss"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/state"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/types"
 	"github.com/marmos91/dittofs/internal/logger"
 )
 
 // HandleExchangeID implements the EXCHANGE_ID operation (RFC 8881 Section 18.35).
 // Registers a v4.1 client with the server and returns a client ID for session creation.
-// Delegates to StateManager.ExchangeID for multi-case algorithm logic; rejects SP4_MACH_CRED/SP4_SSV.
+// Delegates to StateManager.ExchangeIDMachCred for multi-case algorithm logic.
+// Supports SP4_NONE and SP4_MACH_CRED state protection; SP4_SSV is rejected.
 // Creates or updates client record; returns clientid, sequence ID, and server capabilities.
-// Errors: NFS4ERR_CLID_INUSE, NFS4ERR_ENCR_ALG_UNSUPP (non-SP4_NONE), NFS4ERR_BADXDR.
+// Errors: NFS4ERR_CLID_INUSE, NFS4ERR_ENCR_ALG_UNSUPP (SP4_SSV), NFS4ERR_INVAL
+// (SP4_MACH_CRED without RPCSEC_GSS integrity or privacy), NFS4ERR_WRONG_CRED
+// (protected client updated by another principal), NFS4ERR_BADXDR.
 func HandleExchangeID(d *Deps, ctx *types.CompoundContext, _ *types.V41RequestContext, reader io.Reader) *types.CompoundResult {
 	var args types.ExchangeIdArgs
 	if err := args.Decode(reader); err != nil {
@@ -24,9 +30,27 @@ func HandleExchangeID(d *Deps, ctx *types.CompoundContext, _ *types.V41RequestCo
 		}
 	}
 
-	// Reject SP4_MACH_CRED and SP4_SSV before any state allocation
-	if args.StateProtect.How != types.SP4_NONE {
-		logger.Debug("EXCHANGE_ID: rejecting non-SP4_NONE state protection",
+	// Validate state protection before any state allocation
+	var machCred *state.MachCredProtection
+	switch args.StateProtect.How {
+	case types.SP4_NONE:
+	case types.SP4_MACH_CRED:
+		// The machine credential must be an RPCSEC_GSS principal whose
+		// requests are integrity protected (RFC 8881 Section 18.35.4): an
+		// AUTH_SYS "credential" proves nothing, and without integrity the
+		// protected operations could be altered in flight.
+		if !machCredCapable(ctx) {
+			logger.Debug("EXCHANGE_ID: SP4_MACH_CRED requires RPCSEC_GSS with integrity or privacy",
+				"auth_flavor", ctx.AuthFlavor, "client", ctx.ClientAddr)
+			return &types.CompoundResult{
+				Status: types.NFS4ERR_INVAL,
+				OpCode: types.OP_EXCHANGE_ID,
+				Data:   EncodeStatusOnly(types.NFS4ERR_INVAL),
+			}
+		}
+		machCred = state.NegotiateMachCred(ctx.GSSPrincipal, &args.StateProtect)
+	default:
+		logger.Debug("EXCHANGE_ID: rejecting unsupported state protection",
 			"how", args.StateProtect.How, "client", ctx.ClientAddr)
 		return &types.CompoundResult{
 			Status: types.NFS4ERR_ENCR_ALG_UNSUPP,
@@ -44,13 +68,15 @@ func HandleExchangeID(d *Deps, ctx *types.CompoundContext, _ *types.V41RequestCo
 	}
 
 	// Delegate to StateManager for the multi-case algorithm
-	result, err := d.StateManager.ExchangeID(
+	result, err := d.StateManager.ExchangeIDMachCred(
 		args.ClientOwner.OwnerID,
 		args.ClientOwner.Verifier,
 		args.Flags,
 		args.ClientImplId,
 		ctx.ClientAddr,
 		ctx.Principal(),
+		ctx.GSSPrincipal,
+		machCred,
 	)
 	if err != nil {
 		nfsStatus := MapStateError(err)
@@ -64,13 +90,11 @@ func HandleExchangeID(d *Deps, ctx *types.CompoundContext, _ *types.V41RequestCo
 	}
 
 	res := &types.ExchangeIdRes{
-		Status:     types.NFS4_OK,
-		ClientID:   result.ClientID,
-		SequenceID: result.SequenceID,
-		Flags:      result.Flags,
-		StateProtect: types.StateProtect4R{
-			How: types.SP4_NONE,
-		},
+		Status:       types.NFS4_OK,
+		ClientID:     result.ClientID,
+		SequenceID:   result.SequenceID,
+		Flags:        result.Flags,
+		StateProtect: stateProtectResult(result.MachCred),
 		ServerOwner:  result.ServerOwner,
 		ServerScope:  result.ServerScope,
 		ServerImplId: result.ServerImplId,
@@ -98,3 +122,26 @@ func HandleExchangeID(d *Deps, ctx *types.CompoundContext, _ *types.V41RequestCo
 		Data:   buf.Bytes(),
 	}
 }
+
+// machCredCapable reports whether the request can establish a machine
+// credential: an RPCSEC_GSS principal at integrity or privacy service.
+func machCredCapable(ctx *types.CompoundContext) bool {
+	if ctx.AuthFlavor != rpc.AuthRPCSECGSS || ctx.GSSPrincipal == "" {
+		return false
+	}
+	si := gss.SessionInfoFromContext(ctx.Context)
+	return si != nil && si.Service >= gss.RPCGSSSvcIntegrity
+}
+
+// stateProtectResult encodes the client's negotiated protection as
+// state_protect4_r.
+func stateProtectResult(machCred *state.MachCredProtection) types.StateProtect4R {
+	if machCred == nil {
+		return types.StateProtect4R{How: types.SP4_NONE}
+	}
+	return types.StateProtect4R{
+		How:         types.SP4_MACH_CRED,
+		MustEnforce: machCred.MustEnforce,
+		MustAllow:   machCred.MustAllow,
+	}
+}
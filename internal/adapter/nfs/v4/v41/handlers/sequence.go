@@ -126,6 +126,7 @@ func HandleSequenceOp(d *Deps, compCtx *types.CompoundContext, reader io.Reader)
 		// Build V41RequestContext
 		ctx := &types.V41RequestContext{
 			SessionID:     args.SessionID,
+			ClientID:      sess.ClientID,
 			SlotID:        args.SlotID,
 			SequenceID:    args.SequenceID,
 			HighestSlot:   args.HighestSlotID,
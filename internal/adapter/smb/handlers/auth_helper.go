@@ -2,6 +2,7 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"slices"
 
@@ -42,6 +43,35 @@ const (
 	nobodyGID = uint32(65534)
 )
 
+// recordLogonFailure counts a failed NTLM logon against the account's lockout
+// when the user store enforces one (models.LoginRecorder).
+func recordLogonFailure(ctx context.Context, store models.UserStore, username, client string) {
+	recorder, ok := store.(models.LoginRecorder)
+	if !ok {
+		return
+	}
+	locked, err := recorder.RecordLoginFailure(ctx, username)
+	if err != nil {
+		logger.Warn("Failed to record failed SMB logon", "username", username, "error", err)
+		return
+	}
+	if locked {
+		logger.Warn("SMB account locked out after repeated failed logons", "username", username, "client", client)
+	}
+}
+
+// recordLogonSuccess clears a failed-logon count left by earlier attempts.
+func recordLogonSuccess(ctx context.Context, store models.UserStore, user *models.User) {
+	if user.FailedLogins == 0 && user.LockedUntil == nil {
+		return
+	}
+	if recorder, ok := store.(models.LoginRecorder); ok {
+		if err := recorder.RecordLoginSuccess(ctx, user.Username); err != nil {
+			logger.Warn("Failed to reset failed SMB logon count", "username", user.Username, "error", err)
+		}
+	}
+}
+
 // setUnprivilegedIdentity points the AuthContext identity at the nobody/nogroup
 // (65534) UID/GID. Used for guest and null/anonymous sessions so per-file
 // permission checks still apply. Centralised so the two call sites cannot drift
@@ -73,6 +103,7 @@ func BuildAuthContext(ctx *SMBHandlerContext) (*metadata.AuthContext, error) {
 	authCtx := &metadata.AuthContext{
 		Context:                ctx.Context,
 		ClientAddr:             ctx.ClientAddr,
+		Protocol:               "smb",
 		LockClientID:           fmt.Sprintf("smb:%d", ctx.SessionID),
 		Identity:               &metadata.Identity{},
 		BypassTraverseChecking: true,
@@ -164,6 +195,7 @@ func BuildAuthContextFromUser(ctx *SMBHandlerContext, user *models.User) *metada
 	authCtx := &metadata.AuthContext{
 		Context:                ctx.Context,
 		ClientAddr:             ctx.ClientAddr,
+		Protocol:               "smb",
 		LockClientID:           fmt.Sprintf("smb:%d", ctx.SessionID),
 		Identity:               identity,
 		BypassTraverseChecking: true,
@@ -404,6 +436,7 @@ func (h *Handler) buildOpenerAuthContext(ctx *SMBHandlerContext, openFile *OpenF
 	authCtx := &metadata.AuthContext{
 		Context:                ctx.Context,
 		ClientAddr:             ctx.ClientAddr,
+		Protocol:               "smb",
 		LockClientID:           fmt.Sprintf("smb:%d", ctx.SessionID),
 		Identity:               &metadata.Identity{},
 		BypassTraverseChecking: true,
@@ -190,6 +190,12 @@ func (h *Handler) Close(ctx *SMBHandlerContext, req *CloseRequest) (*CloseRespon
 	// and MFsymlink conversion (#619, same class as #603).
 	h.primeAuthContextFromOpenFile(ctx, openFile)
 
+	if h.Registry != nil && !openFile.IsPipe && len(openFile.MetadataHandle) > 0 {
+		if authCtx, err := BuildAuthContext(ctx); err == nil {
+			h.Registry.GetMetadataService().AuditFileAccess(authCtx, openFile.MetadataHandle, metadata.FileAuditClose, 0, nil)
+		}
+	}
+
 	// ========================================================================
 	// Step 3: Flush cached data to block store (ensures durability)
 	// ========================================================================
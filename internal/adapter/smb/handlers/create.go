@@ -677,6 +677,13 @@ func (h *Handler) Create(ctx *SMBHandlerContext, req *CreateRequest) (*CreateRes
 		return &CreateResponse{SMBResponseBase: SMBResponseBase{Status: types.StatusInvalidParameter}}, nil
 	}
 
+	// Windows quota tools open the NTFS quota index to issue
+	// SMB2_0_INFO_QUOTA requests against. It has no backing file, so serve it
+	// from the share root, where QUERY_INFO / SET_INFO reach the share's quotas.
+	if strings.EqualFold(filename, quotaIndexPath) {
+		filename = ""
+	}
+
 	// Normalize NTFS stream syntax. Stream names may contain characters
 	// like "/" which path.Dir/path.Base would misinterpret as path
 	// separators, so extract the stream portion BEFORE any path operations.
@@ -578,6 +578,7 @@ func (h *Handler) recheckExistingFileGates(d *createDraft, effectiveAccess uint3
 				"granted", fmt.Sprintf("0x%x", granted),
 				"disposition", req.CreateDisposition,
 				"error", err)
+			metaSvc.AuditFileAccess(authCtx, d.existingHandle, metadata.FileAuditOpen, effectiveAccess, err)
 			return 0, false, &CreateResponse{SMBResponseBase: SMBResponseBase{Status: common.MapToSMB(err)}}
 		}
 		// Preserve the client-visible grant: clear the disposition-implied
@@ -1353,6 +1354,7 @@ func (h *Handler) completeCreateAfterBreak(ctx *SMBHandlerContext, d *createDraf
 	}
 
 	h.StoreOpenFile(openFile)
+	metaSvc.AuditFileAccess(authCtx, fileHandle, metadata.FileAuditOpen, openFile.GrantedAccess, nil)
 
 	logger.Debug("CREATE successful",
 		"fileID", fmt.Sprintf("%x", smbFileID),
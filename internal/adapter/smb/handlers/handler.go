@@ -484,6 +484,11 @@ type OpenFile struct {
 	EnumerationLastName    string
 	EnumerationSpecialDone int // count of special entries already returned (0..2)
 
+	// QuotaScanIndex is the position in the share's sorted quota entries at
+	// which the next SMB2_0_INFO_QUOTA enumeration on this handle resumes.
+	// Reset by RestartScan or repositioned by a start SID.
+	QuotaScanIndex int
+
 	// Delete on close support (FileDispositionInformation).
 	//
 	// DeletePending tracks the SHARED, committed delete-on-close state per
@@ -1907,6 +1912,7 @@ func (h *Handler) flushFileCache(ctx context.Context, openFile *OpenFile) {
 func (h *Handler) buildCleanupAuthContext(ctx context.Context, sess *session.Session) *metadata.AuthContext {
 	authCtx := &metadata.AuthContext{
 		Context:                ctx,
+		Protocol:               "smb",
 		Identity:               &metadata.Identity{},
 		BypassTraverseChecking: true,
 	}
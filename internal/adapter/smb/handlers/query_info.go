@@ -48,6 +48,10 @@ type QueryInfoRequest struct {
 	// InputBufferLength is the length of the input buffer (if any).
 	InputBufferLength uint32
 
+	// InputBuffer carries the query input. Only quota queries use it
+	// (SMB2_QUERY_QUOTA_INFO); nil when absent or out of bounds.
+	InputBuffer []byte
+
 	// AdditionalInfo contains additional info for security queries.
 	// For security queries, this is a bit mask of OWNER_SECURITY_INFORMATION, etc.
 	AdditionalInfo uint32
@@ -142,6 +146,15 @@ func DecodeQueryInfoRequest(body []byte) (*QueryInfoRequest, error) {
 		copy(req.FileID[:], fileID)
 	}
 
+	// InputBufferOffset is relative to the start of the SMB2 header (64 bytes)
+	// and cannot point into the 40-byte fixed part.
+	if inputBufferLength > 0 {
+		inputStart := max(int(inputBufferOffset)-64, 40)
+		if inputStart+int(inputBufferLength) <= len(body) {
+			req.InputBuffer = body[inputStart : inputStart+int(inputBufferLength)]
+		}
+	}
+
 	return req, nil
 }
 
@@ -406,6 +419,10 @@ func (h *Handler) QueryInfo(ctx *SMBHandlerContext, req *QueryInfoRequest) (*Que
 			}
 		}
 		info, err = BuildSecurityDescriptorWithGrants(file, req.AdditionalInfo, grantACEs)
+	case types.SMB2InfoTypeQuota:
+		// Quota entries are sized to OutputBufferLength as they are built, so
+		// they bypass the generic truncation below.
+		return h.queryQuotaInfo(ctx, authCtx, openFile, req), nil
 	default:
 		return &QueryInfoResponse{SMBResponseBase: SMBResponseBase{Status: types.StatusInvalidParameter}}, nil
 	}
@@ -1137,11 +1154,12 @@ func (h *Handler) buildFilesystemInfo(ctx context.Context, class types.FileInfoC
 		w := smbenc.NewWriter(12 + len(fsName))
 		// FILE_CASE_SENSITIVE_SEARCH(0x01) | FILE_CASE_PRESERVED_NAMES(0x02) |
 		// FILE_UNICODE_ON_DISK(0x04) | FILE_PERSISTENT_ACLS(0x08) |
-		// FILE_FILE_COMPRESSION(0x10) | FILE_SUPPORTS_SPARSE_FILES(0x40) |
+		// FILE_FILE_COMPRESSION(0x10) | FILE_VOLUME_QUOTAS(0x20) |
+		// FILE_SUPPORTS_SPARSE_FILES(0x40) |
 		// FILE_SUPPORTS_REPARSE_POINTS(0x80) | FILE_NAMED_STREAMS(0x40000) |
 		// FILE_SUPPORTS_OBJECT_IDS(0x10000) | FILE_SUPPORTS_ENCRYPTION(0x20000)
 		const fileNamedStreams uint32 = 0x00040000
-		fsAttrs := uint32(0x000300DF) | fileNamedStreams
+		fsAttrs := uint32(0x000300FF) | fileNamedStreams
 		if streamsDisabled {
 			fsAttrs &^= fileNamedStreams
 		}
@@ -1152,17 +1170,25 @@ func (h *Handler) buildFilesystemInfo(ctx context.Context, class types.FileInfoC
 		return w.Bytes(), nil
 
 	case 7: // FileFsFullSizeInformation [MS-FSCC] 2.5.4
+		// Like NTFS with quotas enabled, the total and caller-available units
+		// reflect the caller's own quota while the actual-available units
+		// report the share as a whole.
 		stats, err := metaSvc.GetFilesystemStatisticsForIdentity(ctx, handle, identity)
 		if err != nil {
 			logger.WarnCtx(ctx, "FileFsFullSizeInformation: failed to get stats", "error", err)
 			return nil, err
 		}
-		totalBlocks := stats.TotalBytes / clusterSize
-		availBlocks := stats.AvailableBytes / clusterSize
+		actual := stats
+		if identity != nil {
+			if actual, err = metaSvc.GetFilesystemStatistics(ctx, handle); err != nil {
+				logger.WarnCtx(ctx, "FileFsFullSizeInformation: failed to get share stats", "error", err)
+				return nil, err
+			}
+		}
 		w := smbenc.NewWriter(32)
-		w.WriteUint64(totalBlocks)
-		w.WriteUint64(availBlocks)
-		w.WriteUint64(availBlocks) // CallerAvailableAllocationUnits = same as actual for share quotas
+		w.WriteUint64(stats.TotalBytes / clusterSize)      // TotalAllocationUnits
+		w.WriteUint64(stats.AvailableBytes / clusterSize)  // CallerAvailableAllocationUnits
+		w.WriteUint64(actual.AvailableBytes / clusterSize) // ActualAvailableAllocationUnits
 		w.WriteUint32(sectorsPerUnit)
 		w.WriteUint32(bytesPerSector)
 		return w.Bytes(), nil
@@ -1190,16 +1216,12 @@ func (h *Handler) buildFilesystemInfo(ctx context.Context, class types.FileInfoC
 		return w.Bytes(), nil
 
 	case 6: // FileFsControlInformation [MS-FSCC] 2.5.2
-		// Quota control information (48 bytes)
-		w := smbenc.NewWriter(48)
-		w.WriteUint64(0)          // FreeSpaceStartFiltering
-		w.WriteUint64(0)          // FreeSpaceThreshold
-		w.WriteUint64(0)          // FreeSpaceStopFiltering
-		w.WriteUint64(0x7FFFFFFF) // DefaultQuotaThreshold (no quota)
-		w.WriteUint64(0x7FFFFFFF) // DefaultQuotaLimit (no quota)
-		w.WriteUint32(0)          // FileSystemControlFlags (no quotas)
-		w.WriteZeros(4)           // Padding
-		return w.Bytes(), nil
+		// The default quota is the share's default-user quota.
+		shareName, _, err := metadata.DecodeFileHandle(handle)
+		if err != nil {
+			return nil, err
+		}
+		return EncodeFileFsControlInfo(fsControlInfo(metaSvc, shareName)), nil
 
 	default:
 		return nil, types.ErrNotSupported
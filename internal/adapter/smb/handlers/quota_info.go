@@ -0,0 +1,565 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/marmos91/dittofs/internal/adapter/smb/smbenc"
+	"github.com/marmos91/dittofs/internal/adapter/smb/types"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/auth/sid"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// Per-user quotas over SMB.
+//
+// Windows addresses quota entries by SID. The share's user quotas (the
+// control-plane models.Quota rows enforced by the metadata service) are mapped
+// to and from SIDs exactly like file owners in security descriptors, so the
+// entries a client sees name the same accounts as the Security tab. Byte
+// limits are the only dimension Windows knows; file-count limits are kept
+// untouched by SET_INFO.
+
+const (
+	// quotaIndexPath is the NTFS quota index that Windows quota tools (and
+	// Samba's smbcquotas) open to issue quota requests. It has no backing file:
+	// CREATE resolves it to the share root.
+	quotaIndexPath = "$Extend/$Quota:$Q:$INDEX_ALLOCATION"
+
+	// quotaNoLimit (-1) reports or requests an absent threshold or limit
+	// [MS-FSCC] 2.4.36.
+	quotaNoLimit = ^uint64(0)
+
+	// quotaRemoveEntry (-2) as a SET_INFO QuotaLimit deletes the quota entry
+	// (Samba SMB_NTQUOTAS_NO_ENTRY).
+	quotaRemoveEntry = ^uint64(0) - 1
+
+	// fileQuotaInfoFixedSize is the size of FILE_QUOTA_INFORMATION before its
+	// Sid field [MS-FSCC] 2.4.36.
+	fileQuotaInfoFixedSize = 40
+
+	// fileGetQuotaInfoFixedSize is the size of FILE_GET_QUOTA_INFORMATION
+	// before its Sid field [MS-FSCC] 2.4.38.
+	fileGetQuotaInfoFixedSize = 8
+
+	// queryQuotaInfoFixedSize is the size of SMB2_QUERY_QUOTA_INFO before its
+	// SidBuffer [MS-SMB2] 2.2.37.1.
+	queryQuotaInfoFixedSize = 16
+
+	// fileFsControlInfoSize is the size of FILE_FS_CONTROL_INFORMATION
+	// [MS-FSCC] 2.5.2.
+	fileFsControlInfoSize = 48
+
+	// fileVCQuotaEnforce (FILE_VC_QUOTA_ENFORCE) reports that quota limits are
+	// enforced [MS-FSCC] 2.5.2. DittoFS always tracks per-identity usage and
+	// enforces every configured quota, so the flag is always set.
+	fileVCQuotaEnforce uint32 = 0x00000002
+)
+
+// QueryQuotaInfo represents SMB2_QUERY_QUOTA_INFO [MS-SMB2] 2.2.37.1, the
+// QUERY_INFO input buffer for SMB2_0_INFO_QUOTA.
+type QueryQuotaInfo struct {
+	// ReturnSingle asks for at most one entry.
+	ReturnSingle bool
+
+	// RestartScan restarts the enumeration from the first entry.
+	RestartScan bool
+
+	// SIDs lists the entries to return (the FILE_GET_QUOTA_INFORMATION list).
+	// Nil when the client enumerates instead.
+	SIDs []*sid.SID
+
+	// StartSID, when set, positions the enumeration at this SID.
+	StartSID *sid.SID
+}
+
+// FileQuotaInfo represents one FILE_QUOTA_INFORMATION entry [MS-FSCC] 2.4.36.
+// Threshold and limit use quotaNoLimit for "none".
+type FileQuotaInfo struct {
+	ChangeTime     uint64
+	QuotaUsed      uint64
+	QuotaThreshold uint64
+	QuotaLimit     uint64
+	SID            *sid.SID
+}
+
+// FileFsControlInfo represents FILE_FS_CONTROL_INFORMATION [MS-FSCC] 2.5.2.
+// Only the default quota and control flags are meaningful to DittoFS; the
+// content-indexing free-space fields are always zero.
+type FileFsControlInfo struct {
+	DefaultQuotaThreshold  uint64
+	DefaultQuotaLimit      uint64
+	FileSystemControlFlags uint32
+}
+
+// DecodeQueryQuotaInfo parses SMB2_QUERY_QUOTA_INFO [MS-SMB2] 2.2.37.1. An
+// empty buffer (sent by some older clients) is a plain enumeration. A request
+// carrying both a SID list and a start SID is invalid.
+func DecodeQueryQuotaInfo(buf []byte) (*QueryQuotaInfo, error) {
+	if len(buf) == 0 {
+		return &QueryQuotaInfo{}, nil
+	}
+	if len(buf) < queryQuotaInfoFixedSize {
+		return nil, fmt.Errorf("SMB2_QUERY_QUOTA_INFO too short: %d bytes", len(buf))
+	}
+
+	r := smbenc.NewReader(buf)
+	q := &QueryQuotaInfo{
+		ReturnSingle: r.ReadUint8() != 0,
+		RestartScan:  r.ReadUint8() != 0,
+	}
+	r.Skip(2) // Reserved
+	sidListLength := r.ReadUint32()
+	startSidLength := r.ReadUint32()
+	startSidOffset := r.ReadUint32()
+	if r.Err() != nil {
+		return nil, fmt.Errorf("SMB2_QUERY_QUOTA_INFO parse error: %w", r.Err())
+	}
+	if sidListLength != 0 && startSidLength != 0 {
+		return nil, fmt.Errorf("SMB2_QUERY_QUOTA_INFO has both a SID list and a start SID")
+	}
+
+	sidBuf := buf[queryQuotaInfoFixedSize:]
+	if sidListLength != 0 {
+		if uint64(sidListLength) > uint64(len(sidBuf)) {
+			return nil, fmt.Errorf("SID list length %d exceeds buffer (%d bytes)", sidListLength, len(sidBuf))
+		}
+		err := walkQuotaEntries(sidBuf[:sidListLength], fileGetQuotaInfoFixedSize, func(entry []byte) error {
+			s, err := decodeQuotaEntrySID(entry, fileGetQuotaInfoFixedSize)
+			if err != nil {
+				return err
+			}
+			q.SIDs = append(q.SIDs, s)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if startSidLength != 0 {
+		end := uint64(startSidOffset) + uint64(startSidLength)
+		if end > uint64(len(sidBuf)) {
+			return nil, fmt.Errorf("start SID [%d,%d) exceeds buffer (%d bytes)", startSidOffset, end, len(sidBuf))
+		}
+		s, _, err := sid.DecodeSID(sidBuf[startSidOffset:end])
+		if err != nil {
+			return nil, fmt.Errorf("start SID: %w", err)
+		}
+		q.StartSID = s
+	}
+
+	return q, nil
+}
+
+// DecodeFileQuotaInfo parses a FILE_QUOTA_INFORMATION list [MS-FSCC] 2.4.36,
+// the SET_INFO buffer for SMB2_0_INFO_QUOTA.
+func DecodeFileQuotaInfo(buf []byte) ([]FileQuotaInfo, error) {
+	var entries []FileQuotaInfo
+	err := walkQuotaEntries(buf, fileQuotaInfoFixedSize, func(entry []byte) error {
+		s, err := decodeQuotaEntrySID(entry, fileQuotaInfoFixedSize)
+		if err != nil {
+			return err
+		}
+		r := smbenc.NewReader(entry[8:fileQuotaInfoFixedSize])
+		entries = append(entries, FileQuotaInfo{
+			ChangeTime:     r.ReadUint64(),
+			QuotaUsed:      r.ReadUint64(),
+			QuotaThreshold: r.ReadUint64(),
+			QuotaLimit:     r.ReadUint64(),
+			SID:            s,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// EncodeFileQuotaInfo builds a FILE_QUOTA_INFORMATION list [MS-FSCC] 2.4.36.
+// Entries are chained by NextEntryOffset and 8-byte aligned; the last entry is
+// not padded.
+func EncodeFileQuotaInfo(entries []FileQuotaInfo) []byte {
+	var out []byte
+	for i, e := range entries {
+		var sidBuf bytes.Buffer
+		sid.EncodeSID(&sidBuf, e.SID)
+		size := fileQuotaInfoFixedSize + sidBuf.Len()
+		last := i == len(entries)-1
+
+		var next uint32
+		if !last {
+			next = uint32(alignQuotaEntry(size))
+		}
+		w := smbenc.NewWriter(alignQuotaEntry(size))
+		w.WriteUint32(next)
+		w.WriteUint32(uint32(sidBuf.Len()))
+		w.WriteUint64(e.ChangeTime)
+		w.WriteUint64(e.QuotaUsed)
+		w.WriteUint64(e.QuotaThreshold)
+		w.WriteUint64(e.QuotaLimit)
+		w.WriteBytes(sidBuf.Bytes())
+		if !last {
+			w.WriteZeros(alignQuotaEntry(size) - size)
+		}
+		out = append(out, w.Bytes()...)
+	}
+	return out
+}
+
+// EncodeFileFsControlInfo builds FILE_FS_CONTROL_INFORMATION [MS-FSCC] 2.5.2.
+func EncodeFileFsControlInfo(info *FileFsControlInfo) []byte {
+	w := smbenc.NewWriter(fileFsControlInfoSize)
+	w.WriteUint64(0) // FreeSpaceStartFiltering
+	w.WriteUint64(0) // FreeSpaceThreshold
+	w.WriteUint64(0) // FreeSpaceStopFiltering
+	w.WriteUint64(info.DefaultQuotaThreshold)
+	w.WriteUint64(info.DefaultQuotaLimit)
+	w.WriteUint32(info.FileSystemControlFlags)
+	w.WriteZeros(4) // Padding
+	return w.Bytes()
+}
+
+// DecodeFileFsControlInfo parses FILE_FS_CONTROL_INFORMATION [MS-FSCC] 2.5.2.
+func DecodeFileFsControlInfo(buf []byte) (*FileFsControlInfo, error) {
+	if len(buf) < fileFsControlInfoSize-4 {
+		return nil, fmt.Errorf("buffer too short for FILE_FS_CONTROL_INFORMATION: %d bytes", len(buf))
+	}
+	r := smbenc.NewReader(buf)
+	r.Skip(24) // FreeSpaceStartFiltering, FreeSpaceThreshold, FreeSpaceStopFiltering
+	return &FileFsControlInfo{
+		DefaultQuotaThreshold:  r.ReadUint64(),
+		DefaultQuotaLimit:      r.ReadUint64(),
+		FileSystemControlFlags: r.ReadUint32(),
+	}, nil
+}
+
+// walkQuotaEntries calls fn with each entry of a NextEntryOffset-chained list
+// whose entries are at least minSize bytes.
+func walkQuotaEntries(buf []byte, minSize int, fn func(entry []byte) error) error {
+	for off := 0; ; {
+		if off+minSize > len(buf) {
+			return fmt.Errorf("quota entry at offset %d truncated (%d bytes left)", off, len(buf)-off)
+		}
+		next := int(binary.LittleEndian.Uint32(buf[off:]))
+		end := len(buf)
+		if next != 0 {
+			if next < minSize || next > len(buf)-off {
+				return fmt.Errorf("quota entry at offset %d has invalid NextEntryOffset %d", off, next)
+			}
+			end = off + next
+		}
+		if err := fn(buf[off:end]); err != nil {
+			return err
+		}
+		if next == 0 {
+			return nil
+		}
+		off = end
+	}
+}
+
+// decodeQuotaEntrySID decodes the SID of a FILE_QUOTA_INFORMATION or
+// FILE_GET_QUOTA_INFORMATION entry, whose SidLength field is at offset 4 and
+// whose Sid starts at sidOffset.
+func decodeQuotaEntrySID(entry []byte, sidOffset int) (*sid.SID, error) {
+	sidLen := int(binary.LittleEndian.Uint32(entry[4:]))
+	if sidLen > len(entry)-sidOffset {
+		return nil, fmt.Errorf("quota entry SidLength %d exceeds entry (%d bytes)", sidLen, len(entry))
+	}
+	s, _, err := sid.DecodeSID(entry[sidOffset : sidOffset+sidLen])
+	return s, err
+}
+
+// alignQuotaEntry rounds a quota entry size up to the 8-byte alignment
+// required between chained entries.
+func alignQuotaEntry(n int) int {
+	return (n + 7) &^ 7
+}
+
+// ============================================================================
+// QUERY_INFO / SET_INFO handlers
+// ============================================================================
+
+// queryQuotaInfo handles QUERY_INFO with SMB2_0_INFO_QUOTA [MS-SMB2]
+// 3.3.5.20.4. A SID list is answered in full (SIDs that map to no user are
+// skipped); otherwise the share's configured user quotas are enumerated across
+// calls on the handle, resuming where the previous call stopped until
+// RestartScan. Callers without admin permission on the share see only their
+// own entry.
+func (h *Handler) queryQuotaInfo(ctx *SMBHandlerContext, authCtx *metadata.AuthContext, openFile *OpenFile, req *QueryInfoRequest) *QueryInfoResponse {
+	q, err := DecodeQueryQuotaInfo(req.InputBuffer)
+	if err != nil {
+		logger.Debug("QUERY_INFO quota: invalid input buffer", "error", err)
+		return &QueryInfoResponse{SMBResponseBase: SMBResponseBase{Status: types.StatusInvalidParameter}}
+	}
+
+	shareName := shareNameForOpenFile(openFile)
+	mapper := GetSIDMapper()
+	admin := ctx.Permission.CanAdmin()
+	var callerUID *uint32
+	if authCtx != nil && authCtx.Identity != nil {
+		callerUID = authCtx.Identity.UID
+	}
+	permitted := func(uid uint32) bool {
+		return admin || (callerUID != nil && *callerUID == uid)
+	}
+
+	// Resolve the requested users, and for an enumeration where it starts.
+	var uids []uint32
+	start := 0
+	if q.SIDs != nil {
+		for _, s := range q.SIDs {
+			uid, ok := quotaUIDForSID(mapper, s)
+			if !ok {
+				continue
+			}
+			if !permitted(uid) {
+				return &QueryInfoResponse{SMBResponseBase: SMBResponseBase{Status: types.StatusAccessDenied}}
+			}
+			uids = append(uids, uid)
+		}
+	} else {
+		if admin {
+			uids = quotaUsers(h.Registry.GetMetadataService(), shareName)
+		} else if callerUID != nil {
+			uids = []uint32{*callerUID}
+		}
+
+		openFile.mu.RLock()
+		start = openFile.QuotaScanIndex
+		openFile.mu.RUnlock()
+		if q.RestartScan {
+			start = 0
+		}
+		if q.StartSID != nil {
+			uid, ok := quotaUIDForSID(mapper, q.StartSID)
+			if !ok {
+				return &QueryInfoResponse{SMBResponseBase: SMBResponseBase{Status: types.StatusInvalidParameter}}
+			}
+			start, _ = slices.BinarySearch(uids, uid)
+		}
+		start = min(start, len(uids))
+	}
+
+	// Emit as many entries as fit in the output buffer.
+	var entries []FileQuotaInfo
+	size := 0
+	for _, uid := range uids[start:] {
+		entry, err := h.userQuotaEntry(shareName, mapper, uid)
+		if err != nil {
+			logger.Debug("QUERY_INFO quota: usage lookup failed", "share", shareName, "uid", uid, "error", err)
+			return &QueryInfoResponse{SMBResponseBase: SMBResponseBase{Status: types.StatusInternalError}}
+		}
+		next := alignQuotaEntry(size) + fileQuotaInfoFixedSize + sid.SIDSize(entry.SID)
+		if next > int(req.OutputBufferLength) {
+			break
+		}
+		entries = append(entries, entry)
+		size = next
+		if q.ReturnSingle {
+			break
+		}
+	}
+
+	if q.SIDs == nil {
+		openFile.mu.Lock()
+		openFile.QuotaScanIndex = start + len(entries)
+		openFile.mu.Unlock()
+	}
+
+	if len(entries) == 0 {
+		if start >= len(uids) {
+			return &QueryInfoResponse{SMBResponseBase: SMBResponseBase{Status: types.StatusNoMoreEntries}}
+		}
+		return &QueryInfoResponse{SMBResponseBase: SMBResponseBase{Status: types.StatusBufferTooSmall}}
+	}
+
+	return &QueryInfoResponse{
+		SMBResponseBase: SMBResponseBase{Status: types.StatusSuccess},
+		Data:            EncodeFileQuotaInfo(entries),
+	}
+}
+
+// userQuotaEntry builds the FILE_QUOTA_INFORMATION entry for uid: its live
+// usage and the limits enforced for it (its own quota, else the share's
+// default-user quota, else none).
+func (h *Handler) userQuotaEntry(shareName string, mapper *sid.SIDMapper, uid uint32) (FileQuotaInfo, error) {
+	metaSvc := h.Registry.GetMetadataService()
+	entry := FileQuotaInfo{
+		QuotaThreshold: quotaNoLimit,
+		QuotaLimit:     quotaNoLimit,
+		SID:            ownerSIDFor(mapper, uid),
+	}
+
+	report, ok, err := metaSvc.GetIdentityQuotaReport(shareName, metadata.QuotaScopeUser, uid)
+	if err != nil {
+		return FileQuotaInfo{}, err
+	}
+	if ok {
+		entry.QuotaUsed = uint64(max(report.Usage.Bytes, 0))
+		entry.QuotaThreshold = wireQuotaBytes(report.Quota.SoftBytes)
+		entry.QuotaLimit = wireQuotaBytes(report.Quota.LimitBytes)
+		return entry, nil
+	}
+
+	store, err := metaSvc.GetStoreForShare(shareName)
+	if err != nil {
+		return FileQuotaInfo{}, err
+	}
+	usage, err := store.GetQuotaUsage(metadata.QuotaScopeUser, uid)
+	if err != nil {
+		return FileQuotaInfo{}, err
+	}
+	entry.QuotaUsed = uint64(max(usage.Bytes, 0))
+	return entry, nil
+}
+
+// setQuotaInfo handles SET_INFO with SMB2_0_INFO_QUOTA [MS-SMB2] 3.3.5.21.4.
+// Each FILE_QUOTA_INFORMATION entry replaces the byte threshold and limit of
+// the user its SID maps to; a QuotaLimit of -2 deletes the user's quota.
+// Requires admin permission on the share.
+func (h *Handler) setQuotaInfo(ctx *SMBHandlerContext, openFile *OpenFile, buffer []byte) (*SetInfoResponse, error) {
+	if !ctx.Permission.CanAdmin() {
+		logger.Debug("SET_INFO quota: caller is not a share admin", "share", ctx.ShareName)
+		return setInfoStatus(types.StatusAccessDenied), nil
+	}
+
+	entries, err := DecodeFileQuotaInfo(buffer)
+	if err != nil {
+		logger.Debug("SET_INFO quota: invalid buffer", "error", err)
+		return setInfoStatus(types.StatusInvalidParameter), nil
+	}
+
+	shareName := shareNameForOpenFile(openFile)
+	mapper := GetSIDMapper()
+	for _, e := range entries {
+		uid, ok := quotaUIDForSID(mapper, e.SID)
+		if !ok {
+			logger.Debug("SET_INFO quota: unmappable SID", "sid", sid.FormatSID(e.SID))
+			return setInfoStatus(types.StatusNoneMapped), nil
+		}
+		if err := h.setUserQuotaBytes(ctx.Context, shareName, uid, e.QuotaThreshold, e.QuotaLimit); err != nil {
+			logger.Warn("SET_INFO quota: failed to apply quota",
+				"share", shareName, "uid", uid, "error", err)
+			return setInfoStatus(types.StatusInvalidParameter), nil
+		}
+		logger.Info("SET_INFO quota applied",
+			"share", shareName, "uid", uid,
+			"threshold", int64(e.QuotaThreshold), "limit", int64(e.QuotaLimit))
+	}
+
+	return setInfoStatus(types.StatusSuccess), nil
+}
+
+// setFsControlInfo handles SET_INFO FileFsControlInformation [MS-FSCC] 2.5.2:
+// the default quota threshold and limit become the share's default-user quota.
+// Control flags are ignored since quota enforcement cannot be switched off.
+// Requires admin permission on the share.
+func (h *Handler) setFsControlInfo(ctx *SMBHandlerContext, openFile *OpenFile, buffer []byte) (*SetInfoResponse, error) {
+	info, err := DecodeFileFsControlInfo(buffer)
+	if err != nil {
+		return setInfoStatus(types.StatusInfoLengthMismatch), nil
+	}
+	if !ctx.Permission.CanAdmin() {
+		logger.Debug("SET_INFO FileFsControlInformation: caller is not a share admin", "share", ctx.ShareName)
+		return setInfoStatus(types.StatusAccessDenied), nil
+	}
+
+	shareName := shareNameForOpenFile(openFile)
+	if err := h.setUserQuotaBytes(ctx.Context, shareName, metadata.DefaultUserID, info.DefaultQuotaThreshold, info.DefaultQuotaLimit); err != nil {
+		logger.Warn("SET_INFO FileFsControlInformation: failed to apply default quota",
+			"share", shareName, "error", err)
+		return setInfoStatus(types.StatusInvalidParameter), nil
+	}
+	logger.Info("SET_INFO default quota applied",
+		"share", shareName,
+		"threshold", int64(info.DefaultQuotaThreshold), "limit", int64(info.DefaultQuotaLimit))
+
+	return setInfoStatus(types.StatusSuccess), nil
+}
+
+// setUserQuotaBytes replaces the byte threshold and limit of the user quota
+// keyed by id (metadata.DefaultUserID for the default-user quota), keeping its
+// file-count limits. A limit of quotaRemoveEntry removes the quota entirely.
+func (h *Handler) setUserQuotaBytes(ctx context.Context, shareName string, id uint32, threshold, limit uint64) error {
+	iq := metadata.IdentityQuota{Scope: metadata.QuotaScopeUser, ID: id}
+	if limit != quotaRemoveEntry {
+		var err error
+		if iq.SoftBytes, err = quotaBytesFromWire(threshold); err != nil {
+			return err
+		}
+		if iq.LimitBytes, err = quotaBytesFromWire(limit); err != nil {
+			return err
+		}
+		if existing, ok := h.Registry.GetMetadataService().GetIdentityQuota(shareName, metadata.QuotaScopeUser, id); ok {
+			iq.LimitFiles = existing.LimitFiles
+			iq.SoftFiles = existing.SoftFiles
+		}
+	}
+	return h.Registry.SetIdentityQuotaLimits(ctx, shareName, iq)
+}
+
+// fsControlInfo reports the share's default-user quota as
+// FILE_FS_CONTROL_INFORMATION.
+func fsControlInfo(metaSvc *metadata.Service, shareName string) *FileFsControlInfo {
+	info := &FileFsControlInfo{
+		DefaultQuotaThreshold:  quotaNoLimit,
+		DefaultQuotaLimit:      quotaNoLimit,
+		FileSystemControlFlags: fileVCQuotaEnforce,
+	}
+	if dq, ok := metaSvc.GetIdentityQuota(shareName, metadata.QuotaScopeUser, metadata.DefaultUserID); ok {
+		info.DefaultQuotaThreshold = wireQuotaBytes(dq.SoftBytes)
+		info.DefaultQuotaLimit = wireQuotaBytes(dq.LimitBytes)
+	}
+	return info
+}
+
+// quotaUsers returns the sorted UIDs with an explicit user quota on a share.
+func quotaUsers(metaSvc *metadata.Service, shareName string) []uint32 {
+	var uids []uint32
+	for _, cq := range metaSvc.ListIdentityQuotas() {
+		if cq.Share == shareName && cq.Quota.Scope == metadata.QuotaScopeUser && cq.Quota.ID != metadata.DefaultUserID {
+			uids = append(uids, cq.Quota.ID)
+		}
+	}
+	slices.Sort(uids)
+	return slices.Compact(uids)
+}
+
+// quotaUIDForSID maps a quota entry SID to a UID: the inverse of ownerSIDFor,
+// including BUILTIN\Administrators for root.
+func quotaUIDForSID(mapper *sid.SIDMapper, s *sid.SID) (uint32, bool) {
+	if s.Equal(sid.WellKnownAdministrators) {
+		return 0, true
+	}
+	if uid, ok := mapper.UIDFromSID(s); ok {
+		return uid, true
+	}
+	return directoryUIDForSID(s)
+}
+
+// wireQuotaBytes converts a byte threshold or limit to its wire form, where
+// DittoFS's "0 = none" becomes -1.
+func wireQuotaBytes(v int64) uint64 {
+	if v <= 0 {
+		return quotaNoLimit
+	}
+	return uint64(v)
+}
+
+// quotaBytesFromWire converts a wire threshold or limit to bytes, where -1
+// becomes DittoFS's "0 = none".
+func quotaBytesFromWire(v uint64) (int64, error) {
+	if v == quotaNoLimit {
+		return 0, nil
+	}
+	if v > math.MaxInt64 {
+		return 0, fmt.Errorf("quota value %#x out of range", v)
+	}
+	return int64(v), nil
+}
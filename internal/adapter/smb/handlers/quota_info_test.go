@@ -0,0 +1,434 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/marmos91/dittofs/internal/adapter/smb/types"
+	"github.com/marmos91/dittofs/pkg/auth/sid"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/marmos91/dittofs/pkg/metadata/store/memory"
+)
+
+const quotaTestShare = "/quota-test"
+
+// quotaRuntime is a real runtime whose SetIdentityQuotaLimits applies limits
+// straight to the metadata service, since the test runtime has no
+// control-plane store to persist them in.
+type quotaRuntime struct {
+	*runtime.Runtime
+}
+
+func (q *quotaRuntime) SetIdentityQuotaLimits(_ context.Context, shareName string, iq metadata.IdentityQuota) error {
+	svc := q.GetMetadataService()
+	if iq.LimitBytes == 0 && iq.SoftBytes == 0 && iq.LimitFiles == 0 && iq.SoftFiles == 0 {
+		svc.RemoveIdentityQuota(shareName, iq.Scope, iq.ID)
+		return nil
+	}
+	svc.SetIdentityQuota(shareName, iq)
+	return nil
+}
+
+// setupQuotaShare wires a handler over a memory-backed share and opens the
+// NTFS quota index as a user with the given UID and share permission. Returns
+// the handler, the request context, and the FileID of the open.
+func setupQuotaShare(t *testing.T, uid uint32, perm models.SharePermission) (*Handler, *SMBHandlerContext, [16]byte) {
+	t.Helper()
+
+	rt := runtime.New(nil)
+	if err := rt.RegisterMetadataStore("quota-meta", memory.NewMemoryMetadataStoreWithDefaults()); err != nil {
+		t.Fatalf("RegisterMetadataStore: %v", err)
+	}
+	if err := rt.AddShare(context.Background(), &runtime.ShareConfig{
+		Name:          quotaTestShare,
+		MetadataStore: "quota-meta",
+		Enabled:       true,
+		RootAttr:      &metadata.FileAttr{Type: metadata.FileTypeDirectory, Mode: 0o777},
+	}); err != nil {
+		t.Fatalf("AddShare: %v", err)
+	}
+
+	h := NewHandler()
+	h.Registry = &quotaRuntime{Runtime: rt}
+
+	gid := uint32(1000)
+	sess := h.CreateSession("127.0.0.1:12345", false, "quota-user", "")
+	sess.User = &models.User{Username: "quota-user", UID: &uid, Groups: []models.Group{{GID: &gid}}}
+
+	const treeID uint32 = 1
+	h.StoreTree(&TreeConnection{
+		TreeID:     treeID,
+		SessionID:  sess.SessionID,
+		ShareName:  quotaTestShare,
+		Permission: perm,
+	})
+	ctx := &SMBHandlerContext{
+		Context:   context.Background(),
+		TreeID:    treeID,
+		SessionID: sess.SessionID,
+		ShareName: quotaTestShare,
+	}
+
+	resp, err := h.Create(ctx, &CreateRequest{
+		FileName:          `$Extend\$Quota:$Q:$INDEX_ALLOCATION`,
+		DesiredAccess:     0x02000000, // MAXIMUM_ALLOWED
+		ShareAccess:       0x07,
+		CreateDisposition: types.FileOpen,
+	})
+	if err != nil || resp.Status != types.StatusSuccess {
+		t.Fatalf("Create(quota index): status=%v err=%v", resp.Status, err)
+	}
+	return h, ctx, resp.FileID
+}
+
+func setUserQuota(h *Handler, uid uint32, soft, limit int64) {
+	h.Registry.GetMetadataService().SetIdentityQuota(quotaTestShare, metadata.IdentityQuota{
+		Scope: metadata.QuotaScopeUser, ID: uid, SoftBytes: soft, LimitBytes: limit,
+	})
+}
+
+func encodeQueryQuotaInfo(returnSingle, restart bool, sidList []*sid.SID) []byte {
+	var list []byte
+	for i, s := range sidList {
+		var sidBuf bytes.Buffer
+		sid.EncodeSID(&sidBuf, s)
+		size := fileGetQuotaInfoFixedSize + sidBuf.Len()
+		var next uint32
+		if i < len(sidList)-1 {
+			next = uint32(size)
+		}
+		list = binary.LittleEndian.AppendUint32(list, next)
+		list = binary.LittleEndian.AppendUint32(list, uint32(sidBuf.Len()))
+		list = append(list, sidBuf.Bytes()...)
+	}
+	buf := make([]byte, queryQuotaInfoFixedSize)
+	if returnSingle {
+		buf[0] = 1
+	}
+	if restart {
+		buf[1] = 1
+	}
+	binary.LittleEndian.PutUint32(buf[4:], uint32(len(list)))
+	return append(buf, list...)
+}
+
+func queryQuota(t *testing.T, h *Handler, ctx *SMBHandlerContext, fileID [16]byte, input []byte) (types.Status, []FileQuotaInfo) {
+	t.Helper()
+	resp, err := h.QueryInfo(ctx, &QueryInfoRequest{
+		InfoType:           types.SMB2InfoTypeQuota,
+		OutputBufferLength: 4096,
+		InputBuffer:        input,
+		FileID:             fileID,
+	})
+	if err != nil {
+		t.Fatalf("QueryInfo: %v", err)
+	}
+	if resp.Status != types.StatusSuccess {
+		return resp.Status, nil
+	}
+	entries, err := DecodeFileQuotaInfo(resp.Data)
+	if err != nil {
+		t.Fatalf("DecodeFileQuotaInfo: %v", err)
+	}
+	return resp.Status, entries
+}
+
+func entryUIDs(t *testing.T, entries []FileQuotaInfo) []uint32 {
+	t.Helper()
+	var uids []uint32
+	for _, e := range entries {
+		uid, ok := quotaUIDForSID(GetSIDMapper(), e.SID)
+		if !ok {
+			t.Fatalf("entry SID %s does not map to a uid", sid.FormatSID(e.SID))
+		}
+		uids = append(uids, uid)
+	}
+	return uids
+}
+
+func TestFileQuotaInfo_RoundTrip(t *testing.T) {
+	mapper := GetSIDMapper()
+	in := []FileQuotaInfo{
+		{ChangeTime: 1, QuotaUsed: 2, QuotaThreshold: 3, QuotaLimit: quotaNoLimit, SID: mapper.UserSID(1000)},
+		{QuotaUsed: 5, QuotaThreshold: quotaNoLimit, QuotaLimit: 7, SID: sid.WellKnownAdministrators},
+	}
+	buf := EncodeFileQuotaInfo(in)
+	if next := binary.LittleEndian.Uint32(buf); next%8 != 0 || next == 0 {
+		t.Fatalf("first NextEntryOffset = %d, want nonzero multiple of 8", next)
+	}
+
+	out, err := DecodeFileQuotaInfo(buf)
+	if err != nil {
+		t.Fatalf("DecodeFileQuotaInfo: %v", err)
+	}
+	if len(out) != len(in) {
+		t.Fatalf("decoded %d entries, want %d", len(out), len(in))
+	}
+	for i := range in {
+		if out[i].ChangeTime != in[i].ChangeTime || out[i].QuotaUsed != in[i].QuotaUsed ||
+			out[i].QuotaThreshold != in[i].QuotaThreshold || out[i].QuotaLimit != in[i].QuotaLimit ||
+			!out[i].SID.Equal(in[i].SID) {
+			t.Errorf("entry %d = %+v, want %+v", i, out[i], in[i])
+		}
+	}
+
+	if _, err := DecodeFileQuotaInfo(buf[:fileQuotaInfoFixedSize]); err == nil {
+		t.Error("decoding a truncated entry succeeded")
+	}
+}
+
+func TestDecodeQueryQuotaInfo(t *testing.T) {
+	mapper := GetSIDMapper()
+
+	q, err := DecodeQueryQuotaInfo(nil)
+	if err != nil || q.SIDs != nil || q.StartSID != nil {
+		t.Fatalf("empty buffer = %+v, %v; want plain enumeration", q, err)
+	}
+
+	q, err = DecodeQueryQuotaInfo(encodeQueryQuotaInfo(true, true, []*sid.SID{mapper.UserSID(1000), mapper.UserSID(2000)}))
+	if err != nil {
+		t.Fatalf("DecodeQueryQuotaInfo: %v", err)
+	}
+	if !q.ReturnSingle || !q.RestartScan || len(q.SIDs) != 2 || !q.SIDs[1].Equal(mapper.UserSID(2000)) {
+		t.Fatalf("decoded %+v", q)
+	}
+
+	// A start SID placed in the SidBuffer.
+	var sidBuf bytes.Buffer
+	sid.EncodeSID(&sidBuf, mapper.UserSID(3000))
+	buf := make([]byte, queryQuotaInfoFixedSize)
+	binary.LittleEndian.PutUint32(buf[8:], uint32(sidBuf.Len()))
+	q, err = DecodeQueryQuotaInfo(append(buf, sidBuf.Bytes()...))
+	if err != nil || q.StartSID == nil || !q.StartSID.Equal(mapper.UserSID(3000)) {
+		t.Fatalf("start SID = %+v, %v", q, err)
+	}
+
+	both := encodeQueryQuotaInfo(false, false, []*sid.SID{mapper.UserSID(1000)})
+	binary.LittleEndian.PutUint32(both[8:], 4)
+	if _, err := DecodeQueryQuotaInfo(both); err == nil {
+		t.Error("SID list plus start SID accepted")
+	}
+}
+
+func TestQueryQuota_AdminEnumerates(t *testing.T) {
+	h, ctx, fileID := setupQuotaShare(t, 0, models.PermissionAdmin)
+	setUserQuota(h, 2000, 0, 20<<20)
+	setUserQuota(h, 1000, 5<<20, 10<<20)
+
+	status, entries := queryQuota(t, h, ctx, fileID, nil)
+	if status != types.StatusSuccess {
+		t.Fatalf("status = %v", status)
+	}
+	if got := entryUIDs(t, entries); len(got) != 2 || got[0] != 1000 || got[1] != 2000 {
+		t.Fatalf("uids = %v, want [1000 2000]", got)
+	}
+	if e := entries[0]; e.QuotaThreshold != 5<<20 || e.QuotaLimit != 10<<20 || e.QuotaUsed != 0 {
+		t.Errorf("uid 1000 entry = %+v", e)
+	}
+	if e := entries[1]; e.QuotaThreshold != quotaNoLimit || e.QuotaLimit != 20<<20 {
+		t.Errorf("uid 2000 entry = %+v, want no threshold", e)
+	}
+
+	// The scan resumes where it stopped until restarted.
+	if status, _ := queryQuota(t, h, ctx, fileID, nil); status != types.StatusNoMoreEntries {
+		t.Fatalf("second scan status = %v, want STATUS_NO_MORE_ENTRIES", status)
+	}
+	status, entries = queryQuota(t, h, ctx, fileID, encodeQueryQuotaInfo(true, true, nil))
+	if status != types.StatusSuccess || len(entries) != 1 {
+		t.Fatalf("restart single = %v, %d entries", status, len(entries))
+	}
+	status, entries = queryQuota(t, h, ctx, fileID, encodeQueryQuotaInfo(true, false, nil))
+	if status != types.StatusSuccess || entryUIDs(t, entries)[0] != 2000 {
+		t.Fatalf("continued single = %v, %v", status, entries)
+	}
+}
+
+func TestQueryQuota_SIDList(t *testing.T) {
+	h, ctx, fileID := setupQuotaShare(t, 0, models.PermissionAdmin)
+	mapper := GetSIDMapper()
+	h.Registry.GetMetadataService().SetIdentityQuota(quotaTestShare, metadata.IdentityQuota{
+		Scope: metadata.QuotaScopeUser, ID: metadata.DefaultUserID, LimitBytes: 1 << 30,
+	})
+
+	// A user without an explicit quota reports the default quota; an
+	// unmappable SID is skipped.
+	input := encodeQueryQuotaInfo(false, false, []*sid.SID{mapper.UserSID(4242), mapper.GroupSID(7)})
+	status, entries := queryQuota(t, h, ctx, fileID, input)
+	if status != types.StatusSuccess || len(entries) != 1 {
+		t.Fatalf("status = %v, entries = %d; want 1", status, len(entries))
+	}
+	if entries[0].QuotaLimit != 1<<30 || entries[0].QuotaThreshold != quotaNoLimit {
+		t.Errorf("entry = %+v, want the default quota", entries[0])
+	}
+}
+
+func TestQueryQuota_NonAdminSeesOwnEntry(t *testing.T) {
+	h, ctx, fileID := setupQuotaShare(t, 1000, models.PermissionReadWrite)
+	setUserQuota(h, 1000, 0, 10<<20)
+	setUserQuota(h, 2000, 0, 20<<20)
+	mapper := GetSIDMapper()
+
+	status, entries := queryQuota(t, h, ctx, fileID, nil)
+	if status != types.StatusSuccess {
+		t.Fatalf("status = %v", status)
+	}
+	if got := entryUIDs(t, entries); len(got) != 1 || got[0] != 1000 {
+		t.Fatalf("uids = %v, want only the caller", got)
+	}
+
+	input := encodeQueryQuotaInfo(false, false, []*sid.SID{mapper.UserSID(2000)})
+	if status, _ := queryQuota(t, h, ctx, fileID, input); status != types.StatusAccessDenied {
+		t.Errorf("other user's entry: status = %v, want STATUS_ACCESS_DENIED", status)
+	}
+}
+
+func TestQueryQuota_BufferTooSmall(t *testing.T) {
+	h, ctx, fileID := setupQuotaShare(t, 0, models.PermissionAdmin)
+	setUserQuota(h, 1000, 0, 10<<20)
+
+	resp, err := h.QueryInfo(ctx, &QueryInfoRequest{
+		InfoType:           types.SMB2InfoTypeQuota,
+		OutputBufferLength: fileQuotaInfoFixedSize,
+		FileID:             fileID,
+	})
+	if err != nil || resp.Status != types.StatusBufferTooSmall {
+		t.Fatalf("status = %v, err = %v; want STATUS_BUFFER_TOO_SMALL", resp.Status, err)
+	}
+}
+
+func setQuota(t *testing.T, h *Handler, ctx *SMBHandlerContext, fileID [16]byte, entries ...FileQuotaInfo) types.Status {
+	t.Helper()
+	resp, err := h.SetInfo(ctx, &SetInfoRequest{
+		InfoType: types.SMB2InfoTypeQuota,
+		FileID:   fileID,
+		Buffer:   EncodeFileQuotaInfo(entries),
+	})
+	if err != nil {
+		t.Fatalf("SetInfo: %v", err)
+	}
+	return resp.Status
+}
+
+func TestSetQuota(t *testing.T) {
+	h, ctx, fileID := setupQuotaShare(t, 0, models.PermissionAdmin)
+	svc := h.Registry.GetMetadataService()
+	mapper := GetSIDMapper()
+	svc.SetIdentityQuota(quotaTestShare, metadata.IdentityQuota{
+		Scope: metadata.QuotaScopeUser, ID: 1000, LimitBytes: 1 << 20, LimitFiles: 50,
+	})
+
+	status := setQuota(t, h, ctx, fileID,
+		FileQuotaInfo{QuotaThreshold: 8 << 20, QuotaLimit: 10 << 20, SID: mapper.UserSID(1000)},
+		FileQuotaInfo{QuotaThreshold: quotaNoLimit, QuotaLimit: 2 << 20, SID: mapper.UserSID(2000)},
+	)
+	if status != types.StatusSuccess {
+		t.Fatalf("status = %v", status)
+	}
+	iq, ok := svc.GetIdentityQuota(quotaTestShare, metadata.QuotaScopeUser, 1000)
+	if !ok || iq.SoftBytes != 8<<20 || iq.LimitBytes != 10<<20 || iq.LimitFiles != 50 {
+		t.Errorf("uid 1000 quota = %+v, %v; want new byte limits and kept file limit", iq, ok)
+	}
+	iq, ok = svc.GetIdentityQuota(quotaTestShare, metadata.QuotaScopeUser, 2000)
+	if !ok || iq.SoftBytes != 0 || iq.LimitBytes != 2<<20 {
+		t.Errorf("uid 2000 quota = %+v, %v", iq, ok)
+	}
+
+	// QuotaLimit -2 deletes the entry.
+	if status := setQuota(t, h, ctx, fileID, FileQuotaInfo{QuotaLimit: quotaRemoveEntry, SID: mapper.UserSID(2000)}); status != types.StatusSuccess {
+		t.Fatalf("delete status = %v", status)
+	}
+	if _, ok := svc.GetIdentityQuota(quotaTestShare, metadata.QuotaScopeUser, 2000); ok {
+		t.Error("uid 2000 quota survived deletion")
+	}
+
+	if status := setQuota(t, h, ctx, fileID, FileQuotaInfo{QuotaLimit: 1, SID: mapper.GroupSID(7)}); status != types.StatusNoneMapped {
+		t.Errorf("group SID status = %v, want STATUS_NONE_MAPPED", status)
+	}
+}
+
+func TestSetQuota_RequiresAdmin(t *testing.T) {
+	h, ctx, fileID := setupQuotaShare(t, 1000, models.PermissionReadWrite)
+
+	status := setQuota(t, h, ctx, fileID, FileQuotaInfo{QuotaThreshold: quotaNoLimit, QuotaLimit: 1 << 40, SID: GetSIDMapper().UserSID(1000)})
+	if status != types.StatusAccessDenied {
+		t.Fatalf("status = %v, want STATUS_ACCESS_DENIED", status)
+	}
+	if _, ok := h.Registry.GetMetadataService().GetIdentityQuota(quotaTestShare, metadata.QuotaScopeUser, 1000); ok {
+		t.Error("non-admin raised their own quota")
+	}
+}
+
+func TestFsControlInformation(t *testing.T) {
+	h, ctx, fileID := setupQuotaShare(t, 0, models.PermissionAdmin)
+	svc := h.Registry.GetMetadataService()
+
+	query := func() *FileFsControlInfo {
+		t.Helper()
+		resp, err := h.QueryInfo(ctx, &QueryInfoRequest{
+			InfoType:           types.SMB2InfoTypeFilesystem,
+			FileInfoClass:      6,
+			OutputBufferLength: 4096,
+			FileID:             fileID,
+		})
+		if err != nil || resp.Status != types.StatusSuccess {
+			t.Fatalf("QueryInfo: status=%v err=%v", resp.Status, err)
+		}
+		info, err := DecodeFileFsControlInfo(resp.Data)
+		if err != nil {
+			t.Fatalf("DecodeFileFsControlInfo: %v", err)
+		}
+		return info
+	}
+
+	info := query()
+	if info.DefaultQuotaThreshold != quotaNoLimit || info.DefaultQuotaLimit != quotaNoLimit || info.FileSystemControlFlags != fileVCQuotaEnforce {
+		t.Fatalf("initial control info = %+v", info)
+	}
+
+	resp, err := h.SetInfo(ctx, &SetInfoRequest{
+		InfoType:      types.SMB2InfoTypeFilesystem,
+		FileInfoClass: 6,
+		FileID:        fileID,
+		Buffer: EncodeFileFsControlInfo(&FileFsControlInfo{
+			DefaultQuotaThreshold: 4 << 20, DefaultQuotaLimit: 5 << 20, FileSystemControlFlags: fileVCQuotaEnforce,
+		}),
+	})
+	if err != nil || resp.Status != types.StatusSuccess {
+		t.Fatalf("SetInfo: status=%v err=%v", resp.Status, err)
+	}
+	if dq, ok := svc.GetIdentityQuota(quotaTestShare, metadata.QuotaScopeUser, metadata.DefaultUserID); !ok || dq.SoftBytes != 4<<20 || dq.LimitBytes != 5<<20 {
+		t.Fatalf("default quota = %+v, %v", dq, ok)
+	}
+	if info := query(); info.DefaultQuotaThreshold != 4<<20 || info.DefaultQuotaLimit != 5<<20 {
+		t.Errorf("control info after set = %+v", info)
+	}
+}
+
+func TestFsFullSizeInformation_PerCaller(t *testing.T) {
+	h, ctx, fileID := setupQuotaShare(t, 1000, models.PermissionReadWrite)
+	h.Registry.GetMetadataService().SetQuotaForShare(quotaTestShare, 100<<20)
+	setUserQuota(h, 1000, 0, 10<<20)
+
+	resp, err := h.QueryInfo(ctx, &QueryInfoRequest{
+		InfoType:           types.SMB2InfoTypeFilesystem,
+		FileInfoClass:      7,
+		OutputBufferLength: 4096,
+		FileID:             fileID,
+	})
+	if err != nil || resp.Status != types.StatusSuccess {
+		t.Fatalf("QueryInfo: status=%v err=%v", resp.Status, err)
+	}
+	total := binary.LittleEndian.Uint64(resp.Data[0:])
+	caller := binary.LittleEndian.Uint64(resp.Data[8:])
+	actual := binary.LittleEndian.Uint64(resp.Data[16:])
+	if total != 10<<20/clusterSize || caller != 10<<20/clusterSize {
+		t.Errorf("total/caller units = %d/%d, want the caller's 10 MiB quota", total, caller)
+	}
+	if actual != 100<<20/clusterSize {
+		t.Errorf("actual units = %d, want the share's 100 MiB", actual)
+	}
+}
@@ -41,6 +41,10 @@ type smbRuntime interface {
 	// Returns (nil, nil) for a partially-wired runtime.
 	ShareRootGrantACL(ctx context.Context, shareName string) (*acl.ACL, error)
 
+	// SetIdentityQuotaLimits persists per-user quota limits changed through
+	// SET_INFO (SMB2_0_INFO_QUOTA and FileFsControlInformation).
+	SetIdentityQuotaLimits(ctx context.Context, shareName string, iq metadata.IdentityQuota) error
+
 	// Share-change notification (tree-connect cache invalidation).
 	OnShareChange(callback func(shares []string)) func()
 
@@ -1136,6 +1136,19 @@ func (h *Handler) completeNTLMAuth(ctx *SMBHandlerContext, securityBuffer []byte
 		// Look up user by resolved username
 		user, err := userStore.GetUser(ctx.Context, resolvedUsername)
 		if err == nil && user != nil && user.Enabled {
+			// A locked-out account is refused before its response is
+			// checked, so guessing over SMB stops with the same lockout as
+			// API logins.
+			if user.IsLockedOut(time.Now()) {
+				logger.Warn("SMB logon refused: account is locked out",
+					"username", user.Username,
+					"client", pending.ClientAddr)
+				if pending.IsReauth {
+					h.destroySessionOnReauthFailure(ctx.Context, pending, authMsg.Username)
+				}
+				return NewErrorResult(types.StatusAccountLockedOut), nil
+			}
+
 			// User found and enabled - validate NTLMv2 response if NT hash is available
 			ntHash, hasNTHash := user.GetNTHash()
 
@@ -1200,8 +1213,10 @@ func (h *Handler) completeNTLMAuth(ctx *SMBHandlerContext, securityBuffer []byte
 						errors.Is(validationErr, auth.ErrResponseTooShort) {
 						return NewErrorResult(types.StatusInvalidParameter), nil
 					}
+					recordLogonFailure(ctx.Context, userStore, user.Username, pending.ClientAddr)
 					return NewErrorResult(types.StatusLogonFailure), nil
 				}
+				recordLogonSuccess(ctx.Context, userStore, user)
 
 				// Derive the final signing key
 				// When KEY_EXCH is negotiated, the client sends an encrypted random session key
@@ -1276,6 +1291,19 @@ func (h *Handler) completeNTLMAuth(ctx *SMBHandlerContext, securityBuffer []byte
 				return h.buildAuthenticatedResponse(pending, signingKey[:], authMsg.NegotiateFlags, sess.ShouldEncrypt()), nil
 			}
 
+			// A user provisioned from the directory (LDAP login) has no NT
+			// hash by design: its password lives in the domain, so the
+			// response is validated by the DC, and the session runs as this
+			// local user once the DC identity resolves back to it. Other
+			// hashless users (OIDC-provisioned, service accounts) never
+			// pass through. Fails closed to LOGON_FAILURE below.
+			if !hasNTHash && len(authMsg.NtChallengeResponse) > 0 && !pending.IsReauth &&
+				h.isDirectoryUser(ctx.Context, user) {
+				if res, handled := h.tryNetlogon(ctx, pending, authMsg, user); handled {
+					return res, nil
+				}
+			}
+
 			// SECURITY: User exists and is enabled, but either has no NT hash
 			// configured or presented no NTLM response to validate against it.
 			// We CANNOT prove the client controls this account, so we MUST NOT
@@ -1325,11 +1353,7 @@ func (h *Handler) completeNTLMAuth(ctx *SMBHandlerContext, securityBuffer []byte
 			// execution falls through to the LOGON_FAILURE / reauth-destroy paths
 			// below (never guest, never partial session, never a replaced session).
 			if !pending.IsReauth {
-				if pending.IsBinding {
-					if res, handled := h.tryNetlogonBind(ctx, pending, authMsg); handled {
-						return res, nil
-					}
-				} else if res, handled := h.tryNetlogonFallback(ctx, pending, authMsg); handled {
+				if res, handled := h.tryNetlogon(ctx, pending, authMsg, nil); handled {
 					return res, nil
 				}
 			}
@@ -1364,6 +1388,61 @@ func (h *Handler) completeNTLMAuth(ctx *SMBHandlerContext, securityBuffer []byte
 	return h.createGuestSessionWithID(ctx, pending)
 }
 
+// tryNetlogon validates the NTLM response with a Domain Controller for a
+// user whose password DittoFS does not hold, dispatching to tryNetlogonBind
+// for a session bind and tryNetlogonFallback for a fresh session. local is
+// the directory-provisioned local user the name matched, or nil when there
+// is no local account. Never called on re-auth.
+func (h *Handler) tryNetlogon(ctx *SMBHandlerContext, pending *PendingAuth, authMsg *auth.AuthenticateMessage, local *models.User) (*HandlerResult, bool) {
+	if pending.IsBinding {
+		return h.tryNetlogonBind(ctx, pending, authMsg, local)
+	}
+	return h.tryNetlogonFallback(ctx, pending, authMsg, local)
+}
+
+// isDirectoryUser reports whether a local user without an NT hash may be
+// validated by the DC: it must be an interactive account linked to the
+// directory by an "ldap" identity mapping (the link an LDAP login creates).
+// Fails closed when the mapping store is unavailable.
+func (h *Handler) isDirectoryUser(ctx context.Context, user *models.User) bool {
+	if user.ServiceAccount {
+		return false
+	}
+	ims := h.Registry.GetIdentityMappingStore()
+	if ims == nil {
+		return false
+	}
+	mappings, err := ims.ListIdentityMappingsForUser(ctx, user.Username)
+	if err != nil {
+		logger.Warn("Identity mapping lookup failed; refusing NETLOGON pass-through",
+			"username", user.Username, "error", err)
+		return false
+	}
+	for _, m := range mappings {
+		if m.ProviderName == models.IdentityProviderTypeLDAP {
+			return true
+		}
+	}
+	return false
+}
+
+// netlogonSessionUser picks the user a NETLOGON-validated session runs as.
+// With no local account it is synthesized from the DC-resolved identity. With
+// one, the DC identity must resolve to the local user's UID, otherwise a
+// domain account that merely shares the name would log in as the local user
+// (or as whatever the DC says); nil is returned and the caller fails closed.
+func netlogonSessionUser(resolved *pkgidentity.ResolvedIdentity, local *models.User) *models.User {
+	if local == nil {
+		return synthUserFromResolved(resolved)
+	}
+	if local.UID == nil || *local.UID != resolved.UID {
+		logger.Warn("SECURITY: NETLOGON identity does not match the local directory user; refusing logon",
+			"username", local.Username, "resolvedUsername", resolved.Username, "resolvedUID", resolved.UID)
+		return nil
+	}
+	return local
+}
+
 // tryNetlogonFallback authenticates a domain user that has no local
 // control-plane account by forwarding the NTLM challenge/response to a Domain
 // Controller over a NETLOGON secure channel (#1314), then synthesizing a
@@ -1385,7 +1464,7 @@ func (h *Handler) completeNTLMAuth(ctx *SMBHandlerContext, securityBuffer []byte
 // the caller): a bind must authenticate the same user already on the session,
 // and a re-auth must not resurrect a passed-through identity in place of the
 // original.
-func (h *Handler) tryNetlogonFallback(ctx *SMBHandlerContext, pending *PendingAuth, authMsg *auth.AuthenticateMessage) (*HandlerResult, bool) {
+func (h *Handler) tryNetlogonFallback(ctx *SMBHandlerContext, pending *PendingAuth, authMsg *auth.AuthenticateMessage, local *models.User) (*HandlerResult, bool) {
 	// Disabled (no authenticator injected): behave exactly as before — the
 	// caller returns STATUS_LOGON_FAILURE for the unknown domain user.
 	if h.NetlogonAuth == nil {
@@ -1421,9 +1500,12 @@ func (h *Handler) tryNetlogonFallback(ctx *SMBHandlerContext, pending *PendingAu
 			"username", authMsg.Username, "userSID", res.UserSID)
 		return nil, false
 	}
-	user := synthUserFromResolved(resolved)
+	user := netlogonSessionUser(resolved, local)
+	if user == nil {
+		return nil, false
+	}
 
-	logger.Info("NETLOGON pass-through: authenticated directory-resolved domain user (no local account)",
+	logger.Info("NETLOGON pass-through: authenticated directory-resolved domain user",
 		"username", user.Username, "uid", resolved.UID, "gid", resolved.GID,
 		"userSID", res.UserSID, "domain", res.DomainName)
 
@@ -1487,7 +1569,7 @@ func (h *Handler) tryNetlogonFallback(ctx *SMBHandlerContext, pending *PendingAu
 //
 // Only called on a binding (IsBinding), non-reauth SESSION_SETUP (enforced by
 // the caller): a re-auth must not resurrect a passed-through identity.
-func (h *Handler) tryNetlogonBind(ctx *SMBHandlerContext, pending *PendingAuth, authMsg *auth.AuthenticateMessage) (*HandlerResult, bool) {
+func (h *Handler) tryNetlogonBind(ctx *SMBHandlerContext, pending *PendingAuth, authMsg *auth.AuthenticateMessage, local *models.User) (*HandlerResult, bool) {
 	// Disabled (no authenticator injected): behave exactly as before — the
 	// caller returns STATUS_LOGON_FAILURE for the unknown domain user, leaving
 	// the existing session untouched.
@@ -1524,7 +1606,10 @@ func (h *Handler) tryNetlogonBind(ctx *SMBHandlerContext, pending *PendingAuth,
 			"username", authMsg.Username, "userSID", res.UserSID)
 		return nil, false
 	}
-	user := synthUserFromResolved(resolved)
+	user := netlogonSessionUser(resolved, local)
+	if user == nil {
+		return nil, false
+	}
 
 	// Derive the channel signing key from the DC-provided session base key,
 	// honoring KEY_EXCH exactly as tryNetlogonFallback and the local-user bind
@@ -1537,7 +1622,7 @@ func (h *Handler) tryNetlogonBind(ctx *SMBHandlerContext, pending *PendingAuth,
 
 	ctx.IsGuest = false
 
-	logger.Info("NETLOGON bind pass-through: validated directory-resolved domain user (no local account)",
+	logger.Info("NETLOGON bind pass-through: validated directory-resolved domain user",
 		"username", user.Username, "userSID", res.UserSID, "domain", res.DomainName)
 
 	// completeSessionBind verifies the resolved identity matches the existing
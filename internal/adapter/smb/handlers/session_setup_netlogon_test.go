@@ -7,7 +7,9 @@ import (
 
 	"github.com/marmos91/dittofs/internal/adapter/smb/types"
 	"github.com/marmos91/dittofs/internal/auth/netlogon"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
 	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
 	pkgidentity "github.com/marmos91/dittofs/pkg/identity"
 )
 
@@ -201,3 +203,88 @@ func TestCompleteNTLMAuth_NetlogonDisabled(t *testing.T) {
 		t.Fatalf("status = 0x%08x, want LOGON_FAILURE", uint32(result.Status))
 	}
 }
+
+// newDirectoryUserHandler builds a netlogon fallback handler whose store holds
+// a hashless local user carol with the given UID. With linked set, carol has
+// the "ldap" identity mapping an LDAP login creates. The DC resolves carol's
+// SID to dcUID.
+func newDirectoryUserHandler(t *testing.T, nl *fakeNetlogon, localUID, dcUID uint32, linked, serviceAccount bool) *Handler {
+	t.Helper()
+	const sid = "S-1-5-21-1111111111-2222222222-3333333333-1104"
+	nl.res = &netlogon.LogonResult{UserSID: sid, Username: "carol", DomainName: "CORP"}
+	provider := &fakeIdentityProvider{
+		name:     "netlogon",
+		wantSID:  sid,
+		resolved: &pkgidentity.ResolvedIdentity{Username: "carol", UID: dcUID, GID: dcUID, SID: sid, Found: true},
+	}
+	h := newNetlogonFallbackHandler(t, nl, provider)
+	cpStore := newInMemoryStoreForTest(t)
+	ctx := context.Background()
+	if _, err := cpStore.CreateUser(ctx, &models.User{Username: "carol", Enabled: true, UID: &localUID, ServiceAccount: serviceAccount}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if linked {
+		if err := cpStore.(store.IdentityMappingStore).CreateIdentityMapping(ctx, &models.IdentityMapping{ProviderName: models.IdentityProviderTypeLDAP, Principal: "carol@CORP.EXAMPLE", Username: "carol"}); err != nil {
+			t.Fatalf("CreateIdentityMapping: %v", err)
+		}
+	}
+	h.Registry = runtime.New(cpStore)
+	return h
+}
+
+// TestCompleteNTLMAuth_DirectoryUserPassthrough: a user provisioned by an LDAP
+// login exists locally but has no NT hash; its response is validated by the DC
+// instead of being rejected outright, and the session runs as the local user.
+func TestCompleteNTLMAuth_DirectoryUserPassthrough(t *testing.T) {
+	const uid = uint32(20001)
+	nl := &fakeNetlogon{}
+	h := newDirectoryUserHandler(t, nl, uid, uid, true, false)
+
+	result, sessionID := driveDomainTYPE3(t, h, "carol", "CORP")
+
+	if result.Status.IsError() {
+		t.Fatalf("expected success, got status 0x%08x", uint32(result.Status))
+	}
+	if !nl.called {
+		t.Fatal("NETLOGON authenticator was never called for a user without an NT hash")
+	}
+	sess, ok := h.GetSession(sessionID)
+	if !ok || sess.IsGuest || sess.User == nil || sess.User.UID == nil || *sess.User.UID != uid {
+		t.Fatalf("session = %v (ok=%v), want authenticated carol with UID %d", sess, ok, uid)
+	}
+}
+
+// TestCompleteNTLMAuth_DirectoryUserPassthroughRefused: hashless users that
+// are not directory-linked, service accounts, and DC identities that do not
+// resolve back to the matched local user all fail closed.
+func TestCompleteNTLMAuth_DirectoryUserPassthroughRefused(t *testing.T) {
+	tests := []struct {
+		name           string
+		dcUID          uint32
+		linked         bool
+		serviceAccount bool
+		wantCalled     bool
+	}{
+		{name: "no ldap mapping", dcUID: 20001},
+		{name: "service account", dcUID: 20001, linked: true, serviceAccount: true},
+		{name: "DC identity is another user", dcUID: 30001, linked: true, wantCalled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nl := &fakeNetlogon{}
+			h := newDirectoryUserHandler(t, nl, 20001, tt.dcUID, tt.linked, tt.serviceAccount)
+
+			result, sessionID := driveDomainTYPE3(t, h, "carol", "CORP")
+
+			if result.Status != types.StatusLogonFailure {
+				t.Fatalf("status = 0x%08x, want LOGON_FAILURE", uint32(result.Status))
+			}
+			if nl.called != tt.wantCalled {
+				t.Errorf("NETLOGON called = %v, want %v", nl.called, tt.wantCalled)
+			}
+			if sess, ok := h.GetSession(sessionID); ok {
+				t.Fatalf("a session was created for a refused pass-through: user=%v guest=%v", sess.User, sess.IsGuest)
+			}
+		})
+	}
+}
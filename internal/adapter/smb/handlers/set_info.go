@@ -190,8 +190,8 @@ func decodeEndOfFileInfo(buffer []byte) (uint64, error) {
 //
 // SET_INFO modifies metadata for an open file handle including timestamps,
 // attributes, file size, rename/move operations, delete-on-close disposition,
-// and security descriptors. Dispatches to file or security info handlers
-// based on InfoType.
+// security descriptors, and per-user quotas. Dispatches to file, filesystem,
+// security, or quota info handlers based on InfoType.
 func (h *Handler) SetInfo(ctx *SMBHandlerContext, req *SetInfoRequest) (*SetInfoResponse, error) {
 	logger.Debug("SET_INFO request",
 		"infoType", req.InfoType,
@@ -271,6 +271,15 @@ func (h *Handler) SetInfo(ctx *SMBHandlerContext, req *SetInfoRequest) (*SetInfo
 			secAuthCtx = authCtx
 		}
 		return h.setSecurityInfo(secAuthCtx, openFile, req.AdditionalInfo, req.Buffer)
+	case types.SMB2InfoTypeFilesystem:
+		// FileFsControlInformation (the default quota) is the only settable
+		// filesystem class.
+		if req.FileInfoClass != 6 {
+			return setInfoStatus(types.StatusInvalidParameter), nil
+		}
+		return h.setFsControlInfo(ctx, openFile, req.Buffer)
+	case types.SMB2InfoTypeQuota:
+		return h.setQuotaInfo(ctx, openFile, req.Buffer)
 	default:
 		return setInfoStatus(types.StatusInvalidParameter), nil
 	}
@@ -52,6 +52,10 @@ const (
 	// This is expected at the end of QUERY_DIRECTORY operations.
 	StatusNoMoreFiles Status = 0x80000006
 
+	// StatusNoMoreEntries indicates a quota enumeration (QUERY_INFO
+	// SMB2_0_INFO_QUOTA) has returned every entry.
+	StatusNoMoreEntries Status = 0x8000001A
+
 	// Error codes (severity = 11, both high bits set)
 
 	// StatusUnsuccessful indicates the request failed for unspecified reasons.
@@ -115,6 +119,10 @@ const (
 	// StatusLogonFailure indicates authentication failed.
 	StatusLogonFailure Status = 0xC000006D
 
+	// StatusAccountLockedOut indicates the account is locked after too many
+	// failed logons [MS-ERREF §2.3.1].
+	StatusAccountLockedOut Status = 0xC0000234
+
 	// StatusPrivilegeNotHeld indicates the caller does not hold a required
 	// privilege [MS-ERREF §2.3.1]. Surfaced for CREATE requests that ask
 	// for SEC_FLAG_SYSTEM_SECURITY (0x01000000, "ACCESS_SYSTEM_SECURITY")
@@ -295,6 +303,8 @@ func (s Status) String() string {
 		return "STATUS_MORE_ENTRIES"
 	case StatusNoMoreFiles:
 		return "STATUS_NO_MORE_FILES"
+	case StatusNoMoreEntries:
+		return "STATUS_NO_MORE_ENTRIES"
 	case StatusAccessDenied:
 		return "STATUS_ACCESS_DENIED"
 	case StatusBufferOverflow:
@@ -347,6 +357,8 @@ func (s Status) String() string {
 		return "STATUS_REQUEST_NOT_ACCEPTED"
 	case StatusLogonFailure:
 		return "STATUS_LOGON_FAILURE"
+	case StatusAccountLockedOut:
+		return "STATUS_ACCOUNT_LOCKED_OUT"
 	case StatusPathNotCovered:
 		return "STATUS_PATH_NOT_COVERED"
 	case StatusNetworkNameDeleted:
@@ -0,0 +1,479 @@
+// Package changefeed publishes the change events produced by metadata.Service
+// to consumers outside the server: server-sent-event subscribers of the
+// control-plane API, webhooks and a NATS server.
+//
+// The Broker keeps the most recent events of every share in a ring buffer so
+// a subscriber that reconnects can resume from its last cursor. Cursors are
+// "<epoch>-<seq>": seq orders all events of one server process and epoch
+// identifies the process, so a cursor handed out before a restart, or one
+// that has fallen out of the ring, is detected and answered with a reset
+// rather than silently skipping events.
+//
+// Writes produce one modified event per WRITE. The Broker holds them back
+// until the file has been quiet for the settle window and publishes one event
+// carrying the final size, which is what a consumer waiting for a file to
+// "land" wants.
+package changefeed
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// Event is a change event with its position in the stream.
+type Event struct {
+	// ID is the resumable cursor of this event.
+	ID  string `json:"id"`
+	Seq uint64 `json:"-"`
+	metadata.ChangeEvent
+}
+
+// Filter selects events. Zero-valued fields match everything.
+type Filter struct {
+	Shares []string
+	Types  []metadata.ChangeEventType
+
+	// PathPrefix matches events whose path, or source path for a rename,
+	// is the prefix itself or lies beneath it.
+	PathPrefix string
+}
+
+// Match reports whether ev passes the filter.
+func (f Filter) Match(ev *Event) bool {
+	if len(f.Shares) > 0 && !contains(f.Shares, ev.Share) {
+		return false
+	}
+	if len(f.Types) > 0 && !contains(f.Types, ev.Type) {
+		return false
+	}
+	if f.PathPrefix != "" && !underPath(ev.Path, f.PathPrefix) && !underPath(ev.OldPath, f.PathPrefix) {
+		return false
+	}
+	return true
+}
+
+func contains[T comparable](list []T, v T) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// underPath reports whether path is prefix or lies beneath it.
+func underPath(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return path != ""
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// Sink receives events on a dedicated goroutine. Send may retry internally;
+// ctx is cancelled when the broker gives up on draining at shutdown.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, ev *Event) error
+	Close() error
+}
+
+// subscriberBuffer bounds the events queued for one subscriber. A subscriber
+// that falls further behind is disconnected and resumes from its cursor.
+const subscriberBuffer = 256
+
+// closeGrace bounds how long Close waits for sinks to drain.
+const closeGrace = 10 * time.Second
+
+// Broker buffers and fans out change events. It implements
+// metadata.ChangeListener.
+type Broker struct {
+	bufferSize int
+	settle     time.Duration
+	epoch      string
+
+	mu       sync.Mutex
+	closed   bool
+	seq      uint64
+	rings    map[string]*ring
+	subs     map[*Subscription]struct{}
+	settling map[settleKey]*settlingEvent
+	sinks    []*sinkWorker
+
+	sinkCtx    context.Context
+	sinkCancel context.CancelFunc
+	sinkWG     sync.WaitGroup
+}
+
+var _ metadata.ChangeListener = (*Broker)(nil)
+
+// NewBroker creates a broker retaining up to bufferSize events per share.
+// settle <= 0 publishes every modified event as it arrives.
+func NewBroker(bufferSize int, settle time.Duration) *Broker {
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Broker{
+		bufferSize: bufferSize,
+		settle:     settle,
+		epoch:      strconv.FormatInt(time.Now().UnixNano(), 36),
+		rings:      make(map[string]*ring),
+		subs:       make(map[*Subscription]struct{}),
+		settling:   make(map[settleKey]*settlingEvent),
+		sinkCtx:    ctx,
+		sinkCancel: cancel,
+	}
+}
+
+// AddSink delivers the events matching filter to sink on its own goroutine,
+// queueing up to queueSize of them. Events arriving while the queue is full
+// are dropped and counted.
+func (b *Broker) AddSink(sink Sink, filter Filter, queueSize int) {
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+	w := &sinkWorker{sink: sink, filter: filter, queue: make(chan *Event, queueSize)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		_ = sink.Close()
+		return
+	}
+	b.sinks = append(b.sinks, w)
+	b.sinkWG.Add(1)
+	go w.run(b.sinkCtx, &b.sinkWG)
+}
+
+// WantsChanges implements metadata.ChangeListener: every share is buffered
+// while the broker runs, so a subscriber can resume after a disconnect.
+func (b *Broker) WantsChanges(string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.closed
+}
+
+// OnChange implements metadata.ChangeListener.
+func (b *Broker) OnChange(ev *metadata.ChangeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	switch ev.Type {
+	case metadata.ChangeModified:
+		if b.settle > 0 {
+			b.holdLocked(*ev)
+			return
+		}
+	case metadata.ChangeRemoved, metadata.ChangeRenamed:
+		// Publish what was written before the file goes away or moves, so
+		// consumers see the events in the order they happened.
+		b.releaseLocked(settleKey{ev.Share, ev.Path})
+		if ev.OldPath != "" {
+			b.releaseLocked(settleKey{ev.Share, ev.OldPath})
+		}
+	}
+	b.publishLocked(*ev)
+}
+
+// Cursor returns the cursor of the latest event, from which a subscriber
+// resumes with nothing missed.
+func (b *Broker) Cursor() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cursor(b.seq)
+}
+
+func (b *Broker) cursor(seq uint64) string {
+	return b.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// publishLocked assigns ev its position, stores it and fans it out.
+func (b *Broker) publishLocked(ev metadata.ChangeEvent) {
+	b.seq++
+	e := &Event{ID: b.cursor(b.seq), Seq: b.seq, ChangeEvent: ev}
+
+	r := b.rings[ev.Share]
+	if r == nil {
+		r = &ring{buf: make([]*Event, b.bufferSize)}
+		b.rings[ev.Share] = r
+	}
+	r.push(e)
+
+	for sub := range b.subs {
+		if sub.share != ev.Share || !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.c <- e:
+		default:
+			sub.lagged.Store(true)
+			b.dropSubLocked(sub)
+		}
+	}
+	for _, w := range b.sinks {
+		if w.filter.Match(e) {
+			w.enqueue(e)
+		}
+	}
+}
+
+// Subscription is a live view of one share's events.
+type Subscription struct {
+	b      *Broker
+	share  string
+	filter Filter
+	c      chan *Event
+
+	// Backlog holds the buffered events after the requested cursor, to be
+	// delivered before anything received on C.
+	Backlog []*Event
+
+	// Reset is set when the requested cursor could not be resumed: it
+	// belongs to an earlier server process or its events have left the
+	// buffer. The subscriber should rescan the share.
+	Reset bool
+
+	// Cursor is the position the subscription starts from: resuming from
+	// it after a reset misses nothing published since.
+	Cursor string
+
+	lagged atomic.Bool
+}
+
+// C delivers events published after the subscription was made. It is closed
+// when the subscriber falls too far behind (see Lagged) or the broker closes.
+func (s *Subscription) C() <-chan *Event { return s.c }
+
+// Lagged reports whether C was closed because the subscriber fell behind.
+func (s *Subscription) Lagged() bool { return s.lagged.Load() }
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	s.b.dropSubLocked(s)
+}
+
+func (b *Broker) dropSubLocked(sub *Subscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.c)
+	}
+}
+
+// ErrClosed is returned by Subscribe after Close.
+var ErrClosed = errors.New("change feed closed")
+
+// Subscribe follows share's events matching filter. An empty cursor starts
+// from now; otherwise the buffered events after the cursor are returned in
+// Backlog, or Reset is set when the cursor cannot be resumed.
+func (b *Broker) Subscribe(share, cursor string, filter Filter) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	sub := &Subscription{
+		b:      b,
+		share:  share,
+		filter: filter,
+		c:      make(chan *Event, subscriberBuffer),
+		Cursor: b.cursor(b.seq),
+	}
+
+	if cursor != "" {
+		seq, ok := b.parseCursor(cursor)
+		r := b.rings[share]
+		switch {
+		case !ok:
+			sub.Reset = true
+		case r != nil && seq < r.evicted:
+			sub.Reset = true
+		case r != nil:
+			r.each(func(e *Event) {
+				if e.Seq > seq && filter.Match(e) {
+					sub.Backlog = append(sub.Backlog, e)
+				}
+			})
+		}
+	}
+	b.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// parseCursor returns the sequence number of a cursor issued by this broker.
+func (b *Broker) parseCursor(cursor string) (uint64, bool) {
+	epoch, seqStr, ok := strings.Cut(cursor, "-")
+	if !ok || epoch != b.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil || seq > b.seq {
+		return 0, false
+	}
+	return seq, true
+}
+
+// Close publishes held modified events, ends every subscription and drains
+// the sinks (bounded by a grace period) before closing them.
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	for key := range b.settling {
+		b.releaseLocked(key)
+	}
+	b.closed = true
+	for sub := range b.subs {
+		b.dropSubLocked(sub)
+	}
+	sinks := b.sinks
+	for _, w := range sinks {
+		close(w.queue)
+	}
+	b.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		b.sinkWG.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(closeGrace):
+		b.sinkCancel()
+		<-drained
+	}
+	b.sinkCancel()
+
+	var firstErr error
+	for _, w := range sinks {
+		if err := w.sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if n := w.dropped.Load(); n > 0 {
+			logger.Warn("Change event sink dropped events", "sink", w.sink.Name(), "dropped", n)
+		}
+	}
+	return firstErr
+}
+
+// settleKey identifies a file whose modified events are being held.
+type settleKey struct{ share, path string }
+
+type settlingEvent struct {
+	event    metadata.ChangeEvent
+	deadline time.Time
+	timer    *time.Timer
+}
+
+// holdLocked defers a modified event until the file has been quiet for the
+// settle window, replacing any event already held for it.
+func (b *Broker) holdLocked(ev metadata.ChangeEvent) {
+	key := settleKey{ev.Share, ev.Path}
+	deadline := time.Now().Add(b.settle)
+	if p := b.settling[key]; p != nil {
+		p.event = ev
+		p.deadline = deadline
+		p.timer.Reset(b.settle)
+		return
+	}
+	p := &settlingEvent{event: ev, deadline: deadline}
+	p.timer = time.AfterFunc(b.settle, func() { b.settled(key) })
+	b.settling[key] = p
+}
+
+// settled fires when a held event's timer expires. A timer re-armed while
+// this callback waited for the lock leaves a later deadline, and fires again.
+func (b *Broker) settled(key settleKey) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p := b.settling[key]
+	if p == nil || b.closed || time.Now().Before(p.deadline) {
+		return
+	}
+	delete(b.settling, key)
+	b.publishLocked(p.event)
+}
+
+// releaseLocked publishes the event held for key, if any, immediately.
+func (b *Broker) releaseLocked(key settleKey) {
+	p := b.settling[key]
+	if p == nil {
+		return
+	}
+	p.timer.Stop()
+	delete(b.settling, key)
+	b.publishLocked(p.event)
+}
+
+// ring is a fixed-size buffer of one share's most recent events.
+type ring struct {
+	buf   []*Event
+	head  int // index of the oldest event
+	count int
+
+	// evicted is the sequence number of the newest event pushed out of the
+	// buffer; a cursor older than it has missed events.
+	evicted uint64
+}
+
+func (r *ring) push(e *Event) {
+	if r.count < len(r.buf) {
+		r.buf[(r.head+r.count)%len(r.buf)] = e
+		r.count++
+		return
+	}
+	r.evicted = r.buf[r.head].Seq
+	r.buf[r.head] = e
+	r.head = (r.head + 1) % len(r.buf)
+}
+
+func (r *ring) each(fn func(*Event)) {
+	for i := 0; i < r.count; i++ {
+		fn(r.buf[(r.head+i)%len(r.buf)])
+	}
+}
+
+// sinkWorker feeds one sink from a bounded queue.
+type sinkWorker struct {
+	sink    Sink
+	filter  Filter
+	queue   chan *Event
+	dropped atomic.Uint64
+}
+
+func (w *sinkWorker) enqueue(e *Event) {
+	select {
+	case w.queue <- e:
+	default:
+		if n := w.dropped.Add(1); n&(n-1) == 0 {
+			logger.Warn("Change event sink queue full, dropping events", "sink", w.sink.Name(), "dropped", n)
+		}
+	}
+}
+
+func (w *sinkWorker) run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for e := range w.queue {
+		if ctx.Err() != nil {
+			w.dropped.Add(1)
+			continue
+		}
+		if err := w.sink.Send(ctx, e); err != nil {
+			logger.Warn("Change event delivery failed", "sink", w.sink.Name(), "event", e.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package changefeed
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+func change(typ metadata.ChangeEventType, path string) *metadata.ChangeEvent {
+	return &metadata.ChangeEvent{Share: "/media", Type: typ, Path: path, FileType: "file"}
+}
+
+func TestBroker_ResumeFromCursor(t *testing.T) {
+	b := NewBroker(16, 0)
+	defer b.Close()
+
+	b.OnChange(change(metadata.ChangeCreated, "/a"))
+	cursor := b.Cursor()
+	b.OnChange(change(metadata.ChangeCreated, "/b"))
+	b.OnChange(&metadata.ChangeEvent{Share: "/other", Type: metadata.ChangeCreated, Path: "/x"})
+	b.OnChange(change(metadata.ChangeRemoved, "/a"))
+
+	sub, err := b.Subscribe("/media", cursor, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	if sub.Reset || len(sub.Backlog) != 2 || sub.Backlog[0].Path != "/b" || sub.Backlog[1].Type != metadata.ChangeRemoved {
+		t.Fatalf("reset=%v backlog=%+v", sub.Reset, sub.Backlog)
+	}
+
+	b.OnChange(change(metadata.ChangeCreated, "/c"))
+	select {
+	case ev := <-sub.C():
+		if ev.Path != "/c" {
+			t.Errorf("live event path = %q", ev.Path)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no live event")
+	}
+}
+
+func TestBroker_ResetOnStaleOrForeignCursor(t *testing.T) {
+	b := NewBroker(2, 0)
+	defer b.Close()
+
+	b.OnChange(change(metadata.ChangeCreated, "/a"))
+	cursor := b.Cursor()
+	for _, p := range []string{"/b", "/c", "/d"} {
+		b.OnChange(change(metadata.ChangeCreated, p))
+	}
+
+	for _, c := range []string{cursor, "zzz-1", "garbage"} {
+		sub, err := b.Subscribe("/media", c, Filter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sub.Reset || len(sub.Backlog) != 0 {
+			t.Errorf("cursor %q: reset=%v backlog=%d, want reset", c, sub.Reset, len(sub.Backlog))
+		}
+		sub.Close()
+	}
+}
+
+func TestBroker_FilterByTypeAndPath(t *testing.T) {
+	b := NewBroker(16, 0)
+	defer b.Close()
+	start := b.Cursor()
+
+	b.OnChange(change(metadata.ChangeCreated, "/in/a.mov"))
+	b.OnChange(change(metadata.ChangeCreated, "/inbox/b.mov"))
+	b.OnChange(change(metadata.ChangeRemoved, "/in/a.mov"))
+	b.OnChange(&metadata.ChangeEvent{Share: "/media", Type: metadata.ChangeRenamed, Path: "/out/c.mov", OldPath: "/in/c.mov"})
+
+	sub, err := b.Subscribe("/media", start, Filter{
+		Types:      []metadata.ChangeEventType{metadata.ChangeCreated, metadata.ChangeRenamed},
+		PathPrefix: "/in/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	if len(sub.Backlog) != 2 || sub.Backlog[0].Path != "/in/a.mov" || sub.Backlog[1].OldPath != "/in/c.mov" {
+		t.Fatalf("backlog = %+v", sub.Backlog)
+	}
+}
+
+func TestBroker_SettlesModified(t *testing.T) {
+	b := NewBroker(16, 50*time.Millisecond)
+	defer b.Close()
+	sub, _ := b.Subscribe("/media", "", Filter{})
+
+	for size := uint64(1); size <= 5; size++ {
+		ev := change(metadata.ChangeModified, "/a")
+		ev.Size = size * 100
+		b.OnChange(ev)
+	}
+	select {
+	case ev := <-sub.C():
+		if ev.Type != metadata.ChangeModified || ev.Size != 500 {
+			t.Fatalf("got %s size %d, want one modified of size 500", ev.Type, ev.Size)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("settled event never published")
+	}
+	select {
+	case ev := <-sub.C():
+		t.Fatalf("unexpected extra event %+v", ev)
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	// A held event is flushed ahead of a rename of the same file.
+	b.OnChange(change(metadata.ChangeModified, "/b"))
+	b.OnChange(&metadata.ChangeEvent{Share: "/media", Type: metadata.ChangeRenamed, Path: "/c", OldPath: "/b"})
+	if first := <-sub.C(); first.Type != metadata.ChangeModified || first.Path != "/b" {
+		t.Fatalf("first = %s %s, want modified /b", first.Type, first.Path)
+	}
+	if second := <-sub.C(); second.Type != metadata.ChangeRenamed {
+		t.Fatalf("second = %s, want renamed", second.Type)
+	}
+}
+
+func TestBroker_LaggingSubscriberIsDisconnected(t *testing.T) {
+	b := NewBroker(1024, 0)
+	defer b.Close()
+	sub, _ := b.Subscribe("/media", "", Filter{})
+	for i := 0; i < subscriberBuffer+1; i++ {
+		b.OnChange(change(metadata.ChangeCreated, "/a"))
+	}
+	n := 0
+	for range sub.C() {
+		n++
+	}
+	if !sub.Lagged() || n != subscriberBuffer {
+		t.Errorf("lagged=%v delivered=%d", sub.Lagged(), n)
+	}
+}
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []*Event
+	closed bool
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+func (s *recordingSink) Send(_ context.Context, ev *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, ev)
+	return nil
+}
+func (s *recordingSink) Close() error { s.closed = true; return nil }
+
+func TestBroker_SinksDrainedOnClose(t *testing.T) {
+	b := NewBroker(16, time.Hour)
+	sink := &recordingSink{}
+	b.AddSink(sink, Filter{Shares: []string{"/media"}}, 16)
+
+	b.OnChange(change(metadata.ChangeCreated, "/a"))
+	b.OnChange(&metadata.ChangeEvent{Share: "/other", Type: metadata.ChangeCreated, Path: "/x"})
+	b.OnChange(change(metadata.ChangeModified, "/a"))
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !sink.closed || len(sink.events) != 2 || sink.events[1].Type != metadata.ChangeModified {
+		t.Fatalf("closed=%v events=%+v", sink.closed, sink.events)
+	}
+	if b.WantsChanges("/media") {
+		t.Error("closed broker still wants changes")
+	}
+}
+
+func TestWebhookSink_SignsAndRetries(t *testing.T) {
+	var calls atomic.Int32
+	var got struct {
+		body []byte
+		hdr  http.Header
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		got.body, _ = io.ReadAll(r.Body)
+		got.hdr = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	s := NewWebhookSink("media", srv.URL, WebhookOptions{Secret: "s3cret", Timeout: time.Second, MaxRetries: 2, Backoff: time.Millisecond})
+	ev := &Event{ID: "e-7", Seq: 7, ChangeEvent: *change(metadata.ChangeCreated, "/a.mov")}
+	if err := s.Send(context.Background(), ev); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("calls = %d, want 2", calls.Load())
+	}
+	ts := got.hdr.Get(HeaderTimestamp)
+	if want := Sign("s3cret", ts, got.body); got.hdr.Get(HeaderSignature) != want {
+		t.Errorf("signature %q, want %q", got.hdr.Get(HeaderSignature), want)
+	}
+	if got.hdr.Get(HeaderDelivery) != "e-7" || got.hdr.Get(HeaderEvent) != "created" {
+		t.Errorf("headers %v", got.hdr)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(got.body, &decoded); err != nil || decoded["id"] != "e-7" || decoded["path"] != "/a.mov" {
+		t.Errorf("body %s (err %v)", got.body, err)
+	}
+}
+
+func TestWebhookSink_ClientErrorNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	s := NewWebhookSink("media", srv.URL, WebhookOptions{MaxRetries: 3, Backoff: time.Millisecond})
+	if err := s.Send(context.Background(), &Event{ID: "e-1"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestNATSSink_Publishes(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	type pub struct{ subject, payload, connect string }
+	got := make(chan pub, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		_, _ = conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		connect, _ := r.ReadString('\n')
+		_, _ = r.ReadString('\n') // PING
+		_, _ = conn.Write([]byte("PONG\r\n"))
+		header, _ := r.ReadString('\n')
+		payload, _ := r.ReadString('\n')
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			got <- pub{}
+			return
+		}
+		got <- pub{fields[1], strings.TrimSpace(payload), connect}
+	}()
+
+	s, err := NewNATSSink(NATSOptions{URL: "nats://" + ln.Addr().String(), Token: "tok"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ev := &Event{ID: "e-1", ChangeEvent: *change(metadata.ChangeCreated, "/a.mov")}
+	if err := s.Send(context.Background(), ev); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	select {
+	case p := <-got:
+		if p.subject != "dittofs.events.media.created" {
+			t.Errorf("subject = %q", p.subject)
+		}
+		if !strings.Contains(p.payload, `"path":"/a.mov"`) || !strings.Contains(p.connect, `"auth_token":"tok"`) {
+			t.Errorf("payload %q connect %q", p.payload, p.connect)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing published")
+	}
+}
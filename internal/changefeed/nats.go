@@ -0,0 +1,219 @@
+package changefeed
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/logger"
+)
+
+// NATSOptions configures a NATSSink.
+type NATSOptions struct {
+	// URL is nats://[user:password@]host[:port]. The port defaults to 4222.
+	URL string
+
+	// SubjectPrefix is prepended to "<share>.<type>". Default: dittofs.events
+	SubjectPrefix string
+
+	// Token, or User and Password, authenticate the connection; credentials
+	// in URL take precedence over User and Password.
+	Token    string
+	User     string
+	Password string
+}
+
+// NATSSink publishes each event as JSON on the subject
+// "<prefix>.<share>.<type>" (the share name with its leading slash removed and
+// other separators replaced by "_"). It speaks the core NATS text protocol
+// directly, so any NATS-compatible server works; there is no JetStream
+// acknowledgement, which makes delivery at-most-once.
+type NATSSink struct {
+	opts   NATSOptions
+	addr   string
+	user   string
+	pass   string
+	prefix string
+
+	mu   sync.Mutex
+	conn net.Conn
+	w    *bufio.Writer
+}
+
+// NewNATSSink validates opts. The connection is made on the first publish
+// and re-made after a failure, so an unreachable server does not block
+// startup.
+func NewNATSSink(opts NATSOptions) (*NATSSink, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parse NATS URL: %w", err)
+	}
+	if u.Scheme != "nats" {
+		return nil, fmt.Errorf("unsupported NATS URL scheme %q (want nats://)", u.Scheme)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	s := &NATSSink{opts: opts, addr: addr, user: opts.User, pass: opts.Password, prefix: opts.SubjectPrefix}
+	if u.User != nil {
+		s.user = u.User.Username()
+		s.pass, _ = u.User.Password()
+	}
+	if s.prefix == "" {
+		s.prefix = "dittofs.events"
+	}
+	return s, nil
+}
+
+// Name implements Sink.
+func (s *NATSSink) Name() string { return "nats:" + s.addr }
+
+// Subject returns the subject ev is published on.
+func (s *NATSSink) Subject(ev *Event) string {
+	return s.prefix + "." + subjectToken(ev.Share) + "." + string(ev.Type)
+}
+
+// subjectToken turns a share name into a single NATS subject token.
+func subjectToken(share string) string {
+	share = strings.Trim(share, "/")
+	if share == "" {
+		return "_root"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, share)
+}
+
+// Send implements Sink. A failed publish is retried once on a fresh
+// connection, which covers a restarted server.
+func (s *NATSSink) Send(ctx context.Context, ev *Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	subject := s.Subject(ev)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		if err = s.publishLocked(subject, payload); err == nil {
+			return nil
+		}
+		s.closeLocked()
+	}
+	if err := s.connectLocked(ctx); err != nil {
+		return err
+	}
+	return s.publishLocked(subject, payload)
+}
+
+func (s *NATSSink) publishLocked(subject string, payload []byte) error {
+	_ = s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	fmt.Fprintf(s.w, "PUB %s %d\r\n", subject, len(payload))
+	_, _ = s.w.Write(payload)
+	_, _ = s.w.WriteString("\r\n")
+	return s.w.Flush()
+}
+
+// connectLocked dials the server, completes the INFO/CONNECT handshake and
+// waits for the PONG that confirms the server accepted it.
+func (s *NATSSink) connectLocked(ctx context.Context) error {
+	d := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("connect to NATS: %w", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		_ = conn.Close()
+		return fmt.Errorf("NATS handshake: expected INFO, got %q: %v", strings.TrimSpace(line), err)
+	}
+
+	connect := map[string]any{
+		"verbose": false, "pedantic": false, "lang": "go", "name": "dittofs", "protocol": 0,
+	}
+	switch {
+	case s.opts.Token != "":
+		connect["auth_token"] = s.opts.Token
+	case s.user != "":
+		connect["user"] = s.user
+		connect["pass"] = s.pass
+	}
+	opts, _ := json.Marshal(connect)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", opts); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("NATS handshake: %w", err)
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("NATS handshake: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			_ = conn.Close()
+			return fmt.Errorf("NATS handshake: %s", line)
+		}
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	s.conn = conn
+	s.w = bufio.NewWriter(conn)
+	go s.readLoop(conn, r)
+	return nil
+}
+
+// readLoop answers server PINGs and logs protocol errors until conn closes.
+func (s *NATSSink) readLoop(conn net.Conn, r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PING":
+			s.mu.Lock()
+			if s.conn == conn {
+				_, _ = s.w.WriteString("PONG\r\n")
+				_ = s.w.Flush()
+			}
+			s.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			logger.Warn("NATS server error", "server", s.addr, "error", line)
+		}
+	}
+}
+
+func (s *NATSSink) closeLocked() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+		s.w = nil
+	}
+}
+
+// Close implements Sink.
+func (s *NATSSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked()
+	return nil
+}
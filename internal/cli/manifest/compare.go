@@ -0,0 +1,300 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/bytesize"
+	"github.com/marmos91/dittofs/pkg/schedule"
+)
+
+// redacted is the value the API reports in place of a secret. A secret's
+// live value is unknown, so it is never reported as drift.
+const redacted = "********"
+
+// fields is a JSON object decoded to its fields.
+type fields map[string]any
+
+// toFields decodes the JSON encoding of v. Fields omitted by omitempty are
+// absent, which is how a request type marks what it sets.
+func toFields(v any) (fields, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var f fields
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// into encodes f and decodes it into the request type T.
+func into[T any](f fields) (*T, error) {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	var v T
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// normalizer canonicalizes a field value so equivalent spellings ("10GiB"
+// and "10 GiB", "24h" and "@daily") compare equal.
+type normalizer func(any) any
+
+// normSize canonicalizes a byte size to the server's two-decimal display
+// form; "", "0" and unset are all zero.
+func normSize(v any) any {
+	var n bytesize.ByteSize
+	switch val := v.(type) {
+	case nil:
+	case float64:
+		n = bytesize.ByteSize(val)
+	case string:
+		if strings.TrimSpace(val) == "" {
+			break
+		}
+		parsed, err := bytesize.ParseByteSize(val)
+		if err != nil {
+			return v
+		}
+		n = parsed
+	default:
+		return v
+	}
+	return n.String()
+}
+
+// normDuration canonicalizes a Go duration; "", "0" and unset are zero.
+func normDuration(v any) any {
+	s, _ := v.(string)
+	if s == "" || s == "0" {
+		return time.Duration(0).String()
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return v
+	}
+	return d.String()
+}
+
+// normInterval canonicalizes a snapshot interval (a duration or @-shorthand).
+func normInterval(v any) any {
+	s, _ := v.(string)
+	d, err := schedule.ParseInterval(s)
+	if err != nil {
+		return v
+	}
+	return d.String()
+}
+
+// normSet sorts a string list whose order does not matter.
+func normSet(v any) any {
+	list, ok := v.([]any)
+	if !ok {
+		return v
+	}
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		out = append(out, fmt.Sprint(item))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// normExports canonicalizes an NFS subtree export list: paths take the
+// server's cleaned "/a/b" form and entry order does not matter.
+func normExports(v any) any {
+	list, ok := v.([]any)
+	if !ok {
+		return v
+	}
+	out := make([]any, 0, len(list))
+	for _, item := range list {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			return v
+		}
+		cleaned := maps.Clone(entry)
+		if p, ok := entry["path"].(string); ok {
+			cleaned["path"] = path.Clean("/" + p)
+		}
+		out = append(out, cleaned)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return fmt.Sprint(out[i].(map[string]any)["path"]) < fmt.Sprint(out[j].(map[string]any)["path"])
+	})
+	return out
+}
+
+// isZero reports whether v is a JSON zero value, which omitempty drops.
+func isZero(v any) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !val
+	case float64:
+		return val == 0
+	case string:
+		return val == ""
+	case []any:
+		return len(val) == 0
+	case []string:
+		return len(val) == 0
+	case map[string]any:
+		return len(val) == 0
+	}
+	return false
+}
+
+// equal compares a desired and a live value after normalization. A field
+// either side omits equals the zero value, also inside nested objects such
+// as a share's bandwidth policy.
+func equal(want, live any, norm normalizer) bool {
+	if norm != nil {
+		want, live = norm(want), norm(live)
+	}
+	if isZero(want) && isZero(live) {
+		return true
+	}
+	switch w := want.(type) {
+	case map[string]any:
+		l, ok := live.(map[string]any)
+		if !ok && live != nil {
+			return false
+		}
+		for k, v := range w {
+			if !equal(v, l[k], nil) {
+				return false
+			}
+		}
+		for k, v := range l {
+			if _, ok := w[k]; !ok && !isZero(v) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		l, ok := live.([]any)
+		if !ok || len(l) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !equal(w[i], l[i], nil) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(want, live)
+}
+
+// FieldChange is one field of a resource that differs from the manifest.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// diffFields compares every field set in want with the same field of live
+// and returns the fields that differ with their desired values. Live
+// secrets (reported redacted) are not compared.
+func diffFields(want, live fields, norms map[string]normalizer) ([]FieldChange, fields) {
+	keys := make([]string, 0, len(want))
+	for k := range want {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var changes []FieldChange
+	changed := fields{}
+	for _, k := range keys {
+		lv, ok := live[k]
+		if ok && lv == redacted {
+			continue
+		}
+		if equal(want[k], lv, norms[k]) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: k, From: display(lv), To: display(want[k])})
+		changed[k] = want[k]
+	}
+	return changes, changed
+}
+
+// display renders a field value for a plan.
+func display(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "(unset)"
+	case string:
+		return val
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// secretKey reports whether a store config key holds a secret, mirroring
+// the server's redaction rule.
+func secretKey(key string) bool {
+	k := strings.ToLower(key)
+	return strings.Contains(k, "secret") ||
+		strings.Contains(k, "password") ||
+		strings.HasSuffix(k, "_key") ||
+		k == "token" ||
+		strings.HasSuffix(k, "_token")
+}
+
+// configChanges compares a store's desired config with its live one. Secret
+// values are masked in the returned changes.
+func configChanges(want map[string]any, live []byte) ([]FieldChange, fields, error) {
+	liveFields := fields{}
+	if len(live) > 0 {
+		if err := json.Unmarshal(live, &liveFields); err != nil {
+			return nil, nil, fmt.Errorf("decode live config: %w", err)
+		}
+	}
+	wantFields, err := toFields(want)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, _ := diffFields(wantFields, liveFields, nil)
+	for i := range changes {
+		changes[i].Field = "config." + changes[i].Field
+		if secretKey(strings.TrimPrefix(changes[i].Field, "config.")) {
+			changes[i].From, changes[i].To = redacted, redacted
+		}
+	}
+	return changes, liveFields, nil
+}
+
+// stringSetDiff returns the entries of want missing from have and of have
+// missing from want.
+func stringSetDiff(want, have []string) (add, remove []string) {
+	for _, w := range want {
+		if !slices.Contains(have, w) {
+			add = append(add, w)
+		}
+	}
+	for _, h := range have {
+		if !slices.Contains(want, h) {
+			remove = append(remove, h)
+		}
+	}
+	return add, remove
+}
@@ -0,0 +1,348 @@
+// Package manifest implements declarative server configuration for dfsctl
+// apply and dfsctl diff: a YAML or JSON manifest describing stores, shares,
+// netgroups and adapter settings is compared with a live server and the
+// server is converged to it through the REST API.
+//
+// Only what a manifest states is managed. A field left out of a resource is
+// never compared or changed, so a manifest can pin a few settings and leave
+// the rest to the server defaults. Lists nested in a resource (share
+// permissions, quotas, netgroup members) are authoritative when present:
+// entries missing from the manifest are removed. Whole resources missing
+// from the manifest are removed only with Options.Prune.
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/marmos91/dittofs/pkg/apiclient"
+)
+
+// APIVersion and Kind identify a DittoFS manifest.
+const (
+	APIVersion = "dittofs.io/v1"
+	Kind       = "ServerConfig"
+)
+
+// Manifest is the desired configuration of a DittoFS server.
+type Manifest struct {
+	APIVersion     string       `json:"apiVersion"`
+	Kind           string       `json:"kind"`
+	MetadataStores []Store      `json:"metadata_stores,omitempty"`
+	BlockStores    []BlockStore `json:"block_stores,omitempty"`
+	Netgroups      []Netgroup   `json:"netgroups,omitempty"`
+	Shares         []Share      `json:"shares,omitempty"`
+	// Adapters holds adapter settings by adapter type ("nfs", "smb"), with
+	// the keys of `dfsctl adapter settings <type> show -o json`.
+	Adapters map[string]map[string]any `json:"adapters,omitempty"`
+}
+
+// Store is a metadata store. Config holds the store's type-specific
+// settings; keys left out are not managed.
+type Store struct {
+	Name   string         `json:"name"`
+	Type   string         `json:"type"`
+	Config map[string]any `json:"config,omitempty"`
+}
+
+// BlockStore is a local or remote block store.
+type BlockStore struct {
+	Kind string `json:"kind"` // "local" or "remote"
+	Store
+}
+
+// Netgroup is an NFS netgroup. Members, when present, is the complete
+// member list.
+type Netgroup struct {
+	Name    string            `json:"name"`
+	Members *[]NetgroupMember `json:"members,omitempty"`
+}
+
+// NetgroupMember is a netgroup member (type "ip", "cidr" or "hostname").
+type NetgroupMember struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Share is a share and the settings attached to it. The embedded share
+// settings use the keys of the share API (read_only, quota_bytes,
+// trash_enabled, ...).
+type Share struct {
+	Name            string `json:"name"`
+	MetadataStore   string `json:"metadata_store"`
+	LocalBlockStore string `json:"local_block_store"`
+	// RemoteBlockStore attaches a remote block store; "" detaches it and
+	// nil leaves it unmanaged.
+	RemoteBlockStore *string `json:"remote_block_store,omitempty"`
+	Enabled          *bool   `json:"enabled,omitempty"`
+	apiclient.UpdateShareRequest
+
+	NFS            *apiclient.PatchShareNFSConfigRequest  `json:"nfs,omitempty"`
+	Permissions    *[]Permission                          `json:"permissions,omitempty"`
+	Quotas         *[]Quota                               `json:"quotas,omitempty"`
+	SnapshotPolicy *apiclient.UpsertSnapshotPolicyRequest `json:"snapshot_policy,omitempty"`
+}
+
+// Permission grants a user or a group a level on a share.
+type Permission struct {
+	User  string `json:"user,omitempty"`
+	Group string `json:"group,omitempty"`
+	Level string `json:"level"`
+}
+
+// Quota is a per-identity quota on a share. ID is the UID or GID; it is
+// omitted for the default-user scope.
+type Quota struct {
+	Scope string  `json:"scope"` // "user", "group" or "default-user"
+	ID    *uint32 `json:"id,omitempty"`
+	apiclient.UpsertQuotaRequest
+}
+
+// key identifies the quota within its share.
+func (q Quota) key() string {
+	if q.ID == nil {
+		return q.Scope
+	}
+	return fmt.Sprintf("%s %d", q.Scope, *q.ID)
+}
+
+// shareName returns the canonical form of a share name, with one leading
+// slash, as the server stores it.
+func shareName(name string) string {
+	return "/" + strings.TrimLeft(name, "/")
+}
+
+// Load reads and merges the manifests at paths. A path may be a file, a
+// directory (its *.yaml, *.yml and *.json files, in name order) or "-" for
+// standard input.
+func Load(paths []string, stdin io.Reader) (*Manifest, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no manifest given")
+	}
+	merged := &Manifest{APIVersion: APIVersion, Kind: Kind}
+	for _, path := range paths {
+		files := []string{path}
+		if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return nil, err
+			}
+			files = files[:0]
+			for _, e := range entries {
+				switch filepath.Ext(e.Name()) {
+				case ".yaml", ".yml", ".json":
+					if !e.IsDir() {
+						files = append(files, filepath.Join(path, e.Name()))
+					}
+				}
+			}
+		}
+		for _, file := range files {
+			var data []byte
+			var err error
+			if file == "-" {
+				data, err = io.ReadAll(stdin)
+			} else {
+				data, err = os.ReadFile(file)
+			}
+			if err != nil {
+				return nil, err
+			}
+			m, err := Parse(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			if err := merged.merge(m); err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+		}
+	}
+	if err := merged.Validate(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// envRef matches a ${NAME} environment variable reference.
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Parse decodes a YAML or JSON manifest. ${NAME} references in values are
+// replaced by the environment variable NAME, so secrets such as S3 keys need
+// not be committed; an unset variable is an error. Unknown keys are
+// rejected to catch typos.
+func Parse(data []byte) (*Manifest, error) {
+	// YAML is decoded generically and re-encoded as JSON so the manifest
+	// shares the API's JSON field names (JSON is valid YAML).
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if doc == nil {
+		return &Manifest{}, nil
+	}
+	var missing []string
+	doc = expandEnv(doc, &missing)
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return nil, fmt.Errorf("environment variables not set: %s", strings.Join(slices.Compact(missing), ", "))
+	}
+	asJSON, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(asJSON))
+	dec.DisallowUnknownFields()
+	var m Manifest
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.APIVersion != "" && m.APIVersion != APIVersion {
+		return nil, fmt.Errorf("unsupported apiVersion %q (want %s)", m.APIVersion, APIVersion)
+	}
+	if m.Kind != "" && m.Kind != Kind {
+		return nil, fmt.Errorf("unsupported kind %q (want %s)", m.Kind, Kind)
+	}
+	return &m, nil
+}
+
+// expandEnv replaces ${NAME} references in the strings of a decoded
+// document, recording unset variables in missing.
+func expandEnv(v any, missing *[]string) any {
+	switch val := v.(type) {
+	case string:
+		return envRef.ReplaceAllStringFunc(val, func(ref string) string {
+			name := envRef.FindStringSubmatch(ref)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				*missing = append(*missing, name)
+			}
+			return value
+		})
+	case map[string]any:
+		for k, child := range val {
+			val[k] = expandEnv(child, missing)
+		}
+	case []any:
+		for i, child := range val {
+			val[i] = expandEnv(child, missing)
+		}
+	}
+	return v
+}
+
+// merge adds the resources of other to m.
+func (m *Manifest) merge(other *Manifest) error {
+	m.MetadataStores = append(m.MetadataStores, other.MetadataStores...)
+	m.BlockStores = append(m.BlockStores, other.BlockStores...)
+	m.Netgroups = append(m.Netgroups, other.Netgroups...)
+	m.Shares = append(m.Shares, other.Shares...)
+	for adapter, settings := range other.Adapters {
+		if m.Adapters == nil {
+			m.Adapters = map[string]map[string]any{}
+		}
+		if m.Adapters[adapter] == nil {
+			m.Adapters[adapter] = map[string]any{}
+		}
+		for k, v := range settings {
+			if _, dup := m.Adapters[adapter][k]; dup {
+				return fmt.Errorf("adapter %s setting %s is set twice", adapter, k)
+			}
+			m.Adapters[adapter][k] = v
+		}
+	}
+	return nil
+}
+
+// Validate checks that the manifest is self-consistent: required fields are
+// set and names are unique. References between resources are checked when
+// planning, as they may name resources that exist only on the server.
+func (m *Manifest) Validate() error {
+	var errs []string
+	fail := func(format string, args ...any) { errs = append(errs, fmt.Sprintf(format, args...)) }
+
+	seen := map[string]bool{}
+	unique := func(kind, name string) {
+		if name == "" {
+			fail("%s without a name", kind)
+			return
+		}
+		if seen[kind+"\x00"+name] {
+			fail("%s %q is declared twice", kind, name)
+		}
+		seen[kind+"\x00"+name] = true
+	}
+
+	for _, s := range m.MetadataStores {
+		unique("metadata store", s.Name)
+		if s.Type == "" {
+			fail("metadata store %q has no type", s.Name)
+		}
+	}
+	for _, s := range m.BlockStores {
+		if s.Kind != "local" && s.Kind != "remote" {
+			fail("block store %q: kind must be local or remote", s.Name)
+			continue
+		}
+		unique(s.Kind+" block store", s.Name)
+		if s.Type == "" {
+			fail("block store %q has no type", s.Name)
+		}
+	}
+	for _, ng := range m.Netgroups {
+		unique("netgroup", ng.Name)
+	}
+	for _, s := range m.Shares {
+		unique("share", shareName(s.Name))
+		if s.MetadataStore == "" || s.LocalBlockStore == "" {
+			fail("share %q needs metadata_store and local_block_store", s.Name)
+		}
+		if s.LocalBlockStoreID != nil || s.RemoteBlockStoreID != nil {
+			fail("share %q: use local_block_store/remote_block_store, not the *_id fields", s.Name)
+		}
+		if s.Permissions != nil {
+			for _, p := range *s.Permissions {
+				if (p.User == "") == (p.Group == "") {
+					fail("share %q: each permission names exactly one user or group", s.Name)
+				}
+			}
+		}
+		if s.Quotas != nil {
+			keys := map[string]bool{}
+			for _, q := range *s.Quotas {
+				switch {
+				case q.Scope == "default-user" && q.ID != nil:
+					fail("share %q: a default-user quota takes no id", s.Name)
+				case (q.Scope == "user" || q.Scope == "group") && q.ID == nil:
+					fail("share %q: a %s quota needs an id", s.Name, q.Scope)
+				case q.Scope != "user" && q.Scope != "group" && q.Scope != "default-user":
+					fail("share %q: quota scope must be user, group or default-user", s.Name)
+				}
+				if keys[q.key()] {
+					fail("share %q: quota %s is declared twice", s.Name, q.key())
+				}
+				keys[q.key()] = true
+			}
+		}
+	}
+	for adapter := range m.Adapters {
+		if adapter != "nfs" && adapter != "smb" {
+			fail("adapters: unknown adapter type %q (want nfs or smb)", adapter)
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("invalid manifest:\n  %s", strings.Join(slices.Compact(errs), "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleManifest = `
+apiVersion: dittofs.io/v1
+kind: ServerConfig
+metadata_stores:
+  - name: meta
+    type: badger
+    config:
+      path: /var/lib/dittofs/meta
+block_stores:
+  - kind: local
+    name: cache
+    type: fs
+    config:
+      path: /var/lib/dittofs/cache
+  - kind: remote
+    name: s3
+    type: s3
+    config:
+      bucket: backups
+      secret_access_key: ${TEST_MANIFEST_S3_SECRET}
+shares:
+  - name: data
+    metadata_store: meta
+    local_block_store: cache
+    remote_block_store: s3
+    read_only: true
+    quota_bytes: 10GiB
+    permissions:
+      - user: alice
+        level: read-write
+    quotas:
+      - scope: user
+        id: 1000
+        limit_bytes: 1GiB
+adapters:
+  nfs:
+    lease_time: 120
+`
+
+func TestParse(t *testing.T) {
+	t.Setenv("TEST_MANIFEST_S3_SECRET", "s3cr3t")
+
+	m, err := Parse([]byte(sampleManifest))
+	require.NoError(t, err)
+	require.NoError(t, m.Validate())
+
+	require.Len(t, m.BlockStores, 2)
+	assert.Equal(t, "s3cr3t", m.BlockStores[1].Config["secret_access_key"])
+	require.Len(t, m.Shares, 1)
+	share := m.Shares[0]
+	require.NotNil(t, share.ReadOnly)
+	assert.True(t, *share.ReadOnly)
+	require.NotNil(t, share.QuotaBytes)
+	assert.Equal(t, "10GiB", *share.QuotaBytes)
+	require.NotNil(t, share.Permissions)
+	assert.Equal(t, []Permission{{User: "alice", Level: "read-write"}}, *share.Permissions)
+	assert.Equal(t, float64(120), m.Adapters["nfs"]["lease_time"])
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "unset variable", input: "shares: [{name: a, description: '${TEST_MANIFEST_UNSET}'}]", wantErr: "TEST_MANIFEST_UNSET"},
+		{name: "unknown key", input: "shares: [{name: a, read_onyl: true}]", wantErr: "read_onyl"},
+		{name: "wrong kind", input: "kind: Share", wantErr: "unsupported kind"},
+		{name: "wrong apiVersion", input: "apiVersion: v2", wantErr: "unsupported apiVersion"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	m, err := Parse([]byte(`
+metadata_stores:
+  - {name: meta, type: memory}
+  - {name: meta, type: memory}
+block_stores:
+  - {kind: cold, name: x, type: s3}
+shares:
+  - name: a
+    metadata_store: meta
+    permissions: [{user: alice, group: staff, level: read}]
+    quotas: [{scope: default-user, id: 1}, {scope: user}]
+adapters:
+  ftp: {}
+`))
+	require.NoError(t, err)
+
+	err = m.Validate()
+	require.Error(t, err)
+	for _, want := range []string{
+		`metadata store "meta" is declared twice`,
+		`block store "x": kind must be local or remote`,
+		`share "a" needs metadata_store and local_block_store`,
+		"exactly one user or group",
+		"a default-user quota takes no id",
+		"a user quota needs an id",
+		`unknown adapter type "ftp"`,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestLoad_Merge(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644))
+	}
+	write("10-stores.yaml", "metadata_stores: [{name: meta, type: memory}]\nadapters: {nfs: {lease_time: 90}}\n")
+	write("20-shares.json", `{"shares": [{"name": "/data", "metadata_store": "meta", "local_block_store": "cache"}]}`)
+	write("README.md", "not a manifest")
+
+	m, err := Load([]string{dir}, nil)
+	require.NoError(t, err)
+	assert.Len(t, m.MetadataStores, 1)
+	assert.Len(t, m.Shares, 1)
+
+	// The same adapter setting in two files is ambiguous.
+	_, err = Load([]string{dir, "-"}, strings.NewReader("adapters: {nfs: {lease_time: 60}}"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "lease_time is set twice")
+}
@@ -0,0 +1,552 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/marmos91/dittofs/pkg/apiclient"
+)
+
+// Server is the part of the API client a plan reads and writes through.
+// *apiclient.Client implements it.
+type Server interface {
+	ListMetadataStores() ([]apiclient.MetadataStore, error)
+	CreateMetadataStore(req *apiclient.CreateStoreRequest) (*apiclient.MetadataStore, error)
+	UpdateMetadataStore(name string, req *apiclient.UpdateStoreRequest) (*apiclient.MetadataStore, error)
+	RemoveMetadataStore(name string) error
+
+	ListBlockStores(kind string) ([]apiclient.BlockStore, error)
+	CreateBlockStore(kind string, req *apiclient.CreateStoreRequest) (*apiclient.BlockStore, error)
+	UpdateBlockStore(kind, name string, req *apiclient.UpdateStoreRequest) (*apiclient.BlockStore, error)
+	RemoveBlockStore(kind, name string) error
+
+	ListNetgroups() ([]*apiclient.Netgroup, error)
+	GetNetgroup(name string) (*apiclient.Netgroup, error)
+	CreateNetgroup(name string) (*apiclient.Netgroup, error)
+	RemoveNetgroup(name string) error
+	AddNetgroupMember(netgroupName, memberType, memberValue string) (*apiclient.NetgroupMember, error)
+	RemoveNetgroupMember(netgroupName, memberID string) error
+
+	ListShares() ([]apiclient.Share, error)
+	CreateShare(req *apiclient.CreateShareRequest) (*apiclient.Share, error)
+	UpdateShare(name string, req *apiclient.UpdateShareRequest) (*apiclient.Share, error)
+	RemoveShare(name string) error
+	EnableShare(name string) (*apiclient.Share, error)
+	DisableShare(name string) (*apiclient.Share, error)
+	GetShareNFSConfig(name string) (*apiclient.ShareNFSConfig, error)
+	PatchShareNFSConfig(name string, req *apiclient.PatchShareNFSConfigRequest) (*apiclient.ShareNFSConfig, error)
+
+	ListSharePermissions(shareName string) ([]apiclient.SharePermission, error)
+	SetUserSharePermission(shareName, username, level string) error
+	RemoveUserSharePermission(shareName, username string) error
+	SetGroupSharePermission(shareName, groupName, level string) error
+	RemoveGroupSharePermission(shareName, groupName string) error
+
+	ListQuotas(share string) ([]apiclient.Quota, error)
+	SetQuota(share, scope string, id *uint32, req *apiclient.UpsertQuotaRequest) (*apiclient.Quota, error)
+	RemoveQuota(share, scope string, id *uint32) error
+
+	ListSnapshotPolicies() ([]apiclient.SnapshotPolicy, error)
+	UpsertSnapshotPolicy(share string, req apiclient.UpsertSnapshotPolicyRequest) (*apiclient.SnapshotPolicy, error)
+
+	GetAdapterSettings(adapterType string) (any, error)
+	PatchAdapterSettings(adapterType string, req any, opts ...apiclient.SettingsOption) (any, error)
+}
+
+var _ Server = (*apiclient.Client)(nil)
+
+// Action is what a change does to its resource.
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Change is one step of a plan.
+type Change struct {
+	Action   Action        `json:"action"`
+	Resource string        `json:"resource"`
+	Fields   []FieldChange `json:"fields,omitempty"`
+
+	apply func() error
+}
+
+// Plan is the ordered list of changes that converges a server to a
+// manifest. Unmanaged lists the server's resources the manifest does not
+// mention; they are deleted only when planning with Options.Prune.
+type Plan struct {
+	Changes   []Change `json:"changes"`
+	Unmanaged []string `json:"unmanaged,omitempty"`
+}
+
+// Options tunes planning.
+type Options struct {
+	// Prune deletes stores, netgroups and shares the manifest does not
+	// mention.
+	Prune bool
+}
+
+// Empty reports whether the server already matches the manifest.
+func (p *Plan) Empty() bool { return len(p.Changes) == 0 }
+
+// HasDeletes reports whether applying the plan deletes anything.
+func (p *Plan) HasDeletes() bool {
+	for _, c := range p.Changes {
+		if c.Action == ActionDelete {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply executes the plan in order, reporting each change to w, and stops at
+// the first failure. Creates run before the resources that reference them
+// and deletes run last, so an interrupted apply can simply be re-run.
+func (p *Plan) Apply(w io.Writer) error {
+	for _, c := range p.Changes {
+		if err := c.apply(); err != nil {
+			return fmt.Errorf("%s %s: %w", c.Action, c.Resource, err)
+		}
+		_, _ = fmt.Fprintf(w, "%s: %sd\n", c.Resource, c.Action)
+	}
+	return nil
+}
+
+// Print writes the plan in a diff-like form: "+" creates, "~" updates and
+// "-" deletes.
+func (p *Plan) Print(w io.Writer) {
+	var creates, updates, deletes int
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ActionCreate:
+			creates++
+			_, _ = fmt.Fprintf(w, "+ %s\n", c.Resource)
+			for _, f := range c.Fields {
+				_, _ = fmt.Fprintf(w, "      %s: %s\n", f.Field, f.To)
+			}
+		case ActionUpdate:
+			updates++
+			_, _ = fmt.Fprintf(w, "~ %s\n", c.Resource)
+			for _, f := range c.Fields {
+				_, _ = fmt.Fprintf(w, "      %s: %s -> %s\n", f.Field, f.From, f.To)
+			}
+		case ActionDelete:
+			deletes++
+			_, _ = fmt.Fprintf(w, "- %s\n", c.Resource)
+		}
+	}
+	if len(p.Unmanaged) > 0 {
+		if len(p.Changes) > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		_, _ = fmt.Fprintln(w, "Not in the manifest (kept; use --prune to delete):")
+		for _, r := range p.Unmanaged {
+			_, _ = fmt.Fprintf(w, "  %s\n", r)
+		}
+	}
+	if p.Empty() {
+		_, _ = fmt.Fprintln(w, "No changes: the server matches the manifest.")
+		return
+	}
+	_, _ = fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to delete.\n", creates, updates, deletes)
+}
+
+// BuildPlan compares the manifest with the live server and returns the
+// changes that converge the server to it. Nothing is modified.
+func BuildPlan(m *Manifest, srv Server, opts Options) (*Plan, error) {
+	p := &planner{srv: srv, m: m, opts: opts, plan: &Plan{}}
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	steps := []func() error{
+		p.planMetadataStores,
+		p.planBlockStores,
+		p.planNetgroups,
+		p.planAdapters,
+		p.planShares,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+	// Deletes run last and in reverse dependency order: shares reference
+	// stores and netgroups.
+	for _, deletes := range p.deletes {
+		p.plan.Changes = append(p.plan.Changes, deletes...)
+	}
+	sort.Strings(p.plan.Unmanaged)
+	return p.plan, nil
+}
+
+// planner holds the live state a plan is computed against.
+type planner struct {
+	srv  Server
+	m    *Manifest
+	opts Options
+	plan *Plan
+
+	// deletes are the pruned top-level resources by pruneOrder, appended
+	// to the plan after everything else.
+	deletes [pruneOrders][]Change
+
+	metadataStores map[string]apiclient.MetadataStore
+	blockStores    map[string]apiclient.BlockStore // by kind + "/" + name
+	storeNames     map[string]string               // store ID -> name
+	netgroups      map[string]*apiclient.Netgroup
+	shares         map[string]apiclient.Share
+	policies       map[string]apiclient.SnapshotPolicy
+}
+
+func (p *planner) add(c Change) { p.plan.Changes = append(p.plan.Changes, c) }
+
+// load reads the live state of every top-level resource type.
+func (p *planner) load() error {
+	p.storeNames = map[string]string{}
+
+	metadata, err := p.srv.ListMetadataStores()
+	if err != nil {
+		return fmt.Errorf("list metadata stores: %w", err)
+	}
+	p.metadataStores = map[string]apiclient.MetadataStore{}
+	for _, s := range metadata {
+		p.metadataStores[s.Name] = s
+		p.storeNames[s.ID] = s.Name
+	}
+
+	p.blockStores = map[string]apiclient.BlockStore{}
+	for _, kind := range []string{"local", "remote"} {
+		stores, err := p.srv.ListBlockStores(kind)
+		if err != nil {
+			return fmt.Errorf("list %s block stores: %w", kind, err)
+		}
+		for _, s := range stores {
+			p.blockStores[kind+"/"+s.Name] = s
+			p.storeNames[s.ID] = s.Name
+		}
+	}
+
+	netgroups, err := p.srv.ListNetgroups()
+	if err != nil {
+		return fmt.Errorf("list netgroups: %w", err)
+	}
+	p.netgroups = map[string]*apiclient.Netgroup{}
+	for _, ng := range netgroups {
+		p.netgroups[ng.Name] = ng
+	}
+
+	shares, err := p.srv.ListShares()
+	if err != nil {
+		return fmt.Errorf("list shares: %w", err)
+	}
+	p.shares = map[string]apiclient.Share{}
+	for _, s := range shares {
+		p.shares[shareName(s.Name)] = s
+	}
+
+	policies, err := p.srv.ListSnapshotPolicies()
+	if err != nil {
+		return fmt.Errorf("list snapshot policies: %w", err)
+	}
+	p.policies = map[string]apiclient.SnapshotPolicy{}
+	for _, sp := range policies {
+		p.policies[shareName(sp.Share)] = sp
+	}
+	return nil
+}
+
+// pruneOrder is the position of a pruned resource type among the deletes.
+type pruneOrder int
+
+const (
+	pruneShares pruneOrder = iota
+	pruneNetgroups
+	pruneBlockStores
+	pruneMetadataStores
+	pruneOrders
+)
+
+// prune records a server resource the manifest does not mention: a delete
+// with Options.Prune, otherwise an unmanaged entry.
+func (p *planner) prune(order pruneOrder, resource string, remove func() error) {
+	if !p.opts.Prune {
+		p.plan.Unmanaged = append(p.plan.Unmanaged, resource)
+		return
+	}
+	p.deletes[order] = append(p.deletes[order], Change{Action: ActionDelete, Resource: resource, apply: remove})
+}
+
+// createFields lists the fields of a resource being created.
+func createFields(f fields, prefix string) []FieldChange {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]FieldChange, 0, len(keys))
+	for _, k := range keys {
+		to := display(f[k])
+		if prefix == "config." && secretKey(k) {
+			to = redacted
+		}
+		out = append(out, FieldChange{Field: prefix + k, To: to})
+	}
+	return out
+}
+
+// storeChanges compares a desired store with a live one and returns the
+// field changes and the update request that applies them. The update sends
+// the live config overlaid with the desired keys, as the API replaces the
+// config wholesale; redacted secrets are restored by the server.
+func storeChanges(want Store, liveType string, liveConfig []byte) ([]FieldChange, *apiclient.UpdateStoreRequest, error) {
+	changes, liveFields, err := configChanges(want.Config, liveConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	req := &apiclient.UpdateStoreRequest{}
+	if want.Type != liveType {
+		changes = append([]FieldChange{{Field: "type", From: liveType, To: want.Type}}, changes...)
+		req.Type = &want.Type
+	}
+	if len(changes) == 0 {
+		return nil, nil, nil
+	}
+	config := fields{}
+	for k, v := range liveFields {
+		config[k] = v
+	}
+	for k, v := range want.Config {
+		config[k] = v
+	}
+	req.Config = config
+	return changes, req, nil
+}
+
+func (p *planner) planMetadataStores() error {
+	declared := map[string]bool{}
+	for _, want := range p.m.MetadataStores {
+		declared[want.Name] = true
+		resource := fmt.Sprintf("metadata store %q", want.Name)
+
+		live, ok := p.metadataStores[want.Name]
+		if !ok {
+			f, err := toFields(want.Config)
+			if err != nil {
+				return err
+			}
+			p.add(Change{
+				Action:   ActionCreate,
+				Resource: resource,
+				Fields:   append([]FieldChange{{Field: "type", To: want.Type}}, createFields(f, "config.")...),
+				apply: func() error {
+					_, err := p.srv.CreateMetadataStore(&apiclient.CreateStoreRequest{Name: want.Name, Type: want.Type, Config: want.Config})
+					return err
+				},
+			})
+			continue
+		}
+		changes, req, err := storeChanges(want, live.Type, live.Config)
+		if err != nil {
+			return fmt.Errorf("%s: %w", resource, err)
+		}
+		if len(changes) > 0 {
+			p.add(Change{Action: ActionUpdate, Resource: resource, Fields: changes, apply: func() error {
+				_, err := p.srv.UpdateMetadataStore(want.Name, req)
+				return err
+			}})
+		}
+	}
+	for _, name := range sortedKeys(p.metadataStores) {
+		if !declared[name] {
+			p.prune(pruneMetadataStores, fmt.Sprintf("metadata store %q", name), func() error { return p.srv.RemoveMetadataStore(name) })
+		}
+	}
+	return nil
+}
+
+func (p *planner) planBlockStores() error {
+	declared := map[string]bool{}
+	for _, want := range p.m.BlockStores {
+		key := want.Kind + "/" + want.Name
+		declared[key] = true
+		resource := fmt.Sprintf("%s block store %q", want.Kind, want.Name)
+
+		live, ok := p.blockStores[key]
+		if !ok {
+			f, err := toFields(want.Config)
+			if err != nil {
+				return err
+			}
+			p.add(Change{
+				Action:   ActionCreate,
+				Resource: resource,
+				Fields:   append([]FieldChange{{Field: "type", To: want.Type}}, createFields(f, "config.")...),
+				apply: func() error {
+					_, err := p.srv.CreateBlockStore(want.Kind, &apiclient.CreateStoreRequest{Name: want.Name, Type: want.Type, Config: want.Config})
+					return err
+				},
+			})
+			continue
+		}
+		changes, req, err := storeChanges(want.Store, live.Type, live.Config)
+		if err != nil {
+			return fmt.Errorf("%s: %w", resource, err)
+		}
+		if len(changes) > 0 {
+			p.add(Change{Action: ActionUpdate, Resource: resource, Fields: changes, apply: func() error {
+				_, err := p.srv.UpdateBlockStore(want.Kind, want.Name, req)
+				return err
+			}})
+		}
+	}
+	for _, key := range sortedKeys(p.blockStores) {
+		if !declared[key] {
+			kind, name, _ := strings.Cut(key, "/")
+			p.prune(pruneBlockStores, fmt.Sprintf("%s block store %q", kind, name), func() error { return p.srv.RemoveBlockStore(kind, name) })
+		}
+	}
+	return nil
+}
+
+// memberKey identifies a netgroup member by type and value.
+func memberKey(memberType, value string) string { return memberType + " " + value }
+
+func (p *planner) planNetgroups() error {
+	declared := map[string]bool{}
+	for _, want := range p.m.Netgroups {
+		declared[want.Name] = true
+		resource := fmt.Sprintf("netgroup %q", want.Name)
+
+		var wantMembers []string
+		if want.Members != nil {
+			for _, m := range *want.Members {
+				wantMembers = append(wantMembers, memberKey(m.Type, m.Value))
+			}
+		}
+
+		if _, ok := p.netgroups[want.Name]; !ok {
+			var f []FieldChange
+			if len(wantMembers) > 0 {
+				f = []FieldChange{{Field: "members", To: strings.Join(wantMembers, ", ")}}
+			}
+			p.add(Change{Action: ActionCreate, Resource: resource, Fields: f, apply: func() error {
+				if _, err := p.srv.CreateNetgroup(want.Name); err != nil {
+					return err
+				}
+				if want.Members == nil {
+					return nil
+				}
+				for _, m := range *want.Members {
+					if _, err := p.srv.AddNetgroupMember(want.Name, m.Type, m.Value); err != nil {
+						return fmt.Errorf("add member %s: %w", memberKey(m.Type, m.Value), err)
+					}
+				}
+				return nil
+			}})
+			continue
+		}
+		if want.Members == nil {
+			continue
+		}
+
+		// Member IDs are needed to remove members; the list endpoint may
+		// omit them.
+		live, err := p.srv.GetNetgroup(want.Name)
+		if err != nil {
+			return fmt.Errorf("get %s: %w", resource, err)
+		}
+		ids := map[string]string{}
+		var have []string
+		for _, m := range live.Members {
+			k := memberKey(m.Type, m.Value)
+			ids[k] = m.ID
+			have = append(have, k)
+		}
+		added, removed := stringSetDiff(wantMembers, have)
+		if len(added) == 0 && len(removed) == 0 {
+			continue
+		}
+		sort.Strings(have)
+		sorted := append([]string(nil), wantMembers...)
+		sort.Strings(sorted)
+		p.add(Change{
+			Action:   ActionUpdate,
+			Resource: resource,
+			Fields:   []FieldChange{{Field: "members", From: strings.Join(have, ", "), To: strings.Join(sorted, ", ")}},
+			apply: func() error {
+				for _, k := range removed {
+					if err := p.srv.RemoveNetgroupMember(want.Name, ids[k]); err != nil {
+						return fmt.Errorf("remove member %s: %w", k, err)
+					}
+				}
+				for _, k := range added {
+					memberType, value, _ := strings.Cut(k, " ")
+					if _, err := p.srv.AddNetgroupMember(want.Name, memberType, value); err != nil {
+						return fmt.Errorf("add member %s: %w", k, err)
+					}
+				}
+				return nil
+			},
+		})
+	}
+	for _, name := range sortedKeys(p.netgroups) {
+		if !declared[name] {
+			p.prune(pruneNetgroups, fmt.Sprintf("netgroup %q", name), func() error { return p.srv.RemoveNetgroup(name) })
+		}
+	}
+	return nil
+}
+
+// adapterNorms canonicalizes adapter settings whose order does not matter.
+var adapterNorms = map[string]normalizer{"blocked_operations": normSet}
+
+func (p *planner) planAdapters() error {
+	for _, adapter := range sortedKeys(p.m.Adapters) {
+		want := fields(p.m.Adapters[adapter])
+		resource := adapter + " adapter settings"
+
+		settings, err := p.srv.GetAdapterSettings(adapter)
+		if err != nil {
+			return fmt.Errorf("get %s: %w", resource, err)
+		}
+		live, err := toFields(settings)
+		if err != nil {
+			return err
+		}
+		for _, k := range sortedKeys(want) {
+			if _, ok := live[k]; !ok || k == "version" {
+				return fmt.Errorf("%s: unknown setting %q", resource, k)
+			}
+		}
+		changes, changed := diffFields(want, live, adapterNorms)
+		if len(changes) == 0 {
+			continue
+		}
+		p.add(Change{Action: ActionUpdate, Resource: resource, Fields: changes, apply: func() error {
+			_, err := p.srv.PatchAdapterSettings(adapter, map[string]any(changed))
+			return err
+		}})
+	}
+	return nil
+}
+
+// isNotFound reports whether err is an API 404.
+func isNotFound(err error) bool {
+	var apiErr *apiclient.APIError
+	return errors.As(err, &apiErr) && apiErr.IsNotFound()
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,592 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marmos91/dittofs/internal/bytesize"
+	"github.com/marmos91/dittofs/pkg/apiclient"
+)
+
+// fakeServer is an in-memory Server. Like the real one it redacts store
+// secrets on read, reformats byte sizes and keys block stores by ID.
+type fakeServer struct {
+	nextID   int
+	metadata map[string]*apiclient.MetadataStore
+	blocks   map[string]*apiclient.BlockStore // kind + "/" + name
+	netgrps  map[string]*apiclient.Netgroup
+	shares   map[string]*apiclient.Share
+	nfs      map[string]*apiclient.ShareNFSConfig
+	perms    map[string]map[string]string // share -> "user alice" -> level
+	quotas   map[string]map[string]apiclient.Quota
+	policies map[string]apiclient.SnapshotPolicy
+	adapters map[string]*apiclient.NFSAdapterSettingsResponse
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{
+		metadata: map[string]*apiclient.MetadataStore{},
+		blocks:   map[string]*apiclient.BlockStore{},
+		netgrps:  map[string]*apiclient.Netgroup{},
+		shares:   map[string]*apiclient.Share{},
+		nfs:      map[string]*apiclient.ShareNFSConfig{},
+		perms:    map[string]map[string]string{},
+		quotas:   map[string]map[string]apiclient.Quota{},
+		policies: map[string]apiclient.SnapshotPolicy{},
+		adapters: map[string]*apiclient.NFSAdapterSettingsResponse{"nfs": {LeaseTime: 90, GracePeriod: 90}},
+	}
+}
+
+func (f *fakeServer) id() string { f.nextID++; return fmt.Sprintf("id-%d", f.nextID) }
+
+var errNotFound = &apiclient.APIError{StatusCode: http.StatusNotFound}
+
+// storeConfig serializes a store config as the server keeps it, restoring
+// redacted secrets from the previous config.
+func storeConfig(config any, previous json.RawMessage) json.RawMessage {
+	next := fields{}
+	b, _ := json.Marshal(config)
+	_ = json.Unmarshal(b, &next)
+	old := fields{}
+	_ = json.Unmarshal(previous, &old)
+	for k, v := range next {
+		if v == redacted {
+			next[k] = old[k]
+		}
+	}
+	out, _ := json.Marshal(next)
+	return out
+}
+
+// redactConfig returns a store config as the server reports it.
+func redactConfig(config json.RawMessage) json.RawMessage {
+	f := fields{}
+	_ = json.Unmarshal(config, &f)
+	for k := range f {
+		if secretKey(k) {
+			f[k] = redacted
+		}
+	}
+	out, _ := json.Marshal(f)
+	return out
+}
+
+func (f *fakeServer) ListMetadataStores() ([]apiclient.MetadataStore, error) {
+	var out []apiclient.MetadataStore
+	for _, s := range f.metadata {
+		c := *s
+		c.Config = redactConfig(s.Config)
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (f *fakeServer) CreateMetadataStore(req *apiclient.CreateStoreRequest) (*apiclient.MetadataStore, error) {
+	s := &apiclient.MetadataStore{ID: f.id(), Name: req.Name, Type: req.Type, Config: storeConfig(req.Config, nil)}
+	f.metadata[req.Name] = s
+	return s, nil
+}
+
+func (f *fakeServer) UpdateMetadataStore(name string, req *apiclient.UpdateStoreRequest) (*apiclient.MetadataStore, error) {
+	s := f.metadata[name]
+	if req.Type != nil {
+		s.Type = *req.Type
+	}
+	s.Config = storeConfig(req.Config, s.Config)
+	return s, nil
+}
+
+func (f *fakeServer) RemoveMetadataStore(name string) error {
+	delete(f.metadata, name)
+	return nil
+}
+
+func (f *fakeServer) ListBlockStores(kind string) ([]apiclient.BlockStore, error) {
+	var out []apiclient.BlockStore
+	for _, s := range f.blocks {
+		if s.Kind == kind {
+			c := *s
+			c.Config = redactConfig(s.Config)
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeServer) CreateBlockStore(kind string, req *apiclient.CreateStoreRequest) (*apiclient.BlockStore, error) {
+	s := &apiclient.BlockStore{ID: f.id(), Name: req.Name, Kind: kind, Type: req.Type, Config: storeConfig(req.Config, nil)}
+	f.blocks[kind+"/"+req.Name] = s
+	return s, nil
+}
+
+func (f *fakeServer) UpdateBlockStore(kind, name string, req *apiclient.UpdateStoreRequest) (*apiclient.BlockStore, error) {
+	s := f.blocks[kind+"/"+name]
+	if req.Type != nil {
+		s.Type = *req.Type
+	}
+	s.Config = storeConfig(req.Config, s.Config)
+	return s, nil
+}
+
+func (f *fakeServer) RemoveBlockStore(kind, name string) error {
+	delete(f.blocks, kind+"/"+name)
+	return nil
+}
+
+func (f *fakeServer) blockID(kind, name string) string {
+	if s, ok := f.blocks[kind+"/"+name]; ok {
+		return s.ID
+	}
+	return ""
+}
+
+func (f *fakeServer) ListNetgroups() ([]*apiclient.Netgroup, error) {
+	var out []*apiclient.Netgroup
+	for _, ng := range f.netgrps {
+		out = append(out, &apiclient.Netgroup{ID: ng.ID, Name: ng.Name})
+	}
+	return out, nil
+}
+
+func (f *fakeServer) GetNetgroup(name string) (*apiclient.Netgroup, error) {
+	if ng, ok := f.netgrps[name]; ok {
+		return ng, nil
+	}
+	return nil, errNotFound
+}
+
+func (f *fakeServer) CreateNetgroup(name string) (*apiclient.Netgroup, error) {
+	ng := &apiclient.Netgroup{ID: f.id(), Name: name}
+	f.netgrps[name] = ng
+	return ng, nil
+}
+
+func (f *fakeServer) RemoveNetgroup(name string) error {
+	delete(f.netgrps, name)
+	return nil
+}
+
+func (f *fakeServer) AddNetgroupMember(name, memberType, value string) (*apiclient.NetgroupMember, error) {
+	m := apiclient.NetgroupMember{ID: f.id(), Type: memberType, Value: value}
+	f.netgrps[name].Members = append(f.netgrps[name].Members, m)
+	return &m, nil
+}
+
+func (f *fakeServer) RemoveNetgroupMember(name, memberID string) error {
+	ng := f.netgrps[name]
+	for i, m := range ng.Members {
+		if m.ID == memberID {
+			ng.Members = append(ng.Members[:i], ng.Members[i+1:]...)
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (f *fakeServer) ListShares() ([]apiclient.Share, error) {
+	var out []apiclient.Share
+	for _, s := range f.shares {
+		out = append(out, *s)
+	}
+	return out, nil
+}
+
+func (f *fakeServer) CreateShare(req *apiclient.CreateShareRequest) (*apiclient.Share, error) {
+	s := &apiclient.Share{
+		ID:                f.id(),
+		Name:              req.Name,
+		MetadataStoreID:   f.metadata[req.MetadataStoreID].ID,
+		LocalBlockStoreID: f.blockID("local", req.LocalBlockStore),
+		Enabled:           true,
+	}
+	if req.RemoteBlockStore != nil {
+		id := f.blockID("remote", *req.RemoteBlockStore)
+		s.RemoteBlockStoreID = &id
+	}
+	f.shares[req.Name] = s
+	f.nfs[req.Name] = &apiclient.ShareNFSConfig{Squash: "root_to_guest", AllowAuthSys: true}
+	return s, nil
+}
+
+func (f *fakeServer) UpdateShare(name string, req *apiclient.UpdateShareRequest) (*apiclient.Share, error) {
+	s := f.shares[name]
+	if req.LocalBlockStoreID != nil {
+		s.LocalBlockStoreID = f.blockID("local", *req.LocalBlockStoreID)
+	}
+	if req.RemoteBlockStoreID != nil {
+		if *req.RemoteBlockStoreID == "" {
+			s.RemoteBlockStoreID = nil
+		} else {
+			id := f.blockID("remote", *req.RemoteBlockStoreID)
+			s.RemoteBlockStoreID = &id
+		}
+	}
+	if req.ReadOnly != nil {
+		s.ReadOnly = *req.ReadOnly
+	}
+	if req.Description != nil {
+		s.Description = *req.Description
+	}
+	if req.QuotaBytes != nil {
+		n, err := bytesize.ParseByteSize(*req.QuotaBytes)
+		if err != nil {
+			return nil, err
+		}
+		s.QuotaBytes = n.String()
+	}
+	if req.BlockedOperations != nil {
+		s.BlockedOperations = *req.BlockedOperations
+	}
+	return s, nil
+}
+
+func (f *fakeServer) RemoveShare(name string) error {
+	delete(f.shares, name)
+	return nil
+}
+
+func (f *fakeServer) EnableShare(name string) (*apiclient.Share, error) {
+	f.shares[name].Enabled = true
+	return f.shares[name], nil
+}
+
+func (f *fakeServer) DisableShare(name string) (*apiclient.Share, error) {
+	f.shares[name].Enabled = false
+	return f.shares[name], nil
+}
+
+func (f *fakeServer) GetShareNFSConfig(name string) (*apiclient.ShareNFSConfig, error) {
+	if cfg, ok := f.nfs[name]; ok {
+		return cfg, nil
+	}
+	return nil, errNotFound
+}
+
+func (f *fakeServer) PatchShareNFSConfig(name string, req *apiclient.PatchShareNFSConfigRequest) (*apiclient.ShareNFSConfig, error) {
+	cfg := f.nfs[name]
+	if req.Squash != nil {
+		cfg.Squash = *req.Squash
+	}
+	if req.Netgroup != nil {
+		cfg.Netgroup = *req.Netgroup
+	}
+	if req.Exports != nil {
+		// The server stores cleaned paths; report them in path order.
+		cfg.Exports = nil
+		for _, e := range *req.Exports {
+			e.Path = path.Clean("/" + e.Path)
+			cfg.Exports = append(cfg.Exports, e)
+		}
+		sort.Slice(cfg.Exports, func(i, j int) bool { return cfg.Exports[i].Path < cfg.Exports[j].Path })
+	}
+	return cfg, nil
+}
+
+func (f *fakeServer) ListSharePermissions(share string) ([]apiclient.SharePermission, error) {
+	var out []apiclient.SharePermission
+	for key, level := range f.perms[share] {
+		var p apiclient.SharePermission
+		_, _ = fmt.Sscan(key, &p.Type, &p.Name)
+		p.Level = level
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (f *fakeServer) setPerm(share, key, level string) error {
+	if f.perms[share] == nil {
+		f.perms[share] = map[string]string{}
+	}
+	if level == "" {
+		delete(f.perms[share], key)
+	} else {
+		f.perms[share][key] = level
+	}
+	return nil
+}
+
+func (f *fakeServer) SetUserSharePermission(share, user, level string) error {
+	return f.setPerm(share, "user "+user, level)
+}
+
+func (f *fakeServer) RemoveUserSharePermission(share, user string) error {
+	return f.setPerm(share, "user "+user, "")
+}
+
+func (f *fakeServer) SetGroupSharePermission(share, group, level string) error {
+	return f.setPerm(share, "group "+group, level)
+}
+
+func (f *fakeServer) RemoveGroupSharePermission(share, group string) error {
+	return f.setPerm(share, "group "+group, "")
+}
+
+func (f *fakeServer) ListQuotas(share string) ([]apiclient.Quota, error) {
+	var out []apiclient.Quota
+	for _, q := range f.quotas[share] {
+		out = append(out, q)
+	}
+	return out, nil
+}
+
+func (f *fakeServer) SetQuota(share, scope string, id *uint32, req *apiclient.UpsertQuotaRequest) (*apiclient.Quota, error) {
+	q := apiclient.Quota{ShareName: share, Scope: scope, IdentityID: id, LimitFiles: req.LimitFiles}
+	if req.LimitBytes != "" {
+		n, _ := bytesize.ParseByteSize(req.LimitBytes)
+		q.LimitBytes = n.String()
+	}
+	if f.quotas[share] == nil {
+		f.quotas[share] = map[string]apiclient.Quota{}
+	}
+	f.quotas[share][Quota{Scope: scope, ID: id}.key()] = q
+	return &q, nil
+}
+
+func (f *fakeServer) RemoveQuota(share, scope string, id *uint32) error {
+	delete(f.quotas[share], Quota{Scope: scope, ID: id}.key())
+	return nil
+}
+
+func (f *fakeServer) ListSnapshotPolicies() ([]apiclient.SnapshotPolicy, error) {
+	var out []apiclient.SnapshotPolicy
+	for _, sp := range f.policies {
+		out = append(out, sp)
+	}
+	return out, nil
+}
+
+func (f *fakeServer) UpsertSnapshotPolicy(share string, req apiclient.UpsertSnapshotPolicyRequest) (*apiclient.SnapshotPolicy, error) {
+	sp := apiclient.SnapshotPolicy{
+		Share:    share,
+		Enabled:  req.Enabled == nil || *req.Enabled,
+		Interval: req.Interval,
+		KeepLast: req.KeepLast,
+		TTL:      req.TTL,
+	}
+	// The server reports @-shorthands as durations.
+	if sp.Interval == "@daily" {
+		sp.Interval = "24h0m0s"
+	}
+	f.policies[share] = sp
+	return &sp, nil
+}
+
+func (f *fakeServer) GetAdapterSettings(adapter string) (any, error) {
+	return f.adapters[adapter], nil
+}
+
+func (f *fakeServer) PatchAdapterSettings(adapter string, req any, _ ...apiclient.SettingsOption) (any, error) {
+	b, _ := json.Marshal(req)
+	return f.adapters[adapter], json.Unmarshal(b, f.adapters[adapter])
+}
+
+const planManifest = `
+metadata_stores:
+  - {name: meta, type: badger, config: {path: /data/meta}}
+block_stores:
+  - {kind: local, name: cache, type: fs, config: {path: /data/cache}}
+  - {kind: remote, name: s3, type: s3, config: {bucket: b, secret_access_key: shh}}
+netgroups:
+  - name: office
+    members: [{type: cidr, value: 10.0.0.0/8}, {type: hostname, value: nas.example.com}]
+shares:
+  - name: data
+    metadata_store: meta
+    local_block_store: cache
+    remote_block_store: s3
+    read_only: true
+    quota_bytes: 10GiB
+    blocked_operations: [rename, delete]
+    nfs:
+      squash: root_to_admin
+      netgroup: office
+      exports: [{path: scratch/, squash: all_to_guest}, {path: projects/alpha, read_only: true}]
+    permissions:
+      - {user: alice, level: read-write}
+      - {group: staff, level: read}
+    quotas:
+      - {scope: user, id: 1000, limit_bytes: 1 GiB}
+    snapshot_policy: {interval: "@daily", keep_last: 7}
+adapters:
+  nfs: {lease_time: 120}
+`
+
+func mustParse(t *testing.T, data string) *Manifest {
+	t.Helper()
+	m, err := Parse([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, m.Validate())
+	return m
+}
+
+func resources(p *Plan) []string {
+	var out []string
+	for _, c := range p.Changes {
+		out = append(out, string(c.Action)+" "+c.Resource)
+	}
+	return out
+}
+
+func TestBuildPlan_ConvergesIdempotently(t *testing.T) {
+	srv := newFakeServer()
+	m := mustParse(t, planManifest)
+
+	plan, err := BuildPlan(m, srv, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`create metadata store "meta"`,
+		`create local block store "cache"`,
+		`create remote block store "s3"`,
+		`create netgroup "office"`,
+		`update nfs adapter settings`,
+		`create share "/data"`,
+		`update share "/data" NFS options`,
+		`create share "/data" permission for user "alice"`,
+		`create share "/data" permission for group "staff"`,
+		`create share "/data" quota user 1000`,
+		`create share "/data" snapshot policy`,
+	}, resources(plan))
+
+	var out bytes.Buffer
+	plan.Print(&out)
+	assert.Contains(t, out.String(), "config.secret_access_key: ********")
+	assert.NotContains(t, out.String(), "shh")
+	assert.Contains(t, out.String(), "Plan: 9 to create, 2 to update, 0 to delete.")
+
+	require.NoError(t, plan.Apply(&bytes.Buffer{}))
+	assert.Equal(t, "root_to_admin", srv.nfs["/data"].Squash)
+	require.Len(t, srv.nfs["/data"].Exports, 2)
+	assert.Equal(t, "/projects/alpha", srv.nfs["/data"].Exports[0].Path)
+	assert.Equal(t, 120, srv.adapters["nfs"].LeaseTime)
+	assert.Equal(t, "read", srv.perms["/data"]["group staff"])
+
+	// Re-planning finds nothing to do: reformatted sizes, reordered lists,
+	// redacted secrets and expanded schedules all compare equal.
+	plan, err = BuildPlan(m, srv, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, resources(plan))
+}
+
+func TestBuildPlan_Drift(t *testing.T) {
+	srv := newFakeServer()
+	m := mustParse(t, planManifest)
+	plan, err := BuildPlan(m, srv, Options{})
+	require.NoError(t, err)
+	require.NoError(t, plan.Apply(&bytes.Buffer{}))
+
+	// Out-of-band changes on the server.
+	srv.shares["/data"].ReadOnly = false
+	srv.shares["/data"].Description = "not managed"
+	srv.perms["/data"]["user mallory"] = "admin"
+	delete(srv.perms["/data"], "group staff")
+	ng := srv.netgrps["office"]
+	ng.Members = append(ng.Members, apiclient.NetgroupMember{ID: "x", Type: "ip", Value: "192.0.2.1"})
+	_, _ = srv.CreateMetadataStore(&apiclient.CreateStoreRequest{Name: "scratch", Type: "memory"})
+
+	plan, err = BuildPlan(m, srv, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`update netgroup "office"`,
+		`update share "/data"`,
+		`create share "/data" permission for group "staff"`,
+		`delete share "/data" permission for user "mallory"`,
+	}, resources(plan))
+	assert.Equal(t, []FieldChange{{Field: "read_only", From: "(unset)", To: "true"}}, plan.Changes[1].Fields,
+		"only fields the manifest sets are compared")
+	assert.Equal(t, []string{`metadata store "scratch"`}, plan.Unmanaged)
+	assert.True(t, plan.HasDeletes())
+
+	require.NoError(t, plan.Apply(&bytes.Buffer{}))
+	assert.True(t, srv.shares["/data"].ReadOnly)
+	assert.Equal(t, "not managed", srv.shares["/data"].Description)
+	assert.Len(t, srv.netgrps["office"].Members, 2)
+	_, ok := srv.metadata["scratch"]
+	assert.True(t, ok, "unmanaged store kept without prune")
+
+	plan, err = BuildPlan(m, srv, Options{Prune: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`delete metadata store "scratch"`}, resources(plan))
+	require.NoError(t, plan.Apply(&bytes.Buffer{}))
+	_, ok = srv.metadata["scratch"]
+	assert.False(t, ok)
+}
+
+func TestBuildPlan_PruneOrder(t *testing.T) {
+	srv := newFakeServer()
+	plan, err := BuildPlan(mustParse(t, planManifest), srv, Options{})
+	require.NoError(t, err)
+	require.NoError(t, plan.Apply(&bytes.Buffer{}))
+
+	plan, err = BuildPlan(mustParse(t, "kind: ServerConfig"), srv, Options{Prune: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`delete share "/data"`,
+		`delete netgroup "office"`,
+		`delete local block store "cache"`,
+		`delete remote block store "s3"`,
+		`delete metadata store "meta"`,
+	}, resources(plan))
+}
+
+func TestBuildPlan_StoreConfig(t *testing.T) {
+	srv := newFakeServer()
+	_, _ = srv.CreateBlockStore("remote", &apiclient.CreateStoreRequest{
+		Name: "s3", Type: "s3", Config: map[string]any{"bucket": "old", "region": "eu-west-1", "secret_access_key": "keep"},
+	})
+
+	plan, err := BuildPlan(mustParse(t, `block_stores: [{kind: remote, name: s3, type: s3, config: {bucket: new}}]`), srv, Options{})
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, []FieldChange{{Field: "config.bucket", From: "old", To: "new"}}, plan.Changes[0].Fields)
+	require.NoError(t, plan.Apply(&bytes.Buffer{}))
+
+	cfg := fields{}
+	require.NoError(t, json.Unmarshal(srv.blocks["remote/s3"].Config, &cfg))
+	assert.Equal(t, fields{"bucket": "new", "region": "eu-west-1", "secret_access_key": "keep"}, cfg,
+		"unmanaged keys and secrets survive an update")
+}
+
+func TestBuildPlan_Errors(t *testing.T) {
+	srv := newFakeServer()
+	_, err := BuildPlan(mustParse(t, `shares: [{name: a, metadata_store: nope, local_block_store: cache}]`), srv, Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown metadata store "nope"`)
+
+	_, err = BuildPlan(mustParse(t, `adapters: {nfs: {lease_tiem: 5}}`), srv, Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown setting "lease_tiem"`)
+
+	plan, err := BuildPlan(mustParse(t, planManifest), srv, Options{})
+	require.NoError(t, err)
+	require.NoError(t, plan.Apply(&bytes.Buffer{}))
+	_, _ = srv.CreateMetadataStore(&apiclient.CreateStoreRequest{Name: "other", Type: "memory"})
+	moved := mustParse(t, planManifest)
+	moved.Shares[0].MetadataStore = "other"
+	_, err = BuildPlan(moved, srv, Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migrate-metadata")
+}
+
+func TestPlanPrint_Empty(t *testing.T) {
+	var out bytes.Buffer
+	(&Plan{Unmanaged: []string{`share "/old"`}}).Print(&out)
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	got := make([]string, len(lines))
+	for i, l := range lines {
+		got[i] = string(l)
+	}
+	sort.Strings(got)
+	assert.Equal(t, []string{
+		`  share "/old"`,
+		"No changes: the server matches the manifest.",
+		"Not in the manifest (kept; use --prune to delete):",
+	}, got)
+}
@@ -0,0 +1,413 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marmos91/dittofs/pkg/apiclient"
+)
+
+// shareNorms canonicalizes share settings the server reformats.
+var shareNorms = map[string]normalizer{
+	"quota_bytes":            normSize,
+	"local_store_size":       normSize,
+	"read_buffer_size":       normSize,
+	"retention_ttl":          normDuration,
+	"blocked_operations":     normSet,
+	"trash_exclude_patterns": normSet,
+	"file_audit_operations":  normSet,
+}
+
+func (p *planner) planShares() error {
+	declared := map[string]bool{}
+	for _, want := range p.m.Shares {
+		want.Name = shareName(want.Name)
+		declared[want.Name] = true
+
+		if err := p.checkShareRefs(want); err != nil {
+			return err
+		}
+		live, exists := p.shares[want.Name]
+		if !exists {
+			if err := p.createShare(want); err != nil {
+				return err
+			}
+		} else if err := p.updateShare(want, live); err != nil {
+			return err
+		}
+		if err := p.planShareNFS(want, exists); err != nil {
+			return err
+		}
+		if err := p.planPermissions(want, exists); err != nil {
+			return err
+		}
+		if err := p.planQuotas(want, exists); err != nil {
+			return err
+		}
+		if err := p.planSnapshotPolicy(want); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(p.shares) {
+		if !declared[name] {
+			p.prune(pruneShares, fmt.Sprintf("share %q", name), func() error { return p.srv.RemoveShare(name) })
+		}
+	}
+	return nil
+}
+
+// checkShareRefs checks that the stores a share names exist on the server
+// or are declared in the manifest.
+func (p *planner) checkShareRefs(s Share) error {
+	declared := func(kind, name string) bool {
+		if kind == "metadata" {
+			for _, m := range p.m.MetadataStores {
+				if m.Name == name {
+					return true
+				}
+			}
+			_, ok := p.metadataStores[name]
+			return ok
+		}
+		for _, b := range p.m.BlockStores {
+			if b.Kind == kind && b.Name == name {
+				return true
+			}
+		}
+		_, ok := p.blockStores[kind+"/"+name]
+		return ok
+	}
+	if !declared("metadata", s.MetadataStore) {
+		return fmt.Errorf("share %q: unknown metadata store %q", s.Name, s.MetadataStore)
+	}
+	if !declared("local", s.LocalBlockStore) {
+		return fmt.Errorf("share %q: unknown local block store %q", s.Name, s.LocalBlockStore)
+	}
+	if s.RemoteBlockStore != nil && *s.RemoteBlockStore != "" && !declared("remote", *s.RemoteBlockStore) {
+		return fmt.Errorf("share %q: unknown remote block store %q", s.Name, *s.RemoteBlockStore)
+	}
+	return nil
+}
+
+// createShare plans a new share: it is created with its stores, then given
+// its settings, then disabled if the manifest says so.
+func (p *planner) createShare(want Share) error {
+	settings, err := toFields(want.UpdateShareRequest)
+	if err != nil {
+		return err
+	}
+	f := fields{"metadata_store": want.MetadataStore, "local_block_store": want.LocalBlockStore}
+	if want.RemoteBlockStore != nil && *want.RemoteBlockStore != "" {
+		f["remote_block_store"] = *want.RemoteBlockStore
+	}
+	if want.Enabled != nil {
+		f["enabled"] = *want.Enabled
+	}
+	for k, v := range settings {
+		f[k] = v
+	}
+
+	p.add(Change{Action: ActionCreate, Resource: fmt.Sprintf("share %q", want.Name), Fields: createFields(f, ""), apply: func() error {
+		req := &apiclient.CreateShareRequest{
+			Name:            want.Name,
+			MetadataStoreID: want.MetadataStore,
+			LocalBlockStore: want.LocalBlockStore,
+		}
+		if want.RemoteBlockStore != nil && *want.RemoteBlockStore != "" {
+			req.RemoteBlockStore = want.RemoteBlockStore
+		}
+		if _, err := p.srv.CreateShare(req); err != nil {
+			return err
+		}
+		if len(settings) > 0 {
+			if _, err := p.srv.UpdateShare(want.Name, &want.UpdateShareRequest); err != nil {
+				return fmt.Errorf("apply settings: %w", err)
+			}
+		}
+		if want.Enabled != nil && !*want.Enabled {
+			if _, err := p.srv.DisableShare(want.Name); err != nil {
+				return fmt.Errorf("disable: %w", err)
+			}
+		}
+		return nil
+	}})
+	return nil
+}
+
+// updateShare plans the changes to an existing share's stores and settings.
+func (p *planner) updateShare(want Share, live apiclient.Share) error {
+	if current := p.storeNames[live.MetadataStoreID]; current != want.MetadataStore {
+		return fmt.Errorf("share %q uses metadata store %q, not %q; move it with `dfsctl share migrate-metadata`",
+			want.Name, current, want.MetadataStore)
+	}
+
+	wantFields, err := toFields(want.UpdateShareRequest)
+	if err != nil {
+		return err
+	}
+	liveFields, err := toFields(live)
+	if err != nil {
+		return err
+	}
+	changes, changed := diffFields(wantFields, liveFields, shareNorms)
+	req, err := into[apiclient.UpdateShareRequest](changed)
+	if err != nil {
+		return err
+	}
+
+	// Block stores are named in the manifest and referenced by ID on the
+	// server; the update endpoint accepts either.
+	if current := p.storeNames[live.LocalBlockStoreID]; current != want.LocalBlockStore {
+		changes = append(changes, FieldChange{Field: "local_block_store", From: current, To: want.LocalBlockStore})
+		req.LocalBlockStoreID = &want.LocalBlockStore
+	}
+	if want.RemoteBlockStore != nil {
+		current := ""
+		if live.RemoteBlockStoreID != nil {
+			current = p.storeNames[*live.RemoteBlockStoreID]
+		}
+		if current != *want.RemoteBlockStore {
+			changes = append(changes, FieldChange{Field: "remote_block_store", From: display(current), To: display(*want.RemoteBlockStore)})
+			req.RemoteBlockStoreID = want.RemoteBlockStore
+		}
+	}
+	settingsChanged := len(changes) > 0
+
+	enable := want.Enabled != nil && *want.Enabled != live.Enabled
+	if enable {
+		changes = append(changes, FieldChange{Field: "enabled", From: display(live.Enabled), To: display(*want.Enabled)})
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	p.add(Change{Action: ActionUpdate, Resource: fmt.Sprintf("share %q", want.Name), Fields: changes, apply: func() error {
+		if settingsChanged {
+			if _, err := p.srv.UpdateShare(want.Name, req); err != nil {
+				return err
+			}
+		}
+		if !enable {
+			return nil
+		}
+		var err error
+		if *want.Enabled {
+			_, err = p.srv.EnableShare(want.Name)
+		} else {
+			_, err = p.srv.DisableShare(want.Name)
+		}
+		return err
+	}})
+	return nil
+}
+
+// planShareNFS plans the share's NFS export options.
+func (p *planner) planShareNFS(want Share, exists bool) error {
+	if want.NFS == nil {
+		return nil
+	}
+	wantFields, err := toFields(want.NFS)
+	if err != nil {
+		return err
+	}
+	liveFields := fields{}
+	if exists {
+		cfg, err := p.srv.GetShareNFSConfig(want.Name)
+		switch {
+		case isNotFound(err):
+		case err != nil:
+			return fmt.Errorf("get NFS config of share %q: %w", want.Name, err)
+		default:
+			if liveFields, err = toFields(cfg); err != nil {
+				return err
+			}
+		}
+	}
+	changes, changed := diffFields(wantFields, liveFields, map[string]normalizer{"exports": normExports})
+	if len(changes) == 0 {
+		return nil
+	}
+	req, err := into[apiclient.PatchShareNFSConfigRequest](changed)
+	if err != nil {
+		return err
+	}
+	p.add(Change{Action: ActionUpdate, Resource: fmt.Sprintf("share %q NFS options", want.Name), Fields: changes, apply: func() error {
+		_, err := p.srv.PatchShareNFSConfig(want.Name, req)
+		return err
+	}})
+	return nil
+}
+
+// planPermissions makes the share's user and group permissions match the
+// manifest. Direct SID grants are not managed.
+func (p *planner) planPermissions(want Share, exists bool) error {
+	if want.Permissions == nil {
+		return nil
+	}
+	live := map[string]string{} // "user alice" -> level
+	if exists {
+		perms, err := p.srv.ListSharePermissions(want.Name)
+		if err != nil {
+			return fmt.Errorf("list permissions of share %q: %w", want.Name, err)
+		}
+		for _, perm := range perms {
+			if perm.Type == "user" || perm.Type == "group" {
+				live[perm.Type+" "+perm.Name] = perm.Level
+			}
+		}
+	}
+
+	declared := map[string]bool{}
+	for _, perm := range *want.Permissions {
+		kind, name := "user", perm.User
+		if perm.Group != "" {
+			kind, name = "group", perm.Group
+		}
+		key := kind + " " + name
+		declared[key] = true
+		resource := fmt.Sprintf("share %q permission for %s %q", want.Name, kind, name)
+
+		current, ok := live[key]
+		if ok && current == perm.Level {
+			continue
+		}
+		c := Change{Action: ActionCreate, Resource: resource, Fields: []FieldChange{{Field: "level", To: perm.Level}}}
+		if ok {
+			c.Action = ActionUpdate
+			c.Fields[0].From = current
+		}
+		level := perm.Level
+		c.apply = func() error {
+			if kind == "group" {
+				return p.srv.SetGroupSharePermission(want.Name, name, level)
+			}
+			return p.srv.SetUserSharePermission(want.Name, name, level)
+		}
+		p.add(c)
+	}
+	for _, key := range sortedKeys(live) {
+		if declared[key] {
+			continue
+		}
+		kind, name, _ := strings.Cut(key, " ")
+		p.add(Change{Action: ActionDelete, Resource: fmt.Sprintf("share %q permission for %s %q", want.Name, kind, name), apply: func() error {
+			if kind == "group" {
+				return p.srv.RemoveGroupSharePermission(want.Name, name)
+			}
+			return p.srv.RemoveUserSharePermission(want.Name, name)
+		}})
+	}
+	return nil
+}
+
+// quotaNorms canonicalizes quota byte ceilings.
+var quotaNorms = map[string]normalizer{"limit_bytes": normSize, "soft_bytes": normSize}
+
+// quotaFields returns every field of a quota; an upsert replaces them all.
+func quotaFields(limitBytes, softBytes string, limitFiles, softFiles, graceSeconds int64) fields {
+	return fields{
+		"limit_bytes":   limitBytes,
+		"soft_bytes":    softBytes,
+		"limit_files":   float64(limitFiles),
+		"soft_files":    float64(softFiles),
+		"grace_seconds": float64(graceSeconds),
+	}
+}
+
+// planQuotas makes the share's quotas match the manifest.
+func (p *planner) planQuotas(want Share, exists bool) error {
+	if want.Quotas == nil {
+		return nil
+	}
+	live := map[string]apiclient.Quota{}
+	if exists {
+		quotas, err := p.srv.ListQuotas(want.Name)
+		if err != nil {
+			return fmt.Errorf("list quotas of share %q: %w", want.Name, err)
+		}
+		for _, q := range quotas {
+			live[Quota{Scope: q.Scope, ID: q.IdentityID}.key()] = q
+		}
+	}
+
+	declared := map[string]bool{}
+	for _, q := range *want.Quotas {
+		declared[q.key()] = true
+		resource := fmt.Sprintf("share %q quota %s", want.Name, q.key())
+		wantFields := quotaFields(q.LimitBytes, q.SoftBytes, q.LimitFiles, q.SoftFiles, q.GraceSeconds)
+		apply := func() error {
+			_, err := p.srv.SetQuota(want.Name, q.Scope, q.ID, &q.UpsertQuotaRequest)
+			return err
+		}
+
+		current, ok := live[q.key()]
+		if !ok {
+			set, _ := diffFields(wantFields, fields{}, quotaNorms)
+			for i := range set {
+				set[i].From = ""
+			}
+			p.add(Change{Action: ActionCreate, Resource: resource, Fields: set, apply: apply})
+			continue
+		}
+		liveFields := quotaFields(current.LimitBytes, current.SoftBytes, current.LimitFiles, current.SoftFiles, current.GraceSeconds)
+		if changes, _ := diffFields(wantFields, liveFields, quotaNorms); len(changes) > 0 {
+			p.add(Change{Action: ActionUpdate, Resource: resource, Fields: changes, apply: apply})
+		}
+	}
+	for _, key := range sortedKeys(live) {
+		if declared[key] {
+			continue
+		}
+		q := live[key]
+		p.add(Change{Action: ActionDelete, Resource: fmt.Sprintf("share %q quota %s", want.Name, key), apply: func() error {
+			return p.srv.RemoveQuota(want.Name, q.Scope, q.IdentityID)
+		}})
+	}
+	return nil
+}
+
+// snapshotNorms canonicalizes snapshot policy schedules.
+var snapshotNorms = map[string]normalizer{"interval": normInterval, "ttl": normDuration}
+
+// planSnapshotPolicy plans the share's snapshot policy. A policy is turned
+// off with enabled: false; removing it from the manifest leaves it alone.
+func (p *planner) planSnapshotPolicy(want Share) error {
+	sp := want.SnapshotPolicy
+	if sp == nil {
+		return nil
+	}
+	enabled := sp.Enabled == nil || *sp.Enabled
+	wantFields := fields{
+		"interval":    sp.Interval,
+		"keep_last":   float64(sp.KeepLast),
+		"ttl":         sp.TTL,
+		"enabled":     enabled,
+		"name_prefix": sp.NamePrefix,
+	}
+	resource := fmt.Sprintf("share %q snapshot policy", want.Name)
+	apply := func() error {
+		_, err := p.srv.UpsertSnapshotPolicy(want.Name, *sp)
+		return err
+	}
+
+	live, ok := p.policies[want.Name]
+	if !ok {
+		set, _ := diffFields(wantFields, fields{}, snapshotNorms)
+		for i := range set {
+			set[i].From = ""
+		}
+		p.add(Change{Action: ActionCreate, Resource: resource, Fields: set, apply: apply})
+		return nil
+	}
+	liveFields := fields{
+		"interval":    live.Interval,
+		"keep_last":   float64(live.KeepLast),
+		"ttl":         live.TTL,
+		"enabled":     live.Enabled,
+		"name_prefix": live.NamePrefix,
+	}
+	if changes, _ := diffFields(wantFields, liveFields, snapshotNorms); len(changes) > 0 {
+		p.add(Change{Action: ActionUpdate, Resource: resource, Fields: changes, apply: apply})
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+// APITokenPrefix starts every API token, so tokens are recognizable in
+// Authorization headers (and by secret scanners) and cannot be confused
+// with JWTs.
+const APITokenPrefix = "dfs_"
+
+// apiTokenDisplayLen is how much of the plaintext is kept for listings.
+const apiTokenDisplayLen = 12
+
+// apiTokenTouchInterval bounds last-used writes to one per token per minute.
+const apiTokenTouchInterval = time.Minute
+
+// ErrInvalidAPIToken is returned for unknown, expired or unusable API tokens.
+var ErrInvalidAPIToken = errors.New("invalid API token")
+
+// Scopes grantable to API tokens. Each area has a read and a write scope;
+// write implies read. blockstore:gc additionally covers GC and orphan
+// reconciliation. The users area also covers the NFS rules that decide who
+// a client is or which hosts may mount (identity mappings, netgroups and
+// client certificate mappings), since they are as sensitive as accounts.
+var Scopes = []string{
+	"shares:read", "shares:write",
+	"snapshots:read", "snapshots:write",
+	"blockstore:read", "blockstore:write", "blockstore:gc",
+	"stores:read", "stores:write",
+	"adapters:read", "adapters:write",
+	"users:read", "users:write",
+	"settings:read", "settings:write",
+	"system:read", "system:write",
+}
+
+// ValidScope reports whether scope is in Scopes.
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// ScopeGrants reports whether granted covers need: an exact match, or the
+// area's write scope when need is a read scope.
+func ScopeGrants(granted []string, need string) bool {
+	if slices.Contains(granted, need) {
+		return true
+	}
+	if area, ok := strings.CutSuffix(need, ":read"); ok {
+		return slices.Contains(granted, area+":write")
+	}
+	return false
+}
+
+// RequiredScope returns the scope an API token needs for method on path (a
+// full /api/v1/... or /scim/v2/... request path), or "" for paths no token
+// may reach, such as token management itself.
+func RequiredScope(method, path string) string {
+	access := "write"
+	if method == "GET" || method == "HEAD" {
+		access = "read"
+	}
+	if strings.HasPrefix(path, "/scim/v2/") {
+		return "users:" + access
+	}
+	rest, ok := strings.CutPrefix(path, "/api/v1/")
+	if !ok {
+		return ""
+	}
+	segs := strings.Split(strings.Trim(rest, "/"), "/")
+
+	area := ""
+	switch segs[0] {
+	case "shares":
+		area = "shares"
+		if len(segs) >= 3 {
+			switch segs[2] {
+			case "snapshots", "snapshot-policy":
+				area = "snapshots"
+			case "blockstore":
+				area = "blockstore"
+				if len(segs) >= 4 && strings.HasPrefix(segs[3], "gc") {
+					return "blockstore:gc"
+				}
+			}
+		}
+	case "snapshot-policies":
+		area = "snapshots"
+	case "blockstore":
+		area = "blockstore"
+		if len(segs) >= 2 && strings.HasPrefix(segs[1], "reconcile") {
+			return "blockstore:gc"
+		}
+	case "store":
+		area = "stores"
+	case "adapters":
+		area = "adapters"
+		if len(segs) >= 3 {
+			switch segs[2] {
+			case "identity-mappings", "netgroups", "cert-mappings":
+				area = "users"
+			}
+		}
+	case "users", "groups", "identity-mappings", "sid-mappings":
+		// identity-providers is deliberately absent: whoever configures the
+		// LDAP or OIDC provider decides who logs in as admin, so no token
+		// scope reaches it.
+		area = "users"
+	case "settings":
+		area = "settings"
+	case "system", "clients", "mounts", "durable-handles", "netlogon", "grace":
+		area = "system"
+	default:
+		return ""
+	}
+	return area + ":" + access
+}
+
+// GenerateAPIToken returns a new plaintext API token, its storage hash and
+// its display prefix.
+func GenerateAPIToken() (plaintext, hash, prefix string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("generate API token: %w", err)
+	}
+	plaintext = APITokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return plaintext, HashAPIToken(plaintext), plaintext[:apiTokenDisplayLen], nil
+}
+
+// HashAPIToken returns the storage hash of a plaintext API token. Tokens
+// carry 256 bits of entropy, so an unsalted SHA-256 is enough and keeps the
+// lookup a single indexed query.
+func HashAPIToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIToken reports whether a bearer credential is an API token (rather
+// than a JWT).
+func IsAPIToken(bearer string) bool {
+	return strings.HasPrefix(bearer, APITokenPrefix)
+}
+
+// APITokenStore is the persistence APITokenService needs.
+type APITokenStore interface {
+	GetAPITokenByHash(ctx context.Context, hash string) (*models.APIToken, error)
+	TouchAPIToken(ctx context.Context, id string, at time.Time) error
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+}
+
+// APITokenService authenticates API tokens.
+type APITokenService struct {
+	store APITokenStore
+}
+
+// NewAPITokenService creates an APITokenService.
+func NewAPITokenService(store APITokenStore) *APITokenService {
+	return &APITokenService{store: store}
+}
+
+// Authenticate resolves a plaintext API token to claims for its service
+// account, carrying the token's scopes. The token must exist and be
+// unexpired, and its account must still be an enabled service account.
+func (s *APITokenService) Authenticate(ctx context.Context, plaintext string) (*Claims, error) {
+	token, err := s.store.GetAPITokenByHash(ctx, HashAPIToken(plaintext))
+	if err != nil {
+		if errors.Is(err, models.ErrAPITokenNotFound) {
+			return nil, ErrInvalidAPIToken
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if token.IsExpired(now) {
+		return nil, ErrInvalidAPIToken
+	}
+	user, err := s.store.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, ErrInvalidAPIToken
+		}
+		return nil, err
+	}
+	if !user.Enabled || !user.ServiceAccount {
+		return nil, ErrInvalidAPIToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenTouchInterval {
+		if err := s.store.TouchAPIToken(ctx, token.ID, now); err != nil {
+			logger.WarnCtx(ctx, "failed to record API token use", "token", token.Prefix, "error", err)
+		}
+	}
+
+	return &Claims{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		Groups:    user.GetGroupNames(),
+		TokenType: TokenTypeAPI,
+		Scopes:    token.Scopes,
+	}, nil
+}
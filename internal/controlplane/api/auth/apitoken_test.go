@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method, path, want string
+	}{
+		{"GET", "/api/v1/shares", "shares:read"},
+		{"POST", "/api/v1/shares", "shares:write"},
+		{"PUT", "/api/v1/shares/data/permissions", "shares:write"},
+		{"POST", "/api/v1/shares/data/snapshots", "snapshots:write"},
+		{"GET", "/api/v1/shares/data/snapshot-policy", "snapshots:read"},
+		{"GET", "/api/v1/snapshot-policies", "snapshots:read"},
+		{"GET", "/api/v1/shares/data/blockstore/stats", "blockstore:read"},
+		{"POST", "/api/v1/shares/data/blockstore/warm", "blockstore:write"},
+		{"POST", "/api/v1/shares/data/blockstore/gc", "blockstore:gc"},
+		{"GET", "/api/v1/shares/data/blockstore/gc-status", "blockstore:gc"},
+		{"POST", "/api/v1/shares/data/audit/refcounts", "shares:write"},
+		{"GET", "/api/v1/blockstore/reconcile-report", "blockstore:gc"},
+		{"POST", "/api/v1/blockstore/evict", "blockstore:write"},
+		{"GET", "/api/v1/store/block/remote", "stores:read"},
+		{"PUT", "/api/v1/adapters/nfs/settings", "adapters:write"},
+		{"POST", "/api/v1/adapters/nfs/cert-mappings", "users:write"},
+		{"POST", "/api/v1/adapters/nfs/identity-mappings", "users:write"},
+		{"GET", "/api/v1/adapters/nfs/netgroups", "users:read"},
+		{"GET", "/api/v1/groups", "users:read"},
+		{"PUT", "/api/v1/identity-providers/ldap/config", ""},
+		{"GET", "/api/v1/identity-providers/oidc/config", ""},
+		{"GET", "/api/v1/settings/foo", "settings:read"},
+		{"DELETE", "/api/v1/clients/42", "system:write"},
+		{"POST", "/api/v1/tokens", ""},
+		{"GET", "/api/v1/tokens", ""},
+		{"GET", "/api/v1/unknown", ""},
+		{"GET", "/debug/pprof/heap", ""},
+		{"GET", "/scim/v2/Users", "users:read"},
+		{"PATCH", "/scim/v2/Groups/abc", "users:write"},
+	}
+	for _, tt := range tests {
+		if got := RequiredScope(tt.method, tt.path); got != tt.want {
+			t.Errorf("RequiredScope(%s %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestScopeGrants(t *testing.T) {
+	granted := []string{"shares:write", "blockstore:gc"}
+	for need, want := range map[string]bool{
+		"shares:read":      true,
+		"shares:write":     true,
+		"blockstore:gc":    true,
+		"blockstore:read":  false,
+		"snapshots:read":   false,
+		"snapshots:write":  false,
+		"blockstore:write": false,
+	} {
+		if got := ScopeGrants(granted, need); got != want {
+			t.Errorf("ScopeGrants(%v, %s) = %v, want %v", granted, need, got, want)
+		}
+	}
+}
+
+func TestGenerateAPIToken(t *testing.T) {
+	plain, hash, prefix, err := GenerateAPIToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsAPIToken(plain) || !strings.HasPrefix(plain, prefix) {
+		t.Fatalf("token %q, prefix %q", plain, prefix)
+	}
+	if hash != HashAPIToken(plain) || strings.Contains(hash, plain) {
+		t.Fatalf("hash %q does not match token", hash)
+	}
+	other, _, _, _ := GenerateAPIToken()
+	if other == plain {
+		t.Fatal("two generated tokens are equal")
+	}
+}
+
+// fakeTokenStore is an in-memory APITokenStore.
+type fakeTokenStore struct {
+	tokens  map[string]*models.APIToken
+	users   map[string]*models.User
+	touches int
+}
+
+func (f *fakeTokenStore) GetAPITokenByHash(_ context.Context, hash string) (*models.APIToken, error) {
+	if t, ok := f.tokens[hash]; ok {
+		return t, nil
+	}
+	return nil, models.ErrAPITokenNotFound
+}
+
+func (f *fakeTokenStore) TouchAPIToken(_ context.Context, id string, at time.Time) error {
+	f.touches++
+	for _, t := range f.tokens {
+		if t.ID == id {
+			t.LastUsedAt = &at
+		}
+	}
+	return nil
+}
+
+func (f *fakeTokenStore) GetUserByID(_ context.Context, id string) (*models.User, error) {
+	if u, ok := f.users[id]; ok {
+		return u, nil
+	}
+	return nil, models.ErrUserNotFound
+}
+
+func TestAPITokenService_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	plain, hash, _, _ := GenerateAPIToken()
+	account := &models.User{ID: "u1", Username: "terraform", Role: "user", Enabled: true, ServiceAccount: true}
+	token := &models.APIToken{ID: "t1", UserID: "u1", Hash: hash, Scopes: []string{"shares:read"}}
+	store := &fakeTokenStore{
+		tokens: map[string]*models.APIToken{hash: token},
+		users:  map[string]*models.User{"u1": account},
+	}
+	svc := NewAPITokenService(store)
+
+	claims, err := svc.Authenticate(ctx, plain)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if !claims.IsAPIToken() || claims.Username != "terraform" || len(claims.Scopes) != 1 {
+		t.Fatalf("claims = %+v", claims)
+	}
+	if token.LastUsedAt == nil {
+		t.Fatal("last-used time not recorded")
+	}
+	if _, err := svc.Authenticate(ctx, plain); err != nil {
+		t.Fatal(err)
+	}
+	if store.touches != 1 {
+		t.Fatalf("touches = %d, want 1 (throttled)", store.touches)
+	}
+
+	if _, err := svc.Authenticate(ctx, plain+"x"); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Fatalf("unknown token: err = %v", err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	token.ExpiresAt = &past
+	if _, err := svc.Authenticate(ctx, plain); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Fatalf("expired token: err = %v", err)
+	}
+	token.ExpiresAt = nil
+
+	account.Enabled = false
+	if _, err := svc.Authenticate(ctx, plain); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Fatalf("disabled account: err = %v", err)
+	}
+	account.Enabled = true
+	account.ServiceAccount = false
+	if _, err := svc.Authenticate(ctx, plain); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Fatalf("human account: err = %v", err)
+	}
+}
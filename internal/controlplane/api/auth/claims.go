@@ -13,6 +13,9 @@ const (
 	TokenTypeAccess TokenType = "access"
 	// TokenTypeRefresh is a long-lived token used to obtain new access tokens.
 	TokenTypeRefresh TokenType = "refresh"
+	// TokenTypeAPI marks claims derived from a service-account API token.
+	// They are never signed as a JWT; see APITokenService.
+	TokenTypeAPI TokenType = "api"
 )
 
 // Claims represents JWT claims for DittoFS authentication.
@@ -41,6 +44,16 @@ type Claims struct {
 	// MustChangePassword indicates the user must change their password.
 	// When true, most API operations are blocked until password is changed.
 	MustChangePassword bool `json:"must_change_password,omitempty"`
+
+	// Scopes lists the API areas an API token may reach (see RequiredScope).
+	// Only set for TokenTypeAPI claims.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Delegated marks claims raised to the admin role for a single request
+	// by an API-token scope or a custom role. It is never serialized.
+	// Handlers that could otherwise hand out full admin rights (creating or
+	// editing admin users) refuse delegated callers.
+	Delegated bool `json:"-"`
 }
 
 // IsAccessToken returns true if this is an access token.
@@ -53,6 +66,11 @@ func (c *Claims) IsRefreshToken() bool {
 	return c.TokenType == TokenTypeRefresh
 }
 
+// IsAPIToken returns true if these claims come from an API token.
+func (c *Claims) IsAPIToken() bool {
+	return c.TokenType == TokenTypeAPI
+}
+
 // IsAdmin returns true if the user has admin role.
 func (c *Claims) IsAdmin() bool {
 	return c.Role == "admin"
@@ -0,0 +1,482 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Errors returned by the OIDC client. The device-flow errors mirror the
+// RFC 8628 token endpoint error codes.
+var (
+	ErrOIDCDiscovery         = errors.New("oidc: discovery failed")
+	ErrOIDCInvalidIDToken    = errors.New("oidc: invalid ID token")
+	ErrOIDCNoDeviceFlow      = errors.New("oidc: identity provider does not support the device flow")
+	ErrAuthorizationPending  = errors.New("oidc: authorization pending")
+	ErrSlowDown              = errors.New("oidc: slow down")
+	ErrDeviceAccessDenied    = errors.New("oidc: access denied")
+	ErrDeviceCodeExpired     = errors.New("oidc: device code expired")
+	ErrOIDCTokenRequestError = errors.New("oidc: token request rejected")
+)
+
+// oidcKeyRefreshInterval bounds how often an unknown key ID triggers a JWKS
+// refetch, so a stream of forged tokens cannot hammer the IdP.
+const oidcKeyRefreshInterval = time.Minute
+
+// oidcMaxResponseBytes caps IdP response bodies.
+const oidcMaxResponseBytes = 1 << 20
+
+// OIDCClientConfig holds the relying-party settings of an OIDCClient.
+type OIDCClientConfig struct {
+	// Issuer is the IdP's issuer URL; discovery is fetched from
+	// Issuer + "/.well-known/openid-configuration".
+	Issuer string
+
+	// ClientID is the OAuth client registered for DittoFS.
+	ClientID string
+
+	// ClientSecret authenticates confidential clients at the token endpoint
+	// (client_secret_basic). Empty for public clients.
+	ClientSecret string
+
+	// Scopes are requested by the device flow (and advertised to dfsctl for
+	// the authorization-code flow).
+	Scopes []string
+
+	// HTTPClient performs IdP requests. Default: 10-second-timeout client.
+	HTTPClient *http.Client
+}
+
+// OIDCDiscovery is the subset of the IdP's discovery document DittoFS uses.
+type OIDCDiscovery struct {
+	Issuer                      string `json:"issuer"`
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint,omitempty"`
+	JWKSURI                     string `json:"jwks_uri"`
+}
+
+// OIDCTokens is an IdP token endpoint response.
+type OIDCTokens struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// DeviceAuthorization is an RFC 8628 device authorization response.
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval,omitempty"`
+}
+
+// IDToken is a verified OIDC ID token.
+type IDToken struct {
+	Issuer  string
+	Subject string
+	Claims  jwt.MapClaims
+}
+
+// StringClaim returns the named claim as a string, or "" when it is absent
+// or not a string.
+func (t *IDToken) StringClaim(name string) string {
+	s, _ := t.Claims[name].(string)
+	return s
+}
+
+// StringsClaim returns the named claim as a string list. A single string is
+// returned as a one-element list; non-string elements are skipped.
+func (t *IDToken) StringsClaim(name string) []string {
+	switch v := t.Claims[name].(type) {
+	case string:
+		return []string{v}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// OIDCClient is an OpenID Connect relying party: it discovers the IdP's
+// endpoints, redeems authorization codes and device codes at its token
+// endpoint, and verifies the returned ID tokens against the IdP's JWKS.
+//
+// Discovery and keys are fetched lazily and cached, so an IdP outage at
+// startup does not block the server. Safe for concurrent use.
+type OIDCClient struct {
+	config OIDCClientConfig
+
+	mu          sync.Mutex
+	discovery   *OIDCDiscovery
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+// NewOIDCClient creates an OIDC client for the given IdP.
+func NewOIDCClient(config OIDCClientConfig) (*OIDCClient, error) {
+	if config.Issuer == "" || config.ClientID == "" {
+		return nil, errors.New("oidc: issuer and client ID are required")
+	}
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDCClient{config: config}, nil
+}
+
+// ClientID returns the configured OAuth client ID.
+func (c *OIDCClient) ClientID() string { return c.config.ClientID }
+
+// Scopes returns the configured scopes.
+func (c *OIDCClient) Scopes() []string { return c.config.Scopes }
+
+// Discovery returns the IdP's discovery document, fetching it on first use.
+// A document whose issuer differs from the configured one is rejected.
+func (c *OIDCClient) Discovery(ctx context.Context) (*OIDCDiscovery, error) {
+	c.mu.Lock()
+	cached := c.discovery
+	c.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	var doc OIDCDiscovery
+	if err := c.getJSON(ctx, c.config.Issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCDiscovery, err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != c.config.Issuer {
+		return nil, fmt.Errorf("%w: issuer %q does not match configured %q", ErrOIDCDiscovery, doc.Issuer, c.config.Issuer)
+	}
+	if doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: token_endpoint and jwks_uri are required", ErrOIDCDiscovery)
+	}
+
+	c.mu.Lock()
+	c.discovery = &doc
+	c.mu.Unlock()
+	return &doc, nil
+}
+
+// ExchangeCode redeems an authorization code obtained with PKCE.
+func (c *OIDCClient) ExchangeCode(ctx context.Context, code, codeVerifier, redirectURI string) (*OIDCTokens, error) {
+	doc, err := c.Discovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.tokenRequest(ctx, doc.TokenEndpoint, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"code_verifier": {codeVerifier},
+		"redirect_uri":  {redirectURI},
+	})
+}
+
+// StartDeviceAuthorization begins an RFC 8628 device authorization.
+func (c *OIDCClient) StartDeviceAuthorization(ctx context.Context) (*DeviceAuthorization, error) {
+	doc, err := c.Discovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if doc.DeviceAuthorizationEndpoint == "" {
+		return nil, ErrOIDCNoDeviceFlow
+	}
+	form := url.Values{"scope": {strings.Join(c.config.Scopes, " ")}}
+	resp, err := c.postForm(ctx, doc.DeviceAuthorizationEndpoint, form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, tokenError(resp)
+	}
+	var da DeviceAuthorization
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseBytes)).Decode(&da); err != nil {
+		return nil, fmt.Errorf("oidc: decode device authorization: %w", err)
+	}
+	if da.Interval <= 0 {
+		da.Interval = 5
+	}
+	return &da, nil
+}
+
+// PollDeviceToken polls for the tokens of a device authorization. It returns
+// ErrAuthorizationPending or ErrSlowDown while the user has not yet approved,
+// and ErrDeviceAccessDenied or ErrDeviceCodeExpired when the flow is over.
+func (c *OIDCClient) PollDeviceToken(ctx context.Context, deviceCode string) (*OIDCTokens, error) {
+	doc, err := c.Discovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.tokenRequest(ctx, doc.TokenEndpoint, url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {deviceCode},
+	})
+}
+
+// VerifyIDToken verifies an ID token's signature against the IdP's keys and
+// checks its issuer, audience and expiry. A non-empty nonce must match the
+// token's nonce claim.
+func (c *OIDCClient) VerifyIDToken(ctx context.Context, raw, nonce string) (*IDToken, error) {
+	if _, err := c.Discovery(ctx); err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return c.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(c.config.Issuer),
+		jwt.WithAudience(c.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCInvalidIDToken, err)
+	}
+	if nonce != "" {
+		if got, _ := claims["nonce"].(string); got != nonce {
+			return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCInvalidIDToken)
+		}
+	}
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrOIDCInvalidIDToken)
+	}
+	return &IDToken{Issuer: c.config.Issuer, Subject: sub, Claims: claims}, nil
+}
+
+// key returns the IdP signing key kid, refetching the JWKS (at most once per
+// oidcKeyRefreshInterval) when the key is unknown, e.g. after a rotation.
+// An empty kid matches a JWKS holding a single key.
+func (c *OIDCClient) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	k, ok := lookupKey(c.keys, kid)
+	stale := time.Since(c.keysFetched) >= oidcKeyRefreshInterval
+	c.mu.Unlock()
+	if ok {
+		return k, nil
+	}
+	if !stale {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	doc, err := c.Discovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := c.fetchKeys(ctx, doc.JWKSURI)
+	c.mu.Lock()
+	c.keysFetched = time.Now()
+	if err == nil {
+		c.keys = keys
+	}
+	k, ok = lookupKey(c.keys, kid)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return k, nil
+}
+
+func lookupKey(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, k := range keys {
+			return k, true
+		}
+	}
+	k, ok := keys[kid]
+	return k, ok
+}
+
+// jwk is one JSON Web Key; only the RSA and EC members are read.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchKeys downloads the JWKS, keeping the signature keys it can parse.
+func (c *OIDCClient) fetchKeys(ctx context.Context, jwksURI string) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := c.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc: fetch keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC point")
+		}
+		point := append([]byte{4}, append(x, y...)...)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// tokenRequest posts a grant to the token endpoint.
+func (c *OIDCClient) tokenRequest(ctx context.Context, endpoint string, form url.Values) (*OIDCTokens, error) {
+	resp, err := c.postForm(ctx, endpoint, form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, tokenError(resp)
+	}
+	var tokens OIDCTokens
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseBytes)).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("oidc: decode token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: response has no id_token (is the openid scope requested?)", ErrOIDCTokenRequestError)
+	}
+	return &tokens, nil
+}
+
+// postForm posts form to endpoint, authenticating the client with
+// client_secret_basic when a secret is configured and by client_id otherwise.
+func (c *OIDCClient) postForm(ctx context.Context, endpoint string, form url.Values) (*http.Response, error) {
+	if c.config.ClientSecret == "" {
+		form.Set("client_id", c.config.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.config.ClientID), url.QueryEscape(c.config.ClientSecret))
+	}
+	resp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: %w", err)
+	}
+	return resp, nil
+}
+
+// tokenError converts an OAuth error response into an error, mapping the
+// device-flow codes to their sentinels.
+func tokenError(resp *http.Response) error {
+	var body struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseBytes)).Decode(&body)
+	switch body.Error {
+	case "authorization_pending":
+		return ErrAuthorizationPending
+	case "slow_down":
+		return ErrSlowDown
+	case "access_denied":
+		return ErrDeviceAccessDenied
+	case "expired_token":
+		return ErrDeviceCodeExpired
+	case "":
+		return fmt.Errorf("%w: status %d", ErrOIDCTokenRequestError, resp.StatusCode)
+	}
+	if body.ErrorDescription != "" {
+		return fmt.Errorf("%w: %s: %s", ErrOIDCTokenRequestError, body.Error, body.ErrorDescription)
+	}
+	return fmt.Errorf("%w: %s", ErrOIDCTokenRequestError, body.Error)
+}
+
+func (c *OIDCClient) getJSON(ctx context.Context, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseBytes)).Decode(v)
+}
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeIdP is a minimal OIDC provider: discovery, JWKS, and a token endpoint
+// that answers the authorization-code grant with idToken and the device grant
+// with devicePending until approved.
+type fakeIdP struct {
+	srv     *httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+	// approved flips the device grant from authorization_pending to success.
+	approved bool
+	// gotVerifier records the last code_verifier the token endpoint saw.
+	gotVerifier string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &fakeIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(OIDCDiscovery{
+			Issuer:                      idp.srv.URL,
+			AuthorizationEndpoint:       idp.srv.URL + "/authorize",
+			TokenEndpoint:               idp.srv.URL + "/token",
+			DeviceAuthorizationEndpoint: idp.srv.URL + "/device",
+			JWKSURI:                     idp.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []jwk{{
+			Kty: "RSA", Kid: "k1", Use: "sig",
+			N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(DeviceAuthorization{
+			DeviceCode: "dev-1", UserCode: "ABCD-EFGH",
+			VerificationURI: idp.srv.URL + "/activate", ExpiresIn: 600,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("grant_type") == "urn:ietf:params:oauth:grant-type:device_code" && !idp.approved {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"authorization_pending"}`))
+			return
+		}
+		idp.gotVerifier = r.Form.Get("code_verifier")
+		_ = json.NewEncoder(w).Encode(OIDCTokens{IDToken: idp.idToken, TokenType: "Bearer"})
+	})
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+	return idp
+}
+
+// sign returns an RS256 ID token over claims.
+func (idp *fakeIdP) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = "k1"
+	s, err := tok.SignedString(idp.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func (idp *fakeIdP) claims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":    idp.srv.URL,
+		"aud":    "dittofs",
+		"sub":    "00u1",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"nonce":  nonce,
+		"groups": []string{"storage-admins", "staff"},
+	}
+}
+
+func TestOIDCClient_ExchangeAndVerify(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdP(t)
+	c, err := NewOIDCClient(OIDCClientConfig{Issuer: idp.srv.URL, ClientID: "dittofs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp.idToken = idp.sign(t, idp.claims("n-1"))
+	tokens, err := c.ExchangeCode(ctx, "code", "verifier-1", "http://127.0.0.1:9/callback")
+	if err != nil {
+		t.Fatalf("ExchangeCode: %v", err)
+	}
+	if idp.gotVerifier != "verifier-1" {
+		t.Fatalf("code_verifier = %q, want verifier-1", idp.gotVerifier)
+	}
+	tok, err := c.VerifyIDToken(ctx, tokens.IDToken, "n-1")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if tok.Subject != "00u1" || tok.Issuer != idp.srv.URL {
+		t.Fatalf("token = %+v", tok)
+	}
+	if g := tok.StringsClaim("groups"); len(g) != 2 || g[0] != "storage-admins" {
+		t.Fatalf("groups = %v", g)
+	}
+
+	if _, err := c.VerifyIDToken(ctx, tokens.IDToken, "other"); !errors.Is(err, ErrOIDCInvalidIDToken) {
+		t.Fatalf("nonce mismatch: err = %v", err)
+	}
+}
+
+func TestOIDCClient_RejectsBadTokens(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdP(t)
+	c, _ := NewOIDCClient(OIDCClientConfig{Issuer: idp.srv.URL, ClientID: "dittofs"})
+
+	mutate := map[string]func(jwt.MapClaims){
+		"wrong audience": func(m jwt.MapClaims) { m["aud"] = "someone-else" },
+		"wrong issuer":   func(m jwt.MapClaims) { m["iss"] = "https://evil.example.com" },
+		"expired":        func(m jwt.MapClaims) { m["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no expiry":      func(m jwt.MapClaims) { delete(m, "exp") },
+		"no subject":     func(m jwt.MapClaims) { delete(m, "sub") },
+	}
+	for name, fn := range mutate {
+		t.Run(name, func(t *testing.T) {
+			claims := idp.claims("")
+			fn(claims)
+			if _, err := c.VerifyIDToken(ctx, idp.sign(t, claims), ""); !errors.Is(err, ErrOIDCInvalidIDToken) {
+				t.Fatalf("err = %v, want ErrOIDCInvalidIDToken", err)
+			}
+		})
+	}
+
+	t.Run("foreign key", func(t *testing.T) {
+		other, _ := rsa.GenerateKey(rand.Reader, 2048)
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims(""))
+		tok.Header["kid"] = "k1"
+		raw, _ := tok.SignedString(other)
+		if _, err := c.VerifyIDToken(ctx, raw, ""); !errors.Is(err, ErrOIDCInvalidIDToken) {
+			t.Fatalf("err = %v, want ErrOIDCInvalidIDToken", err)
+		}
+	})
+
+	t.Run("hmac with public key", func(t *testing.T) {
+		tok := jwt.NewWithClaims(jwt.SigningMethodHS256, idp.claims(""))
+		tok.Header["kid"] = "k1"
+		raw, _ := tok.SignedString(idp.key.N.Bytes())
+		if _, err := c.VerifyIDToken(ctx, raw, ""); !errors.Is(err, ErrOIDCInvalidIDToken) {
+			t.Fatalf("err = %v, want ErrOIDCInvalidIDToken", err)
+		}
+	})
+}
+
+func TestOIDCClient_DeviceFlow(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdP(t)
+	c, _ := NewOIDCClient(OIDCClientConfig{Issuer: idp.srv.URL, ClientID: "dittofs", Scopes: []string{"openid"}})
+
+	da, err := c.StartDeviceAuthorization(ctx)
+	if err != nil {
+		t.Fatalf("StartDeviceAuthorization: %v", err)
+	}
+	if da.UserCode != "ABCD-EFGH" || da.Interval != 5 {
+		t.Fatalf("device authorization = %+v", da)
+	}
+	if _, err := c.PollDeviceToken(ctx, da.DeviceCode); !errors.Is(err, ErrAuthorizationPending) {
+		t.Fatalf("poll before approval: err = %v", err)
+	}
+	idp.approved = true
+	idp.idToken = idp.sign(t, idp.claims(""))
+	tokens, err := c.PollDeviceToken(ctx, da.DeviceCode)
+	if err != nil {
+		t.Fatalf("poll after approval: %v", err)
+	}
+	if _, err := c.VerifyIDToken(ctx, tokens.IDToken, ""); err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+}
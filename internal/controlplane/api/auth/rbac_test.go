@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func TestRequiredPermission(t *testing.T) {
+	tests := []struct {
+		method, path, perm, share string
+	}{
+		{"GET", "/api/v1/shares", "shares:read", ""},
+		{"POST", "/api/v1/shares", "shares:write", ""},
+		{"GET", "/api/v1/shares/projects", "shares:read", "/projects"},
+		{"PUT", "/api/v1/shares/projects/permissions/users/bob", "shares:write", "/projects"},
+		{"PUT", "/api/v1/shares/projects/quotas/user/1000", "quotas:write", "/projects"},
+		{"POST", "/api/v1/shares/projects/trash/empty", "trash:write", "/projects"},
+		{"POST", "/api/v1/shares/projects/snapshots", "snapshots:write", "/projects"},
+		{"GET", "/api/v1/shares/projects/snapshot-policy", "snapshots:read", "/projects"},
+		{"POST", "/api/v1/shares/projects/blockstore/gc", "stores:write", "/projects"},
+		{"PATCH", "/api/v1/shares/projects/adapters/nfs/config", "adapters:write", "/projects"},
+		{"GET", "/api/v1/shares/team%2Fa/quotas", "quotas:read", "/team/a"},
+		{"GET", "/api/v1/snapshot-policies", "snapshots:read", ""},
+		{"GET", "/api/v1/store/block/remote", "stores:read", ""},
+		{"POST", "/api/v1/blockstore/evict", "stores:write", ""},
+		{"GET", "/api/v1/adapters", "adapters:read", ""},
+		{"PUT", "/api/v1/adapters/nfs/settings", "adapters:write", ""},
+		{"POST", "/api/v1/adapters/nfs/cert-mappings", "users:write", ""},
+		{"DELETE", "/api/v1/adapters/nfs/netgroups/lab", "users:write", ""},
+		{"DELETE", "/api/v1/groups/eng", "users:write", ""},
+		{"GET", "/api/v1/settings", "", ""},
+		{"GET", "/api/v1/roles", "", ""},
+		{"POST", "/api/v1/tokens", "", ""},
+		{"POST", "/api/v1/system/drain-uploads", "", ""},
+	}
+	for _, tt := range tests {
+		perm, share := RequiredPermission(tt.method, tt.path)
+		if perm != tt.perm || share != tt.share {
+			t.Errorf("RequiredPermission(%s %s) = (%q, %q), want (%q, %q)", tt.method, tt.path, perm, share, tt.perm, tt.share)
+		}
+	}
+}
+
+func TestBindingsAllow(t *testing.T) {
+	lead := &models.Role{Name: "project-lead", Permissions: []string{"quotas:write", "shares:read"}}
+	auditor := &models.Role{Name: "auditor", Permissions: []string{"users:read"}}
+	bindings := []*models.RoleBinding{
+		{Role: lead, Shares: []string{"/projects"}},
+		{Role: auditor},
+	}
+
+	tests := []struct {
+		perm, share string
+		want        bool
+	}{
+		{"quotas:write", "/projects", true},
+		{"quotas:read", "/projects", true},
+		{"shares:read", "/projects", true},
+		{"quotas:write", "/other", false},
+		{"shares:read", "", false},
+		{"trash:write", "/projects", false},
+		{"users:read", "", true},
+		{"users:write", "", false},
+	}
+	for _, tt := range tests {
+		if got := BindingsAllow(bindings, tt.perm, tt.share); got != tt.want {
+			t.Errorf("BindingsAllow(%s, %q) = %v, want %v", tt.perm, tt.share, got, tt.want)
+		}
+	}
+}
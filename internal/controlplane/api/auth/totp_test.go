@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 Appendix B test vectors for HMAC-SHA1, truncated to 6 digits.
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	code, _ := TOTPCode(secret, now)
+
+	step, ok := ValidateTOTP(secret, code, now)
+	if !ok || step != now.Unix()/30 {
+		t.Fatalf("current code: ok=%v step=%d", ok, step)
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(30*time.Second)); !ok {
+		t.Error("code from the previous step rejected despite skew")
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(2*time.Minute)); ok {
+		t.Error("stale code accepted")
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now); ok {
+		t.Error("short code accepted")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("DittoFS", "alice", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/DittoFS:alice?") || !strings.Contains(uri, "secret=ABC") || !strings.Contains(uri, "issuer=DittoFS") {
+		t.Errorf("uri = %s", uri)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/marmos91/dittofs/internal/controlplane/api/middleware"
@@ -55,6 +58,11 @@ type PatchNFSSettingsRequest struct {
 	PortmapperRegisterWithSystem *bool     `json:"portmapper_register_with_system,omitempty"`
 	UDPEnabled                   *bool     `json:"udp_enabled,omitempty"`
 	MDNSEnabled                  *bool     `json:"mdns_enabled,omitempty"`
+	IDMapDomain                  *string   `json:"idmap_domain,omitempty"`
+	IDMapNumericAuthSys          *bool     `json:"idmap_numeric_auth_sys,omitempty"`
+	PNFSDataServers              *[]string `json:"pnfs_data_servers,omitempty"`
+	PNFSDataServerVersion        *string   `json:"pnfs_data_server_version,omitempty"`
+	RQuotaSetQuotaEnabled        *bool     `json:"rquota_set_quota_enabled,omitempty"`
 }
 
 // PutNFSSettingsRequest requires all fields for full replacement.
@@ -84,6 +92,11 @@ type PutNFSSettingsRequest struct {
 	PortmapperRegisterWithSystem bool     `json:"portmapper_register_with_system"`
 	UDPEnabled                   bool     `json:"udp_enabled"`
 	MDNSEnabled                  bool     `json:"mdns_enabled"`
+	IDMapDomain                  string   `json:"idmap_domain"`
+	IDMapNumericAuthSys          bool     `json:"idmap_numeric_auth_sys"`
+	PNFSDataServers              []string `json:"pnfs_data_servers"`
+	PNFSDataServerVersion        string   `json:"pnfs_data_server_version"`
+	RQuotaSetQuotaEnabled        bool     `json:"rquota_set_quota_enabled"`
 }
 
 // --- SMB request types ---
@@ -149,6 +162,11 @@ type NFSSettingsResponse struct {
 	PortmapperRegisterWithSystem bool     `json:"portmapper_register_with_system"`
 	UDPEnabled                   bool     `json:"udp_enabled"`
 	MDNSEnabled                  bool     `json:"mdns_enabled"`
+	IDMapDomain                  string   `json:"idmap_domain"`
+	IDMapNumericAuthSys          bool     `json:"idmap_numeric_auth_sys"`
+	PNFSDataServers              []string `json:"pnfs_data_servers"`
+	PNFSDataServerVersion        string   `json:"pnfs_data_server_version"`
+	RQuotaSetQuotaEnabled        bool     `json:"rquota_set_quota_enabled"`
 	Version                      int      `json:"version"`
 }
 
@@ -294,6 +312,7 @@ func (h *AdapterSettingsHandler) GetDefaults(w http.ResponseWriter, r *http.Requ
 				"v4_max_minor_version":           {Min: 0, Max: 2},
 				"v4_max_connections_per_session": {Min: 0, Max: 1024},
 				"portmapper_port":                {Min: ranges.PortmapperPortMin, Max: ranges.PortmapperPortMax},
+				"pnfs_data_server_version":       {Values: models.ValidPNFSDataServerVersions},
 			},
 		}
 		WriteJSONOK(w, resp)
@@ -375,6 +394,11 @@ func (h *AdapterSettingsHandler) PutSettings(w http.ResponseWriter, r *http.Requ
 		settings.PortmapperRegisterWithSystem = req.PortmapperRegisterWithSystem
 		settings.UDPEnabled = req.UDPEnabled
 		settings.MDNSEnabled = req.MDNSEnabled
+		settings.IDMapDomain = req.IDMapDomain
+		settings.IDMapNumericAuthSys = req.IDMapNumericAuthSys
+		settings.SetPNFSDataServers(req.PNFSDataServers)
+		settings.PNFSDataServerVersion = req.PNFSDataServerVersion
+		settings.RQuotaSetQuotaEnabled = req.RQuotaSetQuotaEnabled
 
 		if !h.validateAndRespond(w, adapterType, settings, nil, force) {
 			return
@@ -559,6 +583,21 @@ func (h *AdapterSettingsHandler) PatchSettings(w http.ResponseWriter, r *http.Re
 		if req.MDNSEnabled != nil {
 			settings.MDNSEnabled = *req.MDNSEnabled
 		}
+		if req.IDMapDomain != nil {
+			settings.IDMapDomain = *req.IDMapDomain
+		}
+		if req.IDMapNumericAuthSys != nil {
+			settings.IDMapNumericAuthSys = *req.IDMapNumericAuthSys
+		}
+		if req.PNFSDataServers != nil {
+			settings.SetPNFSDataServers(*req.PNFSDataServers)
+		}
+		if req.PNFSDataServerVersion != nil {
+			settings.PNFSDataServerVersion = *req.PNFSDataServerVersion
+		}
+		if req.RQuotaSetQuotaEnabled != nil {
+			settings.RQuotaSetQuotaEnabled = *req.RQuotaSetQuotaEnabled
+		}
 
 		if !h.validateAndRespond(w, adapterType, settings, nil, force) {
 			return
@@ -806,6 +845,24 @@ func validateNFSSettings(s *models.NFSAdapterSettings) map[string]string {
 	}
 	validateIntRange(errs, "v4_max_connections_per_session", s.V4MaxConnectionsPerSession, 0, 1024)
 
+	// The ID-mapping domain is the part after "@" in owner strings.
+	if d := s.IDMapDomain; d != "" && (len(d) > 255 || strings.ContainsAny(d, "@ \t/")) {
+		errs["idmap_domain"] = "must be a DNS domain name (no '@', '/' or whitespace)"
+	}
+
+	// pNFS data servers are dialed by clients, so each needs an explicit port.
+	for _, ds := range s.GetPNFSDataServers() {
+		host, port, err := net.SplitHostPort(ds)
+		if p, perr := strconv.Atoi(port); err != nil || host == "" || perr != nil || p < 1 || p > 65535 {
+			errs["pnfs_data_servers"] = fmt.Sprintf("invalid data server address %q (want host:port)", ds)
+			break
+		}
+	}
+	// Empty means the default ("3").
+	if v := s.PNFSDataServerVersion; v != "" && !slices.Contains(models.ValidPNFSDataServerVersions, v) {
+		errs["pnfs_data_server_version"] = fmt.Sprintf("must be one of: %v", models.ValidPNFSDataServerVersions)
+	}
+
 	// Blocked operations validation
 	for _, op := range s.GetBlockedOperations() {
 		if !isValidNFSOperation(op) {
@@ -1003,6 +1060,16 @@ func resetNFSSetting(settings, defaults *models.NFSAdapterSettings, name string)
 		settings.PortmapperRegisterWithSystem = defaults.PortmapperRegisterWithSystem
 	case "mdns_enabled":
 		settings.MDNSEnabled = defaults.MDNSEnabled
+	case "idmap_domain":
+		settings.IDMapDomain = defaults.IDMapDomain
+	case "idmap_numeric_auth_sys":
+		settings.IDMapNumericAuthSys = defaults.IDMapNumericAuthSys
+	case "pnfs_data_servers":
+		settings.PNFSDataServers = defaults.PNFSDataServers
+	case "pnfs_data_server_version":
+		settings.PNFSDataServerVersion = defaults.PNFSDataServerVersion
+	case "rquota_set_quota_enabled":
+		settings.RQuotaSetQuotaEnabled = defaults.RQuotaSetQuotaEnabled
 	default:
 		return false
 	}
@@ -1049,6 +1116,10 @@ func nfsSettingsToResponse(s *models.NFSAdapterSettings) NFSSettingsResponse {
 	if ops == nil {
 		ops = []string{}
 	}
+	dataServers := s.GetPNFSDataServers()
+	if dataServers == nil {
+		dataServers = []string{}
+	}
 	return NFSSettingsResponse{
 		MinVersion:                   s.MinVersion,
 		MaxVersion:                   s.MaxVersion,
@@ -1075,6 +1146,11 @@ func nfsSettingsToResponse(s *models.NFSAdapterSettings) NFSSettingsResponse {
 		PortmapperRegisterWithSystem: s.PortmapperRegisterWithSystem,
 		UDPEnabled:                   s.UDPEnabled,
 		MDNSEnabled:                  s.MDNSEnabled,
+		IDMapDomain:                  s.IDMapDomain,
+		IDMapNumericAuthSys:          s.IDMapNumericAuthSys,
+		PNFSDataServers:              dataServers,
+		PNFSDataServerVersion:        s.PNFSDataServerVersion,
+		RQuotaSetQuotaEnabled:        s.RQuotaSetQuotaEnabled,
 		Version:                      s.Version,
 	}
 }
@@ -226,6 +226,94 @@ func TestPatchNFSSettings_ValidationError(t *testing.T) {
 	}
 }
 
+func TestPatchNFSSettings_InvalidIDMapDomain(t *testing.T) {
+	_, handler, _ := setupAdapterSettingsTest(t)
+
+	domain := "alice@example.com"
+	patchReq := PatchNFSSettingsRequest{
+		IDMapDomain: &domain,
+	}
+
+	req := makeAdapterSettingsRequest(t, http.MethodPatch, "nfs", "", patchReq)
+	w := httptest.NewRecorder()
+
+	handler.PatchSettings(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("PatchSettings() status = %d, want %d, body = %s", w.Code, http.StatusUnprocessableEntity, w.Body.String())
+	}
+
+	var resp ValidationErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal validation error: %v", err)
+	}
+
+	if _, ok := resp.Errors["idmap_domain"]; !ok {
+		t.Error("Expected per-field error for idmap_domain")
+	}
+}
+
+func TestPatchNFSSettings_PNFSDataServers(t *testing.T) {
+	_, handler, _ := setupAdapterSettingsTest(t)
+
+	servers := []string{"ds1.example.com:2049", "[fd00::2]:2049"}
+	version := "4.1"
+	patchReq := PatchNFSSettingsRequest{
+		PNFSDataServers:       &servers,
+		PNFSDataServerVersion: &version,
+	}
+
+	req := makeAdapterSettingsRequest(t, http.MethodPatch, "nfs", "", patchReq)
+	w := httptest.NewRecorder()
+
+	handler.PatchSettings(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("PatchSettings() status = %d, want %d, body = %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp NFSSettingsResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	if len(resp.PNFSDataServers) != 2 || resp.PNFSDataServers[0] != servers[0] || resp.PNFSDataServers[1] != servers[1] {
+		t.Errorf("PNFSDataServers = %v, want %v", resp.PNFSDataServers, servers)
+	}
+	if resp.PNFSDataServerVersion != "4.1" {
+		t.Errorf("PNFSDataServerVersion = %q, want 4.1", resp.PNFSDataServerVersion)
+	}
+}
+
+func TestPatchNFSSettings_InvalidPNFSDataServers(t *testing.T) {
+	_, handler, _ := setupAdapterSettingsTest(t)
+
+	servers := []string{"ds1.example.com"} // no port
+	version := "4.2"
+	patchReq := PatchNFSSettingsRequest{
+		PNFSDataServers:       &servers,
+		PNFSDataServerVersion: &version,
+	}
+
+	req := makeAdapterSettingsRequest(t, http.MethodPatch, "nfs", "", patchReq)
+	w := httptest.NewRecorder()
+
+	handler.PatchSettings(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("PatchSettings() status = %d, want %d, body = %s", w.Code, http.StatusUnprocessableEntity, w.Body.String())
+	}
+
+	var resp ValidationErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal validation error: %v", err)
+	}
+
+	for _, field := range []string{"pnfs_data_servers", "pnfs_data_server_version"} {
+		if _, ok := resp.Errors[field]; !ok {
+			t.Errorf("Expected per-field error for %s", field)
+		}
+	}
+}
+
 func TestPatchNFSSettings_Force(t *testing.T) {
 	_, handler, _ := setupAdapterSettingsTest(t)
 
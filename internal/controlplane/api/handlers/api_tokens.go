@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/marmos91/dittofs/internal/controlplane/api/auth"
+	"github.com/marmos91/dittofs/internal/controlplane/api/middleware"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+)
+
+// apiTokenHandlerStore is the subset of the control plane store the API
+// token handler needs.
+type apiTokenHandlerStore interface {
+	store.UserStore
+	store.APITokenStore
+}
+
+// APITokenHandler handles service-account API token management (admin only).
+type APITokenHandler struct {
+	store apiTokenHandlerStore
+}
+
+// NewAPITokenHandler creates a new APITokenHandler.
+func NewAPITokenHandler(s apiTokenHandlerStore) *APITokenHandler {
+	return &APITokenHandler{store: s}
+}
+
+// CreateAPITokenRequest is the request body for POST /api/v1/tokens.
+type CreateAPITokenRequest struct {
+	// Name labels the token; unique per service account.
+	Name string `json:"name"`
+	// ServiceAccount is the account the token acts as. It is created on
+	// first use; an existing non-service-account user is refused.
+	ServiceAccount string `json:"service_account"`
+	// Scopes are the granted scopes; see auth.Scopes.
+	Scopes []string `json:"scopes"`
+	// ExpiresAt is when the token stops working; omitted means never.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APITokenResponse describes a token without its secret.
+type APITokenResponse struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	ServiceAccount string     `json:"service_account"`
+	Prefix         string     `json:"prefix"`
+	Scopes         []string   `json:"scopes"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy      string     `json:"created_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// CreateAPITokenResponse is the response body for POST /api/v1/tokens. Token
+// is the plaintext secret, returned only here.
+type CreateAPITokenResponse struct {
+	APITokenResponse
+	Token string `json:"token"`
+}
+
+// Create handles POST /api/v1/tokens.
+func (h *APITokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPITokenRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.Name == "" || req.ServiceAccount == "" {
+		BadRequest(w, "name and service_account are required")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		BadRequest(w, "At least one scope is required")
+		return
+	}
+	for _, sc := range req.Scopes {
+		if !auth.ValidScope(sc) {
+			BadRequest(w, "Unknown scope "+sc)
+			return
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		BadRequest(w, "expires_at must be in the future")
+		return
+	}
+
+	account, ok := h.serviceAccount(w, r, req.ServiceAccount)
+	if !ok {
+		return
+	}
+
+	plaintext, hash, prefix, err := auth.GenerateAPIToken()
+	if err != nil {
+		InternalServerError(w, "Failed to generate token")
+		return
+	}
+	var createdBy string
+	if claims := middleware.GetClaimsFromContext(r.Context()); claims != nil {
+		createdBy = claims.Username
+	}
+	token := &models.APIToken{
+		Name:      req.Name,
+		UserID:    account.ID,
+		Hash:      hash,
+		Prefix:    prefix,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: createdBy,
+	}
+	if err := h.store.CreateAPIToken(r.Context(), token); err != nil {
+		if errors.Is(err, models.ErrDuplicateAPIToken) {
+			Conflict(w, "Service account "+account.Username+" already has a token named "+req.Name)
+			return
+		}
+		InternalServerError(w, "Failed to create token")
+		return
+	}
+
+	logger.InfoCtx(r.Context(), "API token created",
+		"token", token.Prefix, "name", token.Name, "service_account", account.Username, "scopes", token.Scopes)
+	WriteJSONCreated(w, CreateAPITokenResponse{
+		APITokenResponse: apiTokenToResponse(token, account.Username),
+		Token:            plaintext,
+	})
+}
+
+// serviceAccount returns the named service account, creating it when no
+// user has that name. Service accounts get an unusable random password:
+// they authenticate with API tokens only.
+func (h *APITokenHandler) serviceAccount(w http.ResponseWriter, r *http.Request, name string) (*models.User, bool) {
+	ctx := r.Context()
+	user, err := h.store.GetUser(ctx, name)
+	if err == nil {
+		if !user.ServiceAccount {
+			BadRequest(w, "User "+name+" is not a service account; API tokens can only be bound to service accounts")
+			return nil, false
+		}
+		return user, true
+	}
+	if !errors.Is(err, models.ErrUserNotFound) {
+		InternalServerError(w, "Failed to look up service account")
+		return nil, false
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		InternalServerError(w, "Failed to create service account")
+		return nil, false
+	}
+	passwordHash, err := models.HashPassword(hex.EncodeToString(secret))
+	if err != nil {
+		InternalServerError(w, "Failed to create service account")
+		return nil, false
+	}
+	user = &models.User{
+		Username:       name,
+		PasswordHash:   passwordHash,
+		Enabled:        true,
+		ServiceAccount: true,
+		Role:           string(models.RoleUser),
+		DisplayName:    "Service account",
+	}
+	if _, err := h.store.CreateUser(ctx, user); err != nil {
+		if errors.Is(err, models.ErrDuplicateUser) {
+			Conflict(w, "User "+name+" was created concurrently; retry")
+			return nil, false
+		}
+		InternalServerError(w, "Failed to create service account")
+		return nil, false
+	}
+	logger.InfoCtx(ctx, "service account created", "username", name)
+	return user, true
+}
+
+// List handles GET /api/v1/tokens[?service_account=name].
+func (h *APITokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	var userID string
+	if name := r.URL.Query().Get("service_account"); name != "" {
+		user, err := h.store.GetUser(r.Context(), name)
+		if err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				NotFound(w, "Service account not found")
+				return
+			}
+			InternalServerError(w, "Failed to look up service account")
+			return
+		}
+		userID = user.ID
+	}
+
+	tokens, err := h.store.ListAPITokens(r.Context(), userID)
+	if err != nil {
+		InternalServerError(w, "Failed to list tokens")
+		return
+	}
+	names := make(map[string]string)
+	out := make([]APITokenResponse, 0, len(tokens))
+	for _, t := range tokens {
+		name, ok := names[t.UserID]
+		if !ok {
+			if u, err := h.store.GetUserByID(r.Context(), t.UserID); err == nil {
+				name = u.Username
+			}
+			names[t.UserID] = name
+		}
+		out = append(out, apiTokenToResponse(t, name))
+	}
+	WriteJSONOK(w, out)
+}
+
+// Revoke handles DELETE /api/v1/tokens/{id}.
+func (h *APITokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.store.DeleteAPIToken(r.Context(), id); err != nil {
+		if errors.Is(err, models.ErrAPITokenNotFound) {
+			NotFound(w, "Token not found")
+			return
+		}
+		InternalServerError(w, "Failed to revoke token")
+		return
+	}
+	logger.InfoCtx(r.Context(), "API token revoked", "id", id)
+	WriteNoContent(w)
+}
+
+func apiTokenToResponse(t *models.APIToken, serviceAccount string) APITokenResponse {
+	return APITokenResponse{
+		ID:             t.ID,
+		Name:           t.Name,
+		ServiceAccount: serviceAccount,
+		Prefix:         t.Prefix,
+		Scopes:         t.Scopes,
+		ExpiresAt:      t.ExpiresAt,
+		LastUsedAt:     t.LastUsedAt,
+		CreatedBy:      t.CreatedBy,
+		CreatedAt:      t.CreatedAt,
+	}
+}
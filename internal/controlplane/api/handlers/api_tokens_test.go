@@ -0,0 +1,81 @@
+//go:build integration
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marmos91/dittofs/internal/controlplane/api/auth"
+)
+
+func postAPIToken(t *testing.T, h *APITokenHandler, req CreateAPITokenRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/tokens", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.Create(w, r)
+	return w
+}
+
+func TestAPITokenHandler_Create(t *testing.T) {
+	cpStore, _, _ := setupAuthTest(t)
+	h := NewAPITokenHandler(cpStore)
+	ctx := context.Background()
+
+	w := postAPIToken(t, h, CreateAPITokenRequest{Name: "ci", ServiceAccount: "terraform", Scopes: []string{"shares:write"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Create() status = %d, body %s", w.Code, w.Body)
+	}
+	var resp CreateAPITokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !auth.IsAPIToken(resp.Token) || resp.ServiceAccount != "terraform" {
+		t.Fatalf("response = %+v", resp)
+	}
+
+	// The service account was provisioned and the token authenticates as it.
+	account, err := cpStore.GetUser(ctx, "terraform")
+	if err != nil || !account.ServiceAccount {
+		t.Fatalf("service account = %+v, %v", account, err)
+	}
+	claims, err := auth.NewAPITokenService(cpStore).Authenticate(ctx, resp.Token)
+	if err != nil || claims.Username != "terraform" {
+		t.Fatalf("Authenticate = %+v, %v", claims, err)
+	}
+
+	// The stored record never holds the plaintext.
+	tokens, _ := cpStore.ListAPITokens(ctx, account.ID)
+	if len(tokens) != 1 || tokens[0].Hash == resp.Token {
+		t.Fatalf("stored tokens = %+v", tokens)
+	}
+
+	if w := postAPIToken(t, h, CreateAPITokenRequest{Name: "ci", ServiceAccount: "terraform", Scopes: []string{"shares:read"}}); w.Code != http.StatusConflict {
+		t.Errorf("duplicate name: status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestAPITokenHandler_Create_Rejects(t *testing.T) {
+	cpStore, _, _ := setupAuthTest(t)
+	h := NewAPITokenHandler(cpStore)
+	createTestUser(t, cpStore, "alice", "password123", true)
+
+	tests := map[string]CreateAPITokenRequest{
+		"human user":    {Name: "x", ServiceAccount: "alice", Scopes: []string{"shares:read"}},
+		"unknown scope": {Name: "x", ServiceAccount: "bot", Scopes: []string{"shares:admin"}},
+		"no scopes":     {Name: "x", ServiceAccount: "bot"},
+	}
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			if w := postAPIToken(t, h, req); w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditHandler serves the control-plane audit log (admin only).
+type AuditHandler struct {
+	store store.AuditStore
+}
+
+// NewAuditHandler creates a new AuditHandler.
+func NewAuditHandler(s store.AuditStore) *AuditHandler {
+	return &AuditHandler{store: s}
+}
+
+// AuditEventResponse is one audit log entry. Request, Before and After are
+// the redacted JSON documents recorded with the event.
+type AuditEventResponse struct {
+	ID         string          `json:"id"`
+	Time       time.Time       `json:"time"`
+	Actor      string          `json:"actor"`
+	AuthMethod string          `json:"auth_method,omitempty"`
+	Delegated  bool            `json:"delegated,omitempty"`
+	Method     string          `json:"method"`
+	Path       string          `json:"path"`
+	Resource   string          `json:"resource"`
+	Status     int             `json:"status"`
+	RequestID  string          `json:"request_id,omitempty"`
+	RemoteAddr string          `json:"remote_addr,omitempty"`
+	Request    json.RawMessage `json:"request,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	Changes    []string        `json:"changes,omitempty"`
+}
+
+// List handles GET /api/v1/audit.
+//
+// Query parameters: since and until (RFC 3339 time, or a duration such as
+// "24h" meaning that long ago), actor, resource, and limit (default 100,
+// max 1000). Events are returned newest first.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	now := time.Now()
+
+	filter := models.AuditFilter{
+		Actor:    q.Get("actor"),
+		Resource: q.Get("resource"),
+		Limit:    defaultAuditLimit,
+	}
+	var err error
+	if filter.Since, err = parseAuditTime(q.Get("since"), now); err != nil {
+		BadRequest(w, "Invalid since: "+err.Error())
+		return
+	}
+	if filter.Until, err = parseAuditTime(q.Get("until"), now); err != nil {
+		BadRequest(w, "Invalid until: "+err.Error())
+		return
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			BadRequest(w, "limit must be a positive integer")
+			return
+		}
+		filter.Limit = min(n, maxAuditLimit)
+	}
+
+	events, err := h.store.ListAuditEvents(r.Context(), filter)
+	if err != nil {
+		InternalServerError(w, "Failed to list audit events")
+		return
+	}
+	out := make([]AuditEventResponse, 0, len(events))
+	for _, e := range events {
+		out = append(out, auditEventToResponse(e))
+	}
+	WriteJSONOK(w, out)
+}
+
+// parseAuditTime parses an RFC 3339 time or a duration before now. An empty
+// value yields the zero time (no bound).
+func parseAuditTime(v string, now time.Time) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(-d), nil
+}
+
+func auditEventToResponse(e *models.AuditEvent) AuditEventResponse {
+	return AuditEventResponse{
+		ID:         e.ID,
+		Time:       e.Time,
+		Actor:      e.Actor,
+		AuthMethod: e.AuthMethod,
+		Delegated:  e.Delegated,
+		Method:     e.Method,
+		Path:       e.Path,
+		Resource:   e.Resource,
+		Status:     e.Status,
+		RequestID:  e.RequestID,
+		RemoteAddr: e.RemoteAddr,
+		Request:    rawJSON(e.Request),
+		Before:     rawJSON(e.Before),
+		After:      rawJSON(e.After),
+		Changes:    e.Changes,
+	}
+}
+
+// rawJSON embeds a stored JSON document in a response, dropping it when it
+// is not valid JSON (see redactedConfigRaw).
+func rawJSON(doc string) json.RawMessage {
+	if doc == "" || !json.Valid([]byte(doc)) {
+		return nil
+	}
+	return json.RawMessage(doc)
+}
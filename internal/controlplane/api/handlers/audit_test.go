@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAuditTime(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "", want: time.Time{}},
+		{in: "24h", want: now.Add(-24 * time.Hour)},
+		{in: "2026-10-01T00:00:00Z", want: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{in: "yesterday", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := parseAuditTime(tc.in, now)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseAuditTime(%q) err = %v, wantErr %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("parseAuditTime(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
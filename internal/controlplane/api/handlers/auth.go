@@ -16,6 +16,17 @@ import (
 type AuthHandler struct {
 	store      store.UserStore
 	jwtService *auth.JWTService
+
+	// passwordLoginDisabled refuses Login so every session goes through
+	// single sign-on (see OIDCHandler).
+	passwordLoginDisabled bool
+
+	// directory, when set, verifies passwords the store does not hold
+	// against LDAP (see EnableDirectoryLogin).
+	directory         DirectoryAuthenticator
+	directoryLinks    store.IdentityMappingStore
+	directorySettings DirectoryLoginSettings
+	onMappingChange   func()
 }
 
 // NewAuthHandler creates a new AuthHandler.
@@ -26,10 +37,19 @@ func NewAuthHandler(s store.UserStore, jwtService *auth.JWTService) *AuthHandler
 	}
 }
 
+// DisablePasswordLogin makes Login refuse every request with 403. Token
+// refresh keeps working for sessions obtained through single sign-on.
+func (h *AuthHandler) DisablePasswordLogin() {
+	h.passwordLoginDisabled = true
+}
+
 // LoginRequest is the request body for POST /api/v1/auth/login.
 type LoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+
+	// OTP is the TOTP code, required when the user has enrolled TOTP.
+	OTP string `json:"otp,omitempty"`
 }
 
 // LoginResponse is the response body for POST /api/v1/auth/login.
@@ -54,6 +74,9 @@ type UserResponse struct {
 	Groups             []string   `json:"groups,omitempty"`
 	Enabled            bool       `json:"enabled"`
 	MustChangePassword bool       `json:"must_change_password"`
+	ServiceAccount     bool       `json:"service_account,omitempty"`
+	TOTPEnabled        bool       `json:"totp_enabled,omitempty"`
+	LockedUntil        *time.Time `json:"locked_until,omitempty"`
 	CreatedAt          time.Time  `json:"created_at"`
 	LastLogin          *time.Time `json:"last_login,omitempty"`
 }
@@ -66,6 +89,11 @@ type RefreshRequest struct {
 // Login handles POST /api/v1/auth/login.
 // Authenticates user credentials and returns a JWT token pair.
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if h.passwordLoginDisabled {
+		Forbidden(w, "Password login is disabled; use single sign-on (dfsctl login --oidc)")
+		return
+	}
+
 	var req LoginRequest
 	if !decodeJSONBody(w, r, &req) {
 		return
@@ -76,8 +104,32 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate credentials
-	user, err := h.store.ValidateCredentials(r.Context(), req.Username, req.Password)
+	// Validate credentials. A user linked to the directory is verified by
+	// LDAP alone: its local hash is a random placeholder, so checking it
+	// would count every directory login as a failure. Otherwise a password
+	// the store does not hold (unknown user) is tried against LDAP; locked
+	// and disabled local accounts are refused before that.
+	linked, err := h.directoryLinkedUser(r.Context(), req.Username)
+	if err != nil {
+		InternalServerError(w, "Authentication failed")
+		return
+	}
+	var user *models.User
+	if linked != nil {
+		var ok bool
+		if user, ok = h.directoryLogin(w, r, req.Username, req.Password, linked); !ok {
+			return
+		}
+	} else {
+		user, err = h.store.ValidateCredentials(r.Context(), req.Username, req.Password)
+		if h.directory != nil && (errors.Is(err, models.ErrInvalidCredentials) || errors.Is(err, models.ErrUserNotFound)) {
+			var ok bool
+			if user, ok = h.directoryLogin(w, r, req.Username, req.Password, nil); !ok {
+				return
+			}
+			err = nil
+		}
+	}
 	if err != nil {
 		if errors.Is(err, models.ErrInvalidCredentials) || errors.Is(err, models.ErrUserNotFound) {
 			Unauthorized(w, "Invalid username or password")
@@ -87,10 +139,46 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 			Forbidden(w, "User account is disabled")
 			return
 		}
+		if errors.Is(err, models.ErrServiceAccountLogin) {
+			Forbidden(w, "Service accounts authenticate with API tokens, not passwords")
+			return
+		}
+		if errors.Is(err, models.ErrAccountLocked) {
+			WriteProblemCode(w, http.StatusForbidden, "Forbidden",
+				"Account is locked after too many failed logins; try again later or ask an admin to unlock it", ProblemCodeAccountLocked)
+			return
+		}
 		InternalServerError(w, "Authentication failed")
 		return
 	}
 
+	// Second factor. A missing code is a prompt, not a failed attempt; a
+	// wrong one counts towards lockout like a wrong password.
+	if user.TOTPEnabled {
+		if req.OTP == "" {
+			WriteProblemCode(w, http.StatusUnauthorized, "Unauthorized", "A one-time code is required", ProblemCodeMFARequired)
+			return
+		}
+		ok, err := verifyTOTP(r.Context(), h.store, user, req.OTP)
+		if err != nil {
+			InternalServerError(w, "Authentication failed")
+			return
+		}
+		if !ok {
+			if _, err := h.store.RecordLoginFailure(r.Context(), user.Username); err != nil {
+				logger.WarnCtx(r.Context(), "failed to record failed login", "username", user.Username, "error", err)
+			}
+			Unauthorized(w, "Invalid one-time code")
+			return
+		}
+	}
+
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := h.store.RecordLoginSuccess(r.Context(), user.Username); err != nil {
+			logger.WarnCtx(r.Context(), "failed to reset failed login count", "username", user.Username, "error", err)
+		}
+	}
+
 	// Generate token pair
 	tokenPair, err := h.jwtService.GenerateTokenPair(user)
 	if err != nil {
@@ -216,7 +304,19 @@ func userToResponse(user *models.User) UserResponse {
 		Groups:             user.GetGroupNames(),
 		Enabled:            user.Enabled,
 		MustChangePassword: user.MustChangePassword,
+		ServiceAccount:     user.ServiceAccount,
+		TOTPEnabled:        user.TOTPEnabled,
+		LockedUntil:        lockedUntil(user),
 		CreatedAt:          user.CreatedAt,
 		LastLogin:          user.LastLogin,
 	}
 }
+
+// lockedUntil returns when the user's lockout ends, or nil when the user is
+// not locked out.
+func lockedUntil(user *models.User) *time.Time {
+	if user.IsLockedOut(time.Now()) {
+		return user.LockedUntil
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/marmos91/dittofs/internal/controlplane/api/middleware"
 	"github.com/marmos91/dittofs/pkg/controlplane/models"
 	"github.com/marmos91/dittofs/pkg/controlplane/store"
+	"github.com/marmos91/dittofs/pkg/identity/ldap"
 )
 
 func setupAuthTest(t *testing.T) (store.Store, *auth.JWTService, *AuthHandler) {
@@ -175,6 +176,144 @@ func TestAuthHandler_Login_DisabledUser(t *testing.T) {
 	}
 }
 
+func TestAuthHandler_Login_PasswordLoginDisabled(t *testing.T) {
+	cpStore, _, handler := setupAuthTest(t)
+	createTestUser(t, cpStore, "ssouser", "password123", true)
+	handler.DisablePasswordLogin()
+
+	body, _ := json.Marshal(LoginRequest{Username: "ssouser", Password: "password123"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.Login(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Login() status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+// doLogin posts req to handler.Login and returns the recorder.
+func doLogin(t *testing.T, handler *AuthHandler, req LoginRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.Login(w, r)
+	return w
+}
+
+func TestAuthHandler_Login_Lockout(t *testing.T) {
+	cpStore, _, handler := setupAuthTest(t)
+	cpStore.(*store.GORMStore).SetAccountPolicy(models.AccountPolicy{LockoutThreshold: 2, LockoutDuration: time.Hour})
+	createTestUser(t, cpStore, "lockme", "password123", true)
+
+	for i := 0; i < 2; i++ {
+		if w := doLogin(t, handler, LoginRequest{Username: "lockme", Password: "wrongpassword"}); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d", i, w.Code)
+		}
+	}
+	w := doLogin(t, handler, LoginRequest{Username: "lockme", Password: "password123"})
+	var problem Problem
+	_ = json.Unmarshal(w.Body.Bytes(), &problem)
+	if w.Code != http.StatusForbidden || problem.Code != ProblemCodeAccountLocked {
+		t.Fatalf("locked login: status = %d, code = %q", w.Code, problem.Code)
+	}
+}
+
+func TestAuthHandler_Login_TOTP(t *testing.T) {
+	cpStore, _, handler := setupAuthTest(t)
+	createTestUser(t, cpStore, "mfauser", "password123", true)
+	secret, _ := auth.NewTOTPSecret()
+	if err := cpStore.SetUserTOTP(context.Background(), "mfauser", secret, true); err != nil {
+		t.Fatal(err)
+	}
+
+	w := doLogin(t, handler, LoginRequest{Username: "mfauser", Password: "password123"})
+	var problem Problem
+	_ = json.Unmarshal(w.Body.Bytes(), &problem)
+	if w.Code != http.StatusUnauthorized || problem.Code != ProblemCodeMFARequired {
+		t.Fatalf("no code: status = %d, code = %q", w.Code, problem.Code)
+	}
+
+	if w := doLogin(t, handler, LoginRequest{Username: "mfauser", Password: "password123", OTP: "000000x"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("bad code: status = %d", w.Code)
+	}
+	if user, _ := cpStore.GetUser(context.Background(), "mfauser"); user.FailedLogins != 1 {
+		t.Errorf("bad code not counted as a failed login: failed = %d", user.FailedLogins)
+	}
+
+	code, _ := auth.TOTPCode(secret, time.Now())
+	if w := doLogin(t, handler, LoginRequest{Username: "mfauser", Password: "password123", OTP: code}); w.Code != http.StatusOK {
+		t.Fatalf("valid code: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	// The same code cannot be replayed.
+	if w := doLogin(t, handler, LoginRequest{Username: "mfauser", Password: "password123", OTP: code}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("replayed code: status = %d", w.Code)
+	}
+}
+
+// fakeDirectory accepts a single account and password.
+type fakeDirectory struct {
+	res      *ldap.BindResult
+	password string
+}
+
+func (d *fakeDirectory) AuthenticateDirectoryUser(_ context.Context, username, password string) (*ldap.BindResult, bool, error) {
+	if username != d.res.Username || password != d.password {
+		return nil, true, ldap.ErrInvalidCredentials
+	}
+	return d.res, true, nil
+}
+
+func TestAuthHandler_Login_Directory(t *testing.T) {
+	cpStore, _, handler := setupAuthTest(t)
+	uid := uint32(20001)
+	dir := &fakeDirectory{password: "dirpass", res: &ldap.BindResult{
+		Username:  "carol",
+		Principal: "carol@EXAMPLE.COM",
+		UID:       &uid,
+		GID:       &uid,
+		Groups:    []string{"CN=dittofs-operators,OU=Groups,DC=example,DC=com"},
+	}}
+	settings := DirectoryLoginSettings{OperatorGroups: []string{"dittofs-operators"}}
+	links := cpStore.(*store.GORMStore)
+
+	handler.EnableDirectoryLogin(dir, links, settings, nil)
+	if w := doLogin(t, handler, LoginRequest{Username: "carol", Password: "dirpass"}); w.Code != http.StatusForbidden {
+		t.Fatalf("unmapped account without auto-provision: status = %d", w.Code)
+	}
+
+	settings.AutoProvision = true
+	handler.EnableDirectoryLogin(dir, links, settings, nil)
+	if w := doLogin(t, handler, LoginRequest{Username: "carol", Password: "wrong"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: status = %d", w.Code)
+	}
+	if w := doLogin(t, handler, LoginRequest{Username: "carol", Password: "dirpass"}); w.Code != http.StatusOK {
+		t.Fatalf("directory login: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	user, err := cpStore.GetUser(context.Background(), "carol")
+	if err != nil {
+		t.Fatalf("provisioned user: %v", err)
+	}
+	if user.Role != string(models.RoleOperator) || user.UID == nil || *user.UID != uid {
+		t.Errorf("provisioned user role = %q, UID = %v", user.Role, user.UID)
+	}
+	if _, ok := user.GetNTHash(); ok {
+		t.Error("provisioned user has an NT hash")
+	}
+	m, err := links.GetIdentityMapping(context.Background(), ldap.ProviderName, "carol@EXAMPLE.COM")
+	if err != nil || m.Username != "carol" {
+		t.Fatalf("identity mapping = %v, %v", m, err)
+	}
+
+	// A second login reuses the mapping rather than provisioning again.
+	if w := doLogin(t, handler, LoginRequest{Username: "carol", Password: "dirpass"}); w.Code != http.StatusOK {
+		t.Fatalf("repeat login: status = %d", w.Code)
+	}
+}
+
 func TestAuthHandler_Refresh(t *testing.T) {
 	cpStore, jwtService, handler := setupAuthTest(t)
 
@@ -313,3 +452,55 @@ func TestAuthHandler_Me(t *testing.T) {
 		}
 	})
 }
+
+func TestAuthHandler_Login_ServiceAccount(t *testing.T) {
+	cpStore, _, handler := setupAuthTest(t)
+	passwordHash, err := models.HashPassword("password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	robot := &models.User{Username: "robot", PasswordHash: passwordHash, Enabled: true, ServiceAccount: true, Role: "user"}
+	if _, err := cpStore.CreateUser(context.Background(), robot); err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(LoginRequest{Username: "robot", Password: "password123"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.Login(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Login() status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestAuthHandler_Login_DirectoryLockout(t *testing.T) {
+	cpStore, _, handler := setupAuthTest(t)
+	cpStore.(*store.GORMStore).SetAccountPolicy(models.AccountPolicy{LockoutThreshold: 1, LockoutDuration: time.Hour})
+	dir := &fakeDirectory{password: "dirpass", res: &ldap.BindResult{Username: "carol", Principal: "carol@EXAMPLE.COM"}}
+	handler.EnableDirectoryLogin(dir, cpStore.(*store.GORMStore), DirectoryLoginSettings{AutoProvision: true}, nil)
+
+	// The random local hash of a provisioned user is never tried, so correct
+	// directory logins do not count as failures even at threshold 1.
+	for i := 0; i < 3; i++ {
+		if w := doLogin(t, handler, LoginRequest{Username: "carol", Password: "dirpass"}); w.Code != http.StatusOK {
+			t.Fatalf("directory login %d: status = %d, body = %s", i, w.Code, w.Body.String())
+		}
+	}
+	if user, _ := cpStore.GetUser(context.Background(), "carol"); user.FailedLogins != 0 {
+		t.Fatalf("successful directory logins counted as failures: failed = %d", user.FailedLogins)
+	}
+
+	// A password the directory rejects is the failure that counts.
+	if w := doLogin(t, handler, LoginRequest{Username: "carol", Password: "wrong"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: status = %d", w.Code)
+	}
+	w := doLogin(t, handler, LoginRequest{Username: "carol", Password: "dirpass"})
+	var problem Problem
+	_ = json.Unmarshal(w.Body.Bytes(), &problem)
+	if w.Code != http.StatusForbidden || problem.Code != ProblemCodeAccountLocked {
+		t.Fatalf("locked login: status = %d, code = %q", w.Code, problem.Code)
+	}
+}
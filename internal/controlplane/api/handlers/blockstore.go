@@ -11,6 +11,7 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/block"
 	"github.com/marmos91/dittofs/pkg/controlplane/runtime/shares"
 )
 
@@ -22,7 +23,7 @@ import (
 type BlockStoreRuntime interface {
 	GetBlockStoreStats(shareName string) (*shares.BlockStoreStatsResponse, error)
 	EvictBlockStore(ctx context.Context, shareName string, opts shares.EvictOptions) (*shares.EvictResult, error)
-	StartWarmBlockStore(ctx context.Context, shareName string) (*shares.WarmJob, error)
+	StartWarmBlockStore(ctx context.Context, shareName string, sel block.WarmSelector) (*shares.WarmJob, error)
 	GetWarmStatus(jobID string) (*shares.WarmJob, bool)
 }
 
@@ -119,22 +120,27 @@ type WarmJobStatusResponse struct {
 	BlocksTotal int64  `json:"blocks_total"`
 	BlocksDone  int64  `json:"blocks_done"`
 	BytesDone   int64  `json:"bytes_done"`
-	StartedAt   string `json:"started_at,omitempty"`
-	FinishedAt  string `json:"finished_at,omitempty"`
-	Error       string `json:"error,omitempty"`
-	Warning     string `json:"warning,omitempty"`
+	// Selector and FilesSelected are set for a selective warm.
+	Selector      *block.WarmSelector `json:"selector,omitempty"`
+	FilesSelected int64               `json:"files_selected,omitempty"`
+	StartedAt     string              `json:"started_at,omitempty"`
+	FinishedAt    string              `json:"finished_at,omitempty"`
+	Error         string              `json:"error,omitempty"`
+	Warning       string              `json:"warning,omitempty"`
 }
 
 func warmJobToResponse(j *shares.WarmJob) WarmJobStatusResponse {
 	resp := WarmJobStatusResponse{
-		ID:          j.ID,
-		Share:       j.Share,
-		State:       j.State,
-		BlocksTotal: j.BlocksTotal,
-		BlocksDone:  j.BlocksDone,
-		BytesDone:   j.BytesDone,
-		Error:       j.Err,
-		Warning:     j.Warning,
+		ID:            j.ID,
+		Share:         j.Share,
+		State:         j.State,
+		BlocksTotal:   j.BlocksTotal,
+		BlocksDone:    j.BlocksDone,
+		BytesDone:     j.BytesDone,
+		Selector:      j.Selector,
+		FilesSelected: j.FilesSelected,
+		Error:         j.Err,
+		Warning:       j.Warning,
 	}
 	if !j.StartedAt.IsZero() {
 		resp.StartedAt = j.StartedAt.UTC().Format(time.RFC3339)
@@ -148,6 +154,8 @@ func warmJobToResponse(j *shares.WarmJob) WarmJobStatusResponse {
 // Warm handles POST /api/v1/shares/{name}/blockstore/warm. It starts (or
 // returns the already-running) async warm job that materializes the share's
 // blocks onto the local tier and responds 202 with the job id + initial status.
+// An optional block.WarmSelector body narrows the warm to path globs, recently
+// read files, or the hottest N files; an empty body warms the whole share.
 func (h *BlockStoreStatsHandler) Warm(w http.ResponseWriter, r *http.Request) {
 	if h.runtime == nil {
 		InternalServerError(w, "runtime not initialized")
@@ -161,7 +169,19 @@ func (h *BlockStoreStatsHandler) Warm(w http.ResponseWriter, r *http.Request) {
 	}
 	shareName = normalizeShareName(shareName)
 
-	job, err := h.runtime.StartWarmBlockStore(r.Context(), shareName)
+	var sel block.WarmSelector
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&sel); err != nil && err != io.EOF {
+			BadRequest(w, "invalid request body: "+err.Error())
+			return
+		}
+	}
+	if err := sel.Validate(); err != nil {
+		BadRequest(w, err.Error())
+		return
+	}
+
+	job, err := h.runtime.StartWarmBlockStore(r.Context(), shareName, sel)
 	if err != nil {
 		logger.Debug("block store warm start error", "share", shareName, "error", err)
 		if errors.Is(err, shares.ErrShareNotFound) {
@@ -13,6 +13,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/marmos91/dittofs/pkg/block"
 	"github.com/marmos91/dittofs/pkg/block/engine"
 	"github.com/marmos91/dittofs/pkg/controlplane/runtime/shares"
 )
@@ -240,6 +241,8 @@ type fakeBlockStoreRuntime struct {
 	warmErr    error
 	warmStatus *shares.WarmJob
 	warmFound  bool
+
+	lastWarmSelector block.WarmSelector
 }
 
 func (f *fakeBlockStoreRuntime) GetBlockStoreStats(shareName string) (*shares.BlockStoreStatsResponse, error) {
@@ -252,8 +255,9 @@ func (f *fakeBlockStoreRuntime) EvictBlockStore(_ context.Context, shareName str
 	return f.evict, f.evictErr
 }
 
-func (f *fakeBlockStoreRuntime) StartWarmBlockStore(_ context.Context, shareName string) (*shares.WarmJob, error) {
+func (f *fakeBlockStoreRuntime) StartWarmBlockStore(_ context.Context, shareName string, sel block.WarmSelector) (*shares.WarmJob, error) {
 	f.lastShare = shareName
+	f.lastWarmSelector = sel
 	return f.warmJob, f.warmErr
 }
 
@@ -340,6 +344,39 @@ func TestBlockStoreStatsHandler_Warm(t *testing.T) {
 		}
 	})
 
+	t.Run("start_with_selector", func(t *testing.T) {
+		fake := &fakeBlockStoreRuntime{warmJob: &shares.WarmJob{ID: "warm-2", Share: "/myshare", State: shares.WarmStateRunning}}
+		h := NewBlockStoreStatsHandler(fake)
+		body := `{"paths":["renders/**/*.exr"],"accessed_within":"7d","hottest":100}`
+		req := newChiRequestForBlockStore(http.MethodPost,
+			"/api/v1/shares/myshare/blockstore/warm", strings.NewReader(body), "name", "myshare")
+		w := httptest.NewRecorder()
+		h.Warm(w, req)
+
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Warm status = %d, want 202", w.Code)
+		}
+		sel := fake.lastWarmSelector
+		if len(sel.Paths) != 1 || sel.Paths[0] != "renders/**/*.exr" || sel.AccessedWithin != "7d" || sel.Hottest != 100 {
+			t.Fatalf("selector = %+v", sel)
+		}
+	})
+
+	t.Run("start_invalid_selector", func(t *testing.T) {
+		fake := &fakeBlockStoreRuntime{}
+		h := NewBlockStoreStatsHandler(fake)
+		req := newChiRequestForBlockStore(http.MethodPost,
+			"/api/v1/shares/myshare/blockstore/warm", strings.NewReader(`{"accessed_within":"soon"}`), "name", "myshare")
+		w := httptest.NewRecorder()
+		h.Warm(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Warm status = %d, want 400", w.Code)
+		}
+		if fake.lastShare != "" {
+			t.Fatalf("runtime was called for an invalid selector")
+		}
+	})
+
 	t.Run("start_error_not_leaked", func(t *testing.T) {
 		fake := &fakeBlockStoreRuntime{warmErr: errors.New(`share "/secret" has no remote tier`)}
 		h := NewBlockStoreStatsHandler(fake)
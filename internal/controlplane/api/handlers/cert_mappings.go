@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+)
+
+// CertMappingHandler handles NFS-over-TLS client certificate mapping endpoints.
+type CertMappingHandler struct {
+	store    store.CertMappingStore
+	onChange func()
+}
+
+// NewCertMappingHandler creates a new CertMappingHandler.
+// The optional onChange callback is invoked after successful create/delete
+// operations so the runtime reloads the rules.
+func NewCertMappingHandler(cpStore store.CertMappingStore, onChange func()) *CertMappingHandler {
+	return &CertMappingHandler{store: cpStore, onChange: onChange}
+}
+
+// CreateCertMappingRequest is the request body for POST /api/v1/adapters/nfs/cert-mappings.
+type CreateCertMappingRequest struct {
+	Name         string   `json:"name"`
+	Priority     int      `json:"priority"`
+	Field        string   `json:"field"`
+	Pattern      string   `json:"pattern"`
+	Shares       []string `json:"shares,omitempty"`
+	Squash       string   `json:"squash,omitempty"`
+	AnonymousUID *uint32  `json:"anonymous_uid,omitempty"`
+	AnonymousGID *uint32  `json:"anonymous_gid,omitempty"`
+}
+
+// CertMappingResponse is the response body for cert mapping endpoints.
+type CertMappingResponse struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Priority     int       `json:"priority"`
+	Field        string    `json:"field"`
+	Pattern      string    `json:"pattern"`
+	Shares       []string  `json:"shares,omitempty"`
+	Squash       string    `json:"squash,omitempty"`
+	AnonymousUID *uint32   `json:"anonymous_uid,omitempty"`
+	AnonymousGID *uint32   `json:"anonymous_gid,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Create handles POST /api/v1/adapters/nfs/cert-mappings.
+func (h *CertMappingHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateCertMappingRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	if req.Name == "" {
+		BadRequest(w, "Cert mapping name is required")
+		return
+	}
+
+	shares := make([]string, 0, len(req.Shares))
+	for _, s := range req.Shares {
+		if s = strings.TrimSpace(s); s != "" {
+			shares = append(shares, "/"+strings.TrimLeft(s, "/"))
+		}
+	}
+	mapping := &models.CertMapping{
+		Name:         req.Name,
+		Priority:     req.Priority,
+		Field:        req.Field,
+		Pattern:      req.Pattern,
+		Shares:       shares,
+		Squash:       req.Squash,
+		AnonymousUID: req.AnonymousUID,
+		AnonymousGID: req.AnonymousGID,
+	}
+	if err := mapping.Validate(); err != nil {
+		BadRequest(w, err.Error())
+		return
+	}
+	if isDelegated(r) && certMappingGrantsRoot(mapping) {
+		Forbidden(w, "Only server admins can create cert mappings that keep or grant root credentials")
+		return
+	}
+
+	if _, err := h.store.CreateCertMapping(r.Context(), mapping); err != nil {
+		if errors.Is(err, models.ErrDuplicateCertMapping) {
+			Conflict(w, "Cert mapping already exists")
+			return
+		}
+		InternalServerError(w, "Failed to create cert mapping")
+		return
+	}
+
+	if h.onChange != nil {
+		h.onChange()
+	}
+	WriteJSONCreated(w, certMappingToResponse(mapping))
+}
+
+// List handles GET /api/v1/adapters/nfs/cert-mappings.
+// Mappings are returned in evaluation order.
+func (h *CertMappingHandler) List(w http.ResponseWriter, r *http.Request) {
+	mappings, err := h.store.ListCertMappings(r.Context())
+	if err != nil {
+		InternalServerError(w, "Failed to list cert mappings")
+		return
+	}
+
+	response := make([]CertMappingResponse, len(mappings))
+	for i, m := range mappings {
+		response[i] = certMappingToResponse(m)
+	}
+
+	WriteJSONOK(w, response)
+}
+
+// Get handles GET /api/v1/adapters/nfs/cert-mappings/{name}.
+func (h *CertMappingHandler) Get(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if name == "" {
+		BadRequest(w, "Cert mapping name is required")
+		return
+	}
+
+	mapping, err := h.store.GetCertMapping(r.Context(), name)
+	if err != nil {
+		if errors.Is(err, models.ErrCertMappingNotFound) {
+			NotFound(w, "Cert mapping not found")
+			return
+		}
+		InternalServerError(w, "Failed to get cert mapping")
+		return
+	}
+
+	WriteJSONOK(w, certMappingToResponse(mapping))
+}
+
+// Remove handles DELETE /api/v1/adapters/nfs/cert-mappings/{name}.
+func (h *CertMappingHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if name == "" {
+		BadRequest(w, "Cert mapping name is required")
+		return
+	}
+
+	if err := h.store.DeleteCertMapping(r.Context(), name); err != nil {
+		if errors.Is(err, models.ErrCertMappingNotFound) {
+			NotFound(w, "Cert mapping not found")
+			return
+		}
+		InternalServerError(w, "Failed to delete cert mapping")
+		return
+	}
+
+	if h.onChange != nil {
+		h.onChange()
+	}
+	WriteNoContent(w)
+}
+
+// certMappingGrantsRoot reports whether a rule can leave matching clients with
+// root or admin credentials: it disables squashing, maps to the admin
+// identity, or sets an anonymous UID or GID of 0.
+func certMappingGrantsRoot(m *models.CertMapping) bool {
+	switch models.SquashMode(m.Squash) {
+	case models.SquashNone, models.SquashRootToAdmin, models.SquashAllToAdmin:
+		return true
+	}
+	return (m.AnonymousUID != nil && *m.AnonymousUID == 0) || (m.AnonymousGID != nil && *m.AnonymousGID == 0)
+}
+
+// certMappingToResponse converts a models.CertMapping to CertMappingResponse.
+func certMappingToResponse(m *models.CertMapping) CertMappingResponse {
+	return CertMappingResponse{
+		ID:           m.ID,
+		Name:         m.Name,
+		Priority:     m.Priority,
+		Field:        m.Field,
+		Pattern:      m.Pattern,
+		Shares:       m.Shares,
+		Squash:       m.Squash,
+		AnonymousUID: m.AnonymousUID,
+		AnonymousGID: m.AnonymousGID,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+	}
+}
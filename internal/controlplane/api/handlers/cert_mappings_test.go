@@ -0,0 +1,147 @@
+//go:build integration
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/marmos91/dittofs/internal/controlplane/api/auth"
+	"github.com/marmos91/dittofs/internal/controlplane/api/middleware"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+)
+
+func setupCertMappingTest(t *testing.T) (*store.GORMStore, *CertMappingHandler, *int) {
+	t.Helper()
+
+	dbConfig := store.Config{
+		Type: "sqlite",
+		SQLite: store.SQLiteConfig{
+			Path: ":memory:",
+		},
+	}
+	cpStore, err := store.New(&dbConfig)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	changes := 0
+	handler := NewCertMappingHandler(cpStore, func() { changes++ })
+	return cpStore, handler, &changes
+}
+
+func postCertMapping(handler *CertMappingHandler, req CreateCertMappingRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/adapters/nfs/cert-mappings", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.Create(w, r)
+	return w
+}
+
+func TestCreateCertMapping_OK(t *testing.T) {
+	cpStore, handler, changes := setupCertMappingTest(t)
+
+	uid := uint32(2000)
+	w := postCertMapping(handler, CreateCertMappingRequest{
+		Name: "build-hosts", Field: "dns", Pattern: "*.build.internal",
+		Shares: []string{"builds"}, Squash: "all_to_guest", AnonymousUID: &uid,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Create() status = %d, want %d, body = %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	var resp CertMappingResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if resp.ID == "" || len(resp.Shares) != 1 || resp.Shares[0] != "/builds" {
+		t.Errorf("response = %+v, want an ID and the normalized share /builds", resp)
+	}
+	if *changes != 1 {
+		t.Errorf("onChange calls = %d, want 1", *changes)
+	}
+	if _, err := cpStore.GetCertMapping(context.Background(), "build-hosts"); err != nil {
+		t.Errorf("GetCertMapping: %v", err)
+	}
+
+	if w := postCertMapping(handler, CreateCertMappingRequest{Name: "build-hosts", Field: "cn", Pattern: "x"}); w.Code != http.StatusConflict {
+		t.Errorf("duplicate status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestCreateCertMapping_Invalid(t *testing.T) {
+	_, handler, changes := setupCertMappingTest(t)
+
+	for _, req := range []CreateCertMappingRequest{
+		{Field: "cn", Pattern: "x"},
+		{Name: "bad-field", Field: "serial", Pattern: "x"},
+		{Name: "bad-squash", Field: "cn", Pattern: "x", Squash: "root_squash"},
+	} {
+		if w := postCertMapping(handler, req); w.Code != http.StatusBadRequest {
+			t.Errorf("Create(%+v) status = %d, want %d", req, w.Code, http.StatusBadRequest)
+		}
+	}
+	if *changes != 0 {
+		t.Errorf("onChange calls = %d, want 0", *changes)
+	}
+}
+
+func TestCreateCertMapping_DelegatedCannotGrantRoot(t *testing.T) {
+	_, handler, _ := setupCertMappingTest(t)
+
+	// Claims as produced by RequireTokenScope for a users:write API token.
+	delegated := middleware.ContextWithClaims(context.Background(),
+		&auth.Claims{Username: "automation", Role: "admin", TokenType: auth.TokenTypeAPI, Delegated: true})
+	post := func(req CreateCertMappingRequest) int {
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/adapters/nfs/cert-mappings", bytes.NewReader(body)).WithContext(delegated)
+		w := httptest.NewRecorder()
+		handler.Create(w, r)
+		return w.Code
+	}
+
+	zero, guest := uint32(0), uint32(2000)
+	for _, req := range []CreateCertMappingRequest{
+		{Name: "to-admin", Field: "cn", Pattern: "x", Squash: "root_to_admin"},
+		{Name: "all-admin", Field: "cn", Pattern: "x", Squash: "all_to_admin"},
+		{Name: "no-squash", Field: "cn", Pattern: "x", Squash: "none"},
+		{Name: "anon-root", Field: "cn", Pattern: "x", Squash: "all_to_guest", AnonymousUID: &zero},
+	} {
+		if code := post(req); code != http.StatusForbidden {
+			t.Errorf("Create(%s) status = %d, want %d", req.Name, code, http.StatusForbidden)
+		}
+	}
+	if code := post(CreateCertMappingRequest{Name: "guest", Field: "cn", Pattern: "x", Squash: "all_to_guest", AnonymousUID: &guest}); code != http.StatusCreated {
+		t.Errorf("Create(all_to_guest 2000) status = %d, want %d", code, http.StatusCreated)
+	}
+}
+
+func TestRemoveCertMapping(t *testing.T) {
+	_, handler, changes := setupCertMappingTest(t)
+	postCertMapping(handler, CreateCertMappingRequest{Name: "deploy", Field: "cn", Pattern: "deploy"})
+
+	remove := func() int {
+		r := httptest.NewRequest(http.MethodDelete, "/api/v1/adapters/nfs/cert-mappings/deploy", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("name", "deploy")
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		handler.Remove(w, r)
+		return w.Code
+	}
+	if code := remove(); code != http.StatusNoContent {
+		t.Fatalf("Remove() status = %d, want %d", code, http.StatusNoContent)
+	}
+	if code := remove(); code != http.StatusNotFound {
+		t.Errorf("second Remove() status = %d, want %d", code, http.StatusNotFound)
+	}
+	if *changes != 2 {
+		t.Errorf("onChange calls = %d, want 2 (create + delete)", *changes)
+	}
+}
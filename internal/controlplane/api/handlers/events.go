@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/marmos91/dittofs/internal/changefeed"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// eventsKeepalive is the interval of SSE comment lines that keep idle
+// proxies from closing the stream and detect a vanished client.
+const eventsKeepalive = 15 * time.Second
+
+// EventsHandler serves GET /api/v1/shares/{name}/events: the share's change
+// events as a server-sent-event stream. Inherits the parent /shares group's
+// RequireAdmin middleware.
+//
+// Each event carries its cursor as the SSE id, so a client that reconnects
+// with Last-Event-ID (EventSource does this on its own) or ?cursor= resumes
+// where it left off. When the cursor cannot be resumed — the server restarted
+// or the events left the buffer — the stream opens with a "reset" event and
+// the client should rescan the share.
+type EventsHandler struct {
+	rt *runtime.Runtime
+	// broker, when set, overrides the runtime's change feed (tests).
+	broker *changefeed.Broker
+}
+
+// NewEventsHandler constructs a handler bound to the Runtime's change feed.
+func NewEventsHandler(rt *runtime.Runtime) *EventsHandler {
+	return &EventsHandler{rt: rt}
+}
+
+func (h *EventsHandler) feed() *changefeed.Broker {
+	if h.broker != nil {
+		return h.broker
+	}
+	if h.rt == nil {
+		return nil
+	}
+	return h.rt.ChangeFeed()
+}
+
+// resetEvent is the data of the "reset" SSE event.
+type resetEvent struct {
+	Cursor string `json:"cursor"`
+	Reason string `json:"reason"`
+}
+
+// Stream handles GET /api/v1/shares/{name}/events. Query parameters:
+// types (comma-separated created,removed,renamed,modified), path_prefix, and
+// cursor (superseded by the Last-Event-ID header).
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	broker := h.feed()
+	if broker == nil {
+		ServiceUnavailable(w, "change events are not enabled on this server (events.enabled)")
+		return
+	}
+	name := normalizeShareName(chi.URLParam(r, "name"))
+	if h.rt != nil && !h.rt.ShareExists(name) {
+		NotFound(w, "share not found")
+		return
+	}
+
+	filter := changefeed.Filter{PathPrefix: r.URL.Query().Get("path_prefix")}
+	if types := r.URL.Query().Get("types"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			t = strings.TrimSpace(t)
+			if !metadata.IsChangeEventType(t) {
+				BadRequest(w, fmt.Sprintf("unknown event type %q", t))
+				return
+			}
+			filter.Types = append(filter.Types, metadata.ChangeEventType(t))
+		}
+	}
+	cursor := r.Header.Get("Last-Event-ID")
+	if cursor == "" {
+		cursor = r.URL.Query().Get("cursor")
+	}
+
+	sub, err := broker.Subscribe(name, cursor, filter)
+	if err != nil {
+		ServiceUnavailable(w, err.Error())
+		return
+	}
+	defer sub.Close()
+
+	// The stream outlives the global request deadline: clear the write
+	// deadline and watch for a real client disconnect only (see
+	// detachFromRequest). A client that vanishes without closing is caught
+	// by the keepalive write failing.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn("events: could not clear write deadline; the stream will be cut off", "error", err)
+	}
+	ctx, cancel := detachFromRequest(r, 0)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	sw := &sseWriter{w: w, rc: rc}
+	sw.line("retry: 3000")
+	if sub.Reset {
+		data, _ := json.Marshal(resetEvent{Cursor: sub.Cursor, Reason: "cursor cannot be resumed"})
+		sw.event(sub.Cursor, "reset", data)
+	}
+	for _, ev := range sub.Backlog {
+		sw.change(ev)
+	}
+	if sw.flush() != nil {
+		return
+	}
+
+	keepalive := time.NewTicker(eventsKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-sub.C():
+			if !ok {
+				// Lagging subscribers are cut off; EventSource reconnects
+				// with Last-Event-ID and resumes from the buffer.
+				return
+			}
+			sw.change(ev)
+			// Drain whatever else is ready before flushing.
+			for more := true; more; {
+				select {
+				case ev, ok := <-sub.C():
+					if !ok {
+						_ = sw.flush()
+						return
+					}
+					sw.change(ev)
+				default:
+					more = false
+				}
+			}
+		case <-keepalive.C:
+			sw.line(": keepalive")
+		}
+		if sw.flush() != nil {
+			return
+		}
+	}
+}
+
+// sseWriter frames server-sent events, remembering the first write error.
+type sseWriter struct {
+	w   http.ResponseWriter
+	rc  *http.ResponseController
+	err error
+}
+
+func (s *sseWriter) line(line string) {
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, "%s\n\n", line)
+	}
+}
+
+func (s *sseWriter) event(id, name string, data []byte) {
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, "id: %s\nevent: %s\ndata: %s\n\n", id, name, data)
+	}
+}
+
+func (s *sseWriter) change(ev *changefeed.Event) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	s.event(ev.ID, string(ev.Type), data)
+}
+
+func (s *sseWriter) flush() error {
+	if s.err == nil {
+		s.err = s.rc.Flush()
+	}
+	return s.err
+}
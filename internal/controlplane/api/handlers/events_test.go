@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/marmos91/dittofs/internal/changefeed"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+func newEventsServer(t *testing.T, broker *changefeed.Broker) *httptest.Server {
+	t.Helper()
+	h := &EventsHandler{broker: broker}
+	r := chi.NewRouter()
+	r.Get("/api/v1/shares/{name}/events", h.Stream)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// readSSE returns the next n events of an SSE stream as "event id data" maps.
+func readSSE(t *testing.T, sc *bufio.Scanner, n int) []map[string]string {
+	t.Helper()
+	var out []map[string]string
+	cur := map[string]string{}
+	for len(out) < n && sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if cur["event"] != "" {
+				out = append(out, cur)
+			}
+			cur = map[string]string{}
+			continue
+		}
+		if k, v, ok := strings.Cut(line, ": "); ok {
+			cur[k] = v
+		}
+	}
+	if len(out) < n {
+		t.Fatalf("stream ended after %d of %d events (err %v)", len(out), n, sc.Err())
+	}
+	return out
+}
+
+func TestEventsStream_ResumesFromLastEventID(t *testing.T) {
+	broker := changefeed.NewBroker(16, 0)
+	defer broker.Close()
+	broker.OnChange(&metadata.ChangeEvent{Share: "/media", Type: metadata.ChangeCreated, Path: "/old.mov"})
+	cursor := broker.Cursor()
+	broker.OnChange(&metadata.ChangeEvent{Share: "/media", Type: metadata.ChangeCreated, Path: "/a.mov"})
+	broker.OnChange(&metadata.ChangeEvent{Share: "/media", Type: metadata.ChangeRemoved, Path: "/old.mov"})
+	srv := newEventsServer(t, broker)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/shares/media/events?types=created", nil)
+	req.Header.Set("Last-Event-ID", cursor)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	sc := bufio.NewScanner(resp.Body)
+
+	backlog := readSSE(t, sc, 1)[0]
+	if backlog["event"] != "created" || !strings.Contains(backlog["data"], `"path":"/a.mov"`) {
+		t.Fatalf("backlog event = %v", backlog)
+	}
+
+	broker.OnChange(&metadata.ChangeEvent{Share: "/media", Type: metadata.ChangeCreated, Path: "/b.mov"})
+	live := readSSE(t, sc, 1)[0]
+	if !strings.Contains(live["data"], `"path":"/b.mov"`) || live["id"] == backlog["id"] {
+		t.Fatalf("live event = %v", live)
+	}
+}
+
+func TestEventsStream_ResetOnUnknownCursor(t *testing.T) {
+	broker := changefeed.NewBroker(16, 0)
+	defer broker.Close()
+	srv := newEventsServer(t, broker)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/shares/media/events?cursor=stale-9", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	ev := readSSE(t, bufio.NewScanner(resp.Body), 1)[0]
+	if ev["event"] != "reset" || ev["id"] != broker.Cursor() {
+		t.Fatalf("first event = %v, want reset at %s", ev, broker.Cursor())
+	}
+}
+
+func TestEventsStream_Errors(t *testing.T) {
+	disabled := newEventsServer(t, nil)
+	resp, err := http.Get(disabled.URL + "/api/v1/shares/media/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("disabled feed: status %d, want 503", resp.StatusCode)
+	}
+
+	broker := changefeed.NewBroker(16, 0)
+	defer broker.Close()
+	srv := newEventsServer(t, broker)
+	resp, err = http.Get(srv.URL + "/api/v1/shares/media/events?types=created,exploded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad type: status %d, want 400", resp.StatusCode)
+	}
+}
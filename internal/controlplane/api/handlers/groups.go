@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -11,13 +13,25 @@ import (
 	"github.com/marmos91/dittofs/pkg/controlplane/store"
 )
 
+// roleBindingLister lists custom role bindings; handlers use it to spot
+// groups whose members inherit a role.
+type roleBindingLister interface {
+	ListRoleBindings(ctx context.Context, roleID string) ([]*models.RoleBinding, error)
+}
+
+// groupStore is the persistence GroupHandler needs.
+type groupStore interface {
+	store.GroupStore
+	roleBindingLister
+}
+
 // GroupHandler handles group management API endpoints.
 type GroupHandler struct {
-	store store.GroupStore
+	store groupStore
 }
 
 // NewGroupHandler creates a new GroupHandler.
-func NewGroupHandler(s store.GroupStore) *GroupHandler {
+func NewGroupHandler(s groupStore) *GroupHandler {
 	return &GroupHandler{store: s}
 }
 
@@ -131,6 +145,11 @@ func (h *GroupHandler) Update(w http.ResponseWriter, r *http.Request) {
 		HandleStoreError(w, err)
 		return
 	}
+	if req.GID != nil {
+		if !checkDelegatedIDs(w, r, nil, req.GID) || !checkDelegatedGroupChange(w, r, h.store, group.Name) {
+			return
+		}
+	}
 
 	// Apply updates
 	if req.Description != nil {
@@ -191,6 +210,9 @@ func (h *GroupHandler) AddMember(w http.ResponseWriter, r *http.Request) {
 		BadRequest(w, "Username is required")
 		return
 	}
+	if !checkDelegatedGroupChange(w, r, h.store, groupName) {
+		return
+	}
 
 	if err := h.store.AddUserToGroup(r.Context(), req.Username, groupName); err != nil {
 		HandleStoreError(w, err)
@@ -214,6 +236,9 @@ func (h *GroupHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
 		BadRequest(w, "Username is required")
 		return
 	}
+	if !checkDelegatedGroupChange(w, r, h.store, groupName) {
+		return
+	}
 
 	if err := h.store.RemoveUserFromGroup(r.Context(), username, groupName); err != nil {
 		HandleStoreError(w, err)
@@ -246,6 +271,38 @@ func (h *GroupHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
 	WriteJSONOK(w, response)
 }
 
+// checkDelegatedGroupChange refuses a delegated caller changing the
+// membership or GID of a privileged group: a system group (admins carries
+// GID 0) or a group named in a role binding, whose members inherit the bound
+// role. Without it a users:write holder could add itself to such a group and
+// widen its own rights. Unknown groups are left to the store to report. It
+// writes the error response and returns false when refused.
+func checkDelegatedGroupChange(w http.ResponseWriter, r *http.Request, s interface {
+	GetGroup(ctx context.Context, name string) (*models.Group, error)
+	roleBindingLister
+}, groupNames ...string) bool {
+	if !isDelegated(r) || len(groupNames) == 0 {
+		return true
+	}
+	bindings, err := s.ListRoleBindings(r.Context(), "")
+	if err != nil {
+		InternalServerError(w, "Failed to list role bindings")
+		return false
+	}
+	for _, name := range groupNames {
+		group, err := s.GetGroup(r.Context(), name)
+		if err != nil {
+			continue
+		}
+		bound := slices.ContainsFunc(bindings, func(b *models.RoleBinding) bool { return b.GroupID == group.ID })
+		if models.IsSystemGroup(group.Name) || (group.GID != nil && *group.GID == 0) || bound {
+			Forbidden(w, "Only server admins can change the membership or GID of system groups or groups bound to a role")
+			return false
+		}
+	}
+	return true
+}
+
 // GroupMemberResponse is the response body for group member endpoints.
 type GroupMemberResponse struct {
 	ID          string `json:"id"`
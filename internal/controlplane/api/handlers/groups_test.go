@@ -13,6 +13,8 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/marmos91/dittofs/internal/controlplane/api/auth"
+	"github.com/marmos91/dittofs/internal/controlplane/api/middleware"
 	"github.com/marmos91/dittofs/pkg/controlplane/models"
 	"github.com/marmos91/dittofs/pkg/controlplane/store"
 )
@@ -387,3 +389,76 @@ func TestGroupHandler_AddRemoveMember(t *testing.T) {
 		t.Errorf("RemoveMember() did not remove user")
 	}
 }
+
+// TestGroupHandler_DelegatedCannotWidenRights checks that a delegated admin
+// (custom role or API token holding users:write) cannot change membership or
+// GID of system groups or role-bound groups, nor assign GID 0, while
+// ordinary groups stay manageable.
+func TestGroupHandler_DelegatedCannotWidenRights(t *testing.T) {
+	cpStore, handler := setupGroupTest(t)
+	ctx := context.Background()
+	if _, err := cpStore.EnsureDefaultGroups(ctx); err != nil {
+		t.Fatalf("EnsureDefaultGroups: %v", err)
+	}
+
+	for i, name := range []string{"auditors", "engineering"} {
+		gid := uint32(3000 + i)
+		if _, err := cpStore.CreateGroup(ctx, &models.Group{Name: name, GID: &gid}); err != nil {
+			t.Fatalf("CreateGroup(%s): %v", name, err)
+		}
+	}
+	auditors, _ := cpStore.GetGroup(ctx, "auditors")
+	roleID, err := cpStore.CreateRole(ctx, &models.Role{Name: "auditor", Permissions: []string{"stores:read"}})
+	if err != nil {
+		t.Fatalf("CreateRole: %v", err)
+	}
+	if err := cpStore.CreateRoleBinding(ctx, &models.RoleBinding{RoleID: roleID, GroupID: auditors.ID}); err != nil {
+		t.Fatalf("CreateRoleBinding: %v", err)
+	}
+	uid := uint32(5000)
+	if _, err := cpStore.CreateUser(ctx, &models.User{Username: "lead", PasswordHash: "hash", UID: &uid, Enabled: true, Role: "user"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	delegated := middleware.ContextWithClaims(ctx, &auth.Claims{Username: "lead", Role: "admin", Delegated: true})
+	call := func(fn http.HandlerFunc, method, group string, params map[string]string, body any) int {
+		b, _ := json.Marshal(body)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("name", group)
+		for k, v := range params {
+			rctx.URLParams.Add(k, v)
+		}
+		r := httptest.NewRequest(method, "/api/v1/groups/"+group, bytes.NewReader(b)).
+			WithContext(context.WithValue(delegated, chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		fn(w, r)
+		return w.Code
+	}
+	join := map[string]string{"username": "lead"}
+	zero, other := uint32(0), uint32(4000)
+
+	for _, group := range []string{"admins", "auditors"} {
+		if code := call(handler.AddMember, http.MethodPost, group, nil, join); code != http.StatusForbidden {
+			t.Errorf("AddMember(%s) status = %d, want %d", group, code, http.StatusForbidden)
+		}
+		if code := call(handler.RemoveMember, http.MethodDelete, group, join, nil); code != http.StatusForbidden {
+			t.Errorf("RemoveMember(%s) status = %d, want %d", group, code, http.StatusForbidden)
+		}
+		if code := call(handler.Update, http.MethodPut, group, nil, UpdateGroupRequest{GID: &other}); code != http.StatusForbidden {
+			t.Errorf("Update(%s gid) status = %d, want %d", group, code, http.StatusForbidden)
+		}
+	}
+	if code := call(handler.Update, http.MethodPut, "engineering", nil, UpdateGroupRequest{GID: &zero}); code != http.StatusForbidden {
+		t.Errorf("Update(engineering gid=0) status = %d, want %d", code, http.StatusForbidden)
+	}
+
+	if code := call(handler.AddMember, http.MethodPost, "engineering", nil, join); code != http.StatusNoContent {
+		t.Errorf("AddMember(engineering) status = %d, want %d", code, http.StatusNoContent)
+	}
+	if code := call(handler.Update, http.MethodPut, "engineering", nil, UpdateGroupRequest{GID: &other}); code != http.StatusOK {
+		t.Errorf("Update(engineering gid) status = %d, want %d", code, http.StatusOK)
+	}
+	if members, _ := cpStore.GetGroupMembers(ctx, "admins"); len(members) != 0 {
+		t.Errorf("admins members = %d, want 0", len(members))
+	}
+}
@@ -63,9 +63,9 @@ const redactedSecret = "********"
 // process; Create/Update still accept plaintext input.
 //
 // A key is considered secret if it is exactly "secret_access_key"/"password"
-// or, by convention, contains "secret"/"password" or ends in "_key" (e.g.
-// "access_key", "api_key"). Matching is case-insensitive. Redaction recurses
-// into nested objects and arrays.
+// or, by convention, contains "secret"/"password", ends in "_key" (e.g.
+// "access_key", "api_key") or names a token. Matching is case-insensitive.
+// Redaction recurses into nested objects and arrays.
 //
 // If the blob is empty or not valid JSON, it is returned unchanged — the goal
 // is to never WIDEN exposure, and an unparseable blob carries no addressable
@@ -111,10 +111,16 @@ func redactedConfigRaw(blob string) json.RawMessage {
 }
 
 // redactSecretValue walks an arbitrary JSON value, redacting secret-bearing
-// keys in any object it encounters.
+// keys in any object it encounters. A SCIM PATCH operation carries its
+// secret under "value", so that is redacted when "path" names a secret.
 func redactSecretValue(v any) any {
 	switch val := v.(type) {
 	case map[string]any:
+		if path, ok := val["path"].(string); ok && isSecretKey(path) {
+			if _, ok := val["value"]; ok {
+				val["value"] = redactedSecret
+			}
+		}
 		for k, child := range val {
 			if isSecretKey(k) {
 				val[k] = redactedSecret
@@ -133,12 +139,23 @@ func redactSecretValue(v any) any {
 	}
 }
 
-// isSecretKey reports whether a config key names a secret value.
+// RedactSecretJSON is redactSecretJSON for callers outside the handlers,
+// such as the audit middleware, which applies it to every request and
+// response body it records.
+func RedactSecretJSON(blob string) string {
+	return redactSecretJSON(blob)
+}
+
+// isSecretKey reports whether a config key names a secret value. Besides
+// store-config credentials this covers bearer credentials in API bodies
+// ("token", "access_token", "refresh_token").
 func isSecretKey(key string) bool {
 	k := strings.ToLower(key)
 	return strings.Contains(k, "secret") ||
 		strings.Contains(k, "password") ||
-		strings.HasSuffix(k, "_key")
+		strings.HasSuffix(k, "_key") ||
+		k == "token" ||
+		strings.HasSuffix(k, "_token")
 }
 
 // mergeRedactedSecrets reconciles an incoming store-config blob (newBlob)
@@ -287,6 +304,12 @@ func MapStoreError(err error) (int, string) {
 		return http.StatusNotFound, "Setting not found"
 	case errors.Is(err, models.ErrNetgroupNotFound):
 		return http.StatusNotFound, "Netgroup not found"
+	case errors.Is(err, models.ErrCertMappingNotFound):
+		return http.StatusNotFound, "Certificate mapping not found"
+	case errors.Is(err, models.ErrRoleNotFound):
+		return http.StatusNotFound, "Role not found"
+	case errors.Is(err, models.ErrRoleBindingNotFound):
+		return http.StatusNotFound, "Role binding not found"
 
 	// Duplicate/conflict errors -> 409
 	case errors.Is(err, models.ErrDuplicateUser):
@@ -301,6 +324,10 @@ func MapStoreError(err error) (int, string) {
 		return http.StatusConflict, "Adapter already exists"
 	case errors.Is(err, models.ErrDuplicateNetgroup):
 		return http.StatusConflict, "Netgroup already exists"
+	case errors.Is(err, models.ErrDuplicateCertMapping):
+		return http.StatusConflict, "Certificate mapping already exists"
+	case errors.Is(err, models.ErrDuplicateRole):
+		return http.StatusConflict, "Role already exists"
 	case errors.Is(err, models.ErrStoreInUse):
 		return http.StatusConflict, "Store is referenced by shares"
 	case errors.Is(err, models.ErrNetgroupInUse):
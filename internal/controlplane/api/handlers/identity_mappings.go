@@ -1,25 +1,36 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/url"
+	"slices"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/marmos91/dittofs/pkg/controlplane/models"
 	"github.com/marmos91/dittofs/pkg/controlplane/store"
 )
 
+// mappingUserStore resolves the local user a mapping targets, so delegated
+// callers can be kept from mapping principals to privileged users.
+type mappingUserStore interface {
+	GetUser(ctx context.Context, username string) (*models.User, error)
+	GetGroup(ctx context.Context, name string) (*models.Group, error)
+	roleBindingLister
+}
+
 // IdentityMappingHandler handles identity mapping API endpoints.
 type IdentityMappingHandler struct {
 	store    store.IdentityMappingStore
+	users    mappingUserStore
 	onChange func()
 }
 
 // NewIdentityMappingHandler creates a new IdentityMappingHandler.
 // The optional onChange callback is invoked after successful create/delete operations.
-func NewIdentityMappingHandler(cpStore store.IdentityMappingStore, onChange func()) *IdentityMappingHandler {
-	return &IdentityMappingHandler{store: cpStore, onChange: onChange}
+func NewIdentityMappingHandler(cpStore store.IdentityMappingStore, users mappingUserStore, onChange func()) *IdentityMappingHandler {
+	return &IdentityMappingHandler{store: cpStore, users: users, onChange: onChange}
 }
 
 // CreateIdentityMappingRequest is the request body for POST /api/v1/identity-mappings.
@@ -69,6 +80,10 @@ func (h *IdentityMappingHandler) Create(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if !h.checkDelegatedTarget(w, r, req.Username, false) {
+		return
+	}
+
 	mapping := &models.IdentityMapping{
 		ProviderName: req.ProviderName,
 		Principal:    req.Principal,
@@ -151,6 +166,21 @@ func (h *IdentityMappingHandler) deleteMapping(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if isDelegated(r) {
+		mapping, err := h.store.GetIdentityMapping(r.Context(), provider, decodedPrincipal)
+		if err != nil {
+			if errors.Is(err, models.ErrMappingNotFound) {
+				NotFound(w, "Identity mapping not found")
+				return
+			}
+			InternalServerError(w, "Failed to get identity mapping")
+			return
+		}
+		if !h.checkDelegatedTarget(w, r, mapping.Username, true) {
+			return
+		}
+	}
+
 	if err := h.store.DeleteIdentityMapping(r.Context(), provider, decodedPrincipal); err != nil {
 		if errors.Is(err, models.ErrMappingNotFound) {
 			NotFound(w, "Identity mapping not found")
@@ -167,6 +197,53 @@ func (h *IdentityMappingHandler) deleteMapping(w http.ResponseWriter, r *http.Re
 	WriteNoContent(w)
 }
 
+// checkDelegatedTarget refuses a delegated caller creating or deleting a
+// mapping to a user it could not edit through UserHandler: an admin, a user
+// with UID or GID 0, or a member of a GID 0 or role-bound group. Logging in
+// through such a mapping yields a real session as that user, so mapping a
+// principal to an admin would hand out full admin rights. A mapping to a user
+// that does not exist may be deleted but not created. It writes the error
+// response and returns false when refused.
+func (h *IdentityMappingHandler) checkDelegatedTarget(w http.ResponseWriter, r *http.Request, username string, deleting bool) bool {
+	if !isDelegated(r) {
+		return true
+	}
+	user, err := h.users.GetUser(r.Context(), username)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound) && deleting:
+			return true
+		case errors.Is(err, models.ErrUserNotFound):
+			NotFound(w, "User not found")
+		default:
+			InternalServerError(w, "Failed to get user")
+		}
+		return false
+	}
+	if user.Role == string(models.RoleAdmin) ||
+		(user.UID != nil && *user.UID == 0) || (user.GID != nil && *user.GID == 0) {
+		Forbidden(w, "Only server admins can manage identity mappings to admin or root users")
+		return false
+	}
+	bindings, err := h.users.ListRoleBindings(r.Context(), "")
+	if err != nil {
+		InternalServerError(w, "Failed to list role bindings")
+		return false
+	}
+	for _, name := range user.GetGroupNames() {
+		group, err := h.users.GetGroup(r.Context(), name)
+		if err != nil {
+			continue
+		}
+		bound := slices.ContainsFunc(bindings, func(b *models.RoleBinding) bool { return b.GroupID == group.ID })
+		if (group.GID != nil && *group.GID == 0) || bound {
+			Forbidden(w, "Only server admins can manage identity mappings to members of GID 0 groups or groups bound to a role")
+			return false
+		}
+	}
+	return true
+}
+
 func mappingsToResponse(mappings []*models.IdentityMapping) []IdentityMappingResponse {
 	response := make([]IdentityMappingResponse, len(mappings))
 	for i, m := range mappings {
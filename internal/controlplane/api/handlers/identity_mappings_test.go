@@ -0,0 +1,128 @@
+//go:build integration
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/marmos91/dittofs/internal/controlplane/api/auth"
+	"github.com/marmos91/dittofs/internal/controlplane/api/middleware"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+)
+
+func setupIdentityMappingTest(t *testing.T) (*store.GORMStore, *IdentityMappingHandler) {
+	t.Helper()
+
+	dbConfig := store.Config{
+		Type: "sqlite",
+		SQLite: store.SQLiteConfig{
+			Path: ":memory:",
+		},
+	}
+	cpStore, err := store.New(&dbConfig)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	ctx := context.Background()
+	passwordHash, ntHash, _ := models.HashPasswordWithNT("password")
+	for _, u := range []struct{ name, role string }{{"root-admin", "admin"}, {"helpdeskuser", "user"}} {
+		if _, err := cpStore.CreateUser(ctx, &models.User{
+			ID:           uuid.New().String(),
+			Username:     u.name,
+			PasswordHash: passwordHash,
+			NTHash:       ntHash,
+			Enabled:      true,
+			Role:         u.role,
+			CreatedAt:    time.Now(),
+		}); err != nil {
+			t.Fatalf("Failed to create user %s: %v", u.name, err)
+		}
+	}
+
+	return cpStore, NewIdentityMappingHandler(cpStore, cpStore, nil)
+}
+
+func postIdentityMapping(ctx context.Context, handler *IdentityMappingHandler, req CreateIdentityMappingRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/identity-mappings", bytes.NewReader(body)).WithContext(ctx)
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.Create(w, r)
+	return w
+}
+
+func deleteIdentityMapping(ctx context.Context, handler *IdentityMappingHandler, provider, principal string) *httptest.ResponseRecorder {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("provider", provider)
+	rctx.URLParams.Add("principal", principal)
+	r := httptest.NewRequest(http.MethodDelete, "/api/v1/identity-mappings/by-provider/"+provider+"/"+principal, nil).
+		WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+	handler.Remove(w, r)
+	return w
+}
+
+func TestIdentityMappingHandler_DelegatedCannotTargetAdmin(t *testing.T) {
+	cpStore, handler := setupIdentityMappingTest(t)
+	ctx := context.Background()
+
+	// Claims as produced by ApplyCustomRoles for a users:write holder.
+	delegated := middleware.ContextWithClaims(ctx, &auth.Claims{Username: "lead", Role: "admin", Delegated: true})
+	admin := middleware.ContextWithClaims(ctx, &auth.Claims{Username: "root-admin", Role: "admin"})
+
+	// Mapping the caller's own principal to an admin would log it in as one.
+	w := postIdentityMapping(delegated, handler, CreateIdentityMappingRequest{ProviderName: "oidc", Principal: "lead@example.com", Username: "root-admin"})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("delegated Create(admin) status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if _, err := cpStore.GetIdentityMapping(ctx, "oidc", "lead@example.com"); err == nil {
+		t.Fatal("refused mapping was stored")
+	}
+
+	// Nor to a member of a GID 0 group.
+	gid := uint32(0)
+	if _, err := cpStore.CreateGroup(ctx, &models.Group{ID: uuid.New().String(), Name: "wheel", GID: &gid, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	if err := cpStore.AddUserToGroup(ctx, "helpdeskuser", "wheel"); err != nil {
+		t.Fatalf("AddUserToGroup: %v", err)
+	}
+	w = postIdentityMapping(delegated, handler, CreateIdentityMappingRequest{ProviderName: "oidc", Principal: "lead@example.com", Username: "helpdeskuser"})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("delegated Create(GID 0 member) status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if err := cpStore.RemoveUserFromGroup(ctx, "helpdeskuser", "wheel"); err != nil {
+		t.Fatalf("RemoveUserFromGroup: %v", err)
+	}
+
+	// Ordinary users remain manageable.
+	w = postIdentityMapping(delegated, handler, CreateIdentityMappingRequest{ProviderName: "oidc", Principal: "helpdesk@example.com", Username: "helpdeskuser"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("delegated Create(user) status = %d, want %d, body = %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if w := deleteIdentityMapping(delegated, handler, "oidc", "helpdesk@example.com"); w.Code != http.StatusNoContent {
+		t.Fatalf("delegated Remove(user) status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	// A server admin's mapping to an admin cannot be removed by a delegated caller.
+	w = postIdentityMapping(admin, handler, CreateIdentityMappingRequest{ProviderName: "oidc", Principal: "admin@example.com", Username: "root-admin"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("admin Create(admin) status = %d, want %d, body = %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if w := deleteIdentityMapping(delegated, handler, "oidc", "admin@example.com"); w.Code != http.StatusForbidden {
+		t.Fatalf("delegated Remove(admin) status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if _, err := cpStore.GetIdentityMapping(ctx, "oidc", "admin@example.com"); err != nil {
+		t.Fatalf("admin mapping was removed: %v", err)
+	}
+}
@@ -52,6 +52,8 @@ type ldapConfigDTO struct {
 	BindDN          string     `json:"bind_dn"`
 	BindPassword    string     `json:"bind_password,omitempty"`
 	UserAttr        string     `json:"user_attr"`
+	UserObjectClass string     `json:"user_object_class,omitempty"`
+	MatchUsername   bool       `json:"match_username,omitempty"`
 	Realm           string     `json:"realm"`
 	Idmap           string     `json:"idmap"`
 	NestedGroups    bool       `json:"nested_groups"`
@@ -263,6 +265,8 @@ func (h *IdentityProviderHandler) ldapConfigFromDTO(r *http.Request, dto *ldapCo
 		BindDN:          dto.BindDN,
 		BindPassword:    dto.BindPassword,
 		UserAttr:        dto.UserAttr,
+		UserObjectClass: dto.UserObjectClass,
+		MatchUsername:   dto.MatchUsername,
 		Realm:           dto.Realm,
 		Idmap:           ldap.IdmapMode(dto.Idmap),
 		NestedGroups:    dto.NestedGroups,
@@ -311,6 +315,8 @@ func ldapConfigToDTO(cfg *ldap.Config) ldapConfigDTO {
 		BaseDN:          cfg.BaseDN,
 		BindDN:          cfg.BindDN,
 		UserAttr:        cfg.UserAttr,
+		UserObjectClass: cfg.UserObjectClass,
+		MatchUsername:   cfg.MatchUsername,
 		Realm:           cfg.Realm,
 		Idmap:           string(cfg.Idmap),
 		NestedGroups:    cfg.NestedGroups,
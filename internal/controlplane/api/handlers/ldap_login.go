@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+	"github.com/marmos91/dittofs/pkg/identity/ldap"
+)
+
+// DirectoryAuthenticator verifies a password by binding to the LDAP directory
+// as the user. found is false when no directory is configured.
+// *runtime.Runtime implements it.
+type DirectoryAuthenticator interface {
+	AuthenticateDirectoryUser(ctx context.Context, username, password string) (res *ldap.BindResult, found bool, err error)
+}
+
+// DirectoryLoginSettings controls how a directory bind becomes a DittoFS login.
+type DirectoryLoginSettings struct {
+	// AdminGroups, OperatorGroups and UserGroups map directory groups (by DN
+	// or CN) to roles. When all are empty, roles are left to DittoFS.
+	AdminGroups    []string
+	OperatorGroups []string
+	UserGroups     []string
+	// AutoProvision creates a user and identity mapping for directory
+	// accounts that map to no DittoFS user.
+	AutoProvision bool
+}
+
+// roleFor maps the user's directory groups to a role; see roleFromGroups.
+func (s DirectoryLoginSettings) roleFor(res *ldap.BindResult) (models.UserRole, bool) {
+	return roleFromGroups(res.InGroup, s.AdminGroups, s.OperatorGroups, s.UserGroups)
+}
+
+// EnableDirectoryLogin makes Login fall back to an LDAP bind for users whose
+// password is not held locally: unknown users, and users provisioned from the
+// directory. The directory account is mapped to a DittoFS user through the
+// "ldap" identity mapping keyed by its principal. The optional
+// onMappingChange callback is invoked after an auto-provisioned identity
+// mapping is created.
+func (h *AuthHandler) EnableDirectoryLogin(dir DirectoryAuthenticator, links store.IdentityMappingStore, settings DirectoryLoginSettings, onMappingChange func()) {
+	h.directory = dir
+	h.directoryLinks = links
+	h.directorySettings = settings
+	h.onMappingChange = onMappingChange
+}
+
+// directoryLinkedUser returns the local user named username when it is
+// linked to the directory by an "ldap" identity mapping, or nil when it is
+// not (or directory login is off).
+func (h *AuthHandler) directoryLinkedUser(ctx context.Context, username string) (*models.User, error) {
+	if h.directory == nil {
+		return nil, nil
+	}
+	user, err := h.store.GetUser(ctx, username)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := h.directoryLinks.ListIdentityMappingsForUser(ctx, user.Username)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mappings {
+		if m.ProviderName == ldap.ProviderName {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+// directoryLogin authenticates username and password against the directory
+// and returns the DittoFS user the account maps to, writing the error
+// response and returning false when it cannot. linked is the directory-linked
+// local user named username, if any: it is refused while locked out, and a
+// password the directory rejects counts towards its lockout.
+func (h *AuthHandler) directoryLogin(w http.ResponseWriter, r *http.Request, username, password string, linked *models.User) (*models.User, bool) {
+	ctx := r.Context()
+	if linked != nil && linked.IsLockedOut(time.Now()) {
+		WriteProblemCode(w, http.StatusForbidden, "Forbidden",
+			"Account is locked after too many failed logins; try again later or ask an admin to unlock it", ProblemCodeAccountLocked)
+		return nil, false
+	}
+	res, found, err := h.directory.AuthenticateDirectoryUser(ctx, username, password)
+	switch {
+	case !found || errors.Is(err, ldap.ErrInvalidCredentials):
+		if linked != nil && found {
+			if _, err := h.store.RecordLoginFailure(ctx, linked.Username); err != nil {
+				logger.WarnCtx(ctx, "failed to record failed login", "username", linked.Username, "error", err)
+			}
+		}
+		Unauthorized(w, "Invalid username or password")
+		return nil, false
+	case err != nil:
+		logger.WarnCtx(ctx, "LDAP login failed", "username", username, "error", err)
+		ServiceUnavailable(w, "Directory is unreachable")
+		return nil, false
+	}
+
+	role, ok := h.directorySettings.roleFor(res)
+	if !ok {
+		logger.InfoCtx(ctx, "LDAP login refused: not in any authorized group", "principal", res.Principal)
+		Forbidden(w, "Not a member of any group authorized for DittoFS")
+		return nil, false
+	}
+
+	var user *models.User
+	m, err := h.directoryLinks.GetIdentityMapping(ctx, ldap.ProviderName, res.Principal)
+	switch {
+	case err == nil:
+		if user, err = h.store.GetUser(ctx, m.Username); err != nil {
+			InternalServerError(w, "Failed to load user")
+			return nil, false
+		}
+	case errors.Is(err, models.ErrMappingNotFound):
+		if !h.directorySettings.AutoProvision {
+			logger.InfoCtx(ctx, "LDAP login refused: no mapped user", "principal", res.Principal)
+			Forbidden(w, "No DittoFS user is mapped to this directory account")
+			return nil, false
+		}
+		if user, ok = h.provisionDirectoryUser(w, r, res, role); !ok {
+			return nil, false
+		}
+	default:
+		InternalServerError(w, "Failed to resolve identity")
+		return nil, false
+	}
+
+	// The mapped user may have a different name from the one presented, so
+	// its own state is checked here rather than by ValidateCredentials.
+	switch {
+	case !user.Enabled:
+		Forbidden(w, "User account is disabled")
+		return nil, false
+	case user.ServiceAccount:
+		Forbidden(w, "Service accounts authenticate with API tokens, not passwords")
+		return nil, false
+	case user.IsLockedOut(time.Now()):
+		WriteProblemCode(w, http.StatusForbidden, "Forbidden",
+			"Account is locked after too many failed logins; try again later or ask an admin to unlock it", ProblemCodeAccountLocked)
+		return nil, false
+	}
+	if role != "" && user.Role != string(role) {
+		user.Role = string(role)
+		if err := h.store.UpdateUser(ctx, user); err != nil {
+			InternalServerError(w, "Failed to update user role")
+			return nil, false
+		}
+	}
+	return user, true
+}
+
+// provisionDirectoryUser creates the DittoFS user for a first-time directory
+// login and links it to the account's principal. Like OIDC-provisioned users
+// it gets a random, never-disclosed password and no NT hash, so it can only
+// log in through the directory (SMB through NETLOGON pass-through). The POSIX
+// ids are copied from the directory so NFS and SMB agree on file ownership.
+func (h *AuthHandler) provisionDirectoryUser(w http.ResponseWriter, r *http.Request, res *ldap.BindResult, role models.UserRole) (*models.User, bool) {
+	ctx := r.Context()
+	if role == "" {
+		role = models.RoleUser
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		InternalServerError(w, "Failed to provision user")
+		return nil, false
+	}
+	passwordHash, err := models.HashPassword(hex.EncodeToString(secret))
+	if err != nil {
+		InternalServerError(w, "Failed to provision user")
+		return nil, false
+	}
+
+	user := &models.User{
+		Username:     res.Username,
+		PasswordHash: passwordHash,
+		Enabled:      true,
+		Role:         string(role),
+		DisplayName:  res.DisplayName,
+		Email:        res.Email,
+		UID:          res.UID,
+		GID:          res.GID,
+	}
+	if _, err := h.store.CreateUser(ctx, user); err != nil {
+		if errors.Is(err, models.ErrDuplicateUser) {
+			Conflict(w, "A DittoFS user named "+res.Username+" already exists; map this account to it with 'dfsctl idmap add --provider ldap --principal "+res.Principal+"'")
+			return nil, false
+		}
+		InternalServerError(w, "Failed to provision user")
+		return nil, false
+	}
+	if err := h.directoryLinks.CreateIdentityMapping(ctx, &models.IdentityMapping{
+		ProviderName: ldap.ProviderName,
+		Principal:    res.Principal,
+		Username:     user.Username,
+	}); err != nil {
+		InternalServerError(w, "Failed to link provisioned user")
+		return nil, false
+	}
+	if h.onMappingChange != nil {
+		h.onMappingChange()
+	}
+	logger.InfoCtx(ctx, "LDAP user provisioned", "username", user.Username, "principal", res.Principal, "role", role)
+	return user, true
+}
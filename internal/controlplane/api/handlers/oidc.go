@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/controlplane/api/auth"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+	"github.com/marmos91/dittofs/pkg/identity"
+	"github.com/marmos91/dittofs/pkg/identity/oidc"
+)
+
+// OIDCSettings controls how a verified ID token becomes a DittoFS login.
+type OIDCSettings struct {
+	// UsernameClaim names the claim used for username matching and as the
+	// name of auto-provisioned users.
+	UsernameClaim string
+	// GroupsClaim names the claim holding the user's IdP groups.
+	GroupsClaim string
+	// AdminGroups, OperatorGroups and UserGroups map IdP groups to roles.
+	// When all are empty, roles are left to DittoFS.
+	AdminGroups    []string
+	OperatorGroups []string
+	UserGroups     []string
+	// RequireMFA refuses tokens whose amr claim holds none of MFAMethods.
+	RequireMFA bool
+	MFAMethods []string
+	// MatchUsername falls back to the username claim for unmapped subjects.
+	MatchUsername bool
+	// AutoProvision creates a user and identity mapping for unknown subjects.
+	AutoProvision bool
+}
+
+// roleFor maps IdP groups to a role, most privileged first. ok is false when
+// role groups are configured and the user is in none of them; role is empty
+// when no role groups are configured.
+func (s OIDCSettings) roleFor(groups []string) (role models.UserRole, ok bool) {
+	inGroup := func(g string) bool { return slices.Contains(groups, g) }
+	return roleFromGroups(inGroup, s.AdminGroups, s.OperatorGroups, s.UserGroups)
+}
+
+// roleFromGroups maps a user's group membership (inGroup) to a role, most
+// privileged first. ok is false when role groups are configured and the user
+// is in none of them; role is empty when no role groups are configured.
+func roleFromGroups(inGroup func(string) bool, admin, operator, user []string) (role models.UserRole, ok bool) {
+	if len(admin) == 0 && len(operator) == 0 && len(user) == 0 {
+		return "", true
+	}
+	switch {
+	case slices.ContainsFunc(admin, inGroup):
+		return models.RoleAdmin, true
+	case slices.ContainsFunc(operator, inGroup):
+		return models.RoleOperator, true
+	case slices.ContainsFunc(user, inGroup):
+		return models.RoleUser, true
+	}
+	return "", false
+}
+
+// hasMFA reports whether the token's authentication methods include one of
+// the accepted multi-factor methods.
+func (s OIDCSettings) hasMFA(tok *auth.IDToken) bool {
+	return slices.ContainsFunc(tok.StringsClaim("amr"), func(m string) bool {
+		return slices.Contains(s.MFAMethods, m)
+	})
+}
+
+// oidcUserStore is the subset of the control plane store the OIDC handler
+// needs: users for login and provisioning, identity mappings for resolution.
+type oidcUserStore interface {
+	store.UserStore
+	store.IdentityMappingStore
+}
+
+// OIDCHandler handles OpenID Connect login endpoints. It redeems
+// authorization codes (PKCE) and device codes with the IdP, maps the ID
+// token's issuer|subject to a DittoFS user through the oidc identity
+// provider, and issues a DittoFS token pair.
+type OIDCHandler struct {
+	store           oidcUserStore
+	client          *auth.OIDCClient
+	provider        *oidc.Provider
+	jwtService      *auth.JWTService
+	settings        OIDCSettings
+	onMappingChange func()
+}
+
+// NewOIDCHandler creates a new OIDCHandler. The optional onMappingChange
+// callback is invoked after an auto-provisioned identity mapping is created.
+func NewOIDCHandler(s oidcUserStore, client *auth.OIDCClient, jwtService *auth.JWTService, settings OIDCSettings, onMappingChange func()) *OIDCHandler {
+	links := &identity.FuncLinkStore{
+		GetLinkFn: func(ctx context.Context, provider, externalID string) (string, bool, error) {
+			m, err := s.GetIdentityMapping(ctx, provider, externalID)
+			if err != nil {
+				if errors.Is(err, models.ErrMappingNotFound) {
+					return "", false, nil
+				}
+				return "", false, err
+			}
+			return m.Username, true, nil
+		},
+	}
+	lookup := func(ctx context.Context, username string) (*identity.ResolvedIdentity, error) {
+		user, err := s.GetUser(ctx, username)
+		if err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				return &identity.ResolvedIdentity{Found: false}, nil
+			}
+			return nil, err
+		}
+		return &identity.ResolvedIdentity{Username: user.Username, Found: true}, nil
+	}
+	return &OIDCHandler{
+		store:           s,
+		client:          client,
+		provider:        oidc.New(links, lookup, settings.MatchUsername),
+		jwtService:      jwtService,
+		settings:        settings,
+		onMappingChange: onMappingChange,
+	}
+}
+
+// OIDCInfoResponse is the response body for GET /api/v1/auth/oidc. It gives
+// dfsctl what it needs to build the authorization request.
+type OIDCInfoResponse struct {
+	Issuer                string   `json:"issuer"`
+	ClientID              string   `json:"client_id"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	Scopes                []string `json:"scopes"`
+	DeviceFlow            bool     `json:"device_flow"`
+}
+
+// OIDCTokenRequest is the request body for POST /api/v1/auth/oidc/token.
+type OIDCTokenRequest struct {
+	Code         string `json:"code"`
+	CodeVerifier string `json:"code_verifier"`
+	RedirectURI  string `json:"redirect_uri"`
+	Nonce        string `json:"nonce"`
+}
+
+// OIDCDeviceTokenRequest is the request body for POST /api/v1/auth/oidc/device/token.
+type OIDCDeviceTokenRequest struct {
+	DeviceCode string `json:"device_code"`
+}
+
+// OIDCDevicePendingResponse is returned with 202 while the user has not yet
+// approved a device login. Status is "authorization_pending" or "slow_down".
+type OIDCDevicePendingResponse struct {
+	Status string `json:"status"`
+}
+
+// Info handles GET /api/v1/auth/oidc.
+func (h *OIDCHandler) Info(w http.ResponseWriter, r *http.Request) {
+	doc, err := h.client.Discovery(r.Context())
+	if err != nil {
+		logger.WarnCtx(r.Context(), "OIDC discovery failed", "error", err)
+		ServiceUnavailable(w, "Identity provider is unreachable")
+		return
+	}
+	WriteJSONOK(w, OIDCInfoResponse{
+		Issuer:                doc.Issuer,
+		ClientID:              h.client.ClientID(),
+		AuthorizationEndpoint: doc.AuthorizationEndpoint,
+		Scopes:                h.client.Scopes(),
+		DeviceFlow:            doc.DeviceAuthorizationEndpoint != "",
+	})
+}
+
+// Token handles POST /api/v1/auth/oidc/token.
+// Redeems a PKCE authorization code and returns a DittoFS token pair.
+func (h *OIDCHandler) Token(w http.ResponseWriter, r *http.Request) {
+	var req OIDCTokenRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.Code == "" || req.CodeVerifier == "" || req.RedirectURI == "" || req.Nonce == "" {
+		BadRequest(w, "code, code_verifier, redirect_uri and nonce are required")
+		return
+	}
+
+	tokens, err := h.client.ExchangeCode(r.Context(), req.Code, req.CodeVerifier, req.RedirectURI)
+	if err != nil {
+		logger.WarnCtx(r.Context(), "OIDC code exchange failed", "error", err)
+		Unauthorized(w, "Authorization code was rejected by the identity provider")
+		return
+	}
+	h.login(w, r, tokens.IDToken, req.Nonce)
+}
+
+// StartDevice handles POST /api/v1/auth/oidc/device.
+// Starts a device-code login and returns the code for the user to enter.
+func (h *OIDCHandler) StartDevice(w http.ResponseWriter, r *http.Request) {
+	da, err := h.client.StartDeviceAuthorization(r.Context())
+	if err != nil {
+		if errors.Is(err, auth.ErrOIDCNoDeviceFlow) {
+			BadRequest(w, "The identity provider does not support the device flow")
+			return
+		}
+		logger.WarnCtx(r.Context(), "OIDC device authorization failed", "error", err)
+		ServiceUnavailable(w, "Identity provider refused the device authorization")
+		return
+	}
+	WriteJSONOK(w, da)
+}
+
+// DeviceToken handles POST /api/v1/auth/oidc/device/token.
+// Returns 202 while the login is pending and a DittoFS token pair once the
+// user has approved it.
+func (h *OIDCHandler) DeviceToken(w http.ResponseWriter, r *http.Request) {
+	var req OIDCDeviceTokenRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.DeviceCode == "" {
+		BadRequest(w, "device_code is required")
+		return
+	}
+
+	tokens, err := h.client.PollDeviceToken(r.Context(), req.DeviceCode)
+	switch {
+	case errors.Is(err, auth.ErrAuthorizationPending):
+		WriteJSONAccepted(w, OIDCDevicePendingResponse{Status: "authorization_pending"})
+		return
+	case errors.Is(err, auth.ErrSlowDown):
+		WriteJSONAccepted(w, OIDCDevicePendingResponse{Status: "slow_down"})
+		return
+	case errors.Is(err, auth.ErrDeviceAccessDenied):
+		Forbidden(w, "Login was denied at the identity provider")
+		return
+	case errors.Is(err, auth.ErrDeviceCodeExpired):
+		Unauthorized(w, "Device code has expired")
+		return
+	case err != nil:
+		logger.WarnCtx(r.Context(), "OIDC device token request failed", "error", err)
+		Unauthorized(w, "Device code was rejected by the identity provider")
+		return
+	}
+	h.login(w, r, tokens.IDToken, "")
+}
+
+// login verifies rawIDToken and completes the DittoFS login it asserts:
+// MFA and role-group checks, user resolution (or provisioning), role sync,
+// and token issuance.
+func (h *OIDCHandler) login(w http.ResponseWriter, r *http.Request, rawIDToken, nonce string) {
+	ctx := r.Context()
+	tok, err := h.client.VerifyIDToken(ctx, rawIDToken, nonce)
+	if err != nil {
+		logger.WarnCtx(ctx, "OIDC ID token rejected", "error", err)
+		Unauthorized(w, "Invalid ID token")
+		return
+	}
+	principal := oidc.ExternalID(tok.Issuer, tok.Subject)
+
+	if h.settings.RequireMFA && !h.settings.hasMFA(tok) {
+		logger.InfoCtx(ctx, "OIDC login refused: no multi-factor authentication", "principal", principal)
+		Forbidden(w, "Multi-factor authentication is required")
+		return
+	}
+	role, ok := h.settings.roleFor(tok.StringsClaim(h.settings.GroupsClaim))
+	if !ok {
+		logger.InfoCtx(ctx, "OIDC login refused: not in any authorized group", "principal", principal)
+		Forbidden(w, "Not a member of any group authorized for DittoFS")
+		return
+	}
+
+	claimedName := tok.StringClaim(h.settings.UsernameClaim)
+	resolved, err := h.provider.Resolve(ctx, &identity.Credential{
+		Provider:   oidc.ProviderName,
+		ExternalID: principal,
+		Attributes: map[string]string{oidc.AttrUsername: claimedName},
+	})
+	if err != nil {
+		InternalServerError(w, "Failed to resolve identity")
+		return
+	}
+
+	var user *models.User
+	if resolved.Found {
+		if user, err = h.store.GetUser(ctx, resolved.Username); err != nil {
+			InternalServerError(w, "Failed to load user")
+			return
+		}
+	} else {
+		if !h.settings.AutoProvision {
+			logger.InfoCtx(ctx, "OIDC login refused: no mapped user", "principal", principal)
+			Forbidden(w, "No DittoFS user is mapped to this identity")
+			return
+		}
+		if user, ok = h.provision(w, r, tok, principal, claimedName, role); !ok {
+			return
+		}
+	}
+
+	if !user.Enabled {
+		Forbidden(w, "User account is disabled")
+		return
+	}
+	if role != "" && user.Role != string(role) {
+		user.Role = string(role)
+		if err := h.store.UpdateUser(ctx, user); err != nil {
+			InternalServerError(w, "Failed to update user role")
+			return
+		}
+	}
+
+	tokenPair, err := h.jwtService.GenerateTokenPair(user)
+	if err != nil {
+		InternalServerError(w, "Failed to generate token")
+		return
+	}
+
+	now := time.Now()
+	if err := h.store.UpdateLastLogin(ctx, user.Username, now); err != nil {
+		logger.WarnCtx(ctx, "failed to update last login time", "username", user.Username, "error", err)
+	} else {
+		user.LastLogin = &now
+	}
+
+	WriteJSONOK(w, LoginResponse{
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		TokenType:    tokenPair.TokenType,
+		ExpiresIn:    tokenPair.ExpiresIn,
+		ExpiresAt:    tokenPair.ExpiresAt,
+		User:         userToResponse(user),
+	})
+}
+
+// provision creates the DittoFS user for a first-time IdP login and links
+// it to principal. The user gets a random, never-disclosed password so it
+// can only log in through the IdP.
+func (h *OIDCHandler) provision(w http.ResponseWriter, r *http.Request, tok *auth.IDToken, principal, username string, role models.UserRole) (*models.User, bool) {
+	ctx := r.Context()
+	if username == "" {
+		Forbidden(w, "ID token has no "+h.settings.UsernameClaim+" claim to provision a user from")
+		return nil, false
+	}
+	if role == "" {
+		role = models.RoleUser
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		InternalServerError(w, "Failed to provision user")
+		return nil, false
+	}
+	passwordHash, err := models.HashPassword(hex.EncodeToString(secret))
+	if err != nil {
+		InternalServerError(w, "Failed to provision user")
+		return nil, false
+	}
+
+	user := &models.User{
+		Username:     username,
+		PasswordHash: passwordHash,
+		Enabled:      true,
+		Role:         string(role),
+		DisplayName:  tok.StringClaim("name"),
+		Email:        tok.StringClaim("email"),
+	}
+	if _, err := h.store.CreateUser(ctx, user); err != nil {
+		if errors.Is(err, models.ErrDuplicateUser) {
+			Conflict(w, "A DittoFS user named "+username+" already exists; map this identity to it with 'dfsctl idmap add --provider oidc'")
+			return nil, false
+		}
+		InternalServerError(w, "Failed to provision user")
+		return nil, false
+	}
+	if err := h.store.CreateIdentityMapping(ctx, &models.IdentityMapping{
+		ProviderName: oidc.ProviderName,
+		Principal:    principal,
+		Username:     username,
+	}); err != nil {
+		InternalServerError(w, "Failed to link provisioned user")
+		return nil, false
+	}
+	if h.onMappingChange != nil {
+		h.onMappingChange()
+	}
+	logger.InfoCtx(ctx, "OIDC user provisioned", "username", username, "principal", principal, "role", role)
+	return user, true
+}
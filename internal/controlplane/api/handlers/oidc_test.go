@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/marmos91/dittofs/internal/controlplane/api/auth"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func TestOIDCSettings_RoleFor(t *testing.T) {
+	s := OIDCSettings{
+		AdminGroups:    []string{"storage-admins"},
+		OperatorGroups: []string{"storage-ops"},
+		UserGroups:     []string{"staff"},
+	}
+	tests := []struct {
+		name   string
+		groups []string
+		want   models.UserRole
+		ok     bool
+	}{
+		{"admin wins", []string{"staff", "storage-admins"}, models.RoleAdmin, true},
+		{"operator", []string{"storage-ops", "staff"}, models.RoleOperator, true},
+		{"user", []string{"staff"}, models.RoleUser, true},
+		{"no authorized group", []string{"contractors"}, "", false},
+		{"no groups", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := s.roleFor(tt.groups)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("roleFor(%v) = %q, %v; want %q, %v", tt.groups, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+
+	if got, ok := (OIDCSettings{}).roleFor([]string{"anything"}); got != "" || !ok {
+		t.Fatalf("unmapped settings: roleFor = %q, %v; want \"\", true", got, ok)
+	}
+}
+
+func TestOIDCSettings_HasMFA(t *testing.T) {
+	s := OIDCSettings{MFAMethods: []string{"mfa", "hwk"}}
+	tests := []struct {
+		amr  any
+		want bool
+	}{
+		{[]any{"pwd", "mfa"}, true},
+		{[]any{"hwk"}, true},
+		{[]any{"pwd"}, false},
+		{"mfa", true},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		tok := &auth.IDToken{Claims: jwt.MapClaims{}}
+		if tt.amr != nil {
+			tok.Claims["amr"] = tt.amr
+		}
+		if got := s.hasMFA(tok); got != tt.want {
+			t.Errorf("hasMFA(amr=%v) = %v, want %v", tt.amr, got, tt.want)
+		}
+	}
+}
@@ -52,6 +52,23 @@ func WriteProblem(w http.ResponseWriter, status int, title, detail string) {
 	_ = json.NewEncoder(w).Encode(problem)
 }
 
+// WriteProblemCode writes an RFC 7807 problem response carrying a
+// machine-readable Code, for errors clients must react to rather than just
+// display (e.g. prompting for a one-time code).
+func WriteProblemCode(w http.ResponseWriter, status int, title, detail, code string) {
+	problem := &Problem{
+		Type:   "about:blank",
+		Title:  title,
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+
+	w.Header().Set("Content-Type", ContentTypeProblemJSON)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
 // Common problem helper functions for standard HTTP errors.
 
 // BadRequest writes a 400 Bad Request problem response.
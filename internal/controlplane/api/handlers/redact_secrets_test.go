@@ -41,6 +41,12 @@ func TestRedactSecretJSON(t *testing.T) {
 			blob:     `{"endpoints":[{"password":"x1"},{"password":"x2"}]}`,
 			mustHide: []string{"x1", "x2"},
 		},
+		{
+			name:     "scim patch password",
+			blob:     `{"Operations":[{"op":"replace","path":"password","value":"pw1"},{"op":"replace","path":"active","value":false}]}`,
+			mustHide: []string{"pw1"},
+			mustKeep: []string{"active", "false"},
+		},
 		{
 			name:     "empty blob unchanged",
 			blob:     "",
@@ -83,8 +89,8 @@ func TestRedactSecretJSON(t *testing.T) {
 
 // TestIsSecretKey documents the key-matching convention.
 func TestIsSecretKey(t *testing.T) {
-	secret := []string{"password", "secret_access_key", "Password", "API_SECRET", "access_key", "private_key"}
-	plain := []string{"host", "port", "bucket", "path", "user", "region", "key_id"}
+	secret := []string{"password", "secret_access_key", "Password", "API_SECRET", "access_key", "private_key", "token", "refresh_token"}
+	plain := []string{"host", "port", "bucket", "path", "user", "region", "key_id", "token_type"}
 
 	for _, k := range secret {
 		if !isSecretKey(k) {
@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/marmos91/dittofs/internal/controlplane/api/auth"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+)
+
+// roleHandlerStore is the subset of the control plane store the role
+// handler needs.
+type roleHandlerStore interface {
+	store.RoleStore
+	store.UserStore
+	store.GroupStore
+	GetShare(ctx context.Context, name string) (*models.Share, error)
+}
+
+// RoleHandler handles custom role and role binding management (admin only).
+type RoleHandler struct {
+	store roleHandlerStore
+}
+
+// NewRoleHandler creates a new RoleHandler.
+func NewRoleHandler(s roleHandlerStore) *RoleHandler {
+	return &RoleHandler{store: s}
+}
+
+// RoleRequest is the request body for POST /api/v1/roles and
+// PUT /api/v1/roles/{name}. On update, omitted fields are left unchanged.
+type RoleRequest struct {
+	Name        string    `json:"name,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Permissions *[]string `json:"permissions,omitempty"`
+}
+
+// RoleResponse is the response body for role endpoints.
+type RoleResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RoleBindingRequest is the request body for POST /api/v1/roles/{name}/bindings.
+// Exactly one of User and Group must be set.
+type RoleBindingRequest struct {
+	User   string   `json:"user,omitempty"`
+	Group  string   `json:"group,omitempty"`
+	Shares []string `json:"shares,omitempty"`
+}
+
+// RoleBindingResponse describes a role binding by names.
+type RoleBindingResponse struct {
+	ID        string    `json:"id"`
+	Role      string    `json:"role"`
+	User      string    `json:"user,omitempty"`
+	Group     string    `json:"group,omitempty"`
+	Shares    []string  `json:"shares,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Create handles POST /api/v1/roles.
+func (h *RoleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req RoleRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		BadRequest(w, "Role name is required")
+		return
+	}
+	if req.Permissions == nil || len(*req.Permissions) == 0 {
+		BadRequest(w, "At least one permission is required")
+		return
+	}
+	if !validatePermissions(w, *req.Permissions) {
+		return
+	}
+
+	role := &models.Role{Name: req.Name, Permissions: *req.Permissions}
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if _, err := h.store.CreateRole(r.Context(), role); err != nil {
+		HandleStoreError(w, err)
+		return
+	}
+	logger.InfoCtx(r.Context(), "role created", "role", role.Name, "permissions", role.Permissions)
+	WriteJSONCreated(w, roleToResponse(role))
+}
+
+// List handles GET /api/v1/roles.
+func (h *RoleHandler) List(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.store.ListRoles(r.Context())
+	if err != nil {
+		HandleStoreError(w, err)
+		return
+	}
+	out := make([]RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		out = append(out, roleToResponse(role))
+	}
+	WriteJSONOK(w, out)
+}
+
+// Get handles GET /api/v1/roles/{name}.
+func (h *RoleHandler) Get(w http.ResponseWriter, r *http.Request) {
+	role, err := h.store.GetRole(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		HandleStoreError(w, err)
+		return
+	}
+	WriteJSONOK(w, roleToResponse(role))
+}
+
+// Update handles PUT /api/v1/roles/{name}.
+func (h *RoleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req RoleRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	role, err := h.store.GetRole(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		HandleStoreError(w, err)
+		return
+	}
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Permissions != nil {
+		if len(*req.Permissions) == 0 {
+			BadRequest(w, "At least one permission is required")
+			return
+		}
+		if !validatePermissions(w, *req.Permissions) {
+			return
+		}
+		role.Permissions = *req.Permissions
+	}
+	if err := h.store.UpdateRole(r.Context(), role); err != nil {
+		HandleStoreError(w, err)
+		return
+	}
+	logger.InfoCtx(r.Context(), "role updated", "role", role.Name, "permissions", role.Permissions)
+	WriteJSONOK(w, roleToResponse(role))
+}
+
+// Remove handles DELETE /api/v1/roles/{name}. The role's bindings are
+// removed with it.
+func (h *RoleHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := h.store.DeleteRole(r.Context(), name); err != nil {
+		HandleStoreError(w, err)
+		return
+	}
+	logger.InfoCtx(r.Context(), "role deleted", "role", name)
+	WriteNoContent(w)
+}
+
+// ListBindings handles GET /api/v1/roles/{name}/bindings.
+func (h *RoleHandler) ListBindings(w http.ResponseWriter, r *http.Request) {
+	role, err := h.store.GetRole(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		HandleStoreError(w, err)
+		return
+	}
+	bindings, err := h.store.ListRoleBindings(r.Context(), role.ID)
+	if err != nil {
+		HandleStoreError(w, err)
+		return
+	}
+	out := make([]RoleBindingResponse, 0, len(bindings))
+	for _, b := range bindings {
+		out = append(out, h.bindingToResponse(r, role, b))
+	}
+	WriteJSONOK(w, out)
+}
+
+// CreateBinding handles POST /api/v1/roles/{name}/bindings.
+func (h *RoleHandler) CreateBinding(w http.ResponseWriter, r *http.Request) {
+	var req RoleBindingRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if (req.User == "") == (req.Group == "") {
+		BadRequest(w, "Exactly one of user or group is required")
+		return
+	}
+	ctx := r.Context()
+	role, err := h.store.GetRole(ctx, chi.URLParam(r, "name"))
+	if err != nil {
+		HandleStoreError(w, err)
+		return
+	}
+
+	binding := &models.RoleBinding{RoleID: role.ID}
+	if req.User != "" {
+		user, err := h.store.GetUser(ctx, req.User)
+		if err != nil {
+			HandleStoreError(w, err)
+			return
+		}
+		binding.UserID = user.ID
+	} else {
+		group, err := h.store.GetGroup(ctx, req.Group)
+		if err != nil {
+			HandleStoreError(w, err)
+			return
+		}
+		binding.GroupID = group.ID
+	}
+	for _, name := range req.Shares {
+		share, err := h.store.GetShare(ctx, "/"+strings.TrimLeft(name, "/"))
+		if err != nil {
+			if errors.Is(err, models.ErrShareNotFound) {
+				BadRequest(w, "Share "+name+" does not exist")
+				return
+			}
+			HandleStoreError(w, err)
+			return
+		}
+		binding.Shares = append(binding.Shares, share.Name)
+	}
+
+	if err := h.store.CreateRoleBinding(ctx, binding); err != nil {
+		HandleStoreError(w, err)
+		return
+	}
+	logger.InfoCtx(ctx, "role bound", "role", role.Name, "user", req.User, "group", req.Group, "shares", binding.Shares)
+	WriteJSONCreated(w, h.bindingToResponse(r, role, binding))
+}
+
+// RemoveBinding handles DELETE /api/v1/roles/{name}/bindings/{id}.
+func (h *RoleHandler) RemoveBinding(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	role, err := h.store.GetRole(ctx, chi.URLParam(r, "name"))
+	if err != nil {
+		HandleStoreError(w, err)
+		return
+	}
+	id := chi.URLParam(r, "id")
+	bindings, err := h.store.ListRoleBindings(ctx, role.ID)
+	if err != nil {
+		HandleStoreError(w, err)
+		return
+	}
+	if !slices.ContainsFunc(bindings, func(b *models.RoleBinding) bool { return b.ID == id }) {
+		NotFound(w, "Role binding not found")
+		return
+	}
+	if err := h.store.DeleteRoleBinding(ctx, id); err != nil {
+		HandleStoreError(w, err)
+		return
+	}
+	logger.InfoCtx(ctx, "role binding removed", "role", role.Name, "id", id)
+	WriteNoContent(w)
+}
+
+// validatePermissions writes a 400 and returns false if any permission is
+// unknown.
+func validatePermissions(w http.ResponseWriter, perms []string) bool {
+	for _, p := range perms {
+		if !auth.ValidPermission(p) {
+			BadRequest(w, "Unknown permission "+p)
+			return false
+		}
+	}
+	return true
+}
+
+func roleToResponse(role *models.Role) RoleResponse {
+	return RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+		CreatedAt:   role.CreatedAt,
+	}
+}
+
+func (h *RoleHandler) bindingToResponse(r *http.Request, role *models.Role, b *models.RoleBinding) RoleBindingResponse {
+	resp := RoleBindingResponse{
+		ID:        b.ID,
+		Role:      role.Name,
+		Shares:    b.Shares,
+		CreatedAt: b.CreatedAt,
+	}
+	if b.UserID != "" {
+		if u, err := h.store.GetUserByID(r.Context(), b.UserID); err == nil {
+			resp.User = u.Username
+		}
+	}
+	if b.GroupID != "" {
+		if g, err := h.store.GetGroupByID(r.Context(), b.GroupID); err == nil {
+			resp.Group = g.Name
+		}
+	}
+	return resp
+}
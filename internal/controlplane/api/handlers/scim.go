@@ -0,0 +1,748 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/auth/sid"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+)
+
+// SCIM 2.0 (RFC 7643, RFC 7644) lets identity platforms such as Okta and
+// Entra ID create, update, disable and delete DittoFS users and groups. The
+// subset those platforms use is implemented: the Users and Groups resources,
+// "eq" filters, PATCH and index-based paging. Bulk operations, sorting and
+// ETags are not.
+const (
+	scimContentType = "application/scim+json"
+	scimUserSchema  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimListSchema  = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimPatchSchema = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	scimErrorSchema = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	// SCIMUserExtension and SCIMGroupExtension are the schemas carrying
+	// DittoFS's POSIX ids and Windows SID.
+	SCIMUserExtension  = "urn:ietf:params:scim:schemas:extension:dittofs:2.0:User"
+	SCIMGroupExtension = "urn:ietf:params:scim:schemas:extension:dittofs:2.0:Group"
+
+	// scimMaxResults caps a page of a list response.
+	scimMaxResults = 200
+)
+
+// errSCIMSIDConflict is returned when a SID is already bound to another id.
+var errSCIMSIDConflict = errors.New("SID is already mapped to another id")
+
+// scimStore is the store surface SCIMHandler needs.
+type scimStore interface {
+	store.UserStore
+	store.GroupStore
+	store.SIDMappingStore
+}
+
+// SCIMSettings controls id assignment for provisioned users and groups.
+type SCIMSettings struct {
+	// UIDBase and GIDBase are the lowest UID and GID assigned to users and
+	// groups provisioned without one.
+	UIDBase uint32
+	GIDBase uint32
+}
+
+// SCIMHandler serves the /scim/v2 provisioning endpoints.
+//
+// System groups and service accounts are not SCIM resources: they are
+// neither listed nor modifiable, so an IdP cannot take over the built-in
+// admins group or the accounts behind API tokens.
+type SCIMHandler struct {
+	store    scimStore
+	sids     func() *sid.SIDMapper
+	settings SCIMSettings
+	onChange func()
+}
+
+// NewSCIMHandler creates a SCIMHandler. sids returns the machine SID mapper
+// used to report the SID of users and groups that have no directory SID; it
+// may be nil or return nil. The optional onChange callback is invoked after
+// every change so identity caches drop deprovisioned users at once.
+func NewSCIMHandler(s scimStore, sids func() *sid.SIDMapper, settings SCIMSettings, onChange func()) *SCIMHandler {
+	return &SCIMHandler{store: s, sids: sids, settings: settings, onChange: onChange}
+}
+
+// SCIMName is the SCIM "name" complex attribute. DittoFS keeps only a
+// display name, derived from Formatted or GivenName and FamilyName.
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// SCIMMultiValue is an entry of a multi-valued attribute such as "emails".
+type SCIMMultiValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// SCIMMember references a group member, or a group of a user, by id.
+type SCIMMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// SCIMMeta is the read-only resource metadata.
+type SCIMMeta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	Location     string     `json:"location,omitempty"`
+}
+
+// SCIMUserExtensionAttrs are the SCIMUserExtension attributes. Ids absent
+// from a request leave the stored ones unchanged; they are never cleared.
+type SCIMUserExtensionAttrs struct {
+	UIDNumber *uint32 `json:"uidNumber,omitempty"`
+	GIDNumber *uint32 `json:"gidNumber,omitempty"`
+	// SID is the user's directory SID (e.g. Entra ID's
+	// onPremisesSecurityIdentifier). It is bound to the UID so ACL entries
+	// naming it resolve to this user.
+	SID string `json:"sid,omitempty"`
+}
+
+// SCIMGroupExtensionAttrs are the SCIMGroupExtension attributes.
+type SCIMGroupExtensionAttrs struct {
+	GIDNumber *uint32 `json:"gidNumber,omitempty"`
+	SID       string  `json:"sid,omitempty"`
+}
+
+// SCIMUser is the SCIM User resource.
+type SCIMUser struct {
+	Schemas     []string                `json:"schemas"`
+	ID          string                  `json:"id,omitempty"`
+	UserName    string                  `json:"userName"`
+	Name        *SCIMName               `json:"name,omitempty"`
+	DisplayName string                  `json:"displayName,omitempty"`
+	Active      *bool                   `json:"active,omitempty"`
+	Password    string                  `json:"password,omitempty"`
+	Emails      []SCIMMultiValue        `json:"emails,omitempty"`
+	Groups      []SCIMMember            `json:"groups,omitempty"`
+	DittoFS     *SCIMUserExtensionAttrs `json:"urn:ietf:params:scim:schemas:extension:dittofs:2.0:User,omitempty"`
+	Meta        *SCIMMeta               `json:"meta,omitempty"`
+}
+
+// displayName returns the display name a representation asks for.
+func (u *SCIMUser) displayName() string {
+	if u.DisplayName != "" || u.Name == nil {
+		return u.DisplayName
+	}
+	if u.Name.Formatted != "" {
+		return u.Name.Formatted
+	}
+	return strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+}
+
+// primaryEmail returns the primary email, or the first one.
+func (u *SCIMUser) primaryEmail() string {
+	for _, e := range u.Emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return ""
+}
+
+// SCIMGroup is the SCIM Group resource. Members absent from a PUT are left
+// unchanged.
+type SCIMGroup struct {
+	Schemas     []string                 `json:"schemas"`
+	ID          string                   `json:"id,omitempty"`
+	DisplayName string                   `json:"displayName"`
+	Members     []SCIMMember             `json:"members,omitempty"`
+	DittoFS     *SCIMGroupExtensionAttrs `json:"urn:ietf:params:scim:schemas:extension:dittofs:2.0:Group,omitempty"`
+	Meta        *SCIMMeta                `json:"meta,omitempty"`
+}
+
+// SCIMListResponse is a page of a list or query.
+type SCIMListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+// scimError is the SCIM error response (RFC 7644 §3.12). SCIM clients do not
+// understand problem details, so these endpoints answer with this instead.
+type scimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// writeSCIM writes a SCIM JSON response.
+func writeSCIM(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// writeSCIMError writes a SCIM error. scimType is optional.
+func writeSCIMError(w http.ResponseWriter, status int, scimType, detail string) {
+	writeSCIM(w, status, scimError{
+		Schemas:  []string{scimErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// decodeSCIMBody decodes a SCIM request body into v, writing a SCIM error
+// and returning false when it cannot.
+func decodeSCIMBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			writeSCIMError(w, http.StatusRequestEntityTooLarge, "", "request body exceeds the 1 MiB limit")
+			return false
+		}
+		writeSCIMError(w, http.StatusBadRequest, "invalidSyntax", "Invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// scimLocation returns the URL of a resource.
+func scimLocation(r *http.Request, resource, id string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + "/scim/v2/" + resource + "/" + id
+}
+
+// changed notifies onChange of a change.
+func (h *SCIMHandler) changed() {
+	if h.onChange != nil {
+		h.onChange()
+	}
+}
+
+// ServiceProviderConfig handles GET /scim/v2/ServiceProviderConfig.
+func (h *SCIMHandler) ServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	supported := func(ok bool) map[string]bool { return map[string]bool{"supported": ok} }
+	writeSCIM(w, http.StatusOK, map[string]any{
+		"schemas":        []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"patch":          supported(true),
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": scimMaxResults},
+		"changePassword": supported(true),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "A DittoFS API token with the users:write scope",
+			"primary":     true,
+		}},
+	})
+}
+
+// ResourceTypes handles GET /scim/v2/ResourceTypes.
+func (h *SCIMHandler) ResourceTypes(w http.ResponseWriter, r *http.Request) {
+	resourceType := func(name, endpoint, schema, extension string) map[string]any {
+		return map[string]any{
+			"schemas":          []string{"urn:ietf:params:scim:schemas:core:2.0:ResourceType"},
+			"id":               name,
+			"name":             name,
+			"endpoint":         endpoint,
+			"schema":           schema,
+			"schemaExtensions": []map[string]any{{"schema": extension, "required": false}},
+		}
+	}
+	resources := []any{
+		resourceType("User", "/Users", scimUserSchema, SCIMUserExtension),
+		resourceType("Group", "/Groups", scimGroupSchema, SCIMGroupExtension),
+	}
+	writeSCIM(w, http.StatusOK, SCIMListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: len(resources),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// scimFilter is a parsed `attribute eq "value"` filter, the only form Okta
+// and Entra ID send.
+type scimFilter struct {
+	attr  string // lower-cased
+	value string
+}
+
+// parseSCIMFilter parses the filter query parameter. It returns nil for an
+// empty filter.
+func parseSCIMFilter(s string) (*scimFilter, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	attr, rest, _ := strings.Cut(s, " ")
+	op, value, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	if !strings.EqualFold(op, "eq") || value == "" {
+		return nil, fmt.Errorf("unsupported filter %q: only 'attribute eq value' is supported", s)
+	}
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal([]byte(value), &value); err != nil {
+			return nil, fmt.Errorf("invalid filter value in %q", s)
+		}
+	}
+	return &scimFilter{attr: strings.ToLower(attr), value: value}, nil
+}
+
+// scimPage writes the page of resources selected by the startIndex and
+// count query parameters.
+func scimPage(w http.ResponseWriter, r *http.Request, resources []any) {
+	start, _ := strconv.Atoi(r.URL.Query().Get("startIndex"))
+	if start < 1 {
+		start = 1
+	}
+	count := scimMaxResults
+	if c := r.URL.Query().Get("count"); c != "" {
+		count, _ = strconv.Atoi(c)
+		count = max(0, min(count, scimMaxResults))
+	}
+	page := []any{}
+	if start <= len(resources) {
+		page = resources[start-1 : min(len(resources), start-1+count)]
+	}
+	writeSCIM(w, http.StatusOK, SCIMListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: len(resources),
+		StartIndex:   start,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	})
+}
+
+// --- Users ---
+
+// toSCIMUser converts a user to its SCIM representation.
+func (h *SCIMHandler) toSCIMUser(r *http.Request, u *models.User) *SCIMUser {
+	active := u.Enabled
+	created := u.CreatedAt
+	out := &SCIMUser{
+		Schemas:     []string{scimUserSchema, SCIMUserExtension},
+		ID:          u.ID,
+		UserName:    u.Username,
+		DisplayName: u.DisplayName,
+		Active:      &active,
+		DittoFS:     &SCIMUserExtensionAttrs{UIDNumber: u.UID, GIDNumber: u.GID, SID: u.SID},
+		Meta:        &SCIMMeta{ResourceType: "User", Created: &created, Location: scimLocation(r, "Users", u.ID)},
+	}
+	if out.DittoFS.SID == "" && u.UID != nil {
+		if m := h.sidMapper(); m != nil {
+			out.DittoFS.SID = sid.FormatSID(m.UserSID(*u.UID))
+		}
+	}
+	if u.DisplayName != "" {
+		out.Name = &SCIMName{Formatted: u.DisplayName}
+	}
+	if u.Email != "" {
+		out.Emails = []SCIMMultiValue{{Value: u.Email, Type: "work", Primary: true}}
+	}
+	for _, g := range u.Groups {
+		if models.IsSystemGroup(g.Name) {
+			continue
+		}
+		out.Groups = append(out.Groups, SCIMMember{Value: g.ID, Display: g.Name, Ref: scimLocation(r, "Groups", g.ID)})
+	}
+	return out
+}
+
+func (h *SCIMHandler) sidMapper() *sid.SIDMapper {
+	if h.sids == nil {
+		return nil
+	}
+	return h.sids()
+}
+
+// matchUser reports whether u matches f.
+func matchUser(u *models.User, f *scimFilter) (bool, error) {
+	if f == nil {
+		return true, nil
+	}
+	switch f.attr {
+	case "username":
+		return strings.EqualFold(u.Username, f.value), nil
+	case "id":
+		return u.ID == f.value, nil
+	case "emails", "emails.value":
+		return u.Email != "" && strings.EqualFold(u.Email, f.value), nil
+	case "displayname":
+		return u.DisplayName == f.value, nil
+	}
+	return false, fmt.Errorf("filtering on %q is not supported", f.attr)
+}
+
+// ListUsers handles GET /scim/v2/Users.
+func (h *SCIMHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseSCIMFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+	users, err := h.store.ListUsers(r.Context())
+	if err != nil {
+		writeSCIMError(w, http.StatusInternalServerError, "", "Failed to list users")
+		return
+	}
+	resources := []any{}
+	for _, u := range users {
+		if u.ServiceAccount {
+			continue
+		}
+		ok, err := matchUser(u, filter)
+		if err != nil {
+			writeSCIMError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+			return
+		}
+		if ok {
+			resources = append(resources, h.toSCIMUser(r, u))
+		}
+	}
+	scimPage(w, r, resources)
+}
+
+// loadUser returns the user named by the {id} URL parameter, writing a SCIM
+// error and returning false when there is none.
+func (h *SCIMHandler) loadUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user, err := h.store.GetUserByID(r.Context(), chi.URLParam(r, "id"))
+	switch {
+	case errors.Is(err, models.ErrUserNotFound) || (err == nil && user.ServiceAccount):
+		writeSCIMError(w, http.StatusNotFound, "", "User not found")
+		return nil, false
+	case err != nil:
+		writeSCIMError(w, http.StatusInternalServerError, "", "Failed to get user")
+		return nil, false
+	}
+	return user, true
+}
+
+// GetUser handles GET /scim/v2/Users/{id}.
+func (h *SCIMHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	if user, ok := h.loadUser(w, r); ok {
+		writeSCIM(w, http.StatusOK, h.toSCIMUser(r, user))
+	}
+}
+
+// CreateUser handles POST /scim/v2/Users.
+func (h *SCIMHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var in SCIMUser
+	if !decodeSCIMBody(w, r, &in) {
+		return
+	}
+	user := &models.User{Enabled: true, Role: string(models.RoleUser)}
+	if in.Password == "" {
+		// Without a pushed password the user can only log in through SSO
+		// or the directory; the random password is never disclosed.
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			writeSCIMError(w, http.StatusInternalServerError, "", "Failed to create user")
+			return
+		}
+		hash, err := models.HashPassword(hex.EncodeToString(secret))
+		if err != nil {
+			writeSCIMError(w, http.StatusInternalServerError, "", "Failed to create user")
+			return
+		}
+		user.PasswordHash = hash
+	}
+	if user, ok := h.saveUser(w, r, user, &in); ok {
+		logger.InfoCtx(r.Context(), "SCIM user provisioned", "username", user.Username, "uid", user.UID)
+		writeSCIM(w, http.StatusCreated, h.toSCIMUser(r, user))
+	}
+}
+
+// ReplaceUser handles PUT /scim/v2/Users/{id}.
+func (h *SCIMHandler) ReplaceUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadUser(w, r)
+	if !ok {
+		return
+	}
+	var in SCIMUser
+	if !decodeSCIMBody(w, r, &in) {
+		return
+	}
+	if user, ok = h.saveUser(w, r, user, &in); ok {
+		writeSCIM(w, http.StatusOK, h.toSCIMUser(r, user))
+	}
+}
+
+// PatchUser handles PATCH /scim/v2/Users/{id}. The operations are applied to
+// the user's current representation, which is then saved like a PUT.
+func (h *SCIMHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadUser(w, r)
+	if !ok {
+		return
+	}
+	var req SCIMPatchRequest
+	if !decodeSCIMBody(w, r, &req) {
+		return
+	}
+	in := h.toSCIMUser(r, user)
+	for _, op := range req.Operations {
+		if err := applyUserPatch(in, op); err != nil {
+			writeSCIMError(w, http.StatusBadRequest, scimTypeOf(err), err.Error())
+			return
+		}
+	}
+	if user, ok = h.saveUser(w, r, user, in); ok {
+		writeSCIM(w, http.StatusOK, h.toSCIMUser(r, user))
+	}
+}
+
+// DeleteUser handles DELETE /scim/v2/Users/{id}.
+func (h *SCIMHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadUser(w, r)
+	if !ok {
+		return
+	}
+	if isDelegated(r) && user.Role == string(models.RoleAdmin) {
+		writeSCIMError(w, http.StatusForbidden, "", "Only server admins can delete admin users")
+		return
+	}
+	switch err := h.store.DeleteUser(r.Context(), user.Username); {
+	case err == nil:
+		logger.InfoCtx(r.Context(), "SCIM user deprovisioned", "username", user.Username)
+		h.changed()
+		WriteNoContent(w)
+	case errors.Is(err, models.ErrCannotDeleteAdmin):
+		writeSCIMError(w, http.StatusForbidden, "", "Cannot delete the admin user")
+	default:
+		writeSCIMError(w, http.StatusInternalServerError, "", "Failed to delete user")
+	}
+}
+
+// saveUser applies in, a full User representation, to user and persists it,
+// creating the user when it has no ID yet. A user without a UID is assigned
+// one, and a directory SID is bound to the UID. Returns the reloaded user.
+func (h *SCIMHandler) saveUser(w http.ResponseWriter, r *http.Request, user *models.User, in *SCIMUser) (*models.User, bool) {
+	ctx := r.Context()
+	if in.UserName == "" {
+		writeSCIMError(w, http.StatusBadRequest, "invalidValue", "userName is required")
+		return nil, false
+	}
+	if isDelegated(r) && user.Role == string(models.RoleAdmin) {
+		writeSCIMError(w, http.StatusForbidden, "", "Only server admins can modify admin users")
+		return nil, false
+	}
+	create := user.ID == ""
+
+	if other, err := h.store.GetUser(ctx, in.UserName); err == nil && other.ID != user.ID {
+		writeSCIMError(w, http.StatusConflict, "uniqueness", "userName "+in.UserName+" is already taken")
+		return nil, false
+	}
+	user.Username = in.UserName
+	user.DisplayName = in.displayName()
+	user.Email = in.primaryEmail()
+	if in.Active != nil {
+		user.Enabled = *in.Active
+	}
+
+	var directorySID string
+	if ext := in.DittoFS; ext != nil {
+		if !h.checkID(w, "uidNumber", user.UID, ext.UIDNumber, h.settings.UIDBase) ||
+			!h.checkID(w, "gidNumber", user.GID, ext.GIDNumber, h.settings.GIDBase) {
+			return nil, false
+		}
+		if ext.UIDNumber != nil {
+			user.UID = ext.UIDNumber
+		}
+		if ext.GIDNumber != nil {
+			user.GID = ext.GIDNumber
+		}
+		var err error
+		if directorySID, err = h.directorySID(ext.SID); err != nil {
+			writeSCIMError(w, http.StatusBadRequest, "invalidValue", "Invalid SID: "+err.Error())
+			return nil, false
+		}
+	}
+	if user.UID == nil {
+		uid, err := h.assignID(ctx, directorySID, false)
+		if err != nil {
+			writeSCIMError(w, http.StatusInternalServerError, "", "Failed to assign a UID: "+err.Error())
+			return nil, false
+		}
+		user.UID = &uid
+	}
+	if other, err := h.store.GetUserByUID(ctx, *user.UID); err == nil && other.ID != user.ID {
+		writeSCIMError(w, http.StatusConflict, "uniqueness", fmt.Sprintf("uidNumber %d is already taken", *user.UID))
+		return nil, false
+	}
+	if err := h.checkSID(ctx, directorySID, *user.UID, false); err != nil {
+		writeSCIMError(w, http.StatusConflict, "uniqueness", err.Error())
+		return nil, false
+	}
+
+	// Okta re-sends a synchronized password with every update; an unchanged
+	// one is not a password change (and must not trip the reuse policy).
+	var passwordHash, ntHash string
+	if in.Password != "" && (create || !models.VerifyPassword(in.Password, user.PasswordHash)) {
+		if err := h.store.CheckPasswordPolicy(ctx, user.Username, in.Password); err != nil {
+			writeSCIMError(w, http.StatusBadRequest, "invalidValue", err.Error())
+			return nil, false
+		}
+		var err error
+		if passwordHash, ntHash, err = models.HashPasswordWithNT(in.Password); err != nil {
+			writeSCIMError(w, http.StatusInternalServerError, "", "Failed to hash password")
+			return nil, false
+		}
+	}
+
+	if create {
+		if passwordHash != "" {
+			user.PasswordHash, user.NTHash = passwordHash, ntHash
+			now := time.Now()
+			user.PasswordChangedAt = &now
+		}
+		if _, err := h.store.CreateUser(ctx, user); err != nil {
+			if errors.Is(err, models.ErrDuplicateUser) {
+				writeSCIMError(w, http.StatusConflict, "uniqueness", "userName or uidNumber is already taken")
+				return nil, false
+			}
+			writeSCIMError(w, http.StatusInternalServerError, "", "Failed to create user")
+			return nil, false
+		}
+	} else {
+		if err := h.store.UpdateUser(ctx, user); err != nil {
+			writeSCIMError(w, http.StatusInternalServerError, "", "Failed to update user")
+			return nil, false
+		}
+		if passwordHash != "" {
+			if err := h.store.UpdatePassword(ctx, user.Username, passwordHash, ntHash); err != nil {
+				writeSCIMError(w, http.StatusInternalServerError, "", "Failed to update password")
+				return nil, false
+			}
+		}
+	}
+
+	if directorySID != "" && directorySID != user.SID {
+		if err := h.bindSID(ctx, directorySID, *user.UID, false, user.Username); err != nil {
+			writeSIDError(w, err)
+			return nil, false
+		}
+		if err := h.store.UpdateUserSIDInfo(ctx, user.Username, directorySID, user.GroupSIDs); err != nil {
+			writeSCIMError(w, http.StatusInternalServerError, "", "Failed to store SID")
+			return nil, false
+		}
+	}
+	h.changed()
+
+	saved, err := h.store.GetUserByID(ctx, user.ID)
+	if err != nil {
+		writeSCIMError(w, http.StatusInternalServerError, "", "Failed to reload user")
+		return nil, false
+	}
+	return saved, true
+}
+
+// directorySID validates the SID of a representation. SIDs DittoFS derives
+// itself (machine-domain and well-known SIDs), which GET reports for users
+// and groups without a directory SID and clients echo back on PUT, are not
+// directory SIDs and yield "".
+func (h *SCIMHandler) directorySID(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	parsed, err := sid.ParseSIDString(s)
+	if err != nil {
+		return "", err
+	}
+	if m := h.sidMapper(); m != nil && !m.IsForeignDomainSID(parsed) {
+		return "", nil
+	}
+	return s, nil
+}
+
+// checkID refuses a uidNumber or gidNumber set to 0 or below the allocation
+// base, which would let the identity platform provision root or collide
+// with local system accounts. An id the resource already holds is accepted
+// as is, so resources created through the REST API stay editable.
+func (h *SCIMHandler) checkID(w http.ResponseWriter, attr string, current, id *uint32, base uint32) bool {
+	if id == nil || (current != nil && *current == *id) {
+		return true
+	}
+	if *id == 0 || *id < base {
+		writeSCIMError(w, http.StatusBadRequest, "invalidValue",
+			fmt.Sprintf("%s %d is reserved: ids must be at least %d", attr, *id, max(base, 1)))
+		return false
+	}
+	return true
+}
+
+// assignID returns the UID (or GID) for a new user (or group): the id its
+// directory SID is already bound to, else the next free id above the
+// configured base.
+func (h *SCIMHandler) assignID(ctx context.Context, directorySID string, isGroup bool) (uint32, error) {
+	if directorySID != "" {
+		if m, err := h.store.GetSIDMapping(ctx, directorySID); err == nil && m.IsGroup == isGroup {
+			return m.UnixID, nil
+		}
+	}
+	if isGroup {
+		return h.store.NextFreeGID(ctx, h.settings.GIDBase)
+	}
+	return h.store.NextFreeUID(ctx, h.settings.UIDBase)
+}
+
+// checkSID returns errSCIMSIDConflict when directorySID is bound to an id
+// other than id. Foreign SIDs are never remapped (see
+// store.SIDMappingStore).
+func (h *SCIMHandler) checkSID(ctx context.Context, directorySID string, id uint32, isGroup bool) error {
+	if directorySID == "" {
+		return nil
+	}
+	m, err := h.store.GetSIDMapping(ctx, directorySID)
+	if err != nil || (m.UnixID == id && m.IsGroup == isGroup) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is bound to id %d", errSCIMSIDConflict, directorySID, m.UnixID)
+}
+
+// writeSIDError writes the SCIM error for a failed bindSID.
+func writeSIDError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errSCIMSIDConflict) {
+		writeSCIMError(w, http.StatusConflict, "uniqueness", err.Error())
+		return
+	}
+	writeSCIMError(w, http.StatusInternalServerError, "", "Failed to map SID")
+}
+
+// bindSID binds directorySID to id.
+func (h *SCIMHandler) bindSID(ctx context.Context, directorySID string, id uint32, isGroup bool, name string) error {
+	m, err := h.store.AllocateSIDMapping(ctx, directorySID, id, isGroup, name)
+	if err != nil {
+		return err
+	}
+	if m.UnixID != id || m.IsGroup != isGroup {
+		return fmt.Errorf("%w: %s is bound to id %d", errSCIMSIDConflict, directorySID, m.UnixID)
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/auth/sid"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+// toSCIMGroup converts a group to its SCIM representation. Members are
+// omitted when withMembers is false (excludedAttributes=members, which Entra
+// ID uses to look groups up cheaply).
+func (h *SCIMHandler) toSCIMGroup(r *http.Request, g *models.Group, withMembers bool) *SCIMGroup {
+	created := g.CreatedAt
+	out := &SCIMGroup{
+		Schemas:     []string{scimGroupSchema, SCIMGroupExtension},
+		ID:          g.ID,
+		DisplayName: g.Name,
+		DittoFS:     &SCIMGroupExtensionAttrs{GIDNumber: g.GID, SID: g.SID},
+		Meta:        &SCIMMeta{ResourceType: "Group", Created: &created, Location: scimLocation(r, "Groups", g.ID)},
+	}
+	if out.DittoFS.SID == "" && g.GID != nil {
+		if m := h.sidMapper(); m != nil {
+			out.DittoFS.SID = sid.FormatSID(m.GroupSID(*g.GID))
+		}
+	}
+	if withMembers {
+		out.Members = []SCIMMember{}
+		for _, u := range g.Users {
+			out.Members = append(out.Members, SCIMMember{Value: u.ID, Display: u.Username, Ref: scimLocation(r, "Users", u.ID)})
+		}
+	}
+	return out
+}
+
+// membersExcluded reports whether the request excludes the members attribute.
+func membersExcluded(r *http.Request) bool {
+	for _, attr := range strings.Split(r.URL.Query().Get("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attr), "members") {
+			return true
+		}
+	}
+	return false
+}
+
+// ListGroups handles GET /scim/v2/Groups.
+func (h *SCIMHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseSCIMFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+	if filter != nil && filter.attr != "displayname" && filter.attr != "id" {
+		writeSCIMError(w, http.StatusBadRequest, "invalidFilter", fmt.Sprintf("filtering on %q is not supported", filter.attr))
+		return
+	}
+	groups, err := h.store.ListGroups(r.Context())
+	if err != nil {
+		writeSCIMError(w, http.StatusInternalServerError, "", "Failed to list groups")
+		return
+	}
+	withMembers := !membersExcluded(r)
+	resources := []any{}
+	for _, g := range groups {
+		if models.IsSystemGroup(g.Name) {
+			continue
+		}
+		if filter != nil {
+			if filter.attr == "id" && g.ID != filter.value ||
+				filter.attr == "displayname" && !strings.EqualFold(g.Name, filter.value) {
+				continue
+			}
+		}
+		resources = append(resources, h.toSCIMGroup(r, g, withMembers))
+	}
+	scimPage(w, r, resources)
+}
+
+// loadGroup returns the group named by the {id} URL parameter, writing a
+// SCIM error and returning false when there is none.
+func (h *SCIMHandler) loadGroup(w http.ResponseWriter, r *http.Request) (*models.Group, bool) {
+	group, err := h.store.GetGroupByID(r.Context(), chi.URLParam(r, "id"))
+	switch {
+	case errors.Is(err, models.ErrGroupNotFound) || (err == nil && models.IsSystemGroup(group.Name)):
+		writeSCIMError(w, http.StatusNotFound, "", "Group not found")
+		return nil, false
+	case err != nil:
+		writeSCIMError(w, http.StatusInternalServerError, "", "Failed to get group")
+		return nil, false
+	}
+	return group, true
+}
+
+// GetGroup handles GET /scim/v2/Groups/{id}.
+func (h *SCIMHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	if group, ok := h.loadGroup(w, r); ok {
+		writeSCIM(w, http.StatusOK, h.toSCIMGroup(r, group, !membersExcluded(r)))
+	}
+}
+
+// CreateGroup handles POST /scim/v2/Groups.
+func (h *SCIMHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var in SCIMGroup
+	if !decodeSCIMBody(w, r, &in) {
+		return
+	}
+	if group, ok := h.saveGroup(w, r, &models.Group{}, &in); ok {
+		logger.InfoCtx(r.Context(), "SCIM group provisioned", "group", group.Name, "gid", group.GID)
+		writeSCIM(w, http.StatusCreated, h.toSCIMGroup(r, group, true))
+	}
+}
+
+// ReplaceGroup handles PUT /scim/v2/Groups/{id}.
+func (h *SCIMHandler) ReplaceGroup(w http.ResponseWriter, r *http.Request) {
+	group, ok := h.loadGroup(w, r)
+	if !ok {
+		return
+	}
+	var in SCIMGroup
+	if !decodeSCIMBody(w, r, &in) {
+		return
+	}
+	if group, ok = h.saveGroup(w, r, group, &in); ok {
+		writeSCIM(w, http.StatusOK, h.toSCIMGroup(r, group, true))
+	}
+}
+
+// PatchGroup handles PATCH /scim/v2/Groups/{id}. Entra ID and Okta push
+// membership changes this way, one add or remove of members at a time.
+func (h *SCIMHandler) PatchGroup(w http.ResponseWriter, r *http.Request) {
+	group, ok := h.loadGroup(w, r)
+	if !ok {
+		return
+	}
+	var req SCIMPatchRequest
+	if !decodeSCIMBody(w, r, &req) {
+		return
+	}
+	in := h.toSCIMGroup(r, group, true)
+	for _, op := range req.Operations {
+		if err := applyGroupPatch(in, op); err != nil {
+			writeSCIMError(w, http.StatusBadRequest, scimTypeOf(err), err.Error())
+			return
+		}
+	}
+	if group, ok = h.saveGroup(w, r, group, in); !ok {
+		return
+	}
+	// RFC 7644 allows 204 for a PATCH; Entra ID expects it, and returning
+	// every member of a large group after each change is wasteful.
+	WriteNoContent(w)
+}
+
+// DeleteGroup handles DELETE /scim/v2/Groups/{id}.
+func (h *SCIMHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	group, ok := h.loadGroup(w, r)
+	if !ok {
+		return
+	}
+	switch err := h.store.DeleteGroup(r.Context(), group.Name); {
+	case err == nil:
+		logger.InfoCtx(r.Context(), "SCIM group deprovisioned", "group", group.Name)
+		h.changed()
+		WriteNoContent(w)
+	case errors.Is(err, models.ErrCannotDeleteSysGroup):
+		writeSCIMError(w, http.StatusForbidden, "", "Cannot delete a system group")
+	default:
+		writeSCIMError(w, http.StatusInternalServerError, "", "Failed to delete group")
+	}
+}
+
+// saveGroup applies in, a full Group representation, to group and persists
+// it, creating the group when it has no ID yet. A group without a GID is
+// assigned one, a directory SID is bound to the GID, and the membership is
+// made to match in.Members when present. Returns the reloaded group.
+func (h *SCIMHandler) saveGroup(w http.ResponseWriter, r *http.Request, group *models.Group, in *SCIMGroup) (*models.Group, bool) {
+	ctx := r.Context()
+	if in.DisplayName == "" {
+		writeSCIMError(w, http.StatusBadRequest, "invalidValue", "displayName is required")
+		return nil, false
+	}
+	if models.IsSystemGroup(in.DisplayName) {
+		writeSCIMError(w, http.StatusConflict, "uniqueness", in.DisplayName+" is a reserved group name")
+		return nil, false
+	}
+	if other, err := h.store.GetGroup(ctx, in.DisplayName); err == nil && other.ID != group.ID {
+		writeSCIMError(w, http.StatusConflict, "uniqueness", "displayName "+in.DisplayName+" is already taken")
+		return nil, false
+	}
+	create := group.ID == ""
+
+	// Resolve members before changing anything, so an unknown member
+	// fails the whole request.
+	var members map[string]string // user ID -> username
+	if in.Members != nil {
+		members = make(map[string]string, len(in.Members))
+		for _, m := range in.Members {
+			u, err := h.store.GetUserByID(ctx, m.Value)
+			if errors.Is(err, models.ErrUserNotFound) || (err == nil && u.ServiceAccount) {
+				writeSCIMError(w, http.StatusBadRequest, "invalidValue", "Unknown member "+m.Value)
+				return nil, false
+			}
+			if err != nil {
+				writeSCIMError(w, http.StatusInternalServerError, "", "Failed to resolve members")
+				return nil, false
+			}
+			members[u.ID] = u.Username
+		}
+	}
+
+	var directorySID string
+	if ext := in.DittoFS; ext != nil {
+		if !h.checkID(w, "gidNumber", group.GID, ext.GIDNumber, h.settings.GIDBase) {
+			return nil, false
+		}
+		if ext.GIDNumber != nil {
+			group.GID = ext.GIDNumber
+		}
+		var err error
+		if directorySID, err = h.directorySID(ext.SID); err != nil {
+			writeSCIMError(w, http.StatusBadRequest, "invalidValue", "Invalid SID: "+err.Error())
+			return nil, false
+		}
+	}
+	if group.GID == nil {
+		gid, err := h.assignID(ctx, directorySID, true)
+		if err != nil {
+			writeSCIMError(w, http.StatusInternalServerError, "", "Failed to assign a GID: "+err.Error())
+			return nil, false
+		}
+		group.GID = &gid
+	}
+	if other, err := h.store.GetGroupByGID(ctx, *group.GID); err == nil && other.ID != group.ID {
+		writeSCIMError(w, http.StatusConflict, "uniqueness", fmt.Sprintf("gidNumber %d is already taken", *group.GID))
+		return nil, false
+	}
+	if err := h.checkSID(ctx, directorySID, *group.GID, true); err != nil {
+		writeSCIMError(w, http.StatusConflict, "uniqueness", err.Error())
+		return nil, false
+	}
+	if directorySID != "" {
+		group.SID = directorySID
+	}
+	group.Name = in.DisplayName
+
+	if create {
+		if _, err := h.store.CreateGroup(ctx, group); err != nil {
+			if errors.Is(err, models.ErrDuplicateGroup) {
+				writeSCIMError(w, http.StatusConflict, "uniqueness", "displayName or gidNumber is already taken")
+				return nil, false
+			}
+			writeSCIMError(w, http.StatusInternalServerError, "", "Failed to create group")
+			return nil, false
+		}
+	} else if err := h.store.UpdateGroup(ctx, group); err != nil {
+		writeSCIMError(w, http.StatusInternalServerError, "", "Failed to update group")
+		return nil, false
+	}
+
+	if directorySID != "" {
+		if err := h.bindSID(ctx, directorySID, *group.GID, true, group.Name); err != nil {
+			writeSIDError(w, err)
+			return nil, false
+		}
+	}
+
+	if members != nil {
+		for _, u := range group.Users {
+			if _, keep := members[u.ID]; keep {
+				delete(members, u.ID)
+				continue
+			}
+			if err := h.store.RemoveUserFromGroup(ctx, u.Username, group.Name); err != nil {
+				writeSCIMError(w, http.StatusInternalServerError, "", "Failed to remove member "+u.Username)
+				return nil, false
+			}
+		}
+		for _, username := range members {
+			if err := h.store.AddUserToGroup(ctx, username, group.Name); err != nil {
+				writeSCIMError(w, http.StatusInternalServerError, "", "Failed to add member "+username)
+				return nil, false
+			}
+		}
+	}
+	h.changed()
+
+	saved, err := h.store.GetGroupByID(ctx, group.ID)
+	if err != nil {
+		writeSCIMError(w, http.StatusInternalServerError, "", "Failed to reload group")
+		return nil, false
+	}
+	return saved, true
+}
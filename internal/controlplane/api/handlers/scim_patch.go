@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SCIMPatchRequest is a SCIM PATCH body (RFC 7644 §3.5.2).
+type SCIMPatchRequest struct {
+	Schemas    []string      `json:"schemas"`
+	Operations []SCIMPatchOp `json:"Operations"`
+}
+
+// SCIMPatchOp is one PATCH operation. Op is matched case-insensitively, as
+// Entra ID sends "Add", "Replace" and "Remove".
+type SCIMPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// scimPatchError is an invalid PATCH operation, reported with its SCIM
+// error type.
+type scimPatchError struct {
+	scimType string
+	detail   string
+}
+
+func (e *scimPatchError) Error() string { return e.detail }
+
+// scimTypeOf returns the SCIM error type of a PATCH error.
+func scimTypeOf(err error) string {
+	var pe *scimPatchError
+	if errors.As(err, &pe) {
+		return pe.scimType
+	}
+	return "invalidValue"
+}
+
+// patchKind validates and normalizes op.Op.
+func patchKind(op SCIMPatchOp) (string, error) {
+	kind := strings.ToLower(op.Op)
+	switch kind {
+	case "add", "replace", "remove":
+		return kind, nil
+	}
+	return "", &scimPatchError{"invalidSyntax", fmt.Sprintf("unsupported PATCH op %q", op.Op)}
+}
+
+// patchAttrs decodes the value of a path-less add or replace, an object of
+// attribute names to values.
+func patchAttrs(kind string, raw json.RawMessage) (map[string]json.RawMessage, error) {
+	if kind == "remove" {
+		return nil, &scimPatchError{"noTarget", "remove requires a path"}
+	}
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &attrs); err != nil {
+		return nil, &scimPatchError{"invalidValue", "a PATCH without a path needs an object value"}
+	}
+	return attrs, nil
+}
+
+// cutSchema strips a schema URN prefix from an attribute path, reporting
+// whether it was present. URNs are matched case-insensitively.
+func cutSchema(path, urn string) (string, bool) {
+	if len(path) > len(urn) && strings.EqualFold(path[:len(urn)], urn) && path[len(urn)] == ':' {
+		return path[len(urn)+1:], true
+	}
+	return path, false
+}
+
+// applyUserPatch applies op to the user representation u. Attributes DittoFS
+// does not store (phone numbers, addresses, titles...) are ignored rather than
+// refused, since IdPs send them with their default attribute mappings.
+func applyUserPatch(u *SCIMUser, op SCIMPatchOp) error {
+	kind, err := patchKind(op)
+	if err != nil {
+		return err
+	}
+	if op.Path != "" {
+		var value json.RawMessage
+		if kind != "remove" {
+			value = op.Value
+		}
+		return setUserAttr(u, op.Path, value)
+	}
+	attrs, err := patchAttrs(kind, op.Value)
+	if err != nil {
+		return err
+	}
+	for name, value := range attrs {
+		if strings.EqualFold(name, SCIMUserExtension) {
+			var ext map[string]json.RawMessage
+			if err := json.Unmarshal(value, &ext); err != nil {
+				return &scimPatchError{"invalidValue", "invalid " + SCIMUserExtension + " value"}
+			}
+			for extName, extValue := range ext {
+				if err := setUserAttr(u, SCIMUserExtension+":"+extName, extValue); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := setUserAttr(u, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setUserAttr sets the attribute at path to raw; a nil raw clears it.
+func setUserAttr(u *SCIMUser, path string, raw json.RawMessage) error {
+	path, _ = cutSchema(path, scimUserSchema)
+	if name, ok := cutSchema(path, SCIMUserExtension); ok {
+		if u.DittoFS == nil {
+			u.DittoFS = &SCIMUserExtensionAttrs{}
+		}
+		switch strings.ToLower(name) {
+		case "uidnumber":
+			return setUint32(&u.DittoFS.UIDNumber, path, raw)
+		case "gidnumber":
+			return setUint32(&u.DittoFS.GIDNumber, path, raw)
+		case "sid":
+			return setString(&u.DittoFS.SID, path, raw)
+		}
+		return nil
+	}
+
+	lower := strings.ToLower(path)
+	switch {
+	case lower == "username":
+		return setString(&u.UserName, path, raw)
+	case lower == "displayname":
+		return setString(&u.DisplayName, path, raw)
+	case lower == "password":
+		return setString(&u.Password, path, raw)
+	case lower == "active":
+		if raw == nil {
+			return nil
+		}
+		active, err := parseSCIMBool(raw)
+		if err != nil {
+			return &scimPatchError{"invalidValue", "active: " + err.Error()}
+		}
+		u.Active = &active
+	case lower == "name":
+		u.Name = nil
+		if raw != nil && json.Unmarshal(raw, &u.Name) != nil {
+			return &scimPatchError{"invalidValue", "invalid name value"}
+		}
+	case strings.HasPrefix(lower, "name."):
+		if u.Name == nil {
+			u.Name = &SCIMName{}
+		}
+		switch lower {
+		case "name.formatted":
+			return setString(&u.Name.Formatted, path, raw)
+		case "name.givenname":
+			return setString(&u.Name.GivenName, path, raw)
+		case "name.familyname":
+			return setString(&u.Name.FamilyName, path, raw)
+		}
+	case lower == "emails":
+		u.Emails = nil
+		if raw != nil && json.Unmarshal(raw, &u.Emails) != nil {
+			return &scimPatchError{"invalidValue", "invalid emails value"}
+		}
+	case strings.HasPrefix(lower, "emails[") && strings.HasSuffix(lower, "].value"):
+		// e.g. emails[type eq "work"].value: DittoFS keeps one address.
+		var email string
+		if err := setString(&email, path, raw); err != nil {
+			return err
+		}
+		u.Emails = nil
+		if email != "" {
+			u.Emails = []SCIMMultiValue{{Value: email, Type: "work", Primary: true}}
+		}
+	}
+	return nil
+}
+
+// applyGroupPatch applies op to the group representation g.
+func applyGroupPatch(g *SCIMGroup, op SCIMPatchOp) error {
+	kind, err := patchKind(op)
+	if err != nil {
+		return err
+	}
+	if op.Path == "" {
+		attrs, err := patchAttrs(kind, op.Value)
+		if err != nil {
+			return err
+		}
+		for name, value := range attrs {
+			switch {
+			case strings.EqualFold(name, "members"):
+				err = patchMembers(g, kind, "", value)
+			case strings.EqualFold(name, SCIMGroupExtension):
+				var ext map[string]json.RawMessage
+				if json.Unmarshal(value, &ext) != nil {
+					return &scimPatchError{"invalidValue", "invalid " + SCIMGroupExtension + " value"}
+				}
+				for extName, extValue := range ext {
+					if err = setGroupAttr(g, SCIMGroupExtension+":"+extName, extValue); err != nil {
+						break
+					}
+				}
+			default:
+				err = setGroupAttr(g, name, value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path, _ := cutSchema(op.Path, scimGroupSchema)
+	attr, filter, _ := strings.Cut(path, "[")
+	if strings.EqualFold(attr, "members") {
+		return patchMembers(g, kind, strings.TrimSuffix(filter, "]"), op.Value)
+	}
+	var value json.RawMessage
+	if kind != "remove" {
+		value = op.Value
+	}
+	return setGroupAttr(g, path, value)
+}
+
+// setGroupAttr sets a single-valued group attribute; a nil raw clears it.
+func setGroupAttr(g *SCIMGroup, path string, raw json.RawMessage) error {
+	path, _ = cutSchema(path, scimGroupSchema)
+	if name, ok := cutSchema(path, SCIMGroupExtension); ok {
+		if g.DittoFS == nil {
+			g.DittoFS = &SCIMGroupExtensionAttrs{}
+		}
+		switch strings.ToLower(name) {
+		case "gidnumber":
+			return setUint32(&g.DittoFS.GIDNumber, path, raw)
+		case "sid":
+			return setString(&g.DittoFS.SID, path, raw)
+		}
+		return nil
+	}
+	if strings.EqualFold(path, "displayName") {
+		return setString(&g.DisplayName, path, raw)
+	}
+	return nil
+}
+
+// patchMembers applies an add, replace or remove of members. filter is the
+// optional `value eq "id"` selector of a remove.
+func patchMembers(g *SCIMGroup, kind, filter string, raw json.RawMessage) error {
+	var members []SCIMMember
+	if len(raw) > 0 && string(raw) != "null" {
+		if json.Unmarshal(raw, &members) != nil {
+			// Some clients send a single member as an object.
+			var one SCIMMember
+			if json.Unmarshal(raw, &one) != nil {
+				return &scimPatchError{"invalidValue", "invalid members value"}
+			}
+			members = []SCIMMember{one}
+		}
+	}
+
+	switch kind {
+	case "replace":
+		g.Members = members
+	case "add":
+		for _, m := range members {
+			if !hasMember(g.Members, m.Value) {
+				g.Members = append(g.Members, m)
+			}
+		}
+	case "remove":
+		if filter != "" {
+			f, err := parseSCIMFilter(filter)
+			if err != nil || f.attr != "value" {
+				return &scimPatchError{"invalidFilter", "unsupported members filter " + strconv.Quote(filter)}
+			}
+			members = []SCIMMember{{Value: f.value}}
+		} else if members == nil {
+			g.Members = []SCIMMember{}
+			return nil
+		}
+		kept := []SCIMMember{}
+		for _, m := range g.Members {
+			if !hasMember(members, m.Value) {
+				kept = append(kept, m)
+			}
+		}
+		g.Members = kept
+	}
+	return nil
+}
+
+func hasMember(members []SCIMMember, id string) bool {
+	for _, m := range members {
+		if m.Value == id {
+			return true
+		}
+	}
+	return false
+}
+
+// setString sets *dst from a JSON string; a nil raw clears it.
+func setString(dst *string, path string, raw json.RawMessage) error {
+	*dst = ""
+	if raw == nil || string(raw) == "null" {
+		return nil
+	}
+	if json.Unmarshal(raw, dst) != nil {
+		return &scimPatchError{"invalidValue", path + ": expected a string"}
+	}
+	return nil
+}
+
+// setUint32 sets *dst from a JSON number or numeric string; a nil raw leaves
+// it unchanged, since ids are never cleared.
+func setUint32(dst **uint32, path string, raw json.RawMessage) error {
+	if raw == nil || string(raw) == "null" {
+		return nil
+	}
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		s = string(raw)
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return &scimPatchError{"invalidValue", path + ": expected a 32-bit unsigned integer"}
+	}
+	v := uint32(n)
+	*dst = &v
+	return nil
+}
+
+// parseSCIMBool parses a JSON boolean, or a "true"/"false" string in any
+// case, as Entra ID sends in PATCH values.
+func parseSCIMBool(raw json.RawMessage) (bool, error) {
+	var b bool
+	if json.Unmarshal(raw, &b) == nil {
+		return b, nil
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		if b, err := strconv.ParseBool(strings.ToLower(s)); err == nil {
+			return b, nil
+		}
+	}
+	return false, fmt.Errorf("expected a boolean, got %s", raw)
+}
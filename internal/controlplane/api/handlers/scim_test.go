@@ -0,0 +1,225 @@
+//go:build integration
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/marmos91/dittofs/pkg/auth/sid"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+)
+
+func setupSCIMTest(t *testing.T) (*store.GORMStore, http.Handler, *int) {
+	t.Helper()
+
+	cpStore, err := store.New(&store.Config{
+		Type:   "sqlite",
+		SQLite: store.SQLiteConfig{Path: ":memory:"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if _, err := cpStore.EnsureDefaultGroups(context.Background()); err != nil {
+		t.Fatalf("EnsureDefaultGroups: %v", err)
+	}
+
+	mapper := sid.NewSIDMapper(1, 2, 3)
+	changes := 0
+	h := NewSCIMHandler(cpStore, func() *sid.SIDMapper { return mapper },
+		SCIMSettings{UIDBase: 100000, GIDBase: 200000}, func() { changes++ })
+
+	r := chi.NewRouter()
+	r.Get("/scim/v2/Users", h.ListUsers)
+	r.Post("/scim/v2/Users", h.CreateUser)
+	r.Get("/scim/v2/Users/{id}", h.GetUser)
+	r.Put("/scim/v2/Users/{id}", h.ReplaceUser)
+	r.Patch("/scim/v2/Users/{id}", h.PatchUser)
+	r.Delete("/scim/v2/Users/{id}", h.DeleteUser)
+	r.Get("/scim/v2/Groups", h.ListGroups)
+	r.Post("/scim/v2/Groups", h.CreateGroup)
+	r.Get("/scim/v2/Groups/{id}", h.GetGroup)
+	r.Patch("/scim/v2/Groups/{id}", h.PatchGroup)
+	r.Delete("/scim/v2/Groups/{id}", h.DeleteGroup)
+	return cpStore, r, &changes
+}
+
+func scimDo(t *testing.T, h http.Handler, method, path, body string, wantStatus int, out any) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", scimContentType)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != wantStatus {
+		t.Fatalf("%s %s status = %d, want %d, body = %s", method, path, w.Code, wantStatus, w.Body.String())
+	}
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("decode %s %s: %v", method, path, err)
+		}
+	}
+}
+
+func TestSCIM_UserLifecycle(t *testing.T) {
+	cpStore, h, changes := setupSCIMTest(t)
+	ctx := context.Background()
+
+	var created SCIMUser
+	scimDo(t, h, http.MethodPost, "/scim/v2/Users", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "alice",
+		"name": {"givenName": "Alice", "familyName": "Liddell"},
+		"emails": [{"value": "alice@example.com", "type": "work", "primary": true}],
+		"active": true,
+		"urn:ietf:params:scim:schemas:extension:dittofs:2.0:User": {"sid": "S-1-5-21-10-20-30-1107"}
+	}`, http.StatusCreated, &created)
+
+	if created.ID == "" || created.UserName != "alice" || created.DisplayName != "Alice Liddell" {
+		t.Fatalf("created = %+v", created)
+	}
+	if created.DittoFS == nil || created.DittoFS.UIDNumber == nil || *created.DittoFS.UIDNumber != 100000 {
+		t.Fatalf("uidNumber = %+v, want 100000", created.DittoFS)
+	}
+	m, err := cpStore.GetSIDMapping(ctx, "S-1-5-21-10-20-30-1107")
+	if err != nil {
+		t.Fatalf("SID not bound: %v", err)
+	}
+	if m.UnixID != 100000 || m.IsGroup {
+		t.Errorf("SID mapping = %+v, want user 100000", m)
+	}
+
+	// The next user gets the next UID, and its SID is derived from it.
+	var bob SCIMUser
+	scimDo(t, h, http.MethodPost, "/scim/v2/Users", `{"userName": "bob"}`, http.StatusCreated, &bob)
+	if *bob.DittoFS.UIDNumber != 100001 {
+		t.Errorf("bob uidNumber = %d, want 100001", *bob.DittoFS.UIDNumber)
+	}
+	if want := sid.FormatSID(sid.NewSIDMapper(1, 2, 3).UserSID(100001)); bob.DittoFS.SID != want {
+		t.Errorf("bob sid = %q, want %q", bob.DittoFS.SID, want)
+	}
+
+	// Okta and Entra ID look users up by userName before creating them.
+	var list SCIMListResponse
+	scimDo(t, h, http.MethodGet, `/scim/v2/Users?filter=userName+eq+%22alice%22`, "", http.StatusOK, &list)
+	if list.TotalResults != 1 {
+		t.Fatalf("filter totalResults = %d, want 1", list.TotalResults)
+	}
+	scimDo(t, h, http.MethodPost, "/scim/v2/Users", `{"userName": "alice"}`, http.StatusConflict, nil)
+
+	// Entra ID disables users with a string boolean.
+	var patched SCIMUser
+	scimDo(t, h, http.MethodPatch, "/scim/v2/Users/"+created.ID, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "Replace", "path": "active", "value": "False"}]
+	}`, http.StatusOK, &patched)
+	if patched.Active == nil || *patched.Active {
+		t.Errorf("active after PATCH = %v, want false", patched.Active)
+	}
+	user, err := cpStore.GetUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if user.Enabled {
+		t.Error("user still enabled after PATCH active=False")
+	}
+	if user.SID != "S-1-5-21-10-20-30-1107" || user.Email != "alice@example.com" {
+		t.Errorf("PATCH lost attributes: sid=%q email=%q", user.SID, user.Email)
+	}
+
+	before := *changes
+	scimDo(t, h, http.MethodDelete, "/scim/v2/Users/"+created.ID, "", http.StatusNoContent, nil)
+	if *changes == before {
+		t.Error("delete did not notify the identity caches")
+	}
+	scimDo(t, h, http.MethodGet, "/scim/v2/Users/"+created.ID, "", http.StatusNotFound, nil)
+}
+
+func TestSCIM_GroupMembership(t *testing.T) {
+	cpStore, h, _ := setupSCIMTest(t)
+	ctx := context.Background()
+
+	var alice, bob SCIMUser
+	scimDo(t, h, http.MethodPost, "/scim/v2/Users", `{"userName": "alice"}`, http.StatusCreated, &alice)
+	scimDo(t, h, http.MethodPost, "/scim/v2/Users", `{"userName": "bob"}`, http.StatusCreated, &bob)
+
+	var group SCIMGroup
+	scimDo(t, h, http.MethodPost, "/scim/v2/Groups",
+		`{"displayName": "engineers", "members": [{"value": "`+alice.ID+`"}]}`, http.StatusCreated, &group)
+	if group.DittoFS == nil || group.DittoFS.GIDNumber == nil || *group.DittoFS.GIDNumber != 200000 {
+		t.Fatalf("gidNumber = %+v, want 200000", group.DittoFS)
+	}
+	if len(group.Members) != 1 || group.Members[0].Value != alice.ID {
+		t.Fatalf("members = %+v, want alice", group.Members)
+	}
+
+	scimDo(t, h, http.MethodPatch, "/scim/v2/Groups/"+group.ID, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "Add", "path": "members", "value": [{"value": "`+bob.ID+`"}]}]
+	}`, http.StatusNoContent, nil)
+	scimDo(t, h, http.MethodPatch, "/scim/v2/Groups/"+group.ID, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "remove", "path": "members[value eq \"`+alice.ID+`\"]"}]
+	}`, http.StatusNoContent, nil)
+
+	g, err := cpStore.GetGroup(ctx, "engineers")
+	if err != nil {
+		t.Fatalf("GetGroup: %v", err)
+	}
+	if len(g.Users) != 1 || g.Users[0].Username != "bob" {
+		t.Errorf("members after PATCH = %+v, want only bob", g.Users)
+	}
+
+	// Unknown members are refused and system groups are not SCIM resources.
+	scimDo(t, h, http.MethodPost, "/scim/v2/Groups",
+		`{"displayName": "ops", "members": [{"value": "nope"}]}`, http.StatusBadRequest, nil)
+	scimDo(t, h, http.MethodPost, "/scim/v2/Groups", `{"displayName": "admins"}`, http.StatusConflict, nil)
+	var list SCIMListResponse
+	scimDo(t, h, http.MethodGet, "/scim/v2/Groups", "", http.StatusOK, &list)
+	if list.TotalResults != 1 {
+		t.Errorf("groups listed = %d, want 1 (system groups hidden)", list.TotalResults)
+	}
+
+	scimDo(t, h, http.MethodDelete, "/scim/v2/Groups/"+group.ID, "", http.StatusNoContent, nil)
+	if _, err := cpStore.GetGroup(ctx, "engineers"); err == nil {
+		t.Error("group still exists after DELETE")
+	}
+}
+
+func TestSCIM_RejectsReservedIDs(t *testing.T) {
+	_, h, _ := setupSCIMTest(t)
+
+	for _, ext := range []string{`{"uidNumber": 0}`, `{"gidNumber": 0}`, `{"uidNumber": 1000}`, `{"gidNumber": 199999}`} {
+		var scimErr struct {
+			ScimType string `json:"scimType"`
+		}
+		scimDo(t, h, http.MethodPost, "/scim/v2/Users", `{
+			"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+			"userName": "mallory",
+			"urn:ietf:params:scim:schemas:extension:dittofs:2.0:User": `+ext+`
+		}`, http.StatusBadRequest, &scimErr)
+		if scimErr.ScimType != "invalidValue" {
+			t.Errorf("%s: scimType = %q, want invalidValue", ext, scimErr.ScimType)
+		}
+	}
+	scimDo(t, h, http.MethodPost, "/scim/v2/Groups", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"],
+		"displayName": "wheel",
+		"urn:ietf:params:scim:schemas:extension:dittofs:2.0:Group": {"gidNumber": 0}
+	}`, http.StatusBadRequest, nil)
+
+	var created SCIMUser
+	scimDo(t, h, http.MethodPost, "/scim/v2/Users", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "mallory",
+		"urn:ietf:params:scim:schemas:extension:dittofs:2.0:User": {"uidNumber": 150000, "gidNumber": 250000}
+	}`, http.StatusCreated, &created)
+	scimDo(t, h, http.MethodPatch, "/scim/v2/Users/"+created.ID, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "path": "urn:ietf:params:scim:schemas:extension:dittofs:2.0:User:uidNumber", "value": 0}]
+	}`, http.StatusBadRequest, nil)
+}
@@ -46,6 +46,20 @@ type ShareNFSConfigResponse struct {
 	MinKerberosLevel   string  `json:"min_kerberos_level"`
 	Netgroup           string  `json:"netgroup"`
 	DisableReaddirplus bool    `json:"disable_readdirplus"`
+
+	Exports []ShareNFSExport `json:"exports,omitempty"`
+}
+
+// ShareNFSExport is a subtree export entry: export rules for a directory
+// inside the share. Path is relative to the share root; Netgroup is a name
+// (empty = the share's allowlist) and an empty Squash inherits the share's.
+type ShareNFSExport struct {
+	Path         string  `json:"path"`
+	Netgroup     string  `json:"netgroup,omitempty"`
+	ReadOnly     bool    `json:"read_only"`
+	Squash       string  `json:"squash,omitempty"`
+	AnonymousUID *uint32 `json:"anonymous_uid,omitempty"`
+	AnonymousGID *uint32 `json:"anonymous_gid,omitempty"`
 }
 
 // PatchShareNFSConfigRequest is the request body for PATCH. All fields are
@@ -61,6 +75,10 @@ type PatchShareNFSConfigRequest struct {
 	MinKerberosLevel   *string `json:"min_kerberos_level,omitempty"`
 	Netgroup           *string `json:"netgroup,omitempty"`
 	DisableReaddirplus *bool   `json:"disable_readdirplus,omitempty"`
+
+	// Exports, when non-nil, replaces the share's subtree export entries; an
+	// empty list removes them all.
+	Exports *[]ShareNFSExport `json:"exports,omitempty"`
 }
 
 // Get handles GET /api/v1/shares/{name}/adapters/nfs/config.
@@ -120,6 +138,19 @@ func (h *ShareNFSConfigHandler) Patch(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if req.Exports != nil {
+		exports, msg, err := h.resolveExports(r, *req.Exports)
+		if err != nil {
+			InternalServerError(w, "Failed to resolve netgroup")
+			return
+		}
+		if msg != "" {
+			BadRequest(w, msg)
+			return
+		}
+		opts.Exports = exports
+	}
+
 	if req.Squash != nil {
 		// Validate rather than store-and-echo: ParseSquashMode silently falls back
 		// to the default (root_to_guest) for anything unrecognized, so `--squash root`
@@ -181,6 +212,13 @@ func (h *ShareNFSConfigHandler) Patch(w http.ResponseWriter, r *http.Request) {
 					"share", share.Name, "squash", opts.Squash, "error", err)
 			}
 		}
+		if req.Exports != nil {
+			live := runtime.ResolveSubtreeExports(r.Context(), h.store, share.Name, opts.Exports)
+			if err := h.runtime.SetShareExports(share.Name, live); err != nil {
+				logger.Warn("NFS config persisted but failed to update runtime subtree exports",
+					"share", share.Name, "error", err)
+			}
+		}
 		h.runtime.InvalidateAuthCache()
 	}
 
@@ -237,20 +275,87 @@ func (h *ShareNFSConfigHandler) optionsToResponse(w http.ResponseWriter, r *http
 		MinKerberosLevel:   opts.MinKerberosLevel,
 		DisableReaddirplus: opts.DisableReaddirplus,
 	}
-	if opts.NetgroupID != nil && *opts.NetgroupID != "" {
-		ng, err := h.store.GetNetgroupByID(r.Context(), *opts.NetgroupID)
-		if err != nil {
-			if errors.Is(err, models.ErrNetgroupNotFound) {
-				// Dangling reference (netgroup deleted out from under the
-				// config) — report empty rather than failing the read.
-				logger.Warn("Share NFS config references unknown netgroup",
-					"netgroup_id", *opts.NetgroupID)
-				return resp, true
+	for _, e := range opts.Exports {
+		exp := ShareNFSExport{
+			Path:         e.Path,
+			ReadOnly:     e.ReadOnly,
+			Squash:       e.Squash,
+			AnonymousUID: e.AnonymousUID,
+			AnonymousGID: e.AnonymousGID,
+		}
+		if e.NetgroupID != nil && *e.NetgroupID != "" {
+			name, ok := h.netgroupName(w, r, *e.NetgroupID)
+			if !ok {
+				return resp, false
 			}
-			InternalServerError(w, "Failed to resolve netgroup")
+			exp.Netgroup = name
+		}
+		resp.Exports = append(resp.Exports, exp)
+	}
+	if opts.NetgroupID != nil && *opts.NetgroupID != "" {
+		name, ok := h.netgroupName(w, r, *opts.NetgroupID)
+		if !ok {
 			return resp, false
 		}
-		resp.Netgroup = ng.Name
+		resp.Netgroup = name
 	}
 	return resp, true
 }
+
+// netgroupName resolves a stored netgroup ID to its name for a response,
+// writing a 500 problem and returning ok=false on a store failure.
+func (h *ShareNFSConfigHandler) netgroupName(w http.ResponseWriter, r *http.Request, id string) (string, bool) {
+	ng, err := h.store.GetNetgroupByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, models.ErrNetgroupNotFound) {
+			// Dangling reference (netgroup deleted out from under the
+			// config) — report empty rather than failing the read.
+			logger.Warn("Share NFS config references unknown netgroup",
+				"netgroup_id", id)
+			return "", true
+		}
+		InternalServerError(w, "Failed to resolve netgroup")
+		return "", false
+	}
+	return ng.Name, true
+}
+
+// resolveExports validates requested subtree export entries and converts
+// them to their stored form, resolving netgroup names to IDs. A validation
+// failure is returned as a client-facing message; err is a store failure.
+func (h *ShareNFSConfigHandler) resolveExports(r *http.Request, req []ShareNFSExport) ([]models.NFSSubtreeExport, string, error) {
+	out := make([]models.NFSSubtreeExport, 0, len(req))
+	seen := make(map[string]bool, len(req))
+	for _, e := range req {
+		p, err := models.CleanExportPath(e.Path)
+		if err != nil {
+			return nil, "Invalid export path: " + e.Path + " (want a directory below the share root, e.g. /projects/alpha)", nil
+		}
+		if seen[p] {
+			return nil, "Duplicate export path: " + p, nil
+		}
+		seen[p] = true
+		if e.Squash != "" && !models.SquashMode(e.Squash).IsValid() {
+			return nil, "Invalid squash for export " + p + ": " + e.Squash + " (want none|root_to_admin|root_to_guest|all_to_admin|all_to_guest)", nil
+		}
+		exp := models.NFSSubtreeExport{
+			Path:         p,
+			ReadOnly:     e.ReadOnly,
+			Squash:       e.Squash,
+			AnonymousUID: e.AnonymousUID,
+			AnonymousGID: e.AnonymousGID,
+		}
+		if e.Netgroup != "" {
+			id, err := h.store.GetNetgroupIDByName(r.Context(), e.Netgroup)
+			if err != nil {
+				if errors.Is(err, models.ErrNetgroupNotFound) {
+					return nil, "Netgroup not found: " + e.Netgroup, nil
+				}
+				return nil, "", err
+			}
+			exp.NetgroupID = &id
+		}
+		out = append(out, exp)
+	}
+	return out, "", nil
+}
@@ -240,3 +240,78 @@ func TestShareNFSConfig_PatchRejectsInvalidSquash(t *testing.T) {
 		}
 	}
 }
+
+func TestShareNFSConfig_PatchExports(t *testing.T) {
+	cpStore, handler, shareID := setupShareNFSConfigTest(t)
+	ctx := context.Background()
+
+	if _, err := cpStore.CreateNetgroup(ctx, &models.Netgroup{Name: "team-alpha"}); err != nil {
+		t.Fatalf("CreateNetgroup: %v", err)
+	}
+
+	w := doRequest(t, handler.Patch, http.MethodPatch,
+		`{"exports":[{"path":"projects/alpha/","netgroup":"team-alpha","squash":"all_to_guest"},{"path":"/archive","read_only":true}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Patch() status = %d, want 200, body = %s", w.Code, w.Body.String())
+	}
+
+	var resp ShareNFSConfigResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(resp.Exports) != 2 {
+		t.Fatalf("Exports = %+v, want 2 entries", resp.Exports)
+	}
+	if got := resp.Exports[0]; got.Path != "/projects/alpha" || got.Netgroup != "team-alpha" || got.Squash != "all_to_guest" {
+		t.Errorf("Exports[0] = %+v, want cleaned path with netgroup team-alpha", got)
+	}
+	if !resp.Exports[1].ReadOnly {
+		t.Errorf("Exports[1].ReadOnly = false, want true")
+	}
+
+	// The netgroup is stored by ID and the entry pins it against deletion.
+	cfg, err := cpStore.GetShareAdapterConfig(ctx, shareID, "nfs")
+	if err != nil || cfg == nil {
+		t.Fatalf("GetShareAdapterConfig: cfg=%v err=%v", cfg, err)
+	}
+	var opts models.NFSExportOptions
+	if err := cfg.ParseConfig(&opts); err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	if len(opts.Exports) != 2 || opts.Exports[0].NetgroupID == nil {
+		t.Fatalf("persisted Exports = %+v, want 2 entries with a netgroup ID", opts.Exports)
+	}
+	if err := cpStore.DeleteNetgroup(ctx, "team-alpha"); err != models.ErrNetgroupInUse {
+		t.Errorf("DeleteNetgroup() = %v, want ErrNetgroupInUse", err)
+	}
+
+	// An empty list removes every entry.
+	w = doRequest(t, handler.Patch, http.MethodPatch, `{"exports":[]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Patch(clear) status = %d, want 200, body = %s", w.Code, w.Body.String())
+	}
+	resp = ShareNFSConfigResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(resp.Exports) != 0 {
+		t.Errorf("Exports = %+v, want none after clear", resp.Exports)
+	}
+}
+
+func TestShareNFSConfig_PatchRejectsInvalidExports(t *testing.T) {
+	_, handler, _ := setupShareNFSConfigTest(t)
+
+	for _, body := range []string{
+		`{"exports":[{"path":"/"}]}`,
+		`{"exports":[{"path":"../etc"}]}`,
+		`{"exports":[{"path":"/a"},{"path":"a/"}]}`,
+		`{"exports":[{"path":"/a","squash":"root"}]}`,
+		`{"exports":[{"path":"/a","netgroup":"does-not-exist"}]}`,
+	} {
+		w := doRequest(t, handler.Patch, http.MethodPatch, body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Patch(%s) status = %d, want 400, body = %s", body, w.Code, w.Body.String())
+		}
+	}
+}
@@ -160,6 +160,16 @@ type CreateShareRequest struct {
 	TrashRestrictToAdmin *bool    `json:"trash_restrict_to_admin,omitempty"`
 	TrashMaxBytes        *int64   `json:"trash_max_bytes,omitempty"`
 	TrashExcludePatterns []string `json:"trash_exclude_patterns,omitempty"`
+	// Bandwidth caps the share's remote upload/download rates, optionally
+	// by time of day. nil = unlimited.
+	Bandwidth *block.BandwidthPolicy `json:"bandwidth,omitempty"`
+	// WarmPolicy re-warms the share's local tier automatically. nil = none.
+	WarmPolicy *block.WarmPolicy `json:"warm_policy,omitempty"`
+	// File-access audit trail. FileAuditOperations names the audited
+	// operations (open, close, read, write, create, delete, rename, set_acl,
+	// set_owner); empty = the default set.
+	FileAuditEnabled    *bool    `json:"file_audit_enabled,omitempty"`
+	FileAuditOperations []string `json:"file_audit_operations,omitempty"`
 }
 
 // UpdateShareRequest is the request body for PUT /api/v1/shares/{name}.
@@ -205,6 +215,16 @@ type UpdateShareRequest struct {
 	TrashRestrictToAdmin *bool    `json:"trash_restrict_to_admin,omitempty"`
 	TrashMaxBytes        *int64   `json:"trash_max_bytes,omitempty"`
 	TrashExcludePatterns []string `json:"trash_exclude_patterns,omitempty"`
+	// Bandwidth replaces the share's bandwidth policy. nil = no change; an
+	// empty object removes every cap. Applied live by the settings watcher.
+	Bandwidth *block.BandwidthPolicy `json:"bandwidth,omitempty"`
+	// WarmPolicy replaces the share's automatic pre-warm policy. nil = no
+	// change; an empty object disables it. Applied live.
+	WarmPolicy *block.WarmPolicy `json:"warm_policy,omitempty"`
+	// File-access audit trail. nil = no change; an empty operations list
+	// restores the default set. Applied live.
+	FileAuditEnabled    *bool     `json:"file_audit_enabled,omitempty"`
+	FileAuditOperations *[]string `json:"file_audit_operations,omitempty"`
 }
 
 // ShareResponse is the response body for share endpoints.
@@ -255,13 +275,23 @@ type ShareResponse struct {
 	// Per-share recycle-bin policy (#190). No omitempty: these are
 	// operator-meaningful state that consumers (dfsctl share show) render
 	// explicitly.
-	TrashEnabled         bool      `json:"trash_enabled"`
-	TrashRetentionDays   int       `json:"trash_retention_days"`
-	TrashRestrictToAdmin bool      `json:"trash_restrict_to_admin"`
-	TrashMaxBytes        int64     `json:"trash_max_bytes"`
-	TrashExcludePatterns []string  `json:"trash_exclude_patterns,omitempty"`
-	CreatedAt            time.Time `json:"created_at"`
-	UpdatedAt            time.Time `json:"updated_at"`
+	TrashEnabled         bool     `json:"trash_enabled"`
+	TrashRetentionDays   int      `json:"trash_retention_days"`
+	TrashRestrictToAdmin bool     `json:"trash_restrict_to_admin"`
+	TrashMaxBytes        int64    `json:"trash_max_bytes"`
+	TrashExcludePatterns []string `json:"trash_exclude_patterns,omitempty"`
+	// Bandwidth is the share's configured bandwidth policy; omitted when
+	// unlimited.
+	Bandwidth *block.BandwidthPolicy `json:"bandwidth,omitempty"`
+	// WarmPolicy is the share's automatic pre-warm policy; omitted when
+	// none is set.
+	WarmPolicy *block.WarmPolicy `json:"warm_policy,omitempty"`
+	// File-access audit trail; FileAuditOperations is omitted when the
+	// default set applies.
+	FileAuditEnabled    bool      `json:"file_audit_enabled"`
+	FileAuditOperations []string  `json:"file_audit_operations,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
 
 	// Status is the worst-of health report derived from the share's
 	// metadata store and block store engine. Non-omitempty so
@@ -530,11 +560,39 @@ func (h *ShareHandler) Create(w http.ResponseWriter, r *http.Request) {
 		share.OwnerUID = &uid
 		share.OwnerGID = &gid
 	}
+	if req.Bandwidth != nil {
+		if err := req.Bandwidth.Validate(); err != nil {
+			BadRequest(w, "Invalid bandwidth: "+err.Error())
+			return
+		}
+		if err := share.SetBandwidthPolicy(*req.Bandwidth); err != nil {
+			InternalServerError(w, "Failed to encode bandwidth policy")
+			return
+		}
+	}
+	if req.WarmPolicy != nil {
+		if err := req.WarmPolicy.Validate(); err != nil {
+			BadRequest(w, "Invalid warm_policy: "+err.Error())
+			return
+		}
+		if err := share.SetWarmPolicy(*req.WarmPolicy); err != nil {
+			InternalServerError(w, "Failed to encode warm policy")
+			return
+		}
+	}
 	if err := metadata.ValidateExcludePatterns(req.TrashExcludePatterns); err != nil {
 		BadRequest(w, err.Error())
 		return
 	}
 	share.SetTrashExcludePatterns(req.TrashExcludePatterns)
+	if req.FileAuditEnabled != nil {
+		share.FileAuditEnabled = *req.FileAuditEnabled
+	}
+	if err := metadata.ValidateFileAuditOps(req.FileAuditOperations); err != nil {
+		BadRequest(w, "Invalid file_audit_operations: "+err.Error())
+		return
+	}
+	share.SetFileAuditOperations(req.FileAuditOperations)
 
 	// Set blocked operations
 	if req.BlockedOperations != nil {
@@ -601,6 +659,9 @@ func (h *ShareHandler) Create(w http.ResponseWriter, r *http.Request) {
 			LocalStoreSize:                   localStoreSize,
 			ReadBufferSize:                   readBufferSize,
 			QuotaBytes:                       quotaBytes,
+			Bandwidth:                        share.GetBandwidthPolicy(),
+			WarmPolicy:                       share.GetWarmPolicy(),
+			FileAudit:                        share.GetFileAuditConfig(),
 			LocalBlockStoreID:                localBlockStore.ID,
 			RetentionPolicy:                  retPolicy,
 			RetentionTTL:                     retTTL,
@@ -805,6 +866,16 @@ func (h *ShareHandler) Update(w http.ResponseWriter, r *http.Request) {
 		}
 		share.SetTrashExcludePatterns(req.TrashExcludePatterns)
 	}
+	if req.FileAuditEnabled != nil {
+		share.FileAuditEnabled = *req.FileAuditEnabled
+	}
+	if req.FileAuditOperations != nil {
+		if err := metadata.ValidateFileAuditOps(*req.FileAuditOperations); err != nil {
+			BadRequest(w, "Invalid file_audit_operations: "+err.Error())
+			return
+		}
+		share.SetFileAuditOperations(*req.FileAuditOperations)
+	}
 	if req.DefaultPermission != nil {
 		if *req.DefaultPermission != "" && !models.SharePermission(*req.DefaultPermission).IsValid() {
 			BadRequest(w, "Invalid default_permission: "+*req.DefaultPermission+" (want none|read|read-write|admin)")
@@ -901,6 +972,29 @@ func (h *ShareHandler) Update(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Bandwidth is persisted only; the settings watcher pushes it into the
+	// live syncer on its next poll.
+	if req.Bandwidth != nil {
+		if err := req.Bandwidth.Validate(); err != nil {
+			BadRequest(w, "Invalid bandwidth: "+err.Error())
+			return
+		}
+		if err := share.SetBandwidthPolicy(*req.Bandwidth); err != nil {
+			InternalServerError(w, "Failed to encode bandwidth policy")
+			return
+		}
+	}
+	if req.WarmPolicy != nil {
+		if err := req.WarmPolicy.Validate(); err != nil {
+			BadRequest(w, "Invalid warm_policy: "+err.Error())
+			return
+		}
+		if err := share.SetWarmPolicy(*req.WarmPolicy); err != nil {
+			InternalServerError(w, "Failed to encode warm policy")
+			return
+		}
+	}
+
 	share.UpdatedAt = time.Now()
 
 	if err := h.store.UpdateShare(r.Context(), share); err != nil {
@@ -943,6 +1037,16 @@ func (h *ShareHandler) Update(w http.ResponseWriter, r *http.Request) {
 		if req.QuotaBytes != nil {
 			h.runtime.UpdateShareQuota(share.Name, share.QuotaBytes)
 		}
+		if req.WarmPolicy != nil {
+			if err := h.runtime.SetShareWarmPolicy(share.Name, share.GetWarmPolicy()); err != nil {
+				logger.Warn("Failed to apply warm policy in runtime", "share", share.Name, "error", err)
+			}
+		}
+		if req.FileAuditEnabled != nil || req.FileAuditOperations != nil {
+			if err := h.runtime.SetShareFileAudit(share.Name, share.GetFileAuditConfig()); err != nil {
+				logger.Warn("Failed to apply file audit policy in runtime", "share", share.Name, "error", err)
+			}
+		}
 		// Recycle-bin policy applies LIVE (#190): push the new settings into
 		// the runtime registry so per-delete decisions and the reaper see them
 		// immediately, then auto-empty the bin if trash was turned off.
@@ -1087,6 +1191,138 @@ func (h *ShareHandler) Enable(w http.ResponseWriter, r *http.Request) {
 	WriteJSONOK(w, h.shareToResponseWithUsage(ctx, share))
 }
 
+// MigrateShareMetadataRequest is the request body for
+// POST /api/v1/shares/{name}/migrate-metadata.
+type MigrateShareMetadataRequest struct {
+	// To is the name or ID of the destination metadata store.
+	To string `json:"to"`
+}
+
+// MigrateMetadata handles POST /api/v1/shares/{name}/migrate-metadata.
+// Moves the (disabled) share's metadata to another metadata store and flips
+// the share onto it. The move is offline: an enabled share is refused. Returns the runtime's MetadataMigrationResult.
+func (h *ShareHandler) MigrateMetadata(w http.ResponseWriter, r *http.Request) {
+	name := normalizeShareName(chi.URLParam(r, "name"))
+	if name == "/" {
+		BadRequest(w, "Share name is required")
+		return
+	}
+	if h.runtime == nil {
+		InternalServerError(w, "Runtime not available")
+		return
+	}
+	var body MigrateShareMetadataRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.To == "" {
+		BadRequest(w, "Target metadata store is required")
+		return
+	}
+
+	// The copy walks the whole share and can outlast the global request
+	// timeout; detach from it but still abort (and roll back) on disconnect.
+	ctx, cancel := detachFromRequest(r, 0)
+	defer cancel()
+
+	res, err := h.runtime.MigrateShareMetadata(ctx, name, body.To)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrShareNotFound):
+			NotFound(w, "Share not found")
+		case errors.Is(err, models.ErrStoreNotFound):
+			NotFound(w, "Metadata store not found: "+body.To)
+		case errors.Is(err, models.ErrMigrationSameStore):
+			BadRequest(w, "Share already uses metadata store "+body.To)
+		case errors.Is(err, models.ErrMigrationShareEnabled):
+			Conflict(w, "share is enabled; disable before migrating metadata")
+		case errors.Is(err, models.ErrMigrationTargetHasShare):
+			Conflict(w, "target metadata store already holds this share")
+		case errors.Is(err, models.ErrMigrationInProgress):
+			Conflict(w, "a restore or metadata migration is already in progress for this share")
+		default:
+			logger.Error("metadata migration error", "share", name, "to", body.To, "error", err)
+			InternalServerError(w, "Failed to migrate share metadata: "+err.Error())
+		}
+		return
+	}
+	WriteJSONOK(w, res)
+}
+
+// RehomeShareRequest is the request body for POST /api/v1/shares/{name}/rehome.
+type RehomeShareRequest struct {
+	// To is the name or ID of the destination remote block store.
+	To string `json:"to"`
+	// Mode is "copy", "repack", or empty to let the server pick.
+	Mode string `json:"mode,omitempty"`
+}
+
+// Rehome handles POST /api/v1/shares/{name}/rehome. It starts (or resumes) the
+// online move of the share's block data to another remote block store and
+// responds 202 with the job. Progress is polled via RehomeStatus.
+func (h *ShareHandler) Rehome(w http.ResponseWriter, r *http.Request) {
+	name := normalizeShareName(chi.URLParam(r, "name"))
+	if name == "/" {
+		BadRequest(w, "Share name is required")
+		return
+	}
+	if h.runtime == nil {
+		InternalServerError(w, "Runtime not available")
+		return
+	}
+	var body RehomeShareRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.To == "" {
+		BadRequest(w, "Target remote block store is required")
+		return
+	}
+
+	job, err := h.runtime.StartShareRehome(r.Context(), name, body.To, body.Mode)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrShareNotFound), errors.Is(err, shares.ErrShareNotFound):
+			NotFound(w, "Share not found")
+		case errors.Is(err, models.ErrStoreNotFound):
+			NotFound(w, "Remote block store not found: "+body.To)
+		case errors.Is(err, models.ErrRehomeSameRemote):
+			BadRequest(w, "Share already uses remote block store "+body.To)
+		case errors.Is(err, models.ErrRehomeNoRemote):
+			BadRequest(w, "Share has no remote block store")
+		case errors.Is(err, models.ErrRehomeModeUnsupported):
+			BadRequest(w, err.Error())
+		case errors.Is(err, models.ErrRehomeInProgress), errors.Is(err, models.ErrRehomeSharedLocators):
+			Conflict(w, err.Error())
+		default:
+			logger.Error("remote rehome error", "share", name, "to", body.To, "error", err)
+			InternalServerError(w, "Failed to start re-home: "+err.Error())
+		}
+		return
+	}
+	WriteJSON(w, http.StatusAccepted, job)
+}
+
+// RehomeStatus handles GET /api/v1/shares/{name}/rehome. It returns the
+// share's latest re-home job since the server started.
+func (h *ShareHandler) RehomeStatus(w http.ResponseWriter, r *http.Request) {
+	name := normalizeShareName(chi.URLParam(r, "name"))
+	if name == "/" {
+		BadRequest(w, "Share name is required")
+		return
+	}
+	if h.runtime == nil {
+		InternalServerError(w, "Runtime not available")
+		return
+	}
+	job, ok := h.runtime.GetShareRehome(name)
+	if !ok {
+		NotFound(w, "No re-home job for this share")
+		return
+	}
+	WriteJSONOK(w, job)
+}
+
 // SetUserPermission handles PUT /api/v1/shares/{name}/users/{username}.
 // Sets a user's permission for a share (admin only).
 func (h *ShareHandler) SetUserPermission(w http.ResponseWriter, r *http.Request) {
@@ -1582,6 +1818,14 @@ func shareToResponse(s *models.Share) ShareResponse {
 	if s.QuotaBytes > 0 {
 		quotaBytesStr = bytesize.ByteSize(s.QuotaBytes).String()
 	}
+	var bandwidth *block.BandwidthPolicy
+	if policy := s.GetBandwidthPolicy(); !policy.IsZero() {
+		bandwidth = &policy
+	}
+	var warmPolicy *block.WarmPolicy
+	if policy := s.GetWarmPolicy(); !policy.IsZero() {
+		warmPolicy = &policy
+	}
 	return ShareResponse{
 		ID:                               s.ID,
 		Name:                             s.Name,
@@ -1611,6 +1855,10 @@ func shareToResponse(s *models.Share) ShareResponse {
 		TrashRestrictToAdmin:             s.TrashRestrictToAdmin,
 		TrashMaxBytes:                    s.TrashMaxBytes,
 		TrashExcludePatterns:             s.GetTrashExcludePatterns(),
+		Bandwidth:                        bandwidth,
+		WarmPolicy:                       warmPolicy,
+		FileAuditEnabled:                 s.FileAuditEnabled,
+		FileAuditOperations:              s.GetFileAuditOperations(),
 		CreatedAt:                        s.CreatedAt,
 		UpdatedAt:                        s.UpdatedAt,
 	}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/marmos91/dittofs/internal/controlplane/api/auth"
+	"github.com/marmos91/dittofs/internal/controlplane/api/middleware"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+)
+
+// Machine-readable problem codes for login errors clients act on.
+const (
+	// ProblemCodeMFARequired asks the client to retry the login with a
+	// one-time code.
+	ProblemCodeMFARequired = "mfa_required"
+
+	// ProblemCodeAccountLocked reports a lockout after failed logins.
+	ProblemCodeAccountLocked = "account_locked"
+)
+
+// TOTPEnrollResponse is the response body for POST /api/v1/users/me/totp.
+type TOTPEnrollResponse struct {
+	// Secret is the base32 secret for manual entry.
+	Secret string `json:"secret"`
+	// URI is the otpauth:// URI, usually rendered as a QR code.
+	URI string `json:"uri"`
+}
+
+// TOTPCodeRequest is the request body for confirming or disabling TOTP.
+type TOTPCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// verifyTOTP checks code against the user's TOTP secret and consumes its time
+// step, so each code is accepted at most once.
+func verifyTOTP(ctx context.Context, s store.UserStore, user *models.User, code string) (bool, error) {
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return s.ConsumeTOTPStep(ctx, user.Username, step)
+}
+
+// selfForTOTP loads the calling user for the /users/me/totp endpoints,
+// writing the error response and returning nil when it cannot.
+func (h *UserHandler) selfForTOTP(w http.ResponseWriter, r *http.Request) *models.User {
+	claims := middleware.GetClaimsFromContext(r.Context())
+	if claims == nil {
+		Unauthorized(w, "Authentication required")
+		return nil
+	}
+	user, err := h.store.GetUser(r.Context(), claims.Username)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			Unauthorized(w, "User not found")
+			return nil
+		}
+		InternalServerError(w, "Failed to get user")
+		return nil
+	}
+	if user.ServiceAccount {
+		Forbidden(w, "Service accounts authenticate with API tokens and cannot enroll TOTP")
+		return nil
+	}
+	return user
+}
+
+// EnrollTOTP handles POST /api/v1/users/me/totp.
+// Generates a new TOTP secret for the current user. The secret is not
+// required at login until it is confirmed with a code (ConfirmTOTP), so an
+// abandoned enrollment cannot lock the user out.
+func (h *UserHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	user := h.selfForTOTP(w, r)
+	if user == nil {
+		return
+	}
+	if user.TOTPEnabled {
+		Conflict(w, "TOTP is already enabled; disable it before enrolling again")
+		return
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		InternalServerError(w, "Failed to generate TOTP secret")
+		return
+	}
+	if err := h.store.SetUserTOTP(r.Context(), user.Username, secret, false); err != nil {
+		InternalServerError(w, "Failed to store TOTP secret")
+		return
+	}
+
+	WriteJSONOK(w, TOTPEnrollResponse{
+		Secret: secret,
+		URI:    auth.TOTPURI(h.store.AccountPolicy().TOTPIssuer, user.Username, secret),
+	})
+}
+
+// ConfirmTOTP handles POST /api/v1/users/me/totp/confirm.
+// Verifies a code from the pending enrollment and turns TOTP on.
+func (h *UserHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	user := h.selfForTOTP(w, r)
+	if user == nil {
+		return
+	}
+	var req TOTPCodeRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if user.TOTPEnabled {
+		Conflict(w, "TOTP is already enabled")
+		return
+	}
+	if user.TOTPSecret == "" {
+		BadRequest(w, "No TOTP enrollment is pending; start one with POST /api/v1/users/me/totp")
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, req.Code, time.Now())
+	if !ok {
+		BadRequest(w, "Invalid one-time code")
+		return
+	}
+	if err := h.store.SetUserTOTP(r.Context(), user.Username, user.TOTPSecret, true); err != nil {
+		InternalServerError(w, "Failed to enable TOTP")
+		return
+	}
+	// The confirming code must not also work for a login.
+	if _, err := h.store.ConsumeTOTPStep(r.Context(), user.Username, step); err != nil {
+		InternalServerError(w, "Failed to enable TOTP")
+		return
+	}
+
+	WriteNoContent(w)
+}
+
+// DisableOwnTOTP handles DELETE /api/v1/users/me/totp.
+// Removes the current user's TOTP enrollment. A confirmed enrollment can only
+// be removed with a valid code; an admin can remove it with ResetTOTP.
+func (h *UserHandler) DisableOwnTOTP(w http.ResponseWriter, r *http.Request) {
+	user := h.selfForTOTP(w, r)
+	if user == nil {
+		return
+	}
+	if user.TOTPEnabled {
+		var req TOTPCodeRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		ok, err := verifyTOTP(r.Context(), h.store, user, req.Code)
+		if err != nil {
+			InternalServerError(w, "Failed to verify one-time code")
+			return
+		}
+		if !ok {
+			Unauthorized(w, "Invalid one-time code")
+			return
+		}
+	}
+
+	if err := h.store.SetUserTOTP(r.Context(), user.Username, "", false); err != nil {
+		InternalServerError(w, "Failed to disable TOTP")
+		return
+	}
+	WriteNoContent(w)
+}
+
+// ResetTOTP handles DELETE /api/v1/users/{username}/totp.
+// Removes a user's TOTP enrollment, e.g. after a lost device (admin only).
+func (h *UserHandler) ResetTOTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.targetUser(w, r, "reset TOTP for")
+	if !ok {
+		return
+	}
+	if err := h.store.SetUserTOTP(r.Context(), user.Username, "", false); err != nil {
+		InternalServerError(w, "Failed to reset TOTP")
+		return
+	}
+	WriteNoContent(w)
+}
+
+// Unlock handles POST /api/v1/users/{username}/unlock.
+// Clears a lockout after failed logins (admin only).
+func (h *UserHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.targetUser(w, r, "unlock")
+	if !ok {
+		return
+	}
+	if err := h.store.UnlockUser(r.Context(), user.Username); err != nil {
+		InternalServerError(w, "Failed to unlock user")
+		return
+	}
+	WriteNoContent(w)
+}
+
+// targetUser loads the {username} of an admin account-maintenance request.
+// Delegated admins may not act on admin users.
+func (h *UserHandler) targetUser(w http.ResponseWriter, r *http.Request, action string) (*models.User, bool) {
+	user, err := h.store.GetUser(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			NotFound(w, "User not found")
+			return nil, false
+		}
+		InternalServerError(w, "Failed to get user")
+		return nil, false
+	}
+	if user.Role == string(models.RoleAdmin) && isDelegated(r) {
+		Forbidden(w, "Only server admins can "+action+" an admin user")
+		return nil, false
+	}
+	return user, true
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"slices"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/marmos91/dittofs/internal/controlplane/api/auth"
@@ -19,6 +20,7 @@ type userStore interface {
 	store.GroupStore
 	store.PermissionStore
 	store.ShareStore
+	roleBindingLister
 }
 
 // UserHandler handles user management API endpoints.
@@ -87,6 +89,10 @@ func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.checkPasswordPolicy(w, r, "", req.Password) {
+		return
+	}
+
 	// Hash password and compute NT hash
 	passwordHash, ntHashHex, err := models.HashPasswordWithNT(req.Password)
 	if err != nil {
@@ -103,6 +109,16 @@ func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if role == models.RoleAdmin && isDelegated(r) {
+		Forbidden(w, "Only server admins can create admin users")
+		return
+	}
+	if !checkDelegatedIDs(w, r, req.UID, req.GID) {
+		return
+	}
+	if !checkDelegatedGroupChange(w, r, h.store, req.Groups...) {
+		return
+	}
 
 	enabled := true
 	if req.Enabled != nil {
@@ -221,6 +237,17 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if isDelegated(r) && (user.Role == string(models.RoleAdmin) || (req.Role != nil && *req.Role == string(models.RoleAdmin))) {
+		Forbidden(w, "Only server admins can modify admin users or grant the admin role")
+		return
+	}
+	if !checkDelegatedIDs(w, r, req.UID, req.GID) {
+		return
+	}
+	if req.Groups != nil && !checkDelegatedGroupChange(w, r, h.store, changedGroups(user, *req.Groups)...) {
+		return
+	}
+
 	// Apply updates
 	if req.Email != nil {
 		user.Email = *req.Email
@@ -310,6 +337,13 @@ func (h *UserHandler) Remove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if isDelegated(r) {
+		if user, err := h.store.GetUser(r.Context(), token); err == nil && user.Role == string(models.RoleAdmin) {
+			Forbidden(w, "Only server admins can delete admin users")
+			return
+		}
+	}
+
 	// The token may be a username or an ID. DeleteUser resolves it and refuses to
 	// delete the admin account by its canonical username, so the guard can't be
 	// bypassed by addressing the admin via its ID.
@@ -354,6 +388,14 @@ func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 		InternalServerError(w, "Failed to get user")
 		return
 	}
+	if user.Role == string(models.RoleAdmin) && isDelegated(r) {
+		Forbidden(w, "Only server admins can reset an admin user's password")
+		return
+	}
+
+	if !h.checkPasswordPolicy(w, r, username, req.NewPassword) {
+		return
+	}
 
 	// Hash password and compute NT hash
 	passwordHash, ntHashHex, err := models.HashPasswordWithNT(req.NewPassword)
@@ -423,6 +465,10 @@ func (h *UserHandler) ChangeOwnPassword(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	if !h.checkPasswordPolicy(w, r, claims.Username, req.NewPassword) {
+		return
+	}
+
 	// Hash password and compute NT hash
 	passwordHash, ntHashHex, err := models.HashPasswordWithNT(req.NewPassword)
 	if err != nil {
@@ -459,3 +505,54 @@ func (h *UserHandler) ChangeOwnPassword(w http.ResponseWriter, r *http.Request)
 		User:         userToResponse(user),
 	})
 }
+
+// checkPasswordPolicy verifies a new password for username (empty for a new
+// user) against the account policy, writing a 400 on a violation.
+func (h *UserHandler) checkPasswordPolicy(w http.ResponseWriter, r *http.Request, username, password string) bool {
+	err := h.store.CheckPasswordPolicy(r.Context(), username, password)
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, models.ErrPasswordPolicy) {
+		BadRequest(w, err.Error())
+	} else {
+		InternalServerError(w, "Failed to check password policy")
+	}
+	return false
+}
+
+// isDelegated reports whether the caller holds admin rights only through an
+// API-token scope or a custom role (see auth.Claims.Delegated).
+func isDelegated(r *http.Request) bool {
+	claims := middleware.GetClaimsFromContext(r.Context())
+	return claims != nil && claims.Delegated
+}
+
+// changedGroups returns the groups a replacement membership list adds the
+// user to or removes the user from.
+func changedGroups(user *models.User, groups []string) []string {
+	current := user.GetGroupNames()
+	var changed []string
+	for _, g := range groups {
+		if !slices.Contains(current, g) {
+			changed = append(changed, g)
+		}
+	}
+	for _, g := range current {
+		if !slices.Contains(groups, g) {
+			changed = append(changed, g)
+		}
+	}
+	return changed
+}
+
+// checkDelegatedIDs refuses a delegated caller assigning UID or GID 0, which
+// is root on NFS and SMB and so only server admins may hand out. It writes
+// the 403 and returns false when refused.
+func checkDelegatedIDs(w http.ResponseWriter, r *http.Request, uid, gid *uint32) bool {
+	if !isDelegated(r) || ((uid == nil || *uid != 0) && (gid == nil || *gid != 0)) {
+		return true
+	}
+	Forbidden(w, "Only server admins can assign UID or GID 0")
+	return false
+}
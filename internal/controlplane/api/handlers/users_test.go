@@ -661,6 +661,30 @@ func TestUserHandler_ResetPassword(t *testing.T) {
 	}
 }
 
+func TestUserHandler_ResetPassword_Policy(t *testing.T) {
+	cpStore, _, handler := setupUserTest(t)
+	ctx := context.Background()
+	cpStore.(*store.GORMStore).SetAccountPolicy(models.AccountPolicy{MinPasswordLength: 12, PasswordHistory: 1})
+
+	passwordHash, ntHash, _ := models.HashPasswordWithNT("current-password")
+	if _, err := cpStore.CreateUser(ctx, &models.User{Username: "policyuser", PasswordHash: passwordHash, NTHash: ntHash, Enabled: true, Role: "user"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	for _, pw := range []string{"too-short", "current-password"} {
+		body, _ := json.Marshal(ChangePasswordRequest{NewPassword: pw})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/policyuser/password", bytes.NewReader(body))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("username", "policyuser")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		handler.ResetPassword(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("password %q: status = %d, want 400, body = %s", pw, w.Code, w.Body.String())
+		}
+	}
+}
+
 func TestUserHandler_ChangeOwnPassword(t *testing.T) {
 	cpStore, jwtService, handler := setupUserTest(t)
 	ctx := context.Background()
@@ -1017,3 +1041,93 @@ func TestUserHandler_Update_WithSharePerms(t *testing.T) {
 		t.Errorf("share perms not persisted, got %v", dbPerms)
 	}
 }
+
+func TestUserHandler_DelegatedAdminCannotGrantAdmin(t *testing.T) {
+	cpStore, _, handler := setupUserTest(t)
+	ctx := context.Background()
+
+	passwordHash, ntHash, _ := models.HashPasswordWithNT("password")
+	user := &models.User{
+		ID:           uuid.New().String(),
+		Username:     "helpdeskuser",
+		PasswordHash: passwordHash,
+		NTHash:       ntHash,
+		Enabled:      true,
+		Role:         "user",
+		CreatedAt:    time.Now(),
+	}
+	if _, err := cpStore.CreateUser(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	// Claims as produced by ApplyCustomRoles for a users:write holder.
+	delegated := middleware.ContextWithClaims(ctx, &auth.Claims{Username: "lead", Role: "admin", Delegated: true})
+
+	body, _ := json.Marshal(CreateUserRequest{Username: "sneaky", Password: "password123", Role: "admin"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewReader(body)).WithContext(delegated)
+	w := httptest.NewRecorder()
+	handler.Create(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Create(admin) status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	newRole := "admin"
+	body, _ = json.Marshal(UpdateUserRequest{Role: &newRole})
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("username", "helpdeskuser")
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/users/helpdeskuser", bytes.NewReader(body)).
+		WithContext(context.WithValue(delegated, chi.RouteCtxKey, rctx))
+	w = httptest.NewRecorder()
+	handler.Update(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Update(role=admin) status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	// Non-admin changes remain allowed.
+	email := "helpdesk@example.com"
+	body, _ = json.Marshal(UpdateUserRequest{Email: &email})
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/users/helpdeskuser", bytes.NewReader(body)).
+		WithContext(context.WithValue(delegated, chi.RouteCtxKey, rctx))
+	w = httptest.NewRecorder()
+	handler.Update(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Update(email) status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestUserHandler_DelegatedAdminCannotAssignRootIDs(t *testing.T) {
+	_, _, handler := setupUserTest(t)
+
+	// Claims as produced by RequireTokenScope for a users:write API token.
+	delegated := middleware.ContextWithClaims(context.Background(),
+		&auth.Claims{Username: "scim", Role: "admin", TokenType: auth.TokenTypeAPI, Delegated: true})
+
+	zero, regular := uint32(0), uint32(5000)
+	create := func(req CreateUserRequest) int {
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewReader(body)).WithContext(delegated)
+		w := httptest.NewRecorder()
+		handler.Create(w, r)
+		return w.Code
+	}
+	if code := create(CreateUserRequest{Username: "rootish", Password: "password123", UID: &zero}); code != http.StatusForbidden {
+		t.Errorf("Create(uid=0) status = %d, want %d", code, http.StatusForbidden)
+	}
+	if code := create(CreateUserRequest{Username: "wheel", Password: "password123", UID: &regular, GID: &zero}); code != http.StatusForbidden {
+		t.Errorf("Create(gid=0) status = %d, want %d", code, http.StatusForbidden)
+	}
+	if code := create(CreateUserRequest{Username: "builder", Password: "password123", UID: &regular, GID: &regular}); code != http.StatusCreated {
+		t.Fatalf("Create(uid=5000) status = %d, want %d", code, http.StatusCreated)
+	}
+
+	body, _ := json.Marshal(UpdateUserRequest{UID: &zero})
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("username", "builder")
+	r := httptest.NewRequest(http.MethodPut, "/api/v1/users/builder", bytes.NewReader(body)).
+		WithContext(context.WithValue(delegated, chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+	handler.Update(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Update(uid=0) status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
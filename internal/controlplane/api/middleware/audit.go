@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/marmos91/dittofs/internal/controlplane/audit"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+// maxAuditBody caps the request and response bodies kept in an audit event.
+// Larger bodies are left out of the event rather than truncated into
+// invalid JSON.
+const maxAuditBody = 64 << 10
+
+const auditSnapshotKey contextKey = "audit_snapshot"
+
+// AuditRecorder receives the audit events built by Audit.
+type AuditRecorder interface {
+	Record(ctx context.Context, event *models.AuditEvent)
+}
+
+// IsAuditSnapshot reports whether the request is an internal GET issued by
+// Audit to capture a resource's state, so request logging can treat it as
+// noise.
+func IsAuditSnapshot(ctx context.Context) bool {
+	v, _ := ctx.Value(auditSnapshotKey).(bool)
+	return v
+}
+
+// Audit records every mutating request (POST, PUT, PATCH, DELETE) as a
+// models.AuditEvent, including refused and failed ones. Must be used after
+// the authentication middleware so the actor is known.
+//
+// For PUT, PATCH and DELETE the target's state is captured before the call
+// by replaying the request as a GET through root (the top-level router); for
+// PUT and PATCH it is captured again afterwards. A POST records its response
+// body as the after state. Every body passes through redact, so secrets
+// never reach the audit log.
+func Audit(rec AuditRecorder, redact func(string) string, root http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isMutation(r.Method) || IsAuditSnapshot(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			event := &models.AuditEvent{
+				Time:       time.Now(),
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Resource:   auditResource(r.URL.Path),
+				RequestID:  chimw.GetReqID(r.Context()),
+				RemoteAddr: r.RemoteAddr,
+			}
+			if claims := GetClaimsFromContext(r.Context()); claims != nil {
+				event.Actor = claims.Username
+				event.ActorID = claims.UserID
+				event.Delegated = claims.Delegated
+				event.AuthMethod = "jwt"
+				if claims.IsAPIToken() {
+					event.AuthMethod = "api_token"
+				}
+			}
+			event.Request = redactJSON(peekBody(r), redact)
+
+			snapshot := r.Method != http.MethodPost
+			if snapshot {
+				event.Before = redactJSON(auditSnapshot(root, r), redact)
+			}
+
+			var body limitedBuffer
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&body)
+			next.ServeHTTP(ww, r)
+
+			event.Status = ww.Status()
+			if event.Status == 0 {
+				event.Status = http.StatusOK
+			}
+			if event.Status < 300 {
+				switch {
+				case r.Method == http.MethodPost && !body.overflow:
+					event.After = redactJSON(body.String(), redact)
+				case snapshot && r.Method != http.MethodDelete && event.Before != "":
+					event.After = redactJSON(auditSnapshot(root, r), redact)
+				}
+				if event.Before != "" || event.After != "" {
+					event.Changes = audit.Diff(event.Before, event.After)
+				}
+			}
+
+			rec.Record(r.Context(), event)
+		})
+	}
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// auditResource returns the first path segment below /api/v1, or "scim"
+// for the SCIM provisioning endpoints.
+func auditResource(path string) string {
+	if strings.HasPrefix(path, "/scim/") {
+		return "scim"
+	}
+	rest := strings.TrimPrefix(path, "/api/v1/")
+	resource, _, _ := strings.Cut(rest, "/")
+	return resource
+}
+
+// peekBody returns the request body (up to maxAuditBody) and restores it
+// for the handler. An oversized body is returned as "".
+func peekBody(r *http.Request) string {
+	if r.Body == nil || r.Body == http.NoBody {
+		return ""
+	}
+	buf, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+	if err != nil || len(buf) > maxAuditBody {
+		return ""
+	}
+	return string(buf)
+}
+
+// auditSnapshot replays r as a GET through root and returns the response
+// body, or "" when the target has no readable JSON representation.
+func auditSnapshot(root http.Handler, r *http.Request) string {
+	// Clear the chi routing context so root routes the request from scratch.
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, nil)
+	ctx = context.WithValue(ctx, auditSnapshotKey, true)
+
+	req := r.Clone(ctx)
+	req.Method = http.MethodGet
+	req.Body = http.NoBody
+	req.ContentLength = 0
+	req.Header.Del("Content-Type")
+
+	sw := &snapshotWriter{header: make(http.Header)}
+	root.ServeHTTP(sw, req)
+	if sw.status != http.StatusOK || sw.body.overflow {
+		return ""
+	}
+	return sw.body.String()
+}
+
+// redactJSON returns blob with secrets redacted, or "" when blob is not
+// valid JSON.
+func redactJSON(blob string, redact func(string) string) string {
+	blob = strings.TrimSpace(blob)
+	if blob == "" || !json.Valid([]byte(blob)) {
+		return ""
+	}
+	return redact(blob)
+}
+
+// limitedBuffer keeps the first maxAuditBody bytes written to it and notes
+// whether more were discarded. Writes never fail.
+type limitedBuffer struct {
+	bytes.Buffer
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxAuditBody - b.Len(); len(p) > room {
+		b.overflow = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// snapshotWriter is the in-memory ResponseWriter for auditSnapshot.
+type snapshotWriter struct {
+	header http.Header
+	status int
+	body   limitedBuffer
+}
+
+func (w *snapshotWriter) Header() http.Header { return w.header }
+
+func (w *snapshotWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *snapshotWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
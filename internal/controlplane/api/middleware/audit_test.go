@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/marmos91/dittofs/internal/controlplane/api/auth"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+type fakeAuditRecorder struct {
+	mu     sync.Mutex
+	events []*models.AuditEvent
+}
+
+func (f *fakeAuditRecorder) Record(_ context.Context, event *models.AuditEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, event)
+}
+
+// newAuditTestRouter serves a single "thing" at /api/v1/things/x whose
+// password field must never reach the audit log.
+func newAuditTestRouter(rec AuditRecorder) http.Handler {
+	redact := func(s string) string { return strings.ReplaceAll(s, "hunter2", "********") }
+	thing := map[string]any{"name": "x", "size": 1, "password": "hunter2"}
+
+	root := chi.NewRouter()
+	root.Route("/api/v1", func(r chi.Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				claims := &auth.Claims{UserID: "u1", Username: "alice", Role: "admin", TokenType: auth.TokenTypeAccess}
+				next.ServeHTTP(w, req.WithContext(ContextWithClaims(req.Context(), claims)))
+			})
+		})
+		r.Use(Audit(rec, redact, root))
+
+		r.Get("/things/x", func(w http.ResponseWriter, _ *http.Request) {
+			_ = json.NewEncoder(w).Encode(thing)
+		})
+		r.Put("/things/x", func(w http.ResponseWriter, req *http.Request) {
+			var patch map[string]any
+			_ = json.NewDecoder(req.Body).Decode(&patch)
+			for k, v := range patch {
+				thing[k] = v
+			}
+			_ = json.NewEncoder(w).Encode(thing)
+		})
+		r.Post("/things", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"name":"y","token":"hunter2"}`))
+		})
+		r.Delete("/things/x", func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "in use", http.StatusConflict)
+		})
+	})
+	return root
+}
+
+func TestAudit_Update(t *testing.T) {
+	rec := &fakeAuditRecorder{}
+	router := newAuditTestRouter(rec)
+
+	// Reads are not audited.
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/things/x", nil))
+	if len(rec.events) != 0 {
+		t.Fatalf("GET recorded %d events", len(rec.events))
+	}
+
+	body := `{"size":2,"password":"hunter2"}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/v1/things/x", strings.NewReader(body)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"size":2`) {
+		t.Fatalf("handler saw a consumed body: %d %s", w.Code, w.Body)
+	}
+
+	if len(rec.events) != 1 {
+		t.Fatalf("recorded %d events, want 1 (snapshot GETs must not be audited)", len(rec.events))
+	}
+	ev := rec.events[0]
+	if ev.Actor != "alice" || ev.AuthMethod != "jwt" || ev.Resource != "things" || ev.Status != http.StatusOK {
+		t.Fatalf("event = %+v", ev)
+	}
+	if !strings.Contains(ev.Before, `"size":1`) || !strings.Contains(ev.After, `"size":2`) {
+		t.Fatalf("before/after = %s / %s", ev.Before, ev.After)
+	}
+	if !slices.Equal(ev.Changes, []string{"size"}) {
+		t.Fatalf("changes = %v, want [size]", ev.Changes)
+	}
+	for _, doc := range []string{ev.Request, ev.Before, ev.After} {
+		if strings.Contains(doc, "hunter2") {
+			t.Fatalf("secret leaked into audit event: %s", doc)
+		}
+	}
+}
+
+func TestAudit_CreateAndFailure(t *testing.T) {
+	rec := &fakeAuditRecorder{}
+	router := newAuditTestRouter(rec)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/things", strings.NewReader(`{"name":"y"}`)))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/things/x", nil))
+
+	if len(rec.events) != 2 {
+		t.Fatalf("recorded %d events, want 2", len(rec.events))
+	}
+	created := rec.events[0]
+	if created.Status != http.StatusCreated || created.Before != "" || !strings.Contains(created.After, `"name":"y"`) {
+		t.Fatalf("create event = %+v", created)
+	}
+	if strings.Contains(created.After, "hunter2") {
+		t.Fatalf("secret leaked into create event: %s", created.After)
+	}
+
+	failed := rec.events[1]
+	if failed.Status != http.StatusConflict || failed.After != "" || failed.Changes != nil {
+		t.Fatalf("failed delete event = %+v", failed)
+	}
+	if failed.Before == "" {
+		t.Fatal("failed delete should still record the state it targeted")
+	}
+}
@@ -509,3 +509,102 @@ func TestRequirePasswordChange(t *testing.T) {
 		}
 	})
 }
+
+func TestRequireTokenScope(t *testing.T) {
+	// RequireTokenScope runs ahead of RequireAdmin on every scoped route.
+	chain := func(claims *auth.Claims, method, path string) int {
+		handler := RequireTokenScope()(RequireAdmin()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})))
+		ctx := context.WithValue(context.Background(), claimsContextKey, claims)
+		req := httptest.NewRequest(method, path, nil).WithContext(ctx)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	token := &auth.Claims{Username: "terraform", Role: "user", TokenType: auth.TokenTypeAPI, Scopes: []string{"shares:write"}}
+
+	tests := []struct {
+		name   string
+		claims *auth.Claims
+		method string
+		path   string
+		want   int
+	}{
+		{"jwt user unaffected", &auth.Claims{Role: "user", TokenType: auth.TokenTypeAccess}, http.MethodGet, "/api/v1/shares", http.StatusForbidden},
+		{"jwt admin unaffected", &auth.Claims{Role: "admin", TokenType: auth.TokenTypeAccess}, http.MethodGet, "/api/v1/tokens", http.StatusOK},
+		{"scope grants write", token, http.MethodPost, "/api/v1/shares", http.StatusOK},
+		{"write implies read", token, http.MethodGet, "/api/v1/shares/data", http.StatusOK},
+		{"missing scope", token, http.MethodPost, "/api/v1/shares/data/snapshots", http.StatusForbidden},
+		{"token management refused", token, http.MethodPost, "/api/v1/tokens", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chain(tt.claims, tt.method, tt.path); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if token.Role != "user" {
+		t.Errorf("token claims mutated: role = %q", token.Role)
+	}
+}
+
+// fakeBindings is an auth.RoleBindingStore keyed by user ID.
+type fakeBindings map[string][]*models.RoleBinding
+
+func (f fakeBindings) ListRoleBindingsForUser(_ context.Context, userID string) ([]*models.RoleBinding, error) {
+	return f[userID], nil
+}
+
+func TestApplyCustomRoles(t *testing.T) {
+	lead := &models.Role{Name: "project-lead", Permissions: []string{"quotas:write", "trash:write"}}
+	roles := auth.NewRoleAuthorizer(fakeBindings{
+		"alice": {{Role: lead, Shares: []string{"/projects"}}},
+	})
+
+	var seen *auth.Claims
+	handler := ApplyCustomRoles(roles)(RequireAdmin()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetClaimsFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})))
+	serve := func(userID, role, method, path string) int {
+		seen = nil
+		claims := &auth.Claims{UserID: userID, Username: userID, Role: role, TokenType: auth.TokenTypeAccess}
+		ctx := context.WithValue(context.Background(), claimsContextKey, claims)
+		req := httptest.NewRequest(method, path, nil).WithContext(ctx)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	tests := []struct {
+		name, user, role, method, path string
+		want                           int
+	}{
+		{"granted on bound share", "alice", "user", http.MethodPut, "/api/v1/shares/projects/quotas/user/1000", http.StatusOK},
+		{"write implies read", "alice", "user", http.MethodGet, "/api/v1/shares/projects/trash", http.StatusOK},
+		{"other share", "alice", "user", http.MethodPut, "/api/v1/shares/finance/quotas/user/1000", http.StatusForbidden},
+		{"permission not in role", "alice", "user", http.MethodPost, "/api/v1/shares/projects/snapshots", http.StatusForbidden},
+		{"never delegated", "alice", "user", http.MethodGet, "/api/v1/settings", http.StatusForbidden},
+		{"no bindings", "bob", "user", http.MethodGet, "/api/v1/shares/projects/trash", http.StatusForbidden},
+		{"admin unaffected", "root", "admin", http.MethodGet, "/api/v1/settings", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serve(tt.user, tt.role, tt.method, tt.path); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	serve("alice", "user", http.MethodGet, "/api/v1/shares/projects/quotas")
+	if seen == nil || !seen.Delegated {
+		t.Errorf("granted request claims = %+v, want delegated admin", seen)
+	}
+	serve("root", "admin", http.MethodGet, "/api/v1/shares")
+	if seen == nil || seen.Delegated {
+		t.Errorf("admin claims = %+v, want not delegated", seen)
+	}
+}
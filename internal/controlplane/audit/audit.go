@@ -0,0 +1,103 @@
+// Package audit records the control-plane audit log: one event per mutating
+// API call, persisted in the control-plane store and optionally copied to
+// external sinks (a JSON-lines file, syslog).
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+)
+
+// Sink receives a copy of every recorded event.
+type Sink interface {
+	// Write emits one event. It must be safe for concurrent use.
+	Write(event *models.AuditEvent) error
+
+	// Close releases the sink's resources.
+	Close() error
+}
+
+// Recorder appends events to the control-plane store and forwards them to
+// the configured sinks. The store is authoritative; sink failures are logged
+// and do not fail the event.
+type Recorder struct {
+	store store.AuditStore
+	sinks []Sink
+}
+
+// NewRecorder creates a Recorder. A nil store records to the sinks only.
+func NewRecorder(s store.AuditStore, sinks ...Sink) *Recorder {
+	return &Recorder{store: s, sinks: sinks}
+}
+
+// Record persists event and forwards it to the sinks. It never fails the
+// caller: errors are logged. The write is detached from ctx cancellation so
+// an event is not lost when the client disconnects right after its call.
+func (r *Recorder) Record(ctx context.Context, event *models.AuditEvent) {
+	if r == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	if r.store != nil {
+		if err := r.store.AppendAuditEvent(ctx, event); err != nil {
+			logger.ErrorCtx(ctx, "Failed to record audit event",
+				"method", event.Method, "path", event.Path, "actor", event.Actor, "error", err)
+		}
+	}
+	for _, s := range r.sinks {
+		if err := s.Write(event); err != nil {
+			logger.WarnCtx(ctx, "Failed to write audit event to sink",
+				"method", event.Method, "path", event.Path, "error", err)
+		}
+	}
+}
+
+// Diff returns the dotted paths of the fields that differ between two JSON
+// documents, sorted. Objects are compared field by field; arrays and scalars
+// are compared as a whole. A document that is empty or not valid JSON counts
+// as absent, so every field of the other side is reported.
+func Diff(before, after string) []string {
+	var b, a any
+	if before != "" {
+		_ = json.Unmarshal([]byte(before), &b)
+	}
+	if after != "" {
+		_ = json.Unmarshal([]byte(after), &a)
+	}
+	var changes []string
+	diffValue("", b, a, &changes)
+	slices.Sort(changes)
+	return changes
+}
+
+func diffValue(path string, b, a any, changes *[]string) {
+	bm, bok := b.(map[string]any)
+	am, aok := a.(map[string]any)
+	if !bok && !aok {
+		if !reflect.DeepEqual(b, a) && path != "" {
+			*changes = append(*changes, path)
+		}
+		return
+	}
+	for k, bv := range bm {
+		diffValue(joinPath(path, k), bv, am[k], changes)
+	}
+	for k, av := range am {
+		if _, seen := bm[k]; !seen {
+			diffValue(joinPath(path, k), nil, av, changes)
+		}
+	}
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
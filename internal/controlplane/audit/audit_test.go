@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          []string
+	}{
+		{"unchanged", `{"a":1,"b":"x"}`, `{"a":1,"b":"x"}`, nil},
+		{"scalar change", `{"a":1,"b":"x"}`, `{"a":2,"b":"x"}`, []string{"a"}},
+		{"nested", `{"cfg":{"port":1,"host":"h"}}`, `{"cfg":{"port":2,"host":"h"}}`, []string{"cfg.port"}},
+		{"added and removed", `{"a":1}`, `{"b":1}`, []string{"a", "b"}},
+		{"array compared whole", `{"l":[1,2]}`, `{"l":[1,3]}`, []string{"l"}},
+		{"created", ``, `{"name":"s","size":1}`, []string{"name", "size"}},
+		{"deleted", `{"name":"s"}`, ``, []string{"name"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Diff(tc.before, tc.after); !slices.Equal(got, tc.want) {
+				t.Errorf("Diff() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+type fakeAuditStore struct {
+	events []*models.AuditEvent
+	err    error
+}
+
+func (f *fakeAuditStore) AppendAuditEvent(_ context.Context, event *models.AuditEvent) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeAuditStore) ListAuditEvents(context.Context, models.AuditFilter) ([]*models.AuditEvent, error) {
+	return f.events, nil
+}
+
+func TestRecorder_StoreAndFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := &fakeAuditStore{}
+	rec := NewRecorder(st, sink)
+
+	rec.Record(context.Background(), &models.AuditEvent{Actor: "alice", Method: "PUT", Path: "/api/v1/shares/a"})
+	// A store failure must not keep the event from the sinks.
+	st.err = errors.New("db down")
+	rec.Record(context.Background(), &models.AuditEvent{Actor: "bob", Method: "DELETE", Path: "/api/v1/shares/b"})
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(st.events) != 1 || st.events[0].Actor != "alice" {
+		t.Fatalf("store events = %+v", st.events)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var actors []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var ev models.AuditEvent
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		actors = append(actors, ev.Actor)
+	}
+	if !slices.Equal(actors, []string{"alice", "bob"}) {
+		t.Fatalf("file sink actors = %v", actors)
+	}
+	if info, err := os.Stat(path); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("audit file mode = %v, want 0600", info.Mode().Perm())
+	}
+}
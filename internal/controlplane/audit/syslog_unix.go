@@ -0,0 +1,45 @@
+//go:build !windows
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+// SyslogSink sends each event as a JSON message to syslog at the
+// LOG_NOTICE|LOG_AUTH priority.
+type SyslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogSink connects to syslog. An empty network and address use the
+// local syslog daemon; otherwise network is "udp" or "tcp" and address is
+// host:port. tag defaults to "dittofs-audit".
+func NewSyslogSink(network, address, tag string) (*SyslogSink, error) {
+	if tag == "" {
+		tag = "dittofs-audit"
+	}
+	w, err := syslog.Dial(network, address, syslog.LOG_NOTICE|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, fmt.Errorf("connect to syslog: %w", err)
+	}
+	return &SyslogSink{w: w}, nil
+}
+
+// Write implements Sink.
+func (s *SyslogSink) Write(event *models.AuditEvent) error {
+	msg, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.w.Notice(string(msg))
+}
+
+// Close implements Sink.
+func (s *SyslogSink) Close() error {
+	return s.w.Close()
+}
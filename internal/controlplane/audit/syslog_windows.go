@@ -0,0 +1,27 @@
+//go:build windows
+
+package audit
+
+import (
+	"errors"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+// SyslogSink is unavailable on Windows.
+type SyslogSink struct{}
+
+// NewSyslogSink always fails on Windows, which has no syslog.
+func NewSyslogSink(network, address, tag string) (*SyslogSink, error) {
+	return nil, errors.New("syslog audit sink is not supported on windows")
+}
+
+// Write implements Sink.
+func (s *SyslogSink) Write(event *models.AuditEvent) error {
+	return nil
+}
+
+// Close implements Sink.
+func (s *SyslogSink) Close() error {
+	return nil
+}
@@ -0,0 +1,195 @@
+// Package fileaudit delivers the per-share file-access audit trail produced by
+// metadata.Service to external sinks: a rotated JSON-lines file, an RFC 5424
+// syslog receiver, or the server log.
+//
+// Events are recorded on protocol goroutines, so the Dispatcher decouples
+// them from sink I/O with a bounded queue and never blocks the caller; when
+// the queue is full the event is dropped and counted. Repeated read and write
+// events for the same file, client and principal are folded into one event
+// per coalescing window so per-I/O auditing stays tractable.
+package fileaudit
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// Sink receives delivered events. Write is only called from the dispatcher
+// goroutine.
+type Sink interface {
+	Write(event *metadata.FileAuditEvent) error
+	Close() error
+}
+
+// coalesceKey identifies events that are folded together.
+type coalesceKey struct {
+	share, path, protocol, clientIP, principal, result string
+	op                                                 metadata.FileAuditOp
+}
+
+// pending is a coalesced event whose window is still open.
+type pending struct {
+	event   metadata.FileAuditEvent
+	expires time.Time
+	repeats int
+}
+
+// Dispatcher fans events out to sinks on a background goroutine.
+type Dispatcher struct {
+	sinks  []Sink
+	window time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan *metadata.FileAuditEvent
+	done   chan struct{}
+
+	dropped atomic.Uint64
+
+	// Owned by the run goroutine.
+	open map[coalesceKey]*pending
+}
+
+// NewDispatcher starts a dispatcher with the given queue size. A window <= 0
+// disables coalescing.
+func NewDispatcher(queueSize int, window time.Duration, sinks ...Sink) *Dispatcher {
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+	d := &Dispatcher{
+		sinks:  sinks,
+		window: window,
+		queue:  make(chan *metadata.FileAuditEvent, queueSize),
+		done:   make(chan struct{}),
+		open:   make(map[coalesceKey]*pending),
+	}
+	go d.run()
+	return d
+}
+
+// RecordFileAudit enqueues an event without blocking. It is dropped when the
+// queue is full or the dispatcher is closed.
+func (d *Dispatcher) RecordFileAudit(event *metadata.FileAuditEvent) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return
+	}
+	select {
+	case d.queue <- event:
+	default:
+		// Log at power-of-two counts only, so a sustained overflow does
+		// not flood the server log.
+		if n := d.dropped.Add(1); n&(n-1) == 0 {
+			logger.Warn("File audit queue full, dropping events", "dropped", n)
+		}
+	}
+}
+
+// Dropped returns the number of events dropped on queue overflow.
+func (d *Dispatcher) Dropped() uint64 {
+	return d.dropped.Load()
+}
+
+// Close stops accepting events, delivers everything queued (including open
+// coalescing windows) and closes the sinks.
+func (d *Dispatcher) Close() error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.queue)
+	d.mu.Unlock()
+
+	<-d.done
+	var firstErr error
+	for _, s := range d.sinks {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+
+	var tick <-chan time.Time
+	if d.window > 0 {
+		interval := d.window / 2
+		if interval < time.Second {
+			interval = time.Second
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case ev, ok := <-d.queue:
+			if !ok {
+				d.flush(time.Time{})
+				return
+			}
+			d.handle(ev)
+		case now := <-tick:
+			d.flush(now)
+		}
+	}
+}
+
+// handle delivers ev, or folds it into an open window for the same key.
+func (d *Dispatcher) handle(ev *metadata.FileAuditEvent) {
+	if d.window <= 0 || (ev.Op != metadata.FileAuditRead && ev.Op != metadata.FileAuditWrite) {
+		d.deliver(ev)
+		return
+	}
+	key := coalesceKey{
+		share: ev.Share, path: ev.Path, protocol: ev.Protocol,
+		clientIP: ev.ClientIP, principal: ev.Principal, result: ev.Result, op: ev.Op,
+	}
+	if p, ok := d.open[key]; ok {
+		if ev.Time.Before(p.expires) {
+			p.repeats++
+			p.event.Time = ev.Time
+			return
+		}
+		d.closeWindow(key, p)
+	}
+	d.deliver(ev)
+	d.open[key] = &pending{event: *ev, expires: ev.Time.Add(d.window)}
+}
+
+// flush closes windows that expired by now (all of them for a zero now) and
+// delivers one summary event for each window that saw repeats.
+func (d *Dispatcher) flush(now time.Time) {
+	for key, p := range d.open {
+		if !now.IsZero() && now.Before(p.expires) {
+			continue
+		}
+		d.closeWindow(key, p)
+	}
+}
+
+func (d *Dispatcher) closeWindow(key coalesceKey, p *pending) {
+	delete(d.open, key)
+	if p.repeats > 0 {
+		p.event.Count = p.repeats
+		d.deliver(&p.event)
+	}
+}
+
+func (d *Dispatcher) deliver(ev *metadata.FileAuditEvent) {
+	for _, s := range d.sinks {
+		if err := s.Write(ev); err != nil {
+			logger.Warn("Failed to write file audit event", "share", ev.Share, "op", ev.Op, "error", err)
+		}
+	}
+}
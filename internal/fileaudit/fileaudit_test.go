@@ -0,0 +1,183 @@
+package fileaudit
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+type memSink struct {
+	mu     sync.Mutex
+	events []metadata.FileAuditEvent
+	closed bool
+}
+
+func (s *memSink) Write(ev *metadata.FileAuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, *ev)
+	return nil
+}
+
+func (s *memSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func event(op metadata.FileAuditOp, path string, at time.Time) *metadata.FileAuditEvent {
+	return &metadata.FileAuditEvent{
+		Time: at, Share: "/data", Op: op, Path: path,
+		Protocol: "smb", ClientIP: "10.0.0.1", Principal: "alice", Result: "success",
+	}
+}
+
+func TestDispatcher_DeliversAndClosesSinks(t *testing.T) {
+	sink := &memSink{}
+	d := NewDispatcher(16, 0, sink)
+	now := time.Now()
+	d.RecordFileAudit(event(metadata.FileAuditOpen, "/a", now))
+	d.RecordFileAudit(event(metadata.FileAuditDelete, "/a", now))
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(sink.events) != 2 || !sink.closed {
+		t.Fatalf("got %d events, closed=%v; want 2 events, closed", len(sink.events), sink.closed)
+	}
+	// Recording after Close is a silent no-op.
+	d.RecordFileAudit(event(metadata.FileAuditOpen, "/a", now))
+}
+
+func TestDispatcher_CoalescesReadsAndWrites(t *testing.T) {
+	sink := &memSink{}
+	d := NewDispatcher(64, time.Hour, sink)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		d.RecordFileAudit(event(metadata.FileAuditRead, "/a", now.Add(time.Duration(i)*time.Second)))
+	}
+	d.RecordFileAudit(event(metadata.FileAuditRead, "/b", now))
+	d.RecordFileAudit(event(metadata.FileAuditOpen, "/a", now))
+	d.RecordFileAudit(event(metadata.FileAuditOpen, "/a", now))
+	_ = d.Close()
+
+	var reads, summaries, opens int
+	for _, ev := range sink.events {
+		switch {
+		case ev.Op == metadata.FileAuditOpen:
+			opens++
+		case ev.Count > 0:
+			summaries++
+			if ev.Path != "/a" || ev.Count != 4 {
+				t.Errorf("summary = %s count %d, want /a count 4", ev.Path, ev.Count)
+			}
+		default:
+			reads++
+		}
+	}
+	if reads != 2 || summaries != 1 || opens != 2 {
+		t.Errorf("reads=%d summaries=%d opens=%d, want 2, 1, 2", reads, summaries, opens)
+	}
+}
+
+func TestDispatcher_DropsWhenFull(t *testing.T) {
+	block := make(chan struct{})
+	sink := &blockingSink{release: block}
+	d := NewDispatcher(1, 0, sink)
+	for i := 0; i < 10; i++ {
+		d.RecordFileAudit(event(metadata.FileAuditOpen, "/a", time.Now()))
+	}
+	if d.Dropped() == 0 {
+		t.Error("expected dropped events with a full queue")
+	}
+	close(block)
+	_ = d.Close()
+}
+
+type blockingSink struct{ release chan struct{} }
+
+func (s *blockingSink) Write(*metadata.FileAuditEvent) error { <-s.release; return nil }
+func (s *blockingSink) Close() error                         { return nil }
+
+func TestFileSink_WritesJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "files.log")
+	s, err := NewFileSink(path, Rotation{MaxSize: 1})
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+	if err := s.Write(event(metadata.FileAuditRename, "/a", time.Now())); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	_ = s.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if fi, _ := f.Stat(); fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", fi.Mode().Perm())
+	}
+	sc := bufio.NewScanner(f)
+	if !sc.Scan() {
+		t.Fatal("no line written")
+	}
+	var got metadata.FileAuditEvent
+	if err := json.Unmarshal(sc.Bytes(), &got); err != nil {
+		t.Fatalf("line is not JSON: %v", err)
+	}
+	if got.Op != metadata.FileAuditRename || got.Principal != "alice" {
+		t.Errorf("decoded %+v", got)
+	}
+}
+
+func TestSyslogSink_RFC5424OverUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	s, err := NewSyslogSink("udp", pc.LocalAddr().String(), "dittofs-fileaudit")
+	if err != nil {
+		t.Fatalf("NewSyslogSink: %v", err)
+	}
+	defer s.Close()
+
+	ev := event(metadata.FileAuditDelete, "/a", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	ev.Result = "AccessDenied"
+	if err := s.Write(ev); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+
+	// facility 13 (log audit) * 8 + severity 4 (warning) for a failure.
+	wantPrefix := "<108>1 2026-01-02T03:04:05Z "
+	if !strings.HasPrefix(msg, wantPrefix) {
+		t.Fatalf("message %q does not start with %q", msg, wantPrefix)
+	}
+	fields := strings.SplitN(msg, " ", 8)
+	if len(fields) != 8 || fields[3] != "dittofs-fileaudit" || fields[5] != "delete" || fields[6] != "-" {
+		t.Fatalf("unexpected header fields %q", fields)
+	}
+	var got metadata.FileAuditEvent
+	if err := json.Unmarshal([]byte(fields[7]), &got); err != nil || got.Path != "/a" {
+		t.Errorf("MSG = %q (err %v)", fields[7], err)
+	}
+}
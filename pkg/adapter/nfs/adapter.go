@@ -18,6 +18,7 @@ import (
 	nsm_handlers "github.com/marmos91/dittofs/internal/adapter/nfs/nsm/handlers"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/portmap"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc/gss"
+	rquota_handlers "github.com/marmos91/dittofs/internal/adapter/nfs/rquota/handlers"
 	v3 "github.com/marmos91/dittofs/internal/adapter/nfs/v3/handlers"
 	v4handlers "github.com/marmos91/dittofs/internal/adapter/nfs/v4/handlers"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/v4/pseudofs"
@@ -104,6 +105,10 @@ type NFSAdapter struct {
 	// nsmNotifier orchestrates SM_NOTIFY callbacks on server restart
 	nsmNotifier *nsm.Notifier
 
+	// rquotaHandler processes rquota operations (GETQUOTA, SETQUOTA) issued by
+	// client quota tools
+	rquotaHandler *rquota_handlers.Handler
+
 	// gssProcessor handles RPCSEC_GSS context lifecycle (INIT/DATA/DESTROY).
 	// nil when Kerberos is not enabled.
 	gssProcessor *gss.GSSProcessor
@@ -152,7 +157,7 @@ type NFSAdapter struct {
 	// Stop() may race Serve() (Stop is documented safe to call concurrently).
 	sysregActive atomic.Bool
 
-	// udpConn is the UDP listener serving NLM/NSM/MOUNT when the UDP transport
+	// udpConn is the UDP listener serving NLM/NSM/MOUNT/RQUOTA when the UDP transport
 	// is enabled (adapters.nfs.udp.enabled). nil when UDP is disabled.
 	udpConn *net.UDPConn
 
@@ -277,8 +282,8 @@ type NFSConfig struct {
 	// Default: disabled. When enabled, listens on port 10111.
 	Portmapper PortmapConfig `mapstructure:"portmapper"`
 
-	// UDP configures serving the lock-manager auxiliary protocols (NLM, NSM)
-	// and MOUNT over UDP, in addition to TCP. NFS itself is never served over
+	// UDP configures serving the lock-manager auxiliary protocols (NLM, NSM),
+	// MOUNT and RQUOTA over UDP, in addition to TCP. NFS itself is never served over
 	// UDP. Disabled by default. BSD/macOS NFSv3 lock clients reach rpc.lockd /
 	// rpc.statd over UDP, so NFSv3 file locking from macOS requires this
 	// enabled together with the portmapper (issue #1353).
@@ -386,9 +391,9 @@ type PortmapConfig struct {
 }
 
 // NFSUDPConfig configures the UDP transport for the lock-manager auxiliary
-// protocols (NLM, NSM) and MOUNT. NFS data operations are never served over UDP.
+// protocols (NLM, NSM), MOUNT and RQUOTA. NFS data operations are never served over UDP.
 type NFSUDPConfig struct {
-	// Enabled controls whether NLM/NSM/MOUNT are served over UDP.
+	// Enabled controls whether NLM/NSM/MOUNT/RQUOTA are served over UDP.
 	// When nil (not specified in config), defaults to false.
 	Enabled *bool `mapstructure:"enabled"`
 }
@@ -533,6 +538,7 @@ func (s *NFSAdapter) SetRuntime(rtAny any) {
 	// Inject runtime into handlers
 	s.nfsHandler.Registry = rt
 	s.mountHandler.Registry = rt
+	s.rquotaHandler = rquota_handlers.NewHandler(rt)
 
 	// Initialize NFSv4 pseudo-filesystem and handler
 	s.pseudoFS = pseudofs.New()
@@ -565,6 +571,11 @@ func (s *NFSAdapter) SetRuntime(rtAny any) {
 	s.v4Handler = v4handlers.NewHandler(rt, s.pseudoFS, v4StateManager)
 	s.v4Handler.KerberosEnabled = s.kerberosConfig != nil
 
+	// pNFS layout credentials are issued by the v4 handler and verified by
+	// whichever protocol the client uses to reach this server as a data
+	// server, so both handlers share one instance.
+	s.nfsHandler.LayoutCreds = s.v4Handler.LayoutCreds
+
 	// Expose StateManager to REST API via runtime (for /clients endpoint and /health server info)
 	rt.SetNFSClientProvider(v4StateManager)
 
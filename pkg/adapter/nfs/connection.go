@@ -3,6 +3,7 @@ package nfs
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
@@ -18,6 +19,7 @@ import (
 	"github.com/marmos91/dittofs/internal/bytesize"
 	"github.com/marmos91/dittofs/internal/logger"
 	"github.com/marmos91/dittofs/pkg/adapter"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
 	"github.com/marmos91/dittofs/pkg/controlplane/runtime/clients"
 )
 
@@ -63,6 +65,12 @@ type NFSConnection struct {
 	// handled synchronously before any request is dispatched concurrently).
 	tlsUpgraded bool
 
+	// peerCert is the verified client certificate of a mutually
+	// authenticated TLS upgrade, attached to every request context for the
+	// runtime's cert mapping rules; nil for plaintext or anonymous TLS. Set
+	// by startTLS before the first dispatch and never changed afterwards.
+	peerCert *x509.Certificate
+
 	// startedDispatch records whether any request has been dispatched
 	// concurrently on this connection. A STARTTLS upgrade is only honored
 	// before the first dispatch (RFC 9289: the AUTH_TLS NULL is the first RPC),
@@ -204,8 +212,15 @@ func (c *NFSConnection) startTLS(ctx context.Context, xid uint32, clientAddr str
 
 	c.conn = tlsConn
 	c.tlsUpgraded = true
-	logger.Info("NFS connection upgraded to TLS", "address", clientAddr,
-		"version", tls.VersionName(tlsConn.ConnectionState().Version))
+	cs := tlsConn.ConnectionState()
+	attrs := []any{"address", clientAddr, "version", tls.VersionName(cs.Version)}
+	if len(cs.VerifiedChains) > 0 {
+		// Only a certificate chained to the configured client CA identifies
+		// the host; an unverified one is never trusted.
+		c.peerCert = cs.PeerCertificates[0]
+		attrs = append(attrs, "client_cn", c.peerCert.Subject.CommonName)
+	}
+	logger.Info("NFS connection upgraded to TLS", attrs...)
 	return nil
 }
 
@@ -346,6 +361,9 @@ func (c *NFSConnection) processRequest(ctx context.Context, call *rpc.RPCCallMes
 		return fmt.Errorf("extract procedure data: %w", err)
 	}
 
+	if c.peerCert != nil {
+		ctx = runtime.WithPeerCertificate(ctx, c.peerCert)
+	}
 	return c.handleRPCCall(ctx, call, rawMessage, procedureData)
 }
 
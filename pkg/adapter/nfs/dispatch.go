@@ -10,6 +10,7 @@ import (
 	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc/gss"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/xdr"
 	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/metadata"
 )
 
 // handleRPCCall dispatches an RPC call to the appropriate handler.
@@ -167,6 +168,7 @@ func (c *NFSConnection) handleRPCCall(ctx context.Context, call *rpc.RPCCallMess
 			SessionKey:        gssResult.SessionKey,
 			SeqNum:            gssResult.SeqNum,
 			Service:           gssResult.Service,
+			Principal:         gssResult.Principal,
 			HasAcceptorSubkey: gssResult.HasAcceptorSubkey,
 		})
 	}
@@ -183,6 +185,13 @@ func (c *NFSConnection) handleRPCCall(ctx context.Context, call *rpc.RPCCallMess
 			return c.handleUnsupportedVersion(call, rpc.NFSVersion3, rpc.NFSVersion4, "NFS", clientAddr)
 		}
 
+	case rpc.ProgramNFSACL:
+		// NFSACL v3 only: the POSIX ACL side protocol for NFSv3 clients.
+		if call.Version != rpc.NFSACLVersion3 {
+			return c.handleUnsupportedVersion(call, rpc.NFSACLVersion3, rpc.NFSACLVersion3, "NFSACL", clientAddr)
+		}
+		replyData, err = c.handleNFSACLProcedure(ctx, call, procedureData, clientAddr)
+
 	case rpc.ProgramMount:
 		// Mount protocol version handling:
 		// - MNT requires v3 (returns v3 file handle format)
@@ -211,6 +220,14 @@ func (c *NFSConnection) handleRPCCall(ctx context.Context, call *rpc.RPCCallMess
 		}
 		replyData, err = c.handleNSMProcedure(ctx, call, procedureData, clientAddr)
 
+	case rpc.ProgramRQuota:
+		// rquota v1 (user quotas) and v2 (user and group quotas) share one
+		// dispatch table; the handlers pick the argument layout by version.
+		if call.Version != rpc.RQuotaVersion1 && call.Version != rpc.RQuotaVersion2 {
+			return c.handleUnsupportedVersion(call, rpc.RQuotaVersion1, rpc.RQuotaVersion2, "RQUOTA", clientAddr)
+		}
+		replyData, err = c.handleRQuotaProcedure(ctx, call, procedureData, clientAddr, false)
+
 	default:
 		logger.Debug("Unknown program", "program", call.Program)
 		// Send PROG_UNAVAIL error reply for unknown programs (RFC 5531 §9)
@@ -281,24 +298,25 @@ func (c *NFSConnection) handleRPCCall(ctx context.Context, call *rpc.RPCCallMess
 //
 // Returns:
 //   - string: Share name, or empty string if no handle present (e.g., NULL procedure)
+//   - metadata.FileHandle: The decoded handle, nil if none is present
 //   - error: Decoding or resolution error
-func (c *NFSConnection) extractShareName(ctx context.Context, data []byte) (string, error) {
+func (c *NFSConnection) extractShareName(ctx context.Context, data []byte) (string, metadata.FileHandle, error) {
 	// Decode file handle from XDR request data
 	handle, err := xdr.DecodeFileHandleFromRequest(data)
 	if err != nil {
-		return "", fmt.Errorf("decode file handle: %w", err)
+		return "", nil, fmt.Errorf("decode file handle: %w", err)
 	}
 
 	// No handle present (procedures like NULL, FSINFO don't have handles)
 	if handle == nil {
-		return "", nil
+		return "", nil, nil
 	}
 
 	// Resolve share name from handle using registry
 	shareName, err := c.server.Registry.GetShareNameForHandle(ctx, handle)
 	if err != nil {
-		return "", fmt.Errorf("resolve share from handle: %w", err)
+		return "", nil, fmt.Errorf("resolve share from handle: %w", err)
 	}
 
-	return shareName, nil
+	return shareName, handle, nil
 }
@@ -15,6 +15,7 @@ import (
 	nsm "github.com/marmos91/dittofs/internal/adapter/nfs/nsm"
 	nsm_handlers "github.com/marmos91/dittofs/internal/adapter/nfs/nsm/handlers"
 	"github.com/marmos91/dittofs/internal/adapter/nfs/rpc"
+	"github.com/marmos91/dittofs/internal/adapter/nfs/rquota"
 	nfs_types "github.com/marmos91/dittofs/internal/adapter/nfs/types"
 	v4handlers "github.com/marmos91/dittofs/internal/adapter/nfs/v4/handlers"
 	v4state "github.com/marmos91/dittofs/internal/adapter/nfs/v4/state"
@@ -58,17 +59,18 @@ func (c *NFSConnection) handleNFSProcedure(ctx context.Context, call *rpc.RPCCal
 	}
 
 	// Extract share name from file handle (best effort for metrics)
-	share, extractErr := c.extractShareName(ctx, data)
+	share, handle, extractErr := c.extractShareName(ctx, data)
 	if extractErr != nil {
 		logger.Warn("Failed to extract share from handle",
 			"procedure", procedure.Name,
 			"error", extractErr)
 		// Continue anyway - handler will validate and return proper NFS error
-		share = ""
+		share, handle = "", nil
 	}
 
 	// Extract handler context (includes share and authentication for handlers)
 	handlerCtx := nfs.ExtractHandlerContext(ctx, call, clientAddr, share, procedure.Name)
+	handlerCtx.Handle = handle
 
 	// Log request with trace context
 	logger.DebugCtx(ctx, "NFS request",
@@ -154,6 +156,67 @@ func (c *NFSConnection) handleNFSProcedure(ctx context.Context, call *rpc.RPCCal
 	return result.Data, err
 }
 
+// handleNFSACLProcedure dispatches an NFSACL v3 procedure call (GETACL,
+// SETACL) to the appropriate handler.
+//
+// NFSACL shares the NFSv3 file handles and handler context, so the share is
+// extracted from the handle exactly as for NFS. SETACL replaces the whole ACL,
+// which makes a retransmit idempotent, so the duplicate-request cache is not
+// consulted.
+//
+// Returns the reply data or an error if the handler fails.
+func (c *NFSConnection) handleNFSACLProcedure(ctx context.Context, call *rpc.RPCCallMessage, data []byte, clientAddr string) ([]byte, error) {
+	// Look up procedure in NFSACL dispatch table
+	procedure, ok := nfs.NfsACLDispatchTable[call.Procedure]
+	if !ok {
+		logger.Debug("Unknown NFSACL procedure", "procedure", call.Procedure)
+		return []byte{}, nil
+	}
+
+	// Extract share name from file handle (best effort for metrics)
+	share, handle, extractErr := c.extractShareName(ctx, data)
+	if extractErr != nil {
+		logger.Warn("Failed to extract share from handle",
+			"procedure", "NFSACL_"+procedure.Name,
+			"error", extractErr)
+		share, handle = "", nil
+	}
+
+	handlerCtx := nfs.ExtractHandlerContext(ctx, call, clientAddr, share, procedure.Name)
+	handlerCtx.Handle = handle
+
+	logger.DebugCtx(ctx, "NFSACL request",
+		"procedure", procedure.Name,
+		"share", share,
+		"client", clientAddr,
+		"xid", fmt.Sprintf("0x%x", call.XID))
+
+	// Check context before dispatching to handler
+	select {
+	case <-ctx.Done():
+		logger.DebugCtx(ctx, "NFSACL request cancelled before handler",
+			"procedure", procedure.Name,
+			"xid", fmt.Sprintf("0x%x", call.XID))
+		return nil, ctx.Err()
+	default:
+	}
+
+	// Dispatch to handler
+	start := time.Now()
+	result, err := procedure.Handler(
+		handlerCtx,
+		c.server.nfsHandler,
+		c.server.Registry,
+		data,
+	)
+	c.recordOp("NFSACL_"+procedure.Name, start, err == nil && (result == nil || result.NFSStatus == 0))
+
+	if result == nil {
+		return nil, err
+	}
+	return result.Data, err
+}
+
 // handleMountProcedure dispatches a MOUNT procedure call to the appropriate handler.
 //
 // It looks up the procedure in the dispatch table, extracts authentication
@@ -390,6 +453,59 @@ func (c *NFSConnection) handleNSMProcedure(ctx context.Context, call *rpc.RPCCal
 	return result.Data, err
 }
 
+// handleRQuotaProcedure dispatches an rquota procedure call to the appropriate
+// handler.
+//
+// rquota requests carry a path rather than a file handle, so no share is
+// extracted before dispatch; the handler resolves it. Credentials are extracted
+// as for NFS so quota queries are authorized against the caller's identity.
+// SETQUOTA replaces the limits wholesale, which makes a retransmit idempotent,
+// so the duplicate-request cache is not consulted. overUDP marks calls from
+// the UDP transport, which SETQUOTA refuses.
+//
+// Returns the reply data or an error if the handler fails.
+func (c *NFSConnection) handleRQuotaProcedure(ctx context.Context, call *rpc.RPCCallMessage, data []byte, clientAddr string, overUDP bool) ([]byte, error) {
+	// Look up procedure in rquota dispatch table
+	procedure, ok := rquota.RQuotaDispatchTable[call.Procedure]
+	if !ok {
+		logger.Debug("Unknown RQUOTA procedure", "procedure", call.Procedure)
+		return []byte{}, nil
+	}
+
+	handlerCtx := middleware.ExtractRQuotaHandlerContext(ctx, call, clientAddr, procedure.Name)
+	handlerCtx.UDP = overUDP
+
+	logger.DebugCtx(ctx, "RQUOTA request",
+		"procedure", procedure.Name,
+		"version", call.Version,
+		"client", clientAddr,
+		"xid", fmt.Sprintf("0x%x", call.XID))
+
+	// Check context before dispatching to handler
+	select {
+	case <-ctx.Done():
+		logger.DebugCtx(ctx, "RQUOTA request cancelled before handler",
+			"procedure", procedure.Name,
+			"xid", fmt.Sprintf("0x%x", call.XID))
+		return nil, ctx.Err()
+	default:
+	}
+
+	// Dispatch to handler
+	start := time.Now()
+	result, err := procedure.Handler(
+		handlerCtx,
+		c.server.rquotaHandler,
+		data,
+	)
+	c.recordOp("RQUOTA_"+procedure.Name, start, err == nil)
+
+	if result == nil {
+		return nil, err
+	}
+	return result.Data, err
+}
+
 // handleNFSv4Procedure dispatches an NFSv4 procedure call to the appropriate handler.
 //
 // NFSv4 has only two RPC procedures (RFC 7530 Section 16):
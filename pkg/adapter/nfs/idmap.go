@@ -0,0 +1,159 @@
+package nfs
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	v4attrs "github.com/marmos91/dittofs/internal/adapter/nfs/v4/attrs"
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
+	"github.com/marmos91/dittofs/pkg/identity"
+	identityldap "github.com/marmos91/dittofs/pkg/identity/ldap"
+)
+
+// applyIDMapping configures NFSv4 owner-string translation from the live
+// settings. An empty domain restores numeric owner strings.
+func (s *NFSAdapter) applyIDMapping(rt *runtime.Runtime, settings *models.NFSAdapterSettings) {
+	if settings.IDMapDomain == "" {
+		v4attrs.SetIDMapping(nil)
+		return
+	}
+	v4attrs.SetIDMapping(&v4attrs.IDMapping{
+		Domain:         settings.IDMapDomain,
+		NumericAuthSys: settings.IDMapNumericAuthSys,
+		Names:          &idNames{rt: rt},
+	})
+	logger.Debug("NFS adapter: applied NFSv4 ID mapping",
+		"domain", settings.IDMapDomain, "numeric_auth_sys", settings.IDMapNumericAuthSys)
+}
+
+// idNames implements v4attrs.IDNameResolver over the control-plane user and
+// group store, falling back to the LDAP directory for accounts that exist only
+// there. Results are cached because an owner string is encoded for every
+// GETATTR and READDIR entry.
+type idNames struct {
+	rt    *runtime.Runtime
+	cache sync.Map // "u:1000" / "U:alice" / "g:..." / "G:..." -> idNameEntry
+
+	mu      sync.Mutex
+	ldapCfg *identityldap.Config
+	ldap    *identityldap.Provider
+}
+
+// idNameEntry is a cached lookup result; misses are cached for a shorter time.
+type idNameEntry struct {
+	name    string
+	id      uint32
+	ok      bool
+	expires time.Time
+}
+
+func (n *idNames) UserName(ctx context.Context, uid uint32) (string, bool) {
+	e := n.lookup("u:"+strconv.FormatUint(uint64(uid), 10), func() idNameEntry {
+		if user, err := n.rt.Store().GetUserByUID(ctx, uid); err == nil {
+			return idNameEntry{name: user.Username, ok: true}
+		}
+		if p := n.directory(); p != nil {
+			if name, _, ok := p.LookupUID(ctx, uid); ok {
+				return idNameEntry{name: name, ok: true}
+			}
+		}
+		return idNameEntry{}
+	})
+	return e.name, e.ok
+}
+
+func (n *idNames) GroupName(ctx context.Context, gid uint32) (string, bool) {
+	e := n.lookup("g:"+strconv.FormatUint(uint64(gid), 10), func() idNameEntry {
+		if group, err := n.rt.Store().GetGroupByGID(ctx, gid); err == nil {
+			return idNameEntry{name: group.Name, ok: true}
+		}
+		if p := n.directory(); p != nil {
+			if name, _, ok := p.LookupGID(ctx, gid); ok {
+				return idNameEntry{name: name, ok: true}
+			}
+		}
+		return idNameEntry{}
+	})
+	return e.name, e.ok
+}
+
+func (n *idNames) UserID(ctx context.Context, name string) (uint32, bool) {
+	e := n.lookup("U:"+name, func() idNameEntry {
+		if user, err := n.rt.Store().GetUser(ctx, name); err == nil && user.UID != nil {
+			return idNameEntry{id: *user.UID, ok: true}
+		}
+		return n.directoryID(ctx, name, false)
+	})
+	return e.id, e.ok
+}
+
+func (n *idNames) GroupID(ctx context.Context, name string) (uint32, bool) {
+	e := n.lookup("G:"+name, func() idNameEntry {
+		if group, err := n.rt.Store().GetGroup(ctx, name); err == nil && group.GID != nil {
+			return idNameEntry{id: *group.GID, ok: true}
+		}
+		return n.directoryID(ctx, name, true)
+	})
+	return e.id, e.ok
+}
+
+// lookup returns the cached entry for key, running resolve on a miss or
+// after expiry.
+func (n *idNames) lookup(key string, resolve func() idNameEntry) idNameEntry {
+	if v, ok := n.cache.Load(key); ok {
+		if e := v.(idNameEntry); time.Now().Before(e.expires) {
+			return e
+		}
+	}
+	e := resolve()
+	ttl := identity.DefaultNegativeCacheTTL
+	if e.ok {
+		ttl = identity.DefaultCacheTTL
+	}
+	e.expires = time.Now().Add(ttl)
+	n.cache.Store(key, e)
+	return e
+}
+
+// directoryID resolves a directory account or group name to its Unix ID.
+func (n *idNames) directoryID(ctx context.Context, name string, group bool) idNameEntry {
+	p := n.directory()
+	if p == nil {
+		return idNameEntry{}
+	}
+	res, found, err := p.ResolvePrincipalSID(ctx, name, group)
+	if err != nil {
+		logger.Debug("NFS ID mapping: directory lookup failed", "name", name, "group", group, "error", err)
+		return idNameEntry{}
+	}
+	if !found {
+		return idNameEntry{}
+	}
+	return idNameEntry{id: res.UnixID, ok: true}
+}
+
+// directory returns the LDAP provider for the current directory config, or
+// nil when LDAP is not enabled. The provider is rebuilt when the config is
+// replaced over the API.
+func (n *idNames) directory() *identityldap.Provider {
+	cfg := n.rt.LDAPConfig()
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if cfg != n.ldapCfg {
+		p, err := identityldap.New(cfg, nil, nil)
+		if err != nil {
+			logger.Warn("NFS ID mapping: LDAP provider unavailable (invalid config)", "error", err)
+			p = nil
+		}
+		n.ldapCfg, n.ldap = cfg, p
+	}
+	return n.ldap
+}
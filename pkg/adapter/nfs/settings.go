@@ -5,6 +5,7 @@ import (
 	"time"
 
 	v4attrs "github.com/marmos91/dittofs/internal/adapter/nfs/v4/attrs"
+	v4handlers "github.com/marmos91/dittofs/internal/adapter/nfs/v4/handlers"
 	"github.com/marmos91/dittofs/internal/logger"
 	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
 )
@@ -101,6 +102,22 @@ func (s *NFSAdapter) applyNFSSettings(rt *runtime.Runtime) {
 	// restart), the mDNS sidecar is toggled live here — start or stop it to
 	// match the setting. No-op until Serve has started the auxsvc group.
 	s.reconcileDiscovery()
+
+	// NFSv4 ID mapping -> attrs package (FATTR4_OWNER/OWNER_GROUP). Applied
+	// live; the name cache starts empty on every change.
+	s.applyIDMapping(rt, settings)
+
+	// rquota SETQUOTA opt-in -> rquotaHandler. Applied live.
+	s.rquotaHandler.SetAllowSetQuota(settings.RQuotaSetQuotaEnabled)
+
+	// pNFS data servers -> v4Handler. Applied live; a changed data server set
+	// recalls outstanding layouts.
+	s.v4Handler.SetPNFSConfig(v4handlers.PNFSConfig{
+		DataServers:       settings.GetPNFSDataServers(),
+		DataServerVersion: settings.PNFSDataServerVersion,
+		RSize:             uint32(max(settings.MaxReadSize, 0)),
+		WSize:             uint32(max(settings.MaxWriteSize, 0)),
+	})
 }
 
 // fsCapabilitiesProbeTimeout bounds the metadata read issued when refreshing
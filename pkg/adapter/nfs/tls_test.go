@@ -123,7 +123,8 @@ func readRPCRecord(t *testing.T, r io.Reader) []byte {
 
 // runStartTLSServer accepts one connection, runs c.startTLS, then echoes 5
 // bytes over the upgraded connection. The handshake error (if any) is returned.
-func runStartTLSServer(ln net.Listener, srv *NFSAdapter, errc chan<- error) {
+// A non-nil peerc receives the client certificate startTLS captured.
+func runStartTLSServer(ln net.Listener, srv *NFSAdapter, errc chan<- error, peerc chan<- *x509.Certificate) {
 	conn, err := ln.Accept()
 	if err != nil {
 		errc <- err
@@ -141,6 +142,9 @@ func runStartTLSServer(ln net.Listener, srv *NFSAdapter, errc chan<- error) {
 		return
 	}
 	_, _ = c.conn.Write(buf)
+	if peerc != nil {
+		peerc <- c.peerCert
+	}
 	errc <- nil
 }
 
@@ -158,7 +162,7 @@ func TestNFSConnection_StartTLS_OpportunisticHandshake(t *testing.T) {
 	defer func() { _ = ln.Close() }()
 
 	errc := make(chan error, 1)
-	go runStartTLSServer(ln, srv, errc)
+	go runStartTLSServer(ln, srv, errc, nil)
 
 	conn, err := net.Dial("tcp", ln.Addr().String())
 	if err != nil {
@@ -218,7 +222,8 @@ func TestNFSConnection_StartTLS_MTLS(t *testing.T) {
 		}
 		defer func() { _ = ln.Close() }()
 		errc := make(chan error, 1)
-		go runStartTLSServer(ln, newSrv(), errc)
+		peerc := make(chan *x509.Certificate, 1)
+		go runStartTLSServer(ln, newSrv(), errc, peerc)
 
 		conn, err := net.Dial("tcp", ln.Addr().String())
 		if err != nil {
@@ -240,6 +245,10 @@ func TestNFSConnection_StartTLS_MTLS(t *testing.T) {
 		if _, err := io.ReadFull(tc, got); err != nil {
 			t.Fatalf("encrypted read: %v", err)
 		}
+		// The verified certificate identifies the host to cert mapping rules.
+		if peer := <-peerc; peer == nil || peer.Subject.CommonName != "client" {
+			t.Fatalf("peerCert = %v, want the verified client certificate", peer)
+		}
 		if err := <-errc; err != nil {
 			t.Fatalf("server: %v", err)
 		}
@@ -253,7 +262,7 @@ func TestNFSConnection_StartTLS_MTLS(t *testing.T) {
 		}
 		defer func() { _ = ln.Close() }()
 		errc := make(chan error, 1)
-		go runStartTLSServer(ln, newSrv(), errc)
+		go runStartTLSServer(ln, newSrv(), errc, nil)
 
 		conn, err := net.Dial("tcp", ln.Addr().String())
 		if err != nil {
@@ -13,7 +13,7 @@ import (
 )
 
 // maxUDPDatagram bounds a single RPC-over-UDP message. The protocols served
-// over UDP (NLM, NSM, MOUNT) exchange only small fixed-size messages, so the
+// over UDP (NLM, NSM, MOUNT, RQUOTA) exchange only small fixed-size messages, so the
 // practical 64 KiB UDP datagram ceiling is far more than enough.
 const maxUDPDatagram = 65535
 
@@ -27,16 +27,16 @@ const rpcRecordMarkLen = 4
 // config does not set MaxRequestsPerConnection (mirrors its default).
 const defaultUDPHandlerLimit = 100
 
-// isUDPEnabled reports whether the UDP transport for NLM/NSM/MOUNT should be
+// isUDPEnabled reports whether the UDP transport for NLM/NSM/MOUNT/RQUOTA should be
 // started. Disabled unless adapters.nfs.udp.enabled is explicitly true.
 func (s *NFSAdapter) isUDPEnabled() bool {
 	return s.config.UDP.Enabled != nil && *s.config.UDP.Enabled
 }
 
 // startUDP binds the UDP transport on the NFS port and serves the lock-manager
-// auxiliary protocols (NLM, NSM) and MOUNT. NFS itself is never served over UDP
-// because READ/WRITE payloads do not fit a single datagram; only the small
-// lock/status/mount messages are. The listener is closed when ctx is cancelled.
+// auxiliary protocols (NLM, NSM), MOUNT and RQUOTA. NFS itself is never served
+// over UDP because READ/WRITE payloads do not fit a single datagram; only the
+// small lock/status/mount/quota messages are. The listener is closed when ctx is cancelled.
 func (s *NFSAdapter) startUDP(ctx context.Context) error {
 	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: s.config.Port})
 	if err != nil {
@@ -44,7 +44,7 @@ func (s *NFSAdapter) startUDP(ctx context.Context) error {
 	}
 	s.udpConn = conn
 
-	logger.Info("NFS UDP transport listening (NLM/NSM/MOUNT)", "port", s.config.Port)
+	logger.Info("NFS UDP transport listening (NLM/NSM/MOUNT/RQUOTA)", "port", s.config.Port)
 
 	// Close the socket on shutdown so the read loop unblocks and exits.
 	go func() {
@@ -57,7 +57,7 @@ func (s *NFSAdapter) startUDP(ctx context.Context) error {
 }
 
 // serveUDP reads datagrams and dispatches each one. A single stateless
-// pseudo-connection is reused for every datagram: the NLM/NSM/MOUNT handlers
+// pseudo-connection is reused for every datagram: the NLM/NSM/MOUNT/RQUOTA handlers
 // read only the adapter (s) and emit metrics — they never touch a net.Conn —
 // and UDP carries no per-connection state (no record marking, TLS, or DRC).
 func (s *NFSAdapter) serveUDP(ctx context.Context, conn *net.UDPConn) {
@@ -161,6 +161,14 @@ func (c *NFSConnection) handleUDPDatagram(ctx context.Context, conn *net.UDPConn
 		}
 		body, err = c.handleMountProcedure(ctx, call, procedureData, clientAddr)
 
+	case rpc.ProgramRQuota:
+		// quota(1) tries UDP first, so rquota is served here as well as on TCP.
+		if call.Version != rpc.RQuotaVersion1 && call.Version != rpc.RQuotaVersion2 {
+			c.writeUDPVersionMismatch(conn, src, call.XID, rpc.RQuotaVersion1, rpc.RQuotaVersion2)
+			return
+		}
+		body, err = c.handleRQuotaProcedure(ctx, call, procedureData, clientAddr, true)
+
 	default:
 		// NFS itself and anything else are not served over UDP.
 		c.writeUDPAcceptError(conn, src, call.XID, rpc.RPCProgUnavail)
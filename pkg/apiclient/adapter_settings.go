@@ -32,6 +32,11 @@ type NFSAdapterSettingsResponse struct {
 	PortmapperRegisterWithSystem bool     `json:"portmapper_register_with_system"`
 	UDPEnabled                   bool     `json:"udp_enabled"`
 	MDNSEnabled                  bool     `json:"mdns_enabled"`
+	IDMapDomain                  string   `json:"idmap_domain"`
+	IDMapNumericAuthSys          bool     `json:"idmap_numeric_auth_sys"`
+	PNFSDataServers              []string `json:"pnfs_data_servers"`
+	PNFSDataServerVersion        string   `json:"pnfs_data_server_version"`
+	RQuotaSetQuotaEnabled        bool     `json:"rquota_set_quota_enabled"`
 	Version                      int      `json:"version"`
 }
 
@@ -98,6 +103,11 @@ type PatchNFSSettingsRequest struct {
 	PortmapperRegisterWithSystem *bool     `json:"portmapper_register_with_system,omitempty"`
 	UDPEnabled                   *bool     `json:"udp_enabled,omitempty"`
 	MDNSEnabled                  *bool     `json:"mdns_enabled,omitempty"`
+	IDMapDomain                  *string   `json:"idmap_domain,omitempty"`
+	IDMapNumericAuthSys          *bool     `json:"idmap_numeric_auth_sys,omitempty"`
+	PNFSDataServers              *[]string `json:"pnfs_data_servers,omitempty"`
+	PNFSDataServerVersion        *string   `json:"pnfs_data_server_version,omitempty"`
+	RQuotaSetQuotaEnabled        *bool     `json:"rquota_set_quota_enabled,omitempty"`
 }
 
 // PatchSMBSettingsRequest uses pointer fields for partial updates.
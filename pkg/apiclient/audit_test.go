@@ -0,0 +1,28 @@
+package apiclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListAuditEvents_Filters verifies the filters are sent as query
+// parameters and the recorded documents decode as raw JSON.
+func TestListAuditEvents_Filters(t *testing.T) {
+	s := newStubServer(t)
+	defer s.Close()
+	s.reset()
+	s.body = []byte(`[{"id":"e1","time":"2026-10-18T10:00:00Z","actor":"admin","method":"PUT","path":"/api/v1/users/bob","resource":"users","status":200,"before":{"role":"user"},"after":{"role":"admin"},"changes":["role"]}]`)
+
+	client := newTestClient(s).WithToken("test-token")
+	got, err := client.ListAuditEvents(AuditListOptions{Since: "24h", Actor: "admin", Limit: 50})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, []string{"role"}, got[0].Changes)
+	assert.JSONEq(t, `{"role":"admin"}`, string(got[0].After))
+
+	calls := s.observedCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "/api/v1/audit?actor=admin&limit=50&since=24h", calls[0].Path)
+}
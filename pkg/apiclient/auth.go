@@ -8,6 +8,7 @@ import (
 type LoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	OTP      string `json:"otp,omitempty"`
 }
 
 // TokenResponse represents the response from login/refresh endpoints.
@@ -24,11 +25,19 @@ func (t *TokenResponse) ExpiresInDuration() time.Duration {
 	return time.Duration(t.ExpiresIn) * time.Second
 }
 
-// Login authenticates with the server and returns tokens.
+// Login authenticates with the server and returns tokens. For a user
+// enrolled in TOTP it fails with an APIError for which IsMFARequired is true;
+// retry with LoginWithOTP.
 func (c *Client) Login(username, password string) (*TokenResponse, error) {
+	return c.LoginWithOTP(username, password, "")
+}
+
+// LoginWithOTP authenticates with a password and a TOTP code.
+func (c *Client) LoginWithOTP(username, password, otp string) (*TokenResponse, error) {
 	req := LoginRequest{
 		Username: username,
 		Password: password,
+		OTP:      otp,
 	}
 
 	var resp TokenResponse
@@ -59,3 +68,86 @@ func (c *Client) RefreshToken(refreshToken string) (*TokenResponse, error) {
 func (c *Client) Logout() error {
 	return c.post("/api/v1/auth/logout", nil, nil)
 }
+
+// OIDCInfo describes the server's OpenID Connect login configuration.
+type OIDCInfo struct {
+	Issuer                string   `json:"issuer"`
+	ClientID              string   `json:"client_id"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	Scopes                []string `json:"scopes"`
+	DeviceFlow            bool     `json:"device_flow"`
+}
+
+// OIDCLoginResponse is the response of an OIDC login: the DittoFS tokens
+// and the user the IdP identity was mapped to.
+type OIDCLoginResponse struct {
+	TokenResponse
+	User User `json:"user"`
+}
+
+// OIDCDeviceAuthorization is a pending device-code login.
+type OIDCDeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// GetOIDCInfo returns the server's OIDC login parameters. The server answers
+// 404 when OIDC login is not enabled.
+func (c *Client) GetOIDCInfo() (*OIDCInfo, error) {
+	var info OIDCInfo
+	if err := c.get("/api/v1/auth/oidc", &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// OIDCLogin redeems an authorization code obtained with PKCE for DittoFS
+// tokens.
+func (c *Client) OIDCLogin(code, codeVerifier, redirectURI, nonce string) (*OIDCLoginResponse, error) {
+	req := struct {
+		Code         string `json:"code"`
+		CodeVerifier string `json:"code_verifier"`
+		RedirectURI  string `json:"redirect_uri"`
+		Nonce        string `json:"nonce"`
+	}{code, codeVerifier, redirectURI, nonce}
+
+	var resp OIDCLoginResponse
+	if err := c.post("/api/v1/auth/oidc/token", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// StartOIDCDeviceLogin starts a device-code login.
+func (c *Client) StartOIDCDeviceLogin() (*OIDCDeviceAuthorization, error) {
+	var resp OIDCDeviceAuthorization
+	if err := c.post("/api/v1/auth/oidc/device", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PollOIDCDeviceLogin polls a device-code login once. While the user has not
+// yet approved it, it returns a nil response and the pending status
+// ("authorization_pending" or "slow_down").
+func (c *Client) PollOIDCDeviceLogin(deviceCode string) (*OIDCLoginResponse, string, error) {
+	req := struct {
+		DeviceCode string `json:"device_code"`
+	}{deviceCode}
+
+	var resp struct {
+		OIDCLoginResponse
+		Status string `json:"status"`
+	}
+	if err := c.post("/api/v1/auth/oidc/device/token", req, &resp); err != nil {
+		return nil, "", err
+	}
+	if resp.Status != "" {
+		return nil, resp.Status, nil
+	}
+	return &resp.OIDCLoginResponse, "", nil
+}
@@ -145,3 +145,40 @@ func TestTokenResponse_ExpiresInDuration(t *testing.T) {
 	duration := resp.ExpiresInDuration()
 	assert.Equal(t, time.Hour, duration)
 }
+
+func TestPollOIDCDeviceLogin(t *testing.T) {
+	approved := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/auth/oidc/device/token", r.URL.Path)
+		var req map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "dev-1", req["device_code"])
+
+		if !approved {
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(map[string]string{"status": "authorization_pending"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "access-token-123",
+			"refresh_token": "refresh-token-456",
+			"token_type":    "Bearer",
+			"user":          map[string]any{"username": "alice", "role": "admin"},
+		})
+	}))
+	defer server.Close()
+
+	client := New(server.URL)
+	resp, status, err := client.PollOIDCDeviceLogin("dev-1")
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, "authorization_pending", status)
+
+	approved = true
+	resp, status, err = client.PollOIDCDeviceLogin("dev-1")
+	require.NoError(t, err)
+	assert.Empty(t, status)
+	require.NotNil(t, resp)
+	assert.Equal(t, "access-token-123", resp.AccessToken)
+	assert.Equal(t, "alice", resp.User.Username)
+}
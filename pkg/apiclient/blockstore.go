@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 
+	"github.com/marmos91/dittofs/pkg/block"
 	"github.com/marmos91/dittofs/pkg/block/engine"
 )
 
@@ -68,10 +69,13 @@ type WarmJobStatus struct {
 	BlocksTotal int64  `json:"blocks_total"`
 	BlocksDone  int64  `json:"blocks_done"`
 	BytesDone   int64  `json:"bytes_done"`
-	StartedAt   string `json:"started_at,omitempty"`
-	FinishedAt  string `json:"finished_at,omitempty"`
-	Error       string `json:"error,omitempty"`
-	Warning     string `json:"warning,omitempty"`
+	// Selector and FilesSelected are set for a selective warm.
+	Selector      *block.WarmSelector `json:"selector,omitempty"`
+	FilesSelected int64               `json:"files_selected,omitempty"`
+	StartedAt     string              `json:"started_at,omitempty"`
+	FinishedAt    string              `json:"finished_at,omitempty"`
+	Error         string              `json:"error,omitempty"`
+	Warning       string              `json:"warning,omitempty"`
 }
 
 // warmStartResponse is the 202 body from POST .../blockstore/warm.
@@ -81,13 +85,14 @@ type warmStartResponse struct {
 }
 
 // StartShareWarm starts (or returns the already-running) async warm job that
-// materializes the named share's blocks onto its local tier. Returns the job
-// id to poll via GetShareWarm.
-func (c *Client) StartShareWarm(name string) (string, error) {
+// materializes the named share's blocks onto its local tier. A non-zero sel
+// warms only the files it selects. Returns the job id to poll via
+// GetShareWarm.
+func (c *Client) StartShareWarm(name string, sel block.WarmSelector) (string, error) {
 	resp, err := createResource[warmStartResponse](
 		c,
 		fmt.Sprintf("/api/v1/shares/%s/blockstore/warm", url.PathEscape(normalizeShareNameForAPI(name))),
-		struct{}{},
+		sel,
 	)
 	if err != nil {
 		return "", err
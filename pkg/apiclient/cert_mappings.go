@@ -0,0 +1,56 @@
+package apiclient
+
+import (
+	"time"
+)
+
+// CertMapping represents an NFS-over-TLS client certificate mapping rule.
+type CertMapping struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Priority     int       `json:"priority"`
+	Field        string    `json:"field"`
+	Pattern      string    `json:"pattern"`
+	Shares       []string  `json:"shares,omitempty"`
+	Squash       string    `json:"squash,omitempty"`
+	AnonymousUID *uint32   `json:"anonymous_uid,omitempty"`
+	AnonymousGID *uint32   `json:"anonymous_gid,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CreateCertMappingRequest is the request to create a cert mapping rule.
+type CreateCertMappingRequest struct {
+	Name         string   `json:"name"`
+	Priority     int      `json:"priority"`
+	Field        string   `json:"field"`
+	Pattern      string   `json:"pattern"`
+	Shares       []string `json:"shares,omitempty"`
+	Squash       string   `json:"squash,omitempty"`
+	AnonymousUID *uint32  `json:"anonymous_uid,omitempty"`
+	AnonymousGID *uint32  `json:"anonymous_gid,omitempty"`
+}
+
+// ListCertMappings returns all cert mapping rules in evaluation order.
+func (c *Client) ListCertMappings() ([]*CertMapping, error) {
+	var mappings []*CertMapping
+	if err := c.get("/api/v1/adapters/nfs/cert-mappings", &mappings); err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+// GetCertMapping returns a cert mapping rule by name.
+func (c *Client) GetCertMapping(name string) (*CertMapping, error) {
+	return getResource[CertMapping](c, resourcePath("/api/v1/adapters/nfs/cert-mappings/%s", name))
+}
+
+// CreateCertMapping creates a new cert mapping rule.
+func (c *Client) CreateCertMapping(req *CreateCertMappingRequest) (*CertMapping, error) {
+	return createResource[CertMapping](c, "/api/v1/adapters/nfs/cert-mappings", req)
+}
+
+// RemoveCertMapping deletes a cert mapping rule by name.
+func (c *Client) RemoveCertMapping(name string) error {
+	return deleteResource(c, resourcePath("/api/v1/adapters/nfs/cert-mappings/%s", name))
+}
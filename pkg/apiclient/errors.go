@@ -61,6 +61,17 @@ func (e *APIError) IsAuthError() bool {
 	return e.StatusCode == 401 || e.StatusCode == 403
 }
 
+// IsMFARequired returns true if a login needs a one-time code.
+func (e *APIError) IsMFARequired() bool {
+	return e.StatusCode == 401 && e.Code == "mfa_required"
+}
+
+// IsAccountLocked returns true if a login was refused because the account is
+// locked out after failed logins.
+func (e *APIError) IsAccountLocked() bool {
+	return e.Code == "account_locked"
+}
+
 // IsNotFound returns true if this is a not found error.
 func (e *APIError) IsNotFound() bool {
 	return e.StatusCode == 404
@@ -0,0 +1,153 @@
+package apiclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ShareEvent is one entry of a share's change-event stream. Type is
+// "created", "removed", "renamed" or "modified", or "reset" when the stream
+// could not resume from the requested cursor and the share should be
+// rescanned.
+type ShareEvent struct {
+	// ID is the resumable cursor of the event.
+	ID       string    `json:"id"`
+	Time     time.Time `json:"time"`
+	Share    string    `json:"share"`
+	Type     string    `json:"type"`
+	Path     string    `json:"path"`
+	OldPath  string    `json:"old_path,omitempty"`
+	FileType string    `json:"file_type,omitempty"`
+	Size     uint64    `json:"size,omitempty"`
+}
+
+// ShareEventsOptions filters StreamShareEvents. Zero fields do not filter;
+// an empty Cursor starts from now.
+type ShareEventsOptions struct {
+	Types      []string
+	PathPrefix string
+	Cursor     string
+}
+
+// StreamShareEvents follows a share's change events, calling fn for each,
+// until ctx ends or fn returns an error. A dropped connection is re-opened
+// from the last cursor received, like a browser EventSource.
+func (c *Client) StreamShareEvents(ctx context.Context, share string, opts ShareEventsOptions, fn func(ShareEvent) error) error {
+	if c.tlsErr != nil {
+		return c.tlsErr
+	}
+	// The stream is long-lived: keep the transport, drop the client timeout.
+	hc := &http.Client{Transport: c.httpClient.Transport}
+
+	q := url.Values{}
+	if len(opts.Types) > 0 {
+		q.Set("types", strings.Join(opts.Types, ","))
+	}
+	if opts.PathPrefix != "" {
+		q.Set("path_prefix", opts.PathPrefix)
+	}
+	path := fmt.Sprintf("/api/v1/shares/%s/events", url.PathEscape(normalizeShareNameForAPI(share)))
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	cursor := opts.Cursor
+	for {
+		err := c.streamOnce(ctx, hc, path, &cursor, fn)
+		if ctx.Err() != nil {
+			return nil
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return err
+		}
+		var cbErr callbackError
+		if errors.As(err, &cbErr) {
+			return cbErr.error
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(3 * time.Second):
+		}
+	}
+}
+
+// callbackError marks an error returned by the caller's fn.
+type callbackError struct{ error }
+
+// streamOnce reads one connection's worth of events, advancing *cursor.
+func (c *Client) streamOnce(ctx context.Context, hc *http.Client, path string, cursor *string, fn func(ShareEvent) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if *cursor != "" {
+		req.Header.Set("Last-Event-ID", *cursor)
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		var apiErr APIError
+		if json.Unmarshal(body, &apiErr) == nil && (apiErr.Title != "" || apiErr.Detail != "") {
+			apiErr.StatusCode = resp.StatusCode
+			return &apiErr
+		}
+		return &APIError{StatusCode: resp.StatusCode, Detail: string(body)}
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	var id, name, data string
+	for sc.Scan() {
+		line := sc.Text()
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "id":
+				id = value
+			case "event":
+				name = value
+			case "data":
+				data = value
+			}
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		ev := ShareEvent{ID: id, Type: name}
+		if name != "reset" {
+			if err := json.Unmarshal([]byte(data), &ev); err != nil {
+				return fmt.Errorf("failed to decode event: %w", err)
+			}
+		}
+		*cursor = id
+		if err := fn(ev); err != nil {
+			return callbackError{err}
+		}
+		id, name, data = "", "", ""
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
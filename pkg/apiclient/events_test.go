@@ -0,0 +1,64 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamShareEvents_ReconnectsFromLastCursor(t *testing.T) {
+	var conns atomic.Int32
+	var resumedFrom atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/shares/media/events", r.URL.Path)
+		assert.Equal(t, "created", r.URL.Query().Get("types"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		if conns.Add(1) == 1 {
+			fmt.Fprint(w, "retry: 3000\n\n")
+			fmt.Fprint(w, "id: e-1\nevent: reset\ndata: {\"cursor\":\"e-1\"}\n\n")
+			fmt.Fprint(w, "id: e-2\nevent: created\ndata: {\"id\":\"e-2\",\"type\":\"created\",\"share\":\"/media\",\"path\":\"/a.mov\"}\n\n")
+			return // connection drops
+		}
+		resumedFrom.Store(r.Header.Get("Last-Event-ID"))
+		fmt.Fprint(w, "id: e-3\nevent: created\ndata: {\"id\":\"e-3\",\"type\":\"created\",\"share\":\"/media\",\"path\":\"/b.mov\"}\n\n")
+	}))
+	defer srv.Close()
+
+	stop := errors.New("stop")
+	var got []ShareEvent
+	err := New(srv.URL).StreamShareEvents(context.Background(), "/media", ShareEventsOptions{Types: []string{"created"}},
+		func(ev ShareEvent) error {
+			got = append(got, ev)
+			if len(got) == 3 {
+				return stop
+			}
+			return nil
+		})
+	require.ErrorIs(t, err, stop)
+	require.Len(t, got, 3)
+	assert.Equal(t, "reset", got[0].Type)
+	assert.Equal(t, "/a.mov", got[1].Path)
+	assert.Equal(t, "/b.mov", got[2].Path)
+	assert.Equal(t, "e-2", resumedFrom.Load())
+}
+
+func TestStreamShareEvents_APIErrorIsFinal(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"title":"Service Unavailable","detail":"change events are not enabled"}`)
+	}))
+	defer srv.Close()
+
+	err := New(srv.URL).StreamShareEvents(context.Background(), "media", ShareEventsOptions{}, func(ShareEvent) error { return nil })
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+}
@@ -32,6 +32,8 @@ type LDAPProviderConfig struct {
 	BindDN          string        `json:"bind_dn"`
 	BindPassword    string        `json:"bind_password,omitempty"`
 	UserAttr        string        `json:"user_attr"`
+	UserObjectClass string        `json:"user_object_class,omitempty"`
+	MatchUsername   bool          `json:"match_username,omitempty"`
 	Realm           string        `json:"realm"`
 	Idmap           string        `json:"idmap"`
 	NestedGroups    bool          `json:"nested_groups"`
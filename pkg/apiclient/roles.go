@@ -0,0 +1,81 @@
+package apiclient
+
+import (
+	"net/url"
+	"time"
+)
+
+// Role is a custom role: a named set of permissions such as "quotas:write".
+type Role struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RoleRequest creates or updates a role. On update, nil fields are left
+// unchanged.
+type RoleRequest struct {
+	Name        string    `json:"name,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Permissions *[]string `json:"permissions,omitempty"`
+}
+
+// RoleBinding grants a role to a user or group, optionally limited to shares.
+type RoleBinding struct {
+	ID        string    `json:"id"`
+	Role      string    `json:"role"`
+	User      string    `json:"user,omitempty"`
+	Group     string    `json:"group,omitempty"`
+	Shares    []string  `json:"shares,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RoleBindingRequest binds a role. Set exactly one of User and Group; an
+// empty Shares list binds the role on every share.
+type RoleBindingRequest struct {
+	User   string   `json:"user,omitempty"`
+	Group  string   `json:"group,omitempty"`
+	Shares []string `json:"shares,omitempty"`
+}
+
+// ListRoles returns all custom roles.
+func (c *Client) ListRoles() ([]Role, error) {
+	return listResources[Role](c, "/api/v1/roles")
+}
+
+// GetRole returns a custom role by name.
+func (c *Client) GetRole(name string) (*Role, error) {
+	return getResource[Role](c, resourcePath("/api/v1/roles/%s", url.PathEscape(name)))
+}
+
+// CreateRole creates a custom role.
+func (c *Client) CreateRole(req *RoleRequest) (*Role, error) {
+	return createResource[Role](c, "/api/v1/roles", req)
+}
+
+// UpdateRole updates a custom role.
+func (c *Client) UpdateRole(name string, req *RoleRequest) (*Role, error) {
+	return updateResource[Role](c, resourcePath("/api/v1/roles/%s", url.PathEscape(name)), req)
+}
+
+// DeleteRole deletes a custom role and its bindings.
+func (c *Client) DeleteRole(name string) error {
+	return deleteResource(c, resourcePath("/api/v1/roles/%s", url.PathEscape(name)))
+}
+
+// ListRoleBindings returns the bindings of a role.
+func (c *Client) ListRoleBindings(role string) ([]RoleBinding, error) {
+	return listResources[RoleBinding](c, resourcePath("/api/v1/roles/%s/bindings", url.PathEscape(role)))
+}
+
+// BindRole grants a role to a user or group.
+func (c *Client) BindRole(role string, req *RoleBindingRequest) (*RoleBinding, error) {
+	return createResource[RoleBinding](c, resourcePath("/api/v1/roles/%s/bindings", url.PathEscape(role)), req)
+}
+
+// UnbindRole removes a role binding by ID.
+func (c *Client) UnbindRole(role, id string) error {
+	return deleteResource(c, resourcePath("/api/v1/roles/%s/bindings/%s", url.PathEscape(role), url.PathEscape(id)))
+}
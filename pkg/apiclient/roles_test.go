@@ -0,0 +1,63 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateRole_RoundTrip verifies CreateRole posts name and permissions
+// and decodes the created role.
+func TestCreateRole_RoundTrip(t *testing.T) {
+	s := newStubServer(t)
+	defer s.Close()
+	s.reset()
+	s.status = http.StatusCreated
+	s.body = []byte(`{"id":"r1","name":"project-lead","permissions":["quotas:write","trash:write"],"created_at":"2026-10-18T10:00:00Z"}`)
+
+	perms := []string{"quotas:write", "trash:write"}
+	client := newTestClient(s).WithToken("test-token")
+	got, err := client.CreateRole(&RoleRequest{Name: "project-lead", Permissions: &perms})
+	require.NoError(t, err)
+	assert.Equal(t, "project-lead", got.Name)
+	assert.Equal(t, perms, got.Permissions)
+
+	calls := s.observedCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, http.MethodPost, calls[0].Method)
+	assert.Equal(t, "/api/v1/roles", calls[0].Path)
+	var sent map[string]any
+	require.NoError(t, json.Unmarshal(calls[0].Body, &sent))
+	assert.NotContains(t, sent, "description")
+}
+
+// TestBindRole_RoundTrip verifies BindRole and UnbindRole hit the role's
+// bindings sub-resource.
+func TestBindRole_RoundTrip(t *testing.T) {
+	s := newStubServer(t)
+	defer s.Close()
+	s.reset()
+	s.status = http.StatusCreated
+	s.body = []byte(`{"id":"b1","role":"project-lead","user":"alice","shares":["/projects"],"created_at":"2026-10-18T10:00:00Z"}`)
+
+	client := newTestClient(s).WithToken("test-token")
+	got, err := client.BindRole("project-lead", &RoleBindingRequest{User: "alice", Shares: []string{"/projects"}})
+	require.NoError(t, err)
+	assert.Equal(t, "b1", got.ID)
+	assert.Equal(t, []string{"/projects"}, got.Shares)
+
+	calls := s.observedCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "/api/v1/roles/project-lead/bindings", calls[0].Path)
+
+	s.reset()
+	s.status = http.StatusNoContent
+	require.NoError(t, client.UnbindRole("project-lead", "b1"))
+	calls = s.observedCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, http.MethodDelete, calls[0].Method)
+	assert.Equal(t, "/api/v1/roles/project-lead/bindings/b1", calls[0].Path)
+}
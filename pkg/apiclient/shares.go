@@ -2,9 +2,12 @@ package apiclient
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/marmos91/dittofs/pkg/block"
 )
 
 // normalizeShareNameForAPI strips all leading slashes from share names for API URLs.
@@ -68,6 +71,14 @@ type Share struct {
 	TrashRestrictToAdmin bool     `json:"trash_restrict_to_admin"`
 	TrashMaxBytes        int64    `json:"trash_max_bytes"`
 	TrashExcludePatterns []string `json:"trash_exclude_patterns,omitempty"`
+	// Bandwidth is the share's remote bandwidth policy; nil when unlimited.
+	Bandwidth *block.BandwidthPolicy `json:"bandwidth,omitempty"`
+	// WarmPolicy is the share's automatic pre-warm policy; nil when none.
+	WarmPolicy *block.WarmPolicy `json:"warm_policy,omitempty"`
+	// File-access audit trail; FileAuditOperations is empty when the
+	// default set applies.
+	FileAuditEnabled    bool     `json:"file_audit_enabled"`
+	FileAuditOperations []string `json:"file_audit_operations,omitempty"`
 	// OwnerUID/OwnerGID report the persisted root-directory owner (#1534).
 	// Nil means root-owned.
 	OwnerUID  *uint32   `json:"owner_uid,omitempty"`
@@ -119,6 +130,14 @@ type CreateShareRequest struct {
 	TrashRestrictToAdmin *bool    `json:"trash_restrict_to_admin,omitempty"`
 	TrashMaxBytes        *int64   `json:"trash_max_bytes,omitempty"`
 	TrashExcludePatterns []string `json:"trash_exclude_patterns,omitempty"`
+	// Bandwidth caps the share's remote upload/download rates, optionally by
+	// time of day. nil = unlimited.
+	Bandwidth *block.BandwidthPolicy `json:"bandwidth,omitempty"`
+	// WarmPolicy re-warms the share's local tier automatically. nil = none.
+	WarmPolicy *block.WarmPolicy `json:"warm_policy,omitempty"`
+	// File-access audit trail. Empty operations = the default set.
+	FileAuditEnabled    *bool    `json:"file_audit_enabled,omitempty"`
+	FileAuditOperations []string `json:"file_audit_operations,omitempty"`
 }
 
 // UpdateShareRequest is the request to update a share.
@@ -161,19 +180,42 @@ type UpdateShareRequest struct {
 	TrashRestrictToAdmin *bool    `json:"trash_restrict_to_admin,omitempty"`
 	TrashMaxBytes        *int64   `json:"trash_max_bytes,omitempty"`
 	TrashExcludePatterns []string `json:"trash_exclude_patterns,omitempty"`
+	// Bandwidth replaces the share's bandwidth policy. nil = no change; an
+	// empty policy removes every cap. Applied live by the server.
+	Bandwidth *block.BandwidthPolicy `json:"bandwidth,omitempty"`
+	// WarmPolicy replaces the share's automatic pre-warm policy. nil = no
+	// change; an empty policy disables it.
+	WarmPolicy *block.WarmPolicy `json:"warm_policy,omitempty"`
+	// File-access audit trail. nil = no change; an empty operations list
+	// restores the default set. Applied live by the server.
+	FileAuditEnabled    *bool     `json:"file_audit_enabled,omitempty"`
+	FileAuditOperations *[]string `json:"file_audit_operations,omitempty"`
 }
 
 // ShareNFSConfig represents the per-share NFS adapter configuration. Netgroup
 // is exposed by name (empty = no association).
 type ShareNFSConfig struct {
-	Squash             string  `json:"squash"`
-	AnonymousUID       *uint32 `json:"anonymous_uid,omitempty"`
-	AnonymousGID       *uint32 `json:"anonymous_gid,omitempty"`
-	AllowAuthSys       bool    `json:"allow_auth_sys"`
-	RequireKerberos    bool    `json:"require_kerberos"`
-	MinKerberosLevel   string  `json:"min_kerberos_level"`
-	Netgroup           string  `json:"netgroup"`
-	DisableReaddirplus bool    `json:"disable_readdirplus"`
+	Squash             string           `json:"squash"`
+	AnonymousUID       *uint32          `json:"anonymous_uid,omitempty"`
+	AnonymousGID       *uint32          `json:"anonymous_gid,omitempty"`
+	AllowAuthSys       bool             `json:"allow_auth_sys"`
+	RequireKerberos    bool             `json:"require_kerberos"`
+	MinKerberosLevel   string           `json:"min_kerberos_level"`
+	Netgroup           string           `json:"netgroup"`
+	DisableReaddirplus bool             `json:"disable_readdirplus"`
+	Exports            []ShareNFSExport `json:"exports,omitempty"`
+}
+
+// ShareNFSExport is a subtree export entry: export rules for a directory
+// inside the share. Netgroup is a name; empty Netgroup and Squash inherit the
+// share's values.
+type ShareNFSExport struct {
+	Path         string  `json:"path"`
+	Netgroup     string  `json:"netgroup,omitempty"`
+	ReadOnly     bool    `json:"read_only"`
+	Squash       string  `json:"squash,omitempty"`
+	AnonymousUID *uint32 `json:"anonymous_uid,omitempty"`
+	AnonymousGID *uint32 `json:"anonymous_gid,omitempty"`
 }
 
 // PatchShareNFSConfigRequest is the request to update a share's NFS adapter
@@ -188,6 +230,8 @@ type PatchShareNFSConfigRequest struct {
 	MinKerberosLevel   *string `json:"min_kerberos_level,omitempty"`
 	Netgroup           *string `json:"netgroup,omitempty"`
 	DisableReaddirplus *bool   `json:"disable_readdirplus,omitempty"`
+	// Exports, when non-nil, replaces the subtree export list.
+	Exports *[]ShareNFSExport `json:"exports,omitempty"`
 }
 
 // SharePermission represents a permission on a share.
@@ -320,3 +364,94 @@ func (c *Client) EnableShare(name string) (*Share, error) {
 	}
 	return &share, nil
 }
+
+// MetadataMigrationStats counts what a metadata migration copied.
+type MetadataMigrationStats struct {
+	Directories    int64 `json:"directories"`
+	Files          int64 `json:"files"`
+	Entries        int64 `json:"entries"`
+	Xattrs         int64 `json:"xattrs"`
+	Chunks         int64 `json:"chunks"`
+	SyncedHashes   int64 `json:"synced_hashes"`
+	BlockRecords   int64 `json:"block_records"`
+	Locks          int64 `json:"locks"`
+	DurableHandles int64 `json:"durable_handles"`
+	SkippedEntries int64 `json:"skipped_entries"`
+}
+
+// MetadataMigrationResult is the response of MigrateShareMetadata.
+type MetadataMigrationResult struct {
+	Share             string                 `json:"share"`
+	From              string                 `json:"from"`
+	To                string                 `json:"to"`
+	RootHandleChanged bool                   `json:"root_handle_changed"`
+	Stats             MetadataMigrationStats `json:"stats"`
+	SourcePurged      bool                   `json:"source_purged"`
+	Warnings          []string               `json:"warnings,omitempty"`
+	DurationMS        int64                  `json:"duration_ms"`
+}
+
+// MigrateShareMetadata moves a disabled share's metadata to the metadata store
+// toStore (name or ID) and flips the share onto it. The copy can take a while
+// on large shares, so the call uses the restore timeout rather than the
+// client's default.
+func (c *Client) MigrateShareMetadata(name, toStore string) (*MetadataMigrationResult, error) {
+	timeout := c.restoreHTTPTimeout
+	if timeout <= 0 {
+		timeout = defaultRestoreHTTPTimeout
+	}
+	var res MetadataMigrationResult
+	path := fmt.Sprintf("/api/v1/shares/%s/migrate-metadata", url.PathEscape(normalizeShareNameForAPI(name)))
+	if err := c.doWithTimeout(http.MethodPost, path, map[string]string{"to": toStore}, &res, timeout); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// RehomeProgress counts what a remote re-home has moved so far.
+type RehomeProgress struct {
+	BlocksTotal    int64 `json:"blocks_total"`
+	BlocksDone     int64 `json:"blocks_done"`
+	BlocksMoved    int64 `json:"blocks_moved"`
+	BlocksPresent  int64 `json:"blocks_present"`
+	BlocksMissing  int64 `json:"blocks_missing"`
+	ChunksRepacked int64 `json:"chunks_repacked"`
+	BytesMoved     int64 `json:"bytes_moved"`
+}
+
+// RehomeJob is the status of a share's remote re-home, returned by
+// StartShareRehome and GetShareRehome.
+type RehomeJob struct {
+	ID         string         `json:"id"`
+	Share      string         `json:"share"`
+	From       string         `json:"from"`
+	To         string         `json:"to"`
+	Mode       string         `json:"mode"`
+	State      string         `json:"state"`
+	Phase      string         `json:"phase"`
+	Progress   RehomeProgress `json:"progress"`
+	Resumed    bool           `json:"resumed,omitempty"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// StartShareRehome starts moving the share's block data to the remote block
+// store toRemote (name or ID) while the share keeps serving, or resumes an
+// unfinished move to the same remote. mode is "copy", "repack" or empty for
+// the server's choice. Poll GetShareRehome for progress.
+func (c *Client) StartShareRehome(name, toRemote, mode string) (*RehomeJob, error) {
+	return createResource[RehomeJob](
+		c,
+		fmt.Sprintf("/api/v1/shares/%s/rehome", url.PathEscape(normalizeShareNameForAPI(name))),
+		map[string]string{"to": toRemote, "mode": mode},
+	)
+}
+
+// GetShareRehome returns the share's latest re-home job.
+func (c *Client) GetShareRehome(name string) (*RehomeJob, error) {
+	return getResource[RehomeJob](
+		c,
+		fmt.Sprintf("/api/v1/shares/%s/rehome", url.PathEscape(normalizeShareNameForAPI(name))),
+	)
+}
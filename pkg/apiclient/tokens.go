@@ -0,0 +1,50 @@
+package apiclient
+
+import (
+	"net/url"
+	"time"
+)
+
+// APIToken describes a service-account API token. Token holds the plaintext
+// secret and is only set in the response to CreateAPIToken.
+type APIToken struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	ServiceAccount string     `json:"service_account"`
+	Prefix         string     `json:"prefix"`
+	Scopes         []string   `json:"scopes"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy      string     `json:"created_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Token          string     `json:"token,omitempty"`
+}
+
+// CreateAPITokenRequest is the request to create an API token. The service
+// account is created on first use.
+type CreateAPITokenRequest struct {
+	Name           string     `json:"name"`
+	ServiceAccount string     `json:"service_account"`
+	Scopes         []string   `json:"scopes"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateAPIToken creates an API token and returns it with its plaintext
+// secret, which the server does not keep.
+func (c *Client) CreateAPIToken(req *CreateAPITokenRequest) (*APIToken, error) {
+	return createResource[APIToken](c, "/api/v1/tokens", req)
+}
+
+// ListAPITokens lists API tokens, optionally limited to one service account.
+func (c *Client) ListAPITokens(serviceAccount string) ([]APIToken, error) {
+	path := "/api/v1/tokens"
+	if serviceAccount != "" {
+		path += "?service_account=" + url.QueryEscape(serviceAccount)
+	}
+	return listResources[APIToken](c, path)
+}
+
+// RevokeAPIToken revokes an API token by ID.
+func (c *Client) RevokeAPIToken(id string) error {
+	return deleteResource(c, resourcePath("/api/v1/tokens/%s", url.PathEscape(id)))
+}
@@ -0,0 +1,64 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateAPIToken_RoundTrip verifies CreateAPIToken posts the request and
+// decodes the one-time plaintext token.
+func TestCreateAPIToken_RoundTrip(t *testing.T) {
+	s := newStubServer(t)
+	defer s.Close()
+	s.reset()
+	s.status = http.StatusCreated
+	s.body = []byte(`{"id":"t1","name":"ci","service_account":"terraform","prefix":"dfs_abcdefgh","scopes":["shares:write"],"created_at":"2026-10-18T10:00:00Z","token":"dfs_abcdefghsecret"}`)
+
+	client := newTestClient(s).WithToken("test-token")
+	got, err := client.CreateAPIToken(&CreateAPITokenRequest{
+		Name: "ci", ServiceAccount: "terraform", Scopes: []string{"shares:write"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "dfs_abcdefghsecret", got.Token)
+	assert.Equal(t, "terraform", got.ServiceAccount)
+
+	calls := s.observedCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, http.MethodPost, calls[0].Method)
+	assert.Equal(t, "/api/v1/tokens", calls[0].Path)
+	var sent map[string]any
+	require.NoError(t, json.Unmarshal(calls[0].Body, &sent))
+	assert.Equal(t, "terraform", sent["service_account"])
+	assert.NotContains(t, sent, "expires_at")
+}
+
+// TestListAPITokens_FilterAndRevoke verifies the service_account filter and
+// the revoke path.
+func TestListAPITokens_FilterAndRevoke(t *testing.T) {
+	s := newStubServer(t)
+	defer s.Close()
+	s.reset()
+	s.body = []byte(`[{"id":"t1","name":"ci","service_account":"terraform","prefix":"dfs_abcdefgh","scopes":["shares:read"],"created_at":"2026-10-18T10:00:00Z"}]`)
+
+	client := newTestClient(s).WithToken("test-token")
+	got, err := client.ListAPITokens("terraform")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Empty(t, got[0].Token)
+	calls := s.observedCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "/api/v1/tokens?service_account=terraform", calls[0].Path)
+
+	s.reset()
+	s.status = http.StatusNoContent
+	require.NoError(t, client.RevokeAPIToken("t1"))
+
+	calls = s.observedCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, http.MethodDelete, calls[0].Method)
+	assert.Equal(t, "/api/v1/tokens/t1", calls[0].Path)
+}
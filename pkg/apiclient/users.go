@@ -2,6 +2,7 @@ package apiclient
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 )
 
@@ -17,6 +18,9 @@ type User struct {
 	Groups             []string          `json:"groups,omitempty"`
 	Enabled            bool              `json:"enabled"`
 	MustChangePassword bool              `json:"must_change_password"`
+	ServiceAccount     bool              `json:"service_account,omitempty"`
+	TOTPEnabled        bool              `json:"totp_enabled,omitempty"`
+	LockedUntil        *time.Time        `json:"locked_until,omitempty"`
 	SharePermissions   map[string]string `json:"share_permissions,omitempty"`
 	CreatedAt          time.Time         `json:"created_at"`
 	LastLogin          *time.Time        `json:"last_login,omitempty"`
@@ -103,3 +107,42 @@ func (c *Client) ChangeOwnPassword(currentPassword, newPassword string) (*TokenR
 func (c *Client) GetCurrentUser() (*User, error) {
 	return getResource[User](c, "/api/v1/auth/me")
 }
+
+// TOTPEnrollment is a pending TOTP enrollment.
+type TOTPEnrollment struct {
+	// Secret is the base32 secret for manual entry.
+	Secret string `json:"secret"`
+	// URI is the otpauth:// URI for authenticator apps.
+	URI string `json:"uri"`
+}
+
+// EnrollTOTP starts TOTP enrollment for the current user. The code is not
+// required at login until ConfirmTOTP succeeds.
+func (c *Client) EnrollTOTP() (*TOTPEnrollment, error) {
+	var resp TOTPEnrollment
+	if err := c.post("/api/v1/users/me/totp", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ConfirmTOTP completes TOTP enrollment with a code from the authenticator.
+func (c *Client) ConfirmTOTP(code string) error {
+	return c.post("/api/v1/users/me/totp/confirm", map[string]string{"code": code}, nil)
+}
+
+// DisableTOTP removes the current user's TOTP enrollment; code is a current
+// one-time code.
+func (c *Client) DisableTOTP(code string) error {
+	return c.do(http.MethodDelete, "/api/v1/users/me/totp", map[string]string{"code": code}, nil)
+}
+
+// ResetUserTOTP removes a user's TOTP enrollment (admin operation).
+func (c *Client) ResetUserTOTP(username string) error {
+	return deleteResource(c, resourcePath("/api/v1/users/%s/totp", username))
+}
+
+// UnlockUser clears a lockout after failed logins (admin operation).
+func (c *Client) UnlockUser(username string) error {
+	return c.post(resourcePath("/api/v1/users/%s/unlock", username), nil, nil)
+}
@@ -0,0 +1,277 @@
+package block
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/bytesize"
+)
+
+// BandwidthRate is a remote transfer rate in bytes per second. 0 means
+// unlimited. It decodes from either a JSON number (bytes/sec) or a human
+// byte-size string ("20MB", "1.5GiB") so remote store configs and share
+// settings can be written by hand; it always encodes as a number.
+type BandwidthRate int64
+
+// UnmarshalJSON accepts a number of bytes/sec or a byte-size string.
+func (r *BandwidthRate) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		parsed, err := ParseBandwidthRate(s)
+		if err != nil {
+			return err
+		}
+		*r = parsed
+		return nil
+	}
+	var n float64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("bandwidth rate: expected number or byte-size string: %w", err)
+	}
+	if n < 0 || n != float64(int64(n)) {
+		return fmt.Errorf("bandwidth rate: expected non-negative integer bytes/sec, got %v", n)
+	}
+	*r = BandwidthRate(n)
+	return nil
+}
+
+// String renders the rate as a human byte size per second, or "unlimited".
+func (r BandwidthRate) String() string {
+	if r <= 0 {
+		return "unlimited"
+	}
+	return bytesize.ByteSize(r).String() + "/s"
+}
+
+// ParseBandwidthRate parses a byte-size string ("20MB", "0", "unlimited")
+// into a BandwidthRate. A trailing "/s" is accepted and ignored.
+func ParseBandwidthRate(s string) (BandwidthRate, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(s, "/s")
+	if s == "" || strings.EqualFold(s, "unlimited") {
+		return 0, nil
+	}
+	bs, err := bytesize.ParseByteSize(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth rate %q: %w", s, err)
+	}
+	return BandwidthRate(bs.Int64()), nil
+}
+
+// BandwidthLimit caps a remote's (or a share's) upload and download rates.
+// A zero field leaves that direction unlimited.
+type BandwidthLimit struct {
+	Upload   BandwidthRate `json:"upload,omitempty"`
+	Download BandwidthRate `json:"download,omitempty"`
+}
+
+// BandwidthWindow overrides the base limit during a recurring time-of-day
+// window, e.g. 20 MB/s uploads on weekdays from 08:00 to 18:00.
+type BandwidthWindow struct {
+	// Days restricts the window to these weekdays ("mon".."sun"). Empty
+	// means every day.
+	Days []string `json:"days,omitempty"`
+	// Start and End are "HH:MM" in server local time. End earlier than Start
+	// wraps past midnight (22:00-06:00); Start == End covers the whole day.
+	Start string `json:"start"`
+	End   string `json:"end"`
+
+	BandwidthLimit
+}
+
+// BandwidthPolicy is the complete bandwidth configuration for one remote
+// store or one share: a base limit plus an optional schedule whose first
+// matching window replaces it.
+type BandwidthPolicy struct {
+	BandwidthLimit
+	Schedule []BandwidthWindow `json:"schedule,omitempty"`
+}
+
+// IsZero reports whether the policy imposes no limit at any time.
+func (p BandwidthPolicy) IsZero() bool {
+	if p.Upload > 0 || p.Download > 0 {
+		return false
+	}
+	for _, w := range p.Schedule {
+		if w.Upload > 0 || w.Download > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks every schedule window's days and clock times.
+func (p BandwidthPolicy) Validate() error {
+	if p.Upload < 0 || p.Download < 0 {
+		return fmt.Errorf("bandwidth: rates must not be negative")
+	}
+	for i, w := range p.Schedule {
+		if _, err := w.matcher(); err != nil {
+			return fmt.Errorf("bandwidth schedule[%d]: %w", i, err)
+		}
+		if w.Upload < 0 || w.Download < 0 {
+			return fmt.Errorf("bandwidth schedule[%d]: rates must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// LimitAt returns the limit in force at t: the first schedule window covering
+// t, or the base limit when none does. It parses the schedule on every call;
+// callers evaluating the policy repeatedly should Compile it once instead.
+func (p BandwidthPolicy) LimitAt(t time.Time) BandwidthLimit {
+	return p.Compile().LimitAt(t)
+}
+
+// Compile parses the schedule windows once so the policy can be evaluated on
+// every transfer without re-parsing day names and clock times. Malformed
+// windows are dropped (Validate rejects them on the write path).
+func (p BandwidthPolicy) Compile() BandwidthSchedule {
+	s := BandwidthSchedule{base: p.BandwidthLimit}
+	for _, w := range p.Schedule {
+		m, err := w.matcher()
+		if err != nil {
+			continue
+		}
+		s.windows = append(s.windows, scheduledWindow{match: m, limit: w.BandwidthLimit})
+	}
+	return s
+}
+
+// BandwidthSchedule is a compiled BandwidthPolicy. The zero value is
+// unlimited at all times.
+type BandwidthSchedule struct {
+	base    BandwidthLimit
+	windows []scheduledWindow
+}
+
+type scheduledWindow struct {
+	match windowMatcher
+	limit BandwidthLimit
+}
+
+// LimitAt returns the limit in force at t: the first window covering t, or
+// the base limit when none does.
+func (s BandwidthSchedule) LimitAt(t time.Time) BandwidthLimit {
+	for _, w := range s.windows {
+		if w.match.covers(t) {
+			return w.limit
+		}
+	}
+	return s.base
+}
+
+// ParseBandwidthPolicy decodes a policy from a config value as stored in a
+// block store config map (a JSON object decoded to map[string]any) or a raw
+// JSON string. A nil value yields the zero (unlimited) policy.
+func ParseBandwidthPolicy(v any) (BandwidthPolicy, error) {
+	var p BandwidthPolicy
+	if v == nil {
+		return p, nil
+	}
+	var raw []byte
+	switch t := v.(type) {
+	case string:
+		if strings.TrimSpace(t) == "" {
+			return p, nil
+		}
+		raw = []byte(t)
+	case []byte:
+		raw = t
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return p, fmt.Errorf("bandwidth: %w", err)
+		}
+		raw = b
+	}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return BandwidthPolicy{}, fmt.Errorf("bandwidth: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return BandwidthPolicy{}, err
+	}
+	return p, nil
+}
+
+// windowMatcher is the parsed form of a BandwidthWindow.
+type windowMatcher struct {
+	days       [7]bool
+	start, end int // minutes since midnight
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday,
+	"wed": time.Wednesday, "thu": time.Thursday, "fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func (w BandwidthWindow) matcher() (windowMatcher, error) {
+	var m windowMatcher
+	if len(w.Days) == 0 {
+		for i := range m.days {
+			m.days[i] = true
+		}
+	}
+	for _, d := range w.Days {
+		key := strings.ToLower(strings.TrimSpace(d))
+		if len(key) > 3 {
+			key = key[:3]
+		}
+		wd, ok := weekdayNames[key]
+		if !ok {
+			return m, fmt.Errorf("invalid day %q (want mon..sun)", d)
+		}
+		m.days[wd] = true
+	}
+	var err error
+	if m.start, err = parseClock(w.Start); err != nil {
+		return m, fmt.Errorf("start: %w", err)
+	}
+	if m.end, err = parseClock(w.End); err != nil {
+		return m, fmt.Errorf("end: %w", err)
+	}
+	return m, nil
+}
+
+// covers reports whether t falls inside the window. For a window wrapping
+// past midnight the day filter applies to the day the window started on.
+func (m windowMatcher) covers(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	switch {
+	case m.start == m.end:
+		return m.days[day]
+	case m.start < m.end:
+		return m.days[day] && minute >= m.start && minute < m.end
+	case minute >= m.start:
+		return m.days[day]
+	case minute < m.end:
+		return m.days[(day+6)%7]
+	default:
+		return false
+	}
+}
+
+// parseClock parses "HH:MM" (24h) into minutes since midnight. "24:00" is
+// accepted as end-of-day.
+func parseClock(s string) (int, error) {
+	hh, mm, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	h, err1 := strconv.Atoi(hh)
+	m, err2 := strconv.Atoi(mm)
+	if err1 != nil || err2 != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return h*60 + m, nil
+}
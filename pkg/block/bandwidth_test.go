@@ -0,0 +1,113 @@
+package block
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBandwidthPolicy(t *testing.T) {
+	raw := map[string]any{
+		"upload":   "100MB",
+		"download": float64(0),
+		"schedule": []any{
+			map[string]any{
+				"days":   []any{"mon", "Tuesday", "wed", "thu", "fri"},
+				"start":  "08:00",
+				"end":    "18:00",
+				"upload": "20MB",
+			},
+			map[string]any{"start": "22:00", "end": "06:00", "upload": float64(1 << 20)},
+		},
+	}
+	p, err := ParseBandwidthPolicy(raw)
+	if err != nil {
+		t.Fatalf("ParseBandwidthPolicy: %v", err)
+	}
+	if p.Upload != 100_000_000 {
+		t.Errorf("base upload = %d, want 100MB", p.Upload)
+	}
+	if len(p.Schedule) != 2 {
+		t.Fatalf("schedule windows = %d, want 2", len(p.Schedule))
+	}
+
+	// Wednesday 2026-10-14.
+	wed := func(h, m int) time.Time { return time.Date(2026, 10, 14, h, m, 0, 0, time.Local) }
+	sat := func(h, m int) time.Time { return time.Date(2026, 10, 17, h, m, 0, 0, time.Local) }
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want BandwidthRate
+	}{
+		{"business hours", wed(9, 30), p.Schedule[0].Upload},
+		{"window end is exclusive", wed(18, 0), p.Upload},
+		{"weekend daytime uses base", sat(12, 0), p.Upload},
+		{"overnight before midnight", wed(23, 0), 1 << 20},
+		{"overnight after midnight", wed(5, 59), 1 << 20},
+		{"overnight window closed", wed(6, 0), p.Upload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.LimitAt(tt.at).Upload; got != tt.want {
+				t.Errorf("LimitAt(%s).Upload = %d, want %d", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBandwidthPolicy_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+	}{
+		{"bad day", map[string]any{"schedule": []any{map[string]any{"days": []any{"funday"}, "start": "08:00", "end": "09:00"}}}},
+		{"bad clock", map[string]any{"schedule": []any{map[string]any{"start": "8am", "end": "09:00"}}}},
+		{"minute out of range", map[string]any{"schedule": []any{map[string]any{"start": "08:60", "end": "09:00"}}}},
+		{"negative rate", map[string]any{"upload": float64(-1)}},
+		{"bad size string", map[string]any{"download": "fast"}},
+		{"not an object", "[1,2]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseBandwidthPolicy(tt.in); err == nil {
+				t.Errorf("ParseBandwidthPolicy(%v) succeeded, want error", tt.in)
+			}
+		})
+	}
+}
+
+func TestBandwidthPolicy_IsZero(t *testing.T) {
+	if !(BandwidthPolicy{}).IsZero() {
+		t.Error("empty policy should be zero")
+	}
+	p := BandwidthPolicy{Schedule: []BandwidthWindow{{Start: "00:00", End: "00:00", BandwidthLimit: BandwidthLimit{Download: 1}}}}
+	if p.IsZero() {
+		t.Error("policy with a capped window should not be zero")
+	}
+	if got := p.LimitAt(time.Now()).Download; got != 1 {
+		t.Errorf("all-day window: Download = %d, want 1", got)
+	}
+}
+
+func TestBandwidthPolicy_Compile(t *testing.T) {
+	p := BandwidthPolicy{
+		BandwidthLimit: BandwidthLimit{Upload: 100},
+		Schedule: []BandwidthWindow{
+			{Start: "8am", End: "09:00", BandwidthLimit: BandwidthLimit{Upload: 1}},
+			{Days: []string{"wed"}, Start: "08:00", End: "18:00", BandwidthLimit: BandwidthLimit{Upload: 20}},
+		},
+	}
+	s := p.Compile()
+
+	// Wednesday 2026-10-14; the malformed first window is dropped.
+	at := time.Date(2026, 10, 14, 8, 30, 0, 0, time.Local)
+	if got := s.LimitAt(at).Upload; got != 20 {
+		t.Errorf("compiled LimitAt(wed 08:30).Upload = %d, want 20", got)
+	}
+	if got := s.LimitAt(at.Add(24 * time.Hour)).Upload; got != 100 {
+		t.Errorf("compiled LimitAt(thu 08:30).Upload = %d, want base 100", got)
+	}
+	if got := (BandwidthSchedule{}).LimitAt(at); got != (BandwidthLimit{}) {
+		t.Errorf("zero schedule LimitAt = %+v, want unlimited", got)
+	}
+}
@@ -0,0 +1,377 @@
+package engine
+
+import (
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Access-history defaults. 64Ki tracked payloads keep the persisted file in
+// the low megabytes; a one-day half-life lets yesterday's working set fade
+// within a few days of going quiet.
+const (
+	DefaultAccessHistoryCapacity = 1 << 16
+	DefaultAccessHistoryHalfLife = 24 * time.Hour
+)
+
+// accessRenormalizeAt bounds the forward-decay weights: once the current
+// time's gain exceeds it, every weight is rescaled and the landmark moved.
+const accessRenormalizeAt = 1 << 32
+
+// AccessRecord is one payload's entry in an AccessHistory snapshot.
+type AccessRecord struct {
+	PayloadID string `json:"payload_id"`
+	// Score is the exponentially decayed read count as of the snapshot.
+	Score      float64   `json:"score"`
+	LastAccess time.Time `json:"last_access"`
+}
+
+// accessShards is the number of pending-hit shards Record spreads payloads
+// over, so concurrent reads of different payloads rarely share a lock.
+const accessShards = 64
+
+// accessFoldPending is the number of distinct payloads a shard buffers before
+// the Record that crosses it folds the pending hits into the ranking. Reads of
+// the snapshot (Snapshot, Len, Save) fold as well, so the ranking is current
+// whenever it is observed.
+const accessFoldPending = 1024
+
+// AccessHistory is a bounded access-frequency sketch of a share's payloads,
+// used to pick the hot working set for cache pre-warming. Each read bumps the
+// payload's score by one; scores halve every half-life (forward decay: a hit
+// at time t weighs 2^((t-landmark)/halfLife), so ranking never needs a
+// rescan). When capacity payloads are tracked, a new payload replaces the
+// lowest-scored one and inherits its score (space-saving), which can only
+// overestimate and keeps genuinely hot payloads from being displaced by a
+// scan of cold ones.
+//
+// Record sits on the ReadAt hot path, so it only counts the hit in a
+// per-payload shard buffer. The buffered hits are folded into the ranking
+// (and the heap rebalanced once per fold) when a shard fills up or the
+// ranking is read.
+//
+// Safe for concurrent use. Persisted with Save and restored with
+// LoadAccessHistory so the hot set survives a restart.
+type AccessHistory struct {
+	shards [accessShards]accessShard
+
+	// mu guards the ranking below; Record never takes it.
+	mu       sync.Mutex
+	capacity int
+	halfLife time.Duration
+	landmark time.Time
+	entries  map[string]*accessEntry
+	byWeight accessHeap
+	dirty    bool
+	now      func() time.Time
+}
+
+type accessEntry struct {
+	payloadID string
+	weight    float64
+	last      time.Time
+	index     int
+}
+
+// accessShard buffers hits recorded since the last fold.
+type accessShard struct {
+	mu      sync.Mutex
+	pending map[string]*pendingAccess
+}
+
+type pendingAccess struct {
+	hits int64
+	last time.Time
+}
+
+// NewAccessHistory returns an empty history. Non-positive arguments select
+// the defaults.
+func NewAccessHistory(capacity int, halfLife time.Duration) *AccessHistory {
+	if capacity <= 0 {
+		capacity = DefaultAccessHistoryCapacity
+	}
+	if halfLife <= 0 {
+		halfLife = DefaultAccessHistoryHalfLife
+	}
+	return &AccessHistory{
+		capacity: capacity,
+		halfLife: halfLife,
+		landmark: time.Now(),
+		entries:  make(map[string]*accessEntry),
+		now:      time.Now,
+	}
+}
+
+// gain returns the forward-decay weight of a hit at t. Caller holds mu.
+func (h *AccessHistory) gain(t time.Time) float64 {
+	return math.Exp2(float64(t.Sub(h.landmark)) / float64(h.halfLife))
+}
+
+// Record notes one read of payloadID.
+func (h *AccessHistory) Record(payloadID string) {
+	if h == nil || payloadID == "" {
+		return
+	}
+	now := h.now()
+
+	sh := &h.shards[accessShardOf(payloadID)]
+	sh.mu.Lock()
+	if sh.pending == nil {
+		sh.pending = make(map[string]*pendingAccess)
+	}
+	p := sh.pending[payloadID]
+	if p == nil {
+		p = &pendingAccess{}
+		sh.pending[payloadID] = p
+	}
+	p.hits++
+	p.last = now
+	full := len(sh.pending) >= accessFoldPending
+	sh.mu.Unlock()
+
+	// A concurrent fold drains this shard anyway, so never wait for one.
+	if full && h.mu.TryLock() {
+		h.foldLocked(now)
+		h.mu.Unlock()
+	}
+}
+
+// accessShardOf returns the shard index of payloadID (FNV-1a).
+func accessShardOf(payloadID string) int {
+	var x uint32 = 2166136261
+	for i := 0; i < len(payloadID); i++ {
+		x ^= uint32(payloadID[i])
+		x *= 16777619
+	}
+	return int(x % accessShards)
+}
+
+// foldLocked moves every buffered hit into the ranking. Weights of tracked
+// payloads are updated in place and the heap rebuilt once; new payloads then
+// take free slots or replace the current minimum. Caller holds mu.
+func (h *AccessHistory) foldLocked(now time.Time) {
+	var fresh []string
+	var freshHits []*pendingAccess
+	updated := false
+
+	if g := h.gain(now); g > accessRenormalizeAt {
+		// Uniform rescale: ordering (and so the heap) is unaffected.
+		for _, e := range h.entries {
+			e.weight /= g
+		}
+		h.landmark = now
+	}
+
+	for i := range h.shards {
+		sh := &h.shards[i]
+		sh.mu.Lock()
+		pending := sh.pending
+		sh.pending = nil
+		sh.mu.Unlock()
+
+		for id, p := range pending {
+			if e, ok := h.entries[id]; ok {
+				e.weight += float64(p.hits) * h.gain(p.last)
+				e.last = p.last
+				updated = true
+				continue
+			}
+			fresh = append(fresh, id)
+			freshHits = append(freshHits, p)
+		}
+	}
+	if !updated && len(fresh) == 0 {
+		return
+	}
+	h.dirty = true
+	if updated {
+		heap.Init(&h.byWeight)
+	}
+
+	for i, id := range fresh {
+		p := freshHits[i]
+		w := float64(p.hits) * h.gain(p.last)
+		if len(h.entries) < h.capacity {
+			e := &accessEntry{payloadID: id, weight: w, last: p.last}
+			h.entries[id] = e
+			heap.Push(&h.byWeight, e)
+			continue
+		}
+		victim := h.byWeight[0]
+		delete(h.entries, victim.payloadID)
+		victim.payloadID = id
+		victim.weight += w
+		victim.last = p.last
+		h.entries[id] = victim
+		heap.Fix(&h.byWeight, 0)
+	}
+}
+
+// Snapshot returns every tracked payload, hottest first.
+func (h *AccessHistory) Snapshot() []AccessRecord {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	h.foldLocked(now)
+	return h.snapshotLocked(now)
+}
+
+func (h *AccessHistory) snapshotLocked(now time.Time) []AccessRecord {
+	g := h.gain(now)
+	out := make([]AccessRecord, 0, len(h.entries))
+	for _, e := range h.entries {
+		out = append(out, AccessRecord{PayloadID: e.payloadID, Score: e.weight / g, LastAccess: e.last})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].PayloadID < out[j].PayloadID
+	})
+	return out
+}
+
+// Len returns the number of tracked payloads.
+func (h *AccessHistory) Len() int {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.foldLocked(h.now())
+	return len(h.entries)
+}
+
+// SetAccessHistory installs the sketch ReadAt records reads into. nil stops
+// recording.
+func (bs *Store) SetAccessHistory(h *AccessHistory) {
+	bs.access.Store(h)
+}
+
+// accessHistoryFile is the persisted form of an AccessHistory.
+type accessHistoryFile struct {
+	SavedAt time.Time      `json:"saved_at"`
+	Entries []AccessRecord `json:"entries"`
+}
+
+// Save writes the history to path atomically (.tmp + rename) when it changed
+// since the last Save or load. An empty path is a no-op.
+func (h *AccessHistory) Save(path string) error {
+	if h == nil || path == "" {
+		return nil
+	}
+	h.mu.Lock()
+	now := h.now()
+	h.foldLocked(now)
+	if !h.dirty {
+		h.mu.Unlock()
+		return nil
+	}
+	file := accessHistoryFile{SavedAt: now, Entries: h.snapshotLocked(now)}
+	h.dirty = false
+	h.mu.Unlock()
+
+	body, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("access history: marshal: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("access history: mkdir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		h.markDirty()
+		return fmt.Errorf("access history: write: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		h.markDirty()
+		return fmt.Errorf("access history: rename: %w", err)
+	}
+	return nil
+}
+
+func (h *AccessHistory) markDirty() {
+	h.mu.Lock()
+	h.dirty = true
+	h.mu.Unlock()
+}
+
+// LoadAccessHistory restores a history saved at path, decaying its scores by
+// the time elapsed since the save. A missing file yields an empty history;
+// an unreadable one is reported alongside an empty history so callers can
+// log and carry on.
+func LoadAccessHistory(path string, capacity int, halfLife time.Duration) (*AccessHistory, error) {
+	h := NewAccessHistory(capacity, halfLife)
+	if path == "" {
+		return h, nil
+	}
+	body, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return h, fmt.Errorf("access history: read: %w", err)
+	}
+	var file accessHistoryFile
+	if err := json.Unmarshal(body, &file); err != nil {
+		return h, fmt.Errorf("access history: decode %s: %w", path, err)
+	}
+
+	now := h.now()
+	h.landmark = now
+	decay := 1.0
+	if elapsed := now.Sub(file.SavedAt); elapsed > 0 {
+		decay = math.Exp2(-float64(elapsed) / float64(h.halfLife))
+	}
+	// Entries are stored hottest first, so a smaller capacity keeps the
+	// hottest ones.
+	for _, rec := range file.Entries {
+		if len(h.entries) >= h.capacity {
+			break
+		}
+		if rec.PayloadID == "" || h.entries[rec.PayloadID] != nil {
+			continue
+		}
+		e := &accessEntry{payloadID: rec.PayloadID, weight: rec.Score * decay, last: rec.LastAccess}
+		h.entries[rec.PayloadID] = e
+		heap.Push(&h.byWeight, e)
+	}
+	return h, nil
+}
+
+// accessHeap is a min-heap of entries by weight.
+type accessHeap []*accessEntry
+
+func (q accessHeap) Len() int           { return len(q) }
+func (q accessHeap) Less(i, j int) bool { return q[i].weight < q[j].weight }
+func (q accessHeap) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *accessHeap) Push(x any) {
+	e := x.(*accessEntry)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *accessHeap) Pop() any {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return e
+}
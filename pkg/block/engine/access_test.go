@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestAccessHistory(capacity int, clock *time.Time) *AccessHistory {
+	h := NewAccessHistory(capacity, time.Hour)
+	h.landmark = *clock
+	h.now = func() time.Time { return *clock }
+	return h
+}
+
+func TestAccessHistory_RanksByDecayedFrequency(t *testing.T) {
+	clock := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	h := newTestAccessHistory(16, &clock)
+
+	for i := 0; i < 8; i++ {
+		h.Record("old")
+	}
+	clock = clock.Add(4 * time.Hour) // "old" decays to 8/16 = 0.5
+	h.Record("new")
+	h.Record("new")
+
+	snap := h.Snapshot()
+	if len(snap) != 2 || snap[0].PayloadID != "new" {
+		t.Fatalf("snapshot = %+v, want new first", snap)
+	}
+	if got := snap[1].Score; got < 0.49 || got > 0.51 {
+		t.Fatalf("decayed score of old = %v, want 0.5", got)
+	}
+}
+
+func TestAccessHistory_BoundedKeepsHotEntries(t *testing.T) {
+	clock := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	h := newTestAccessHistory(4, &clock)
+
+	for i := 0; i < 50; i++ {
+		h.Record("hot")
+	}
+	// A scan of many cold payloads must not displace the hot one.
+	for i := 0; i < 100; i++ {
+		h.Record(fmt.Sprintf("scan-%d", i))
+	}
+	if h.Len() != 4 {
+		t.Fatalf("Len = %d, want capacity 4", h.Len())
+	}
+	if snap := h.Snapshot(); snap[0].PayloadID != "hot" {
+		t.Fatalf("hottest = %s, want hot", snap[0].PayloadID)
+	}
+}
+
+// TestAccessHistory_ConcurrentRecord checks that hits buffered by concurrent
+// readers, including ones that trigger a fold mid-stream, are all counted.
+func TestAccessHistory_ConcurrentRecord(t *testing.T) {
+	clock := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	h := newTestAccessHistory(1<<16, &clock)
+
+	const readers, perReader = 8, 3 * accessFoldPending
+	var wg sync.WaitGroup
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perReader; i++ {
+				h.Record("hot")
+				h.Record(fmt.Sprintf("cold-%d", i))
+			}
+		}()
+	}
+	wg.Wait()
+
+	snap := h.Snapshot()
+	if len(snap) != perReader+1 {
+		t.Fatalf("tracked = %d, want %d", len(snap), perReader+1)
+	}
+	if snap[0].PayloadID != "hot" || snap[0].Score != readers*perReader {
+		t.Fatalf("hottest = %+v, want hot with score %d", snap[0], readers*perReader)
+	}
+	for _, rec := range snap[1:] {
+		if rec.Score != readers {
+			t.Fatalf("%s score = %v, want %d", rec.PayloadID, rec.Score, readers)
+		}
+	}
+}
+
+func TestAccessHistory_SaveLoad(t *testing.T) {
+	clock := time.Now()
+	h := newTestAccessHistory(8, &clock)
+	h.Record("a")
+	h.Record("a")
+	h.Record("b")
+
+	path := filepath.Join(t.TempDir(), "access-history.json")
+	if err := h.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := LoadAccessHistory(path, 8, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadAccessHistory: %v", err)
+	}
+	snap := loaded.Snapshot()
+	if len(snap) != 2 || snap[0].PayloadID != "a" || snap[1].PayloadID != "b" {
+		t.Fatalf("loaded snapshot = %+v", snap)
+	}
+
+	missing, err := LoadAccessHistory(filepath.Join(t.TempDir(), "none.json"), 8, time.Hour)
+	if err != nil || missing.Len() != 0 {
+		t.Fatalf("missing file: len=%d err=%v", missing.Len(), err)
+	}
+}
@@ -0,0 +1,182 @@
+package engine
+
+import (
+	"context"
+	gosync "sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/marmos91/dittofs/pkg/block"
+)
+
+// minBandwidthBurst is the smallest token-bucket burst a BandwidthLimiter uses.
+// The burst is otherwise one second of the configured rate; the floor keeps a
+// very low cap (a few KB/s) from splitting every block into thousands of waits.
+const minBandwidthBurst = 256 << 10
+
+// BandwidthLimiter throttles remote transfer bytes against a block.BandwidthPolicy.
+// One limiter exists per share and one per shared remote store; a Syncer waits
+// on every limiter wired to it (SetBandwidthLimiters), so the tighter of the
+// per-share and per-remote caps wins and the per-remote cap is shared by every
+// share uploading to that remote.
+//
+// The policy can be swapped at runtime (SetPolicy, driven by the settings
+// watcher). The schedule is evaluated lazily on each wait, so a time-of-day
+// window takes effect on the first transfer after it opens without a ticker
+// goroutine per limiter.
+type BandwidthLimiter struct {
+	mu       gosync.Mutex
+	policy   block.BandwidthPolicy
+	schedule block.BandwidthSchedule // policy, compiled once per SetPolicy
+	current  block.BandwidthLimit
+	upload   *rate.Limiter
+	download *rate.Limiter
+
+	// now is the clock used to evaluate the schedule. Tests replace it.
+	now func() time.Time
+}
+
+// NewBandwidthLimiter returns a limiter enforcing policy. A zero policy is
+// unlimited and costs one mutex round-trip per wait.
+func NewBandwidthLimiter(policy block.BandwidthPolicy) *BandwidthLimiter {
+	l := &BandwidthLimiter{
+		upload:   rate.NewLimiter(rate.Inf, minBandwidthBurst),
+		download: rate.NewLimiter(rate.Inf, minBandwidthBurst),
+		now:      time.Now,
+	}
+	l.SetPolicy(policy)
+	return l
+}
+
+// SetPolicy replaces the enforced policy. Waiters already parked keep their
+// reservation; subsequent waits use the new rate.
+func (l *BandwidthLimiter) SetPolicy(policy block.BandwidthPolicy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.policy = policy
+	l.schedule = policy.Compile()
+	l.applyLocked(l.schedule.LimitAt(l.now()))
+}
+
+// Policy returns the policy currently enforced.
+func (l *BandwidthLimiter) Policy() block.BandwidthPolicy {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.policy
+}
+
+// Current returns the limit in force right now (after schedule evaluation).
+func (l *BandwidthLimiter) Current() block.BandwidthLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.applyLocked(l.schedule.LimitAt(l.now()))
+	return l.current
+}
+
+// WaitUpload blocks until n upload bytes fit under the current cap, or ctx is
+// done. A nil limiter never blocks.
+func (l *BandwidthLimiter) WaitUpload(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+	return l.wait(ctx, n, true)
+}
+
+// WaitDownload blocks until n download bytes fit under the current cap, or
+// ctx is done. A nil limiter never blocks.
+func (l *BandwidthLimiter) WaitDownload(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+	return l.wait(ctx, n, false)
+}
+
+func (l *BandwidthLimiter) wait(ctx context.Context, n int, upload bool) error {
+	l.mu.Lock()
+	l.applyLocked(l.schedule.LimitAt(l.now()))
+	lim := l.download
+	if upload {
+		lim = l.upload
+	}
+	l.mu.Unlock()
+
+	if lim.Limit() == rate.Inf || n <= 0 {
+		return nil
+	}
+	// WaitN rejects n larger than the burst, so a 16 MiB block is admitted in
+	// burst-sized slices. Each slice re-reads the burst because a concurrent
+	// SetPolicy may have resized it.
+	for n > 0 {
+		step := min(n, lim.Burst())
+		if err := lim.WaitN(ctx, step); err != nil {
+			return err
+		}
+		n -= step
+	}
+	return nil
+}
+
+// applyLocked pushes limit into the token buckets when it differs from the
+// one currently applied. Caller holds l.mu.
+func (l *BandwidthLimiter) applyLocked(limit block.BandwidthLimit) {
+	if limit == l.current {
+		return
+	}
+	l.current = limit
+	setBandwidthRate(l.upload, limit.Upload)
+	setBandwidthRate(l.download, limit.Download)
+}
+
+func setBandwidthRate(lim *rate.Limiter, r block.BandwidthRate) {
+	if r <= 0 {
+		lim.SetLimit(rate.Inf)
+		return
+	}
+	lim.SetBurst(max(int(r), minBandwidthBurst))
+	lim.SetLimit(rate.Limit(r))
+}
+
+// SetBandwidthLimiters wires the byte-rate limiters remote transfers must pass
+// (typically the share's own limiter and its remote store's shared one). Nil
+// entries are skipped; calling with none removes all caps. Safe to call at any
+// time; in-flight transfers finish under the limiters they started with.
+func (m *Syncer) SetBandwidthLimiters(limiters ...*BandwidthLimiter) {
+	wired := make([]*BandwidthLimiter, 0, len(limiters))
+	for _, l := range limiters {
+		if l != nil {
+			wired = append(wired, l)
+		}
+	}
+	m.bandwidth.Store(&wired)
+}
+
+// waitUploadBandwidth blocks until n bytes may be uploaded under every wired
+// limiter.
+func (m *Syncer) waitUploadBandwidth(ctx context.Context, n int) error {
+	return waitBandwidth(ctx, m.bandwidth.Load(), n, true)
+}
+
+// waitDownloadBandwidth blocks until n bytes may be downloaded under every
+// wired limiter.
+func (m *Syncer) waitDownloadBandwidth(ctx context.Context, n int) error {
+	return waitBandwidth(ctx, m.bandwidth.Load(), n, false)
+}
+
+// waitBandwidth blocks until n bytes fit under every limiter in ls (nil means
+// none), in the upload or download direction.
+func waitBandwidth(ctx context.Context, ls *[]*BandwidthLimiter, n int, upload bool) error {
+	if ls == nil {
+		return nil
+	}
+	for _, l := range *ls {
+		wait := l.WaitDownload
+		if upload {
+			wait = l.WaitUpload
+		}
+		if err := wait(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
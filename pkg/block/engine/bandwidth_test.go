@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/block"
+)
+
+func TestBandwidthLimiter_NilAndUnlimitedNeverBlock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // any real wait would fail on a cancelled context
+
+	var nilLim *BandwidthLimiter
+	if err := nilLim.WaitUpload(ctx, 64<<20); err != nil {
+		t.Fatalf("nil limiter WaitUpload: %v", err)
+	}
+	l := NewBandwidthLimiter(block.BandwidthPolicy{})
+	if err := l.WaitDownload(ctx, 64<<20); err != nil {
+		t.Fatalf("unlimited WaitDownload: %v", err)
+	}
+}
+
+func TestBandwidthLimiter_ThrottlesAboveBurst(t *testing.T) {
+	const rate = 1 << 20 // 1 MiB/s
+	l := NewBandwidthLimiter(block.BandwidthPolicy{BandwidthLimit: block.BandwidthLimit{Upload: rate}})
+
+	// The bucket starts full (one second of rate), so the first MiB is free
+	// and the next half MiB must wait ~0.5s.
+	start := time.Now()
+	if err := l.WaitUpload(context.Background(), rate+rate/2); err != nil {
+		t.Fatalf("WaitUpload: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("1.5 MiB at 1 MiB/s took %v, want >= ~500ms", elapsed)
+	}
+
+	// Downloads are not capped by an upload-only policy.
+	start = time.Now()
+	if err := l.WaitDownload(context.Background(), 8*rate); err != nil {
+		t.Fatalf("WaitDownload: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("uncapped download waited %v", elapsed)
+	}
+}
+
+func TestBandwidthLimiter_ScheduleAndSetPolicy(t *testing.T) {
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.Local) // Wednesday
+	policy := block.BandwidthPolicy{
+		Schedule: []block.BandwidthWindow{{
+			Days:           []string{"mon", "tue", "wed", "thu", "fri"},
+			Start:          "08:00",
+			End:            "18:00",
+			BandwidthLimit: block.BandwidthLimit{Upload: 20_000_000},
+		}},
+	}
+	l := NewBandwidthLimiter(policy)
+	l.now = func() time.Time { return now }
+
+	if got := l.Current().Upload; got != 20_000_000 {
+		t.Fatalf("business-hours upload cap = %d, want 20MB", got)
+	}
+	now = now.Add(10 * time.Hour) // 19:00, window closed
+	if got := l.Current().Upload; got != 0 {
+		t.Fatalf("after-hours upload cap = %d, want unlimited", got)
+	}
+
+	l.SetPolicy(block.BandwidthPolicy{BandwidthLimit: block.BandwidthLimit{Download: 5_000_000}})
+	if got := l.Current(); got.Upload != 0 || got.Download != 5_000_000 {
+		t.Fatalf("after SetPolicy Current() = %+v, want download-only 5MB", got)
+	}
+}
+
+func TestSyncer_WaitBandwidthHonoursCancellation(t *testing.T) {
+	m := &Syncer{}
+	// Unwired syncer: no limiters, never blocks.
+	if err := m.waitUploadBandwidth(context.Background(), 1<<30); err != nil {
+		t.Fatalf("unwired waitUploadBandwidth: %v", err)
+	}
+
+	tight := NewBandwidthLimiter(block.BandwidthPolicy{BandwidthLimit: block.BandwidthLimit{Upload: 1024, Download: 1024}})
+	m.SetBandwidthLimiters(nil, tight)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	// 64 MiB at 1 KiB/s cannot complete before the deadline.
+	if err := m.waitDownloadBandwidth(ctx, 64<<20); err == nil {
+		t.Fatal("waitDownloadBandwidth should fail once the context deadline makes the wait impossible")
+	}
+}
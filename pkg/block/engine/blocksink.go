@@ -163,6 +163,9 @@ type engineBlockSink struct {
 	rbs         remote.RemoteBlockStore
 	committer   blockCommitter
 	commitLocks *carveCommitLocks
+	// throttle, when set, blocks until the block's bytes fit under the
+	// syncer's upload bandwidth caps (SetBandwidthLimiters).
+	throttle func(ctx context.Context, n int) error
 }
 
 func (s engineBlockSink) CommitBlock(ctx context.Context, chunks []journal.CarveChunk) error {
@@ -226,6 +229,11 @@ func (s engineBlockSink) CommitBlock(ctx context.Context, chunks []journal.Carve
 	blockBytes := buf.Bytes()
 	blockHash := block.ContentHash(blake3.Sum256(blockBytes))
 
+	if s.throttle != nil {
+		if err := s.throttle(ctx, len(blockBytes)); err != nil {
+			return fmt.Errorf("carve: bandwidth wait: %w", err)
+		}
+	}
 	// PutBlock first: a crash before the commit leaves an orphan block (GC
 	// reclaims it), never an unbacked record.
 	if err := s.rbs.PutBlock(ctx, blockID, bytes.NewReader(blockBytes)); err != nil {
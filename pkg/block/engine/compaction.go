@@ -87,6 +87,11 @@ type CompactOptions struct {
 	LiveRatio float64
 	// DryRun reports candidates without moving or deleting anything.
 	DryRun bool
+	// Bandwidth holds, indexed like views, the byte-rate limiters each view's
+	// block GETs and PUTs must pass (typically its share's limiter and the
+	// remote's shared one, as wired by SetBandwidthLimiters). Missing or nil
+	// entries leave that view uncapped.
+	Bandwidth [][]*BandwidthLimiter
 }
 
 // CompactReport is the output of a compaction pass.
@@ -133,7 +138,12 @@ func CompactBlocks(
 		return report, nil // remote cannot hold blocks, or compaction disabled
 	}
 
-	for _, v := range views {
+	for i, v := range views {
+		var limiters *[]*BandwidthLimiter
+		if i < len(opts.Bandwidth) {
+			limiters = &opts.Bandwidth[i]
+		}
+
 		// Live bytes per block: sum the WireLength of every live synced locator.
 		// Single scan: EnumerateSynced yields each marker's locator alongside its
 		// hash (same row), so no GetLocator round trip per hash — the O(N) serial
@@ -167,7 +177,7 @@ func CompactBlocks(
 			if err := ctx.Err(); err != nil {
 				return report, err
 			}
-			compactOneBlock(ctx, v, rbs, blockID, opts, &report)
+			compactOneBlock(ctx, v, rbs, limiters, blockID, opts, &report)
 		}
 	}
 	return report, nil
@@ -179,6 +189,7 @@ func compactOneBlock(
 	ctx context.Context,
 	v CompactMetaView,
 	rbs remote.RemoteBlockStore,
+	limiters *[]*BandwidthLimiter,
 	blockID string,
 	opts CompactOptions,
 	report *CompactReport,
@@ -193,6 +204,11 @@ func compactOneBlock(
 		return // already gone (raced a concurrent reclaim in the same run)
 	}
 
+	if err := waitBandwidth(ctx, limiters, int(rec.Length), false); err != nil {
+		slog.Warn("compaction: bandwidth wait failed — skipping", "block_id", blockID, "err", err)
+		report.Errors++
+		return
+	}
 	data, err := rbs.GetBlock(ctx, blockID)
 	if err != nil {
 		if errors.Is(err, block.ErrChunkNotFound) {
@@ -298,6 +314,11 @@ func compactOneBlock(
 	}
 	newBytes := buf.Bytes()
 
+	if err := waitBandwidth(ctx, limiters, len(newBytes), true); err != nil {
+		slog.Warn("compaction: bandwidth wait failed — skipping", "block_id", blockID, "err", err)
+		report.Errors++
+		return
+	}
 	// (1) PutBlock — orphan object on crash (reconcile class 3), never data loss.
 	if err := rbs.PutBlock(ctx, newID, bytes.NewReader(newBytes)); err != nil {
 		slog.Warn("compaction: put new block failed — skipping", "block_id", blockID, "new_block_id", newID, "err", err)
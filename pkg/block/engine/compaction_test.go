@@ -183,6 +183,71 @@ func TestCompactBlocks_PartiallyDeadBlock(t *testing.T) {
 }
 
 // TestCompactBlocks_SkipsHealthyBlock proves a block above the live-ratio
+// TestCompactBlocks_RespectsBandwidthCap: compaction's block GET and PUT wait
+// on the view's limiters, so a low cap (a business-hours WAN schedule) slows
+// the pass instead of being bypassed, and a wait that cannot finish before the
+// deadline leaves the block intact.
+func TestCompactBlocks_RespectsBandwidthCap(t *testing.T) {
+	ctx := t.Context()
+	const rate = 2000 // bytes/s
+	seed := func() (*metadatamemory.MemoryMetadataStore, remote.RemoteBlockStore) {
+		st := metadatamemory.NewMemoryMetadataStoreWithDefaults()
+		rbs := remotememory.New()
+		t.Cleanup(func() { _ = rbs.Close() })
+		hashes := seedRealPackedBlock(t, st, rbs, "blk-capped", [][]byte{
+			bytes.Repeat([]byte("L"), 100), bytes.Repeat([]byte("D"), 300),
+		})
+		killChunk(t, st, "blk-capped", hashes[1])
+		return st, rbs
+	}
+	drained := func() *BandwidthLimiter {
+		l := NewBandwidthLimiter(block.BandwidthPolicy{BandwidthLimit: block.BandwidthLimit{Upload: rate, Download: rate}})
+		// Empty the initial burst so every further byte waits at rate.
+		if err := l.WaitUpload(ctx, minBandwidthBurst); err != nil {
+			t.Fatalf("drain upload: %v", err)
+		}
+		if err := l.WaitDownload(ctx, minBandwidthBurst); err != nil {
+			t.Fatalf("drain download: %v", err)
+		}
+		return l
+	}
+
+	// A ~400 byte GET plus a ~100 byte PUT at 2000 B/s takes >= ~250ms.
+	st, rbs := seed()
+	start := time.Now()
+	rep, err := CompactBlocks(ctx, []CompactMetaView{st}, rbs, CompactOptions{
+		LiveRatio: 0.5,
+		Bandwidth: [][]*BandwidthLimiter{{drained()}},
+	})
+	if err != nil {
+		t.Fatalf("CompactBlocks: %v", err)
+	}
+	if rep.BlocksCompacted != 1 || rep.Errors != 0 {
+		t.Fatalf("report = %+v; want 1 compacted, 0 errors", rep)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("capped compaction took %v, want >= ~250ms", elapsed)
+	}
+
+	// A wait that cannot fit before the deadline skips the block untouched.
+	st, rbs = seed()
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	rep, err = CompactBlocks(short, []CompactMetaView{st}, rbs, CompactOptions{
+		LiveRatio: 0.5,
+		Bandwidth: [][]*BandwidthLimiter{{drained()}},
+	})
+	if err != nil {
+		t.Fatalf("CompactBlocks: %v", err)
+	}
+	if rep.BlocksCompacted != 0 || rep.Errors != 1 {
+		t.Fatalf("report = %+v; want 0 compacted, 1 error", rep)
+	}
+	if _, ok, _ := st.GetBlockRecord(ctx, "blk-capped"); !ok {
+		t.Error("block record removed although compaction was throttled out")
+	}
+}
+
 // threshold is left untouched.
 func TestCompactBlocks_SkipsHealthyBlock(t *testing.T) {
 	ctx := t.Context()
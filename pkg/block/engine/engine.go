@@ -82,6 +82,11 @@ type Store struct {
 	// read it. Nil pointer (the zero value) until set; call sites guard.
 	metrics atomic.Pointer[DataplaneMetrics]
 
+	// access is the share's access-frequency sketch, fed by ReadAt and used
+	// to pick pre-warm targets. Owned by the share so it survives a rebind;
+	// nil (the zero value) disables recording.
+	access atomic.Pointer[AccessHistory]
+
 	// widened to EngineFileChunkStore so populateBlockCounts
 	// can call ListFileChunks (engine-internal method not on the public
 	// FileChunkStore surface).
@@ -546,6 +551,16 @@ func (bs *Store) WarmAll(ctx context.Context, progress func(done, total int64))
 	return bs.syncer.WarmAll(ctx, progress)
 }
 
+// WarmPayloads is WarmAll restricted to payloadIDs, under the same close
+// gate. See (*Syncer).WarmPayloads.
+func (bs *Store) WarmPayloads(ctx context.Context, payloadIDs []string, progress func(done, total int64)) (WarmResult, error) {
+	if err := bs.enter(); err != nil {
+		return WarmResult{}, err
+	}
+	defer bs.closeMu.RUnlock()
+	return bs.syncer.WarmPayloads(ctx, payloadIDs, progress)
+}
+
 // loadCache returns the current cache under cacheMu. Always non-nil (Null
 // Object pattern). Callers invoke the returned interface's methods OUTSIDE
 // the lock — cacheMu only guards the field read.
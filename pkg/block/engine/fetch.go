@@ -213,7 +213,17 @@ func (m *Syncer) resolveLocator(ctx context.Context, hash block.ContentHash) (bl
 func (m *Syncer) readChunkVerified(ctx context.Context, loc block.ChunkLocator, hash block.ContentHash) ([]byte, error) {
 	// remote.RemoteStore embeds ChunkReader, so ranged block reads are always
 	// available — no capability probe needed.
+	if err := m.waitDownloadBandwidth(ctx, int(loc.WireLength)); err != nil {
+		return nil, err
+	}
 	data, err := m.remoteStore.ReadChunk(ctx, loc.BlockID, loc.WireOffset, loc.WireLength, hash)
+	if errors.Is(err, block.ErrChunkNotFound) {
+		// Dual-read during a re-home: the block has not been copied to the
+		// new remote yet, so serve it from the previous one.
+		if fb := m.fallback.Load(); fb != nil {
+			data, err = fb.reader.ReadChunk(ctx, loc.BlockID, loc.WireOffset, loc.WireLength, hash)
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,759 @@
+// Package engine — fsck
+//
+// Fsck is the offline consistency checker behind `dfs fsck`. It cross-checks
+// one share's namespace (parents, link counts, the PayloadID index, the
+// #recycle bin) against its FileChunk manifest, the block record store, the
+// local journal and the remote block store, and reports every inconsistency
+// it finds by category.
+//
+// Unlike AuditRefcounts, which runs online and only checks the manifest
+// invariant, Fsck assumes exclusive access: the server must be stopped, so
+// there are no open handles, no in-flight carves and no concurrent commits.
+// That is what makes the repair categories safe to fix without the open-handle
+// hold or the grace window the online reconcile needs.
+//
+// The scan is read-only. With FsckOptions.Repair set, fixes for the safe
+// categories are queued during the scan and applied after it completes, so a
+// repair never perturbs a directory listing that is still being paged.
+// Categories whose fix could lose data (a dangling manifest ref, a missing
+// remote block) are report-only; block-record and remote-object leaks are
+// left to the block GC (`dfsctl store block gc`), which owns remote deletes.
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/marmos91/dittofs/pkg/block/remote"
+	// justification: like AuditRefcounts, fsck walks the share namespace and
+	// the FileChunk manifest, which only metadata.Store exposes.
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// FsckLocalView is the local-tier surface fsck needs: the payloads that have
+// bytes in the share's journal, and a way to drop one. *fs.FSStore satisfies
+// it.
+type FsckLocalView interface {
+	ListFiles(ctx context.Context) []string
+	Delete(ctx context.Context, payloadID string) error
+}
+
+// FsckInput names the stores one fsck run inspects.
+type FsckInput struct {
+	// Share is the share whose namespace is walked.
+	Share string
+	// Store is the share's metadata store.
+	Store metadata.Store
+	// Local is the share's journal. Nil for a non-persistent local tier;
+	// the journal checks are then skipped.
+	Local FsckLocalView
+	// Remote is the share's remote block store. Nil for a local-only share;
+	// the remote checks are then skipped.
+	Remote remote.RemoteBlockStore
+	// RemoteSiblings are the metadata stores of every OTHER share bound to
+	// Remote. Their block records are unioned in so a sibling's live block is
+	// never reported as an orphan remote object.
+	RemoteSiblings []metadata.Store
+}
+
+// FsckOptions parameterizes a run.
+type FsckOptions struct {
+	// Repair applies the fixes for the safe categories after the scan.
+	Repair bool
+	// GracePeriod protects recently uploaded remote objects from being
+	// reported as orphans. Non-positive falls back to the GC default (1h).
+	GracePeriod time.Duration
+	// SampleCap bounds the per-category sample. Zero defaults to
+	// defaultReconcileSampleCap. Counts are always exact.
+	SampleCap int
+}
+
+// FsckClass is one fsck category's tally, a bounded sample of the affected
+// paths or IDs, and how many were repaired.
+type FsckClass struct {
+	Count     int64    `json:"count"`
+	Repaired  int64    `json:"repaired,omitempty"`
+	Sample    []string `json:"sample,omitempty"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+func (c *FsckClass) add(id string, cap int) {
+	c.Count++
+	if len(c.Sample) < cap {
+		c.Sample = append(c.Sample, id)
+	} else {
+		c.Truncated = true
+	}
+}
+
+// FsckReport is the outcome of one fsck run.
+type FsckReport struct {
+	Share       string    `json:"share"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	DurationMS  int64     `json:"duration_ms"`
+	Repair      bool      `json:"repair"`
+
+	DirectoriesScanned   int64 `json:"directories_scanned"`
+	FilesScanned         int64 `json:"files_scanned"`
+	ChunkRefsScanned     int64 `json:"chunk_refs_scanned"`
+	BlockRecordsScanned  int64 `json:"block_records_scanned"`
+	RemoteObjectsScanned int64 `json:"remote_objects_scanned"`
+
+	// DanglingEntries: directory entries whose target inode is gone.
+	// Repair removes the entry.
+	DanglingEntries FsckClass `json:"dangling_entries"`
+	// ParentMismatches: a directory (or singly-linked file) whose parent
+	// pointer is not the directory that lists it. Repair rewrites the pointer.
+	ParentMismatches FsckClass `json:"parent_mismatches"`
+	// NlinkMismatches: a stored link count that disagrees with the entries
+	// found in the walk (2 + subdirectories for a directory). Repair stores
+	// the observed count.
+	NlinkMismatches FsckClass `json:"nlink_mismatches"`
+	// PayloadIndexMismatches: a file whose PayloadID does not resolve back to
+	// it. Repair re-puts the file when the index entry is simply missing; a
+	// payload claimed by another file is report-only.
+	PayloadIndexMismatches FsckClass `json:"payload_index_mismatches"`
+	// DanglingChunkRefs: manifest refs (File.Blocks) with no FileChunk row
+	// at that offset and hash — the silent-data-loss class. Report-only.
+	DanglingChunkRefs FsckClass `json:"dangling_chunk_refs"`
+	// UnprojectedManifests: files whose File.Blocks lags their FileChunk
+	// rows. Repair re-projects the rows, which are authoritative.
+	UnprojectedManifests FsckClass `json:"unprojected_manifests"`
+	// UnbackedChunks: FileChunk rows neither synced to the remote nor present
+	// in the journal, so no tier holds their bytes. Report-only.
+	UnbackedChunks FsckClass `json:"unbacked_chunks"`
+	// DanglingLocators: synced locators pointing at a block with no block
+	// record. Report-only.
+	DanglingLocators FsckClass `json:"dangling_locators"`
+	// MissingRemoteBlocks: block records whose object is absent from the
+	// remote store. Report-only.
+	MissingRemoteBlocks FsckClass `json:"missing_remote_blocks"`
+	// OrphanedChunks: FileChunk rows whose payload no live inode references.
+	// Repair reaps the rows; the next GC pass reclaims their bytes.
+	OrphanedChunks FsckClass `json:"orphaned_chunks"`
+	// OrphanedJournalFiles: journal payloads no live inode references.
+	// Repair deletes them from the journal.
+	OrphanedJournalFiles FsckClass `json:"orphaned_journal_files"`
+	// OrphanedRecycleEntries: nodes in #recycle with no DeletedAt stamp on
+	// themselves or an ancestor, so the bin can neither list nor reap them.
+	// Repair stamps them as deleted now.
+	OrphanedRecycleEntries FsckClass `json:"orphaned_recycle_entries"`
+	// StaleRecycleStamps: live nodes outside #recycle still carrying a
+	// DeletedAt stamp (a recycle move that failed mid-way). Repair clears it.
+	StaleRecycleStamps FsckClass `json:"stale_recycle_stamps"`
+
+	// Block-record leaks, as classified by Reconcile. Report-only: the block
+	// GC reclaims them.
+	ZeroRefRecords      FsckClass `json:"zero_ref_records"`
+	LeakedBlocks        FsckClass `json:"leaked_blocks"`
+	OrphanRemoteObjects FsckClass `json:"orphan_remote_objects"`
+
+	// RepairErrors counts fixes that were attempted and failed.
+	RepairErrors int64 `json:"repair_errors,omitempty"`
+}
+
+// classes returns every category, for totals.
+func (r *FsckReport) classes() []*FsckClass {
+	return []*FsckClass{
+		&r.DanglingEntries, &r.ParentMismatches, &r.NlinkMismatches,
+		&r.PayloadIndexMismatches, &r.DanglingChunkRefs, &r.UnprojectedManifests,
+		&r.UnbackedChunks, &r.DanglingLocators, &r.MissingRemoteBlocks,
+		&r.OrphanedChunks, &r.OrphanedJournalFiles, &r.OrphanedRecycleEntries,
+		&r.StaleRecycleStamps, &r.ZeroRefRecords, &r.LeakedBlocks,
+		&r.OrphanRemoteObjects,
+	}
+}
+
+// Problems is the total number of inconsistencies found.
+func (r *FsckReport) Problems() int64 {
+	var n int64
+	for _, c := range r.classes() {
+		n += c.Count
+	}
+	return n
+}
+
+// Unresolved is the number of inconsistencies still present after repair.
+func (r *FsckReport) Unresolved() int64 {
+	var n int64
+	for _, c := range r.classes() {
+		n += c.Count - c.Repaired
+	}
+	return n
+}
+
+// fsckFix is a queued repair, applied after the scan.
+type fsckFix struct {
+	class *FsckClass
+	id    string
+	apply func(ctx context.Context) error
+}
+
+// fsckNode is one inode seen during the namespace walk.
+type fsckNode struct {
+	handle  metadata.FileHandle
+	path    string
+	file    *metadata.File
+	links   uint32 // directory entries naming this inode
+	subdirs uint32 // for directories: child directories
+	parent  metadata.FileHandle
+}
+
+type fsckRun struct {
+	in     FsckInput
+	opts   FsckOptions
+	cap    int
+	report *FsckReport
+	fixes  []fsckFix
+
+	ownRecords map[string]struct{}
+	allRecords map[string]struct{}
+	remoteIDs  map[string]struct{}
+	journalIDs map[string]struct{}
+	nodes      map[string]*fsckNode
+	order      []*fsckNode
+}
+
+// fsckSubdir is a directory queued for descent with its bin context.
+type fsckSubdir struct {
+	node    *fsckNode
+	inBin   bool
+	stamped bool
+}
+
+// Fsck checks one share for consistency across its metadata store, journal and
+// remote block store, optionally repairing the safe categories. It must only
+// run against a stopped server: it takes no locks and assumes nothing else
+// mutates the stores.
+func Fsck(ctx context.Context, in FsckInput, opts FsckOptions) (*FsckReport, error) {
+	if in.Store == nil {
+		return nil, errors.New("fsck: metadata store is nil")
+	}
+	if in.Share == "" {
+		return nil, errors.New("fsck: share is empty")
+	}
+	run := &fsckRun{
+		in:   in,
+		opts: opts,
+		cap:  opts.SampleCap,
+		report: &FsckReport{
+			Share:     in.Share,
+			StartedAt: time.Now().UTC(),
+			Repair:    opts.Repair,
+		},
+		ownRecords: make(map[string]struct{}),
+		allRecords: make(map[string]struct{}),
+		nodes:      make(map[string]*fsckNode),
+	}
+	if run.cap <= 0 {
+		run.cap = defaultReconcileSampleCap
+	}
+
+	steps := []struct {
+		name string
+		fn   func(context.Context) error
+	}{
+		{"walk block records", run.scanBlockRecords},
+		{"walk remote blocks", run.scanRemote},
+		{"list journal", run.scanJournal},
+		{"walk namespace", run.scanNamespace},
+		{"check orphans", run.scanOrphans},
+		{"classify block records", run.scanRecordLeaks},
+	}
+	for _, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := step.fn(ctx); err != nil {
+			return nil, fmt.Errorf("fsck %q: %s: %w", in.Share, step.name, err)
+		}
+	}
+
+	if opts.Repair {
+		run.applyFixes(ctx)
+	}
+
+	r := run.report
+	r.CompletedAt = time.Now().UTC()
+	r.DurationMS = r.CompletedAt.Sub(r.StartedAt).Milliseconds()
+	return r, nil
+}
+
+// queue records a repair for id under class. Fixes only run with Repair set.
+func (f *fsckRun) queue(class *FsckClass, id string, apply func(ctx context.Context) error) {
+	if f.opts.Repair {
+		f.fixes = append(f.fixes, fsckFix{class: class, id: id, apply: apply})
+	}
+}
+
+func (f *fsckRun) applyFixes(ctx context.Context) {
+	for _, fix := range f.fixes {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := fix.apply(ctx); err != nil {
+			f.report.RepairErrors++
+			slog.Warn("fsck: repair failed", "share", f.in.Share, "id", fix.id, "error", err)
+			continue
+		}
+		fix.class.Repaired++
+	}
+}
+
+// scanBlockRecords indexes the block records of the share's store and of every
+// sibling store on the same remote.
+func (f *fsckRun) scanBlockRecords(ctx context.Context) error {
+	if err := f.in.Store.WalkBlockRecords(ctx, func(rec block.BlockRecord) error {
+		f.report.BlockRecordsScanned++
+		f.ownRecords[rec.BlockID] = struct{}{}
+		f.allRecords[rec.BlockID] = struct{}{}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, sib := range f.in.RemoteSiblings {
+		if sib == nil || sib == f.in.Store {
+			continue
+		}
+		if err := sib.WalkBlockRecords(ctx, func(rec block.BlockRecord) error {
+			f.allRecords[rec.BlockID] = struct{}{}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanRemote enumerates the remote block store, reporting objects no record
+// references (past the grace window) and records whose object is missing.
+func (f *fsckRun) scanRemote(ctx context.Context) error {
+	if f.in.Remote == nil {
+		return nil
+	}
+	f.remoteIDs = make(map[string]struct{})
+	cutoff := time.Now().Add(-resolveGracePeriod(&Options{GracePeriod: f.opts.GracePeriod}))
+	if err := f.in.Remote.WalkBlocks(ctx, func(blockID string, meta block.Meta) error {
+		f.report.RemoteObjectsScanned++
+		f.remoteIDs[blockID] = struct{}{}
+		if _, known := f.allRecords[blockID]; known {
+			return nil
+		}
+		// Same fail-closed aging as Reconcile: an object we cannot age, or
+		// one inside the grace window, is not reported.
+		if meta.LastModified.IsZero() || meta.LastModified.After(cutoff) {
+			return nil
+		}
+		f.report.OrphanRemoteObjects.add(blockID, f.cap)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for id := range f.ownRecords {
+		if _, ok := f.remoteIDs[id]; !ok {
+			f.report.MissingRemoteBlocks.add(id, f.cap)
+		}
+	}
+	return nil
+}
+
+func (f *fsckRun) scanJournal(ctx context.Context) error {
+	if f.in.Local == nil {
+		return nil
+	}
+	f.journalIDs = make(map[string]struct{})
+	for _, id := range f.in.Local.ListFiles(ctx) {
+		f.journalIDs[id] = struct{}{}
+	}
+	return nil
+}
+
+// scanNamespace walks the share tree, then checks link counts and parents
+// against what the walk observed.
+func (f *fsckRun) scanNamespace(ctx context.Context) error {
+	store := f.in.Store
+	rootHandle, err := store.GetRootHandle(ctx, f.in.Share)
+	if err != nil {
+		return fmt.Errorf("get root handle: %w", err)
+	}
+	rootFile, err := store.GetFile(ctx, rootHandle)
+	if err != nil {
+		return fmt.Errorf("get root: %w", err)
+	}
+	root := &fsckNode{handle: rootHandle, path: "/", file: rootFile}
+	f.nodes[string(rootHandle)] = root
+	f.order = append(f.order, root)
+	f.report.DirectoriesScanned++
+
+	if err := f.walkDir(ctx, root, false, false); err != nil {
+		return err
+	}
+
+	for _, n := range f.order {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := f.checkLinks(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkDir visits every entry of dir, then descends into its subdirectories.
+// inBin is set inside #recycle; stamped is set when an enclosing bin entry
+// carries a DeletedAt stamp.
+func (f *fsckRun) walkDir(ctx context.Context, dir *fsckNode, inBin, stamped bool) error {
+	store := f.in.Store
+	var subdirs []fsckSubdir
+	cursor := ""
+	for {
+		entries, next, err := store.ListChildren(ctx, dir.handle, cursor, 0)
+		if err != nil {
+			return fmt.Errorf("list %s: %w", dir.path, err)
+		}
+		for _, e := range entries {
+			childPath := path.Join(dir.path, e.Name)
+			child, err := store.GetFile(ctx, e.Handle)
+			if err != nil {
+				if !metadata.IsNotFoundError(err) {
+					return fmt.Errorf("get %s: %w", childPath, err)
+				}
+				f.report.DanglingEntries.add(childPath, f.cap)
+				dirHandle, name := dir.handle, e.Name
+				f.queue(&f.report.DanglingEntries, childPath, func(ctx context.Context) error {
+					return store.DeleteChild(ctx, dirHandle, name)
+				})
+				continue
+			}
+
+			key := string(e.Handle)
+			n, seen := f.nodes[key]
+			if !seen {
+				n = &fsckNode{handle: e.Handle, path: childPath, file: child, parent: dir.handle}
+				f.nodes[key] = n
+				f.order = append(f.order, n)
+			}
+			n.links++
+
+			if child.Type == metadata.FileTypeDirectory {
+				dir.subdirs++
+				if seen {
+					// A directory listed twice would make the walk loop;
+					// its link count check reports the extra entry.
+					continue
+				}
+				f.report.DirectoriesScanned++
+				if dir.parent == nil && e.Name == metadata.RecycleDirName {
+					// The bin itself: everything below is recycled content.
+					subdirs = append(subdirs, fsckSubdir{node: n, inBin: true})
+					continue
+				}
+				f.checkRecycleStamp(n, inBin, stamped)
+				subdirs = append(subdirs, fsckSubdir{
+					node:    n,
+					inBin:   inBin,
+					stamped: stamped || (inBin && child.DeletedAt != nil),
+				})
+				continue
+			}
+
+			if seen {
+				continue
+			}
+			f.checkRecycleStamp(n, inBin, stamped)
+			if child.Type == metadata.FileTypeRegular {
+				f.report.FilesScanned++
+				if err := f.checkFile(ctx, n); err != nil {
+					return err
+				}
+			}
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	for _, sub := range subdirs {
+		if err := f.walkDir(ctx, sub.node, sub.inBin, sub.stamped); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRecycleStamp reports a bin node that nothing stamps (invisible to the
+// bin listing and reaper) and a live node that still carries a stamp.
+// Unstamped directories inside the bin are the recreated parent scaffolding
+// and are fine.
+func (f *fsckRun) checkRecycleStamp(n *fsckNode, inBin, stamped bool) {
+	store := f.in.Store
+	handle := n.handle
+	switch {
+	case inBin && !stamped && n.file.DeletedAt == nil && n.file.Type != metadata.FileTypeDirectory:
+		f.report.OrphanedRecycleEntries.add(n.path, f.cap)
+		binRel := n.path
+		if rel, ok := cutPrefixPath(n.path, "/"+metadata.RecycleDirName); ok {
+			binRel = rel
+		}
+		f.queue(&f.report.OrphanedRecycleEntries, n.path, func(ctx context.Context) error {
+			file, err := store.GetFile(ctx, handle)
+			if err != nil {
+				return err
+			}
+			now := time.Now().UTC()
+			file.DeletedAt = &now
+			if file.OriginalPath == "" {
+				file.OriginalPath = binRel
+			}
+			return store.PutFile(ctx, file)
+		})
+	case !inBin && n.file.DeletedAt != nil:
+		f.report.StaleRecycleStamps.add(n.path, f.cap)
+		f.queue(&f.report.StaleRecycleStamps, n.path, func(ctx context.Context) error {
+			file, err := store.GetFile(ctx, handle)
+			if err != nil {
+				return err
+			}
+			file.DeletedAt = nil
+			file.OriginalPath = ""
+			file.DeletedBy = ""
+			return store.PutFile(ctx, file)
+		})
+	}
+}
+
+// cutPrefixPath strips dir from p, returning the remainder without a leading
+// slash.
+func cutPrefixPath(p, dir string) (string, bool) {
+	if len(p) <= len(dir)+1 || p[:len(dir)] != dir || p[len(dir)] != '/' {
+		return "", false
+	}
+	return p[len(dir)+1:], true
+}
+
+// checkFile cross-checks one regular file's manifest, payload index and chunk
+// backing.
+func (f *fsckRun) checkFile(ctx context.Context, n *fsckNode) error {
+	store := f.in.Store
+	file := n.file
+	if file.PayloadID == "" {
+		return nil
+	}
+	payloadID := string(file.PayloadID)
+
+	indexed, err := store.GetFileByPayloadID(ctx, file.PayloadID)
+	switch {
+	case err != nil && metadata.IsNotFoundError(err):
+		f.report.PayloadIndexMismatches.add(n.path, f.cap)
+		handle := n.handle
+		f.queue(&f.report.PayloadIndexMismatches, n.path, func(ctx context.Context) error {
+			cur, err := store.GetFile(ctx, handle)
+			if err != nil {
+				return err
+			}
+			return store.PutFile(ctx, cur)
+		})
+	case err != nil:
+		return fmt.Errorf("payload index lookup for %s: %w", n.path, err)
+	case indexed == nil || (indexed.ID != uuid.Nil && indexed.ID != file.ID):
+		// Some backends resolve the index without populating File.ID;
+		// only a known, different ID is a conflict.
+		f.report.PayloadIndexMismatches.add(n.path, f.cap)
+	}
+
+	rows, err := store.ListFileChunks(ctx, payloadID)
+	if err != nil {
+		return fmt.Errorf("list chunks for %s: %w", n.path, err)
+	}
+
+	// Manifest refs must be backed by a row at the same offset and hash.
+	byOffset := make(map[uint64]block.ContentHash, len(rows))
+	for _, row := range rows {
+		if row == nil || row.Hash.IsZero() {
+			continue
+		}
+		if off, ok := block.ParseChunkOffset(row.ID); ok {
+			byOffset[off] = row.Hash
+		}
+	}
+	dangling := 0
+	for _, ref := range file.Blocks {
+		if h, ok := byOffset[ref.Offset]; !ok || h != ref.Hash || h.IsZero() {
+			dangling++
+			f.report.DanglingChunkRefs.add(fmt.Sprintf("%s@%d", n.path, ref.Offset), f.cap)
+		}
+	}
+	// Rows are authoritative; a File.Blocks that merely lags them is safe to
+	// re-project. With a dangling ref present, re-projecting would silently
+	// drop it, so leave that file for the operator.
+	if dangling == 0 && !chunkRefsEqual(metadata.ManifestToChunkRefs(rows), file.Blocks) {
+		f.report.UnprojectedManifests.add(n.path, f.cap)
+		f.queue(&f.report.UnprojectedManifests, n.path, func(ctx context.Context) error {
+			return store.WithTransaction(ctx, func(tx metadata.Transaction) error {
+				return metadata.ProjectManifestToBlocks(ctx, tx, payloadID)
+			})
+		})
+	}
+
+	// Every row's bytes must live somewhere: synced to the remote (and the
+	// locator's block must exist), or still in the journal.
+	_, inJournal := f.journalIDs[payloadID]
+	for _, row := range rows {
+		if row == nil || row.Hash.IsZero() {
+			continue
+		}
+		f.report.ChunkRefsScanned++
+		loc, synced, err := store.GetLocator(ctx, row.Hash)
+		if err != nil {
+			return fmt.Errorf("get locator for %s: %w", row.ID, err)
+		}
+		if synced {
+			if loc.BlockID != "" {
+				if _, ok := f.ownRecords[loc.BlockID]; !ok {
+					f.report.DanglingLocators.add(row.Hash.String(), f.cap)
+				}
+			}
+			continue
+		}
+		if f.journalIDs != nil && !inJournal {
+			f.report.UnbackedChunks.add(row.ID, f.cap)
+		}
+	}
+	return nil
+}
+
+func chunkRefsEqual(a, b []block.ChunkRef) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkLinks compares a node's stored link count with the walk, and a
+// directory's (or singly-linked file's) parent pointer with its listing dir.
+func (f *fsckRun) checkLinks(ctx context.Context, n *fsckNode) error {
+	store := f.in.Store
+	handle := n.handle
+
+	want := n.links
+	if n.file.Type == metadata.FileTypeDirectory {
+		want = 2 + n.subdirs
+	}
+	got, err := store.GetLinkCount(ctx, handle)
+	if err != nil {
+		return fmt.Errorf("get link count for %s: %w", n.path, err)
+	}
+	if got != want {
+		f.report.NlinkMismatches.add(fmt.Sprintf("%s (stored %d, found %d)", n.path, got, want), f.cap)
+		f.queue(&f.report.NlinkMismatches, n.path, func(ctx context.Context) error {
+			return store.SetLinkCount(ctx, handle, want)
+		})
+	}
+
+	if n.parent == nil || (n.file.Type != metadata.FileTypeDirectory && n.links != 1) {
+		return nil
+	}
+	parent, err := store.GetParent(ctx, handle)
+	if err != nil && !metadata.IsNotFoundError(err) {
+		return fmt.Errorf("get parent for %s: %w", n.path, err)
+	}
+	if err != nil || string(parent) != string(n.parent) {
+		f.report.ParentMismatches.add(n.path, f.cap)
+		want := n.parent
+		f.queue(&f.report.ParentMismatches, n.path, func(ctx context.Context) error {
+			return store.SetParent(ctx, handle, want)
+		})
+	}
+	return nil
+}
+
+// scanOrphans reports FileChunk rows and journal payloads that no live inode
+// references. The live set is store-wide, so a store shared by several shares
+// never has one share's payloads mistaken for another's orphans.
+func (f *fsckRun) scanOrphans(ctx context.Context) error {
+	store := f.in.Store
+	live := make(map[string]struct{})
+	if err := store.EnumerateLivePayloadIDs(ctx, func(p string) error {
+		live[p] = struct{}{}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("enumerate live payloads: %w", err)
+	}
+
+	var stranded []string
+	if err := store.EnumeratePayloads(ctx, func(p string) error {
+		if _, ok := live[p]; !ok {
+			stranded = append(stranded, p)
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("enumerate payloads: %w", err)
+	}
+	for _, pid := range stranded {
+		rows, err := store.ListFileChunks(ctx, pid)
+		if err != nil {
+			return fmt.Errorf("list chunks for %s: %w", pid, err)
+		}
+		for _, row := range rows {
+			if row == nil {
+				continue
+			}
+			f.report.OrphanedChunks.add(row.ID, f.cap)
+			id := row.ID
+			f.queue(&f.report.OrphanedChunks, id, func(ctx context.Context) error {
+				_, err := store.DecrementRefCountAndReap(ctx, id)
+				return err
+			})
+		}
+	}
+
+	for id := range f.journalIDs {
+		if _, ok := live[id]; ok {
+			continue
+		}
+		f.report.OrphanedJournalFiles.add(id, f.cap)
+		payloadID := id
+		f.queue(&f.report.OrphanedJournalFiles, id, func(ctx context.Context) error {
+			return f.in.Local.Delete(ctx, payloadID)
+		})
+	}
+	return nil
+}
+
+// scanRecordLeaks folds Reconcile's per-store record classes into the report.
+// Stores without the EnumerateSynced scan are skipped rather than misreported.
+func (f *fsckRun) scanRecordLeaks(ctx context.Context) error {
+	view, ok := f.in.Store.(ReconcileMetaView)
+	if !ok {
+		return nil
+	}
+	rep, err := Reconcile(ctx, []ReconcileMetaView{view}, nil, nil, ReconcileOptions{
+		GracePeriod: f.opts.GracePeriod,
+		SampleCap:   f.cap,
+	})
+	if err != nil {
+		return err
+	}
+	f.report.ZeroRefRecords = fsckClassFrom(rep.ZeroRefRecords)
+	f.report.LeakedBlocks = fsckClassFrom(rep.LeakedBlocks)
+	return nil
+}
+
+func fsckClassFrom(c ReconcileClass) FsckClass {
+	return FsckClass{Count: c.Count, Sample: c.Sample, Truncated: c.Truncated}
+}
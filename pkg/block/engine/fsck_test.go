@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/block"
+)
+
+func TestFsck_CleanShare(t *testing.T) {
+	store, share, _, _ := seedAuditTestStore(t)
+
+	rep, err := Fsck(context.Background(), FsckInput{Share: share, Store: store}, FsckOptions{})
+	if err != nil {
+		t.Fatalf("Fsck: %v", err)
+	}
+	if rep.Problems() != 0 {
+		t.Fatalf("clean share reported %d problems: %+v", rep.Problems(), rep)
+	}
+	if rep.FilesScanned != 3 || rep.DirectoriesScanned != 1 {
+		t.Errorf("scanned files=%d dirs=%d, want 3 and 1", rep.FilesScanned, rep.DirectoriesScanned)
+	}
+}
+
+// TestFsck_ReportAndRepair manufactures one problem in each of the repairable
+// namespace categories plus a report-only dangling ref, then checks that a
+// report run leaves them in place and a repair run fixes all but the ref.
+func TestFsck_ReportAndRepair(t *testing.T) {
+	ctx := context.Background()
+	store, share, payloadIDs, offsets := seedAuditTestStore(t)
+
+	rootHandle, err := store.GetRootHandle(ctx, share)
+	if err != nil {
+		t.Fatalf("GetRootHandle: %v", err)
+	}
+	fileHandle, err := store.GetChild(ctx, rootHandle, fileNameFor(0))
+	if err != nil {
+		t.Fatalf("GetChild: %v", err)
+	}
+
+	// nlink mismatch.
+	if err := store.SetLinkCount(ctx, fileHandle, 3); err != nil {
+		t.Fatalf("SetLinkCount: %v", err)
+	}
+	// Dangling directory entry: a handle that was never stored.
+	ghost, err := store.GenerateHandle(ctx, share, "/ghost")
+	if err != nil {
+		t.Fatalf("GenerateHandle: %v", err)
+	}
+	if err := store.SetChild(ctx, rootHandle, "ghost", ghost); err != nil {
+		t.Fatalf("SetChild: %v", err)
+	}
+	// Orphaned chunk row: a payload no inode references.
+	if err := store.Put(ctx, &block.FileChunk{
+		ID:        "stranded/0",
+		Hash:      hashForFileChunk(9, 0),
+		DataSize:  4096,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("Put stranded row: %v", err)
+	}
+	// Dangling manifest ref: drop one backing row of file 1.
+	if err := store.Delete(ctx, payloadIDs[1]+"/"+jstr(int(offsets[1][0]))); err != nil {
+		t.Fatalf("Delete row: %v", err)
+	}
+
+	rep, err := Fsck(ctx, FsckInput{Share: share, Store: store}, FsckOptions{})
+	if err != nil {
+		t.Fatalf("Fsck: %v", err)
+	}
+	if rep.NlinkMismatches.Count != 1 || rep.DanglingEntries.Count != 1 ||
+		rep.OrphanedChunks.Count != 1 || rep.DanglingChunkRefs.Count != 1 {
+		t.Fatalf("unexpected report: nlink=%d dangling=%d orphaned=%d refs=%d",
+			rep.NlinkMismatches.Count, rep.DanglingEntries.Count,
+			rep.OrphanedChunks.Count, rep.DanglingChunkRefs.Count)
+	}
+
+	rep, err = Fsck(ctx, FsckInput{Share: share, Store: store}, FsckOptions{Repair: true})
+	if err != nil {
+		t.Fatalf("Fsck repair: %v", err)
+	}
+	if rep.RepairErrors != 0 {
+		t.Fatalf("repair errors: %d", rep.RepairErrors)
+	}
+	if got := rep.Unresolved(); got != 1 {
+		t.Errorf("Unresolved = %d, want 1 (the report-only dangling ref)", got)
+	}
+
+	rep, err = Fsck(ctx, FsckInput{Share: share, Store: store}, FsckOptions{})
+	if err != nil {
+		t.Fatalf("Fsck after repair: %v", err)
+	}
+	if rep.Problems() != 1 || rep.DanglingChunkRefs.Count != 1 {
+		t.Errorf("after repair: problems=%d danglingRefs=%d, want 1 and 1",
+			rep.Problems(), rep.DanglingChunkRefs.Count)
+	}
+}
+
+func TestFsck_RecycleStamps(t *testing.T) {
+	ctx := context.Background()
+	store, share, _, _ := seedAuditTestStore(t)
+
+	rootHandle, err := store.GetRootHandle(ctx, share)
+	if err != nil {
+		t.Fatalf("GetRootHandle: %v", err)
+	}
+	// A live file carrying a leftover recycle stamp.
+	fileHandle, err := store.GetChild(ctx, rootHandle, fileNameFor(2))
+	if err != nil {
+		t.Fatalf("GetChild: %v", err)
+	}
+	file, err := store.GetFile(ctx, fileHandle)
+	if err != nil {
+		t.Fatalf("GetFile: %v", err)
+	}
+	now := time.Now().UTC()
+	file.DeletedAt = &now
+	if err := store.PutFile(ctx, file); err != nil {
+		t.Fatalf("PutFile: %v", err)
+	}
+
+	rep, err := Fsck(ctx, FsckInput{Share: share, Store: store}, FsckOptions{Repair: true})
+	if err != nil {
+		t.Fatalf("Fsck: %v", err)
+	}
+	if rep.StaleRecycleStamps.Count != 1 || rep.StaleRecycleStamps.Repaired != 1 {
+		t.Fatalf("stale stamps = %+v, want 1 found and repaired", rep.StaleRecycleStamps)
+	}
+	got, err := store.GetFile(ctx, fileHandle)
+	if err != nil {
+		t.Fatalf("GetFile: %v", err)
+	}
+	if got.DeletedAt != nil {
+		t.Error("stamp not cleared")
+	}
+}
@@ -309,16 +309,36 @@ func (m *Syncer) packAndCommitMigrated(
 	if rbs == nil {
 		return errors.New("cas→blocks migration: remote block store not wired")
 	}
+	var batchBytes int
+	for _, c := range batch {
+		batchBytes += len(c.data)
+	}
+	if err := m.waitUploadBandwidth(ctx, batchBytes); err != nil {
+		return err
+	}
+	_, err := packAndCommitBatch(ctx, rbs, sealer, committer, batch)
+	return err
+}
 
+// packAndCommitBatch is the body of packAndCommitMigrated, shared with the
+// remote re-home repacker (rehome.go). It uploads the batch to rbs as one new
+// block and returns its ID.
+func packAndCommitBatch(
+	ctx context.Context,
+	rbs remote.RemoteBlockStore,
+	sealer remote.ChunkSealer,
+	committer blockCommitter,
+	batch []migrationChunk,
+) (string, error) {
 	blockID, err := newBlockID()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	var buf bytes.Buffer
 	builder, err := blockcodec.NewBuilder(&buf, blockID, nil)
 	if err != nil {
-		return fmt.Errorf("migration: new builder: %w", err)
+		return "", fmt.Errorf("migration: new builder: %w", err)
 	}
 
 	commits := make([]block.BlockChunkCommit, 0, len(batch))
@@ -327,12 +347,12 @@ func (m *Syncer) packAndCommitMigrated(
 		if sealer != nil {
 			wire, err = sealer.SealChunk(ctx, c.hash, c.data)
 			if err != nil {
-				return fmt.Errorf("migration: seal chunk %s: %w", c.hash, err)
+				return "", fmt.Errorf("migration: seal chunk %s: %w", c.hash, err)
 			}
 		}
 		chunkLoc, err := builder.Add(c.hash, wire)
 		if err != nil {
-			return fmt.Errorf("migration: frame chunk %s: %w", c.hash, err)
+			return "", fmt.Errorf("migration: frame chunk %s: %w", c.hash, err)
 		}
 		commits = append(commits, block.BlockChunkCommit{
 			Hash:   c.hash,
@@ -340,7 +360,7 @@ func (m *Syncer) packAndCommitMigrated(
 		})
 	}
 	if _, err := builder.Finish(); err != nil {
-		return fmt.Errorf("migration: finish block: %w", err)
+		return "", fmt.Errorf("migration: finish block: %w", err)
 	}
 
 	blockBytes := buf.Bytes()
@@ -349,7 +369,7 @@ func (m *Syncer) packAndCommitMigrated(
 	// PutBlock before the commit: a crash in between leaves an orphan block
 	// object (PR5 reconcile), never an unbacked record.
 	if err := rbs.PutBlock(ctx, blockID, bytes.NewReader(blockBytes)); err != nil {
-		return fmt.Errorf("migration: put block %s: %w", blockID, err)
+		return "", fmt.Errorf("migration: put block %s: %w", blockID, err)
 	}
 
 	rec := block.BlockRecord{
@@ -360,7 +380,7 @@ func (m *Syncer) packAndCommitMigrated(
 		SyncState:      block.BlockStateRemote,
 	}
 	if err := metadata.DefaultCommitBlock(ctx, committer, rec, commits, nil); err != nil {
-		return fmt.Errorf("migration: commit block %s: %w", blockID, err)
+		return "", fmt.Errorf("migration: commit block %s: %w", blockID, err)
 	}
-	return nil
+	return blockID, nil
 }
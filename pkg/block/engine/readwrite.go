@@ -30,6 +30,7 @@ func (bs *Store) ReadAt(ctx context.Context, payloadID string, blocks []block.Ch
 	}
 	_ = blocks // opaque to the readahead driver; kept for interface symmetry
 	bs.syncer.scheduleReadahead(payloadID, offset, uint32(len(data)))
+	bs.access.Load().Record(payloadID)
 	return n, nil
 }
 
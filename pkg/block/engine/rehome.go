@@ -0,0 +1,350 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"lukechampine.com/blake3"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/marmos91/dittofs/pkg/block/remote"
+)
+
+// This file is the data mover behind a planned remote re-home (dfsctl share
+// rehome): moving a share's packed blocks from one remote block store to
+// another while the share keeps serving. It reuses the cas→blocks migration
+// machinery (legacy_migration.go) — enumerate the synced-hash index, re-pack
+// through packAndCommitBatch — but its source is a whole second remote rather
+// than the legacy cas/ namespace.
+//
+// The control plane drives it in passes. While the share is still bound to the
+// old remote, a first RehomeBlocks pass moves the bulk of the data with the new
+// remote installed as the read fallback (SetFallbackRemote), so chunks whose
+// locators a repack already rewrote stay readable. The share is then rebound
+// to the new remote with the old one as the fallback, and a second pass moves
+// whatever was carved to the old remote in the meantime. Throughout, a chunk
+// whose block is not on the primary is read from the other remote
+// (readChunkVerified), so clients never see the gap.
+//
+// RehomeBlocks is resumable and idempotent by construction: every block already
+// present on the new remote is skipped, and a re-packed chunk's locator points
+// at a new-remote block once its commit lands, so a re-run picks up exactly
+// the work that is left.
+
+// RehomeMode selects how RehomeBlocks moves blocks between remotes.
+type RehomeMode string
+
+const (
+	// RehomeModeCopy copies each packed block object verbatim under the same
+	// block ID. Locators and block records are untouched, so it is only valid
+	// when both remotes apply the same per-chunk compression/encryption
+	// transform (ChunkSealer) — the wire bytes are copied, not re-sealed.
+	RehomeModeCopy RehomeMode = "copy"
+
+	// RehomeModeRepack reads every live chunk back to plaintext through the
+	// old remote's transform chain and packs it into new blocks sealed by the
+	// new remote, rewriting locators and block records as it goes. Required
+	// when the two remotes' transforms differ; dead chunks are dropped on the
+	// way.
+	RehomeModeRepack RehomeMode = "repack"
+)
+
+// RehomeProgress counts the work of a RehomeBlocks run. Blocks are the distinct
+// block IDs the share's synced-hash index points at.
+type RehomeProgress struct {
+	BlocksTotal int64 `json:"blocks_total"`
+	// BlocksDone counts blocks handled so far: moved, already present on the
+	// new remote, or missing.
+	BlocksDone int64 `json:"blocks_done"`
+	// BlocksMoved counts blocks copied (copy mode) or re-packed (repack mode).
+	BlocksMoved int64 `json:"blocks_moved"`
+	// BlocksPresent counts blocks that already lived on the new remote.
+	BlocksPresent int64 `json:"blocks_present"`
+	// BlocksMissing counts blocks found on neither remote. Reads of their
+	// chunks were already failing before the re-home.
+	BlocksMissing  int64 `json:"blocks_missing"`
+	ChunksRepacked int64 `json:"chunks_repacked"`
+	BytesMoved     int64 `json:"bytes_moved"`
+}
+
+// fallbackRemote boxes the re-home read fallback for the atomic pointer.
+type fallbackRemote struct {
+	reader remote.ChunkReader
+}
+
+// rehomeChunk is one synced hash and the locator it resolves to.
+type rehomeChunk struct {
+	hash block.ContentHash
+	loc  block.ChunkLocator
+}
+
+// blockRecordDeleter is the block-record slice the repacker needs to drop the
+// record of a block whose chunks all moved. The production metadata stores
+// implement it; a committer without it leaves the stale record to reconcile.
+type blockRecordDeleter interface {
+	DeleteBlockRecord(ctx context.Context, blockID string) error
+}
+
+// SetFallbackRemote installs r as the secondary remote block-resident chunk
+// reads fall back to when the primary reports a block absent. A nil r clears
+// it. The caller owns r and must clear the fallback before closing it.
+func (m *Syncer) SetFallbackRemote(r remote.ChunkReader) {
+	if r == nil {
+		m.fallback.Store(nil)
+		return
+	}
+	m.fallback.Store(&fallbackRemote{reader: r})
+}
+
+// SetFallbackRemote installs (or, with nil, clears) the re-home read fallback
+// on this store's syncer. See (*Syncer).SetFallbackRemote.
+func (bs *Store) SetFallbackRemote(r remote.ChunkReader) {
+	bs.syncer.SetFallbackRemote(r)
+}
+
+// RehomeBlocks runs the syncer's RehomeBlocks under the store's close-gate so
+// a concurrent Close drains the run instead of racing the teardown.
+func (bs *Store) RehomeBlocks(ctx context.Context, from, to remote.RemoteStore, mode RehomeMode, progress func(RehomeProgress)) (RehomeProgress, error) {
+	if err := bs.enter(); err != nil {
+		return RehomeProgress{}, err
+	}
+	defer bs.closeMu.RUnlock()
+	return bs.syncer.RehomeBlocks(ctx, from, to, mode, progress)
+}
+
+// RehomeBlocks moves every block the share's synced-hash index references
+// from the remote from onto the remote to, using mode. Either may be the
+// syncer's own remote. Repacked chunks are sealed by to and committed through
+// the share's metadata store. progress (may be nil) is invoked after each
+// block with the running counts.
+//
+// A block that is on neither remote is counted as missing and skipped, not
+// treated as an error: the locator set is store-wide, so it may belong to a
+// sibling share on another remote, and if not, its reads were already failing.
+func (m *Syncer) RehomeBlocks(ctx context.Context, from, to remote.RemoteStore, mode RehomeMode, progress func(RehomeProgress)) (RehomeProgress, error) {
+	var p RehomeProgress
+	if from == nil || to == nil {
+		return p, errors.New("rehome: source and target remotes are required")
+	}
+	if mode != RehomeModeCopy && mode != RehomeModeRepack {
+		return p, fmt.Errorf("rehome: unknown mode %q", mode)
+	}
+
+	m.mu.RLock()
+	committer := m.blockCommitter
+	shs := m.syncedHashStore
+	m.mu.RUnlock()
+	if committer == nil {
+		return p, errors.New("rehome: share has no block commit substrate")
+	}
+	enum, ok := shs.(SyncedHashIndex)
+	if !ok {
+		return p, errors.New("rehome: metadata store cannot enumerate synced hashes")
+	}
+
+	// Group the locators by block. Collected up front (like Phase R of the
+	// cas→blocks migration) so no metadata writes happen while the
+	// enumeration iterator is open.
+	byBlock := make(map[string][]rehomeChunk)
+	if err := enum.EnumerateSynced(ctx, func(h block.ContentHash, loc block.ChunkLocator, _ time.Time) error {
+		if loc.BlockID == "" {
+			return nil // standalone pre-flip chunk: the cas→blocks migration owns it
+		}
+		byBlock[loc.BlockID] = append(byBlock[loc.BlockID], rehomeChunk{hash: h, loc: loc})
+		return nil
+	}); err != nil {
+		return p, fmt.Errorf("rehome: enumerate synced hashes: %w", err)
+	}
+	blockIDs := make([]string, 0, len(byBlock))
+	for id := range byBlock {
+		blockIDs = append(blockIDs, id)
+	}
+	slices.Sort(blockIDs)
+
+	present := make(map[string]struct{})
+	if err := to.WalkBlocks(ctx, func(id string, _ block.Meta) error {
+		present[id] = struct{}{}
+		return nil
+	}); err != nil {
+		return p, fmt.Errorf("rehome: list target blocks: %w", err)
+	}
+
+	p.BlocksTotal = int64(len(blockIDs))
+	report := func() {
+		if progress != nil {
+			progress(p)
+		}
+	}
+	report()
+
+	var err error
+	if mode == RehomeModeCopy {
+		err = m.rehomeCopy(ctx, from, to, blockIDs, present, &p, report)
+	} else {
+		err = m.rehomeRepack(ctx, from, to, to, committer, blockIDs, byBlock, present, &p, report)
+	}
+	if err != nil {
+		return p, err
+	}
+	if p.BlocksMissing > 0 {
+		logger.Warn("rehome: blocks found on neither remote",
+			"missing", p.BlocksMissing, "total", p.BlocksTotal)
+	}
+	return p, nil
+}
+
+// rehomeCopy copies each block object not yet on the target verbatim.
+func (m *Syncer) rehomeCopy(
+	ctx context.Context,
+	from remote.RemoteStore,
+	rbs remote.RemoteBlockStore,
+	blockIDs []string,
+	present map[string]struct{},
+	p *RehomeProgress,
+	report func(),
+) error {
+	for _, id := range blockIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, ok := present[id]; ok {
+			p.BlocksPresent++
+			p.BlocksDone++
+			report()
+			continue
+		}
+		data, err := from.GetBlock(ctx, id)
+		if errors.Is(err, block.ErrChunkNotFound) {
+			p.BlocksMissing++
+			p.BlocksDone++
+			report()
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("rehome: read block %s: %w", id, err)
+		}
+		if err := m.waitUploadBandwidth(ctx, len(data)); err != nil {
+			return err
+		}
+		if err := rbs.PutBlock(ctx, id, bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("rehome: put block %s: %w", id, err)
+		}
+		p.BlocksMoved++
+		p.BlocksDone++
+		p.BytesMoved += int64(len(data))
+		report()
+	}
+	return nil
+}
+
+// rehomeRepack re-packs the live chunks of every block not yet on the target
+// into carve-sized target blocks. Blocks are never split across batches, so
+// once a batch commits every old block it drew from is fully moved and its
+// record can go.
+func (m *Syncer) rehomeRepack(
+	ctx context.Context,
+	from remote.RemoteStore,
+	rbs remote.RemoteBlockStore,
+	sealer remote.ChunkSealer,
+	committer blockCommitter,
+	blockIDs []string,
+	byBlock map[string][]rehomeChunk,
+	present map[string]struct{},
+	p *RehomeProgress,
+	report func(),
+) error {
+	target := m.carveBlockSize()
+	deleter, canDelete := committer.(blockRecordDeleter)
+	var (
+		batch      []migrationChunk
+		batchBytes int64
+		drained    []string // old block IDs whose chunks are all in batch
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := m.waitUploadBandwidth(ctx, int(batchBytes)); err != nil {
+			return err
+		}
+		if _, err := packAndCommitBatch(ctx, rbs, sealer, committer, batch); err != nil {
+			return fmt.Errorf("rehome: %w", err)
+		}
+		// Every locator into the drained blocks now points at the new block.
+		if canDelete {
+			for _, id := range drained {
+				if err := deleter.DeleteBlockRecord(ctx, id); err != nil {
+					logger.Warn("rehome: failed to drop record of moved block (reconcile will reclaim it)",
+						"block", id, "error", err)
+				}
+			}
+		}
+		p.ChunksRepacked += int64(len(batch))
+		p.BytesMoved += batchBytes
+		p.BlocksMoved += int64(len(drained))
+		p.BlocksDone += int64(len(drained))
+		report()
+		batch = batch[:0]
+		batchBytes = 0
+		drained = drained[:0]
+		return nil
+	}
+
+	for _, id := range blockIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, ok := present[id]; ok {
+			p.BlocksPresent++
+			p.BlocksDone++
+			report()
+			continue
+		}
+		chunks, err := readRehomeChunks(ctx, from, byBlock[id])
+		if errors.Is(err, block.ErrChunkNotFound) {
+			p.BlocksMissing++
+			p.BlocksDone++
+			report()
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("rehome: read block %s: %w", id, err)
+		}
+		for _, c := range chunks {
+			batch = append(batch, c)
+			batchBytes += int64(len(c.data))
+		}
+		drained = append(drained, id)
+		if batchBytes >= target {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// readRehomeChunks reads and BLAKE3-verifies the plaintext of every chunk of
+// one old block through the source's transform chain. Verified here because
+// the re-packed copy replaces the only locator that reaches the original.
+func readRehomeChunks(ctx context.Context, from remote.RemoteStore, chunks []rehomeChunk) ([]migrationChunk, error) {
+	out := make([]migrationChunk, 0, len(chunks))
+	for _, c := range chunks {
+		data, err := from.ReadChunk(ctx, c.loc.BlockID, c.loc.WireOffset, c.loc.WireLength, c.hash)
+		if err != nil {
+			return nil, err
+		}
+		if computed := block.ContentHash(blake3.Sum256(data)); computed != c.hash {
+			return nil, fmt.Errorf("%w: block %s chunk %s computed %s",
+				block.ErrChunkContentMismatch, c.loc.BlockID, c.hash, computed)
+		}
+		out = append(out, migrationChunk{hash: c.hash, data: data})
+	}
+	return out, nil
+}
@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"lukechampine.com/blake3"
+
+	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/marmos91/dittofs/pkg/block/compression"
+	remotememory "github.com/marmos91/dittofs/pkg/block/remote/memory"
+)
+
+// plantRehomeBlocks packs chunks into blocks on src (one block per group) and
+// commits their records and locators into the fixture's metadata store, as if
+// the share had been carving to src all along.
+func plantRehomeBlocks(t *testing.T, ctx context.Context, f *carveFixture, src *remotememory.Store, groups ...[][]byte) map[block.ContentHash][]byte {
+	t.Helper()
+	want := make(map[block.ContentHash][]byte)
+	for _, group := range groups {
+		batch := make([]migrationChunk, 0, len(group))
+		for _, data := range group {
+			h := block.ContentHash(blake3.Sum256(data))
+			batch = append(batch, migrationChunk{hash: h, data: data})
+			want[h] = data
+		}
+		if _, err := packAndCommitBatch(ctx, src, src, f.syncer.blockCommitter, batch); err != nil {
+			t.Fatalf("plant block: %v", err)
+		}
+	}
+	return want
+}
+
+func assertChunksReadable(t *testing.T, ctx context.Context, f *carveFixture, want map[block.ContentHash][]byte) {
+	t.Helper()
+	for h, data := range want {
+		loc, ok, err := f.ms.GetLocator(ctx, h)
+		if err != nil || !ok {
+			t.Fatalf("GetLocator(%s): ok=%v err=%v", h, ok, err)
+		}
+		got, err := f.syncer.readChunkVerified(ctx, loc, h)
+		if err != nil {
+			t.Fatalf("readChunkVerified(%s): %v", h, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("chunk %s not byte-identical", h)
+		}
+	}
+}
+
+// TestRehomeBlocks_Copy proves the copy flow: reads are served through the
+// fallback until the blocks are moved, the objects land on the target under
+// the same IDs, and a re-run is a no-op.
+func TestRehomeBlocks_Copy(t *testing.T) {
+	ctx := context.Background()
+	src := remotememory.New()
+	dst := remotememory.New()
+	f := newCarveFixture(t, dst, 1<<20)
+
+	want := plantRehomeBlocks(t, ctx, f, src,
+		[][]byte{[]byte("alpha chunk"), []byte("beta chunk")},
+		[][]byte{[]byte("gamma chunk")},
+	)
+
+	// Before the move the target has nothing: without a fallback the read
+	// fails, with one it is served from the old remote.
+	for h := range want {
+		loc, _, _ := f.ms.GetLocator(ctx, h)
+		if _, err := f.syncer.readChunkVerified(ctx, loc, h); !errors.Is(err, block.ErrChunkNotFound) {
+			t.Fatalf("read without fallback: err=%v, want ErrChunkNotFound", err)
+		}
+		break
+	}
+	f.syncer.SetFallbackRemote(src)
+	assertChunksReadable(t, ctx, f, want)
+
+	var last RehomeProgress
+	p, err := f.syncer.RehomeBlocks(ctx, src, dst, RehomeModeCopy, func(p RehomeProgress) { last = p })
+	if err != nil {
+		t.Fatalf("RehomeBlocks: %v", err)
+	}
+	if p.BlocksTotal != 2 || p.BlocksMoved != 2 || p.BlocksDone != 2 || p.BytesMoved == 0 {
+		t.Fatalf("progress = %+v", p)
+	}
+	if last != p {
+		t.Fatalf("last reported progress %+v != result %+v", last, p)
+	}
+	if n := countRemoteBlocks(t, ctx, dst); n != 2 {
+		t.Fatalf("target holds %d blocks, want 2", n)
+	}
+
+	f.syncer.SetFallbackRemote(nil)
+	assertChunksReadable(t, ctx, f, want)
+
+	again, err := f.syncer.RehomeBlocks(ctx, src, dst, RehomeModeCopy, nil)
+	if err != nil {
+		t.Fatalf("re-run: %v", err)
+	}
+	if again.BlocksPresent != 2 || again.BlocksMoved != 0 {
+		t.Fatalf("re-run progress = %+v, want all present", again)
+	}
+}
+
+// TestRehomeBlocks_RepackDecoratedTarget proves the repack flow onto a target
+// with a different transform: chunks are unsealed from the old remote,
+// re-sealed by the target's compression layer into new blocks, and the old
+// block records are dropped.
+func TestRehomeBlocks_RepackDecoratedTarget(t *testing.T) {
+	ctx := context.Background()
+	src := remotememory.New()
+	base := remotememory.New()
+	stack, err := compression.NewRemote(base, compression.CompressionPolicy{Algo: compression.AlgoZstd})
+	if err != nil {
+		t.Fatalf("compression.NewRemote: %v", err)
+	}
+	f := newCarveFixture(t, stack, 96)
+
+	var groups [][][]byte
+	for i := 0; i < 3; i++ {
+		groups = append(groups, [][]byte{
+			[]byte(fmt.Sprintf("block %d first chunk ..........................", i)),
+			[]byte(fmt.Sprintf("block %d second chunk .........................", i)),
+		})
+	}
+	want := plantRehomeBlocks(t, ctx, f, src, groups...)
+	oldRecords, _ := sumLiveChunkCounts(t, ctx, f)
+
+	f.syncer.SetFallbackRemote(src)
+	p, err := f.syncer.RehomeBlocks(ctx, src, stack, RehomeModeRepack, nil)
+	if err != nil {
+		t.Fatalf("RehomeBlocks: %v", err)
+	}
+	if p.BlocksMoved != 3 || p.ChunksRepacked != 6 {
+		t.Fatalf("progress = %+v", p)
+	}
+	f.syncer.SetFallbackRemote(nil)
+
+	onTarget := make(map[string]struct{})
+	_ = base.WalkBlocks(ctx, func(id string, _ block.Meta) error {
+		onTarget[id] = struct{}{}
+		return nil
+	})
+	for h := range want {
+		loc, _, _ := f.ms.GetLocator(ctx, h)
+		if _, ok := onTarget[loc.BlockID]; !ok {
+			t.Fatalf("chunk %s still points at %s, not a target block", h, loc.BlockID)
+		}
+	}
+	assertChunksReadable(t, ctx, f, want)
+
+	records, live := sumLiveChunkCounts(t, ctx, f)
+	if records != len(onTarget) || live != 6 {
+		t.Fatalf("records=%d live=%d after repack (had %d records); want %d records, 6 live",
+			records, live, oldRecords, len(onTarget))
+	}
+
+	again, err := f.syncer.RehomeBlocks(ctx, src, stack, RehomeModeRepack, nil)
+	if err != nil {
+		t.Fatalf("re-run: %v", err)
+	}
+	if again.BlocksMoved != 0 || again.BlocksPresent != again.BlocksTotal {
+		t.Fatalf("re-run progress = %+v, want all present", again)
+	}
+}
@@ -120,6 +120,16 @@ type Syncer struct {
 	uploadedBytesWindow atomic.Int64
 	uploadErrWindow     atomic.Int64
 
+	// bandwidth holds the byte-rate limiters every carve PutBlock and remote
+	// chunk read waits on (the share's own cap and its remote's shared cap).
+	// Swapped wholesale by SetBandwidthLimiters; nil means unlimited.
+	bandwidth atomic.Pointer[[]*BandwidthLimiter]
+
+	// fallback is the secondary remote a block-resident chunk read consults
+	// when the primary reports it absent — the previous remote of a share that
+	// is being re-homed (rehome.go). nil outside a re-home.
+	fallback atomic.Pointer[fallbackRemote]
+
 	// --- block carve path (#1414 object packing) ---
 
 	// remoteBlockStore is the block-keyed remote (PutBlock) the carver uploads
@@ -783,7 +793,7 @@ func (m *Syncer) wireCarveTargets() {
 			return // remote configured but deps not fully wired yet
 		}
 		deduper := engineDeduper{synced: m.syncedHashStore}
-		sink := engineBlockSink{sealer: m.chunkSealer, rbs: m.remoteBlockStore, committer: m.blockCommitter, commitLocks: &carveCommitLocks{}}
+		sink := engineBlockSink{sealer: m.chunkSealer, rbs: m.remoteBlockStore, committer: m.blockCommitter, commitLocks: &carveCommitLocks{}, throttle: m.waitUploadBandwidth}
 		m.local.SetCarveTargets(deduper, sink)
 		m.carveTargetsWired = true
 		return
@@ -57,14 +57,6 @@ func (m *Syncer) WarmAll(ctx context.Context, progress func(done, total int64))
 		return WarmResult{}, errors.New("warm: share has no remote tier to warm from")
 	}
 
-	// Enumerate all FileChunk rows and split into already-local (counted,
-	// skipped) and to-fetch (the work list). The enumeration walks the same
-	// surface as populateBlockCounts: fileChunkStore.EnumeratePayloads ->
-	// per-payload ListFileChunks (the authoritative metadata, which survives
-	// rollup). Each to-fetch target carries the resolved row so the worker
-	// fetches by the row in hand (fetchResolvedBlock) instead of round-tripping
-	// through a BlockSize-aligned blockIdx lookup, which would miss every
-	// non-aligned FastCDC chunk (#1374).
 	var payloadIDs []string
 	if err := m.fileChunkStore.EnumeratePayloads(ctx, func(payloadID string) error {
 		payloadIDs = append(payloadIDs, payloadID)
@@ -72,7 +64,40 @@ func (m *Syncer) WarmAll(ctx context.Context, progress func(done, total int64))
 	}); err != nil {
 		return WarmResult{}, fmt.Errorf("warm: enumerate payloads: %w", err)
 	}
+	return m.warmPayloads(ctx, payloadIDs, progress)
+}
 
+// WarmPayloads is WarmAll restricted to the given payloads: only their blocks
+// are materialized onto the local tier. Unknown or empty payloads contribute
+// nothing. It backs targeted warms (path globs, access history).
+func (m *Syncer) WarmPayloads(ctx context.Context, payloadIDs []string, progress func(done, total int64)) (WarmResult, error) {
+	if err := m.checkReady(ctx); err != nil {
+		return WarmResult{}, err
+	}
+	if m.remoteStore == nil {
+		return WarmResult{}, errors.New("warm: share has no remote tier to warm from")
+	}
+	return m.warmPayloads(ctx, payloadIDs, progress)
+}
+
+// residencyProber is the optional local-store capability that reports whether
+// a byte range is held locally (the journal-backed fs store implements it).
+// Without it every covering chunk is fetched.
+type residencyProber interface {
+	IsResident(payloadID string, offset, length int64) bool
+}
+
+// warmPayloads fetches every not-yet-local block of payloadIDs. See WarmAll.
+func (m *Syncer) warmPayloads(ctx context.Context, payloadIDs []string, progress func(done, total int64)) (WarmResult, error) {
+	// Split the payloads' FileChunk rows into already-local (counted,
+	// skipped) and to-fetch (the work list). The rows come from the same
+	// surface as populateBlockCounts: per-payload ListFileChunks (the
+	// authoritative metadata, which survives rollup). Each to-fetch target
+	// carries the resolved row so the worker fetches by the row in hand
+	// (fetchResolvedBlock) instead of round-tripping through a
+	// BlockSize-aligned blockIdx lookup, which would miss every non-aligned
+	// FastCDC chunk (#1374).
+	prober, _ := m.local.(residencyProber)
 	var (
 		targets      []warmTarget
 		alreadyLocal int64
@@ -89,12 +114,18 @@ func (m *Syncer) WarmAll(ctx context.Context, progress func(done, total int64))
 			if fb == nil {
 				continue
 			}
-			if _, ok := block.ParseChunkOffset(fb.ID); !ok {
+			off, ok := block.ParseChunkOffset(fb.ID)
+			if !ok {
+				continue
+			}
+			// The journal is not hash-keyed, so presence is probed by the
+			// chunk's byte range. Without a prober every covering chunk is
+			// warmed; re-hydrating a warm chunk is idempotent — a redundant
+			// GET at worst.
+			if prober != nil && fb.DataSize > 0 && prober.IsResident(payloadID, int64(off), int64(fb.DataSize)) {
+				alreadyLocal++
 				continue
 			}
-			// ponytail: no per-hash local-presence probe (journal is not
-			// hash-keyed), so warm every covering chunk. Re-hydrating an
-			// already-warm chunk is idempotent — a redundant GET at worst.
 			targets = append(targets, warmTarget{payloadID: payloadID, fb: fb})
 		}
 	}
@@ -0,0 +1,95 @@
+package engine_test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/marmos91/dittofs/pkg/block/engine"
+	remotememory "github.com/marmos91/dittofs/pkg/block/remote/memory"
+	metadatamemory "github.com/marmos91/dittofs/pkg/metadata/store/memory"
+)
+
+// TestWarmPayloads_TargetedAndSkipsResident proves a targeted warm only
+// fetches the requested payload's evicted chunks, and that a second run finds
+// them resident instead of downloading them again.
+func TestWarmPayloads_TargetedAndSkipsResident(t *testing.T) {
+	ctx := context.Background()
+	ms := metadatamemory.NewMemoryMetadataStoreWithDefaults()
+	bs := newEngineWithRemote(t, ms, remotememory.New())
+
+	root := createShare(t, ms, "warmtarget")
+	hot, _ := createRealFile(t, ms, "warmtarget", "hot.bin", root)
+	cold, _ := createRealFile(t, ms, "warmtarget", "cold.bin", root)
+
+	rng := rand.New(rand.NewSource(7)) //nolint:gosec // deterministic test fixture
+	for _, pid := range []string{hot, cold} {
+		buf := make([]byte, 2<<20)
+		rng.Read(buf)
+		if _, err := bs.WriteAt(ctx, pid, nil, buf, 0); err != nil {
+			t.Fatalf("WriteAt: %v", err)
+		}
+		if _, err := bs.Flush(ctx, pid); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+	}
+	if err := bs.DrainRollups(ctx); err != nil {
+		t.Fatalf("DrainRollups: %v", err)
+	}
+	if err := bs.DrainAllUploads(ctx); err != nil {
+		t.Fatalf("DrainAllUploads: %v", err)
+	}
+	if _, err := bs.DrainLocalSynced(ctx); err != nil {
+		t.Fatalf("DrainLocalSynced: %v", err)
+	}
+
+	first, err := bs.WarmPayloads(ctx, []string{hot}, nil)
+	if err != nil {
+		t.Fatalf("WarmPayloads: %v", err)
+	}
+	if first.BlocksFetched == 0 || first.BlocksAlreadyLocal != 0 {
+		t.Fatalf("first warm = %+v, want fetches and nothing local", first)
+	}
+
+	second, err := bs.WarmPayloads(ctx, []string{hot}, nil)
+	if err != nil {
+		t.Fatalf("WarmPayloads (again): %v", err)
+	}
+	if second.BlocksFetched != 0 || second.BlocksAlreadyLocal != first.BlocksFetched {
+		t.Fatalf("second warm = %+v, want all %d chunks resident", second, first.BlocksFetched)
+	}
+
+	// The other payload was not part of the selection and is still cold.
+	all, err := bs.WarmPayloads(ctx, []string{hot, cold}, nil)
+	if err != nil {
+		t.Fatalf("WarmPayloads (both): %v", err)
+	}
+	if all.BlocksFetched == 0 {
+		t.Fatalf("warm of both = %+v, want the untouched payload fetched", all)
+	}
+}
+
+// TestAccessHistory_RecordsReads proves ReadAt feeds the installed history.
+func TestAccessHistory_RecordsReads(t *testing.T) {
+	ctx := context.Background()
+	ms := metadatamemory.NewMemoryMetadataStoreWithDefaults()
+	bs := newEngineWithRemote(t, ms, remotememory.New())
+	root := createShare(t, ms, "history")
+	pid, _ := createRealFile(t, ms, "history", "f.bin", root)
+	if _, err := bs.WriteAt(ctx, pid, nil, []byte("hello"), 0); err != nil {
+		t.Fatalf("WriteAt: %v", err)
+	}
+
+	h := engine.NewAccessHistory(0, 0)
+	bs.SetAccessHistory(h)
+	buf := make([]byte, 5)
+	for i := 0; i < 3; i++ {
+		if _, err := bs.ReadAt(ctx, pid, nil, buf, 0); err != nil {
+			t.Fatalf("ReadAt: %v", err)
+		}
+	}
+	snap := h.Snapshot()
+	if len(snap) != 1 || snap[0].PayloadID != pid || snap[0].Score < 2.9 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+}
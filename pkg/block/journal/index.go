@@ -276,6 +276,28 @@ func (fi *fileIndex) plan(offset, n int64) []piece {
 	return pieces
 }
 
+// IsResident reports whether every byte of [offset, offset+length) is held
+// locally: written or hydrated and not evicted. Holes and cold ranges are not
+// resident, so a range the journal has never seen reports false.
+func (s *Store) IsResident(id FileID, offset, length int64) bool {
+	if s.closed.Load() || offset < 0 || length <= 0 {
+		return false
+	}
+	sh := s.shardFor(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	fi := sh.index[id]
+	if fi == nil {
+		return false
+	}
+	for _, p := range fi.plan(offset, length) {
+		if p.hole || p.cold {
+			return false
+		}
+	}
+	return true
+}
+
 // ReadAt fills dst with the file's bytes at offset. Ranges never written locally
 // are POSIX holes and are zero-filled. Ranges written but evicted are reported
 // via cold so the caller can hydrate from the remote store and retry; their dst
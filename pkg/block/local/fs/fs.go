@@ -177,6 +177,12 @@ func (s *FSStore) ReadAt(ctx context.Context, payloadID string, offset int64, ds
 	return s.Store.ReadAt(ctx, journal.FileID(payloadID), offset, dst)
 }
 
+// IsResident reports whether [offset, offset+length) of payloadID is held
+// locally. Cache warming uses it to skip chunks that need no download.
+func (s *FSStore) IsResident(payloadID string, offset, length int64) bool {
+	return s.Store.IsResident(journal.FileID(payloadID), offset, length)
+}
+
 func (s *FSStore) Hydrate(ctx context.Context, payloadID string, offset int64, data []byte) error {
 	return s.Store.Hydrate(ctx, journal.FileID(payloadID), offset, data)
 }
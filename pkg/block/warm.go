@@ -0,0 +1,195 @@
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WarmSelector picks the files a cache pre-warm materializes onto the local
+// tier. Every field that is set narrows the selection; a zero selector warms
+// the whole share.
+type WarmSelector struct {
+	// Paths are globs relative to the share root ("renders/**/*.exr"). A
+	// "**" segment matches any number of directories; other segments use
+	// path.Match syntax. A file matching any glob is selected.
+	Paths []string `json:"paths,omitempty"`
+	// AccessedWithin selects files read within this window ("72h", "7d"),
+	// as recorded by the share's access history.
+	AccessedWithin string `json:"accessed_within,omitempty"`
+	// Hottest caps the selection to the N most frequently read files, as
+	// ranked by the share's access history.
+	Hottest int `json:"hottest,omitempty"`
+}
+
+// IsZero reports whether the selector selects the whole share.
+func (s WarmSelector) IsZero() bool {
+	return len(s.Paths) == 0 && s.AccessedWithin == "" && s.Hottest == 0
+}
+
+// Validate checks the globs and the recency window.
+func (s WarmSelector) Validate() error {
+	for _, p := range s.Paths {
+		if _, err := MatchWarmPath(p, "probe"); err != nil {
+			return fmt.Errorf("warm: invalid path glob %q: %w", p, err)
+		}
+	}
+	if _, err := s.Window(); err != nil {
+		return err
+	}
+	if s.Hottest < 0 {
+		return fmt.Errorf("warm: hottest must not be negative")
+	}
+	return nil
+}
+
+// Window returns the parsed AccessedWithin window, or 0 when unset.
+func (s WarmSelector) Window() (time.Duration, error) {
+	if s.AccessedWithin == "" {
+		return 0, nil
+	}
+	d, err := ParseWarmDuration(s.AccessedWithin)
+	if err != nil {
+		return 0, fmt.Errorf("warm: accessed_within: %w", err)
+	}
+	return d, nil
+}
+
+// MatchesPath reports whether rel (a slash-separated path relative to the
+// share root) matches one of the selector's globs. A selector without globs
+// matches every path.
+func (s WarmSelector) MatchesPath(rel string) bool {
+	if len(s.Paths) == 0 {
+		return true
+	}
+	for _, p := range s.Paths {
+		if ok, err := MatchWarmPath(p, rel); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WarmPolicy is a share's automatic pre-warm policy: which files to warm
+// (the embedded selector) and when.
+type WarmPolicy struct {
+	WarmSelector
+	// OnStart warms the selection whenever the share is loaded, so a
+	// restart does not leave clients facing a cold cache.
+	OnStart bool `json:"on_start,omitempty"`
+	// AfterEvict re-warms the selection after an operator eviction.
+	AfterEvict bool `json:"after_evict,omitempty"`
+	// Interval re-warms the selection periodically ("1h"), which also
+	// brings back blocks the local tier evicted under pressure. Empty
+	// disables periodic re-warming.
+	Interval string `json:"interval,omitempty"`
+}
+
+// IsZero reports whether the policy never triggers a warm.
+func (p WarmPolicy) IsZero() bool {
+	return !p.OnStart && !p.AfterEvict && p.Interval == ""
+}
+
+// Validate checks the selector and the interval.
+func (p WarmPolicy) Validate() error {
+	if err := p.WarmSelector.Validate(); err != nil {
+		return err
+	}
+	if _, err := p.Period(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Period returns the parsed re-warm interval, or 0 when unset.
+func (p WarmPolicy) Period() (time.Duration, error) {
+	if p.Interval == "" {
+		return 0, nil
+	}
+	d, err := ParseWarmDuration(p.Interval)
+	if err != nil {
+		return 0, fmt.Errorf("warm: interval: %w", err)
+	}
+	return d, nil
+}
+
+// ParseWarmPolicy decodes a policy from its JSON column. An empty string
+// yields the zero (disabled) policy.
+func ParseWarmPolicy(s string) (WarmPolicy, error) {
+	var p WarmPolicy
+	if strings.TrimSpace(s) == "" {
+		return p, nil
+	}
+	if err := json.Unmarshal([]byte(s), &p); err != nil {
+		return WarmPolicy{}, fmt.Errorf("warm policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return WarmPolicy{}, err
+	}
+	return p, nil
+}
+
+// ParseWarmDuration parses a Go duration or a whole number of days ("7d").
+// The result must be positive.
+func ParseWarmDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive, got %q", s)
+	}
+	return d, nil
+}
+
+// MatchWarmPath reports whether the slash-separated path name matches pattern.
+// Leading slashes on either side are ignored. A "**" segment matches zero or
+// more path segments; every other segment is matched with path.Match.
+func MatchWarmPath(pattern, name string) (bool, error) {
+	pat := strings.Split(strings.Trim(pattern, "/"), "/")
+	segs := strings.Split(strings.Trim(name, "/"), "/")
+	for _, p := range pat {
+		if p == "**" {
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return false, err
+		}
+	}
+	return matchSegments(pat, segs), nil
+}
+
+func matchSegments(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			rest := pat[1:]
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(rest, segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
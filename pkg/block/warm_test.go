@@ -0,0 +1,67 @@
+package block
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchWarmPath(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"renders/**/*.exr", "renders/shot01/beauty.exr", true},
+		{"renders/**/*.exr", "renders/beauty.exr", true},
+		{"renders/**/*.exr", "/renders/a/b/c/beauty.exr", true},
+		{"renders/**/*.exr", "renders/a/beauty.png", false},
+		{"renders/*.exr", "renders/a/beauty.exr", false},
+		{"**", "anything/at/all", true},
+		{"ci/cache/**", "ci/cache", true},
+		{"ci/cache/**", "ci/other/x", false},
+		{"textures/[a-c]*.tx", "textures/brick.tx", true},
+	}
+	for _, tt := range tests {
+		got, err := MatchWarmPath(tt.pattern, tt.name)
+		if err != nil {
+			t.Fatalf("MatchWarmPath(%q, %q): %v", tt.pattern, tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("MatchWarmPath(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := MatchWarmPath("bad/[", "x"); err == nil {
+		t.Error("malformed glob accepted")
+	}
+}
+
+func TestParseWarmPolicy(t *testing.T) {
+	p, err := ParseWarmPolicy(`{"paths":["ci/**"],"accessed_within":"7d","hottest":500,"on_start":true,"interval":"1h"}`)
+	if err != nil {
+		t.Fatalf("ParseWarmPolicy: %v", err)
+	}
+	if w, _ := p.Window(); w != 7*24*time.Hour {
+		t.Errorf("window = %v, want 168h", w)
+	}
+	if d, _ := p.Period(); d != time.Hour {
+		t.Errorf("period = %v, want 1h", d)
+	}
+	if !p.OnStart || p.Hottest != 500 || !p.MatchesPath("ci/job/out.o") {
+		t.Errorf("policy = %+v", p)
+	}
+
+	for _, bad := range []string{
+		`{"accessed_within":"soon"}`,
+		`{"interval":"-1h"}`,
+		`{"paths":["["]}`,
+		`{"hottest":-1}`,
+	} {
+		if _, err := ParseWarmPolicy(bad); err == nil {
+			t.Errorf("ParseWarmPolicy(%s) accepted", bad)
+		}
+	}
+
+	if p, err := ParseWarmPolicy(""); err != nil || !p.IsZero() {
+		t.Errorf("empty policy = %+v, %v", p, err)
+	}
+}
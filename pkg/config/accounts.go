@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+// AccountsConfig configures the policy for local password accounts: password
+// strength, reuse and age, lockout after failed logins, and TOTP second
+// factors. Lockout applies to every protocol that checks a local password
+// (REST API login and SMB NTLM session setup).
+type AccountsConfig struct {
+	// Password configures password strength, reuse and age.
+	Password PasswordPolicyConfig `mapstructure:"password" yaml:"password"`
+
+	// Lockout configures account lockout after repeated failed logins.
+	Lockout LockoutConfig `mapstructure:"lockout" yaml:"lockout"`
+
+	// TOTP configures time-based one-time code enrollment.
+	TOTP TOTPConfig `mapstructure:"totp" yaml:"totp"`
+}
+
+// PasswordPolicyConfig configures the password rules. Zero fields disable
+// the corresponding rule.
+type PasswordPolicyConfig struct {
+	// MinLength is the minimum password length. The built-in floor of 8
+	// always applies. Default: 8
+	MinLength int `mapstructure:"min_length" validate:"omitempty,gte=0,lte=72" yaml:"min_length"`
+
+	// History is how many previous passwords a user may not reuse.
+	// Default: 0 (reuse allowed)
+	History int `mapstructure:"history" validate:"omitempty,gte=0,lte=24" yaml:"history"`
+
+	// MaxAge is how long a password stays valid. A login with an older
+	// password succeeds but must change the password before anything else.
+	// Default: 0 (never expires)
+	MaxAge time.Duration `mapstructure:"max_age" yaml:"max_age"`
+}
+
+// LockoutConfig configures account lockout.
+type LockoutConfig struct {
+	// Threshold is the number of consecutive failed logins that locks the
+	// account. 0 uses the default; a negative value disables lockout.
+	// Default: 5
+	Threshold int `mapstructure:"threshold" yaml:"threshold"`
+
+	// Duration is how long a locked account stays locked before it unlocks
+	// on its own. An admin can unlock earlier with `dfsctl user unlock`.
+	// Default: 15m
+	Duration time.Duration `mapstructure:"duration" yaml:"duration"`
+}
+
+// TOTPConfig configures TOTP (RFC 6238) second factors. Enrollment is per
+// user and optional; an enrolled user must present a code at API login.
+type TOTPConfig struct {
+	// Issuer is the account issuer shown by authenticator apps.
+	// Default: DittoFS
+	Issuer string `mapstructure:"issuer" yaml:"issuer"`
+}
+
+// ApplyDefaults fills zero-valued fields.
+func (c *AccountsConfig) ApplyDefaults() {
+	if c.Password.MinLength == 0 {
+		c.Password.MinLength = models.MinPasswordLength
+	}
+	if c.Lockout.Threshold == 0 {
+		c.Lockout.Threshold = 5
+	}
+	if c.Lockout.Duration == 0 {
+		c.Lockout.Duration = 15 * time.Minute
+	}
+	if c.TOTP.Issuer == "" {
+		c.TOTP.Issuer = "DittoFS"
+	}
+}
+
+// Validate checks the accounts section for inconsistent settings.
+func (c *AccountsConfig) Validate() error {
+	if c.Password.MaxAge < 0 {
+		return fmt.Errorf("accounts.password.max_age must not be negative")
+	}
+	if c.Lockout.Threshold > 0 && c.Lockout.Duration < 0 {
+		return fmt.Errorf("accounts.lockout.duration must not be negative")
+	}
+	return nil
+}
+
+// Policy returns the account policy enforced by the control plane store.
+func (c *AccountsConfig) Policy() models.AccountPolicy {
+	p := models.AccountPolicy{
+		MinPasswordLength: c.Password.MinLength,
+		PasswordHistory:   c.Password.History,
+		PasswordMaxAge:    c.Password.MaxAge,
+		TOTPIssuer:        c.TOTP.Issuer,
+	}
+	if c.Lockout.Threshold > 0 {
+		p.LockoutThreshold = c.Lockout.Threshold
+		p.LockoutDuration = c.Lockout.Duration
+	}
+	return p
+}
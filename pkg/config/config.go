@@ -99,6 +99,18 @@ type Config struct {
 	// Environment overrides follow the DITTOFS_LDAP_* convention, e.g.
 	// DITTOFS_LDAP_URL, DITTOFS_LDAP_BIND_PASSWORD.
 	LDAP ldap.Config `mapstructure:"ldap" yaml:"ldap"`
+
+	// FileAudit configures the sinks of the per-share file-access audit
+	// trail. See FileAuditConfig.
+	FileAudit FileAuditConfig `mapstructure:"file_audit" yaml:"file_audit"`
+
+	// Events configures the share change-event stream and its webhook and
+	// NATS sinks. See EventsConfig.
+	Events EventsConfig `mapstructure:"events" yaml:"events"`
+
+	// Accounts configures the password, lockout and TOTP policy for local
+	// user accounts. See AccountsConfig.
+	Accounts AccountsConfig `mapstructure:"accounts" yaml:"accounts"`
 }
 
 // IdentityConfig configures Windows/SID identity behavior shared across NFS,
@@ -29,6 +29,9 @@ func ApplyDefaults(cfg *Config) {
 	cfg.Metadata.ApplyDefaults()
 	cfg.Metrics.ApplyDefaults()
 	cfg.LDAP.ApplyDefaults()
+	cfg.FileAudit.ApplyDefaults()
+	cfg.Events.ApplyDefaults()
+	cfg.Accounts.ApplyDefaults()
 }
 
 // applyLoggingDefaults sets logging defaults and normalizes values.
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// EventsConfig configures the share change-event stream: the created,
+// removed, renamed and modified events behind SMB CHANGE_NOTIFY and NFSv4
+// CB_NOTIFY, published to API subscribers (GET /api/v1/shares/{name}/events),
+// webhooks and a NATS server.
+type EventsConfig struct {
+	// Enabled turns on event production. Default: false.
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+
+	// BufferSize is the number of recent events kept per share for
+	// subscribers resuming from a cursor. Default: 10000.
+	BufferSize int `mapstructure:"buffer_size" validate:"omitempty,gt=0" yaml:"buffer_size"`
+
+	// SettleWindow holds back a file's modified events until no write has
+	// arrived for this long, then publishes one. A negative value publishes
+	// every write. Default: 2s.
+	SettleWindow time.Duration `mapstructure:"settle_window" yaml:"settle_window"`
+
+	// Webhooks each receive the matching events as signed JSON POSTs.
+	Webhooks []WebhookConfig `mapstructure:"webhooks" yaml:"webhooks"`
+
+	// NATS publishes every event to a NATS server.
+	NATS EventsNATSConfig `mapstructure:"nats" yaml:"nats"`
+}
+
+// WebhookConfig configures one webhook receiver.
+type WebhookConfig struct {
+	// Name identifies the webhook in logs.
+	Name string `mapstructure:"name" yaml:"name"`
+
+	// URL receives a POST per event.
+	URL string `mapstructure:"url" yaml:"url"`
+
+	// Secret keys the HMAC-SHA256 X-DittoFS-Signature header. Empty sends
+	// unsigned requests.
+	Secret string `mapstructure:"secret" yaml:"secret"`
+
+	// Shares, Types and PathPrefix select the events delivered; empty
+	// means all.
+	Shares     []string `mapstructure:"shares" yaml:"shares"`
+	Types      []string `mapstructure:"types" yaml:"types"`
+	PathPrefix string   `mapstructure:"path_prefix" yaml:"path_prefix"`
+
+	// Timeout bounds each request. Default: 10s.
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
+
+	// MaxRetries is the number of retries after a 5xx, 429 or network
+	// error, with exponential backoff from 1s. Default: 5.
+	MaxRetries int `mapstructure:"max_retries" validate:"omitempty,gte=0" yaml:"max_retries"`
+
+	// QueueSize bounds the events waiting for delivery; overflow is
+	// dropped. Default: 1024.
+	QueueSize int `mapstructure:"queue_size" validate:"omitempty,gt=0" yaml:"queue_size"`
+}
+
+// EventsNATSConfig configures the NATS publisher.
+type EventsNATSConfig struct {
+	// URL is nats://[user:password@]host[:port]. Empty disables NATS.
+	URL string `mapstructure:"url" yaml:"url"`
+
+	// SubjectPrefix is prepended to "<share>.<type>".
+	// Default: dittofs.events
+	SubjectPrefix string `mapstructure:"subject_prefix" yaml:"subject_prefix"`
+
+	// Token, or User and Password, authenticate the connection.
+	Token    string `mapstructure:"token" yaml:"token"`
+	User     string `mapstructure:"user" yaml:"user"`
+	Password string `mapstructure:"password" yaml:"password"`
+
+	// QueueSize bounds the events waiting for delivery. Default: 1024.
+	QueueSize int `mapstructure:"queue_size" validate:"omitempty,gt=0" yaml:"queue_size"`
+}
+
+// ApplyDefaults fills zero-valued fields.
+func (c *EventsConfig) ApplyDefaults() {
+	if c.BufferSize == 0 {
+		c.BufferSize = 10000
+	}
+	if c.SettleWindow == 0 {
+		c.SettleWindow = 2 * time.Second
+	}
+	for i := range c.Webhooks {
+		wh := &c.Webhooks[i]
+		if wh.Timeout == 0 {
+			wh.Timeout = 10 * time.Second
+		}
+		if wh.MaxRetries == 0 {
+			wh.MaxRetries = 5
+		}
+		if wh.QueueSize == 0 {
+			wh.QueueSize = 1024
+		}
+	}
+	if c.NATS.SubjectPrefix == "" {
+		c.NATS.SubjectPrefix = "dittofs.events"
+	}
+	if c.NATS.QueueSize == 0 {
+		c.NATS.QueueSize = 1024
+	}
+}
+
+// Validate checks the events section for inconsistent settings.
+func (c *EventsConfig) Validate() error {
+	for i, wh := range c.Webhooks {
+		u, err := url.Parse(wh.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("events.webhooks[%d]: url must be an http(s) URL", i)
+		}
+		for _, t := range wh.Types {
+			if !metadata.IsChangeEventType(t) {
+				return fmt.Errorf("events.webhooks[%d]: unknown event type %q", i, t)
+			}
+		}
+	}
+	if c.NATS.URL != "" {
+		if u, err := url.Parse(c.NATS.URL); err != nil || u.Scheme != "nats" {
+			return fmt.Errorf("events.nats: url must be a nats:// URL")
+		}
+	}
+	return nil
+}
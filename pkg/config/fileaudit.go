@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// FileAuditConfig configures where file-access audit events are delivered.
+//
+// Auditing itself is opted into per share (`dfsctl share edit --file-audit`);
+// this section only chooses the sinks. With no sink configured, events from
+// auditing shares are written to the server log.
+type FileAuditConfig struct {
+	// File is a path events are appended to as JSON lines. Empty disables
+	// the file sink. The file is created with mode 0600.
+	File string `mapstructure:"file" yaml:"file"`
+
+	// Rotation configures rotation of File. Default max_size: 100 MB.
+	Rotation LogRotationConfig `mapstructure:"rotation" yaml:"rotation"`
+
+	// Syslog sends events as RFC 5424 messages to a syslog receiver.
+	Syslog FileAuditSyslogConfig `mapstructure:"syslog" yaml:"syslog"`
+
+	// QueueSize bounds the events buffered between protocol handlers and
+	// the sinks. Events arriving while the queue is full are dropped and
+	// counted. Default: 4096.
+	QueueSize int `mapstructure:"queue_size" validate:"omitempty,gt=0" yaml:"queue_size"`
+
+	// CoalesceWindow folds repeated read and write events for the same file,
+	// client and principal into one event per window. 0 uses the default;
+	// a negative value disables coalescing. Default: 1m.
+	CoalesceWindow time.Duration `mapstructure:"coalesce_window" yaml:"coalesce_window"`
+}
+
+// FileAuditSyslogConfig configures the RFC 5424 syslog sink.
+type FileAuditSyslogConfig struct {
+	// Enabled turns on the syslog sink.
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+
+	// Network is "udp", "tcp" (RFC 6587 octet-counted framing) or "unix".
+	Network string `mapstructure:"network" validate:"omitempty,oneof=udp tcp unix" yaml:"network"`
+
+	// Address is host:port for udp/tcp, or a socket path for unix.
+	Address string `mapstructure:"address" yaml:"address"`
+
+	// AppName is the RFC 5424 APP-NAME field. Default: dittofs-fileaudit
+	AppName string `mapstructure:"app_name" yaml:"app_name"`
+}
+
+// ApplyDefaults fills zero-valued fields.
+func (c *FileAuditConfig) ApplyDefaults() {
+	if c.Rotation.MaxSize == 0 {
+		c.Rotation.MaxSize = 100
+	}
+	if c.QueueSize == 0 {
+		c.QueueSize = 4096
+	}
+	if c.CoalesceWindow == 0 {
+		c.CoalesceWindow = time.Minute
+	}
+	if c.Syslog.AppName == "" {
+		c.Syslog.AppName = "dittofs-fileaudit"
+	}
+}
+
+// Validate checks the file_audit section for inconsistent settings.
+func (c *FileAuditConfig) Validate() error {
+	if c.Syslog.Enabled && (c.Syslog.Network == "" || c.Syslog.Address == "") {
+		return fmt.Errorf("file_audit.syslog: network and address are required when enabled")
+	}
+	return nil
+}
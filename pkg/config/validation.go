@@ -63,6 +63,18 @@ func Validate(cfg *Config) error {
 		return err
 	}
 
+	if err := cfg.FileAudit.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.Events.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.Accounts.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -19,6 +19,9 @@ const redactedSecret = "********"
 // EnvControlPlaneSecret is the name of the environment variable for the control plane's JWT authentication signing secret.
 const EnvControlPlaneSecret = "DITTOFS_CONTROLPLANE_SECRET"
 
+// EnvOIDCClientSecret is the name of the environment variable for the OIDC client secret.
+const EnvOIDCClientSecret = "DITTOFS_OIDC_CLIENT_SECRET"
+
 // APIConfig configures the REST API HTTP server.
 //
 // The API server provides health check endpoints, authentication endpoints,
@@ -66,6 +69,22 @@ type APIConfig struct {
 	// plain HTTP (back-compat). See TLSConfig.
 	TLS TLSConfig `mapstructure:"tls" yaml:"tls"`
 
+	// OIDC configures OpenID Connect single sign-on for the API (and so for
+	// dfsctl). See OIDCConfig.
+	OIDC OIDCConfig `mapstructure:"oidc" yaml:"oidc"`
+
+	// LDAPLogin lets directory users log in to the API with their LDAP
+	// password. See LDAPLoginConfig.
+	LDAPLogin LDAPLoginConfig `mapstructure:"ldap_login" yaml:"ldap_login"`
+
+	// SCIM exposes the SCIM 2.0 provisioning endpoints under /scim/v2. See
+	// SCIMConfig.
+	SCIM SCIMConfig `mapstructure:"scim" yaml:"scim"`
+
+	// Audit configures where the control-plane audit log is copied to. The
+	// log is always kept in the control plane store. See AuditConfig.
+	Audit AuditConfig `mapstructure:"audit" yaml:"audit"`
+
 	// Pprof enables Go pprof profiling endpoints at /debug/pprof/*.
 	// Useful for CPU, memory, and goroutine profiling during benchmarks.
 	// Default: false
@@ -122,6 +141,182 @@ type JWTConfig struct {
 	RefreshTokenDuration time.Duration `mapstructure:"refresh_token_duration" yaml:"refresh_token_duration"`
 }
 
+// OIDCConfig configures OpenID Connect login.
+//
+// DittoFS acts as a confidential (or public) OAuth client of the IdP: dfsctl
+// obtains an authorization code with PKCE (or runs the device flow on
+// headless hosts), the API server redeems it, verifies the ID token, maps
+// its issuer|subject principal to a DittoFS user through the identity
+// mappings, and issues the usual DittoFS token pair. Group claims map to
+// roles on every login, so IdP group changes take effect at the next login.
+type OIDCConfig struct {
+	// Enabled turns on the /api/v1/auth/oidc endpoints.
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+
+	// Issuer is the IdP's issuer URL (e.g. "https://sso.example.com/realms/corp").
+	Issuer string `mapstructure:"issuer" yaml:"issuer"`
+
+	// ClientID is the OAuth client ID registered for DittoFS. The client must
+	// allow loopback redirect URIs (http://127.0.0.1:<port>/callback) for
+	// `dfsctl login --oidc`, and the device grant for `--device`.
+	ClientID string `mapstructure:"client_id" yaml:"client_id"`
+
+	// ClientSecret authenticates the server to the IdP's token endpoint.
+	// Empty for a public client. Can also be set via the
+	// DITTOFS_OIDC_CLIENT_SECRET environment variable, which takes precedence.
+	ClientSecret string `mapstructure:"client_secret" yaml:"client_secret"`
+
+	// Scopes requested from the IdP.
+	// Default: [openid, profile, email, groups]
+	Scopes []string `mapstructure:"scopes" yaml:"scopes"`
+
+	// UsernameClaim is the ID token claim holding the user's name, used for
+	// username matching and auto-provisioning. Default: preferred_username
+	UsernameClaim string `mapstructure:"username_claim" yaml:"username_claim"`
+
+	// GroupsClaim is the ID token claim holding the user's IdP groups.
+	// Default: groups
+	GroupsClaim string `mapstructure:"groups_claim" yaml:"groups_claim"`
+
+	// AdminGroups, OperatorGroups and UserGroups map IdP groups to DittoFS
+	// roles; the most privileged match wins. When any of them is set, the
+	// role is re-derived at every login and a user in none of them is
+	// refused. When all are empty, roles are managed in DittoFS.
+	AdminGroups    []string `mapstructure:"admin_groups" yaml:"admin_groups"`
+	OperatorGroups []string `mapstructure:"operator_groups" yaml:"operator_groups"`
+	UserGroups     []string `mapstructure:"user_groups" yaml:"user_groups"`
+
+	// RequireMFA refuses logins whose ID token does not carry one of
+	// MFAMethods in its amr (authentication methods) claim.
+	RequireMFA bool `mapstructure:"require_mfa" yaml:"require_mfa"`
+
+	// MFAMethods are the amr values accepted as multi-factor.
+	// Default: [mfa]
+	MFAMethods []string `mapstructure:"mfa_methods" yaml:"mfa_methods"`
+
+	// MatchUsername resolves an IdP user with no explicit identity mapping
+	// to the DittoFS user named by UsernameClaim. Only enable it when the IdP
+	// controls that claim; otherwise users could claim each other's accounts.
+	MatchUsername bool `mapstructure:"match_username" yaml:"match_username"`
+
+	// AutoProvision creates a DittoFS user (and its identity mapping) on the
+	// first login of an IdP user that resolves to no one. Auto-provisioned
+	// users have no usable password.
+	AutoProvision bool `mapstructure:"auto_provision" yaml:"auto_provision"`
+
+	// DisablePasswordLogin refuses password login on /api/v1/auth/login so
+	// every API session goes through the IdP (and its MFA).
+	DisablePasswordLogin bool `mapstructure:"disable_password_login" yaml:"disable_password_login"`
+}
+
+// LDAPLoginConfig configures API password login against the LDAP/AD
+// directory set up as the LDAP identity provider (the top-level "ldap"
+// section or `dfsctl identity-provider set ldap`).
+//
+// A login whose password the control plane does not hold — an unknown user,
+// or one provisioned from the directory — is verified by binding to the
+// directory as the user. The account maps to a DittoFS user through an
+// "ldap" identity mapping keyed by its principal (name@REALM, or the bare
+// name without a realm). Group memberships map to roles on every login, so
+// directory group changes take effect at the next login.
+type LDAPLoginConfig struct {
+	// Enabled turns on directory login on /api/v1/auth/login.
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+
+	// AdminGroups, OperatorGroups and UserGroups map directory groups, by
+	// DN or CN, to DittoFS roles; the most privileged match wins. When any
+	// of them is set, the role is re-derived at every login and a user in
+	// none of them is refused. When all are empty, roles are managed in
+	// DittoFS.
+	AdminGroups    []string `mapstructure:"admin_groups" yaml:"admin_groups"`
+	OperatorGroups []string `mapstructure:"operator_groups" yaml:"operator_groups"`
+	UserGroups     []string `mapstructure:"user_groups" yaml:"user_groups"`
+
+	// AutoProvision creates a DittoFS user (and its identity mapping) on the
+	// first login of a directory account that maps to no one, copying its
+	// name, display name, email and POSIX ids. Provisioned users have no
+	// usable local password and no NT hash.
+	AutoProvision bool `mapstructure:"auto_provision" yaml:"auto_provision"`
+}
+
+// SCIMConfig configures SCIM 2.0 provisioning of users and groups by an
+// identity platform such as Okta or Entra ID. The platform authenticates
+// with an API token carrying the users:write scope.
+type SCIMConfig struct {
+	// Enabled serves /scim/v2/Users, /scim/v2/Groups and the discovery
+	// endpoints.
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+
+	// UIDBase and GIDBase are the lowest UID and GID assigned to users and
+	// groups provisioned without a uidNumber/gidNumber. Each new one gets
+	// the next id above the highest already in use in that range.
+	// Default: 100000.
+	UIDBase uint32 `mapstructure:"uid_base" yaml:"uid_base"`
+	GIDBase uint32 `mapstructure:"gid_base" yaml:"gid_base"`
+}
+
+// GetClientSecret returns the OIDC client secret, preferring the
+// environment variable.
+func (c *OIDCConfig) GetClientSecret() string {
+	if env := os.Getenv(EnvOIDCClientSecret); env != "" {
+		return env
+	}
+	return c.ClientSecret
+}
+
+// MarshalYAML redacts the OIDC client secret when the config is serialized
+// for display.
+func (c OIDCConfig) MarshalYAML() (interface{}, error) {
+	type alias OIDCConfig // avoid infinite recursion
+	out := alias(c)
+	if out.ClientSecret != "" {
+		out.ClientSecret = redactedSecret
+	}
+	return out, nil
+}
+
+// MarshalJSON redacts the OIDC client secret when the config is serialized
+// for display. See MarshalYAML.
+func (c OIDCConfig) MarshalJSON() ([]byte, error) {
+	type alias OIDCConfig // avoid infinite recursion
+	out := alias(c)
+	if out.ClientSecret != "" {
+		out.ClientSecret = redactedSecret
+	}
+	return json.Marshal(out)
+}
+
+// AuditConfig configures optional copies of the control-plane audit log.
+//
+// Every mutating API call is recorded in the control plane store and served
+// by GET /api/v1/audit regardless of these settings; the sinks below stream
+// the same events (one JSON document each) to systems that outlive the
+// server, such as a SIEM.
+type AuditConfig struct {
+	// File is a path the events are appended to as JSON lines. Empty
+	// disables the file sink. The file is created with mode 0600.
+	File string `mapstructure:"file" yaml:"file"`
+
+	// Syslog sends the events to syslog. Not supported on Windows.
+	Syslog AuditSyslogConfig `mapstructure:"syslog" yaml:"syslog"`
+}
+
+// AuditSyslogConfig configures the syslog audit sink.
+type AuditSyslogConfig struct {
+	// Enabled turns on the syslog sink.
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+
+	// Network is "udp" or "tcp" for a remote syslog server; empty uses the
+	// local syslog daemon.
+	Network string `mapstructure:"network" validate:"omitempty,oneof=udp tcp" yaml:"network"`
+
+	// Address is the remote server's host:port. Required when Network is set.
+	Address string `mapstructure:"address" yaml:"address"`
+
+	// Tag is the syslog tag. Default: dittofs-audit
+	Tag string `mapstructure:"tag" yaml:"tag"`
+}
+
 // TLSConfig configures native, file-based TLS for the control plane API.
 //
 // DittoFS only loads cert files — it does not act as a CA, does not generate
@@ -176,6 +371,15 @@ func (c *APIConfig) Validate() error {
 	if err := c.TLS.shared().Validate(); err != nil {
 		return fmt.Errorf("controlplane.tls.%w", err)
 	}
+	if c.OIDC.Enabled && (c.OIDC.Issuer == "" || c.OIDC.ClientID == "") {
+		return fmt.Errorf("controlplane.oidc: issuer and client_id are required when enabled")
+	}
+	if c.OIDC.DisablePasswordLogin && !c.OIDC.Enabled {
+		return fmt.Errorf("controlplane.oidc: disable_password_login requires oidc to be enabled")
+	}
+	if c.Audit.Syslog.Enabled && (c.Audit.Syslog.Network == "") != (c.Audit.Syslog.Address == "") {
+		return fmt.Errorf("controlplane.audit.syslog: network and address must be set together")
+	}
 	return nil
 }
 
@@ -249,6 +453,26 @@ func (c *APIConfig) ApplyDefaults() {
 	if c.JWT.RefreshTokenDuration == 0 {
 		c.JWT.RefreshTokenDuration = 7 * 24 * time.Hour
 	}
+	// OIDC defaults
+	if len(c.OIDC.Scopes) == 0 {
+		c.OIDC.Scopes = []string{"openid", "profile", "email", "groups"}
+	}
+	if c.OIDC.UsernameClaim == "" {
+		c.OIDC.UsernameClaim = "preferred_username"
+	}
+	if c.OIDC.GroupsClaim == "" {
+		c.OIDC.GroupsClaim = "groups"
+	}
+	if len(c.OIDC.MFAMethods) == 0 {
+		c.OIDC.MFAMethods = []string{"mfa"}
+	}
+	// SCIM defaults
+	if c.SCIM.UIDBase == 0 {
+		c.SCIM.UIDBase = 100000
+	}
+	if c.SCIM.GIDBase == 0 {
+		c.SCIM.GIDBase = 100000
+	}
 }
 
 // GetJWTSecret returns the JWT secret, preferring the environment variable.
@@ -12,7 +12,9 @@ import (
 	"github.com/marmos91/dittofs/internal/controlplane/api/auth"
 	"github.com/marmos91/dittofs/internal/controlplane/api/handlers"
 	apiMiddleware "github.com/marmos91/dittofs/internal/controlplane/api/middleware"
+	"github.com/marmos91/dittofs/internal/controlplane/audit"
 	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/auth/sid"
 	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
 	"github.com/marmos91/dittofs/pkg/controlplane/store"
 )
@@ -34,10 +36,16 @@ const passwordChangePath = "/api/v1/users/me/password"
 // Routes:
 //   - GET /health - Liveness probe
 //   - GET /health/ready - Readiness probe
-//   - POST /api/v1/auth/login - User authentication
+//   - POST /api/v1/auth/login - User authentication (local or LDAP directory)
 //   - POST /api/v1/auth/refresh - Token refresh
+//   - GET /api/v1/auth/oidc - OIDC login parameters (when OIDC is enabled)
+//   - POST /api/v1/auth/oidc/token - OIDC authorization-code (PKCE) login
+//   - POST /api/v1/auth/oidc/device[/token] - OIDC device-code login
 //   - GET /api/v1/auth/me - Current user info
 //   - POST /api/v1/users/me/password - Change own password
+//   - /api/v1/tokens/* - Service-account API token management (admin only)
+//   - /api/v1/roles/* - Custom roles and role bindings (admin only)
+//   - GET /api/v1/audit - Control-plane audit log (admin only)
 //   - /api/v1/users/* - User management (admin only)
 //   - /api/v1/groups/* - Group management (admin only)
 //   - /api/v1/shares/* - Share management (admin only)
@@ -50,14 +58,20 @@ const passwordChangePath = "/api/v1/users/me/password"
 //   - /api/v1/adapters/nfs/clients/{id}/sessions - NFS client sessions (admin only)
 //   - /api/v1/adapters/{type}/grace - NFS grace period management (admin only)
 //   - /api/v1/adapters/{type}/netgroups - NFS netgroup management (admin only)
+//   - /api/v1/adapters/{type}/cert-mappings - NFS-over-TLS client certificate mapping (admin only)
 //   - /api/v1/adapters/{type}/identity-mappings - NFS identity mapping management (admin only)
 //   - /api/v1/adapters/{type}/mounts - Protocol-specific mount listing (admin only)
 //   - /api/v1/settings/* - System settings management (admin only)
 //   - /api/v1/mounts - Unified mount listing (admin only)
 //   - GET /api/v1/durable-handles - List active durable handles (admin only)
 //   - DELETE /api/v1/durable-handles/{id} - Force-close a durable handle (admin only)
-func NewRouter(rt *runtime.Runtime, jwtService *auth.JWTService, cpStore store.Store, pprofEnabled bool, timeouts Timeouts) http.Handler {
+//   - /scim/v2/* - SCIM 2.0 user and group provisioning (when SCIM is enabled)
+//
+// Every mutating authenticated call is recorded in the audit log (see
+// apiMiddleware.Audit), in cpStore and in any auditSinks.
+func NewRouter(rt *runtime.Runtime, jwtService *auth.JWTService, cpStore store.Store, pprofEnabled bool, timeouts Timeouts, oidcConfig OIDCConfig, ldapLogin LDAPLoginConfig, scim SCIMConfig, auditSinks ...audit.Sink) http.Handler {
 	r := chi.NewRouter()
+	auditLog := apiMiddleware.Audit(audit.NewRecorder(cpStore, auditSinks...), handlers.RedactSecretJSON, r)
 
 	// Middleware stack - order matters
 	r.Use(middleware.RequestID)
@@ -128,6 +142,11 @@ func NewRouter(rt *runtime.Runtime, jwtService *auth.JWTService, cpStore store.S
 
 	// API handlers - use cpStore directly since API handlers have request context
 	authHandler := handlers.NewAuthHandler(cpStore, jwtService)
+	oidcHandler := newOIDCHandler(oidcConfig, rt, cpStore, jwtService)
+	if oidcHandler != nil && oidcConfig.DisablePasswordLogin {
+		authHandler.DisablePasswordLogin()
+	}
+	enableLDAPLogin(authHandler, ldapLogin, rt, cpStore)
 	userHandler, err := handlers.NewUserHandler(cpStore, jwtService)
 	if err != nil {
 		// This is a programming error - jwtService should always be provided
@@ -141,6 +160,12 @@ func NewRouter(rt *runtime.Runtime, jwtService *auth.JWTService, cpStore store.S
 			// Public endpoints
 			r.Post("/login", authHandler.Login)
 			r.Post("/refresh", authHandler.Refresh)
+			if oidcHandler != nil {
+				r.Get("/oidc", oidcHandler.Info)
+				r.Post("/oidc/token", oidcHandler.Token)
+				r.Post("/oidc/device", oidcHandler.StartDevice)
+				r.Post("/oidc/device/token", oidcHandler.DeviceToken)
+			}
 
 			// Authenticated endpoint
 			r.Group(func(r chi.Router) {
@@ -153,19 +178,71 @@ func NewRouter(rt *runtime.Runtime, jwtService *auth.JWTService, cpStore store.S
 		// This allows users who must change their password to actually change it
 		r.Route("/users/me/password", func(r chi.Router) {
 			r.Use(apiMiddleware.JWTAuth(jwtService))
+			r.Use(auditLog)
 			r.Post("/", userHandler.ChangeOwnPassword)
 		})
 
-		// Protected routes - require authentication and password change complete
+		// Protected routes - require authentication and password change complete.
+		// Service-account API tokens are accepted here too, limited to the
+		// routes their scopes cover.
 		r.Group(func(r chi.Router) {
-			r.Use(apiMiddleware.JWTAuth(jwtService))
+			r.Use(apiMiddleware.BearerAuth(jwtService, auth.NewAPITokenService(cpStore)))
+			r.Use(apiMiddleware.RequireTokenScope())
 			r.Use(apiMiddleware.RequirePasswordChange(passwordChangePath))
+			// Custom roles may stand in for RequireAdmin on the routes their
+			// permissions cover (see auth.RequiredPermission).
+			r.Use(apiMiddleware.ApplyCustomRoles(auth.NewRoleAuthorizer(cpStore)))
+			// Record every mutation, including ones the role checks below
+			// refuse.
+			r.Use(auditLog)
+
+			// API token management (admin only). No scope covers these
+			// routes, so API tokens cannot mint or revoke tokens.
+			r.Route("/tokens", func(r chi.Router) {
+				r.Use(apiMiddleware.RequireAdmin())
+
+				tokenHandler := handlers.NewAPITokenHandler(cpStore)
+				r.Post("/", tokenHandler.Create)
+				r.Get("/", tokenHandler.List)
+				r.Delete("/{id}", tokenHandler.Revoke)
+			})
+
+			// Custom role and role binding management (admin only). No
+			// custom role permission covers these routes, and the user and
+			// group handlers refuse delegated changes to the membership of
+			// system and role-bound groups, so delegated admins cannot widen
+			// their own rights.
+			r.Route("/roles", func(r chi.Router) {
+				r.Use(apiMiddleware.RequireAdmin())
+
+				roleHandler := handlers.NewRoleHandler(cpStore)
+				r.Post("/", roleHandler.Create)
+				r.Get("/", roleHandler.List)
+				r.Get("/{name}", roleHandler.Get)
+				r.Put("/{name}", roleHandler.Update)
+				r.Delete("/{name}", roleHandler.Remove)
+				r.Get("/{name}/bindings", roleHandler.ListBindings)
+				r.Post("/{name}/bindings", roleHandler.CreateBinding)
+				r.Delete("/{name}/bindings/{id}", roleHandler.RemoveBinding)
+			})
+
+			// Control-plane audit log (admin only). No scope covers it, so
+			// API tokens cannot read it.
+			r.Route("/audit", func(r chi.Router) {
+				r.Use(apiMiddleware.RequireAdmin())
+				r.Get("/", handlers.NewAuditHandler(cpStore).List)
+			})
 
 			// User management
 			r.Route("/users", func(r chi.Router) {
 				// Self-access allowed - handler does its own authorization
 				r.Get("/{username}", userHandler.Get)
 
+				// TOTP enrollment for the calling user
+				r.Post("/me/totp", userHandler.EnrollTOTP)
+				r.Post("/me/totp/confirm", userHandler.ConfirmTOTP)
+				r.Delete("/me/totp", userHandler.DisableOwnTOTP)
+
 				// Admin-only operations
 				r.Group(func(r chi.Router) {
 					r.Use(apiMiddleware.RequireAdmin())
@@ -175,6 +252,8 @@ func NewRouter(rt *runtime.Runtime, jwtService *auth.JWTService, cpStore store.S
 					r.Put("/{username}", userHandler.Update)
 					r.Delete("/{username}", userHandler.Remove)
 					r.Post("/{username}/password", userHandler.ResetPassword)
+					r.Post("/{username}/unlock", userHandler.Unlock)
+					r.Delete("/{username}/totp", userHandler.ResetTOTP)
 				})
 			})
 
@@ -217,6 +296,13 @@ func NewRouter(rt *runtime.Runtime, jwtService *auth.JWTService, cpStore store.S
 				r.Post("/{name}/disable", shareHandler.Disable)
 				r.Post("/{name}/enable", shareHandler.Enable)
 
+				// Offline cross-engine metadata move (share must be disabled).
+				r.Post("/{name}/migrate-metadata", shareHandler.MigrateMetadata)
+
+				// Online move of block data to another remote block store.
+				r.Post("/{name}/rehome", shareHandler.Rehome)
+				r.Get("/{name}/rehome", shareHandler.RehomeStatus)
+
 				// Per-share snapshot lifecycle. RequireAdmin is inherited
 				// from the parent /shares group.
 				snapshotHandler := handlers.NewSnapshotHandler(rt, timeouts.Restore, rt.LocalStoreDir)
@@ -251,6 +337,10 @@ func NewRouter(rt *runtime.Runtime, jwtService *auth.JWTService, cpStore store.S
 					r.Get("/status", trashHandler.Status)
 				})
 
+				// Change-event stream (server-sent events). RequireAdmin is
+				// inherited from the parent /shares group.
+				r.Get("/{name}/events", handlers.NewEventsHandler(rt).Stream)
+
 				// Per-share NFS adapter config (squash, netgroup association,
 				// auth flavor). Requires NetgroupStore capability for
 				// name<->ID resolution.
@@ -440,10 +530,21 @@ func NewRouter(rt *runtime.Runtime, jwtService *auth.JWTService, cpStore store.S
 							})
 						}
 
+						// NFS-over-TLS client certificate mapping - requires CertMappingStore capability
+						if cs, ok := cpStore.(store.CertMappingStore); ok {
+							r.Route("/cert-mappings", func(r chi.Router) {
+								certMappingHandler := handlers.NewCertMappingHandler(cs, rt.NotifyCertMappingChange)
+								r.Post("/", certMappingHandler.Create)
+								r.Get("/", certMappingHandler.List)
+								r.Get("/{name}", certMappingHandler.Get)
+								r.Delete("/{name}", certMappingHandler.Remove)
+							})
+						}
+
 						// Legacy identity mapping route (deprecated: use /api/v1/identity-mappings instead)
 						if ims, ok := cpStore.(store.IdentityMappingStore); ok {
 							r.Route("/identity-mappings", func(r chi.Router) {
-								legacyHandler := handlers.NewIdentityMappingHandler(ims, rt.NotifyIdentityMappingChange)
+								legacyHandler := handlers.NewIdentityMappingHandler(ims, cpStore, rt.NotifyIdentityMappingChange)
 								r.Get("/", legacyHandler.List)
 								r.Post("/", legacyHandler.Create)
 								r.Delete("/{principal}", legacyHandler.RemoveLegacy)
@@ -457,7 +558,7 @@ func NewRouter(rt *runtime.Runtime, jwtService *auth.JWTService, cpStore store.S
 			if ims, ok := cpStore.(store.IdentityMappingStore); ok {
 				r.Route("/identity-mappings", func(r chi.Router) {
 					r.Use(apiMiddleware.RequireAdmin())
-					idmapHandler := handlers.NewIdentityMappingHandler(ims, rt.NotifyIdentityMappingChange)
+					idmapHandler := handlers.NewIdentityMappingHandler(ims, cpStore, rt.NotifyIdentityMappingChange)
 					r.Get("/", idmapHandler.List)
 					r.Post("/", idmapHandler.Create)
 					r.Delete("/by-provider/{provider}/{principal}", idmapHandler.Remove)
@@ -544,6 +645,36 @@ func NewRouter(rt *runtime.Runtime, jwtService *auth.JWTService, cpStore store.S
 		})
 	})
 
+	// SCIM 2.0 provisioning. Identity platforms authenticate with an API
+	// token; the users scope covers every SCIM route (see
+	// auth.RequiredScope).
+	if scimHandler := newSCIMHandler(scim, rt, cpStore); scimHandler != nil {
+		r.Route("/scim/v2", func(r chi.Router) {
+			r.Use(apiMiddleware.BearerAuth(jwtService, auth.NewAPITokenService(cpStore)))
+			r.Use(apiMiddleware.RequireTokenScope())
+			r.Use(apiMiddleware.RequirePasswordChange(passwordChangePath))
+			r.Use(auditLog)
+			r.Use(apiMiddleware.RequireAdmin())
+
+			r.Get("/ServiceProviderConfig", scimHandler.ServiceProviderConfig)
+			r.Get("/ResourceTypes", scimHandler.ResourceTypes)
+
+			r.Get("/Users", scimHandler.ListUsers)
+			r.Post("/Users", scimHandler.CreateUser)
+			r.Get("/Users/{id}", scimHandler.GetUser)
+			r.Put("/Users/{id}", scimHandler.ReplaceUser)
+			r.Patch("/Users/{id}", scimHandler.PatchUser)
+			r.Delete("/Users/{id}", scimHandler.DeleteUser)
+
+			r.Get("/Groups", scimHandler.ListGroups)
+			r.Post("/Groups", scimHandler.CreateGroup)
+			r.Get("/Groups/{id}", scimHandler.GetGroup)
+			r.Put("/Groups/{id}", scimHandler.ReplaceGroup)
+			r.Patch("/Groups/{id}", scimHandler.PatchGroup)
+			r.Delete("/Groups/{id}", scimHandler.DeleteGroup)
+		})
+	}
+
 	return r
 }
 
@@ -602,11 +733,100 @@ func requestLogger(next http.Handler) http.Handler {
 			"duration", duration.String(),
 		}
 
-		// Log healthcheck requests at DEBUG to avoid polluting logs in k8s
-		if isHealthPath(r.URL.Path) {
+		// Log healthcheck requests and audit snapshots at DEBUG to avoid
+		// polluting logs in k8s
+		if isHealthPath(r.URL.Path) || apiMiddleware.IsAuditSnapshot(r.Context()) {
 			logger.Debug("API request completed", logArgs...)
 		} else {
 			logger.Info("API request completed", logArgs...)
 		}
 	})
 }
+
+// newOIDCHandler builds the OIDC login handler, or returns nil when OIDC is
+// disabled or the store cannot hold identity mappings.
+func newOIDCHandler(cfg OIDCConfig, rt *runtime.Runtime, cpStore store.Store, jwtService *auth.JWTService) *handlers.OIDCHandler {
+	if !cfg.Enabled {
+		return nil
+	}
+	ims, ok := cpStore.(interface {
+		store.UserStore
+		store.IdentityMappingStore
+	})
+	if !ok {
+		logger.Warn("OIDC login disabled: control plane store does not support identity mappings")
+		return nil
+	}
+	client, err := auth.NewOIDCClient(auth.OIDCClientConfig{
+		Issuer:       cfg.Issuer,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.GetClientSecret(),
+		Scopes:       cfg.Scopes,
+	})
+	if err != nil {
+		logger.Warn("OIDC login disabled", "error", err)
+		return nil
+	}
+	var onChange func()
+	if rt != nil {
+		onChange = rt.NotifyIdentityMappingChange
+	}
+	return handlers.NewOIDCHandler(ims, client, jwtService, handlers.OIDCSettings{
+		UsernameClaim:  cfg.UsernameClaim,
+		GroupsClaim:    cfg.GroupsClaim,
+		AdminGroups:    cfg.AdminGroups,
+		OperatorGroups: cfg.OperatorGroups,
+		UserGroups:     cfg.UserGroups,
+		RequireMFA:     cfg.RequireMFA,
+		MFAMethods:     cfg.MFAMethods,
+		MatchUsername:  cfg.MatchUsername,
+		AutoProvision:  cfg.AutoProvision,
+	}, onChange)
+}
+
+// enableLDAPLogin turns on directory password login when configured. The
+// directory itself is the runtime's LDAP identity provider config, read at
+// each login so API-driven changes apply without a restart.
+func enableLDAPLogin(h *handlers.AuthHandler, cfg LDAPLoginConfig, rt *runtime.Runtime, cpStore store.Store) {
+	if !cfg.Enabled || rt == nil {
+		return
+	}
+	ims, ok := cpStore.(store.IdentityMappingStore)
+	if !ok {
+		logger.Warn("LDAP login disabled: control plane store does not support identity mappings")
+		return
+	}
+	h.EnableDirectoryLogin(rt, ims, handlers.DirectoryLoginSettings{
+		AdminGroups:    cfg.AdminGroups,
+		OperatorGroups: cfg.OperatorGroups,
+		UserGroups:     cfg.UserGroups,
+		AutoProvision:  cfg.AutoProvision,
+	}, rt.NotifyIdentityMappingChange)
+}
+
+// newSCIMHandler builds the SCIM provisioning handler, or returns nil when
+// SCIM is disabled or the store cannot hold SID mappings.
+func newSCIMHandler(cfg SCIMConfig, rt *runtime.Runtime, cpStore store.Store) *handlers.SCIMHandler {
+	if !cfg.Enabled {
+		return nil
+	}
+	ss, ok := cpStore.(interface {
+		store.UserStore
+		store.GroupStore
+		store.SIDMappingStore
+	})
+	if !ok {
+		logger.Warn("SCIM disabled: control plane store does not support SID mappings")
+		return nil
+	}
+	var sids func() *sid.SIDMapper
+	var onChange func()
+	if rt != nil {
+		sids = rt.SIDMapper
+		onChange = rt.NotifyIdentityMappingChange
+	}
+	return handlers.NewSCIMHandler(ss, sids, handlers.SCIMSettings{
+		UIDBase: cfg.UIDBase,
+		GIDBase: cfg.GIDBase,
+	}, onChange)
+}
@@ -37,7 +37,7 @@ func newTestRouter(t *testing.T, pprofEnabled bool) (http.Handler, *auth.JWTServ
 		t.Fatalf("create jwt service: %v", err)
 	}
 
-	router := NewRouter(runtime.New(cpStore), jwtService, cpStore, pprofEnabled, Timeouts{Restore: 30 * time.Minute, DrainStall: 5 * time.Minute})
+	router := NewRouter(runtime.New(cpStore), jwtService, cpStore, pprofEnabled, Timeouts{Restore: 30 * time.Minute, DrainStall: 5 * time.Minute}, OIDCConfig{}, LDAPLoginConfig{}, SCIMConfig{})
 	return router, jwtService, cpStore
 }
 
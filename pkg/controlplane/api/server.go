@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/marmos91/dittofs/internal/controlplane/api/auth"
+	"github.com/marmos91/dittofs/internal/controlplane/audit"
 	"github.com/marmos91/dittofs/internal/logger"
 	"github.com/marmos91/dittofs/internal/tlsconfig"
 	"github.com/marmos91/dittofs/pkg/controlplane/runtime"
@@ -60,6 +61,7 @@ type Server struct {
 	jwtService   *auth.JWTService
 	cpStore      store.Store
 	config       APIConfig
+	auditSinks   []audit.Sink
 	shutdownOnce sync.Once
 }
 
@@ -141,8 +143,13 @@ func NewServer(config APIConfig, rt *runtime.Runtime, cpStore store.Store, timeo
 		goruntime.SetBlockProfileRate(0)
 	}
 
+	auditSinks, err := newAuditSinks(config.Audit)
+	if err != nil {
+		return nil, err
+	}
+
 	// cpStore implements both IdentityStore and Store
-	router := NewRouter(rt, jwtService, cpStore, config.Pprof, timeouts)
+	router := NewRouter(rt, jwtService, cpStore, config.Pprof, timeouts, config.OIDC, config.LDAPLogin, config.SCIM, auditSinks...)
 
 	writeTimeout := config.WriteTimeout
 	if config.Pprof && writeTimeout < 120*time.Second {
@@ -165,9 +172,35 @@ func NewServer(config APIConfig, rt *runtime.Runtime, cpStore store.Store, timeo
 		jwtService: jwtService,
 		cpStore:    cpStore,
 		config:     config,
+		auditSinks: auditSinks,
 	}, nil
 }
 
+// newAuditSinks opens the audit log sinks enabled in cfg.
+func newAuditSinks(cfg AuditConfig) ([]audit.Sink, error) {
+	var sinks []audit.Sink
+	if cfg.File != "" {
+		fs, err := audit.NewFileSink(cfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("controlplane.audit.file: %w", err)
+		}
+		sinks = append(sinks, fs)
+		logger.Info("Audit log file sink enabled", "path", cfg.File)
+	}
+	if cfg.Syslog.Enabled {
+		ss, err := audit.NewSyslogSink(cfg.Syslog.Network, cfg.Syslog.Address, cfg.Syslog.Tag)
+		if err != nil {
+			for _, s := range sinks {
+				_ = s.Close()
+			}
+			return nil, fmt.Errorf("controlplane.audit.syslog: %w", err)
+		}
+		sinks = append(sinks, ss)
+		logger.Info("Audit log syslog sink enabled", "network", cfg.Syslog.Network, "address", cfg.Syslog.Address)
+	}
+	return sinks, nil
+}
+
 // Start starts the API HTTP server and blocks until the context is cancelled
 // or an error occurs.
 //
@@ -250,6 +283,12 @@ func (s *Server) Stop(ctx context.Context) error {
 		} else {
 			logger.Info("API server stopped gracefully")
 		}
+
+		for _, sink := range s.auditSinks {
+			if err := sink.Close(); err != nil {
+				logger.Warn("Failed to close audit log sink", "error", err)
+			}
+		}
 	})
 	return shutdownErr
 }
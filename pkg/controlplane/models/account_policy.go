@@ -0,0 +1,77 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Account policy errors.
+var (
+	// ErrAccountLocked is returned when a login is refused because the
+	// account is locked out after too many failed attempts.
+	ErrAccountLocked = errors.New("account is locked after too many failed logins")
+
+	// ErrPasswordPolicy is wrapped by every password policy violation.
+	ErrPasswordPolicy = errors.New("password does not meet the password policy")
+)
+
+// AccountPolicy governs local password accounts: password strength, reuse and
+// age, lockout after repeated failed logins, and TOTP enrollment. Zero
+// fields disable the corresponding rule. Lockout applies to every protocol
+// that checks a local password (REST API login and SMB NTLM session setup).
+type AccountPolicy struct {
+	// MinPasswordLength is the minimum password length. Values below
+	// MinPasswordLength (the built-in floor) have no effect.
+	MinPasswordLength int
+
+	// PasswordHistory is how many previous passwords a user may not reuse.
+	PasswordHistory int
+
+	// PasswordMaxAge is how long a password stays valid. A login with an
+	// older password succeeds but must change the password first.
+	PasswordMaxAge time.Duration
+
+	// LockoutThreshold is the number of consecutive failed logins that locks
+	// the account.
+	LockoutThreshold int
+
+	// LockoutDuration is how long a locked account stays locked before it
+	// unlocks on its own.
+	LockoutDuration time.Duration
+
+	// TOTPIssuer is the issuer named in TOTP enrollment URIs.
+	TOTPIssuer string
+}
+
+// CheckPassword verifies a new password against the length rules. Reuse is
+// checked by the store, which holds the history.
+func (p AccountPolicy) CheckPassword(password string) error {
+	minLen := max(p.MinPasswordLength, MinPasswordLength)
+	if len(password) < minLen {
+		return fmt.Errorf("%w: password must be at least %d characters", ErrPasswordPolicy, minLen)
+	}
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("%w: %v", ErrPasswordPolicy, ErrPasswordTooLong)
+	}
+	return nil
+}
+
+// PasswordExpired reports whether the user's password is older than the
+// policy allows at now. Passwords set before the policy tracked their age
+// are measured from the account's creation.
+func (p AccountPolicy) PasswordExpired(u *User, now time.Time) bool {
+	if p.PasswordMaxAge <= 0 {
+		return false
+	}
+	changed := u.CreatedAt
+	if u.PasswordChangedAt != nil {
+		changed = *u.PasswordChangedAt
+	}
+	return !changed.IsZero() && now.Sub(changed) > p.PasswordMaxAge
+}
+
+// IsLockedOut reports whether the user is locked out at now.
+func (u *User) IsLockedOut(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
@@ -106,6 +106,36 @@ type NFSAdapterSettings struct {
 	// field name, mismatching the JSON tag and the store's column maps.
 	MDNSEnabled bool `gorm:"column:mdns_enabled;default:false" json:"mdns_enabled"`
 
+	// IDMapDomain is the NFSv4 ID-mapping domain (RFC 7530 Section 5.9). When
+	// set, FATTR4_OWNER/OWNER_GROUP carry "name@domain" strings translated
+	// through the user/group store and the LDAP directory, matching the
+	// clients' idmapd Domain. Empty keeps purely numeric owner strings.
+	// Applied live.
+	IDMapDomain string `gorm:"column:idmap_domain;size:255" json:"idmap_domain"`
+
+	// IDMapNumericAuthSys sends numeric owner strings to AUTH_SYS requests even
+	// when IDMapDomain is set, like the Linux server's nfs4_disable_idmapping.
+	// Kerberos requests always get names. Defaults to true.
+	IDMapNumericAuthSys bool `gorm:"column:idmap_numeric_auth_sys;default:true" json:"idmap_numeric_auth_sys"`
+
+	// PNFSDataServers lists pNFS data servers ("host:port", JSON array stored
+	// as text). When non-empty, NFSv4.1 clients get Flexible Files layouts
+	// (RFC 8435) and send READ/WRITE straight to these servers. Each must be
+	// a DittoFS instance exporting the same shares from the same metadata and
+	// block stores. Empty disables pNFS. Applied live.
+	PNFSDataServers string `gorm:"column:pnfs_data_servers;type:text" json:"-"`
+
+	// PNFSDataServerVersion is the NFS version clients use to reach the data
+	// servers: "3" (default) or "4.1".
+	PNFSDataServerVersion string `gorm:"column:pnfs_data_server_version;default:3;size:10" json:"pnfs_data_server_version"`
+
+	// RQuotaSetQuotaEnabled lets root on an NFS client change quotas with
+	// rquota SETQUOTA/SETACTIVEQUOTA (setquota, edquota). Root is taken from
+	// the AUTH_SYS credential, which any host allowed by the export can claim,
+	// so this defaults to false (opt-in) and is never honored over UDP.
+	// Quota reporting is unaffected. Applied live.
+	RQuotaSetQuotaEnabled bool `gorm:"column:rquota_set_quota_enabled;default:false" json:"rquota_set_quota_enabled"`
+
 	// Version counter for change detection (monotonic, starts at 1, incremented on every update)
 	Version int `gorm:"default:1" json:"version"`
 
@@ -128,6 +158,16 @@ func (s *NFSAdapterSettings) SetBlockedOperations(ops []string) {
 	s.BlockedOperations = marshalStringSlice(ops)
 }
 
+// GetPNFSDataServers returns the pNFS data server addresses as a string slice.
+func (s *NFSAdapterSettings) GetPNFSDataServers() []string {
+	return parseStringSlice(s.PNFSDataServers)
+}
+
+// SetPNFSDataServers serializes the pNFS data server addresses from a string slice.
+func (s *NFSAdapterSettings) SetPNFSDataServers(servers []string) {
+	s.PNFSDataServers = marshalStringSlice(servers)
+}
+
 // GetV4MinMinorVersion returns the minimum NFSv4 minor version as uint32.
 // Defaults to 0 (v4.0) for negative values, which is the lowest supported version.
 func (s *NFSAdapterSettings) GetV4MinMinorVersion() uint32 {
@@ -283,6 +323,9 @@ func NewDefaultNFSSettings(adapterID string) *NFSAdapterSettings {
 		PortmapperRegisterWithSystem: false,
 		UDPEnabled:                   false,
 		MDNSEnabled:                  false,
+		IDMapNumericAuthSys:          true,
+		PNFSDataServerVersion:        "3",
+		RQuotaSetQuotaEnabled:        false,
 		Version:                      1,
 	}
 }
@@ -396,6 +439,10 @@ func DefaultSMBSettingsValidRange() SMBSettingsValidRange {
 	}
 }
 
+// ValidPNFSDataServerVersions lists the NFS versions pNFS data servers can be
+// reached with.
+var ValidPNFSDataServerVersions = []string{"3", "4.1"}
+
 // ValidNFSVersions lists supported NFS version strings.
 var ValidNFSVersions = []string{"3", "4.0", "4.1"}
 
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// APIToken is a long-lived, revocable credential for automation, bound to a
+// service account (a User with ServiceAccount set). Only the SHA-256 hash of
+// the token is stored; the plaintext is shown once, at creation.
+//
+// A token authorizes only the API areas named by its Scopes (e.g.
+// "shares:read", "snapshots:write", "blockstore:gc"), never the user's role.
+type APIToken struct {
+	ID string `gorm:"primaryKey;size:36" json:"id"`
+
+	// Name labels the token for humans (e.g. "terraform-prod"). Unique per
+	// service account.
+	Name string `gorm:"not null;size:255;uniqueIndex:idx_api_token_user_name" json:"name"`
+
+	// UserID is the service account the token acts as.
+	UserID string `gorm:"not null;size:36;index;uniqueIndex:idx_api_token_user_name" json:"user_id"`
+
+	// Hash is the hex SHA-256 of the plaintext token.
+	Hash string `gorm:"not null;size:64;uniqueIndex" json:"-"`
+
+	// Prefix is the first characters of the plaintext token, kept so an
+	// operator can tell tokens apart in listings and logs.
+	Prefix string `gorm:"not null;size:16" json:"prefix"`
+
+	// Scopes lists the granted scopes.
+	Scopes []string `gorm:"serializer:json" json:"scopes"`
+
+	// ExpiresAt is when the token stops working; nil means never.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// LastUsedAt is updated (at minute granularity) on authenticated use.
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	// CreatedBy is the username of the admin who created the token.
+	CreatedBy string `gorm:"size:255" json:"created_by,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// IsExpired reports whether the token has expired at now.
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// HasScope reports whether the token grants scope.
+func (t *APIToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// AuditEvent records one mutating control-plane API call: who made it, what
+// it targeted, how it ended, and the state of the target before and after.
+//
+// The audit log is append-only: events are never updated or deleted through
+// the store. Before, After and Request hold JSON documents with secret values
+// already redacted; they are empty when the state could not be captured
+// (e.g. an action endpoint with no readable resource, or a failed call).
+type AuditEvent struct {
+	ID   string    `gorm:"primaryKey;size:36" json:"id"`
+	Time time.Time `gorm:"column:occurred_at;not null;index" json:"time"`
+
+	// Actor is the username the call was authenticated as; ActorID its user ID.
+	Actor   string `gorm:"size:255;index" json:"actor"`
+	ActorID string `gorm:"size:36" json:"actor_id,omitempty"`
+
+	// AuthMethod is "jwt" or "api_token".
+	AuthMethod string `gorm:"size:16" json:"auth_method,omitempty"`
+
+	// Delegated is set when admin rights came from an API-token scope or a
+	// custom role rather than the built-in admin role.
+	Delegated bool `json:"delegated,omitempty"`
+
+	// Method and Path identify the call; Resource is the first path segment
+	// below /api/v1 (e.g. "shares", "users", "store").
+	Method   string `gorm:"not null;size:8" json:"method"`
+	Path     string `gorm:"not null;size:1024" json:"path"`
+	Resource string `gorm:"size:64;index" json:"resource"`
+
+	// Status is the HTTP status code returned to the caller.
+	Status int `json:"status"`
+
+	RequestID  string `gorm:"size:128" json:"request_id,omitempty"`
+	RemoteAddr string `gorm:"size:255" json:"remote_addr,omitempty"`
+
+	// Request is the redacted request body.
+	Request string `gorm:"type:text" json:"request,omitempty"`
+
+	// Before and After are the redacted target state around the call.
+	Before string `gorm:"type:text" json:"before,omitempty"`
+	After  string `gorm:"type:text" json:"after,omitempty"`
+
+	// Changes lists the dotted field paths that differ between Before and
+	// After.
+	Changes []string `gorm:"serializer:json" json:"changes,omitempty"`
+}
+
+// TableName returns the table name for AuditEvent.
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+// AuditFilter selects audit events. Zero fields do not filter.
+type AuditFilter struct {
+	// Since and Until bound the event time (inclusive, exclusive).
+	Since time.Time
+	Until time.Time
+
+	// Actor matches the username exactly.
+	Actor string
+
+	// Resource matches the first path segment below /api/v1.
+	Resource string
+
+	// Limit caps the number of events returned, newest first.
+	Limit int
+}
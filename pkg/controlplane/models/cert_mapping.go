@@ -0,0 +1,127 @@
+package models
+
+import (
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CertMapping maps NFS-over-TLS client certificates to an access policy.
+//
+// A rule matches a verified client certificate when one value of its Field
+// (the subject CN or a DNS, email or URI SAN) matches Pattern. The matching
+// rule then decides which shares the client may use and how its AUTH_SYS
+// credentials are squashed, giving hosts strong authentication without
+// Kerberos. Rules are evaluated in ascending Priority, then by Name.
+type CertMapping struct {
+	ID       string `gorm:"primaryKey;size:36" json:"id"`
+	Name     string `gorm:"uniqueIndex;not null;size:255" json:"name"`
+	Priority int    `gorm:"not null;default:0" json:"priority"`
+
+	// Field is the certificate field matched: "cn", "dns", "email" or "uri".
+	Field string `gorm:"not null;size:20" json:"field"`
+	// Pattern is an exact value, a "*.example.com" suffix wildcard, or "*"
+	// for any certificate carrying the field.
+	Pattern string `gorm:"not null;size:1024" json:"pattern"`
+
+	// Shares limits the rule to these share names (normalized with a leading
+	// slash). Empty means every share. A share named by any rule may only be
+	// used by certificates matching a rule that names it.
+	Shares []string `gorm:"serializer:json" json:"shares,omitempty"`
+
+	// Squash, AnonymousUID and AnonymousGID override the share's identity
+	// mapping for matching clients; empty/nil keeps the share's value.
+	Squash       string    `gorm:"size:20" json:"squash,omitempty"`
+	AnonymousUID *uint32   `json:"anonymous_uid,omitempty"`
+	AnonymousGID *uint32   `json:"anonymous_gid,omitempty"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for CertMapping.
+func (CertMapping) TableName() string {
+	return "cert_mappings"
+}
+
+// ValidCertMatchFields lists the certificate fields a rule can match.
+var ValidCertMatchFields = []string{"cn", "dns", "email", "uri"}
+
+// Validate checks the match field, pattern and squash mode of a rule.
+func (m *CertMapping) Validate() error {
+	if !slices.Contains(ValidCertMatchFields, m.Field) {
+		return fmt.Errorf("invalid match field %q: must be one of %s", m.Field, strings.Join(ValidCertMatchFields, ", "))
+	}
+	switch {
+	case m.Pattern == "":
+		return fmt.Errorf("pattern must not be empty")
+	case strings.ContainsAny(m.Pattern, " \t\n\r"):
+		return fmt.Errorf("pattern must not contain whitespace: %s", m.Pattern)
+	case m.Pattern == "*.":
+		return fmt.Errorf("wildcard pattern must have a domain: %s", m.Pattern)
+	}
+	if m.Squash != "" && !SquashMode(m.Squash).IsValid() {
+		return fmt.Errorf("invalid squash mode %q", m.Squash)
+	}
+	return nil
+}
+
+// MatchesCertificate reports whether any value of the rule's field in cert
+// matches its pattern. Matching is case-insensitive except for URIs.
+func (m *CertMapping) MatchesCertificate(cert *x509.Certificate) bool {
+	if cert == nil {
+		return false
+	}
+	var values []string
+	switch m.Field {
+	case "cn":
+		if cert.Subject.CommonName != "" {
+			values = []string{cert.Subject.CommonName}
+		}
+	case "dns":
+		values = cert.DNSNames
+	case "email":
+		values = cert.EmailAddresses
+	case "uri":
+		for _, u := range cert.URIs {
+			values = append(values, u.String())
+		}
+	}
+	for _, v := range values {
+		if m.matchValue(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *CertMapping) matchValue(v string) bool {
+	if m.Pattern == "*" {
+		return true
+	}
+	if m.Field == "uri" {
+		return v == m.Pattern
+	}
+	if suffix, ok := strings.CutPrefix(m.Pattern, "*"); ok && strings.HasPrefix(suffix, ".") {
+		return len(v) > len(suffix) && strings.HasSuffix(strings.ToLower(v), strings.ToLower(suffix))
+	}
+	return strings.EqualFold(v, m.Pattern)
+}
+
+// NamesShare reports whether the rule lists share explicitly.
+func (m *CertMapping) NamesShare(share string) bool {
+	share = "/" + strings.TrimLeft(share, "/")
+	for _, s := range m.Shares {
+		if "/"+strings.TrimLeft(s, "/") == share {
+			return true
+		}
+	}
+	return false
+}
+
+// CoversShare reports whether the rule applies to share: unscoped rules
+// cover every share.
+func (m *CertMapping) CoversShare(share string) bool {
+	return len(m.Shares) == 0 || m.NamesShare(share)
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+)
+
+func TestCertMapping_MatchesCertificate(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://corp/ci/runner")
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "Deploy-01"},
+		DNSNames:       []string{"runner7.build.internal"},
+		EmailAddresses: []string{"ops@example.com"},
+		URIs:           []*url.URL{spiffe},
+	}
+
+	tests := []struct {
+		field, pattern string
+		want           bool
+	}{
+		{"cn", "deploy-01", true},
+		{"cn", "deploy", false},
+		{"dns", "*.build.internal", true},
+		{"dns", "*.BUILD.internal", true},
+		{"dns", "*.internal", true},
+		{"dns", "*.test.internal", false},
+		{"dns", "build.internal", false},
+		{"email", "ops@example.com", true},
+		{"uri", "spiffe://corp/ci/runner", true},
+		{"uri", "SPIFFE://corp/ci/runner", false},
+		{"dns", "*", true},
+	}
+	for _, tt := range tests {
+		m := &CertMapping{Field: tt.field, Pattern: tt.pattern}
+		if got := m.MatchesCertificate(cert); got != tt.want {
+			t.Errorf("%s %q: match = %v, want %v", tt.field, tt.pattern, got, tt.want)
+		}
+	}
+
+	// A wildcard never matches the bare domain, and no certificate matches nothing.
+	bare := &x509.Certificate{DNSNames: []string{".build.internal"}}
+	if (&CertMapping{Field: "dns", Pattern: "*.build.internal"}).MatchesCertificate(bare) {
+		t.Error("wildcard matched the bare suffix")
+	}
+	if (&CertMapping{Field: "cn", Pattern: "*"}).MatchesCertificate(nil) {
+		t.Error("nil certificate matched")
+	}
+	if (&CertMapping{Field: "cn", Pattern: "*"}).MatchesCertificate(&x509.Certificate{}) {
+		t.Error("\"*\" matched a certificate without a CN")
+	}
+}
+
+func TestCertMapping_Validate(t *testing.T) {
+	valid := []CertMapping{
+		{Field: "cn", Pattern: "deploy"},
+		{Field: "dns", Pattern: "*.build.internal", Squash: string(SquashAllToGuest)},
+	}
+	for _, m := range valid {
+		if err := m.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v, want nil", m, err)
+		}
+	}
+	invalid := []CertMapping{
+		{Field: "serial", Pattern: "1"},
+		{Field: "cn", Pattern: ""},
+		{Field: "cn", Pattern: "two words"},
+		{Field: "dns", Pattern: "*."},
+		{Field: "cn", Pattern: "x", Squash: "root_squash"},
+	}
+	for _, m := range invalid {
+		if err := m.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", m)
+		}
+	}
+}
+
+func TestCertMapping_Shares(t *testing.T) {
+	m := &CertMapping{Shares: []string{"builds"}}
+	if !m.NamesShare("/builds") || !m.CoversShare("/builds") {
+		t.Error("rule does not name /builds")
+	}
+	if m.CoversShare("/home") {
+		t.Error("scoped rule covers an unlisted share")
+	}
+	if unscoped := (&CertMapping{}); unscoped.NamesShare("/home") || !unscoped.CoversShare("/home") {
+		t.Error("unscoped rule must cover, but not name, every share")
+	}
+}
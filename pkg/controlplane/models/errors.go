@@ -81,6 +81,32 @@ var (
 	ErrRestoreMarkerNotFound       = errors.New("restore marker not found")
 	ErrRestoreInProgress           = errors.New("a restore is already in progress for this share")
 
+	// Metadata migration sentinels (dfsctl share migrate-metadata).
+	ErrMigrationShareEnabled   = errors.New("share must be disabled before metadata migration")
+	ErrMigrationSameStore      = errors.New("share already uses this metadata store")
+	ErrMigrationTargetHasShare = errors.New("target metadata store already holds this share")
+	ErrMigrationInProgress     = errors.New("a restore or metadata migration is already in progress for this share")
+
+	// Remote re-home sentinels (dfsctl share rehome).
+	ErrRehomeSameRemote      = errors.New("share already uses this remote block store")
+	ErrRehomeNoRemote        = errors.New("share has no remote block store to re-home from")
+	ErrRehomeInProgress      = errors.New("a remote re-home is already in progress for this share")
+	ErrRehomeModeUnsupported = errors.New("remote transforms differ; copy mode requires identical compression and encryption settings")
+	ErrRehomeSharedLocators  = errors.New("another share on the same metadata store uses the source remote; repack would move its chunks")
+	ErrRehomeMarkerNotFound  = errors.New("rehome marker not found")
+
+	// API token errors
+	ErrAPITokenNotFound    = errors.New("API token not found")
+	ErrDuplicateAPIToken   = errors.New("API token with this name already exists")
+	ErrNotServiceAccount   = errors.New("user is not a service account")
+	ErrServiceAccountLogin = errors.New("service accounts authenticate with API tokens only")
+
+	// Custom role errors
+	ErrRoleNotFound         = errors.New("role not found")
+	ErrDuplicateRole        = errors.New("role already exists")
+	ErrRoleBindingNotFound  = errors.New("role binding not found")
+	ErrDuplicateRoleBinding = errors.New("role binding already exists")
+
 	// Setting errors
 	ErrSettingNotFound = errors.New("setting not found")
 
@@ -89,6 +115,10 @@ var (
 	ErrDuplicateNetgroup = errors.New("netgroup already exists")
 	ErrNetgroupInUse     = errors.New("netgroup is referenced by shares")
 
+	// Certificate mapping errors
+	ErrCertMappingNotFound  = errors.New("certificate mapping not found")
+	ErrDuplicateCertMapping = errors.New("certificate mapping already exists")
+
 	// Guest errors
 	ErrGuestDisabled = errors.New("guest access is disabled")
 )
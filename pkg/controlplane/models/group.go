@@ -14,6 +14,11 @@ type Group struct {
 	Description string    `gorm:"size:1024" json:"description,omitempty"`
 	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
 
+	// SID is the group's Windows Security Identifier when it comes from a
+	// directory (e.g. set by SCIM provisioning). Empty for groups whose SID
+	// is derived from the GID and the machine SID.
+	SID string `gorm:"column:sid;size:184" json:"sid,omitempty"`
+
 	// Many-to-many relationship with users
 	Users []User `gorm:"many2many:user_groups;" json:"users,omitempty"`
 
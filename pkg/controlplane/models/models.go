@@ -13,6 +13,7 @@ func AllModels() []any {
 		&Snapshot{},
 		&SnapshotPolicy{},
 		&RestoreMarker{},
+		&RehomeMarker{},
 		&ShareAccessRule{},
 		&ShareAdapterConfig{},
 		&UserSharePermission{},
@@ -27,5 +28,10 @@ func AllModels() []any {
 		&SMBAdapterSettings{},
 		&Netgroup{},
 		&NetgroupMember{},
+		&CertMapping{},
+		&APIToken{},
+		&Role{},
+		&RoleBinding{},
+		&AuditEvent{},
 	}
 }
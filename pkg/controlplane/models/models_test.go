@@ -346,3 +346,21 @@ func TestParseSharePermission(t *testing.T) {
 		})
 	}
 }
+
+func TestCleanExportPath(t *testing.T) {
+	for in, want := range map[string]string{
+		"projects/alpha":   "/projects/alpha",
+		"/projects/alpha/": "/projects/alpha",
+		"/a//b/./c":        "/a/b/c",
+	} {
+		got, err := CleanExportPath(in)
+		if err != nil || got != want {
+			t.Errorf("CleanExportPath(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "/", "../etc", "/a/../../b", `a\b`} {
+		if _, err := CleanExportPath(in); err == nil {
+			t.Errorf("CleanExportPath(%q) succeeded, want error", in)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Re-home steps. They record how far a share's remote re-home got, so a
+// restart resumes at the right point.
+const (
+	// RehomeStepPending is written before the share's remote binding
+	// changes. Models.Share still names the source remote; on startup the
+	// job simply starts over.
+	RehomeStepPending string = "pending"
+
+	// RehomeStepSwitched is written once Share.RemoteBlockStoreID names the
+	// target remote. Blocks may still be on the source only, so on startup
+	// the source is re-attached as the read fallback before the share
+	// serves, and the data move resumes.
+	RehomeStepSwitched string = "switched"
+)
+
+// RehomeMarker is the durable record of an in-flight remote re-home (dfsctl
+// share rehome): moving a share's block data from one remote block store to
+// another while it keeps serving. At most one marker exists per share; it is
+// written before the share's binding changes and deleted once every block is
+// on the target. A marker found at server startup means the re-home was
+// interrupted and is resumed by recoverInterruptedRehomes.
+type RehomeMarker struct {
+	// ShareName is the primary key: at most one re-home per share.
+	ShareName string `gorm:"primaryKey;size:255" json:"share_name"`
+
+	// SourceRemoteID and TargetRemoteID are the remote block-store config
+	// IDs the share moves from and to.
+	SourceRemoteID string `gorm:"not null;size:36" json:"source_remote_id"`
+	TargetRemoteID string `gorm:"not null;size:36" json:"target_remote_id"`
+
+	// Mode is the engine re-home mode: "copy" or "repack".
+	Mode string `gorm:"not null;size:20" json:"mode"`
+
+	// Step is one of the RehomeStep* constants.
+	Step string `gorm:"not null;size:20" json:"step"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (RehomeMarker) TableName() string {
+	return "rehome_markers"
+}
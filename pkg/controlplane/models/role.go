@@ -0,0 +1,71 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Role is a custom control-plane role: a named set of permissions that can
+// be granted to users and groups without making them server admins. It is
+// distinct from UserRole, the built-in admin/user/operator tier stored on
+// each user.
+//
+// A permission is "<resource>:<verb>", e.g. "quotas:write". Resources are
+// shares, snapshots, quotas, trash, stores, adapters and users; verbs are
+// read and write, with write implying read.
+type Role struct {
+	ID          string    `gorm:"primaryKey;size:36" json:"id"`
+	Name        string    `gorm:"uniqueIndex;not null;size:255" json:"name"`
+	Description string    `gorm:"size:1024" json:"description,omitempty"`
+	Permissions []string  `gorm:"serializer:json" json:"permissions"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for Role.
+func (Role) TableName() string {
+	return "roles"
+}
+
+// RoleBinding grants a Role to exactly one user or group. When Shares is
+// non-empty the role's share-level permissions apply only to those shares,
+// which is how administration of a single share is delegated.
+type RoleBinding struct {
+	ID     string `gorm:"primaryKey;size:36" json:"id"`
+	RoleID string `gorm:"not null;size:36;index" json:"role_id"`
+	Role   *Role  `gorm:"foreignKey:RoleID" json:"-"`
+
+	// UserID or GroupID names the subject; the other is empty.
+	UserID  string `gorm:"size:36;index" json:"user_id,omitempty"`
+	GroupID string `gorm:"size:36;index" json:"group_id,omitempty"`
+
+	// Shares limits the binding to these share names (normalized with a
+	// leading slash). Empty means every share.
+	Shares []string `gorm:"serializer:json" json:"shares,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for RoleBinding.
+func (RoleBinding) TableName() string {
+	return "role_bindings"
+}
+
+// CoversShare reports whether the binding applies to share. Unscoped
+// bindings cover every share; share == "" (a server-wide request) is only
+// covered by unscoped bindings.
+func (b *RoleBinding) CoversShare(share string) bool {
+	if len(b.Shares) == 0 {
+		return true
+	}
+	if share == "" {
+		return false
+	}
+	share = "/" + strings.TrimLeft(share, "/")
+	for _, s := range b.Shares {
+		if "/"+strings.TrimLeft(s, "/") == share {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/marmos91/dittofs/pkg/metadata"
 )
 
 // KerberosLevel constants for share security policy.
@@ -77,7 +78,20 @@ type Share struct {
 	// TrashExcludePatterns are globs that bypass the bin (immediate delete),
 	// stored as a JSON array string (same encoding as BlockedOperations).
 	TrashExcludePatterns string `gorm:"type:text" json:"-"`
-	DefaultPermission    string `gorm:"default:none;size:50" json:"default_permission"` // none, read, read-write, admin
+	// Bandwidth caps this share's remote upload/download rates, optionally by
+	// time of day, as a JSON-encoded block.BandwidthPolicy (empty = unlimited).
+	// The settings watcher re-applies it to the live syncer on change.
+	Bandwidth         string `gorm:"type:text" json:"-"`
+	DefaultPermission string `gorm:"default:none;size:50" json:"default_permission"` // none, read, read-write, admin
+	// WarmPolicy is the share's automatic cache pre-warm policy as a
+	// JSON-encoded block.WarmPolicy (empty = none).
+	WarmPolicy string `gorm:"type:text" json:"-"`
+	// FileAuditEnabled turns on the per-share file-access audit trail.
+	// Default false.
+	FileAuditEnabled bool `gorm:"default:false;not null" json:"file_audit_enabled"`
+	// FileAuditOperations are the audited operations (metadata.FileAuditOp
+	// names) as a JSON array string; empty = the default set.
+	FileAuditOperations string `gorm:"type:text" json:"-"`
 	// OwnerUID/OwnerGID persist the UID/GID that owns the share's root
 	// directory (resolved from the owner username at creation). Nil means no
 	// explicit owner (root-owned). Startup re-applies these to the root so
@@ -159,6 +173,58 @@ func (s *Share) GetRetentionTTL() time.Duration {
 	return time.Duration(s.RetentionTTL) * time.Second
 }
 
+// GetBandwidthPolicy returns the share's parsed bandwidth policy. An empty or
+// malformed column yields the zero (unlimited) policy; the API validates the
+// policy before it is stored.
+func (s *Share) GetBandwidthPolicy() block.BandwidthPolicy {
+	p, err := block.ParseBandwidthPolicy(s.Bandwidth)
+	if err != nil {
+		return block.BandwidthPolicy{}
+	}
+	return p
+}
+
+// SetBandwidthPolicy serializes p into the Bandwidth column. A zero policy
+// clears the column.
+func (s *Share) SetBandwidthPolicy(p block.BandwidthPolicy) error {
+	if p.IsZero() {
+		s.Bandwidth = ""
+		return nil
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	s.Bandwidth = string(data)
+	return nil
+}
+
+// GetWarmPolicy returns the share's parsed pre-warm policy. An empty or
+// malformed column yields the zero (disabled) policy; the API validates the
+// policy before it is stored.
+func (s *Share) GetWarmPolicy() block.WarmPolicy {
+	p, err := block.ParseWarmPolicy(s.WarmPolicy)
+	if err != nil {
+		return block.WarmPolicy{}
+	}
+	return p
+}
+
+// SetWarmPolicy serializes p into the WarmPolicy column. A policy that never
+// triggers clears the column.
+func (s *Share) SetWarmPolicy(p block.WarmPolicy) error {
+	if p.IsZero() {
+		s.WarmPolicy = ""
+		return nil
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	s.WarmPolicy = string(data)
+	return nil
+}
+
 // ShareAccessRule defines client access rules for a share.
 type ShareAccessRule struct {
 	ID            string `gorm:"primaryKey;size:36" json:"id"`
@@ -192,3 +258,24 @@ func (s *Share) GetTrashExcludePatterns() []string {
 func (s *Share) SetTrashExcludePatterns(patterns []string) {
 	s.TrashExcludePatterns = marshalStringSlice(patterns)
 }
+
+// GetFileAuditOperations returns the audited operation names as a string slice.
+func (s *Share) GetFileAuditOperations() []string {
+	return parseStringSlice(s.FileAuditOperations)
+}
+
+// SetFileAuditOperations serializes the audited operation names to a JSON
+// string for storage (same encoding as BlockedOperations).
+func (s *Share) SetFileAuditOperations(ops []string) {
+	s.FileAuditOperations = marshalStringSlice(ops)
+}
+
+// GetFileAuditConfig returns the share's file-access audit policy in the
+// metadata layer's form.
+func (s *Share) GetFileAuditConfig() metadata.FileAuditConfig {
+	cfg := metadata.FileAuditConfig{Enabled: s.FileAuditEnabled}
+	for _, op := range s.GetFileAuditOperations() {
+		cfg.Operations = append(cfg.Operations, metadata.FileAuditOp(op))
+	}
+	return cfg
+}
@@ -2,6 +2,9 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
+	"path"
+	"strings"
 	"time"
 )
 
@@ -51,6 +54,47 @@ type NFSExportOptions struct {
 	MinKerberosLevel   string  `json:"min_kerberos_level"`
 	NetgroupID         *string `json:"netgroup_id,omitempty"`
 	DisableReaddirplus bool    `json:"disable_readdirplus"`
+
+	// Exports are subtree export entries layered on the share's export: each
+	// one gives a directory inside the share its own client allowlist,
+	// read-only flag and squash policy.
+	Exports []NFSSubtreeExport `json:"exports,omitempty"`
+}
+
+// NFSSubtreeExport is an export rule set for a directory inside a share. Unset
+// fields inherit the share's settings; ReadOnly can only narrow access.
+type NFSSubtreeExport struct {
+	// Path is the directory relative to the share root (e.g. "/projects/alpha").
+	Path         string  `json:"path"`
+	NetgroupID   *string `json:"netgroup_id,omitempty"`
+	ReadOnly     bool    `json:"read_only"`
+	Squash       string  `json:"squash,omitempty"`
+	AnonymousUID *uint32 `json:"anonymous_uid,omitempty"`
+	AnonymousGID *uint32 `json:"anonymous_gid,omitempty"`
+}
+
+// ErrInvalidExportPath is returned by CleanExportPath for a subtree path that
+// is empty, names the share root, or climbs out of the share.
+var ErrInvalidExportPath = errors.New("invalid subtree export path")
+
+// CleanExportPath normalizes a share-relative subtree export path to a
+// rooted, slash-separated form without a trailing slash ("projects/alpha/"
+// becomes "/projects/alpha").
+func CleanExportPath(p string) (string, error) {
+	p = strings.TrimSpace(p)
+	if p == "" || strings.Contains(p, "\\") {
+		return "", ErrInvalidExportPath
+	}
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." {
+			return "", ErrInvalidExportPath
+		}
+	}
+	cleaned := path.Clean("/" + p)
+	if cleaned == "/" {
+		return "", ErrInvalidExportPath
+	}
+	return cleaned, nil
 }
 
 // DefaultNFSExportOptions returns the default NFS export options for a new share.
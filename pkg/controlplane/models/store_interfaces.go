@@ -58,3 +58,15 @@ type IdentityStore interface {
 	// IsGuestEnabled returns whether guest access is enabled for the share.
 	IsGuestEnabled(ctx context.Context, shareName string) bool
 }
+
+// LoginRecorder is implemented by user stores that enforce account lockout.
+// Protocol handlers that verify a local password themselves (SMB NTLM) report
+// the outcome so failed guesses count against the same lockout as API logins.
+type LoginRecorder interface {
+	// RecordLoginFailure counts a failed login and locks the account when the
+	// lockout threshold is reached. Returns whether the account is now locked.
+	RecordLoginFailure(ctx context.Context, username string) (bool, error)
+
+	// RecordLoginSuccess clears the failed-login count and lockout.
+	RecordLoginSuccess(ctx context.Context, username string) error
+}
@@ -35,6 +35,7 @@ type User struct {
 	NTHash             string     `json:"-"` // For SMB NTLM authentication
 	Enabled            bool       `gorm:"default:true" json:"enabled"`
 	MustChangePassword bool       `gorm:"default:false" json:"must_change_password"`
+	ServiceAccount     bool       `gorm:"default:false" json:"service_account"`
 	Role               string     `gorm:"default:user;size:50" json:"role"` // user, admin, operator
 	UID                *uint32    `gorm:"uniqueIndex" json:"uid,omitempty"`
 	GID                *uint32    `json:"gid,omitempty"`
@@ -55,6 +56,32 @@ type User struct {
 	// built-in json serializer (AD-3 #1235).
 	GroupSIDs []string `gorm:"column:group_sids;serializer:json" json:"group_sids,omitempty"`
 
+	// PasswordChangedAt is when the password was last set; nil for accounts
+	// created before password age was tracked. See AccountPolicy.
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+
+	// PasswordHistory holds the bcrypt hashes of previous passwords, newest
+	// first, trimmed to AccountPolicy.PasswordHistory.
+	PasswordHistory []string `gorm:"serializer:json" json:"-"`
+
+	// FailedLogins counts consecutive failed password logins since the last
+	// success or lockout.
+	FailedLogins int `gorm:"default:0" json:"failed_logins,omitempty"`
+
+	// LockedUntil is when a lockout expires; nil or past means unlocked.
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+
+	// TOTPSecret is the base32 RFC 6238 secret. It is set at enrollment and
+	// only required at login once TOTPEnabled is set by a confirmed code.
+	TOTPSecret string `gorm:"column:totp_secret;size:64" json:"-"`
+
+	// TOTPEnabled requires a one-time code at API login.
+	TOTPEnabled bool `gorm:"column:totp_enabled;default:false" json:"totp_enabled"`
+
+	// TOTPLastStep is the time step of the last accepted code, so a code
+	// cannot be replayed within its validity window.
+	TOTPLastStep int64 `gorm:"column:totp_last_step;default:0" json:"-"`
+
 	// Many-to-many relationship with groups
 	Groups []Group `gorm:"many2many:user_groups;" json:"groups,omitempty"`
 
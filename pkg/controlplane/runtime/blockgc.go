@@ -591,7 +591,10 @@ func (r *Runtime) compactRemoteForEntry(ctx context.Context, entry shares.Remote
 	if !ok {
 		return // remote cannot hold packed blocks — nothing to compact
 	}
-	var views []engine.CompactMetaView
+	var (
+		views     []engine.CompactMetaView
+		bandwidth [][]*engine.BandwidthLimiter
+	)
 	for _, shareName := range entry.Shares {
 		mds, err := r.GetMetadataStoreForShare(shareName)
 		if err != nil {
@@ -603,6 +606,9 @@ func (r *Runtime) compactRemoteForEntry(ctx context.Context, entry shares.Remote
 		// assert the compaction view like the reconcile/reclaim passes do.
 		if cv, ok := mds.(engine.CompactMetaView); ok {
 			views = append(views, cv)
+			// Compaction GETs and PUTs whole blocks: hold it to the same
+			// per-share and per-remote caps as the share's own syncer.
+			bandwidth = append(bandwidth, r.sharesSvc.BandwidthLimiters(shareName))
 		} else {
 			logger.Warn("GC compaction: metadata store does not implement the compaction view — share excluded",
 				"share", shareName)
@@ -611,7 +617,10 @@ func (r *Runtime) compactRemoteForEntry(ctx context.Context, entry shares.Remote
 	if len(views) == 0 {
 		return
 	}
-	rep, err := engine.CompactBlocks(ctx, views, rbs, engine.CompactOptions{LiveRatio: gcDefaults.CompactionLiveRatio})
+	rep, err := engine.CompactBlocks(ctx, views, rbs, engine.CompactOptions{
+		LiveRatio: gcDefaults.CompactionLiveRatio,
+		Bandwidth: bandwidth,
+	})
 	if err != nil {
 		logger.Warn("GC compaction: aborted", "configID", entry.ConfigID, "err", err)
 		return
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/marmos91/dittofs/internal/pathutil"
+	"github.com/marmos91/dittofs/pkg/block"
 	"github.com/marmos91/dittofs/pkg/block/engine"
 	s3store "github.com/marmos91/dittofs/pkg/block/remote/s3"
 	"github.com/marmos91/dittofs/pkg/controlplane/models"
@@ -67,7 +68,7 @@ func ValidateBlockStoreConfig(kind models.BlockStoreKind, storeType string, cfg
 	case models.BlockStoreKindRemote:
 		switch storeType {
 		case "memory":
-			return nil
+			return validateBandwidth(config)
 		case "s3":
 			bucket, ok := config["bucket"].(string)
 			if !ok || bucket == "" {
@@ -97,6 +98,9 @@ func ValidateBlockStoreConfig(kind models.BlockStoreKind, storeType string, cfg
 			if err := validateParallelUploads(config); err != nil {
 				return err
 			}
+			if err := validateBandwidth(config); err != nil {
+				return err
+			}
 			return nil
 		default:
 			return fmt.Errorf("unsupported remote block store type: %s", storeType)
@@ -157,3 +161,13 @@ func validateParallelUploads(config map[string]any) error {
 	}
 	return nil
 }
+
+// validateBandwidth checks the optional per-remote "bandwidth" policy: base
+// upload/download caps plus a time-of-day schedule (see block.BandwidthPolicy).
+// An absent key means unlimited.
+func validateBandwidth(config map[string]any) error {
+	if _, err := block.ParseBandwidthPolicy(config["bandwidth"]); err != nil {
+		return err
+	}
+	return nil
+}
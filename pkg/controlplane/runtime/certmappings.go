@@ -0,0 +1,191 @@
+package runtime
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+)
+
+// NFS-over-TLS client certificate mapping.
+//
+// A connection that completed a mutually authenticated STARTTLS upgrade
+// carries its verified client certificate in the request context. The
+// certificate is matched against the cert mapping rules of the control plane
+// while resolving the export policy of each request, so the rules restrict
+// which shares a host may use and how its AUTH_SYS credentials are squashed.
+
+// peerCertKey is the context key for the verified TLS client certificate.
+type peerCertKey struct{}
+
+// WithPeerCertificate returns a context carrying the verified client
+// certificate of the connection a request arrived on.
+func WithPeerCertificate(ctx context.Context, cert *x509.Certificate) context.Context {
+	return context.WithValue(ctx, peerCertKey{}, cert)
+}
+
+// PeerCertificateFromContext returns the verified client certificate of the
+// request's connection, or nil for plaintext and anonymous TLS connections.
+func PeerCertificateFromContext(ctx context.Context) *x509.Certificate {
+	cert, _ := ctx.Value(peerCertKey{}).(*x509.Certificate)
+	return cert
+}
+
+// Retry backoff for a failed cert mapping load. Variables so tests can
+// shorten them.
+var (
+	certMappingRetryMin = time.Second
+	certMappingRetryMax = time.Minute
+)
+
+// certMappingSet is an immutable snapshot of the cert mapping rules.
+type certMappingSet struct {
+	// rules are in evaluation order (ascending priority, then name).
+	rules []*models.CertMapping
+	// err is set when no load has succeeded yet and the last one failed;
+	// every decision then fails closed until a reload succeeds.
+	err error
+}
+
+// ReloadCertMappings loads the cert mapping rules from the control plane
+// store. Before the first load no rules apply. A failed load keeps the last
+// successfully loaded rules in force; if none ever loaded, decisions fail
+// closed rather than serving without the rules. Either way the load is
+// retried in the background with backoff until it succeeds.
+func (r *Runtime) ReloadCertMappings(ctx context.Context) error {
+	err := r.loadCertMappings(ctx)
+	if err != nil {
+		r.retryCertMappings()
+	}
+	return err
+}
+
+// loadCertMappings performs one load. Loads are serialized so a slow, older
+// read can never replace the rules of a newer one.
+func (r *Runtime) loadCertMappings(ctx context.Context) error {
+	r.certMappingsMu.Lock()
+	defer r.certMappingsMu.Unlock()
+
+	cs, ok := r.store.(store.CertMappingStore)
+	if !ok {
+		r.certMappings.Store(&certMappingSet{})
+		return nil
+	}
+	rules, err := cs.ListCertMappings(ctx)
+	if err != nil {
+		err = fmt.Errorf("load cert mappings: %w", err)
+		if prev := r.certMappings.Load(); prev == nil || prev.err != nil {
+			r.certMappings.Store(&certMappingSet{err: err})
+		}
+		return err
+	}
+	r.certMappings.Store(&certMappingSet{rules: rules})
+	return nil
+}
+
+// retryCertMappings starts the background retry of a failed load, unless one
+// is already running. It stops on the first successful load or at shutdown.
+func (r *Runtime) retryCertMappings() {
+	if !r.certMappingsRetrying.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer r.certMappingsRetrying.Store(false)
+		delay := certMappingRetryMin
+		for {
+			select {
+			case <-r.runtimeCtx.Done():
+				return
+			case <-time.After(delay):
+			}
+			err := r.loadCertMappings(r.runtimeCtx)
+			if err == nil {
+				logger.Info("Cert mappings loaded after retry")
+				return
+			}
+			logger.Warn("Cert mapping reload failed, retrying", "retry_in", delay, "error", err)
+			delay = min(delay*2, certMappingRetryMax)
+		}
+	}()
+}
+
+// NotifyCertMappingChange reloads the cert mapping rules after they were
+// changed via the API. The new rules govern the next request of every
+// connection, including clients that are already mounted. If the reload
+// fails, the previous rules stay in force until the background retry
+// succeeds.
+func (r *Runtime) NotifyCertMappingChange() {
+	if err := r.ReloadCertMappings(r.runtimeCtx); err != nil {
+		logger.Error("Failed to reload cert mappings", "error", err)
+	}
+}
+
+// certMappingFor returns the rule governing a client on a share:
+//
+//  1. A share named by any rule is restricted: only a certificate matching a
+//     rule that names it may use it.
+//  2. Otherwise the first matching rule naming the share wins, then the
+//     first matching unscoped rule.
+//  3. A certificate that matches rules, none of which covers the share, is
+//     confined to the shares of its rules and is denied.
+//
+// It returns (nil, nil) when no rule applies and ErrExportAccessDenied when
+// the client may not use the share.
+func (r *Runtime) certMappingFor(ctx context.Context, shareName string) (*models.CertMapping, error) {
+	set := r.certMappings.Load()
+	if set == nil {
+		return nil, nil
+	}
+	if set.err != nil {
+		return nil, set.err
+	}
+
+	cert := PeerCertificateFromContext(ctx)
+	restricted := false
+	matched := false
+	var named, unscoped *models.CertMapping
+	for _, rule := range set.rules {
+		names := rule.NamesShare(shareName)
+		restricted = restricted || names
+		if !rule.MatchesCertificate(cert) {
+			continue
+		}
+		matched = true
+		switch {
+		case names && named == nil:
+			named = rule
+		case len(rule.Shares) == 0 && unscoped == nil:
+			unscoped = rule
+		}
+	}
+
+	switch {
+	case named != nil:
+		return named, nil
+	case restricted:
+		return nil, fmt.Errorf("%w: share %q requires a mapped client certificate", ErrExportAccessDenied, shareName)
+	case unscoped != nil:
+		return unscoped, nil
+	case matched:
+		return nil, fmt.Errorf("%w: client certificate is not mapped to share %q", ErrExportAccessDenied, shareName)
+	default:
+		return nil, nil
+	}
+}
+
+// applyCertMapping layers a rule's identity mapping onto an export policy.
+func applyCertMapping(policy *ExportPolicy, rule *models.CertMapping) {
+	if rule.Squash != "" {
+		policy.Squash = models.SquashMode(rule.Squash)
+	}
+	if rule.AnonymousUID != nil {
+		policy.AnonymousUID = *rule.AnonymousUID
+	}
+	if rule.AnonymousGID != nil {
+		policy.AnonymousGID = *rule.AnonymousGID
+	}
+}
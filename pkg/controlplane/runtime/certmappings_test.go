@@ -0,0 +1,203 @@
+//go:build integration
+
+package runtime
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+)
+
+// TestResolveExportPolicy_CertMappings covers the cert mapping rules on shares
+// without subtree exports: squash overrides for matching hosts, restricted
+// shares, and certificates confined to the shares of their rules.
+func TestResolveExportPolicy_CertMappings(t *testing.T) {
+	rt, s, _ := createTestRuntimeWithStore(t)
+	cpStore := s.(store.CertMappingStore)
+	ctx := context.Background()
+
+	uid, gid := uint32(2000), uint32(2000)
+	for _, m := range []*models.CertMapping{
+		{Name: "deploy", Priority: 10, Field: "cn", Pattern: "deploy", Shares: []string{"/releases"}},
+		{Name: "build-hosts", Priority: 20, Field: "dns", Pattern: "*.build.internal",
+			Squash: string(models.SquashAllToGuest), AnonymousUID: &uid, AnonymousGID: &gid},
+		{Name: "ci-cache", Priority: 30, Field: "cn", Pattern: "cache", Shares: []string{"/cache"}},
+	} {
+		if _, err := cpStore.CreateCertMapping(ctx, m); err != nil {
+			t.Fatalf("CreateCertMapping(%s): %v", m.Name, err)
+		}
+	}
+	for _, name := range []string{"/export", "/releases", "/cache"} {
+		addShareDirect(rt, name, "")
+	}
+
+	client := net.ParseIP("10.0.0.5")
+	withCert := func(cn string, dns ...string) context.Context {
+		return WithPeerCertificate(ctx, &x509.Certificate{Subject: pkix.Name{CommonName: cn}, DNSNames: dns})
+	}
+	build := withCert("runner7", "runner7.build.internal")
+	deploy := withCert("deploy", "deploy.build.internal")
+
+	// Before the rules are loaded nothing applies.
+	if policy, err := rt.ResolveExportPolicy(build, "/export", nil, client); err != nil || policy != nil {
+		t.Fatalf("unloaded: policy = %+v, err = %v, want nil, nil", policy, err)
+	}
+	if err := rt.ReloadCertMappings(ctx); err != nil {
+		t.Fatalf("ReloadCertMappings: %v", err)
+	}
+
+	// A build host is squashed to uid 2000 on an unrestricted share.
+	policy, err := rt.ResolveExportPolicy(build, "/export", nil, client)
+	if err != nil {
+		t.Fatalf("build host on /export: %v", err)
+	}
+	if policy == nil || policy.Squash != models.SquashAllToGuest || policy.AnonymousUID != 2000 || policy.AnonymousGID != 2000 {
+		t.Fatalf("build host policy = %+v, want all_to_guest as 2000:2000", policy)
+	}
+
+	// Clients without a matching certificate keep the share's settings.
+	for _, c := range []context.Context{ctx, withCert("laptop")} {
+		if policy, err := rt.ResolveExportPolicy(c, "/export", nil, client); err != nil || policy != nil {
+			t.Errorf("unmatched client on /export: policy = %+v, err = %v, want nil, nil", policy, err)
+		}
+	}
+
+	// Only the deploy certificate may use the restricted /releases share, and
+	// its own rule (no overrides) governs there, not the build-host rule.
+	if policy, err := rt.ResolveExportPolicy(deploy, "/releases", nil, client); err != nil || policy != nil {
+		t.Errorf("deploy on /releases: policy = %+v, err = %v, want nil, nil", policy, err)
+	}
+	for name, c := range map[string]context.Context{"plaintext": ctx, "build host": build} {
+		if _, err := rt.ResolveExportPolicy(c, "/releases", nil, client); !errors.Is(err, ErrExportAccessDenied) {
+			t.Errorf("%s on /releases: err = %v, want ErrExportAccessDenied", name, err)
+		}
+	}
+
+	// The deploy certificate also matches the unscoped build-host rule, which
+	// governs it elsewhere.
+	if policy, err := rt.ResolveExportPolicy(deploy, "/export", nil, client); err != nil || policy == nil || policy.AnonymousUID != 2000 {
+		t.Errorf("deploy on /export: policy = %+v, err = %v, want the build-host squash", policy, err)
+	}
+
+	// A certificate matching only scoped rules is confined to their shares.
+	if _, err := rt.ResolveExportPolicy(withCert("cache"), "/export", nil, client); !errors.Is(err, ErrExportAccessDenied) {
+		t.Errorf("cache on /export: err = %v, want ErrExportAccessDenied", err)
+	}
+
+	// Removing a rule takes effect on reload.
+	if err := cpStore.DeleteCertMapping(ctx, "deploy"); err != nil {
+		t.Fatalf("DeleteCertMapping: %v", err)
+	}
+	rt.NotifyCertMappingChange()
+	if policy, err := rt.ResolveExportPolicy(ctx, "/releases", nil, client); err != nil || policy != nil {
+		t.Errorf("after delete, /releases: policy = %+v, err = %v, want unrestricted", policy, err)
+	}
+}
+
+// flakyCertMappingStore fails ListCertMappings while err is set.
+type flakyCertMappingStore struct {
+	store.Store
+	store.CertMappingStore
+
+	mu  sync.Mutex
+	err error
+}
+
+func (f *flakyCertMappingStore) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *flakyCertMappingStore) ListCertMappings(ctx context.Context) ([]*models.CertMapping, error) {
+	f.mu.Lock()
+	err := f.err
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return f.CertMappingStore.ListCertMappings(ctx)
+}
+
+// TestReloadCertMappings_Failure covers failed loads: closed before any rules
+// ever loaded, the last good rules kept afterwards, and the background retry
+// picking up the store once it recovers.
+func TestReloadCertMappings_Failure(t *testing.T) {
+	rt, s, _ := createTestRuntimeWithStore(t)
+	cpStore := s.(store.CertMappingStore)
+	flaky := &flakyCertMappingStore{Store: s, CertMappingStore: cpStore}
+	rt.store = flaky
+	ctx := context.Background()
+
+	prevMin, prevMax := certMappingRetryMin, certMappingRetryMax
+	certMappingRetryMin, certMappingRetryMax = 10*time.Millisecond, 20*time.Millisecond
+	t.Cleanup(func() { certMappingRetryMin, certMappingRetryMax = prevMin, prevMax })
+
+	if _, err := cpStore.CreateCertMapping(ctx, &models.CertMapping{
+		Name: "deploy", Priority: 10, Field: "cn", Pattern: "deploy", Shares: []string{"/releases"},
+	}); err != nil {
+		t.Fatalf("CreateCertMapping: %v", err)
+	}
+	for _, name := range []string{"/export", "/releases"} {
+		addShareDirect(rt, name, "")
+	}
+	client := net.ParseIP("10.0.0.5")
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// No rules have ever loaded: every share fails closed.
+	loadErr := errors.New("database unavailable")
+	flaky.setErr(loadErr)
+	if err := rt.ReloadCertMappings(ctx); !errors.Is(err, loadErr) {
+		t.Fatalf("ReloadCertMappings err = %v, want %v", err, loadErr)
+	}
+	if _, err := rt.ResolveExportPolicy(ctx, "/export", nil, client); !errors.Is(err, loadErr) {
+		t.Fatalf("/export before first load: err = %v, want %v", err, loadErr)
+	}
+
+	// The retry loads the rules once the store recovers.
+	flaky.setErr(nil)
+	waitFor("first successful load", func() bool {
+		_, err := rt.ResolveExportPolicy(ctx, "/export", nil, client)
+		return err == nil
+	})
+	if _, err := rt.ResolveExportPolicy(ctx, "/releases", nil, client); !errors.Is(err, ErrExportAccessDenied) {
+		t.Fatalf("/releases after load: err = %v, want ErrExportAccessDenied", err)
+	}
+
+	// A later failure keeps the last good rules in force.
+	flaky.setErr(loadErr)
+	if err := cpStore.DeleteCertMapping(ctx, "deploy"); err != nil {
+		t.Fatalf("DeleteCertMapping: %v", err)
+	}
+	rt.NotifyCertMappingChange()
+	if policy, err := rt.ResolveExportPolicy(ctx, "/export", nil, client); err != nil || policy != nil {
+		t.Fatalf("/export after failed reload: policy = %+v, err = %v, want nil, nil", policy, err)
+	}
+	if _, err := rt.ResolveExportPolicy(ctx, "/releases", nil, client); !errors.Is(err, ErrExportAccessDenied) {
+		t.Fatalf("/releases after failed reload: err = %v, want ErrExportAccessDenied", err)
+	}
+
+	// The retry then applies the change that failed to load.
+	flaky.setErr(nil)
+	waitFor("retried reload", func() bool {
+		_, err := rt.ResolveExportPolicy(ctx, "/releases", nil, client)
+		return err == nil
+	})
+}
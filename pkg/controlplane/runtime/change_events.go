@@ -0,0 +1,45 @@
+package runtime
+
+import (
+	"sync/atomic"
+
+	"github.com/marmos91/dittofs/internal/changefeed"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime/shares"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// SetChangeFeed installs the broker that buffers share change events for the
+// events API, webhooks and NATS. Until one is set (events disabled), the
+// metadata layer produces no change events.
+func (r *Runtime) SetChangeFeed(b *changefeed.Broker) {
+	r.changeFeed.broker.Store(b)
+}
+
+// ChangeFeed returns the installed change-event broker, or nil when change
+// events are disabled.
+func (r *Runtime) ChangeFeed() *changefeed.Broker {
+	return r.changeFeed.broker.Load()
+}
+
+// changeFeed forwards the shared MetadataService's change events to the
+// broker installed by SetChangeFeed. It is installed at construction, before
+// the broker exists, like fileAuditor.
+type changeFeed struct {
+	sharesSvc *shares.Service
+	broker    atomic.Pointer[changefeed.Broker]
+}
+
+var _ metadata.ChangeListener = (*changeFeed)(nil)
+
+// WantsChanges implements metadata.ChangeListener.
+func (f *changeFeed) WantsChanges(shareName string) bool {
+	b := f.broker.Load()
+	return b != nil && f.sharesSvc.ShareExists(shareName) && b.WantsChanges(shareName)
+}
+
+// OnChange implements metadata.ChangeListener.
+func (f *changeFeed) OnChange(ev *metadata.ChangeEvent) {
+	if b := f.broker.Load(); b != nil {
+		b.OnChange(ev)
+	}
+}
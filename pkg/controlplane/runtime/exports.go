@@ -0,0 +1,352 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime/identity"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime/shares"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// NFS subtree exports.
+//
+// A share's NFS config can carry subtree export entries that give a
+// directory inside the share its own allowlist, read-only flag and squash
+// policy. A client can mount such a directory directly (MOUNT /share/sub/dir
+// or an NFSv4 LOOKUP walk), and every request on a share with subtree exports
+// is governed by the deepest export containing the file: a subtree export
+// when one matches, otherwise the share's own export.
+
+var (
+	// ErrExportPathNotFound is returned by ResolveExportPath when a path does
+	// not name a share or a directory inside one.
+	ErrExportPathNotFound = errors.New("export path not found")
+
+	// ErrExportAccessDenied is returned by ResolveExportPolicy when the client
+	// is outside the allowlist of the export governing a file.
+	ErrExportAccessDenied = errors.New("export access denied")
+)
+
+// netgroupDecisionTTL bounds how long a per-request netgroup decision is
+// reused. Membership edits are not pushed to the runtime, so this is how long
+// one can take to reach clients already holding a subtree export.
+const netgroupDecisionTTL = 30 * time.Second
+
+// ExportTarget is a mount path resolved to a share and a directory in it.
+type ExportTarget struct {
+	ShareName string
+	// Path is the share-relative directory; "/" for the share root.
+	Path   string
+	Handle metadata.FileHandle
+}
+
+// ExportPolicy is the effective export rule set for one file of a share
+// that has subtree exports.
+type ExportPolicy struct {
+	// Path is the share-relative directory of the governing subtree export;
+	// empty when the share's own export governs the file.
+	Path string
+
+	// ReadOnly forces read-only access on top of the share's settings.
+	ReadOnly bool
+
+	// Traverse is set when the client may not use the governing export but
+	// the file is a directory above an export it may use. Such directories
+	// are visible read-only so the client can walk down to its export, but
+	// cannot be mounted.
+	Traverse bool
+
+	Squash       models.SquashMode
+	AnonymousUID uint32
+	AnonymousGID uint32
+}
+
+// CacheKey identifies the policy in per-request auth-context cache keys.
+func (p *ExportPolicy) CacheKey() string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s|%t|%t|%s|%d|%d", p.Path, p.ReadOnly, p.Traverse, p.Squash, p.AnonymousUID, p.AnonymousGID)
+}
+
+// ResolveSubtreeExports converts persisted subtree export entries to their
+// live form, resolving netgroup IDs to names. An entry whose netgroup cannot
+// be resolved is logged and keeps the share's allowlist, matching how a
+// dangling share-level netgroup is handled at startup.
+func ResolveSubtreeExports(ctx context.Context, ns store.NetgroupStore, shareName string, entries []models.NFSSubtreeExport) []SubtreeExport {
+	if len(entries) == 0 {
+		return nil
+	}
+	out := make([]SubtreeExport, 0, len(entries))
+	for _, e := range entries {
+		exp := SubtreeExport{
+			Path:         e.Path,
+			ReadOnly:     e.ReadOnly,
+			Squash:       models.SquashMode(e.Squash),
+			AnonymousUID: e.AnonymousUID,
+			AnonymousGID: e.AnonymousGID,
+		}
+		if e.NetgroupID != nil && *e.NetgroupID != "" && ns != nil {
+			ng, err := ns.GetNetgroupByID(ctx, *e.NetgroupID)
+			if err == nil {
+				exp.NetgroupName = ng.Name
+			} else {
+				logger.Warn("Subtree export references unknown netgroup",
+					"share", shareName, "path", e.Path, "netgroup_id", *e.NetgroupID)
+			}
+		}
+		out = append(out, exp)
+	}
+	return out
+}
+
+// ShareExports returns a share's subtree exports, deepest path first.
+// ok=false when the share is unknown.
+func (r *Runtime) ShareExports(name string) ([]SubtreeExport, bool) {
+	return r.sharesSvc.ShareExports(name)
+}
+
+// SetShareExports replaces a share's live subtree exports. Takes effect for
+// the next MOUNT and, once cached auth contexts are invalidated, for
+// requests from clients that are already mounted.
+func (r *Runtime) SetShareExports(name string, exports []SubtreeExport) error {
+	if err := r.sharesSvc.SetShareExports(name, exports); err != nil {
+		return err
+	}
+	r.clearNetgroupDecisions()
+	return nil
+}
+
+// ResolveExportPath resolves an NFS export path to a share and a directory
+// inside it. A path naming a share resolves to its root; otherwise the share
+// with the longest name that prefixes the path on a component boundary is
+// chosen and the rest of the path is walked in its metadata store. Every
+// component must be a directory.
+func (r *Runtime) ResolveExportPath(ctx context.Context, dirPath string) (*ExportTarget, error) {
+	if r.sharesSvc.ShareExists(dirPath) {
+		handle, err := r.sharesSvc.GetRootHandle(dirPath)
+		if err != nil {
+			return nil, err
+		}
+		return &ExportTarget{ShareName: dirPath, Path: "/", Handle: handle}, nil
+	}
+
+	cleaned := path.Clean("/" + dirPath)
+	shareName := ""
+	for _, name := range r.sharesSvc.ListShares() {
+		prefix := strings.TrimSuffix(name, "/") + "/"
+		if strings.HasPrefix(cleaned, prefix) && len(name) > len(shareName) {
+			shareName = name
+		}
+	}
+	if shareName == "" {
+		return nil, fmt.Errorf("%w: %q", ErrExportPathNotFound, dirPath)
+	}
+
+	handle, err := r.sharesSvc.GetRootHandle(shareName)
+	if err != nil {
+		return nil, err
+	}
+	rel := strings.TrimPrefix(cleaned, strings.TrimSuffix(shareName, "/"))
+	for _, name := range strings.Split(strings.Trim(rel, "/"), "/") {
+		child, err := r.metadataService.GetChild(ctx, handle, name)
+		if err != nil {
+			if metadata.IsNotFoundError(err) {
+				return nil, fmt.Errorf("%w: %q", ErrExportPathNotFound, dirPath)
+			}
+			return nil, err
+		}
+		file, err := r.metadataService.GetFile(ctx, child)
+		if err != nil {
+			return nil, err
+		}
+		if file.Type != metadata.FileTypeDirectory {
+			return nil, fmt.Errorf("%w: %q is not a directory", ErrExportPathNotFound, dirPath)
+		}
+		handle = child
+	}
+	return &ExportTarget{ShareName: shareName, Path: rel, Handle: handle}, nil
+}
+
+// ResolveExportPolicy returns the export policy governing a file of a share
+// for a client. It returns (nil, nil) when the share has no subtree exports
+// and no client certificate rule changes the client's squash settings: the
+// share-level settings then apply unchanged and no per-request work is done.
+// ErrExportAccessDenied means the client may not access the file.
+func (r *Runtime) ResolveExportPolicy(ctx context.Context, shareName string, handle metadata.FileHandle, clientIP net.IP) (*ExportPolicy, error) {
+	exports, ok := r.sharesSvc.ShareExports(shareName)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", shares.ErrShareNotFound, shareName)
+	}
+	rule, err := r.certMappingFor(ctx, shareName)
+	if err != nil {
+		return nil, err
+	}
+	remaps := rule != nil && (rule.Squash != "" || rule.AnonymousUID != nil || rule.AnonymousGID != nil)
+	if len(exports) == 0 && !remaps {
+		return nil, nil
+	}
+
+	filePath := "/"
+	if len(exports) > 0 {
+		file, err := r.metadataService.GetFile(ctx, handle)
+		if err != nil {
+			return nil, err
+		}
+		if file.Path != "" {
+			filePath = file.Path
+		}
+	}
+	policy, err := r.exportPolicyForPath(ctx, shareName, exports, filePath, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	if remaps {
+		applyCertMapping(policy, rule)
+	}
+	return policy, nil
+}
+
+// exportPolicyForPath applies the deepest export containing filePath and
+// checks the client against its allowlist, falling back to traverse-only
+// access for directories above an export the client may use.
+func (r *Runtime) exportPolicyForPath(ctx context.Context, shareName string, exports []SubtreeExport, filePath string, clientIP net.IP) (*ExportPolicy, error) {
+	share, err := r.sharesSvc.GetShare(shareName)
+	if err != nil {
+		return nil, err
+	}
+	shareNetgroup, _ := r.sharesSvc.GetShareNetgroupName(shareName)
+
+	policy := &ExportPolicy{
+		Squash:       share.Squash,
+		AnonymousUID: share.AnonymousUID,
+		AnonymousGID: share.AnonymousGID,
+	}
+	netgroup := shareNetgroup
+	if exp := shares.MatchExport(exports, filePath); exp != nil {
+		policy.Path = exp.Path
+		policy.ReadOnly = exp.ReadOnly
+		if exp.Squash != "" {
+			policy.Squash = exp.Squash
+		}
+		if exp.AnonymousUID != nil {
+			policy.AnonymousUID = *exp.AnonymousUID
+		}
+		if exp.AnonymousGID != nil {
+			policy.AnonymousGID = *exp.AnonymousGID
+		}
+		if exp.NetgroupName != "" {
+			netgroup = exp.NetgroupName
+		}
+	}
+
+	allowed, err := r.cachedNetgroupAllows(ctx, shareName, netgroup, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	if allowed {
+		return policy, nil
+	}
+
+	// Directories above an export the client may use stay reachable.
+	for i := range exports {
+		below := exports[i].Path
+		if filePath != "/" && !strings.HasPrefix(below, filePath+"/") {
+			continue
+		}
+		ng := exports[i].NetgroupName
+		if ng == "" {
+			ng = shareNetgroup
+		}
+		if ok, err := r.cachedNetgroupAllows(ctx, shareName, ng, clientIP); err == nil && ok {
+			policy.ReadOnly = true
+			policy.Traverse = true
+			return policy, nil
+		}
+	}
+	return nil, ErrExportAccessDenied
+}
+
+// netgroupDecision is a cached netgroup allowlist decision.
+type netgroupDecision struct {
+	allowed bool
+	expires time.Time
+}
+
+// cachedNetgroupAllows is netgroupAllows with a short-lived cache, so a share
+// with subtree exports does not query netgroup members on every request.
+func (r *Runtime) cachedNetgroupAllows(ctx context.Context, shareName, netgroupName string, clientIP net.IP) (bool, error) {
+	if netgroupName == "" {
+		return true, nil
+	}
+	if clientIP == nil {
+		return false, nil
+	}
+	key := netgroupName + "|" + clientIP.String()
+	if v, ok := r.netgroupDecisions.Load(key); ok {
+		if d := v.(netgroupDecision); time.Now().Before(d.expires) {
+			return d.allowed, nil
+		}
+	}
+	allowed, err := r.netgroupAllows(ctx, shareName, netgroupName, clientIP)
+	if err != nil {
+		return false, err
+	}
+	r.netgroupDecisions.Store(key, netgroupDecision{allowed: allowed, expires: time.Now().Add(netgroupDecisionTTL)})
+	return allowed, nil
+}
+
+// clearNetgroupDecisions drops all cached netgroup decisions.
+func (r *Runtime) clearNetgroupDecisions() {
+	r.netgroupDecisions.Range(func(key, _ any) bool {
+		r.netgroupDecisions.Delete(key)
+		return true
+	})
+}
+
+// exportIdentityProvider serves the squash settings of an export policy in
+// place of the share's own.
+type exportIdentityProvider struct {
+	policy *ExportPolicy
+}
+
+func (p *exportIdentityProvider) GetShareIdentityInfo(string) (*identity.ShareInfo, error) {
+	return &identity.ShareInfo{
+		Squash:       p.policy.Squash,
+		AnonymousUID: p.policy.AnonymousUID,
+		AnonymousGID: p.policy.AnonymousGID,
+	}, nil
+}
+
+// ApplyExportIdentityMapping is ApplyIdentityMapping under an export policy's
+// squash settings. A nil policy applies the share's own settings.
+func (r *Runtime) ApplyExportIdentityMapping(shareName string, policy *ExportPolicy, ident *metadata.Identity) (*metadata.Identity, error) {
+	if policy == nil {
+		return r.ApplyIdentityMapping(shareName, ident)
+	}
+	return r.identitySvc.ApplyIdentityMapping(shareName, ident, &exportIdentityProvider{policy: policy})
+}
+
+// ApplyExportPolicy returns a copy of share with an export policy's
+// read-only flag and squash settings layered on, for the share-permission
+// resolution of a request. A nil policy returns share unchanged.
+func ApplyExportPolicy(share *Share, policy *ExportPolicy) *Share {
+	if share == nil || policy == nil {
+		return share
+	}
+	overlay := *share
+	overlay.ReadOnly = share.ReadOnly || policy.ReadOnly
+	overlay.Squash = policy.Squash
+	overlay.AnonymousUID = policy.AnonymousUID
+	overlay.AnonymousGID = policy.AnonymousGID
+	return &overlay
+}
@@ -0,0 +1,69 @@
+//go:build integration
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+// TestExportPolicyForPath_Netgroups covers the governing-export lookup: the
+// deepest export decides, ancestors of an allowed export are traverse-only
+// and everything else is denied.
+func TestExportPolicyForPath_Netgroups(t *testing.T) {
+	rt, _, ngStore := createTestRuntimeWithStore(t)
+	ctx := context.Background()
+
+	if _, err := ngStore.CreateNetgroup(ctx, &models.Netgroup{ID: uuid.New().String(), Name: "team-alpha"}); err != nil {
+		t.Fatalf("CreateNetgroup failed: %v", err)
+	}
+	if err := ngStore.AddNetgroupMember(ctx, "team-alpha", &models.NetgroupMember{Type: "ip", Value: "10.0.0.5"}); err != nil {
+		t.Fatalf("AddNetgroupMember failed: %v", err)
+	}
+	if _, err := ngStore.CreateNetgroup(ctx, &models.Netgroup{ID: uuid.New().String(), Name: "admins"}); err != nil {
+		t.Fatalf("CreateNetgroup failed: %v", err)
+	}
+
+	addShareDirect(rt, "/export", "admins")
+	squash := models.SquashAllToGuest
+	if err := rt.SetShareExports("/export", []SubtreeExport{
+		{Path: "/projects/alpha", NetgroupName: "team-alpha", Squash: squash},
+		{Path: "/projects/alpha/frozen", ReadOnly: true},
+	}); err != nil {
+		t.Fatalf("SetShareExports failed: %v", err)
+	}
+	exports, _ := rt.ShareExports("/export")
+	client := net.ParseIP("10.0.0.5")
+
+	policy, err := rt.exportPolicyForPath(ctx, "/export", exports, "/projects/alpha/src/main.go", client)
+	if err != nil {
+		t.Fatalf("exportPolicyForPath(alpha) failed: %v", err)
+	}
+	if policy.Path != "/projects/alpha" || policy.Traverse || policy.ReadOnly || policy.Squash != squash {
+		t.Errorf("alpha policy = %+v, want the alpha export with its squash", policy)
+	}
+
+	// The deeper entry inherits the share's netgroup, which the client is not in.
+	if _, err := rt.exportPolicyForPath(ctx, "/export", exports, "/projects/alpha/frozen/a", client); !errors.Is(err, ErrExportAccessDenied) {
+		t.Errorf("exportPolicyForPath(frozen) err = %v, want ErrExportAccessDenied", err)
+	}
+
+	for _, p := range []string{"/", "/projects"} {
+		policy, err := rt.exportPolicyForPath(ctx, "/export", exports, p, client)
+		if err != nil {
+			t.Fatalf("exportPolicyForPath(%s) failed: %v", p, err)
+		}
+		if !policy.Traverse || !policy.ReadOnly {
+			t.Errorf("policy(%s) = %+v, want read-only traverse", p, policy)
+		}
+	}
+
+	if _, err := rt.exportPolicyForPath(ctx, "/export", exports, "/home", client); !errors.Is(err, ErrExportAccessDenied) {
+		t.Errorf("exportPolicyForPath(/home) err = %v, want ErrExportAccessDenied", err)
+	}
+}
@@ -0,0 +1,52 @@
+package runtime
+
+import (
+	"sync/atomic"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime/shares"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// FileAuditRecorder receives the file-access audit events of every auditing
+// share. RecordFileAudit is called on protocol goroutines and must not block.
+type FileAuditRecorder interface {
+	RecordFileAudit(event *metadata.FileAuditEvent)
+}
+
+// SetFileAuditRecorder installs the destination of file-access audit events.
+// Until one is set, shares with auditing enabled emit nothing.
+func (r *Runtime) SetFileAuditRecorder(rec FileAuditRecorder) {
+	r.fileAuditor.recorder.Store(&rec)
+}
+
+// SetShareFileAudit applies a share's file-access audit policy to the live
+// share. The policy is persisted separately by the API handler.
+func (r *Runtime) SetShareFileAudit(name string, cfg metadata.FileAuditConfig) error {
+	return r.sharesSvc.SetShareFileAudit(name, cfg)
+}
+
+// fileAuditor adapts the shares service and the configured recorder into a
+// metadata.FileAuditor. Like trashPolicy, one share-aware instance installed
+// on the shared MetadataService serves every share.
+type fileAuditor struct {
+	sharesSvc *shares.Service
+	recorder  atomic.Pointer[FileAuditRecorder]
+}
+
+var _ metadata.FileAuditor = (*fileAuditor)(nil)
+
+// FileAuditConfigForShare reports the share's policy. Auditing is reported
+// off while no recorder is installed so the metadata layer skips the work.
+func (a *fileAuditor) FileAuditConfigForShare(shareName string) (metadata.FileAuditConfig, bool) {
+	if a.recorder.Load() == nil {
+		return metadata.FileAuditConfig{}, false
+	}
+	return a.sharesSvc.FileAuditConfigForShare(shareName)
+}
+
+// RecordFileAudit forwards event to the installed recorder.
+func (a *fileAuditor) RecordFileAudit(event *metadata.FileAuditEvent) {
+	if rec := a.recorder.Load(); rec != nil {
+		(*rec).RecordFileAudit(event)
+	}
+}
@@ -0,0 +1,144 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/block/engine"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime/shares"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// FsckShare runs the offline consistency check (engine.Fsck) for one share.
+// It does not need a Runtime: the share's metadata store, journal and remote
+// store are opened straight from the control-plane config and closed again on
+// return, so it must only run while the server is stopped (`dfs fsck` checks
+// the PID file first).
+//
+// The metadata stores of sibling shares on the same remote are opened too, so
+// their live blocks are not reported as orphan remote objects. An in-memory
+// metadata store is rejected: it holds nothing once the server has exited.
+func FsckShare(ctx context.Context, s store.Store, shareName string, opts engine.FsckOptions) (*engine.FsckReport, error) {
+	share, err := s.GetShare(ctx, shareName)
+	if err != nil {
+		return nil, err
+	}
+
+	metaCfg, err := resolveMetadataStoreConfig(ctx, s, share.MetadataStoreID)
+	if err != nil {
+		return nil, fmt.Errorf("share %q: %w", shareName, err)
+	}
+	if metaCfg.Type == "memory" {
+		return nil, fmt.Errorf("share %q uses in-memory metadata store %q: nothing persists to check", shareName, metaCfg.Name)
+	}
+	mds, err := CreateMetadataStoreFromConfig(ctx, metaCfg.Type, metaCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata store %q: %w", metaCfg.Name, err)
+	}
+	defer func() { _ = mds.Close() }()
+
+	remoteRef := ""
+	if share.RemoteBlockStoreID != nil {
+		remoteRef = *share.RemoteBlockStoreID
+	}
+	targets, err := shares.OpenFsckTargets(ctx, s, share.Name, share.LocalBlockStoreID, remoteRef)
+	if err != nil {
+		return nil, fmt.Errorf("share %q: %w", shareName, err)
+	}
+	defer func() { _ = targets.Close() }()
+
+	in := engine.FsckInput{
+		Share: share.Name,
+		Store: mds,
+		Local: targets.Local,
+	}
+	if targets.Remote != nil {
+		in.Remote = targets.Remote
+		siblings, closeSiblings, err := openRemoteSiblingStores(ctx, s, share.Name, metaCfg.ID, targets.RemoteConfigID)
+		if err != nil {
+			return nil, err
+		}
+		defer closeSiblings()
+		in.RemoteSiblings = siblings
+	}
+
+	logger.Info("fsck: starting",
+		"share", share.Name,
+		"metadataStore", metaCfg.Name,
+		"journal", targets.Local != nil,
+		"remote", targets.Remote != nil,
+		"repair", opts.Repair)
+	rep, err := engine.Fsck(ctx, in, opts)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("fsck: complete",
+		"share", share.Name,
+		"problems", rep.Problems(),
+		"unresolved", rep.Unresolved(),
+		"repairErrors", rep.RepairErrors,
+		"durationMs", rep.DurationMS)
+	return rep, nil
+}
+
+// resolveMetadataStoreConfig looks a metadata store up by ID, falling back to
+// its name (the same order buildShareConfig uses).
+func resolveMetadataStoreConfig(ctx context.Context, s store.Store, ref string) (*models.MetadataStoreConfig, error) {
+	cfg, err := s.GetMetadataStoreByID(ctx, ref)
+	if err == nil {
+		return cfg, nil
+	}
+	cfg, nameErr := s.GetMetadataStore(ctx, ref)
+	if nameErr != nil {
+		return nil, fmt.Errorf("unknown metadata store %q: %w", ref, err)
+	}
+	return cfg, nil
+}
+
+// openRemoteSiblingStores opens the metadata stores of every other share bound
+// to remoteConfigID, skipping the share's own store (metaStoreID) and
+// in-memory ones. The returned func closes them.
+func openRemoteSiblingStores(ctx context.Context, s store.Store, shareName, metaStoreID, remoteConfigID string) ([]metadata.Store, func(), error) {
+	all, err := s.ListShares(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list shares: %w", err)
+	}
+	var opened []metadata.Store
+	closeAll := func() {
+		for _, m := range opened {
+			_ = m.Close()
+		}
+	}
+	seen := map[string]struct{}{metaStoreID: {}}
+	for _, other := range all {
+		if other.Name == shareName || other.RemoteBlockStoreID == nil {
+			continue
+		}
+		id, err := shares.ResolveRemoteConfigID(ctx, s, *other.RemoteBlockStoreID)
+		if err != nil || id != remoteConfigID {
+			continue
+		}
+		cfg, err := resolveMetadataStoreConfig(ctx, s, other.MetadataStoreID)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("share %q: %w", other.Name, err)
+		}
+		if _, dup := seen[cfg.ID]; dup {
+			continue
+		}
+		seen[cfg.ID] = struct{}{}
+		if cfg.Type == "memory" {
+			continue
+		}
+		m, err := CreateMetadataStoreFromConfig(ctx, cfg.Type, cfg)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("failed to open metadata store %q of share %q: %w", cfg.Name, other.Name, err)
+		}
+		opened = append(opened, m)
+	}
+	return opened, closeAll, nil
+}
@@ -0,0 +1,74 @@
+package runtime
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marmos91/dittofs/pkg/block/engine"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/marmos91/dittofs/pkg/metadata/store/badger"
+)
+
+// TestFsckShare checks the offline entry point against control-plane rows
+// alone: a share on a persistent metadata store is opened and checked without
+// a running Runtime, an in-memory one is refused, and an unknown share fails.
+func TestFsckShare(t *testing.T) {
+	_, s := setupTestRuntime(t)
+	ctx := context.Background()
+	localID := createLocalBlockStoreConfig(t, s, "fsck-local")
+
+	// Seed the share root the way a previous server run would have, then close
+	// the store: fsck must reopen it from its config row.
+	metaPath := filepath.Join(t.TempDir(), "meta")
+	seed, err := badger.NewBadgerMetadataStoreWithDefaults(ctx, metaPath)
+	if err != nil {
+		t.Fatalf("NewBadgerMetadataStoreWithDefaults: %v", err)
+	}
+	if err := seed.CreateShare(ctx, &metadata.Share{Name: "/persistent"}); err != nil {
+		t.Fatalf("CreateShare: %v", err)
+	}
+	if _, err := seed.CreateRootDirectory(ctx, "/persistent", &metadata.FileAttr{Type: metadata.FileTypeDirectory, Mode: 0o755}); err != nil {
+		t.Fatalf("CreateRootDirectory: %v", err)
+	}
+	_ = seed.Close()
+
+	badgerCfg := &models.MetadataStoreConfig{Name: "fsck-badger", Type: "badger"}
+	if err := badgerCfg.SetConfig(map[string]any{"path": metaPath}); err != nil {
+		t.Fatalf("SetConfig: %v", err)
+	}
+	badgerID, err := s.CreateMetadataStore(ctx, badgerCfg)
+	if err != nil {
+		t.Fatalf("CreateMetadataStore: %v", err)
+	}
+	memStores, _ := s.ListMetadataStores(ctx)
+	var memID string
+	for _, m := range memStores {
+		if m.Type == "memory" {
+			memID = m.ID
+		}
+	}
+
+	for name, metaID := range map[string]string{"/persistent": badgerID, "/volatile": memID} {
+		if _, err := s.CreateShare(ctx, &models.Share{Name: name, MetadataStoreID: metaID, LocalBlockStoreID: localID}); err != nil {
+			t.Fatalf("CreateShare(%s): %v", name, err)
+		}
+	}
+
+	rep, err := FsckShare(ctx, s, "/persistent", engine.FsckOptions{})
+	if err != nil {
+		t.Fatalf("FsckShare(/persistent): %v", err)
+	}
+	if rep.Share != "/persistent" || rep.Problems() != 0 {
+		t.Errorf("report = share %q, %d problem(s); want /persistent, 0", rep.Share, rep.Problems())
+	}
+
+	if _, err := FsckShare(ctx, s, "/volatile", engine.FsckOptions{}); err == nil || !strings.Contains(err.Error(), "in-memory") {
+		t.Errorf("FsckShare(/volatile) err = %v, want in-memory refusal", err)
+	}
+	if _, err := FsckShare(ctx, s, "/missing", engine.FsckOptions{}); err == nil {
+		t.Error("FsckShare(/missing) succeeded, want error")
+	}
+}
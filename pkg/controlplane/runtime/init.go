@@ -306,9 +306,10 @@ func buildShareConfig(ctx context.Context, s store.Store, share *models.Share) (
 		_ = nfsCfg.ParseConfig(&nfsOpts)
 	}
 
+	ns, _ := s.(store.NetgroupStore)
 	var netgroupName string
 	if nfsOpts.NetgroupID != nil && *nfsOpts.NetgroupID != "" {
-		if ns, ok := s.(store.NetgroupStore); ok {
+		if ns != nil {
 			ng, ngErr := ns.GetNetgroupByID(ctx, *nfsOpts.NetgroupID)
 			if ngErr == nil {
 				netgroupName = ng.Name
@@ -361,12 +362,16 @@ func buildShareConfig(ctx context.Context, s store.Store, share *models.Share) (
 		MinKerberosLevel:                 nfsOpts.MinKerberosLevel,
 		DisableReaddirplus:               nfsOpts.DisableReaddirplus,
 		NetgroupName:                     netgroupName,
+		Exports:                          ResolveSubtreeExports(ctx, ns, share.Name, nfsOpts.Exports),
 		BlockedOperations:                share.GetBlockedOps(),
 		RetentionPolicy:                  share.GetRetentionPolicy(),
 		RetentionTTL:                     share.GetRetentionTTL(),
 		LocalStoreSize:                   share.LocalStoreSize,
 		ReadBufferSize:                   share.ReadBufferSize,
 		QuotaBytes:                       share.QuotaBytes,
+		Bandwidth:                        share.GetBandwidthPolicy(),
+		WarmPolicy:                       share.GetWarmPolicy(),
+		FileAudit:                        share.GetFileAuditConfig(),
 		LocalBlockStoreID:                share.LocalBlockStoreID,
 		RemoteBlockStoreID:               derefString(share.RemoteBlockStoreID),
 	}, nil
@@ -0,0 +1,199 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/marmos91/dittofs/pkg/metadata/transfer"
+)
+
+// migrateFlushTimeout bounds the pending-write flush that quiesces a share
+// before its metadata is copied.
+const migrateFlushTimeout = 30 * time.Second
+
+// MetadataMigrationResult reports a completed MigrateShareMetadata.
+type MetadataMigrationResult struct {
+	Share string `json:"share"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	// RootHandleChanged is true when the destination engine minted a new root
+	// handle. Every other file handle is preserved; clients holding the old
+	// root handle must remount.
+	RootHandleChanged bool           `json:"root_handle_changed"`
+	Stats             transfer.Stats `json:"stats"`
+	// SourcePurged is false when the share's records could not be removed
+	// from the previous store; they are then unreferenced and safe to drop.
+	SourcePurged bool     `json:"source_purged"`
+	Warnings     []string `json:"warnings,omitempty"`
+	DurationMS   int64    `json:"duration_ms"`
+}
+
+// MigrateShareMetadata moves a share's metadata to another metadata store.
+//
+// The migration is offline: the share must be disabled
+// (models.ErrMigrationShareEnabled) and stays unavailable for the whole copy. Pending
+// writes are flushed and queued uploads drained, then every record of the
+// share (see package transfer) is streamed from the current store into
+// toStore. Only after the copy is verified is Share.MetadataStoreID flipped
+// and the running share rebound to the new store; any failure before that
+// leaves the share on its old store and removes what was written to the new
+// one. Block data is never touched. Finally the share is purged from the old
+// store so the block GC does not see its records twice.
+//
+// Snapshots taken before the move hold engine-specific metadata dumps and can
+// no longer be restored once the engine changed; a warning reports them.
+func (r *Runtime) MigrateShareMetadata(ctx context.Context, shareName, toStore string) (*MetadataMigrationResult, error) {
+	if r == nil || r.store == nil {
+		return nil, errors.New("runtime: nil store")
+	}
+	start := time.Now()
+
+	shareModel, err := r.store.GetShare(ctx, shareName)
+	if err != nil {
+		return nil, err
+	}
+	fromCfg, err := resolveMetadataStoreConfig(ctx, r.store, shareModel.MetadataStoreID)
+	if err != nil {
+		return nil, fmt.Errorf("share %q: %w", shareName, err)
+	}
+	toCfg, err := resolveMetadataStoreConfig(ctx, r.store, toStore)
+	if err != nil {
+		return nil, err
+	}
+	if fromCfg.ID == toCfg.ID {
+		return nil, fmt.Errorf("migrate share %q to %q: %w", shareName, toCfg.Name, models.ErrMigrationSameStore)
+	}
+
+	// Share the restore mutex: a restore and a migration of the same share
+	// must never interleave.
+	rlock := r.restoreLock(shareName)
+	if !rlock.TryLock() {
+		return nil, fmt.Errorf("migrate share %q: %w", shareName, models.ErrMigrationInProgress)
+	}
+	defer rlock.Unlock()
+
+	enabled, err := r.sharesSvc.IsShareEnabled(shareName)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, fmt.Errorf("migrate share %q: %w", shareName, models.ErrMigrationShareEnabled)
+	}
+
+	src, err := r.storesSvc.GetMetadataStore(fromCfg.Name)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := r.storesSvc.GetMetadataStore(toCfg.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// --- quiesce ---
+	if _, err := r.metadataService.FlushAllPendingWritesForShutdown(migrateFlushTimeout); err != nil {
+		return nil, fmt.Errorf("migrate share %q: flush pending writes: %w", shareName, err)
+	}
+	if bs, err := r.sharesSvc.GetBlockStoreForShare(shareName); err == nil && bs != nil {
+		if err := bs.DrainAllUploads(ctx); err != nil {
+			return nil, fmt.Errorf("migrate share %q: drain uploads: %w", shareName, err)
+		}
+	}
+
+	logger.Info("metadata migration: copying share",
+		"share", shareName, "from", fromCfg.Name, "from_type", fromCfg.Type,
+		"to", toCfg.Name, "to_type", toCfg.Type)
+
+	// --- copy ---
+	copied, err := transfer.Copy(ctx, src, dst, shareName)
+	if err != nil {
+		if errors.Is(err, metadata.ErrRestoreDestinationNotEmpty) {
+			return nil, fmt.Errorf("migrate share %q to %q: %w", shareName, toCfg.Name, models.ErrMigrationTargetHasShare)
+		}
+		return nil, fmt.Errorf("migrate share %q: %w", shareName, err)
+	}
+
+	// discardCopy removes the copy from the destination when the flip below
+	// fails, leaving the share exactly where it was.
+	discardCopy := func() {
+		if _, perr := transfer.Purge(context.WithoutCancel(ctx), dst, shareName); perr != nil {
+			logger.Warn("metadata migration: failed to remove copy from target store",
+				"share", shareName, "store", toCfg.Name, "error", perr)
+		}
+	}
+
+	// --- flip ---
+	prevStoreID := shareModel.MetadataStoreID
+	shareModel.MetadataStoreID = toCfg.ID
+	if err := r.store.UpdateShare(ctx, shareModel); err != nil {
+		discardCopy()
+		return nil, fmt.Errorf("migrate share %q: update share: %w", shareName, err)
+	}
+	newCfg, err := buildShareConfig(ctx, r.store, shareModel)
+	if err == nil && newCfg == nil {
+		err = fmt.Errorf("share %q references an unknown metadata store", shareName)
+	}
+	if err == nil {
+		oldCfg := *newCfg
+		oldCfg.MetadataStore = fromCfg.Name
+
+		r.mu.RLock()
+		localDefaults := r.localStoreDefaults
+		syncDefaults := r.syncerDefaults
+		r.mu.RUnlock()
+
+		err = r.sharesSvc.RebindShareMetadataStore(ctx, newCfg, &oldCfg, r.storesSvc, r.metadataService, r.store, localDefaults, syncDefaults)
+	}
+	if err != nil {
+		shareModel.MetadataStoreID = prevStoreID
+		if rerr := r.store.UpdateShare(context.WithoutCancel(ctx), shareModel); rerr != nil {
+			// The DB now names the new store while the live share still runs on
+			// the old one; keep the copy so a restart comes up consistent.
+			logger.Error("metadata migration: failed to revert share to its previous store",
+				"share", shareName, "error", rerr)
+			return nil, fmt.Errorf("migrate share %q: rebind failed (%v) and revert failed; restart the server: %w", shareName, err, rerr)
+		}
+		discardCopy()
+		return nil, fmt.Errorf("migrate share %q: rebind: %w", shareName, err)
+	}
+
+	res := &MetadataMigrationResult{
+		Share:             shareName,
+		From:              fromCfg.Name,
+		To:                toCfg.Name,
+		RootHandleChanged: string(copied.OldRootHandle) != string(copied.NewRootHandle),
+		Stats:             copied.Stats,
+		SourcePurged:      true,
+	}
+
+	// --- purge source ---
+	if _, err := transfer.Purge(ctx, src, shareName); err != nil {
+		res.SourcePurged = false
+		res.Warnings = append(res.Warnings, fmt.Sprintf("share records were left in %q: %v", fromCfg.Name, err))
+		logger.Warn("metadata migration: failed to purge share from previous store",
+			"share", shareName, "store", fromCfg.Name, "error", err)
+	}
+
+	if copied.Stats.SkippedEntries > 0 {
+		res.Warnings = append(res.Warnings, fmt.Sprintf(
+			"%d directory entries pointed at missing inodes and were not copied", copied.Stats.SkippedEntries))
+	}
+	if fromCfg.Type != toCfg.Type {
+		if snaps, err := r.store.ListSnapshots(ctx, shareName); err == nil && len(snaps) > 0 {
+			res.Warnings = append(res.Warnings, fmt.Sprintf(
+				"%d existing snapshot(s) hold %s metadata dumps and cannot be restored on %s; take a new snapshot",
+				len(snaps), fromCfg.Type, toCfg.Type))
+		}
+	}
+
+	res.DurationMS = time.Since(start).Milliseconds()
+	logger.Info("metadata migration: complete",
+		"share", shareName, "from", fromCfg.Name, "to", toCfg.Name,
+		"files", res.Stats.Files, "directories", res.Stats.Directories,
+		"chunks", res.Stats.Chunks, "duration_ms", res.DurationMS)
+	return res, nil
+}
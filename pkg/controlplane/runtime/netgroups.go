@@ -188,6 +188,13 @@ func (r *Runtime) CheckNetgroupAccess(ctx context.Context, shareName string, cli
 		return false, err
 	}
 
+	return r.netgroupAllows(ctx, shareName, netgroupName, clientIP)
+}
+
+// netgroupAllows matches a client IP against the members of a netgroup
+// (steps 2-5 of CheckNetgroupAccess). An empty netgroupName allows all
+// clients; shareName is only used for logging.
+func (r *Runtime) netgroupAllows(ctx context.Context, shareName, netgroupName string, clientIP net.IP) (bool, error) {
 	// 2. If share has no netgroup -> allow all
 	if netgroupName == "" {
 		return true, nil
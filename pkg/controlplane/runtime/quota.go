@@ -2,8 +2,11 @@ package runtime
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/marmos91/dittofs/internal/logger"
 	"github.com/marmos91/dittofs/pkg/controlplane/models"
 	"github.com/marmos91/dittofs/pkg/metadata"
@@ -145,6 +148,63 @@ func (r *Runtime) RemoveIdentityQuota(shareName, scope string, identityID *uint3
 	}
 }
 
+// SetIdentityQuotaLimits persists the limits of iq (LimitBytes, SoftBytes,
+// LimitFiles, SoftFiles) as the quota for (iq.Scope, iq.ID) on a share and
+// hot-updates the metadata service, as a REST PUT does. An existing quota keeps
+// its grace period and any running grace timer; setting every limit to zero
+// removes the quota. Used by protocol-level quota administration (NFS rquota
+// SETQUOTA, SMB quota SET_INFO).
+func (r *Runtime) SetIdentityQuotaLimits(ctx context.Context, shareName string, iq metadata.IdentityQuota) error {
+	if r.store == nil {
+		return errors.New("no control-plane store: quotas are read-only")
+	}
+	if iq.LimitBytes < 0 || iq.SoftBytes < 0 || iq.LimitFiles < 0 || iq.SoftFiles < 0 {
+		return fmt.Errorf("quota limits must be >= 0")
+	}
+	if iq.LimitBytes > 0 && iq.SoftBytes > iq.LimitBytes {
+		return fmt.Errorf("soft byte limit %d exceeds hard limit %d", iq.SoftBytes, iq.LimitBytes)
+	}
+	if iq.LimitFiles > 0 && iq.SoftFiles > iq.LimitFiles {
+		return fmt.Errorf("soft file limit %d exceeds hard limit %d", iq.SoftFiles, iq.LimitFiles)
+	}
+
+	scope, identityID := modelScope(iq.Scope, iq.ID)
+
+	if iq.LimitBytes == 0 && iq.SoftBytes == 0 && iq.LimitFiles == 0 && iq.SoftFiles == 0 {
+		if err := r.store.DeleteQuota(ctx, shareName, scope, identityID); err != nil {
+			if errors.Is(err, models.ErrQuotaNotFound) {
+				return nil
+			}
+			return err
+		}
+		r.RemoveIdentityQuota(shareName, scope, identityID)
+		return nil
+	}
+
+	now := time.Now()
+	quota := &models.Quota{
+		ID:         uuid.New().String(),
+		ShareName:  shareName,
+		Scope:      scope,
+		IdentityID: identityID,
+		LimitBytes: iq.LimitBytes,
+		SoftBytes:  iq.SoftBytes,
+		LimitFiles: iq.LimitFiles,
+		SoftFiles:  iq.SoftFiles,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if existing, err := r.store.GetQuota(ctx, shareName, scope, identityID); err == nil {
+		quota.GraceSeconds = existing.GraceSeconds
+		quota.GraceStartedAt = existing.GraceStartedAt
+	}
+	if err := r.store.UpsertQuota(ctx, quota); err != nil {
+		return err
+	}
+	r.UpdateIdentityQuota(quota)
+	return nil
+}
+
 // GetIdentityQuotaUsage returns the live per-identity usage (bytes + file count)
 // for the given share / scope / identity, read from the metadata store backing
 // the share. scope is a models scope string; identityID is nil for default-user.
@@ -192,6 +252,20 @@ func metadataScope(scope string, identityID *uint32) (metadata.QuotaScope, uint3
 	}
 }
 
+// modelScope maps a metadata scope + id back to the models scope string and
+// identity used by the control-plane DB. The default-user sentinel uid maps to
+// the default-user scope with a NULL identity. Inverse of metadataScope.
+func modelScope(scope metadata.QuotaScope, id uint32) (string, *uint32) {
+	if scope == metadata.QuotaScopeUser && id == metadata.DefaultUserID {
+		return models.QuotaScopeDefaultUser, nil
+	}
+	v := id
+	if scope == metadata.QuotaScopeUser {
+		return models.QuotaScopeUser, &v
+	}
+	return models.QuotaScopeGroup, &v
+}
+
 // quotaGracePersister persists grace-timer transitions from the metadata
 // enforcer back to the control-plane DB. Implements metadata.QuotaGracePersister.
 type quotaGracePersister struct {
@@ -203,20 +277,7 @@ type quotaGracePersister struct {
 // default-user scope with a NULL identity. Best-effort: errors are logged, not
 // surfaced to the write path.
 func (p *quotaGracePersister) PersistQuotaGrace(shareName string, scope metadata.QuotaScope, id uint32, t time.Time) {
-	modelScope := models.QuotaScopeGroup
-	var identityID *uint32
-	if scope == metadata.QuotaScopeUser {
-		if id == metadata.DefaultUserID {
-			modelScope = models.QuotaScopeDefaultUser
-		} else {
-			modelScope = models.QuotaScopeUser
-			v := id
-			identityID = &v
-		}
-	} else {
-		v := id
-		identityID = &v
-	}
+	scopeName, identityID := modelScope(scope, id)
 
 	var tp *time.Time
 	if !t.IsZero() {
@@ -225,9 +286,9 @@ func (p *quotaGracePersister) PersistQuotaGrace(shareName string, scope metadata
 	// Use a short bounded context: this runs off the write hot path.
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	if err := p.rt.store.SetQuotaGraceStartedAt(ctx, shareName, modelScope, identityID, tp); err != nil {
+	if err := p.rt.store.SetQuotaGraceStartedAt(ctx, shareName, scopeName, identityID, tp); err != nil {
 		logger.Warn("failed to persist quota grace timer",
-			"share", shareName, "scope", modelScope, "error", err)
+			"share", shareName, "scope", scopeName, "error", err)
 	}
 }
 
@@ -0,0 +1,351 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/block/engine"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime/shares"
+	"github.com/marmos91/dittofs/pkg/controlplane/store"
+)
+
+// rehomeTransformKeys are the remote config sub-keys that change how block
+// bytes are stored. Two remotes can exchange raw blocks only when these agree.
+var rehomeTransformKeys = []string{"compression", "encryption"}
+
+// StartShareRehome moves a share's block data to another remote block store
+// while the share keeps serving.
+//
+// The move runs as a background job (see GetShareRehome for progress):
+//
+//  1. copying: every block the share's locators reference is moved from the
+//     current remote to toRemote, while reads that miss on the current remote
+//     fall back to toRemote (dual-read);
+//  2. switching: Share.RemoteBlockStoreID is flipped and the running share is
+//     rebound onto toRemote; from here reads that miss fall back to the old
+//     remote;
+//  3. catching_up: blocks written to the old remote during the first pass
+//     are moved as well, after which the fallback is dropped.
+//
+// mode is "copy" (move packed blocks byte-for-byte; requires identical
+// compression and encryption settings), "repack" (unseal live chunks and
+// re-pack them through the target's transforms), or "" to pick copy when
+// possible. The old remote's objects are left in place.
+//
+// A models.RehomeMarker records the job durably: a failed job keeps dual-read
+// in place and is resumed by calling StartShareRehome again with the same
+// target, and an interrupted one is resumed at startup.
+func (r *Runtime) StartShareRehome(ctx context.Context, shareName, toRemote, mode string) (*shares.RehomeJob, error) {
+	if r == nil || r.store == nil {
+		return nil, errors.New("runtime: nil store")
+	}
+
+	shareModel, err := r.store.GetShare(ctx, shareName)
+	if err != nil {
+		return nil, err
+	}
+
+	// A marker means a re-home was interrupted or failed: resume it rather
+	// than planning a new one, refusing only a different target.
+	if marker, err := r.store.GetRehomeMarker(ctx, shareName); err == nil {
+		toCfg, err := resolveRemoteBlockStoreConfig(ctx, r.store, toRemote)
+		if err != nil {
+			return nil, err
+		}
+		if toCfg.ID != marker.TargetRemoteID {
+			return nil, fmt.Errorf("rehome share %q: %w (to %s)", shareName, models.ErrRehomeInProgress, marker.TargetRemoteID)
+		}
+		return r.launchRehome(ctx, marker, true)
+	} else if !errors.Is(err, models.ErrRehomeMarkerNotFound) {
+		return nil, err
+	}
+
+	if shareModel.RemoteBlockStoreID == nil || *shareModel.RemoteBlockStoreID == "" {
+		return nil, fmt.Errorf("rehome share %q: %w", shareName, models.ErrRehomeNoRemote)
+	}
+	fromCfg, err := resolveRemoteBlockStoreConfig(ctx, r.store, *shareModel.RemoteBlockStoreID)
+	if err != nil {
+		return nil, fmt.Errorf("share %q: %w", shareName, err)
+	}
+	toCfg, err := resolveRemoteBlockStoreConfig(ctx, r.store, toRemote)
+	if err != nil {
+		return nil, err
+	}
+	if fromCfg.ID == toCfg.ID {
+		return nil, fmt.Errorf("rehome share %q to %q: %w", shareName, toCfg.Name, models.ErrRehomeSameRemote)
+	}
+
+	sameTransforms, err := remoteTransformsEqual(fromCfg, toCfg)
+	if err != nil {
+		return nil, err
+	}
+	switch engine.RehomeMode(mode) {
+	case "":
+		if sameTransforms {
+			mode = string(engine.RehomeModeCopy)
+		} else {
+			mode = string(engine.RehomeModeRepack)
+		}
+	case engine.RehomeModeCopy:
+		if !sameTransforms {
+			return nil, fmt.Errorf("rehome share %q to %q: %w", shareName, toCfg.Name, models.ErrRehomeModeUnsupported)
+		}
+	case engine.RehomeModeRepack:
+	default:
+		return nil, fmt.Errorf("rehome share %q: unknown mode %q (want copy or repack)", shareName, mode)
+	}
+
+	// Repack rewrites locators, which are keyed by content hash across the
+	// whole metadata store: a sibling share deduplicating into the same store
+	// and still bound to the source would have its chunks moved under it.
+	if engine.RehomeMode(mode) == engine.RehomeModeRepack {
+		if err := r.checkRehomeSiblings(ctx, shareModel, fromCfg); err != nil {
+			return nil, err
+		}
+	}
+
+	marker := &models.RehomeMarker{
+		ShareName:      shareName,
+		SourceRemoteID: fromCfg.ID,
+		TargetRemoteID: toCfg.ID,
+		Mode:           mode,
+		Step:           models.RehomeStepPending,
+	}
+	if err := r.store.PutRehomeMarker(ctx, marker); err != nil {
+		return nil, fmt.Errorf("rehome share %q: write marker: %w", shareName, err)
+	}
+
+	logger.Info("remote rehome: starting",
+		"share", shareName, "from", fromCfg.Name, "to", toCfg.Name, "mode", mode)
+	return r.launchRehome(ctx, marker, false)
+}
+
+// GetShareRehome returns the share's latest re-home job.
+func (r *Runtime) GetShareRehome(shareName string) (*shares.RehomeJob, bool) {
+	return r.sharesSvc.GetRehome(shareName)
+}
+
+// launchRehome attaches the re-home remotes to the running share and starts
+// the job. The share's restore lock is held for the job's whole run so a
+// snapshot restore or metadata migration cannot interleave with it.
+func (r *Runtime) launchRehome(ctx context.Context, marker *models.RehomeMarker, resumed bool) (*shares.RehomeJob, error) {
+	shareName := marker.ShareName
+
+	if job, ok := r.sharesSvc.GetRehome(shareName); ok && job.State == shares.RehomeStateRunning {
+		return nil, fmt.Errorf("rehome share %q: %w", shareName, models.ErrRehomeInProgress)
+	}
+	rlock := r.restoreLock(shareName)
+	if !rlock.TryLock() {
+		return nil, fmt.Errorf("rehome share %q: %w", shareName, models.ErrRehomeInProgress)
+	}
+
+	if err := r.sharesSvc.BeginRehome(ctx, shareName, marker.SourceRemoteID, marker.TargetRemoteID, r.store); err != nil {
+		rlock.Unlock()
+		return nil, err
+	}
+
+	job := shares.RehomeJob{
+		Share:   shareName,
+		From:    marker.SourceRemoteID,
+		To:      marker.TargetRemoteID,
+		Mode:    marker.Mode,
+		Resumed: resumed,
+	}
+	snap, started := r.sharesSvc.StartRehome(job, func(jctx context.Context, hooks shares.RehomeHooks) error {
+		defer rlock.Unlock()
+		return r.runRehome(jctx, marker, hooks)
+	})
+	if !started {
+		rlock.Unlock()
+		return nil, fmt.Errorf("rehome share %q: %w", shareName, models.ErrRehomeInProgress)
+	}
+	return snap, nil
+}
+
+// runRehome drives one re-home from wherever its marker says it stands to
+// completion. On error the marker and the dual-read attachment are left in
+// place so the share keeps serving every chunk and the job can be resumed.
+func (r *Runtime) runRehome(ctx context.Context, marker *models.RehomeMarker, hooks shares.RehomeHooks) error {
+	shareName := marker.ShareName
+	mode := engine.RehomeMode(marker.Mode)
+
+	shareModel, err := r.store.GetShare(ctx, shareName)
+	if err != nil {
+		return err
+	}
+	if shareModel.RemoteBlockStoreID == nil {
+		return fmt.Errorf("rehome share %q: %w", shareName, models.ErrRehomeNoRemote)
+	}
+	current, err := resolveRemoteBlockStoreConfig(ctx, r.store, *shareModel.RemoteBlockStoreID)
+	if err != nil {
+		return fmt.Errorf("share %q: %w", shareName, err)
+	}
+	switched := current.ID == marker.TargetRemoteID
+	if !switched && current.ID != marker.SourceRemoteID {
+		return fmt.Errorf("rehome share %q: share was rebound to %s outside the re-home", shareName, current.Name)
+	}
+
+	if !switched {
+		// --- copying ---
+		hooks.SetPhase(shares.RehomePhaseCopying)
+		if err := r.rehomePass(ctx, shareName, mode, hooks); err != nil {
+			return fmt.Errorf("rehome share %q: copy: %w", shareName, err)
+		}
+
+		// --- switching ---
+		hooks.SetPhase(shares.RehomePhaseSwitching)
+		marker.Step = models.RehomeStepSwitched
+		if err := r.store.PutRehomeMarker(ctx, marker); err != nil {
+			return fmt.Errorf("rehome share %q: update marker: %w", shareName, err)
+		}
+		prevRemoteID := shareModel.RemoteBlockStoreID
+		targetID := marker.TargetRemoteID
+		shareModel.RemoteBlockStoreID = &targetID
+		if err := r.store.UpdateShare(ctx, shareModel); err != nil {
+			return fmt.Errorf("rehome share %q: update share: %w", shareName, err)
+		}
+		if err := r.RebindShareBlockStore(ctx, shareName, shareModel.LocalBlockStoreID, *prevRemoteID); err != nil {
+			// The share still runs on the source remote; point the DB back at
+			// it so a restart comes up consistent with the live binding.
+			shareModel.RemoteBlockStoreID = prevRemoteID
+			if rerr := r.store.UpdateShare(context.WithoutCancel(ctx), shareModel); rerr != nil {
+				logger.Error("remote rehome: failed to revert share to its previous remote",
+					"share", shareName, "error", rerr)
+				return fmt.Errorf("rehome share %q: rebind failed (%v) and revert failed; restart the server: %w", shareName, err, rerr)
+			}
+			marker.Step = models.RehomeStepPending
+			if merr := r.store.PutRehomeMarker(context.WithoutCancel(ctx), marker); merr != nil {
+				logger.Warn("remote rehome: failed to reset marker step", "share", shareName, "error", merr)
+			}
+			return fmt.Errorf("rehome share %q: rebind: %w", shareName, err)
+		}
+		logger.Info("remote rehome: share switched to target remote",
+			"share", shareName, "remote", marker.TargetRemoteID)
+	}
+
+	// --- catching up ---
+	hooks.SetPhase(shares.RehomePhaseCatchingUp)
+	if err := r.rehomePass(ctx, shareName, mode, hooks); err != nil {
+		return fmt.Errorf("rehome share %q: catch-up: %w", shareName, err)
+	}
+
+	if err := r.store.DeleteRehomeMarker(ctx, shareName); err != nil && !errors.Is(err, models.ErrRehomeMarkerNotFound) {
+		return fmt.Errorf("rehome share %q: clear marker: %w", shareName, err)
+	}
+	r.sharesSvc.EndRehome(shareName)
+	logger.Info("remote rehome: complete",
+		"share", shareName, "from", marker.SourceRemoteID, "to", marker.TargetRemoteID)
+	return nil
+}
+
+// rehomePass runs one block-moving pass from the source to the target remote.
+func (r *Runtime) rehomePass(ctx context.Context, shareName string, mode engine.RehomeMode, hooks shares.RehomeHooks) error {
+	bs, source, target, err := r.sharesSvc.RehomeTargets(shareName)
+	if err != nil {
+		return err
+	}
+	_, err = bs.RehomeBlocks(ctx, source, target, mode, hooks.Progress)
+	return err
+}
+
+// checkRehomeSiblings refuses a repack when another share on the same
+// metadata store is bound to the source remote.
+func (r *Runtime) checkRehomeSiblings(ctx context.Context, shareModel *models.Share, fromCfg *models.BlockStoreConfig) error {
+	all, err := r.store.ListShares(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list shares: %w", err)
+	}
+	for _, other := range all {
+		if other.Name == shareModel.Name || other.MetadataStoreID != shareModel.MetadataStoreID {
+			continue
+		}
+		if other.RemoteBlockStoreID == nil {
+			continue
+		}
+		if ref := *other.RemoteBlockStoreID; ref == fromCfg.ID || ref == fromCfg.Name {
+			return fmt.Errorf("rehome share %q (shared with %q): %w", shareModel.Name, other.Name, models.ErrRehomeSharedLocators)
+		}
+	}
+	return nil
+}
+
+// recoverInterruptedRehomes resumes every re-home a previous run left
+// unfinished. It runs at startup, after the shares are loaded and before the
+// adapters serve, so dual-read is back in place before any client read. The
+// block-moving passes themselves continue in the background.
+//
+// Non-fatal: a share that cannot be resumed is logged and its marker kept, so
+// the operator can retry with dfsctl share rehome once the cause is fixed.
+func (r *Runtime) recoverInterruptedRehomes(ctx context.Context) error {
+	if r == nil || r.store == nil {
+		return nil
+	}
+
+	markers, err := r.store.ListRehomeMarkers(ctx)
+	if err != nil {
+		logger.Error("rehome recovery: list rehome markers failed", "error", err)
+		return err
+	}
+
+	var firstErr error
+	for _, m := range markers {
+		if _, serr := r.sharesSvc.IsShareEnabled(m.ShareName); errors.Is(serr, shares.ErrShareNotFound) {
+			logger.Warn("rehome recovery: marker for unknown share, clearing (share was removed)",
+				"share", m.ShareName)
+			if derr := r.store.DeleteRehomeMarker(ctx, m.ShareName); derr != nil && firstErr == nil {
+				firstErr = derr
+			}
+			continue
+		}
+
+		logger.Warn("rehome recovery: resuming interrupted remote rehome",
+			"share", m.ShareName, "from", m.SourceRemoteID, "to", m.TargetRemoteID, "step", m.Step)
+		if _, err := r.launchRehome(ctx, m, true); err != nil {
+			logger.Error("rehome recovery: failed to resume", "share", m.ShareName, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// resolveRemoteBlockStoreConfig looks a remote block store up by ID, then by
+// name.
+func resolveRemoteBlockStoreConfig(ctx context.Context, s store.Store, ref string) (*models.BlockStoreConfig, error) {
+	cfg, err := s.GetBlockStoreByID(ctx, ref)
+	if err != nil {
+		var nameErr error
+		cfg, nameErr = s.GetBlockStore(ctx, ref, models.BlockStoreKindRemote)
+		if nameErr != nil {
+			return nil, fmt.Errorf("unknown remote block store %q: %w", ref, err)
+		}
+	}
+	if cfg.Kind != models.BlockStoreKindRemote {
+		return nil, fmt.Errorf("block store %q is a %s store, not a remote one", ref, cfg.Kind)
+	}
+	return cfg, nil
+}
+
+// remoteTransformsEqual reports whether two remotes store block bytes the
+// same way, so raw blocks can be copied between them.
+func remoteTransformsEqual(a, b *models.BlockStoreConfig) (bool, error) {
+	ac, err := a.GetConfig()
+	if err != nil {
+		return false, fmt.Errorf("remote block store %q: %w", a.Name, err)
+	}
+	bc, err := b.GetConfig()
+	if err != nil {
+		return false, fmt.Errorf("remote block store %q: %w", b.Name, err)
+	}
+	for _, k := range rehomeTransformKeys {
+		if !reflect.DeepEqual(ac[k], bc[k]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
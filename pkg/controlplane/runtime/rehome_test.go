@@ -0,0 +1,44 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func TestRemoteTransformsEqual(t *testing.T) {
+	mk := func(cfg map[string]any) *models.BlockStoreConfig {
+		t.Helper()
+		b := &models.BlockStoreConfig{Name: "r", Kind: models.BlockStoreKindRemote, Type: "s3"}
+		if err := b.SetConfig(cfg); err != nil {
+			t.Fatalf("SetConfig: %v", err)
+		}
+		return b
+	}
+
+	plainA := mk(map[string]any{"bucket": "a", "endpoint": "http://minio:9000"})
+	plainB := mk(map[string]any{"bucket": "b", "region": "eu-west-1"})
+	zstd := mk(map[string]any{"bucket": "c", "compression": map[string]any{"algo": "zstd"}})
+	zstd2 := mk(map[string]any{"bucket": "d", "compression": map[string]any{"algo": "zstd"}})
+
+	cases := []struct {
+		name string
+		a, b *models.BlockStoreConfig
+		want bool
+	}{
+		{"different buckets, no transforms", plainA, plainB, true},
+		{"compression on one side", plainA, zstd, false},
+		{"same compression", zstd, zstd2, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := remoteTransformsEqual(tc.a, tc.b)
+			if err != nil {
+				t.Fatalf("remoteTransformsEqual: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("remoteTransformsEqual = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
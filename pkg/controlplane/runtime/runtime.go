@@ -2,6 +2,7 @@ package runtime
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -88,6 +89,14 @@ type Runtime struct {
 	// Serve/shutdown path. Guarded by mu.
 	trashSvc *trash.Service
 
+	// fileAuditor routes file-access audit events from the shared
+	// MetadataService to the recorder installed by SetFileAuditRecorder.
+	fileAuditor *fileAuditor
+
+	// changeFeed routes share change events from the shared MetadataService
+	// to the broker installed by SetChangeFeed.
+	changeFeed *changeFeed
+
 	// snapSchedSvc is the background snapshot scheduler (policy-driven
 	// create + prune), constructed lazily by SnapshotScheduler() and
 	// started/stopped by the lifecycle Serve/shutdown path. Guarded by mu.
@@ -103,6 +112,19 @@ type Runtime struct {
 	gcReg              *gcRegistry
 	settingsWatcher    *SettingsWatcher
 
+	// netgroupDecisions caches per-request netgroup allowlist decisions for
+	// shares with subtree exports (see exports.go). Keyed by netgroup name and
+	// client IP; entries expire after netgroupDecisionTTL.
+	netgroupDecisions sync.Map
+
+	// certMappings is the live NFS-over-TLS client certificate rule set (see
+	// certmappings.go), swapped atomically by ReloadCertMappings. nil until
+	// the first load: no rules apply. certMappingsMu serializes loads;
+	// certMappingsRetrying is set while a failed load is being retried.
+	certMappings         atomic.Pointer[certMappingSet]
+	certMappingsMu       sync.Mutex
+	certMappingsRetrying atomic.Bool
+
 	adapterProviders   map[string]any
 	adapterProvidersMu sync.RWMutex
 	// oplockBreaker mirrors the "oplock_breaker" adapter provider for the NFS
@@ -221,6 +243,15 @@ func New(s store.Store) *Runtime {
 	// take effect immediately.
 	rt.metadataService.SetTrashPolicy(&trashPolicy{sharesSvc: rt.sharesSvc})
 
+	// File-access auditing follows the same pattern: one share-aware auditor
+	// reads each share's policy under the shares-service lock per operation.
+	rt.fileAuditor = &fileAuditor{sharesSvc: rt.sharesSvc}
+	rt.metadataService.SetFileAuditor(rt.fileAuditor)
+
+	// Change events for the events API, webhooks and NATS.
+	rt.changeFeed = &changeFeed{sharesSvc: rt.sharesSvc}
+	rt.metadataService.SetChangeListener(rt.changeFeed)
+
 	// Wire the block-store-backed reader so the unified xattr resolver can
 	// surface named-stream-backed xattr values (the read half of cross-protocol
 	// parity for SMB-created streams). The shares service owns block-store
@@ -238,6 +269,7 @@ func New(s store.Store) *Runtime {
 
 	if s != nil {
 		rt.settingsWatcher = NewSettingsWatcher(s, DefaultPollInterval)
+		rt.settingsWatcher.OnBandwidthChange(rt.applyBandwidthSettings)
 	}
 
 	return rt
@@ -323,6 +355,7 @@ func (r *Runtime) Shutdown(ctx context.Context) error {
 	// the DB is still open or it races the close. Bounded by the caller's ctx
 	// (an overall deadline across shares) so shutdown stays predictable.
 	r.sharesSvc.StopRollups(ctx)
+	r.sharesSvc.SaveAccessHistories()
 	r.CloseMetadataStores()
 	return nil
 }
@@ -598,6 +631,21 @@ func (r *Runtime) SetShareTrashConfig(name string, cfg shares.TrashSettings) err
 	return r.sharesSvc.SetShareTrashConfig(r.store, name, cfg)
 }
 
+// applyBandwidthSettings pushes a freshly polled bandwidth snapshot into the
+// live per-remote and per-share limiters. Registered with the settings watcher
+// in New; shares or remotes not currently loaded are skipped (they read their
+// policy from the store when added).
+func (r *Runtime) applyBandwidthSettings(bw *BandwidthSettings) {
+	for id, policy := range bw.Remotes {
+		r.sharesSvc.SetRemoteBandwidth(id, policy)
+	}
+	for name, policy := range bw.Shares {
+		if err := r.sharesSvc.SetShareBandwidth(name, policy); err != nil && !errors.Is(err, shares.ErrShareNotFound) {
+			logger.Warn("Failed to apply share bandwidth policy", "share", name, "error", err)
+		}
+	}
+}
+
 // SetShareNetgroup updates the live netgroup association for a share's NFS
 // export. An empty netgroupName clears the association (allow-all). Takes
 // effect immediately for subsequent CheckNetgroupAccess calls.
@@ -763,6 +811,10 @@ func (r *Runtime) Serve(ctx context.Context) error {
 	// an explicit Trash().Stop() from Runtime.Shutdown.
 	r.Trash().Start(ctx)
 
+	// Apply per-share warm policies (on_start warms fire on the first pass)
+	// and persist access histories periodically. Exits on ctx cancellation.
+	r.StartWarmPolicies(ctx)
+
 	// Launch the snapshot scheduler unless disabled. Same lifecycle as the
 	// reaper: exits on ctx cancellation or SnapshotScheduler().Stop() from
 	// Runtime.Shutdown. Policy-free fleets pay one ListPolicies query per tick.
@@ -770,6 +822,13 @@ func (r *Runtime) Serve(ctx context.Context) error {
 		r.SnapshotScheduler().Start(ctx)
 	}
 
+	// Load the client certificate rules before adapters accept TLS clients.
+	// A failure is logged and keeps rule-governed decisions failing closed
+	// until the background retry or an API change loads them.
+	if err := r.ReloadCertMappings(r.runtimeCtx); err != nil {
+		logger.Error("cert mapping load returned error (continuing startup)", "error", err)
+	}
+
 	// Reconcile snapshot rows abandoned by a prior crash BEFORE
 	// adapters start serving. Metadata stores and shares are already
 	// registered by the cmd/dfs boot sequence at this point; running
@@ -792,6 +851,13 @@ func (r *Runtime) Serve(ctx context.Context) error {
 		logger.Error("restore recovery returned error (continuing startup)", "error", err)
 	}
 
+	// Resume remote re-homes a prior run left unfinished. Runs after restore
+	// recovery so a rolled-back share is re-homed from its final state, and
+	// before adapters serve so dual-read is in place for the first client read.
+	if err := r.recoverInterruptedRehomes(r.runtimeCtx); err != nil {
+		logger.Error("rehome recovery returned error (continuing startup)", "error", err)
+	}
+
 	return r.lifecycleSvc.Serve(ctx, r.settingsWatcher, r.adaptersSvc, r.metadataService, r.storesSvc, r.store, r, r)
 }
 
@@ -972,14 +1038,26 @@ func (r *Runtime) GetBlockStoreStats(shareName string) (*shares.BlockStoreStatsR
 }
 
 // EvictBlockStore evicts block store data for the given share (or all shares).
+// Shares whose warm policy has after_evict set are re-warmed afterwards.
 func (r *Runtime) EvictBlockStore(ctx context.Context, shareName string, opts shares.EvictOptions) (*shares.EvictResult, error) {
-	return r.sharesSvc.EvictBlockStore(ctx, shareName, opts)
+	res, err := r.sharesSvc.EvictBlockStore(ctx, shareName, opts)
+	if err == nil {
+		r.warmAfterEvict(ctx, shareName)
+	}
+	return res, err
 }
 
 // StartWarmBlockStore starts (or returns the already-running) async warm job
-// that materializes the named share's blocks onto its local tier.
-func (r *Runtime) StartWarmBlockStore(ctx context.Context, shareName string) (*shares.WarmJob, error) {
-	return r.sharesSvc.StartWarm(ctx, shareName)
+// that materializes the named share's blocks, or the files sel selects, onto
+// its local tier.
+func (r *Runtime) StartWarmBlockStore(ctx context.Context, shareName string, sel block.WarmSelector) (*shares.WarmJob, error) {
+	return r.sharesSvc.StartWarm(ctx, shareName, sel, r.storesSvc)
+}
+
+// SetShareWarmPolicy applies a share's automatic pre-warm policy to the live
+// share. The policy is persisted separately by the API handler.
+func (r *Runtime) SetShareWarmPolicy(name string, policy block.WarmPolicy) error {
+	return r.sharesSvc.SetShareWarmPolicy(name, policy)
 }
 
 // GetWarmStatus returns a snapshot of a warm job by ID, or (nil, false) if the
@@ -1068,6 +1146,27 @@ func (r *Runtime) ResolveDirectoryPrincipalSID(ctx context.Context, name string,
 	return provider.ResolvePrincipalSID(ctx, name, isGroup)
 }
 
+// AuthenticateDirectoryUser verifies a username and password by binding to the
+// configured LDAP directory as that user, for API password login of directory
+// users with no local password.
+//
+// Returns found=false when LDAP is not configured/enabled; an error wrapping
+// ldap.ErrInvalidCredentials for an unknown user or a wrong password; any
+// other error is a directory infrastructure failure. Like
+// ResolveDirectoryPrincipalSID, a one-shot provider is built per call.
+func (r *Runtime) AuthenticateDirectoryUser(ctx context.Context, username, password string) (*ldap.BindResult, bool, error) {
+	cfg := r.LDAPConfig()
+	if cfg == nil || !cfg.Enabled {
+		return nil, false, nil
+	}
+	provider, err := ldap.New(cfg, nil, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	res, err := provider.Authenticate(ctx, username, password)
+	return res, true, err
+}
+
 // SetNetlogonCredential sets the NETLOGON machine credential / DC binding used
 // for SMB NTLM pass-through. A nil value disables passthrough. It is set at
 // startup from the Kerberos machine-account config and updated by the
@@ -3,12 +3,15 @@ package runtime
 import (
 	"context"
 	"errors"
+	"reflect"
 	"runtime/debug"
 	"sync"
 	"time"
 
 	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/block"
 	"github.com/marmos91/dittofs/pkg/controlplane/models"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime/shares"
 	"github.com/marmos91/dittofs/pkg/controlplane/store"
 )
 
@@ -44,6 +47,12 @@ type SettingsWatcher struct {
 	nfsCallbacks []func(*models.NFSAdapterSettings)
 	smbCallbacks []func(*models.SMBAdapterSettings)
 
+	// Per-remote and per-share bandwidth policies. There is no version
+	// counter on shares or block stores, so change detection compares the
+	// parsed snapshot.
+	bandwidth          *BandwidthSettings
+	bandwidthCallbacks []func(*BandwidthSettings)
+
 	pollInterval time.Duration
 	stopCh       chan struct{}
 	stopped      chan struct{} // closed when polling goroutine exits
@@ -66,6 +75,31 @@ func (w *SettingsWatcher) OnSMBSettingsChange(cb func(*models.SMBAdapterSettings
 	w.smbCallbacks = append(w.smbCallbacks, cb)
 }
 
+// BandwidthSettings is a snapshot of every remote block store's and every
+// share's bandwidth policy. Entries without a configured policy carry the
+// zero (unlimited) policy so removing a cap is observed as a change.
+type BandwidthSettings struct {
+	Remotes map[string]block.BandwidthPolicy // keyed by block store ID
+	Shares  map[string]block.BandwidthPolicy // keyed by share name
+}
+
+// OnBandwidthChange registers a callback invoked whenever any remote or share
+// bandwidth policy changes (after the initial load). The runtime uses it to
+// retune live syncers without a restart.
+func (w *SettingsWatcher) OnBandwidthChange(cb func(*BandwidthSettings)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.bandwidthCallbacks = append(w.bandwidthCallbacks, cb)
+}
+
+// GetBandwidthSettings returns the cached bandwidth snapshot, or nil before
+// the first load. The returned value must NOT be mutated by callers.
+func (w *SettingsWatcher) GetBandwidthSettings() *BandwidthSettings {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.bandwidth
+}
+
 // NewSettingsWatcher creates a new SettingsWatcher with the given store and poll interval.
 // If pollInterval is 0, DefaultPollInterval (10s) is used.
 func NewSettingsWatcher(s store.Store, pollInterval time.Duration) *SettingsWatcher {
@@ -99,6 +133,10 @@ func (w *SettingsWatcher) LoadInitial(ctx context.Context) error {
 		// Non-fatal: SMB adapter may not exist yet
 	}
 
+	if err := w.pollBandwidth(ctx); err != nil {
+		logger.Warn("Settings watcher: failed to load initial bandwidth settings", "error", err)
+	}
+
 	return nil
 }
 
@@ -181,7 +219,7 @@ func (w *SettingsWatcher) pollWithRecover(ctx context.Context) {
 	w.poll(ctx)
 }
 
-// poll checks both NFS and SMB settings for changes.
+// poll checks NFS, SMB and bandwidth settings for changes.
 func (w *SettingsWatcher) poll(ctx context.Context) {
 	if err := w.pollNFSSettings(ctx); err != nil {
 		logger.Warn("Settings watcher: failed to poll NFS settings", "error", err)
@@ -189,6 +227,9 @@ func (w *SettingsWatcher) poll(ctx context.Context) {
 	if err := w.pollSMBSettings(ctx); err != nil {
 		logger.Warn("Settings watcher: failed to poll SMB settings", "error", err)
 	}
+	if err := w.pollBandwidth(ctx); err != nil {
+		logger.Warn("Settings watcher: failed to poll bandwidth settings", "error", err)
+	}
 }
 
 // pollNFSSettings checks the DB for NFS adapter settings changes.
@@ -315,3 +356,60 @@ func (w *SettingsWatcher) pollSMBSettings(ctx context.Context) error {
 
 	return nil
 }
+
+// pollBandwidth re-reads the bandwidth policy of every remote block store and
+// every share. When the snapshot differs from the cached one it is swapped in
+// and the bandwidth callbacks run. A malformed policy is logged and treated as
+// unlimited rather than failing the whole poll.
+func (w *SettingsWatcher) pollBandwidth(ctx context.Context) error {
+	remotes, err := w.store.ListBlockStores(ctx, models.BlockStoreKindRemote)
+	if err != nil {
+		return err
+	}
+	shareRows, err := w.store.ListShares(ctx)
+	if err != nil {
+		return err
+	}
+
+	next := &BandwidthSettings{
+		Remotes: make(map[string]block.BandwidthPolicy, len(remotes)),
+		Shares:  make(map[string]block.BandwidthPolicy, len(shareRows)),
+	}
+	for _, rs := range remotes {
+		policy, perr := shares.RemoteBandwidthPolicy(rs)
+		if perr != nil {
+			logger.Warn("Settings watcher: invalid remote bandwidth policy, treating as unlimited",
+				"block_store", rs.Name, "error", perr)
+		}
+		next.Remotes[rs.ID] = policy
+	}
+	for _, sh := range shareRows {
+		policy, perr := block.ParseBandwidthPolicy(sh.Bandwidth)
+		if perr != nil {
+			logger.Warn("Settings watcher: invalid share bandwidth policy, treating as unlimited",
+				"share", sh.Name, "error", perr)
+		}
+		next.Shares[sh.Name] = policy
+	}
+
+	w.mu.Lock()
+	prev := w.bandwidth
+	if prev != nil && reflect.DeepEqual(prev, next) {
+		w.mu.Unlock()
+		return nil
+	}
+	w.bandwidth = next
+	cbs := w.bandwidthCallbacks
+	w.mu.Unlock()
+
+	if prev == nil {
+		// Initial load: AddShare seeds each limiter from the same rows.
+		return nil
+	}
+	logger.Info("Bandwidth settings reloaded",
+		"remotes", len(next.Remotes), "shares", len(next.Shares))
+	for _, cb := range cbs {
+		cb(next)
+	}
+	return nil
+}
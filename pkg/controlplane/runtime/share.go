@@ -17,4 +17,5 @@ type (
 	Share           = shares.Share
 	ShareConfig     = shares.ShareConfig
 	LegacyMountInfo = shares.LegacyMountInfo
+	SubtreeExport   = shares.SubtreeExport
 )
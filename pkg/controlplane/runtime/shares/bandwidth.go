@@ -0,0 +1,93 @@
+package shares
+
+import (
+	"fmt"
+
+	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/marmos91/dittofs/pkg/block/engine"
+)
+
+// remoteConfigGetter is the slice of models.BlockStoreConfig that
+// RemoteBandwidthPolicy reads.
+type remoteConfigGetter interface {
+	GetConfig() (map[string]any, error)
+}
+
+// RemoteBandwidthPolicy returns the bandwidth policy stored under the
+// "bandwidth" key of a remote block store config. An absent key is the zero
+// (unlimited) policy.
+func RemoteBandwidthPolicy(cfg remoteConfigGetter) (block.BandwidthPolicy, error) {
+	m, err := cfg.GetConfig()
+	if err != nil {
+		return block.BandwidthPolicy{}, fmt.Errorf("parse block store config: %w", err)
+	}
+	return block.ParseBandwidthPolicy(m["bandwidth"])
+}
+
+// remoteBandwidthLimiter returns the shared limiter of a live remote store,
+// or nil when configID is empty or not acquired.
+func (s *Service) remoteBandwidthLimiter(configID string) *engine.BandwidthLimiter {
+	if configID == "" {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if sr, ok := s.remoteStores[configID]; ok {
+		return sr.bandwidth
+	}
+	return nil
+}
+
+// BandwidthLimiters returns the limiters a share's remote transfers must pass:
+// its own and its remote store's shared one, as wired into its syncer. Work
+// that moves blocks outside the syncer (GC compaction) waits on the same ones.
+// Returns nil for an unknown share.
+func (s *Service) BandwidthLimiters(name string) []*engine.BandwidthLimiter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	share, exists := s.registry[name]
+	if !exists {
+		return nil
+	}
+	var limiters []*engine.BandwidthLimiter
+	if share.bandwidth != nil {
+		limiters = append(limiters, share.bandwidth)
+	}
+	if sr, ok := s.remoteStores[share.remoteConfigID]; ok && sr.bandwidth != nil {
+		limiters = append(limiters, sr.bandwidth)
+	}
+	return limiters
+}
+
+// SetShareBandwidth replaces a live share's bandwidth policy. The change
+// applies to the next transfer; no rebind or restart is needed.
+func (s *Service) SetShareBandwidth(name string, policy block.BandwidthPolicy) error {
+	s.mu.RLock()
+	share, exists := s.registry[name]
+	var limiter *engine.BandwidthLimiter
+	if exists {
+		limiter = share.bandwidth
+	}
+	s.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("%w: %q", ErrShareNotFound, name)
+	}
+	if limiter == nil {
+		// Metadata-only share: nothing transfers to a remote.
+		return nil
+	}
+	limiter.SetPolicy(policy)
+	return nil
+}
+
+// SetRemoteBandwidth replaces the shared bandwidth policy of a live remote
+// store. Returns false when no share currently uses the remote; the policy is
+// then picked up from the store config the next time it is acquired.
+func (s *Service) SetRemoteBandwidth(configID string, policy block.BandwidthPolicy) bool {
+	limiter := s.remoteBandwidthLimiter(configID)
+	if limiter == nil {
+		return false
+	}
+	limiter.SetPolicy(policy)
+	return true
+}
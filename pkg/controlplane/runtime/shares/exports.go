@@ -0,0 +1,84 @@
+package shares
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+// SubtreeExport is the live form of models.NFSSubtreeExport: export rules for
+// a directory inside a share. An empty Squash and nil anonymous IDs inherit
+// the share's values; an empty NetgroupName inherits the share's allowlist.
+type SubtreeExport struct {
+	// Path is the cleaned share-relative directory ("/projects/alpha").
+	Path         string
+	NetgroupName string
+	ReadOnly     bool
+	Squash       models.SquashMode
+	AnonymousUID *uint32
+	AnonymousGID *uint32
+}
+
+// Contains reports whether the share-relative path p is the export's
+// directory or lies below it.
+func (e *SubtreeExport) Contains(p string) bool {
+	return p == e.Path || strings.HasPrefix(p, e.Path+"/")
+}
+
+// MatchExport returns the most specific export containing the share-relative
+// path p, or nil when p is governed by the share itself. exports must be
+// ordered deepest first, as ShareExports returns them.
+func MatchExport(exports []SubtreeExport, p string) *SubtreeExport {
+	for i := range exports {
+		if exports[i].Contains(p) {
+			return &exports[i]
+		}
+	}
+	return nil
+}
+
+// sortExports returns a copy of exports ordered deepest path first, so the
+// first match in MatchExport is the most specific one.
+func sortExports(exports []SubtreeExport) []SubtreeExport {
+	if len(exports) == 0 {
+		return nil
+	}
+	out := slices.Clone(exports)
+	slices.SortFunc(out, func(a, b SubtreeExport) int {
+		if c := cmp.Compare(strings.Count(b.Path, "/"), strings.Count(a.Path, "/")); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Path, b.Path)
+	})
+	return out
+}
+
+// ShareExports returns a copy of a share's subtree exports, deepest first,
+// read under the service lock. ok=false when the share is unknown.
+func (s *Service) ShareExports(name string) ([]SubtreeExport, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	share, exists := s.registry[name]
+	if !exists {
+		return nil, false
+	}
+	return slices.Clone(share.Exports), true
+}
+
+// SetShareExports replaces a share's live subtree exports. The entries are
+// persisted separately by the API handler.
+func (s *Service) SetShareExports(name string, exports []SubtreeExport) error {
+	sorted := sortExports(exports)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	share, ok := s.registry[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrShareNotFound, name)
+	}
+	share.Exports = sorted
+	return nil
+}
@@ -0,0 +1,33 @@
+package shares
+
+import "testing"
+
+func TestMatchExport_DeepestWins(t *testing.T) {
+	exports := sortExports([]SubtreeExport{
+		{Path: "/projects"},
+		{Path: "/projects/alpha"},
+		{Path: "/scratch"},
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/projects/alpha/src", "/projects/alpha"},
+		{"/projects/alpha", "/projects/alpha"},
+		{"/projects/alphabet", "/projects"},
+		{"/projects", "/projects"},
+		{"/scratch/tmp", "/scratch"},
+		{"/home", ""},
+		{"/", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if e := MatchExport(exports, tt.path); e != nil {
+			got = e.Path
+		}
+		if got != tt.want {
+			t.Errorf("MatchExport(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
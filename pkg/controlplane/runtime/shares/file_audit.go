@@ -0,0 +1,38 @@
+package shares
+
+import (
+	"fmt"
+
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// FileAuditConfigForShare returns the file-access audit policy for a share,
+// read under the service lock. The Operations slice is copied so callers
+// cannot observe a concurrent SetShareFileAudit. ok=false when the share is
+// unknown.
+func (s *Service) FileAuditConfigForShare(name string) (metadata.FileAuditConfig, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	share, exists := s.registry[name]
+	if !exists {
+		return metadata.FileAuditConfig{}, false
+	}
+	cfg := share.FileAudit
+	cfg.Operations = append([]metadata.FileAuditOp(nil), cfg.Operations...)
+	return cfg, true
+}
+
+// SetShareFileAudit applies a share's file-access audit policy to the live
+// share. The policy is persisted separately by the API handler.
+func (s *Service) SetShareFileAudit(name string, cfg metadata.FileAuditConfig) error {
+	cfg.Operations = append([]metadata.FileAuditOp(nil), cfg.Operations...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	share, ok := s.registry[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrShareNotFound, name)
+	}
+	share.FileAudit = cfg
+	return nil
+}
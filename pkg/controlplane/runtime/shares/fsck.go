@@ -0,0 +1,99 @@
+package shares
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/marmos91/dittofs/pkg/block/engine"
+	"github.com/marmos91/dittofs/pkg/block/local/fs"
+	"github.com/marmos91/dittofs/pkg/block/remote"
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+// FsckTargets are a stopped share's journal and remote block store, opened
+// straight from their block store configs for an offline fsck. Either may be
+// nil: an in-memory tier holds nothing to check after a restart.
+type FsckTargets struct {
+	Local  engine.FsckLocalView
+	Remote remote.RemoteBlockStore
+	// RemoteConfigID is the canonical ID of the remote, "" when none.
+	RemoteConfigID string
+
+	closers []func() error
+}
+
+// Close releases everything OpenFsckTargets opened.
+func (t *FsckTargets) Close() error {
+	var errs []error
+	for _, c := range t.closers {
+		errs = append(errs, c())
+	}
+	return errors.Join(errs...)
+}
+
+// OpenFsckTargets opens the journal and remote store of share. It never
+// migrates: a local dir still holding the pre-journal layout fails to open
+// instead of being archived, and a missing local dir is treated as empty
+// rather than created. The server must be stopped — the journal is not safe
+// to open twice.
+func OpenFsckTargets(ctx context.Context, provider BlockStoreConfigProvider, shareName, localRef, remoteRef string) (*FsckTargets, error) {
+	t := &FsckTargets{}
+
+	localCfg, err := resolveBlockStoreConfig(ctx, provider, localRef, models.BlockStoreKindLocal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve local block store config %q: %w", localRef, err)
+	}
+	if localCfg.Type == "fs" {
+		dir := deriveLocalStoreDir(localCfg, shareName)
+		if dir == "" {
+			return nil, fmt.Errorf("local block store %q has no usable path", localCfg.Name)
+		}
+		if _, err := os.Stat(dir); err == nil {
+			ls, err := fs.New(dir, 0, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open journal at %s: %w", dir, err)
+			}
+			t.Local = ls
+			t.closers = append(t.closers, ls.Close)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	if remoteRef == "" {
+		return t, nil
+	}
+	remoteCfg, err := resolveBlockStoreConfig(ctx, provider, remoteRef, models.BlockStoreKindRemote)
+	if err != nil {
+		_ = t.Close()
+		return nil, fmt.Errorf("failed to resolve remote block store config %q: %w", remoteRef, err)
+	}
+	t.RemoteConfigID = remoteCfg.ID
+	if remoteCfg.Type == "memory" {
+		return t, nil
+	}
+	// Listing block keys needs neither the encryption nor the compression
+	// decorator, so the raw store is enough.
+	rs, err := CreateRemoteStoreFromConfig(ctx, remoteCfg.Type, remoteCfg)
+	if err != nil {
+		_ = t.Close()
+		return nil, fmt.Errorf("failed to create remote store: %w", err)
+	}
+	t.closers = append(t.closers, rs.Close)
+	if rbs, ok := rs.(remote.RemoteBlockStore); ok {
+		t.Remote = rbs
+	}
+	return t, nil
+}
+
+// ResolveRemoteConfigID returns the canonical ID of the remote block store ref
+// names (a UUID, or a legacy name).
+func ResolveRemoteConfigID(ctx context.Context, provider BlockStoreConfigProvider, ref string) (string, error) {
+	cfg, err := resolveBlockStoreConfig(ctx, provider, ref, models.BlockStoreKindRemote)
+	if err != nil {
+		return "", err
+	}
+	return cfg.ID, nil
+}
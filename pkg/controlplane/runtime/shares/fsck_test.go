@@ -0,0 +1,79 @@
+package shares
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+// fakeBlockStoreConfigs is a BlockStoreConfigProvider over a fixed set of
+// block store rows, looked up by ID.
+type fakeBlockStoreConfigs map[string]*models.BlockStoreConfig
+
+func (f fakeBlockStoreConfigs) GetBlockStoreByID(_ context.Context, id string) (*models.BlockStoreConfig, error) {
+	if cfg, ok := f[id]; ok {
+		return cfg, nil
+	}
+	return nil, models.ErrStoreNotFound
+}
+
+func (f fakeBlockStoreConfigs) GetBlockStore(_ context.Context, name string, kind models.BlockStoreKind) (*models.BlockStoreConfig, error) {
+	for _, cfg := range f {
+		if cfg.Name == name && cfg.Kind == kind {
+			return cfg, nil
+		}
+	}
+	return nil, models.ErrStoreNotFound
+}
+
+// TestOpenFsckTargets covers the offline open path: a share whose journal dir
+// does not exist yet is treated as empty (and the dir is not created), an
+// existing journal is opened, and an in-memory remote resolves its canonical
+// ID without opening a store.
+func TestOpenFsckTargets(t *testing.T) {
+	ctx := context.Background()
+	base := t.TempDir()
+	provider := fakeBlockStoreConfigs{
+		"local-1":  {ID: "local-1", Name: "local", Kind: models.BlockStoreKindLocal, Type: "fs", ParsedConfig: map[string]any{"path": base}},
+		"remote-1": {ID: "remote-1", Name: "mem", Kind: models.BlockStoreKindRemote, Type: "memory", ParsedConfig: map[string]any{}},
+	}
+
+	targets, err := OpenFsckTargets(ctx, provider, "/data", "local-1", "mem")
+	if err != nil {
+		t.Fatalf("OpenFsckTargets (no journal dir): %v", err)
+	}
+	if targets.Local != nil || targets.Remote != nil {
+		t.Errorf("targets = %+v, want no journal and no remote store", targets)
+	}
+	if targets.RemoteConfigID != "remote-1" {
+		t.Errorf("RemoteConfigID = %q, want remote-1 (resolved by name)", targets.RemoteConfigID)
+	}
+	_ = targets.Close()
+
+	dir := filepath.Join(base, "shares", sanitizeShareName("/data"))
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("journal dir was created by fsck: stat err = %v", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	targets, err = OpenFsckTargets(ctx, provider, "/data", "local-1", "")
+	if err != nil {
+		t.Fatalf("OpenFsckTargets (journal dir): %v", err)
+	}
+	defer func() { _ = targets.Close() }()
+	if targets.Local == nil {
+		t.Error("existing journal dir was not opened")
+	}
+	if targets.RemoteConfigID != "" {
+		t.Errorf("RemoteConfigID = %q, want empty without a remote", targets.RemoteConfigID)
+	}
+
+	if _, err := OpenFsckTargets(ctx, provider, "/data", "missing", ""); err == nil {
+		t.Error("OpenFsckTargets with an unknown local store succeeded, want error")
+	}
+}
@@ -0,0 +1,300 @@
+package shares
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/block/engine"
+	"github.com/marmos91/dittofs/pkg/block/remote"
+)
+
+// Re-home job states.
+const (
+	RehomeStateRunning  = "running"
+	RehomeStateDone     = "done"
+	RehomeStateFailed   = "failed"
+	RehomeStateCanceled = "canceled"
+)
+
+// Re-home job phases, in order.
+const (
+	// RehomePhaseCopying moves blocks while the share still runs on the
+	// source remote.
+	RehomePhaseCopying = "copying"
+	// RehomePhaseSwitching rebinds the share onto the target remote.
+	RehomePhaseSwitching = "switching"
+	// RehomePhaseCatchingUp moves the blocks written during the first pass
+	// while the share runs on the target remote.
+	RehomePhaseCatchingUp = "catching_up"
+)
+
+// RehomeJob is the process-local record of a remote re-home (dfsctl share
+// rehome). The durable state that lets a re-home resume after a restart is
+// the models.RehomeMarker; this record only carries progress for polling, and
+// a resumed re-home starts a fresh job. All fields are read/written under the
+// rehomeRegistry mutex.
+type RehomeJob struct {
+	ID    string `json:"id"`
+	Share string `json:"share"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Mode  string `json:"mode"`
+	// State is one of RehomeState{Running,Done,Failed,Canceled}.
+	State string `json:"state"`
+	// Phase is one of RehomePhase{Copying,Switching,CatchingUp}.
+	Phase      string                `json:"phase"`
+	Progress   engine.RehomeProgress `json:"progress"`
+	Resumed    bool                  `json:"resumed,omitempty"`
+	StartedAt  time.Time             `json:"started_at"`
+	FinishedAt time.Time             `json:"finished_at"`
+	Err        string                `json:"error,omitempty"`
+}
+
+func (j *RehomeJob) clone() *RehomeJob {
+	cp := *j
+	return &cp
+}
+
+// RehomeHooks lets a re-home run report its phase and progress to its job.
+type RehomeHooks struct {
+	SetPhase func(phase string)
+	Progress func(p engine.RehomeProgress)
+}
+
+// rehomeRegistry tracks the latest re-home job of each share. Unlike warm jobs
+// a re-home is addressed by share name: there is at most one per share and it
+// may outlive the job that started it (resume after restart or failure).
+type rehomeRegistry struct {
+	mu      sync.Mutex
+	jobs    map[string]*RehomeJob         // shareName -> latest job
+	cancels map[string]context.CancelFunc // shareName -> running job's cancel
+	counter int64
+}
+
+func newRehomeRegistry() *rehomeRegistry {
+	return &rehomeRegistry{
+		jobs:    make(map[string]*RehomeJob),
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// start launches run for job.Share on a detached context. If a job is already
+// running for the share it is returned with started=false and run is not
+// invoked.
+func (r *rehomeRegistry) start(job RehomeJob, run func(ctx context.Context, hooks RehomeHooks) error) (*RehomeJob, bool) {
+	shareName := job.Share
+
+	r.mu.Lock()
+	if cur, ok := r.jobs[shareName]; ok && cur.State == RehomeStateRunning {
+		snapshot := cur.clone()
+		r.mu.Unlock()
+		return snapshot, false
+	}
+	r.counter++
+	job.ID = fmt.Sprintf("rehome-%d", r.counter)
+	job.State = RehomeStateRunning
+	job.StartedAt = time.Now()
+	stored := &job
+	r.jobs[shareName] = stored
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancels[shareName] = cancel
+	snapshot := stored.clone()
+	r.mu.Unlock()
+
+	hooks := RehomeHooks{
+		SetPhase: func(phase string) {
+			r.mu.Lock()
+			stored.Phase = phase
+			r.mu.Unlock()
+		},
+		Progress: func(p engine.RehomeProgress) {
+			r.mu.Lock()
+			stored.Progress = p
+			r.mu.Unlock()
+		},
+	}
+
+	go func() {
+		err := run(ctx, hooks)
+		canceled := ctx.Err() != nil
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if c, ok := r.cancels[shareName]; ok && r.jobs[shareName] == stored {
+			c()
+			delete(r.cancels, shareName)
+		}
+		stored.FinishedAt = time.Now()
+		switch {
+		case err == nil:
+			stored.State = RehomeStateDone
+		case canceled:
+			stored.State = RehomeStateCanceled
+			stored.Err = err.Error()
+		default:
+			stored.State = RehomeStateFailed
+			stored.Err = err.Error()
+		}
+		logger.Info("rehome job finished",
+			"job", stored.ID, "share", shareName, "state", stored.State,
+			"blocks_moved", stored.Progress.BlocksMoved, "error", stored.Err)
+	}()
+
+	return snapshot, true
+}
+
+func (r *rehomeRegistry) get(shareName string) (*RehomeJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[shareName]
+	if !ok {
+		return nil, false
+	}
+	return job.clone(), true
+}
+
+// cancelForShare cancels the running re-home of shareName, if any.
+func (r *rehomeRegistry) cancelForShare(shareName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.cancels[shareName]; ok {
+		c()
+	}
+}
+
+// rehomePeers is the pair of remotes a re-homing share holds references to.
+// Whichever of the two the share is not bound to serves as its block store's
+// read fallback.
+type rehomePeers struct {
+	sourceID string
+	targetID string
+	source   remote.RemoteStore
+	target   remote.RemoteStore
+}
+
+// installRehomeFallback points the share's block store at the re-home peer it
+// is not bound to, or clears the fallback when no re-home is in flight. Caller
+// holds s.mu (or owns the share exclusively).
+func installRehomeFallback(share *Share) {
+	if share.BlockStore == nil {
+		return
+	}
+	p := share.rehome
+	switch {
+	case p == nil:
+		share.BlockStore.SetFallbackRemote(nil)
+	case share.remoteConfigID == p.targetID:
+		share.BlockStore.SetFallbackRemote(p.source)
+	default:
+		share.BlockStore.SetFallbackRemote(p.target)
+	}
+}
+
+// BeginRehome attaches the source and target remotes of a re-home to a
+// running share: it takes a reference on both, installs the one the share is
+// not bound to as its read fallback (dual-read), and withholds both from GC
+// (DistinctRemoteStores). Calling it again for a share already attached to the
+// same pair is a no-op, so a retried or resumed re-home can call it blindly.
+func (s *Service) BeginRehome(ctx context.Context, name, sourceRef, targetRef string, provider BlockStoreConfigProvider) error {
+	source, sourceID, err := s.acquireRemoteStore(ctx, sourceRef, provider)
+	if err != nil {
+		return fmt.Errorf("rehome share %q: source remote: %w", name, err)
+	}
+	target, targetID, err := s.acquireRemoteStore(ctx, targetRef, provider)
+	if err != nil {
+		s.releaseRemoteStore(sourceID)
+		return fmt.Errorf("rehome share %q: target remote: %w", name, err)
+	}
+
+	s.mu.Lock()
+	share, ok := s.registry[name]
+	if !ok {
+		s.mu.Unlock()
+		s.releaseRemoteStore(sourceID)
+		s.releaseRemoteStore(targetID)
+		return fmt.Errorf("%w: %q", ErrShareNotFound, name)
+	}
+	if p := share.rehome; p != nil {
+		s.mu.Unlock()
+		s.releaseRemoteStore(sourceID)
+		s.releaseRemoteStore(targetID)
+		if p.sourceID != sourceID || p.targetID != targetID {
+			return fmt.Errorf("share %q is already re-homing from %s to %s", name, p.sourceID, p.targetID)
+		}
+		return nil
+	}
+	share.rehome = &rehomePeers{sourceID: sourceID, targetID: targetID, source: source, target: target}
+	s.rehoming[sourceID]++
+	s.rehoming[targetID]++
+	installRehomeFallback(share)
+	s.mu.Unlock()
+	return nil
+}
+
+// EndRehome clears the share's read fallback and drops its references on both
+// re-home remotes, re-admitting them to GC. A share with no re-home attached
+// is left alone.
+func (s *Service) EndRehome(name string) {
+	s.mu.Lock()
+	share, ok := s.registry[name]
+	if !ok || share.rehome == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+	s.releaseRehomePeers(share)
+}
+
+// releaseRehomePeers detaches share.rehome (if any) and releases it.
+func (s *Service) releaseRehomePeers(share *Share) {
+	s.mu.Lock()
+	p := share.rehome
+	if p == nil {
+		s.mu.Unlock()
+		return
+	}
+	share.rehome = nil
+	installRehomeFallback(share)
+	for _, id := range []string{p.sourceID, p.targetID} {
+		if s.rehoming[id]--; s.rehoming[id] <= 0 {
+			delete(s.rehoming, id)
+		}
+	}
+	s.mu.Unlock()
+
+	s.releaseRemoteStore(p.sourceID)
+	s.releaseRemoteStore(p.targetID)
+}
+
+// RehomeTargets returns the share's block store and the remote a re-home pass
+// should move blocks from and to: while the share is still bound to the
+// source, blocks go from its own remote to the target; once it is bound to
+// the target, from the source into its own remote. BeginRehome must have
+// been called.
+func (s *Service) RehomeTargets(name string) (*engine.Store, remote.RemoteStore, remote.RemoteStore, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	share, ok := s.registry[name]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w: %q", ErrShareNotFound, name)
+	}
+	if share.BlockStore == nil || share.rehome == nil {
+		return nil, nil, nil, fmt.Errorf("share %q has no re-home attached", name)
+	}
+	return share.BlockStore, share.rehome.source, share.rehome.target, nil
+}
+
+// StartRehome launches run as the share's re-home job unless one is already
+// running, in which case that job is returned with started=false.
+func (s *Service) StartRehome(job RehomeJob, run func(ctx context.Context, hooks RehomeHooks) error) (*RehomeJob, bool) {
+	return s.rehomeJobs.start(job, run)
+}
+
+// GetRehome returns a snapshot of the share's latest re-home job.
+func (s *Service) GetRehome(shareName string) (*RehomeJob, bool) {
+	return s.rehomeJobs.get(shareName)
+}
@@ -116,10 +116,22 @@ type Share struct {
 	NetgroupName      string
 	BlockedOperations []string
 
+	// Exports are the NFS subtree export entries layered on the share. Read
+	// via the locked ShareExports accessor; replaced by SetShareExports.
+	Exports []SubtreeExport
+
 	// Retention policy for local blocks.
 	RetentionPolicy block.RetentionPolicy
 	RetentionTTL    time.Duration
 
+	// WarmPolicy re-warms the share's local tier automatically (on load,
+	// after an eviction, periodically). Zero = never.
+	WarmPolicy block.WarmPolicy
+
+	// FileAudit is the share's file-access audit policy. Read per operation
+	// via the locked FileAuditConfigForShare accessor.
+	FileAudit metadata.FileAuditConfig
+
 	// BlockStore is the per-share block store orchestrator.
 	// Nil only for metadata-only shares (unlikely in practice).
 	BlockStore *engine.Store
@@ -127,6 +139,17 @@ type Share struct {
 	// remoteConfigID tracks which remote store config this share uses (for ref counting).
 	remoteConfigID string
 
+	// bandwidth is this share's own remote byte-rate limiter. It survives a
+	// block-store rebind so the live policy is not reset; SetShareBandwidth
+	// swaps its policy in place.
+	bandwidth *engine.BandwidthLimiter
+
+	// access is the share's read-frequency sketch that drives targeted and
+	// policy warms. Like bandwidth it survives a block-store rebind; it is
+	// persisted to accessPath (empty for in-memory local stores).
+	access     *engine.AccessHistory
+	accessPath string
+
 	// gcStateRoot is the on-disk directory under which the GC engine
 	// persists per-run gc-state and `last-run.json`.
 	// Populated for fs-backed local stores at share creation; empty for
@@ -146,6 +169,10 @@ type Share struct {
 	// AddShare tells the metadata service to relax the per-op FILE_SYNC flush
 	// for this share's handles. Default false (durable).
 	writeback bool
+
+	// rehome holds both remotes of an in-flight remote re-home (rehome.go).
+	// nil otherwise.
+	rehome *rehomePeers
 }
 
 // GCStateRoot returns the per-share gc-state directory used by the GC
@@ -224,6 +251,7 @@ type ShareConfig struct {
 	MinKerberosLevel  string
 	NetgroupName      string
 	BlockedOperations []string
+	Exports           []SubtreeExport
 
 	// Retention policy for local blocks.
 	RetentionPolicy block.RetentionPolicy
@@ -236,6 +264,14 @@ type ShareConfig struct {
 	// Per-share byte quota (0 = unlimited).
 	QuotaBytes int64
 
+	// Bandwidth caps the share's remote upload/download rates (zero =
+	// unlimited). The share's remote store may impose its own, shared cap.
+	Bandwidth block.BandwidthPolicy
+	// WarmPolicy is the share's automatic pre-warm policy (zero = none).
+	WarmPolicy block.WarmPolicy
+	// FileAudit is the share's file-access audit policy (zero = off).
+	FileAudit metadata.FileAuditConfig
+
 	// Block store config IDs resolved from the DB share model.
 	LocalBlockStoreID  string // Required: references a local BlockStoreConfig
 	RemoteBlockStoreID string // Optional: references a remote BlockStoreConfig (empty = local-only)
@@ -375,6 +411,9 @@ type sharedRemote struct {
 	store    remote.RemoteStore
 	refCount int
 	configID string
+	// bandwidth is the remote's byte-rate limiter, shared by every share that
+	// uploads to or downloads from it (the per-remote "bandwidth" config).
+	bandwidth *engine.BandwidthLimiter
 }
 
 // nonClosingRemote wraps a remote.RemoteStore and makes Close() a no-op.
@@ -510,6 +549,13 @@ type Service struct {
 	// RemoveShare. See warm.go.
 	warmJobs *warmRegistry
 
+	// rehomeJobs tracks remote re-home jobs (latest per share); rehoming
+	// counts, per remote config ID, the in-flight re-homes involving that
+	// remote, whose GC DistinctRemoteStores withholds. rehoming is guarded by
+	// mu. See rehome.go.
+	rehomeJobs *rehomeRegistry
+	rehoming   map[string]int
+
 	// blockStoreCache is a lock-free mirror of registry[name].BlockStore keyed by
 	// share name. Every NFS/SMB data op resolves the per-share store here without
 	// touching s.mu, so the read/write hot path pays no reader-lock contention.
@@ -529,6 +575,8 @@ func New() *Service {
 		changeCallbacks:         make(map[int]func(shares []string)),
 		authInvalidateCallbacks: make(map[int]func()),
 		warmJobs:                newWarmRegistry(),
+		rehomeJobs:              newRehomeRegistry(),
+		rehoming:                make(map[string]int),
 	}
 }
 
@@ -960,8 +1008,11 @@ func (s *Service) prepareShare(
 		MinKerberosLevel:                 config.MinKerberosLevel,
 		NetgroupName:                     config.NetgroupName,
 		BlockedOperations:                config.BlockedOperations,
+		Exports:                          sortExports(config.Exports),
 		RetentionPolicy:                  config.RetentionPolicy,
 		RetentionTTL:                     config.RetentionTTL,
+		WarmPolicy:                       config.WarmPolicy,
+		FileAudit:                        config.FileAudit,
 	}
 
 	return share, metadataStore, nil
@@ -1106,6 +1157,13 @@ func (s *Service) createBlockStoreForShare(
 		}
 	}
 
+	// Byte-rate caps: the share's own limiter plus the remote's shared one.
+	// The share limiter is reused across rebinds so a live policy survives.
+	if share.bandwidth == nil {
+		share.bandwidth = engine.NewBandwidthLimiter(config.Bandwidth)
+	}
+	syncer.SetBandwidthLimiters(share.bandwidth, s.remoteBandwidthLimiter(remoteConfigID))
+
 	cleanup := func() {
 		_ = syncer.Close()
 		_ = localStore.Close()
@@ -1263,6 +1321,7 @@ func (s *Service) createBlockStoreForShare(
 	// Same source-of-truth + emptiness semantics as gcStateRoot — memory
 	// backends produce "" so the status handler can short-circuit.
 	share.localStoreDir = deriveLocalStoreDir(localCfg, config.Name)
+	installAccessHistory(share)
 
 	// A pinned share never evicts, so a bounded local tier can fill and then
 	// fail reads with ErrDiskFull once the working set exceeds it. Warn the
@@ -1299,8 +1358,9 @@ func (s *Service) createBlockStoreForShare(
 // Start seeds the pending-upload set from disk.
 //
 // newConfig carries the new binding; oldConfig is identical except for the
-// block-store IDs and is used only to rebuild the previous store if the new one
-// fails to build, so a rebind failure never leaves the share storeless.
+// block-store IDs (or, from RebindShareMetadataStore, the metadata store) and is
+// used only to rebuild the previous store if the new one fails to build, so a
+// rebind failure never leaves the share storeless.
 func (s *Service) RebindShareBlockStore(
 	ctx context.Context,
 	newConfig *ShareConfig,
@@ -1327,11 +1387,18 @@ func (s *Service) RebindShareBlockStore(
 		return fmt.Errorf("cannot rebind share %q: not found in registry", name)
 	}
 
-	// Resolve the metadata store (fileChunkStore); unchanged by a binding change.
+	// Resolve the metadata store (fileChunkStore) for each binding. They only
+	// differ when RebindShareMetadataStore moves the share to another store.
 	fileChunkStore, err := storeProvider.GetMetadataStore(newConfig.MetadataStore)
 	if err != nil {
 		return fmt.Errorf("failed to resolve metadata store for share %q: %w", name, err)
 	}
+	oldFileChunkStore := fileChunkStore
+	if oldConfig.MetadataStore != newConfig.MetadataStore {
+		if oldFileChunkStore, err = storeProvider.GetMetadataStore(oldConfig.MetadataStore); err != nil {
+			return fmt.Errorf("failed to resolve previous metadata store for share %q: %w", name, err)
+		}
+	}
 
 	// Pre-validate the new binding resolves BEFORE tearing down the live store,
 	// so a bad binding fails fast without disrupting the running share.
@@ -1347,6 +1414,8 @@ func (s *Service) RebindShareBlockStore(
 	s.mu.RLock()
 	oldBS := share.BlockStore
 	oldRemoteConfigID := share.remoteConfigID
+	bandwidth := share.bandwidth
+	access, accessPath := share.access, share.accessPath
 	s.mu.RUnlock()
 
 	// Flush pending uploads to the OLD remote before teardown so switching or
@@ -1364,14 +1433,14 @@ func (s *Service) RebindShareBlockStore(
 	}
 
 	// Build the new store over the same (now-closed) local dir.
-	rebuilt := &Share{Name: name}
+	rebuilt := &Share{Name: name, bandwidth: bandwidth, access: access, accessPath: accessPath}
 	if buildErr := s.createBlockStoreForShare(ctx, rebuilt, newConfig, blockStoreProvider, fileChunkStore, localStoreDefaults, syncerDefaults); buildErr != nil {
 		// Recovery: rebuild the previous binding so the share is not left
 		// storeless. oldConfig differs from newConfig only in the block-store IDs.
 		logger.Error("rebind: failed to build new block store; restoring previous binding",
 			"share", name, "error", buildErr)
-		recovered := &Share{Name: name}
-		if recErr := s.createBlockStoreForShare(ctx, recovered, oldConfig, blockStoreProvider, fileChunkStore, localStoreDefaults, syncerDefaults); recErr != nil {
+		recovered := &Share{Name: name, bandwidth: bandwidth, access: access, accessPath: accessPath}
+		if recErr := s.createBlockStoreForShare(ctx, recovered, oldConfig, blockStoreProvider, oldFileChunkStore, localStoreDefaults, syncerDefaults); recErr != nil {
 			// Both failed: the share keeps its now-closed store (ops return
 			// ErrClosed, not a nil-deref panic) and needs a restart. Release the
 			// original remote ref since nothing holds it anymore.
@@ -1400,6 +1469,7 @@ func (s *Service) RebindShareBlockStore(
 		share.remoteConfigID = recovered.remoteConfigID
 		share.gcStateRoot = recovered.gcStateRoot
 		share.localStoreDir = recovered.localStoreDir
+		installRehomeFallback(share)
 		s.blockStoreCache.Store(name, share.BlockStore)
 		s.mu.Unlock()
 		if oldRemoteConfigID != "" {
@@ -1432,6 +1502,7 @@ func (s *Service) RebindShareBlockStore(
 	share.remoteConfigID = rebuilt.remoteConfigID
 	share.gcStateRoot = rebuilt.gcStateRoot
 	share.localStoreDir = rebuilt.localStoreDir
+	installRehomeFallback(share)
 	s.blockStoreCache.Store(name, share.BlockStore)
 	s.mu.Unlock()
 
@@ -1449,6 +1520,74 @@ func (s *Service) RebindShareBlockStore(
 	return nil
 }
 
+// RebindShareMetadataStore points a running share at another metadata store
+// after its metadata was copied there (dfsctl share migrate-metadata). The
+// per-share BlockStore is rebuilt through RebindShareBlockStore because its
+// syncer and GC read FileChunk rows from the metadata store it was built over;
+// block data itself is untouched. The share's root handle is re-read from the
+// new store, since each engine mints its own root ID.
+//
+// The caller must have quiesced the share (disabled, pending writes flushed).
+// oldConfig is newConfig with the previous metadata store and is used to
+// restore the previous block store if the new one fails to build.
+func (s *Service) RebindShareMetadataStore(
+	ctx context.Context,
+	newConfig *ShareConfig,
+	oldConfig *ShareConfig,
+	storeProvider MetadataStoreProvider,
+	metadataSvc MetadataServiceRegistrar,
+	blockStoreProvider BlockStoreConfigProvider,
+	localStoreDefaults *LocalStoreDefaults,
+	syncerDefaults *SyncerDefaults,
+) error {
+	name := newConfig.Name
+	if metadataSvc == nil {
+		return fmt.Errorf("metadata service registrar is required for share %q", name)
+	}
+	metadataStore, err := storeProvider.GetMetadataStore(newConfig.MetadataStore)
+	if err != nil {
+		return fmt.Errorf("failed to resolve metadata store for share %q: %w", name, err)
+	}
+	rootHandle, err := metadataStore.GetRootHandle(ctx, name)
+	if err != nil {
+		return fmt.Errorf("share %q has no root in metadata store %q: %w", name, newConfig.MetadataStore, err)
+	}
+
+	s.mu.RLock()
+	_, ok := s.registry[name]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("cannot rebind share %q: not found in registry", name)
+	}
+
+	if newConfig.LocalBlockStoreID != "" {
+		if err := s.RebindShareBlockStore(ctx, newConfig, oldConfig, storeProvider, blockStoreProvider, localStoreDefaults, syncerDefaults); err != nil {
+			return err
+		}
+	}
+
+	if err := metadataSvc.RegisterStoreForShare(name, metadataStore); err != nil {
+		return fmt.Errorf("failed to register metadata store for share %q: %w", name, err)
+	}
+
+	s.mu.Lock()
+	share, ok := s.registry[name]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("share %q was removed during rebind", name)
+	}
+	share.MetadataStore = newConfig.MetadataStore
+	share.RootHandle = rootHandle
+	s.mu.Unlock()
+
+	s.notifyShareChange()
+	logger.Info("Share metadata store rebound live",
+		"share", name,
+		"from", oldConfig.MetadataStore,
+		"to", newConfig.MetadataStore)
+	return nil
+}
+
 // acquireRemoteStore returns a shared remote store, creating it if needed.
 // Uses double-checked locking to avoid holding s.mu during potentially slow
 // network/DB I/O (config resolution, S3 client initialization).
@@ -1479,6 +1618,16 @@ func (s *Service) acquireRemoteStore(ctx context.Context, ref string, provider B
 	}
 	configID := remoteCfg.ID
 
+	// A malformed bandwidth policy must not keep the share offline:
+	// validateBandwidth rejects it at store-create time, so a bad value here is
+	// an old or hand-edited row. Fall back to unlimited and say so.
+	policy, err := RemoteBandwidthPolicy(remoteCfg)
+	if err != nil {
+		logger.Warn("ignoring invalid remote bandwidth policy",
+			"config_id", configID, "error", err)
+		policy = block.BandwidthPolicy{}
+	}
+
 	s.mu.Lock()
 	if sr, ok := s.remoteStores[configID]; ok {
 		sr.refCount++
@@ -1529,9 +1678,10 @@ func (s *Service) acquireRemoteStore(ctx context.Context, ref string, provider B
 	}
 
 	s.remoteStores[configID] = &sharedRemote{
-		store:    newStore,
-		refCount: 1,
-		configID: configID,
+		store:     newStore,
+		refCount:  1,
+		configID:  configID,
+		bandwidth: engine.NewBandwidthLimiter(policy),
 	}
 	s.mu.Unlock()
 
@@ -1654,6 +1804,13 @@ func (s *Service) RemoveShare(name string) error {
 	// Cancel any in-flight warm job for this share so it cannot keep fetching
 	// into a block store that is about to be closed.
 	s.warmJobs.cancelForShare(name)
+	if err := share.access.Save(share.accessPath); err != nil {
+		logger.Warn("RemoveShare: failed to persist access history", "share", name, "error", err)
+	}
+	// Likewise stop a re-home and drop its hold on both remotes. Its durable
+	// marker stays behind; startup recovery discards it if the share is gone.
+	s.rehomeJobs.cancelForShare(name)
+	s.releaseRehomePeers(share)
 
 	var errs []error
 
@@ -2452,7 +2609,10 @@ type RemoteStoreEntry struct {
 // referenced by at least one registered share. Shares that reference the same
 // remote-store config (ref-counted via remoteStores) are grouped into a
 // single entry — deduped by ConfigID, NOT by the per-share nonClosingRemote
-// wrapper pointer. Local-only shares (no remote) contribute nothing.
+// wrapper pointer. Local-only shares (no remote) contribute nothing, and
+// remotes taking part in a re-home (BeginRehome) are withheld until it ends:
+// their blocks are referenced from whichever remote a locator names, so GC
+// of either side mid-move would reclaim live data.
 //
 // Returned entries have a non-nil Store and a non-empty Shares slice. Order
 // is unspecified (map iteration).
@@ -2472,6 +2632,14 @@ func (s *Service) DistinctRemoteStores() []RemoteStoreEntry {
 
 	out := make([]RemoteStoreEntry, 0, len(sharesByConfigID))
 	for cid, shareNames := range sharesByConfigID {
+		if s.rehoming[cid] > 0 {
+			// A re-home is moving blocks onto or off this remote: objects
+			// there are referenced by a share bound to the other remote (or
+			// not referenced yet), so GC and reconcile would misread them as
+			// orphans. Skip it until the move completes.
+			logger.Debug("DistinctRemoteStores: skipping remote with a re-home in flight", "config_id", cid)
+			continue
+		}
 		sr, ok := s.remoteStores[cid]
 		if !ok || sr == nil || sr.store == nil {
 			// Orphaned configID → skip. DistinctRemoteStores is a read-only
@@ -2783,12 +2951,19 @@ func (s *Service) EvictBlockStore(ctx context.Context, shareName string, opts Ev
 }
 
 // StartWarm starts (or returns the already-running) async warm job for
-// shareName. The job proactively materializes every remote block of the share
-// onto the local tier (engine.Store.WarmAll) on a DETACHED context so it
-// outlives the request that triggered it; RemoveShare cancels it. Returns an
-// error if the share is unknown or has no block store. The returned WarmJob is
-// a snapshot taken under the registry lock.
-func (s *Service) StartWarm(_ context.Context, shareName string) (*WarmJob, error) {
+// shareName. A zero sel proactively materializes every remote block of the
+// share onto the local tier (engine.Store.WarmAll); otherwise only the files
+// sel resolves to are warmed (selectWarmPayloads), with globs resolved
+// against the share's metadata store from storeProvider. The job runs on a
+// DETACHED context so it outlives the request that triggered it; RemoveShare
+// cancels it. Returns an error if the share is unknown, has no block store, or
+// sel is invalid. The returned WarmJob is a snapshot taken under the registry
+// lock.
+func (s *Service) StartWarm(_ context.Context, shareName string, sel block.WarmSelector, storeProvider MetadataStoreProvider) (*WarmJob, error) {
+	if err := sel.Validate(); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	share, exists := s.registry[shareName]
 	if !exists {
@@ -2800,6 +2975,8 @@ func (s *Service) StartWarm(_ context.Context, shareName string) (*WarmJob, erro
 		return nil, fmt.Errorf("share %q has no block store configured", shareName)
 	}
 	bs := share.BlockStore
+	history := share.access
+	metaName, root := share.MetadataStore, share.RootHandle
 	s.mu.RUnlock()
 
 	// Capture the share's currently-stored byte count up front (O(1) lite
@@ -2807,12 +2984,40 @@ func (s *Service) StartWarm(_ context.Context, shareName string) (*WarmJob, erro
 	// run enumerates zero blocks on a non-empty share (#1374).
 	usedBytes := bs.GetStatsLite().LocalDiskUsed
 
-	job := s.warmJobs.start(shareName, usedBytes, func(ctx context.Context, progress func(done, total int64)) (warmAllResult, error) {
-		res, err := bs.WarmAll(ctx, progress)
+	if sel.IsZero() {
+		job := s.warmJobs.start(shareName, nil, usedBytes, func(ctx context.Context, progress func(done, total int64)) (warmAllResult, error) {
+			res, err := bs.WarmAll(ctx, progress)
+			return warmAllResult{
+				BlocksFetched:      res.BlocksFetched,
+				BytesFetched:       res.BytesFetched,
+				BlocksAlreadyLocal: res.BlocksAlreadyLocal,
+			}, err
+		})
+		return job, nil
+	}
+
+	var meta metadata.Store
+	if len(sel.Paths) > 0 {
+		if storeProvider == nil {
+			return nil, fmt.Errorf("share %q: path selection needs a metadata store", shareName)
+		}
+		var err error
+		if meta, err = storeProvider.GetMetadataStore(metaName); err != nil {
+			return nil, fmt.Errorf("share %q: metadata store %q: %w", shareName, metaName, err)
+		}
+	}
+
+	job := s.warmJobs.start(shareName, &sel, usedBytes, func(ctx context.Context, progress func(done, total int64)) (warmAllResult, error) {
+		payloadIDs, err := selectWarmPayloads(ctx, meta, root, sel, history)
+		if err != nil {
+			return warmAllResult{}, fmt.Errorf("select files: %w", err)
+		}
+		res, err := bs.WarmPayloads(ctx, payloadIDs, progress)
 		return warmAllResult{
 			BlocksFetched:      res.BlocksFetched,
 			BytesFetched:       res.BytesFetched,
 			BlocksAlreadyLocal: res.BlocksAlreadyLocal,
+			FilesSelected:      int64(len(payloadIDs)),
 		}, err
 	})
 	return job, nil
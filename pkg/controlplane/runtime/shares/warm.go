@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/block"
 )
 
 // Warm job states.
@@ -31,13 +32,19 @@ type WarmJob struct {
 	ID    string `json:"id"`
 	Share string `json:"share"`
 	// State is one of WarmState{Running,Done,Failed,Canceled}.
-	State       string    `json:"state"`
-	BlocksTotal int64     `json:"blocks_total"`
-	BlocksDone  int64     `json:"blocks_done"`
-	BytesDone   int64     `json:"bytes_done"`
-	StartedAt   time.Time `json:"started_at"`
-	FinishedAt  time.Time `json:"finished_at"`
-	Err         string    `json:"error,omitempty"`
+	State       string `json:"state"`
+	BlocksTotal int64  `json:"blocks_total"`
+	BlocksDone  int64  `json:"blocks_done"`
+	BytesDone   int64  `json:"bytes_done"`
+	// Selector is the targeting of a selective warm; nil warms the whole
+	// share.
+	Selector *block.WarmSelector `json:"selector,omitempty"`
+	// FilesSelected is the number of files a selective warm resolved to,
+	// set once selection finishes.
+	FilesSelected int64     `json:"files_selected,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	FinishedAt    time.Time `json:"finished_at"`
+	Err           string    `json:"error,omitempty"`
 	// Warning carries a non-fatal advisory surfaced to the operator on an
 	// otherwise-successful run. It is set when a warm run enumerated zero
 	// blocks to materialize for a share that nonetheless reports nonzero
@@ -48,6 +55,10 @@ type WarmJob struct {
 // clone returns a copy safe to hand outside the registry lock.
 func (j *WarmJob) clone() *WarmJob {
 	cp := *j
+	if j.Selector != nil {
+		sel := *j.Selector
+		cp.Selector = &sel
+	}
 	return &cp
 }
 
@@ -89,19 +100,23 @@ type warmAllResult struct {
 	BlocksFetched      int64
 	BytesFetched       int64
 	BlocksAlreadyLocal int64
+	// FilesSelected is the size of a selective warm's selection.
+	FilesSelected int64
 }
 
 // start launches a warm run for shareName against run on a DETACHED context
 // (derived from context.Background(), not the request ctx) so the job outlives
 // the HTTP request that triggered it. If a job is already running for the
-// share, the existing job is returned and run is not invoked.
+// share, the existing job is returned and run is not invoked. sel records the
+// targeting of a selective warm on the job (nil = whole share).
 //
 // usedBytes is the share's currently-stored byte count (captured by the
 // caller before launching). When the run completes with zero enumerable
 // blocks (nothing fetched AND nothing already-local) but usedBytes > 0, the
 // job's Warning field is set: a non-empty share with no warmable blocks is a
-// strong hint that block metadata is missing (#1374).
-func (r *warmRegistry) start(shareName string, usedBytes int64, run func(ctx context.Context, progress func(done, total int64)) (warmAllResult, error)) *WarmJob {
+// strong hint that block metadata is missing (#1374). A selective warm never
+// warns, since an empty selection is a legitimate outcome.
+func (r *warmRegistry) start(shareName string, sel *block.WarmSelector, usedBytes int64, run func(ctx context.Context, progress func(done, total int64)) (warmAllResult, error)) *WarmJob {
 	r.mu.Lock()
 	if existingID, ok := r.active[shareName]; ok {
 		job := r.jobs[existingID].clone()
@@ -119,6 +134,7 @@ func (r *warmRegistry) start(shareName string, usedBytes int64, run func(ctx con
 		ID:        jobID,
 		Share:     shareName,
 		State:     WarmStateRunning,
+		Selector:  sel,
 		StartedAt: time.Now(),
 	}
 	r.jobs[jobID] = job
@@ -163,6 +179,7 @@ func (r *warmRegistry) start(shareName string, usedBytes int64, run func(ctx con
 		}
 		j.FinishedAt = time.Now()
 		j.BytesDone = res.BytesFetched
+		j.FilesSelected = res.FilesSelected
 		switch {
 		case err == nil:
 			j.State = WarmStateDone
@@ -171,7 +188,7 @@ func (r *warmRegistry) start(shareName string, usedBytes int64, run func(ctx con
 			// If that is zero yet the share reports stored bytes, the warm
 			// found nothing to do on a non-empty share — surface a warning
 			// (#1374) rather than a silent no-op success.
-			if res.BlocksFetched+res.BlocksAlreadyLocal == 0 && usedBytes > 0 {
+			if sel == nil && res.BlocksFetched+res.BlocksAlreadyLocal == 0 && usedBytes > 0 {
 				j.Warning = fmt.Sprintf(
 					"warm found 0 enumerable blocks for share %s but it has %d bytes on the local disk tier — block metadata may be missing",
 					shareName, usedBytes)
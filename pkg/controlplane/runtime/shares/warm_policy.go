@@ -0,0 +1,193 @@
+package shares
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/marmos91/dittofs/pkg/block/engine"
+	"github.com/marmos91/dittofs/pkg/metadata"
+)
+
+// accessHistoryFile is the per-share access-history file under the share's
+// local store directory.
+const accessHistoryFile = "access-history.json"
+
+// warmWalkPageSize is the ListChildren page size of a path-glob selection walk.
+const warmWalkPageSize = 500
+
+// installAccessHistory loads (once per share) the share's persisted access
+// history and installs it on the freshly built block store, so reads keep
+// feeding the same sketch across block-store rebinds. Memory-backed shares
+// get an in-memory history that does not survive a restart.
+func installAccessHistory(share *Share) {
+	if share.BlockStore == nil {
+		return
+	}
+	if share.access == nil {
+		var histPath string
+		if share.localStoreDir != "" {
+			histPath = filepath.Join(share.localStoreDir, accessHistoryFile)
+		}
+		h, err := engine.LoadAccessHistory(histPath, 0, 0)
+		if err != nil {
+			logger.Warn("ignoring unreadable access history; starting empty",
+				"share", share.Name, "path", histPath, "error", err)
+		}
+		share.access = h
+		share.accessPath = histPath
+	}
+	share.BlockStore.SetAccessHistory(share.access)
+}
+
+// SaveAccessHistories persists every share's access history that changed since
+// its last save. Best-effort: failures are logged, never returned.
+func (s *Service) SaveAccessHistories() {
+	type entry struct {
+		name string
+		h    *engine.AccessHistory
+		path string
+	}
+	s.mu.RLock()
+	entries := make([]entry, 0, len(s.registry))
+	for name, sh := range s.registry {
+		if sh.access != nil && sh.accessPath != "" {
+			entries = append(entries, entry{name: name, h: sh.access, path: sh.accessPath})
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, e := range entries {
+		if err := e.h.Save(e.path); err != nil {
+			logger.Warn("failed to persist access history", "share", e.name, "error", err)
+		}
+	}
+}
+
+// SetShareWarmPolicy replaces a live share's automatic pre-warm policy. The
+// runtime's warm-policy loop picks it up on its next tick.
+func (s *Service) SetShareWarmPolicy(name string, policy block.WarmPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	share, ok := s.registry[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrShareNotFound, name)
+	}
+	share.WarmPolicy = policy
+	return nil
+}
+
+// WarmPolicies returns the pre-warm policy of every share that has one,
+// keyed by share name.
+func (s *Service) WarmPolicies() map[string]block.WarmPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]block.WarmPolicy)
+	for name, sh := range s.registry {
+		if !sh.WarmPolicy.IsZero() {
+			out[name] = sh.WarmPolicy
+		}
+	}
+	return out
+}
+
+// selectWarmPayloads resolves sel to the payload IDs to warm.
+//
+// Globs are matched by walking the share's directory tree in meta. The
+// recency window and the hottest-N cap are answered from the access history:
+// a file with no recorded reads is never "recent" or "hot". The criteria are
+// ANDed, and a Hottest cap ranks whatever the other criteria selected.
+func selectWarmPayloads(ctx context.Context, meta metadata.Store, root metadata.FileHandle, sel block.WarmSelector, history *engine.AccessHistory) ([]string, error) {
+	window, err := sel.Window()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched map[string]struct{}
+	if len(sel.Paths) > 0 {
+		if matched, err = walkWarmPaths(ctx, meta, root, sel); err != nil {
+			return nil, err
+		}
+		if window == 0 && sel.Hottest == 0 {
+			out := make([]string, 0, len(matched))
+			for pid := range matched {
+				out = append(out, pid)
+			}
+			return out, nil
+		}
+	}
+
+	var cutoff time.Time
+	if window > 0 {
+		cutoff = time.Now().Add(-window)
+	}
+	var out []string
+	for _, rec := range history.Snapshot() {
+		if sel.Hottest > 0 && len(out) >= sel.Hottest {
+			break
+		}
+		if matched != nil {
+			if _, ok := matched[rec.PayloadID]; !ok {
+				continue
+			}
+		}
+		if !cutoff.IsZero() && rec.LastAccess.Before(cutoff) {
+			continue
+		}
+		out = append(out, rec.PayloadID)
+	}
+	return out, nil
+}
+
+// walkWarmPaths returns the payload IDs of the regular files under root whose
+// share-relative path matches one of sel's globs.
+func walkWarmPaths(ctx context.Context, meta metadata.Store, root metadata.FileHandle, sel block.WarmSelector) (map[string]struct{}, error) {
+	type dir struct {
+		handle metadata.FileHandle
+		rel    string
+	}
+	matched := make(map[string]struct{})
+	queue := []dir{{handle: root}}
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+		cursor := ""
+		for {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			entries, next, err := meta.ListChildren(ctx, d.handle, cursor, warmWalkPageSize)
+			if err != nil {
+				return nil, fmt.Errorf("list %q: %w", "/"+d.rel, err)
+			}
+			for _, e := range entries {
+				rel := path.Join(d.rel, e.Name)
+				attr := e.Attr
+				if attr == nil {
+					f, err := meta.GetFile(ctx, e.Handle)
+					if err != nil {
+						return nil, fmt.Errorf("stat %q: %w", "/"+rel, err)
+					}
+					attr = &f.FileAttr
+				}
+				switch attr.Type {
+				case metadata.FileTypeDirectory:
+					queue = append(queue, dir{handle: e.Handle, rel: rel})
+				case metadata.FileTypeRegular:
+					if attr.PayloadID != "" && sel.MatchesPath(rel) {
+						matched[string(attr.PayloadID)] = struct{}{}
+					}
+				}
+			}
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+	}
+	return matched, nil
+}
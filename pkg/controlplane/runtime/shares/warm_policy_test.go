@@ -0,0 +1,124 @@
+package shares
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/marmos91/dittofs/pkg/block/engine"
+	"github.com/marmos91/dittofs/pkg/metadata"
+	metamem "github.com/marmos91/dittofs/pkg/metadata/store/memory"
+)
+
+// warmTree builds /renders/shot1/{a.exr,a.txt}, /renders/b.exr and /top.exr
+// in an in-memory store and returns it with its root handle.
+func warmTree(t *testing.T) (metadata.Store, metadata.FileHandle) {
+	t.Helper()
+	ctx := context.Background()
+	store := metamem.NewMemoryMetadataStoreWithDefaults()
+	rootFile, err := store.CreateRootDirectory(ctx, "/warm", &metadata.FileAttr{Type: metadata.FileTypeDirectory, Mode: 0o755})
+	if err != nil {
+		t.Fatalf("CreateRootDirectory: %v", err)
+	}
+	root, err := metadata.EncodeFileHandle(rootFile)
+	if err != nil {
+		t.Fatalf("EncodeFileHandle: %v", err)
+	}
+
+	put := func(parent metadata.FileHandle, path string, typ metadata.FileType) metadata.FileHandle {
+		h, err := store.GenerateHandle(ctx, "/warm", path)
+		if err != nil {
+			t.Fatalf("GenerateHandle: %v", err)
+		}
+		_, id, _ := metadata.DecodeFileHandle(h)
+		attr := metadata.FileAttr{Type: typ, Mode: 0o755}
+		if typ == metadata.FileTypeRegular {
+			attr.PayloadID = metadata.PayloadID("pid:" + path)
+		}
+		if err := store.PutFile(ctx, &metadata.File{ID: id, ShareName: "/warm", Path: path, FileAttr: attr}); err != nil {
+			t.Fatalf("PutFile %s: %v", path, err)
+		}
+		if err := store.SetParent(ctx, h, parent); err != nil {
+			t.Fatalf("SetParent %s: %v", path, err)
+		}
+		if err := store.SetChild(ctx, parent, filepath.Base(path), h); err != nil {
+			t.Fatalf("SetChild %s: %v", path, err)
+		}
+		return h
+	}
+	renders := put(root, "/renders", metadata.FileTypeDirectory)
+	shot := put(renders, "/renders/shot1", metadata.FileTypeDirectory)
+	put(shot, "/renders/shot1/a.exr", metadata.FileTypeRegular)
+	put(shot, "/renders/shot1/a.txt", metadata.FileTypeRegular)
+	put(renders, "/renders/b.exr", metadata.FileTypeRegular)
+	put(root, "/top.exr", metadata.FileTypeRegular)
+	return store, root
+}
+
+// loadHistory returns a history holding records with the given ages, hottest
+// first.
+func loadHistory(t *testing.T, recs ...engine.AccessRecord) *engine.AccessHistory {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), accessHistoryFile)
+	body, _ := json.Marshal(map[string]any{"saved_at": time.Now(), "entries": recs})
+	if err := os.WriteFile(path, body, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h, err := engine.LoadAccessHistory(path, 0, 0)
+	if err != nil {
+		t.Fatalf("LoadAccessHistory: %v", err)
+	}
+	return h
+}
+
+func TestSelectWarmPayloads(t *testing.T) {
+	ctx := context.Background()
+	store, root := warmTree(t)
+	now := time.Now()
+	history := loadHistory(t,
+		engine.AccessRecord{PayloadID: "pid:/top.exr", Score: 50, LastAccess: now.Add(-time.Hour)},
+		engine.AccessRecord{PayloadID: "pid:/renders/b.exr", Score: 20, LastAccess: now.Add(-10 * 24 * time.Hour)},
+		engine.AccessRecord{PayloadID: "pid:/renders/shot1/a.exr", Score: 5, LastAccess: now.Add(-2 * time.Hour)},
+	)
+
+	tests := []struct {
+		name string
+		sel  block.WarmSelector
+		want []string
+	}{
+		{"glob", block.WarmSelector{Paths: []string{"renders/**/*.exr"}},
+			[]string{"pid:/renders/b.exr", "pid:/renders/shot1/a.exr"}},
+		{"two globs", block.WarmSelector{Paths: []string{"top.exr", "renders/shot1/*.txt"}},
+			[]string{"pid:/renders/shot1/a.txt", "pid:/top.exr"}},
+		{"accessed within", block.WarmSelector{AccessedWithin: "1d"},
+			[]string{"pid:/renders/shot1/a.exr", "pid:/top.exr"}},
+		{"hottest", block.WarmSelector{Hottest: 2},
+			[]string{"pid:/renders/b.exr", "pid:/top.exr"}},
+		{"glob and hottest", block.WarmSelector{Paths: []string{"renders/**"}, Hottest: 1},
+			[]string{"pid:/renders/b.exr"}},
+		{"glob and recency", block.WarmSelector{Paths: []string{"**/*.exr"}, AccessedWithin: "3h"},
+			[]string{"pid:/renders/shot1/a.exr", "pid:/top.exr"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectWarmPayloads(ctx, store, root, tt.sel, history)
+			if err != nil {
+				t.Fatalf("selectWarmPayloads: %v", err)
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
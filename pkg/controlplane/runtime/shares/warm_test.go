@@ -32,7 +32,7 @@ func TestWarmRegistry_DeterministicIDsAndSuccess(t *testing.T) {
 		return warmAllResult{BlocksFetched: 2, BytesFetched: 42}, nil
 	}
 
-	job := r.start("/share", 0, run)
+	job := r.start("/share", nil, 0, run)
 	if job.ID != "warm-1" {
 		t.Fatalf("job ID = %q, want warm-1", job.ID)
 	}
@@ -60,7 +60,7 @@ func TestWarmRegistry_DeterministicIDsAndSuccess(t *testing.T) {
 	}
 
 	// A second start for the same share gets a fresh, monotonic ID.
-	job2 := r.start("/share", 0, func(_ context.Context, _ func(done, total int64)) (warmAllResult, error) {
+	job2 := r.start("/share", nil, 0, func(_ context.Context, _ func(done, total int64)) (warmAllResult, error) {
 		return warmAllResult{}, nil
 	})
 	if job2.ID != "warm-2" {
@@ -76,7 +76,7 @@ func TestWarmRegistry_DeterministicIDsAndSuccess(t *testing.T) {
 
 func TestWarmRegistry_SlashShareYieldsSlashFreeID(t *testing.T) {
 	r := newWarmRegistry()
-	job := r.start("/export", 0, func(_ context.Context, _ func(done, total int64)) (warmAllResult, error) {
+	job := r.start("/export", nil, 0, func(_ context.Context, _ func(done, total int64)) (warmAllResult, error) {
 		return warmAllResult{}, nil
 	})
 	if strings.Contains(job.ID, "/") {
@@ -92,7 +92,7 @@ func TestWarmRegistry_WarnsOnEmptyEnumerationNonEmptyShare(t *testing.T) {
 
 	// Zero enumerable blocks (nothing fetched, nothing already-local) on a
 	// share that reports nonzero stored bytes must set the Warning (#1374).
-	job := r.start("/export", 4096, func(_ context.Context, _ func(done, total int64)) (warmAllResult, error) {
+	job := r.start("/export", nil, 4096, func(_ context.Context, _ func(done, total int64)) (warmAllResult, error) {
 		return warmAllResult{}, nil
 	})
 	waitFor(t, func() bool {
@@ -108,7 +108,7 @@ func TestWarmRegistry_WarnsOnEmptyEnumerationNonEmptyShare(t *testing.T) {
 	}
 
 	// A share that genuinely has no stored bytes must NOT warn.
-	jobEmpty := r.start("/empty", 0, func(_ context.Context, _ func(done, total int64)) (warmAllResult, error) {
+	jobEmpty := r.start("/empty", nil, 0, func(_ context.Context, _ func(done, total int64)) (warmAllResult, error) {
 		return warmAllResult{}, nil
 	})
 	waitFor(t, func() bool {
@@ -121,7 +121,7 @@ func TestWarmRegistry_WarnsOnEmptyEnumerationNonEmptyShare(t *testing.T) {
 	}
 
 	// A run that DID enumerate blocks must not warn even if used bytes > 0.
-	jobOK := r.start("/full", 4096, func(_ context.Context, _ func(done, total int64)) (warmAllResult, error) {
+	jobOK := r.start("/full", nil, 4096, func(_ context.Context, _ func(done, total int64)) (warmAllResult, error) {
 		return warmAllResult{BlocksAlreadyLocal: 3}, nil
 	})
 	waitFor(t, func() bool {
@@ -148,8 +148,8 @@ func TestWarmRegistry_OneActivePerShare(t *testing.T) {
 		return warmAllResult{}, nil
 	}
 
-	job1 := r.start("/share", 0, run)
-	job2 := r.start("/share", 0, run) // should return the running job, not start a new one
+	job1 := r.start("/share", nil, 0, run)
+	job2 := r.start("/share", nil, 0, run) // should return the running job, not start a new one
 	if job1.ID != job2.ID {
 		t.Fatalf("second start got a different job: %q vs %q", job1.ID, job2.ID)
 	}
@@ -177,7 +177,7 @@ func TestWarmRegistry_CancelForShare(t *testing.T) {
 		return warmAllResult{}, ctx.Err()
 	}
 
-	job := r.start("/share", 0, run)
+	job := r.start("/share", nil, 0, run)
 	<-started
 	r.cancelForShare("/share")
 
@@ -195,7 +195,7 @@ func TestWarmRegistry_CancelForShare(t *testing.T) {
 func TestWarmRegistry_Failure(t *testing.T) {
 	r := newWarmRegistry()
 	boom := errors.New("boom")
-	job := r.start("/share", 0, func(_ context.Context, _ func(done, total int64)) (warmAllResult, error) {
+	job := r.start("/share", nil, 0, func(_ context.Context, _ func(done, total int64)) (warmAllResult, error) {
 		return warmAllResult{}, boom
 	})
 	waitFor(t, func() bool {
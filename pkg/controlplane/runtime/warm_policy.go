@@ -0,0 +1,116 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/marmos91/dittofs/pkg/controlplane/runtime/shares"
+)
+
+// warmPolicyTick is how often the warm-policy loop persists access histories
+// and checks for due periodic re-warms.
+const warmPolicyTick = time.Minute
+
+// warmScheduler decides which shares' warm policies are due. It only holds
+// the loop's bookkeeping, so the decisions are testable without a clock.
+type warmScheduler struct {
+	// seen marks shares the loop has already observed, so on_start fires
+	// once per share load (at boot, or when a share is added later).
+	seen map[string]bool
+	// last is the time of each share's last policy-triggered warm.
+	last map[string]time.Time
+}
+
+func newWarmScheduler() *warmScheduler {
+	return &warmScheduler{seen: make(map[string]bool), last: make(map[string]time.Time)}
+}
+
+// due returns the shares whose policies should warm at now: on_start for a
+// share seen for the first time, and interval for a share whose last warm
+// (or first sighting) is at least one interval old.
+func (s *warmScheduler) due(policies map[string]block.WarmPolicy, now time.Time) []string {
+	var out []string
+	for name, p := range policies {
+		first := !s.seen[name]
+		s.seen[name] = true
+		if first {
+			if p.OnStart {
+				out = append(out, name)
+				s.last[name] = now
+				continue
+			}
+			s.last[name] = now
+		}
+		period, err := p.Period()
+		if err != nil || period == 0 {
+			continue
+		}
+		if now.Sub(s.last[name]) >= period {
+			out = append(out, name)
+			s.last[name] = now
+		}
+	}
+	// Forget removed shares so re-adding one counts as a fresh load.
+	for name := range s.seen {
+		if _, ok := policies[name]; !ok {
+			delete(s.seen, name)
+			delete(s.last, name)
+		}
+	}
+	return out
+}
+
+// StartWarmPolicies launches the loop that applies every share's automatic
+// pre-warm policy: on_start warms run on the first pass (right after boot),
+// interval warms on every tick they fall due. Each tick also persists the
+// shares' access histories so a crash loses at most one tick of reads. The
+// loop saves them once more and exits on ctx cancellation.
+func (r *Runtime) StartWarmPolicies(ctx context.Context) {
+	go func() {
+		sched := newWarmScheduler()
+		ticker := time.NewTicker(warmPolicyTick)
+		defer ticker.Stop()
+		for {
+			for _, name := range sched.due(r.sharesSvc.WarmPolicies(), time.Now()) {
+				r.startPolicyWarm(ctx, name, "schedule")
+			}
+			select {
+			case <-ctx.Done():
+				r.sharesSvc.SaveAccessHistories()
+				return
+			case <-ticker.C:
+				r.sharesSvc.SaveAccessHistories()
+			}
+		}
+	}()
+}
+
+// warmAfterEvict re-warms the evicted shares whose policy asks for it. An
+// empty shareName means every share was evicted.
+func (r *Runtime) warmAfterEvict(ctx context.Context, shareName string) {
+	for name, p := range r.sharesSvc.WarmPolicies() {
+		if p.AfterEvict && (shareName == "" || shareName == name) {
+			r.startPolicyWarm(ctx, name, "after_evict")
+		}
+	}
+}
+
+// startPolicyWarm launches a policy-triggered warm of the share's policy
+// selection. Failures are logged; a share with no remote tier is skipped.
+func (r *Runtime) startPolicyWarm(ctx context.Context, name, trigger string) {
+	share, err := r.sharesSvc.GetShare(name)
+	if err != nil || share.BlockStore == nil || !share.BlockStore.HasRemoteStore() {
+		return
+	}
+	job, err := r.sharesSvc.StartWarm(ctx, name, share.WarmPolicy.WarmSelector, r.storesSvc)
+	if err != nil {
+		if !errors.Is(err, shares.ErrShareNotFound) {
+			logger.Warn("warm policy: failed to start warm", "share", name, "trigger", trigger, "error", err)
+		}
+		return
+	}
+	logger.Info("warm policy: warm started", "share", name, "trigger", trigger, "job", job.ID)
+}
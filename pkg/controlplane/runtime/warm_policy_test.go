@@ -0,0 +1,49 @@
+package runtime
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/block"
+)
+
+// TestWarmScheduler_Due covers on_start (once per share load) and interval
+// re-warms, including a share that is removed and added back.
+func TestWarmScheduler_Due(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	policies := map[string]block.WarmPolicy{
+		"/boot":     {OnStart: true},
+		"/hourly":   {Interval: "1h"},
+		"/evict":    {AfterEvict: true},
+		"/boot-hot": {OnStart: true, Interval: "2h"},
+	}
+	s := newWarmScheduler()
+
+	due := func(now time.Time) []string {
+		got := s.due(policies, now)
+		sort.Strings(got)
+		return got
+	}
+	check := func(step string, got []string, want ...string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("%s: due = %v, want %v", step, got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("%s: due = %v, want %v", step, got, want)
+			}
+		}
+	}
+
+	check("boot", due(t0), "/boot", "/boot-hot")
+	check("+1m", due(t0.Add(time.Minute)))
+	check("+1h", due(t0.Add(time.Hour)), "/hourly")
+	check("+2h", due(t0.Add(2*time.Hour)), "/boot-hot", "/hourly")
+
+	delete(policies, "/boot")
+	check("removed", due(t0.Add(2*time.Hour+time.Minute)))
+	policies["/boot"] = block.WarmPolicy{OnStart: true}
+	check("re-added", due(t0.Add(2*time.Hour+2*time.Minute)), "/boot")
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+// SetAccountPolicy sets the password and lockout policy enforced by the
+// credential methods. Safe to call while the store is in use.
+func (s *GORMStore) SetAccountPolicy(p models.AccountPolicy) {
+	s.accountPolicy.Store(&p)
+}
+
+func (s *GORMStore) AccountPolicy() models.AccountPolicy {
+	if p := s.accountPolicy.Load(); p != nil {
+		return *p
+	}
+	return models.AccountPolicy{}
+}
+
+func (s *GORMStore) CheckPasswordPolicy(ctx context.Context, username, password string) error {
+	policy := s.AccountPolicy()
+	if err := policy.CheckPassword(password); err != nil {
+		return err
+	}
+	if policy.PasswordHistory <= 0 || username == "" {
+		return nil
+	}
+
+	user, err := s.GetUser(ctx, username)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	previous := append([]string{user.PasswordHash}, user.PasswordHistory...)
+	if len(previous) > policy.PasswordHistory {
+		previous = previous[:policy.PasswordHistory]
+	}
+	for _, hash := range previous {
+		if hash != "" && models.VerifyPassword(password, hash) {
+			return fmt.Errorf("%w: password was used recently; the last %d passwords cannot be reused",
+				models.ErrPasswordPolicy, policy.PasswordHistory)
+		}
+	}
+	return nil
+}
+
+func (s *GORMStore) RecordLoginFailure(ctx context.Context, username string) (bool, error) {
+	policy := s.AccountPolicy()
+	locked := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.User{}).
+			Where("username = ?", username).
+			Update("failed_logins", gorm.Expr("failed_logins + 1"))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return models.ErrUserNotFound
+		}
+		if policy.LockoutThreshold <= 0 {
+			return nil
+		}
+
+		var user models.User
+		if err := tx.Select("id", "failed_logins").Where("username = ?", username).First(&user).Error; err != nil {
+			return err
+		}
+		if user.FailedLogins < policy.LockoutThreshold {
+			return nil
+		}
+		// Lock, and restart the count so the account gets a full set of
+		// attempts once the lockout expires.
+		until := time.Now().Add(policy.LockoutDuration)
+		locked = true
+		return tx.Model(&models.User{}).
+			Where("id = ?", user.ID).
+			Updates(map[string]any{"failed_logins": 0, "locked_until": until}).Error
+	})
+	return locked, err
+}
+
+func (s *GORMStore) RecordLoginSuccess(ctx context.Context, username string) error {
+	return s.UnlockUser(ctx, username)
+}
+
+func (s *GORMStore) UnlockUser(ctx context.Context, username string) error {
+	res := s.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("username = ?", username).
+		Updates(map[string]any{"failed_logins": 0, "locked_until": nil})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *GORMStore) SetUserTOTP(ctx context.Context, username, secret string, enabled bool) error {
+	res := s.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("username = ?", username).
+		Updates(map[string]any{
+			"totp_secret":    secret,
+			"totp_enabled":   enabled && secret != "",
+			"totp_last_step": 0,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *GORMStore) ConsumeTOTPStep(ctx context.Context, username string, step int64) (bool, error) {
+	res := s.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("username = ? AND totp_last_step < ?", username, step).
+		Update("totp_last_step", step)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// passwordHistory returns the history to keep when current is replaced:
+// current followed by the older hashes, limited so that, together with the
+// new password, the last keep passwords are remembered.
+func passwordHistory(current string, older []string, keep int) []string {
+	if keep <= 1 {
+		return nil
+	}
+	hist := append([]string{current}, older...)
+	if len(hist) > keep-1 {
+		hist = hist[:keep-1]
+	}
+	return hist
+}
@@ -0,0 +1,129 @@
+//go:build integration
+
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func createPasswordUser(t *testing.T, s *GORMStore, username, password string) {
+	t.Helper()
+	hash, nt, err := models.HashPasswordWithNT(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateUser(context.Background(), &models.User{Username: username, PasswordHash: hash, NTHash: nt, Enabled: true, Role: "user"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+}
+
+func TestAccountLockout(t *testing.T) {
+	s := createTestStore(t)
+	ctx := context.Background()
+	s.SetAccountPolicy(models.AccountPolicy{LockoutThreshold: 3, LockoutDuration: time.Hour})
+	createPasswordUser(t, s, "alice", "correct-horse")
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.ValidateCredentials(ctx, "alice", "wrong-password"); !errors.Is(err, models.ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidCredentials", i, err)
+		}
+	}
+	// Locked: even the right password is refused.
+	if _, err := s.ValidateCredentials(ctx, "alice", "correct-horse"); !errors.Is(err, models.ErrAccountLocked) {
+		t.Fatalf("locked account: err = %v, want ErrAccountLocked", err)
+	}
+	user, _ := s.GetUser(ctx, "alice")
+	if !user.IsLockedOut(time.Now()) || user.FailedLogins != 0 {
+		t.Fatalf("locked_until=%v failed=%d", user.LockedUntil, user.FailedLogins)
+	}
+
+	if err := s.UnlockUser(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ValidateCredentials(ctx, "alice", "correct-horse"); err != nil {
+		t.Fatalf("after unlock: %v", err)
+	}
+
+	// An expired lockout unlocks on its own.
+	s.SetAccountPolicy(models.AccountPolicy{LockoutThreshold: 1, LockoutDuration: time.Millisecond})
+	if locked, err := s.RecordLoginFailure(ctx, "alice"); err != nil || !locked {
+		t.Fatalf("RecordLoginFailure: locked=%v err=%v", locked, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := s.ValidateCredentials(ctx, "alice", "correct-horse"); err != nil {
+		t.Fatalf("after lockout expiry: %v", err)
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	s := createTestStore(t)
+	ctx := context.Background()
+	s.SetAccountPolicy(models.AccountPolicy{MinPasswordLength: 12, PasswordHistory: 2, PasswordMaxAge: time.Hour})
+	createPasswordUser(t, s, "bob", "first-password")
+
+	if err := s.CheckPasswordPolicy(ctx, "bob", "short-pw"); !errors.Is(err, models.ErrPasswordPolicy) {
+		t.Errorf("short password: err = %v", err)
+	}
+	if err := s.CheckPasswordPolicy(ctx, "bob", "first-password"); !errors.Is(err, models.ErrPasswordPolicy) {
+		t.Errorf("current password reuse: err = %v", err)
+	}
+
+	hash, nt, _ := models.HashPasswordWithNT("second-password")
+	if err := s.UpdatePassword(ctx, "bob", hash, nt); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CheckPasswordPolicy(ctx, "bob", "first-password"); !errors.Is(err, models.ErrPasswordPolicy) {
+		t.Errorf("previous password reuse: err = %v", err)
+	}
+	hash, nt, _ = models.HashPasswordWithNT("third-password")
+	if err := s.UpdatePasswordAndFlags(ctx, "bob", hash, nt, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CheckPasswordPolicy(ctx, "bob", "first-password"); err != nil {
+		t.Errorf("password older than the history: err = %v", err)
+	}
+
+	// An expired password logs in into a forced change.
+	old := time.Now().Add(-2 * time.Hour)
+	if err := s.db.Model(&models.User{}).Where("username = ?", "bob").Update("password_changed_at", old).Error; err != nil {
+		t.Fatal(err)
+	}
+	user, err := s.ValidateCredentials(ctx, "bob", "third-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.MustChangePassword {
+		t.Error("expired password did not force a change")
+	}
+}
+
+func TestConsumeTOTPStep(t *testing.T) {
+	s := createTestStore(t)
+	ctx := context.Background()
+	createPasswordUser(t, s, "carol", "carol-password")
+	if err := s.SetUserTOTP(ctx, "carol", "JBSWY3DPEHPK3PXP", true); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		step int64
+		want bool
+	}{{100, true}, {100, false}, {99, false}, {101, true}} {
+		got, err := s.ConsumeTOTPStep(ctx, "carol", tt.step)
+		if err != nil || got != tt.want {
+			t.Errorf("ConsumeTOTPStep(%d) = %v, %v; want %v", tt.step, got, err, tt.want)
+		}
+	}
+
+	if err := s.SetUserTOTP(ctx, "carol", "", true); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := s.GetUser(ctx, "carol"); user.TOTPEnabled || user.TOTPSecret != "" {
+		t.Errorf("TOTP still enrolled: %+v", user)
+	}
+}
@@ -510,6 +510,20 @@ func TestDeleteNetgroup_InUse(t *testing.T) {
 	if err != models.ErrNetgroupInUse {
 		t.Errorf("Expected ErrNetgroupInUse, got %v", err)
 	}
+
+	// A reference from a subtree export entry also pins the netgroup
+	nfsOpts.NetgroupID = nil
+	nfsOpts.Exports = []models.NFSSubtreeExport{{Path: "/projects/alpha", NetgroupID: &ngID}}
+	if err := nfsCfg.SetConfig(nfsOpts); err != nil {
+		t.Fatalf("Failed to set NFS config: %v", err)
+	}
+	if err := s.SetShareAdapterConfig(ctx, nfsCfg); err != nil {
+		t.Fatalf("Failed to set adapter config: %v", err)
+	}
+	err = s.DeleteNetgroup(ctx, "in-use-test")
+	if err != models.ErrNetgroupInUse {
+		t.Errorf("Expected ErrNetgroupInUse for subtree export reference, got %v", err)
+	}
 }
 
 func TestAddNetgroupMember_IP(t *testing.T) {
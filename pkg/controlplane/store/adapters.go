@@ -150,6 +150,11 @@ func (s *GORMStore) UpdateNFSAdapterSettings(ctx context.Context, settings *mode
 			"portmapper_register_with_system": settings.PortmapperRegisterWithSystem,
 			"udp_enabled":                     settings.UDPEnabled,
 			"mdns_enabled":                    settings.MDNSEnabled,
+			"idmap_domain":                    settings.IDMapDomain,
+			"idmap_numeric_auth_sys":          settings.IDMapNumericAuthSys,
+			"pnfs_data_servers":               settings.PNFSDataServers,
+			"pnfs_data_server_version":        settings.PNFSDataServerVersion,
+			"rquota_set_quota_enabled":        settings.RQuotaSetQuotaEnabled,
 			"version":                         gorm.Expr("version + 1"),
 			"updated_at":                      time.Now(),
 		})
@@ -193,6 +198,11 @@ func (s *GORMStore) ResetNFSAdapterSettings(ctx context.Context, adapterID strin
 			"portmapper_register_with_system": defaults.PortmapperRegisterWithSystem,
 			"udp_enabled":                     defaults.UDPEnabled,
 			"mdns_enabled":                    defaults.MDNSEnabled,
+			"idmap_domain":                    defaults.IDMapDomain,
+			"idmap_numeric_auth_sys":          defaults.IDMapNumericAuthSys,
+			"pnfs_data_servers":               "",
+			"pnfs_data_server_version":        defaults.PNFSDataServerVersion,
+			"rquota_set_quota_enabled":        defaults.RQuotaSetQuotaEnabled,
 			"version":                         gorm.Expr("version + 1"),
 			"updated_at":                      time.Now(),
 		})
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func (s *GORMStore) CreateAPIToken(ctx context.Context, token *models.APIToken) error {
+	token.CreatedAt = time.Now()
+	token.Scopes = dedup(token.Scopes)
+	_, err := createWithID(s.db, ctx, token, func(t *models.APIToken, id string) { t.ID = id }, token.ID, models.ErrDuplicateAPIToken)
+	return err
+}
+
+func (s *GORMStore) GetAPITokenByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := s.db.WithContext(ctx).Where("hash = ?", hash).First(&token).Error; err != nil {
+		return nil, convertNotFoundError(err, models.ErrAPITokenNotFound)
+	}
+	return &token, nil
+}
+
+func (s *GORMStore) ListAPITokens(ctx context.Context, userID string) ([]*models.APIToken, error) {
+	q := s.db.WithContext(ctx).Order("created_at")
+	if userID != "" {
+		q = q.Where("user_id = ?", userID)
+	}
+	var tokens []*models.APIToken
+	if err := q.Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (s *GORMStore) DeleteAPIToken(ctx context.Context, id string) error {
+	result := s.db.WithContext(ctx).Where("id = ?", id).Delete(&models.APIToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrAPITokenNotFound
+	}
+	return nil
+}
+
+func (s *GORMStore) TouchAPIToken(ctx context.Context, id string, at time.Time) error {
+	return s.db.WithContext(ctx).
+		Model(&models.APIToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
@@ -0,0 +1,105 @@
+//go:build integration
+
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func TestAPITokens(t *testing.T) {
+	s := createTestStore(t)
+	ctx := context.Background()
+
+	account := &models.User{Username: "terraform", PasswordHash: "x", Enabled: true, ServiceAccount: true, Role: "user"}
+	if _, err := s.CreateUser(ctx, account); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	token := &models.APIToken{
+		Name:   "ci",
+		UserID: account.ID,
+		Hash:   "hash-1",
+		Prefix: "dfs_abcdefgh",
+		Scopes: []string{"shares:read", "shares:read", "snapshots:write"},
+	}
+	if err := s.CreateAPIToken(ctx, token); err != nil {
+		t.Fatalf("CreateAPIToken: %v", err)
+	}
+	dup := &models.APIToken{Name: "ci", UserID: account.ID, Hash: "hash-2"}
+	if err := s.CreateAPIToken(ctx, dup); !errors.Is(err, models.ErrDuplicateAPIToken) {
+		t.Fatalf("duplicate name: err = %v, want ErrDuplicateAPIToken", err)
+	}
+
+	got, err := s.GetAPITokenByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("GetAPITokenByHash: %v", err)
+	}
+	if len(got.Scopes) != 2 || got.LastUsedAt != nil {
+		t.Fatalf("token = %+v", got)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	if err := s.TouchAPIToken(ctx, token.ID, now); err != nil {
+		t.Fatalf("TouchAPIToken: %v", err)
+	}
+	got, _ = s.GetAPITokenByHash(ctx, "hash-1")
+	if got.LastUsedAt == nil || !got.LastUsedAt.Equal(now) {
+		t.Fatalf("last used = %v, want %v", got.LastUsedAt, now)
+	}
+
+	list, err := s.ListAPITokens(ctx, account.ID)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListAPITokens = %v, %v", list, err)
+	}
+
+	if err := s.DeleteAPIToken(ctx, token.ID); err != nil {
+		t.Fatalf("DeleteAPIToken: %v", err)
+	}
+	if err := s.DeleteAPIToken(ctx, token.ID); !errors.Is(err, models.ErrAPITokenNotFound) {
+		t.Fatalf("second delete: err = %v", err)
+	}
+	if _, err := s.GetAPITokenByHash(ctx, "hash-1"); !errors.Is(err, models.ErrAPITokenNotFound) {
+		t.Fatalf("revoked token still found: %v", err)
+	}
+}
+
+func TestAPITokens_DeletedWithUser(t *testing.T) {
+	s := createTestStore(t)
+	ctx := context.Background()
+
+	account := &models.User{Username: "ci", PasswordHash: "x", Enabled: true, ServiceAccount: true, Role: "user"}
+	if _, err := s.CreateUser(ctx, account); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateAPIToken(ctx, &models.APIToken{Name: "deploy", UserID: account.ID, Hash: "h"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteUser(ctx, "ci"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetAPITokenByHash(ctx, "h"); !errors.Is(err, models.ErrAPITokenNotFound) {
+		t.Fatalf("token survived user deletion: %v", err)
+	}
+}
+
+func TestValidateCredentials_ServiceAccount(t *testing.T) {
+	s := createTestStore(t)
+	ctx := context.Background()
+
+	hash, err := models.HashPassword("secret-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := &models.User{Username: "bot", PasswordHash: hash, Enabled: true, ServiceAccount: true, Role: "user"}
+	if _, err := s.CreateUser(ctx, account); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ValidateCredentials(ctx, "bot", "secret-password"); !errors.Is(err, models.ErrServiceAccountLogin) {
+		t.Fatalf("err = %v, want ErrServiceAccountLogin", err)
+	}
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func (s *GORMStore) AppendAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	return s.db.WithContext(ctx).Create(event).Error
+}
+
+func (s *GORMStore) ListAuditEvents(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	q := s.db.WithContext(ctx).Order("occurred_at DESC")
+	if !filter.Since.IsZero() {
+		q = q.Where("occurred_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		q = q.Where("occurred_at < ?", filter.Until)
+	}
+	if filter.Actor != "" {
+		q = q.Where("actor = ?", filter.Actor)
+	}
+	if filter.Resource != "" {
+		q = q.Where("resource = ?", filter.Resource)
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	var events []*models.AuditEvent
+	if err := q.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,55 @@
+//go:build integration
+
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func TestAuditEvents(t *testing.T) {
+	s := createTestStore(t)
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	events := []*models.AuditEvent{
+		{Time: base, Actor: "admin", Method: "POST", Path: "/api/v1/shares", Resource: "shares", Status: 201},
+		{Time: base.Add(10 * time.Minute), Actor: "alice", Method: "PUT", Path: "/api/v1/users/bob", Resource: "users", Status: 200,
+			Changes: []string{"role"}},
+		{Time: base.Add(20 * time.Minute), Actor: "admin", Method: "DELETE", Path: "/api/v1/shares/data", Resource: "shares", Status: 204},
+	}
+	for _, ev := range events {
+		if err := s.AppendAuditEvent(ctx, ev); err != nil {
+			t.Fatalf("AppendAuditEvent: %v", err)
+		}
+		if ev.ID == "" {
+			t.Fatal("AppendAuditEvent did not assign an ID")
+		}
+	}
+
+	all, err := s.ListAuditEvents(ctx, models.AuditFilter{})
+	if err != nil || len(all) != 3 {
+		t.Fatalf("ListAuditEvents = %d events, %v", len(all), err)
+	}
+	if all[0].Method != "DELETE" {
+		t.Fatalf("events not newest first: %+v", all[0])
+	}
+
+	since, _ := s.ListAuditEvents(ctx, models.AuditFilter{Since: base.Add(5 * time.Minute)})
+	if len(since) != 2 {
+		t.Fatalf("since filter: got %d events, want 2", len(since))
+	}
+
+	byActor, _ := s.ListAuditEvents(ctx, models.AuditFilter{Actor: "alice"})
+	if len(byActor) != 1 || len(byActor[0].Changes) != 1 || byActor[0].Changes[0] != "role" {
+		t.Fatalf("actor filter: %+v", byActor)
+	}
+
+	shares, _ := s.ListAuditEvents(ctx, models.AuditFilter{Resource: "shares", Limit: 1})
+	if len(shares) != 1 || shares[0].Method != "DELETE" {
+		t.Fatalf("resource+limit filter: %+v", shares)
+	}
+}
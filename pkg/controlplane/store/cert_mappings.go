@@ -0,0 +1,35 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func (s *GORMStore) GetCertMapping(ctx context.Context, name string) (*models.CertMapping, error) {
+	return getByNameOrID[models.CertMapping](s.db, ctx, "name", name, models.ErrCertMappingNotFound)
+}
+
+func (s *GORMStore) ListCertMappings(ctx context.Context) ([]*models.CertMapping, error) {
+	var mappings []*models.CertMapping
+	if err := s.db.WithContext(ctx).Order("priority").Order("name").Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+func (s *GORMStore) CreateCertMapping(ctx context.Context, mapping *models.CertMapping) (string, error) {
+	if err := mapping.Validate(); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	mapping.CreatedAt = now
+	mapping.UpdatedAt = now
+	mapping.Shares = dedup(mapping.Shares)
+	return createWithID(s.db, ctx, mapping, func(m *models.CertMapping, id string) { m.ID = id }, mapping.ID, models.ErrDuplicateCertMapping)
+}
+
+func (s *GORMStore) DeleteCertMapping(ctx context.Context, name string) error {
+	return deleteByField[models.CertMapping](s.db, ctx, "name", name, models.ErrCertMappingNotFound)
+}
@@ -0,0 +1,57 @@
+//go:build integration
+
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func TestCertMappings(t *testing.T) {
+	s := createTestStore(t)
+	ctx := context.Background()
+
+	uid := uint32(2000)
+	build := &models.CertMapping{
+		Name: "build-hosts", Priority: 20, Field: "dns", Pattern: "*.build.internal",
+		Shares: []string{"/builds", "/builds"}, Squash: string(models.SquashAllToGuest), AnonymousUID: &uid,
+	}
+	deploy := &models.CertMapping{Name: "deploy", Priority: 10, Field: "cn", Pattern: "deploy"}
+	for _, m := range []*models.CertMapping{build, deploy} {
+		if _, err := s.CreateCertMapping(ctx, m); err != nil {
+			t.Fatalf("CreateCertMapping(%s): %v", m.Name, err)
+		}
+	}
+	if _, err := s.CreateCertMapping(ctx, &models.CertMapping{Name: "deploy", Field: "cn", Pattern: "x"}); !errors.Is(err, models.ErrDuplicateCertMapping) {
+		t.Fatalf("duplicate: err = %v, want ErrDuplicateCertMapping", err)
+	}
+	if _, err := s.CreateCertMapping(ctx, &models.CertMapping{Name: "bad", Field: "serial", Pattern: "x"}); err == nil {
+		t.Fatal("invalid field accepted")
+	}
+
+	got, err := s.GetCertMapping(ctx, "build-hosts")
+	if err != nil {
+		t.Fatalf("GetCertMapping: %v", err)
+	}
+	if len(got.Shares) != 1 || got.AnonymousUID == nil || *got.AnonymousUID != 2000 {
+		t.Fatalf("stored mapping = %+v", got)
+	}
+
+	list, err := s.ListCertMappings(ctx)
+	if err != nil {
+		t.Fatalf("ListCertMappings: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "deploy" || list[1].Name != "build-hosts" {
+		t.Fatalf("list order = %v, want priority order", list)
+	}
+
+	if err := s.DeleteCertMapping(ctx, "deploy"); err != nil {
+		t.Fatalf("DeleteCertMapping: %v", err)
+	}
+	if err := s.DeleteCertMapping(ctx, "deploy"); !errors.Is(err, models.ErrCertMappingNotFound) {
+		t.Fatalf("second delete: err = %v, want ErrCertMappingNotFound", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/glebarez/sqlite"
@@ -196,6 +197,10 @@ func (c *Config) Validate() error {
 type GORMStore struct {
 	db     *gorm.DB
 	config *Config
+
+	// accountPolicy is the password and lockout policy enforced by the
+	// credential methods (see SetAccountPolicy). Nil means no policy.
+	accountPolicy atomic.Pointer[models.AccountPolicy]
 }
 
 // New creates a new control plane store based on the configuration.
@@ -30,6 +30,10 @@ func (s *GORMStore) ListGroups(ctx context.Context) ([]*models.Group, error) {
 	return listAll[models.Group](s.db, ctx, "Users", "SharePermissions")
 }
 
+func (s *GORMStore) NextFreeGID(ctx context.Context, floor uint32) (uint32, error) {
+	return nextFreeID[models.Group](s.db, ctx, "g_id", floor)
+}
+
 func (s *GORMStore) CreateGroup(ctx context.Context, group *models.Group) (string, error) {
 	group.CreatedAt = time.Now()
 	return createWithID(s.db, ctx, group, func(g *models.Group, id string) { g.ID = id }, group.ID, models.ErrDuplicateGroup)
@@ -45,7 +49,7 @@ func (s *GORMStore) UpdateGroup(ctx context.Context, group *models.Group) error
 	// Update specific fields using Select to handle pointers properly
 	return s.db.WithContext(ctx).
 		Model(&existing).
-		Select("Name", "GID", "Description").
+		Select("Name", "GID", "Description", "SID").
 		Updates(group).Error
 }
 
@@ -67,6 +71,11 @@ func (s *GORMStore) DeleteGroup(ctx context.Context, name string) error {
 			return err
 		}
 
+		// Delete role bindings
+		if err := tx.Where("group_id = ?", group.ID).Delete(&models.RoleBinding{}).Error; err != nil {
+			return err
+		}
+
 		// Remove users from group (GORM handles the join table)
 		if err := tx.Model(group).Association("Users").Clear(); err != nil {
 			return err
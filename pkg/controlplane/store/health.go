@@ -37,6 +37,7 @@ var (
 	_ AdminStore               = (*GORMStore)(nil)
 	_ HealthStore              = (*GORMStore)(nil)
 	_ NetgroupStore            = (*GORMStore)(nil)
+	_ CertMappingStore         = (*GORMStore)(nil)
 	_ IdentityMappingStore     = (*GORMStore)(nil)
 
 	// Assertions for adapter-facing interfaces defined in models package.
@@ -2,7 +2,10 @@ package store
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"math"
 	"strings"
 
 	"github.com/google/uuid"
@@ -131,6 +134,32 @@ func createWithID[T any](db *gorm.DB, ctx context.Context, entity *T, idSetter f
 	return id, nil
 }
 
+// nextFreeID returns one past the highest value of the numeric column of T
+// that is at least floor, or floor when no row is in that range. Values below
+// floor (e.g. hand-assigned ids) are ignored, so allocation never reuses an
+// id and never reaches into the range reserved for them.
+//
+// Example:
+//
+//	uid, err := nextFreeID[models.User](db, ctx, "uid", 100000)
+func nextFreeID[T any](db *gorm.DB, ctx context.Context, column string, floor uint32) (uint32, error) {
+	var highest sql.NullInt64
+	err := db.WithContext(ctx).Model(new(T)).
+		Where(column+" >= ?", floor).
+		Select("MAX(" + column + ")").
+		Scan(&highest).Error
+	if err != nil {
+		return 0, err
+	}
+	if !highest.Valid {
+		return floor, nil
+	}
+	if highest.Int64 >= math.MaxUint32 {
+		return 0, fmt.Errorf("no free %s at or above %d", column, floor)
+	}
+	return uint32(highest.Int64) + 1, nil
+}
+
 // dedup returns a new slice with duplicate strings removed, preserving order.
 func dedup(s []string) []string {
 	seen := make(map[string]struct{}, len(s))
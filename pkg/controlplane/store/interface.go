@@ -41,6 +41,12 @@ type UserStore interface {
 	// Use with caution for large user counts.
 	ListUsers(ctx context.Context) ([]*models.User, error)
 
+	// NextFreeUID returns a UID at or above floor that is higher than every
+	// UID already assigned in that range. The UID is not reserved: a
+	// concurrent CreateUser with the same UID fails with
+	// models.ErrDuplicateUser.
+	NextFreeUID(ctx context.Context, floor uint32) (uint32, error)
+
 	// CreateUser creates a new user.
 	// The user ID will be generated if empty.
 	// Returns the generated ID.
@@ -67,7 +73,8 @@ type UserStore interface {
 	// Also deletes all share permissions for the user.
 	DeleteUser(ctx context.Context, username string) error
 
-	// UpdatePassword updates a user's password hash and NT hash.
+	// UpdatePassword updates a user's password hash and NT hash, keeping the
+	// previous hash in the password history and clearing any lockout.
 	// Returns models.ErrUserNotFound if the user doesn't exist.
 	UpdatePassword(ctx context.Context, username, passwordHash, ntHash string) error
 
@@ -84,8 +91,44 @@ type UserStore interface {
 	// Returns the user if credentials are valid.
 	// Returns models.ErrInvalidCredentials if the credentials are invalid.
 	// Returns models.ErrUserDisabled if the user account is disabled.
+	// Returns models.ErrAccountLocked if the account is locked out.
+	// A wrong password is recorded as a failed login; the caller records
+	// the success with RecordLoginSuccess once every factor has been checked.
+	// An expired password sets MustChangePassword on the returned user.
 	ValidateCredentials(ctx context.Context, username, password string) (*models.User, error)
 
+	// AccountPolicy returns the password and lockout policy in force.
+	AccountPolicy() models.AccountPolicy
+
+	// CheckPasswordPolicy verifies a new password for username against the
+	// account policy, including reuse of the user's previous passwords.
+	// Returns an error wrapping models.ErrPasswordPolicy on a violation.
+	CheckPasswordPolicy(ctx context.Context, username, password string) error
+
+	// RecordLoginFailure counts a failed login and locks the account when the
+	// lockout threshold is reached. Returns whether the account is now locked.
+	// Returns models.ErrUserNotFound if the user doesn't exist.
+	RecordLoginFailure(ctx context.Context, username string) (bool, error)
+
+	// RecordLoginSuccess clears the failed-login count and lockout. Callers
+	// refuse locked accounts before checking their credentials.
+	// Returns models.ErrUserNotFound if the user doesn't exist.
+	RecordLoginSuccess(ctx context.Context, username string) error
+
+	// UnlockUser clears a lockout and the failed-login count.
+	// Returns models.ErrUserNotFound if the user doesn't exist.
+	UnlockUser(ctx context.Context, username string) error
+
+	// SetUserTOTP stores a user's TOTP secret and whether it is required at
+	// login. An empty secret removes the enrollment.
+	// Returns models.ErrUserNotFound if the user doesn't exist.
+	SetUserTOTP(ctx context.Context, username, secret string, enabled bool) error
+
+	// ConsumeTOTPStep records step as the user's last accepted TOTP time
+	// step. Returns false if a code for this or a later step was already
+	// accepted, so a code cannot be replayed.
+	ConsumeTOTPStep(ctx context.Context, username string, step int64) (bool, error)
+
 	// GetGuestUser returns the guest user for a specific share if guest access is enabled.
 	// Returns models.ErrGuestDisabled if guest access is not configured for the share.
 	GetGuestUser(ctx context.Context, shareName string) (*models.User, error)
@@ -115,6 +158,12 @@ type GroupStore interface {
 	// ListGroups returns all groups.
 	ListGroups(ctx context.Context) ([]*models.Group, error)
 
+	// NextFreeGID returns a GID at or above floor that is higher than every
+	// GID already assigned in that range. The GID is not reserved: a
+	// concurrent CreateGroup with the same GID fails with
+	// models.ErrDuplicateGroup.
+	NextFreeGID(ctx context.Context, floor uint32) (uint32, error)
+
 	// CreateGroup creates a new group.
 	// The group ID will be generated if empty.
 	// Returns the generated ID.
@@ -673,6 +722,102 @@ type RestoreMarkerStore interface {
 	DeleteRestoreMarker(ctx context.Context, shareName string) error
 }
 
+// RehomeMarkerStore provides durable remote re-home marker CRUD.
+//
+// A re-home marker records that a share's block data is being moved to another
+// remote block store (dfsctl share rehome). It is written before the share's
+// binding changes and deleted once the move completes; a marker surviving to
+// the next startup makes the server resume the move.
+type RehomeMarkerStore interface {
+	// PutRehomeMarker upserts the per-share marker (keyed by share name).
+	PutRehomeMarker(ctx context.Context, marker *models.RehomeMarker) error
+
+	// GetRehomeMarker returns the marker for shareName, or
+	// models.ErrRehomeMarkerNotFound if none exists.
+	GetRehomeMarker(ctx context.Context, shareName string) (*models.RehomeMarker, error)
+
+	// ListRehomeMarkers returns every re-home marker across all shares.
+	// Returns an empty slice (not nil) when none exist.
+	ListRehomeMarkers(ctx context.Context) ([]*models.RehomeMarker, error)
+
+	// DeleteRehomeMarker removes the marker for shareName. Deleting an
+	// absent marker is not an error (idempotent).
+	DeleteRehomeMarker(ctx context.Context, shareName string) error
+}
+
+// APITokenStore persists service-account API tokens. Tokens are looked up by
+// the SHA-256 hash of their plaintext; the plaintext is never stored.
+type APITokenStore interface {
+	// CreateAPIToken stores a new token. The ID is generated if empty.
+	// Returns models.ErrDuplicateAPIToken if the service account already has
+	// a token with the same name.
+	CreateAPIToken(ctx context.Context, token *models.APIToken) error
+
+	// GetAPITokenByHash returns the token with the given hash, or
+	// models.ErrAPITokenNotFound.
+	GetAPITokenByHash(ctx context.Context, hash string) (*models.APIToken, error)
+
+	// ListAPITokens returns the tokens of the given service account, or of
+	// every account when userID is empty.
+	ListAPITokens(ctx context.Context, userID string) ([]*models.APIToken, error)
+
+	// DeleteAPIToken revokes a token by ID.
+	// Returns models.ErrAPITokenNotFound if it doesn't exist.
+	DeleteAPIToken(ctx context.Context, id string) error
+
+	// TouchAPIToken records a use of the token at the given time.
+	TouchAPIToken(ctx context.Context, id string, at time.Time) error
+}
+
+// RoleStore persists custom roles and their bindings to users and groups.
+type RoleStore interface {
+	// GetRole returns a role by name or ID.
+	// Returns models.ErrRoleNotFound if it doesn't exist.
+	GetRole(ctx context.Context, name string) (*models.Role, error)
+
+	// ListRoles returns all custom roles.
+	ListRoles(ctx context.Context) ([]*models.Role, error)
+
+	// CreateRole creates a role. The ID is generated if empty.
+	// Returns models.ErrDuplicateRole if the name is taken.
+	CreateRole(ctx context.Context, role *models.Role) (string, error)
+
+	// UpdateRole updates a role's description and permissions.
+	// Returns models.ErrRoleNotFound if it doesn't exist.
+	UpdateRole(ctx context.Context, role *models.Role) error
+
+	// DeleteRole deletes a role by name or ID, together with its bindings.
+	// Returns models.ErrRoleNotFound if it doesn't exist.
+	DeleteRole(ctx context.Context, name string) error
+
+	// CreateRoleBinding binds a role to a user or group. The ID is
+	// generated if empty.
+	CreateRoleBinding(ctx context.Context, binding *models.RoleBinding) error
+
+	// ListRoleBindings returns the bindings of a role, or of every role
+	// when roleID is empty.
+	ListRoleBindings(ctx context.Context, roleID string) ([]*models.RoleBinding, error)
+
+	// DeleteRoleBinding deletes a binding by ID.
+	// Returns models.ErrRoleBindingNotFound if it doesn't exist.
+	DeleteRoleBinding(ctx context.Context, id string) error
+
+	// ListRoleBindingsForUser returns the bindings that apply to a user,
+	// directly or through group membership, with Role populated.
+	ListRoleBindingsForUser(ctx context.Context, userID string) ([]*models.RoleBinding, error)
+}
+
+// AuditStore persists the control-plane audit log. The log is append-only:
+// the interface offers no way to update or delete an event.
+type AuditStore interface {
+	// AppendAuditEvent stores an event. The ID is generated if empty and
+	// Time defaults to now.
+	AppendAuditEvent(ctx context.Context, event *models.AuditEvent) error
+
+	// ListAuditEvents returns the events matching filter, newest first.
+	ListAuditEvents(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEvent, error)
+}
+
 // HealthStore provides store health check and lifecycle operations.
 //
 // These methods are used by health check endpoints and graceful shutdown.
@@ -737,6 +882,31 @@ type NetgroupStore interface {
 	GetSharesByNetgroup(ctx context.Context, netgroupName string) ([]*models.Share, error)
 }
 
+// CertMappingStore provides NFS-over-TLS client certificate mapping
+// operations. Like NetgroupStore it is separate from Store so that only
+// NFS-aware components depend on it. Use type assertion to check:
+//
+//	if cs, ok := store.(CertMappingStore); ok { ... }
+type CertMappingStore interface {
+	// GetCertMapping returns a certificate mapping by name or ID.
+	// Returns models.ErrCertMappingNotFound if the mapping doesn't exist.
+	GetCertMapping(ctx context.Context, name string) (*models.CertMapping, error)
+
+	// ListCertMappings returns all certificate mappings in evaluation order
+	// (ascending priority, then name).
+	ListCertMappings(ctx context.Context) ([]*models.CertMapping, error)
+
+	// CreateCertMapping creates a new certificate mapping.
+	// The ID will be generated if empty.
+	// Returns the generated ID.
+	// Returns models.ErrDuplicateCertMapping if a mapping with the same name exists.
+	CreateCertMapping(ctx context.Context, mapping *models.CertMapping) (string, error)
+
+	// DeleteCertMapping deletes a certificate mapping by name.
+	// Returns models.ErrCertMappingNotFound if the mapping doesn't exist.
+	DeleteCertMapping(ctx context.Context, name string) error
+}
+
 // IdentityMappingStore provides identity mapping operations.
 // This is a separate interface from Store so that only identity-aware
 // components need to depend on identity mapping functionality.
@@ -840,6 +1010,10 @@ type Store interface {
 	SnapshotStore
 	SnapshotPolicyStore
 	RestoreMarkerStore
+	RehomeMarkerStore
+	APITokenStore
+	RoleStore
+	AuditStore
 	HealthStore
 	SIDMappingStore
 	IdentityProviderConfigStore
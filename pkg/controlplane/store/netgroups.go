@@ -65,10 +65,12 @@ func (s *GORMStore) DeleteNetgroup(ctx context.Context, name string) error {
 		}
 
 		// Check if any NFS adapter configs reference this netgroup ID in their JSON config.
-		// The netgroup_id is stored inside share_adapter_configs.config JSON blob.
+		// The netgroup_id is stored inside share_adapter_configs.config JSON blob,
+		// either at the top level or inside a subtree export entry.
 		var refCount int64
 		if err := tx.Model(&models.ShareAdapterConfig{}).
-			Where("adapter_type = ? AND "+netgroupIDExpr(s.config.Type), "nfs", netgroup.ID).
+			Where("adapter_type = ? AND ("+netgroupIDExpr(s.config.Type)+" OR config LIKE ?)",
+				"nfs", netgroup.ID, `%"netgroup_id":"`+netgroup.ID+`"%`).
 			Count(&refCount).Error; err != nil {
 			return err
 		}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+// PutRehomeMarker upserts the per-share re-home marker. A re-write for the
+// same share overwrites the remotes, mode and step; CreatedAt is preserved.
+func (s *GORMStore) PutRehomeMarker(ctx context.Context, marker *models.RehomeMarker) error {
+	now := time.Now()
+	if marker.CreatedAt.IsZero() {
+		marker.CreatedAt = now
+	}
+	marker.UpdatedAt = now
+	return s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "share_name"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"source_remote_id", "target_remote_id", "mode", "step", "updated_at",
+			}),
+		}).
+		Create(marker).Error
+}
+
+// GetRehomeMarker returns the re-home marker for shareName, or
+// models.ErrRehomeMarkerNotFound if none exists.
+func (s *GORMStore) GetRehomeMarker(ctx context.Context, shareName string) (*models.RehomeMarker, error) {
+	var marker models.RehomeMarker
+	err := s.db.WithContext(ctx).
+		Where("share_name = ?", shareName).
+		First(&marker).Error
+	if err != nil {
+		return nil, convertNotFoundError(err, models.ErrRehomeMarkerNotFound)
+	}
+	return &marker, nil
+}
+
+// ListRehomeMarkers returns every re-home marker. Used by the startup scan
+// that resumes interrupted re-homes. Returns an empty slice (not nil) when
+// none exist.
+func (s *GORMStore) ListRehomeMarkers(ctx context.Context) ([]*models.RehomeMarker, error) {
+	var markers []*models.RehomeMarker
+	if err := s.db.WithContext(ctx).Find(&markers).Error; err != nil {
+		return nil, err
+	}
+	return markers, nil
+}
+
+// DeleteRehomeMarker removes the re-home marker for shareName. Deleting an
+// absent marker is not an error.
+func (s *GORMStore) DeleteRehomeMarker(ctx context.Context, shareName string) error {
+	return s.db.WithContext(ctx).
+		Where("share_name = ?", shareName).
+		Delete(&models.RehomeMarker{}).Error
+}
@@ -0,0 +1,70 @@
+//go:build integration
+
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func TestRehomeMarker_PutGetListDelete(t *testing.T) {
+	store := createTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	if _, err := store.GetRehomeMarker(ctx, "alpha"); !errors.Is(err, models.ErrRehomeMarkerNotFound) {
+		t.Fatalf("Get absent: err = %v, want ErrRehomeMarkerNotFound", err)
+	}
+
+	m := &models.RehomeMarker{
+		ShareName:      "alpha",
+		SourceRemoteID: "src-1",
+		TargetRemoteID: "dst-1",
+		Mode:           "copy",
+		Step:           models.RehomeStepPending,
+	}
+	if err := store.PutRehomeMarker(ctx, m); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	created := m.CreatedAt
+
+	// Upsert advances the step and keeps CreatedAt.
+	m2 := &models.RehomeMarker{
+		ShareName:      "alpha",
+		SourceRemoteID: "src-1",
+		TargetRemoteID: "dst-1",
+		Mode:           "copy",
+		Step:           models.RehomeStepSwitched,
+	}
+	if err := store.PutRehomeMarker(ctx, m2); err != nil {
+		t.Fatalf("Put upsert: %v", err)
+	}
+	got, err := store.GetRehomeMarker(ctx, "alpha")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Step != models.RehomeStepSwitched || got.TargetRemoteID != "dst-1" {
+		t.Fatalf("Get returned unexpected row: %+v", got)
+	}
+	if !got.CreatedAt.Equal(created) {
+		t.Fatalf("CreatedAt changed on upsert: %v -> %v", created, got.CreatedAt)
+	}
+
+	all, err := store.ListRehomeMarkers(ctx)
+	if err != nil || len(all) != 1 {
+		t.Fatalf("List: %d markers, err = %v", len(all), err)
+	}
+
+	if err := store.DeleteRehomeMarker(ctx, "alpha"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.GetRehomeMarker(ctx, "alpha"); !errors.Is(err, models.ErrRehomeMarkerNotFound) {
+		t.Fatalf("Get after delete: err = %v, want ErrRehomeMarkerNotFound", err)
+	}
+	if err := store.DeleteRehomeMarker(ctx, "alpha"); err != nil {
+		t.Fatalf("Delete idempotent: %v", err)
+	}
+}
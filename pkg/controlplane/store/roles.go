@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func (s *GORMStore) GetRole(ctx context.Context, name string) (*models.Role, error) {
+	return getByNameOrID[models.Role](s.db, ctx, "name", name, models.ErrRoleNotFound)
+}
+
+func (s *GORMStore) ListRoles(ctx context.Context) ([]*models.Role, error) {
+	var roles []*models.Role
+	if err := s.db.WithContext(ctx).Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (s *GORMStore) CreateRole(ctx context.Context, role *models.Role) (string, error) {
+	role.CreatedAt = time.Now()
+	role.Permissions = dedup(role.Permissions)
+	return createWithID(s.db, ctx, role, func(r *models.Role, id string) { r.ID = id }, role.ID, models.ErrDuplicateRole)
+}
+
+func (s *GORMStore) UpdateRole(ctx context.Context, role *models.Role) error {
+	var existing models.Role
+	if err := s.db.WithContext(ctx).Where("id = ?", role.ID).First(&existing).Error; err != nil {
+		return convertNotFoundError(err, models.ErrRoleNotFound)
+	}
+
+	role.Permissions = dedup(role.Permissions)
+	return s.db.WithContext(ctx).
+		Model(&existing).
+		Select("Description", "Permissions").
+		Updates(role).Error
+}
+
+func (s *GORMStore) DeleteRole(ctx context.Context, name string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role, err := getByNameOrID[models.Role](tx, ctx, "name", name, models.ErrRoleNotFound)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.RoleBinding{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(role).Error
+	})
+}
+
+func (s *GORMStore) CreateRoleBinding(ctx context.Context, binding *models.RoleBinding) error {
+	binding.CreatedAt = time.Now()
+	binding.Shares = dedup(binding.Shares)
+	_, err := createWithID(s.db, ctx, binding, func(b *models.RoleBinding, id string) { b.ID = id }, binding.ID, models.ErrDuplicateRoleBinding)
+	return err
+}
+
+func (s *GORMStore) ListRoleBindings(ctx context.Context, roleID string) ([]*models.RoleBinding, error) {
+	q := s.db.WithContext(ctx).Preload("Role").Order("created_at")
+	if roleID != "" {
+		q = q.Where("role_id = ?", roleID)
+	}
+	var bindings []*models.RoleBinding
+	if err := q.Find(&bindings).Error; err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+func (s *GORMStore) DeleteRoleBinding(ctx context.Context, id string) error {
+	return deleteByField[models.RoleBinding](s.db, ctx, "id", id, models.ErrRoleBindingNotFound)
+}
+
+func (s *GORMStore) ListRoleBindingsForUser(ctx context.Context, userID string) ([]*models.RoleBinding, error) {
+	var groupIDs []string
+	if err := s.db.WithContext(ctx).
+		Table("user_groups").
+		Where("user_id = ?", userID).
+		Pluck("group_id", &groupIDs).Error; err != nil {
+		return nil, err
+	}
+
+	q := s.db.WithContext(ctx).Preload("Role").Where("user_id = ?", userID)
+	if len(groupIDs) > 0 {
+		q = q.Or("group_id IN ?", groupIDs)
+	}
+	var bindings []*models.RoleBinding
+	if err := q.Find(&bindings).Error; err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
@@ -0,0 +1,113 @@
+//go:build integration
+
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/marmos91/dittofs/pkg/controlplane/models"
+)
+
+func TestRoles(t *testing.T) {
+	s := createTestStore(t)
+	ctx := context.Background()
+
+	role := &models.Role{Name: "project-lead", Permissions: []string{"quotas:write", "quotas:write", "trash:write"}}
+	if _, err := s.CreateRole(ctx, role); err != nil {
+		t.Fatalf("CreateRole: %v", err)
+	}
+	if _, err := s.CreateRole(ctx, &models.Role{Name: "project-lead"}); !errors.Is(err, models.ErrDuplicateRole) {
+		t.Fatalf("duplicate: err = %v, want ErrDuplicateRole", err)
+	}
+
+	got, err := s.GetRole(ctx, "project-lead")
+	if err != nil {
+		t.Fatalf("GetRole: %v", err)
+	}
+	if len(got.Permissions) != 2 {
+		t.Fatalf("permissions = %v, want deduplicated", got.Permissions)
+	}
+
+	got.Description = "Per-project maintenance"
+	got.Permissions = []string{"snapshots:write"}
+	if err := s.UpdateRole(ctx, got); err != nil {
+		t.Fatalf("UpdateRole: %v", err)
+	}
+	got, _ = s.GetRole(ctx, role.ID)
+	if got.Description != "Per-project maintenance" || len(got.Permissions) != 1 || got.Permissions[0] != "snapshots:write" {
+		t.Fatalf("updated role = %+v", got)
+	}
+
+	if err := s.DeleteRole(ctx, "project-lead"); err != nil {
+		t.Fatalf("DeleteRole: %v", err)
+	}
+	if _, err := s.GetRole(ctx, "project-lead"); !errors.Is(err, models.ErrRoleNotFound) {
+		t.Fatalf("deleted role: err = %v", err)
+	}
+}
+
+func TestRoleBindingsForUser(t *testing.T) {
+	s := createTestStore(t)
+	ctx := context.Background()
+
+	alice := &models.User{Username: "alice", PasswordHash: "x", Enabled: true, Role: "user"}
+	bob := &models.User{Username: "bob", PasswordHash: "x", Enabled: true, Role: "user"}
+	for _, u := range []*models.User{alice, bob} {
+		if _, err := s.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	group := &models.Group{Name: "leads"}
+	if _, err := s.CreateGroup(ctx, group); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddUserToGroup(ctx, "bob", "leads"); err != nil {
+		t.Fatal(err)
+	}
+
+	lead := &models.Role{Name: "lead", Permissions: []string{"quotas:write"}}
+	auditor := &models.Role{Name: "auditor", Permissions: []string{"users:read"}}
+	for _, r := range []*models.Role{lead, auditor} {
+		if _, err := s.CreateRole(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	direct := &models.RoleBinding{RoleID: lead.ID, UserID: alice.ID, Shares: []string{"/projects"}}
+	viaGroup := &models.RoleBinding{RoleID: auditor.ID, GroupID: group.ID}
+	for _, b := range []*models.RoleBinding{direct, viaGroup} {
+		if err := s.CreateRoleBinding(ctx, b); err != nil {
+			t.Fatalf("CreateRoleBinding: %v", err)
+		}
+	}
+
+	got, err := s.ListRoleBindingsForUser(ctx, alice.ID)
+	if err != nil || len(got) != 1 || got[0].Role == nil || got[0].Role.Name != "lead" {
+		t.Fatalf("alice bindings = %+v, %v", got, err)
+	}
+	if len(got[0].Shares) != 1 || got[0].Shares[0] != "/projects" {
+		t.Fatalf("alice shares = %v", got[0].Shares)
+	}
+	got, err = s.ListRoleBindingsForUser(ctx, bob.ID)
+	if err != nil || len(got) != 1 || got[0].Role.Name != "auditor" {
+		t.Fatalf("bob bindings = %+v, %v", got, err)
+	}
+
+	// Deleting the group removes its bindings; deleting a role removes its own.
+	if err := s.DeleteGroup(ctx, "leads"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.ListRoleBindingsForUser(ctx, bob.ID); len(got) != 0 {
+		t.Fatalf("bob bindings after group delete = %+v", got)
+	}
+	if err := s.DeleteRole(ctx, "lead"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.ListRoleBindings(ctx, ""); len(got) != 0 {
+		t.Fatalf("bindings after role delete = %+v", got)
+	}
+	if err := s.DeleteRoleBinding(ctx, direct.ID); !errors.Is(err, models.ErrRoleBindingNotFound) {
+		t.Fatalf("DeleteRoleBinding of removed binding: err = %v", err)
+	}
+}
@@ -97,10 +97,14 @@ func (s *GORMStore) UpdateShare(ctx context.Context, share *models.Share) error
 		"trash_restrict_to_admin":             share.TrashRestrictToAdmin,
 		"trash_max_bytes":                     share.TrashMaxBytes,
 		"trash_exclude_patterns":              share.TrashExcludePatterns,
+		"file_audit_enabled":                  share.FileAuditEnabled,
+		"file_audit_operations":               share.FileAuditOperations,
 		"encrypt_data":                        share.EncryptData,
 		"local_store_size":                    share.LocalStoreSize,
 		"read_buffer_size":                    share.ReadBufferSize,
 		"quota_bytes":                         share.QuotaBytes,
+		"bandwidth":                           share.Bandwidth,
+		"warm_policy":                         share.WarmPolicy,
 		"updated_at":                          share.UpdatedAt,
 	}
 	// Handle remote_block_store_id explicitly: GORM map-based Updates may skip
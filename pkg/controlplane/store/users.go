@@ -31,6 +31,10 @@ func (s *GORMStore) ListUsers(ctx context.Context) ([]*models.User, error) {
 	return listAll[models.User](s.db, ctx, "Groups", "SharePermissions")
 }
 
+func (s *GORMStore) NextFreeUID(ctx context.Context, floor uint32) (uint32, error) {
+	return nextFreeID[models.User](s.db, ctx, "uid", floor)
+}
+
 func (s *GORMStore) CreateUser(ctx context.Context, user *models.User) (string, error) {
 	user.CreatedAt = time.Now()
 	return createWithID(s.db, ctx, user, func(u *models.User, id string) { u.ID = id }, user.ID, models.ErrDuplicateUser)
@@ -148,6 +152,16 @@ func (s *GORMStore) DeleteUser(ctx context.Context, username string) error {
 			return err
 		}
 
+		// Revoke the user's API tokens
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.APIToken{}).Error; err != nil {
+			return err
+		}
+
+		// Delete role bindings
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RoleBinding{}).Error; err != nil {
+			return err
+		}
+
 		// Delete user
 		if err := tx.Delete(user).Error; err != nil {
 			return err
@@ -158,39 +172,35 @@ func (s *GORMStore) DeleteUser(ctx context.Context, username string) error {
 }
 
 func (s *GORMStore) UpdatePassword(ctx context.Context, username, passwordHash, ntHash string) error {
-	result := s.db.WithContext(ctx).
-		Model(&models.User{}).
-		Where("username = ?", username).
-		Updates(map[string]any{
-			"password_hash": passwordHash,
-			"nt_hash":       ntHash,
-		})
-
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return models.ErrUserNotFound
-	}
-	return nil
+	return s.setPassword(ctx, username, &models.User{PasswordHash: passwordHash, NTHash: ntHash})
 }
 
 func (s *GORMStore) UpdatePasswordAndFlags(ctx context.Context, username, passwordHash, ntHash string, mustChangePassword bool) error {
+	return s.setPassword(ctx, username, &models.User{PasswordHash: passwordHash, NTHash: ntHash, MustChangePassword: mustChangePassword}, "MustChangePassword")
+}
+
+// setPassword writes the new password hashes from update, moves the previous
+// hash into the password history and clears any lockout, in one transaction.
+// extra names further columns of update to write.
+func (s *GORMStore) setPassword(ctx context.Context, username string, update *models.User, extra ...string) error {
 	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		result := tx.Model(&models.User{}).
-			Where("username = ?", username).
-			Updates(map[string]any{
-				"password_hash":        passwordHash,
-				"nt_hash":              ntHash,
-				"must_change_password": mustChangePassword,
-			})
-		if result.Error != nil {
-			return result.Error
-		}
-		if result.RowsAffected == 0 {
-			return models.ErrUserNotFound
+		var existing models.User
+		if err := tx.Select("id", "password_hash", "password_history").
+			Where("username = ?", username).First(&existing).Error; err != nil {
+			return convertNotFoundError(err, models.ErrUserNotFound)
 		}
-		return nil
+
+		now := time.Now()
+		update.PasswordChangedAt = &now
+		update.PasswordHistory = passwordHistory(existing.PasswordHash, existing.PasswordHistory, s.AccountPolicy().PasswordHistory)
+		update.FailedLogins = 0
+		update.LockedUntil = nil
+
+		columns := append([]string{"PasswordHash", "NTHash", "PasswordChangedAt", "PasswordHistory", "FailedLogins", "LockedUntil"}, extra...)
+		return tx.Model(&models.User{}).
+			Where("id = ?", existing.ID).
+			Select(columns).
+			Updates(update).Error
 	})
 }
 
@@ -222,10 +232,34 @@ func (s *GORMStore) ValidateCredentials(ctx context.Context, username, password
 		return nil, models.ErrUserDisabled
 	}
 
+	if user.ServiceAccount {
+		return nil, models.ErrServiceAccountLogin
+	}
+
+	// A locked account is refused before the password is checked, so
+	// guessing cannot continue while it is locked.
+	now := time.Now()
+	if user.IsLockedOut(now) {
+		return nil, models.ErrAccountLocked
+	}
+
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		if _, rerr := s.RecordLoginFailure(ctx, user.Username); rerr != nil {
+			return nil, rerr
+		}
 		return nil, models.ErrInvalidCredentials
 	}
 
+	// An expired password still logs in, into the forced password change.
+	if !user.MustChangePassword && s.AccountPolicy().PasswordExpired(user, now) {
+		if err := s.db.WithContext(ctx).Model(&models.User{}).
+			Where("id = ?", user.ID).
+			Update("must_change_password", true).Error; err != nil {
+			return nil, err
+		}
+		user.MustChangePassword = true
+	}
+
 	return user, nil
 }
 
@@ -0,0 +1,122 @@
+package ldap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	ldapv3 "github.com/go-ldap/ldap/v3"
+)
+
+// ErrInvalidCredentials is returned by Authenticate when no user matches the
+// name or the directory rejects the password.
+var ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+
+// BindResult is a user authenticated by a directory bind.
+type BindResult struct {
+	// Username is the account name (sAMAccountName on AD, else UserAttr).
+	Username string
+	// Principal is Username@Realm, or Username when no realm is configured.
+	// It keys the "ldap" identity mapping of a DittoFS user provisioned for
+	// the account, the same form Kerberos principals take.
+	Principal string
+	// DN is the user entry's distinguished name.
+	DN string
+	// DisplayName and Email are informational (displayName/cn, mail).
+	DisplayName string
+	Email       string
+	// UID and GID are the POSIX ids under the configured idmap mode; nil when
+	// the entry carries neither RFC2307 attributes nor an objectSid.
+	UID *uint32
+	GID *uint32
+	// Groups are the DNs of the user's groups (transitive with NestedGroups).
+	Groups []string
+}
+
+// InGroup reports whether the user belongs to group, given either as a full
+// DN or as the value of its leading RDN (e.g. "dittofs-admins" for
+// "CN=dittofs-admins,OU=Groups,DC=example,DC=com"). Case-insensitive, as
+// directory names are.
+func (r *BindResult) InGroup(group string) bool {
+	for _, dn := range r.Groups {
+		if strings.EqualFold(dn, group) || strings.EqualFold(leadingRDNValue(dn), group) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate verifies username and password against the directory: it
+// finds the user entry as the service account, resolves the user's groups,
+// then re-binds the same connection as the user with password. username may
+// be a bare name, DOMAIN\name or name@REALM.
+//
+// Returns ErrInvalidCredentials when no user matches or the bind is refused;
+// any other error is a directory infrastructure failure.
+func (p *Provider) Authenticate(ctx context.Context, username, password string) (*BindResult, error) {
+	// An empty password would be an unauthenticated bind, which many
+	// directories accept.
+	name := sAMAccountNameFromPrincipal(username)
+	if name == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	c, err := p.connect(ctx, p.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: connect/bind: %w", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	attrs := []string{"sAMAccountName", p.cfg.UserAttr, "displayName", "cn", "mail", "uidNumber", "gidNumber", "objectSid", "objectClass", "memberOf"}
+	entry, err := p.findUser(c, p.nameFilter(name), attrs)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Group lookups need the service account's rights, so they run before
+	// the connection is re-bound as the user.
+	groups, err := p.groupDNs(c, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Bind(entry.DN, password); err != nil {
+		if ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("ldap: bind as %s: %w", entry.DN, err)
+	}
+
+	result := &BindResult{
+		Username:    p.entryUsername(entry),
+		Principal:   p.entryUsername(entry),
+		DN:          entry.DN,
+		DisplayName: entry.GetAttributeValue("displayName"),
+		Email:       entry.GetAttributeValue("mail"),
+		Groups:      groups,
+	}
+	if p.cfg.Realm != "" {
+		result.Principal += "@" + p.cfg.Realm
+	}
+	if result.DisplayName == "" {
+		result.DisplayName = entry.GetAttributeValue("cn")
+	}
+	if uid, gid, err := p.deriveUIDGID(entry); err == nil {
+		result.UID, result.GID = &uid, &gid
+	}
+	return result, nil
+}
+
+// leadingRDNValue returns the value of a DN's first RDN, or "" when dn does
+// not parse.
+func leadingRDNValue(dn string) string {
+	parsed, err := ldapv3.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return ""
+	}
+	return parsed.RDNs[0].Attributes[0].Value
+}
@@ -0,0 +1,138 @@
+package ldap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	ldapv3 "github.com/go-ldap/ldap/v3"
+
+	"github.com/marmos91/dittofs/pkg/identity"
+)
+
+// openLDAPConn answers searches like an OpenLDAP directory: posixAccount
+// users matched on uid, no objectSid.
+func openLDAPConn() *fakeConn {
+	return &fakeConn{
+		search: func(req *ldapv3.SearchRequest) ([]*ldapv3.Entry, error) {
+			if strings.Contains(req.Filter, "(objectClass=posixAccount)(uid=carol)") {
+				return []*ldapv3.Entry{entry("uid=carol,ou=people,dc=example,dc=com", map[string][]string{
+					"uid":       {"carol"},
+					"cn":        {"Carol Example"},
+					"mail":      {"carol@example.com"},
+					"uidNumber": {"20001"},
+					"gidNumber": {"20000"},
+					"memberOf":  {"cn=dittofs-admins,ou=groups,dc=example,dc=com"},
+				}, nil)}, nil
+			}
+			return nil, nil
+		},
+	}
+}
+
+func openLDAPCfg() *Config {
+	cfg := baseCfg()
+	cfg.BaseDN = "dc=example,dc=com"
+	cfg.UserAttr = "uid"
+	cfg.UserObjectClass = "posixAccount"
+	cfg.NestedGroups = false
+	return cfg
+}
+
+func TestAuthenticate(t *testing.T) {
+	p := newTestProvider(t, openLDAPCfg(), openLDAPConn())
+
+	res, err := p.Authenticate(context.Background(), "carol", "secret")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if res.Username != "carol" || res.Principal != "carol@DITTOFS.AD" || res.DisplayName != "Carol Example" || res.Email != "carol@example.com" {
+		t.Errorf("result = %+v", res)
+	}
+	if res.UID == nil || *res.UID != 20001 || res.GID == nil || *res.GID != 20000 {
+		t.Errorf("UID/GID = %v/%v, want 20001/20000", res.UID, res.GID)
+	}
+	if !res.InGroup("DittoFS-Admins") || !res.InGroup("cn=dittofs-admins,ou=groups,dc=example,dc=com") {
+		t.Errorf("InGroup(dittofs-admins) = false for groups %v", res.Groups)
+	}
+	if res.InGroup("dittofs-users") {
+		t.Error("InGroup(dittofs-users) = true")
+	}
+}
+
+func TestAuthenticate_InvalidCredentials(t *testing.T) {
+	fc := openLDAPConn()
+	p := newTestProvider(t, openLDAPCfg(), fc)
+
+	if _, err := p.Authenticate(context.Background(), "nobody", "secret"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown user: err = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := p.Authenticate(context.Background(), "carol", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("empty password: err = %v, want ErrInvalidCredentials", err)
+	}
+
+	fc.bindErr = ldapv3.NewError(ldapv3.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	if _, err := p.Authenticate(context.Background(), "carol", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password: err = %v, want ErrInvalidCredentials", err)
+	}
+
+	fc.bindErr = ldapv3.NewError(ldapv3.LDAPResultUnavailable, errors.New("busy"))
+	if _, err := p.Authenticate(context.Background(), "carol", "secret"); err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("directory failure: err = %v, want an infrastructure error", err)
+	}
+}
+
+// Two entries matching the login name must not bind as either of them.
+func TestAuthenticate_AmbiguousUser(t *testing.T) {
+	fc := &fakeConn{
+		search: func(req *ldapv3.SearchRequest) ([]*ldapv3.Entry, error) {
+			if req.SizeLimit != 2 {
+				t.Errorf("SizeLimit = %d, want 2", req.SizeLimit)
+			}
+			return []*ldapv3.Entry{
+				entry("uid=carol,ou=people,dc=example,dc=com", map[string][]string{"uid": {"carol"}}, nil),
+				entry("uid=carol,ou=contractors,dc=example,dc=com", map[string][]string{"uid": {"carol"}}, nil),
+			}, nil
+		},
+	}
+	p := newTestProvider(t, openLDAPCfg(), fc)
+
+	if _, err := p.Authenticate(context.Background(), "carol", "secret"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("ambiguous user: err = %v, want ErrInvalidCredentials", err)
+	}
+	res, err := p.Resolve(context.Background(), &identity.Credential{ExternalID: "carol"})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if res.Found {
+		t.Errorf("ambiguous user resolved as %q", res.Username)
+	}
+}
+
+func TestResolve_MatchUsernameForUnknownSID(t *testing.T) {
+	cred := &identity.Credential{
+		ExternalID: "S-1-5-21-1-2-3-1104",
+		Attributes: map[string]string{"username": "carol"},
+	}
+
+	p := newTestProvider(t, openLDAPCfg(), openLDAPConn())
+	res, err := p.Resolve(context.Background(), cred)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if res.Found {
+		t.Fatal("SID resolved by name without match_username")
+	}
+
+	cfg := openLDAPCfg()
+	cfg.MatchUsername = true
+	p = newTestProvider(t, cfg, openLDAPConn())
+	res, err = p.Resolve(context.Background(), cred)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if !res.Found || res.Username != "carol" || res.UID != 20001 || res.GID != 20000 {
+		t.Errorf("resolved = %+v, want carol 20001/20000", res)
+	}
+}
@@ -36,6 +36,7 @@ const (
 const (
 	DefaultTimeout         = 10 * time.Second
 	DefaultUserAttr        = "sAMAccountName"
+	DefaultUserObjectClass = "user"
 	defaultGroupNameAttr   = "cn"
 	DefaultMaxGroupResults = 200
 )
@@ -98,6 +99,18 @@ type Config struct {
 	// (default "sAMAccountName" for AD).
 	UserAttr string `mapstructure:"user_attr" yaml:"user_attr,omitempty"`
 
+	// UserObjectClass is the objectClass of user entries matched by name
+	// (default "user" for AD). Set it to e.g. "posixAccount" or
+	// "inetOrgPerson" for a non-AD directory such as OpenLDAP.
+	UserObjectClass string `mapstructure:"user_object_class" yaml:"user_object_class,omitempty"`
+
+	// MatchUsername resolves a SID the directory does not hold by the
+	// credential's account name instead. It is for non-AD directories (no
+	// objectSid) whose account names match the domain's, so SMB users
+	// authenticated by NETLOGON pass-through get their POSIX identity from
+	// the directory. Only enable it when both name spaces are the same.
+	MatchUsername bool `mapstructure:"match_username" yaml:"match_username,omitempty"`
+
 	// Realm is the expected AD realm/domain (e.g. "EXAMPLE.COM"). Used to match
 	// "user@REALM" principal credentials and stamped on the resolved Domain.
 	Realm string `mapstructure:"realm" yaml:"realm"`
@@ -157,6 +170,9 @@ func (c *Config) ApplyDefaults() {
 	if c.UserAttr == "" {
 		c.UserAttr = DefaultUserAttr
 	}
+	if c.UserObjectClass == "" {
+		c.UserObjectClass = DefaultUserObjectClass
+	}
 	if c.Idmap == "" {
 		c.Idmap = IdmapRFC2307
 	}
@@ -210,6 +226,9 @@ func (c *Config) Validate() error {
 	if c.UserAttr != "" && !ldapAttrNameRe.MatchString(c.UserAttr) {
 		return fmt.Errorf("ldap.user_attr %q is not a valid LDAP attribute name", c.UserAttr)
 	}
+	if c.UserObjectClass != "" && !ldapAttrNameRe.MatchString(c.UserObjectClass) {
+		return fmt.Errorf("ldap.user_object_class %q is not a valid LDAP object class name", c.UserObjectClass)
+	}
 	if c.MaxGroupResults < 0 {
 		return fmt.Errorf("ldap.max_group_results must be >= 0 (got %d)", c.MaxGroupResults)
 	}
@@ -168,30 +168,27 @@ func (p *Provider) Resolve(ctx context.Context, cred *identity.Credential) (*ide
 		return nil, err
 	}
 
-	attrs := []string{"sAMAccountName", "uidNumber", "gidNumber", "objectSid", "objectClass", "primaryGroupID", "memberOf", "distinguishedName"}
-	req := ldapv3.NewSearchRequest(
-		p.cfg.BaseDN,
-		ldapv3.ScopeWholeSubtree, ldapv3.NeverDerefAliases, 2, int(p.cfg.Timeout.Seconds()), false,
-		filter, attrs, nil,
-	)
-	res, err := c.Search(req)
+	attrs := []string{"sAMAccountName", p.cfg.UserAttr, "uidNumber", "gidNumber", "objectSid", "objectClass", "primaryGroupID", "memberOf", "distinguishedName"}
+	entry, err := p.findUser(c, filter, attrs)
 	if err != nil {
-		return nil, fmt.Errorf("ldap: user search: %w", err)
+		return nil, err
 	}
-	if len(res.Entries) == 0 {
-		logger.Debug("ldap: no user matched", "filter", filter, "base", p.cfg.BaseDN)
-		return &identity.ResolvedIdentity{Found: false}, nil
+	// A SID the directory does not hold (a non-AD directory has no objectSid)
+	// falls back to the account name the authenticator reported, when
+	// configured (MatchUsername).
+	if entry == nil && p.cfg.MatchUsername && strings.HasPrefix(cred.ExternalID, "S-1-") {
+		if name := cred.Attributes["username"]; name != "" {
+			entry, err = p.findUser(c, p.nameFilter(name), attrs)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
-	if len(res.Entries) > 1 {
-		// SizeLimit caps this at 2, so >1 means the lookup is ambiguous. The
-		// first entry is used but the resolved identity is non-deterministic —
-		// surface it rather than silently picking one.
-		logger.Warn("ldap: ambiguous user lookup — multiple entries matched, using first",
-			"filter", filter, "base", p.cfg.BaseDN, "count", len(res.Entries))
+	if entry == nil {
+		return &identity.ResolvedIdentity{Found: false}, nil
 	}
-	entry := res.Entries[0]
 
-	username := entry.GetAttributeValue("sAMAccountName")
+	username := p.entryUsername(entry)
 	uid, gid, err := p.deriveUIDGID(entry)
 	if err != nil {
 		return nil, err
@@ -265,7 +262,7 @@ func (p *Provider) ResolvePrincipalSID(ctx context.Context, name string, isGroup
 	// Users are matched on the configured name attribute (UserAttr); groups are
 	// matched on sAMAccountName, the AD group-name attribute — UserAttr may be a
 	// user-only attribute (e.g. "uid") that group objects do not carry.
-	objectClass, nameAttr := "user", p.cfg.UserAttr
+	objectClass, nameAttr := p.cfg.UserObjectClass, p.cfg.UserAttr
 	if isGroup {
 		objectClass, nameAttr = "group", "sAMAccountName"
 	}
@@ -574,7 +571,52 @@ func (p *Provider) userFilter(cred *identity.Credential) (string, error) {
 	if name == "" {
 		name = cred.ExternalID
 	}
-	return fmt.Sprintf("(&(objectClass=user)(%s=%s))", p.cfg.UserAttr, ldapv3.EscapeFilter(name)), nil
+	return p.nameFilter(name), nil
+}
+
+// nameFilter matches a user object by its bare account name on the configured
+// UserAttr and UserObjectClass.
+func (p *Provider) nameFilter(name string) string {
+	return fmt.Sprintf("(&(objectClass=%s)(%s=%s))", p.cfg.UserObjectClass, p.cfg.UserAttr, ldapv3.EscapeFilter(name))
+}
+
+// findUser runs a user search and returns its single entry, or nil when
+// nothing or more than one entry matched.
+func (p *Provider) findUser(c conn, filter string, attrs []string) (*ldapv3.Entry, error) {
+	req := ldapv3.NewSearchRequest(
+		p.cfg.BaseDN,
+		ldapv3.ScopeWholeSubtree, ldapv3.NeverDerefAliases, 2, int(p.cfg.Timeout.Seconds()), false,
+		filter, attrs, nil,
+	)
+	res, err := c.Search(req)
+	if err != nil {
+		// More than SizeLimit matches is as ambiguous as two.
+		if res == nil || !ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultSizeLimitExceeded) {
+			return nil, fmt.Errorf("ldap: user search: %w", err)
+		}
+	}
+	switch len(res.Entries) {
+	case 1:
+		return res.Entries[0], nil
+	case 0:
+		logger.Debug("ldap: no user matched", "filter", filter, "base", p.cfg.BaseDN)
+	default:
+		// Picking one of several matches would bind (or map) as whichever
+		// entry the server happened to return first, so an ambiguous lookup
+		// matches nobody.
+		logger.Warn("ldap: ambiguous user lookup, multiple entries matched; refusing",
+			"filter", filter, "base", p.cfg.BaseDN)
+	}
+	return nil, nil
+}
+
+// entryUsername returns a user entry's account name: sAMAccountName on AD,
+// else the configured UserAttr (e.g. "uid" on OpenLDAP).
+func (p *Provider) entryUsername(entry *ldapv3.Entry) string {
+	if name := entry.GetAttributeValue("sAMAccountName"); name != "" {
+		return name
+	}
+	return entry.GetAttributeValue(p.cfg.UserAttr)
 }
 
 // isGroupEntry reports whether a directory entry is a group object (objectClass
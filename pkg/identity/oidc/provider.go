@@ -0,0 +1,119 @@
+// Package oidc provides an OpenID Connect identity provider for DittoFS.
+//
+// It resolves OIDC principals, written "issuer|subject" (e.g.,
+// "https://sso.example.com|00u1abcd"), to DittoFS users via two strategies in
+// order:
+//  1. Explicit mapping from the LinkStore (admin-configured principal → username)
+//  2. Optional username matching: the token's username claim, carried in the
+//     credential's "username" attribute, looked up as a DittoFS username
+package oidc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/marmos91/dittofs/pkg/identity"
+)
+
+const ProviderName = "oidc"
+
+// AttrUsername is the credential attribute carrying the token's username
+// claim (e.g., preferred_username) for username matching.
+const AttrUsername = "username"
+
+// Provider resolves OIDC principals to DittoFS users.
+// Implements identity.IdentityProvider.
+type Provider struct {
+	store         identity.LinkStore
+	userLookup    identity.UserLookup
+	matchUsername bool
+}
+
+// New creates an OIDC identity provider.
+//
+// Parameters:
+//   - store: link store for explicit principal → username mappings (may be nil)
+//   - userLookup: callback to resolve a DittoFS username to UID/GID
+//   - matchUsername: fall back to the token's username claim when no explicit
+//     mapping exists. Only safe when the IdP controls that claim (users
+//     cannot edit it), otherwise a user could claim another's account.
+func New(store identity.LinkStore, userLookup identity.UserLookup, matchUsername bool) *Provider {
+	return &Provider{
+		store:         store,
+		userLookup:    userLookup,
+		matchUsername: matchUsername,
+	}
+}
+
+// ExternalID returns the principal of the subject sub at issuer.
+func ExternalID(issuer, subject string) string {
+	return issuer + "|" + subject
+}
+
+func (p *Provider) Name() string { return ProviderName }
+
+func (p *Provider) CanResolve(cred *identity.Credential) bool {
+	if cred.Provider != "" {
+		return cred.Provider == ProviderName
+	}
+	issuer, subject := parsePrincipal(cred.ExternalID)
+	return issuer != "" && subject != ""
+}
+
+// Resolve maps an OIDC principal to a DittoFS user.
+//
+// Resolution order:
+//  1. Explicit mapping in LinkStore: ("oidc", "issuer|subject") → username
+//  2. Username matching (when enabled): the "username" attribute looked up
+//     as a DittoFS username via userLookup
+//  3. Found=false if unmapped
+//
+// The subject is never used as a username: subjects are opaque and stable,
+// and only an administrator's link (or the IdP-controlled username claim)
+// ties one to a DittoFS account.
+func (p *Provider) Resolve(ctx context.Context, cred *identity.Credential) (*identity.ResolvedIdentity, error) {
+	issuer, _ := parsePrincipal(cred.ExternalID)
+
+	if p.store != nil {
+		username, found, err := p.store.GetLink(ctx, ProviderName, cred.ExternalID)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			resolved, err := p.userLookup(ctx, username)
+			if err != nil {
+				return nil, err
+			}
+			if resolved != nil && resolved.Found {
+				out := *resolved
+				out.Domain = issuer
+				return &out, nil
+			}
+		}
+	}
+
+	name := cred.Attributes[AttrUsername]
+	if !p.matchUsername || name == "" {
+		return &identity.ResolvedIdentity{Found: false}, nil
+	}
+	resolved, err := p.userLookup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if resolved == nil || !resolved.Found {
+		return &identity.ResolvedIdentity{Found: false}, nil
+	}
+	out := *resolved
+	out.Domain = issuer
+	return &out, nil
+}
+
+// parsePrincipal splits "issuer|subject" at the first separator: issuers are
+// URLs and never contain "|", but subjects are opaque.
+func parsePrincipal(principal string) (issuer, subject string) {
+	idx := strings.Index(principal, "|")
+	if idx < 0 {
+		return "", ""
+	}
+	return principal[:idx], principal[idx+1:]
+}
@@ -0,0 +1,103 @@
+package oidc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/marmos91/dittofs/pkg/identity"
+)
+
+const issuer = "https://sso.example.com"
+
+func aliceLookup(_ context.Context, username string) (*identity.ResolvedIdentity, error) {
+	users := map[string]*identity.ResolvedIdentity{
+		"alice": {Username: "alice", UID: 1000, GID: 1000, Found: true},
+		"bob":   {Username: "bob", UID: 2000, GID: 2000, Found: true},
+	}
+	if r, ok := users[username]; ok {
+		return r, nil
+	}
+	return &identity.ResolvedIdentity{Found: false}, nil
+}
+
+type memLinkStore struct {
+	links map[string]string // "provider|externalID" -> username
+}
+
+func (m *memLinkStore) GetLink(_ context.Context, provider, externalID string) (string, bool, error) {
+	if u, ok := m.links[provider+"|"+externalID]; ok {
+		return u, true, nil
+	}
+	return "", false, nil
+}
+
+func (m *memLinkStore) ListLinks(context.Context, string) ([]identity.IdentityLink, error) {
+	return nil, nil
+}
+func (m *memLinkStore) CreateLink(context.Context, identity.IdentityLink) error { return nil }
+func (m *memLinkStore) DeleteLink(context.Context, string, string) error        { return nil }
+func (m *memLinkStore) ListLinksForUser(context.Context, string) ([]identity.IdentityLink, error) {
+	return nil, nil
+}
+
+func TestProvider_Resolve(t *testing.T) {
+	store := &memLinkStore{links: map[string]string{
+		"oidc|" + ExternalID(issuer, "00u1"): "alice",
+	}}
+
+	tests := []struct {
+		name          string
+		matchUsername bool
+		subject       string
+		username      string
+		want          string
+	}{
+		{"explicit link", false, "00u1", "", "alice"},
+		{"link beats username claim", true, "00u1", "bob", "alice"},
+		{"unlinked without matching", false, "00u2", "bob", ""},
+		{"username matching", true, "00u2", "bob", "bob"},
+		{"unknown username", true, "00u2", "carol", ""},
+		{"no username claim", true, "00u2", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(store, aliceLookup, tt.matchUsername)
+			got, err := p.Resolve(context.Background(), &identity.Credential{
+				Provider:   ProviderName,
+				ExternalID: ExternalID(issuer, tt.subject),
+				Attributes: map[string]string{AttrUsername: tt.username},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if got.Found {
+					t.Fatalf("expected not found, got %+v", got)
+				}
+				return
+			}
+			if !got.Found || got.Username != tt.want || got.Domain != issuer {
+				t.Fatalf("expected %s@%s, got %+v", tt.want, issuer, got)
+			}
+		})
+	}
+}
+
+func TestProvider_CanResolve(t *testing.T) {
+	p := New(nil, aliceLookup, false)
+	tests := []struct {
+		cred identity.Credential
+		want bool
+	}{
+		{identity.Credential{Provider: "oidc", ExternalID: "anything"}, true},
+		{identity.Credential{Provider: "kerberos", ExternalID: issuer + "|00u1"}, false},
+		{identity.Credential{ExternalID: issuer + "|00u1"}, true},
+		{identity.Credential{ExternalID: "alice@EXAMPLE.COM"}, false},
+		{identity.Credential{ExternalID: "|00u1"}, false},
+	}
+	for _, tt := range tests {
+		if got := p.CanResolve(&tt.cred); got != tt.want {
+			t.Errorf("CanResolve(%+v) = %v, want %v", tt.cred, got, tt.want)
+		}
+	}
+}
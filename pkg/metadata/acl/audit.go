@@ -0,0 +1,40 @@
+package acl
+
+// AuditMatch evaluates the audit ACEs of a (its SACL, plus any AUDIT ACEs an
+// NFSv4 client placed directly in the ACE list) for one access attempt.
+//
+// hasAudit reports whether the ACL carries any effective audit ACE at all;
+// when false the caller falls back to its own policy. matched reports whether
+// an audit ACE selects this attempt: its principal matches the requester, its
+// mask shares at least one bit with accessMask, and it carries
+// SUCCESSFUL_ACCESS (success=true) or FAILED_ACCESS (success=false). This is
+// the MS-DTYP §2.4.4.10 SYSTEM_AUDIT_ACE rule Windows applies before emitting
+// Security event 4663.
+//
+// Inherit-only ACEs do not apply to the object itself and are skipped. ALARM
+// ACEs are never evaluated (Windows does not implement them either).
+func AuditMatch(a *ACL, evalCtx *EvaluateContext, accessMask uint32, success bool) (hasAudit, matched bool) {
+	if a == nil || accessMask == 0 {
+		return false, false
+	}
+	want := uint32(ACE4_FAILED_ACCESS_ACE_FLAG)
+	if success {
+		want = ACE4_SUCCESSFUL_ACCESS_ACE_FLAG
+	}
+	for _, list := range [][]ACE{a.SACL, a.ACEs} {
+		for i := range list {
+			ace := &list[i]
+			if ace.Type != ACE4_SYSTEM_AUDIT_ACE_TYPE || ace.IsInheritOnly() {
+				continue
+			}
+			hasAudit = true
+			if ace.Flag&want == 0 || ace.AccessMask&accessMask == 0 {
+				continue
+			}
+			if aceMatchesWhoWithOwnerRights(ace, evalCtx, false) {
+				return true, true
+			}
+		}
+	}
+	return hasAudit, false
+}
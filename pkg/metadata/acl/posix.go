@@ -0,0 +1,394 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// POSIX ACL entry tags (acl_tag_t). The values match the Linux kernel and the
+// NFSACL side protocol wire encoding, so protocol adapters can carry them
+// through unchanged.
+const (
+	POSIXTagUserObj  = 0x01 // owning user (ACL_USER_OBJ)
+	POSIXTagUser     = 0x02 // named user (ACL_USER)
+	POSIXTagGroupObj = 0x04 // owning group (ACL_GROUP_OBJ)
+	POSIXTagGroup    = 0x08 // named group (ACL_GROUP)
+	POSIXTagMask     = 0x10 // group-class upper bound (ACL_MASK)
+	POSIXTagOther    = 0x20 // everyone else (ACL_OTHER)
+)
+
+// ErrInvalidPOSIXACL is returned when a POSIX ACL is not well formed: a
+// missing or duplicated USER_OBJ/GROUP_OBJ/OTHER entry, a missing MASK
+// alongside named entries, a duplicated named entry, an unknown tag, or a
+// permission outside rwx.
+var ErrInvalidPOSIXACL = errors.New("invalid POSIX ACL")
+
+// POSIXEntry is a single POSIX ACL entry. ID is only meaningful for the named
+// POSIXTagUser and POSIXTagGroup tags; Perm is an rwx triplet (0-7).
+type POSIXEntry struct {
+	Tag  uint32
+	ID   uint32
+	Perm uint32
+}
+
+// ValidatePOSIXACL checks that entries form a well-formed POSIX ACL as
+// defined by POSIX.1e: exactly one USER_OBJ, GROUP_OBJ and OTHER entry, at
+// most one MASK (required when any named entry is present), and no duplicate
+// named user or group. An empty slice is not a valid ACL.
+func ValidatePOSIXACL(entries []POSIXEntry) error {
+	var userObj, groupObj, other, mask, named int
+	users := make(map[uint32]bool)
+	groups := make(map[uint32]bool)
+
+	for _, e := range entries {
+		if e.Perm&^0x7 != 0 {
+			return fmt.Errorf("%w: permission %#o out of range", ErrInvalidPOSIXACL, e.Perm)
+		}
+		switch e.Tag {
+		case POSIXTagUserObj:
+			userObj++
+		case POSIXTagGroupObj:
+			groupObj++
+		case POSIXTagOther:
+			other++
+		case POSIXTagMask:
+			mask++
+		case POSIXTagUser:
+			if users[e.ID] {
+				return fmt.Errorf("%w: duplicate entry for user %d", ErrInvalidPOSIXACL, e.ID)
+			}
+			users[e.ID] = true
+			named++
+		case POSIXTagGroup:
+			if groups[e.ID] {
+				return fmt.Errorf("%w: duplicate entry for group %d", ErrInvalidPOSIXACL, e.ID)
+			}
+			groups[e.ID] = true
+			named++
+		default:
+			return fmt.Errorf("%w: unknown tag %#x", ErrInvalidPOSIXACL, e.Tag)
+		}
+	}
+
+	if userObj != 1 || groupObj != 1 || other != 1 {
+		return fmt.Errorf("%w: need exactly one user::, group:: and other:: entry", ErrInvalidPOSIXACL)
+	}
+	if mask > 1 || (named > 0 && mask == 0) {
+		return fmt.Errorf("%w: named entries require exactly one mask:: entry", ErrInvalidPOSIXACL)
+	}
+	return nil
+}
+
+// POSIXFromMode returns the minimal three-entry access ACL equivalent to the
+// permission bits of mode.
+func POSIXFromMode(mode uint32) []POSIXEntry {
+	return []POSIXEntry{
+		{Tag: POSIXTagUserObj, Perm: (mode >> 6) & 0x7},
+		{Tag: POSIXTagGroupObj, Perm: (mode >> 3) & 0x7},
+		{Tag: POSIXTagOther, Perm: mode & 0x7},
+	}
+}
+
+// POSIXModeBits returns the 9-bit permission mode implied by an access ACL:
+// the owner bits come from USER_OBJ, the group bits from MASK when present
+// (otherwise GROUP_OBJ), and the other bits from OTHER — the same projection
+// POSIX.1e applies to stat(2) st_mode.
+func POSIXModeBits(entries []POSIXEntry) uint32 {
+	var owner, group, other, mask uint32
+	hasMask := false
+	for _, e := range entries {
+		switch e.Tag {
+		case POSIXTagUserObj:
+			owner = e.Perm
+		case POSIXTagGroupObj:
+			group = e.Perm
+		case POSIXTagOther:
+			other = e.Perm
+		case POSIXTagMask:
+			mask, hasMask = e.Perm, true
+		}
+	}
+	if hasMask {
+		group = mask
+	}
+	return (owner << 6) | (group << 3) | other
+}
+
+// IsMinimalPOSIXACL reports whether entries carry no information beyond the
+// mode bits: no named user or group entries.
+func IsMinimalPOSIXACL(entries []POSIXEntry) bool {
+	for _, e := range entries {
+		if e.Tag == POSIXTagUser || e.Tag == POSIXTagGroup {
+			return false
+		}
+	}
+	return true
+}
+
+// SortPOSIXEntries orders entries canonically: by tag (USER_OBJ, USER,
+// GROUP_OBJ, GROUP, MASK, OTHER), then by id within the named tags.
+func SortPOSIXEntries(entries []POSIXEntry) {
+	slices.SortFunc(entries, func(a, b POSIXEntry) int {
+		if a.Tag != b.Tag {
+			return int(a.Tag) - int(b.Tag)
+		}
+		switch {
+		case a.ID < b.ID:
+			return -1
+		case a.ID > b.ID:
+			return 1
+		}
+		return 0
+	})
+}
+
+// posixInheritFlags marks the ACEs translated from a default ACL: they apply
+// only to children created in the directory, never to the directory itself.
+const posixInheritFlags = ACE4_FILE_INHERIT_ACE | ACE4_DIRECTORY_INHERIT_ACE | ACE4_INHERIT_ONLY_ACE
+
+// POSIXToACL translates a POSIX access ACL and (for directories) default ACL
+// into the internal ACL model, following the POSIX-draft to NFSv4 mapping
+// Linux nfsd uses: each entry becomes an ALLOW ACE, and a DENY ACE follows
+// wherever a broader class evaluated later (group class, EVERYONE@) would
+// otherwise grant bits POSIX withholds — so the owner, named users and the
+// group class keep POSIX's first-matching-class semantics under ordered ACE
+// evaluation. MASK is folded into the named and GROUP_OBJ entries it bounds.
+//
+// Named groups carry ACE4_IDENTIFIER_GROUP so ACLToPOSIX can tell them from
+// named users; both use the "{id}@localdomain" form the evaluator matches
+// numerically.
+//
+// ACEs of existing whose principal POSIX cannot name (SYSTEM@,
+// ADMINISTRATORS@, "sid:" principals, named strings) are preserved ahead of the
+// translated entries, along with the SACL and the Protected flag, so a setfacl
+// from an NFSv3 client does not strip grants made over SMB.
+//
+// Both entry slices must already pass ValidatePOSIXACL; dflt may be empty.
+func POSIXToACL(access, dflt []POSIXEntry, isDirectory bool, existing *ACL) *ACL {
+	var aces []ACE
+	result := &ACL{Source: ACLSourcePOSIXExplicit}
+
+	if existing != nil {
+		for _, ace := range existing.ACEs {
+			if _, ok := posixKeyFor(&ace); !ok {
+				aces = append(aces, ace)
+			}
+		}
+		result.Protected = existing.Protected
+		if existing.SACL != nil {
+			result.SACL = slices.Clone(existing.SACL)
+		}
+	}
+
+	aces = append(aces, posixEntriesToACEs(access, 0, isDirectory)...)
+	if isDirectory && len(dflt) > 0 {
+		aces = append(aces, posixEntriesToACEs(dflt, posixInheritFlags, isDirectory)...)
+	}
+
+	result.ACEs = aces
+	return result
+}
+
+// posixEntriesToACEs translates one validated POSIX ACL into ordered ACEs,
+// each carrying flag.
+func posixEntriesToACEs(entries []POSIXEntry, flag uint32, isDirectory bool) []ACE {
+	var userObj, groupObj, other, mask uint32
+	hasMask := false
+	var users, groups []POSIXEntry
+	for _, e := range entries {
+		switch e.Tag {
+		case POSIXTagUserObj:
+			userObj = e.Perm
+		case POSIXTagGroupObj:
+			groupObj = e.Perm
+		case POSIXTagOther:
+			other = e.Perm
+		case POSIXTagMask:
+			mask, hasMask = e.Perm, true
+		case POSIXTagUser:
+			users = append(users, e)
+		case POSIXTagGroup:
+			groups = append(groups, e)
+		}
+	}
+
+	effective := func(perm uint32) uint32 {
+		if hasMask {
+			return perm & mask
+		}
+		return perm
+	}
+
+	groupClass := effective(groupObj)
+	for _, g := range groups {
+		groupClass |= effective(g.Perm)
+	}
+
+	var aces []ACE
+	allow := func(who string, groupFlag, accessMask uint32) {
+		aces = append(aces, ACE{Type: ACE4_ACCESS_ALLOWED_ACE_TYPE, Flag: flag | groupFlag, AccessMask: accessMask, Who: who})
+	}
+	// deny withholds the rwx bits a later, broader ACE would grant but perm
+	// does not. Nothing is emitted when there is nothing to withhold.
+	deny := func(who string, groupFlag, perm, later uint32) {
+		if bits := later &^ perm; bits != 0 {
+			aces = append(aces, ACE{Type: ACE4_ACCESS_DENIED_ACE_TYPE, Flag: flag | groupFlag, AccessMask: rwxToMask(bits), Who: who})
+		}
+	}
+
+	// Owner: USER_OBJ only, never the group class or other.
+	allow(SpecialOwner, 0, rwxToFullMask(userObj, isDirectory)|alwaysGrantedMask)
+	deny(SpecialOwner, 0, userObj, groupClass|other)
+
+	// Named users: their entry bounded by the mask, never the group class or other.
+	for _, u := range users {
+		who := LocalDomainPrincipal(u.ID)
+		allow(who, 0, rwxToFullMask(effective(u.Perm), isDirectory))
+		deny(who, 0, effective(u.Perm), groupClass|other)
+	}
+
+	// Group class: the union of every matching group entry, so all ALLOWs
+	// precede the DENYs that stop other from leaking in.
+	if perm := effective(groupObj); perm != 0 {
+		allow(SpecialGroup, 0, rwxToFullMask(perm, isDirectory))
+	}
+	for _, g := range groups {
+		allow(LocalDomainPrincipal(g.ID), ACE4_IDENTIFIER_GROUP, rwxToFullMask(effective(g.Perm), isDirectory))
+	}
+	deny(SpecialGroup, 0, effective(groupObj), other)
+	for _, g := range groups {
+		deny(LocalDomainPrincipal(g.ID), ACE4_IDENTIFIER_GROUP, effective(g.Perm), other)
+	}
+
+	if other != 0 {
+		allow(SpecialEveryone, 0, rwxToFullMask(other, isDirectory))
+	}
+	return aces
+}
+
+// ACLToPOSIX projects an ACL onto a POSIX access ACL and, for directories, a
+// default ACL built from its inheritable ACEs. A nil ACL yields the minimal
+// access ACL for mode and no default ACL.
+//
+// Only OWNER@, GROUP@, EVERYONE@ and "{id}@localdomain" principals have a
+// POSIX form; other ACEs are left out. Each entry's permission is what its
+// ALLOW ACEs grant, less bits an earlier DENY for the same principal
+// withheld. When named entries are present, MASK is the union of the
+// group-class permissions, so every entry's effective permission equals the
+// one stored.
+//
+// The returned entries are in canonical order. dflt is nil when the ACL has
+// no inheritable POSIX-representable ACE.
+func ACLToPOSIX(a *ACL, mode uint32, isDirectory bool) (access, dflt []POSIXEntry) {
+	if a == nil {
+		return POSIXFromMode(mode), nil
+	}
+
+	access, _ = collectPOSIXEntries(a.ACEs, func(ace *ACE) bool {
+		return !ace.IsInheritOnly()
+	})
+	if isDirectory {
+		var found bool
+		dflt, found = collectPOSIXEntries(a.ACEs, func(ace *ACE) bool {
+			return ace.Flag&(ACE4_FILE_INHERIT_ACE|ACE4_DIRECTORY_INHERIT_ACE) != 0
+		})
+		if !found {
+			dflt = nil
+		}
+	}
+	return access, dflt
+}
+
+// posixKey identifies the POSIX entry an ACE principal maps to.
+type posixKey struct {
+	tag uint32
+	id  uint32
+}
+
+// posixKeyFor maps an ACE principal to its POSIX entry, or false when POSIX
+// has no way to name it.
+func posixKeyFor(ace *ACE) (posixKey, bool) {
+	switch ace.Who {
+	case SpecialOwner:
+		return posixKey{tag: POSIXTagUserObj}, true
+	case SpecialGroup:
+		return posixKey{tag: POSIXTagGroupObj}, true
+	case SpecialEveryone:
+		return posixKey{tag: POSIXTagOther}, true
+	}
+	id, ok := parseLocalDomainID(ace.Who)
+	if !ok {
+		return posixKey{}, false
+	}
+	if ace.Flag&ACE4_IDENTIFIER_GROUP != 0 {
+		return posixKey{tag: POSIXTagGroup, id: id}, true
+	}
+	return posixKey{tag: POSIXTagUser, id: id}, true
+}
+
+// collectPOSIXEntries walks the allow/deny ACEs selected by include and
+// builds a canonical POSIX ACL from them. found reports whether any selected
+// ACE had a POSIX form.
+func collectPOSIXEntries(aces []ACE, include func(*ACE) bool) (entries []POSIXEntry, found bool) {
+	type bits struct{ allowed, denied uint32 }
+	perms := make(map[posixKey]*bits)
+	var order []posixKey
+
+	for i := range aces {
+		ace := &aces[i]
+		if ace.Type != ACE4_ACCESS_ALLOWED_ACE_TYPE && ace.Type != ACE4_ACCESS_DENIED_ACE_TYPE {
+			continue
+		}
+		if !include(ace) {
+			continue
+		}
+		key, ok := posixKeyFor(ace)
+		if !ok {
+			continue
+		}
+		b := perms[key]
+		if b == nil {
+			b = &bits{}
+			perms[key] = b
+			order = append(order, key)
+		}
+		// First match wins per bit, as in ACE evaluation.
+		rwx := maskToRWX(ace.AccessMask)
+		if ace.Type == ACE4_ACCESS_ALLOWED_ACE_TYPE {
+			b.allowed |= rwx &^ b.denied
+		} else {
+			b.denied |= rwx &^ b.allowed
+		}
+	}
+
+	perm := func(key posixKey) uint32 {
+		if b := perms[key]; b != nil {
+			return b.allowed
+		}
+		return 0
+	}
+
+	entries = []POSIXEntry{
+		{Tag: POSIXTagUserObj, Perm: perm(posixKey{tag: POSIXTagUserObj})},
+		{Tag: POSIXTagGroupObj, Perm: perm(posixKey{tag: POSIXTagGroupObj})},
+		{Tag: POSIXTagOther, Perm: perm(posixKey{tag: POSIXTagOther})},
+	}
+	groupClass := entries[1].Perm
+	named := false
+	for _, key := range order {
+		if key.tag != POSIXTagUser && key.tag != POSIXTagGroup {
+			continue
+		}
+		p := perm(key)
+		entries = append(entries, POSIXEntry{Tag: key.tag, ID: key.id, Perm: p})
+		groupClass |= p
+		named = true
+	}
+	if named {
+		entries = append(entries, POSIXEntry{Tag: POSIXTagMask, Perm: groupClass})
+	}
+
+	SortPOSIXEntries(entries)
+	return entries, len(order) > 0
+}
@@ -0,0 +1,198 @@
+package acl
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestValidatePOSIXACL(t *testing.T) {
+	valid := []POSIXEntry{
+		{Tag: POSIXTagUserObj, Perm: 7},
+		{Tag: POSIXTagUser, ID: 1001, Perm: 6},
+		{Tag: POSIXTagGroupObj, Perm: 5},
+		{Tag: POSIXTagMask, Perm: 7},
+		{Tag: POSIXTagOther, Perm: 0},
+	}
+	if err := ValidatePOSIXACL(valid); err != nil {
+		t.Fatalf("ValidatePOSIXACL(valid) = %v", err)
+	}
+	if err := ValidatePOSIXACL(POSIXFromMode(0o640)); err != nil {
+		t.Fatalf("ValidatePOSIXACL(minimal) = %v", err)
+	}
+
+	cases := map[string][]POSIXEntry{
+		"empty":          nil,
+		"missing other":  valid[:4],
+		"named no mask":  {valid[0], valid[1], valid[2], valid[4]},
+		"duplicate user": append(slices.Clone(valid), POSIXEntry{Tag: POSIXTagUser, ID: 1001, Perm: 4}),
+		"bad perm":       {{Tag: POSIXTagUserObj, Perm: 8}, valid[2], valid[4]},
+		"unknown tag":    append(POSIXFromMode(0o644), POSIXEntry{Tag: 0x40}),
+	}
+	for name, entries := range cases {
+		if err := ValidatePOSIXACL(entries); !errors.Is(err, ErrInvalidPOSIXACL) {
+			t.Errorf("%s: err = %v, want ErrInvalidPOSIXACL", name, err)
+		}
+	}
+}
+
+func TestPOSIXModeBits(t *testing.T) {
+	if got := POSIXModeBits(POSIXFromMode(0o754)); got != 0o754 {
+		t.Errorf("minimal mode = %#o, want 0754", got)
+	}
+	withMask := []POSIXEntry{
+		{Tag: POSIXTagUserObj, Perm: 6},
+		{Tag: POSIXTagGroupObj, Perm: 4},
+		{Tag: POSIXTagGroup, ID: 50, Perm: 6},
+		{Tag: POSIXTagMask, Perm: 6},
+		{Tag: POSIXTagOther, Perm: 0},
+	}
+	if got := POSIXModeBits(withMask); got != 0o660 {
+		t.Errorf("mode with mask = %#o, want 0660 (group bits from mask)", got)
+	}
+}
+
+func TestPOSIXToACL_RoundTrip(t *testing.T) {
+	access := []POSIXEntry{
+		{Tag: POSIXTagUserObj, Perm: 7},
+		{Tag: POSIXTagUser, ID: 1001, Perm: 6},
+		{Tag: POSIXTagGroupObj, Perm: 5},
+		{Tag: POSIXTagGroup, ID: 50, Perm: 4},
+		{Tag: POSIXTagMask, Perm: 7},
+		{Tag: POSIXTagOther, Perm: 1},
+	}
+	dflt := []POSIXEntry{
+		{Tag: POSIXTagUserObj, Perm: 7},
+		{Tag: POSIXTagGroupObj, Perm: 5},
+		{Tag: POSIXTagOther, Perm: 0},
+	}
+
+	a := POSIXToACL(access, dflt, true, nil)
+	if err := ValidateACL(a); err != nil {
+		t.Fatalf("ValidateACL: %v", err)
+	}
+	if a.Source != ACLSourcePOSIXExplicit {
+		t.Errorf("Source = %q, want %q", a.Source, ACLSourcePOSIXExplicit)
+	}
+
+	// The mask equals the group-class union, so it round-trips exactly.
+	gotAccess, gotDefault := ACLToPOSIX(a, 0, true)
+	if !slices.Equal(gotAccess, access) {
+		t.Errorf("access = %+v, want %+v", gotAccess, access)
+	}
+	if !slices.Equal(gotDefault, dflt) {
+		t.Errorf("default = %+v, want %+v", gotDefault, dflt)
+	}
+}
+
+func TestPOSIXToACL_MaskBoundsGroupClass(t *testing.T) {
+	access := []POSIXEntry{
+		{Tag: POSIXTagUserObj, Perm: 7},
+		{Tag: POSIXTagUser, ID: 1001, Perm: 7},
+		{Tag: POSIXTagGroupObj, Perm: 7},
+		{Tag: POSIXTagMask, Perm: 4},
+		{Tag: POSIXTagOther, Perm: 0},
+	}
+	a := POSIXToACL(access, nil, false, nil)
+
+	named := &EvaluateContext{UID: 1001, GID: 1001, FileOwnerUID: 0, FileOwnerGID: 100}
+	if !Evaluate(a, named, ACE4_READ_DATA) {
+		t.Error("named user should keep read")
+	}
+	if Evaluate(a, named, ACE4_WRITE_DATA) {
+		t.Error("mask must withhold write from the named user")
+	}
+
+	got, _ := ACLToPOSIX(a, 0, false)
+	want := []POSIXEntry{
+		{Tag: POSIXTagUserObj, Perm: 7},
+		{Tag: POSIXTagUser, ID: 1001, Perm: 4},
+		{Tag: POSIXTagGroupObj, Perm: 4},
+		{Tag: POSIXTagMask, Perm: 4},
+		{Tag: POSIXTagOther, Perm: 0},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("access = %+v, want %+v", got, want)
+	}
+}
+
+func TestPOSIXToACL_ClassSemantics(t *testing.T) {
+	// Mode 0604: the owning group gets nothing even though other may read.
+	a := POSIXToACL(POSIXFromMode(0o604), nil, false, nil)
+
+	member := &EvaluateContext{UID: 2000, GID: 100, FileOwnerUID: 1000, FileOwnerGID: 100}
+	if Evaluate(a, member, ACE4_READ_DATA) {
+		t.Error("group member must not inherit other's read")
+	}
+	outsider := &EvaluateContext{UID: 3000, GID: 300, FileOwnerUID: 1000, FileOwnerGID: 100}
+	if !Evaluate(a, outsider, ACE4_READ_DATA) {
+		t.Error("other should read")
+	}
+
+	// A named user with no permissions is denied despite other's grant.
+	access := []POSIXEntry{
+		{Tag: POSIXTagUserObj, Perm: 6},
+		{Tag: POSIXTagUser, ID: 3000, Perm: 0},
+		{Tag: POSIXTagGroupObj, Perm: 4},
+		{Tag: POSIXTagMask, Perm: 4},
+		{Tag: POSIXTagOther, Perm: 4},
+	}
+	a = POSIXToACL(access, nil, false, nil)
+	if Evaluate(a, outsider, ACE4_READ_DATA) {
+		t.Error("user:3000:--- must deny read")
+	}
+	got, _ := ACLToPOSIX(a, 0, false)
+	if !slices.Contains(got, POSIXEntry{Tag: POSIXTagUser, ID: 3000, Perm: 0}) {
+		t.Errorf("access = %+v, want the empty named entry kept", got)
+	}
+}
+
+func TestPOSIXToACL_PreservesNonPOSIXPrincipals(t *testing.T) {
+	existing := SynthesizeFromMode(0o755, 1000, 100, false)
+	existing.Protected = true
+	existing.SACL = []ACE{{Type: ACE4_SYSTEM_AUDIT_ACE_TYPE, AccessMask: ACE4_WRITE_DATA, Who: SpecialEveryone}}
+
+	a := POSIXToACL(POSIXFromMode(0o700), nil, false, existing)
+
+	if findACE(a.ACEs, ACE4_ACCESS_ALLOWED_ACE_TYPE, SpecialSystem) == nil ||
+		findACE(a.ACEs, ACE4_ACCESS_ALLOWED_ACE_TYPE, SpecialAdministrators) == nil {
+		t.Error("SYSTEM@/ADMINISTRATORS@ ACEs must survive SETACL")
+	}
+	if findACE(a.ACEs, ACE4_ACCESS_ALLOWED_ACE_TYPE, SpecialEveryone) != nil {
+		t.Error("EVERYONE@ grant from the old ACL must be replaced")
+	}
+	if !a.Protected || len(a.SACL) != 1 {
+		t.Errorf("Protected/SACL not preserved: %+v", a)
+	}
+}
+
+func TestACLToPOSIX_NilACLUsesMode(t *testing.T) {
+	access, dflt := ACLToPOSIX(nil, 0o751, true)
+	if !slices.Equal(access, POSIXFromMode(0o751)) {
+		t.Errorf("access = %+v, want minimal ACL for 0751", access)
+	}
+	if dflt != nil {
+		t.Errorf("default = %+v, want nil", dflt)
+	}
+}
+
+func TestACLToPOSIX_NamedGroupFlag(t *testing.T) {
+	a := &ACL{ACEs: []ACE{
+		{Type: ACE4_ACCESS_ALLOWED_ACE_TYPE, AccessMask: rwxToMask(7), Who: SpecialOwner},
+		{Type: ACE4_ACCESS_ALLOWED_ACE_TYPE, AccessMask: rwxToMask(4), Who: LocalDomainPrincipal(42)},
+		{Type: ACE4_ACCESS_ALLOWED_ACE_TYPE, Flag: ACE4_IDENTIFIER_GROUP, AccessMask: rwxToMask(6), Who: LocalDomainPrincipal(42)},
+	}}
+
+	access, _ := ACLToPOSIX(a, 0, false)
+	want := []POSIXEntry{
+		{Tag: POSIXTagUserObj, Perm: 7},
+		{Tag: POSIXTagUser, ID: 42, Perm: 4},
+		{Tag: POSIXTagGroupObj, Perm: 0},
+		{Tag: POSIXTagGroup, ID: 42, Perm: 6},
+		{Tag: POSIXTagMask, Perm: 6},
+		{Tag: POSIXTagOther, Perm: 0},
+	}
+	if !slices.Equal(access, want) {
+		t.Errorf("access = %+v, want %+v", access, want)
+	}
+}
@@ -147,3 +147,45 @@ func TestAuditFlags_RoundTrip(t *testing.T) {
 		t.Errorf("Windows->NFSv4 audit flags = 0x%x, want 0x%x", back, nfs)
 	}
 }
+
+// TestAuditMatch verifies SACL audit ACEs select attempts by principal, mask
+// and success/failure flag, and that an ACL without audit ACEs reports
+// hasAudit=false so the caller can fall back to its own policy.
+func TestAuditMatch(t *testing.T) {
+	a := &ACL{
+		ACEs: []ACE{{Type: ACE4_ACCESS_ALLOWED_ACE_TYPE, AccessMask: ACE4_READ_DATA, Who: SpecialEveryone}},
+		SACL: []ACE{
+			{Type: ACE4_SYSTEM_AUDIT_ACE_TYPE, Flag: ACE4_FAILED_ACCESS_ACE_FLAG, AccessMask: ACE4_READ_DATA, Who: SpecialEveryone},
+			{Type: ACE4_SYSTEM_AUDIT_ACE_TYPE, Flag: ACE4_SUCCESSFUL_ACCESS_ACE_FLAG, AccessMask: ACE4_DELETE, Who: "sid:S-1-5-21-1-2-3-1001"},
+			{Type: ACE4_SYSTEM_AUDIT_ACE_TYPE, Flag: ACE4_SUCCESSFUL_ACCESS_ACE_FLAG | ACE4_INHERIT_ONLY_ACE, AccessMask: ACE4_WRITE_DATA, Who: SpecialEveryone},
+		},
+	}
+	alice := &EvaluateContext{UID: 1001, SID: "S-1-5-21-1-2-3-1001"}
+	bob := &EvaluateContext{UID: 1002, SID: "S-1-5-21-1-2-3-1002"}
+
+	tests := []struct {
+		name    string
+		ctx     *EvaluateContext
+		mask    uint32
+		success bool
+		want    bool
+	}{
+		{"failed read by anyone", bob, ACE4_READ_DATA, false, true},
+		{"successful read not audited", bob, ACE4_READ_DATA, true, false},
+		{"delete by alice", alice, ACE4_DELETE, true, true},
+		{"delete by bob", bob, ACE4_DELETE, true, false},
+		{"inherit-only ACE skipped", alice, ACE4_WRITE_DATA, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasAudit, matched := AuditMatch(a, tt.ctx, tt.mask, tt.success)
+			if !hasAudit || matched != tt.want {
+				t.Errorf("AuditMatch = (%v, %v), want (true, %v)", hasAudit, matched, tt.want)
+			}
+		})
+	}
+
+	if hasAudit, _ := AuditMatch(&ACL{ACEs: a.ACEs}, alice, ACE4_READ_DATA, true); hasAudit {
+		t.Error("ACL without audit ACEs reported hasAudit")
+	}
+}
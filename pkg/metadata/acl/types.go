@@ -24,6 +24,7 @@ const (
 	ACE4_INHERIT_ONLY_ACE           = 0x00000008
 	ACE4_SUCCESSFUL_ACCESS_ACE_FLAG = 0x00000010
 	ACE4_FAILED_ACCESS_ACE_FLAG     = 0x00000020
+	ACE4_IDENTIFIER_GROUP           = 0x00000040
 	ACE4_INHERITED_ACE              = 0x00000080
 )
 
@@ -158,6 +159,10 @@ const (
 	// ACLSourceNFSExplicit indicates the ACL was set explicitly via NFSv4.
 	ACLSourceNFSExplicit ACLSource = "nfs-explicit"
 
+	// ACLSourcePOSIXExplicit indicates the ACL was set explicitly as a POSIX
+	// access/default ACL pair (NFSv3 NFSACL SETACL). See POSIXToACL.
+	ACLSourcePOSIXExplicit ACLSource = "posix-explicit"
+
 	// ACLSourceWindowsDefault indicates the ACL was synthesized as the
 	// Windows default (owner + SYSTEM FullControl, no inherit flags). Used
 	// by SMB read-side when a file has no stored ACL and no inheritable
@@ -177,19 +182,20 @@ const (
 // The ACEs/Source/Protected/AutoInherited/NullDACL fields describe the
 // DACL (discretionary ACL) — the access-control portion that drives
 // permission evaluation. SACL holds the system ACL (audit/alarm ACEs)
-// which is never consulted for access decisions; it is preserved purely
-// for round-trip fidelity with Windows clients that SET and QUERY the
-// SACL_SECURITY_INFORMATION section (MS-DTYP §2.4.6). A nil SACL means
-// "no SACL stored" and is backward compatible with existing data — the
-// SMB read side emits an empty SACL stub in that case, matching prior
-// behavior exactly.
+// which is never consulted for access decisions; it is preserved for
+// round-trip fidelity with Windows clients that SET and QUERY the
+// SACL_SECURITY_INFORMATION section (MS-DTYP §2.4.6), and its audit ACEs
+// select events for the file-access audit trail (see AuditMatch). A nil
+// SACL means "no SACL stored" and is backward compatible with existing
+// data — the SMB read side emits an empty SACL stub in that case, matching
+// prior behavior exactly.
 type ACL struct {
 	ACEs          []ACE     `json:"aces"`
 	Source        ACLSource `json:"source,omitempty"`         // How this ACL was created
 	Protected     bool      `json:"protected,omitempty"`      // SE_DACL_PROTECTED - blocks inheritance
 	AutoInherited bool      `json:"auto_inherited,omitempty"` // SE_DACL_AUTO_INHERITED — DACL was auto-inherited from parent
 	NullDACL      bool      `json:"null_dacl,omitempty"`      // Windows null DACL — no DACL present (everyone full access)
-	SACL          []ACE     `json:"sacl,omitempty"`           // System ACL (audit/alarm ACEs); preserved for round-trip, not used for access checks
+	SACL          []ACE     `json:"sacl,omitempty"`           // System ACL (audit/alarm ACEs); not used for access checks
 }
 
 // isSpecialWho reports whether who is one of the special identifiers:
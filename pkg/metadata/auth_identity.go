@@ -31,6 +31,11 @@ type AuthContext struct {
 	// Format: "IP:port" or just "IP"
 	ClientAddr string
 
+	// Protocol names the protocol the request arrived on ("nfs3", "nfs4",
+	// "smb"). Informational only: it is recorded in file-access audit events
+	// and never used for permission decisions. Empty for internal operations.
+	Protocol string
+
 	// ShareReadOnly indicates whether the user has read-only access to the share.
 	// This is the PER-USER share permission, resolved from the user's share grant
 	// (NFS auth_helper / SMB tree-connect), and is independent of the store-level
@@ -62,6 +67,15 @@ type AuthContext struct {
 	// of this flag.
 	WriteAuthorizedByHandle bool
 
+	// LayoutGrant is set by the NFS data-server path when the request carries
+	// a verified pNFS Flex Files layout credential (RFC 8435 Section 2.2)
+	// instead of a user identity. It authorizes reads of Handle, and writes
+	// when the layout was issued for LAYOUTIOMODE4_RW, regardless of the
+	// file's POSIX mode or ACL: the metadata server already checked the
+	// client's open against both before issuing the layout. Both read-only
+	// ceilings still apply. Nil for every other request.
+	LayoutGrant *LayoutGrant
+
 	// LockClientID is the lock-layer client identifier used for lease exclusion.
 	// This must match the LockOwner.ClientID format used when acquiring leases.
 	// For SMB: "smb:<sessionID>", for NFS: the NFS client identifier.
@@ -116,6 +130,13 @@ type AuthContext struct {
 	BypassTraverseChecking bool
 }
 
+// LayoutGrant is the access a verified pNFS layout credential carries: the
+// single file it was issued for and whether it permits writes.
+type LayoutGrant struct {
+	Handle FileHandle
+	Write  bool
+}
+
 // NewSystemAuthContext creates an AuthContext for internal/system operations
 // that bypass normal authentication (e.g., durable handle scavenger cleanup).
 // Uses UID 0 (root) with full write permissions.
@@ -1,6 +1,7 @@
 package metadata
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"net"
@@ -302,6 +303,23 @@ func (s *Service) checkFilePermissionsFile(ctx *AuthContext, handle FileHandle,
 	// the write falls through to calculatePermissions, which strips write for a
 	// read-only share.
 	shareReadOnly := shareOpts != nil && shareOpts.ReadOnly
+
+	// pNFS layout credential: the data-server request is authorized by the
+	// layout, not by an identity, so it gets exactly what the layout carries
+	// on exactly the file it was issued for -- read, plus write for an RW
+	// layout on a writable share -- and nothing else (no delete, no
+	// attribute or ownership changes).
+	if g := ctx.LayoutGrant; g != nil {
+		if !bytes.Equal(g.Handle, handle) {
+			return 0, nil
+		}
+		granted := requested & PermissionRead
+		if g.Write && !ctx.ShareReadOnly && !shareReadOnly {
+			granted |= requested & PermissionWrite
+		}
+		return granted, nil
+	}
+
 	if ctx.WriteAuthorizedByHandle && !ctx.ShareReadOnly && !shareReadOnly && !acl.HasExplicitDeny(file.ACL) {
 		// Only grant write-related permissions via the handle authorization.
 		// Read permissions still go through normal calculatePermissions checks.
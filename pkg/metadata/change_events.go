@@ -0,0 +1,183 @@
+package metadata
+
+import (
+	"time"
+
+	"github.com/marmos91/dittofs/internal/logger"
+)
+
+// ChangeEventType names a namespace or content change published to external
+// consumers of a share's change stream.
+type ChangeEventType string
+
+const (
+	ChangeCreated  ChangeEventType = "created"
+	ChangeRemoved  ChangeEventType = "removed"
+	ChangeRenamed  ChangeEventType = "renamed"
+	ChangeModified ChangeEventType = "modified"
+)
+
+// ChangeEventTypes lists every change event type.
+var ChangeEventTypes = []ChangeEventType{ChangeCreated, ChangeRemoved, ChangeRenamed, ChangeModified}
+
+// IsChangeEventType reports whether t names a known change event type.
+func IsChangeEventType(t string) bool {
+	for _, known := range ChangeEventTypes {
+		if ChangeEventType(t) == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeEvent is one change to a share's namespace or file content. These are
+// the same mutations that drive SMB CHANGE_NOTIFY and NFSv4 CB_NOTIFY through
+// lock.DirChangeNotifier, resolved to share-relative paths so they can leave
+// the server.
+type ChangeEvent struct {
+	Time  time.Time       `json:"time"`
+	Share string          `json:"share"`
+	Type  ChangeEventType `json:"type"`
+
+	// Path is the share-relative path of the object; for a rename it is the
+	// destination and OldPath the source.
+	Path    string `json:"path"`
+	OldPath string `json:"old_path,omitempty"`
+
+	// FileType is "file", "directory", "symlink" or "special", when known.
+	FileType string `json:"file_type,omitempty"`
+
+	// Size is the file size after the change, for created and modified
+	// regular files.
+	Size uint64 `json:"size,omitempty"`
+}
+
+// ChangeListener receives the change events of every share. Implemented by
+// the runtime; nil on a MetadataService means no events are produced.
+type ChangeListener interface {
+	// WantsChanges reports whether anything consumes the named share's
+	// events. The metadata layer skips path resolution when it does not.
+	WantsChanges(shareName string) bool
+
+	// OnChange accepts one event. It is called on protocol goroutines and
+	// must not block.
+	OnChange(event *ChangeEvent)
+}
+
+// SetChangeListener installs the consumer of change events. A nil listener
+// (the default) disables them on every share.
+func (s *Service) SetChangeListener(l ChangeListener) { s.changeListener = l }
+
+// pendingChange is one change event being assembled after a successful
+// mutation. A nil *pendingChange is a no-op, which is what callers get when
+// nothing listens to the share.
+type pendingChange struct {
+	s     *Service
+	ctx   *AuthContext
+	event ChangeEvent
+}
+
+// startChange returns a pending event of type typ for the share owning
+// handle, or nil when nothing listens to it.
+func (s *Service) startChange(ctx *AuthContext, typ ChangeEventType, handle FileHandle) *pendingChange {
+	if s.changeListener == nil || ctx == nil {
+		return nil
+	}
+	shareName := shareNameForHandle(handle)
+	if !s.changeListener.WantsChanges(shareName) {
+		return nil
+	}
+	return &pendingChange{
+		s:     s,
+		ctx:   ctx,
+		event: ChangeEvent{Share: shareName, Type: typ},
+	}
+}
+
+// entryPath resolves the path of the entry name in dirHandle.
+func (c *pendingChange) entryPath(dirHandle FileHandle, name string) string {
+	dir, err := c.s.GetFile(c.ctx.Context, dirHandle)
+	if err != nil {
+		return name
+	}
+	return buildPath(dir.Path, name)
+}
+
+// entry sets the event's path to the entry name in dirHandle.
+func (c *pendingChange) entry(dirHandle FileHandle, name string) *pendingChange {
+	if c != nil {
+		c.event.Path = c.entryPath(dirHandle, name)
+	}
+	return c
+}
+
+// from sets the source path of a rename.
+func (c *pendingChange) from(dirHandle FileHandle, name string) *pendingChange {
+	if c != nil {
+		c.event.OldPath = c.entryPath(dirHandle, name)
+	}
+	return c
+}
+
+// file fills the type, size and (if not yet known) path from the object.
+func (c *pendingChange) file(f *File) *pendingChange {
+	if c == nil || f == nil {
+		return c
+	}
+	if c.event.Path == "" {
+		c.event.Path = f.Path
+	}
+	c.event.FileType = changeFileType(f.Type)
+	if f.Type == FileTypeRegular && c.event.Type != ChangeRemoved {
+		c.event.Size = f.Size
+	}
+	return c
+}
+
+// emit delivers the event. Delivery is fire-and-forget: a panicking listener
+// never fails the mutation that produced the event.
+func (c *pendingChange) emit() {
+	if c == nil {
+		return
+	}
+	ev := c.event
+	ev.Time = time.Now().UTC()
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("change listener panic", "share", ev.Share, "error", r)
+		}
+	}()
+	c.s.changeListener.OnChange(&ev)
+}
+
+// emitModified publishes a content change of the file behind handle. Writes
+// land here once per WRITE; the listener is expected to debounce.
+func (s *Service) emitModified(ctx *AuthContext, handle FileHandle) {
+	c := s.startChange(ctx, ChangeModified, handle)
+	if c == nil {
+		return
+	}
+	file := s.pendingWrites.GetCachedFile(handle)
+	if file == nil || file.Path == "" {
+		var err error
+		if file, err = s.GetFile(ctx.Context, handle); err != nil {
+			return
+		}
+	}
+	if size, ok := s.pendingWrites.GetPendingSize(handle); ok && size > file.Size {
+		file.Size = size
+	}
+	c.file(file).emit()
+}
+
+func changeFileType(t FileType) string {
+	switch t {
+	case FileTypeRegular:
+		return "file"
+	case FileTypeDirectory:
+		return "directory"
+	case FileTypeSymlink:
+		return "symlink"
+	}
+	return "special"
+}
@@ -0,0 +1,100 @@
+package metadata_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubChangeListener records every event of the shares it wants.
+type stubChangeListener struct {
+	mu     sync.Mutex
+	wants  bool
+	events []metadata.ChangeEvent
+}
+
+func (l *stubChangeListener) WantsChanges(string) bool { return l.wants }
+
+func (l *stubChangeListener) OnChange(ev *metadata.ChangeEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, *ev)
+}
+
+func (l *stubChangeListener) types() []metadata.ChangeEventType {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	types := make([]metadata.ChangeEventType, len(l.events))
+	for i, ev := range l.events {
+		types[i] = ev.Type
+	}
+	return types
+}
+
+func TestChangeEvents_NamespaceAndWrites(t *testing.T) {
+	fx := newTestFixture(t)
+	listener := &stubChangeListener{wants: true}
+	fx.service.SetChangeListener(listener)
+	ctx := fx.userContext()
+
+	_, _, err := fx.service.CreateDirectory(ctx, fx.rootHandle, "in", &metadata.FileAttr{Mode: 0o755})
+	require.NoError(t, err)
+	file, _, err := fx.service.CreateFile(ctx, fx.rootHandle, "a.mov", &metadata.FileAttr{Mode: 0o644})
+	require.NoError(t, err)
+	handle, err := metadata.EncodeShareHandle(file.ShareName, file.ID)
+	require.NoError(t, err)
+	op, err := fx.service.PrepareWrite(ctx, handle, 4096)
+	require.NoError(t, err)
+	_, err = fx.service.CommitWrite(ctx, op)
+	require.NoError(t, err)
+	inHandle, err := fx.store.GetChild(ctx.Context, fx.rootHandle, "in")
+	require.NoError(t, err)
+	_, err = fx.service.Move(ctx, fx.rootHandle, "a.mov", inHandle, "b.mov")
+	require.NoError(t, err)
+	_, _, err = fx.service.RemoveFile(ctx, inHandle, "b.mov")
+	require.NoError(t, err)
+
+	require.Equal(t, []metadata.ChangeEventType{
+		metadata.ChangeCreated, metadata.ChangeCreated, metadata.ChangeModified,
+		metadata.ChangeRenamed, metadata.ChangeRemoved,
+	}, listener.types())
+
+	dir := listener.events[0]
+	assert.Equal(t, fx.shareName, dir.Share)
+	assert.Equal(t, "/in", dir.Path)
+	assert.Equal(t, "directory", dir.FileType)
+
+	write := listener.events[2]
+	assert.Equal(t, "/a.mov", write.Path)
+	assert.Equal(t, "file", write.FileType)
+	assert.Equal(t, uint64(4096), write.Size)
+
+	rename := listener.events[3]
+	assert.Equal(t, "/in/b.mov", rename.Path)
+	assert.Equal(t, "/a.mov", rename.OldPath)
+
+	assert.Equal(t, "/in/b.mov", listener.events[4].Path)
+}
+
+func TestChangeEvents_SkippedWhenUnwanted(t *testing.T) {
+	fx := newTestFixture(t)
+	listener := &stubChangeListener{}
+	fx.service.SetChangeListener(listener)
+
+	_, _, err := fx.service.CreateFile(fx.userContext(), fx.rootHandle, "a.txt", &metadata.FileAttr{Mode: 0o644})
+	require.NoError(t, err)
+	assert.Empty(t, listener.types())
+}
+
+func TestChangeEvents_FailedOpEmitsNothing(t *testing.T) {
+	fx := newTestFixture(t)
+	listener := &stubChangeListener{wants: true}
+	fx.service.SetChangeListener(listener)
+
+	_, _, err := fx.service.RemoveFile(fx.userContext(), fx.rootHandle, "missing")
+	require.Error(t, err)
+	assert.Empty(t, listener.types())
+}
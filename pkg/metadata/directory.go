@@ -125,6 +125,19 @@ func (s *Service) ReadDirectory(ctx *AuthContext, dirHandle FileHandle, cookie u
 // DirWcc carries the parent directory's pre/post attributes captured atomically
 // with the mutation for protocol weak-cache-consistency data (H9).
 func (s *Service) RemoveDirectory(ctx *AuthContext, parentHandle FileHandle, name string) (*DirWcc, error) {
+	audit := s.auditEntry(ctx, FileAuditDelete, parentHandle, name)
+	wcc, err := s.removeDirectory(ctx, parentHandle, name)
+	audit.done(err)
+	if err == nil {
+		if c := s.startChange(ctx, ChangeRemoved, parentHandle).entry(parentHandle, name); c != nil {
+			c.event.FileType = changeFileType(FileTypeDirectory)
+			c.emit()
+		}
+	}
+	return wcc, err
+}
+
+func (s *Service) removeDirectory(ctx *AuthContext, parentHandle FileHandle, name string) (*DirWcc, error) {
 	store, err := s.storeForHandle(parentHandle)
 	if err != nil {
 		return nil, err
@@ -289,10 +302,13 @@ func (s *Service) RemoveDirectory(ctx *AuthContext, parentHandle FileHandle, nam
 // CreateDirectory creates a new directory in a parent directory. The returned
 // DirWcc carries the parent's pre/post attributes captured atomically (H9).
 func (s *Service) CreateDirectory(ctx *AuthContext, parentHandle FileHandle, name string, attr *FileAttr) (*File, *DirWcc, error) {
+	audit := s.auditEntry(ctx, FileAuditCreate, parentHandle, name)
 	file, wcc, err := s.createEntry(ctx, parentHandle, name, attr, FileTypeDirectory, "", 0, 0)
+	audit.done(err)
 	if err != nil {
 		return nil, nil, err
 	}
 	s.notifyDirChange(shareNameForHandle(parentHandle), parentHandle, lock.DirChangeAddEntry, ctx)
+	s.startChange(ctx, ChangeCreated, parentHandle).file(file).emit()
 	return file, wcc, nil
 }
@@ -0,0 +1,326 @@
+package metadata
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/metadata/acl"
+)
+
+// FileAuditOp names an audited file operation.
+type FileAuditOp string
+
+const (
+	FileAuditOpen     FileAuditOp = "open"
+	FileAuditClose    FileAuditOp = "close"
+	FileAuditRead     FileAuditOp = "read"
+	FileAuditWrite    FileAuditOp = "write"
+	FileAuditCreate   FileAuditOp = "create"
+	FileAuditDelete   FileAuditOp = "delete"
+	FileAuditRename   FileAuditOp = "rename"
+	FileAuditSetACL   FileAuditOp = "set_acl"
+	FileAuditSetOwner FileAuditOp = "set_owner"
+)
+
+// FileAuditOps lists every audited operation.
+var FileAuditOps = []FileAuditOp{
+	FileAuditOpen, FileAuditClose, FileAuditRead, FileAuditWrite,
+	FileAuditCreate, FileAuditDelete, FileAuditRename, FileAuditSetACL, FileAuditSetOwner,
+}
+
+// DefaultFileAuditOps is the set audited when a share enables auditing
+// without choosing operations: opens and every namespace or security change.
+// Read, write and close are per-I/O and must be asked for explicitly.
+var DefaultFileAuditOps = []FileAuditOp{
+	FileAuditOpen, FileAuditCreate, FileAuditDelete, FileAuditRename, FileAuditSetACL, FileAuditSetOwner,
+}
+
+// accessMask returns the NFSv4/Windows access bits an operation exercises,
+// used to match SACL audit ACEs. Close exercises none: it is selected by the
+// share policy alone.
+func (op FileAuditOp) accessMask() uint32 {
+	switch op {
+	case FileAuditOpen, FileAuditRead:
+		return acl.ACE4_READ_DATA
+	case FileAuditWrite:
+		return acl.ACE4_WRITE_DATA | acl.ACE4_APPEND_DATA
+	case FileAuditCreate:
+		return acl.ACE4_ADD_FILE | acl.ACE4_ADD_SUBDIRECTORY
+	case FileAuditDelete, FileAuditRename:
+		return acl.ACE4_DELETE
+	case FileAuditSetACL:
+		return acl.ACE4_WRITE_ACL
+	case FileAuditSetOwner:
+		return acl.ACE4_WRITE_OWNER
+	}
+	return 0
+}
+
+// perIO reports whether op happens on every read, write or close rather than
+// once per open or namespace change.
+func (op FileAuditOp) perIO() bool {
+	return op == FileAuditRead || op == FileAuditWrite || op == FileAuditClose
+}
+
+// ValidateFileAuditOps reports the first unknown operation name in ops.
+func ValidateFileAuditOps(ops []string) error {
+	for _, name := range ops {
+		if !isFileAuditOp(FileAuditOp(name)) {
+			return fmt.Errorf("unknown audit operation %q", name)
+		}
+	}
+	return nil
+}
+
+func isFileAuditOp(op FileAuditOp) bool {
+	for _, known := range FileAuditOps {
+		if op == known {
+			return true
+		}
+	}
+	return false
+}
+
+// FileAuditConfig is a per-share file-access audit policy snapshot, returned
+// by a FileAuditor under lock so callers never read a shared, mutating value.
+type FileAuditConfig struct {
+	Enabled bool
+
+	// Operations are audited on files whose ACL carries no SACL audit ACE.
+	// Files with audit ACEs are governed by those ACEs instead, except for
+	// the per-I/O operations (read, write, close): those are only considered
+	// when listed here, so that unaudited I/O never pays for a file lookup.
+	// Empty means DefaultFileAuditOps.
+	Operations []FileAuditOp
+}
+
+// Audits reports whether op is selected by the share policy.
+func (c FileAuditConfig) Audits(op FileAuditOp) bool {
+	ops := c.Operations
+	if len(ops) == 0 {
+		ops = DefaultFileAuditOps
+	}
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// FileAuditEvent is one audited file access, the counterpart of a Windows
+// Security event 4663 ("An attempt was made to access an object").
+type FileAuditEvent struct {
+	Time  time.Time   `json:"time"`
+	Share string      `json:"share"`
+	Op    FileAuditOp `json:"op"`
+
+	// Path is the share-relative path of the object; NewPath is the
+	// destination of a rename.
+	Path    string `json:"path"`
+	NewPath string `json:"new_path,omitempty"`
+
+	// Protocol is "nfs3", "nfs4" or "smb"; ClientIP the peer address.
+	Protocol string `json:"protocol,omitempty"`
+	ClientIP string `json:"client_ip,omitempty"`
+
+	// Identity of the requester after identity mapping.
+	UID       *uint32 `json:"uid,omitempty"`
+	GID       *uint32 `json:"gid,omitempty"`
+	SID       string  `json:"sid,omitempty"`
+	Principal string  `json:"principal,omitempty"`
+
+	// AccessMask is the access requested or granted (NFSv4/Windows bits).
+	AccessMask uint32 `json:"access_mask,omitempty"`
+
+	// Result is "success" or the metadata error code of a failed attempt
+	// (e.g. "AccessDenied"); Error carries the error text.
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+
+	// SACL is set when the event was selected by a SACL audit ACE rather
+	// than the share policy.
+	SACL bool `json:"sacl,omitempty"`
+
+	// Count is set on a summary event: the number of identical events
+	// (typically per-I/O reads and writes) that followed the first one
+	// within a coalescing window and were folded into this record.
+	Count int `json:"count,omitempty"`
+}
+
+// Success reports whether the audited attempt succeeded.
+func (e *FileAuditEvent) Success() bool { return e.Result == fileAuditSuccess }
+
+const fileAuditSuccess = "success"
+
+// FileAuditor receives file-access audit events. Implemented by the runtime;
+// nil on a MetadataService means auditing is off everywhere.
+type FileAuditor interface {
+	// FileAuditConfigForShare returns the policy for the named share.
+	// ok=false when the share is unknown.
+	FileAuditConfigForShare(shareName string) (cfg FileAuditConfig, ok bool)
+
+	// RecordFileAudit accepts one event. It is called on protocol
+	// goroutines and must not block.
+	RecordFileAudit(event *FileAuditEvent)
+}
+
+// fileAudit is one pending audit record, started before an operation runs
+// (so SACL and path reflect the object as it was) and completed with the
+// operation's outcome. A nil *fileAudit is a no-op, which is what callers get
+// when auditing is off for the share.
+type fileAudit struct {
+	s      *Service
+	ctx    *AuthContext
+	cfg    FileAuditConfig
+	object *File
+	event  FileAuditEvent
+}
+
+// startFileAudit returns a pending record for op on the share owning handle,
+// or nil when the share does not audit.
+func (s *Service) startFileAudit(ctx *AuthContext, op FileAuditOp, handle FileHandle) *fileAudit {
+	if s.fileAuditor == nil || ctx == nil {
+		return nil
+	}
+	shareName := shareNameForHandle(handle)
+	cfg, ok := s.fileAuditor.FileAuditConfigForShare(shareName)
+	if !ok || !cfg.Enabled || (op.perIO() && !cfg.Audits(op)) {
+		return nil
+	}
+	return &fileAudit{
+		s:   s,
+		ctx: ctx,
+		cfg: cfg,
+		event: FileAuditEvent{
+			Share:      shareName,
+			Op:         op,
+			AccessMask: op.accessMask(),
+		},
+	}
+}
+
+// auditHandle starts a record for op on the object behind handle. The
+// object is only looked up once the share is known to audit op, and the
+// write path's cached file is reused when there is one.
+func (s *Service) auditHandle(ctx *AuthContext, op FileAuditOp, handle FileHandle) *fileAudit {
+	a := s.startFileAudit(ctx, op, handle)
+	if a == nil {
+		return nil
+	}
+	if file := s.pendingWrites.GetCachedFile(handle); file != nil {
+		a.object = file
+		a.event.Path = file.Path
+	} else if file, err := s.GetFile(ctx.Context, handle); err == nil {
+		a.object = file
+		a.event.Path = file.Path
+	}
+	return a
+}
+
+// auditEntry starts a record for op on the entry name in parentHandle. The
+// SACL consulted is the parent's for a create (ADD_FILE is a right on the
+// directory) and the entry's own otherwise.
+func (s *Service) auditEntry(ctx *AuthContext, op FileAuditOp, parentHandle FileHandle, name string) *fileAudit {
+	a := s.startFileAudit(ctx, op, parentHandle)
+	if a == nil {
+		return nil
+	}
+	parent, err := s.GetFile(ctx.Context, parentHandle)
+	if err != nil {
+		a.event.Path = name
+		return a
+	}
+	a.event.Path = buildPath(parent.Path, name)
+	if op == FileAuditCreate {
+		a.object = parent
+	} else if child, err := s.GetChild(ctx.Context, parentHandle, name); err == nil {
+		a.object, _ = s.GetFile(ctx.Context, child)
+	}
+	return a
+}
+
+// setNewPath records the destination of a rename.
+func (a *fileAudit) setNewPath(dirHandle FileHandle, name string) {
+	if a == nil {
+		return
+	}
+	if dir, err := a.s.GetFile(a.ctx.Context, dirHandle); err == nil {
+		a.event.NewPath = buildPath(dir.Path, name)
+	} else {
+		a.event.NewPath = name
+	}
+}
+
+// done completes the record with the operation's outcome and emits it when
+// the object's SACL, or the share policy for objects without audit ACEs,
+// selects it.
+func (a *fileAudit) done(opErr error) {
+	if a == nil {
+		return
+	}
+	success := opErr == nil
+	if !a.selected(success) {
+		return
+	}
+
+	ev := a.event
+	ev.Time = time.Now().UTC()
+	ev.Protocol = a.ctx.Protocol
+	ev.ClientIP = clientIP(a.ctx.ClientAddr)
+	if id := a.ctx.Identity; id != nil {
+		ev.UID = id.UID
+		ev.GID = id.GID
+		if id.SID != nil {
+			ev.SID = *id.SID
+		}
+	}
+	ev.Principal = principalOf(a.ctx)
+	if success {
+		ev.Result = fileAuditSuccess
+	} else {
+		ev.Result = "error"
+		var storeErr *StoreError
+		if stderrors.As(opErr, &storeErr) {
+			ev.Result = storeErr.Code.String()
+		}
+		ev.Error = opErr.Error()
+	}
+	a.s.fileAuditor.RecordFileAudit(&ev)
+}
+
+func (a *fileAudit) selected(success bool) bool {
+	if a.object != nil && a.object.ACL != nil && a.event.AccessMask != 0 {
+		evalCtx := buildFileAccessEvalContext(a.object, a.ctx)
+		if hasAudit, matched := acl.AuditMatch(a.object.ACL, evalCtx, a.event.AccessMask, success); hasAudit {
+			a.event.SACL = matched
+			return matched
+		}
+	}
+	return a.cfg.Audits(a.event.Op)
+}
+
+// clientIP strips the port from a "host:port" client address.
+func clientIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// AuditFileAccess records a protocol-level access to the object behind handle
+// for the file-access audit trail: an open (accessMask = the access requested
+// or granted), a close, or any other op the metadata layer cannot see on its
+// own. opErr is the outcome. It is a no-op unless the share audits.
+func (s *Service) AuditFileAccess(ctx *AuthContext, handle FileHandle, op FileAuditOp, accessMask uint32, opErr error) {
+	a := s.auditHandle(ctx, op, handle)
+	if a == nil {
+		return
+	}
+	if accessMask != 0 && op != FileAuditClose {
+		a.event.AccessMask = accessMask
+	}
+	a.done(opErr)
+}
@@ -0,0 +1,182 @@
+package metadata_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/marmos91/dittofs/pkg/metadata/acl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubFileAuditor records every event for one share policy.
+type stubFileAuditor struct {
+	mu     sync.Mutex
+	cfg    metadata.FileAuditConfig
+	events []metadata.FileAuditEvent
+}
+
+func (a *stubFileAuditor) FileAuditConfigForShare(string) (metadata.FileAuditConfig, bool) {
+	return a.cfg, true
+}
+
+func (a *stubFileAuditor) RecordFileAudit(ev *metadata.FileAuditEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, *ev)
+}
+
+func (a *stubFileAuditor) ops() []metadata.FileAuditOp {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ops := make([]metadata.FileAuditOp, len(a.events))
+	for i, ev := range a.events {
+		ops[i] = ev.Op
+	}
+	return ops
+}
+
+func newAuditFixture(t *testing.T, cfg metadata.FileAuditConfig) (*testFixture, *stubFileAuditor) {
+	t.Helper()
+	fx := newTestFixture(t)
+	auditor := &stubFileAuditor{cfg: cfg}
+	fx.service.SetFileAuditor(auditor)
+	return fx, auditor
+}
+
+func TestFileAudit_NamespaceOps(t *testing.T) {
+	fx, auditor := newAuditFixture(t, metadata.FileAuditConfig{Enabled: true})
+	ctx := fx.userContext()
+	ctx.Protocol = "nfs4"
+
+	file, _, err := fx.service.CreateFile(ctx, fx.rootHandle, "a.txt", &metadata.FileAttr{Mode: 0644})
+	require.NoError(t, err)
+	_, err = fx.service.Move(ctx, fx.rootHandle, "a.txt", fx.rootHandle, "b.txt")
+	require.NoError(t, err)
+	_, _, err = fx.service.RemoveFile(ctx, fx.rootHandle, "b.txt")
+	require.NoError(t, err)
+
+	require.Equal(t, []metadata.FileAuditOp{
+		metadata.FileAuditCreate, metadata.FileAuditRename, metadata.FileAuditDelete,
+	}, auditor.ops())
+
+	create := auditor.events[0]
+	assert.Equal(t, fx.shareName, create.Share)
+	assert.Equal(t, file.Path, create.Path)
+	assert.Equal(t, "nfs4", create.Protocol)
+	assert.Equal(t, "127.0.0.1", create.ClientIP)
+	require.NotNil(t, create.UID)
+	assert.Equal(t, uint32(1000), *create.UID)
+	assert.Equal(t, "success", create.Result)
+	assert.False(t, create.SACL)
+
+	rename := auditor.events[1]
+	assert.Equal(t, "/a.txt", rename.Path)
+	assert.Equal(t, "/b.txt", rename.NewPath)
+}
+
+func TestFileAudit_FailureRecorded(t *testing.T) {
+	fx, auditor := newAuditFixture(t, metadata.FileAuditConfig{Enabled: true})
+
+	_, _, err := fx.service.RemoveFile(fx.userContext(), fx.rootHandle, "missing")
+	require.Error(t, err)
+
+	require.Len(t, auditor.events, 1)
+	assert.Equal(t, metadata.FileAuditDelete, auditor.events[0].Op)
+	assert.Equal(t, "NotFound", auditor.events[0].Result)
+	assert.NotEmpty(t, auditor.events[0].Error)
+}
+
+func TestFileAudit_Disabled(t *testing.T) {
+	fx, auditor := newAuditFixture(t, metadata.FileAuditConfig{})
+
+	_, _, err := fx.service.CreateFile(fx.userContext(), fx.rootHandle, "a.txt", &metadata.FileAttr{Mode: 0644})
+	require.NoError(t, err)
+	assert.Empty(t, auditor.events)
+}
+
+func TestFileAudit_OperationFilter(t *testing.T) {
+	fx, auditor := newAuditFixture(t, metadata.FileAuditConfig{
+		Enabled:    true,
+		Operations: []metadata.FileAuditOp{metadata.FileAuditDelete},
+	})
+	ctx := fx.userContext()
+
+	_, _, err := fx.service.CreateFile(ctx, fx.rootHandle, "a.txt", &metadata.FileAttr{Mode: 0644})
+	require.NoError(t, err)
+	_, _, err = fx.service.RemoveFile(ctx, fx.rootHandle, "a.txt")
+	require.NoError(t, err)
+
+	assert.Equal(t, []metadata.FileAuditOp{metadata.FileAuditDelete}, auditor.ops())
+}
+
+// A SACL audit ACE overrides the share's operation list for its file: writes
+// are audited on the file that asks for them, and deletes are not audited
+// there because no ACE selects them, even though the share audits both.
+func TestFileAudit_SACLSelectsEvents(t *testing.T) {
+	fx, auditor := newAuditFixture(t, metadata.FileAuditConfig{
+		Enabled:    true,
+		Operations: []metadata.FileAuditOp{metadata.FileAuditWrite, metadata.FileAuditDelete},
+	})
+	root := fx.rootContext()
+
+	file, _, err := fx.service.CreateFile(root, fx.rootHandle, "audited.txt", &metadata.FileAttr{Mode: 0666})
+	require.NoError(t, err)
+	handle, err := metadata.EncodeFileHandle(file)
+	require.NoError(t, err)
+
+	_, err = fx.service.SetFileAttributes(root, handle, &metadata.SetAttrs{ACL: &acl.ACL{
+		ACEs: []acl.ACE{{Type: acl.ACE4_ACCESS_ALLOWED_ACE_TYPE, AccessMask: acl.FullAccessMask, Who: acl.SpecialEveryone}},
+		SACL: []acl.ACE{{
+			Type:       acl.ACE4_SYSTEM_AUDIT_ACE_TYPE,
+			Flag:       acl.ACE4_SUCCESSFUL_ACCESS_ACE_FLAG,
+			AccessMask: acl.ACE4_WRITE_DATA,
+			Who:        acl.SpecialEveryone,
+		}},
+	}})
+	require.NoError(t, err)
+	auditor.events = nil
+
+	ctx := fx.userContext()
+	_, err = fx.service.PrepareWrite(ctx, handle, 10)
+	require.NoError(t, err)
+	_, _, err = fx.service.RemoveFile(ctx, fx.rootHandle, "audited.txt")
+	require.NoError(t, err)
+
+	require.Equal(t, []metadata.FileAuditOp{metadata.FileAuditWrite}, auditor.ops())
+	assert.True(t, auditor.events[0].SACL)
+}
+
+// Per-I/O operations the share does not list are never audited, even on a
+// file whose SACL would select them, so unaudited reads and writes skip the
+// audit lookup entirely.
+func TestFileAudit_UnlistedIOSkipped(t *testing.T) {
+	fx, auditor := newAuditFixture(t, metadata.FileAuditConfig{Enabled: true})
+	root := fx.rootContext()
+
+	file, _, err := fx.service.CreateFile(root, fx.rootHandle, "io.txt", &metadata.FileAttr{Mode: 0666})
+	require.NoError(t, err)
+	handle, err := metadata.EncodeFileHandle(file)
+	require.NoError(t, err)
+	_, err = fx.service.SetFileAttributes(root, handle, &metadata.SetAttrs{ACL: &acl.ACL{
+		ACEs: []acl.ACE{{Type: acl.ACE4_ACCESS_ALLOWED_ACE_TYPE, AccessMask: acl.FullAccessMask, Who: acl.SpecialEveryone}},
+		SACL: []acl.ACE{{
+			Type:       acl.ACE4_SYSTEM_AUDIT_ACE_TYPE,
+			Flag:       acl.ACE4_SUCCESSFUL_ACCESS_ACE_FLAG,
+			AccessMask: acl.ACE4_READ_DATA | acl.ACE4_WRITE_DATA,
+			Who:        acl.SpecialEveryone,
+		}},
+	}})
+	require.NoError(t, err)
+	auditor.events = nil
+
+	ctx := fx.userContext()
+	_, err = fx.service.PrepareWrite(ctx, handle, 10)
+	require.NoError(t, err)
+	_, err = fx.service.PrepareRead(ctx, handle)
+	require.NoError(t, err)
+	fx.service.AuditFileAccess(ctx, handle, metadata.FileAuditClose, 0, nil)
+
+	assert.Empty(t, auditor.events)
+}
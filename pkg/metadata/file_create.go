@@ -26,11 +26,14 @@ type createCacheStore interface {
 // carries the parent's pre/post attributes captured atomically with the create
 // (H9).
 func (s *Service) CreateFile(ctx *AuthContext, parentHandle FileHandle, name string, attr *FileAttr) (*File, *DirWcc, error) {
+	audit := s.auditEntry(ctx, FileAuditCreate, parentHandle, name)
 	file, wcc, err := s.createEntry(ctx, parentHandle, name, attr, FileTypeRegular, "", 0, 0)
+	audit.done(err)
 	if err != nil {
 		return nil, nil, err
 	}
 	s.notifyDirChange(shareNameForHandle(parentHandle), parentHandle, lock.DirChangeAddEntry, ctx)
+	s.startChange(ctx, ChangeCreated, parentHandle).file(file).emit()
 	return file, wcc, nil
 }
 
@@ -42,11 +45,14 @@ func (s *Service) CreateSymlink(ctx *AuthContext, parentHandle FileHandle, name
 		return nil, nil, err
 	}
 
+	audit := s.auditEntry(ctx, FileAuditCreate, parentHandle, name)
 	file, wcc, err := s.createEntry(ctx, parentHandle, name, attr, FileTypeSymlink, target, 0, 0)
+	audit.done(err)
 	if err != nil {
 		return nil, nil, err
 	}
 	s.notifyDirChange(shareNameForHandle(parentHandle), parentHandle, lock.DirChangeAddEntry, ctx)
+	s.startChange(ctx, ChangeCreated, parentHandle).file(file).emit()
 	return file, wcc, nil
 }
 
@@ -66,11 +72,14 @@ func (s *Service) CreateSpecialFile(ctx *AuthContext, parentHandle FileHandle, n
 		}
 	}
 
+	audit := s.auditEntry(ctx, FileAuditCreate, parentHandle, name)
 	file, wcc, err := s.createEntry(ctx, parentHandle, name, attr, fileType, "", deviceMajor, deviceMinor)
+	audit.done(err)
 	if err != nil {
 		return nil, nil, err
 	}
 	s.notifyDirChange(shareNameForHandle(parentHandle), parentHandle, lock.DirChangeAddEntry, ctx)
+	s.startChange(ctx, ChangeCreated, parentHandle).file(file).emit()
 	return file, wcc, nil
 }
 
@@ -78,6 +87,19 @@ func (s *Service) CreateSpecialFile(ctx *AuthContext, parentHandle FileHandle, n
 // carries the link directory's pre/post attributes captured atomically with the
 // mutation (H9).
 func (s *Service) CreateHardLink(ctx *AuthContext, dirHandle FileHandle, name string, targetHandle FileHandle) (*DirWcc, error) {
+	audit := s.auditEntry(ctx, FileAuditCreate, dirHandle, name)
+	wcc, err := s.createHardLink(ctx, dirHandle, name, targetHandle)
+	audit.done(err)
+	if err == nil {
+		if c := s.startChange(ctx, ChangeCreated, dirHandle).entry(dirHandle, name); c != nil {
+			target, _ := s.GetFile(ctx.Context, targetHandle)
+			c.file(target).emit()
+		}
+	}
+	return wcc, err
+}
+
+func (s *Service) createHardLink(ctx *AuthContext, dirHandle FileHandle, name string, targetHandle FileHandle) (*DirWcc, error) {
 	store, err := s.storeForHandle(dirHandle)
 	if err != nil {
 		return nil, err
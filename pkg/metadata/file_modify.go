@@ -214,7 +214,28 @@ func (s *Service) ReadSymlink(ctx *AuthContext, handle FileHandle) (string, *Fil
 // data: Before is the state the operation transitioned from (the attributes the
 // mutation observed), After is the resulting state (H9). For SETATTR the WCC
 // subject is the file itself, not a parent directory.
+//
+// Permission changes (ACL or mode) and ownership changes are recorded in the
+// file-access audit trail.
 func (s *Service) SetFileAttributes(ctx *AuthContext, handle FileHandle, attrs *SetAttrs) (*DirWcc, error) {
+	var audits []*fileAudit
+	if attrs != nil && (attrs.ACL != nil || attrs.Mode != nil) {
+		audits = append(audits, s.auditHandle(ctx, FileAuditSetACL, handle))
+	}
+	if attrs != nil && (attrs.UID != nil || attrs.GID != nil) {
+		audits = append(audits, s.auditHandle(ctx, FileAuditSetOwner, handle))
+	}
+	wcc, err := s.setFileAttributes(ctx, handle, attrs)
+	for _, audit := range audits {
+		audit.done(err)
+	}
+	if err == nil && attrs != nil && attrs.Size != nil {
+		s.emitModified(ctx, handle)
+	}
+	return wcc, err
+}
+
+func (s *Service) setFileAttributes(ctx *AuthContext, handle FileHandle, attrs *SetAttrs) (*DirWcc, error) {
 	store, err := s.storeForHandle(handle)
 	if err != nil {
 		return nil, err
@@ -597,6 +618,23 @@ func (s *Service) SetFileAttributes(ctx *AuthContext, handle FileHandle, attrs *
 
 // Move moves or renames a file or directory atomically.
 func (s *Service) Move(ctx *AuthContext, fromDir FileHandle, fromName string, toDir FileHandle, toName string) (*RenameWcc, error) {
+	audit := s.auditEntry(ctx, FileAuditRename, fromDir, fromName)
+	audit.setNewPath(toDir, toName)
+	wcc, err := s.move(ctx, fromDir, fromName, toDir, toName)
+	audit.done(err)
+	if err == nil {
+		if c := s.startChange(ctx, ChangeRenamed, toDir).entry(toDir, toName).from(fromDir, fromName); c != nil {
+			if child, cErr := s.GetChild(ctx.Context, toDir, toName); cErr == nil {
+				moved, _ := s.GetFile(ctx.Context, child)
+				c.file(moved)
+			}
+			c.emit()
+		}
+	}
+	return wcc, err
+}
+
+func (s *Service) move(ctx *AuthContext, fromDir FileHandle, fromName string, toDir FileHandle, toName string) (*RenameWcc, error) {
 	store, err := s.storeForHandle(fromDir)
 	if err != nil {
 		return nil, err
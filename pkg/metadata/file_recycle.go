@@ -100,10 +100,12 @@ func (s *Service) recycleNode(ctx *AuthContext, shareName string, parentHandle F
 	}
 
 	// 6. Move the (now-stamped) victim into the bin (atomic, metadata-only —
-	//    a directory moves its whole subtree as one entry). If this fails, the
-	//    source is still live but marked-deleted: clear the stamp best-effort so
-	//    a live file is not left looking recycled, then surface the move error.
-	if _, err := s.Move(ctx, parentHandle, name, destParent, destName); err != nil {
+	//    a directory moves its whole subtree as one entry). The unaudited
+	//    move is used: the caller audits a delete, not a rename. If this
+	//    fails, the source is still live but marked-deleted: clear the stamp
+	//    best-effort so a live file is not left looking recycled, then
+	//    surface the move error.
+	if _, err := s.move(ctx, parentHandle, name, destParent, destName); err != nil {
 		if clearErr := s.clearRecycleStamp(ctx, victimHandle); clearErr != nil {
 			return nil, &StoreError{
 				Code:    ErrIOError,
@@ -26,6 +26,16 @@ import (
 //   - When last link is removed, nlink is set to 0 (not deleted)
 //   - This allows fstat() on open file descriptors to return nlink=0
 func (s *Service) RemoveFile(ctx *AuthContext, parentHandle FileHandle, name string) (*File, *DirWcc, error) {
+	audit := s.auditEntry(ctx, FileAuditDelete, parentHandle, name)
+	file, wcc, err := s.removeFile(ctx, parentHandle, name)
+	audit.done(err)
+	if err == nil {
+		s.startChange(ctx, ChangeRemoved, parentHandle).entry(parentHandle, name).file(file).emit()
+	}
+	return file, wcc, err
+}
+
+func (s *Service) removeFile(ctx *AuthContext, parentHandle FileHandle, name string) (*File, *DirWcc, error) {
 	store, err := s.storeForHandle(parentHandle)
 	if err != nil {
 		return nil, nil, err
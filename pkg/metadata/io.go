@@ -70,6 +70,13 @@ type ReadMetadata struct {
 //  2. ContentStore.WriteAt - writes actual content
 //  3. CommitWrite - updates metadata (size, mtime, ctime)
 func (s *Service) PrepareWrite(ctx *AuthContext, handle FileHandle, newSize uint64) (*WriteOperation, error) {
+	audit := s.auditHandle(ctx, FileAuditWrite, handle)
+	op, err := s.prepareWrite(ctx, handle, newSize)
+	audit.done(err)
+	return op, err
+}
+
+func (s *Service) prepareWrite(ctx *AuthContext, handle FileHandle, newSize uint64) (*WriteOperation, error) {
 	// Check context
 	if err := ctx.Context.Err(); err != nil {
 		return nil, err
@@ -217,11 +224,20 @@ func (s *Service) PrepareWrite(ctx *AuthContext, handle FileHandle, newSize uint
 // This prevents race conditions where concurrent writes would result in
 // the smaller size being stored if it commits last.
 func (s *Service) CommitWrite(ctx *AuthContext, intent *WriteOperation) (*File, error) {
+	var (
+		file *File
+		err  error
+	)
 	// Check if deferred commits are enabled (lock-free read on the hot path).
 	if s.deferredCommit.Load() {
-		return s.deferredCommitWrite(ctx, intent)
+		file, err = s.deferredCommitWrite(ctx, intent)
+	} else {
+		file, err = s.immediateCommitWrite(ctx, intent)
 	}
-	return s.immediateCommitWrite(ctx, intent)
+	if err == nil {
+		s.emitModified(ctx, intent.Handle)
+	}
+	return file, err
 }
 
 // deferredCommitWrite records the write in pending state without touching the store.
@@ -540,6 +556,13 @@ func (s *Service) PrewarmWriteCache(handle FileHandle, file *File) {
 // The method does NOT perform actual data reading. The protocol handler
 // coordinates between metadata and content stores.
 func (s *Service) PrepareRead(ctx *AuthContext, handle FileHandle) (*ReadMetadata, error) {
+	audit := s.auditHandle(ctx, FileAuditRead, handle)
+	md, err := s.prepareRead(ctx, handle)
+	audit.done(err)
+	return md, err
+}
+
+func (s *Service) prepareRead(ctx *AuthContext, handle FileHandle) (*ReadMetadata, error) {
 	_, err := s.storeForHandle(handle)
 	if err != nil {
 		return nil, err
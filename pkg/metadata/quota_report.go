@@ -0,0 +1,69 @@
+package metadata
+
+import "time"
+
+// QuotaReport is a point-in-time view of one identity's quota on a share: the
+// limits that apply to it, its live usage, and how long its soft-limit grace
+// window has left. It backs quota queries from clients (NFS rquota, SMB
+// FileQuotaInformation), which report rather than enforce.
+type QuotaReport struct {
+	// Quota is the resolved quota. For a user without an explicit quota this is
+	// the share's default-user quota (Quota.ID == DefaultUserID).
+	Quota IdentityQuota
+
+	// Usage is the identity's live usage, keyed by the real uid/gid.
+	Usage UsageStat
+
+	// BytesGraceLeft / FilesGraceLeft are the time remaining before a soft
+	// threshold crossed on that dimension is enforced as hard. Zero when the
+	// dimension is under its soft threshold, no grace is configured, or the
+	// window has already expired.
+	BytesGraceLeft time.Duration
+	FilesGraceLeft time.Duration
+}
+
+// GetIdentityQuotaReport returns the quota report for (scope, id) on a share.
+// A user without an explicit quota falls through to the default-user quota,
+// exactly as enforcement does. ok is false when no quota applies to the
+// identity; err is non-nil only when the share's store cannot be resolved or
+// usage cannot be read.
+func (s *Service) GetIdentityQuotaReport(shareName string, scope QuotaScope, id uint32) (report QuotaReport, ok bool, err error) {
+	var iq IdentityQuota
+	switch scope {
+	case QuotaScopeUser:
+		iq, ok = s.identityQuotas.resolveUser(shareName, id)
+	default:
+		iq, ok = s.identityQuotas.get(shareName, scope, id)
+	}
+	if !ok {
+		return QuotaReport{}, false, nil
+	}
+
+	store, err := s.GetStoreForShare(shareName)
+	if err != nil {
+		return QuotaReport{}, false, err
+	}
+	usage, err := store.GetQuotaUsage(scope, id)
+	if err != nil {
+		return QuotaReport{}, false, err
+	}
+
+	report = QuotaReport{Quota: iq, Usage: usage}
+
+	if iq.GraceSeconds > 0 {
+		isDefaultUserFallback := scope == QuotaScopeUser && iq.ID == DefaultUserID
+		if start := s.liveGrace(shareName, scope, id, isDefaultUserFallback, iq.ID); !start.IsZero() {
+			left := time.Until(start.Add(time.Duration(iq.GraceSeconds) * time.Second))
+			if left > 0 {
+				if iq.SoftBytes > 0 && usage.Bytes > iq.SoftBytes {
+					report.BytesGraceLeft = left
+				}
+				if iq.SoftFiles > 0 && usage.Files > iq.SoftFiles {
+					report.FilesGraceLeft = left
+				}
+			}
+		}
+	}
+
+	return report, true, nil
+}
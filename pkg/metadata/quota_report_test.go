@@ -0,0 +1,68 @@
+package metadata_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetIdentityQuotaReport verifies the report a quota query (rquota, SMB
+// FileQuotaInformation) sees: resolved limits, the identity's own usage, the
+// default-user fallthrough, and the remaining grace on an over-soft dimension.
+func TestGetIdentityQuotaReport(t *testing.T) {
+	t.Parallel()
+	fx := newTestFixture(t)
+	fx.service.SetDeferredCommit(false)
+
+	// No quota configured: nothing to report.
+	_, ok, err := fx.service.GetIdentityQuotaReport(fx.shareName, metadata.QuotaScopeUser, 1000)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	graceStart := time.Now()
+	fx.service.SetIdentityQuota(fx.shareName, metadata.IdentityQuota{
+		Scope:          metadata.QuotaScopeUser,
+		ID:             1000,
+		SoftBytes:      1000,
+		LimitBytes:     10000,
+		GraceSeconds:   3600,
+		GraceStartedAt: graceStart,
+	})
+	fx.service.SetIdentityQuota(fx.shareName, metadata.IdentityQuota{
+		Scope:      metadata.QuotaScopeUser,
+		ID:         metadata.DefaultUserID,
+		LimitFiles: 50,
+	})
+
+	uc := fx.userContext()
+	file, _, err := fx.service.CreateFile(uc, fx.rootHandle, "r.bin", &metadata.FileAttr{Mode: 0o644})
+	require.NoError(t, err)
+	op, err := fx.service.PrepareWrite(uc, handleForFile(t, file), 4000)
+	require.NoError(t, err)
+	_, err = fx.service.CommitWrite(uc, op)
+	require.NoError(t, err)
+
+	report, ok, err := fx.service.GetIdentityQuotaReport(fx.shareName, metadata.QuotaScopeUser, 1000)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(10000), report.Quota.LimitBytes)
+	require.Equal(t, int64(4000), report.Usage.Bytes)
+	require.Equal(t, int64(1), report.Usage.Files)
+	require.Greater(t, report.BytesGraceLeft, 59*time.Minute, "bytes are over soft: grace should be counting down")
+	require.Zero(t, report.FilesGraceLeft, "files have no soft threshold")
+
+	// A user without an explicit quota reports the default-user quota.
+	report, ok, err = fx.service.GetIdentityQuotaReport(fx.shareName, metadata.QuotaScopeUser, 2000)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, metadata.DefaultUserID, report.Quota.ID)
+	require.Equal(t, int64(50), report.Quota.LimitFiles)
+	require.Zero(t, report.Usage.Files)
+
+	// Groups have no default fallback.
+	_, ok, err = fx.service.GetIdentityQuotaReport(fx.shareName, metadata.QuotaScopeGroup, 1000)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
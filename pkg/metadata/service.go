@@ -120,6 +120,16 @@ type Service struct {
 	// content as before. Installed via SetTrashPolicy.
 	trashPolicy TrashPolicy
 
+	// fileAuditor, if set, supplies the per-share file-access audit policy
+	// and receives the resulting events. Nil (the default) disables the audit
+	// trail entirely. Installed via SetFileAuditor.
+	fileAuditor FileAuditor
+
+	// changeListener, if set, receives the resolved change events of every
+	// share for the external change stream. Nil (the default) disables them.
+	// Installed via SetChangeListener.
+	changeListener ChangeListener
+
 	// xattrStreamReader, if set, reads the content of a named-stream child File
 	// so the xattr resolver can surface stream-backed xattr values (the
 	// stream-entity backing). It is wired by the runtime layer, which has
@@ -279,6 +289,10 @@ func (s *Service) SetByteRangeReleaseHook(fn func(handleKey string)) {
 // (the default) disables trash: deletes destroy content as before.
 func (s *Service) SetTrashPolicy(p TrashPolicy) { s.trashPolicy = p }
 
+// SetFileAuditor installs the file-access audit trail. A nil auditor (the
+// default) disables auditing on every share.
+func (s *Service) SetFileAuditor(a FileAuditor) { s.fileAuditor = a }
+
 // SetShareWriteback opts a share into (or out of) the metadata writeback tier
 // (#1757). When enabled, FlushPendingWriteForFile downgrades an otherwise
 // durable per-op flush (FILE_SYNC WRITE, SMB CLOSE/FLUSH) to the relaxed
@@ -0,0 +1,308 @@
+package transfer
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/marmos91/dittofs/pkg/metadata/backup"
+	"github.com/marmos91/dittofs/pkg/metadata/lock"
+)
+
+// Export writes shareName from src to w as a transfer stream. The share
+// should be quiesced (disabled, uploads drained): the walk reads the live
+// store and is not a point-in-time view.
+func Export(ctx context.Context, src metadata.Store, shareName string, w io.Writer) (*Stats, error) {
+	names, err := src.ListShares(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list shares: %w", err)
+	}
+	if !slices.Contains(names, shareName) {
+		return nil, fmt.Errorf("%w: %q", ErrShareNotFound, shareName)
+	}
+
+	ew, err := backup.NewWriter(w, EngineTag)
+	if err != nil {
+		return nil, err
+	}
+	var vBuf [4]byte
+	binary.LittleEndian.PutUint32(vBuf[:], schemaVersion)
+	if _, err := ew.Write(vBuf[:]); err != nil {
+		return nil, fmt.Errorf("write schema version: %w", err)
+	}
+
+	e := &exporter{
+		src:    src,
+		share:  shareName,
+		w:      ew,
+		seen:   make(map[uuid.UUID]struct{}),
+		hashes: make(map[block.ContentHash]struct{}),
+		blocks: make(map[string]struct{}),
+	}
+	if err := e.run(ctx); err != nil {
+		return nil, err
+	}
+	if err := writeRecord(ew, kindEnd, &e.stats); err != nil {
+		return nil, err
+	}
+	if err := ew.Finish(); err != nil {
+		return nil, fmt.Errorf("finish envelope: %w", err)
+	}
+	return &e.stats, nil
+}
+
+type exporter struct {
+	src   metadata.Store
+	share string
+	w     io.Writer
+	stats Stats
+
+	seen   map[uuid.UUID]struct{}
+	hashes map[block.ContentHash]struct{}
+	blocks map[string]struct{}
+}
+
+func (e *exporter) run(ctx context.Context) error {
+	rootHandle, err := e.src.GetRootHandle(ctx, e.share)
+	if err != nil {
+		return fmt.Errorf("get root handle: %w", err)
+	}
+	root, err := e.src.GetFile(ctx, rootHandle)
+	if err != nil {
+		return fmt.Errorf("get root: %w", err)
+	}
+	opts, err := e.src.GetShareOptions(ctx, e.share)
+	if err != nil {
+		return fmt.Errorf("get share options: %w", err)
+	}
+	rootRec, err := e.fileRecord(ctx, rootHandle, root)
+	if err != nil {
+		return err
+	}
+	hdr := headerRecord{Share: e.share, Options: *opts, Root: rootRec}
+	if fsMeta, err := e.src.GetFilesystemMeta(ctx, e.share); err == nil {
+		hdr.FilesystemMeta = fsMeta
+	} else if !metadata.IsNotFoundError(err) {
+		return fmt.Errorf("get filesystem meta: %w", err)
+	}
+	if err := writeRecord(e.w, kindHeader, &hdr); err != nil {
+		return err
+	}
+	e.seen[rootRec.File.ID] = struct{}{}
+	e.stats.Directories++
+
+	// Breadth-first: a directory's inode record always precedes its entries,
+	// and every child record precedes the entry that links it.
+	queue := []metadata.FileHandle{rootHandle}
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		dir := queue[0]
+		queue = queue[1:]
+		subdirs, err := e.exportDir(ctx, dir)
+		if err != nil {
+			return err
+		}
+		queue = append(queue, subdirs...)
+	}
+
+	if ls, ok := lockStoreOf(e.src); ok {
+		locks, err := ls.ListLocks(ctx, lock.LockQuery{ShareName: e.share})
+		if err != nil {
+			return fmt.Errorf("list locks: %w", err)
+		}
+		for _, l := range locks {
+			if err := writeRecord(e.w, kindLock, l); err != nil {
+				return err
+			}
+			e.stats.Locks++
+		}
+	}
+	if ds, ok := durableStoreOf(e.src); ok {
+		handles, err := ds.ListDurableHandlesByShare(ctx, e.share)
+		if err != nil {
+			return fmt.Errorf("list durable handles: %w", err)
+		}
+		for _, h := range handles {
+			if err := writeRecord(e.w, kindDurable, h); err != nil {
+				return err
+			}
+			e.stats.DurableHandles++
+		}
+	}
+	return nil
+}
+
+// exportDir writes every entry of dir (and the inode records of children not
+// yet seen) and returns the subdirectories still to visit.
+func (e *exporter) exportDir(ctx context.Context, dir metadata.FileHandle) ([]metadata.FileHandle, error) {
+	_, dirID, err := metadata.DecodeFileHandle(dir)
+	if err != nil {
+		return nil, fmt.Errorf("decode directory handle: %w", err)
+	}
+	var subdirs []metadata.FileHandle
+	cursor := ""
+	for {
+		entries, next, err := e.src.ListChildren(ctx, dir, cursor, listPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("list children of %s: %w", dirID, err)
+		}
+		for _, ent := range entries {
+			_, childID, err := metadata.DecodeFileHandle(ent.Handle)
+			if err != nil {
+				return nil, fmt.Errorf("decode handle of %q: %w", ent.Name, err)
+			}
+			if _, seen := e.seen[childID]; !seen {
+				child, err := e.src.GetFile(ctx, ent.Handle)
+				if err != nil {
+					if metadata.IsNotFoundError(err) {
+						e.stats.SkippedEntries++
+						continue
+					}
+					return nil, fmt.Errorf("get %q: %w", ent.Name, err)
+				}
+				if err := e.exportFile(ctx, ent.Handle, child); err != nil {
+					return nil, err
+				}
+				e.seen[childID] = struct{}{}
+				if child.Type == metadata.FileTypeDirectory {
+					subdirs = append(subdirs, ent.Handle)
+				}
+			}
+			if err := writeRecord(e.w, kindEntry, &entryRecord{DirID: dirID, Name: ent.Name, ChildID: childID}); err != nil {
+				return nil, err
+			}
+			e.stats.Entries++
+		}
+		if next == "" {
+			return subdirs, nil
+		}
+		cursor = next
+	}
+}
+
+// exportFile writes the inode record of file, followed (for regular files) by
+// its chunk rows and the locators and block records they resolve to.
+func (e *exporter) exportFile(ctx context.Context, handle metadata.FileHandle, file *metadata.File) error {
+	rec, err := e.fileRecord(ctx, handle, file)
+	if err != nil {
+		return err
+	}
+	if err := writeRecord(e.w, kindFile, rec); err != nil {
+		return err
+	}
+	if file.Type == metadata.FileTypeDirectory {
+		e.stats.Directories++
+	} else {
+		e.stats.Files++
+	}
+	if file.Type != metadata.FileTypeRegular || file.PayloadID == "" {
+		return nil
+	}
+
+	rows, err := e.src.ListFileChunks(ctx, string(file.PayloadID))
+	if err != nil {
+		return fmt.Errorf("list chunks of %s: %w", file.PayloadID, err)
+	}
+	for _, row := range rows {
+		if row == nil {
+			continue
+		}
+		if err := writeRecord(e.w, kindChunk, row); err != nil {
+			return err
+		}
+		e.stats.Chunks++
+		if err := e.exportHash(ctx, row.Hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportHash writes the synced locator of hash and its block record, once
+// each across the whole stream.
+func (e *exporter) exportHash(ctx context.Context, hash block.ContentHash) error {
+	if _, done := e.hashes[hash]; done {
+		return nil
+	}
+	e.hashes[hash] = struct{}{}
+	loc, ok, err := e.src.GetLocator(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("get locator %s: %w", hash, err)
+	}
+	if !ok {
+		return nil
+	}
+	if err := writeRecord(e.w, kindSynced, &syncedRecord{Hash: hash, Locator: loc}); err != nil {
+		return err
+	}
+	e.stats.SyncedHashes++
+
+	if loc.BlockID == "" {
+		return nil
+	}
+	if _, done := e.blocks[loc.BlockID]; done {
+		return nil
+	}
+	e.blocks[loc.BlockID] = struct{}{}
+	rec, ok, err := e.src.GetBlockRecord(ctx, loc.BlockID)
+	if err != nil {
+		return fmt.Errorf("get block record %s: %w", loc.BlockID, err)
+	}
+	if !ok {
+		return nil
+	}
+	if err := writeRecord(e.w, kindBlock, &rec); err != nil {
+		return err
+	}
+	e.stats.BlockRecords++
+	return nil
+}
+
+func (e *exporter) fileRecord(ctx context.Context, handle metadata.FileHandle, file *metadata.File) (*fileRecord, error) {
+	_, id, err := metadata.DecodeFileHandle(handle)
+	if err != nil {
+		return nil, fmt.Errorf("decode handle: %w", err)
+	}
+	// Some engines leave File.ID unset on reads; the handle is authoritative.
+	file.ID = id
+	rec := &fileRecord{File: file}
+
+	if parent, err := e.src.GetParent(ctx, handle); err == nil {
+		if _, pid, err := metadata.DecodeFileHandle(parent); err == nil {
+			rec.ParentID = pid
+		}
+	} else if !metadata.IsNotFoundError(err) {
+		return nil, fmt.Errorf("get parent of %s: %w", id, err)
+	}
+	if rec.LinkCount, err = e.src.GetLinkCount(ctx, handle); err != nil {
+		return nil, fmt.Errorf("get link count of %s: %w", id, err)
+	}
+
+	names, err := e.src.ListXattr(ctx, handle)
+	if err != nil {
+		return nil, fmt.Errorf("list xattrs of %s: %w", id, err)
+	}
+	for _, name := range names {
+		value, ok, err := e.src.GetXattr(ctx, handle, name)
+		if err != nil {
+			return nil, fmt.Errorf("get xattr %q of %s: %w", name, id, err)
+		}
+		if !ok {
+			continue
+		}
+		if rec.Xattrs == nil {
+			rec.Xattrs = make(map[string][]byte, len(names))
+		}
+		rec.Xattrs[name] = value
+		e.stats.Xattrs++
+	}
+	return rec, nil
+}
@@ -0,0 +1,315 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/marmos91/dittofs/internal/logger"
+	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/marmos91/dittofs/pkg/metadata/backup"
+	"github.com/marmos91/dittofs/pkg/metadata/lock"
+)
+
+// Import reads a transfer stream from r and recreates its share in dst.
+//
+// dst must not already hold the share (metadata.ErrRestoreDestinationNotEmpty).
+// Stream faults wrap metadata.ErrRestoreCorrupt, a different schema version
+// metadata.ErrSchemaVersionMismatch. If Import fails after creating the share
+// it removes it again with Purge, so dst is left as it was found.
+func Import(ctx context.Context, dst metadata.Store, r io.Reader) (res *Result, err error) {
+	tag, pr, acc, err := backup.ReadHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", metadata.ErrRestoreCorrupt, err)
+	}
+	if err := backup.VerifyEngine(tag, EngineTag); err != nil {
+		return nil, fmt.Errorf("%w: %v", metadata.ErrRestoreCorrupt, err)
+	}
+	var vBuf [4]byte
+	if _, err := io.ReadFull(pr, vBuf[:]); err != nil {
+		return nil, fmt.Errorf("%w: read schema version: %v", metadata.ErrRestoreCorrupt, err)
+	}
+	if v := binary.LittleEndian.Uint32(vBuf[:]); v != schemaVersion {
+		return nil, fmt.Errorf("%w: got %d, want %d", metadata.ErrSchemaVersionMismatch, v, schemaVersion)
+	}
+
+	kind, body, err := readRecord(pr)
+	if err != nil {
+		return nil, err
+	}
+	if kind != kindHeader {
+		return nil, fmt.Errorf("%w: stream starts with record %d, want header", metadata.ErrRestoreCorrupt, kind)
+	}
+	var hdr headerRecord
+	if err := decodeRecord(kind, body, &hdr); err != nil {
+		return nil, err
+	}
+	if hdr.Share == "" || hdr.Root == nil || hdr.Root.File == nil {
+		return nil, fmt.Errorf("%w: incomplete header", metadata.ErrRestoreCorrupt)
+	}
+
+	names, err := dst.ListShares(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list destination shares: %w", err)
+	}
+	if slices.Contains(names, hdr.Share) {
+		return nil, fmt.Errorf("%w: share %q already exists", metadata.ErrRestoreDestinationNotEmpty, hdr.Share)
+	}
+
+	im := &importer{
+		dst:       dst,
+		share:     hdr.Share,
+		oldRootID: hdr.Root.File.ID,
+		parents:   make(map[uuid.UUID]uuid.UUID),
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		// Roll back on a context that survives the caller's cancellation, so
+		// an aborted import does not leave a half-built share behind.
+		if _, perr := Purge(context.WithoutCancel(ctx), dst, hdr.Share); perr != nil {
+			logger.Warn("transfer: failed to remove partially imported share",
+				"share", hdr.Share, "error", perr)
+		}
+	}()
+	if err := im.createShare(ctx, &hdr); err != nil {
+		return nil, err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		kind, body, err := readRecord(pr)
+		if err != nil {
+			return nil, err
+		}
+		if kind == kindEnd {
+			var want Stats
+			if err := decodeRecord(kind, body, &want); err != nil {
+				return nil, err
+			}
+			if err := backup.VerifyCRC(r, acc); err != nil {
+				return nil, fmt.Errorf("%w: %v", metadata.ErrRestoreCorrupt, err)
+			}
+			want.SkippedEntries = 0
+			if want != im.stats {
+				return nil, fmt.Errorf("%w: imported %+v, stream declared %+v", metadata.ErrRestoreCorrupt, im.stats, want)
+			}
+			break
+		}
+		if err := im.apply(ctx, kind, body); err != nil {
+			return nil, err
+		}
+	}
+
+	// Parent pointers go last: a hard-linked file's recorded parent may be
+	// a directory the stream reaches after the file itself.
+	for child, parent := range im.parents {
+		if err := dst.SetParent(ctx, im.handle(child), im.handle(parent)); err != nil {
+			return nil, fmt.Errorf("set parent of %s: %w", child, err)
+		}
+	}
+
+	oldRoot, err := metadata.EncodeShareHandle(hdr.Share, im.oldRootID)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Share:         hdr.Share,
+		OldRootHandle: oldRoot,
+		NewRootHandle: im.handle(im.newRootID),
+		Stats:         im.stats,
+	}, nil
+}
+
+type importer struct {
+	dst       metadata.Store
+	share     string
+	oldRootID uuid.UUID
+	newRootID uuid.UUID
+	oldRoot   metadata.FileHandle
+	newRoot   metadata.FileHandle
+	parents   map[uuid.UUID]uuid.UUID
+	stats     Stats
+}
+
+// handle returns the destination handle of a source inode ID.
+func (im *importer) handle(id uuid.UUID) metadata.FileHandle {
+	if id == im.oldRootID {
+		id = im.newRootID
+	}
+	h, _ := metadata.EncodeShareHandle(im.share, id)
+	return h
+}
+
+// createShare creates the share and its root the way the runtime does
+// (CreateRootDirectory, then options), then overwrites the fresh root with the
+// source root's attributes.
+func (im *importer) createShare(ctx context.Context, hdr *headerRecord) error {
+	root, err := im.dst.CreateRootDirectory(ctx, hdr.Share, &hdr.Root.File.FileAttr)
+	if err != nil {
+		return fmt.Errorf("create root directory: %w", err)
+	}
+	im.newRootID = root.ID
+	im.oldRoot, _ = metadata.EncodeShareHandle(hdr.Share, im.oldRootID)
+	im.newRoot = im.handle(im.newRootID)
+
+	opts := hdr.Options
+	if err := im.dst.UpdateShareOptions(ctx, hdr.Share, &opts); err != nil {
+		return fmt.Errorf("set share options: %w", err)
+	}
+	if hdr.FilesystemMeta != nil {
+		if err := im.dst.PutFilesystemMeta(ctx, hdr.Share, hdr.FilesystemMeta); err != nil {
+			return fmt.Errorf("put filesystem meta: %w", err)
+		}
+	}
+	im.stats.Directories++
+	return im.putFile(ctx, hdr.Root)
+}
+
+func (im *importer) apply(ctx context.Context, kind byte, body []byte) error {
+	switch kind {
+	case kindFile:
+		var rec fileRecord
+		if err := decodeRecord(kind, body, &rec); err != nil {
+			return err
+		}
+		if rec.File == nil {
+			return fmt.Errorf("%w: file record without inode", metadata.ErrRestoreCorrupt)
+		}
+		if rec.File.Type == metadata.FileTypeDirectory {
+			im.stats.Directories++
+		} else {
+			im.stats.Files++
+		}
+		return im.putFile(ctx, &rec)
+
+	case kindEntry:
+		var rec entryRecord
+		if err := decodeRecord(kind, body, &rec); err != nil {
+			return err
+		}
+		if err := im.dst.SetChild(ctx, im.handle(rec.DirID), rec.Name, im.handle(rec.ChildID)); err != nil {
+			return fmt.Errorf("link %q into %s: %w", rec.Name, rec.DirID, err)
+		}
+		im.stats.Entries++
+		return nil
+
+	case kindChunk:
+		var row block.FileChunk
+		if err := decodeRecord(kind, body, &row); err != nil {
+			return err
+		}
+		if err := im.dst.Put(ctx, &row); err != nil {
+			return fmt.Errorf("put chunk %s: %w", row.ID, err)
+		}
+		im.stats.Chunks++
+		return nil
+
+	case kindSynced:
+		var rec syncedRecord
+		if err := decodeRecord(kind, body, &rec); err != nil {
+			return err
+		}
+		if err := im.dst.MarkSynced(ctx, rec.Hash, rec.Locator); err != nil {
+			return fmt.Errorf("mark synced %s: %w", rec.Hash, err)
+		}
+		im.stats.SyncedHashes++
+		return nil
+
+	case kindBlock:
+		var rec block.BlockRecord
+		if err := decodeRecord(kind, body, &rec); err != nil {
+			return err
+		}
+		// Another share of dst may already reference the same block.
+		if _, exists, err := im.dst.GetBlockRecord(ctx, rec.BlockID); err != nil {
+			return fmt.Errorf("get block record %s: %w", rec.BlockID, err)
+		} else if !exists {
+			if err := im.dst.PutBlockRecord(ctx, rec); err != nil {
+				return fmt.Errorf("put block record %s: %w", rec.BlockID, err)
+			}
+		}
+		im.stats.BlockRecords++
+		return nil
+
+	case kindLock:
+		var l lock.PersistedLock
+		if err := decodeRecord(kind, body, &l); err != nil {
+			return err
+		}
+		im.stats.Locks++
+		ls, ok := lockStoreOf(im.dst)
+		if !ok {
+			return nil
+		}
+		if l.FileID == string(im.oldRoot) {
+			l.FileID = string(im.newRoot)
+		}
+		if err := ls.PutLock(ctx, &l); err != nil {
+			return fmt.Errorf("put lock %s: %w", l.ID, err)
+		}
+		return nil
+
+	case kindDurable:
+		var h lock.PersistedDurableHandle
+		if err := decodeRecord(kind, body, &h); err != nil {
+			return err
+		}
+		im.stats.DurableHandles++
+		ds, ok := durableStoreOf(im.dst)
+		if !ok {
+			return nil
+		}
+		if bytes.Equal(h.MetadataHandle, im.oldRoot) {
+			h.MetadataHandle = im.newRoot
+		}
+		if bytes.Equal(h.ParentHandle, im.oldRoot) {
+			h.ParentHandle = im.newRoot
+		}
+		if err := ds.PutDurableHandle(ctx, &h); err != nil {
+			return fmt.Errorf("put durable handle %s: %w", h.ID, err)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w: unknown record kind %d", metadata.ErrRestoreCorrupt, kind)
+	}
+}
+
+func (im *importer) putFile(ctx context.Context, rec *fileRecord) error {
+	f := rec.File
+	isRoot := f.ID == im.oldRootID
+	if isRoot {
+		f.ID = im.newRootID
+	}
+	f.ShareName = im.share
+	// BlocksDirty is transient (json:"-"); set it so engines that keep the
+	// manifest beside the inode persist the carried Blocks list.
+	f.BlocksDirty = f.Type == metadata.FileTypeRegular && len(f.Blocks) > 0
+	if err := im.dst.PutFile(ctx, f); err != nil {
+		return fmt.Errorf("put %s: %w", f.ID, err)
+	}
+	h := im.handle(f.ID)
+	if err := im.dst.SetLinkCount(ctx, h, rec.LinkCount); err != nil {
+		return fmt.Errorf("set link count of %s: %w", f.ID, err)
+	}
+	for name, value := range rec.Xattrs {
+		if err := im.dst.SetXattr(ctx, h, name, value); err != nil {
+			return fmt.Errorf("set xattr %q of %s: %w", name, f.ID, err)
+		}
+		im.stats.Xattrs++
+	}
+	if !isRoot && rec.ParentID != uuid.Nil {
+		im.parents[f.ID] = rec.ParentID
+	}
+	return nil
+}
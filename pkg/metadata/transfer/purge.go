@@ -0,0 +1,182 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/marmos91/dittofs/pkg/metadata/lock"
+)
+
+// Purge removes shareName and everything Export would carry for it from s:
+// the namespace (via DeleteShare), the chunk rows of its files, its locks and
+// durable handles, and the synced locators and block records that no other
+// share of s still references. It is the cleanup for the source after a
+// successful migration and for the destination after a failed one. A share s
+// does not hold is a no-op.
+//
+// Block data is not touched. Chunk rows of inodes unreachable from the share
+// root are left behind (dfs fsck reports them as orphaned chunks).
+func Purge(ctx context.Context, s metadata.Store, shareName string) (*Stats, error) {
+	var st Stats
+	names, err := s.ListShares(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list shares: %w", err)
+	}
+	if !slices.Contains(names, shareName) {
+		return &st, nil
+	}
+
+	payloads, err := collectPayloads(ctx, s, shareName, &st)
+	if err != nil {
+		return nil, err
+	}
+
+	if ls, ok := lockStoreOf(s); ok {
+		locks, err := ls.ListLocks(ctx, lock.LockQuery{ShareName: shareName})
+		if err != nil {
+			return nil, fmt.Errorf("list locks: %w", err)
+		}
+		for _, l := range locks {
+			if err := ls.DeleteLock(ctx, l.ID); err != nil && !metadata.IsNotFoundError(err) {
+				return nil, fmt.Errorf("delete lock %s: %w", l.ID, err)
+			}
+			st.Locks++
+		}
+	}
+	if ds, ok := durableStoreOf(s); ok {
+		handles, err := ds.ListDurableHandlesByShare(ctx, shareName)
+		if err != nil {
+			return nil, fmt.Errorf("list durable handles: %w", err)
+		}
+		for _, h := range handles {
+			if err := ds.DeleteDurableHandle(ctx, h.ID); err != nil && !metadata.IsNotFoundError(err) {
+				return nil, fmt.Errorf("delete durable handle %s: %w", h.ID, err)
+			}
+			st.DurableHandles++
+		}
+	}
+
+	hashes := make(map[block.ContentHash]struct{})
+	for _, payloadID := range payloads {
+		rows, err := s.ListFileChunks(ctx, payloadID)
+		if err != nil {
+			return nil, fmt.Errorf("list chunks of %s: %w", payloadID, err)
+		}
+		for _, row := range rows {
+			if row == nil {
+				continue
+			}
+			hashes[row.Hash] = struct{}{}
+			if err := s.Delete(ctx, row.ID); err != nil && !metadata.IsNotFoundError(err) {
+				return nil, fmt.Errorf("delete chunk %s: %w", row.ID, err)
+			}
+			st.Chunks++
+		}
+	}
+
+	if err := s.DeleteShare(ctx, shareName); err != nil && !metadata.IsNotFoundError(err) {
+		return nil, fmt.Errorf("delete share: %w", err)
+	}
+
+	// A locator or block record may be shared with another share of s
+	// (cross-share dedup). Only release what no remaining chunk row uses.
+	released := make(map[string]struct{})
+	kept := make(map[string]struct{})
+	for hash := range hashes {
+		loc, ok, err := s.GetLocator(ctx, hash)
+		if err != nil {
+			return nil, fmt.Errorf("get locator %s: %w", hash, err)
+		}
+		other, err := s.GetByHash(ctx, hash)
+		if err != nil {
+			return nil, fmt.Errorf("lookup %s: %w", hash, err)
+		}
+		if other != nil {
+			if ok && loc.BlockID != "" {
+				kept[loc.BlockID] = struct{}{}
+			}
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := s.DeleteSynced(ctx, hash); err != nil {
+			return nil, fmt.Errorf("delete synced %s: %w", hash, err)
+		}
+		st.SyncedHashes++
+		if loc.BlockID != "" {
+			released[loc.BlockID] = struct{}{}
+		}
+	}
+	for blockID := range released {
+		if _, busy := kept[blockID]; busy {
+			continue
+		}
+		if err := s.DeleteBlockRecord(ctx, blockID); err != nil && !metadata.IsNotFoundError(err) {
+			return nil, fmt.Errorf("delete block record %s: %w", blockID, err)
+		}
+		st.BlockRecords++
+	}
+	return &st, nil
+}
+
+// collectPayloads walks the share namespace and returns the payload IDs of
+// its regular files, counting inodes into st.
+func collectPayloads(ctx context.Context, s metadata.Store, shareName string, st *Stats) ([]string, error) {
+	rootHandle, err := s.GetRootHandle(ctx, shareName)
+	if err != nil {
+		if metadata.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get root handle: %w", err)
+	}
+	seen := map[string]struct{}{string(rootHandle): {}}
+	var payloads []string
+	queue := []metadata.FileHandle{rootHandle}
+	st.Directories++
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		dir := queue[0]
+		queue = queue[1:]
+		cursor := ""
+		for {
+			entries, next, err := s.ListChildren(ctx, dir, cursor, listPageSize)
+			if err != nil {
+				return nil, fmt.Errorf("list children: %w", err)
+			}
+			for _, ent := range entries {
+				if _, dup := seen[string(ent.Handle)]; dup {
+					continue
+				}
+				seen[string(ent.Handle)] = struct{}{}
+				f, err := s.GetFile(ctx, ent.Handle)
+				if err != nil {
+					if metadata.IsNotFoundError(err) {
+						continue
+					}
+					return nil, fmt.Errorf("get %q: %w", ent.Name, err)
+				}
+				switch {
+				case f.Type == metadata.FileTypeDirectory:
+					st.Directories++
+					queue = append(queue, ent.Handle)
+				case f.Type == metadata.FileTypeRegular && f.PayloadID != "":
+					st.Files++
+					payloads = append(payloads, string(f.PayloadID))
+				default:
+					st.Files++
+				}
+			}
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+	}
+	return payloads, nil
+}
@@ -0,0 +1,220 @@
+// Package transfer moves one share's metadata between metadata.Store
+// instances of any engine (memory, badger, sqlite, postgres).
+//
+// Engine snapshots (metadata.Snapshotable) are opaque per-engine dumps and can
+// only be restored into the engine that wrote them. transfer instead streams
+// a share through the public metadata.Store surface, so the source and
+// destination may differ. The stream reuses the backup envelope (magic,
+// version, trailing CRC32) with the engine tag "portable"; the payload is a
+// sequence of framed records:
+//
+//	schema_version uint32 LE
+//	[ kind uint8 | len uint32 LE | JSON body ]*   terminated by kindEnd
+//
+// What is carried: the namespace (every inode reachable from the share root,
+// with attributes, ACLs, xattrs, parent pointers, link counts and directory
+// entries, including the #recycle bin), the FileChunk manifest of every
+// regular file, the synced-hash locators and block records those chunks
+// resolve to, the share's persisted locks and SMB durable handles, and its
+// FilesystemMeta. Block data is never touched: chunk rows, locators and block
+// records are copied verbatim, so they keep pointing at the same journal
+// entries and remote objects.
+//
+// Handles are preserved for every inode except the root. Each engine mints
+// its own root ID in CreateRootDirectory, so the root handle changes; Import
+// remaps references to it (parent pointers, entries, locks, durable handles)
+// and reports both handles in the Result.
+//
+// NSM client registrations and pending-write state are store-wide rather
+// than per-share and are not carried.
+package transfer
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+
+	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/marmos91/dittofs/pkg/metadata/lock"
+)
+
+// EngineTag is the envelope engine tag of a transfer stream.
+const EngineTag = "portable"
+
+// schemaVersion is written after the envelope header. Import rejects any
+// other value with metadata.ErrSchemaVersionMismatch.
+const schemaVersion = uint32(1)
+
+// maxRecordSize bounds a single record body. The largest records are regular
+// files whose Blocks list grows with file size; 1 GiB covers multi-TiB files.
+const maxRecordSize = 1 << 30
+
+// listPageSize is the ListChildren page size used by the namespace walks.
+const listPageSize = 1000
+
+// Record kinds.
+const (
+	kindHeader  byte = 1
+	kindFile    byte = 2
+	kindEntry   byte = 3
+	kindChunk   byte = 4
+	kindSynced  byte = 5
+	kindBlock   byte = 6
+	kindLock    byte = 7
+	kindDurable byte = 8
+	kindEnd     byte = 9
+)
+
+// Stats counts what a transfer carried. Export and Import each produce one;
+// Import fails when its counts differ from the exporter's.
+type Stats struct {
+	Directories    int64 `json:"directories"`
+	Files          int64 `json:"files"`
+	Entries        int64 `json:"entries"`
+	Xattrs         int64 `json:"xattrs"`
+	Chunks         int64 `json:"chunks"`
+	SyncedHashes   int64 `json:"synced_hashes"`
+	BlockRecords   int64 `json:"block_records"`
+	Locks          int64 `json:"locks"`
+	DurableHandles int64 `json:"durable_handles"`
+	// SkippedEntries counts source directory entries whose inode is missing.
+	// They are dropped rather than copied (dfs fsck reports them as dangling).
+	SkippedEntries int64 `json:"skipped_entries"`
+}
+
+// Result describes a completed Import or Copy.
+type Result struct {
+	Share         string
+	OldRootHandle metadata.FileHandle
+	NewRootHandle metadata.FileHandle
+	Stats         Stats
+}
+
+// fileRecord is one inode with everything stored beside it.
+type fileRecord struct {
+	File      *metadata.File    `json:"file"`
+	ParentID  uuid.UUID         `json:"parent_id"`
+	LinkCount uint32            `json:"link_count"`
+	Xattrs    map[string][]byte `json:"xattrs,omitempty"`
+}
+
+type headerRecord struct {
+	Share          string                   `json:"share"`
+	Options        metadata.ShareOptions    `json:"options"`
+	Root           *fileRecord              `json:"root"`
+	FilesystemMeta *metadata.FilesystemMeta `json:"filesystem_meta,omitempty"`
+}
+
+type entryRecord struct {
+	DirID   uuid.UUID `json:"dir_id"`
+	Name    string    `json:"name"`
+	ChildID uuid.UUID `json:"child_id"`
+}
+
+type syncedRecord struct {
+	Hash    block.ContentHash  `json:"hash"`
+	Locator block.ChunkLocator `json:"locator"`
+}
+
+// ErrShareNotFound is returned by Export when the source store does not hold
+// the share.
+var ErrShareNotFound = errors.New("transfer: share not found in source store")
+
+// Copy streams shareName from src into dst through an in-memory pipe. dst
+// must not already hold the share; on failure anything Copy wrote to dst is
+// removed again.
+func Copy(ctx context.Context, src, dst metadata.Store, shareName string) (*Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	exportDone := make(chan error, 1)
+	go func() {
+		_, err := Export(ctx, src, shareName, pw)
+		_ = pw.CloseWithError(err)
+		exportDone <- err
+	}()
+
+	res, importErr := Import(ctx, dst, pr)
+	if importErr != nil {
+		// Unblock an exporter still writing into the pipe.
+		_ = pr.CloseWithError(importErr)
+		cancel()
+	}
+	exportErr := <-exportDone
+
+	switch {
+	case exportErr != nil && !errors.Is(exportErr, io.ErrClosedPipe) && importErr != nil:
+		// The exporter failed first; its error is the cause of the
+		// truncated stream the importer saw.
+		return nil, fmt.Errorf("export: %w", exportErr)
+	case importErr != nil:
+		return nil, fmt.Errorf("import: %w", importErr)
+	case exportErr != nil:
+		return nil, fmt.Errorf("export: %w", exportErr)
+	}
+	return res, nil
+}
+
+// --------------------------------------------------------------------------
+// Framing
+// --------------------------------------------------------------------------
+
+func writeRecord(w io.Writer, kind byte, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode record %d: %w", kind, err)
+	}
+	if len(body) > maxRecordSize {
+		return fmt.Errorf("record %d is %d bytes, over the %d limit", kind, len(body), maxRecordSize)
+	}
+	var hdr [5]byte
+	hdr[0] = kind
+	binary.LittleEndian.PutUint32(hdr[1:], uint32(len(body)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+func readRecord(r io.Reader) (byte, []byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, nil, fmt.Errorf("%w: read record header: %v", metadata.ErrRestoreCorrupt, err)
+	}
+	n := binary.LittleEndian.Uint32(hdr[1:])
+	if n > maxRecordSize {
+		return 0, nil, fmt.Errorf("%w: record size %d exceeds maximum %d", metadata.ErrRestoreCorrupt, n, maxRecordSize)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, fmt.Errorf("%w: read record body: %v", metadata.ErrRestoreCorrupt, err)
+	}
+	return hdr[0], body, nil
+}
+
+func decodeRecord(kind byte, body []byte, v any) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: decode record %d: %v", metadata.ErrRestoreCorrupt, kind, err)
+	}
+	return nil
+}
+
+// lockStoreOf and durableStoreOf discover the optional lock capabilities the
+// same way metadata.Service does.
+func lockStoreOf(s metadata.Store) (lock.LockStore, bool) {
+	ls, ok := s.(lock.LockStore)
+	return ls, ok
+}
+
+func durableStoreOf(s metadata.Store) (lock.DurableHandleStore, bool) {
+	ds, ok := s.(lock.DurableHandleStore)
+	return ds, ok
+}
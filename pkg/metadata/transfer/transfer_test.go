@@ -0,0 +1,244 @@
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/marmos91/dittofs/pkg/block"
+	"github.com/marmos91/dittofs/pkg/metadata"
+	"github.com/marmos91/dittofs/pkg/metadata/store/badger"
+	"github.com/marmos91/dittofs/pkg/metadata/store/memory"
+)
+
+const testShare = "/export"
+
+func hashOfSeed(seed string) block.ContentHash {
+	return block.ContentHash(sha256.Sum256([]byte(seed)))
+}
+
+// seedSource builds a small share in a memory store: /docs/report.bin with
+// two chunk rows (one synced into blk-1) and an xattr, hard-linked again at
+// /report-link. It returns the file's handle.
+func seedSource(t *testing.T, s metadata.Store) metadata.FileHandle {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	if err := s.CreateShare(ctx, &metadata.Share{Name: testShare}); err != nil {
+		t.Fatalf("CreateShare: %v", err)
+	}
+	root, err := s.CreateRootDirectory(ctx, testShare, &metadata.FileAttr{Type: metadata.FileTypeDirectory, Mode: 0o755})
+	if err != nil {
+		t.Fatalf("CreateRootDirectory: %v", err)
+	}
+	rootHandle, err := metadata.EncodeFileHandle(root)
+	if err != nil {
+		t.Fatalf("EncodeFileHandle: %v", err)
+	}
+
+	put := func(path string, attr metadata.FileAttr, parent metadata.FileHandle, name string, nlink uint32) metadata.FileHandle {
+		h, err := s.GenerateHandle(ctx, testShare, path)
+		if err != nil {
+			t.Fatalf("GenerateHandle %s: %v", path, err)
+		}
+		_, id, err := metadata.DecodeFileHandle(h)
+		if err != nil {
+			t.Fatalf("DecodeFileHandle: %v", err)
+		}
+		attr.Mtime, attr.Ctime, attr.Atime = now, now, now
+		f := &metadata.File{ID: id, ShareName: testShare, FileAttr: attr}
+		f.BlocksDirty = len(attr.Blocks) > 0
+		if err := s.PutFile(ctx, f); err != nil {
+			t.Fatalf("PutFile %s: %v", path, err)
+		}
+		if err := s.SetParent(ctx, h, parent); err != nil {
+			t.Fatalf("SetParent %s: %v", path, err)
+		}
+		if err := s.SetLinkCount(ctx, h, nlink); err != nil {
+			t.Fatalf("SetLinkCount %s: %v", path, err)
+		}
+		if err := s.SetChild(ctx, parent, name, h); err != nil {
+			t.Fatalf("SetChild %s: %v", path, err)
+		}
+		return h
+	}
+
+	docs := put("/docs", metadata.FileAttr{Type: metadata.FileTypeDirectory, Mode: 0o755}, rootHandle, "docs", 2)
+
+	h0, h1 := hashOfSeed("c0"), hashOfSeed("c1")
+	for i, h := range []block.ContentHash{h0, h1} {
+		if err := s.Put(ctx, &block.FileChunk{
+			ID:        "payload-a/" + string(rune('0'+i)),
+			Hash:      h,
+			DataSize:  4096,
+			State:     block.BlockStateRemote,
+			CreatedAt: now,
+		}); err != nil {
+			t.Fatalf("Put chunk: %v", err)
+		}
+	}
+	file := put("/docs/report.bin", metadata.FileAttr{
+		Type:      metadata.FileTypeRegular,
+		Mode:      0o644,
+		Size:      8192,
+		PayloadID: "payload-a",
+		Blocks: []block.ChunkRef{
+			{Hash: h0, Offset: 0, Size: 4096},
+			{Hash: h1, Offset: 4096, Size: 4096},
+		},
+	}, docs, "report.bin", 2)
+	if err := s.SetChild(ctx, rootHandle, "report-link", file); err != nil {
+		t.Fatalf("SetChild link: %v", err)
+	}
+	if err := s.SetXattr(ctx, file, "user.origin", []byte("scanner")); err != nil {
+		t.Fatalf("SetXattr: %v", err)
+	}
+	if err := s.MarkSynced(ctx, h0, block.ChunkLocator{BlockID: "blk-1", WireOffset: 0, WireLength: 4096}); err != nil {
+		t.Fatalf("MarkSynced: %v", err)
+	}
+	if err := s.PutBlockRecord(ctx, block.BlockRecord{BlockID: "blk-1", Length: 4096, LiveChunkCount: 1, SyncState: block.BlockStateRemote}); err != nil {
+		t.Fatalf("PutBlockRecord: %v", err)
+	}
+	return file
+}
+
+func newBadgerStore(t *testing.T) metadata.Store {
+	t.Helper()
+	s, err := badger.NewBadgerMetadataStoreWithDefaults(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatalf("open badger: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestCopy_MemoryToBadger(t *testing.T) {
+	ctx := context.Background()
+	src := memory.NewMemoryMetadataStoreWithDefaults()
+	fileHandle := seedSource(t, src)
+	dst := newBadgerStore(t)
+
+	res, err := Copy(ctx, src, dst, testShare)
+	if err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	want := Stats{Directories: 2, Files: 1, Entries: 3, Xattrs: 1, Chunks: 2, SyncedHashes: 1, BlockRecords: 1}
+	if res.Stats != want {
+		t.Errorf("stats = %+v, want %+v", res.Stats, want)
+	}
+
+	newRoot, err := dst.GetRootHandle(ctx, testShare)
+	if err != nil {
+		t.Fatalf("GetRootHandle: %v", err)
+	}
+	if string(newRoot) != string(res.NewRootHandle) {
+		t.Errorf("NewRootHandle = %x, store root = %x", res.NewRootHandle, newRoot)
+	}
+
+	// Non-root handles survive the move.
+	docs, err := dst.GetChild(ctx, newRoot, "docs")
+	if err != nil {
+		t.Fatalf("GetChild docs: %v", err)
+	}
+	got, err := dst.GetChild(ctx, docs, "report.bin")
+	if err != nil {
+		t.Fatalf("GetChild report.bin: %v", err)
+	}
+	if string(got) != string(fileHandle) {
+		t.Errorf("file handle changed: %x -> %x", fileHandle, got)
+	}
+	if link, err := dst.GetChild(ctx, newRoot, "report-link"); err != nil || string(link) != string(fileHandle) {
+		t.Errorf("hard link = %x, %v; want %x", link, err, fileHandle)
+	}
+	if n, err := dst.GetLinkCount(ctx, fileHandle); err != nil || n != 2 {
+		t.Errorf("link count = %d, %v; want 2", n, err)
+	}
+	if parent, err := dst.GetParent(ctx, docs); err != nil || string(parent) != string(newRoot) {
+		t.Errorf("parent of docs = %x, %v; want new root %x", parent, err, newRoot)
+	}
+
+	f, err := dst.GetFile(ctx, fileHandle)
+	if err != nil {
+		t.Fatalf("GetFile: %v", err)
+	}
+	if f.PayloadID != "payload-a" || f.Size != 8192 || len(f.Blocks) != 2 {
+		t.Errorf("file = payload %q size %d blocks %d", f.PayloadID, f.Size, len(f.Blocks))
+	}
+	if v, ok, err := dst.GetXattr(ctx, fileHandle, "user.origin"); err != nil || !ok || string(v) != "scanner" {
+		t.Errorf("xattr = %q, %v, %v", v, ok, err)
+	}
+
+	rows, err := dst.ListFileChunks(ctx, "payload-a")
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("chunk rows = %d, %v; want 2", len(rows), err)
+	}
+	loc, ok, err := dst.GetLocator(ctx, hashOfSeed("c0"))
+	if err != nil || !ok || loc.BlockID != "blk-1" {
+		t.Errorf("locator = %+v, %v, %v", loc, ok, err)
+	}
+	if _, ok, err := dst.GetBlockRecord(ctx, "blk-1"); err != nil || !ok {
+		t.Errorf("block record present = %v, %v", ok, err)
+	}
+}
+
+func TestCopy_DestinationHasShare(t *testing.T) {
+	ctx := context.Background()
+	src := memory.NewMemoryMetadataStoreWithDefaults()
+	seedSource(t, src)
+	dst := newBadgerStore(t)
+
+	if _, err := Copy(ctx, src, dst, testShare); err != nil {
+		t.Fatalf("first Copy: %v", err)
+	}
+	_, err := Copy(ctx, src, dst, testShare)
+	if !errors.Is(err, metadata.ErrRestoreDestinationNotEmpty) {
+		t.Fatalf("second Copy error = %v, want ErrRestoreDestinationNotEmpty", err)
+	}
+	// The refusal must not roll back the share that was already there.
+	if names, _ := dst.ListShares(ctx); !slices.Contains(names, testShare) {
+		t.Errorf("destination lost the existing share: %v", names)
+	}
+}
+
+func TestCopy_UnknownShare(t *testing.T) {
+	src := memory.NewMemoryMetadataStoreWithDefaults()
+	dst := memory.NewMemoryMetadataStoreWithDefaults()
+	if _, err := Copy(context.Background(), src, dst, "/missing"); !errors.Is(err, ErrShareNotFound) {
+		t.Fatalf("Copy error = %v, want ErrShareNotFound", err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewMemoryMetadataStoreWithDefaults()
+	seedSource(t, s)
+
+	st, err := Purge(ctx, s, testShare)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if st.Chunks != 2 || st.SyncedHashes != 1 || st.BlockRecords != 1 {
+		t.Errorf("purge stats = %+v", st)
+	}
+	if names, _ := s.ListShares(ctx); slices.Contains(names, testShare) {
+		t.Error("share still listed after Purge")
+	}
+	if rows, _ := s.ListFileChunks(ctx, "payload-a"); len(rows) != 0 {
+		t.Errorf("%d chunk rows left", len(rows))
+	}
+	if _, ok, _ := s.GetLocator(ctx, hashOfSeed("c0")); ok {
+		t.Error("locator left behind")
+	}
+	if _, ok, _ := s.GetBlockRecord(ctx, "blk-1"); ok {
+		t.Error("block record left behind")
+	}
+
+	// A second purge is a no-op.
+	if _, err := Purge(ctx, s, testShare); err != nil {
+		t.Fatalf("second Purge: %v", err)
+	}
+}